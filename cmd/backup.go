@@ -0,0 +1,148 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+
+	"oolio/internal/database"
+)
+
+func newBackupCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "backup",
+		Short: "Export and import the stores, products, coupons and orders",
+	}
+
+	cmd.AddCommand(newBackupExportCommand(), newBackupImportCommand())
+	return cmd
+}
+
+func newBackupExportCommand() *cobra.Command {
+	var resume bool
+
+	cmd := &cobra.Command{
+		Use:   "export <file>",
+		Short: "Write a backup bundle of the stores, products, coupons and orders",
+		Long: "Write the stores, products, coupons and orders, with their items, to a versioned JSON " +
+			"Lines bundle, or to standard output when the file is -. With --resume, a bundle whose " +
+			"export was cut short is completed after its last complete record.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withDatabase(func(db *database.Database) error {
+				if err := requirePostgres(db); err != nil {
+					return err
+				}
+
+				if args[0] == "-" {
+					if resume {
+						return fmt.Errorf("--resume needs a file")
+					}
+					_, err := db.ExportBackup(cmd.Context(), cmd.OutOrStdout(), database.BackupPosition{})
+					return err
+				}
+
+				flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+				if resume {
+					flags = os.O_RDWR
+				}
+				file, err := os.OpenFile(args[0], flags, 0o600)
+				if err != nil {
+					return err
+				}
+				defer file.Close()
+
+				var from database.BackupPosition
+				if resume {
+					if from, err = database.ScanBackup(file); err != nil {
+						return fmt.Errorf("%s: %w", args[0], err)
+					}
+					if from.Complete {
+						return fmt.Errorf("%s is already complete", args[0])
+					}
+					// Drop the record that was being written when the export stopped
+					if err := file.Truncate(from.Size); err != nil {
+						return err
+					}
+					if _, err := file.Seek(from.Size, io.SeekStart); err != nil {
+						return err
+					}
+				}
+
+				counts, err := db.ExportBackup(cmd.Context(), file, from)
+				if err != nil {
+					return err
+				}
+				if err := file.Sync(); err != nil {
+					return err
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "Exported %s to %s\n", formatBackupCounts(counts), args[0])
+				return nil
+			})
+		},
+	}
+
+	cmd.Flags().BoolVar(&resume, "resume", false, "complete a bundle whose export was cut short")
+	return cmd
+}
+
+func newBackupImportCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "import <file>",
+		Short: "Restore the records of a backup bundle",
+		Long: "Restore the stores, products, coupons and orders of a backup bundle, or of standard input " +
+			"when the file is -. Records that already exist are skipped, so an import that was cut " +
+			"short can be run again.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			in := cmd.InOrStdin()
+			if args[0] != "-" {
+				file, err := os.Open(args[0])
+				if err != nil {
+					return err
+				}
+				defer file.Close()
+				in = file
+			}
+
+			return withDatabase(func(db *database.Database) error {
+				if err := requirePostgres(db); err != nil {
+					return err
+				}
+
+				result, err := db.ImportBackup(cmd.Context(), in)
+				fmt.Fprintf(cmd.OutOrStdout(), "Imported %s, skipped %s already present\n",
+					formatBackupCounts(result.Imported), formatBackupCounts(result.Skipped))
+				if errors.Is(err, database.ErrBackupIncomplete) {
+					return fmt.Errorf("%s: %w; import it again once it is complete", args[0], err)
+				}
+				return err
+			})
+		},
+	}
+}
+
+// formatBackupCounts lists counts as type=count, sorted by type
+func formatBackupCounts(counts database.BackupCounts) string {
+	if len(counts) == 0 {
+		return "nothing"
+	}
+	types := make([]string, 0, len(counts))
+	for recordType := range counts {
+		types = append(types, recordType)
+	}
+	sort.Strings(types)
+
+	formatted := ""
+	for i, recordType := range types {
+		if i > 0 {
+			formatted += " "
+		}
+		formatted += fmt.Sprintf("%s=%d", recordType, counts[recordType])
+	}
+	return formatted
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"oolio/internal/app/models"
+	"oolio/internal/config"
+)
+
+func newCouponCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "coupon",
+		Short: "Manage promo coupons",
+	}
+
+	cmd.AddCommand(newCouponRefreshCommand())
+	return cmd
+}
+
+// Valid coupon codes live in the memory of the running server, so refreshing goes through
+// its admin API rather than the database
+func newCouponRefreshCommand() *cobra.Command {
+	var (
+		apiURL  string
+		timeout time.Duration
+	)
+
+	cmd := &cobra.Command{
+		Use:   "refresh",
+		Short: "Make the running server download and parse the coupon files now",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := config.Load()
+			if apiURL == "" {
+				apiURL = "http://localhost:" + cfg.Server.Port
+			}
+
+			req, err := http.NewRequestWithContext(cmd.Context(), http.MethodPost, strings.TrimSuffix(apiURL, "/")+"/api/v1/admin/coupons/refresh", nil)
+			if err != nil {
+				return err
+			}
+			req.Header.Set("X-API-Key", cfg.API.APIKey)
+
+			resp, err := (&http.Client{Timeout: timeout}).Do(req)
+			if err != nil {
+				return fmt.Errorf("failed to reach %s: %w", apiURL, err)
+			}
+			defer resp.Body.Close()
+
+			body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+			if err != nil {
+				return fmt.Errorf("failed to read response: %w", err)
+			}
+
+			if resp.StatusCode != http.StatusOK {
+				var apiErr models.ApiResponse
+				if json.Unmarshal(body, &apiErr) == nil && apiErr.Message != "" {
+					return fmt.Errorf("%s: %s", resp.Status, apiErr.Message)
+				}
+				return fmt.Errorf("%s", resp.Status)
+			}
+
+			var stats models.CouponStats
+			if err := json.Unmarshal(body, &stats); err != nil {
+				return fmt.Errorf("failed to decode coupon stats: %w", err)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Refreshed coupons in %dms: %d valid from files, %d stored\n",
+				stats.LastRefreshMs, stats.ValidCoupons, stats.StoredCoupons)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&apiURL, "api-url", "", "base URL of the running server (default http://localhost:SERVER_PORT)")
+	cmd.Flags().DurationVar(&timeout, "timeout", 5*time.Minute, "how long to wait for the refresh to finish")
+	return cmd
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"oolio/internal/loadtest"
+)
+
+func newLoadTestCommand() *cobra.Command {
+	var (
+		opts       loadtest.Options
+		mix        string
+		budgetFile string
+		reportFile string
+		noBudget   bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "loadtest",
+		Short: "Send product and order traffic to a running API and check it against a budget",
+		Long: "Send a mix of product listings, product reads and orders to a running API, such as the " +
+			"docker-compose environment, then print p50/p95/p99 latencies and error rates per operation. " +
+			"The command fails when the run goes over the latency and error budget, so performance " +
+			"regressions are caught before they ship. The API must have products, e.g. from oolio seed.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var err error
+			if opts.Mix, err = loadtest.ParseMix(mix); err != nil {
+				return err
+			}
+
+			var budget *loadtest.Budget
+			switch {
+			case noBudget:
+			case budgetFile != "":
+				budget, err = loadtest.LoadBudget(budgetFile)
+			default:
+				budget, err = loadtest.DefaultBudget()
+			}
+			if err != nil {
+				return err
+			}
+
+			// From here on failures are about the run, not how the command was used
+			cmd.SilenceUsage = true
+
+			fmt.Fprintf(cmd.ErrOrStderr(), "Sending traffic to %s from %d customers for %s\n", opts.BaseURL, opts.Concurrency, opts.Duration)
+			report, err := loadtest.Run(cmd.Context(), opts)
+			if err != nil {
+				return err
+			}
+			if err := report.WriteTable(cmd.OutOrStdout()); err != nil {
+				return err
+			}
+
+			if reportFile != "" {
+				data, err := json.MarshalIndent(report, "", "  ")
+				if err != nil {
+					return err
+				}
+				if err := os.WriteFile(reportFile, append(data, '\n'), 0o644); err != nil {
+					return err
+				}
+			}
+
+			if budget == nil {
+				return nil
+			}
+			violations := budget.Check(report)
+			for _, violation := range violations {
+				fmt.Fprintf(cmd.ErrOrStderr(), "over budget: %s\n", violation)
+			}
+			if len(violations) > 0 {
+				return fmt.Errorf("%d budget violations", len(violations))
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), "Within budget")
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.BaseURL, "url", "http://localhost:8080", "base URL of the API")
+	cmd.Flags().StringVar(&opts.APIKey, "api-key", "apitest", "API key sent as X-API-Key")
+	cmd.Flags().DurationVarP(&opts.Duration, "duration", "d", 30*time.Second, "how long to send traffic for")
+	cmd.Flags().IntVarP(&opts.Concurrency, "concurrency", "n", 10, "customers sending requests at once")
+	cmd.Flags().StringVar(&mix, "mix", "list=3,get=5,order=2", "relative weights of the list, get and order operations")
+	cmd.Flags().StringVar(&opts.CouponCode, "coupon", "", "coupon code applied to every order")
+	cmd.Flags().IntVar(&opts.MaxItems, "max-items", 3, "most distinct products in an order")
+	cmd.Flags().DurationVar(&opts.WaitTimeout, "wait", time.Minute, "how long to wait for the API to become healthy")
+	cmd.Flags().StringVar(&budgetFile, "budget", "", "budget JSON file (default: the budget built in from internal/loadtest/budget.json)")
+	cmd.Flags().BoolVar(&noBudget, "no-budget", false, "only report, without checking a budget")
+	cmd.Flags().StringVar(&reportFile, "report", "", "also write the report as JSON to this file")
+
+	return cmd
+}
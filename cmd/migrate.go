@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"oolio/internal/config"
+	"oolio/internal/database"
+)
+
+func newMigrateCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Manage the database schema using the embedded migrations",
+	}
+
+	cmd.AddCommand(
+		&cobra.Command{
+			Use:   "up",
+			Short: "Apply all pending migrations",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return withDatabase(func(db *database.Database) error {
+					if err := db.MigrateUp(); err != nil {
+						return err
+					}
+					fmt.Fprintln(cmd.OutOrStdout(), "Migrations applied")
+					return nil
+				})
+			},
+		},
+		&cobra.Command{
+			Use:   "down [steps]",
+			Short: "Roll back the given number of migrations (default 1)",
+			Args:  cobra.MaximumNArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				steps := 1
+				if len(args) == 1 {
+					n, err := strconv.Atoi(args[0])
+					if err != nil {
+						return fmt.Errorf("invalid steps %q: %w", args[0], err)
+					}
+					steps = n
+				}
+
+				return withDatabase(func(db *database.Database) error {
+					if err := db.MigrateDown(steps); err != nil {
+						return err
+					}
+					fmt.Fprintf(cmd.OutOrStdout(), "Rolled back %d migration(s)\n", steps)
+					return nil
+				})
+			},
+		},
+		&cobra.Command{
+			Use:   "version",
+			Short: "Print the current schema version",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return withDatabase(func(db *database.Database) error {
+					version, dirty, err := db.MigrationVersion()
+					if err != nil {
+						return err
+					}
+					fmt.Fprintf(cmd.OutOrStdout(), "version=%d dirty=%t\n", version, dirty)
+					return nil
+				})
+			},
+		},
+		newPartitionQueueCommand(),
+	)
+
+	return cmd
+}
+
+func newPartitionQueueCommand() *cobra.Command {
+	var monthsAhead int
+
+	cmd := &cobra.Command{
+		Use:   "partition-queue",
+		Short: "Partition the order queue by month (optional, safe to re-run)",
+		Long: "Convert order_queue into a table partitioned by created_at, if it isn't already, and create " +
+			"monthly partitions through --months-ahead months from now. Re-run it periodically to keep " +
+			"upcoming months prepared.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withDatabase(func(db *database.Database) error {
+				created, err := db.PartitionOrderQueue(cmd.Context(), monthsAhead)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "Created %d order queue partition(s)\n", created)
+				return nil
+			})
+		},
+	}
+
+	cmd.Flags().IntVar(&monthsAhead, "months-ahead", 3, "number of future monthly partitions to keep ready")
+	return cmd
+}
+
+// withDatabase opens a connection for one-off commands. Auto-migration is disabled
+// so that e.g. "migrate down" isn't immediately undone on connect.
+func withDatabase(fn func(db *database.Database) error) error {
+	cfg := config.Load()
+	cfg.Database.AutoMigrate = false
+
+	db, err := database.NewDatabase(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return fn(db)
+}
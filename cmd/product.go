@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"oolio/internal/app/repository"
+	"oolio/internal/app/services"
+	"oolio/internal/database"
+)
+
+func newProductCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "product",
+		Short: "Manage the product catalogue",
+	}
+
+	cmd.AddCommand(newProductImportCommand())
+	return cmd
+}
+
+func newProductImportCommand() *cobra.Command {
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "import <file.csv>",
+		Short: "Create products from a CSV file",
+		Long: "Create products from a CSV file with a header row naming the columns name, price and " +
+			"category, plus optionally thumbnail, mobile, tablet and desktop image URLs. Products whose " +
+			"name already exists are skipped, so it is safe to run repeatedly.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			file, err := os.Open(args[0])
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+
+			products, err := services.ParseProductCSV(file)
+			if err != nil {
+				return fmt.Errorf("%s: %w", args[0], err)
+			}
+
+			return withDatabase(func(db *database.Database) error {
+				if err := requirePostgres(db); err != nil {
+					return err
+				}
+
+				productRepo := repository.NewProductRepository(db.DB, nil)
+				productService := services.NewProductService(productRepo)
+
+				existing, err := productRepo.Find(cmd.Context())
+				if err != nil {
+					return err
+				}
+				names := make(map[string]bool, len(existing))
+				for _, product := range existing {
+					names[strings.ToLower(product.Name)] = true
+				}
+
+				created, skipped := 0, 0
+				for i := range products {
+					product := &products[i]
+					if names[strings.ToLower(product.Name)] {
+						skipped++
+						continue
+					}
+					if !dryRun {
+						if err := productService.CreateProduct(cmd.Context(), product); err != nil {
+							return fmt.Errorf("%q: %w (created %d so far)", product.Name, err, created)
+						}
+					}
+					names[strings.ToLower(product.Name)] = true
+					created++
+				}
+
+				verb := "Created"
+				if dryRun {
+					verb = "Would create"
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "%s %d product(s), skipped %d existing\n", verb, created, skipped)
+				return nil
+			})
+		},
+	}
+
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "validate the file and report what would be created")
+	return cmd
+}
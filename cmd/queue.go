@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/spf13/cobra"
+
+	"oolio/internal/app/repository"
+	"oolio/internal/database"
+)
+
+func newQueueCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "queue",
+		Short: "Inspect and manage the order queue",
+	}
+
+	cmd.AddCommand(
+		&cobra.Command{
+			Use:   "stats",
+			Short: "Print the number of queue items by status",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return withQueueRepository(func(queueRepo repository.OrderQueueRepository) error {
+					stats, err := queueRepo.GetQueueStats(cmd.Context())
+					if err != nil {
+						return err
+					}
+
+					statuses := make([]string, 0, len(stats))
+					for status := range stats {
+						statuses = append(statuses, status)
+					}
+					sort.Strings(statuses)
+
+					for _, status := range statuses {
+						fmt.Fprintf(cmd.OutOrStdout(), "%s=%d\n", status, stats[status])
+					}
+					return nil
+				})
+			},
+		},
+		&cobra.Command{
+			Use:   "retry <id>",
+			Short: "Queue a failed order again with a fresh retry budget",
+			Args:  cobra.ExactArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return withQueueRepository(func(queueRepo repository.OrderQueueRepository) error {
+					if err := queueRepo.Requeue(cmd.Context(), args[0]); err != nil {
+						return err
+					}
+					fmt.Fprintf(cmd.OutOrStdout(), "Order %s queued for retry\n", args[0])
+					return nil
+				})
+			},
+		},
+	)
+
+	return cmd
+}
+
+func withQueueRepository(fn func(queueRepo repository.OrderQueueRepository) error) error {
+	return withDatabase(func(db *database.Database) error {
+		if err := requirePostgres(db); err != nil {
+			return err
+		}
+		return fn(repository.NewOrderQueueRepository(db.DB, nil))
+	})
+}
+
+// requirePostgres rejects the memory driver: a one-off command would only see its own,
+// empty, in-process store
+func requirePostgres(db *database.Database) error {
+	if db.InMemory() {
+		return fmt.Errorf("this command is not available with the memory driver")
+	}
+	return nil
+}
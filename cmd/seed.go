@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"oolio/internal/database"
+)
+
+func newSeedCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "seed",
+		Short: "Apply pending migrations and load the sample menu and demo coupons",
+		Long: "Apply pending migrations and load the sample menu and demo coupons. " +
+			"Existing products (by name) and coupons (by code) are left untouched, so it is safe to run repeatedly.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withDatabase(func(db *database.Database) error {
+				if err := db.MigrateUp(); err != nil {
+					return err
+				}
+
+				result, err := db.Seed(cmd.Context())
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "Seeded %d product(s) and %d coupon(s)\n", result.Products, result.Coupons)
+				return nil
+			})
+		},
+	}
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"github.com/spf13/cobra"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+
+	providerfx "oolio/internal/app/fx"
+	"oolio/internal/app/worker"
+	"oolio/internal/config"
+	"oolio/internal/database"
+	"oolio/internal/scheduler"
+)
+
+func newServeCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "serve",
+		Short: "Run the HTTP API and order worker",
+		Run: func(cmd *cobra.Command, args []string) {
+			runServer()
+		},
+	}
+}
+
+func runServer() {
+	app := fx.New(
+		providerfx.AppModule,
+		fx.Options(
+			fx.Provide(
+				NewHTTPServer,
+			),
+		),
+		fx.Invoke(StartServer),
+		fx.StopTimeout(stopTimeout(config.Load())),
+	)
+
+	// Run stops the app on SIGINT or SIGTERM, running the OnStop hooks of StartServer
+	app.Run()
+}
+
+// stopTimeout leaves every shutdown step its own timeout, plus a little to close the stores
+func stopTimeout(cfg *config.Config) time.Duration {
+	return cfg.Server.ShutdownTimeout + cfg.Worker.DrainTimeout + 5*time.Second
+}
+
+func NewHTTPServer(cfg *config.Config, ginRouter *gin.Engine) (*http.Server, error) {
+	if err := cfg.Server.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid server configuration: %w", err)
+	}
+
+	return &http.Server{
+		Addr:              cfg.Server.Host + ":" + cfg.Server.Port,
+		Handler:           ginRouter,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+		MaxHeaderBytes:    cfg.Server.MaxHeaderBytes,
+	}, nil
+}
+
+// StartServer starts the HTTP server and the background jobs. fx runs OnStop hooks in the
+// reverse order they were appended, so the hooks below are appended last step first: on
+// shutdown HTTP stops accepting and drains, then the background jobs finish their batches
+// and flush the outbox and notifications, and only then are Redis and Postgres closed.
+func StartServer(
+	lc fx.Lifecycle,
+	cfg *config.Config,
+	registry *config.Registry,
+	server *http.Server,
+	db *database.Database,
+	redisClient redis.UniversalClient,
+	jobScheduler *scheduler.Scheduler,
+	orderWorker *worker.OrderWorker,
+	outboxRelay *worker.OutboxRelay,
+	notificationWorker *worker.NotificationWorker,
+	webhookWorker *worker.WebhookWorker,
+	logger *zap.Logger,
+) {
+	lc.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			logger.Info("Closing Redis and database connections")
+
+			redisErr := redisClient.Close()
+			if redisErr != nil {
+				logger.Error("Failed to close Redis connection", zap.Error(redisErr))
+			}
+			dbErr := db.Close()
+			if dbErr != nil {
+				logger.Error("Failed to close database connection", zap.Error(dbErr))
+			}
+			logger.Info("Application stopped gracefully")
+			return errors.Join(redisErr, dbErr)
+		},
+	})
+
+	jobs := worker.NewGroup()
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			jobs.Go("scheduler", jobScheduler.Start)
+			jobs.Go("orders", orderWorker.Start)
+			jobs.Go("outbox", outboxRelay.Start)
+			jobs.Go("notifications", notificationWorker.Start)
+			jobs.Go("webhooks", webhookWorker.Start)
+			jobs.Go("db-pool-monitor", func(ctx context.Context) {
+				db.MonitorPool(ctx, cfg.Database.StatsInterval, cfg.Database.WaitWarnThreshold, logger.Named("db"))
+			})
+			jobs.Go("db-replica-monitor", func(ctx context.Context) {
+				db.MonitorReplica(ctx, cfg.Database.ReplicaCheckInterval, logger.Named("db"))
+			})
+			jobs.Go("db-connection-monitor", func(ctx context.Context) {
+				db.MonitorConnection(ctx, cfg.Database.BreakerProbeInterval, logger.Named("db"))
+			})
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			logger.Info("Draining background jobs")
+
+			drainCtx, cancel := context.WithTimeout(ctx, cfg.Worker.DrainTimeout)
+			defer cancel()
+
+			err := jobs.Stop(drainCtx)
+			if err != nil {
+				logger.Warn("Background jobs did not stop in time", zap.Error(err))
+			}
+
+			// The last orders may have left events and notifications behind
+			if err := outboxRelay.Flush(drainCtx); err != nil {
+				logger.Warn("Failed to flush the outbox", zap.Error(err))
+			}
+			if err := notificationWorker.Flush(drainCtx); err != nil {
+				logger.Warn("Failed to flush notifications", zap.Error(err))
+			}
+			return err
+		},
+	})
+
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			logger.Info("Starting HTTP server",
+				zap.String("address", server.Addr))
+
+			// Listening here rather than in the goroutine fails startup when the address is
+			// taken, instead of running on without a server
+			listener, err := net.Listen("tcp", server.Addr)
+			if err != nil {
+				return fmt.Errorf("failed to listen on %s: %w", server.Addr, err)
+			}
+			go func() {
+				if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+					logger.Error("HTTP server stopped", zap.Error(err))
+				}
+			}()
+
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			logger.Info("Shutting down HTTP server")
+
+			shutdownCtx, cancel := context.WithTimeout(ctx, cfg.Server.ShutdownTimeout)
+			defer cancel()
+
+			return server.Shutdown(shutdownCtx)
+		},
+	})
+
+	// Reload safe-to-change settings on SIGHUP
+	hup := make(chan os.Signal, 1)
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			signal.Notify(hup, syscall.SIGHUP)
+			go func() {
+				for range hup {
+					registry.Reload()
+					logger.Info("Configuration reloaded")
+				}
+			}()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			// No more signals are delivered once stopped, so closing ends the reloads
+			signal.Stop(hup)
+			close(hup)
+			logger.Info("Shutdown signal received")
+			return nil
+		},
+	})
+
+	logger.Info("Food Ordering API Backend started successfully")
+}
+
+func handleError(err error, message string) {
+	if err != nil {
+		log.Fatalf("%s: %v", message, err)
+	}
+}
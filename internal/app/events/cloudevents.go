@@ -0,0 +1,72 @@
+// Package events defines the envelope for every event the application emits. Events follow
+// the CloudEvents 1.0 JSON format so brokers, webhooks and stream consumers all see the same
+// attributes regardless of transport.
+package events
+
+import (
+	"encoding/json"
+	"path"
+	"strings"
+	"time"
+
+	"oolio/internal/app/models"
+)
+
+const (
+	SpecVersion     = "1.0"
+	DataContentType = "application/json"
+
+	// TypePrefix namespaces domain event types, e.g. com.oolio.order.queued
+	TypePrefix = "com.oolio."
+
+	// DefaultSource is the source URI-reference when none is configured
+	DefaultSource = "/oolio"
+)
+
+// Event is a CloudEvents 1.0 event in structured JSON mode.
+//
+// Source is the configured source followed by the aggregate type (/oolio/order) and Subject
+// is the aggregate ID, so source, subject and type together say what changed.
+type Event struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject,omitempty"`
+	Time            time.Time       `json:"time"`
+	DataContentType string          `json:"datacontenttype,omitempty"`
+	Data            json.RawMessage `json:"data,omitempty"`
+}
+
+// New builds an event about aggregateID of aggregateType. eventType is a domain event type
+// such as models.EventOrderQueued and is prefixed with TypePrefix.
+func New(source, id, aggregateType, aggregateID, eventType string, data json.RawMessage, at time.Time) Event {
+	if source == "" {
+		source = DefaultSource
+	}
+	return Event{
+		SpecVersion:     SpecVersion,
+		ID:              id,
+		Source:          strings.TrimSuffix(source, "/") + "/" + aggregateType,
+		Type:            TypePrefix + eventType,
+		Subject:         aggregateID,
+		Time:            at.UTC(),
+		DataContentType: DataContentType,
+		Data:            data,
+	}
+}
+
+// FromOutbox wraps an outbox event, reusing its ID so redeliveries can be deduplicated
+func FromOutbox(source string, event models.OutboxEvent) Event {
+	return New(source, event.ID, event.AggregateType, event.AggregateID, event.EventType, event.Payload, event.CreatedAt)
+}
+
+// AggregateType is the aggregate the event is about, taken from the last segment of Source
+func (e Event) AggregateType() string {
+	return path.Base(e.Source)
+}
+
+// DomainType is Type without TypePrefix, e.g. order.queued
+func (e Event) DomainType() string {
+	return strings.TrimPrefix(e.Type, TypePrefix)
+}
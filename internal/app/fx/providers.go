@@ -1,48 +1,140 @@
 package fx
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
 	"go.uber.org/fx"
+	"go.uber.org/zap"
 
 	"oolio/internal/app/handler"
 	"oolio/internal/app/middleware"
+	"oolio/internal/app/models"
 	"oolio/internal/app/repository"
 	"oolio/internal/app/router"
 	"oolio/internal/app/services"
 	"oolio/internal/app/worker"
+	"oolio/internal/chaos"
 	"oolio/internal/config"
 	"oolio/internal/database"
+	"oolio/internal/jsoncodec"
+	"oolio/internal/logger"
+	"oolio/internal/scheduler"
+	"oolio/internal/storage"
+	"oolio/internal/workerpool"
 )
 
 // Config Module
 var ConfigModule = fx.Module("config",
-	fx.Provide(config.Load),
+	fx.Provide(
+		config.Load,
+		config.NewRegistry,
+	),
+)
+
+// Logger Module
+var LoggerModule = fx.Module("logger",
+	fx.Provide(
+		NewLogLevel,
+		logger.NewLogger,
+	),
 )
 
 // Database Module
 var DatabaseModule = fx.Module("database",
 	fx.Provide(database.NewDatabase),
 	fx.Provide(func(d *database.Database) *sql.DB { return d.DB }),
+	fx.Provide(func(d *database.Database) repository.ReadRouter { return d }),
+	fx.Provide(func(d *database.Database) repository.Retrier { return d.Retrier }),
+	fx.Provide(database.NewRedisClient),
+	fx.Provide(NewChaosInjector),
+)
+
+// Storage Module
+var StorageModule = fx.Module("storage",
+	fx.Provide(NewStorage),
 )
 
 // Repository Module
 var RepositoryModule = fx.Module("repository",
-	fx.Provide(repository.NewProductRepository),
-	fx.Provide(repository.NewOrderRepository),
-	fx.Provide(repository.NewOrderQueueRepository),
+	fx.Provide(NewProductRepository),
+	fx.Provide(NewOrderRepository),
+	fx.Provide(NewOrderQueueRepository),
+	fx.Provide(NewOutboxRepository),
+	fx.Provide(NewCouponRepository),
+	fx.Provide(NewAddressRepository),
+	fx.Provide(NewFavoriteRepository),
+	fx.Provide(NewCartRepository),
+	fx.Provide(NewSegmentRepository),
+	fx.Provide(NewPricingRepository),
+	fx.Provide(NewCouponRedemptionRepository),
+	fx.Provide(NewNotificationRepository),
+	fx.Provide(NewNotificationOutboxRepository),
+	fx.Provide(NewNotificationFeedRepository),
+	fx.Provide(NewPushDeviceRepository),
+	fx.Provide(NewPaymentEventRepository),
+	fx.Provide(NewGiftCardRepository),
+	fx.Provide(NewInventoryRepository),
+	fx.Provide(NewInvoiceRepository),
+	fx.Provide(NewDeliveryRepository),
+	fx.Provide(NewTabRepository),
+	fx.Provide(NewOrderDiscrepancyRepository),
+	fx.Provide(NewWebhookDeliveryRepository),
+	fx.Provide(NewStoreRepository),
 )
 
 // Service Module
 var ServiceModule = fx.Module("service",
 	fx.Provide(
-		services.NewProductService,
-		services.NewOrderService,
-		services.NewOrderQueueService,
+		NewProductService,
+		NewCatalogVersion,
+		NewOrderService,
+		NewPaymentPolicy,
+		NewWorkerPools,
+		NewOrderQueueService,
 		NewRateLimiterService,
 		NewCouponService,
+		NewWebhookService,
+		NewEventPublisher,
+		NewFulfillmentProvider,
+		NewDeliveryProvider,
+		NewDeliveryService,
+		NewAlerter,
+		NewRetryLinks,
+		NewScheduleFeedLinks,
+		NewProductImageService,
+		NewMenuImportService,
+		NewStatusService,
+		services.NewAddressService,
+		services.NewFavoriteService,
+		NewCartService,
+		NewSegmentService,
+		NewVerificationService,
+		services.NewPricingService,
+		services.NewCouponRedemptionService,
+		NewEmailSender,
+		NewNotificationService,
+		NewSalesDigestService,
+		NewSalesReportService,
+		NewDashboardService,
+		NewReconciliationService,
+		NewPaymentService,
+		NewPaymentWebhookService,
+		services.NewGiftCardService,
+		NewInventoryService,
+		NewInvoiceService,
+		NewCurrencyConverter,
+		services.NewStoreService,
+		services.NewScheduleService,
+		services.NewTabService,
 	),
 )
 
@@ -50,7 +142,28 @@ var ServiceModule = fx.Module("service",
 var HandlerModule = fx.Module("handler",
 	fx.Provide(
 		handler.NewProductHandler,
-		NewOrderHandler,
+		handler.NewOrderHandler,
+		handler.NewGraphQLHandler,
+		handler.NewAdminHandler,
+		handler.NewFileHandler,
+		NewStatusHandler,
+		handler.NewCustomerHandler,
+		handler.NewCartHandler,
+		handler.NewVerificationHandler,
+		handler.NewPricingHandler,
+		handler.NewNotificationHandler,
+		handler.NewPaymentHandler,
+		handler.NewGiftCardHandler,
+		handler.NewInvoiceHandler,
+		handler.NewWebhookHandler,
+		handler.NewQueueHandler,
+		handler.NewStoreHandler,
+		handler.NewScheduleHandler,
+		handler.NewDeliveryHandler,
+		handler.NewTabHandler,
+		handler.NewReportHandler,
+		handler.NewJobHandler,
+		handler.NewInventoryHandler,
 	),
 )
 
@@ -60,12 +173,37 @@ var MiddlewareModule = fx.Module("middleware",
 		NewAuthMiddleware,
 		NewErrorHandlerMiddleware,
 		NewRateLimitMiddleware,
+		NewAccessLogMiddleware,
+		NewAvailabilityMiddleware,
+		NewDeprecationMiddleware,
+		NewChaosMiddleware,
+		NewOrderLookup,
+		middleware.NewPricingMiddleware,
+		NewResponseCacheMiddleware,
 	),
 )
 
 // Worker Module
 var WorkerModule = fx.Module("worker",
 	fx.Provide(NewOrderWorker),
+	fx.Provide(NewOutboxRelay),
+	fx.Provide(NewNotificationWorker),
+	fx.Provide(NewWebhookWorker),
+)
+
+// Scheduler Module; providers annotated with scheduledJobs add their periodic jobs to it
+var SchedulerModule = fx.Module("scheduler",
+	fx.Provide(
+		NewSchedulerStore,
+		fx.Annotate(NewScheduler, fx.ParamTags(``, ``, ``, `group:"jobs"`)),
+		scheduledJobs(NewCouponJobs),
+		scheduledJobs(NewMenuImportJobs),
+		scheduledJobs(NewCartJobs),
+		scheduledJobs(NewInventoryJobs),
+		scheduledJobs(NewSegmentJobs),
+		scheduledJobs(NewSalesDigestJobs),
+		scheduledJobs(NewReconciliationJobs),
+	),
 )
 
 // Router Module
@@ -73,14 +211,866 @@ var RouterModule = fx.Module("router",
 	fx.Provide(NewRouter),
 )
 
+// Custom providers for repositories, switching to in-memory storage for local development
+func NewProductRepository(cfg *config.Config, db *sql.DB, reader repository.ReadRouter, retrier repository.Retrier, redisClient redis.UniversalClient) (repository.ProductRepository, error) {
+	var repo repository.ProductRepository
+	if cfg.Database.Driver == config.DriverMemory {
+		repo = repository.NewMemoryProductRepository()
+	} else {
+		repo = repository.NewRetryingProductRepository(repository.NewProductRepository(db, reader), retrier)
+	}
+
+	cache, err := newRepositoryCache(cfg.Cache.Products, redisClient, "products")
+	if err != nil || cache == nil {
+		return repo, err
+	}
+	return repository.NewCachingProductRepository(repo, cache, cfg.Cache.Products.TTL), nil
+}
+
+func NewOrderRepository(cfg *config.Config, db *sql.DB, reader repository.ReadRouter, retrier repository.Retrier) repository.OrderRepository {
+	if cfg.Database.Driver == config.DriverMemory {
+		return repository.NewMemoryOrderRepository()
+	}
+	return repository.NewRetryingOrderRepository(repository.NewOrderRepository(db, reader), retrier)
+}
+
+func NewOrderQueueRepository(cfg *config.Config, db *sql.DB, reader repository.ReadRouter, retrier repository.Retrier) repository.OrderQueueRepository {
+	if cfg.Database.Driver == config.DriverMemory {
+		return repository.NewMemoryOrderQueueRepository()
+	}
+	return repository.NewRetryingOrderQueueRepository(repository.NewOrderQueueRepository(db, reader), retrier)
+}
+
+// Custom provider for Product Service, caching listings when CACHE_PRODUCT_LIST_DRIVER is set
+func NewProductService(cfg *config.Config, repo repository.ProductRepository, redisClient redis.UniversalClient, version *services.CatalogVersion) (services.ProductService, error) {
+	products := services.NewProductService(repo)
+
+	cache, err := newRepositoryCache(cfg.Cache.ProductList, redisClient, "product-list")
+	if err != nil {
+		return nil, err
+	}
+	if cache != nil {
+		products = services.NewCachingProductService(products, cache, cfg.Cache.ProductList.TTL)
+	}
+
+	// Cached responses are keyed by the catalog version, so every write busts them
+	if version != nil {
+		products = services.NewHookedProductService(products, version.Bump)
+	}
+	return products, nil
+}
+
+// Custom provider for the catalog version keying cached responses; nil when
+// CACHE_RESPONSE_DRIVER is none
+func NewCatalogVersion(cfg *config.Config, redisClient redis.UniversalClient) (*services.CatalogVersion, error) {
+	cache, err := newRepositoryCache(cfg.Cache.Responses, redisClient, "catalog")
+	if err != nil {
+		return nil, err
+	}
+	return services.NewCatalogVersion(cache), nil
+}
+
+// newRepositoryCache builds the cache configured for one repository, nil when caching is off
+func newRepositoryCache(cfg config.RepositoryCacheConfig, redisClient redis.UniversalClient, name string) (repository.Cache, error) {
+	switch cfg.Driver {
+	case "", config.DriverNone:
+		return nil, nil
+	case config.DriverMemory:
+		return repository.NewLRUCache(cfg.Size), nil
+	case config.DriverRedis:
+		return repository.NewRedisCache(redisClient, "oolio:cache:"+name+":"), nil
+	default:
+		return nil, fmt.Errorf("unknown cache driver %q for %s", cfg.Driver, name)
+	}
+}
+
+// Outbox events are only recorded by the Postgres repositories, so memory mode has no outbox
+func NewOutboxRepository(cfg *config.Config, db *sql.DB) repository.OutboxRepository {
+	if cfg.Database.Driver == config.DriverMemory {
+		return nil
+	}
+	return repository.NewOutboxRepository(db)
+}
+
+// Stored coupons live in Postgres; memory mode relies on the coupon files and named discounts
+func NewCouponRepository(cfg *config.Config, db *sql.DB) repository.CouponRepository {
+	if cfg.Database.Driver == config.DriverMemory {
+		return nil
+	}
+	return repository.NewCouponRepository(db)
+}
+
+func NewAddressRepository(cfg *config.Config, db *sql.DB, retrier repository.Retrier) repository.AddressRepository {
+	if cfg.Database.Driver == config.DriverMemory {
+		return repository.NewMemoryAddressRepository()
+	}
+	return repository.NewRetryingAddressRepository(repository.NewAddressRepository(db), retrier)
+}
+
+func NewFavoriteRepository(cfg *config.Config, db *sql.DB, retrier repository.Retrier) repository.FavoriteRepository {
+	if cfg.Database.Driver == config.DriverMemory {
+		return repository.NewMemoryFavoriteRepository()
+	}
+	return repository.NewRetryingFavoriteRepository(repository.NewFavoriteRepository(db), retrier)
+}
+
+func NewCartRepository(cfg *config.Config, db *sql.DB, retrier repository.Retrier) repository.CartRepository {
+	if cfg.Database.Driver == config.DriverMemory {
+		return repository.NewMemoryCartRepository()
+	}
+	return repository.NewRetryingCartRepository(repository.NewCartRepository(db), retrier)
+}
+
+func NewSegmentRepository(cfg *config.Config, db *sql.DB, orders repository.OrderRepository, retrier repository.Retrier) repository.SegmentRepository {
+	if cfg.Database.Driver == config.DriverMemory {
+		return repository.NewMemorySegmentRepository(orders)
+	}
+	return repository.NewRetryingSegmentRepository(repository.NewSegmentRepository(db), retrier)
+}
+
+func NewPricingRepository(cfg *config.Config, db *sql.DB, retrier repository.Retrier) repository.PricingRepository {
+	if cfg.Database.Driver == config.DriverMemory {
+		return repository.NewMemoryPricingRepository()
+	}
+	return repository.NewRetryingPricingRepository(repository.NewPricingRepository(db), retrier)
+}
+
+func NewStoreRepository(cfg *config.Config, db *sql.DB, retrier repository.Retrier) repository.StoreRepository {
+	if cfg.Database.Driver == config.DriverMemory {
+		return repository.NewMemoryStoreRepository()
+	}
+	return repository.NewRetryingStoreRepository(repository.NewStoreRepository(db), retrier)
+}
+
+func NewCouponRedemptionRepository(cfg *config.Config, db *sql.DB, retrier repository.Retrier) repository.CouponRedemptionRepository {
+	if cfg.Database.Driver == config.DriverMemory {
+		return repository.NewMemoryCouponRedemptionRepository()
+	}
+	return repository.NewRetryingCouponRedemptionRepository(repository.NewCouponRedemptionRepository(db), retrier)
+}
+
+func NewPaymentEventRepository(cfg *config.Config, db *sql.DB, retrier repository.Retrier) repository.PaymentEventRepository {
+	if cfg.Database.Driver == config.DriverMemory {
+		return repository.NewMemoryPaymentEventRepository()
+	}
+	return repository.NewRetryingPaymentEventRepository(repository.NewPaymentEventRepository(db), retrier)
+}
+
+func NewGiftCardRepository(cfg *config.Config, db *sql.DB, retrier repository.Retrier) repository.GiftCardRepository {
+	if cfg.Database.Driver == config.DriverMemory {
+		return repository.NewMemoryGiftCardRepository()
+	}
+	return repository.NewRetryingGiftCardRepository(repository.NewGiftCardRepository(db), retrier)
+}
+
+func NewInventoryRepository(cfg *config.Config, db *sql.DB, reader repository.ReadRouter, retrier repository.Retrier, products repository.ProductRepository) repository.InventoryRepository {
+	if cfg.Database.Driver == config.DriverMemory {
+		return repository.NewMemoryInventoryRepository(products)
+	}
+	return repository.NewRetryingInventoryRepository(repository.NewInventoryRepository(db, reader), retrier)
+}
+
+func NewNotificationRepository(cfg *config.Config, db *sql.DB, retrier repository.Retrier) repository.NotificationRepository {
+	if cfg.Database.Driver == config.DriverMemory {
+		return repository.NewMemoryNotificationRepository()
+	}
+	return repository.NewRetryingNotificationRepository(repository.NewNotificationRepository(db), retrier)
+}
+
+func NewNotificationOutboxRepository(cfg *config.Config, db *sql.DB, retrier repository.Retrier) repository.NotificationOutboxRepository {
+	if cfg.Database.Driver == config.DriverMemory {
+		return repository.NewMemoryNotificationOutboxRepository()
+	}
+	return repository.NewRetryingNotificationOutboxRepository(repository.NewNotificationOutboxRepository(db), retrier)
+}
+
+func NewWebhookDeliveryRepository(cfg *config.Config, db *sql.DB, retrier repository.Retrier) repository.WebhookDeliveryRepository {
+	if cfg.Database.Driver == config.DriverMemory {
+		return repository.NewMemoryWebhookDeliveryRepository()
+	}
+	return repository.NewRetryingWebhookDeliveryRepository(repository.NewWebhookDeliveryRepository(db), retrier)
+}
+
+func NewNotificationFeedRepository(cfg *config.Config, db *sql.DB, retrier repository.Retrier) repository.NotificationFeedRepository {
+	if cfg.Database.Driver == config.DriverMemory {
+		return repository.NewMemoryNotificationFeedRepository()
+	}
+	return repository.NewRetryingNotificationFeedRepository(repository.NewNotificationFeedRepository(db), retrier)
+}
+
+func NewPushDeviceRepository(cfg *config.Config, db *sql.DB, retrier repository.Retrier) repository.PushDeviceRepository {
+	if cfg.Database.Driver == config.DriverMemory {
+		return repository.NewMemoryPushDeviceRepository()
+	}
+	return repository.NewRetryingPushDeviceRepository(repository.NewPushDeviceRepository(db), retrier)
+}
+
+func NewInvoiceRepository(cfg *config.Config, db *sql.DB, retrier repository.Retrier) repository.InvoiceRepository {
+	if cfg.Database.Driver == config.DriverMemory {
+		return repository.NewMemoryInvoiceRepository()
+	}
+	return repository.NewRetryingInvoiceRepository(repository.NewInvoiceRepository(db), retrier)
+}
+
+func NewTabRepository(cfg *config.Config, db *sql.DB, retrier repository.Retrier) repository.TabRepository {
+	if cfg.Database.Driver == config.DriverMemory {
+		return repository.NewMemoryTabRepository()
+	}
+	return repository.NewRetryingTabRepository(repository.NewTabRepository(db), retrier)
+}
+
+func NewOrderDiscrepancyRepository(cfg *config.Config, db *sql.DB, reader repository.ReadRouter, retrier repository.Retrier) repository.OrderDiscrepancyRepository {
+	if cfg.Database.Driver == config.DriverMemory {
+		return repository.NewMemoryOrderDiscrepancyRepository()
+	}
+	return repository.NewRetryingOrderDiscrepancyRepository(repository.NewOrderDiscrepancyRepository(db, reader), retrier)
+}
+
+func NewDeliveryRepository(cfg *config.Config, db *sql.DB, retrier repository.Retrier) repository.DeliveryRepository {
+	if cfg.Database.Driver == config.DriverMemory {
+		return repository.NewMemoryDeliveryRepository()
+	}
+	return repository.NewRetryingDeliveryRepository(repository.NewDeliveryRepository(db), retrier)
+}
+
+// Custom provider for Log Level (reloadable)
+func NewLogLevel(cfg *config.Config, registry *config.Registry) (zap.AtomicLevel, error) {
+	level, err := logger.NewLevel(cfg)
+	if err != nil {
+		return level, err
+	}
+
+	registry.Subscribe(func(cfg *config.Config) {
+		// An invalid value leaves the current level untouched
+		_ = level.UnmarshalText([]byte(cfg.Log.Level))
+	})
+
+	return level, nil
+}
+
+// Custom provider for Inventory Service
+func NewInventoryService(cfg *config.Config, repo repository.InventoryRepository, products repository.ProductRepository, logger *zap.Logger) services.InventoryService {
+	return services.NewInventoryService(repo, products, cfg.Inventory.LowStockThreshold, cfg.Inventory.ReservationTTL, logger.Named("inventory"))
+}
+
+// Custom provider for Order Service; deletions are recorded on the audit logger
+func NewOrderService(orderRepo repository.OrderRepository, productRepo repository.ProductRepository, queueRepo repository.OrderQueueRepository, couponService services.CouponService, segments services.SegmentService, pricing services.PricingService, redemptions services.CouponRedemptionService, payments services.PaymentService, giftCards services.GiftCardService, currencies services.CurrencyConverter, inventory services.InventoryService, logger *zap.Logger) services.OrderService {
+	return services.NewOrderService(orderRepo, productRepo, queueRepo, couponService, segments, pricing, redemptions, payments, giftCards, currencies, inventory, logger.Named("audit"))
+}
+
+// Custom provider for the Payment Policy shared by the order handler and queue
+func NewPaymentPolicy(cfg *config.Config) (services.PaymentPolicy, error) {
+	policy, err := services.NewPaymentPolicy(cfg.Payment.Required, cfg.Payment.Window, cfg.Payment.Methods)
+	if err != nil {
+		return services.PaymentPolicy{}, fmt.Errorf("invalid PAYMENT_METHODS: %w", err)
+	}
+	return policy, nil
+}
+
 // Custom provider for Coupon Service
-func NewCouponService(cfg *config.Config) services.CouponService {
-	return services.NewCouponService(cfg.Coupon.BaseURL)
+func NewCouponService(cfg *config.Config, registry *config.Registry, store repository.CouponRepository, alerter services.Alerter, files storage.Storage, pools *workerpool.Registry, logger *zap.Logger) services.CouponService {
+	var cache storage.Storage
+	if cfg.Coupon.CacheFiles {
+		cache = files
+	}
+
+	// One client for every refresh, so downloads reuse their connections
+	client := services.NewCouponHTTPClient(services.CouponHTTPOptions{
+		DialTimeout:           cfg.Coupon.DialTimeout,
+		TLSHandshakeTimeout:   cfg.Coupon.TLSHandshakeTimeout,
+		ResponseHeaderTimeout: cfg.Coupon.ResponseHeaderTimeout,
+	})
+
+	couponService := services.NewCouponService(services.CouponOptions{
+		BaseURL:            cfg.Coupon.BaseURL,
+		Discounts:          cfg.Coupon.Discounts,
+		Segments:           cfg.Coupon.Segments,
+		SingleUse:          cfg.Coupon.SingleUse,
+		FileCodesSingleUse: cfg.Coupon.FileCodesSingleUse,
+		DefaultDiscount:    cfg.Coupon.DefaultDiscount,
+		MinLength:          cfg.Coupon.MinLength,
+		MaxLength:          cfg.Coupon.MaxLength,
+		MinFileOccurrences: cfg.Coupon.MinFileOccurrences,
+		MaxDownloadMB:      cfg.Coupon.MaxDownloadMB,
+		FileTimeout:        cfg.Coupon.FileTimeout,
+		HTTPClient:         client,
+		DownloadRetries:    cfg.Coupon.DownloadRetries,
+		ParseBufferSize:    cfg.Coupon.ParseBufferKB << 10,
+		CSVParser:          cfg.Coupon.CSVParser,
+		Store:              store,
+		Alerter:            alerter,
+		Cache:              cache,
+		Pool:               pools.New("coupons", cfg.Coupon.Concurrency),
+	}, logger.Named("coupon"))
+
+	registry.Subscribe(func(cfg *config.Config) {
+		couponService.SetDiscounts(cfg.Coupon.Discounts, cfg.Coupon.DefaultDiscount)
+	})
+
+	return couponService
+}
+
+// Custom provider for Event Publisher
+func NewEventPublisher(cfg *config.Config, webhooks services.WebhookService, logger *zap.Logger) (services.EventPublisher, error) {
+	switch cfg.Outbox.Broker {
+	case config.BrokerLog:
+		return services.NewLogEventPublisher(logger.Named("events")), nil
+	case config.BrokerKafka:
+		return services.NewKafkaEventPublisher(services.KafkaOptions{
+			RESTURL: cfg.Outbox.KafkaRESTURL,
+			Topic:   cfg.Outbox.KafkaTopic,
+			Topics:  cfg.Outbox.KafkaTopics,
+			Timeout: cfg.Outbox.KafkaTimeout,
+		}), nil
+	case config.BrokerWebhook:
+		if cfg.Outbox.WebhookURL == "" {
+			return nil, fmt.Errorf("OUTBOX_WEBHOOK_URL is required for the webhook outbox broker")
+		}
+		return services.NewWebhookEventPublisher(webhooks, cfg.Outbox.WebhookEvents), nil
+	default:
+		return nil, fmt.Errorf("unsupported outbox broker %q", cfg.Outbox.Broker)
+	}
+}
+
+// Custom provider for Webhook Service; an endpoint is only configured when it has a URL
+func NewWebhookService(cfg *config.Config, repo repository.WebhookDeliveryRepository, pools *workerpool.Registry) services.WebhookService {
+	endpoints := map[string]services.WebhookEndpoint{}
+	for name, endpoint := range map[string]services.WebhookEndpoint{
+		models.WebhookEndpointFulfillment: {URL: cfg.Fulfillment.WebhookURL, Secret: cfg.Fulfillment.WebhookSecret, Timeout: cfg.Fulfillment.Timeout},
+		models.WebhookEndpointEvents:      {URL: cfg.Outbox.WebhookURL, Secret: cfg.Outbox.WebhookSecret, Timeout: cfg.Outbox.WebhookTimeout},
+		models.WebhookEndpointDigest:      {URL: cfg.Digest.WebhookURL, Secret: cfg.Digest.WebhookSecret, Timeout: cfg.Digest.Timeout},
+		models.WebhookEndpointDelivery:    {URL: cfg.Delivery.WebhookURL, Secret: cfg.Delivery.WebhookSecret, Timeout: cfg.Delivery.Timeout},
+	} {
+		if endpoint.URL != "" {
+			endpoints[name] = endpoint
+		}
+	}
+
+	return services.NewWebhookService(repo, services.WebhookOptions{
+		Endpoints:    endpoints,
+		MaxAttempts:  cfg.Webhook.MaxAttempts,
+		RetryBackoff: cfg.Webhook.RetryBackoff,
+		Pool:         pools.New("webhooks", cfg.Webhook.Concurrency),
+	})
+}
+
+// Custom provider for Fulfillment Provider
+func NewFulfillmentProvider(cfg *config.Config, webhooks services.WebhookService) (services.FulfillmentProvider, error) {
+	switch cfg.Fulfillment.Provider {
+	case config.ProviderNone:
+		return services.NewNoopFulfillmentProvider(), nil
+	case config.ProviderWebhook:
+		if cfg.Fulfillment.WebhookURL == "" {
+			return nil, fmt.Errorf("FULFILLMENT_WEBHOOK_URL is required for the webhook fulfillment provider")
+		}
+		return services.NewWebhookFulfillmentProvider(webhooks, cfg.Outbox.EventSource), nil
+	default:
+		return nil, fmt.Errorf("unsupported fulfillment provider %q", cfg.Fulfillment.Provider)
+	}
+}
+
+// Custom provider for Delivery Provider; nil when DELIVERY_PROVIDER is none
+func NewDeliveryProvider(cfg *config.Config, webhooks services.WebhookService) (services.DeliveryProvider, error) {
+	dc := cfg.Delivery
+	switch dc.Provider {
+	case config.ProviderNone:
+		return nil, nil
+	case config.ProviderMock:
+		return services.NewMockDeliveryProvider(dc.MockStep), nil
+	case config.ProviderWebhook:
+		if dc.WebhookURL == "" {
+			return nil, fmt.Errorf("DELIVERY_WEBHOOK_URL is required for the webhook delivery provider")
+		}
+		if dc.StatusSecret == "" {
+			return nil, fmt.Errorf("DELIVERY_STATUS_SECRET is required for the webhook delivery provider")
+		}
+		return services.NewWebhookDeliveryProvider(webhooks, cfg.Outbox.EventSource), nil
+	default:
+		return nil, fmt.Errorf("unsupported delivery provider %q", dc.Provider)
+	}
+}
+
+// Custom provider for Delivery Service; nil without a delivery provider, when orders
+// aren't delivered
+func NewDeliveryService(cfg *config.Config, repo repository.DeliveryRepository, provider services.DeliveryProvider, logger *zap.Logger) services.DeliveryService {
+	if provider == nil {
+		return nil
+	}
+	return services.NewDeliveryService(repo, provider, logger, services.DeliveryOptions{
+		StatusSecret: cfg.Delivery.StatusSecret,
+	})
+}
+
+// Custom provider for Alerter; ALERT_EMAIL_TO adds email next to the chat provider
+func NewAlerter(cfg *config.Config, email services.EmailSender) (services.Alerter, error) {
+	var alerters []services.Alerter
+	switch cfg.Alert.Provider {
+	case config.ProviderNone:
+	case config.ProviderSlack, config.ProviderDiscord:
+		if cfg.Alert.WebhookURL == "" {
+			return nil, fmt.Errorf("ALERT_WEBHOOK_URL is required for the %s alert provider", cfg.Alert.Provider)
+		}
+		if cfg.Alert.Provider == config.ProviderSlack {
+			alerters = append(alerters, services.NewSlackAlerter(cfg.Alert.WebhookURL, cfg.Alert.Timeout))
+		} else {
+			alerters = append(alerters, services.NewDiscordAlerter(cfg.Alert.WebhookURL, cfg.Alert.Timeout))
+		}
+	default:
+		return nil, fmt.Errorf("unsupported alert provider %q", cfg.Alert.Provider)
+	}
+
+	if len(cfg.Alert.EmailTo) > 0 {
+		if email == nil {
+			return nil, fmt.Errorf("ALERT_EMAIL_TO needs a NOTIFICATION_EMAIL_PROVIDER to send with")
+		}
+		alerters = append(alerters, services.NewEmailAlerter(email, cfg.Alert.EmailTo))
+	}
+
+	if len(alerters) == 0 {
+		return services.NewNoopAlerter(), nil
+	}
+	return services.NewMultiAlerter(alerters...), nil
 }
 
-// Custom provider for Auth Middleware
-func NewAuthMiddleware(cfg *config.Config) gin.HandlerFunc {
-	return middleware.APIKeyAuth([]string{cfg.API.APIKey})
+// Custom provider for the bounded worker pools, one per kind of background work
+func NewWorkerPools() *workerpool.Registry {
+	return workerpool.NewRegistry()
+}
+
+// Custom provider for Order Queue Service, processing the orders of a batch on its own pool
+func NewOrderQueueService(cfg *config.Config, deps services.OrderQueueDeps, pools *workerpool.Registry) services.OrderQueueService {
+	return services.NewOrderQueueService(deps, pools.New("orders", cfg.Worker.Concurrency), cfg.Worker.InstanceID)
+}
+
+// Custom provider for the signed retry links in failed order alerts; nil without
+// ALERT_RETRY_LINK_SECRET or ALERT_RETRY_LINK_BASE_URL
+func NewRetryLinks(cfg *config.Config) *services.RetryLinks {
+	return services.NewRetryLinks(cfg.Alert.RetryLinkSecret, cfg.Alert.RetryLinkBaseURL, cfg.Alert.RetryLinkTTL)
+}
+
+// Custom provider for the signed URLs of the scheduled orders feed; nil, which disables the
+// feed, without SCHEDULE_FEED_SECRET or SCHEDULE_FEED_BASE_URL
+func NewScheduleFeedLinks(cfg *config.Config) *services.ScheduleFeedLinks {
+	return services.NewScheduleFeedLinks(cfg.API.ScheduleFeedSecret, cfg.API.ScheduleFeedBaseURL, cfg.API.ScheduleFeedTTL)
+}
+
+// Custom provider for Verification Service; nil when VERIFICATION_PROVIDER is none
+func NewVerificationService(cfg *config.Config, products repository.ProductRepository, logger *zap.Logger) (services.VerificationService, error) {
+	vc := cfg.Verification
+	var sender services.SMSSender
+	switch vc.Provider {
+	case config.ProviderNone:
+		return nil, nil
+	case config.ProviderLog:
+		sender = services.NewLogSMSSender(logger.Named("sms"))
+	case config.ProviderWebhook:
+		if vc.WebhookURL == "" {
+			return nil, fmt.Errorf("VERIFICATION_WEBHOOK_URL is required for the webhook verification provider")
+		}
+		sender = services.NewWebhookSMSSender(vc.WebhookURL, vc.Token, vc.Timeout)
+	default:
+		return nil, fmt.Errorf("unsupported verification provider %q", vc.Provider)
+	}
+	if vc.Secret == "" {
+		return nil, fmt.Errorf("VERIFICATION_SECRET is required for phone verification")
+	}
+
+	return services.NewVerificationService(sender, products, services.VerificationOptions{
+		Secret:        vc.Secret,
+		CodeTTL:       vc.CodeTTL,
+		TokenTTL:      vc.TokenTTL,
+		GuestOrderMin: models.Cents(int64(math.Round(vc.GuestOrderMin * 100))),
+	}), nil
+}
+
+// Custom provider for the Email Sender shared by notifications and the sales digest; it is
+// nil for the "none" provider
+func NewEmailSender(cfg *config.Config, logger *zap.Logger) (services.EmailSender, error) {
+	nc := cfg.Notification
+
+	var email services.EmailSender
+	switch nc.EmailProvider {
+	case config.ProviderNone:
+	case config.ProviderLog:
+		email = services.NewLogEmailSender(logger.Named("email"))
+	case config.ProviderWebhook:
+		if nc.EmailWebhookURL == "" {
+			return nil, fmt.Errorf("NOTIFICATION_EMAIL_WEBHOOK_URL is required for the webhook email provider")
+		}
+		email = services.NewWebhookEmailSender(nc.EmailWebhookURL, nc.Token, nc.Timeout)
+	case config.ProviderSMTP:
+		if nc.SMTPHost == "" {
+			return nil, fmt.Errorf("NOTIFICATION_SMTP_HOST is required for the smtp email provider")
+		}
+		sender, err := services.NewSMTPEmailSender(services.SMTPOptions{
+			Host:     nc.SMTPHost,
+			Port:     nc.SMTPPort,
+			Username: nc.SMTPUsername,
+			Password: nc.SMTPPassword,
+			From:     nc.EmailFrom,
+			Timeout:  nc.Timeout,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("invalid NOTIFICATION_EMAIL_FROM: %w", err)
+		}
+		email = sender
+	case config.ProviderSES:
+		if nc.SESRegion == "" || nc.EmailFrom == "" {
+			return nil, fmt.Errorf("NOTIFICATION_SES_REGION and NOTIFICATION_EMAIL_FROM are required for the ses email provider")
+		}
+		email = services.NewSESEmailSender(services.SESOptions{
+			Region:          nc.SESRegion,
+			Endpoint:        nc.SESEndpoint,
+			AccessKeyID:     nc.SESAccessKeyID,
+			SecretAccessKey: nc.SESSecretAccessKey,
+			From:            nc.EmailFrom,
+			Timeout:         nc.Timeout,
+		})
+	default:
+		return nil, fmt.Errorf("unsupported notification email provider %q", nc.EmailProvider)
+	}
+	if email != nil && nc.Sandbox {
+		email = services.NewSandboxEmailSender(email, nc.SandboxAllow, nc.SandboxRedirect, logger.Named("email"))
+	}
+	return email, nil
+}
+
+// Custom provider for Notification Service
+func NewNotificationService(cfg *config.Config, registry *config.Registry, repo repository.NotificationRepository, events repository.OutboxRepository, outbox repository.NotificationOutboxRepository, feed repository.NotificationFeedRepository, devices repository.PushDeviceRepository, email services.EmailSender, rateLimiter services.RateLimiterService, logger *zap.Logger) (services.NotificationService, error) {
+	nc := cfg.Notification
+
+	var sms services.SMSSender
+	switch nc.SMSProvider {
+	case config.ProviderNone:
+	case config.ProviderLog:
+		sms = services.NewLogSMSSender(logger.Named("sms"))
+	case config.ProviderWebhook:
+		if nc.SMSWebhookURL == "" {
+			return nil, fmt.Errorf("NOTIFICATION_SMS_WEBHOOK_URL is required for the webhook SMS provider")
+		}
+		sms = services.NewWebhookSMSSender(nc.SMSWebhookURL, nc.Token, nc.Timeout)
+	case config.ProviderTwilio:
+		if nc.TwilioAccountSID == "" || nc.TwilioAuthToken == "" || nc.TwilioFrom == "" {
+			return nil, fmt.Errorf("NOTIFICATION_TWILIO_ACCOUNT_SID, NOTIFICATION_TWILIO_AUTH_TOKEN and NOTIFICATION_TWILIO_FROM are required for the twilio SMS provider")
+		}
+		sms = services.NewTwilioSMSSender(services.TwilioOptions{
+			AccountSID:        nc.TwilioAccountSID,
+			AuthToken:         nc.TwilioAuthToken,
+			From:              nc.TwilioFrom,
+			BaseURL:           nc.TwilioBaseURL,
+			StatusCallbackURL: nc.SMSStatusCallbackURL,
+			Timeout:           nc.Timeout,
+		})
+	default:
+		return nil, fmt.Errorf("unsupported notification SMS provider %q", nc.SMSProvider)
+	}
+
+	push, err := newPushSenders(nc, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	templates, err := services.LoadEmailTemplates(nc.TemplateDir)
+	if err != nil {
+		return nil, err
+	}
+
+	// Ready texts can be turned off with a reload, e.g. while the SMS provider is down
+	var smsReadyEnabled atomic.Bool
+	smsReadyEnabled.Store(nc.SMSReadyEnabled)
+	registry.Subscribe(func(cfg *config.Config) {
+		smsReadyEnabled.Store(cfg.Notification.SMSReadyEnabled)
+	})
+	var pushEnabled atomic.Bool
+	pushEnabled.Store(nc.PushEnabled)
+	registry.Subscribe(func(cfg *config.Config) {
+		pushEnabled.Store(cfg.Notification.PushEnabled)
+	})
+
+	return services.NewNotificationService(repo, email, sms, services.NotificationOptions{
+		Templates:       templates,
+		Events:          events,
+		RateLimiter:     rateLimiter,
+		SMSPerHour:      nc.SMSPerHour,
+		SMSReadyEnabled: smsReadyEnabled.Load,
+		Outbox:          outbox,
+		MaxAttempts:     nc.MaxAttempts,
+		RetryBackoff:    nc.RetryBackoff,
+		Feed:            feed,
+		Push:            push,
+		Devices:         devices,
+		PushEnabled:     pushEnabled.Load,
+	}), nil
+}
+
+// newPushSenders returns the push sender of each platform in NOTIFICATION_PUSH_PROVIDERS
+func newPushSenders(nc config.NotificationConfig, logger *zap.Logger) (map[string]services.PushSender, error) {
+	push := make(map[string]services.PushSender)
+	for _, provider := range nc.PushProviders {
+		switch provider {
+		case config.ProviderNone:
+		case config.ProviderLog:
+			sender := services.NewLogPushSender(logger.Named("push"))
+			push[models.PushPlatformFCM] = sender
+			push[models.PushPlatformAPNs] = sender
+		case config.ProviderFCM:
+			if nc.FCMCredentialsFile == "" {
+				return nil, fmt.Errorf("NOTIFICATION_FCM_CREDENTIALS_FILE is required for the fcm push provider")
+			}
+			opts, err := services.LoadFCMServiceAccount(nc.FCMCredentialsFile)
+			if err != nil {
+				return nil, err
+			}
+			opts.BaseURL = nc.FCMBaseURL
+			opts.Timeout = nc.Timeout
+			sender, err := services.NewFCMPushSender(opts)
+			if err != nil {
+				return nil, err
+			}
+			push[models.PushPlatformFCM] = sender
+		case config.ProviderAPNs:
+			if nc.APNsKeyFile == "" || nc.APNsKeyID == "" || nc.APNsTeamID == "" || nc.APNsTopic == "" {
+				return nil, fmt.Errorf("NOTIFICATION_APNS_KEY_FILE, NOTIFICATION_APNS_KEY_ID, NOTIFICATION_APNS_TEAM_ID and NOTIFICATION_APNS_TOPIC are required for the apns push provider")
+			}
+			key, err := os.ReadFile(nc.APNsKeyFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read NOTIFICATION_APNS_KEY_FILE: %w", err)
+			}
+			sender, err := services.NewAPNsPushSender(services.APNsOptions{
+				KeyID:      nc.APNsKeyID,
+				TeamID:     nc.APNsTeamID,
+				PrivateKey: string(key),
+				Topic:      nc.APNsTopic,
+				Production: nc.APNsProduction,
+				BaseURL:    nc.APNsBaseURL,
+				Timeout:    nc.Timeout,
+			})
+			if err != nil {
+				return nil, err
+			}
+			push[models.PushPlatformAPNs] = sender
+		default:
+			return nil, fmt.Errorf("unsupported notification push provider %q", provider)
+		}
+	}
+	return push, nil
+}
+
+// Custom provider for Sales Digest Service
+func NewSalesDigestService(cfg *config.Config, orders repository.OrderRepository, email services.EmailSender, webhooks services.WebhookService, logger *zap.Logger) (services.SalesDigestService, error) {
+	dc := cfg.Digest
+	if dc.Hour < 0 || dc.Hour > 23 {
+		return nil, fmt.Errorf("DIGEST_HOUR must be between 0 and 23")
+	}
+	location, err := time.LoadLocation(dc.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DIGEST_TIME_ZONE: %w", err)
+	}
+	templates, err := services.LoadEmailTemplates(cfg.Notification.TemplateDir)
+	if err != nil {
+		return nil, err
+	}
+
+	return services.NewSalesDigestService(orders, email, webhooks, services.SalesDigestOptions{
+		Recipients:  dc.Recipients,
+		Location:    location,
+		TopProducts: dc.TopProducts,
+		Webhook:     dc.WebhookURL != "",
+		Source:      cfg.Outbox.EventSource,
+		Templates:   templates,
+	}, logger.Named("digest")), nil
+}
+
+// NewSalesReportService reports days in DIGEST_TIME_ZONE, so that reports and digests agree
+// on them
+func NewSalesReportService(cfg *config.Config, orders repository.OrderRepository) (services.SalesReportService, error) {
+	location, err := time.LoadLocation(cfg.Digest.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DIGEST_TIME_ZONE: %w", err)
+	}
+	return services.NewSalesReportService(orders, location), nil
+}
+
+// NewDashboardService counts today's sales from midnight in DIGEST_TIME_ZONE, like the
+// sales reports
+func NewDashboardService(cfg *config.Config, orders repository.OrderRepository, queue repository.OrderQueueRepository) (services.DashboardService, error) {
+	location, err := time.LoadLocation(cfg.Digest.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DIGEST_TIME_ZONE: %w", err)
+	}
+	return services.NewDashboardService(orders, queue, location), nil
+}
+
+func NewReconciliationService(orders repository.OrderRepository, discrepancies repository.OrderDiscrepancyRepository, coupons services.CouponService, logger *zap.Logger) services.ReconciliationService {
+	return services.NewReconciliationService(orders, discrepancies, coupons, logger.Named("reconcile"))
+}
+
+// Custom provider for Payment Service
+func NewPaymentService(cfg *config.Config) (services.PaymentService, error) {
+	pc := cfg.Payment
+	switch pc.Provider {
+	case config.ProviderNone:
+		if pc.Required {
+			return nil, fmt.Errorf("PAYMENT_REQUIRED needs a PAYMENT_PROVIDER")
+		}
+		return nil, nil
+	case config.ProviderMock:
+		return services.NewMockPaymentService(pc.Currency), nil
+	case config.ProviderStripe:
+		if pc.StripeSecretKey == "" {
+			return nil, fmt.Errorf("PAYMENT_STRIPE_SECRET_KEY is required for the stripe payment provider")
+		}
+		return services.NewStripePaymentService(services.StripeOptions{
+			SecretKey: pc.StripeSecretKey,
+			Currency:  pc.Currency,
+			BaseURL:   pc.StripeBaseURL,
+			Timeout:   pc.Timeout,
+		}), nil
+	default:
+		return nil, fmt.Errorf("unsupported payment provider %q", pc.Provider)
+	}
+}
+
+// Custom provider for Currency Converter; the "none" provider leaves orders in the payment
+// currency
+func NewCurrencyConverter(cfg *config.Config) (services.CurrencyConverter, error) {
+	cc := cfg.Currency
+	settlement := make([]string, 0, len(cc.Settlement))
+	for _, currency := range cc.Settlement {
+		settlement = append(settlement, strings.ToLower(currency))
+	}
+
+	var rates services.ExchangeRateProvider
+	switch cc.Provider {
+	case config.ProviderNone:
+		return nil, nil
+	case config.ProviderFixed:
+		if len(cc.Rates) == 0 {
+			return nil, fmt.Errorf("CURRENCY_RATES is required for the fixed currency provider")
+		}
+		if len(settlement) == 0 {
+			for currency := range cc.Rates {
+				settlement = append(settlement, currency)
+			}
+		}
+		rates = services.NewFixedExchangeRates(cfg.Payment.Currency, cc.Rates)
+	case config.ProviderHTTP:
+		if len(settlement) == 0 {
+			return nil, fmt.Errorf("CURRENCY_SETTLEMENT is required for the http currency provider")
+		}
+		rates = services.NewCachedExchangeRates(services.NewHTTPExchangeRates(services.HTTPExchangeRatesOptions{
+			URL:     cc.RatesURL,
+			Timeout: cc.Timeout,
+		}), cc.CacheTTL)
+	default:
+		return nil, fmt.Errorf("unsupported currency provider %q", cc.Provider)
+	}
+
+	return services.NewCurrencyConverter(cfg.Payment.Currency, settlement, rates), nil
+}
+
+// Custom provider for Invoice Service
+func NewInvoiceService(cfg *config.Config, invoices repository.InvoiceRepository, orders repository.OrderRepository) (services.InvoiceService, error) {
+	ic := cfg.Invoice
+	service, err := services.NewInvoiceService(invoices, orders, services.InvoiceOptions{
+		Series:   ic.Series,
+		Seller:   models.InvoiceSeller{Name: ic.SellerName, TaxID: ic.SellerTaxID},
+		Currency: cfg.Payment.Currency,
+		TaxName:  ic.TaxName,
+		TaxRate:  ic.TaxRate,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid invoice configuration: %w", err)
+	}
+	return service, nil
+}
+
+// Custom provider for Payment Webhook Service; without PAYMENT_WEBHOOK_SECRET the
+// webhook is off
+func NewPaymentWebhookService(cfg *config.Config, events repository.PaymentEventRepository, orders repository.OrderRepository, queue services.OrderQueueService, fulfillment services.FulfillmentProvider, logger *zap.Logger) (services.PaymentWebhookService, error) {
+	pc := cfg.Payment
+	if pc.WebhookSecret == "" {
+		return nil, nil
+	}
+	if pc.Provider == config.ProviderNone {
+		return nil, fmt.Errorf("PAYMENT_WEBHOOK_SECRET needs a PAYMENT_PROVIDER")
+	}
+	return services.NewPaymentWebhookService(events, orders, queue, fulfillment, logger, services.PaymentWebhookOptions{
+		Secret:    pc.WebhookSecret,
+		Tolerance: pc.WebhookTolerance,
+	}), nil
+}
+
+// Custom provider for Storage
+func NewStorage(cfg *config.Config) (storage.Storage, error) {
+	sc := cfg.Storage
+	switch sc.Driver {
+	case config.StorageLocal:
+		return storage.NewLocalStorage(sc.Dir)
+	case config.StorageS3, config.StorageGCS:
+		opts := storage.S3Options{
+			Endpoint:        sc.Endpoint,
+			Region:          sc.Region,
+			Bucket:          sc.Bucket,
+			AccessKeyID:     sc.AccessKeyID,
+			SecretAccessKey: sc.SecretAccessKey,
+			Timeout:         sc.Timeout,
+		}
+		// GCS is reached through its S3-compatible XML API with an HMAC key
+		if sc.Driver == config.StorageGCS {
+			opts.Region = storage.GCSRegion
+			if opts.Endpoint == "" {
+				opts.Endpoint = storage.GCSEndpoint
+			}
+		}
+		return storage.NewS3Storage(opts)
+	default:
+		return nil, fmt.Errorf("unsupported storage driver %q", sc.Driver)
+	}
+}
+
+// Custom provider for Product Image Service
+func NewProductImageService(cfg *config.Config, productService services.ProductService, files storage.Storage) services.ProductImageService {
+	return services.NewProductImageService(productService, files, cfg.Storage.PublicURL)
+}
+
+// Custom provider for Menu Import Service; the links between menu items and products are
+// kept in file storage
+func NewMenuImportService(cfg *config.Config, productService services.ProductService, files storage.Storage, logger *zap.Logger) (services.MenuImportService, error) {
+	var source services.MenuSource
+	switch cfg.MenuImport.Provider {
+	case config.ProviderNone:
+	case config.ProviderGeneric:
+		if cfg.MenuImport.URL == "" {
+			return nil, fmt.Errorf("MENU_IMPORT_URL is required for the generic menu provider")
+		}
+		source = services.NewGenericMenuSource(services.GenericMenuOptions{
+			URL:     cfg.MenuImport.URL,
+			Token:   cfg.MenuImport.Token,
+			Timeout: cfg.MenuImport.Timeout,
+		})
+	default:
+		return nil, fmt.Errorf("unsupported menu import provider %q", cfg.MenuImport.Provider)
+	}
+	return services.NewMenuImportService(source, productService, files, logger.Named("menu")), nil
+}
+
+// Custom provider for Status Service
+func NewStatusService(cfg *config.Config, db *database.Database, queueService services.OrderQueueService, orderWorker *worker.OrderWorker) services.StatusService {
+	return services.NewStatusService(services.StatusOptions{
+		Database:         db.Retrier.Breaker,
+		Queue:            queueService,
+		WorkerInterval:   cfg.Worker.Interval,
+		WorkerBatchSize:  orderWorker.BatchSize,
+		BacklogThreshold: cfg.Alert.QueueBacklog,
+		CacheTTL:         cfg.Server.StatusCacheTTL,
+	})
+}
+
+// Custom provider for Auth Middleware; API_KEY reaches every store, store API keys only
+// their own
+func NewAuthMiddleware(cfg *config.Config, stores services.StoreService) gin.HandlerFunc {
+	return middleware.StoreAPIKeyAuth([]string{cfg.API.APIKey}, stores)
 }
 
 // Custom provider for Error Handler Middleware
@@ -92,50 +1082,304 @@ func NewErrorHandlerMiddleware() []gin.HandlerFunc {
 }
 
 // Custom provider for Rate Limiter Service
-func NewRateLimiterService(cfg *config.Config) services.RateLimiterService {
-	return services.NewRateLimiterService(cfg.Redis.Addr, cfg.Redis.Password, cfg.Redis.DB)
+func NewRateLimiterService(cfg *config.Config, redisClient redis.UniversalClient) services.RateLimiterService {
+	if cfg.Redis.Driver == config.DriverMemory {
+		return services.NewMemoryRateLimiterService()
+	}
+	return services.NewRateLimiterService(redisClient)
 }
 
 // Custom provider for Rate Limit Middleware
-func NewRateLimitMiddleware(rateLimiter services.RateLimiterService) *middleware.RateLimitMiddleware {
-	return middleware.NewRateLimitMiddleware(rateLimiter)
+func NewRateLimitMiddleware(cfg *config.Config, registry *config.Registry, rateLimiter services.RateLimiterService) *middleware.RateLimitMiddleware {
+	rateLimitMiddleware := middleware.NewRateLimitMiddleware(rateLimiter)
+
+	applyLimits := func(cfg *config.Config) {
+		rateLimitMiddleware.SetLimit("product", cfg.RateLimit.ProductPerMinute)
+		rateLimitMiddleware.SetLimit("order", cfg.RateLimit.OrderPerMinute)
+		rateLimitMiddleware.SetLimit("queue", cfg.RateLimit.QueuePerMinute)
+	}
+	applyLimits(cfg)
+	registry.Subscribe(applyLimits)
+
+	return rateLimitMiddleware
+}
+
+// Custom provider for Access Log Middleware
+func NewAccessLogMiddleware(cfg *config.Config, log *zap.Logger, lc fx.Lifecycle) *middleware.AccessLogMiddleware {
+	accessLogger, closer := logger.NewAccessLogger(cfg.Log, log)
+	if closer != nil {
+		lc.Append(fx.Hook{
+			OnStop: func(ctx context.Context) error {
+				_ = accessLogger.Sync()
+				return closer.Close()
+			},
+		})
+	}
+	return middleware.NewAccessLogMiddleware(accessLogger)
+}
+
+// Custom provider for Availability Middleware, failing fast while the database circuit breaker is open
+func NewAvailabilityMiddleware(cfg *config.Config, db *database.Database) *middleware.AvailabilityMiddleware {
+	return middleware.NewAvailabilityMiddleware(db.Retrier.Breaker, cfg.Database.BreakerProbeInterval)
 }
 
-// Custom provider for OrderHandler
-func NewOrderHandler(orderService services.OrderService, queueService services.OrderQueueService) *handler.OrderHandler {
-	return handler.NewOrderHandler(orderService, queueService)
+// Custom provider for the fault injector, nil unless CHAOS_ENABLED. Chaos is for
+// development, so it refuses to start in gin's release mode. The database and Redis faults
+// are hooked into the retrier and the Redis client here, and all faults follow reloads.
+func NewChaosInjector(cfg *config.Config, registry *config.Registry, db *database.Database, redisClient redis.UniversalClient, logger *zap.Logger) (*chaos.Injector, error) {
+	if !cfg.Chaos.Enabled {
+		return nil, nil
+	}
+	if gin.Mode() == gin.ReleaseMode {
+		return nil, fmt.Errorf("CHAOS_ENABLED is for development and can't be used with GIN_MODE=release")
+	}
+
+	injector := chaos.New(cfg.Chaos)
+	registry.Subscribe(func(cfg *config.Config) {
+		injector.Configure(cfg.Chaos)
+	})
+	db.Retrier.Faults = injector.Database
+	redisClient.AddHook(chaos.NewRedisHook(injector.Redis))
+
+	logger.Warn("Fault injection is enabled",
+		zap.Any("http", cfg.Chaos.HTTP),
+		zap.Any("database", cfg.Chaos.Database),
+		zap.Any("redis", cfg.Chaos.Redis))
+	return injector, nil
+}
+
+// Custom provider for Chaos Middleware; nil without the fault injector
+func NewChaosMiddleware(injector *chaos.Injector) *middleware.ChaosMiddleware {
+	if injector == nil {
+		return nil
+	}
+	return middleware.NewChaosMiddleware(injector.HTTP)
+}
+
+// Custom provider for Response Cache Middleware; nil when CACHE_RESPONSE_DRIVER is none
+func NewResponseCacheMiddleware(cfg *config.Config, redisClient redis.UniversalClient, version *services.CatalogVersion) (*middleware.ResponseCacheMiddleware, error) {
+	cache, err := newRepositoryCache(cfg.Cache.Responses, redisClient, "responses")
+	if err != nil {
+		return nil, err
+	}
+	return middleware.NewResponseCacheMiddleware(cache, version, cfg.Cache.Responses.TTL), nil
+}
+
+// Custom provider for Deprecation Middleware, announcing the retirement of /api/v1
+func NewDeprecationMiddleware(cfg *config.Config) *middleware.DeprecationMiddleware {
+	return middleware.NewDeprecationMiddleware(cfg.API.V1DeprecatedAt, cfg.API.V1Sunset)
+}
+
+// Custom provider for Cart Service
+func NewCartService(cfg *config.Config, carts repository.CartRepository, products repository.ProductRepository, logger *zap.Logger) services.CartService {
+	return services.NewCartService(carts, products, cfg.Cart.GuestTTL, logger.Named("cart"))
+}
+
+// Custom provider for Segment Service
+func NewSegmentService(cfg *config.Config, segments repository.SegmentRepository, logger *zap.Logger) services.SegmentService {
+	return services.NewSegmentService(segments, models.SegmentRules{
+		NewWindow:   cfg.Segment.NewWindow,
+		LapsedAfter: cfg.Segment.LapsedAfter,
+		VIPSpend:    models.Cents(int64(math.Round(cfg.Segment.VIPSpend * 100))),
+		VIPWindow:   cfg.Segment.VIPWindow,
+	}, logger.Named("segment"))
+}
+
+// Custom provider for Order Lookup tokens
+func NewOrderLookup(cfg *config.Config) *middleware.OrderLookup {
+	return middleware.NewOrderLookup(cfg.API.OrderLookupSecret, cfg.API.OrderLookupTTL)
+}
+
+// Custom provider for Status Handler; clients may cache the status as long as the server does
+func NewStatusHandler(cfg *config.Config, statusService services.StatusService) *handler.StatusHandler {
+	return handler.NewStatusHandler(statusService, cfg.Server.StatusCacheTTL)
 }
 
 // Custom provider for Router
-func NewRouter(
-	productHandler *handler.ProductHandler,
-	orderHandler *handler.OrderHandler,
-	authMiddleware gin.HandlerFunc,
-	errorMiddleware []gin.HandlerFunc,
-	rateLimitMiddleware *middleware.RateLimitMiddleware,
-) *gin.Engine {
-	return router.SetupRouter(
-		productHandler,
-		orderHandler,
-		authMiddleware,
-		errorMiddleware,
-		rateLimitMiddleware,
-	)
+func NewRouter(cfg *config.Config, deps router.Deps) (*gin.Engine, error) {
+	if err := jsoncodec.Use(cfg.Server.JSONCodec); err != nil {
+		return nil, fmt.Errorf("invalid SERVER_JSON_CODEC: %w", err)
+	}
+
+	return router.SetupRouter(deps), nil
 }
 
 // Custom provider for Order Worker
-func NewOrderWorker(queueService services.OrderQueueService) *worker.OrderWorker {
-	return worker.NewOrderWorker(queueService, 5*time.Second, 10) // Process every 5 seconds, batch size 10
+func NewOrderWorker(cfg *config.Config, registry *config.Registry, queueService services.OrderQueueService, alerter services.Alerter) *worker.OrderWorker {
+	orderWorker := worker.NewOrderWorker(queueService, alerter, cfg.Worker.Interval, cfg.Worker.BatchSize, worker.BacklogThresholds{
+		Pending:       cfg.Alert.QueueBacklog,
+		OldestAge:     cfg.Alert.QueueOldest,
+		FailureRate:   cfg.Alert.QueueFailureRate,
+		FailureWindow: cfg.Alert.QueueFailureWindow,
+	})
+
+	registry.Subscribe(func(cfg *config.Config) {
+		orderWorker.SetBatchSize(cfg.Worker.BatchSize)
+	})
+
+	return orderWorker
+}
+
+// Custom provider for Outbox Relay
+func NewOutboxRelay(cfg *config.Config, outboxRepo repository.OutboxRepository, publisher services.EventPublisher) *worker.OutboxRelay {
+	return worker.NewOutboxRelay(outboxRepo, publisher, cfg.Outbox.EventSource, cfg.Outbox.Interval, cfg.Outbox.BatchSize, worker.OutboxRetries{
+		MaxAttempts: cfg.Outbox.MaxAttempts,
+		Backoff:     cfg.Outbox.RetryBackoff,
+	})
+}
+
+// Custom provider for Notification Worker
+func NewNotificationWorker(cfg *config.Config, notifications services.NotificationService) *worker.NotificationWorker {
+	return worker.NewNotificationWorker(notifications, cfg.Notification.WorkerInterval, cfg.Notification.WorkerBatchSize)
+}
+
+// Custom provider for Webhook Worker
+func NewWebhookWorker(cfg *config.Config, webhooks services.WebhookService) *worker.WebhookWorker {
+	return worker.NewWebhookWorker(webhooks, cfg.Webhook.Interval, cfg.Webhook.BatchSize)
+}
+
+// scheduledJobs registers the jobs a provider returns with the scheduler
+func scheduledJobs(provider any) any {
+	return fx.Annotate(provider, fx.ResultTags(`group:"jobs,flatten"`))
+}
+
+// Custom provider for the scheduler's store; the memory Redis driver keeps the locks and
+// runs in this process
+func NewSchedulerStore(cfg *config.Config, redisClient redis.UniversalClient) scheduler.Store {
+	if cfg.Redis.Driver == config.DriverMemory {
+		return scheduler.NewMemoryStore()
+	}
+	return scheduler.NewRedisStore(redisClient)
+}
+
+// Custom provider for the Scheduler, running the jobs registered by the modules
+func NewScheduler(cfg *config.Config, store scheduler.Store, logger *zap.Logger, jobs []scheduler.Job) (*scheduler.Scheduler, error) {
+	location, err := time.LoadLocation(cfg.Scheduler.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SCHEDULER_TIME_ZONE: %w", err)
+	}
+	schedules := make(map[string]scheduler.Schedule, len(cfg.Scheduler.Schedules))
+	for job, spec := range cfg.Scheduler.Schedules {
+		if schedules[job], err = scheduler.Parse(spec, location); err != nil {
+			return nil, fmt.Errorf("invalid SCHEDULER_SCHEDULES for %s: %w", job, err)
+		}
+	}
+
+	jobScheduler, err := scheduler.New(store, scheduler.Options{
+		Instance:  cfg.Worker.InstanceID,
+		LockTTL:   cfg.Scheduler.LockTTL,
+		Schedules: schedules,
+	}, logger.Named("scheduler"), jobs...)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SCHEDULER_SCHEDULES: %w", err)
+	}
+	return jobScheduler, nil
+}
+
+// Coupon files are held in memory, so every instance downloads them at startup and
+// refreshes its own copy every COUPON_REFRESH_INTERVAL
+func NewCouponJobs(cfg *config.Config, coupons services.CouponService) []scheduler.Job {
+	return []scheduler.Job{{
+		Name:       "coupons",
+		Schedule:   scheduler.Every(cfg.Coupon.RefreshInterval),
+		RunAtStart: true,
+		Local:      true,
+		Run:        coupons.DownloadAndParseCouponFiles,
+	}}
+}
+
+func NewMenuImportJobs(cfg *config.Config, menuImport services.MenuImportService) []scheduler.Job {
+	if cfg.MenuImport.Provider == config.ProviderNone {
+		return nil
+	}
+	return []scheduler.Job{{
+		Name:     "menu-import",
+		Schedule: scheduler.Every(cfg.MenuImport.Interval),
+		Run: func(ctx context.Context) error {
+			_, err := menuImport.Import(ctx)
+			return err
+		},
+	}}
+}
+
+func NewCartJobs(cfg *config.Config, carts services.CartService) []scheduler.Job {
+	return []scheduler.Job{{
+		Name:     "cart-cleanup",
+		Schedule: scheduler.Every(cfg.Cart.CleanupInterval),
+		Run:      carts.DeleteExpired,
+	}}
+}
+
+// Expired reservations no longer hold stock, so deleting them only keeps the table small
+func NewInventoryJobs(cfg *config.Config, inventory services.InventoryService) []scheduler.Job {
+	return []scheduler.Job{{
+		Name:     "stock-reservations",
+		Schedule: scheduler.Every(cfg.Inventory.ReservationTTL),
+		Run:      inventory.DeleteExpiredReservations,
+	}}
+}
+
+// Segments are refreshed at startup too, unless SEGMENT_REFRESH_INTERVAL disables them
+func NewSegmentJobs(cfg *config.Config, segments services.SegmentService) []scheduler.Job {
+	return []scheduler.Job{{
+		Name:       "segments",
+		Schedule:   scheduler.Every(cfg.Segment.RefreshInterval),
+		RunAtStart: cfg.Segment.RefreshInterval > 0,
+		Run:        segments.Refresh,
+	}}
+}
+
+// The digest of the day before is sent every day at DIGEST_HOUR in DIGEST_TIME_ZONE
+func NewSalesDigestJobs(cfg *config.Config, digest services.SalesDigestService) ([]scheduler.Job, error) {
+	if !digest.Enabled() {
+		return nil, nil
+	}
+	location, err := time.LoadLocation(cfg.Digest.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DIGEST_TIME_ZONE: %w", err)
+	}
+	schedule, err := scheduler.Parse(fmt.Sprintf("0 %d * * *", cfg.Digest.Hour), location)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DIGEST_HOUR: %w", err)
+	}
+	return []scheduler.Job{{
+		Name:     "sales-digest",
+		Schedule: schedule,
+		Run: func(ctx context.Context) error {
+			return digest.SendDay(ctx, time.Now().In(location).AddDate(0, 0, -1))
+		},
+	}}, nil
+}
+
+// Orders changed within RECONCILE_WINDOW are checked every RECONCILE_INTERVAL
+func NewReconciliationJobs(cfg *config.Config, reconciliation services.ReconciliationService, logger *zap.Logger) []scheduler.Job {
+	return []scheduler.Job{{
+		Name:     "reconciliation",
+		Schedule: scheduler.Every(cfg.Reconcile.Interval),
+		Run: func(ctx context.Context) error {
+			result, err := reconciliation.Reconcile(ctx, time.Now().Add(-cfg.Reconcile.Window))
+			if err != nil {
+				return err
+			}
+			if result.Found > 0 {
+				logger.Named("reconcile").Warn("Orders don't add up", zap.Int("checked", result.Checked), zap.Int("discrepancies", result.Found))
+			}
+			return nil
+		},
+	}}
 }
 
 // Application Modules
 var AppModule = fx.Options(
 	ConfigModule,
+	LoggerModule,
 	DatabaseModule,
+	StorageModule,
 	RepositoryModule,
 	ServiceModule,
 	HandlerModule,
 	MiddlewareModule,
 	WorkerModule,
+	SchedulerModule,
 	RouterModule,
 )
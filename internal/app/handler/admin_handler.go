@@ -0,0 +1,496 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"oolio/internal/app/middleware"
+	"oolio/internal/app/models"
+	"oolio/internal/app/repository"
+	"oolio/internal/app/services"
+	"oolio/internal/config"
+	"oolio/internal/database"
+	"oolio/internal/workerpool"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// defaultDeletedWindow is how far back the deleted listings look without ?deleted_since=
+const defaultDeletedWindow = 30 * 24 * time.Hour
+
+type AdminHandler struct {
+	logLevel       zap.AtomicLevel
+	couponService  services.CouponService
+	productService services.ProductService
+	imageService   services.ProductImageService
+	menuImport     services.MenuImportService
+	digest         services.SalesDigestService
+	db             *database.Database
+	registry       *config.Registry
+	pools          *workerpool.Registry
+}
+
+func NewAdminHandler(logLevel zap.AtomicLevel, couponService services.CouponService, productService services.ProductService, imageService services.ProductImageService, menuImport services.MenuImportService, digest services.SalesDigestService, db *database.Database, registry *config.Registry, pools *workerpool.Registry) *AdminHandler {
+	return &AdminHandler{
+		logLevel:       logLevel,
+		couponService:  couponService,
+		productService: productService,
+		imageService:   imageService,
+		menuImport:     menuImport,
+		digest:         digest,
+		db:             db,
+		registry:       registry,
+		pools:          pools,
+	}
+}
+
+func (h *AdminHandler) GetLogLevel(c *gin.Context) {
+	c.JSON(http.StatusOK, models.LogLevelReq{
+		Level: h.logLevel.Level().String(),
+	})
+}
+
+func (h *AdminHandler) SetLogLevel(c *gin.Context) {
+	var req models.LogLevelReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Code:    http.StatusBadRequest,
+			Type:    "error",
+			Message: "Invalid request format",
+		})
+		return
+	}
+
+	level, err := zapcore.ParseLevel(req.Level)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Code:    http.StatusBadRequest,
+			Type:    "error",
+			Message: "Invalid log level",
+		})
+		return
+	}
+
+	h.logLevel.SetLevel(level)
+
+	c.JSON(http.StatusOK, models.LogLevelReq{
+		Level: h.logLevel.Level().String(),
+	})
+}
+
+func (h *AdminHandler) GetCouponStats(c *gin.Context) {
+	c.JSON(http.StatusOK, h.couponService.GetStats())
+}
+
+// RefreshCoupons downloads and parses the coupon files now instead of waiting for the
+// periodic refresh, and responds with the resulting stats
+func (h *AdminHandler) RefreshCoupons(c *gin.Context) {
+	if err := h.couponService.DownloadAndParseCouponFiles(c.Request.Context()); err != nil {
+		c.JSON(http.StatusBadGateway, models.ApiResponse{
+			Code:    http.StatusBadGateway,
+			Type:    "error",
+			Message: "Failed to refresh coupons: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, h.couponService.GetStats())
+}
+
+// ImportMenu reconciles the products with the external menu now instead of waiting for the
+// scheduled import
+func (h *AdminHandler) ImportMenu(c *gin.Context) {
+	result, err := h.menuImport.Import(c.Request.Context())
+	switch {
+	case errors.Is(err, services.ErrMenuImportDisabled):
+		c.JSON(http.StatusNotImplemented, models.ApiResponse{
+			Code:    http.StatusNotImplemented,
+			Type:    "error",
+			Message: "Menu import is not configured",
+		})
+	case errors.Is(err, services.ErrMenuImportInProgress):
+		c.JSON(http.StatusConflict, models.ApiResponse{
+			Code:    http.StatusConflict,
+			Type:    "error",
+			Message: "A menu import is already running",
+		})
+	case err != nil:
+		c.JSON(http.StatusBadGateway, models.ApiResponse{
+			Code:    http.StatusBadGateway,
+			Type:    "error",
+			Message: "Failed to import menu: " + err.Error(),
+		})
+	default:
+		c.JSON(http.StatusOK, result)
+	}
+}
+
+func (h *AdminHandler) GetDatabaseStats(c *gin.Context) {
+	c.JSON(http.StatusOK, struct {
+		database.PoolStats
+		Retries database.RetryStats   `json:"retries"`
+		Breaker database.BreakerStats `json:"breaker"`
+	}{
+		PoolStats: h.db.Stats(),
+		Retries:   h.db.Retrier.Stats(),
+		Breaker:   h.db.Retrier.Breaker.Stats(),
+	})
+}
+
+// GetWorkerStats reports how busy each background worker pool is
+func (h *AdminHandler) GetWorkerStats(c *gin.Context) {
+	c.JSON(http.StatusOK, h.pools.Stats())
+}
+
+func (h *AdminHandler) GetConfig(c *gin.Context) {
+	c.JSON(http.StatusOK, h.registry.Current().Redacted())
+}
+
+// CreateProduct adds a product to the menu of the caller's store
+func (h *AdminHandler) CreateProduct(c *gin.Context) {
+	var req models.ProductReq
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	product := &models.Product{
+		Name:     req.Name,
+		Price:    req.Price,
+		Category: req.Category,
+		Image:    req.Image,
+		StoreID:  middleware.StoreID(c),
+
+		Variants:  req.Variants,
+		Modifiers: req.Modifiers,
+	}
+	if err := h.productService.CreateProduct(c.Request.Context(), product); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Code:    http.StatusInternalServerError,
+			Type:    "error",
+			Message: "Failed to create product",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, product)
+}
+
+// UpdateProduct replaces a product's details, as long as it is still at the version the
+// client sent
+func (h *AdminHandler) UpdateProduct(c *gin.Context) {
+	var req models.ProductUpdateReq
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	product := &models.Product{
+		ID:       c.Param("productId"),
+		Name:     req.Name,
+		Price:    req.Price,
+		Category: req.Category,
+		Image:    req.Image,
+		Version:  req.Version,
+
+		Variants:  req.Variants,
+		Modifiers: req.Modifiers,
+	}
+	if err := h.productService.UpdateProduct(c.Request.Context(), product); err != nil {
+		var conflict *repository.ConflictError
+		status, message := http.StatusInternalServerError, "Failed to update product"
+		switch {
+		case errors.As(err, &conflict):
+			status, message = http.StatusConflict, "Product was changed since version "+strconv.Itoa(req.Version)
+		case strings.Contains(err.Error(), "not found") || strings.Contains(err.Error(), "invalid product ID"):
+			status, message = http.StatusNotFound, "Product not found"
+		}
+
+		c.JSON(status, models.ApiResponse{
+			Code:    status,
+			Type:    "error",
+			Message: message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, product)
+}
+
+// DeleteProduct takes a product off the menu. It stays restorable from
+// /admin/products/deleted.
+func (h *AdminHandler) DeleteProduct(c *gin.Context) {
+	err := h.productService.DeleteProduct(c.Request.Context(), c.Param("productId"))
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") || strings.Contains(err.Error(), "invalid product ID") {
+			c.JSON(http.StatusNotFound, models.ApiResponse{
+				Code:    http.StatusNotFound,
+				Type:    "error",
+				Message: "Product not found",
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Code:    http.StatusInternalServerError,
+			Type:    "error",
+			Message: "Failed to delete product",
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *AdminHandler) ListDeletedProducts(c *gin.Context) {
+	since, ok := parseDeletedSince(c)
+	if !ok {
+		return
+	}
+
+	products, err := h.productService.GetDeletedProducts(c.Request.Context(), since)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Code:    http.StatusInternalServerError,
+			Type:    "error",
+			Message: "Failed to get deleted products",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, products)
+}
+
+func (h *AdminHandler) RestoreProduct(c *gin.Context) {
+	err := h.productService.RestoreProduct(c.Request.Context(), c.Param("productId"))
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") || strings.Contains(err.Error(), "invalid product ID") {
+			c.JSON(http.StatusNotFound, models.ApiResponse{
+				Code:    http.StatusNotFound,
+				Type:    "error",
+				Message: "Deleted product not found",
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Code:    http.StatusInternalServerError,
+			Type:    "error",
+			Message: "Failed to restore product",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Code:    http.StatusOK,
+		Type:    "success",
+		Message: "Product restored",
+	})
+}
+
+// UploadProductImage replaces the product's images with the multipart file in "image"
+func (h *AdminHandler) UploadProductImage(c *gin.Context) {
+	// Leave room for the multipart envelope around the image
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, services.MaxProductImageBytes+64<<10)
+
+	file, err := c.FormFile("image")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Code:    http.StatusBadRequest,
+			Type:    "error",
+			Message: "Expected a multipart form with an image file",
+		})
+		return
+	}
+	if file.Size > services.MaxProductImageBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, models.ApiResponse{
+			Code:    http.StatusRequestEntityTooLarge,
+			Type:    "error",
+			Message: services.ErrImageTooLarge.Error(),
+		})
+		return
+	}
+
+	image, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Code:    http.StatusBadRequest,
+			Type:    "error",
+			Message: "Failed to read image",
+		})
+		return
+	}
+	defer image.Close()
+
+	product, err := h.imageService.Upload(c.Request.Context(), c.Param("productId"), image)
+	if err != nil {
+		status, message := http.StatusInternalServerError, "Failed to upload image"
+		switch {
+		case errors.Is(err, services.ErrUnsupportedImage):
+			status, message = http.StatusUnsupportedMediaType, err.Error()
+		case errors.Is(err, services.ErrImageTooLarge), errors.Is(err, services.ErrImageTooManyPixels):
+			status, message = http.StatusRequestEntityTooLarge, err.Error()
+		case strings.Contains(err.Error(), "not found") || strings.Contains(err.Error(), "invalid product ID"):
+			status, message = http.StatusNotFound, "Product not found"
+		}
+
+		c.JSON(status, models.ApiResponse{
+			Code:    status,
+			Type:    "error",
+			Message: message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, product)
+}
+
+func (h *AdminHandler) DeleteCoupon(c *gin.Context) {
+	err := h.couponService.DeleteCoupon(c.Request.Context(), c.Param("code"))
+	if err != nil {
+		respondCouponStoreError(c, err, "Coupon not found", "Failed to delete coupon")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Code:    http.StatusOK,
+		Type:    "success",
+		Message: "Coupon deleted",
+	})
+}
+
+func (h *AdminHandler) ListDeletedCoupons(c *gin.Context) {
+	since, ok := parseDeletedSince(c)
+	if !ok {
+		return
+	}
+
+	coupons, err := h.couponService.GetDeletedCoupons(c.Request.Context(), since)
+	if err != nil {
+		respondCouponStoreError(c, err, "", "Failed to get deleted coupons")
+		return
+	}
+
+	c.JSON(http.StatusOK, coupons)
+}
+
+func (h *AdminHandler) RestoreCoupon(c *gin.Context) {
+	err := h.couponService.RestoreCoupon(c.Request.Context(), c.Param("code"))
+	if err != nil {
+		respondCouponStoreError(c, err, "Deleted coupon not found", "Failed to restore coupon")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Code:    http.StatusOK,
+		Type:    "success",
+		Message: "Coupon restored",
+	})
+}
+
+// parseDeletedSince reads the optional ?deleted_since= RFC 3339 timestamp, defaulting to
+// the last 30 days. It writes the 400 response itself when the value is invalid.
+func parseDeletedSince(c *gin.Context) (time.Time, bool) {
+	value := c.Query("deleted_since")
+	if value == "" {
+		return time.Now().Add(-defaultDeletedWindow), true
+	}
+
+	since, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Code:    http.StatusBadRequest,
+			Type:    "error",
+			Message: "Invalid deleted_since, expected an RFC 3339 timestamp",
+		})
+		return time.Time{}, false
+	}
+	return since, true
+}
+
+func respondCouponStoreError(c *gin.Context, err error, notFoundMessage, failedMessage string) {
+	switch {
+	case errors.Is(err, services.ErrNoCouponStore):
+		c.JSON(http.StatusNotImplemented, models.ApiResponse{
+			Code:    http.StatusNotImplemented,
+			Type:    "error",
+			Message: "Stored coupons require the postgres database driver",
+		})
+	case notFoundMessage != "" && strings.Contains(err.Error(), "not found"):
+		c.JSON(http.StatusNotFound, models.ApiResponse{
+			Code:    http.StatusNotFound,
+			Type:    "error",
+			Message: notFoundMessage,
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Code:    http.StatusInternalServerError,
+			Type:    "error",
+			Message: failedMessage,
+		})
+	}
+}
+
+// SalesDigest builds the sales digest of ?date=, e.g. 2026-10-15, defaulting to yesterday,
+// without sending it
+func (h *AdminHandler) SalesDigest(c *gin.Context) {
+	digest, ok := h.buildDigest(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, digest)
+}
+
+// SendSalesDigest sends the sales digest of ?date= now, e.g. again after a failed delivery
+func (h *AdminHandler) SendSalesDigest(c *gin.Context) {
+	digest, ok := h.buildDigest(c)
+	if !ok {
+		return
+	}
+
+	if err := h.digest.Send(c.Request.Context(), digest); err != nil {
+		status, message := http.StatusBadGateway, "Failed to send sales digest: "+err.Error()
+		if errors.Is(err, services.ErrSalesDigestDisabled) {
+			status, message = http.StatusNotImplemented, "The sales digest is not configured"
+		}
+		c.JSON(status, models.ApiResponse{
+			Code:    status,
+			Type:    "error",
+			Message: message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, digest)
+}
+
+func (h *AdminHandler) buildDigest(c *gin.Context) (*models.SalesDigest, bool) {
+	date := time.Now().AddDate(0, 0, -1)
+	if value := c.Query("date"); value != "" {
+		parsed, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ApiResponse{
+				Code:    http.StatusBadRequest,
+				Type:    "error",
+				Message: "Invalid date, expected YYYY-MM-DD",
+			})
+			return nil, false
+		}
+		date = parsed
+	}
+
+	digest, err := h.digest.Build(c.Request.Context(), date)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Code:    http.StatusInternalServerError,
+			Type:    "error",
+			Message: "Failed to build sales digest",
+		})
+		return nil, false
+	}
+	return digest, true
+}
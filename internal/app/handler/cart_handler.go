@@ -0,0 +1,162 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"oolio/internal/app/middleware"
+	"oolio/internal/app/models"
+	"oolio/internal/app/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CartHandler serves the cart of the customer named in X-Customer-ID, or for guests the
+// cart named in X-Cart-Token
+type CartHandler struct {
+	cartService services.CartService
+	orders      *OrderHandler
+}
+
+// NewCartHandler returns the handler; checkout places orders the way orders does
+func NewCartHandler(cartService services.CartService, orders *OrderHandler) *CartHandler {
+	return &CartHandler{
+		cartService: cartService,
+		orders:      orders,
+	}
+}
+
+func (h *CartHandler) GetCart(c *gin.Context) {
+	cart, err := h.cartService.GetCart(c.Request.Context(), cartOwner(c))
+	if err != nil {
+		respondCartError(c, err, "Failed to get cart")
+		return
+	}
+
+	c.JSON(http.StatusOK, cart)
+}
+
+func (h *CartHandler) AddItem(c *gin.Context) {
+	var req models.CartItemReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Code:    http.StatusBadRequest,
+			Type:    "error",
+			Message: "Invalid request format",
+		})
+		return
+	}
+
+	cart, err := h.cartService.AddItem(c.Request.Context(), cartOwner(c), req)
+	if err != nil {
+		respondCartError(c, err, "Failed to add item")
+		return
+	}
+
+	c.JSON(http.StatusOK, cart)
+}
+
+func (h *CartHandler) UpdateItem(c *gin.Context) {
+	var req models.CartQuantityReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Code:    http.StatusBadRequest,
+			Type:    "error",
+			Message: "Invalid request format",
+		})
+		return
+	}
+
+	cart, err := h.cartService.SetQuantity(c.Request.Context(), cartOwner(c), c.Param("productId"), req.Quantity)
+	if err != nil {
+		respondCartError(c, err, "Failed to update item")
+		return
+	}
+
+	c.JSON(http.StatusOK, cart)
+}
+
+func (h *CartHandler) RemoveItem(c *gin.Context) {
+	cart, err := h.cartService.RemoveItem(c.Request.Context(), cartOwner(c), c.Param("productId"))
+	if err != nil {
+		respondCartError(c, err, "Failed to remove item")
+		return
+	}
+
+	c.JSON(http.StatusOK, cart)
+}
+
+func (h *CartHandler) ClearCart(c *gin.Context) {
+	if err := h.cartService.Clear(c.Request.Context(), cartOwner(c)); err != nil {
+		respondCartError(c, err, "Failed to clear cart")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Code:    http.StatusOK,
+		Type:    "success",
+		Message: "Cart cleared",
+	})
+}
+
+// Checkout places the cart's items as an order and empties the cart once it is queued
+func (h *CartHandler) Checkout(c *gin.Context) {
+	var req models.CheckoutReq
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, models.ApiResponse{
+				Code:    http.StatusBadRequest,
+				Type:    "error",
+				Message: "Invalid request format",
+			})
+			return
+		}
+	}
+
+	owner := cartOwner(c)
+	orderReq, err := h.cartService.CheckoutOrder(c.Request.Context(), owner, req)
+	if err != nil {
+		respondCartError(c, err, "Failed to check out cart")
+		return
+	}
+
+	if !h.orders.queueOrder(c, orderReq) {
+		return
+	}
+	// The order is already queued, so a cart left full is only an inconvenience
+	_ = h.cartService.Clear(c.Request.Context(), owner)
+}
+
+func cartOwner(c *gin.Context) services.CartOwner {
+	return services.CartOwner{
+		CustomerID: middleware.CustomerID(c),
+		Token:      strings.TrimSpace(c.GetHeader(middleware.CartTokenHeader)),
+	}
+}
+
+func respondCartError(c *gin.Context, err error, failedMessage string) {
+	status, message := http.StatusInternalServerError, failedMessage
+	switch {
+	case errors.Is(err, services.ErrInvalidCartItem):
+		status, message = http.StatusBadRequest, err.Error()
+	case errors.Is(err, services.ErrCartFull):
+		status, message = http.StatusConflict, err.Error()
+	case errors.Is(err, services.ErrCartEmpty):
+		status, message = http.StatusUnprocessableEntity, "Cart is empty"
+	case errors.Is(err, services.ErrInvalidOption):
+		status, message = http.StatusUnprocessableEntity, err.Error()
+	case strings.Contains(err.Error(), "product not found") || strings.Contains(err.Error(), "invalid product ID"):
+		status, message = http.StatusNotFound, "Product not found"
+	case strings.Contains(err.Error(), "cart item not found"):
+		status, message = http.StatusNotFound, "Product is not in the cart"
+	case strings.Contains(err.Error(), "cart not found") || strings.Contains(err.Error(), "invalid cart ID"):
+		status, message = http.StatusNotFound, "Cart not found or expired"
+	}
+
+	c.JSON(status, models.ApiResponse{
+		Code:    status,
+		Type:    "error",
+		Message: message,
+	})
+}
@@ -0,0 +1,181 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"oolio/internal/app/middleware"
+	"oolio/internal/app/models"
+	"oolio/internal/app/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CustomerHandler serves the /customer/me routes for the customer named in the
+// X-Customer-ID header
+type CustomerHandler struct {
+	addressService  services.AddressService
+	favoriteService services.FavoriteService
+}
+
+func NewCustomerHandler(addressService services.AddressService, favoriteService services.FavoriteService) *CustomerHandler {
+	return &CustomerHandler{
+		addressService:  addressService,
+		favoriteService: favoriteService,
+	}
+}
+
+func (h *CustomerHandler) ListAddresses(c *gin.Context) {
+	addresses, err := h.addressService.ListAddresses(c.Request.Context(), middleware.CustomerID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Code:    http.StatusInternalServerError,
+			Type:    "error",
+			Message: "Failed to list addresses",
+		})
+		return
+	}
+	if addresses == nil {
+		addresses = []models.Address{}
+	}
+
+	c.JSON(http.StatusOK, addresses)
+}
+
+func (h *CustomerHandler) CreateAddress(c *gin.Context) {
+	var req models.AddressReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Code:    http.StatusBadRequest,
+			Type:    "error",
+			Message: "Invalid request format",
+		})
+		return
+	}
+
+	address, err := h.addressService.AddAddress(c.Request.Context(), middleware.CustomerID(c), req)
+	if err != nil {
+		status, message := http.StatusInternalServerError, "Failed to add address"
+		switch {
+		case errors.Is(err, services.ErrInvalidAddress):
+			status, message = http.StatusBadRequest, err.Error()
+		case errors.Is(err, services.ErrAddressBookFull):
+			status, message = http.StatusConflict, err.Error()
+		}
+
+		c.JSON(status, models.ApiResponse{
+			Code:    status,
+			Type:    "error",
+			Message: message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, address)
+}
+
+func (h *CustomerHandler) SetDefaultAddress(c *gin.Context) {
+	err := h.addressService.SetDefaultAddress(c.Request.Context(), middleware.CustomerID(c), c.Param("addressId"))
+	if err != nil {
+		respondAddressError(c, err, "Failed to set default address")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Code:    http.StatusOK,
+		Type:    "success",
+		Message: "Default address updated",
+	})
+}
+
+func (h *CustomerHandler) DeleteAddress(c *gin.Context) {
+	err := h.addressService.DeleteAddress(c.Request.Context(), middleware.CustomerID(c), c.Param("addressId"))
+	if err != nil {
+		respondAddressError(c, err, "Failed to delete address")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Code:    http.StatusOK,
+		Type:    "success",
+		Message: "Address deleted",
+	})
+}
+
+func (h *CustomerHandler) ListFavorites(c *gin.Context) {
+	favorites, err := h.favoriteService.ListFavorites(c.Request.Context(), middleware.CustomerID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Code:    http.StatusInternalServerError,
+			Type:    "error",
+			Message: "Failed to list favorites",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, favorites)
+}
+
+func (h *CustomerHandler) AddFavorite(c *gin.Context) {
+	err := h.favoriteService.AddFavorite(c.Request.Context(), middleware.CustomerID(c), c.Param("productId"))
+	if err != nil {
+		status, message := http.StatusInternalServerError, "Failed to add favorite"
+		switch {
+		case errors.Is(err, services.ErrFavoritesFull):
+			status, message = http.StatusConflict, err.Error()
+		case strings.Contains(err.Error(), "not found") || strings.Contains(err.Error(), "invalid product ID"):
+			status, message = http.StatusNotFound, "Product not found"
+		}
+
+		c.JSON(status, models.ApiResponse{
+			Code:    status,
+			Type:    "error",
+			Message: message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Code:    http.StatusOK,
+		Type:    "success",
+		Message: "Favorite added",
+	})
+}
+
+func (h *CustomerHandler) RemoveFavorite(c *gin.Context) {
+	err := h.favoriteService.RemoveFavorite(c.Request.Context(), middleware.CustomerID(c), c.Param("productId"))
+	if err != nil {
+		status, message := http.StatusInternalServerError, "Failed to remove favorite"
+		if strings.Contains(err.Error(), "not found") || strings.Contains(err.Error(), "invalid product ID") {
+			status, message = http.StatusNotFound, "Favorite not found"
+		}
+
+		c.JSON(status, models.ApiResponse{
+			Code:    status,
+			Type:    "error",
+			Message: message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Code:    http.StatusOK,
+		Type:    "success",
+		Message: "Favorite removed",
+	})
+}
+
+// respondAddressError answers 404 for addresses that don't exist or belong to another customer
+func respondAddressError(c *gin.Context, err error, failedMessage string) {
+	status, message := http.StatusInternalServerError, failedMessage
+	if strings.Contains(err.Error(), "not found") || strings.Contains(err.Error(), "invalid address ID") {
+		status, message = http.StatusNotFound, "Address not found"
+	}
+
+	c.JSON(status, models.ApiResponse{
+		Code:    status,
+		Type:    "error",
+		Message: message,
+	})
+}
@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"oolio/internal/app/models"
+	"oolio/internal/app/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DeliveryHandler takes the delivery provider's status updates and lets admins call
+// couriers off
+type DeliveryHandler struct {
+	deliveries services.DeliveryService
+	orders     services.OrderService
+}
+
+// NewDeliveryHandler returns the handler; without deliveries its routes answer 404
+func NewDeliveryHandler(deliveries services.DeliveryService, orders services.OrderService) *DeliveryHandler {
+	return &DeliveryHandler{deliveries: deliveries, orders: orders}
+}
+
+// Webhook takes the webhook delivery provider's status updates. It is authenticated by
+// the signature over the body, made like the signatures of outbound webhooks, so it sits
+// outside the API key check.
+func (h *DeliveryHandler) Webhook(c *gin.Context) {
+	if h.deliveries == nil {
+		respondDeliveriesDisabled(c)
+		return
+	}
+
+	payload, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxWebhookBody))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Code:    http.StatusBadRequest,
+			Type:    "error",
+			Message: "Invalid request format",
+		})
+		return
+	}
+
+	delivery, err := h.deliveries.HandleStatus(c.Request.Context(), payload,
+		c.GetHeader(services.DeliveryHeader), c.GetHeader(services.TimestampHeader), c.GetHeader(services.SignatureHeader))
+	if err != nil {
+		status, message := http.StatusInternalServerError, "Failed to update delivery"
+		switch {
+		case errors.Is(err, services.ErrDeliverySignature) || errors.Is(err, services.ErrDeliveryPayload):
+			status, message = http.StatusBadRequest, err.Error()
+		case errors.Is(err, services.ErrDeliveryNotFound):
+			status, message = http.StatusNotFound, err.Error()
+		}
+		c.JSON(status, models.ApiResponse{
+			Code:    status,
+			Type:    "error",
+			Message: message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, delivery)
+}
+
+// Cancel calls off the courier of an order of the caller's store
+func (h *DeliveryHandler) Cancel(c *gin.Context) {
+	if h.deliveries == nil {
+		respondDeliveriesDisabled(c)
+		return
+	}
+	ctx := c.Request.Context()
+
+	order, err := h.orders.GetOrder(ctx, c.Param("orderId"))
+	if err != nil {
+		if err.Error() == "order not found" {
+			respondOrderNotFound(c)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Code:    http.StatusInternalServerError,
+			Type:    "error",
+			Message: "Failed to retrieve order",
+		})
+		return
+	}
+	if !ofCallerStore(c, order.StoreID) {
+		respondOrderNotFound(c)
+		return
+	}
+
+	delivery, err := h.deliveries.Cancel(ctx, order.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrDeliveryNotFound):
+			c.JSON(http.StatusNotFound, models.ApiResponse{
+				Code:    http.StatusNotFound,
+				Type:    "error",
+				Message: err.Error(),
+			})
+		case errors.Is(err, services.ErrDeliveryFinished):
+			c.JSON(http.StatusConflict, gin.H{
+				"code":     http.StatusConflict,
+				"type":     "error",
+				"message":  err.Error(),
+				"delivery": delivery,
+			})
+		default:
+			c.JSON(http.StatusBadGateway, models.ApiResponse{
+				Code:    http.StatusBadGateway,
+				Type:    "error",
+				Message: "Failed to cancel delivery",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, delivery)
+}
+
+func respondDeliveriesDisabled(c *gin.Context) {
+	c.JSON(http.StatusNotFound, models.ApiResponse{
+		Code:    http.StatusNotFound,
+		Type:    "error",
+		Message: "Deliveries are not enabled",
+	})
+}
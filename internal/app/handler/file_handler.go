@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"oolio/internal/app/models"
+	"oolio/internal/storage"
+)
+
+type FileHandler struct {
+	storage storage.Storage
+}
+
+func NewFileHandler(store storage.Storage) *FileHandler {
+	return &FileHandler{storage: store}
+}
+
+// ServeFile streams an object from storage, e.g. a product image. Uploads get a fresh key
+// each time, so objects never change and may be cached indefinitely.
+func (h *FileHandler) ServeFile(c *gin.Context) {
+	key := strings.TrimPrefix(c.Param("key"), "/")
+	if _, err := storage.CleanKey(key); err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Code:    http.StatusBadRequest,
+			Type:    "error",
+			Message: "Invalid file path",
+		})
+		return
+	}
+
+	file, err := h.storage.Get(c.Request.Context(), key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			c.JSON(http.StatusNotFound, models.ApiResponse{
+				Code:    http.StatusNotFound,
+				Type:    "error",
+				Message: "File not found",
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Code:    http.StatusInternalServerError,
+			Type:    "error",
+			Message: "Failed to read file",
+		})
+		return
+	}
+	defer file.Close()
+
+	contentType := mime.TypeByExtension(path.Ext(key))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	c.Header("Content-Type", contentType)
+	c.Header("Cache-Control", "public, max-age=31536000, immutable")
+	c.Status(http.StatusOK)
+	io.Copy(c.Writer, file)
+}
@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"oolio/internal/app/models"
+	"oolio/internal/app/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GiftCardHandler serves the admin routes that issue gift cards and the balance lookup
+// for customers holding one
+type GiftCardHandler struct {
+	giftCards services.GiftCardService
+}
+
+func NewGiftCardHandler(giftCards services.GiftCardService) *GiftCardHandler {
+	return &GiftCardHandler{giftCards: giftCards}
+}
+
+// Issue is admin-only
+func (h *GiftCardHandler) Issue(c *gin.Context) {
+	var req models.GiftCardReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Code:    http.StatusBadRequest,
+			Type:    "error",
+			Message: "Invalid request format",
+		})
+		return
+	}
+
+	card, err := h.giftCards.Issue(c.Request.Context(), req)
+	if err != nil {
+		status, message := http.StatusInternalServerError, "Failed to issue gift card"
+		switch {
+		case errors.Is(err, services.ErrInvalidGiftCard):
+			status, message = http.StatusBadRequest, err.Error()
+		case err.Error() == "gift card already exists":
+			status, message = http.StatusConflict, "Gift card "+services.NormalizeGiftCardCode(req.Code)+" already exists"
+		}
+		c.JSON(status, models.ApiResponse{
+			Code:    status,
+			Type:    "error",
+			Message: message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, card)
+}
+
+// Get is admin-only, showing what the card was issued with alongside its balance
+func (h *GiftCardHandler) Get(c *gin.Context) {
+	card, ok := h.find(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, card)
+}
+
+// Balance tells whoever holds the code what is left on the card
+func (h *GiftCardHandler) Balance(c *gin.Context) {
+	card, ok := h.find(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, models.GiftCardBalance{
+		Code:      card.Code,
+		Balance:   card.Balance,
+		ExpiresAt: card.ExpiresAt,
+	})
+}
+
+// find looks up the card named in the path, and responds itself when it can't
+func (h *GiftCardHandler) find(c *gin.Context) (*models.GiftCard, bool) {
+	card, err := h.giftCards.Get(c.Request.Context(), c.Param("code"))
+	if err != nil {
+		status, message := http.StatusInternalServerError, "Failed to get gift card"
+		if errors.Is(err, services.ErrGiftCardNotFound) {
+			status, message = http.StatusNotFound, "Gift card not found"
+		}
+		c.JSON(status, models.ApiResponse{
+			Code:    status,
+			Type:    "error",
+			Message: message,
+		})
+		return nil, false
+	}
+	return card, true
+}
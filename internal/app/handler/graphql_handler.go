@@ -0,0 +1,210 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"oolio/internal/app/middleware"
+	"oolio/internal/app/models"
+	"oolio/internal/app/services"
+	"oolio/internal/graphql"
+
+	"github.com/gin-gonic/gin"
+)
+
+// QueueStatus is the count of queued orders in each status, as the GraphQL queueStatus field
+type QueueStatus struct {
+	Pending    int `json:"pending"`
+	Processing int `json:"processing"`
+	Completed  int `json:"completed"`
+	Failed     int `json:"failed"`
+}
+
+// GraphQLHandler serves /graphql, letting clients select the fields they need from
+// products, orders and the queue status, several of them in one request
+type GraphQLHandler struct {
+	productService services.ProductService
+	orderService   services.OrderService
+	queueService   services.OrderQueueService
+}
+
+func NewGraphQLHandler(productService services.ProductService, orderService services.OrderService, queueService services.OrderQueueService) *GraphQLHandler {
+	return &GraphQLHandler{
+		productService: productService,
+		orderService:   orderService,
+		queueService:   queueService,
+	}
+}
+
+// schema is built per request, as what the resolvers return depends on the caller, such
+// as product prices on their pricing tier
+func (h *GraphQLHandler) schema(c *gin.Context) *graphql.Schema {
+	return &graphql.Schema{Query: map[string]*graphql.Field{
+		"products": {
+			Args:    map[string]string{"updatedSince": "String"},
+			Type:    reflect.TypeFor[[]models.Product](),
+			Resolve: resolveWith(c, h.products),
+		},
+		"product": {
+			Args:    map[string]string{"id": "ID!"},
+			Type:    reflect.TypeFor[*models.Product](),
+			Resolve: resolveWith(c, h.product),
+		},
+		"categories": {
+			Type:    reflect.TypeFor[[]string](),
+			Resolve: resolveWith(c, h.categories),
+		},
+		"orders": {
+			Args:    map[string]string{"limit": "Int", "offset": "Int"},
+			Type:    reflect.TypeFor[[]models.Order](),
+			Resolve: resolveWith(c, h.orders),
+		},
+		"order": {
+			Args:    map[string]string{"id": "ID!"},
+			Type:    reflect.TypeFor[*models.Order](),
+			Resolve: resolveWith(c, h.order),
+		},
+		"queueStatus": {
+			Type:    reflect.TypeFor[*QueueStatus](),
+			Resolve: resolveWith(c, h.queueStatus),
+		},
+	}}
+}
+
+// resolveWith hands a resolver the request it resolves for
+func resolveWith(c *gin.Context, resolve func(*gin.Context, map[string]any) (any, error)) func(context.Context, map[string]any) (any, error) {
+	return func(_ context.Context, args map[string]any) (any, error) {
+		return resolve(c, args)
+	}
+}
+
+// Query executes a GraphQL query. Failed fields come back null with an entry in errors,
+// alongside the rest of the data, so the response is 200 unless the body isn't a request
+func (h *GraphQLHandler) Query(c *gin.Context) {
+	var req graphql.Request
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Code:    http.StatusBadRequest,
+			Type:    "error",
+			Message: "Invalid request format, expected a JSON body with a query",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, h.schema(c).Execute(c.Request.Context(), req))
+}
+
+// products are the caller's store's menu priced on their tier, like GET /product
+func (h *GraphQLHandler) products(c *gin.Context, args map[string]any) (any, error) {
+	ctx := c.Request.Context()
+	value, ok := args["updatedSince"].(string)
+	if !ok {
+		products, err := h.productService.GetAllProducts(ctx)
+		if err != nil {
+			return nil, errors.New("failed to retrieve products")
+		}
+		return middleware.PricingTier(c).Apply(productsOfStore(products, middleware.StoreID(c))), nil
+	}
+
+	since, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, errors.New("invalid updatedSince, expected an RFC 3339 timestamp")
+	}
+	products, err := h.productService.GetProductsUpdatedSince(ctx, since)
+	if err != nil {
+		return nil, errors.New("failed to retrieve products")
+	}
+	return middleware.PricingTier(c).Apply(productsOfStore(products, middleware.StoreID(c))), nil
+}
+
+// product is null for a product that doesn't exist or is another store's
+func (h *GraphQLHandler) product(c *gin.Context, args map[string]any) (any, error) {
+	product, err := h.productService.GetProductByID(c.Request.Context(), args["id"].(string))
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "invalid product ID") || strings.Contains(err.Error(), "invalid UUID"):
+			return nil, errors.New("invalid product ID format")
+		case strings.Contains(err.Error(), "product not found"):
+			return nil, nil
+		}
+		return nil, errors.New("failed to retrieve product")
+	}
+	if storeID := middleware.StoreID(c); storeID != "" && models.StoreOrDefault(product.StoreID) != storeID {
+		return nil, nil
+	}
+	return &middleware.PricingTier(c).Apply([]models.Product{*product})[0], nil
+}
+
+// categories lists the categories of the caller's store's products, sorted
+func (h *GraphQLHandler) categories(c *gin.Context, _ map[string]any) (any, error) {
+	products, err := h.productService.GetAllProducts(c.Request.Context())
+	if err != nil {
+		return nil, errors.New("failed to retrieve categories")
+	}
+	products = productsOfStore(products, middleware.StoreID(c))
+
+	categories := make([]string, 0, len(products))
+	for _, product := range products {
+		categories = append(categories, product.Category)
+	}
+	slices.Sort(categories)
+	return slices.Compact(categories), nil
+}
+
+// orders is one page of the caller's store's orders, newest first, as ListAllOrders pages them
+func (h *GraphQLHandler) orders(c *gin.Context, args map[string]any) (any, error) {
+	page := models.PageRequest{StoreID: middleware.StoreID(c)}
+	page.Limit, _ = args["limit"].(int)
+	page.Offset, _ = args["offset"].(int)
+
+	orders, _, err := h.orderService.ListOrders(c.Request.Context(), page.Normalize())
+	if err != nil {
+		return nil, errors.New("failed to get orders")
+	}
+	return orders, nil
+}
+
+// order looks the ID up in the queue first, for recent orders, then in the orders
+// table, like GET /order/:orderId. It is null for an order that doesn't exist or is another
+// store's
+func (h *GraphQLHandler) order(c *gin.Context, args map[string]any) (any, error) {
+	ctx := c.Request.Context()
+	id := args["id"].(string)
+	queueItem, err := h.queueService.GetOrderFromQueue(ctx, id)
+	if err == nil && queueItem.Order != nil {
+		if !ofCallerStore(c, queueItem.OrderReq.StoreID) {
+			return nil, nil
+		}
+		return queueItem.Order, nil
+	}
+
+	order, err := h.orderService.GetOrder(ctx, id)
+	if err != nil {
+		if strings.Contains(err.Error(), "order not found") {
+			return nil, nil
+		}
+		return nil, errors.New("failed to retrieve order")
+	}
+	if !ofCallerStore(c, order.StoreID) {
+		return nil, nil
+	}
+	return order, nil
+}
+
+func (h *GraphQLHandler) queueStatus(c *gin.Context, _ map[string]any) (any, error) {
+	stats, err := h.queueService.GetQueueStatus(c.Request.Context())
+	if err != nil {
+		return nil, errors.New("failed to get queue status")
+	}
+	return &QueueStatus{
+		Pending:    stats["pending"],
+		Processing: stats["processing"],
+		Completed:  stats["completed"],
+		Failed:     stats["failed"],
+	}, nil
+}
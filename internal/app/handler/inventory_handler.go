@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"oolio/internal/app/models"
+	"oolio/internal/app/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InventoryHandler serves the admin routes that restock products and list those running
+// low
+type InventoryHandler struct {
+	inventory services.InventoryService
+}
+
+func NewInventoryHandler(inventory services.InventoryService) *InventoryHandler {
+	return &InventoryHandler{inventory: inventory}
+}
+
+// Restock adds units to a product's stock, starting to count it when it wasn't
+func (h *InventoryHandler) Restock(c *gin.Context) {
+	var req models.RestockReq
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	level, err := h.inventory.Restock(c.Request.Context(), c.Param("productId"), req.Quantity)
+	if err != nil {
+		status, message := http.StatusInternalServerError, "Failed to restock product"
+		if strings.Contains(err.Error(), "not found") || strings.Contains(err.Error(), "invalid product ID") {
+			status, message = http.StatusNotFound, "Product not found"
+		}
+		c.JSON(status, models.ApiResponse{
+			Code:    status,
+			Type:    "error",
+			Message: message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, level)
+}
+
+// LowStock lists the products with ?threshold= units left or fewer, INVENTORY_LOW_STOCK_THRESHOLD
+// by default, fewest first; ?store_id= narrows them to a store's menu
+func (h *InventoryHandler) LowStock(c *gin.Context) {
+	threshold := h.inventory.LowStockThreshold()
+	if value := c.Query("threshold"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			c.JSON(http.StatusBadRequest, models.ApiResponse{
+				Code:    http.StatusBadRequest,
+				Type:    "error",
+				Message: "threshold must be a whole number of 0 or more",
+			})
+			return
+		}
+		threshold = parsed
+	}
+	storeID, ok := parseReportStore(c)
+	if !ok {
+		return
+	}
+
+	levels, err := h.inventory.LowStock(c.Request.Context(), storeID, threshold)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Code:    http.StatusInternalServerError,
+			Type:    "error",
+			Message: "Failed to list low stock",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, levels)
+}
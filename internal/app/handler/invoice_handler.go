@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"oolio/internal/app/middleware"
+	"oolio/internal/app/models"
+	"oolio/internal/app/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InvoiceHandler serves the tax invoices of completed orders
+type InvoiceHandler struct {
+	invoices services.InvoiceService
+	queue    services.OrderQueueService
+}
+
+func NewInvoiceHandler(invoices services.InvoiceService, queue services.OrderQueueService) *InvoiceHandler {
+	return &InvoiceHandler{invoices: invoices, queue: queue}
+}
+
+// Get returns the invoice of an order, named by its order ID or queueItemId, issuing it
+// on first request
+func (h *InvoiceHandler) Get(c *gin.Context) {
+	ctx := c.Request.Context()
+	orderID := c.Param("orderId")
+
+	// Guests know their order by its queue item, which has no order until it is processed
+	if item, err := h.queue.GetOrderFromQueue(ctx, orderID); err == nil {
+		if !ofCallerStore(c, item.OrderReq.StoreID) {
+			respondOrderNotFound(c)
+			return
+		}
+		if item.Order == nil || item.Order.ID == "" {
+			c.JSON(http.StatusConflict, models.ApiResponse{
+				Code:    http.StatusConflict,
+				Type:    "error",
+				Message: services.ErrOrderNotInvoiceable.Error(),
+			})
+			return
+		}
+		orderID = item.Order.ID
+	}
+
+	invoice, err := h.invoices.Invoice(ctx, middleware.StoreID(c), orderID)
+	if err != nil {
+		status, message := http.StatusInternalServerError, "Failed to get invoice"
+		switch {
+		case errors.Is(err, services.ErrInvoiceOrderNotFound):
+			status, message = http.StatusNotFound, "Order not found"
+		case errors.Is(err, services.ErrOrderNotInvoiceable):
+			status, message = http.StatusConflict, err.Error()
+		}
+		c.JSON(status, models.ApiResponse{
+			Code:    status,
+			Type:    "error",
+			Message: message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, invoice)
+}
@@ -0,0 +1,34 @@
+package handler
+
+import (
+	"net/http"
+
+	"oolio/internal/app/models"
+	"oolio/internal/scheduler"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JobHandler serves the status of the scheduled jobs
+type JobHandler struct {
+	scheduler *scheduler.Scheduler
+}
+
+func NewJobHandler(jobScheduler *scheduler.Scheduler) *JobHandler {
+	return &JobHandler{scheduler: jobScheduler}
+}
+
+// List returns every scheduled job with its schedule, next run and last run
+func (h *JobHandler) List(c *gin.Context) {
+	statuses, err := h.scheduler.Status(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Code:    http.StatusInternalServerError,
+			Type:    "error",
+			Message: "Failed to read job status",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, statuses)
+}
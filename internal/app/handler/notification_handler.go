@@ -0,0 +1,311 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"oolio/internal/app/middleware"
+	"oolio/internal/app/models"
+	"oolio/internal/app/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// NotificationHandler serves customers' notification preferences, in-app feed and push
+// devices, the admin routes that tell a customer their order is ready and look after the
+// notification outbox, and the SMS provider's delivery reports
+type NotificationHandler struct {
+	notifications services.NotificationService
+	orders        services.OrderService
+}
+
+func NewNotificationHandler(notifications services.NotificationService, orders services.OrderService) *NotificationHandler {
+	return &NotificationHandler{notifications: notifications, orders: orders}
+}
+
+func (h *NotificationHandler) GetPreferences(c *gin.Context) {
+	preferences, err := h.notifications.GetPreferences(c.Request.Context(), middleware.CustomerID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Code:    http.StatusInternalServerError,
+			Type:    "error",
+			Message: "Failed to get notification preferences",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, preferences)
+}
+
+func (h *NotificationHandler) SavePreferences(c *gin.Context) {
+	var req models.NotificationPreferencesReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Code:    http.StatusBadRequest,
+			Type:    "error",
+			Message: "Invalid request format",
+		})
+		return
+	}
+
+	preferences, err := h.notifications.SavePreferences(c.Request.Context(), middleware.CustomerID(c), req)
+	if err != nil {
+		status, message := http.StatusInternalServerError, "Failed to save notification preferences"
+		if errors.Is(err, services.ErrInvalidEmail) || errors.Is(err, services.ErrInvalidPhone) {
+			status, message = http.StatusBadRequest, err.Error()
+		}
+		c.JSON(status, models.ApiResponse{
+			Code:    status,
+			Type:    "error",
+			Message: message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, preferences)
+}
+
+// Feed pages through the customer's in-app notifications with ?limit= and ?offset=, only
+// the unread ones with ?unread=true
+func (h *NotificationHandler) Feed(c *gin.Context) {
+	var query struct {
+		models.PageRequest
+		Unread bool `form:"unread"`
+	}
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Code:    http.StatusBadRequest,
+			Type:    "error",
+			Message: "Invalid limit, offset or unread",
+		})
+		return
+	}
+
+	feed, err := h.notifications.Feed(c.Request.Context(), middleware.CustomerID(c), query.Unread, query.PageRequest)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Code:    http.StatusInternalServerError,
+			Type:    "error",
+			Message: "Failed to get notifications",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, feed)
+}
+
+// MarkRead marks one of the customer's in-app notifications read
+func (h *NotificationHandler) MarkRead(c *gin.Context) {
+	err := h.notifications.MarkRead(c.Request.Context(), middleware.CustomerID(c), c.Param("notificationId"))
+	if err != nil {
+		status, message := http.StatusInternalServerError, "Failed to mark notification read"
+		if errors.Is(err, services.ErrFeedNotificationNotFound) {
+			status, message = http.StatusNotFound, "Notification not found"
+		}
+		c.JSON(status, models.ApiResponse{
+			Code:    status,
+			Type:    "error",
+			Message: message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"read": true})
+}
+
+// MarkAllRead marks all the customer's in-app notifications read
+func (h *NotificationHandler) MarkAllRead(c *gin.Context) {
+	marked, err := h.notifications.MarkAllRead(c.Request.Context(), middleware.CustomerID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Code:    http.StatusInternalServerError,
+			Type:    "error",
+			Message: "Failed to mark notifications read",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"marked": marked})
+}
+
+// RegisterDevice registers the customer's device for push notifications about their orders
+func (h *NotificationHandler) RegisterDevice(c *gin.Context) {
+	var req models.PushDeviceReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Code:    http.StatusBadRequest,
+			Type:    "error",
+			Message: "Invalid request format",
+		})
+		return
+	}
+
+	device, err := h.notifications.RegisterDevice(c.Request.Context(), middleware.CustomerID(c), req)
+	if err != nil {
+		status, message := http.StatusInternalServerError, "Failed to register device"
+		if errors.Is(err, services.ErrPushPlatformNotSupported) || errors.Is(err, services.ErrInvalidPushToken) {
+			status, message = http.StatusBadRequest, err.Error()
+		}
+		c.JSON(status, models.ApiResponse{
+			Code:    status,
+			Type:    "error",
+			Message: message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, device)
+}
+
+func (h *NotificationHandler) ListDevices(c *gin.Context) {
+	devices, err := h.notifications.ListDevices(c.Request.Context(), middleware.CustomerID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Code:    http.StatusInternalServerError,
+			Type:    "error",
+			Message: "Failed to list devices",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, devices)
+}
+
+func (h *NotificationHandler) UnregisterDevice(c *gin.Context) {
+	err := h.notifications.UnregisterDevice(c.Request.Context(), middleware.CustomerID(c), c.Param("token"))
+	if err != nil {
+		status, message := http.StatusInternalServerError, "Failed to unregister device"
+		if errors.Is(err, services.ErrPushDeviceNotFound) {
+			status, message = http.StatusNotFound, "Device not found"
+		}
+		c.JSON(status, models.ApiResponse{
+			Code:    status,
+			Type:    "error",
+			Message: message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Code:    http.StatusOK,
+		Type:    "success",
+		Message: "Device unregistered",
+	})
+}
+
+// OrderReady texts and emails the order's customer that it is ready, as they asked
+func (h *NotificationHandler) OrderReady(c *gin.Context) {
+	orderID := c.Param("orderId")
+	notFound := func() {
+		c.JSON(http.StatusNotFound, models.ApiResponse{
+			Code:    http.StatusNotFound,
+			Type:    "error",
+			Message: "Order not found",
+		})
+	}
+	if _, err := uuid.Parse(orderID); err != nil {
+		notFound()
+		return
+	}
+
+	order, err := h.orders.GetOrder(c.Request.Context(), orderID)
+	if err != nil {
+		if strings.Contains(err.Error(), "order not found") {
+			notFound()
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Code:    http.StatusInternalServerError,
+			Type:    "error",
+			Message: "Failed to retrieve order",
+		})
+		return
+	}
+
+	notified, err := h.notifications.OrderReady(c.Request.Context(), order)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, models.ApiResponse{
+			Code:    http.StatusBadGateway,
+			Type:    "error",
+			Message: "Failed to send ready alert",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"notified": notified})
+}
+
+// SMSStatus records a delivery report from the SMS provider, authenticated by its signature
+func (h *NotificationHandler) SMSStatus(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxWebhookBody)
+	if err := c.Request.ParseForm(); err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Code:    http.StatusBadRequest,
+			Type:    "error",
+			Message: "Invalid request format",
+		})
+		return
+	}
+
+	err := h.notifications.SMSStatus(c.Request.Context(), c.Request.URL.Query(), c.Request.PostForm, c.GetHeader(services.TwilioSignatureHeader))
+	if err != nil {
+		status, message := http.StatusInternalServerError, "Failed to record SMS status"
+		switch {
+		case errors.Is(err, services.ErrSMSStatusNotSupported):
+			status, message = http.StatusNotFound, err.Error()
+		case errors.Is(err, services.ErrSMSStatusSignature):
+			status, message = http.StatusBadRequest, err.Error()
+		}
+		c.JSON(status, models.ApiResponse{
+			Code:    status,
+			Type:    "error",
+			Message: message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"received": true})
+}
+
+// DeliveryStats counts the notifications in the outbox by status
+func (h *NotificationHandler) DeliveryStats(c *gin.Context) {
+	stats, err := h.notifications.GetDeliveryStats(c.Request.Context())
+	if err != nil {
+		status, message := http.StatusInternalServerError, "Failed to get notification stats"
+		if errors.Is(err, services.ErrNotificationOutboxDisabled) {
+			status, message = http.StatusNotFound, err.Error()
+		}
+		c.JSON(status, models.ApiResponse{
+			Code:    status,
+			Type:    "error",
+			Message: message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// Redeliver queues a dead notification again
+func (h *NotificationHandler) Redeliver(c *gin.Context) {
+	err := h.notifications.Redeliver(c.Request.Context(), c.Param("notificationId"))
+	if err != nil {
+		status, message := http.StatusInternalServerError, "Failed to redeliver notification"
+		switch {
+		case errors.Is(err, services.ErrNotificationOutboxDisabled):
+			status, message = http.StatusNotFound, err.Error()
+		case strings.Contains(err.Error(), "notification not found"):
+			status, message = http.StatusNotFound, "Dead notification not found"
+		}
+		c.JSON(status, models.ApiResponse{
+			Code:    status,
+			Type:    "error",
+			Message: message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"redelivered": true})
+}
@@ -1,88 +1,449 @@
 package handler
 
 import (
+	"context"
+	"errors"
 	"net/http"
+	"slices"
+	"strings"
+	"time"
 
+	"oolio/internal/app/middleware"
 	"oolio/internal/app/models"
 	"oolio/internal/app/services"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/fx"
 )
 
+// CurrencyHeader names the currency a partner settles in; the order's total is also
+// given in it
+const CurrencyHeader = "X-Currency"
+
 type OrderHandler struct {
-	service      services.OrderService
-	queueService services.OrderQueueService
+	service        services.OrderService
+	queueService   services.OrderQueueService
+	addressService services.AddressService
+	lookup         *middleware.OrderLookup
+	verification   services.VerificationService
+	redemptions    services.CouponRedemptionService
+	giftCards      services.GiftCardService
+	currencies     services.CurrencyConverter
+	payment        services.PaymentPolicy
+	stores         services.StoreService
+	deliveries     services.DeliveryService
+	tabs           services.TabService
+}
+
+// OrderHandlerDeps are the services NewOrderHandler builds the handler from. fx fills
+// them in by type; tests set only the ones they need. Without an AddressService orders
+// can't refer to saved addresses, without Lookup guests get no order lookup tokens,
+// without Verification guest orders never need a verified phone, and without Redemptions
+// single-use coupons are only enforced when orders are processed, if at all; likewise
+// without GiftCards for gift cards. Without Currencies orders can't name a settlement
+// currency. Payment decides the payment methods orders may use and whether they are told
+// they await payment. Without Stores every store is always open, without Deliveries
+// orders carry no delivery tracking, and without Tabs orders can't be placed at a table.
+type OrderHandlerDeps struct {
+	fx.In
+
+	OrderService   services.OrderService
+	QueueService   services.OrderQueueService
+	AddressService services.AddressService
+	Lookup         *middleware.OrderLookup
+	Verification   services.VerificationService
+	Redemptions    services.CouponRedemptionService
+	GiftCards      services.GiftCardService
+	Currencies     services.CurrencyConverter
+	Payment        services.PaymentPolicy
+	Stores         services.StoreService
+	Deliveries     services.DeliveryService
+	Tabs           services.TabService
 }
 
-func NewOrderHandler(service services.OrderService, queueService services.OrderQueueService) *OrderHandler {
+func NewOrderHandler(d OrderHandlerDeps) *OrderHandler {
 	return &OrderHandler{
-		service:      service,
-		queueService: queueService,
+		service:        d.OrderService,
+		queueService:   d.QueueService,
+		addressService: d.AddressService,
+		lookup:         d.Lookup,
+		verification:   d.Verification,
+		redemptions:    d.Redemptions,
+		giftCards:      d.GiftCards,
+		currencies:     d.Currencies,
+		payment:        d.Payment,
+		stores:         d.Stores,
+		deliveries:     d.Deliveries,
+		tabs:           d.Tabs,
 	}
 }
 
 func (h *OrderHandler) PlaceOrder(c *gin.Context) {
-	ctx := c.Request.Context()
-
 	var orderReq models.OrderReq
-	if err := c.ShouldBindJSON(&orderReq); err != nil {
+	if !bindJSON(c, &orderReq) {
+		return
+	}
+
+	h.queueOrder(c, &orderReq)
+}
+
+// queueOrder adds a validated order to the queue for batch processing and responds with
+// the queue item; it reports whether the order was queued
+func (h *OrderHandler) queueOrder(c *gin.Context, orderReq *models.OrderReq) bool {
+	orderReq.CustomerID = middleware.CustomerID(c)
+	orderReq.PricingTier = middleware.PricingTier(c).Name
+	orderReq.StoreID = middleware.StoreID(c)
+	orderReq.Sandbox = middleware.Sandbox(c)
+	orderReq.TableNumber = strings.TrimSpace(orderReq.TableNumber)
+	orderReq.TabID = ""
+	requested := orderReq.ScheduledFor
+	if !h.scheduleOrder(c, orderReq) {
+		return false
+	}
+	if orderReq.TableNumber != "" && orderReq.ScheduledFor != nil {
+		status, message := http.StatusBadRequest, "Table orders can't be scheduled"
+		if requested == nil {
+			status, message = http.StatusConflict, "The store is closed"
+		}
+		c.JSON(status, models.ApiResponse{
+			Code:    status,
+			Type:    "error",
+			Message: message,
+		})
+		return false
+	}
+	if !h.resolveCurrency(c, orderReq) {
+		return false
+	}
+	if err := services.NormalizeGuestContact(orderReq); err != nil {
 		c.JSON(http.StatusBadRequest, models.ApiResponse{
 			Code:    http.StatusBadRequest,
 			Type:    "error",
-			Message: "Invalid request format",
+			Message: err.Error(),
 		})
-		return
+		return false
+	}
+	if !h.resolveDeliveryAddress(c, orderReq) {
+		return false
+	}
+	// Whether the intent is authorized is checked when the order is processed
+	orderReq.PaymentIntentID = strings.TrimSpace(orderReq.PaymentIntentID)
+	if err := h.payment.CheckMethod(orderReq); err != nil {
+		status := http.StatusUnprocessableEntity
+		if errors.Is(err, services.ErrPaymentMethodNotAccepted) || errors.Is(err, services.ErrTableOrderPaidOnTab) ||
+			errors.Is(err, services.ErrSandboxTableOrder) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, models.ApiResponse{
+			Code:    status,
+			Type:    "error",
+			Message: err.Error(),
+		})
+		return false
+	}
+	if !h.checkVerification(c, orderReq) {
+		return false
+	}
+	if !h.checkCouponRedemption(c, orderReq) {
+		return false
+	}
+	if !h.checkGiftCard(c, orderReq) {
+		return false
+	}
+	if !h.checkOptions(c, orderReq) {
+		return false
+	}
+	if !h.joinTab(c, orderReq) {
+		return false
 	}
 
-	// Validate request
-	if len(orderReq.Items) == 0 {
-		c.JSON(http.StatusBadRequest, models.ApiResponse{
-			Code:    http.StatusBadRequest,
+	// Stock is reserved as the order is queued, so orders of more than is left are
+	// refused here rather than failing when processed
+	queueItem, err := h.queueService.AddOrderToQueue(c.Request.Context(), orderReq)
+	if err != nil {
+		status, message := http.StatusInternalServerError, "Failed to queue order"
+		if errors.Is(err, services.ErrOutOfStock) {
+			status, message = http.StatusUnprocessableEntity, err.Error()
+		}
+		c.JSON(status, models.ApiResponse{
+			Code:    status,
 			Type:    "error",
-			Message: "Order must contain at least one item",
+			Message: message,
 		})
-		return
+		return false
+	}
+
+	response := models.QueuedOrder{
+		Message:      "Order queued for processing",
+		QueueItemID:  queueItem.ID,
+		Status:       queueItem.Status,
+		ScheduledFor: orderReq.ScheduledFor,
+		Sandbox:      orderReq.Sandbox,
+	}
+	if orderReq.ScheduledFor != nil && requested == nil {
+		response.Message = "The store is closed; order scheduled for when it opens"
+	}
+	if orderReq.TabID != "" {
+		response.TableNumber = orderReq.TableNumber
+		response.TabID = orderReq.TabID
+	}
+	// The order waits in the queue until POST /order/{queueItemId}/pay is used
+	if h.payment.AwaitsPayment(orderReq) {
+		response.Message = services.ErrPaymentRequired.Error()
+		response.PaymentRequired = true
+	}
+	// Guests get a token that reads this order alone, so they never need the API key
+	if middleware.CustomerID(c) == "" {
+		response.LookupToken = h.lookup.Issue(queueItem.ID)
 	}
 
-	// Validate each item
-	for _, item := range orderReq.Items {
-		// Validate product ID format (UUID)
-		if item.ProductID == "" || len(item.ProductID) != 36 {
+	c.JSON(http.StatusAccepted, response)
+	return true
+}
+
+// scheduleOrder settles when the order is processed, given the store's opening hours and
+// the time asked for in the body, and responds itself when the order is refused
+func (h *OrderHandler) scheduleOrder(c *gin.Context, orderReq *models.OrderReq) bool {
+	if h.stores == nil {
+		return true
+	}
+
+	scheduledFor, err := h.stores.ScheduleOrder(c.Request.Context(), orderReq.StoreID, time.Now(), orderReq.ScheduledFor)
+	if err != nil {
+		var closed *services.StoreClosedError
+		switch {
+		case errors.As(err, &closed):
+			response := gin.H{
+				"code":    http.StatusConflict,
+				"type":    "error",
+				"message": "The store is closed",
+			}
+			if closed.NextOpenAt != nil {
+				response["nextOpenAt"] = closed.NextOpenAt
+			}
+			c.JSON(http.StatusConflict, response)
+		case errors.Is(err, services.ErrInvalidSchedule):
 			c.JSON(http.StatusBadRequest, models.ApiResponse{
 				Code:    http.StatusBadRequest,
 				Type:    "error",
-				Message: "Invalid product ID format",
+				Message: err.Error(),
 			})
-			return
-		}
-
-		// Validate quantity
-		if item.Quantity <= 0 {
-			c.JSON(http.StatusUnprocessableEntity, models.ApiResponse{
-				Code:    http.StatusUnprocessableEntity,
+		default:
+			c.JSON(http.StatusInternalServerError, models.ApiResponse{
+				Code:    http.StatusInternalServerError,
 				Type:    "error",
-				Message: "Quantity must be greater than 0",
+				Message: "Failed to check the store's opening hours",
 			})
-			return
 		}
+		return false
 	}
 
-	// Add order to queue for batch processing
-	queueItem, err := h.queueService.AddOrderToQueue(ctx, &orderReq)
+	orderReq.ScheduledFor = scheduledFor
+	return true
+}
+
+// joinTab adds a table order to the table's open tab, opening one for its first order,
+// and responds itself when it can't
+func (h *OrderHandler) joinTab(c *gin.Context, orderReq *models.OrderReq) bool {
+	if orderReq.TableNumber == "" {
+		return true
+	}
+	if h.tabs == nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Code:    http.StatusBadRequest,
+			Type:    "error",
+			Message: "Table ordering is not enabled",
+		})
+		return false
+	}
+
+	tab, err := h.tabs.Join(c.Request.Context(), orderReq.StoreID, orderReq.TableNumber)
+	if err != nil {
+		status, message := http.StatusInternalServerError, "Failed to open the table's tab"
+		if errors.Is(err, services.ErrInvalidTable) {
+			status, message = http.StatusBadRequest, err.Error()
+		}
+		c.JSON(status, models.ApiResponse{
+			Code:    status,
+			Type:    "error",
+			Message: message,
+		})
+		return false
+	}
+
+	orderReq.TabID = tab.ID
+	return true
+}
+
+// resolveDeliveryAddress validates the order's delivery address, copying a saved address
+// into the order when it refers to one, and responds itself when it fails
+func (h *OrderHandler) resolveDeliveryAddress(c *gin.Context, orderReq *models.OrderReq) bool {
+	fail := func(status int, message string) bool {
+		c.JSON(status, models.ApiResponse{
+			Code:    status,
+			Type:    "error",
+			Message: message,
+		})
+		return false
+	}
+
+	if orderReq.AddressID == "" {
+		if orderReq.DeliveryAddress == nil {
+			return true
+		}
+		if err := services.ValidateDeliveryAddress(orderReq.DeliveryAddress); err != nil {
+			return fail(http.StatusBadRequest, err.Error())
+		}
+		return true
+	}
+
+	if orderReq.DeliveryAddress != nil {
+		return fail(http.StatusBadRequest, "Send either addressId or deliveryAddress, not both")
+	}
+	customerID := middleware.CustomerID(c)
+	if customerID == "" || h.addressService == nil {
+		return fail(http.StatusBadRequest, "addressId requires the "+middleware.CustomerHeader+" header")
+	}
+
+	address, err := h.addressService.ResolveDeliveryAddress(c.Request.Context(), customerID, orderReq.AddressID)
+	switch {
+	case errors.Is(err, services.ErrNoDefaultAddress),
+		err != nil && (strings.Contains(err.Error(), "not found") || strings.Contains(err.Error(), "invalid address ID")):
+		return fail(http.StatusUnprocessableEntity, "Unknown delivery address")
+	case err != nil:
+		return fail(http.StatusInternalServerError, "Failed to resolve delivery address")
+	}
+
+	orderReq.DeliveryAddress = address
+	return true
+}
+
+// checkVerification makes guests verify their phone for orders worth
+// VERIFICATION_GUEST_ORDER_MIN or more, and responds itself when they haven't. A verified
+// phone replaces the phone in the body.
+func (h *OrderHandler) checkVerification(c *gin.Context, orderReq *models.OrderReq) bool {
+	if h.verification == nil || orderReq.CustomerID != "" {
+		return true
+	}
+	if phone, ok := h.verification.VerifiedPhone(c.GetHeader(VerificationTokenHeader)); ok {
+		orderReq.Phone = phone
+		return true
+	}
+
+	required, err := h.verification.RequiredFor(c.Request.Context(), orderReq)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ApiResponse{
 			Code:    http.StatusInternalServerError,
 			Type:    "error",
-			Message: "Failed to queue order",
+			Message: "Failed to check whether the order needs phone verification",
 		})
-		return
+		return false
+	}
+	if !required {
+		return true
+	}
+
+	c.JSON(http.StatusForbidden, models.ApiResponse{
+		Code:    http.StatusForbidden,
+		Type:    "error",
+		Message: "Orders of this value need a verified phone: send the token from /verification/check in " + VerificationTokenHeader,
+	})
+	return false
+}
+
+// checkCouponRedemption rejects single-use coupons their redeemer has already used, and
+// responds itself when it does
+func (h *OrderHandler) checkCouponRedemption(c *gin.Context, orderReq *models.OrderReq) bool {
+	if h.redemptions == nil {
+		return true
+	}
+
+	err := h.redemptions.Check(c.Request.Context(), orderReq)
+	if err == nil {
+		return true
+	}
+
+	status, message := http.StatusInternalServerError, "Failed to check coupon redemption"
+	switch {
+	case errors.Is(err, services.ErrCouponAlreadyUsed):
+		status, message = http.StatusConflict, "Coupon "+orderReq.CouponCode+" has already been used"
+	case errors.Is(err, services.ErrCouponNeedsRedeemer):
+		status, message = http.StatusBadRequest, err.Error()
+	}
+	c.JSON(status, models.ApiResponse{
+		Code:    status,
+		Type:    "error",
+		Message: message,
+	})
+	return false
+}
+
+// resolveCurrency takes the order's settlement currency from X-Currency, responding
+// itself when it isn't supported
+func (h *OrderHandler) resolveCurrency(c *gin.Context, orderReq *models.OrderReq) bool {
+	orderReq.Currency = strings.ToLower(strings.TrimSpace(c.GetHeader(CurrencyHeader)))
+	if orderReq.Currency == "" || (h.currencies != nil && h.currencies.Supports(orderReq.Currency)) {
+		return true
+	}
+
+	c.JSON(http.StatusBadRequest, models.ApiResponse{
+		Code:    http.StatusBadRequest,
+		Type:    "error",
+		Message: "Orders can't be settled in " + strings.ToUpper(orderReq.Currency),
+	})
+	return false
+}
+
+// checkGiftCard normalizes the order's gift card code and makes sure the card can be
+// spent, responding itself when it can't. How much it pays is decided when the order is
+// processed.
+func (h *OrderHandler) checkGiftCard(c *gin.Context, orderReq *models.OrderReq) bool {
+	orderReq.GiftCardCode = services.NormalizeGiftCardCode(orderReq.GiftCardCode)
+	if orderReq.GiftCardCode == "" || h.giftCards == nil {
+		return true
 	}
 
-	c.JSON(http.StatusAccepted, gin.H{
-		"message":     "Order queued for processing",
-		"queueItemId": queueItem.ID,
-		"status":      queueItem.Status,
+	_, err := h.giftCards.Apply(c.Request.Context(), orderReq.GiftCardCode, 0)
+	if err == nil {
+		return true
+	}
+
+	status, message := http.StatusInternalServerError, "Failed to check gift card"
+	switch {
+	case errors.Is(err, services.ErrGiftCardNotFound):
+		status, message = http.StatusNotFound, "Gift card "+orderReq.GiftCardCode+" not found"
+	case errors.Is(err, services.ErrGiftCardExpired), errors.Is(err, services.ErrGiftCardEmpty):
+		status, message = http.StatusUnprocessableEntity, err.Error()
+	}
+	c.JSON(status, models.ApiResponse{
+		Code:    status,
+		Type:    "error",
+		Message: message,
+	})
+	return false
+}
+
+// checkOptions prices orders whose items choose variants or modifiers, so options the
+// product doesn't have are refused here rather than failing the order when it's processed
+func (h *OrderHandler) checkOptions(c *gin.Context, orderReq *models.OrderReq) bool {
+	if !slices.ContainsFunc(orderReq.Items, func(item models.OrderItem) bool {
+		return item.VariantID != "" || len(item.ModifierIDs) > 0
+	}) {
+		return true
+	}
+
+	_, err := h.service.QuoteOrder(c.Request.Context(), orderReq)
+	if !errors.Is(err, services.ErrInvalidOption) {
+		// Other failures are left to the worker, as for orders without options
+		return true
+	}
+	c.JSON(http.StatusUnprocessableEntity, models.ApiResponse{
+		Code:    http.StatusUnprocessableEntity,
+		Type:    "error",
+		Message: err.Error(),
 	})
+	return false
 }
 
 func (h *OrderHandler) GetOrder(c *gin.Context) {
@@ -101,14 +462,23 @@ func (h *OrderHandler) GetOrder(c *gin.Context) {
 	// First try to get order from queue (for recent orders)
 	queueItem, err := h.queueService.GetOrderFromQueue(ctx, orderID)
 	if err == nil && queueItem.Order != nil {
-		c.JSON(http.StatusOK, queueItem.Order)
+		if !ofCallerStore(c, queueItem.OrderReq.StoreID) {
+			respondOrderNotFound(c)
+			return
+		}
+		c.JSON(http.StatusOK, h.withDelivery(ctx, queueItem.Order))
 		return
 	}
 
 	// If not found in queue, try the orders table
 	order, err := h.service.GetOrder(ctx, orderID)
+	if err == nil && !ofCallerStore(c, order.StoreID) {
+		respondOrderNotFound(c)
+		return
+	}
 	if err != nil {
-		if err.Error() == "order not found" {
+		// The service wraps the repository's error with the order ID
+		if strings.Contains(err.Error(), "order not found") {
 			c.JSON(http.StatusNotFound, models.ApiResponse{
 				Code:    http.StatusNotFound,
 				Type:    "error",
@@ -125,14 +495,44 @@ func (h *OrderHandler) GetOrder(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, order)
+	c.JSON(http.StatusOK, h.withDelivery(ctx, order))
+}
+
+// withDelivery returns a copy of the order with its delivery tracking. The order is still
+// worth showing when tracking can't be looked up, so it is shown without.
+func (h *OrderHandler) withDelivery(ctx context.Context, order *models.Order) *models.Order {
+	if h.deliveries == nil {
+		return order
+	}
+	delivery, err := h.deliveries.Tracking(ctx, order.ID)
+	if err != nil || delivery == nil {
+		return order
+	}
+	tracked := *order
+	tracked.Delivery = delivery
+	return &tracked
 }
 
 func (h *OrderHandler) ListOrders(c *gin.Context) {
 	ctx := c.Request.Context()
 
-	// Get all orders from queue
-	orders, err := h.queueService.GetCompletedOrders(ctx)
+	since, ok, err := parseUpdatedSince(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Code:    http.StatusBadRequest,
+			Type:    "error",
+			Message: "Invalid updated_since, expected an RFC 3339 timestamp",
+		})
+		return
+	}
+
+	// Get orders from queue, only those changed since the given time for sync clients
+	var orders []*models.OrderQueueItem
+	if ok {
+		orders, err = h.queueService.GetOrdersUpdatedSince(ctx, since)
+	} else {
+		orders, err = h.queueService.GetCompletedOrders(ctx)
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ApiResponse{
 			Code:    http.StatusInternalServerError,
@@ -150,52 +550,44 @@ func (h *OrderHandler) ListOrders(c *gin.Context) {
 	}
 
 	// Transform queue items to order display format
-	orderList := make([]gin.H, 0)
+	orderList := make([]models.OrderSummary, 0)
 	for _, item := range orders {
-		orderDisplay := gin.H{
-			"id":        item.ID,
-			"status":    item.Status,
-			"createdAt": item.CreatedAt,
-			"updatedAt": item.UpdatedAt,
-			"customer":  "Guest", // Default customer name
+		if !ofCallerStore(c, item.OrderReq.StoreID) {
+			continue
+		}
+		summary := models.OrderSummary{
+			ID:        item.ID,
+			Status:    item.Status,
+			CreatedAt: item.CreatedAt,
+			UpdatedAt: item.UpdatedAt,
+			Customer:  "Guest", // Default customer name
+			Sandbox:   item.OrderReq.Sandbox,
 		}
 
 		// Add order data if available
 		if item.Order != nil {
-			orderDisplay["total"] = item.Order.Total
-			if item.Order.Items != nil {
-				orderDisplay["items"] = item.Order.Items
-			}
+			summary.Total = item.Order.Total
+			summary.Items = item.Order.Items
 		} else {
 			// Calculate total from order request if order data not available
-			total := 0.0
-			if len(item.OrderReq.Items) > 0 {
-				items := make([]gin.H, 0)
-				for _, reqItem := range item.OrderReq.Items {
-					total += reqItem.Price * float64(reqItem.Quantity)
-					items = append(items, gin.H{
-						"productId": reqItem.ProductID,
-						"price":     reqItem.Price,
-						"quantity":  reqItem.Quantity,
-					})
-				}
-				orderDisplay["items"] = items
+			for _, reqItem := range item.OrderReq.Items {
+				summary.Total += reqItem.Price.Mul(reqItem.Quantity)
 			}
-			orderDisplay["total"] = total
+			summary.Items = item.OrderReq.Items
 		}
 
 		// Add error message if failed
-		if item.Status == "failed" && item.Error != "" {
-			orderDisplay["error"] = item.Error
+		if item.Status == "failed" {
+			summary.Error = item.Error
 		}
 
-		orderList = append(orderList, orderDisplay)
+		orderList = append(orderList, summary)
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"orders":  orderList,
-		"stats":   stats,
-		"message": "Orders retrieved successfully",
+	c.JSON(http.StatusOK, models.OrderList{
+		Orders:  orderList,
+		Stats:   stats,
+		Message: "Orders retrieved successfully",
 	})
 }
 
@@ -212,7 +604,132 @@ func (h *OrderHandler) GetQueueStatus(c *gin.Context) {
 		return
 	}
 
+	c.JSON(http.StatusOK, models.QueueStatus{QueueStats: stats})
+}
+
+// ListAllOrders is the admin listing of orders with their items and products, paged
+// with ?limit= and ?offset= and narrowed to one store with ?store_id=
+func (h *OrderHandler) ListAllOrders(c *gin.Context) {
+	var page models.PageRequest
+	if err := c.ShouldBindQuery(&page); err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Code:    http.StatusBadRequest,
+			Type:    "error",
+			Message: "Invalid limit or offset",
+		})
+		return
+	}
+	page = page.Normalize()
+	if _, err := uuid.Parse(page.StoreID); page.StoreID != "" && err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Code:    http.StatusBadRequest,
+			Type:    "error",
+			Message: "Invalid store_id",
+		})
+		return
+	}
+
+	orders, total, err := h.service.ListOrders(c.Request.Context(), page)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Code:    http.StatusInternalServerError,
+			Type:    "error",
+			Message: "Failed to get orders",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"orders": orders,
+		"total":  total,
+		"limit":  page.Limit,
+		"offset": page.Offset,
+	})
+}
+
+// PaymentMethodReport is the admin summary of orders per payment method since ?since=, an
+// RFC 3339 timestamp defaulting to the start of today, e.g. to count the cash taken
+func (h *OrderHandler) PaymentMethodReport(c *gin.Context) {
+	now := time.Now()
+	since := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if value := c.Query("since"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ApiResponse{
+				Code:    http.StatusBadRequest,
+				Type:    "error",
+				Message: "Invalid since, expected an RFC 3339 timestamp",
+			})
+			return
+		}
+		since = parsed
+	}
+
+	totals, err := h.service.PaymentMethodReport(c.Request.Context(), since)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Code:    http.StatusInternalServerError,
+			Type:    "error",
+			Message: "Failed to get payment method report",
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"queueStats": stats,
+		"since":   since,
+		"methods": totals,
+	})
+}
+
+// DeleteOrder is admin-only; see OrderService.DeleteOrder for which orders qualify
+func (h *OrderHandler) DeleteOrder(c *gin.Context) {
+	orderID := c.Param("orderId")
+
+	err := h.service.DeleteOrder(c.Request.Context(), orderID, c.ClientIP())
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrOrderNotDeletable):
+			c.JSON(http.StatusConflict, models.ApiResponse{
+				Code:    http.StatusConflict,
+				Type:    "error",
+				Message: "Only cancelled or failed orders can be deleted",
+			})
+		case strings.Contains(err.Error(), "order not found"):
+			c.JSON(http.StatusNotFound, models.ApiResponse{
+				Code:    http.StatusNotFound,
+				Type:    "error",
+				Message: "Order not found",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, models.ApiResponse{
+				Code:    http.StatusInternalServerError,
+				Type:    "error",
+				Message: "Failed to delete order",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Code:    http.StatusOK,
+		Type:    "success",
+		Message: "Order deleted",
+	})
+}
+
+// ofCallerStore reports whether an order placed at storeID belongs to the store the request
+// acts for. Requests without a store, e.g. guests with a lookup token for this very order,
+// may see it.
+func ofCallerStore(c *gin.Context, storeID string) bool {
+	caller := middleware.StoreID(c)
+	return caller == "" || caller == models.StoreOrDefault(storeID)
+}
+
+// respondOrderNotFound answers for orders that don't exist and for those of other stores
+func respondOrderNotFound(c *gin.Context) {
+	c.JSON(http.StatusNotFound, models.ApiResponse{
+		Code:    http.StatusNotFound,
+		Type:    "error",
+		Message: "Order not found",
 	})
 }
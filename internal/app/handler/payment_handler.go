@@ -0,0 +1,400 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"oolio/internal/app/middleware"
+	"oolio/internal/app/models"
+	"oolio/internal/app/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PaymentHandler creates payment intents for orders, and serves the admin routes that
+// capture and refund them, and the provider's webhook
+type PaymentHandler struct {
+	payments services.PaymentService
+	orders   services.OrderService
+	queue    services.OrderQueueService
+	webhooks services.PaymentWebhookService
+}
+
+// NewPaymentHandler returns the handler; without a payment service, when
+// PAYMENT_PROVIDER is none, its routes answer 404, as the webhook does without a webhook
+// service
+func NewPaymentHandler(payments services.PaymentService, orders services.OrderService, queue services.OrderQueueService, webhooks services.PaymentWebhookService) *PaymentHandler {
+	return &PaymentHandler{payments: payments, orders: orders, queue: queue, webhooks: webhooks}
+}
+
+// CreateIntent prices the order in the body, as it would be charged by POST /order, and
+// starts a payment of that amount
+func (h *PaymentHandler) CreateIntent(c *gin.Context) {
+	if !h.enabled(c) {
+		return
+	}
+
+	var orderReq models.OrderReq
+	if !bindJSON(c, &orderReq) {
+		return
+	}
+	orderReq.CustomerID = middleware.CustomerID(c)
+	orderReq.PricingTier = middleware.PricingTier(c).Name
+	orderReq.StoreID = middleware.StoreID(c)
+	if middleware.Sandbox(c) {
+		c.JSON(http.StatusUnprocessableEntity, models.ApiResponse{
+			Code:    http.StatusUnprocessableEntity,
+			Type:    "error",
+			Message: services.ErrSandboxPayment.Error(),
+		})
+		return
+	}
+
+	due, ok := h.amountDue(c, &orderReq)
+	if !ok {
+		return
+	}
+
+	metadata := map[string]string{}
+	if orderReq.CustomerID != "" {
+		metadata["customerId"] = orderReq.CustomerID
+	}
+	intent, err := h.payments.CreateIntent(c.Request.Context(), due, metadata)
+	if err != nil {
+		respondPaymentError(c, err, "Failed to create payment intent")
+		return
+	}
+
+	c.JSON(http.StatusCreated, intent)
+}
+
+// PayOrder starts the payment of an order in the queue, named by its queueItemId, and
+// returns the intent with the client secret to authorize it. An intent the order already
+// has is returned instead while it can still pay for the order.
+func (h *PaymentHandler) PayOrder(c *gin.Context) {
+	if !h.enabled(c) {
+		return
+	}
+	ctx := c.Request.Context()
+	itemID := c.Param("orderId")
+
+	item, err := h.queue.GetOrderFromQueue(ctx, itemID)
+	if err != nil {
+		status, message := http.StatusInternalServerError, "Failed to retrieve order"
+		if err.Error() == "order not found" {
+			status, message = http.StatusNotFound, "Order not found"
+		}
+		c.JSON(status, models.ApiResponse{
+			Code:    status,
+			Type:    "error",
+			Message: message,
+		})
+		return
+	}
+	if !ofCallerStore(c, item.OrderReq.StoreID) {
+		respondOrderNotFound(c)
+		return
+	}
+	if item.Status == "completed" {
+		c.JSON(http.StatusConflict, models.ApiResponse{
+			Code:    http.StatusConflict,
+			Type:    "error",
+			Message: services.ErrOrderNotPayable.Error(),
+		})
+		return
+	}
+	if item.OrderReq.Sandbox {
+		c.JSON(http.StatusUnprocessableEntity, models.ApiResponse{
+			Code:    http.StatusUnprocessableEntity,
+			Type:    "error",
+			Message: services.ErrSandboxPayment.Error(),
+		})
+		return
+	}
+	// Cash and counter orders are paid when they are handed over
+	if method := item.OrderReq.PaymentMethod; method != "" && method != models.PaymentMethodCard {
+		c.JSON(http.StatusUnprocessableEntity, models.ApiResponse{
+			Code:    http.StatusUnprocessableEntity,
+			Type:    "error",
+			Message: services.ErrPaymentIntentNeedsCard.Error(),
+		})
+		return
+	}
+
+	due, ok := h.amountDue(c, &item.OrderReq)
+	if !ok {
+		return
+	}
+
+	if item.OrderReq.PaymentIntentID != "" {
+		intent, err := h.payments.GetIntent(ctx, item.OrderReq.PaymentIntentID)
+		if err == nil && intent.Amount >= due &&
+			intent.Status != models.PaymentStatusSucceeded && intent.Status != models.PaymentStatusCanceled {
+			c.JSON(http.StatusOK, intent)
+			return
+		}
+	}
+
+	metadata := map[string]string{"orderId": item.ID}
+	if item.OrderReq.CustomerID != "" {
+		metadata["customerId"] = item.OrderReq.CustomerID
+	}
+	intent, err := h.payments.CreateIntent(ctx, due, metadata)
+	if err != nil {
+		respondPaymentError(c, err, "Failed to create payment intent")
+		return
+	}
+
+	if err := h.queue.AttachPayment(ctx, item.ID, intent.ID); err != nil {
+		status, message := http.StatusInternalServerError, "Failed to attach payment to order"
+		if errors.Is(err, services.ErrOrderNotPayable) {
+			status, message = http.StatusConflict, err.Error()
+		}
+		c.JSON(status, models.ApiResponse{
+			Code:    status,
+			Type:    "error",
+			Message: message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, intent)
+}
+
+func (h *PaymentHandler) GetIntent(c *gin.Context) {
+	if !h.enabled(c) {
+		return
+	}
+
+	intent, err := h.payments.GetIntent(c.Request.Context(), c.Param("intentId"))
+	if err != nil {
+		respondPaymentError(c, err, "Failed to get payment intent")
+		return
+	}
+
+	// The client secret was handed out when the intent was created
+	intent.ClientSecret = ""
+	c.JSON(http.StatusOK, intent)
+}
+
+// Capture is admin-only, for authorizations that failed to capture when their order
+// was created
+func (h *PaymentHandler) Capture(c *gin.Context) {
+	req, ok := h.bindAmount(c)
+	if !ok {
+		return
+	}
+
+	intent, err := h.payments.Capture(c.Request.Context(), c.Param("intentId"), req.Amount)
+	if err != nil {
+		respondPaymentError(c, err, "Failed to capture payment")
+		return
+	}
+
+	intent.ClientSecret = ""
+	c.JSON(http.StatusOK, intent)
+}
+
+func (h *PaymentHandler) Refund(c *gin.Context) {
+	req, ok := h.bindAmount(c)
+	if !ok {
+		return
+	}
+
+	refund, err := h.payments.Refund(c.Request.Context(), c.Param("intentId"), req.Amount)
+	if err != nil {
+		respondPaymentError(c, err, "Failed to refund payment")
+		return
+	}
+
+	c.JSON(http.StatusOK, refund)
+}
+
+// RefundOrder is admin-only. It refunds an order's payment through the payment provider
+// and records the refund against the order, unlike Refund which only knows the intent.
+func (h *PaymentHandler) RefundOrder(c *gin.Context) {
+	req, ok := h.bindAmount(c)
+	if !ok {
+		return
+	}
+
+	refund, err := h.orders.RefundOrder(c.Request.Context(), c.Param("orderId"), req.Amount, c.ClientIP())
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrOrderNotRefundable):
+			c.JSON(http.StatusConflict, models.ApiResponse{
+				Code:    http.StatusConflict,
+				Type:    "error",
+				Message: err.Error(),
+			})
+		case errors.Is(err, services.ErrRefundExceedsPayment):
+			c.JSON(http.StatusUnprocessableEntity, models.ApiResponse{
+				Code:    http.StatusUnprocessableEntity,
+				Type:    "error",
+				Message: err.Error(),
+			})
+		case strings.Contains(err.Error(), "order not found") || strings.Contains(err.Error(), "invalid order ID"):
+			c.JSON(http.StatusNotFound, models.ApiResponse{
+				Code:    http.StatusNotFound,
+				Type:    "error",
+				Message: "Order not found",
+			})
+		case refund != nil:
+			respondPaymentError(c, err, "Failed to refund payment")
+		default:
+			c.JSON(http.StatusInternalServerError, models.ApiResponse{
+				Code:    http.StatusInternalServerError,
+				Type:    "error",
+				Message: "Failed to refund order",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, refund)
+}
+
+// ListOrderRefunds is admin-only, listing every refund of the order with its status
+func (h *PaymentHandler) ListOrderRefunds(c *gin.Context) {
+	refunds, err := h.orders.GetRefunds(c.Request.Context(), c.Param("orderId"))
+	if err != nil {
+		status, message := http.StatusInternalServerError, "Failed to get refunds"
+		if strings.Contains(err.Error(), "order not found") || strings.Contains(err.Error(), "invalid order ID") {
+			status, message = http.StatusNotFound, "Order not found"
+		}
+		c.JSON(status, models.ApiResponse{
+			Code:    status,
+			Type:    "error",
+			Message: message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, refunds)
+}
+
+// maxWebhookBody bounds the payment webhook's body; provider events are a few KB
+const maxWebhookBody = 1 << 20
+
+// Webhook takes the payment provider's signed event notifications. It is authenticated by
+// the signature rather than the API key, and acknowledges repeated deliveries without
+// applying them again.
+func (h *PaymentHandler) Webhook(c *gin.Context) {
+	if h.webhooks == nil {
+		c.JSON(http.StatusNotFound, models.ApiResponse{
+			Code:    http.StatusNotFound,
+			Type:    "error",
+			Message: "Payment webhooks are not enabled",
+		})
+		return
+	}
+
+	payload, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxWebhookBody))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Code:    http.StatusBadRequest,
+			Type:    "error",
+			Message: "Invalid request format",
+		})
+		return
+	}
+
+	duplicate, err := h.webhooks.Handle(c.Request.Context(), payload, c.GetHeader(services.PaymentSignatureHeader))
+	if err != nil {
+		status, message := http.StatusInternalServerError, "Failed to handle payment event"
+		switch {
+		case errors.Is(err, services.ErrWebhookSignature) || errors.Is(err, services.ErrWebhookPayload):
+			status, message = http.StatusBadRequest, err.Error()
+		case errors.Is(err, services.ErrWebhookOrderPending):
+			status, message = http.StatusServiceUnavailable, err.Error()
+		}
+		c.JSON(status, models.ApiResponse{
+			Code:    status,
+			Type:    "error",
+			Message: message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.PaymentWebhookAck{Received: true, Duplicate: duplicate})
+}
+
+// amountDue prices the order as it will be charged when processed, and responds itself
+// when it can't or there is nothing to pay
+func (h *PaymentHandler) amountDue(c *gin.Context, orderReq *models.OrderReq) (models.Money, bool) {
+	quote, err := h.orders.QuoteOrder(c.Request.Context(), orderReq)
+	if err != nil {
+		status, message := http.StatusInternalServerError, "Failed to price order"
+		if strings.Contains(err.Error(), "validation failed") || strings.Contains(err.Error(), "not found") ||
+			strings.Contains(err.Error(), "coupon") || strings.Contains(err.Error(), "gift card") || errors.Is(err, services.ErrInvalidOption) {
+			status, message = http.StatusUnprocessableEntity, err.Error()
+		}
+		c.JSON(status, models.ApiResponse{
+			Code:    status,
+			Type:    "error",
+			Message: message,
+		})
+		return 0, false
+	}
+
+	due := quote.AmountDue()
+	if due <= 0 {
+		c.JSON(http.StatusUnprocessableEntity, models.ApiResponse{
+			Code:    http.StatusUnprocessableEntity,
+			Type:    "error",
+			Message: "The order has nothing to pay",
+		})
+		return 0, false
+	}
+	return due, true
+}
+
+// bindAmount reads the optional amount of a capture or refund, and responds itself when
+// it can't
+func (h *PaymentHandler) bindAmount(c *gin.Context) (models.PaymentAmountReq, bool) {
+	var req models.PaymentAmountReq
+	if !h.enabled(c) {
+		return req, false
+	}
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil || req.Amount < 0 {
+			c.JSON(http.StatusBadRequest, models.ApiResponse{
+				Code:    http.StatusBadRequest,
+				Type:    "error",
+				Message: "Invalid request format",
+			})
+			return req, false
+		}
+	}
+	return req, true
+}
+
+func (h *PaymentHandler) enabled(c *gin.Context) bool {
+	if h.payments != nil {
+		return true
+	}
+	c.JSON(http.StatusNotFound, models.ApiResponse{
+		Code:    http.StatusNotFound,
+		Type:    "error",
+		Message: "Payments are not enabled",
+	})
+	return false
+}
+
+func respondPaymentError(c *gin.Context, err error, failedMessage string) {
+	status, message := http.StatusBadGateway, failedMessage
+	switch {
+	case errors.Is(err, services.ErrPaymentRejected):
+		status, message = http.StatusUnprocessableEntity, err.Error()
+	case err.Error() == "payment intent not found":
+		status, message = http.StatusNotFound, "Payment intent not found"
+	}
+
+	c.JSON(status, models.ApiResponse{
+		Code:    status,
+		Type:    "error",
+		Message: message,
+	})
+}
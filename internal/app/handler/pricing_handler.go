@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"oolio/internal/app/models"
+	"oolio/internal/app/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PricingHandler serves the admin routes that manage pricing tiers and who gets them
+type PricingHandler struct {
+	pricing services.PricingService
+}
+
+func NewPricingHandler(pricing services.PricingService) *PricingHandler {
+	return &PricingHandler{pricing: pricing}
+}
+
+func (h *PricingHandler) ListTiers(c *gin.Context) {
+	tiers, err := h.pricing.ListTiers(c.Request.Context())
+	if err != nil {
+		respondPricingError(c, err, "Failed to list pricing tiers")
+		return
+	}
+
+	c.JSON(http.StatusOK, tiers)
+}
+
+func (h *PricingHandler) SaveTier(c *gin.Context) {
+	var req models.PricingTierReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Code:    http.StatusBadRequest,
+			Type:    "error",
+			Message: "Invalid request format",
+		})
+		return
+	}
+
+	tier, err := h.pricing.SaveTier(c.Request.Context(), c.Param("tier"), req)
+	if err != nil {
+		respondPricingError(c, err, "Failed to save pricing tier")
+		return
+	}
+
+	c.JSON(http.StatusOK, tier)
+}
+
+func (h *PricingHandler) DeleteTier(c *gin.Context) {
+	if err := h.pricing.DeleteTier(c.Request.Context(), c.Param("tier")); err != nil {
+		respondPricingError(c, err, "Failed to delete pricing tier")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Code:    http.StatusOK,
+		Type:    "success",
+		Message: "Pricing tier deleted",
+	})
+}
+
+func (h *PricingHandler) ListAssignments(c *gin.Context) {
+	assignments, err := h.pricing.ListAssignments(c.Request.Context())
+	if err != nil {
+		respondPricingError(c, err, "Failed to list pricing assignments")
+		return
+	}
+
+	c.JSON(http.StatusOK, assignments)
+}
+
+func (h *PricingHandler) Assign(c *gin.Context) {
+	var req models.PricingAssignmentReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Code:    http.StatusBadRequest,
+			Type:    "error",
+			Message: "Invalid request format",
+		})
+		return
+	}
+
+	if err := h.pricing.Assign(c.Request.Context(), req); err != nil {
+		respondPricingError(c, err, "Failed to assign pricing tier")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Code:    http.StatusOK,
+		Type:    "success",
+		Message: "Pricing tier assigned",
+	})
+}
+
+func respondPricingError(c *gin.Context, err error, failedMessage string) {
+	status, message := http.StatusInternalServerError, failedMessage
+	switch {
+	case errors.Is(err, services.ErrInvalidPricingTier), errors.Is(err, services.ErrRetailTier), errors.Is(err, services.ErrInvalidPricingOwner):
+		status, message = http.StatusBadRequest, err.Error()
+	case strings.Contains(err.Error(), "not found"):
+		status, message = http.StatusNotFound, "Pricing tier not found"
+	}
+
+	c.JSON(status, models.ApiResponse{
+		Code:    status,
+		Type:    "error",
+		Message: message,
+	})
+}
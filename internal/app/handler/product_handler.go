@@ -1,9 +1,13 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
+	"oolio/internal/app/middleware"
 	"oolio/internal/app/models"
 	"oolio/internal/app/services"
 
@@ -20,10 +24,52 @@ func NewProductHandler(service services.ProductService) *ProductHandler {
 	}
 }
 
+// ListProducts returns the menu of the caller's store at its pricing tier. ?limit= or
+// ?offset= page it, and X-Total-Count tells how many products there are on every page.
 func (h *ProductHandler) ListProducts(c *gin.Context) {
 	ctx := c.Request.Context()
 
-	products, err := h.service.GetAllProducts(ctx)
+	since, ok, err := parseUpdatedSince(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Code:    http.StatusBadRequest,
+			Type:    "error",
+			Message: "Invalid updated_since, expected an RFC 3339 timestamp",
+		})
+		return
+	}
+
+	var page models.PageRequest
+	if err := c.ShouldBindQuery(&page); err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Code:    http.StatusBadRequest,
+			Type:    "error",
+			Message: "Invalid limit or offset",
+		})
+		return
+	}
+	paged := c.Query("limit") != "" || c.Query("offset") != ""
+	if paged && ok {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Code:    http.StatusBadRequest,
+			Type:    "error",
+			Message: "updated_since can't be combined with limit or offset",
+		})
+		return
+	}
+
+	var products []models.Product
+	var total int
+	switch {
+	case paged:
+		page = page.Normalize()
+		page.StoreID = middleware.StoreID(c)
+		products, total, err = h.service.ListProducts(ctx, page)
+	case ok:
+		products, err = h.service.GetProductsUpdatedSince(ctx, since)
+	default:
+		products, err = h.service.GetAllProducts(ctx)
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ApiResponse{
 			Code:    http.StatusInternalServerError,
@@ -33,7 +79,12 @@ func (h *ProductHandler) ListProducts(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, products)
+	if !paged {
+		products = productsOfStore(products, middleware.StoreID(c))
+		total = len(products)
+	}
+	c.Header(models.TotalCountHeader, strconv.Itoa(total))
+	c.JSON(http.StatusOK, middleware.PricingTier(c).Apply(products))
 }
 
 func (h *ProductHandler) GetProduct(c *gin.Context) {
@@ -78,5 +129,87 @@ func (h *ProductHandler) GetProduct(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, product)
+	// Other stores' products are as unknown to the caller as products that don't exist
+	if storeID := middleware.StoreID(c); storeID != "" && models.StoreOrDefault(product.StoreID) != storeID {
+		c.JSON(http.StatusNotFound, models.ApiResponse{
+			Code:    http.StatusNotFound,
+			Type:    "error",
+			Message: "Product not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, middleware.PricingTier(c).Apply([]models.Product{*product})[0])
+}
+
+// ListChanges serves the catalog change feed, so clients keeping a copy of the menu can
+// sync it incrementally: they pass the cursor of the last page as since
+func (h *ProductHandler) ListChanges(c *gin.Context) {
+	limit := 0
+	if value := c.Query("limit"); value != "" {
+		var err error
+		if limit, err = strconv.Atoi(value); err != nil {
+			c.JSON(http.StatusBadRequest, models.ApiResponse{
+				Code:    http.StatusBadRequest,
+				Type:    "error",
+				Message: "Invalid limit",
+			})
+			return
+		}
+	}
+
+	feed, err := h.service.GetChanges(c.Request.Context(), middleware.StoreID(c), c.Query("since"), limit)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidCursor) {
+			c.JSON(http.StatusBadRequest, models.ApiResponse{
+				Code:    http.StatusBadRequest,
+				Type:    "error",
+				Message: "Invalid since, expected a cursor from the change feed",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Code:    http.StatusInternalServerError,
+			Type:    "error",
+			Message: "Failed to retrieve product changes",
+		})
+		return
+	}
+
+	tier := middleware.PricingTier(c)
+	for i, change := range feed.Changes {
+		if change.Product != nil {
+			feed.Changes[i].Product = &tier.Apply([]models.Product{*change.Product})[0]
+		}
+	}
+	c.JSON(http.StatusOK, feed)
+}
+
+// productsOfStore keeps the products on the menu of the store; all of them when the request
+// has no store
+func productsOfStore(products []models.Product, storeID string) []models.Product {
+	if storeID == "" {
+		return products
+	}
+	menu := make([]models.Product, 0, len(products))
+	for _, product := range products {
+		if models.StoreOrDefault(product.StoreID) == storeID {
+			menu = append(menu, product)
+		}
+	}
+	return menu
+}
+
+// parseUpdatedSince reads the optional ?updated_since= RFC 3339 timestamp used by sync clients
+func parseUpdatedSince(c *gin.Context) (time.Time, bool, error) {
+	value := c.Query("updated_since")
+	if value == "" {
+		return time.Time{}, false, nil
+	}
+
+	since, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return since, true, nil
 }
@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"errors"
+	"html/template"
+	"net/http"
+
+	"oolio/internal/app/models"
+	"oolio/internal/app/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// QueueHandler requeues orders that failed for good, from the admin API and from the signed
+// links in failure alerts
+type QueueHandler struct {
+	queue services.OrderQueueService
+	links *services.RetryLinks
+}
+
+func NewQueueHandler(queue services.OrderQueueService, links *services.RetryLinks) *QueueHandler {
+	return &QueueHandler{queue: queue, links: links}
+}
+
+// retryPage is shown to operators who open a retry link. The link only shows a button that
+// posts back to it, so chat apps fetching link previews can't requeue the order.
+var retryPage = template.Must(template.New("retry").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Retry order</title></head>
+<body>
+<p>{{.Message}}</p>
+{{if .Confirm}}<form method="post"><button type="submit">Retry queue item {{.ItemID}}</button></form>{{end}}
+</body>
+</html>
+`))
+
+type retryPageData struct {
+	ItemID  string
+	Message string
+	Confirm bool
+}
+
+// ConfirmRetry renders the page that asks the operator to confirm the retry
+func (h *QueueHandler) ConfirmRetry(c *gin.Context) {
+	itemID := c.Param("itemId")
+	if !h.links.Valid(itemID, c.Query("expires"), c.Query("signature")) {
+		h.renderRetryPage(c, http.StatusForbidden, retryPageData{Message: "This retry link is invalid or has expired."})
+		return
+	}
+	h.renderRetryPage(c, http.StatusOK, retryPageData{
+		ItemID:  itemID,
+		Message: "The order failed and won't be retried on its own. Retry it once the cause is fixed.",
+		Confirm: true,
+	})
+}
+
+// RetryFromLink requeues the order of a confirmed retry link
+func (h *QueueHandler) RetryFromLink(c *gin.Context) {
+	itemID := c.Param("itemId")
+	if !h.links.Valid(itemID, c.Query("expires"), c.Query("signature")) {
+		h.renderRetryPage(c, http.StatusForbidden, retryPageData{Message: "This retry link is invalid or has expired."})
+		return
+	}
+
+	err := h.queue.Retry(c.Request.Context(), itemID)
+	switch {
+	case errors.Is(err, services.ErrQueueItemNotFailed):
+		h.renderRetryPage(c, http.StatusConflict, retryPageData{Message: "The order isn't failed any more; it may have been retried already."})
+	case err != nil:
+		h.renderRetryPage(c, http.StatusInternalServerError, retryPageData{Message: "The order could not be requeued. Try again, or use `queue retry " + itemID + "`."})
+	default:
+		h.renderRetryPage(c, http.StatusOK, retryPageData{Message: "The order is back in the queue and will be processed shortly."})
+	}
+}
+
+func (h *QueueHandler) renderRetryPage(c *gin.Context, status int, data retryPageData) {
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Header("Cache-Control", "no-store")
+	c.Status(status)
+	retryPage.Execute(c.Writer, data)
+}
+
+// Inspect returns a queue item with its request, failed attempts, claiming worker and
+// resulting order for admins
+func (h *QueueHandler) Inspect(c *gin.Context) {
+	details, err := h.queue.Inspect(c.Request.Context(), c.Param("itemId"))
+	if err != nil {
+		status, message := http.StatusInternalServerError, "Failed to get queue item"
+		if errors.Is(err, services.ErrQueueItemNotFound) {
+			status, message = http.StatusNotFound, "Queue item not found"
+		}
+		c.JSON(status, models.ApiResponse{
+			Code:    status,
+			Type:    "error",
+			Message: message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, details)
+}
+
+// Retry requeues a failed order for admins
+func (h *QueueHandler) Retry(c *gin.Context) {
+	err := h.queue.Retry(c.Request.Context(), c.Param("itemId"))
+	if err != nil {
+		status, message := http.StatusInternalServerError, "Failed to retry order"
+		if errors.Is(err, services.ErrQueueItemNotFailed) {
+			status, message = http.StatusNotFound, "Failed queue item not found"
+		}
+		c.JSON(status, models.ApiResponse{
+			Code:    status,
+			Type:    "error",
+			Message: message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Code:    http.StatusOK,
+		Type:    "success",
+		Message: "Order requeued",
+	})
+}
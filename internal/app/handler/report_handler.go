@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"oolio/internal/app/models"
+	"oolio/internal/app/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ReportHandler serves the admin sales reports, dashboard and reconciliation report
+type ReportHandler struct {
+	sales          services.SalesReportService
+	dashboard      services.DashboardService
+	reconciliation services.ReconciliationService
+}
+
+func NewReportHandler(sales services.SalesReportService, dashboard services.DashboardService, reconciliation services.ReconciliationService) *ReportHandler {
+	return &ReportHandler{sales: sales, dashboard: dashboard, reconciliation: reconciliation}
+}
+
+// Dashboard sums up today's sales, the queue's health, today's best sellers and coupon
+// usage for an operations dashboard
+func (h *ReportHandler) Dashboard(c *gin.Context) {
+	dashboard, err := h.dashboard.Summary(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Code:    http.StatusInternalServerError,
+			Type:    "error",
+			Message: "Failed to build dashboard",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dashboard)
+}
+
+// Sales reports the orders placed from ?from= to ?to=, both YYYY-MM-DD and included,
+// grouped by ?group_by=day|product|category. to defaults to today and from to the 30 days
+// up to it; ?store_id= narrows the orders to a store's.
+func (h *ReportHandler) Sales(c *gin.Context) {
+	to, ok := parseReportDate(c, "to", time.Now())
+	if !ok {
+		return
+	}
+	from, ok := parseReportDate(c, "from", to.AddDate(0, 0, -29))
+	if !ok {
+		return
+	}
+	storeID, ok := parseReportStore(c)
+	if !ok {
+		return
+	}
+
+	report, err := h.sales.Build(c.Request.Context(), c.Query("group_by"), from, to, storeID)
+	if err != nil {
+		status, message := http.StatusInternalServerError, "Failed to build sales report"
+		if errors.Is(err, services.ErrInvalidSalesGrouping) || errors.Is(err, services.ErrInvalidSalesRange) {
+			status, message = http.StatusBadRequest, err.Error()
+		}
+		c.JSON(status, models.ApiResponse{
+			Code:    status,
+			Type:    "error",
+			Message: message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// Discrepancies lists the orders the reconciliation job found don't add up, for finance to
+// review; ?store_id= narrows them to a store's orders
+func (h *ReportHandler) Discrepancies(c *gin.Context) {
+	storeID, ok := parseReportStore(c)
+	if !ok {
+		return
+	}
+
+	report, err := h.reconciliation.Discrepancies(c.Request.Context(), storeID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Code:    http.StatusInternalServerError,
+			Type:    "error",
+			Message: "Failed to list discrepancies",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// parseReportStore reads the optional store_id query parameter and responds itself when it
+// is not a UUID
+func parseReportStore(c *gin.Context) (string, bool) {
+	storeID := c.Query("store_id")
+	if _, err := uuid.Parse(storeID); storeID != "" && err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Code:    http.StatusBadRequest,
+			Type:    "error",
+			Message: "Invalid store_id",
+		})
+		return "", false
+	}
+	return storeID, true
+}
+
+// parseReportDate reads a YYYY-MM-DD query parameter, defaulting to fallback, and responds
+// itself when it is invalid
+func parseReportDate(c *gin.Context, name string, fallback time.Time) (time.Time, bool) {
+	value := c.Query(name)
+	if value == "" {
+		return fallback, true
+	}
+	parsed, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Code:    http.StatusBadRequest,
+			Type:    "error",
+			Message: "Invalid " + name + ", expected YYYY-MM-DD",
+		})
+		return time.Time{}, false
+	}
+	return parsed, true
+}
@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"oolio/internal/app/models"
+	"oolio/internal/app/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ScheduleHandler serves the feed of scheduled orders. Admins get its signed URLs through
+// the API; calendar and spreadsheet tools then read it with just the URL.
+type ScheduleHandler struct {
+	schedule services.ScheduleService
+	links    *services.ScheduleFeedLinks
+}
+
+// NewScheduleHandler returns the handler; without links the feed is disabled
+func NewScheduleHandler(schedule services.ScheduleService, links *services.ScheduleFeedLinks) *ScheduleHandler {
+	return &ScheduleHandler{schedule: schedule, links: links}
+}
+
+// IssueLinks returns signed URLs to the feed of the store in ?store_id=, or of every store
+func (h *ScheduleHandler) IssueLinks(c *gin.Context) {
+	if h.links == nil {
+		c.JSON(http.StatusNotFound, models.ApiResponse{
+			Code:    http.StatusNotFound,
+			Type:    "error",
+			Message: "The schedule feed is disabled; set SCHEDULE_FEED_SECRET and SCHEDULE_FEED_BASE_URL",
+		})
+		return
+	}
+	storeID := c.Query("store_id")
+	if storeID != "" {
+		if _, err := uuid.Parse(storeID); err != nil {
+			c.JSON(http.StatusBadRequest, models.ApiResponse{
+				Code:    http.StatusBadRequest,
+				Type:    "error",
+				Message: "Invalid store_id",
+			})
+			return
+		}
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, h.links.Issue(storeID))
+}
+
+// FeedICS serves the scheduled orders as an iCalendar feed to a signed URL
+func (h *ScheduleHandler) FeedICS(c *gin.Context) {
+	orders, ok := h.upcoming(c)
+	if !ok {
+		return
+	}
+	c.Header("Content-Type", "text/calendar; charset=utf-8")
+	c.Status(http.StatusOK)
+	services.WriteScheduleICS(c.Writer, orders, time.Now())
+}
+
+// FeedCSV serves the scheduled orders as CSV to a signed URL
+func (h *ScheduleHandler) FeedCSV(c *gin.Context) {
+	orders, ok := h.upcoming(c)
+	if !ok {
+		return
+	}
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", `attachment; filename="scheduled-orders.csv"`)
+	c.Status(http.StatusOK)
+	services.WriteScheduleCSV(c.Writer, orders)
+}
+
+// upcoming checks the URL's signature and loads its orders, responding itself when either
+// fails
+func (h *ScheduleHandler) upcoming(c *gin.Context) ([]models.ScheduledOrder, bool) {
+	storeID := c.Query("store_id")
+	if !h.links.Valid(storeID, c.Query("expires"), c.Query("signature")) {
+		c.JSON(http.StatusForbidden, models.ApiResponse{
+			Code:    http.StatusForbidden,
+			Type:    "error",
+			Message: "This schedule link is invalid or has expired",
+		})
+		return nil, false
+	}
+
+	orders, err := h.schedule.Upcoming(c.Request.Context(), storeID, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Code:    http.StatusInternalServerError,
+			Type:    "error",
+			Message: "Failed to list scheduled orders",
+		})
+		return nil, false
+	}
+	c.Header("Cache-Control", "private, no-cache")
+	return orders, true
+}
@@ -0,0 +1,27 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"oolio/internal/app/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type StatusHandler struct {
+	statusService services.StatusService
+	maxAge        time.Duration
+}
+
+// NewStatusHandler serves the public status; responses may be cached by clients and
+// proxies for maxAge
+func NewStatusHandler(statusService services.StatusService, maxAge time.Duration) *StatusHandler {
+	return &StatusHandler{statusService: statusService, maxAge: maxAge}
+}
+
+func (h *StatusHandler) GetStatus(c *gin.Context) {
+	c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", int(h.maxAge.Seconds())))
+	c.JSON(http.StatusOK, h.statusService.Status(c.Request.Context()))
+}
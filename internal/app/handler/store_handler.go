@@ -0,0 +1,188 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"oolio/internal/app/models"
+	"oolio/internal/app/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StoreHandler serves the admin routes that manage stores and their API keys
+type StoreHandler struct {
+	stores services.StoreService
+}
+
+func NewStoreHandler(stores services.StoreService) *StoreHandler {
+	return &StoreHandler{stores: stores}
+}
+
+func (h *StoreHandler) ListStores(c *gin.Context) {
+	stores, err := h.stores.ListStores(c.Request.Context())
+	if err != nil {
+		respondStoreError(c, err, "Failed to list stores")
+		return
+	}
+
+	c.JSON(http.StatusOK, stores)
+}
+
+func (h *StoreHandler) GetStore(c *gin.Context) {
+	store, err := h.stores.GetStore(c.Request.Context(), c.Param("storeId"))
+	if err != nil {
+		respondStoreError(c, err, "Failed to get store")
+		return
+	}
+
+	c.JSON(http.StatusOK, store)
+}
+
+func (h *StoreHandler) CreateStore(c *gin.Context) {
+	var req models.StoreReq
+	if !bindStoreRequest(c, &req) {
+		return
+	}
+
+	store, err := h.stores.CreateStore(c.Request.Context(), req)
+	if err != nil {
+		respondStoreError(c, err, "Failed to create store")
+		return
+	}
+
+	c.JSON(http.StatusCreated, store)
+}
+
+func (h *StoreHandler) UpdateStore(c *gin.Context) {
+	var req models.StoreReq
+	if !bindStoreRequest(c, &req) {
+		return
+	}
+
+	store, err := h.stores.UpdateStore(c.Request.Context(), c.Param("storeId"), req)
+	if err != nil {
+		respondStoreError(c, err, "Failed to update store")
+		return
+	}
+
+	c.JSON(http.StatusOK, store)
+}
+
+func (h *StoreHandler) ListAPIKeys(c *gin.Context) {
+	keys, err := h.stores.ListAPIKeys(c.Request.Context(), c.Param("storeId"))
+	if err != nil {
+		respondStoreError(c, err, "Failed to list store API keys")
+		return
+	}
+
+	c.JSON(http.StatusOK, keys)
+}
+
+// CreateAPIKey returns the new key; it is the only time the key is shown
+func (h *StoreHandler) CreateAPIKey(c *gin.Context) {
+	var req models.StoreAPIKeyReq
+	if !bindStoreRequest(c, &req) {
+		return
+	}
+
+	key, err := h.stores.CreateAPIKey(c.Request.Context(), c.Param("storeId"), req)
+	if err != nil {
+		respondStoreError(c, err, "Failed to create store API key")
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusCreated, key)
+}
+
+func (h *StoreHandler) RevokeAPIKey(c *gin.Context) {
+	if err := h.stores.RevokeAPIKey(c.Request.Context(), c.Param("storeId"), c.Param("keyId")); err != nil {
+		respondStoreError(c, err, "Failed to revoke store API key")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Code:    http.StatusOK,
+		Type:    "success",
+		Message: "Store API key revoked",
+	})
+}
+
+// GetOpeningHours returns the store's opening hours; a store without any is always open
+func (h *StoreHandler) GetOpeningHours(c *gin.Context) {
+	hours, err := h.stores.GetOpeningHours(c.Request.Context(), c.Param("storeId"))
+	if err != nil {
+		respondStoreError(c, err, "Failed to get opening hours")
+		return
+	}
+	if hours == nil {
+		c.JSON(http.StatusNotFound, models.ApiResponse{
+			Code:    http.StatusNotFound,
+			Type:    "error",
+			Message: "The store has no opening hours and is always open",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, hours)
+}
+
+func (h *StoreHandler) SetOpeningHours(c *gin.Context) {
+	var req models.OpeningHours
+	if !bindStoreRequest(c, &req) {
+		return
+	}
+
+	hours, err := h.stores.SetOpeningHours(c.Request.Context(), c.Param("storeId"), req)
+	if err != nil {
+		respondStoreError(c, err, "Failed to set opening hours")
+		return
+	}
+
+	c.JSON(http.StatusOK, hours)
+}
+
+func (h *StoreHandler) DeleteOpeningHours(c *gin.Context) {
+	if err := h.stores.DeleteOpeningHours(c.Request.Context(), c.Param("storeId")); err != nil {
+		respondStoreError(c, err, "Failed to delete opening hours")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ApiResponse{
+		Code:    http.StatusOK,
+		Type:    "success",
+		Message: "The store is now always open",
+	})
+}
+
+func bindStoreRequest(c *gin.Context, req any) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Code:    http.StatusBadRequest,
+			Type:    "error",
+			Message: "Invalid request format",
+		})
+		return false
+	}
+	return true
+}
+
+func respondStoreError(c *gin.Context, err error, failedMessage string) {
+	status, message := http.StatusInternalServerError, failedMessage
+	switch {
+	case errors.Is(err, services.ErrInvalidStoreName), errors.Is(err, services.ErrInvalidOpeningHours):
+		status, message = http.StatusBadRequest, err.Error()
+	case strings.Contains(err.Error(), "store API key not found"):
+		status, message = http.StatusNotFound, "Store API key not found"
+	case strings.Contains(err.Error(), "store not found"):
+		status, message = http.StatusNotFound, "Store not found"
+	}
+
+	c.JSON(status, models.ApiResponse{
+		Code:    status,
+		Type:    "error",
+		Message: message,
+	})
+}
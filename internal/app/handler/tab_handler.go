@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"oolio/internal/app/middleware"
+	"oolio/internal/app/models"
+	"oolio/internal/app/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TabHandler serves the tabs of dine-in tables of the caller's store
+type TabHandler struct {
+	tabs services.TabService
+}
+
+func NewTabHandler(tabs services.TabService) *TabHandler {
+	return &TabHandler{tabs: tabs}
+}
+
+// Get returns the table's open tab: what was ordered at it so far and what that comes to
+func (h *TabHandler) Get(c *gin.Context) {
+	tab, err := h.tabs.Get(c.Request.Context(), middleware.StoreID(c), c.Param("tableNumber"))
+	if err != nil {
+		respondTabError(c, err, "Failed to get tab")
+		return
+	}
+	c.JSON(http.StatusOK, tab)
+}
+
+// Close settles the table's open tab, so the next order at the table opens a new one
+func (h *TabHandler) Close(c *gin.Context) {
+	var req models.TabCloseReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Code:    http.StatusBadRequest,
+			Type:    "error",
+			Message: "Invalid request format",
+		})
+		return
+	}
+
+	tab, err := h.tabs.Close(c.Request.Context(), middleware.StoreID(c), c.Param("tableNumber"), req.PaymentMethod)
+	if err != nil {
+		respondTabError(c, err, "Failed to close tab")
+		return
+	}
+	c.JSON(http.StatusOK, tab)
+}
+
+// Kitchen lists the store's tables with an open tab and what each has ordered
+func (h *TabHandler) Kitchen(c *gin.Context) {
+	tables, err := h.tabs.Kitchen(c.Request.Context(), middleware.StoreID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Code:    http.StatusInternalServerError,
+			Type:    "error",
+			Message: "Failed to list tables",
+		})
+		return
+	}
+	c.JSON(http.StatusOK, tables)
+}
+
+func respondTabError(c *gin.Context, err error, failedMessage string) {
+	status, message := http.StatusInternalServerError, failedMessage
+	switch {
+	case errors.Is(err, services.ErrTabNotFound):
+		status, message = http.StatusNotFound, err.Error()
+	case errors.Is(err, services.ErrTabOrdersQueued):
+		status, message = http.StatusConflict, err.Error()
+	case errors.Is(err, services.ErrPaymentMethodNotAccepted):
+		status, message = http.StatusBadRequest, err.Error()
+	}
+	c.JSON(status, models.ApiResponse{
+		Code:    status,
+		Type:    "error",
+		Message: message,
+	})
+}
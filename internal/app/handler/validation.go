@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+
+	"oolio/internal/app/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// jsonFieldNames makes the validator name fields by their JSON keys, so errors point at
+// the body the client sent rather than at Go struct fields
+var jsonFieldNames sync.Once
+
+// bindJSON binds the request body into obj and checks its binding tags. It responds itself
+// when the body isn't valid JSON or breaks a rule, and reports whether obj can be used.
+func bindJSON(c *gin.Context, obj any) bool {
+	jsonFieldNames.Do(func() {
+		if engine, ok := binding.Validator.Engine().(*validator.Validate); ok {
+			engine.RegisterTagNameFunc(func(field reflect.StructField) string {
+				name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+				if name == "-" {
+					return ""
+				}
+				return name
+			})
+		}
+	})
+
+	if err := c.ShouldBindJSON(obj); err != nil {
+		status, response := invalidRequest(err)
+		c.JSON(status, response)
+		return false
+	}
+	return true
+}
+
+// invalidRequest translates a binding error into the response for it. Every failed rule is
+// listed in Errors, and the message is the first one's. A value out of range in a body that
+// is otherwise well formed is 422, like a quantity of 0; anything else is 400.
+func invalidRequest(err error) (int, models.ApiResponse) {
+	var failed validator.ValidationErrors
+	if !errors.As(err, &failed) {
+		return http.StatusBadRequest, models.ApiResponse{
+			Code:    http.StatusBadRequest,
+			Type:    "error",
+			Message: "Invalid request format",
+		}
+	}
+
+	status := http.StatusUnprocessableEntity
+	fields := make([]models.FieldError, len(failed))
+	for i, fieldErr := range failed {
+		fields[i] = models.FieldError{
+			Field:   fieldPath(fieldErr),
+			Rule:    fieldErr.Tag(),
+			Message: fieldMessage(fieldErr),
+		}
+		if !isRangeError(fieldErr) {
+			status = http.StatusBadRequest
+		}
+	}
+	return status, models.ApiResponse{
+		Code:    status,
+		Type:    "error",
+		Message: fields[0].Message,
+		Errors:  fields,
+	}
+}
+
+// fieldPath is the field's path in the body, e.g. items[0].quantity, without the name of
+// the request type it starts with
+func fieldPath(fieldErr validator.FieldError) string {
+	_, path, found := strings.Cut(fieldErr.Namespace(), ".")
+	if !found {
+		return fieldErr.Field()
+	}
+	return path
+}
+
+func fieldMessage(fieldErr validator.FieldError) string {
+	path := fieldPath(fieldErr)
+	switch fieldErr.Tag() {
+	case "required":
+		return path + " is required"
+	case "uuid4":
+		return path + " must be a UUID"
+	case "min":
+		switch fieldErr.Kind() {
+		case reflect.Slice, reflect.Array, reflect.Map:
+			return fmt.Sprintf("%s must have at least %s item(s)", path, fieldErr.Param())
+		case reflect.String:
+			return fmt.Sprintf("%s must be at least %s characters long", path, fieldErr.Param())
+		}
+		return fmt.Sprintf("%s must be at least %s", path, fieldErr.Param())
+	case "max":
+		switch fieldErr.Kind() {
+		case reflect.Slice, reflect.Array, reflect.Map:
+			return fmt.Sprintf("%s must have at most %s item(s)", path, fieldErr.Param())
+		case reflect.String:
+			return fmt.Sprintf("%s must be at most %s characters long", path, fieldErr.Param())
+		}
+		return fmt.Sprintf("%s must be at most %s", path, fieldErr.Param())
+	}
+	return fmt.Sprintf("%s is invalid (%s)", path, fieldErr.Tag())
+}
+
+// isRangeError reports whether the field is a number outside the range its rule allows
+func isRangeError(fieldErr validator.FieldError) bool {
+	switch fieldErr.Tag() {
+	case "min", "max", "gt", "gte", "lt", "lte":
+	default:
+		return false
+	}
+	switch fieldErr.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"oolio/internal/app/models"
+	"oolio/internal/app/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VerificationTokenHeader carries the token from a phone verification, for guest orders
+// that need a verified phone
+const VerificationTokenHeader = "X-Verification-Token"
+
+// VerificationHandler texts one-time codes and checks them
+type VerificationHandler struct {
+	verification services.VerificationService
+}
+
+// NewVerificationHandler returns the handler; without a verification service, when
+// VERIFICATION_PROVIDER is none, its routes answer 404
+func NewVerificationHandler(verification services.VerificationService) *VerificationHandler {
+	return &VerificationHandler{verification: verification}
+}
+
+func (h *VerificationHandler) SendCode(c *gin.Context) {
+	if !h.enabled(c) {
+		return
+	}
+
+	var req models.VerificationReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Code:    http.StatusBadRequest,
+			Type:    "error",
+			Message: "Invalid request format",
+		})
+		return
+	}
+
+	challenge, err := h.verification.SendCode(c.Request.Context(), req.Phone)
+	if err != nil {
+		status, message := http.StatusBadGateway, "Failed to send verification code"
+		if errors.Is(err, services.ErrInvalidPhone) {
+			status, message = http.StatusBadRequest, err.Error()
+		}
+		c.JSON(status, models.ApiResponse{
+			Code:    status,
+			Type:    "error",
+			Message: message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, challenge)
+}
+
+func (h *VerificationHandler) CheckCode(c *gin.Context) {
+	if !h.enabled(c) {
+		return
+	}
+
+	var req models.VerificationCheckReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Code:    http.StatusBadRequest,
+			Type:    "error",
+			Message: "Invalid request format",
+		})
+		return
+	}
+
+	verified, err := h.verification.CheckCode(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, models.ApiResponse{
+			Code:    http.StatusUnprocessableEntity,
+			Type:    "error",
+			Message: "Invalid or expired verification code",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, verified)
+}
+
+func (h *VerificationHandler) enabled(c *gin.Context) bool {
+	if h.verification != nil {
+		return true
+	}
+	c.JSON(http.StatusNotFound, models.ApiResponse{
+		Code:    http.StatusNotFound,
+		Type:    "error",
+		Message: "Phone verification is not enabled",
+	})
+	return false
+}
@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"oolio/internal/app/models"
+	"oolio/internal/app/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WebhookHandler lets admins follow outbound webhook deliveries and redeliver dead ones
+type WebhookHandler struct {
+	webhooks services.WebhookService
+}
+
+func NewWebhookHandler(webhooks services.WebhookService) *WebhookHandler {
+	return &WebhookHandler{webhooks: webhooks}
+}
+
+// ListDeliveries pages through the deliveries, newest first, limited to ?status= when it
+// is pending, delivered or dead
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	status := c.Query("status")
+	switch status {
+	case "", models.WebhookPending, models.WebhookDelivered, models.WebhookDead:
+	default:
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Code:    http.StatusBadRequest,
+			Type:    "error",
+			Message: "Invalid status",
+		})
+		return
+	}
+
+	var page models.PageRequest
+	if err := c.ShouldBindQuery(&page); err != nil {
+		c.JSON(http.StatusBadRequest, models.ApiResponse{
+			Code:    http.StatusBadRequest,
+			Type:    "error",
+			Message: "Invalid limit or offset",
+		})
+		return
+	}
+	page = page.Normalize()
+
+	deliveries, total, err := h.webhooks.ListDeliveries(c.Request.Context(), status, page)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ApiResponse{
+			Code:    http.StatusInternalServerError,
+			Type:    "error",
+			Message: "Failed to get webhook deliveries",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"deliveries": deliveries,
+		"total":      total,
+		"limit":      page.Limit,
+		"offset":     page.Offset,
+	})
+}
+
+// GetDelivery returns a delivery with the log of its attempts
+func (h *WebhookHandler) GetDelivery(c *gin.Context) {
+	delivery, err := h.webhooks.GetDelivery(c.Request.Context(), c.Param("deliveryId"))
+	if err != nil {
+		status, message := http.StatusInternalServerError, "Failed to get webhook delivery"
+		if strings.Contains(err.Error(), "not found") {
+			status, message = http.StatusNotFound, "Webhook delivery not found"
+		}
+		c.JSON(status, models.ApiResponse{
+			Code:    status,
+			Type:    "error",
+			Message: message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, delivery)
+}
+
+// Redeliver queues a dead delivery again, e.g. once the receiver is fixed
+func (h *WebhookHandler) Redeliver(c *gin.Context) {
+	err := h.webhooks.Redeliver(c.Request.Context(), c.Param("deliveryId"))
+	if err != nil {
+		status, message := http.StatusInternalServerError, "Failed to redeliver webhook"
+		if strings.Contains(err.Error(), "webhook delivery not found") {
+			status, message = http.StatusNotFound, "Dead webhook delivery not found"
+		}
+		c.JSON(status, models.ApiResponse{
+			Code:    status,
+			Type:    "error",
+			Message: message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"redelivered": true})
+}
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type AccessLogMiddleware struct {
+	logger *zap.Logger
+}
+
+func NewAccessLogMiddleware(logger *zap.Logger) *AccessLogMiddleware {
+	return &AccessLogMiddleware{
+		logger: logger,
+	}
+}
+
+// AccessLog writes one structured entry per request. Sampling is applied by the injected logger
+func (m *AccessLogMiddleware) AccessLog() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// If logger is nil (e.g., in tests), fall back to gin's default logger
+		if m == nil || m.logger == nil {
+			gin.Logger()(c)
+			return
+		}
+
+		start := time.Now()
+		path := c.Request.URL.Path
+		query := c.Request.URL.RawQuery
+
+		c.Next()
+
+		fields := []zap.Field{
+			zap.String("method", c.Request.Method),
+			zap.String("path", path),
+			zap.String("query", query),
+			zap.Int("status", c.Writer.Status()),
+			zap.Duration("latency", time.Since(start)),
+			zap.String("clientIP", c.ClientIP()),
+			zap.Int("bytes", c.Writer.Size()),
+		}
+
+		if len(c.Errors) > 0 {
+			fields = append(fields, zap.String("errors", c.Errors.String()))
+		}
+
+		switch {
+		case c.Writer.Status() >= 500:
+			m.logger.Error("request", fields...)
+		case c.Writer.Status() >= 400:
+			m.logger.Warn("request", fields...)
+		default:
+			m.logger.Info("request", fields...)
+		}
+	}
+}
@@ -9,13 +9,19 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// APIKey returns the API key the request was made with, "" without one
+func APIKey(c *gin.Context) string {
+	apiKey := c.GetHeader("X-API-Key")
+	if apiKey == "" {
+		// Fallback to lowercase for compatibility
+		apiKey = c.GetHeader("api_key")
+	}
+	return apiKey
+}
+
 func APIKeyAuth(validKeys []string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		apiKey := c.GetHeader("X-API-Key")
-		if apiKey == "" {
-			// Fallback to lowercase for compatibility
-			apiKey = c.GetHeader("api_key")
-		}
+		apiKey := APIKey(c)
 		if apiKey == "" {
 			c.JSON(http.StatusUnauthorized, models.ApiResponse{
 				Code:    http.StatusUnauthorized,
@@ -45,8 +51,17 @@ func APIKeyAuth(validKeys []string) gin.HandlerFunc {
 
 func RequirePermission(permission string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// For now, this is a placeholder for future permission-based access control
-		// We can extend this to check user roles and permissions from a database or JWT claims
+		// Store API keys run a single location; only the deployment's API key administers it.
+		// Beyond that this is a placeholder for future permission-based access control, e.g.
+		// roles and permissions from a database or JWT claims.
+		if storeAPIKey(c) {
+			c.AbortWithStatusJSON(http.StatusForbidden, models.ApiResponse{
+				Code:    http.StatusForbidden,
+				Type:    "error",
+				Message: "Store API keys don't have the " + permission + " permission",
+			})
+			return
+		}
 		c.Next()
 	}
 }
@@ -56,7 +71,7 @@ func CORSMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
 		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, api_key, X-API-Key")
+		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, api_key, X-API-Key, X-Customer-ID, X-Cart-Token, X-Order-Token, X-Verification-Token, X-Currency, X-Store-ID")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(http.StatusNoContent)
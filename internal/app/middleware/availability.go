@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"oolio/internal/app/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Breaker reports whether a dependency is known to be down
+type Breaker interface {
+	Open() bool
+}
+
+type AvailabilityMiddleware struct {
+	breaker    Breaker
+	retryAfter time.Duration
+}
+
+// NewAvailabilityMiddleware fails requests fast while breaker is open, hinting clients to
+// come back after retryAfter
+func NewAvailabilityMiddleware(breaker Breaker, retryAfter time.Duration) *AvailabilityMiddleware {
+	return &AvailabilityMiddleware{breaker: breaker, retryAfter: retryAfter}
+}
+
+// RequireDatabase answers 503 while the database circuit breaker is open instead of letting
+// the request wait on a connection that is bound to fail
+func (m *AvailabilityMiddleware) RequireDatabase() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if m == nil || !m.breaker.Open() {
+			c.Next()
+			return
+		}
+
+		seconds := max(int(math.Ceil(m.retryAfter.Seconds())), 1)
+		c.Header("Retry-After", strconv.Itoa(seconds))
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, models.ApiResponse{
+			Code:    http.StatusServiceUnavailable,
+			Type:    "service_unavailable",
+			Message: "Database temporarily unavailable, please retry shortly",
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"net/http"
+
+	"oolio/internal/app/models"
+	"oolio/internal/chaos"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ChaosMiddleware struct {
+	fault *chaos.Fault
+}
+
+// NewChaosMiddleware injects the faults of fault into requests; a nil fault injects none
+func NewChaosMiddleware(fault *chaos.Fault) *ChaosMiddleware {
+	return &ChaosMiddleware{fault: fault}
+}
+
+// Inject delays the requests the fault picks for latency and fails those it picks for
+// errors with 500. The health check is left alone, so that an orchestrator doesn't restart
+// the instance under test.
+func (m *ChaosMiddleware) Inject() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if m == nil || c.Request.URL.Path == "/health" || !m.fault.Inject(c.Request.Context()) {
+			c.Next()
+			return
+		}
+
+		c.Header("X-Chaos-Injected", "true")
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ApiResponse{
+			Code:    http.StatusInternalServerError,
+			Type:    "error",
+			Message: "Injected failure",
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"oolio/internal/app/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CustomerHeader names the customer an ordering frontend acts for. Frontends sign their
+// customers in themselves; the API trusts the customer ID because it trusts the API key.
+const CustomerHeader = "X-Customer-ID"
+
+// CartTokenHeader carries the ID of a guest cart, which guests keep in place of a customer ID
+const CartTokenHeader = "X-Cart-Token"
+
+const maxCustomerIDLength = 100
+
+// CustomerID returns the customer named in the request, or "" for guests and IDs that
+// are too long or contain control characters
+func CustomerID(c *gin.Context) string {
+	id := strings.TrimSpace(c.GetHeader(CustomerHeader))
+	if len(id) > maxCustomerIDLength || strings.ContainsFunc(id, func(r rune) bool { return r < ' ' || r == 0x7f }) {
+		return ""
+	}
+	return id
+}
+
+// RequireCustomer rejects requests that don't name a customer, for routes under
+// /customer/me
+func RequireCustomer() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if CustomerID(c) == "" {
+			c.AbortWithStatusJSON(http.StatusBadRequest, models.ApiResponse{
+				Code:    http.StatusBadRequest,
+				Type:    "error",
+				Message: "The " + CustomerHeader + " header must name the customer (at most 100 characters)",
+			})
+			return
+		}
+		c.Next()
+	}
+}
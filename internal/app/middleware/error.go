@@ -1,10 +1,12 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 
 	"oolio/internal/app/models"
+	"oolio/internal/app/repository"
 
 	"github.com/gin-gonic/gin"
 )
@@ -115,7 +117,9 @@ func isForbiddenError(err error) bool {
 }
 
 func isConflictError(err error) bool {
-	return strings.Contains(err.Error(), "conflict") ||
+	var conflict *repository.ConflictError
+	return errors.As(err, &conflict) ||
+		strings.Contains(err.Error(), "conflict") ||
 		strings.Contains(err.Error(), "duplicate")
 }
 
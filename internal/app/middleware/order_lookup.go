@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"oolio/internal/app/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OrderLookupHeader carries an order lookup token, which lets a guest read the one order
+// it was issued for without the API key
+const OrderLookupHeader = "X-Order-Token"
+
+// OrderLookup issues and checks order lookup tokens. A token is its expiry and an
+// HMAC-SHA256 of the order ID and expiry, so it can't be used for another order.
+type OrderLookup struct {
+	secret []byte
+	ttl    time.Duration
+}
+
+// NewOrderLookup returns nil without a secret, which disables lookup tokens
+func NewOrderLookup(secret string, ttl time.Duration) *OrderLookup {
+	if secret == "" {
+		return nil
+	}
+	return &OrderLookup{secret: []byte(secret), ttl: ttl}
+}
+
+// Issue returns a token for orderID, or "" when lookup tokens are disabled
+func (l *OrderLookup) Issue(orderID string) string {
+	if l == nil {
+		return ""
+	}
+	expires := strconv.FormatInt(time.Now().Add(l.ttl).Unix(), 36)
+	return expires + "." + l.sign(orderID, expires)
+}
+
+// Valid reports whether token was issued for orderID and hasn't expired
+func (l *OrderLookup) Valid(token, orderID string) bool {
+	if l == nil {
+		return false
+	}
+	expires, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return false
+	}
+	unix, err := strconv.ParseInt(expires, 36, 64)
+	if err != nil || time.Now().Unix() >= unix {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(l.sign(orderID, expires)))
+}
+
+// Authorize lets requests with a valid token for the :orderId route parameter through and
+// hands requests without a token to fallback, normally the API key check. A token that
+// isn't valid for the order is rejected rather than falling back.
+func (l *OrderLookup) Authorize(fallback gin.HandlerFunc) gin.HandlerFunc {
+	if l == nil {
+		return fallback
+	}
+	return func(c *gin.Context) {
+		token := c.GetHeader(OrderLookupHeader)
+		if token == "" {
+			fallback(c)
+			return
+		}
+		if !l.Valid(token, c.Param("orderId")) {
+			c.AbortWithStatusJSON(http.StatusForbidden, models.ApiResponse{
+				Code:    http.StatusForbidden,
+				Type:    "error",
+				Message: "Invalid or expired order token",
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+func (l *OrderLookup) sign(orderID, expires string) string {
+	mac := hmac.New(sha256.New, l.secret)
+	mac.Write([]byte(orderID + "|" + expires))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"net/http"
+
+	"oolio/internal/app/models"
+	"oolio/internal/app/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+const pricingTierKey = "pricing_tier"
+
+// PricingMiddleware resolves the pricing tier of the caller: the tier of the customer
+// named in X-Customer-ID, or else of the API key
+type PricingMiddleware struct {
+	pricing services.PricingService
+}
+
+func NewPricingMiddleware(pricing services.PricingService) *PricingMiddleware {
+	return &PricingMiddleware{pricing: pricing}
+}
+
+// ResolveTier stores the caller's tier for PricingTier. A nil middleware leaves everyone
+// at retail prices.
+func (m *PricingMiddleware) ResolveTier() gin.HandlerFunc {
+	if m == nil {
+		return func(c *gin.Context) { c.Next() }
+	}
+	return func(c *gin.Context) {
+		tier, err := m.pricing.ResolveTier(c.Request.Context(), CustomerID(c), APIKey(c))
+		if err != nil {
+			// Charging list prices to a discounted customer is worse than failing the request
+			c.AbortWithStatusJSON(http.StatusInternalServerError, models.ApiResponse{
+				Code:    http.StatusInternalServerError,
+				Type:    "error",
+				Message: "Failed to resolve pricing tier",
+			})
+			return
+		}
+		c.Set(pricingTierKey, *tier)
+		c.Next()
+	}
+}
+
+// PricingTier returns the tier ResolveTier found for the request, retail without one
+func PricingTier(c *gin.Context) models.PricingTier {
+	if tier, ok := c.Get(pricingTierKey); ok {
+		return tier.(models.PricingTier)
+	}
+	return models.PricingTier{Name: models.TierRetail}
+}
@@ -3,6 +3,7 @@ package middleware
 import (
 	"net/http"
 	"strconv"
+	"sync"
 	"time"
 
 	"oolio/internal/app/services"
@@ -12,60 +13,94 @@ import (
 
 type RateLimitMiddleware struct {
 	rateLimiter services.RateLimiterService
+
+	// Per-route limit overrides, updated at runtime on config reload
+	limitsMutex sync.RWMutex
+	limits      map[string]int
 }
 
 func NewRateLimitMiddleware(rateLimiter services.RateLimiterService) *RateLimitMiddleware {
 	return &RateLimitMiddleware{
 		rateLimiter: rateLimiter,
+		limits:      make(map[string]int),
 	}
 }
 
-// RateLimit creates a middleware that limits requests based on the provided parameters
-func (m *RateLimitMiddleware) RateLimit(requestsPerMinute int, window time.Duration) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		// If rate limiter is nil (e.g., in tests), skip rate limiting
-		if m.rateLimiter == nil {
-			c.Next()
-			return
-		}
+// SetLimit overrides the requests-per-window limit for a named route group
+func (m *RateLimitMiddleware) SetLimit(name string, limit int) {
+	m.limitsMutex.Lock()
+	defer m.limitsMutex.Unlock()
+	m.limits[name] = limit
+}
 
-		// Use IP address as the key for rate limiting
-		key := "rate_limit:" + c.ClientIP()
+func (m *RateLimitMiddleware) limitFor(name string, defaultLimit int) int {
+	m.limitsMutex.RLock()
+	defer m.limitsMutex.RUnlock()
+	if limit, ok := m.limits[name]; ok && limit > 0 {
+		return limit
+	}
+	return defaultLimit
+}
 
-		// Check if request is allowed
-		allowed, err := m.rateLimiter.AllowRequest(c.Request.Context(), key, requestsPerMinute, window)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "Rate limiter error",
-			})
-			c.Abort()
-			return
-		}
+// RateLimitNamed behaves like RateLimit but resolves the limit for the named route group
+// on every request, so it picks up changes made through SetLimit
+func (m *RateLimitMiddleware) RateLimitNamed(name string, defaultLimit int, window time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		m.limit(c, m.limitFor(name, defaultLimit), window)
+	}
+}
 
-		if !allowed {
-			// Get remaining tokens for response headers
-			remaining, _ := m.rateLimiter.GetRemainingTokens(c.Request.Context(), key, requestsPerMinute)
+// RateLimit creates a middleware that limits requests based on the provided parameters
+func (m *RateLimitMiddleware) RateLimit(requestsPerMinute int, window time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		m.limit(c, requestsPerMinute, window)
+	}
+}
 
-			c.Header("X-RateLimit-Limit", strconv.Itoa(requestsPerMinute))
-			c.Header("X-RateLimit-Remaining", strconv.Itoa(remaining))
-			c.Header("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(window).Unix(), 10))
+// limit lets the request through while its client is within requestsPerMinute
+func (m *RateLimitMiddleware) limit(c *gin.Context, requestsPerMinute int, window time.Duration) {
+	// If rate limiter is nil (e.g., in tests), skip rate limiting
+	if m.rateLimiter == nil {
+		c.Next()
+		return
+	}
 
-			c.JSON(http.StatusTooManyRequests, gin.H{
-				"error":   "Rate limit exceeded",
-				"message": "Too many requests. Please try again later.",
-			})
-			c.Abort()
-			return
-		}
+	// Use IP address as the key for rate limiting
+	key := "rate_limit:" + c.ClientIP()
+
+	// Check if request is allowed
+	allowed, err := m.rateLimiter.AllowRequest(c.Request.Context(), key, requestsPerMinute, window)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Rate limiter error",
+		})
+		c.Abort()
+		return
+	}
 
-		// Add rate limit headers for successful requests
+	if !allowed {
+		// Get remaining tokens for response headers
 		remaining, _ := m.rateLimiter.GetRemainingTokens(c.Request.Context(), key, requestsPerMinute)
+
 		c.Header("X-RateLimit-Limit", strconv.Itoa(requestsPerMinute))
 		c.Header("X-RateLimit-Remaining", strconv.Itoa(remaining))
 		c.Header("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(window).Unix(), 10))
 
-		c.Next()
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error":   "Rate limit exceeded",
+			"message": "Too many requests. Please try again later.",
+		})
+		c.Abort()
+		return
 	}
+
+	// Add rate limit headers for successful requests
+	remaining, _ := m.rateLimiter.GetRemainingTokens(c.Request.Context(), key, requestsPerMinute)
+	c.Header("X-RateLimit-Limit", strconv.Itoa(requestsPerMinute))
+	c.Header("X-RateLimit-Remaining", strconv.Itoa(remaining))
+	c.Header("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(window).Unix(), 10))
+
+	c.Next()
 }
 
 // RateLimitByUser creates a middleware that limits requests per user (requires user ID in context)
@@ -0,0 +1,224 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"oolio/internal/app/models"
+	"oolio/internal/app/repository"
+	"oolio/internal/app/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ResponseCacheHeader tells clients whether a response came from the cache: HIT or MISS
+const ResponseCacheHeader = "X-Cache"
+
+// cachedHeaders are the headers handlers set that are part of the response, and are kept
+// with it in the cache
+var cachedHeaders = []string{models.TotalCountHeader}
+
+// cachedResponse is a response as kept in the cache
+type cachedResponse struct {
+	Status      int               `json:"status"`
+	ContentType string            `json:"contentType"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	ETag        string            `json:"etag"`
+	Body        []byte            `json:"body"`
+}
+
+// ResponseCacheMiddleware serves repeated GETs of catalog routes from a cache. Responses are
+// cached per catalog version, so every product write through the product service drops
+// them all at once.
+type ResponseCacheMiddleware struct {
+	cache   repository.Cache
+	version *services.CatalogVersion
+	ttl     time.Duration
+}
+
+// NewResponseCacheMiddleware caches responses in cache for up to ttl, keyed by version;
+// nil without a cache or a version
+func NewResponseCacheMiddleware(cache repository.Cache, version *services.CatalogVersion, ttl time.Duration) *ResponseCacheMiddleware {
+	if cache == nil || version == nil {
+		return nil
+	}
+	return &ResponseCacheMiddleware{cache: cache, version: version, ttl: ttl}
+}
+
+// Cache serves GETs from the cache and caches the 200 responses of those it didn't. It goes
+// after ResolveTier and the API key check, since prices follow the tier and menus the store. Requests with Cache-Control no-cache or
+// max-age=0 skip the cached copy and refresh it, no-store skips the cache altogether, and
+// responses marked no-store, no-cache or private aren't kept. A nil middleware caches nothing.
+func (m *ResponseCacheMiddleware) Cache() gin.HandlerFunc {
+	if m == nil {
+		return func(c *gin.Context) { c.Next() }
+	}
+	return func(c *gin.Context) {
+		request := parseCacheControl(c.GetHeader("Cache-Control"))
+		if c.Request.Method != http.MethodGet || request.noStore {
+			c.Next()
+			return
+		}
+
+		// The cache is best effort: when it fails the request is served as if uncached
+		ctx := c.Request.Context()
+		version, err := m.version.Current(ctx)
+		if err != nil {
+			c.Next()
+			return
+		}
+		tier := PricingTier(c)
+		key := strings.Join([]string{version, StoreID(c), tier.Name, strconv.FormatFloat(tier.DiscountPercentage, 'f', -1, 64), c.Request.URL.RequestURI()}, ":")
+
+		if !request.noCache && request.maxAge != 0 {
+			if data, ok, err := m.cache.Get(ctx, key); err == nil && ok {
+				var cached cachedResponse
+				if json.Unmarshal(data, &cached) == nil {
+					c.Header(ResponseCacheHeader, "HIT")
+					writeCachedResponse(c, &cached)
+					c.Abort()
+					return
+				}
+			}
+		}
+
+		writer := &bufferedWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+		c.Writer = writer.ResponseWriter
+
+		response := &cachedResponse{
+			Status:      writer.Status(),
+			ContentType: c.Writer.Header().Get("Content-Type"),
+			Body:        writer.body.Bytes(),
+		}
+		for _, name := range cachedHeaders {
+			if value := c.Writer.Header().Get(name); value != "" {
+				if response.Headers == nil {
+					response.Headers = make(map[string]string)
+				}
+				response.Headers[name] = value
+			}
+		}
+		if response.Status == http.StatusOK {
+			sum := sha256.Sum256(response.Body)
+			response.ETag = `"` + hex.EncodeToString(sum[:16]) + `"`
+
+			ttl := m.ttl
+			directives := parseCacheControl(c.Writer.Header().Get("Cache-Control"))
+			if directives.maxAge >= 0 {
+				ttl = min(ttl, time.Duration(directives.maxAge)*time.Second)
+			}
+			if !directives.noStore && !directives.noCache && !directives.private && ttl > 0 {
+				if data, err := json.Marshal(response); err == nil {
+					_ = m.cache.Set(ctx, key, data, ttl)
+				}
+			}
+		}
+
+		c.Header(ResponseCacheHeader, "MISS")
+		writeCachedResponse(c, response)
+	}
+}
+
+// writeCachedResponse writes response, or 304 Not Modified when the client already has it
+func writeCachedResponse(c *gin.Context, response *cachedResponse) {
+	if response.ETag != "" {
+		c.Header("ETag", response.ETag)
+		if etagMatches(c.GetHeader("If-None-Match"), response.ETag) {
+			c.Status(http.StatusNotModified)
+			c.Writer.WriteHeaderNow()
+			return
+		}
+	}
+	if response.ContentType != "" {
+		c.Header("Content-Type", response.ContentType)
+	}
+	for name, value := range response.Headers {
+		c.Header(name, value)
+	}
+	c.Status(response.Status)
+	_, _ = c.Writer.Write(response.Body)
+}
+
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+type cacheControl struct {
+	noStore bool
+	noCache bool
+	private bool
+	maxAge  int // -1 when absent
+}
+
+func parseCacheControl(header string) cacheControl {
+	directives := cacheControl{maxAge: -1}
+	for _, directive := range strings.Split(header, ",") {
+		name, value, _ := strings.Cut(strings.ToLower(strings.TrimSpace(directive)), "=")
+		switch name {
+		case "no-store":
+			directives.noStore = true
+		case "no-cache":
+			directives.noCache = true
+		case "private":
+			directives.private = true
+		case "max-age", "s-maxage":
+			if seconds, err := strconv.Atoi(strings.Trim(value, `"`)); err == nil && seconds >= 0 {
+				directives.maxAge = seconds
+			}
+		}
+	}
+	return directives
+}
+
+// bufferedWriter holds back the response of the handlers, so it can be cached and given an
+// ETag before anything is sent
+type bufferedWriter struct {
+	gin.ResponseWriter
+	body   bytes.Buffer
+	status int
+}
+
+func (w *bufferedWriter) WriteHeader(code int) {
+	if code > 0 {
+		w.status = code
+	}
+}
+
+func (w *bufferedWriter) WriteHeaderNow() {}
+
+func (w *bufferedWriter) Write(data []byte) (int, error) {
+	return w.body.Write(data)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
+
+func (w *bufferedWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+func (w *bufferedWriter) Size() int {
+	return w.body.Len()
+}
+
+func (w *bufferedWriter) Written() bool {
+	return w.status != 0 || w.body.Len() > 0
+}
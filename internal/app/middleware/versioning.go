@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIVersionHeader tells clients which API version served the response
+const APIVersionHeader = "API-Version"
+
+const apiVersionKey = "apiVersion"
+
+// Version tags the requests of a route group with its API version, so a handler shared
+// between versions can tell which contract it is serving
+func Version(version int) gin.HandlerFunc {
+	value := strconv.Itoa(version)
+	return func(c *gin.Context) {
+		c.Set(apiVersionKey, version)
+		c.Header(APIVersionHeader, value)
+		c.Next()
+	}
+}
+
+// APIVersion returns the API version of the request; routes outside a versioned group,
+// such as /health, count as version 1
+func APIVersion(c *gin.Context) int {
+	if version, ok := c.Get(apiVersionKey); ok {
+		return version.(int)
+	}
+	return 1
+}
+
+// AtLeast reports whether the request is served under version or a later one. Breaking
+// changes are gated on it so earlier versions keep their behaviour:
+//
+//	if middleware.AtLeast(c, 2) { /* new contract */ }
+func AtLeast(c *gin.Context, version int) bool {
+	return APIVersion(c) >= version
+}
+
+// DeprecationMiddleware announces that an API version is deprecated and when it goes away,
+// using the Deprecation (RFC 9745) and Sunset (RFC 8594) headers
+type DeprecationMiddleware struct {
+	deprecatedAt time.Time
+	sunset       time.Time
+}
+
+// NewDeprecationMiddleware returns the middleware; a zero time leaves its header out
+func NewDeprecationMiddleware(deprecatedAt, sunset time.Time) *DeprecationMiddleware {
+	return &DeprecationMiddleware{deprecatedAt: deprecatedAt, sunset: sunset}
+}
+
+// Deprecate adds the headers to responses under prefix and links each one to the same path
+// under successor, e.g. /api/v1/product to /api/v2/product
+func (m *DeprecationMiddleware) Deprecate(prefix, successor string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if m == nil || m.deprecatedAt.IsZero() && m.sunset.IsZero() {
+			c.Next()
+			return
+		}
+
+		if !m.deprecatedAt.IsZero() {
+			c.Header("Deprecation", "@"+strconv.FormatInt(m.deprecatedAt.Unix(), 10))
+		}
+		if !m.sunset.IsZero() {
+			c.Header("Sunset", m.sunset.UTC().Format(http.TimeFormat))
+		}
+		if rest, ok := strings.CutPrefix(c.Request.URL.Path, prefix); ok {
+			c.Header("Link", "<"+successor+rest+`>; rel="successor-version"`)
+		}
+		c.Next()
+	}
+}
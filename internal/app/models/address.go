@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// DeliveryAddress is where an order is delivered
+type DeliveryAddress struct {
+	Line1        string `json:"line1" example:"1 Collins St"`
+	Line2        string `json:"line2,omitempty" example:"Level 3"`
+	City         string `json:"city" example:"Melbourne"`
+	Region       string `json:"region,omitempty" example:"VIC"`
+	PostalCode   string `json:"postalCode,omitempty" example:"3000"`
+	Country      string `json:"country" example:"AU" description:"ISO 3166-1 alpha-2 country code"`
+	Instructions string `json:"instructions,omitempty" example:"Ring the bell twice"`
+}
+
+// Address is a delivery address saved in a customer's address book
+type Address struct {
+	ID    string `json:"id"`
+	Label string `json:"label,omitempty" example:"Home"`
+	DeliveryAddress
+	IsDefault bool      `json:"isDefault" description:"Used for orders that name no address"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// AddressReq adds an address to the address book
+type AddressReq struct {
+	Label string `json:"label" example:"Home"`
+	DeliveryAddress
+	IsDefault bool `json:"isDefault" description:"Make this the default address; the first address always is"`
+}
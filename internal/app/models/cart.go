@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// CartItem is one product line of a cart, with the options it is ordered with
+type CartItem struct {
+	ProductID   string   `json:"productId" description:"ID of the product"`
+	Quantity    int      `json:"quantity" description:"Item count"`
+	VariantID   string   `json:"variantId,omitempty" description:"Variant of the product; its first when omitted"`
+	ModifierIDs []string `json:"modifierIds,omitempty" description:"Modifiers of the product"`
+}
+
+// Cart holds the items a customer or guest intends to order until it is checked out
+type Cart struct {
+	ID        string     `json:"id" description:"Guest carts: send it back in X-Cart-Token to use the cart again"`
+	Items     []CartItem `json:"items"`
+	Products  []Product  `json:"products" description:"The products in the cart"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty" description:"Guest carts only; every change extends it"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+}
+
+// CartItemReq adds a product to the cart
+type CartItemReq struct {
+	ProductID string `json:"productId" binding:"required"`
+	Quantity  int    `json:"quantity" description:"Added to the quantity already in the cart; defaults to 1"`
+
+	VariantID   string   `json:"variantId,omitempty" binding:"omitempty,uuid4" description:"Variant of the product; replaces the one of a product already in the cart"`
+	ModifierIDs []string `json:"modifierIds,omitempty" binding:"max=20,dive,uuid4" description:"Modifiers of the product, each at most once; replace those of a product already in the cart"`
+}
+
+// CartQuantityReq replaces the quantity of a product in the cart
+type CartQuantityReq struct {
+	Quantity int `json:"quantity" binding:"required"`
+}
+
+// CheckoutReq places the cart's items as an order; the fields mean the same as in OrderReq
+type CheckoutReq struct {
+	CouponCode      string           `json:"couponCode" description:"Optional promo code applied to the order"`
+	AddressID       string           `json:"addressId,omitempty" description:"Saved address of the customer named in X-Customer-ID; \"default\" picks their default"`
+	DeliveryAddress *DeliveryAddress `json:"deliveryAddress,omitempty"`
+	Phone           string           `json:"phone,omitempty" example:"+61400000000" description:"Guest phone in international format"`
+	Email           string           `json:"email,omitempty" example:"jo@example.com" description:"Guest email"`
+	PaymentIntentID string           `json:"paymentIntentId,omitempty" description:"Authorized payment intent for the order"`
+	PaymentMethod   string           `json:"paymentMethod,omitempty" example:"card" description:"card, cash (on delivery) or counter (when collected)"`
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Coupon is a code stored in the database with its own discount
+type Coupon struct {
+	Code               string     `json:"code"`
+	DiscountPercentage float64    `json:"discountPercentage"`
+	Segment            string     `json:"segment,omitempty" description:"Only customers in this segment may use the coupon"`
+	SingleUse          bool       `json:"singleUse,omitempty" description:"Each customer may use the coupon once"`
+	StoreID            string     `json:"storeId,omitempty" description:"The only store the coupon is valid at; empty for every store"`
+	DeletedAt          *time.Time `json:"deletedAt,omitempty"`
+}
+
+// CouponRedeemer returns who a single-use coupon on the order is redeemed by: the
+// customer, or for guests their phone or else their email. It is "" for guests that gave
+// neither, who can't use single-use coupons.
+func (r *OrderReq) CouponRedeemer() string {
+	switch {
+	case r.CustomerID != "":
+		return "customer:" + r.CustomerID
+	case r.Phone != "":
+		return "phone:" + r.Phone
+	case r.Email != "":
+		return "email:" + strings.ToLower(r.Email)
+	}
+	return ""
+}
+
+type CouponFileStats struct {
+	Filename      string  `json:"filename"`
+	RowsProcessed int     `json:"rowsProcessed"`
+	CodesAccepted int     `json:"codesAccepted"`
+	Duplicates    int     `json:"duplicates" description:"Codes repeated within this file; codes shared with other files are not counted"`
+	DuplicateRate float64 `json:"duplicateRate"`
+	ParseErrors   int     `json:"parseErrors"`
+	Error         string  `json:"error,omitempty"`
+	FromCache     bool    `json:"fromCache,omitempty" description:"The download failed and the last cached copy was used"`
+	DurationMs    int64   `json:"durationMs"`
+}
+
+type CouponStats struct {
+	ValidCoupons        int               `json:"validCoupons"`
+	StoredCoupons       int               `json:"storedCoupons"`
+	FilesProcessed      bool              `json:"filesProcessed"`
+	RefreshInProgress   bool              `json:"refreshInProgress"`
+	RefreshCount        int               `json:"refreshCount"`
+	ErrorCount          int               `json:"errorCount"`
+	LastRefreshStarted  *time.Time        `json:"lastRefreshStarted,omitempty"`
+	LastRefreshFinished *time.Time        `json:"lastRefreshFinished,omitempty"`
+	LastRefreshMs       int64             `json:"lastRefreshDurationMs"`
+	Files               []CouponFileStats `json:"files"`
+}
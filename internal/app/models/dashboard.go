@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// Dashboard is what an operations dashboard shows at a glance: today's sales so far, the
+// order queue's health, what sells best today and which coupons are being used
+type Dashboard struct {
+	Date        string         `json:"date" example:"2026-10-16" description:"Today, in timeZone"`
+	TimeZone    string         `json:"timeZone" example:"Australia/Sydney"`
+	Today       SalesFigures   `json:"today"`
+	Queue       QueueHealth    `json:"queue"`
+	TopProducts []ProductSales `json:"topProducts"`
+	Coupons     []CouponUsage  `json:"coupons" description:"Coupons used today, most used first"`
+	GeneratedAt time.Time      `json:"generatedAt"`
+}
+
+// QueueHealth counts the order queue's items by status, with how long the oldest pending
+// one has been due
+type QueueHealth struct {
+	Pending    int `json:"pending"`
+	Processing int `json:"processing"`
+	Completed  int `json:"completed"`
+	Failed     int `json:"failed" description:"Failed orders, waiting for a retry or for an admin to retry them"`
+	// OldestWaitSeconds is how long the oldest due pending order has waited, 0 when none is due
+	OldestWaitSeconds int        `json:"oldestWaitSeconds"`
+	OldestDueAt       *time.Time `json:"oldestDueAt,omitempty"`
+}
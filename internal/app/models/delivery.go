@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+// Delivery statuses, in the order a delivery goes through them; the last three end it
+const (
+	DeliveryStatusRequested = "requested" // booked, waiting for a courier
+	DeliveryStatusAssigned  = "assigned"  // a courier is on the way to the store
+	DeliveryStatusPickedUp  = "picked_up"
+	DeliveryStatusDelivered = "delivered"
+	DeliveryStatusCancelled = "cancelled"
+	DeliveryStatusFailed    = "failed"
+)
+
+// Delivery is the courier booked for an order with a delivery address
+type Delivery struct {
+	ID          string     `json:"id"`
+	OrderID     string     `json:"orderId"`
+	Provider    string     `json:"provider" example:"mock"`
+	ExternalID  string     `json:"externalId,omitempty" description:"The provider's job ID"`
+	Status      string     `json:"status" example:"picked_up" description:"requested, assigned, picked_up, delivered, cancelled or failed"`
+	TrackingURL string     `json:"trackingUrl,omitempty" description:"Page following the courier, for the customer"`
+	CourierName string     `json:"courierName,omitempty"`
+	EstimatedAt *time.Time `json:"estimatedDeliveryAt,omitempty"`
+	Error       string     `json:"error,omitempty" description:"Why the delivery failed"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+}
+
+// Finished reports whether the delivery has ended and won't change any more
+func (d *Delivery) Finished() bool {
+	switch d.Status {
+	case DeliveryStatusDelivered, DeliveryStatusCancelled, DeliveryStatusFailed:
+		return true
+	}
+	return false
+}
+
+// DeliveryStatusUpdate is posted by a webhook delivery provider when a delivery moves on
+type DeliveryStatusUpdate struct {
+	OrderID     string     `json:"orderId"`
+	ExternalID  string     `json:"externalId,omitempty" description:"The provider's job ID, when it is first known"`
+	Status      string     `json:"status" example:"assigned"`
+	TrackingURL string     `json:"trackingUrl,omitempty"`
+	CourierName string     `json:"courierName,omitempty"`
+	EstimatedAt *time.Time `json:"estimatedDeliveryAt,omitempty"`
+	Error       string     `json:"error,omitempty"`
+}
+
+// DeliveryJob is what the webhook delivery provider posts: the order and address to book a
+// courier for, or the job to cancel
+type DeliveryJob struct {
+	OrderID    string           `json:"orderId"`
+	ExternalID string           `json:"externalId,omitempty"`
+	Address    *DeliveryAddress `json:"address,omitempty"`
+	Order      *Order           `json:"order,omitempty"`
+}
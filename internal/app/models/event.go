@@ -0,0 +1,47 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Domain event types recorded in the outbox
+const (
+	EventOrderQueued     = "order.queued"
+	EventOrderCreated    = "order.created"
+	EventOrderCompleted  = "order.completed"
+	EventOrderFailed     = "order.failed"
+	EventOrderDeleted    = "order.deleted"
+	EventProductCreated  = "product.created"
+	EventProductUpdated  = "product.updated"
+	EventProductDeleted  = "product.deleted"
+	EventProductRestored = "product.restored"
+
+	// Refunds of an order move from requested to refunded or refund_failed
+	EventOrderRefundRequested = "order.refund_requested"
+	EventOrderRefunded        = "order.refunded"
+	EventOrderRefundFailed    = "order.refund_failed"
+
+	// What became of a text about an order; the payload is an SMSStatus
+	EventOrderSMSStatus = "order.sms_status"
+
+	// The daily sales digest, posted to the digest webhook rather than recorded; the
+	// payload is a SalesDigest
+	EventSalesDigest = "sales.digest"
+
+	// Delivery jobs, posted to the delivery webhook rather than recorded; the payload is a
+	// DeliveryJob
+	EventDeliveryRequested = "delivery.requested"
+	EventDeliveryCancelled = "delivery.cancelled"
+)
+
+// OutboxEvent is a domain event stored in the same transaction as the change it describes
+type OutboxEvent struct {
+	ID            string          `json:"id"`
+	AggregateType string          `json:"aggregateType"` // "order", "order_queue_item" or "product"
+	AggregateID   string          `json:"aggregateId"`
+	EventType     string          `json:"eventType"`
+	Payload       json.RawMessage `json:"payload"`
+	CreatedAt     time.Time       `json:"createdAt"`
+	Attempts      int             `json:"attempts"` // Failed publish attempts so far
+}
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// GiftCard holds store credit that orders spend by naming its code
+type GiftCard struct {
+	Code           string     `json:"code" example:"GC-7KQ2-M9XD-4HPA"`
+	InitialBalance Money      `json:"initialBalance" example:"50.00"`
+	Balance        Money      `json:"balance" example:"24.02" description:"What is left to spend"`
+	ExpiresAt      *time.Time `json:"expiresAt,omitempty" description:"The card can't be spent from then on"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	UpdatedAt      time.Time  `json:"updatedAt"`
+}
+
+// Expired reports whether the card can no longer be spent at now
+func (g *GiftCard) Expired(now time.Time) bool {
+	return g.ExpiresAt != nil && !now.Before(*g.ExpiresAt)
+}
+
+// GiftCardReq issues a gift card; without a code one is generated
+type GiftCardReq struct {
+	Code      string     `json:"code,omitempty" example:"GC-7KQ2-M9XD-4HPA" description:"Letters, digits and dashes; generated when empty"`
+	Amount    Money      `json:"amount" binding:"required" example:"50.00"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+// GiftCardBalance is what customers may see of a card
+type GiftCardBalance struct {
+	Code      string     `json:"code" example:"GC-7KQ2-M9XD-4HPA"`
+	Balance   Money      `json:"balance" example:"24.02"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
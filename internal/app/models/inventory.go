@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// StockLevel is what is left of a product whose stock is counted. Products whose stock
+// isn't counted are never out of stock.
+type StockLevel struct {
+	ProductID string    `json:"productId"`
+	Name      string    `json:"name,omitempty" example:"Chicken Waffle"`
+	Category  string    `json:"category,omitempty" example:"Waffle"`
+	StoreID   string    `json:"storeId,omitempty" description:"Store whose menu the product is on"`
+	Quantity  int       `json:"quantity" example:"3" description:"Units left"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// RestockReq adds units to a product's stock
+type RestockReq struct {
+	Quantity int `json:"quantity" binding:"min=1,max=100000" example:"24" description:"Units added; a product whose stock wasn't counted starts with them"`
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Invoice is the tax invoice of an order. It is kept as issued: later changes to the order,
+// the seller or the tax rate don't alter it.
+type Invoice struct {
+	Number     string        `json:"number" example:"INV-000042" description:"Series and its next number, without gaps"`
+	OrderID    string        `json:"orderId"`
+	StoreID    string        `json:"storeId" description:"Store that issued the invoice; each store numbers its own"`
+	IssuedAt   time.Time     `json:"issuedAt"`
+	Seller     InvoiceSeller `json:"seller"`
+	CustomerID string        `json:"customerId,omitempty"`
+	Currency   string        `json:"currency" example:"aud"`
+	Lines      []InvoiceLine `json:"lines"`
+	Subtotal   Money         `json:"subtotal" example:"100.00" description:"Sum of the lines, tax included"`
+	Discounts  Money         `json:"discounts" example:"10.00"`
+	Total      Money         `json:"total" example:"90.00" description:"Subtotal less discounts, tax included"`
+	Taxes      []InvoiceTax  `json:"taxes" description:"Tax included in the total, per rate; empty when no tax applies"`
+	// StoreCredit is the part of the total paid from a gift card; it is a payment, so it
+	// doesn't lower the tax
+	StoreCredit   Money  `json:"storeCredit,omitempty" example:"10.00"`
+	PaymentMethod string `json:"paymentMethod,omitempty" example:"card"`
+}
+
+type InvoiceSeller struct {
+	Name  string `json:"name" example:"Oolio Burgers Pty Ltd"`
+	TaxID string `json:"taxId,omitempty" example:"51 824 753 556" description:"e.g. the ABN"`
+}
+
+type InvoiceLine struct {
+	ProductID   string `json:"productId"`
+	Description string `json:"description" example:"Chicken Waffle"`
+	Quantity    int    `json:"quantity" example:"2"`
+	UnitPrice   Money  `json:"unitPrice" example:"12.99" description:"Price charged, tax included"`
+	Amount      Money  `json:"amount" example:"25.98"`
+}
+
+type InvoiceTax struct {
+	Name    string  `json:"name" example:"GST"`
+	Rate    float64 `json:"rate" example:"10" description:"Percentage"`
+	Taxable Money   `json:"taxable" example:"81.82" description:"Amount the tax is on, tax excluded"`
+	Amount  Money   `json:"amount" example:"8.18"`
+}
+
+// InvoiceNumber formats the number of an invoice within its series
+func InvoiceNumber(series string, number int64) string {
+	return fmt.Sprintf("%s-%06d", series, number)
+}
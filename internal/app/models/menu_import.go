@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// MenuImportResult summarises one reconciliation of the products against an external menu
+type MenuImportResult struct {
+	Source     string    `json:"source" example:"generic"`
+	Created    int       `json:"created"`
+	Updated    int       `json:"updated"`
+	Restored   int       `json:"restored" description:"Archived products that reappeared on the menu"`
+	Archived   int       `json:"archived" description:"Products dropped from the menu, soft deleted"`
+	Unchanged  int       `json:"unchanged"`
+	Errors     []string  `json:"errors,omitempty" description:"Menu items that could not be applied; the rest of the import still ran"`
+	StartedAt  time.Time `json:"startedAt"`
+	FinishedAt time.Time `json:"finishedAt"`
+}
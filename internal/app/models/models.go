@@ -1,12 +1,97 @@
 package models
 
+import "time"
+
 type OrderReq struct {
 	CouponCode string      `json:"couponCode" description:"Optional promo code applied to the order"`
-	Items      []OrderItem `json:"items" binding:"required"`
+	Items      []OrderItem `json:"items" binding:"required,min=1,dive"`
+
+	// Delivery is optional. AddressID picks an address from the customer's address book,
+	// which is copied into DeliveryAddress when the order is placed.
+	AddressID       string           `json:"addressId,omitempty" description:"Saved address of the customer named in X-Customer-ID; \"default\" picks their default"`
+	DeliveryAddress *DeliveryAddress `json:"deliveryAddress,omitempty"`
+
+	// CustomerID is taken from X-Customer-ID when the order is placed; a value in the body
+	// is ignored. It is kept so queued orders still know their customer.
+	CustomerID string `json:"customerId,omitempty" description:"Set from X-Customer-ID; ignored in the body"`
+
+	// PricingTier is the caller's tier when the order is placed; a value in the body is
+	// ignored. The order is priced with the tier's discount when it is processed.
+	PricingTier string `json:"pricingTier,omitempty" description:"Set from the caller's pricing tier; ignored in the body"`
+
+	// StoreID is the store of the API key or X-Store-ID the order is placed with; a value in
+	// the body is ignored. Orders queued before stores existed have none and belong to the
+	// default store.
+	StoreID string `json:"storeId,omitempty" description:"Set from the caller's store; ignored in the body"`
+
+	// Sandbox marks orders placed with a test API key; a value in the body is ignored. They
+	// are processed like any other, without taking payments or notifying anyone.
+	Sandbox bool `json:"sandbox,omitempty" description:"Set for orders placed with a test key; ignored in the body"`
+
+	// TableNumber is the dine-in table the order is placed at, e.g. from a QR code on it.
+	// Table orders join the table's open tab, named by TabID, and are paid when it is closed.
+	TableNumber string `json:"tableNumber,omitempty" example:"12" description:"Dine-in table; the order joins its open tab"`
+	TabID       string `json:"tabId,omitempty" description:"Set to the table's open tab; ignored in the body"`
+
+	// ScheduledFor is when the order is to be processed rather than straight away: the
+	// time asked for in the body, or the store's next opening when it was placed while the
+	// store was closed. Its queue item waits until then.
+	ScheduledFor *time.Time `json:"scheduledFor,omitempty" description:"Process the order at this time, when the store is open"`
+
+	// StockReservation names the stock set aside for the order when it was queued, which
+	// is taken when it is processed; a value in the body is ignored
+	StockReservation string `json:"stockReservation,omitempty" description:"Set when stock is reserved for the order; ignored in the body"`
+
+	// Currency is the one the caller settles in, from X-Currency when the order is placed;
+	// a value in the body is ignored. The order's total is converted to it when it is priced.
+	Currency string `json:"currency,omitempty" example:"usd" description:"Set from X-Currency; ignored in the body"`
+
+	// Guests' contact details, which single-use coupons are tracked against. A phone
+	// verified with X-Verification-Token replaces the phone in the body.
+	Phone string `json:"phone,omitempty" example:"+61400000000" description:"Guest phone in international format"`
+	Email string `json:"email,omitempty" example:"jo@example.com" description:"Guest email"`
+
+	// PaymentIntentID names an intent from POST /payments/intents that the customer has
+	// authorized; it is captured when the order is created
+	PaymentIntentID string `json:"paymentIntentId,omitempty" description:"Authorized payment intent for the order"`
+
+	// PaymentMethod is card, cash or counter; empty picks the first method in PAYMENT_METHODS
+	PaymentMethod string `json:"paymentMethod,omitempty" example:"card" description:"card, cash (on delivery) or counter (when collected)"`
+
+	// GiftCardCode pays for the order, after any coupon, from the card's balance; the
+	// rest is paid with the payment method
+	GiftCardCode string `json:"giftCardCode,omitempty" example:"GC-7KQ2-M9XD-4HPA" description:"Gift card to spend store credit from"`
+}
+
+// ProductIDs returns the products of the order's items, each once, in the order they first
+// appear, so a cart with repeated lines still loads its products in one small query
+func (r *OrderReq) ProductIDs() []string {
+	seen := make(map[string]bool, len(r.Items))
+	ids := make([]string, 0, len(r.Items))
+	for _, item := range r.Items {
+		if !seen[item.ProductID] {
+			seen[item.ProductID] = true
+			ids = append(ids, item.ProductID)
+		}
+	}
+	return ids
 }
 
 type ApiResponse struct {
 	Code    int    `json:"code" format:"int32"`
 	Type    string `json:"type"`
 	Message string `json:"message"`
+	// Errors lists every field of a request body that failed validation
+	Errors []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError is a request field that failed a validation rule
+type FieldError struct {
+	Field   string `json:"field" example:"items[0].quantity" description:"Path of the field in the JSON body"`
+	Rule    string `json:"rule" example:"min" description:"The binding rule it failed, e.g. required, min or uuid4"`
+	Message string `json:"message" example:"items[0].quantity must be at least 1"`
+}
+
+type LogLevelReq struct {
+	Level string `json:"level" binding:"required" example:"debug"`
 }
@@ -0,0 +1,180 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Notification kinds customers choose between
+const (
+	NotificationEmailReceipt = "email_receipt" // Receipt emailed when an order is placed
+	NotificationSMSReady     = "sms_ready"     // Text when an order is ready
+	NotificationEmailReady   = "email_ready"   // Email when an order is ready
+	NotificationMarketing    = "marketing"     // Promotions by email
+)
+
+// NotificationPreferencesReq sets which notifications a customer wants and where to send
+// them; it replaces the previous preferences
+type NotificationPreferencesReq struct {
+	Email           string `json:"email" example:"jo@example.com"`
+	Phone           string `json:"phone" example:"+61400000000" description:"International format"`
+	EmailReceipt    bool   `json:"emailReceipt" description:"Email a receipt when an order is placed"`
+	SMSReadyAlert   bool   `json:"smsReadyAlert" description:"Text when an order is ready"`
+	EmailReadyAlert bool   `json:"emailReadyAlert" description:"Email when an order is ready"`
+	Marketing       bool   `json:"marketing" description:"Promotions by email"`
+}
+
+// NotificationPreferences are a customer's saved preferences; UpdatedAt is nil for
+// customers that never saved any, who get the defaults
+type NotificationPreferences struct {
+	NotificationPreferencesReq
+	UpdatedAt *time.Time `json:"updatedAt,omitempty"`
+}
+
+// DefaultNotificationPreferences are used for customers without saved preferences: email
+// receipts, once they give an email, and nothing else
+func DefaultNotificationPreferences() NotificationPreferences {
+	return NotificationPreferences{NotificationPreferencesReq: NotificationPreferencesReq{EmailReceipt: true}}
+}
+
+// Wants reports whether the customer opted in to a kind of notification and gave the
+// email or phone it is sent to
+func (p NotificationPreferences) Wants(kind string) bool {
+	switch kind {
+	case NotificationEmailReceipt:
+		return p.EmailReceipt && p.Email != ""
+	case NotificationSMSReady:
+		return p.SMSReadyAlert && p.Phone != ""
+	case NotificationEmailReady:
+		return p.EmailReadyAlert && p.Email != ""
+	case NotificationMarketing:
+		return p.Marketing && p.Email != ""
+	}
+	return false
+}
+
+// Statuses of a text recorded on its order's events. Texts go from sent to delivered or
+// undelivered as the provider reports; failed ones were never accepted by the provider.
+const (
+	SMSStatusSent        = "sent"
+	SMSStatusFailed      = "failed"
+	SMSStatusRateLimited = "rate_limited" // Not sent; the phone had too many texts lately
+	SMSStatusDelivered   = "delivered"
+	SMSStatusUndelivered = "undelivered"
+)
+
+// SMSStatus says what became of a text
+type SMSStatus struct {
+	Kind      string `json:"kind,omitempty" example:"sms_ready"`
+	Status    string `json:"status" example:"delivered"`
+	MessageID string `json:"messageId,omitempty" description:"The provider's ID of the message"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Channels notifications are sent on
+const (
+	NotificationChannelEmail = "email"
+	NotificationChannelSMS   = "sms"
+	NotificationChannelPush  = "push" // Recipient is the device, see PushRecipient
+)
+
+// Statuses of a notification in the outbox. Pending ones are retried with backoff until
+// they are sent or run out of attempts, when they stay dead until an admin redelivers them.
+const (
+	NotificationPending = "pending"
+	NotificationSent    = "sent"
+	NotificationDead    = "dead"
+)
+
+// NotificationMessage is a notification waiting in the outbox to be sent by the worker
+type NotificationMessage struct {
+	ID        string `json:"id"`
+	Channel   string `json:"channel" example:"email"`
+	Kind      string `json:"kind" example:"email_receipt"`
+	Recipient string `json:"recipient" description:"Email address, phone, or platform:token of a device"`
+	Subject   string `json:"subject,omitempty"`
+	Text      string `json:"text"`
+	// OrderID is set for notifications about an order, whose texts are recorded on its events
+	OrderID       string     `json:"orderId,omitempty"`
+	Status        string     `json:"status" example:"pending"`
+	Attempts      int        `json:"attempts"`
+	NextAttemptAt time.Time  `json:"nextAttemptAt"`
+	LastError     string     `json:"lastError,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	SentAt        *time.Time `json:"sentAt,omitempty"`
+}
+
+// NotificationDeliveryStats describe the notification outbox
+type NotificationDeliveryStats struct {
+	Pending  int `json:"pending"`
+	Retrying int `json:"retrying" description:"Pending notifications that failed at least once"`
+	Sent     int `json:"sent"`
+	Dead     int `json:"dead" description:"Notifications that ran out of attempts"`
+	// OldestPendingAt is when the longest waiting pending notification was queued
+	OldestPendingAt *time.Time `json:"oldestPendingAt,omitempty"`
+}
+
+// NotificationDeliveryResult counts what became of a batch of notifications
+type NotificationDeliveryResult struct {
+	Sent   int      `json:"sent"`
+	Failed int      `json:"failed" description:"Failed notifications that will be retried"`
+	Dead   int      `json:"dead" description:"Failed notifications that ran out of attempts"`
+	Errors []string `json:"errors,omitempty"`
+}
+
+// Kinds of the entries of a customer's in-app notification feed
+const (
+	FeedOrderPlaced = "order_placed"
+	FeedOrderReady  = "order_ready"
+)
+
+// FeedNotification is an entry of a customer's in-app notification feed
+type FeedNotification struct {
+	ID        string     `json:"id"`
+	Kind      string     `json:"kind" example:"order_ready"`
+	Title     string     `json:"title" example:"Your order is ready"`
+	Body      string     `json:"body" example:"Order 1A2B3C4D is ready."`
+	OrderID   string     `json:"orderId,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+	ReadAt    *time.Time `json:"readAt,omitempty" description:"When the customer read it; unread notifications have none"`
+}
+
+// NotificationFeed is a page of a customer's in-app notifications, newest first
+type NotificationFeed struct {
+	Notifications []FeedNotification `json:"notifications"`
+	Total         int                `json:"total" description:"Notifications matching the request, on every page"`
+	Unread        int                `json:"unread" description:"Unread notifications, e.g. for a badge"`
+	Limit         int                `json:"limit"`
+	Offset        int                `json:"offset"`
+}
+
+// Platforms of the devices customers register for push notifications
+const (
+	PushPlatformFCM  = "fcm"  // Firebase Cloud Messaging, for Android and web
+	PushPlatformAPNs = "apns" // Apple Push Notification service
+)
+
+// PushDeviceReq registers a customer's device for push notifications
+type PushDeviceReq struct {
+	Platform string `json:"platform" binding:"required" example:"fcm" description:"fcm or apns"`
+	Token    string `json:"token" binding:"required" description:"The registration token FCM or APNs gave the app"`
+}
+
+// PushDevice is a device a customer registered for push notifications
+type PushDevice struct {
+	Platform  string    `json:"platform" example:"apns"`
+	Token     string    `json:"token"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// PushRecipient is the Recipient of a push notification queued for the device
+func PushRecipient(platform, token string) string {
+	return platform + ":" + token
+}
+
+// ParsePushRecipient splits the Recipient of a push notification into the device's
+// platform and token
+func ParsePushRecipient(recipient string) (platform, token string, ok bool) {
+	platform, token, ok = strings.Cut(recipient, ":")
+	return platform, token, ok && token != ""
+}
@@ -1,19 +1,107 @@
 package models
 
-import "time"
+import (
+	"strings"
+	"time"
+)
+
+// Order statuses as stored in the orders table
+const (
+	OrderStatusPending   = "pending"
+	OrderStatusCompleted = "completed"
+	OrderStatusCancelled = "cancelled"
+	OrderStatusFailed    = "failed"
+)
+
+// Queue error codes say what a failed or waiting queue item is stuck on
+const (
+	QueueErrorOrderFailed          = "order_failed"           // the order couldn't be created
+	QueueErrorPaymentRequired      = "payment_required"       // waiting for the customer to pay
+	QueueErrorPaymentNotAuthorized = "payment_not_authorized" // the payment intent isn't authorized yet
+	QueueErrorPaymentExpired       = "payment_expired"        // not paid for within the payment window
+	QueueErrorCaptureFailed        = "capture_failed"         // the order exists but its payment wasn't captured
+	QueueErrorOutOfStock           = "out_of_stock"           // a product hasn't enough left; not retried
+	QueueErrorInvalidOption        = "invalid_option"         // an item's variant or modifier is gone; not retried
+)
 
 type OrderItem struct {
-	ProductID string  `json:"productId" description:"ID of the product"`
-	Quantity  int     `json:"quantity" description:"Item count"`
-	Price     float64 `json:"price" description:"Price at time of order"`
+	ProductID string `json:"productId" binding:"required,uuid4" description:"ID of the product"`
+	Quantity  int    `json:"quantity" binding:"min=1" description:"Item count"`
+	Price     Money  `json:"price" description:"Price at time of order, with the options chosen"`
+
+	VariantID   string   `json:"variantId,omitempty" binding:"omitempty,uuid4" description:"Variant of the product; its first when omitted"`
+	ModifierIDs []string `json:"modifierIds,omitempty" binding:"max=20,dive,uuid4" description:"Modifiers of the product, each at most once"`
+	// Options are the variant and modifiers the item was charged for, as they were when
+	// the order was priced, so renaming or repricing them doesn't change past orders
+	Options []OrderItemOption `json:"options,omitempty" description:"The options charged for; ignored in the body"`
+}
+
+// OrderItemOption is a product option as an order item was charged for it
+type OrderItemOption struct {
+	ID         string `json:"id"`
+	Kind       string `json:"kind" example:"variant" description:"variant or modifier"`
+	Name       string `json:"name" example:"Large"`
+	PriceDelta Money  `json:"priceDelta" example:"1.50"`
+}
+
+// Describe names the item as the product named name with its options, e.g. for invoice lines
+func (i OrderItem) Describe(name string) string {
+	if len(i.Options) == 0 {
+		return name
+	}
+	options := make([]string, len(i.Options))
+	for j, option := range i.Options {
+		options[j] = option.Name
+	}
+	return name + " (" + strings.Join(options, ", ") + ")"
 }
 
 type Order struct {
-	ID        string      `json:"id" example:"0000-0000-0000-0000"`
-	Total     float64     `json:"total" example:"90.0"`
-	Discounts float64     `json:"discounts" example:"10.0"`
-	Items     []OrderItem `json:"items"`
-	Products  []Product   `json:"products"`
+	ID         string      `json:"id" example:"0000-0000-0000-0000"`
+	Total      Money       `json:"total" example:"90.0"`
+	Discounts  Money       `json:"discounts" example:"10.0"`
+	Items      []OrderItem `json:"items"`
+	Products   []Product   `json:"products"`
+	Status     string      `json:"status,omitempty"`
+	CustomerID string      `json:"customerId,omitempty" description:"X-Customer-ID the order was placed with; empty for guests"`
+	StoreID    string      `json:"storeId,omitempty" description:"Store the order was placed at"`
+	Version    int         `json:"version"`
+	CreatedAt  time.Time   `json:"createdAt"`
+	UpdatedAt  time.Time   `json:"updatedAt"`
+	Sandbox    bool        `json:"sandbox,omitempty" description:"Placed with a test key; no payment was taken and no one was notified"`
+
+	PaymentMethod string `json:"paymentMethod,omitempty" example:"card" description:"card, cash or counter"`
+	// Orders paid through a payment intent; empty for orders placed without one
+	PaymentIntentID string `json:"paymentIntentId,omitempty"`
+	PaymentStatus   string `json:"paymentStatus,omitempty" example:"captured" description:"authorized, or captured once the payment was taken"`
+
+	// StoreCredit is the part of the total, after discounts, paid from the gift card
+	StoreCredit  Money  `json:"storeCredit,omitempty" example:"10.0" description:"Paid from the gift card, after discounts"`
+	GiftCardCode string `json:"giftCardCode,omitempty"`
+
+	// CouponCode is the coupon the discounts came from, in upper case
+	CouponCode string `json:"couponCode,omitempty"`
+
+	// Settlement is the amount due in the currency the order was placed with, when that
+	// isn't the payment currency. It is kept with the order's queue item, not the orders
+	// table.
+	Settlement *Settlement `json:"settlement,omitempty"`
+
+	// Delivery tracks the courier of an order with a delivery address. It is kept in the
+	// deliveries table and added when the order is read.
+	Delivery *Delivery `json:"delivery,omitempty"`
+}
+
+// Settlement is an amount converted to the currency a partner settles in
+type Settlement struct {
+	Currency  string  `json:"currency" example:"usd"`
+	Rate      float64 `json:"rate" example:"0.6512" description:"Units of the currency per unit of PAYMENT_CURRENCY, when the order was priced"`
+	AmountDue Money   `json:"amountDue" example:"16.92"`
+}
+
+// AmountDue is what is left to pay after discounts and store credit
+func (o *Order) AmountDue() Money {
+	return o.Total - o.Discounts - o.StoreCredit
 }
 
 type OrderQueueItem struct {
@@ -23,13 +111,123 @@ type OrderQueueItem struct {
 	CreatedAt  time.Time `json:"createdAt"`
 	UpdatedAt  time.Time `json:"updatedAt"`
 	Error      string    `json:"error,omitempty"`
+	ErrorCode  string    `json:"errorCode,omitempty"` // one of the QueueError codes, while failed or waiting on payment
 	Order      *Order    `json:"order,omitempty"`
 	RetryCount int       `json:"retryCount"`
+	// NextAttemptAt holds a failed item back from the worker until its retry delay has passed
+	NextAttemptAt time.Time `json:"nextAttemptAt"`
+	// ClaimedBy is the worker instance that last picked the item up, at ClaimedAt
+	ClaimedBy string     `json:"claimedBy,omitempty"`
+	ClaimedAt *time.Time `json:"claimedAt,omitempty"`
+}
+
+// QueuedOrder answers a placed order with the queue item to poll for it
+type QueuedOrder struct {
+	Message         string     `json:"message"`
+	QueueItemID     string     `json:"queueItemId" description:"Poll GET /order/{orderId} with it until the order is processed"`
+	Status          string     `json:"status"`
+	ScheduledFor    *time.Time `json:"scheduledFor,omitempty" description:"When the order is processed, for scheduled orders"`
+	TableNumber     string     `json:"tableNumber,omitempty"`
+	TabID           string     `json:"tabId,omitempty" description:"The open tab of the table the order joined"`
+	PaymentRequired bool       `json:"paymentRequired,omitempty" description:"The order waits until it is paid for with POST /order/{orderId}/pay"`
+	Sandbox         bool       `json:"sandbox,omitempty" description:"Placed with a test key; processed without payments or notifications"`
+	LookupToken     string     `json:"lookupToken,omitempty" description:"Reads this order alone in X-Order-Token, for guests"`
+}
+
+// QueueStatus counts the items of the order queue by status
+type QueueStatus struct {
+	QueueStats map[string]int `json:"queueStats"`
+}
+
+// OrderList is the listing of queued and completed orders
+type OrderList struct {
+	Orders  []OrderSummary `json:"orders"`
+	Stats   map[string]int `json:"stats"`
+	Message string         `json:"message"`
+}
+
+// OrderSummary shows a queue item in the order listing. Items and the total come from
+// the order once it is created, and from the request until then.
+type OrderSummary struct {
+	ID        string      `json:"id"`
+	Status    string      `json:"status"`
+	CreatedAt time.Time   `json:"createdAt"`
+	UpdatedAt time.Time   `json:"updatedAt"`
+	Customer  string      `json:"customer"`
+	Total     Money       `json:"total"`
+	Items     []OrderItem `json:"items,omitempty"`
+	Error     string      `json:"error,omitempty" description:"Why a failed order failed"`
+	Sandbox   bool        `json:"sandbox,omitempty" description:"Placed with a test key"`
+}
+
+// QueueFailure is one failed attempt at processing a queue item
+type QueueFailure struct {
+	Attempt   int       `json:"attempt" description:"Counts every failure of the item, including those before it was requeued"`
+	Error     string    `json:"error"`
+	ErrorCode string    `json:"errorCode,omitempty"`
+	At        time.Time `json:"at"`
+}
+
+// QueueItemDetails is everything known about a queue item, for debugging an order that is
+// stuck or failed without reading the database
+type QueueItemDetails struct {
+	ID            string         `json:"id"`
+	Status        string         `json:"status"`
+	OrderReq      OrderReq       `json:"orderReq" description:"The request as the customer placed it"`
+	RetryCount    int            `json:"retryCount"`
+	NextAttemptAt time.Time      `json:"nextAttemptAt"`
+	Error         string         `json:"error,omitempty"`
+	ErrorCode     string         `json:"errorCode,omitempty"`
+	Attempts      []QueueFailure `json:"attempts" description:"Every failed attempt, oldest first"`
+	ClaimedBy     string         `json:"claimedBy,omitempty" description:"The worker instance that last picked the item up"`
+	ClaimedAt     *time.Time     `json:"claimedAt,omitempty"`
+	OrderID       string         `json:"orderId,omitempty" description:"The order the item produced, once created"`
+	CreatedAt     time.Time      `json:"createdAt"`
+	UpdatedAt     time.Time      `json:"updatedAt"`
+}
+
+// QueueBacklog describes the pending items of the order queue. OldestDueAt is when the
+// longest waiting item became due, so items held back for their payment don't count as
+// waiting until they can be processed.
+type QueueBacklog struct {
+	Pending     int
+	OldestDueAt *time.Time
 }
 
 type BatchProcessResult struct {
-	Processed int              `json:"processed"`
-	Failed    int              `json:"failed"`
-	Errors    []string         `json:"errors"`
-	Items     []OrderQueueItem `json:"items"`
+	Processed int `json:"processed"`
+	Failed    int `json:"failed"`
+	// PaymentFailed counts items whose order was created but whose payment wasn't captured;
+	// they are retried without creating the order again
+	PaymentFailed int `json:"paymentFailed"`
+	// AwaitingPayment counts items put back in the queue until their payment is authorized
+	AwaitingPayment int              `json:"awaitingPayment"`
+	Errors          []string         `json:"errors"`
+	Items           []OrderQueueItem `json:"items"`
+}
+
+// ScheduledOrder is an order placed for a later time, as listed in the schedule feed
+type ScheduledOrder struct {
+	QueueItemID   string               `json:"queueItemId"`
+	StoreID       string               `json:"storeId"`
+	ScheduledFor  time.Time            `json:"scheduledFor"`
+	Status        string               `json:"status" description:"pending until the order is processed at its time"`
+	Items         []ScheduledOrderItem `json:"items"`
+	PaymentMethod string               `json:"paymentMethod,omitempty"`
+	Delivery      bool                 `json:"delivery" description:"Whether the order is delivered rather than collected"`
+}
+
+// ScheduledOrderItem is a line of a scheduled order, named for the kitchen
+type ScheduledOrderItem struct {
+	ProductID string `json:"productId"`
+	Name      string `json:"name" description:"The product's name, or its ID when it no longer exists"`
+	Quantity  int    `json:"quantity"`
+}
+
+// ScheduleFeedLinks are signed URLs to the schedule feed, for calendar and spreadsheet
+// tools that can't send the API key
+type ScheduleFeedLinks struct {
+	ICS       string    `json:"ics" description:"iCalendar feed to subscribe to"`
+	CSV       string    `json:"csv"`
+	ExpiresAt time.Time `json:"expiresAt"`
 }
@@ -0,0 +1,40 @@
+package models
+
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+// TotalCountHeader gives the number of items on every page of a listing whose body is a
+// plain array, so it can be paged without changing its shape
+const TotalCountHeader = "X-Total-Count"
+
+// PageRequest selects a window of a listing
+type PageRequest struct {
+	Limit  int `form:"limit" json:"limit"`
+	Offset int `form:"offset" json:"offset"`
+	// StoreID narrows listings kept per store to one store; empty lists every store
+	StoreID string `form:"store_id" json:"storeId,omitempty"`
+}
+
+// Normalize fills in the default limit and clamps out of range values
+func (p PageRequest) Normalize() PageRequest {
+	if p.Limit <= 0 {
+		p.Limit = DefaultPageLimit
+	}
+	if p.Limit > MaxPageLimit {
+		p.Limit = MaxPageLimit
+	}
+	if p.Offset < 0 {
+		p.Offset = 0
+	}
+	return p
+}
+
+// Window returns the [start, end) bounds of the page within a listing of total items
+func (p PageRequest) Window(total int) (int, int) {
+	p = p.Normalize()
+	start := min(p.Offset, total)
+	end := min(start+p.Limit, total)
+	return start, end
+}
@@ -0,0 +1,99 @@
+package models
+
+import "time"
+
+// Payment intent statuses, as Stripe names them. An intent authorized for manual capture
+// is in PaymentStatusRequiresCapture.
+const (
+	PaymentStatusRequiresPaymentMethod = "requires_payment_method"
+	PaymentStatusRequiresConfirmation  = "requires_confirmation"
+	PaymentStatusRequiresAction        = "requires_action"
+	PaymentStatusProcessing            = "processing"
+	PaymentStatusRequiresCapture       = "requires_capture"
+	PaymentStatusSucceeded             = "succeeded"
+	PaymentStatusCanceled              = "canceled"
+)
+
+// Payment methods of an order. Card orders may be paid online through a payment intent;
+// cash orders are paid on delivery and counter orders when they are collected.
+const (
+	PaymentMethodCard    = "card"
+	PaymentMethodCash    = "cash"
+	PaymentMethodCounter = "counter"
+)
+
+// PaymentMethods are the methods orders can be placed with, card first
+var PaymentMethods = []string{PaymentMethodCard, PaymentMethodCash, PaymentMethodCounter}
+
+// PaymentMethodTotal is what orders paid with one method came to
+type PaymentMethodTotal struct {
+	Method string `json:"method" example:"cash"`
+	Orders int    `json:"orders" example:"12"`
+	Paid   Money  `json:"paid" example:"311.76" description:"Order totals after discounts"`
+}
+
+// Payment statuses of an order paid through an intent. The intent was authorized when the
+// order was created; the worker only fulfills orders in OrderPaymentCaptured. Orders whose
+// payment was refunded in full are OrderPaymentRefunded.
+const (
+	OrderPaymentAuthorized = "authorized"
+	OrderPaymentCaptured   = "captured"
+	OrderPaymentRefunded   = "refunded"
+)
+
+// PaymentIntent is an amount to be authorized by the customer, then captured when their
+// order is created
+type PaymentIntent struct {
+	ID             string `json:"id" example:"pi_3MtwBwLkdIwHu7ix28a3tqPa"`
+	ClientSecret   string `json:"clientSecret,omitempty" description:"Lets the frontend confirm the payment with the provider"`
+	Amount         Money  `json:"amount" example:"25.98"`
+	AmountCaptured Money  `json:"amountCaptured" example:"0"`
+	Currency       string `json:"currency" example:"aud"`
+	Status         string `json:"status" example:"requires_payment_method"`
+}
+
+// Authorized reports whether the intent's amount is held and can be captured
+func (p *PaymentIntent) Authorized() bool {
+	return p.Status == PaymentStatusRequiresCapture
+}
+
+// PaymentRefund returns captured money to the customer
+type PaymentRefund struct {
+	ID              string `json:"id" example:"re_1Nispe2eZvKYlo2Cd31jOCgZ"`
+	PaymentIntentID string `json:"paymentIntentId"`
+	Amount          Money  `json:"amount" example:"25.98"`
+	Status          string `json:"status" example:"succeeded"`
+}
+
+// PaymentWebhookAck acknowledges a payment provider's event; Duplicate events were
+// delivered before and are not applied again
+type PaymentWebhookAck struct {
+	Received  bool `json:"received" example:"true"`
+	Duplicate bool `json:"duplicate" example:"false"`
+}
+
+// Refund statuses of an order. A refund is requested before the payment provider is asked
+// for it, then processed or failed with the provider's answer.
+const (
+	RefundStatusRequested = "requested"
+	RefundStatusProcessed = "processed"
+	RefundStatusFailed    = "failed"
+)
+
+// OrderRefund is money returned, or to be returned, for an order paid through an intent
+type OrderRefund struct {
+	ID               string    `json:"id"`
+	OrderID          string    `json:"orderId"`
+	PaymentIntentID  string    `json:"paymentIntentId"`
+	Amount           Money     `json:"amount" example:"25.98"`
+	Status           string    `json:"status" example:"processed" description:"requested, processed or failed"`
+	ProviderRefundID string    `json:"providerRefundId,omitempty" example:"re_1Nispe2eZvKYlo2Cd31jOCgZ" description:"The payment provider's refund, once processed"`
+	Error            string    `json:"error,omitempty" description:"Why the payment provider refused the refund"`
+	CreatedAt        time.Time `json:"createdAt"`
+	UpdatedAt        time.Time `json:"updatedAt"`
+}
+
+// PaymentAmountReq names the amount to capture or refund; zero means all of it
+type PaymentAmountReq struct {
+	Amount Money `json:"amount" example:"25.98"`
+}
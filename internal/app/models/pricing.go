@@ -0,0 +1,79 @@
+package models
+
+import "time"
+
+// TierRetail is the tier of everyone without another one: list prices
+const TierRetail = "retail"
+
+// Subjects a pricing tier can be assigned to
+const (
+	PricingSubjectCustomer = "customer" // A customer named in X-Customer-ID
+	PricingSubjectAPIKey   = "api_key"  // Every request made with an API key
+)
+
+// PricingTier takes a percentage off list prices for the customers and API keys assigned to it
+type PricingTier struct {
+	Name               string     `json:"name" example:"staff"`
+	DiscountPercentage float64    `json:"discountPercentage" description:"Percentage taken off list prices"`
+	UpdatedAt          *time.Time `json:"updatedAt,omitempty"`
+}
+
+// Price returns the tier's price for a list price, rounded to the nearest cent
+func (t PricingTier) Price(listPrice Money) Money {
+	if t.DiscountPercentage <= 0 {
+		return listPrice
+	}
+	return listPrice - listPrice.Percent(t.DiscountPercentage)
+}
+
+// Apply returns copies of products at the tier's prices, keeping the list price in
+// ListPrice, with their options' price deltas discounted alike. Products are returned as
+// they are at list price.
+func (t PricingTier) Apply(products []Product) []Product {
+	if t.DiscountPercentage <= 0 {
+		return products
+	}
+
+	priced := make([]Product, len(products))
+	for i, product := range products {
+		product.ListPrice = product.Price
+		product.Price = t.Price(product.Price)
+		product.Variants = t.applyOptions(product.Variants)
+		product.Modifiers = t.applyOptions(product.Modifiers)
+		priced[i] = product
+	}
+	return priced
+}
+
+func (t PricingTier) applyOptions(options []ProductOption) []ProductOption {
+	if options == nil {
+		return nil
+	}
+	priced := make([]ProductOption, len(options))
+	for i, option := range options {
+		option.PriceDelta = t.Price(option.PriceDelta)
+		priced[i] = option
+	}
+	return priced
+}
+
+// PricingTierReq creates or changes a pricing tier
+type PricingTierReq struct {
+	DiscountPercentage float64 `json:"discountPercentage" description:"Percentage taken off list prices, from 0 up to but excluding 100"`
+}
+
+// PricingAssignment gives a customer or API key a pricing tier. API keys are kept and
+// listed as their SHA-256 hash, never in the clear.
+type PricingAssignment struct {
+	SubjectType string    `json:"subjectType" example:"customer" description:"customer or api_key"`
+	Subject     string    `json:"subject" description:"Customer ID, or the SHA-256 hash of the API key"`
+	Tier        string    `json:"tier" example:"staff"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// PricingAssignmentReq assigns a tier to a customer or API key; exactly one of them is set
+type PricingAssignmentReq struct {
+	CustomerID string `json:"customerId,omitempty"`
+	APIKey     string `json:"apiKey,omitempty"`
+	Tier       string `json:"tier" binding:"required" example:"staff" description:"retail removes the assignment"`
+}
@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 type Image struct {
 	Thumbnail string `json:"thumbnail"`
 	Mobile    string `json:"mobile"`
@@ -8,9 +10,79 @@ type Image struct {
 }
 
 type Product struct {
-	ID       string  `json:"id" example:"10"`
-	Name     string  `json:"name" example:"Chicken Waffle"`
-	Price    float64 `json:"price" description:"Selling price"`
-	Category string  `json:"category" example:"Waffle"`
-	Image    Image   `json:"image"`
+	ID        string `json:"id" example:"10"`
+	Name      string `json:"name" example:"Chicken Waffle"`
+	Price     Money  `json:"price" description:"Selling price"`
+	ListPrice Money  `json:"listPrice,omitempty" description:"Price before the caller's pricing tier; omitted at list price"`
+	Category  string `json:"category" example:"Waffle"`
+	Image     Image  `json:"image"`
+	Version   int    `json:"version" description:"Incremented on every update"`
+	StoreID   string `json:"storeId,omitempty" description:"Store whose menu the product is on"`
+
+	Variants  []ProductOption `json:"variants,omitempty" description:"Sizes of which an order item picks one, the first unless it names another"`
+	Modifiers []ProductOption `json:"modifiers,omitempty" description:"Add-ons an order item picks any of"`
+
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+	DeletedAt *time.Time `json:"deletedAt,omitempty"`
+}
+
+// Kinds of ProductOption
+const (
+	OptionVariant  = "variant"
+	OptionModifier = "modifier"
+)
+
+// ProductOption is a variant or modifier of a product, charged on top of its price
+type ProductOption struct {
+	ID         string `json:"id,omitempty" description:"Kept when the product is updated with it; assigned to new options"`
+	Name       string `json:"name" binding:"required,max=100" example:"Large"`
+	PriceDelta Money  `json:"priceDelta" binding:"min=0" example:"1.50" description:"Added to the product's price"`
+}
+
+// ProductReq creates a product on the menu of the caller's store
+type ProductReq struct {
+	Name     string `json:"name" binding:"required,max=255" example:"Chicken Waffle"`
+	Price    Money  `json:"price" binding:"gt=0" example:"12.50" description:"Selling price"`
+	Category string `json:"category" binding:"required,max=100" example:"Waffle"`
+	Image    Image  `json:"image"`
+
+	Variants  []ProductOption `json:"variants" binding:"max=20,dive"`
+	Modifiers []ProductOption `json:"modifiers" binding:"max=20,dive"`
+}
+
+// ProductUpdateReq replaces a product's details. Version is the one the client last read;
+// the update is refused when the product changed since.
+type ProductUpdateReq struct {
+	Name     string `json:"name" binding:"required,max=255" example:"Chicken Waffle"`
+	Price    Money  `json:"price" binding:"gt=0" example:"12.50" description:"Selling price"`
+	Category string `json:"category" binding:"required,max=100" example:"Waffle"`
+	Image    Image  `json:"image"`
+	Version  int    `json:"version" binding:"required,min=1" description:"Version of the product the change is based on"`
+
+	Variants  []ProductOption `json:"variants" binding:"max=20,dive" description:"Replace the product's variants; options keep their ID when sent with it"`
+	Modifiers []ProductOption `json:"modifiers" binding:"max=20,dive" description:"Replace the product's modifiers; options keep their ID when sent with it"`
+}
+
+// Kinds of ProductChange. A restored product is created again.
+const (
+	ProductCreated = "created"
+	ProductUpdated = "updated"
+	ProductDeleted = "deleted"
+)
+
+// ProductChange is one entry of the catalog change feed
+type ProductChange struct {
+	Cursor    string    `json:"cursor" description:"Pass as since to get the changes after this one"`
+	Kind      string    `json:"kind" description:"created, updated or deleted"`
+	ProductID string    `json:"productId"`
+	Product   *Product  `json:"product,omitempty" description:"The product as it is now; omitted for deletions"`
+	ChangedAt time.Time `json:"changedAt"`
+}
+
+// ProductChanges is a page of the catalog change feed
+type ProductChanges struct {
+	Changes []ProductChange `json:"changes"`
+	Cursor  string          `json:"cursor" description:"Pass as since to get the next changes; the one sent when there were none"`
+	HasMore bool            `json:"hasMore" description:"More changes follow the cursor"`
 }
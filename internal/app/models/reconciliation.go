@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// Kinds of order discrepancy, by the recorded amount that doesn't match
+const (
+	DiscrepancyTotal    = "total"    // the total isn't what the items' prices add up to
+	DiscrepancyDiscount = "discount" // the discounts aren't what the coupon takes off the total
+)
+
+// OrderDiscrepancy is an amount recorded on an order that the reconciliation job couldn't
+// recompute from the order's item snapshots
+type OrderDiscrepancy struct {
+	OrderID    string    `json:"orderId"`
+	StoreID    string    `json:"storeId,omitempty"`
+	Kind       string    `json:"kind" example:"total" description:"total or discount"`
+	Recorded   Money     `json:"recorded" example:"18.0" description:"The amount stored with the order"`
+	Expected   Money     `json:"expected" example:"20.0" description:"The amount recomputed from the order's items"`
+	Difference Money     `json:"difference" example:"-2.0" description:"recorded less expected"`
+	CouponCode string    `json:"couponCode,omitempty"`
+	OrderedAt  time.Time `json:"orderedAt"`
+	DetectedAt time.Time `json:"detectedAt" description:"When a run first found the discrepancy"`
+	CheckedAt  time.Time `json:"checkedAt" description:"When a run last found it"`
+}
+
+// DiscrepancyReport lists the open discrepancies for finance to review, most recently
+// detected first
+type DiscrepancyReport struct {
+	StoreID       string             `json:"storeId,omitempty"`
+	Count         int                `json:"count"`
+	Discrepancies []OrderDiscrepancy `json:"discrepancies"`
+}
+
+// Reconciliation sums up one run of the reconciliation job
+type Reconciliation struct {
+	Since   time.Time `json:"since" description:"Orders changed from then on were checked"`
+	Checked int       `json:"checked" description:"Orders checked"`
+	Found   int       `json:"found" description:"Discrepancies found"`
+}
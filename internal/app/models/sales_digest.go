@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// SalesDigest sums up the orders placed from From until before To, leaving out cancelled
+// and failed orders
+type SalesDigest struct {
+	From           time.Time            `json:"from"`
+	To             time.Time            `json:"to"`
+	Orders         int                  `json:"orders" example:"42"`
+	Revenue        Money                `json:"revenue" example:"1311.76" description:"What customers paid, after discounts and store credit"`
+	PaymentMethods []PaymentMethodTotal `json:"paymentMethods"`
+	TopProducts    []ProductSales       `json:"topProducts"`
+	Coupons        []CouponUsage        `json:"coupons"`
+}
+
+// ProductSales is how much of one product was ordered
+type ProductSales struct {
+	ProductID string `json:"productId"`
+	Name      string `json:"name" example:"Waffle with Berries"`
+	Quantity  int    `json:"quantity" example:"18"`
+	Revenue   Money  `json:"revenue" example:"117.0" description:"At the prices charged, before order discounts"`
+}
+
+// CouponUsage is how often one coupon was used and what it took off
+type CouponUsage struct {
+	Code      string `json:"code" example:"HAPPYHRS"`
+	Orders    int    `json:"orders" example:"7"`
+	Discounts Money  `json:"discounts" example:"21.5"`
+}
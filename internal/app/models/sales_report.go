@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// Sales report groupings
+const (
+	SalesByDay      = "day"
+	SalesByProduct  = "product"
+	SalesByCategory = "category"
+)
+
+// SalesGroupings are the ways a sales report can be grouped, the default first
+var SalesGroupings = []string{SalesByDay, SalesByProduct, SalesByCategory}
+
+// SalesQuery selects the orders of a sales report: those placed from Since until before
+// Until, of one store when StoreID is set, leaving out cancelled and failed orders. Days
+// start at midnight in Location.
+type SalesQuery struct {
+	GroupBy  string
+	Since    time.Time
+	Until    time.Time
+	Location *time.Location
+	StoreID  string
+}
+
+// SalesFigures is what a set of orders came to
+type SalesFigures struct {
+	Orders            int   `json:"orders" example:"42"`
+	Revenue           Money `json:"revenue" example:"1311.76" description:"Order totals after discounts; store credit and gift cards count as paid"`
+	Discounts         Money `json:"discounts" example:"48.2"`
+	AverageOrderValue Money `json:"averageOrderValue" example:"31.23" description:"Revenue per order"`
+}
+
+// SalesGroup is what the orders of one day, product or category came to. A product's or
+// category's share of an order's discounts is in proportion to what it added to the order.
+type SalesGroup struct {
+	Key      string `json:"key" example:"2026-10-15" description:"The day (YYYY-MM-DD), product ID or category"`
+	Name     string `json:"name,omitempty" example:"Waffle with Berries" description:"The product's name"`
+	Quantity int    `json:"quantity,omitempty" example:"18" description:"Items sold of the product or category"`
+	SalesFigures
+}
+
+// SalesReport sums up the orders placed from From until before To
+type SalesReport struct {
+	From     time.Time    `json:"from"`
+	To       time.Time    `json:"to"`
+	TimeZone string       `json:"timeZone" example:"Australia/Sydney"`
+	StoreID  string       `json:"storeId,omitempty"`
+	GroupBy  string       `json:"groupBy" example:"day"`
+	Totals   SalesFigures `json:"totals"`
+	Groups   []SalesGroup `json:"groups" description:"Days oldest first; products and categories by revenue, highest first"`
+}
@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// Customer segments, recomputed periodically from order history
+const (
+	SegmentNew     = "new"     // First order placed recently
+	SegmentRegular = "regular" // Ordering, but neither new, lapsed nor VIP
+	SegmentLapsed  = "lapsed"  // No order for a while
+	SegmentVIP     = "vip"     // Spent enough within the VIP window
+)
+
+// SegmentRules decide which segment a customer falls in. A lapsed customer stays lapsed
+// whatever they spent, so win-back promotions reach former VIPs too.
+type SegmentRules struct {
+	NewWindow   time.Duration
+	LapsedAfter time.Duration
+	VIPSpend    Money
+	VIPWindow   time.Duration
+}
+
+// Segment returns the segment for a customer's order history as of now
+func (r SegmentRules) Segment(now, firstOrderAt, lastOrderAt time.Time, recentSpend Money) string {
+	switch {
+	case now.Sub(lastOrderAt) >= r.LapsedAfter:
+		return SegmentLapsed
+	case recentSpend >= r.VIPSpend:
+		return SegmentVIP
+	case now.Sub(firstOrderAt) < r.NewWindow:
+		return SegmentNew
+	default:
+		return SegmentRegular
+	}
+}
+
+type CustomerSegment struct {
+	CustomerID   string    `json:"customerId"`
+	Segment      string    `json:"segment"`
+	OrderCount   int       `json:"orderCount"`
+	TotalSpent   Money     `json:"totalSpent"`
+	FirstOrderAt time.Time `json:"firstOrderAt"`
+	LastOrderAt  time.Time `json:"lastOrderAt"`
+	ComputedAt   time.Time `json:"computedAt"`
+}
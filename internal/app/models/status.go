@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// Coarse service states reported by the public status endpoint
+const (
+	StatusOperational = "operational"
+	StatusDegraded    = "degraded" // Working, but orders take longer than usual
+	StatusUnavailable = "unavailable"
+)
+
+// ServiceStatus is the public, unauthenticated view of the service for ordering frontends.
+// It deliberately carries no counts or internals.
+type ServiceStatus struct {
+	Status               string    `json:"status" example:"operational" description:"operational, degraded or unavailable"`
+	AcceptingOrders      bool      `json:"acceptingOrders"`
+	EstimatedWaitSeconds *int      `json:"estimatedWaitSeconds,omitempty" description:"Expected time until a new order is processed; omitted when unknown"`
+	UpdatedAt            time.Time `json:"updatedAt"`
+}
@@ -0,0 +1,81 @@
+package models
+
+import "time"
+
+// DefaultStoreID is the store everything belongs to that was created before stores
+// existed, and the store of API_KEY requests that don't name another one
+const DefaultStoreID = "00000000-0000-0000-0000-000000000001"
+
+// Store is a restaurant location served by the deployment, with its own menu, orders,
+// coupons and API keys
+type Store struct {
+	ID        string    `json:"id" example:"00000000-0000-0000-0000-000000000001"`
+	Name      string    `json:"name" example:"Fitzroy"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// StoreReq creates or renames a store
+type StoreReq struct {
+	Name string `json:"name" binding:"required" example:"Fitzroy" description:"1-100 characters"`
+}
+
+// StoreAPIKey is an API key that only reaches its store. The key itself is kept as its
+// SHA-256 hash and only returned when it is created.
+type StoreAPIKey struct {
+	ID        string     `json:"id"`
+	StoreID   string     `json:"storeId"`
+	Name      string     `json:"name" example:"Front counter"`
+	Prefix    string     `json:"prefix" example:"sk_3f9a1c" description:"Start of the key, to tell keys apart"`
+	Key       string     `json:"key,omitempty" description:"The key; only returned when it is created"`
+	Test      bool       `json:"test" description:"Orders placed with the key go through the sandbox"`
+	CreatedAt time.Time  `json:"createdAt"`
+	RevokedAt *time.Time `json:"revokedAt,omitempty"`
+}
+
+// StoreAPIKeyReq creates an API key for a store
+type StoreAPIKeyReq struct {
+	Name string `json:"name" binding:"required" example:"Front counter" description:"1-100 characters, to tell keys apart"`
+	Test bool   `json:"test" description:"Make a test key, whose orders are processed without payments or notifications"`
+}
+
+// StoreOrDefault returns storeID, or the default store for orders and products from
+// before stores existed, e.g. orders still queued from then
+func StoreOrDefault(storeID string) string {
+	if storeID == "" {
+		return DefaultStoreID
+	}
+	return storeID
+}
+
+// What happens to orders placed while a store is closed
+const (
+	OutsideHoursReject   = "reject"   // refused, with the next opening time
+	OutsideHoursSchedule = "schedule" // scheduled for the next opening time
+)
+
+// OpeningHours say when a store takes orders. Times are wall-clock times in TimeZone; a
+// period that closes at or before it opens runs past midnight. Holidays replace the
+// weekly hours on their date.
+type OpeningHours struct {
+	TimeZone     string          `json:"timeZone" binding:"required" example:"Australia/Melbourne" description:"IANA time zone of the store"`
+	Weekly       []OpeningPeriod `json:"weekly" description:"Regular hours; days without a period are closed"`
+	Holidays     []HolidayHours  `json:"holidays,omitempty" description:"Dates with other hours than usual"`
+	OutsideHours string          `json:"outsideHours,omitempty" example:"schedule" description:"reject (default) or schedule orders placed while the store is closed"`
+}
+
+// OpeningPeriod is a span of a weekday the store is open for
+type OpeningPeriod struct {
+	Day    string `json:"day" example:"monday"`
+	Opens  string `json:"opens" example:"09:00" description:"HH:MM"`
+	Closes string `json:"closes" example:"17:00" description:"HH:MM; at or before opens for periods past midnight"`
+}
+
+// HolidayHours are the hours of one date. A date without opening times is closed all day;
+// a date open for several periods is listed once for each.
+type HolidayHours struct {
+	Date   string `json:"date" example:"2026-12-25" description:"YYYY-MM-DD"`
+	Name   string `json:"name,omitempty" example:"Christmas Day"`
+	Opens  string `json:"opens,omitempty" example:"10:00" description:"HH:MM; empty when closed all day"`
+	Closes string `json:"closes,omitempty" example:"14:00" description:"HH:MM"`
+}
@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// Tab statuses. A table has at most one open tab, which orders placed at it join until
+// staff close it when it is settled.
+const (
+	TabStatusOpen   = "open"
+	TabStatusClosed = "closed"
+)
+
+// Tab is what a dine-in table has ordered since it was seated
+type Tab struct {
+	ID            string     `json:"id"`
+	StoreID       string     `json:"storeId"`
+	TableNumber   string     `json:"tableNumber" example:"12"`
+	Status        string     `json:"status" example:"open" description:"open, or closed once settled"`
+	PaymentMethod string     `json:"paymentMethod,omitempty" example:"card" description:"How the closed tab was settled"`
+	OpenedAt      time.Time  `json:"openedAt"`
+	ClosedAt      *time.Time `json:"closedAt,omitempty"`
+
+	// Rounds and Total are read from the tab's queue items; they are left out of listings
+	Rounds []TabRound `json:"rounds"`
+	Total  Money      `json:"total" example:"42.5" description:"What the created orders of the tab come to, after discounts and store credit"`
+}
+
+// TabRound is one order placed at a table, from its queue item
+type TabRound struct {
+	QueueItemID string      `json:"queueItemId"`
+	OrderID     string      `json:"orderId,omitempty" description:"Set once the worker has created the order"`
+	Status      string      `json:"status" example:"completed" description:"The queue item's status"`
+	Items       []OrderItem `json:"items"`
+	Total       Money       `json:"total,omitempty" description:"Amount due for the created order"`
+	PlacedAt    time.Time   `json:"placedAt"`
+}
+
+// TabCloseReq settles a table's open tab
+type TabCloseReq struct {
+	PaymentMethod string `json:"paymentMethod" binding:"required" example:"card" description:"card, cash or counter"`
+}
+
+// KitchenTable is a table with an open tab, for kitchen displays
+type KitchenTable struct {
+	TableNumber string     `json:"tableNumber" example:"12"`
+	TabID       string     `json:"tabId"`
+	OpenedAt    time.Time  `json:"openedAt"`
+	Rounds      []TabRound `json:"rounds" description:"Oldest first"`
+}
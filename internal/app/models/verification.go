@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// VerificationReq asks for a one-time code to be texted to a phone
+type VerificationReq struct {
+	Phone string `json:"phone" binding:"required" example:"+61400000000" description:"International format"`
+}
+
+// VerificationChallenge identifies a sent code; it is checked together with the code
+type VerificationChallenge struct {
+	VerificationID string    `json:"verificationId"`
+	ExpiresAt      time.Time `json:"expiresAt"`
+}
+
+// VerificationCheckReq checks the code texted for a verification
+type VerificationCheckReq struct {
+	VerificationID string `json:"verificationId" binding:"required"`
+	Code           string `json:"code" binding:"required" example:"123456"`
+}
+
+// VerifiedPhone proves the phone was verified until it expires
+type VerifiedPhone struct {
+	Phone     string    `json:"phone"`
+	Token     string    `json:"token" description:"Send it in X-Verification-Token with guest orders that need a verified phone"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Endpoints outbound webhooks are delivered to, each with its own URL and secret
+const (
+	WebhookEndpointFulfillment = "fulfillment" // FULFILLMENT_WEBHOOK_URL
+	WebhookEndpointEvents      = "events"      // OUTBOX_WEBHOOK_URL
+	WebhookEndpointDigest      = "digest"      // DIGEST_WEBHOOK_URL
+	WebhookEndpointDelivery    = "delivery"    // DELIVERY_WEBHOOK_URL
+)
+
+// Statuses of a webhook delivery. Pending ones are retried with backoff until they are
+// accepted or run out of attempts, when they stay dead until an admin redelivers them.
+const (
+	WebhookPending   = "pending"
+	WebhookDelivered = "delivered"
+	WebhookDead      = "dead"
+)
+
+// WebhookDelivery is a CloudEvent waiting to be posted, or posted, to a webhook endpoint
+type WebhookDelivery struct {
+	ID        string          `json:"id"`
+	Endpoint  string          `json:"endpoint" example:"fulfillment"`
+	EventID   string          `json:"eventId" description:"ID of the CloudEvent; an endpoint gets each event once"`
+	EventType string          `json:"eventType" example:"com.oolio.order.created"`
+	Body      json.RawMessage `json:"body"`
+	Status    string          `json:"status" example:"pending"`
+	// Attempts counts the posts since the delivery was queued or redelivered
+	Attempts      int        `json:"attempts"`
+	NextAttemptAt time.Time  `json:"nextAttemptAt"`
+	LastError     string     `json:"lastError,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	DeliveredAt   *time.Time `json:"deliveredAt,omitempty"`
+	// AttemptLog lists every attempt, oldest first, when the delivery is looked up on its own
+	AttemptLog []WebhookAttempt `json:"attemptLog,omitempty"`
+}
+
+// WebhookAttempt is one post of a delivery and what the endpoint made of it
+type WebhookAttempt struct {
+	URL         string    `json:"url"`
+	StatusCode  int       `json:"statusCode,omitempty" description:"HTTP status of the response; absent when there was none"`
+	Error       string    `json:"error,omitempty"`
+	DurationMS  int       `json:"durationMs"`
+	AttemptedAt time.Time `json:"attemptedAt"`
+}
+
+// WebhookDeliveryResult counts what became of a batch of webhook deliveries
+type WebhookDeliveryResult struct {
+	Delivered int      `json:"delivered"`
+	Failed    int      `json:"failed" description:"Failed deliveries that will be retried"`
+	Dead      int      `json:"dead" description:"Failed deliveries that ran out of attempts or were refused outright"`
+	Errors    []string `json:"errors,omitempty"`
+}
@@ -0,0 +1,215 @@
+// Package openapi builds the OpenAPI 3 document from the operations the router declares and
+// the models they exchange, so the published spec follows the code instead of drifting from it.
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const Version = "3.0.3"
+
+// APIKeyScheme is the name of the security scheme for the X-API-Key header
+const APIKeyScheme = "ApiKey"
+
+// Operation describes one route. Body and response values are only inspected for their
+// type; nil means no body. Headers are set on the operation's successful responses.
+type Operation struct {
+	Method      string
+	Path        string // Gin syntax, e.g. /api/v1/product/:productId
+	Summary     string
+	Description string
+	Tag         string
+	Auth        bool
+	Query       []Param
+	Body        any
+	Responses   map[int]any
+	Headers     []Header
+}
+
+// Param is a query parameter. Path parameters are derived from the path.
+type Param struct {
+	Name        string
+	Description string
+	Type        string // OpenAPI type, "string" when empty
+	Format      string
+	Required    bool
+}
+
+// Header is a response header, documented as a string
+type Header struct {
+	Name        string
+	Description string
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+type Document struct {
+	OpenAPI    string                          `json:"openapi"`
+	Info       Info                            `json:"info"`
+	Paths      map[string]map[string]*pathItem `json:"paths"`
+	Components components                      `json:"components"`
+}
+
+type components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]securityScheme `json:"securitySchemes"`
+}
+
+type securityScheme struct {
+	Type string `json:"type"`
+	In   string `json:"in"`
+	Name string `json:"name"`
+}
+
+type pathItem struct {
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	OperationID string                `json:"operationId"`
+	Parameters  []parameter           `json:"parameters,omitempty"`
+	RequestBody *requestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]response   `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+type parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required"`
+	Schema      *Schema `json:"schema"`
+}
+
+type requestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]mediaType `json:"content"`
+}
+
+type response struct {
+	Description string               `json:"description"`
+	Headers     map[string]header    `json:"headers,omitempty"`
+	Content     map[string]mediaType `json:"content,omitempty"`
+}
+
+type header struct {
+	Description string  `json:"description,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type mediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// NewDocument builds the document for ops, collecting every named struct they use
+// under components/schemas
+func NewDocument(info Info, ops []Operation) *Document {
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    info,
+		Paths:   make(map[string]map[string]*pathItem),
+		Components: components{
+			Schemas: make(map[string]*Schema),
+			SecuritySchemes: map[string]securityScheme{
+				APIKeyScheme: {Type: "apiKey", In: "header", Name: "X-API-Key"},
+			},
+		},
+	}
+	gen := &generator{schemas: doc.Components.Schemas}
+
+	for _, op := range ops {
+		path, pathParams := convertPath(op.Path)
+		method := strings.ToLower(op.Method)
+
+		item := &pathItem{
+			Summary:     op.Summary,
+			Description: op.Description,
+			OperationID: operationID(method, op.Path),
+			Responses:   make(map[string]response),
+		}
+		if op.Tag != "" {
+			item.Tags = []string{op.Tag}
+		}
+		if op.Auth {
+			item.Security = []map[string][]string{{APIKeyScheme: {}}}
+		}
+
+		for _, name := range pathParams {
+			item.Parameters = append(item.Parameters, parameter{
+				Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"},
+			})
+		}
+		for _, p := range op.Query {
+			schemaType := p.Type
+			if schemaType == "" {
+				schemaType = "string"
+			}
+			item.Parameters = append(item.Parameters, parameter{
+				Name: p.Name, In: "query", Description: p.Description, Required: p.Required,
+				Schema: &Schema{Type: schemaType, Format: p.Format},
+			})
+		}
+
+		if op.Body != nil {
+			item.RequestBody = &requestBody{
+				Required: true,
+				Content:  map[string]mediaType{"application/json": {Schema: gen.schemaFor(reflect.TypeOf(op.Body))}},
+			}
+		}
+
+		for status, body := range op.Responses {
+			resp := response{Description: http.StatusText(status)}
+			if body != nil {
+				resp.Content = map[string]mediaType{"application/json": {Schema: gen.schemaFor(reflect.TypeOf(body))}}
+			}
+			if status < 300 && len(op.Headers) > 0 {
+				resp.Headers = make(map[string]header, len(op.Headers))
+				for _, h := range op.Headers {
+					resp.Headers[h.Name] = header{Description: h.Description, Schema: &Schema{Type: "string"}}
+				}
+			}
+			item.Responses[strconv.Itoa(status)] = resp
+		}
+
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = make(map[string]*pathItem)
+		}
+		doc.Paths[path][method] = item
+	}
+
+	return doc
+}
+
+// convertPath turns /product/:productId into /product/{productId}. A catch-all such as
+// /files/*key becomes a plain {key} parameter; its value may contain slashes.
+func convertPath(path string) (string, []string) {
+	segments := strings.Split(path, "/")
+	var params []string
+	for i, segment := range segments {
+		name, ok := strings.CutPrefix(segment, ":")
+		if !ok {
+			name, ok = strings.CutPrefix(segment, "*")
+		}
+		if ok {
+			segments[i] = "{" + name + "}"
+			params = append(params, name)
+		}
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// operationID derives a stable ID such as getApiV1ProductProductId
+func operationID(method, path string) string {
+	var b strings.Builder
+	b.WriteString(method)
+	for _, part := range strings.FieldsFunc(path, func(r rune) bool { return r == '/' || r == ':' || r == '*' || r == '-' || r == '_' }) {
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
@@ -0,0 +1,163 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ValidateResponse checks a response of the route method path, in Gin syntax as in
+// Operation.Path, against the document: the status must be documented for the route, its
+// documented headers set, and a documented body must be JSON matching the schema. Objects
+// may not carry properties their schema doesn't list, since the generator lists every
+// field of a model; an unknown one means the handler and the document have drifted apart.
+// Every mismatch found is returned.
+func (d *Document) ValidateResponse(method, path string, status int, header http.Header, body []byte) error {
+	converted, _ := convertPath(path)
+	item := d.Paths[converted][strings.ToLower(method)]
+	if item == nil {
+		return fmt.Errorf("%s %s is not documented", method, path)
+	}
+	resp, ok := item.Responses[strconv.Itoa(status)]
+	if !ok {
+		return fmt.Errorf("%s %s answered %d, which is not documented", method, path, status)
+	}
+
+	var errs []error
+	for _, name := range slices.Sorted(maps.Keys(resp.Headers)) {
+		if header.Get(name) == "" {
+			errs = append(errs, fmt.Errorf("header %s is missing", name))
+		}
+	}
+
+	if media, ok := resp.Content["application/json"]; ok {
+		if contentType := header.Get("Content-Type"); !strings.HasPrefix(contentType, "application/json") {
+			errs = append(errs, fmt.Errorf("content type is %q, not application/json", contentType))
+		}
+
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		decoder.UseNumber()
+		var value any
+		if err := decoder.Decode(&value); err != nil {
+			errs = append(errs, fmt.Errorf("body is not JSON: %w", err))
+		} else {
+			errs = append(errs, d.validate("body", media.Schema, value)...)
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("%s %s answered %d not as documented:\n%w", method, path, status, err)
+	}
+	return nil
+}
+
+// validate checks a decoded JSON value against s, naming it at in the errors
+func (d *Document) validate(at string, s *Schema, value any) []error {
+	if s.Ref != "" {
+		name := strings.TrimPrefix(s.Ref, "#/components/schemas/")
+		if s = d.Components.Schemas[name]; s == nil {
+			return []error{fmt.Errorf("%s: schema %s is not defined", at, name)}
+		}
+	}
+	if value == nil {
+		if s.Nullable || s.Type == "" {
+			return nil
+		}
+		return []error{fmt.Errorf("%s is null, expected %s", at, s.Type)}
+	}
+
+	mismatch := func() []error {
+		return []error{fmt.Errorf("%s is %s, expected %s", at, jsonType(value), s.Type)}
+	}
+	switch s.Type {
+	case "":
+		// Any value
+	case "object":
+		object, ok := value.(map[string]any)
+		if !ok {
+			return mismatch()
+		}
+		var errs []error
+		for _, name := range s.Required {
+			if _, ok := object[name]; !ok {
+				errs = append(errs, fmt.Errorf("%s.%s is missing", at, name))
+			}
+		}
+		for _, name := range slices.Sorted(maps.Keys(object)) {
+			prop := s.Properties[name]
+			if prop == nil {
+				prop = s.AdditionalProperties
+			}
+			if prop == nil {
+				errs = append(errs, fmt.Errorf("%s.%s is not documented", at, name))
+				continue
+			}
+			errs = append(errs, d.validate(at+"."+name, prop, object[name])...)
+		}
+		return errs
+	case "array":
+		array, ok := value.([]any)
+		if !ok {
+			return mismatch()
+		}
+		var errs []error
+		for i, element := range array {
+			errs = append(errs, d.validate(at+"["+strconv.Itoa(i)+"]", s.Items, element)...)
+		}
+		return errs
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			return mismatch()
+		}
+		if s.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339Nano, str); err != nil {
+				return []error{fmt.Errorf("%s is %q, expected a date-time", at, str)}
+			}
+		}
+	case "integer":
+		number, ok := value.(json.Number)
+		if !ok {
+			return mismatch()
+		}
+		if _, err := number.Int64(); err != nil {
+			return []error{fmt.Errorf("%s is %s, expected an integer", at, number)}
+		}
+	case "number":
+		if _, ok := value.(json.Number); !ok {
+			return mismatch()
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return mismatch()
+		}
+	default:
+		return []error{fmt.Errorf("%s: unknown schema type %s", at, s.Type)}
+	}
+	return nil
+}
+
+// jsonType names the JSON type of a decoded value
+func jsonType(value any) string {
+	switch value.(type) {
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case json.Number:
+		return "number"
+	case bool:
+		return "boolean"
+	default:
+		return "null"
+	}
+}
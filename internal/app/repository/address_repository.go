@@ -0,0 +1,199 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"oolio/internal/app/models"
+	"oolio/internal/database/sqlc"
+
+	"github.com/google/uuid"
+)
+
+// AddressRepository manages customers' address books. Every call is scoped to one customer,
+// so a customer can never read or change another customer's addresses.
+type AddressRepository interface {
+	// FindByCustomer returns the default address first, then the rest oldest first
+	FindByCustomer(ctx context.Context, customerID string) ([]models.Address, error)
+	FindOne(ctx context.Context, customerID, id string) (*models.Address, error)
+	Count(ctx context.Context, customerID string) (int, error)
+	// Create adds the address; it becomes the default if marked so or if it is the first
+	Create(ctx context.Context, customerID string, address *models.Address) error
+	SetDefault(ctx context.Context, customerID, id string) error
+	// Delete removes the address; if it was the default, the newest remaining one takes over
+	Delete(ctx context.Context, customerID, id string) error
+}
+
+type addressRepository struct {
+	db  *sql.DB
+	qtx *sqlc.Queries
+}
+
+func NewAddressRepository(db *sql.DB) AddressRepository {
+	return &addressRepository{db: db, qtx: sqlc.New(db)}
+}
+
+func (r *addressRepository) FindByCustomer(ctx context.Context, customerID string) ([]models.Address, error) {
+	dbAddresses, err := r.qtx.GetCustomerAddresses(ctx, customerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get addresses: %w", err)
+	}
+
+	addresses := make([]models.Address, len(dbAddresses))
+	for i, a := range dbAddresses {
+		addresses[i] = mapSQLCAddress(a)
+	}
+	return addresses, nil
+}
+
+func (r *addressRepository) FindOne(ctx context.Context, customerID, id string) (*models.Address, error) {
+	addressID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid address ID: %w", err)
+	}
+
+	dbAddress, err := r.qtx.GetCustomerAddress(ctx, sqlc.GetCustomerAddressParams{ID: addressID, CustomerID: customerID})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("address not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get address: %w", err)
+	}
+
+	address := mapSQLCAddress(dbAddress)
+	return &address, nil
+}
+
+func (r *addressRepository) Count(ctx context.Context, customerID string) (int, error) {
+	count, err := r.qtx.CountCustomerAddresses(ctx, customerID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count addresses: %w", err)
+	}
+	return int(count), nil
+}
+
+func (r *addressRepository) Create(ctx context.Context, customerID string, address *models.Address) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	qtx := r.qtx.WithTx(tx)
+
+	count, err := qtx.CountCustomerAddresses(ctx, customerID)
+	if err != nil {
+		return fmt.Errorf("failed to count addresses: %w", err)
+	}
+	isDefault := address.IsDefault || count == 0
+	if isDefault {
+		if err := qtx.ClearDefaultCustomerAddress(ctx, customerID); err != nil {
+			return fmt.Errorf("failed to clear default address: %w", err)
+		}
+	}
+
+	dbAddress, err := qtx.CreateCustomerAddress(ctx, sqlc.CreateCustomerAddressParams{
+		CustomerID:   customerID,
+		Label:        address.Label,
+		Line1:        address.Line1,
+		Line2:        address.Line2,
+		City:         address.City,
+		Region:       address.Region,
+		PostalCode:   address.PostalCode,
+		Country:      address.Country,
+		Instructions: address.Instructions,
+		IsDefault:    isDefault,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create address: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit address: %w", err)
+	}
+
+	*address = mapSQLCAddress(dbAddress)
+	return nil
+}
+
+func (r *addressRepository) SetDefault(ctx context.Context, customerID, id string) error {
+	addressID, err := uuid.Parse(id)
+	if err != nil {
+		return fmt.Errorf("invalid address ID: %w", err)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	qtx := r.qtx.WithTx(tx)
+
+	if err := qtx.ClearDefaultCustomerAddress(ctx, customerID); err != nil {
+		return fmt.Errorf("failed to clear default address: %w", err)
+	}
+	updated, err := qtx.SetDefaultCustomerAddress(ctx, sqlc.SetDefaultCustomerAddressParams{ID: addressID, CustomerID: customerID})
+	if err != nil {
+		return fmt.Errorf("failed to set default address: %w", err)
+	}
+	if updated == 0 {
+		return fmt.Errorf("address not found")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit default address: %w", err)
+	}
+	return nil
+}
+
+func (r *addressRepository) Delete(ctx context.Context, customerID, id string) error {
+	addressID, err := uuid.Parse(id)
+	if err != nil {
+		return fmt.Errorf("invalid address ID: %w", err)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	qtx := r.qtx.WithTx(tx)
+
+	deleted, err := qtx.DeleteCustomerAddress(ctx, sqlc.DeleteCustomerAddressParams{ID: addressID, CustomerID: customerID})
+	if err != nil {
+		return fmt.Errorf("failed to delete address: %w", err)
+	}
+	if deleted == 0 {
+		return fmt.Errorf("address not found")
+	}
+	if err := qtx.PromoteNewestCustomerAddress(ctx, customerID); err != nil {
+		return fmt.Errorf("failed to promote default address: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit address deletion: %w", err)
+	}
+	return nil
+}
+
+func mapSQLCAddress(a sqlc.CustomerAddress) models.Address {
+	return models.Address{
+		ID:    a.ID.String(),
+		Label: a.Label,
+		DeliveryAddress: models.DeliveryAddress{
+			Line1:        a.Line1,
+			Line2:        a.Line2,
+			City:         a.City,
+			Region:       a.Region,
+			PostalCode:   a.PostalCode,
+			Country:      a.Country,
+			Instructions: a.Instructions,
+		},
+		IsDefault: a.IsDefault,
+		CreatedAt: a.CreatedAt,
+	}
+}
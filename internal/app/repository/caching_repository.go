@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"oolio/internal/app/models"
+)
+
+// cachingRepository serves Find and FindOne from a cache and invalidates them on every
+// write. Pages and change feeds depend on their arguments and go straight to the repository.
+type cachingRepository[T any] struct {
+	repo  BaseRepository[T]
+	cache Cache
+	name  string
+	ttl   time.Duration
+}
+
+func newCachingRepository[T any](repo BaseRepository[T], cache Cache, name string, ttl time.Duration) *cachingRepository[T] {
+	return &cachingRepository[T]{repo: repo, cache: cache, name: name, ttl: ttl}
+}
+
+func (r *cachingRepository[T]) allKey() string {
+	return r.name + ":all"
+}
+
+func (r *cachingRepository[T]) oneKey(id string) string {
+	return r.name + ":one:" + id
+}
+
+func (r *cachingRepository[T]) Find(ctx context.Context) ([]T, error) {
+	return cached(ctx, r, r.allKey(), r.repo.Find)
+}
+
+func (r *cachingRepository[T]) FindPage(ctx context.Context, page models.PageRequest) ([]T, int, error) {
+	return r.repo.FindPage(ctx, page)
+}
+
+func (r *cachingRepository[T]) FindUpdatedSince(ctx context.Context, since time.Time) ([]T, error) {
+	return r.repo.FindUpdatedSince(ctx, since)
+}
+
+func (r *cachingRepository[T]) FindOne(ctx context.Context, id string) (*T, error) {
+	return cached(ctx, r, r.oneKey(id), func(ctx context.Context) (*T, error) {
+		return r.repo.FindOne(ctx, id)
+	})
+}
+
+func (r *cachingRepository[T]) Create(ctx context.Context, entity *T) error {
+	defer r.invalidate(ctx)
+	return r.repo.Create(ctx, entity)
+}
+
+func (r *cachingRepository[T]) Update(ctx context.Context, entity *T) error {
+	// The id isn't reachable through T, so the decorator for the concrete type
+	// invalidates the single item key
+	defer r.invalidate(ctx)
+	return r.repo.Update(ctx, entity)
+}
+
+func (r *cachingRepository[T]) Delete(ctx context.Context, id string) error {
+	defer r.invalidate(ctx, id)
+	return r.repo.Delete(ctx, id)
+}
+
+// invalidate drops the listing and the given items. It runs even when the write fails,
+// since a failed call may still have been applied.
+func (r *cachingRepository[T]) invalidate(ctx context.Context, ids ...string) {
+	keys := []string{r.allKey()}
+	for _, id := range ids {
+		keys = append(keys, r.oneKey(id))
+	}
+	_ = r.cache.Delete(context.WithoutCancel(ctx), keys...)
+}
+
+func cached[T, V any](ctx context.Context, r *cachingRepository[T], key string, load func(ctx context.Context) (V, error)) (V, error) {
+	if data, ok, err := r.cache.Get(ctx, key); err == nil && ok {
+		var value V
+		if err := json.Unmarshal(data, &value); err == nil {
+			return value, nil
+		}
+	}
+
+	value, err := load(ctx)
+	if err != nil {
+		return value, err
+	}
+
+	if data, err := json.Marshal(value); err == nil {
+		_ = r.cache.Set(ctx, key, data, r.ttl)
+	}
+	return value, nil
+}
+
+type cachingProductRepository struct {
+	*cachingRepository[models.Product]
+	repo ProductRepository
+}
+
+// NewCachingProductRepository wraps repo so product listings and lookups are served from
+// cache for up to ttl, with writes through this repository invalidating them
+func NewCachingProductRepository(repo ProductRepository, cache Cache, ttl time.Duration) ProductRepository {
+	return &cachingProductRepository{
+		cachingRepository: newCachingRepository[models.Product](repo, cache, "products", ttl),
+		repo:              repo,
+	}
+}
+
+func (r *cachingProductRepository) Update(ctx context.Context, product *models.Product) error {
+	defer r.invalidate(ctx, product.ID)
+	return r.repo.Update(ctx, product)
+}
+
+func (r *cachingProductRepository) FindByIDs(ctx context.Context, ids []string) ([]models.Product, error) {
+	return r.repo.FindByIDs(ctx, ids)
+}
+
+func (r *cachingProductRepository) FindOneForUpdate(ctx context.Context, id string) (*models.Product, error) {
+	return r.repo.FindOneForUpdate(ctx, id)
+}
+
+func (r *cachingProductRepository) FindChanges(ctx context.Context, storeID string, after int64, limit int) ([]models.ProductChange, error) {
+	return r.repo.FindChanges(ctx, storeID, after, limit)
+}
+
+func (r *cachingProductRepository) FindDeleted(ctx context.Context, since time.Time) ([]models.Product, error) {
+	return r.repo.FindDeleted(ctx, since)
+}
+
+func (r *cachingProductRepository) Restore(ctx context.Context, id string) error {
+	defer r.invalidate(ctx, id)
+	return r.repo.Restore(ctx, id)
+}
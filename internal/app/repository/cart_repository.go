@@ -0,0 +1,227 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"oolio/internal/app/models"
+	"oolio/internal/database/sqlc"
+
+	"github.com/google/uuid"
+)
+
+// CartRepository keeps carts between visits. A customer has one cart that never expires;
+// guest carts are found by their ID until they expire.
+type CartRepository interface {
+	// FindByCustomer returns the customer's cart with its items
+	FindByCustomer(ctx context.Context, customerID string) (*models.Cart, error)
+	// FindGuest returns the guest cart with its items unless it has expired
+	FindGuest(ctx context.Context, id string) (*models.Cart, error)
+	// EnsureForCustomer returns the customer's cart, creating an empty one if they have none
+	EnsureForCustomer(ctx context.Context, customerID string) (*models.Cart, error)
+	CreateGuest(ctx context.Context, expiresAt time.Time) (*models.Cart, error)
+	// AddItem adds item.Quantity to the quantity of the product already in the cart, which
+	// takes item's options
+	AddItem(ctx context.Context, cartID string, item models.CartItem) error
+	// SetQuantity replaces the quantity of a product already in the cart
+	SetQuantity(ctx context.Context, cartID string, item models.CartItem) error
+	RemoveItem(ctx context.Context, cartID, productID string) error
+	Clear(ctx context.Context, cartID string) error
+	// Touch records a change to the cart; a guest cart now expires at expiresAt
+	Touch(ctx context.Context, cartID string, expiresAt time.Time) error
+	// DeleteExpired removes the guest carts that expired at or before before
+	DeleteExpired(ctx context.Context, before time.Time) (int, error)
+}
+
+type cartRepository struct {
+	qtx *sqlc.Queries
+}
+
+func NewCartRepository(db *sql.DB) CartRepository {
+	return &cartRepository{qtx: sqlc.New(db)}
+}
+
+func (r *cartRepository) FindByCustomer(ctx context.Context, customerID string) (*models.Cart, error) {
+	dbCart, err := r.qtx.GetCustomerCart(ctx, sql.NullString{String: customerID, Valid: true})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("cart not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cart: %w", err)
+	}
+	return r.withItems(ctx, dbCart)
+}
+
+func (r *cartRepository) FindGuest(ctx context.Context, id string) (*models.Cart, error) {
+	cartID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cart ID: %w", err)
+	}
+
+	dbCart, err := r.qtx.GetGuestCart(ctx, cartID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("cart not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cart: %w", err)
+	}
+	return r.withItems(ctx, dbCart)
+}
+
+func (r *cartRepository) EnsureForCustomer(ctx context.Context, customerID string) (*models.Cart, error) {
+	dbCart, err := r.qtx.UpsertCustomerCart(ctx, sql.NullString{String: customerID, Valid: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cart: %w", err)
+	}
+	return r.withItems(ctx, dbCart)
+}
+
+func (r *cartRepository) CreateGuest(ctx context.Context, expiresAt time.Time) (*models.Cart, error) {
+	dbCart, err := r.qtx.CreateGuestCart(ctx, sql.NullTime{Time: expiresAt, Valid: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cart: %w", err)
+	}
+
+	cart := mapSQLCCart(dbCart)
+	return &cart, nil
+}
+
+func (r *cartRepository) AddItem(ctx context.Context, cartID string, item models.CartItem) error {
+	cartUUID, productUUID, err := parseCartItemIDs(cartID, item.ProductID)
+	if err != nil {
+		return err
+	}
+
+	params := sqlc.AddCartItemParams{CartID: cartUUID, ProductID: productUUID, Quantity: int32(item.Quantity)}
+	if item.VariantID != "" {
+		variantUUID, err := uuid.Parse(item.VariantID)
+		if err != nil {
+			return fmt.Errorf("invalid variant ID: %w", err)
+		}
+		params.VariantID = uuid.NullUUID{UUID: variantUUID, Valid: true}
+	}
+	params.ModifierIds, err = json.Marshal(slices.Concat([]string{}, item.ModifierIDs))
+	if err != nil {
+		return fmt.Errorf("failed to encode cart item modifiers: %w", err)
+	}
+
+	err = r.qtx.AddCartItem(ctx, params)
+	if err != nil {
+		return fmt.Errorf("failed to add cart item: %w", err)
+	}
+	return nil
+}
+
+func (r *cartRepository) SetQuantity(ctx context.Context, cartID string, item models.CartItem) error {
+	cartUUID, productUUID, err := parseCartItemIDs(cartID, item.ProductID)
+	if err != nil {
+		return err
+	}
+
+	updated, err := r.qtx.SetCartItemQuantity(ctx, sqlc.SetCartItemQuantityParams{CartID: cartUUID, ProductID: productUUID, Quantity: int32(item.Quantity)})
+	if err != nil {
+		return fmt.Errorf("failed to update cart item: %w", err)
+	}
+	if updated == 0 {
+		return fmt.Errorf("cart item not found")
+	}
+	return nil
+}
+
+func (r *cartRepository) RemoveItem(ctx context.Context, cartID, productID string) error {
+	cartUUID, productUUID, err := parseCartItemIDs(cartID, productID)
+	if err != nil {
+		return err
+	}
+
+	deleted, err := r.qtx.DeleteCartItem(ctx, sqlc.DeleteCartItemParams{CartID: cartUUID, ProductID: productUUID})
+	if err != nil {
+		return fmt.Errorf("failed to remove cart item: %w", err)
+	}
+	if deleted == 0 {
+		return fmt.Errorf("cart item not found")
+	}
+	return nil
+}
+
+func (r *cartRepository) Clear(ctx context.Context, cartID string) error {
+	cartUUID, err := uuid.Parse(cartID)
+	if err != nil {
+		return fmt.Errorf("invalid cart ID: %w", err)
+	}
+
+	if err := r.qtx.ClearCartItems(ctx, cartUUID); err != nil {
+		return fmt.Errorf("failed to clear cart: %w", err)
+	}
+	return nil
+}
+
+func (r *cartRepository) Touch(ctx context.Context, cartID string, expiresAt time.Time) error {
+	cartUUID, err := uuid.Parse(cartID)
+	if err != nil {
+		return fmt.Errorf("invalid cart ID: %w", err)
+	}
+
+	err = r.qtx.TouchCart(ctx, sqlc.TouchCartParams{ID: cartUUID, ExpiresAt: sql.NullTime{Time: expiresAt, Valid: true}})
+	if err != nil {
+		return fmt.Errorf("failed to touch cart: %w", err)
+	}
+	return nil
+}
+
+func (r *cartRepository) DeleteExpired(ctx context.Context, before time.Time) (int, error) {
+	deleted, err := r.qtx.DeleteExpiredCarts(ctx, sql.NullTime{Time: before, Valid: true})
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired carts: %w", err)
+	}
+	return int(deleted), nil
+}
+
+func (r *cartRepository) withItems(ctx context.Context, dbCart sqlc.Cart) (*models.Cart, error) {
+	dbItems, err := r.qtx.GetCartItems(ctx, dbCart.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cart items: %w", err)
+	}
+
+	cart := mapSQLCCart(dbCart)
+	for _, dbItem := range dbItems {
+		item := models.CartItem{ProductID: dbItem.ProductID.String(), Quantity: int(dbItem.Quantity)}
+		if dbItem.VariantID.Valid {
+			item.VariantID = dbItem.VariantID.UUID.String()
+		}
+		if err := json.Unmarshal(dbItem.ModifierIds, &item.ModifierIDs); err != nil {
+			return nil, fmt.Errorf("failed to decode cart item modifiers: %w", err)
+		}
+		cart.Items = append(cart.Items, item)
+	}
+	return &cart, nil
+}
+
+func parseCartItemIDs(cartID, productID string) (uuid.UUID, uuid.UUID, error) {
+	cartUUID, err := uuid.Parse(cartID)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, fmt.Errorf("invalid cart ID: %w", err)
+	}
+	productUUID, err := uuid.Parse(productID)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, fmt.Errorf("invalid product ID: %w", err)
+	}
+	return cartUUID, productUUID, nil
+}
+
+func mapSQLCCart(c sqlc.Cart) models.Cart {
+	cart := models.Cart{
+		ID:        c.ID.String(),
+		Items:     []models.CartItem{},
+		UpdatedAt: c.UpdatedAt,
+	}
+	if c.ExpiresAt.Valid {
+		cart.ExpiresAt = &c.ExpiresAt.Time
+	}
+	return cart
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"oolio/internal/database/sqlc"
+)
+
+// CouponRedemptionRepository records who has used which single-use coupon. Codes are
+// stored as given; callers pass them in upper case.
+type CouponRedemptionRepository interface {
+	IsRedeemed(ctx context.Context, code, redeemer string) (bool, error)
+	// Redeem records the redemption, failing with "coupon already redeemed" when the
+	// redeemer has used the code before
+	Redeem(ctx context.Context, code, redeemer string) error
+	// Release forgets a redemption, e.g. when the order it was for couldn't be created
+	Release(ctx context.Context, code, redeemer string) error
+}
+
+type couponRedemptionRepository struct {
+	qtx *sqlc.Queries
+}
+
+func NewCouponRedemptionRepository(db *sql.DB) CouponRedemptionRepository {
+	return &couponRedemptionRepository{qtx: sqlc.New(db)}
+}
+
+func (r *couponRedemptionRepository) IsRedeemed(ctx context.Context, code, redeemer string) (bool, error) {
+	redeemed, err := r.qtx.CouponRedeemed(ctx, sqlc.CouponRedeemedParams{Code: code, Redeemer: redeemer})
+	if err != nil {
+		return false, fmt.Errorf("failed to get coupon redemption: %w", err)
+	}
+	return redeemed, nil
+}
+
+func (r *couponRedemptionRepository) Redeem(ctx context.Context, code, redeemer string) error {
+	inserted, err := r.qtx.InsertCouponRedemption(ctx, sqlc.InsertCouponRedemptionParams{Code: code, Redeemer: redeemer})
+	if err != nil {
+		return fmt.Errorf("failed to redeem coupon: %w", err)
+	}
+	if inserted == 0 {
+		return fmt.Errorf("coupon already redeemed")
+	}
+	return nil
+}
+
+func (r *couponRedemptionRepository) Release(ctx context.Context, code, redeemer string) error {
+	deleted, err := r.qtx.DeleteCouponRedemption(ctx, sqlc.DeleteCouponRedemptionParams{Code: code, Redeemer: redeemer})
+	if err != nil {
+		return fmt.Errorf("failed to release coupon redemption: %w", err)
+	}
+	if deleted == 0 {
+		return fmt.Errorf("coupon redemption not found")
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"oolio/internal/app/models"
+	"oolio/internal/database/sqlc"
+)
+
+// CouponRepository manages the coupon codes stored in the database, e.g. by the seed
+// command. Coupons are identified by their code.
+type CouponRepository interface {
+	FindAll(ctx context.Context) ([]models.Coupon, error)
+	Delete(ctx context.Context, code string) error
+	SoftDeleteRepository[models.Coupon]
+}
+
+type couponRepository struct {
+	qtx *sqlc.Queries
+}
+
+func NewCouponRepository(db *sql.DB) CouponRepository {
+	return &couponRepository{qtx: sqlc.New(db)}
+}
+
+func (r *couponRepository) FindAll(ctx context.Context) ([]models.Coupon, error) {
+	dbCoupons, err := r.qtx.GetCoupons(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get coupons: %w", err)
+	}
+
+	return mapSQLCCoupons(dbCoupons), nil
+}
+
+func (r *couponRepository) Delete(ctx context.Context, code string) error {
+	deleted, err := r.qtx.DeleteCoupon(ctx, code)
+	if err != nil {
+		return fmt.Errorf("failed to delete coupon: %w", err)
+	}
+	if deleted == 0 {
+		return fmt.Errorf("coupon not found")
+	}
+	return nil
+}
+
+func (r *couponRepository) FindDeleted(ctx context.Context, since time.Time) ([]models.Coupon, error) {
+	dbCoupons, err := r.qtx.GetDeletedCoupons(ctx, sql.NullTime{Time: since, Valid: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deleted coupons: %w", err)
+	}
+
+	return mapSQLCCoupons(dbCoupons), nil
+}
+
+func (r *couponRepository) Restore(ctx context.Context, code string) error {
+	restored, err := r.qtx.RestoreCoupon(ctx, code)
+	if err != nil {
+		return fmt.Errorf("failed to restore coupon: %w", err)
+	}
+	if restored == 0 {
+		return fmt.Errorf("deleted coupon not found")
+	}
+	return nil
+}
+
+func mapSQLCCoupons(dbCoupons []sqlc.Coupon) []models.Coupon {
+	coupons := make([]models.Coupon, len(dbCoupons))
+	for i, c := range dbCoupons {
+		var storeID string
+		if c.StoreID.Valid {
+			storeID = c.StoreID.UUID.String()
+		}
+		coupons[i] = models.Coupon{
+			Code:               c.Code,
+			DiscountPercentage: c.DiscountPercentage,
+			Segment:            nullStringToString(c.Segment),
+			SingleUse:          c.SingleUse,
+			StoreID:            storeID,
+			DeletedAt:          nullTimeToPtr(c.DeletedAt),
+		}
+	}
+	return coupons
+}
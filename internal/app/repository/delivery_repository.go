@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"oolio/internal/app/models"
+	"oolio/internal/database/sqlc"
+)
+
+// DeliveryRepository stores the delivery booked for an order, at most one per order
+type DeliveryRepository interface {
+	// Create stores the delivery and fills in its ID and times. It fails with
+	// "delivery already exists" when the order has one.
+	Create(ctx context.Context, delivery *models.Delivery) error
+	// FindByOrder returns the order's delivery, or "delivery not found"
+	FindByOrder(ctx context.Context, orderID string) (*models.Delivery, error)
+	// Update saves the delivery's provider job, status and tracking details
+	Update(ctx context.Context, delivery *models.Delivery) error
+}
+
+type deliveryRepository struct {
+	qtx *sqlc.Queries
+}
+
+func NewDeliveryRepository(db *sql.DB) DeliveryRepository {
+	return &deliveryRepository{qtx: sqlc.New(db)}
+}
+
+func (r *deliveryRepository) Create(ctx context.Context, delivery *models.Delivery) error {
+	orderUUID, err := uuid.Parse(delivery.OrderID)
+	if err != nil {
+		return fmt.Errorf("invalid order ID: %w", err)
+	}
+
+	dbDelivery, err := r.qtx.CreateDelivery(ctx, sqlc.CreateDeliveryParams{
+		OrderID:     orderUUID,
+		Provider:    delivery.Provider,
+		ExternalID:  delivery.ExternalID,
+		Status:      delivery.Status,
+		TrackingUrl: delivery.TrackingURL,
+		CourierName: delivery.CourierName,
+		EstimatedAt: ptrToNullTime(delivery.EstimatedAt),
+		Error:       delivery.Error,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("delivery already exists")
+		}
+		return fmt.Errorf("failed to create delivery: %w", err)
+	}
+	*delivery = convertDelivery(dbDelivery)
+	return nil
+}
+
+func (r *deliveryRepository) FindByOrder(ctx context.Context, orderID string) (*models.Delivery, error) {
+	orderUUID, err := uuid.Parse(orderID)
+	if err != nil {
+		return nil, fmt.Errorf("delivery not found")
+	}
+
+	dbDelivery, err := r.qtx.GetDeliveryByOrder(ctx, orderUUID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("delivery not found")
+		}
+		return nil, fmt.Errorf("failed to get delivery: %w", err)
+	}
+	delivery := convertDelivery(dbDelivery)
+	return &delivery, nil
+}
+
+func (r *deliveryRepository) Update(ctx context.Context, delivery *models.Delivery) error {
+	deliveryUUID, err := uuid.Parse(delivery.ID)
+	if err != nil {
+		return fmt.Errorf("delivery not found")
+	}
+
+	dbDelivery, err := r.qtx.UpdateDelivery(ctx, sqlc.UpdateDeliveryParams{
+		ID:          deliveryUUID,
+		ExternalID:  delivery.ExternalID,
+		Status:      delivery.Status,
+		TrackingUrl: delivery.TrackingURL,
+		CourierName: delivery.CourierName,
+		EstimatedAt: ptrToNullTime(delivery.EstimatedAt),
+		Error:       delivery.Error,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("delivery not found")
+		}
+		return fmt.Errorf("failed to update delivery: %w", err)
+	}
+	*delivery = convertDelivery(dbDelivery)
+	return nil
+}
+
+func convertDelivery(d sqlc.Delivery) models.Delivery {
+	return models.Delivery{
+		ID:          d.ID.String(),
+		OrderID:     d.OrderID.String(),
+		Provider:    d.Provider,
+		ExternalID:  d.ExternalID,
+		Status:      d.Status,
+		TrackingURL: d.TrackingUrl,
+		CourierName: d.CourierName,
+		EstimatedAt: nullTimeToPtr(d.EstimatedAt),
+		Error:       d.Error,
+		CreatedAt:   d.CreatedAt,
+		UpdatedAt:   d.UpdatedAt,
+	}
+}
+
+func ptrToNullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: *t, Valid: true}
+}
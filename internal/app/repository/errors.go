@@ -0,0 +1,25 @@
+package repository
+
+import "fmt"
+
+// ConflictError is returned by Update when the entity was changed since the caller read it,
+// i.e. the version it carries is stale
+type ConflictError struct {
+	Entity  string
+	ID      string
+	Version int
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%s %s update conflict: version %d is stale", e.Entity, e.ID, e.Version)
+}
+
+// OutOfStockError is returned when a product has fewer units left than were asked for
+type OutOfStockError struct {
+	ProductID string
+	Available int
+}
+
+func (e *OutOfStockError) Error() string {
+	return fmt.Sprintf("product %s has %d left", e.ProductID, e.Available)
+}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"oolio/internal/database/sqlc"
+
+	"github.com/google/uuid"
+)
+
+// FavoriteRepository keeps the products each customer marked as favorites
+type FavoriteRepository interface {
+	// FindByCustomer returns the IDs of the customer's favorite products, newest first
+	FindByCustomer(ctx context.Context, customerID string) ([]string, error)
+	// Add does nothing if the product already is a favorite
+	Add(ctx context.Context, customerID, productID string) error
+	Remove(ctx context.Context, customerID, productID string) error
+}
+
+type favoriteRepository struct {
+	qtx *sqlc.Queries
+}
+
+func NewFavoriteRepository(db *sql.DB) FavoriteRepository {
+	return &favoriteRepository{qtx: sqlc.New(db)}
+}
+
+func (r *favoriteRepository) FindByCustomer(ctx context.Context, customerID string) ([]string, error) {
+	productIDs, err := r.qtx.GetCustomerFavorites(ctx, customerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get favorites: %w", err)
+	}
+
+	ids := make([]string, len(productIDs))
+	for i, id := range productIDs {
+		ids[i] = id.String()
+	}
+	return ids, nil
+}
+
+func (r *favoriteRepository) Add(ctx context.Context, customerID, productID string) error {
+	productUUID, err := uuid.Parse(productID)
+	if err != nil {
+		return fmt.Errorf("invalid product ID: %w", err)
+	}
+
+	err = r.qtx.AddCustomerFavorite(ctx, sqlc.AddCustomerFavoriteParams{CustomerID: customerID, ProductID: productUUID})
+	if err != nil {
+		return fmt.Errorf("failed to add favorite: %w", err)
+	}
+	return nil
+}
+
+func (r *favoriteRepository) Remove(ctx context.Context, customerID, productID string) error {
+	productUUID, err := uuid.Parse(productID)
+	if err != nil {
+		return fmt.Errorf("invalid product ID: %w", err)
+	}
+
+	deleted, err := r.qtx.DeleteCustomerFavorite(ctx, sqlc.DeleteCustomerFavoriteParams{CustomerID: customerID, ProductID: productUUID})
+	if err != nil {
+		return fmt.Errorf("failed to remove favorite: %w", err)
+	}
+	if deleted == 0 {
+		return fmt.Errorf("favorite not found")
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"oolio/internal/app/models"
+	"oolio/internal/database/sqlc"
+)
+
+// GiftCardRepository stores gift cards and their balances. Codes are stored as given;
+// callers pass them in upper case.
+type GiftCardRepository interface {
+	// Create stores a new card with its whole amount to spend, failing with "gift card
+	// already exists" when the code is taken
+	Create(ctx context.Context, card *models.GiftCard) error
+	// FindOne returns the card, or "gift card not found"
+	FindOne(ctx context.Context, code string) (*models.GiftCard, error)
+	// Debit takes amount from the balance in one step, failing with "insufficient gift card
+	// balance" when the card is unknown, expired or holds less
+	Debit(ctx context.Context, code string, amount models.Money) error
+	// Credit puts amount back, e.g. when the order it was debited for couldn't be created
+	Credit(ctx context.Context, code string, amount models.Money) error
+}
+
+type giftCardRepository struct {
+	qtx *sqlc.Queries
+}
+
+func NewGiftCardRepository(db *sql.DB) GiftCardRepository {
+	return &giftCardRepository{qtx: sqlc.New(db)}
+}
+
+func (r *giftCardRepository) Create(ctx context.Context, card *models.GiftCard) error {
+	var expiresAt sql.NullTime
+	if card.ExpiresAt != nil {
+		expiresAt = sql.NullTime{Time: *card.ExpiresAt, Valid: true}
+	}
+
+	dbCard, err := r.qtx.CreateGiftCard(ctx, sqlc.CreateGiftCardParams{
+		Code:           card.Code,
+		InitialBalance: card.InitialBalance,
+		ExpiresAt:      expiresAt,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("gift card already exists")
+		}
+		return fmt.Errorf("failed to create gift card: %w", err)
+	}
+
+	*card = mapSQLCToGiftCard(dbCard)
+	return nil
+}
+
+func (r *giftCardRepository) FindOne(ctx context.Context, code string) (*models.GiftCard, error) {
+	dbCard, err := r.qtx.GetGiftCard(ctx, code)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("gift card not found")
+		}
+		return nil, fmt.Errorf("failed to get gift card: %w", err)
+	}
+
+	card := mapSQLCToGiftCard(dbCard)
+	return &card, nil
+}
+
+func (r *giftCardRepository) Debit(ctx context.Context, code string, amount models.Money) error {
+	updated, err := r.qtx.DebitGiftCard(ctx, sqlc.DebitGiftCardParams{Amount: amount, Code: code})
+	if err != nil {
+		return fmt.Errorf("failed to debit gift card: %w", err)
+	}
+	if updated == 0 {
+		return fmt.Errorf("insufficient gift card balance")
+	}
+	return nil
+}
+
+func (r *giftCardRepository) Credit(ctx context.Context, code string, amount models.Money) error {
+	updated, err := r.qtx.CreditGiftCard(ctx, sqlc.CreditGiftCardParams{Amount: amount, Code: code})
+	if err != nil {
+		return fmt.Errorf("failed to credit gift card: %w", err)
+	}
+	if updated == 0 {
+		return fmt.Errorf("gift card not found")
+	}
+	return nil
+}
+
+func mapSQLCToGiftCard(dbCard sqlc.GiftCard) models.GiftCard {
+	card := models.GiftCard{
+		Code:           dbCard.Code,
+		InitialBalance: dbCard.InitialBalance,
+		Balance:        dbCard.Balance,
+		CreatedAt:      dbCard.CreatedAt,
+		UpdatedAt:      dbCard.UpdatedAt,
+	}
+	if dbCard.ExpiresAt.Valid {
+		card.ExpiresAt = &dbCard.ExpiresAt.Time
+	}
+	return card
+}
@@ -2,24 +2,77 @@ package repository
 
 import (
 	"context"
+	"database/sql"
+	"time"
 
 	"oolio/internal/app/models"
 )
 
 type BaseRepository[T any] interface {
 	Find(ctx context.Context) ([]T, error)
+	// FindPage returns one page of the listing and the total number of items
+	FindPage(ctx context.Context, page models.PageRequest) ([]T, int, error)
+	// FindUpdatedSince returns items created or updated at or after since, oldest change first
+	FindUpdatedSince(ctx context.Context, since time.Time) ([]T, error)
 	FindOne(ctx context.Context, id string) (*T, error)
 	Create(ctx context.Context, entity *T) error
 	Update(ctx context.Context, entity *T) error
 	Delete(ctx context.Context, id string) error
 }
 
+// SoftDeleteRepository is implemented by repositories whose Delete only marks items as
+// deleted. Deleted items are hidden from every other read until restored.
+type SoftDeleteRepository[T any] interface {
+	// FindDeleted returns items deleted at or after since, most recently deleted first
+	FindDeleted(ctx context.Context, since time.Time) ([]T, error)
+	// Restore brings back a deleted item; it fails if there is no deleted item with that id
+	Restore(ctx context.Context, id string) error
+}
+
 type ProductRepository interface {
 	BaseRepository[models.Product]
+	SoftDeleteRepository[models.Product]
+	// FindByIDs loads several products in one query, ordered by name. Unknown IDs are
+	// skipped, so callers compare the result against what they asked for.
+	FindByIDs(ctx context.Context, ids []string) ([]models.Product, error)
+	// FindOneForUpdate is FindOne read from the primary, past any cache, for callers that
+	// write the product back
+	FindOneForUpdate(ctx context.Context, id string) (*models.Product, error)
+	// FindChanges returns up to limit entries of the change feed after the one at cursor
+	// after, oldest first, narrowed to storeID's products when set. Every write records one.
+	FindChanges(ctx context.Context, storeID string, after int64, limit int) ([]models.ProductChange, error)
 }
 
 type OrderRepository interface {
 	BaseRepository[models.Order]
 	CreateOrderItems(ctx context.Context, orderID string, items []models.OrderItem) error
 	GetOrderItems(ctx context.Context, orderID string) ([]models.OrderItem, error)
+	// SetPaymentStatus records what became of the payment of an order paid through an intent
+	SetPaymentStatus(ctx context.Context, orderID string, status string) error
+	// FindByPaymentIntent returns the order paid through the intent, or "order not found"
+	FindByPaymentIntent(ctx context.Context, intentID string) (*models.Order, error)
+	// PaymentMethodTotals sums the orders placed from since until before until, per payment
+	// method, leaving out cancelled and failed orders
+	PaymentMethodTotals(ctx context.Context, since, until time.Time) ([]models.PaymentMethodTotal, error)
+	// TopProducts returns the products ordered most from since until before until, at most
+	// limit of them, leaving out cancelled and failed orders
+	TopProducts(ctx context.Context, since, until time.Time, limit int) ([]models.ProductSales, error)
+	// CouponUsage counts the orders placed with each coupon from since until before until,
+	// most used first, leaving out cancelled and failed orders
+	CouponUsage(ctx context.Context, since, until time.Time) ([]models.CouponUsage, error)
+	// Sales sums the orders the query selects by day, oldest first, or by product or
+	// category, highest revenue first
+	Sales(ctx context.Context, query models.SalesQuery) ([]models.SalesGroup, error)
+	// CreateRefund records a requested refund of the order, filling in its ID, status and
+	// timestamps; UpdateRefund records the payment provider's answer. Both record an event.
+	CreateRefund(ctx context.Context, refund *models.OrderRefund) error
+	UpdateRefund(ctx context.Context, refund *models.OrderRefund) error
+	// GetRefunds returns the order's refunds, oldest first
+	GetRefunds(ctx context.Context, orderID string) ([]models.OrderRefund, error)
+}
+
+// ReadRouter picks the connection for read-only queries, e.g. a replica with
+// fallback to the primary
+type ReadRouter interface {
+	Reader() *sql.DB
 }
@@ -0,0 +1,253 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"oolio/internal/app/models"
+	"oolio/internal/database/sqlc"
+
+	"github.com/google/uuid"
+)
+
+// InventoryRepository counts the stock of products. Products it holds no stock for aren't
+// counted, and are never out of stock.
+type InventoryRepository interface {
+	// Reserve sets the quantities, by product ID, aside under reservationID until expiresAt,
+	// so that others can't take them. When a product has fewer units left that aren't
+	// reserved it reserves none and fails with an *OutOfStockError.
+	Reserve(ctx context.Context, reservationID string, quantities map[string]int, expiresAt time.Time) error
+	// Release deletes a reservation, leaving what it set aside to others
+	Release(ctx context.Context, reservationID string) error
+	// Take takes the quantities from stock at once, along with the reservation made for
+	// them, if any and when set. When a product has fewer units left that others haven't
+	// reserved it takes none and fails with an *OutOfStockError.
+	Take(ctx context.Context, reservationID string, quantities map[string]int) error
+	// Return puts back quantities taken, e.g. when the order they were taken for couldn't
+	// be created
+	Return(ctx context.Context, quantities map[string]int) error
+	// Restock adds quantity to the product's stock, starting to count it when it wasn't
+	Restock(ctx context.Context, productID string, quantity int) (*models.StockLevel, error)
+	// LowStock lists the products on the menu with threshold units left or fewer, fewest
+	// first, narrowed to a store when storeID is set
+	LowStock(ctx context.Context, threshold int, storeID string) ([]models.StockLevel, error)
+	// DeleteExpiredReservations deletes the reservations that expired at or before before,
+	// returning how many products they had set aside stock of
+	DeleteExpiredReservations(ctx context.Context, before time.Time) (int, error)
+}
+
+type inventoryRepository struct {
+	db     *sql.DB
+	qtx    *sqlc.Queries
+	reader ReadRouter
+}
+
+// NewInventoryRepository writes to db. The low-stock listing goes through reader when
+// set, otherwise db.
+func NewInventoryRepository(db *sql.DB, reader ReadRouter) InventoryRepository {
+	return &inventoryRepository{db: db, qtx: sqlc.New(db), reader: reader}
+}
+
+func (r *inventoryRepository) Reserve(ctx context.Context, reservationID string, quantities map[string]int, expiresAt time.Time) error {
+	reservation, err := uuid.Parse(reservationID)
+	if err != nil {
+		return fmt.Errorf("invalid reservation ID: %w", err)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	qtx := r.qtx.WithTx(tx)
+
+	stock, err := available(ctx, qtx, quantities)
+	if err != nil {
+		return err
+	}
+	for _, s := range stock {
+		err := qtx.CreateStockReservation(ctx, sqlc.CreateStockReservationParams{
+			ReservationID: reservation,
+			ProductID:     s.ProductID,
+			Quantity:      int32(quantities[s.ProductID.String()]),
+			ExpiresAt:     expiresAt,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to reserve stock of product %s: %w", s.ProductID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit stock reservation: %w", err)
+	}
+	return nil
+}
+
+func (r *inventoryRepository) Release(ctx context.Context, reservationID string) error {
+	reservation, err := uuid.Parse(reservationID)
+	if err != nil {
+		return fmt.Errorf("invalid reservation ID: %w", err)
+	}
+	if _, err := r.qtx.DeleteStockReservation(ctx, reservation); err != nil {
+		return fmt.Errorf("failed to release stock reservation %s: %w", reservationID, err)
+	}
+	return nil
+}
+
+func (r *inventoryRepository) Take(ctx context.Context, reservationID string, quantities map[string]int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	qtx := r.qtx.WithTx(tx)
+
+	// The order's own reservation is deleted first, so only others' count against it
+	if reservationID != "" {
+		reservation, err := uuid.Parse(reservationID)
+		if err != nil {
+			return fmt.Errorf("invalid reservation ID: %w", err)
+		}
+		if _, err := qtx.DeleteStockReservation(ctx, reservation); err != nil {
+			return fmt.Errorf("failed to delete stock reservation %s: %w", reservationID, err)
+		}
+	}
+
+	stock, err := available(ctx, qtx, quantities)
+	if err != nil {
+		return err
+	}
+	for _, s := range stock {
+		if err := qtx.TakeStock(ctx, sqlc.TakeStockParams{Quantity: int32(quantities[s.ProductID.String()]), ProductID: s.ProductID}); err != nil {
+			return fmt.Errorf("failed to take stock of product %s: %w", s.ProductID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit stock: %w", err)
+	}
+	return nil
+}
+
+// available locks the stock of the products counted among quantities and makes sure each
+// has its quantity left besides what unexpired reservations set aside. It returns the
+// stock it locked, to reserve or take from within the same transaction.
+func available(ctx context.Context, qtx *sqlc.Queries, quantities map[string]int) ([]sqlc.ProductStock, error) {
+	ids := make([]uuid.UUID, 0, len(quantities))
+	for productID := range quantities {
+		id, err := uuid.Parse(productID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid product ID: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	// Locked in the same order by every order, so two orders never wait on each other's
+	// rows, and before reading the reservations, which are only added under the lock
+	stock, err := qtx.LockStock(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock stock: %w", err)
+	}
+	rows, err := qtx.GetReservedStock(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reserved stock: %w", err)
+	}
+	reserved := make(map[uuid.UUID]int, len(rows))
+	for _, row := range rows {
+		reserved[row.ProductID] = int(row.Reserved)
+	}
+
+	for _, s := range stock {
+		left := int(s.Quantity) - reserved[s.ProductID]
+		if left < quantities[s.ProductID.String()] {
+			return nil, &OutOfStockError{ProductID: s.ProductID.String(), Available: max(left, 0)}
+		}
+	}
+	return stock, nil
+}
+
+func (r *inventoryRepository) Return(ctx context.Context, quantities map[string]int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	qtx := r.qtx.WithTx(tx)
+
+	for _, productID := range slices.Sorted(maps.Keys(quantities)) {
+		id, err := uuid.Parse(productID)
+		if err != nil {
+			return fmt.Errorf("invalid product ID: %w", err)
+		}
+		if _, err := qtx.ReturnStock(ctx, sqlc.ReturnStockParams{Quantity: int32(quantities[productID]), ProductID: id}); err != nil {
+			return fmt.Errorf("failed to return stock of product %s: %w", productID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit stock: %w", err)
+	}
+	return nil
+}
+
+func (r *inventoryRepository) Restock(ctx context.Context, productID string, quantity int) (*models.StockLevel, error) {
+	id, err := uuid.Parse(productID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid product ID: %w", err)
+	}
+
+	stock, err := r.qtx.Restock(ctx, sqlc.RestockParams{ProductID: id, Quantity: int32(quantity)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to restock product %s: %w", productID, err)
+	}
+	return &models.StockLevel{
+		ProductID: stock.ProductID.String(),
+		Quantity:  int(stock.Quantity),
+		UpdatedAt: stock.UpdatedAt,
+	}, nil
+}
+
+func (r *inventoryRepository) LowStock(ctx context.Context, threshold int, storeID string) ([]models.StockLevel, error) {
+	var store uuid.NullUUID
+	if storeID != "" {
+		parsed, err := uuid.Parse(storeID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid store ID: %w", err)
+		}
+		store = uuid.NullUUID{UUID: parsed, Valid: true}
+	}
+
+	queries := r.qtx
+	if r.reader != nil {
+		queries = sqlc.New(r.reader.Reader())
+	}
+	rows, err := queries.GetLowStock(ctx, sqlc.GetLowStockParams{Threshold: int32(threshold), StoreID: store})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get low stock: %w", err)
+	}
+
+	levels := make([]models.StockLevel, len(rows))
+	for i, row := range rows {
+		levels[i] = models.StockLevel{
+			ProductID: row.ProductID.String(),
+			Name:      row.Name,
+			Category:  row.Category,
+			StoreID:   row.StoreID.String(),
+			Quantity:  int(row.Quantity),
+			UpdatedAt: row.UpdatedAt,
+		}
+	}
+	return levels, nil
+}
+
+func (r *inventoryRepository) DeleteExpiredReservations(ctx context.Context, before time.Time) (int, error) {
+	deleted, err := r.qtx.DeleteExpiredStockReservations(ctx, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired stock reservations: %w", err)
+	}
+	return int(deleted), nil
+}
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"oolio/internal/app/models"
+	"oolio/internal/database/sqlc"
+)
+
+// InvoiceRepository numbers and stores issued invoices, one per order
+type InvoiceRepository interface {
+	// Issue gives the invoice the next number of its store's series and its issue time, then
+	// stores it. It fails with "invoice already exists" when the order has one, without
+	// using up a number.
+	Issue(ctx context.Context, series string, invoice *models.Invoice) error
+	// FindByOrder returns the order's invoice, or "invoice not found"
+	FindByOrder(ctx context.Context, orderID string) (*models.Invoice, error)
+}
+
+type invoiceRepository struct {
+	db  *sql.DB
+	qtx *sqlc.Queries
+}
+
+func NewInvoiceRepository(db *sql.DB) InvoiceRepository {
+	return &invoiceRepository{db: db, qtx: sqlc.New(db)}
+}
+
+func (r *invoiceRepository) Issue(ctx context.Context, series string, invoice *models.Invoice) error {
+	orderUUID, err := uuid.Parse(invoice.OrderID)
+	if err != nil {
+		return fmt.Errorf("invalid order ID: %w", err)
+	}
+	storeUUID, err := uuid.Parse(models.StoreOrDefault(invoice.StoreID))
+	if err != nil {
+		return fmt.Errorf("invalid store ID: %w", err)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	qtx := r.qtx.WithTx(tx)
+
+	number, err := qtx.NextInvoiceNumber(ctx, sqlc.NextInvoiceNumberParams{StoreID: storeUUID, Series: series})
+	if err != nil {
+		return fmt.Errorf("failed to number invoice: %w", err)
+	}
+
+	issued := *invoice
+	issued.StoreID = storeUUID.String()
+	issued.Number = models.InvoiceNumber(series, number)
+	issued.IssuedAt = time.Now()
+	document, err := json.Marshal(issued)
+	if err != nil {
+		return fmt.Errorf("failed to marshal invoice: %w", err)
+	}
+
+	created, err := qtx.CreateInvoice(ctx, sqlc.CreateInvoiceParams{
+		OrderID:  orderUUID,
+		Series:   series,
+		Number:   number,
+		IssuedAt: issued.IssuedAt,
+		Document: document,
+		StoreID:  storeUUID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create invoice: %w", err)
+	}
+	if created == 0 {
+		return fmt.Errorf("invoice already exists")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit invoice: %w", err)
+	}
+	*invoice = issued
+	return nil
+}
+
+func (r *invoiceRepository) FindByOrder(ctx context.Context, orderID string) (*models.Invoice, error) {
+	orderUUID, err := uuid.Parse(orderID)
+	if err != nil {
+		return nil, fmt.Errorf("invoice not found")
+	}
+
+	dbInvoice, err := r.qtx.GetInvoice(ctx, orderUUID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("invoice not found")
+		}
+		return nil, fmt.Errorf("failed to get invoice: %w", err)
+	}
+
+	var invoice models.Invoice
+	if err := json.Unmarshal(dbInvoice.Document, &invoice); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal invoice: %w", err)
+	}
+	// Invoices issued before stores don't name theirs in the document
+	invoice.StoreID = dbInvoice.StoreID.String()
+	return &invoice, nil
+}
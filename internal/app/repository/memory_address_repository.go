@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"oolio/internal/app/models"
+
+	"github.com/google/uuid"
+)
+
+// memoryAddressRepository is an in-process AddressRepository for local development
+// and tests that run without Postgres
+type memoryAddressRepository struct {
+	mutex     sync.RWMutex
+	addresses map[string][]models.Address // By customer, oldest first
+}
+
+func NewMemoryAddressRepository() AddressRepository {
+	return &memoryAddressRepository{
+		addresses: make(map[string][]models.Address),
+	}
+}
+
+func (r *memoryAddressRepository) FindByCustomer(ctx context.Context, customerID string) ([]models.Address, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	addresses := slices.Clone(r.addresses[customerID])
+	slices.SortStableFunc(addresses, func(a, b models.Address) int {
+		switch {
+		case a.IsDefault == b.IsDefault:
+			return 0
+		case a.IsDefault:
+			return -1
+		default:
+			return 1
+		}
+	})
+	return addresses, nil
+}
+
+func (r *memoryAddressRepository) FindOne(ctx context.Context, customerID, id string) (*models.Address, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, fmt.Errorf("invalid address ID: %w", err)
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	i := r.index(customerID, id)
+	if i < 0 {
+		return nil, fmt.Errorf("address not found")
+	}
+	address := r.addresses[customerID][i]
+	return &address, nil
+}
+
+func (r *memoryAddressRepository) Count(ctx context.Context, customerID string) (int, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return len(r.addresses[customerID]), nil
+}
+
+func (r *memoryAddressRepository) Create(ctx context.Context, customerID string, address *models.Address) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	addresses := r.addresses[customerID]
+	address.ID = uuid.New().String()
+	address.CreatedAt = time.Now()
+	address.IsDefault = address.IsDefault || len(addresses) == 0
+	if address.IsDefault {
+		for i := range addresses {
+			addresses[i].IsDefault = false
+		}
+	}
+	r.addresses[customerID] = append(addresses, *address)
+	return nil
+}
+
+func (r *memoryAddressRepository) SetDefault(ctx context.Context, customerID, id string) error {
+	if _, err := uuid.Parse(id); err != nil {
+		return fmt.Errorf("invalid address ID: %w", err)
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	target := r.index(customerID, id)
+	if target < 0 {
+		return fmt.Errorf("address not found")
+	}
+	addresses := r.addresses[customerID]
+	for i := range addresses {
+		addresses[i].IsDefault = i == target
+	}
+	return nil
+}
+
+func (r *memoryAddressRepository) Delete(ctx context.Context, customerID, id string) error {
+	if _, err := uuid.Parse(id); err != nil {
+		return fmt.Errorf("invalid address ID: %w", err)
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	i := r.index(customerID, id)
+	if i < 0 {
+		return fmt.Errorf("address not found")
+	}
+	wasDefault := r.addresses[customerID][i].IsDefault
+	addresses := slices.Delete(r.addresses[customerID], i, i+1)
+	if wasDefault && len(addresses) > 0 {
+		addresses[len(addresses)-1].IsDefault = true
+	}
+	r.addresses[customerID] = addresses
+	return nil
+}
+
+// index returns the position of the address in the customer's book, -1 when absent
+func (r *memoryAddressRepository) index(customerID, id string) int {
+	return slices.IndexFunc(r.addresses[customerID], func(a models.Address) bool {
+		return a.ID == id
+	})
+}
@@ -0,0 +1,190 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"oolio/internal/app/models"
+
+	"github.com/google/uuid"
+)
+
+// memoryCartRepository is an in-process CartRepository for local development
+// and tests that run without Postgres
+type memoryCartRepository struct {
+	mutex     sync.RWMutex
+	carts     map[string]*models.Cart
+	customers map[string]string // Cart ID by customer
+}
+
+func NewMemoryCartRepository() CartRepository {
+	return &memoryCartRepository{
+		carts:     make(map[string]*models.Cart),
+		customers: make(map[string]string),
+	}
+}
+
+func (r *memoryCartRepository) FindByCustomer(ctx context.Context, customerID string) (*models.Cart, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	id, ok := r.customers[customerID]
+	if !ok {
+		return nil, fmt.Errorf("cart not found")
+	}
+	return r.copyCart(id), nil
+}
+
+func (r *memoryCartRepository) FindGuest(ctx context.Context, id string) (*models.Cart, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, fmt.Errorf("invalid cart ID: %w", err)
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	cart, ok := r.carts[id]
+	if !ok || cart.ExpiresAt == nil || !cart.ExpiresAt.After(time.Now()) {
+		return nil, fmt.Errorf("cart not found")
+	}
+	return r.copyCart(id), nil
+}
+
+func (r *memoryCartRepository) EnsureForCustomer(ctx context.Context, customerID string) (*models.Cart, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	id, ok := r.customers[customerID]
+	if !ok {
+		id = uuid.New().String()
+		r.carts[id] = &models.Cart{ID: id, Items: []models.CartItem{}, UpdatedAt: time.Now()}
+		r.customers[customerID] = id
+	}
+	return r.copyCart(id), nil
+}
+
+func (r *memoryCartRepository) CreateGuest(ctx context.Context, expiresAt time.Time) (*models.Cart, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	id := uuid.New().String()
+	r.carts[id] = &models.Cart{ID: id, Items: []models.CartItem{}, ExpiresAt: &expiresAt, UpdatedAt: time.Now()}
+	return r.copyCart(id), nil
+}
+
+func (r *memoryCartRepository) AddItem(ctx context.Context, cartID string, item models.CartItem) error {
+	if _, err := uuid.Parse(item.ProductID); err != nil {
+		return fmt.Errorf("invalid product ID: %w", err)
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	cart, ok := r.carts[cartID]
+	if !ok {
+		return fmt.Errorf("cart not found")
+	}
+	item.ModifierIDs = slices.Clone(item.ModifierIDs)
+	if i := indexCartItem(cart.Items, item.ProductID); i >= 0 {
+		cart.Items[i].Quantity += item.Quantity
+		cart.Items[i].VariantID, cart.Items[i].ModifierIDs = item.VariantID, item.ModifierIDs
+	} else {
+		cart.Items = append(cart.Items, item)
+	}
+	return nil
+}
+
+func (r *memoryCartRepository) SetQuantity(ctx context.Context, cartID string, item models.CartItem) error {
+	if _, err := uuid.Parse(item.ProductID); err != nil {
+		return fmt.Errorf("invalid product ID: %w", err)
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	cart, ok := r.carts[cartID]
+	if !ok {
+		return fmt.Errorf("cart not found")
+	}
+	i := indexCartItem(cart.Items, item.ProductID)
+	if i < 0 {
+		return fmt.Errorf("cart item not found")
+	}
+	cart.Items[i].Quantity = item.Quantity
+	return nil
+}
+
+func (r *memoryCartRepository) RemoveItem(ctx context.Context, cartID, productID string) error {
+	if _, err := uuid.Parse(productID); err != nil {
+		return fmt.Errorf("invalid product ID: %w", err)
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	cart, ok := r.carts[cartID]
+	if !ok {
+		return fmt.Errorf("cart not found")
+	}
+	i := indexCartItem(cart.Items, productID)
+	if i < 0 {
+		return fmt.Errorf("cart item not found")
+	}
+	cart.Items = slices.Delete(cart.Items, i, i+1)
+	return nil
+}
+
+func (r *memoryCartRepository) Clear(ctx context.Context, cartID string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if cart, ok := r.carts[cartID]; ok {
+		cart.Items = []models.CartItem{}
+	}
+	return nil
+}
+
+func (r *memoryCartRepository) Touch(ctx context.Context, cartID string, expiresAt time.Time) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	cart, ok := r.carts[cartID]
+	if !ok {
+		return nil
+	}
+	cart.UpdatedAt = time.Now()
+	if cart.ExpiresAt != nil {
+		cart.ExpiresAt = &expiresAt
+	}
+	return nil
+}
+
+func (r *memoryCartRepository) DeleteExpired(ctx context.Context, before time.Time) (int, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	deleted := 0
+	for id, cart := range r.carts {
+		if cart.ExpiresAt != nil && !cart.ExpiresAt.After(before) {
+			delete(r.carts, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// copyCart returns a copy callers may change without touching the stored cart
+func (r *memoryCartRepository) copyCart(id string) *models.Cart {
+	cart := *r.carts[id]
+	cart.Items = slices.Clone(cart.Items)
+	return &cart
+}
+
+func indexCartItem(items []models.CartItem, productID string) int {
+	return slices.IndexFunc(items, func(item models.CartItem) bool {
+		return item.ProductID == productID
+	})
+}
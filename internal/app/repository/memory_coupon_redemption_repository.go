@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// memoryCouponRedemptionRepository is an in-process CouponRedemptionRepository for local
+// development and tests that run without Postgres
+type memoryCouponRedemptionRepository struct {
+	mutex       sync.Mutex
+	redemptions map[[2]string]bool // code and redeemer
+}
+
+func NewMemoryCouponRedemptionRepository() CouponRedemptionRepository {
+	return &memoryCouponRedemptionRepository{redemptions: make(map[[2]string]bool)}
+}
+
+func (r *memoryCouponRedemptionRepository) IsRedeemed(ctx context.Context, code, redeemer string) (bool, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.redemptions[[2]string{code, redeemer}], nil
+}
+
+func (r *memoryCouponRedemptionRepository) Redeem(ctx context.Context, code, redeemer string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	key := [2]string{code, redeemer}
+	if r.redemptions[key] {
+		return fmt.Errorf("coupon already redeemed")
+	}
+	r.redemptions[key] = true
+	return nil
+}
+
+func (r *memoryCouponRedemptionRepository) Release(ctx context.Context, code, redeemer string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	key := [2]string{code, redeemer}
+	if !r.redemptions[key] {
+		return fmt.Errorf("coupon redemption not found")
+	}
+	delete(r.redemptions, key)
+	return nil
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"oolio/internal/app/models"
+)
+
+// memoryDeliveryRepository is an in-process DeliveryRepository for local development and
+// tests that run without Postgres
+type memoryDeliveryRepository struct {
+	mutex      sync.Mutex
+	deliveries map[string]models.Delivery // By order ID
+}
+
+func NewMemoryDeliveryRepository() DeliveryRepository {
+	return &memoryDeliveryRepository{deliveries: make(map[string]models.Delivery)}
+}
+
+func (r *memoryDeliveryRepository) Create(ctx context.Context, delivery *models.Delivery) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, ok := r.deliveries[delivery.OrderID]; ok {
+		return fmt.Errorf("delivery already exists")
+	}
+	now := time.Now()
+	delivery.ID = uuid.New().String()
+	delivery.CreatedAt = now
+	delivery.UpdatedAt = now
+	r.deliveries[delivery.OrderID] = *delivery
+	return nil
+}
+
+func (r *memoryDeliveryRepository) FindByOrder(ctx context.Context, orderID string) (*models.Delivery, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	delivery, ok := r.deliveries[orderID]
+	if !ok {
+		return nil, fmt.Errorf("delivery not found")
+	}
+	return &delivery, nil
+}
+
+func (r *memoryDeliveryRepository) Update(ctx context.Context, delivery *models.Delivery) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	stored, ok := r.deliveries[delivery.OrderID]
+	if !ok || stored.ID != delivery.ID {
+		return fmt.Errorf("delivery not found")
+	}
+	delivery.Provider = stored.Provider
+	delivery.CreatedAt = stored.CreatedAt
+	delivery.UpdatedAt = time.Now()
+	r.deliveries[delivery.OrderID] = *delivery
+	return nil
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// memoryFavoriteRepository is an in-process FavoriteRepository for local development
+// and tests that run without Postgres
+type memoryFavoriteRepository struct {
+	mutex     sync.RWMutex
+	favorites map[string][]string // Product IDs by customer, oldest first
+}
+
+func NewMemoryFavoriteRepository() FavoriteRepository {
+	return &memoryFavoriteRepository{
+		favorites: make(map[string][]string),
+	}
+}
+
+func (r *memoryFavoriteRepository) FindByCustomer(ctx context.Context, customerID string) ([]string, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	ids := slices.Clone(r.favorites[customerID])
+	slices.Reverse(ids)
+	return ids, nil
+}
+
+func (r *memoryFavoriteRepository) Add(ctx context.Context, customerID, productID string) error {
+	if _, err := uuid.Parse(productID); err != nil {
+		return fmt.Errorf("invalid product ID: %w", err)
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if !slices.Contains(r.favorites[customerID], productID) {
+		r.favorites[customerID] = append(r.favorites[customerID], productID)
+	}
+	return nil
+}
+
+func (r *memoryFavoriteRepository) Remove(ctx context.Context, customerID, productID string) error {
+	if _, err := uuid.Parse(productID); err != nil {
+		return fmt.Errorf("invalid product ID: %w", err)
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	i := slices.Index(r.favorites[customerID], productID)
+	if i < 0 {
+		return fmt.Errorf("favorite not found")
+	}
+	r.favorites[customerID] = slices.Delete(r.favorites[customerID], i, i+1)
+	return nil
+}
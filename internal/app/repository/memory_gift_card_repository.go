@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"oolio/internal/app/models"
+)
+
+// memoryGiftCardRepository is an in-process GiftCardRepository for local development and
+// tests that run without Postgres
+type memoryGiftCardRepository struct {
+	mutex sync.Mutex
+	cards map[string]models.GiftCard
+}
+
+func NewMemoryGiftCardRepository() GiftCardRepository {
+	return &memoryGiftCardRepository{cards: make(map[string]models.GiftCard)}
+}
+
+func (r *memoryGiftCardRepository) Create(ctx context.Context, card *models.GiftCard) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, ok := r.cards[card.Code]; ok {
+		return fmt.Errorf("gift card already exists")
+	}
+	card.Balance = card.InitialBalance
+	card.CreatedAt = time.Now()
+	card.UpdatedAt = card.CreatedAt
+	r.cards[card.Code] = *card
+	return nil
+}
+
+func (r *memoryGiftCardRepository) FindOne(ctx context.Context, code string) (*models.GiftCard, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	card, ok := r.cards[code]
+	if !ok {
+		return nil, fmt.Errorf("gift card not found")
+	}
+	return &card, nil
+}
+
+func (r *memoryGiftCardRepository) Debit(ctx context.Context, code string, amount models.Money) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	card, ok := r.cards[code]
+	if !ok || card.Expired(time.Now()) || card.Balance < amount {
+		return fmt.Errorf("insufficient gift card balance")
+	}
+	card.Balance -= amount
+	card.UpdatedAt = time.Now()
+	r.cards[code] = card
+	return nil
+}
+
+func (r *memoryGiftCardRepository) Credit(ctx context.Context, code string, amount models.Money) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	card, ok := r.cards[code]
+	if !ok {
+		return fmt.Errorf("gift card not found")
+	}
+	card.Balance += amount
+	card.UpdatedAt = time.Now()
+	r.cards[code] = card
+	return nil
+}
@@ -0,0 +1,186 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"oolio/internal/app/models"
+)
+
+// memoryInventoryRepository is an in-process InventoryRepository for local development and
+// tests that run without Postgres. It reads product details for the low-stock listing from
+// products.
+type memoryInventoryRepository struct {
+	mutex        sync.Mutex
+	stock        map[string]models.StockLevel
+	reservations map[string]memoryReservation
+	products     ProductRepository
+}
+
+// memoryReservation holds the quantities a reservation set aside of counted products
+type memoryReservation struct {
+	quantities map[string]int
+	expiresAt  time.Time
+}
+
+func NewMemoryInventoryRepository(products ProductRepository) InventoryRepository {
+	return &memoryInventoryRepository{
+		stock:        make(map[string]models.StockLevel),
+		reservations: make(map[string]memoryReservation),
+		products:     products,
+	}
+}
+
+func (r *memoryInventoryRepository) Reserve(ctx context.Context, reservationID string, quantities map[string]int, expiresAt time.Time) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if err := r.available(quantities, ""); err != nil {
+		return err
+	}
+	reserved := make(map[string]int, len(quantities))
+	for productID, quantity := range quantities {
+		if _, ok := r.stock[productID]; ok {
+			reserved[productID] = quantity
+		}
+	}
+	r.reservations[reservationID] = memoryReservation{quantities: reserved, expiresAt: expiresAt}
+	return nil
+}
+
+func (r *memoryInventoryRepository) Release(ctx context.Context, reservationID string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	delete(r.reservations, reservationID)
+	return nil
+}
+
+func (r *memoryInventoryRepository) Take(ctx context.Context, reservationID string, quantities map[string]int) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if err := r.available(quantities, reservationID); err != nil {
+		return err
+	}
+	delete(r.reservations, reservationID)
+	r.add(quantities, -1)
+	return nil
+}
+
+// available makes sure each counted product has its quantity left besides what unexpired
+// reservations other than except set aside
+func (r *memoryInventoryRepository) available(quantities map[string]int, except string) error {
+	now := time.Now()
+	for productID, quantity := range quantities {
+		level, ok := r.stock[productID]
+		if !ok {
+			continue
+		}
+		left := level.Quantity
+		for id, reservation := range r.reservations {
+			if id != except && reservation.expiresAt.After(now) {
+				left -= reservation.quantities[productID]
+			}
+		}
+		if left < quantity {
+			return &OutOfStockError{ProductID: productID, Available: max(left, 0)}
+		}
+	}
+	return nil
+}
+
+func (r *memoryInventoryRepository) Return(ctx context.Context, quantities map[string]int) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.add(quantities, 1)
+	return nil
+}
+
+// add adds sign times the quantities to the stock of the products whose stock is counted
+func (r *memoryInventoryRepository) add(quantities map[string]int, sign int) {
+	for productID, quantity := range quantities {
+		if level, ok := r.stock[productID]; ok {
+			level.Quantity += sign * quantity
+			level.UpdatedAt = time.Now()
+			r.stock[productID] = level
+		}
+	}
+}
+
+func (r *memoryInventoryRepository) Restock(ctx context.Context, productID string, quantity int) (*models.StockLevel, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	level := r.stock[productID]
+	level.ProductID = productID
+	level.Quantity += quantity
+	level.UpdatedAt = time.Now()
+	r.stock[productID] = level
+	return &level, nil
+}
+
+func (r *memoryInventoryRepository) LowStock(ctx context.Context, threshold int, storeID string) ([]models.StockLevel, error) {
+	r.mutex.Lock()
+	low := make(map[string]models.StockLevel)
+	for productID, level := range r.stock {
+		if level.Quantity <= threshold {
+			low[productID] = level
+		}
+	}
+	r.mutex.Unlock()
+	if len(low) == 0 {
+		return []models.StockLevel{}, nil
+	}
+
+	// Deleted products aren't found, so they are left out
+	ids := make([]string, 0, len(low))
+	for productID := range low {
+		ids = append(ids, productID)
+	}
+	products, err := r.products.FindByIDs(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get low stock: %w", err)
+	}
+
+	levels := make([]models.StockLevel, 0, len(products))
+	for _, product := range products {
+		if storeID != "" && models.StoreOrDefault(product.StoreID) != storeID {
+			continue
+		}
+		level := low[product.ID]
+		level.Name = product.Name
+		level.Category = product.Category
+		level.StoreID = models.StoreOrDefault(product.StoreID)
+		levels = append(levels, level)
+	}
+	slices.SortFunc(levels, func(a, b models.StockLevel) int {
+		if a.Quantity != b.Quantity {
+			return a.Quantity - b.Quantity
+		}
+		if c := strings.Compare(a.Name, b.Name); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ProductID, b.ProductID)
+	})
+	return levels, nil
+}
+
+func (r *memoryInventoryRepository) DeleteExpiredReservations(ctx context.Context, before time.Time) (int, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	deleted := 0
+	for id, reservation := range r.reservations {
+		if !reservation.expiresAt.After(before) {
+			deleted += len(reservation.quantities)
+			delete(r.reservations, id)
+		}
+	}
+	return deleted, nil
+}
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"oolio/internal/app/models"
+)
+
+// memoryInvoiceRepository is an in-process InvoiceRepository for local development and
+// tests that run without Postgres
+type memoryInvoiceRepository struct {
+	mutex    sync.Mutex
+	numbers  map[string]int64 // Last number taken per store and series
+	invoices map[string]models.Invoice
+}
+
+func NewMemoryInvoiceRepository() InvoiceRepository {
+	return &memoryInvoiceRepository{
+		numbers:  make(map[string]int64),
+		invoices: make(map[string]models.Invoice),
+	}
+}
+
+func (r *memoryInvoiceRepository) Issue(ctx context.Context, series string, invoice *models.Invoice) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, ok := r.invoices[invoice.OrderID]; ok {
+		return fmt.Errorf("invoice already exists")
+	}
+	invoice.StoreID = models.StoreOrDefault(invoice.StoreID)
+	key := invoice.StoreID + "/" + series
+	r.numbers[key]++
+	invoice.Number = models.InvoiceNumber(series, r.numbers[key])
+	invoice.IssuedAt = time.Now()
+	r.invoices[invoice.OrderID] = *invoice
+	return nil
+}
+
+func (r *memoryInvoiceRepository) FindByOrder(ctx context.Context, orderID string) (*models.Invoice, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	invoice, ok := r.invoices[orderID]
+	if !ok {
+		return nil, fmt.Errorf("invoice not found")
+	}
+	return &invoice, nil
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"oolio/internal/app/models"
+)
+
+// memoryNotificationFeedRepository is an in-process NotificationFeedRepository for local
+// development and tests that run without Postgres
+type memoryNotificationFeedRepository struct {
+	mutex sync.RWMutex
+	feeds map[string][]models.FeedNotification // By customer, oldest first
+}
+
+func NewMemoryNotificationFeedRepository() NotificationFeedRepository {
+	return &memoryNotificationFeedRepository{feeds: make(map[string][]models.FeedNotification)}
+}
+
+func (r *memoryNotificationFeedRepository) Add(ctx context.Context, customerID string, notification *models.FeedNotification) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	notification.ID = uuid.New().String()
+	notification.CreatedAt = time.Now()
+	notification.ReadAt = nil
+	r.feeds[customerID] = append(r.feeds[customerID], *notification)
+	return nil
+}
+
+func (r *memoryNotificationFeedRepository) FindPage(ctx context.Context, customerID string, unreadOnly bool, page models.PageRequest) ([]models.FeedNotification, int, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	feed := r.feeds[customerID]
+	notifications := make([]models.FeedNotification, 0, len(feed))
+	for i := len(feed) - 1; i >= 0; i-- {
+		if !unreadOnly || feed[i].ReadAt == nil {
+			notifications = append(notifications, feed[i])
+		}
+	}
+	start, end := page.Window(len(notifications))
+	return notifications[start:end], len(notifications), nil
+}
+
+func (r *memoryNotificationFeedRepository) CountUnread(ctx context.Context, customerID string) (int, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	unread := 0
+	for _, notification := range r.feeds[customerID] {
+		if notification.ReadAt == nil {
+			unread++
+		}
+	}
+	return unread, nil
+}
+
+func (r *memoryNotificationFeedRepository) MarkRead(ctx context.Context, customerID, id string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	feed := r.feeds[customerID]
+	for i := range feed {
+		if feed[i].ID == id {
+			if feed[i].ReadAt == nil {
+				now := time.Now()
+				feed[i].ReadAt = &now
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("feed notification not found")
+}
+
+func (r *memoryNotificationFeedRepository) MarkAllRead(ctx context.Context, customerID string) (int, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	now := time.Now()
+	marked := 0
+	feed := r.feeds[customerID]
+	for i := range feed {
+		if feed[i].ReadAt == nil {
+			feed[i].ReadAt = &now
+			marked++
+		}
+	}
+	return marked, nil
+}
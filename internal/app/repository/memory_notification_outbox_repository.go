@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"oolio/internal/app/models"
+)
+
+// memoryNotificationOutboxRepository is an in-process NotificationOutboxRepository for
+// local development and tests that run without Postgres
+type memoryNotificationOutboxRepository struct {
+	mutex    sync.Mutex
+	messages map[string]models.NotificationMessage
+}
+
+func NewMemoryNotificationOutboxRepository() NotificationOutboxRepository {
+	return &memoryNotificationOutboxRepository{
+		messages: make(map[string]models.NotificationMessage),
+	}
+}
+
+func (r *memoryNotificationOutboxRepository) Enqueue(ctx context.Context, message *models.NotificationMessage) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	now := time.Now()
+	message.ID = uuid.New().String()
+	message.Status = models.NotificationPending
+	message.Attempts = 0
+	message.NextAttemptAt = now
+	message.CreatedAt = now
+	r.messages[message.ID] = *message
+	return nil
+}
+
+func (r *memoryNotificationOutboxRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]*models.NotificationMessage, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	now := time.Now()
+	var due []*models.NotificationMessage
+	for _, message := range r.messages {
+		if message.Status == models.NotificationPending && !message.NextAttemptAt.After(now) {
+			due = append(due, &message)
+		}
+	}
+	sortNotificationMessages(due)
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	for _, message := range due {
+		message.NextAttemptAt = now.Add(lease)
+		r.messages[message.ID] = *message
+	}
+	return due, nil
+}
+
+func (r *memoryNotificationOutboxRepository) MarkSent(ctx context.Context, id string) error {
+	return r.update(id, func(message *models.NotificationMessage) {
+		now := time.Now()
+		message.Status = models.NotificationSent
+		message.Attempts++
+		message.LastError = ""
+		message.SentAt = &now
+	})
+}
+
+func (r *memoryNotificationOutboxRepository) MarkFailed(ctx context.Context, id, errorMsg string, retryAt time.Time) error {
+	return r.update(id, func(message *models.NotificationMessage) {
+		message.Attempts++
+		message.LastError = errorMsg
+		message.NextAttemptAt = retryAt
+	})
+}
+
+func (r *memoryNotificationOutboxRepository) MarkDead(ctx context.Context, id, errorMsg string) error {
+	return r.update(id, func(message *models.NotificationMessage) {
+		message.Status = models.NotificationDead
+		message.Attempts++
+		message.LastError = errorMsg
+	})
+}
+
+func (r *memoryNotificationOutboxRepository) Redeliver(ctx context.Context, id string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	message, ok := r.messages[id]
+	if !ok || message.Status != models.NotificationDead {
+		return fmt.Errorf("notification not found")
+	}
+	message.Status = models.NotificationPending
+	message.Attempts = 0
+	message.NextAttemptAt = time.Now()
+	r.messages[id] = message
+	return nil
+}
+
+func (r *memoryNotificationOutboxRepository) GetStats(ctx context.Context) (*models.NotificationDeliveryStats, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	stats := &models.NotificationDeliveryStats{}
+	for _, message := range r.messages {
+		switch message.Status {
+		case models.NotificationPending:
+			stats.Pending++
+			if message.Attempts > 0 {
+				stats.Retrying++
+			}
+			if stats.OldestPendingAt == nil || message.CreatedAt.Before(*stats.OldestPendingAt) {
+				createdAt := message.CreatedAt
+				stats.OldestPendingAt = &createdAt
+			}
+		case models.NotificationSent:
+			stats.Sent++
+		case models.NotificationDead:
+			stats.Dead++
+		}
+	}
+	return stats, nil
+}
+
+func (r *memoryNotificationOutboxRepository) update(id string, fn func(message *models.NotificationMessage)) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	message, ok := r.messages[id]
+	if !ok {
+		return fmt.Errorf("notification not found")
+	}
+	fn(&message)
+	r.messages[id] = message
+	return nil
+}
@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"oolio/internal/app/models"
+)
+
+// memoryNotificationRepository is an in-process NotificationRepository for local
+// development and tests that run without Postgres
+type memoryNotificationRepository struct {
+	mutex       sync.RWMutex
+	preferences map[string]models.NotificationPreferences
+}
+
+func NewMemoryNotificationRepository() NotificationRepository {
+	return &memoryNotificationRepository{preferences: make(map[string]models.NotificationPreferences)}
+}
+
+func (r *memoryNotificationRepository) FindByCustomer(ctx context.Context, customerID string) (*models.NotificationPreferences, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	preferences, ok := r.preferences[customerID]
+	if !ok {
+		return nil, fmt.Errorf("notification preferences not found")
+	}
+	return &preferences, nil
+}
+
+func (r *memoryNotificationRepository) Save(ctx context.Context, customerID string, req models.NotificationPreferencesReq) (*models.NotificationPreferences, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	now := time.Now()
+	preferences := models.NotificationPreferences{NotificationPreferencesReq: req, UpdatedAt: &now}
+	r.preferences[customerID] = preferences
+	return &preferences, nil
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"oolio/internal/app/models"
+)
+
+// memoryOrderDiscrepancyRepository is an in-process OrderDiscrepancyRepository for local
+// development and tests that run without Postgres. It keeps the store, coupon and order
+// time the discrepancies were recorded with, where Postgres reads them from the order.
+type memoryOrderDiscrepancyRepository struct {
+	mutex         sync.Mutex
+	discrepancies map[string]models.OrderDiscrepancy // by order ID and kind
+}
+
+func NewMemoryOrderDiscrepancyRepository() OrderDiscrepancyRepository {
+	return &memoryOrderDiscrepancyRepository{discrepancies: make(map[string]models.OrderDiscrepancy)}
+}
+
+func (r *memoryOrderDiscrepancyRepository) Record(ctx context.Context, checked []string, found []models.OrderDiscrepancy) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	now := time.Now()
+	kept := make(map[string]models.OrderDiscrepancy, len(found))
+	for _, discrepancy := range found {
+		key := discrepancy.OrderID + "/" + discrepancy.Kind
+		discrepancy.Difference = discrepancy.Recorded - discrepancy.Expected
+		discrepancy.DetectedAt = now
+		if existing, ok := r.discrepancies[key]; ok {
+			discrepancy.DetectedAt = existing.DetectedAt
+		}
+		discrepancy.CheckedAt = now
+		kept[key] = discrepancy
+	}
+
+	isChecked := make(map[string]bool, len(checked))
+	for _, id := range checked {
+		isChecked[id] = true
+	}
+	for key, discrepancy := range r.discrepancies {
+		if isChecked[discrepancy.OrderID] {
+			delete(r.discrepancies, key)
+		}
+	}
+	for key, discrepancy := range kept {
+		r.discrepancies[key] = discrepancy
+	}
+	return nil
+}
+
+func (r *memoryOrderDiscrepancyRepository) Find(ctx context.Context, storeID string) ([]models.OrderDiscrepancy, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	discrepancies := make([]models.OrderDiscrepancy, 0, len(r.discrepancies))
+	for _, discrepancy := range r.discrepancies {
+		if storeID == "" || discrepancy.StoreID == storeID {
+			discrepancies = append(discrepancies, discrepancy)
+		}
+	}
+	sort.Slice(discrepancies, func(i, j int) bool {
+		a, b := discrepancies[i], discrepancies[j]
+		if !a.DetectedAt.Equal(b.DetectedAt) {
+			return a.DetectedAt.After(b.DetectedAt)
+		}
+		if a.OrderID != b.OrderID {
+			return a.OrderID < b.OrderID
+		}
+		return a.Kind < b.Kind
+	})
+	return discrepancies, nil
+}
@@ -0,0 +1,307 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"oolio/internal/app/models"
+)
+
+// memoryOrderQueueRepository is an in-process OrderQueueRepository for local development
+// and tests that run without Postgres. Items are stored by value so callers can't mutate
+// the queue without going through the repository.
+type memoryOrderQueueRepository struct {
+	mutex    sync.RWMutex
+	items    map[string]models.OrderQueueItem
+	failures map[string][]models.QueueFailure // By item, oldest first
+}
+
+func NewMemoryOrderQueueRepository() OrderQueueRepository {
+	return &memoryOrderQueueRepository{
+		items:    make(map[string]models.OrderQueueItem),
+		failures: make(map[string][]models.QueueFailure),
+	}
+}
+
+func (r *memoryOrderQueueRepository) AddToQueue(ctx context.Context, item *models.OrderQueueItem) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.items[item.ID] = *item
+	return nil
+}
+
+func (r *memoryOrderQueueRepository) GetPendingItems(ctx context.Context, batchSize int) ([]*models.OrderQueueItem, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	now := time.Now()
+	var items []*models.OrderQueueItem
+	for _, item := range r.sortedItems(false) {
+		if item.NextAttemptAt.After(now) {
+			continue
+		}
+		if item.Status == "pending" || (item.Status == "failed" && item.RetryCount < 3) {
+			items = append(items, item)
+			if len(items) == batchSize {
+				break
+			}
+		}
+	}
+	return items, nil
+}
+
+func (r *memoryOrderQueueRepository) UpdateItem(ctx context.Context, item *models.OrderQueueItem) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, ok := r.items[item.ID]; !ok {
+		return fmt.Errorf("order not found")
+	}
+	r.items[item.ID] = *item
+	if item.Status == "failed" {
+		r.recordFailure(item.ID, item.Error, item.ErrorCode)
+	}
+	return nil
+}
+
+// recordFailure keeps the failure for GetFailures, as the order.failed events do in Postgres
+func (r *memoryOrderQueueRepository) recordFailure(itemID, errorMsg, errorCode string) {
+	r.failures[itemID] = append(r.failures[itemID], models.QueueFailure{
+		Attempt:   len(r.failures[itemID]) + 1,
+		Error:     errorMsg,
+		ErrorCode: errorCode,
+		At:        time.Now(),
+	})
+}
+
+func (r *memoryOrderQueueRepository) MarkAsProcessing(ctx context.Context, itemID string) error {
+	return r.update(itemID, func(item *models.OrderQueueItem) {
+		item.Status = "processing"
+	})
+}
+
+func (r *memoryOrderQueueRepository) Claim(ctx context.Context, itemID, instance string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	item, ok := r.items[itemID]
+	if !ok {
+		return fmt.Errorf("order not found")
+	}
+	now := time.Now()
+	item.ClaimedBy = instance
+	item.ClaimedAt = &now
+	r.items[itemID] = item
+	return nil
+}
+
+func (r *memoryOrderQueueRepository) MarkAsCompleted(ctx context.Context, itemID string, order *models.Order) error {
+	return r.update(itemID, func(item *models.OrderQueueItem) {
+		item.Status = "completed"
+		item.Order = order
+		item.Error = ""
+		item.ErrorCode = ""
+	})
+}
+
+func (r *memoryOrderQueueRepository) MarkAsFailed(ctx context.Context, itemID string, errorMsg string) error {
+	return r.update(itemID, func(item *models.OrderQueueItem) {
+		item.Status = "failed"
+		item.Error = errorMsg
+		item.ErrorCode = models.QueueErrorOrderFailed
+		item.RetryCount++
+		r.recordFailure(itemID, errorMsg, item.ErrorCode)
+	})
+}
+
+func (r *memoryOrderQueueRepository) GetFailures(ctx context.Context, itemID string) ([]models.QueueFailure, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return slices.Clone(r.failures[itemID]), nil
+}
+
+func (r *memoryOrderQueueRepository) Requeue(ctx context.Context, itemID string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	item, ok := r.items[itemID]
+	if !ok || item.Status != "failed" {
+		return fmt.Errorf("failed order %s not found", itemID)
+	}
+	item.Status = "pending"
+	item.Error = ""
+	item.ErrorCode = ""
+	item.RetryCount = 0
+	item.UpdatedAt = time.Now()
+	item.NextAttemptAt = item.UpdatedAt
+	r.items[itemID] = item
+	return nil
+}
+
+func (r *memoryOrderQueueRepository) SetPaymentIntent(ctx context.Context, itemID, intentID string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	item, ok := r.items[itemID]
+	if !ok || !(item.Status == "pending" || (item.Status == "failed" && item.RetryCount < 3)) || item.Order != nil {
+		return fmt.Errorf("order awaiting payment not found")
+	}
+	item.OrderReq.PaymentIntentID = intentID
+	item.UpdatedAt = time.Now()
+	item.NextAttemptAt = item.UpdatedAt
+	if scheduled := item.OrderReq.ScheduledFor; scheduled != nil && scheduled.After(item.NextAttemptAt) {
+		item.NextAttemptAt = *scheduled
+	}
+	r.items[itemID] = item
+	return nil
+}
+
+func (r *memoryOrderQueueRepository) GetQueueStats(ctx context.Context) (map[string]int, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	stats := make(map[string]int)
+	for _, item := range r.items {
+		if item.OrderReq.Sandbox {
+			continue
+		}
+		stats[item.Status]++
+	}
+	return stats, nil
+}
+
+func (r *memoryOrderQueueRepository) GetBacklog(ctx context.Context) (*models.QueueBacklog, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	backlog := &models.QueueBacklog{}
+	for _, item := range r.items {
+		if item.Status != "pending" || item.OrderReq.Sandbox {
+			continue
+		}
+		backlog.Pending++
+		if backlog.OldestDueAt == nil || item.NextAttemptAt.Before(*backlog.OldestDueAt) {
+			dueAt := item.NextAttemptAt
+			backlog.OldestDueAt = &dueAt
+		}
+	}
+	return backlog, nil
+}
+
+func (r *memoryOrderQueueRepository) GetOrderFromQueue(ctx context.Context, itemID string) (*models.OrderQueueItem, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	item, ok := r.items[itemID]
+	if !ok {
+		return nil, fmt.Errorf("order not found")
+	}
+	return &item, nil
+}
+
+func (r *memoryOrderQueueRepository) GetAllOrders(ctx context.Context) ([]*models.OrderQueueItem, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.sortedItems(true), nil
+}
+
+func (r *memoryOrderQueueRepository) FindPage(ctx context.Context, page models.PageRequest) ([]*models.OrderQueueItem, int, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	items := r.sortedItems(true)
+	start, end := page.Window(len(items))
+	return items[start:end], len(items), nil
+}
+
+func (r *memoryOrderQueueRepository) FindScheduled(ctx context.Context, storeID string, from, until time.Time) ([]*models.OrderQueueItem, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var items []*models.OrderQueueItem
+	for _, item := range r.sortedItems(false) {
+		scheduled := item.OrderReq.ScheduledFor
+		if scheduled == nil || item.Status == "failed" || scheduled.Before(from) || !scheduled.Before(until) {
+			continue
+		}
+		if storeID != "" && models.StoreOrDefault(item.OrderReq.StoreID) != storeID {
+			continue
+		}
+		items = append(items, item)
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].OrderReq.ScheduledFor.Before(*items[j].OrderReq.ScheduledFor)
+	})
+	return items, nil
+}
+
+func (r *memoryOrderQueueRepository) FindByTab(ctx context.Context, tabID string) ([]*models.OrderQueueItem, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var items []*models.OrderQueueItem
+	for _, item := range r.sortedItems(false) {
+		if item.OrderReq.TabID == tabID {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+func (r *memoryOrderQueueRepository) Anonymize(ctx context.Context, id string) (int, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	anonymized := 0
+	for itemID, item := range r.items {
+		if itemID != id && (item.Order == nil || item.Order.ID != id) {
+			continue
+		}
+		item.OrderReq = models.OrderReq{}
+		item.Order = nil
+		item.Error = ""
+		item.ErrorCode = ""
+		item.UpdatedAt = time.Now()
+		r.items[itemID] = item
+		anonymized++
+	}
+	return anonymized, nil
+}
+
+func (r *memoryOrderQueueRepository) update(itemID string, fn func(item *models.OrderQueueItem)) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	item, ok := r.items[itemID]
+	if !ok {
+		return fmt.Errorf("order not found")
+	}
+	fn(&item)
+	item.UpdatedAt = time.Now()
+	r.items[itemID] = item
+	return nil
+}
+
+// sortedItems returns copies of all items ordered by creation time. Callers must hold the lock.
+func (r *memoryOrderQueueRepository) sortedItems(newestFirst bool) []*models.OrderQueueItem {
+	items := make([]*models.OrderQueueItem, 0, len(r.items))
+	for _, item := range r.items {
+		item := item
+		items = append(items, &item)
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		if newestFirst {
+			return items[i].CreatedAt.After(items[j].CreatedAt)
+		}
+		return items[i].CreatedAt.Before(items[j].CreatedAt)
+	})
+	return items
+}
@@ -0,0 +1,408 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"oolio/internal/app/models"
+
+	"github.com/google/uuid"
+)
+
+// memoryOrderRepository is an in-process OrderRepository for local development
+// and tests that run without Postgres
+type memoryOrderRepository struct {
+	mutex   sync.RWMutex
+	orders  map[string]models.Order
+	ids     []string // Creation order, so listings are stable
+	refunds map[string][]models.OrderRefund
+}
+
+func NewMemoryOrderRepository() OrderRepository {
+	return &memoryOrderRepository{
+		orders:  make(map[string]models.Order),
+		refunds: make(map[string][]models.OrderRefund),
+	}
+}
+
+func (r *memoryOrderRepository) Find(ctx context.Context) ([]models.Order, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.newestFirst(), nil
+}
+
+func (r *memoryOrderRepository) FindPage(ctx context.Context, page models.PageRequest) ([]models.Order, int, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	orders := r.newestFirst()
+	if page.StoreID != "" {
+		orders = slices.DeleteFunc(orders, func(order models.Order) bool { return order.StoreID != page.StoreID })
+	}
+	start, end := page.Window(len(orders))
+	return orders[start:end], len(orders), nil
+}
+
+func (r *memoryOrderRepository) FindUpdatedSince(ctx context.Context, since time.Time) ([]models.Order, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var orders []models.Order
+	for _, order := range r.orders {
+		if !order.UpdatedAt.Before(since) {
+			orders = append(orders, order)
+		}
+	}
+
+	sort.Slice(orders, func(i, j int) bool {
+		if orders[i].UpdatedAt.Equal(orders[j].UpdatedAt) {
+			return orders[i].ID < orders[j].ID
+		}
+		return orders[i].UpdatedAt.Before(orders[j].UpdatedAt)
+	})
+
+	return orders, nil
+}
+
+func (r *memoryOrderRepository) FindOne(ctx context.Context, id string) (*models.Order, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, fmt.Errorf("invalid order ID: %w", err)
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	order, ok := r.orders[id]
+	if !ok {
+		return nil, fmt.Errorf("order not found")
+	}
+	return &order, nil
+}
+
+func (r *memoryOrderRepository) Create(ctx context.Context, order *models.Order) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	order.ID = uuid.New().String()
+	order.StoreID = models.StoreOrDefault(order.StoreID)
+	order.Version = 1
+	order.CreatedAt = time.Now()
+	order.UpdatedAt = order.CreatedAt
+	order.Status = models.OrderStatusPending
+	r.orders[order.ID] = *order
+	r.ids = append(r.ids, order.ID)
+	return nil
+}
+
+func (r *memoryOrderRepository) Update(ctx context.Context, order *models.Order) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	stored, ok := r.orders[order.ID]
+	if !ok {
+		return fmt.Errorf("order not found")
+	}
+	if stored.Version != order.Version {
+		return &ConflictError{Entity: "order", ID: order.ID, Version: order.Version}
+	}
+	stored.Version++
+	stored.UpdatedAt = time.Now()
+	stored.Status = models.OrderStatusCompleted
+	order.Version = stored.Version
+	order.UpdatedAt = stored.UpdatedAt
+	order.Status = stored.Status
+	r.orders[order.ID] = stored
+	return nil
+}
+
+func (r *memoryOrderRepository) Delete(ctx context.Context, id string) error {
+	if _, err := uuid.Parse(id); err != nil {
+		return fmt.Errorf("invalid order ID: %w", err)
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, ok := r.orders[id]; !ok {
+		return fmt.Errorf("order not found")
+	}
+	delete(r.orders, id)
+	delete(r.refunds, id)
+	r.ids = slices.DeleteFunc(r.ids, func(orderID string) bool { return orderID == id })
+	return nil
+}
+
+func (r *memoryOrderRepository) SetPaymentStatus(ctx context.Context, orderID string, status string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	order, ok := r.orders[orderID]
+	if !ok {
+		return fmt.Errorf("order not found")
+	}
+	order.PaymentStatus = status
+	r.orders[orderID] = order
+	return nil
+}
+
+func (r *memoryOrderRepository) FindByPaymentIntent(ctx context.Context, intentID string) (*models.Order, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, order := range r.orders {
+		if intentID != "" && order.PaymentIntentID == intentID {
+			return &order, nil
+		}
+	}
+	return nil, fmt.Errorf("order not found")
+}
+
+func (r *memoryOrderRepository) CreateRefund(ctx context.Context, refund *models.OrderRefund) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, ok := r.orders[refund.OrderID]; !ok {
+		return fmt.Errorf("order not found")
+	}
+	refund.ID = uuid.New().String()
+	refund.Status = models.RefundStatusRequested
+	refund.CreatedAt = time.Now()
+	refund.UpdatedAt = refund.CreatedAt
+	r.refunds[refund.OrderID] = append(r.refunds[refund.OrderID], *refund)
+	return nil
+}
+
+func (r *memoryOrderRepository) UpdateRefund(ctx context.Context, refund *models.OrderRefund) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	refunds := r.refunds[refund.OrderID]
+	for i := range refunds {
+		if refunds[i].ID == refund.ID {
+			refunds[i].Status = refund.Status
+			refunds[i].ProviderRefundID = refund.ProviderRefundID
+			refunds[i].Error = refund.Error
+			refunds[i].UpdatedAt = time.Now()
+			*refund = refunds[i]
+			return nil
+		}
+	}
+	return fmt.Errorf("refund not found")
+}
+
+func (r *memoryOrderRepository) GetRefunds(ctx context.Context, orderID string) ([]models.OrderRefund, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return slices.Clone(r.refunds[orderID]), nil
+}
+
+// placedBetween returns the orders placed from since until before until, leaving out
+// cancelled and failed orders, and sandbox orders
+func (r *memoryOrderRepository) placedBetween(since, until time.Time) []models.Order {
+	var orders []models.Order
+	for _, order := range r.orders {
+		if order.CreatedAt.Before(since) || !order.CreatedAt.Before(until) || order.Status == models.OrderStatusCancelled || order.Status == models.OrderStatusFailed || order.Sandbox {
+			continue
+		}
+		orders = append(orders, order)
+	}
+	return orders
+}
+
+func (r *memoryOrderRepository) PaymentMethodTotals(ctx context.Context, since, until time.Time) ([]models.PaymentMethodTotal, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	byMethod := make(map[string]*models.PaymentMethodTotal)
+	for _, order := range r.placedBetween(since, until) {
+		method := order.PaymentMethod
+		if method == "" {
+			method = models.PaymentMethodCard
+		}
+		total, ok := byMethod[method]
+		if !ok {
+			total = &models.PaymentMethodTotal{Method: method}
+			byMethod[method] = total
+		}
+		total.Orders++
+		total.Paid += order.AmountDue()
+	}
+
+	totals := make([]models.PaymentMethodTotal, 0, len(byMethod))
+	for _, total := range byMethod {
+		totals = append(totals, *total)
+	}
+	sort.Slice(totals, func(i, j int) bool { return totals[i].Method < totals[j].Method })
+	return totals, nil
+}
+
+func (r *memoryOrderRepository) TopProducts(ctx context.Context, since, until time.Time, limit int) ([]models.ProductSales, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	byProduct := make(map[string]*models.ProductSales)
+	for _, order := range r.placedBetween(since, until) {
+		names := make(map[string]string, len(order.Products))
+		for _, product := range order.Products {
+			names[product.ID] = product.Name
+		}
+		for _, item := range order.Items {
+			sales, ok := byProduct[item.ProductID]
+			if !ok {
+				sales = &models.ProductSales{ProductID: item.ProductID, Name: names[item.ProductID]}
+				byProduct[item.ProductID] = sales
+			}
+			sales.Quantity += item.Quantity
+			sales.Revenue += item.Price.Mul(item.Quantity)
+		}
+	}
+
+	products := make([]models.ProductSales, 0, len(byProduct))
+	for _, sales := range byProduct {
+		products = append(products, *sales)
+	}
+	sort.Slice(products, func(i, j int) bool {
+		if products[i].Quantity != products[j].Quantity {
+			return products[i].Quantity > products[j].Quantity
+		}
+		return products[i].Name < products[j].Name
+	})
+	if len(products) > limit {
+		products = products[:limit]
+	}
+	return products, nil
+}
+
+func (r *memoryOrderRepository) CouponUsage(ctx context.Context, since, until time.Time) ([]models.CouponUsage, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	byCode := make(map[string]*models.CouponUsage)
+	for _, order := range r.placedBetween(since, until) {
+		if order.CouponCode == "" {
+			continue
+		}
+		usage, ok := byCode[order.CouponCode]
+		if !ok {
+			usage = &models.CouponUsage{Code: order.CouponCode}
+			byCode[order.CouponCode] = usage
+		}
+		usage.Orders++
+		usage.Discounts += order.Discounts
+	}
+
+	coupons := make([]models.CouponUsage, 0, len(byCode))
+	for _, usage := range byCode {
+		coupons = append(coupons, *usage)
+	}
+	sort.Slice(coupons, func(i, j int) bool {
+		if coupons[i].Orders != coupons[j].Orders {
+			return coupons[i].Orders > coupons[j].Orders
+		}
+		return coupons[i].Code < coupons[j].Code
+	})
+	return coupons, nil
+}
+
+func (r *memoryOrderRepository) Sales(ctx context.Context, query models.SalesQuery) ([]models.SalesGroup, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	byKey := make(map[string]*models.SalesGroup)
+	counted := make(map[string]map[string]bool)
+	add := func(key, name, orderID string, quantity int, revenue, discounts models.Money) {
+		group, ok := byKey[key]
+		if !ok {
+			group = &models.SalesGroup{Key: key, Name: name}
+			byKey[key] = group
+			counted[key] = make(map[string]bool)
+		}
+		if !counted[key][orderID] {
+			counted[key][orderID] = true
+			group.Orders++
+		}
+		group.Quantity += quantity
+		group.Revenue += revenue
+		group.Discounts += discounts
+	}
+
+	for _, order := range r.placedBetween(query.Since, query.Until) {
+		if query.StoreID != "" && models.StoreOrDefault(order.StoreID) != query.StoreID {
+			continue
+		}
+		if query.GroupBy == models.SalesByDay {
+			add(order.CreatedAt.In(query.Location).Format(time.DateOnly), "", order.ID, 0, order.Total-order.Discounts, order.Discounts)
+			continue
+		}
+
+		products := make(map[string]models.Product, len(order.Products))
+		for _, product := range order.Products {
+			products[product.ID] = product
+		}
+		for _, item := range order.Items {
+			// The item's share of the order's discounts
+			line, share := item.Price.Mul(item.Quantity), models.Money(0)
+			if order.Total != 0 {
+				share = models.Money(math.Round(float64(line) * float64(order.Discounts) / float64(order.Total)))
+			}
+			product := products[item.ProductID]
+			if query.GroupBy == models.SalesByCategory {
+				add(product.Category, "", order.ID, item.Quantity, line-share, share)
+			} else {
+				add(item.ProductID, product.Name, order.ID, item.Quantity, line-share, share)
+			}
+		}
+	}
+
+	groups := make([]models.SalesGroup, 0, len(byKey))
+	for _, group := range byKey {
+		groups = append(groups, *group)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if query.GroupBy != models.SalesByDay && groups[i].Revenue != groups[j].Revenue {
+			return groups[i].Revenue > groups[j].Revenue
+		}
+		if groups[i].Name != groups[j].Name {
+			return groups[i].Name < groups[j].Name
+		}
+		return groups[i].Key < groups[j].Key
+	})
+	return groups, nil
+}
+
+func (r *memoryOrderRepository) CreateOrderItems(ctx context.Context, orderID string, items []models.OrderItem) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	order, ok := r.orders[orderID]
+	if !ok {
+		return fmt.Errorf("order not found")
+	}
+	order.Items = append(order.Items, items...)
+	r.orders[orderID] = order
+	return nil
+}
+
+func (r *memoryOrderRepository) GetOrderItems(ctx context.Context, orderID string) ([]models.OrderItem, error) {
+	order, err := r.FindOne(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	return order.Items, nil
+}
+
+// newestFirst returns all orders, most recently created first. Callers must hold the lock.
+func (r *memoryOrderRepository) newestFirst() []models.Order {
+	orders := make([]models.Order, 0, len(r.ids))
+	for i := len(r.ids) - 1; i >= 0; i-- {
+		orders = append(orders, r.orders[r.ids[i]])
+	}
+	return orders
+}
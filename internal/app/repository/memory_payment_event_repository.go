@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// memoryPaymentEventRepository is an in-process PaymentEventRepository for local
+// development and tests that run without Postgres
+type memoryPaymentEventRepository struct {
+	mutex  sync.Mutex
+	events map[string]string // Event ID to type
+}
+
+func NewMemoryPaymentEventRepository() PaymentEventRepository {
+	return &memoryPaymentEventRepository{events: make(map[string]string)}
+}
+
+func (r *memoryPaymentEventRepository) Record(ctx context.Context, id, eventType string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, ok := r.events[id]; ok {
+		return fmt.Errorf("payment event already recorded")
+	}
+	r.events[id] = eventType
+	return nil
+}
+
+func (r *memoryPaymentEventRepository) Forget(ctx context.Context, id string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	delete(r.events, id)
+	return nil
+}
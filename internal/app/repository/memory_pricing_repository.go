@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"oolio/internal/app/models"
+)
+
+// memoryPricingRepository is an in-process PricingRepository for local development and
+// tests that run without Postgres. It starts with the tiers the migration seeds.
+type memoryPricingRepository struct {
+	mutex       sync.RWMutex
+	tiers       map[string]models.PricingTier
+	assignments map[[2]string]models.PricingAssignment // By subject type and subject
+}
+
+func NewMemoryPricingRepository() PricingRepository {
+	now := time.Now()
+	return &memoryPricingRepository{
+		tiers: map[string]models.PricingTier{
+			"staff":     {Name: "staff", UpdatedAt: &now},
+			"corporate": {Name: "corporate", UpdatedAt: &now},
+		},
+		assignments: make(map[[2]string]models.PricingAssignment),
+	}
+}
+
+func (r *memoryPricingRepository) FindTiers(ctx context.Context) ([]models.PricingTier, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	tiers := make([]models.PricingTier, 0, len(r.tiers))
+	for _, tier := range r.tiers {
+		tiers = append(tiers, tier)
+	}
+	slices.SortFunc(tiers, func(a, b models.PricingTier) int { return strings.Compare(a.Name, b.Name) })
+	return tiers, nil
+}
+
+func (r *memoryPricingRepository) FindTier(ctx context.Context, name string) (*models.PricingTier, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	tier, ok := r.tiers[name]
+	if !ok {
+		return nil, fmt.Errorf("pricing tier not found")
+	}
+	return &tier, nil
+}
+
+func (r *memoryPricingRepository) SaveTier(ctx context.Context, name string, discountPercentage float64) (*models.PricingTier, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	now := time.Now()
+	tier := models.PricingTier{Name: name, DiscountPercentage: discountPercentage, UpdatedAt: &now}
+	r.tiers[name] = tier
+	return &tier, nil
+}
+
+func (r *memoryPricingRepository) DeleteTier(ctx context.Context, name string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, ok := r.tiers[name]; !ok {
+		return fmt.Errorf("pricing tier not found")
+	}
+	delete(r.tiers, name)
+	for key, assignment := range r.assignments {
+		if assignment.Tier == name {
+			delete(r.assignments, key)
+		}
+	}
+	return nil
+}
+
+func (r *memoryPricingRepository) FindAssignments(ctx context.Context) ([]models.PricingAssignment, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	assignments := make([]models.PricingAssignment, 0, len(r.assignments))
+	for _, assignment := range r.assignments {
+		assignments = append(assignments, assignment)
+	}
+	slices.SortFunc(assignments, func(a, b models.PricingAssignment) int {
+		if c := strings.Compare(a.SubjectType, b.SubjectType); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Subject, b.Subject)
+	})
+	return assignments, nil
+}
+
+func (r *memoryPricingRepository) Assign(ctx context.Context, subjectType, subject, tier string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, ok := r.tiers[tier]; !ok {
+		return fmt.Errorf("pricing tier not found")
+	}
+	r.assignments[[2]string{subjectType, subject}] = models.PricingAssignment{
+		SubjectType: subjectType,
+		Subject:     subject,
+		Tier:        tier,
+		CreatedAt:   time.Now(),
+	}
+	return nil
+}
+
+func (r *memoryPricingRepository) Unassign(ctx context.Context, subjectType, subject string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	key := [2]string{subjectType, subject}
+	if _, ok := r.assignments[key]; !ok {
+		return fmt.Errorf("pricing assignment not found")
+	}
+	delete(r.assignments, key)
+	return nil
+}
+
+func (r *memoryPricingRepository) Resolve(ctx context.Context, customerID, apiKeyHash string) (*models.PricingTier, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, key := range [][2]string{{models.PricingSubjectCustomer, customerID}, {models.PricingSubjectAPIKey, apiKeyHash}} {
+		if assignment, ok := r.assignments[key]; ok {
+			tier := r.tiers[assignment.Tier]
+			return &tier, nil
+		}
+	}
+	return nil, fmt.Errorf("pricing tier not found")
+}
@@ -0,0 +1,307 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"oolio/internal/app/models"
+
+	"github.com/google/uuid"
+)
+
+// memoryProductRepository is an in-process ProductRepository for local development
+// and tests that run without Postgres. Deleted products stay in the map with DeletedAt set.
+type memoryProductRepository struct {
+	mutex    sync.RWMutex
+	products map[string]models.Product
+	changes  []productChange // The change feed; an entry's cursor is its position plus one
+}
+
+type productChange struct {
+	productID string
+	kind      string
+	at        time.Time
+}
+
+func NewMemoryProductRepository() ProductRepository {
+	r := &memoryProductRepository{
+		products: make(map[string]models.Product),
+	}
+
+	// Mirror migrations/004_seed_products_data so the API is usable out of the box
+	for _, seed := range []struct {
+		name  string
+		price models.Money
+		slug  string
+	}{
+		{"Chicken Waffle", 1599, "chicken-waffle"},
+		{"Classic Waffle", 899, "classic-waffle"},
+		{"Chocolate Waffle", 1099, "chocolate-waffle"},
+		{"Berry Waffle", 1299, "berry-waffle"},
+		{"Sausage Waffle", 1499, "sausage-waffle"},
+	} {
+		product := models.Product{
+			Name:     seed.name,
+			Price:    seed.price,
+			Category: "Waffle",
+			Image: models.Image{
+				Thumbnail: "https://example.com/images/" + seed.slug + "-thumb.jpg",
+				Mobile:    "https://example.com/images/" + seed.slug + "-mobile.jpg",
+				Tablet:    "https://example.com/images/" + seed.slug + "-tablet.jpg",
+				Desktop:   "https://example.com/images/" + seed.slug + "-desktop.jpg",
+			},
+		}
+		_ = r.Create(context.Background(), &product)
+	}
+
+	return r
+}
+
+func (r *memoryProductRepository) Find(ctx context.Context) ([]models.Product, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	products := make([]models.Product, 0, len(r.products))
+	for _, product := range r.products {
+		if product.DeletedAt == nil {
+			products = append(products, product)
+		}
+	}
+
+	sort.Slice(products, func(i, j int) bool {
+		if products[i].Name == products[j].Name {
+			return products[i].ID < products[j].ID
+		}
+		return products[i].Name < products[j].Name
+	})
+
+	return products, nil
+}
+
+func (r *memoryProductRepository) FindPage(ctx context.Context, page models.PageRequest) ([]models.Product, int, error) {
+	products, err := r.Find(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	if page.StoreID != "" {
+		products = slices.DeleteFunc(products, func(product models.Product) bool {
+			return models.StoreOrDefault(product.StoreID) != page.StoreID
+		})
+	}
+
+	start, end := page.Window(len(products))
+	return products[start:end], len(products), nil
+}
+
+func (r *memoryProductRepository) FindUpdatedSince(ctx context.Context, since time.Time) ([]models.Product, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var products []models.Product
+	for _, product := range r.products {
+		if !product.UpdatedAt.Before(since) {
+			products = append(products, product)
+		}
+	}
+
+	sort.Slice(products, func(i, j int) bool {
+		if products[i].UpdatedAt.Equal(products[j].UpdatedAt) {
+			return products[i].ID < products[j].ID
+		}
+		return products[i].UpdatedAt.Before(products[j].UpdatedAt)
+	})
+
+	return products, nil
+}
+
+func (r *memoryProductRepository) FindOne(ctx context.Context, id string) (*models.Product, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, fmt.Errorf("invalid product ID: %w", err)
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	product, ok := r.products[id]
+	if !ok || product.DeletedAt != nil {
+		return nil, fmt.Errorf("product not found")
+	}
+	return &product, nil
+}
+
+func (r *memoryProductRepository) FindOneForUpdate(ctx context.Context, id string) (*models.Product, error) {
+	return r.FindOne(ctx, id)
+}
+
+func (r *memoryProductRepository) FindByIDs(ctx context.Context, ids []string) ([]models.Product, error) {
+	for _, id := range ids {
+		if _, err := uuid.Parse(id); err != nil {
+			return nil, fmt.Errorf("invalid product ID: %w", err)
+		}
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	seen := make(map[string]bool, len(ids))
+	products := make([]models.Product, 0, len(ids))
+	for _, id := range ids {
+		product, ok := r.products[id]
+		if !ok || product.DeletedAt != nil || seen[id] {
+			continue
+		}
+		seen[id] = true
+		products = append(products, product)
+	}
+
+	sort.Slice(products, func(i, j int) bool {
+		return products[i].Name < products[j].Name
+	})
+
+	return products, nil
+}
+
+func (r *memoryProductRepository) Create(ctx context.Context, product *models.Product) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	product.ID = uuid.New().String()
+	product.StoreID = models.StoreOrDefault(product.StoreID)
+	product.Version = 1
+	product.CreatedAt = time.Now()
+	product.UpdatedAt = product.CreatedAt
+	assignOptionIDs(product, nil)
+	r.store(*product)
+	r.logChange(product.ID, models.ProductCreated, product.CreatedAt)
+	return nil
+}
+
+func (r *memoryProductRepository) Update(ctx context.Context, product *models.Product) error {
+	if _, err := uuid.Parse(product.ID); err != nil {
+		return fmt.Errorf("invalid product ID: %w", err)
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	stored, ok := r.products[product.ID]
+	if !ok || stored.DeletedAt != nil {
+		return fmt.Errorf("product not found")
+	}
+	if stored.Version != product.Version {
+		return &ConflictError{Entity: "product", ID: product.ID, Version: product.Version}
+	}
+	product.Version++
+	product.StoreID = stored.StoreID
+	product.CreatedAt = stored.CreatedAt
+	product.UpdatedAt = time.Now()
+	owned := make(map[string]bool, len(stored.Variants)+len(stored.Modifiers))
+	for _, option := range slices.Concat(stored.Variants, stored.Modifiers) {
+		owned[option.ID] = true
+	}
+	assignOptionIDs(product, owned)
+	r.store(*product)
+	r.logChange(product.ID, models.ProductUpdated, product.UpdatedAt)
+	return nil
+}
+
+func (r *memoryProductRepository) Delete(ctx context.Context, id string) error {
+	if _, err := uuid.Parse(id); err != nil {
+		return fmt.Errorf("invalid product ID: %w", err)
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	product, ok := r.products[id]
+	if !ok || product.DeletedAt != nil {
+		return fmt.Errorf("product not found")
+	}
+	now := time.Now()
+	product.DeletedAt = &now
+	product.UpdatedAt = now
+	product.Version++
+	r.products[id] = product
+	r.logChange(id, models.ProductDeleted, now)
+	return nil
+}
+
+func (r *memoryProductRepository) FindDeleted(ctx context.Context, since time.Time) ([]models.Product, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var products []models.Product
+	for _, product := range r.products {
+		if product.DeletedAt != nil && !product.DeletedAt.Before(since) {
+			products = append(products, product)
+		}
+	}
+
+	sort.Slice(products, func(i, j int) bool {
+		return products[i].DeletedAt.After(*products[j].DeletedAt)
+	})
+
+	return products, nil
+}
+
+func (r *memoryProductRepository) Restore(ctx context.Context, id string) error {
+	if _, err := uuid.Parse(id); err != nil {
+		return fmt.Errorf("invalid product ID: %w", err)
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	product, ok := r.products[id]
+	if !ok || product.DeletedAt == nil {
+		return fmt.Errorf("deleted product not found")
+	}
+	product.DeletedAt = nil
+	product.UpdatedAt = time.Now()
+	product.Version++
+	r.products[id] = product
+	r.logChange(id, models.ProductCreated, product.UpdatedAt)
+	return nil
+}
+
+func (r *memoryProductRepository) FindChanges(ctx context.Context, storeID string, after int64, limit int) ([]models.ProductChange, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var changes []models.ProductChange
+	for i := int(max(after, 0)); i < len(r.changes) && len(changes) < limit; i++ {
+		change := r.changes[i]
+		product := r.products[change.productID]
+		if storeID != "" && models.StoreOrDefault(product.StoreID) != storeID {
+			continue
+		}
+		entry := models.ProductChange{
+			Cursor:    strconv.Itoa(i + 1),
+			Kind:      change.kind,
+			ProductID: change.productID,
+			ChangedAt: change.at,
+		}
+		if change.kind != models.ProductDeleted {
+			entry.Product = &product
+		}
+		changes = append(changes, entry)
+	}
+	return changes, nil
+}
+
+// store keeps a copy of the product whose options the caller can't change. Callers must
+// hold the lock.
+func (r *memoryProductRepository) store(product models.Product) {
+	product.Variants, product.Modifiers = slices.Clone(product.Variants), slices.Clone(product.Modifiers)
+	r.products[product.ID] = product
+}
+
+// logChange adds a change to the feed. Callers must hold the lock.
+func (r *memoryProductRepository) logChange(productID, kind string, at time.Time) {
+	r.changes = append(r.changes, productChange{productID: productID, kind: kind, at: at})
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"oolio/internal/app/models"
+)
+
+// memoryPushDeviceRepository is an in-process PushDeviceRepository for local development
+// and tests that run without Postgres
+type memoryPushDeviceRepository struct {
+	mutex     sync.RWMutex
+	devices   map[string]models.PushDevice // By token
+	customers map[string]string            // Customer of each token
+}
+
+func NewMemoryPushDeviceRepository() PushDeviceRepository {
+	return &memoryPushDeviceRepository{
+		devices:   make(map[string]models.PushDevice),
+		customers: make(map[string]string),
+	}
+}
+
+func (r *memoryPushDeviceRepository) Register(ctx context.Context, customerID string, device *models.PushDevice) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	device.CreatedAt = time.Now()
+	r.devices[device.Token] = *device
+	r.customers[device.Token] = customerID
+	return nil
+}
+
+func (r *memoryPushDeviceRepository) FindByCustomer(ctx context.Context, customerID string) ([]models.PushDevice, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var devices []models.PushDevice
+	for token, device := range r.devices {
+		if r.customers[token] == customerID {
+			devices = append(devices, device)
+		}
+	}
+	slices.SortFunc(devices, func(a, b models.PushDevice) int {
+		if c := b.CreatedAt.Compare(a.CreatedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Token, b.Token)
+	})
+	return devices, nil
+}
+
+func (r *memoryPushDeviceRepository) Unregister(ctx context.Context, customerID, token string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if customer, ok := r.customers[token]; !ok || customer != customerID {
+		return fmt.Errorf("push device not found")
+	}
+	delete(r.devices, token)
+	delete(r.customers, token)
+	return nil
+}
+
+func (r *memoryPushDeviceRepository) Remove(ctx context.Context, token string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	delete(r.devices, token)
+	delete(r.customers, token)
+	return nil
+}
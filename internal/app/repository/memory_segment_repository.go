@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"oolio/internal/app/models"
+)
+
+// memorySegmentRepository is an in-process SegmentRepository for local development
+// and tests that run without Postgres. Segments are computed from orders.
+type memorySegmentRepository struct {
+	orders   OrderRepository
+	mutex    sync.RWMutex
+	segments map[string]models.CustomerSegment
+}
+
+func NewMemorySegmentRepository(orders OrderRepository) SegmentRepository {
+	return &memorySegmentRepository{
+		orders:   orders,
+		segments: make(map[string]models.CustomerSegment),
+	}
+}
+
+func (r *memorySegmentRepository) Refresh(ctx context.Context, rules models.SegmentRules, now time.Time) (int64, error) {
+	orders, err := r.orders.Find(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to refresh customer segments: %w", err)
+	}
+
+	computed := make(map[string]models.CustomerSegment)
+	recentSpend := make(map[string]models.Money)
+	for _, order := range orders {
+		if order.CustomerID == "" || order.Status == models.OrderStatusCancelled || order.Status == models.OrderStatusFailed || order.Sandbox {
+			continue
+		}
+
+		spent := order.Total - order.Discounts
+		segment, ok := computed[order.CustomerID]
+		if !ok {
+			segment = models.CustomerSegment{CustomerID: order.CustomerID, FirstOrderAt: order.CreatedAt, LastOrderAt: order.CreatedAt}
+		}
+		segment.OrderCount++
+		segment.TotalSpent += spent
+		if order.CreatedAt.Before(segment.FirstOrderAt) {
+			segment.FirstOrderAt = order.CreatedAt
+		}
+		if order.CreatedAt.After(segment.LastOrderAt) {
+			segment.LastOrderAt = order.CreatedAt
+		}
+		computed[order.CustomerID] = segment
+
+		if now.Sub(order.CreatedAt) < rules.VIPWindow {
+			recentSpend[order.CustomerID] += spent
+		}
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for customerID, segment := range computed {
+		segment.Segment = rules.Segment(now, segment.FirstOrderAt, segment.LastOrderAt, recentSpend[customerID])
+		segment.ComputedAt = now
+		r.segments[customerID] = segment
+	}
+	return int64(len(computed)), nil
+}
+
+func (r *memorySegmentRepository) FindByCustomer(ctx context.Context, customerID string) (*models.CustomerSegment, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	segment, ok := r.segments[customerID]
+	if !ok {
+		return nil, fmt.Errorf("segment not found")
+	}
+	return &segment, nil
+}
@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"oolio/internal/app/models"
+
+	"github.com/google/uuid"
+)
+
+// memoryStoreRepository is an in-process StoreRepository for local development and tests
+// that run without Postgres. It starts with the default store the migration seeds.
+type memoryStoreRepository struct {
+	mutex  sync.RWMutex
+	stores map[string]models.Store
+	keys   []memoryStoreAPIKey // Oldest first
+	hours  map[string]models.OpeningHours
+}
+
+type memoryStoreAPIKey struct {
+	models.StoreAPIKey
+	hash string
+}
+
+func NewMemoryStoreRepository() StoreRepository {
+	now := time.Now()
+	return &memoryStoreRepository{
+		stores: map[string]models.Store{
+			models.DefaultStoreID: {ID: models.DefaultStoreID, Name: "Default store", CreatedAt: now, UpdatedAt: now},
+		},
+		hours: make(map[string]models.OpeningHours),
+	}
+}
+
+func (r *memoryStoreRepository) FindAll(ctx context.Context) ([]models.Store, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	stores := make([]models.Store, 0, len(r.stores))
+	for _, store := range r.stores {
+		stores = append(stores, store)
+	}
+	slices.SortFunc(stores, func(a, b models.Store) int {
+		if c := strings.Compare(a.Name, b.Name); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+	return stores, nil
+}
+
+func (r *memoryStoreRepository) FindOne(ctx context.Context, id string) (*models.Store, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	store, ok := r.stores[id]
+	if !ok {
+		return nil, fmt.Errorf("store not found")
+	}
+	return &store, nil
+}
+
+func (r *memoryStoreRepository) Create(ctx context.Context, name string) (*models.Store, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	now := time.Now()
+	store := models.Store{ID: uuid.New().String(), Name: name, CreatedAt: now, UpdatedAt: now}
+	r.stores[store.ID] = store
+	return &store, nil
+}
+
+func (r *memoryStoreRepository) Update(ctx context.Context, id, name string) (*models.Store, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	store, ok := r.stores[id]
+	if !ok {
+		return nil, fmt.Errorf("store not found")
+	}
+	store.Name = name
+	store.UpdatedAt = time.Now()
+	r.stores[id] = store
+	return &store, nil
+}
+
+func (r *memoryStoreRepository) FindAPIKeys(ctx context.Context, storeID string) ([]models.StoreAPIKey, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	keys := make([]models.StoreAPIKey, 0)
+	for _, key := range r.keys {
+		if key.StoreID == storeID {
+			keys = append(keys, key.StoreAPIKey)
+		}
+	}
+	return keys, nil
+}
+
+func (r *memoryStoreRepository) CreateAPIKey(ctx context.Context, key *models.StoreAPIKey, keyHash string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, ok := r.stores[key.StoreID]; !ok {
+		return fmt.Errorf("store not found")
+	}
+	key.ID = uuid.New().String()
+	key.CreatedAt = time.Now()
+	stored := *key
+	stored.Key = ""
+	r.keys = append(r.keys, memoryStoreAPIKey{StoreAPIKey: stored, hash: keyHash})
+	return nil
+}
+
+func (r *memoryStoreRepository) RevokeAPIKey(ctx context.Context, storeID, id string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for i, key := range r.keys {
+		if key.ID == id && key.StoreID == storeID && key.RevokedAt == nil {
+			now := time.Now()
+			r.keys[i].RevokedAt = &now
+			return nil
+		}
+	}
+	return fmt.Errorf("store API key not found")
+}
+
+func (r *memoryStoreRepository) FindAPIKeyByHash(ctx context.Context, keyHash string) (*models.StoreAPIKey, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, key := range r.keys {
+		if key.hash == keyHash && key.RevokedAt == nil {
+			found := key.StoreAPIKey
+			return &found, nil
+		}
+	}
+	return nil, fmt.Errorf("store API key not found")
+}
+
+func (r *memoryStoreRepository) FindOpeningHours(ctx context.Context, storeID string) (*models.OpeningHours, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	hours, ok := r.hours[storeID]
+	if !ok {
+		return nil, nil
+	}
+	return &hours, nil
+}
+
+func (r *memoryStoreRepository) SaveOpeningHours(ctx context.Context, storeID string, hours *models.OpeningHours) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, ok := r.stores[storeID]; !ok {
+		return fmt.Errorf("store not found")
+	}
+	r.hours[storeID] = *hours
+	return nil
+}
+
+func (r *memoryStoreRepository) DeleteOpeningHours(ctx context.Context, storeID string) (bool, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	_, ok := r.hours[storeID]
+	delete(r.hours, storeID)
+	return ok, nil
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"oolio/internal/app/models"
+)
+
+// memoryTabRepository is an in-process TabRepository for local development and tests that
+// run without Postgres
+type memoryTabRepository struct {
+	mutex sync.Mutex
+	tabs  map[string]*models.Tab
+}
+
+func NewMemoryTabRepository() TabRepository {
+	return &memoryTabRepository{tabs: make(map[string]*models.Tab)}
+}
+
+func (r *memoryTabRepository) Open(ctx context.Context, storeID, tableNumber string) (*models.Tab, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if tab := r.findOpen(storeID, tableNumber); tab != nil {
+		copied := *tab
+		return &copied, nil
+	}
+	tab := &models.Tab{
+		ID:          uuid.New().String(),
+		StoreID:     storeID,
+		TableNumber: tableNumber,
+		Status:      models.TabStatusOpen,
+		OpenedAt:    time.Now(),
+	}
+	r.tabs[tab.ID] = tab
+	copied := *tab
+	return &copied, nil
+}
+
+func (r *memoryTabRepository) FindOpen(ctx context.Context, storeID, tableNumber string) (*models.Tab, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	tab := r.findOpen(storeID, tableNumber)
+	if tab == nil {
+		return nil, fmt.Errorf("tab not found")
+	}
+	copied := *tab
+	return &copied, nil
+}
+
+func (r *memoryTabRepository) FindOpenByStore(ctx context.Context, storeID string) ([]models.Tab, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var tabs []models.Tab
+	for _, tab := range r.tabs {
+		if tab.StoreID == storeID && tab.Status == models.TabStatusOpen {
+			tabs = append(tabs, *tab)
+		}
+	}
+	sort.Slice(tabs, func(i, j int) bool {
+		if !tabs[i].OpenedAt.Equal(tabs[j].OpenedAt) {
+			return tabs[i].OpenedAt.Before(tabs[j].OpenedAt)
+		}
+		return tabs[i].ID < tabs[j].ID
+	})
+	return tabs, nil
+}
+
+func (r *memoryTabRepository) Close(ctx context.Context, id, paymentMethod string) (*models.Tab, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	tab, ok := r.tabs[id]
+	if !ok || tab.Status != models.TabStatusOpen {
+		return nil, fmt.Errorf("tab not found")
+	}
+	now := time.Now()
+	tab.Status = models.TabStatusClosed
+	tab.PaymentMethod = paymentMethod
+	tab.ClosedAt = &now
+	copied := *tab
+	return &copied, nil
+}
+
+func (r *memoryTabRepository) findOpen(storeID, tableNumber string) *models.Tab {
+	for _, tab := range r.tabs {
+		if tab.StoreID == storeID && tab.TableNumber == tableNumber && tab.Status == models.TabStatusOpen {
+			return tab
+		}
+	}
+	return nil
+}
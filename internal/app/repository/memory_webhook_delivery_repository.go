@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"oolio/internal/app/models"
+)
+
+// memoryWebhookDeliveryRepository is an in-process WebhookDeliveryRepository for local
+// development and tests that run without Postgres
+type memoryWebhookDeliveryRepository struct {
+	mutex      sync.Mutex
+	deliveries map[string]models.WebhookDelivery
+	ids        []string // Creation order, so listings are stable
+}
+
+func NewMemoryWebhookDeliveryRepository() WebhookDeliveryRepository {
+	return &memoryWebhookDeliveryRepository{
+		deliveries: make(map[string]models.WebhookDelivery),
+	}
+}
+
+func (r *memoryWebhookDeliveryRepository) Enqueue(ctx context.Context, delivery *models.WebhookDelivery) (bool, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, existing := range r.deliveries {
+		if existing.Endpoint == delivery.Endpoint && existing.EventID == delivery.EventID {
+			return false, nil
+		}
+	}
+
+	now := time.Now()
+	delivery.ID = uuid.New().String()
+	delivery.Status = models.WebhookPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = now
+	delivery.CreatedAt = now
+	delivery.AttemptLog = nil
+	r.deliveries[delivery.ID] = *delivery
+	r.ids = append(r.ids, delivery.ID)
+	return true, nil
+}
+
+func (r *memoryWebhookDeliveryRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]*models.WebhookDelivery, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	now := time.Now()
+	var due []*models.WebhookDelivery
+	for _, id := range r.ids {
+		delivery := r.deliveries[id]
+		if delivery.Status == models.WebhookPending && !delivery.NextAttemptAt.After(now) {
+			delivery.AttemptLog = nil
+			due = append(due, &delivery)
+		}
+	}
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	for _, delivery := range due {
+		stored := r.deliveries[delivery.ID]
+		stored.NextAttemptAt = now.Add(lease)
+		r.deliveries[delivery.ID] = stored
+		delivery.NextAttemptAt = stored.NextAttemptAt
+	}
+	return due, nil
+}
+
+func (r *memoryWebhookDeliveryRepository) MarkDelivered(ctx context.Context, id string, attempt models.WebhookAttempt) error {
+	return r.update(id, attempt, func(delivery *models.WebhookDelivery) {
+		now := time.Now()
+		delivery.Status = models.WebhookDelivered
+		delivery.LastError = ""
+		delivery.DeliveredAt = &now
+	})
+}
+
+func (r *memoryWebhookDeliveryRepository) MarkFailed(ctx context.Context, id string, attempt models.WebhookAttempt, retryAt time.Time) error {
+	return r.update(id, attempt, func(delivery *models.WebhookDelivery) {
+		delivery.LastError = attempt.Error
+		delivery.NextAttemptAt = retryAt
+	})
+}
+
+func (r *memoryWebhookDeliveryRepository) MarkDead(ctx context.Context, id string, attempt models.WebhookAttempt) error {
+	return r.update(id, attempt, func(delivery *models.WebhookDelivery) {
+		delivery.Status = models.WebhookDead
+		delivery.LastError = attempt.Error
+	})
+}
+
+func (r *memoryWebhookDeliveryRepository) Redeliver(ctx context.Context, id string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	delivery, ok := r.deliveries[id]
+	if !ok || delivery.Status != models.WebhookDead {
+		return fmt.Errorf("webhook delivery not found")
+	}
+	delivery.Status = models.WebhookPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = time.Now()
+	r.deliveries[id] = delivery
+	return nil
+}
+
+func (r *memoryWebhookDeliveryRepository) FindOne(ctx context.Context, id string) (*models.WebhookDelivery, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	delivery, ok := r.deliveries[id]
+	if !ok {
+		return nil, fmt.Errorf("webhook delivery not found")
+	}
+	delivery.AttemptLog = slices.Clone(delivery.AttemptLog)
+	if delivery.AttemptLog == nil {
+		delivery.AttemptLog = []models.WebhookAttempt{}
+	}
+	return &delivery, nil
+}
+
+func (r *memoryWebhookDeliveryRepository) FindPage(ctx context.Context, status string, page models.PageRequest) ([]models.WebhookDelivery, int, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	deliveries := []models.WebhookDelivery{}
+	for i := len(r.ids) - 1; i >= 0; i-- {
+		delivery := r.deliveries[r.ids[i]]
+		if status == "" || delivery.Status == status {
+			delivery.AttemptLog = nil
+			deliveries = append(deliveries, delivery)
+		}
+	}
+	start, end := page.Window(len(deliveries))
+	return deliveries[start:end], len(deliveries), nil
+}
+
+// update logs the attempt and records its outcome with fn
+func (r *memoryWebhookDeliveryRepository) update(id string, attempt models.WebhookAttempt, fn func(delivery *models.WebhookDelivery)) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	delivery, ok := r.deliveries[id]
+	if !ok {
+		return fmt.Errorf("webhook delivery not found")
+	}
+	delivery.Attempts++
+	delivery.AttemptLog = append(slices.Clip(delivery.AttemptLog), attempt)
+	fn(&delivery)
+	r.deliveries[id] = delivery
+	return nil
+}
@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"oolio/internal/app/models"
+	"oolio/internal/database/sqlc"
+)
+
+// NotificationFeedRepository keeps customers' in-app notification feeds
+type NotificationFeedRepository interface {
+	// Add stores an unread notification for the customer, giving it an ID
+	Add(ctx context.Context, customerID string, notification *models.FeedNotification) error
+	// FindPage lists the customer's notifications, newest first, only the unread ones when
+	// unreadOnly, along with how many there are
+	FindPage(ctx context.Context, customerID string, unreadOnly bool, page models.PageRequest) ([]models.FeedNotification, int, error)
+	// CountUnread is how many of the customer's notifications are unread
+	CountUnread(ctx context.Context, customerID string) (int, error)
+	// MarkRead marks one of the customer's notifications read. It fails with "feed
+	// notification not found" if the customer has none with that id.
+	MarkRead(ctx context.Context, customerID, id string) error
+	// MarkAllRead marks all the customer's notifications read and returns how many were unread
+	MarkAllRead(ctx context.Context, customerID string) (int, error)
+}
+
+type notificationFeedRepository struct {
+	qtx *sqlc.Queries
+}
+
+func NewNotificationFeedRepository(db *sql.DB) NotificationFeedRepository {
+	return &notificationFeedRepository{qtx: sqlc.New(db)}
+}
+
+func (r *notificationFeedRepository) Add(ctx context.Context, customerID string, notification *models.FeedNotification) error {
+	var orderID uuid.NullUUID
+	if notification.OrderID != "" {
+		id, err := uuid.Parse(notification.OrderID)
+		if err != nil {
+			return fmt.Errorf("invalid order ID: %w", err)
+		}
+		orderID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+
+	id := uuid.New()
+	now := time.Now()
+	err := r.qtx.InsertCustomerNotification(ctx, sqlc.InsertCustomerNotificationParams{
+		ID:         id,
+		CustomerID: customerID,
+		Kind:       notification.Kind,
+		Title:      notification.Title,
+		Body:       notification.Body,
+		OrderID:    orderID,
+		CreatedAt:  now,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add feed notification: %w", err)
+	}
+
+	notification.ID = id.String()
+	notification.CreatedAt = now
+	notification.ReadAt = nil
+	return nil
+}
+
+func (r *notificationFeedRepository) FindPage(ctx context.Context, customerID string, unreadOnly bool, page models.PageRequest) ([]models.FeedNotification, int, error) {
+	page = page.Normalize()
+	counts, err := r.qtx.CountCustomerNotifications(ctx, customerID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count feed notifications: %w", err)
+	}
+	rows, err := r.qtx.ListCustomerNotifications(ctx, sqlc.ListCustomerNotificationsParams{
+		CustomerID: customerID,
+		UnreadOnly: unreadOnly,
+		PageLimit:  int32(page.Limit),
+		PageOffset: int32(page.Offset),
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list feed notifications: %w", err)
+	}
+
+	notifications := make([]models.FeedNotification, len(rows))
+	for i, row := range rows {
+		notifications[i] = models.FeedNotification{
+			ID:        row.ID.String(),
+			Kind:      row.Kind,
+			Title:     row.Title,
+			Body:      row.Body,
+			CreatedAt: row.CreatedAt,
+			ReadAt:    nullTimeToPtr(row.ReadAt),
+		}
+		if row.OrderID.Valid {
+			notifications[i].OrderID = row.OrderID.UUID.String()
+		}
+	}
+
+	total := counts.Total
+	if unreadOnly {
+		total = counts.Unread
+	}
+	return notifications, int(total), nil
+}
+
+func (r *notificationFeedRepository) CountUnread(ctx context.Context, customerID string) (int, error) {
+	counts, err := r.qtx.CountCustomerNotifications(ctx, customerID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count feed notifications: %w", err)
+	}
+	return int(counts.Unread), nil
+}
+
+func (r *notificationFeedRepository) MarkRead(ctx context.Context, customerID, id string) error {
+	notificationID, err := uuid.Parse(id)
+	if err != nil {
+		return fmt.Errorf("feed notification not found")
+	}
+	updated, err := r.qtx.MarkCustomerNotificationRead(ctx, sqlc.MarkCustomerNotificationReadParams{
+		ID:         notificationID,
+		CustomerID: customerID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to mark feed notification read: %w", err)
+	}
+	if updated == 0 {
+		return fmt.Errorf("feed notification not found")
+	}
+	return nil
+}
+
+func (r *notificationFeedRepository) MarkAllRead(ctx context.Context, customerID string) (int, error) {
+	updated, err := r.qtx.MarkAllCustomerNotificationsRead(ctx, customerID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark feed notifications read: %w", err)
+	}
+	return int(updated), nil
+}
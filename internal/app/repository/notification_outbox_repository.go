@@ -0,0 +1,191 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"oolio/internal/app/models"
+	"oolio/internal/database/sqlc"
+)
+
+// NotificationOutboxRepository holds notifications until the notification worker sends them
+type NotificationOutboxRepository interface {
+	// Enqueue stores a pending notification that is due right away, giving it an ID
+	Enqueue(ctx context.Context, message *models.NotificationMessage) error
+	// ClaimDue returns up to limit due notifications, oldest first, and holds them back from
+	// other workers for lease. A worker that dies before recording the outcome leaves them
+	// to be sent again once the lease is over.
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]*models.NotificationMessage, error)
+	MarkSent(ctx context.Context, id string) error
+	// MarkFailed records a failed attempt and makes the notification due again at retryAt
+	MarkFailed(ctx context.Context, id, errorMsg string, retryAt time.Time) error
+	// MarkDead records the last failed attempt; the notification isn't sent again
+	MarkDead(ctx context.Context, id, errorMsg string) error
+	// Redeliver makes a dead notification pending again with a fresh set of attempts. It
+	// fails with "notification not found" if there is no dead notification with that id.
+	Redeliver(ctx context.Context, id string) error
+	GetStats(ctx context.Context) (*models.NotificationDeliveryStats, error)
+}
+
+type notificationOutboxRepository struct {
+	qtx *sqlc.Queries
+}
+
+func NewNotificationOutboxRepository(db *sql.DB) NotificationOutboxRepository {
+	return &notificationOutboxRepository{qtx: sqlc.New(db)}
+}
+
+func (r *notificationOutboxRepository) Enqueue(ctx context.Context, message *models.NotificationMessage) error {
+	var orderID uuid.NullUUID
+	if message.OrderID != "" {
+		id, err := uuid.Parse(message.OrderID)
+		if err != nil {
+			return fmt.Errorf("invalid order ID: %w", err)
+		}
+		orderID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+
+	id := uuid.New()
+	now := time.Now()
+	err := r.qtx.EnqueueNotification(ctx, sqlc.EnqueueNotificationParams{
+		ID:            id,
+		Channel:       message.Channel,
+		Kind:          message.Kind,
+		Recipient:     message.Recipient,
+		Subject:       message.Subject,
+		Body:          message.Text,
+		OrderID:       orderID,
+		NextAttemptAt: now,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to enqueue notification: %w", err)
+	}
+
+	message.ID = id.String()
+	message.Status = models.NotificationPending
+	message.Attempts = 0
+	message.NextAttemptAt = now
+	message.CreatedAt = now
+	return nil
+}
+
+func (r *notificationOutboxRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]*models.NotificationMessage, error) {
+	dbMessages, err := r.qtx.ClaimDueNotifications(ctx, sqlc.ClaimDueNotificationsParams{
+		Limit:         int32(limit),
+		NextAttemptAt: time.Now().Add(lease),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim notifications: %w", err)
+	}
+
+	messages := make([]*models.NotificationMessage, 0, len(dbMessages))
+	for _, dbMessage := range dbMessages {
+		messages = append(messages, mapSQLCToNotificationMessage(dbMessage))
+	}
+	// RETURNING doesn't keep the subquery's order
+	sortNotificationMessages(messages)
+	return messages, nil
+}
+
+func (r *notificationOutboxRepository) MarkSent(ctx context.Context, id string) error {
+	messageUUID, err := uuid.Parse(id)
+	if err != nil {
+		return fmt.Errorf("invalid notification ID: %w", err)
+	}
+	if err := r.qtx.MarkNotificationSent(ctx, messageUUID); err != nil {
+		return fmt.Errorf("failed to mark notification sent: %w", err)
+	}
+	return nil
+}
+
+func (r *notificationOutboxRepository) MarkFailed(ctx context.Context, id, errorMsg string, retryAt time.Time) error {
+	messageUUID, err := uuid.Parse(id)
+	if err != nil {
+		return fmt.Errorf("invalid notification ID: %w", err)
+	}
+	err = r.qtx.MarkNotificationFailed(ctx, sqlc.MarkNotificationFailedParams{
+		ID:            messageUUID,
+		LastError:     stringToNullString(errorMsg),
+		NextAttemptAt: retryAt,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to mark notification failed: %w", err)
+	}
+	return nil
+}
+
+func (r *notificationOutboxRepository) MarkDead(ctx context.Context, id, errorMsg string) error {
+	messageUUID, err := uuid.Parse(id)
+	if err != nil {
+		return fmt.Errorf("invalid notification ID: %w", err)
+	}
+	err = r.qtx.MarkNotificationDead(ctx, sqlc.MarkNotificationDeadParams{
+		ID:        messageUUID,
+		LastError: stringToNullString(errorMsg),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to mark notification dead: %w", err)
+	}
+	return nil
+}
+
+func (r *notificationOutboxRepository) Redeliver(ctx context.Context, id string) error {
+	messageUUID, err := uuid.Parse(id)
+	if err != nil {
+		return fmt.Errorf("notification not found")
+	}
+	updated, err := r.qtx.RedeliverNotification(ctx, messageUUID)
+	if err != nil {
+		return fmt.Errorf("failed to redeliver notification: %w", err)
+	}
+	if updated == 0 {
+		return fmt.Errorf("notification not found")
+	}
+	return nil
+}
+
+func (r *notificationOutboxRepository) GetStats(ctx context.Context) (*models.NotificationDeliveryStats, error) {
+	row, err := r.qtx.GetNotificationOutboxStats(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification stats: %w", err)
+	}
+	return &models.NotificationDeliveryStats{
+		Pending:         int(row.Pending),
+		Retrying:        int(row.Retrying),
+		Sent:            int(row.Sent),
+		Dead:            int(row.Dead),
+		OldestPendingAt: nullTimeToPtr(row.OldestPendingAt),
+	}, nil
+}
+
+func mapSQLCToNotificationMessage(dbMessage sqlc.NotificationOutbox) *models.NotificationMessage {
+	message := &models.NotificationMessage{
+		ID:            dbMessage.ID.String(),
+		Channel:       dbMessage.Channel,
+		Kind:          dbMessage.Kind,
+		Recipient:     dbMessage.Recipient,
+		Subject:       dbMessage.Subject,
+		Text:          dbMessage.Body,
+		Status:        dbMessage.Status,
+		Attempts:      int(dbMessage.Attempts),
+		NextAttemptAt: dbMessage.NextAttemptAt,
+		LastError:     nullStringToString(dbMessage.LastError),
+		CreatedAt:     dbMessage.CreatedAt,
+		SentAt:        nullTimeToPtr(dbMessage.SentAt),
+	}
+	if dbMessage.OrderID.Valid {
+		message.OrderID = dbMessage.OrderID.UUID.String()
+	}
+	return message
+}
+
+func sortNotificationMessages(messages []*models.NotificationMessage) {
+	sort.Slice(messages, func(i, j int) bool {
+		return messages[i].CreatedAt.Before(messages[j].CreatedAt)
+	})
+}
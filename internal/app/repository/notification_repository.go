@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"oolio/internal/app/models"
+	"oolio/internal/database/sqlc"
+)
+
+// NotificationRepository keeps customers' notification preferences
+type NotificationRepository interface {
+	FindByCustomer(ctx context.Context, customerID string) (*models.NotificationPreferences, error)
+	Save(ctx context.Context, customerID string, req models.NotificationPreferencesReq) (*models.NotificationPreferences, error)
+}
+
+type notificationRepository struct {
+	qtx *sqlc.Queries
+}
+
+func NewNotificationRepository(db *sql.DB) NotificationRepository {
+	return &notificationRepository{qtx: sqlc.New(db)}
+}
+
+func (r *notificationRepository) FindByCustomer(ctx context.Context, customerID string) (*models.NotificationPreferences, error) {
+	row, err := r.qtx.GetNotificationPreferences(ctx, customerID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("notification preferences not found")
+		}
+		return nil, fmt.Errorf("failed to get notification preferences: %w", err)
+	}
+	return mapSQLCNotificationPreferences(row), nil
+}
+
+func (r *notificationRepository) Save(ctx context.Context, customerID string, req models.NotificationPreferencesReq) (*models.NotificationPreferences, error) {
+	row, err := r.qtx.UpsertNotificationPreferences(ctx, sqlc.UpsertNotificationPreferencesParams{
+		CustomerID:      customerID,
+		Email:           req.Email,
+		Phone:           req.Phone,
+		EmailReceipt:    req.EmailReceipt,
+		SmsReadyAlert:   req.SMSReadyAlert,
+		EmailReadyAlert: req.EmailReadyAlert,
+		Marketing:       req.Marketing,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save notification preferences: %w", err)
+	}
+	return mapSQLCNotificationPreferences(row), nil
+}
+
+func mapSQLCNotificationPreferences(row sqlc.CustomerNotificationPreference) *models.NotificationPreferences {
+	return &models.NotificationPreferences{
+		NotificationPreferencesReq: models.NotificationPreferencesReq{
+			Email:           row.Email,
+			Phone:           row.Phone,
+			EmailReceipt:    row.EmailReceipt,
+			SMSReadyAlert:   row.SmsReadyAlert,
+			EmailReadyAlert: row.EmailReadyAlert,
+			Marketing:       row.Marketing,
+		},
+		UpdatedAt: &row.UpdatedAt,
+	}
+}
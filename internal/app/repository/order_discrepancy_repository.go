@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"oolio/internal/app/models"
+	"oolio/internal/database/sqlc"
+)
+
+// OrderDiscrepancyRepository stores what the reconciliation job finds, at most one
+// discrepancy per order and kind
+type OrderDiscrepancyRepository interface {
+	// Record stores the discrepancies found among the checked orders, keeping when those
+	// already stored were first detected, and removes the other ones of the checked orders
+	Record(ctx context.Context, checked []string, found []models.OrderDiscrepancy) error
+	// Find lists the discrepancies, of the store's orders when storeID isn't empty, most
+	// recently detected first
+	Find(ctx context.Context, storeID string) ([]models.OrderDiscrepancy, error)
+}
+
+type orderDiscrepancyRepository struct {
+	db     *sql.DB
+	qtx    *sqlc.Queries
+	reader ReadRouter
+}
+
+// NewOrderDiscrepancyRepository lists discrepancies from reader's replica when it has one
+func NewOrderDiscrepancyRepository(db *sql.DB, reader ReadRouter) OrderDiscrepancyRepository {
+	return &orderDiscrepancyRepository{db: db, qtx: sqlc.New(db), reader: reader}
+}
+
+func (r *orderDiscrepancyRepository) Record(ctx context.Context, checked []string, found []models.OrderDiscrepancy) error {
+	checkedUUIDs := make([]uuid.UUID, len(checked))
+	for i, id := range checked {
+		orderUUID, err := uuid.Parse(id)
+		if err != nil {
+			return fmt.Errorf("invalid order ID: %w", err)
+		}
+		checkedUUIDs[i] = orderUUID
+	}
+	params := sqlc.UpsertOrderDiscrepanciesParams{
+		OrderIds: make([]uuid.UUID, len(found)),
+		Kinds:    make([]string, len(found)),
+		Recorded: make([]models.Money, len(found)),
+		Expected: make([]models.Money, len(found)),
+	}
+	for i, discrepancy := range found {
+		orderUUID, err := uuid.Parse(discrepancy.OrderID)
+		if err != nil {
+			return fmt.Errorf("invalid order ID: %w", err)
+		}
+		params.OrderIds[i] = orderUUID
+		params.Kinds[i] = discrepancy.Kind
+		params.Recorded[i] = discrepancy.Recorded
+		params.Expected[i] = discrepancy.Expected
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	qtx := r.qtx.WithTx(tx)
+
+	err = qtx.DeleteResolvedOrderDiscrepancies(ctx, sqlc.DeleteResolvedOrderDiscrepanciesParams{
+		Checked:  checkedUUIDs,
+		OrderIds: params.OrderIds,
+		Kinds:    params.Kinds,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete resolved discrepancies: %w", err)
+	}
+	if len(found) > 0 {
+		if err := qtx.UpsertOrderDiscrepancies(ctx, params); err != nil {
+			return fmt.Errorf("failed to record discrepancies: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit discrepancies: %w", err)
+	}
+	return nil
+}
+
+// Find is a report and reads the replica
+func (r *orderDiscrepancyRepository) Find(ctx context.Context, storeID string) ([]models.OrderDiscrepancy, error) {
+	var storeUUID uuid.NullUUID
+	if storeID != "" {
+		parsed, err := uuid.Parse(storeID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid store ID: %w", err)
+		}
+		storeUUID = uuid.NullUUID{UUID: parsed, Valid: true}
+	}
+
+	queries := r.qtx
+	if r.reader != nil {
+		queries = sqlc.New(r.reader.Reader())
+	}
+	rows, err := queries.ListOrderDiscrepancies(ctx, storeUUID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list discrepancies: %w", err)
+	}
+
+	discrepancies := make([]models.OrderDiscrepancy, len(rows))
+	for i, row := range rows {
+		discrepancies[i] = models.OrderDiscrepancy{
+			OrderID:    row.OrderID.String(),
+			StoreID:    row.StoreID.String(),
+			Kind:       row.Kind,
+			Recorded:   row.Recorded,
+			Expected:   row.Expected,
+			Difference: row.Recorded - row.Expected,
+			CouponCode: row.CouponCode,
+			OrderedAt:  row.CreatedAt.Time,
+			DetectedAt: row.DetectedAt,
+			CheckedAt:  row.CheckedAt,
+		}
+	}
+	return discrepancies, nil
+}
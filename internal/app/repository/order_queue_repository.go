@@ -8,6 +8,9 @@ import (
 	"time"
 
 	"oolio/internal/app/models"
+	"oolio/internal/database/sqlc"
+
+	"github.com/google/uuid"
 )
 
 type OrderQueueRepository interface {
@@ -15,21 +18,51 @@ type OrderQueueRepository interface {
 	GetPendingItems(ctx context.Context, batchSize int) ([]*models.OrderQueueItem, error)
 	UpdateItem(ctx context.Context, item *models.OrderQueueItem) error
 	MarkAsProcessing(ctx context.Context, itemID string) error
+	// Claim records the worker instance that picked the item up
+	Claim(ctx context.Context, itemID, instance string) error
 	MarkAsCompleted(ctx context.Context, itemID string, order *models.Order) error
 	MarkAsFailed(ctx context.Context, itemID string, errorMsg string) error
+	// GetQueueStats counts the items by status. The sandbox items of test API keys are left
+	// out, here and in the backlog, so test traffic doesn't show in queue monitoring.
 	GetQueueStats(ctx context.Context) (map[string]int, error)
+	// GetBacklog counts the pending items and finds the one that has been due the longest
+	GetBacklog(ctx context.Context) (*models.QueueBacklog, error)
 	GetOrderFromQueue(ctx context.Context, itemID string) (*models.OrderQueueItem, error)
 	GetAllOrders(ctx context.Context) ([]*models.OrderQueueItem, error)
+	// FindPage returns one page of queue items, newest first, and the total number of items
+	FindPage(ctx context.Context, page models.PageRequest) ([]*models.OrderQueueItem, int, error)
+	// FindScheduled returns the items scheduled from from until until that haven't failed,
+	// soonest first. A storeID narrows them to that store's orders.
+	FindScheduled(ctx context.Context, storeID string, from, until time.Time) ([]*models.OrderQueueItem, error)
+	// FindByTab returns the items of the orders placed on a table's tab, oldest first
+	FindByTab(ctx context.Context, tabID string) ([]*models.OrderQueueItem, error)
+	// Anonymize clears the request, result and error of the queue item with the given id and
+	// of any item that produced the order with that id, keeping status and timestamps
+	Anonymize(ctx context.Context, id string) (int, error)
+	// GetFailures lists the item's failed attempts, oldest first, from its order.failed
+	// events
+	GetFailures(ctx context.Context, itemID string) ([]models.QueueFailure, error)
+	// Requeue makes a failed item pending again with a fresh retry budget and records an
+	// order.queued event. It fails if there is no failed item with that id.
+	Requeue(ctx context.Context, itemID string) error
+	// SetPaymentIntent stores the payment intent in the item's request and makes it due
+	// again, or at its scheduled time for scheduled orders. It fails if there is no pending item, or failed item with retries left, with
+	// that id, or if its order was already created.
+	SetPaymentIntent(ctx context.Context, itemID, intentID string) error
 }
 
 type orderQueueRepository struct {
-	db *sql.DB
+	db     *sql.DB
+	reader ReadRouter
 }
 
-func NewOrderQueueRepository(db *sql.DB) OrderQueueRepository {
-	return &orderQueueRepository{db: db}
+// NewOrderQueueRepository writes to db. Order listings go through reader when set;
+// status lookups stay on db so clients polling right after a write see their order.
+func NewOrderQueueRepository(db *sql.DB, reader ReadRouter) OrderQueueRepository {
+	return &orderQueueRepository{db: db, reader: reader}
 }
 
+// AddToQueue stores the item and records an order.queued event with it
 func (r *orderQueueRepository) AddToQueue(ctx context.Context, item *models.OrderQueueItem) error {
 	orderReqJSON, err := json.Marshal(item.OrderReq)
 	if err != nil {
@@ -37,25 +70,59 @@ func (r *orderQueueRepository) AddToQueue(ctx context.Context, item *models.Orde
 	}
 
 	query := `
-		INSERT INTO order_queue (id, order_req, status, created_at, updated_at, retry_count)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO order_queue (id, order_req, status, created_at, updated_at, retry_count, next_attempt_at, sandbox)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 	`
 
-	_, err = r.db.ExecContext(ctx, query, item.ID, orderReqJSON, item.Status, item.CreatedAt, item.UpdatedAt, item.RetryCount)
+	if item.NextAttemptAt.IsZero() {
+		item.NextAttemptAt = item.CreatedAt
+	}
+
+	return r.withEvent(ctx, item.ID, models.EventOrderQueued, item, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, query, item.ID, orderReqJSON, item.Status, item.CreatedAt, item.UpdatedAt, item.RetryCount, item.NextAttemptAt, item.OrderReq.Sandbox)
+		if err != nil {
+			return fmt.Errorf("failed to insert into order queue: %w", err)
+		}
+		return nil
+	})
+}
+
+// withEvent runs fn and records a queue lifecycle event for the item in one transaction.
+// The events are about the queue item, which has no order yet when it is queued or fails,
+// so they have an aggregate type of their own rather than passing the item off as an order.
+func (r *orderQueueRepository) withEvent(ctx context.Context, itemID string, eventType string, payload any, fn func(tx *sql.Tx) error) error {
+	itemUUID, err := uuid.Parse(itemID)
+	if err != nil {
+		return fmt.Errorf("invalid queue item ID: %w", err)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("failed to insert into order queue: %w", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
 	}
 
+	if err := writeOutboxEvent(ctx, sqlc.New(tx), "order_queue_item", itemUUID, eventType, payload); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit queue item: %w", err)
+	}
 	return nil
 }
 
 func (r *orderQueueRepository) GetPendingItems(ctx context.Context, batchSize int) ([]*models.OrderQueueItem, error) {
 	query := `
-		SELECT id, order_req, status, created_at, updated_at, error, order_data, retry_count
+		SELECT id, order_req, status, created_at, updated_at, error, error_code, order_data, retry_count, next_attempt_at, claimed_by, claimed_at
 		FROM order_queue
-		WHERE status = 'pending' 
-		OR (status = 'failed' AND retry_count < 3)
-		ORDER BY created_at ASC
+		WHERE (status = 'pending' OR (status = 'failed' AND retry_count < 3))
+		AND next_attempt_at <= NOW()
+		ORDER BY next_attempt_at ASC
 		LIMIT $1
 		FOR UPDATE SKIP LOCKED
 	`
@@ -68,40 +135,10 @@ func (r *orderQueueRepository) GetPendingItems(ctx context.Context, batchSize in
 
 	var items []*models.OrderQueueItem
 	for rows.Next() {
-		item := &models.OrderQueueItem{}
-		var orderReqJSON []byte
-		var orderData []byte
-		var error sql.NullString
-
-		err := rows.Scan(
-			&item.ID,
-			&orderReqJSON,
-			&item.Status,
-			&item.CreatedAt,
-			&item.UpdatedAt,
-			&error,
-			&orderData,
-			&item.RetryCount,
-		)
+		item, err := scanQueueItem(rows)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan queue item: %w", err)
+			return nil, err
 		}
-
-		if err := json.Unmarshal(orderReqJSON, &item.OrderReq); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal order request: %w", err)
-		}
-
-		if error.Valid {
-			item.Error = error.String
-		}
-
-		if len(orderData) > 0 {
-			var order models.Order
-			if err := json.Unmarshal(orderData, &order); err == nil {
-				item.Order = &order
-			}
-		}
-
 		items = append(items, item)
 	}
 
@@ -112,6 +149,8 @@ func (r *orderQueueRepository) GetPendingItems(ctx context.Context, batchSize in
 	return items, nil
 }
 
+// UpdateItem saves the item. Moving it to failed records an order.failed event for every
+// failed attempt; the payload's retryCount tells whether it will be retried.
 func (r *orderQueueRepository) UpdateItem(ctx context.Context, item *models.OrderQueueItem) error {
 	orderDataJSON := []byte("{}")
 	if item.Order != nil {
@@ -124,16 +163,24 @@ func (r *orderQueueRepository) UpdateItem(ctx context.Context, item *models.Orde
 
 	query := `
 		UPDATE order_queue 
-		SET status = $2, updated_at = $3, error = $4, order_data = $5, retry_count = $6
+		SET status = $2, updated_at = $3, error = $4, error_code = $5, order_data = $6, retry_count = $7, next_attempt_at = $8
 		WHERE id = $1
 	`
 
-	_, err := r.db.ExecContext(ctx, query, item.ID, item.Status, item.UpdatedAt, item.Error, orderDataJSON, item.RetryCount)
-	if err != nil {
-		return fmt.Errorf("failed to update queue item: %w", err)
+	update := func(db sqlc.DBTX) error {
+		_, err := db.ExecContext(ctx, query, item.ID, item.Status, item.UpdatedAt, item.Error, item.ErrorCode, orderDataJSON, item.RetryCount, item.NextAttemptAt)
+		if err != nil {
+			return fmt.Errorf("failed to update queue item: %w", err)
+		}
+		return nil
 	}
 
-	return nil
+	if item.Status != "failed" {
+		return update(r.db)
+	}
+	return r.withEvent(ctx, item.ID, models.EventOrderFailed, item, func(tx *sql.Tx) error {
+		return update(tx)
+	})
 }
 
 func (r *orderQueueRepository) MarkAsProcessing(ctx context.Context, itemID string) error {
@@ -142,6 +189,15 @@ func (r *orderQueueRepository) MarkAsProcessing(ctx context.Context, itemID stri
 	return err
 }
 
+func (r *orderQueueRepository) Claim(ctx context.Context, itemID, instance string) error {
+	query := `UPDATE order_queue SET claimed_by = $1, claimed_at = NOW() WHERE id = $2`
+	if _, err := r.db.ExecContext(ctx, query, instance, itemID); err != nil {
+		return fmt.Errorf("failed to claim queue item: %w", err)
+	}
+	return nil
+}
+
+// MarkAsCompleted stores the resulting order and records an order.completed event
 func (r *orderQueueRepository) MarkAsCompleted(ctx context.Context, itemID string, order *models.Order) error {
 	orderJSON, err := json.Marshal(order)
 	if err != nil {
@@ -150,27 +206,118 @@ func (r *orderQueueRepository) MarkAsCompleted(ctx context.Context, itemID strin
 
 	query := `
 		UPDATE order_queue 
-		SET status = 'completed', updated_at = $1, order_data = $2, error = NULL
+		SET status = 'completed', updated_at = $1, order_data = $2, error = NULL, error_code = ''
 		WHERE id = $3
 	`
-	_, err = r.db.ExecContext(ctx, query, time.Now(), orderJSON, itemID)
-	return err
+
+	payload := map[string]any{"queueItemId": itemID, "order": order}
+	return r.withEvent(ctx, itemID, models.EventOrderCompleted, payload, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, query, time.Now(), orderJSON, itemID)
+		return err
+	})
 }
 
 func (r *orderQueueRepository) MarkAsFailed(ctx context.Context, itemID string, errorMsg string) error {
 	query := `
 		UPDATE order_queue 
-		SET status = 'failed', updated_at = $1, error = $2, retry_count = retry_count + 1
+		SET status = 'failed', updated_at = $1, error = $2, error_code = 'order_failed', retry_count = retry_count + 1
 		WHERE id = $3
 	`
-	_, err := r.db.ExecContext(ctx, query, time.Now(), errorMsg, itemID)
-	return err
+
+	payload := map[string]any{"queueItemId": itemID, "error": errorMsg}
+	return r.withEvent(ctx, itemID, models.EventOrderFailed, payload, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, query, time.Now(), errorMsg, itemID)
+		return err
+	})
+}
+
+func (r *orderQueueRepository) Requeue(ctx context.Context, itemID string) error {
+	query := `
+		UPDATE order_queue
+		SET status = 'pending', updated_at = NOW(), error = NULL, error_code = '', retry_count = 0, next_attempt_at = NOW()
+		WHERE id = $1 AND status = 'failed'
+	`
+
+	payload := map[string]any{"queueItemId": itemID, "requeued": true}
+	return r.withEvent(ctx, itemID, models.EventOrderQueued, payload, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, query, itemID)
+		if err != nil {
+			return fmt.Errorf("failed to requeue item: %w", err)
+		}
+		if n, err := result.RowsAffected(); err != nil {
+			return fmt.Errorf("failed to requeue item: %w", err)
+		} else if n == 0 {
+			return fmt.Errorf("failed order %s not found", itemID)
+		}
+		return nil
+	})
+}
+
+func (r *orderQueueRepository) GetFailures(ctx context.Context, itemID string) ([]models.QueueFailure, error) {
+	query := `
+		SELECT payload, created_at
+		FROM outbox_events
+		WHERE aggregate_type = 'order_queue_item' AND aggregate_id = $1 AND event_type = $2
+		ORDER BY created_at, id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, itemID, models.EventOrderFailed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get queue item failures: %w", err)
+	}
+	defer rows.Close()
+
+	var failures []models.QueueFailure
+	for rows.Next() {
+		var payload []byte
+		failure := models.QueueFailure{Attempt: len(failures) + 1}
+		if err := rows.Scan(&payload, &failure.At); err != nil {
+			return nil, fmt.Errorf("failed to scan queue item failure: %w", err)
+		}
+		// The events carry the failed item, or only its error when marked failed directly
+		var event struct {
+			Error     string `json:"error"`
+			ErrorCode string `json:"errorCode"`
+		}
+		if err := json.Unmarshal(payload, &event); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal queue item failure: %w", err)
+		}
+		failure.Error = event.Error
+		failure.ErrorCode = event.ErrorCode
+		failures = append(failures, failure)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get queue item failures: %w", err)
+	}
+	return failures, nil
+}
+
+func (r *orderQueueRepository) SetPaymentIntent(ctx context.Context, itemID, intentID string) error {
+	query := `
+		UPDATE order_queue
+		SET order_req = jsonb_set(order_req, '{paymentIntentId}', to_jsonb($2::text)), updated_at = NOW(),
+			next_attempt_at = GREATEST(NOW(), COALESCE((order_req->>'scheduledFor')::timestamptz, NOW()))
+		WHERE id = $1 AND (status = 'pending' OR (status = 'failed' AND retry_count < 3))
+		AND COALESCE(order_data->>'id', '') = ''
+	`
+
+	result, err := r.db.ExecContext(ctx, query, itemID, intentID)
+	if err != nil {
+		return fmt.Errorf("failed to set payment intent: %w", err)
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to set payment intent: %w", err)
+	} else if n == 0 {
+		return fmt.Errorf("order awaiting payment not found")
+	}
+	return nil
 }
 
 func (r *orderQueueRepository) GetQueueStats(ctx context.Context) (map[string]int, error) {
 	query := `
 		SELECT status, COUNT(*) 
 		FROM order_queue 
+		WHERE NOT sandbox
 		GROUP BY status
 	`
 
@@ -193,9 +340,26 @@ func (r *orderQueueRepository) GetQueueStats(ctx context.Context) (map[string]in
 	return stats, nil
 }
 
+func (r *orderQueueRepository) GetBacklog(ctx context.Context) (*models.QueueBacklog, error) {
+	query := `
+		SELECT COUNT(*), MIN(next_attempt_at)
+		FROM order_queue
+		WHERE status = 'pending' AND NOT sandbox
+	`
+
+	var backlog models.QueueBacklog
+	var oldestDueAt sql.NullTime
+	if err := r.db.QueryRowContext(ctx, query).Scan(&backlog.Pending, &oldestDueAt); err != nil {
+		return nil, fmt.Errorf("failed to get queue backlog: %w", err)
+	}
+	backlog.OldestDueAt = nullTimeToPtr(oldestDueAt)
+
+	return &backlog, nil
+}
+
 func (r *orderQueueRepository) GetOrderFromQueue(ctx context.Context, itemID string) (*models.OrderQueueItem, error) {
 	query := `
-		SELECT id, order_req, status, created_at, updated_at, error, order_data, retry_count
+		SELECT id, order_req, status, created_at, updated_at, error, error_code, order_data, retry_count, next_attempt_at, claimed_by, claimed_at
 		FROM order_queue
 		WHERE id = $1
 	`
@@ -204,6 +368,7 @@ func (r *orderQueueRepository) GetOrderFromQueue(ctx context.Context, itemID str
 	var orderReqJSON []byte
 	var orderData []byte
 	var error sql.NullString
+	var claimedAt sql.NullTime
 
 	err := r.db.QueryRowContext(ctx, query, itemID).Scan(
 		&item.ID,
@@ -212,8 +377,12 @@ func (r *orderQueueRepository) GetOrderFromQueue(ctx context.Context, itemID str
 		&item.CreatedAt,
 		&item.UpdatedAt,
 		&error,
+		&item.ErrorCode,
 		&orderData,
 		&item.RetryCount,
+		&item.NextAttemptAt,
+		&item.ClaimedBy,
+		&claimedAt,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -229,6 +398,7 @@ func (r *orderQueueRepository) GetOrderFromQueue(ctx context.Context, itemID str
 	if error.Valid {
 		item.Error = error.String
 	}
+	item.ClaimedAt = nullTimeToPtr(claimedAt)
 
 	if len(orderData) > 0 {
 		var order models.Order
@@ -242,12 +412,17 @@ func (r *orderQueueRepository) GetOrderFromQueue(ctx context.Context, itemID str
 
 func (r *orderQueueRepository) GetAllOrders(ctx context.Context) ([]*models.OrderQueueItem, error) {
 	query := `
-		SELECT id, order_req, status, created_at, updated_at, error, order_data, retry_count
+		SELECT id, order_req, status, created_at, updated_at, error, error_code, order_data, retry_count, next_attempt_at, claimed_by, claimed_at
 		FROM order_queue
 		ORDER BY created_at DESC
 	`
 
-	rows, err := r.db.QueryContext(ctx, query)
+	db := r.db
+	if r.reader != nil {
+		db = r.reader.Reader()
+	}
+
+	rows, err := db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get all orders: %w", err)
 	}
@@ -255,42 +430,193 @@ func (r *orderQueueRepository) GetAllOrders(ctx context.Context) ([]*models.Orde
 
 	var orders []*models.OrderQueueItem
 	for rows.Next() {
-		var item models.OrderQueueItem
-		var orderReqJSON []byte
-		var orderData []byte
-		var error sql.NullString
-
-		err := rows.Scan(
-			&item.ID,
-			&orderReqJSON,
-			&item.Status,
-			&item.CreatedAt,
-			&item.UpdatedAt,
-			&error,
-			&orderData,
-			&item.RetryCount,
-		)
+		item, err := scanQueueItem(rows)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan order: %w", err)
+			return nil, err
 		}
+		orders = append(orders, item)
+	}
+
+	return orders, nil
+}
+
+func (r *orderQueueRepository) FindPage(ctx context.Context, page models.PageRequest) ([]*models.OrderQueueItem, int, error) {
+	page = page.Normalize()
+
+	db := r.db
+	if r.reader != nil {
+		db = r.reader.Reader()
+	}
+
+	var total int
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM order_queue`).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count queue items: %w", err)
+	}
+
+	query := `
+		SELECT id, order_req, status, created_at, updated_at, error, error_code, order_data, retry_count, next_attempt_at, claimed_by, claimed_at
+		FROM order_queue
+		ORDER BY created_at DESC
+		LIMIT $1 OFFSET $2
+	`
 
-		if err := json.Unmarshal(orderReqJSON, &item.OrderReq); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal order request: %w", err)
+	rows, err := db.QueryContext(ctx, query, page.Limit, page.Offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query queue items: %w", err)
+	}
+	defer rows.Close()
+
+	items := make([]*models.OrderQueueItem, 0, page.Limit)
+	for rows.Next() {
+		item, err := scanQueueItem(rows)
+		if err != nil {
+			return nil, 0, err
 		}
+		items = append(items, item)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating queue items: %w", err)
+	}
+
+	return items, total, nil
+}
+
+func (r *orderQueueRepository) FindScheduled(ctx context.Context, storeID string, from, until time.Time) ([]*models.OrderQueueItem, error) {
+	db := r.db
+	if r.reader != nil {
+		db = r.reader.Reader()
+	}
+
+	// Orders queued before stores existed have no storeId and belong to the default store
+	query := `
+		SELECT id, order_req, status, created_at, updated_at, error, error_code, order_data, retry_count, next_attempt_at, claimed_by, claimed_at
+		FROM order_queue
+		WHERE order_req ? 'scheduledFor' AND status <> 'failed'
+		AND (order_req->>'scheduledFor')::timestamptz >= $1 AND (order_req->>'scheduledFor')::timestamptz < $2
+		AND ($3 = '' OR COALESCE(NULLIF(order_req->>'storeId', ''), $4) = $3)
+		ORDER BY (order_req->>'scheduledFor')::timestamptz, id
+	`
+
+	rows, err := db.QueryContext(ctx, query, from, until, storeID, models.DefaultStoreID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query scheduled queue items: %w", err)
+	}
+	defer rows.Close()
 
-		if error.Valid {
-			item.Error = error.String
+	var items []*models.OrderQueueItem
+	for rows.Next() {
+		item, err := scanQueueItem(rows)
+		if err != nil {
+			return nil, err
 		}
+		items = append(items, item)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating scheduled queue items: %w", err)
+	}
+
+	return items, nil
+}
 
-		if len(orderData) > 0 {
-			var order models.Order
-			if err := json.Unmarshal(orderData, &order); err == nil {
-				item.Order = &order
-			}
+// FindByTab reads the primary: closing a tab checks none of its orders are still queued
+func (r *orderQueueRepository) FindByTab(ctx context.Context, tabID string) ([]*models.OrderQueueItem, error) {
+	query := `
+		SELECT id, order_req, status, created_at, updated_at, error, error_code, order_data, retry_count, next_attempt_at, claimed_by, claimed_at
+		FROM order_queue
+		WHERE order_req ? 'tabId' AND order_req->>'tabId' = $1
+		ORDER BY created_at, id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, tabID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tab queue items: %w", err)
+	}
+	defer rows.Close()
+
+	var items []*models.OrderQueueItem
+	for rows.Next() {
+		item, err := scanQueueItem(rows)
+		if err != nil {
+			return nil, err
 		}
+		items = append(items, item)
+	}
 
-		orders = append(orders, &item)
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating tab queue items: %w", err)
 	}
 
-	return orders, nil
+	return items, nil
+}
+
+func (r *orderQueueRepository) Anonymize(ctx context.Context, id string) (int, error) {
+	itemUUID, err := uuid.Parse(id)
+	if err != nil {
+		return 0, fmt.Errorf("invalid order ID: %w", err)
+	}
+
+	query := `
+		UPDATE order_queue
+		SET order_req = '{}', order_data = NULL, error = NULL, error_code = '', updated_at = NOW()
+		WHERE id = $1 OR order_data->>'id' = $2
+	`
+
+	result, err := r.db.ExecContext(ctx, query, itemUUID, id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to anonymize queue items: %w", err)
+	}
+
+	anonymized, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to anonymize queue items: %w", err)
+	}
+	return int(anonymized), nil
+}
+
+// scanQueueItem reads one row selected as id, order_req, status, created_at, updated_at,
+// error, error_code, order_data, retry_count, next_attempt_at, claimed_by, claimed_at, claimed_by, claimed_at
+func scanQueueItem(rows *sql.Rows) (*models.OrderQueueItem, error) {
+	item := &models.OrderQueueItem{}
+	var orderReqJSON []byte
+	var orderData []byte
+	var errorMsg sql.NullString
+	var claimedAt sql.NullTime
+
+	err := rows.Scan(
+		&item.ID,
+		&orderReqJSON,
+		&item.Status,
+		&item.CreatedAt,
+		&item.UpdatedAt,
+		&errorMsg,
+		&item.ErrorCode,
+		&orderData,
+		&item.RetryCount,
+		&item.NextAttemptAt,
+		&item.ClaimedBy,
+		&claimedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan queue item: %w", err)
+	}
+
+	if err := json.Unmarshal(orderReqJSON, &item.OrderReq); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal order request: %w", err)
+	}
+
+	if errorMsg.Valid {
+		item.Error = errorMsg.String
+	}
+	item.ClaimedAt = nullTimeToPtr(claimedAt)
+
+	if len(orderData) > 0 {
+		var order models.Order
+		if err := json.Unmarshal(orderData, &order); err == nil {
+			item.Order = &order
+		}
+	}
+
+	return item, nil
 }
@@ -3,7 +3,9 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"time"
 
 	"oolio/internal/app/models"
 	"oolio/internal/database/sqlc"
@@ -12,30 +14,90 @@ import (
 )
 
 type orderRepository struct {
-	db  *sql.DB
-	qtx *sqlc.Queries
+	db     *sql.DB
+	qtx    *sqlc.Queries
+	reader ReadRouter
 }
 
-func NewOrderRepository(db *sql.DB) OrderRepository {
+// NewOrderRepository writes to db. Reads go through reader when set, otherwise db.
+func NewOrderRepository(db *sql.DB, reader ReadRouter) OrderRepository {
 	return &orderRepository{
-		db:  db,
-		qtx: sqlc.New(db),
+		db:     db,
+		qtx:    sqlc.New(db),
+		reader: reader,
 	}
 }
 
+func (r *orderRepository) readQueries() *sqlc.Queries {
+	if r.reader == nil {
+		return r.qtx
+	}
+	return sqlc.New(r.reader.Reader())
+}
+
+// Find returns every order with its items and products, newest first
 func (r *orderRepository) Find(ctx context.Context) ([]models.Order, error) {
-	// This would need to be implemented with a new query in SQLC
-	// For now, returning empty slice
-	return []models.Order{}, nil
+	summaries, err := r.readQueries().GetOrderSummaries(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get orders: %w", err)
+	}
+
+	return mapSummariesToModels(summaries)
+}
+
+func (r *orderRepository) FindPage(ctx context.Context, page models.PageRequest) ([]models.Order, int, error) {
+	page = page.Normalize()
+	queries := r.readQueries()
+
+	var storeID uuid.NullUUID
+	if page.StoreID != "" {
+		parsed, err := uuid.Parse(page.StoreID)
+		if err != nil {
+			return nil, 0, fmt.Errorf("invalid store ID: %w", err)
+		}
+		storeID = uuid.NullUUID{UUID: parsed, Valid: true}
+	}
+
+	total, err := queries.CountOrders(ctx, storeID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count orders: %w", err)
+	}
+
+	summaries, err := queries.GetOrderSummariesPage(ctx, sqlc.GetOrderSummariesPageParams{
+		Limit:   int32(page.Limit),
+		Offset:  int32(page.Offset),
+		StoreID: storeID,
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get orders: %w", err)
+	}
+
+	orders, err := mapSummariesToModels(summaries)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return orders, int(total), nil
+}
+
+func (r *orderRepository) FindUpdatedSince(ctx context.Context, since time.Time) ([]models.Order, error) {
+	summaries, err := r.readQueries().GetOrderSummariesUpdatedSince(ctx, sql.NullTime{Time: since, Valid: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get updated orders: %w", err)
+	}
+
+	return mapSummariesToModels(summaries)
 }
 
+// FindOne reads the primary: refunds, deletes and captures load an order before changing it,
+// and a lagging replica would have them act on a stale copy
 func (r *orderRepository) FindOne(ctx context.Context, id string) (*models.Order, error) {
 	orderUUID, err := uuid.Parse(id)
 	if err != nil {
 		return nil, fmt.Errorf("invalid order ID: %w", err)
 	}
 
-	dbOrder, err := r.qtx.GetOrderByID(ctx, orderUUID)
+	summary, err := r.qtx.GetOrderSummaryByID(ctx, orderUUID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("order not found")
@@ -43,39 +105,77 @@ func (r *orderRepository) FindOne(ctx context.Context, id string) (*models.Order
 		return nil, fmt.Errorf("failed to get order: %w", err)
 	}
 
-	// Get order items
-	orderItems, err := r.qtx.GetOrderItemsByOrderID(ctx, uuid.NullUUID{UUID: orderUUID, Valid: true})
+	order, err := mapSummaryToModel(summary)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get order items: %w", err)
+		return nil, err
 	}
-
-	order := r.mapSQLCToModel(dbOrder, orderItems)
 	return &order, nil
 }
 
 func (r *orderRepository) Create(ctx context.Context, order *models.Order) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	qtx := r.qtx.WithTx(tx)
+
+	storeID, err := uuid.Parse(models.StoreOrDefault(order.StoreID))
+	if err != nil {
+		return fmt.Errorf("invalid store ID: %w", err)
+	}
+
 	params := sqlc.CreateOrderParams{
-		Total:     fmt.Sprintf("%.2f", order.Total),
-		Discounts: stringToNullString(fmt.Sprintf("%.2f", order.Discounts)),
-		Status:    stringToNullString("pending"),
+		Total:           order.Total,
+		Discounts:       order.Discounts,
+		Status:          stringToNullString("pending"),
+		CustomerID:      stringToNullString(order.CustomerID),
+		PaymentIntentID: stringToNullString(order.PaymentIntentID),
+		PaymentStatus:   stringToNullString(order.PaymentStatus),
+		PaymentMethod:   order.PaymentMethod,
+		StoreCredit:     order.StoreCredit,
+		GiftCardCode:    order.GiftCardCode,
+		CouponCode:      order.CouponCode,
+		StoreID:         storeID,
+		Sandbox:         order.Sandbox,
 	}
 
-	dbOrder, err := r.qtx.CreateOrder(ctx, params)
+	dbOrder, err := qtx.CreateOrder(ctx, params)
 	if err != nil {
 		return fmt.Errorf("failed to create order: %w", err)
 	}
 
-	// Update the order with the generated ID
-	order.ID = dbOrder.ID.String()
-
 	// Create order items
 	if len(order.Items) > 0 {
-		err = r.CreateOrderItems(ctx, order.ID, order.Items)
+		err = r.createOrderItems(ctx, qtx, dbOrder.ID, order.Items)
 		if err != nil {
 			return fmt.Errorf("failed to create order items: %w", err)
 		}
 	}
 
+	created := *order
+	created.ID = dbOrder.ID.String()
+	created.StoreID = dbOrder.StoreID.String()
+	created.Status = nullStringToString(dbOrder.Status)
+	created.Version = int(dbOrder.Version)
+	created.CreatedAt = dbOrder.CreatedAt.Time
+	created.UpdatedAt = dbOrder.UpdatedAt.Time
+	if err := writeOutboxEvent(ctx, qtx, "order", dbOrder.ID, models.EventOrderCreated, created); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit order: %w", err)
+	}
+
+	// Update the order with the generated ID
+	order.ID = created.ID
+	order.StoreID = created.StoreID
+	order.Status = created.Status
+	order.Version = created.Version
+	order.CreatedAt = created.CreatedAt
+	order.UpdatedAt = created.UpdatedAt
 	return nil
 }
 
@@ -85,21 +185,339 @@ func (r *orderRepository) Update(ctx context.Context, order *models.Order) error
 		return fmt.Errorf("invalid order ID: %w", err)
 	}
 
-	_, err = r.qtx.UpdateOrderStatus(ctx, sqlc.UpdateOrderStatusParams{
-		ID:     orderUUID,
-		Status: stringToNullString("completed"),
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	qtx := r.qtx.WithTx(tx)
+
+	dbOrder, err := qtx.UpdateOrderStatus(ctx, sqlc.UpdateOrderStatusParams{
+		ID:      orderUUID,
+		Status:  stringToNullString("completed"),
+		Version: int32(order.Version),
 	})
 	if err != nil {
+		if err == sql.ErrNoRows {
+			// Either the order is gone or a concurrent transition bumped its version
+			if _, err := qtx.GetOrderByID(ctx, orderUUID); err == nil {
+				return &ConflictError{Entity: "order", ID: order.ID, Version: order.Version}
+			}
+			return fmt.Errorf("order not found")
+		}
 		return fmt.Errorf("failed to update order: %w", err)
 	}
 
+	updated := *order
+	updated.Version = int(dbOrder.Version)
+	updated.Status = nullStringToString(dbOrder.Status)
+	updated.UpdatedAt = dbOrder.UpdatedAt.Time
+	if err := writeOutboxEvent(ctx, qtx, "order", orderUUID, models.EventOrderCompleted, updated); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit order: %w", err)
+	}
+
+	order.Version = updated.Version
+	order.UpdatedAt = updated.UpdatedAt
+	order.Status = updated.Status
 	return nil
 }
 
+// Delete removes the order and its items. Which orders may be deleted is up to the caller.
 func (r *orderRepository) Delete(ctx context.Context, id string) error {
-	// Order deletion would need to be implemented with proper cascade handling
-	// For now, not implemented as per business requirements
-	return fmt.Errorf("order deletion not implemented")
+	orderUUID, err := uuid.Parse(id)
+	if err != nil {
+		return fmt.Errorf("invalid order ID: %w", err)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	qtx := r.qtx.WithTx(tx)
+
+	deleted, err := qtx.DeleteOrder(ctx, orderUUID)
+	if err != nil {
+		return fmt.Errorf("failed to delete order: %w", err)
+	}
+	if deleted == 0 {
+		return fmt.Errorf("order not found")
+	}
+
+	if err := writeOutboxEvent(ctx, qtx, "order", orderUUID, models.EventOrderDeleted, map[string]string{"id": id}); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit order deletion: %w", err)
+	}
+
+	return nil
+}
+
+func (r *orderRepository) SetPaymentStatus(ctx context.Context, orderID string, status string) error {
+	orderUUID, err := uuid.Parse(orderID)
+	if err != nil {
+		return fmt.Errorf("invalid order ID: %w", err)
+	}
+
+	updated, err := r.qtx.UpdateOrderPaymentStatus(ctx, sqlc.UpdateOrderPaymentStatusParams{
+		ID:            orderUUID,
+		PaymentStatus: stringToNullString(status),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update order payment status: %w", err)
+	}
+	if updated == 0 {
+		return fmt.Errorf("order not found")
+	}
+	return nil
+}
+
+// FindByPaymentIntent reads the primary, so a payment webhook arriving right after the
+// order was created finds it
+func (r *orderRepository) FindByPaymentIntent(ctx context.Context, intentID string) (*models.Order, error) {
+	summary, err := r.qtx.GetOrderSummaryByPaymentIntent(ctx, stringToNullString(intentID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("order not found")
+		}
+		return nil, fmt.Errorf("failed to get order: %w", err)
+	}
+
+	order, err := mapSummaryToModel(summary)
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+func (r *orderRepository) CreateRefund(ctx context.Context, refund *models.OrderRefund) error {
+	orderUUID, err := uuid.Parse(refund.OrderID)
+	if err != nil {
+		return fmt.Errorf("invalid order ID: %w", err)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	qtx := r.qtx.WithTx(tx)
+
+	dbRefund, err := qtx.CreateOrderRefund(ctx, sqlc.CreateOrderRefundParams{
+		OrderID:         orderUUID,
+		PaymentIntentID: refund.PaymentIntentID,
+		Amount:          refund.Amount,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create refund: %w", err)
+	}
+
+	created := mapSQLCToOrderRefund(dbRefund)
+	if err := writeOutboxEvent(ctx, qtx, "order", orderUUID, models.EventOrderRefundRequested, created); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit refund: %w", err)
+	}
+
+	*refund = created
+	return nil
+}
+
+func (r *orderRepository) UpdateRefund(ctx context.Context, refund *models.OrderRefund) error {
+	refundUUID, err := uuid.Parse(refund.ID)
+	if err != nil {
+		return fmt.Errorf("invalid refund ID: %w", err)
+	}
+
+	eventType := models.EventOrderRefunded
+	if refund.Status == models.RefundStatusFailed {
+		eventType = models.EventOrderRefundFailed
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	qtx := r.qtx.WithTx(tx)
+
+	dbRefund, err := qtx.UpdateOrderRefund(ctx, sqlc.UpdateOrderRefundParams{
+		ID:               refundUUID,
+		Status:           refund.Status,
+		ProviderRefundID: refund.ProviderRefundID,
+		Error:            refund.Error,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("refund not found")
+		}
+		return fmt.Errorf("failed to update refund: %w", err)
+	}
+
+	updated := mapSQLCToOrderRefund(dbRefund)
+	if err := writeOutboxEvent(ctx, qtx, "order", dbRefund.OrderID, eventType, updated); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit refund: %w", err)
+	}
+
+	*refund = updated
+	return nil
+}
+
+func (r *orderRepository) GetRefunds(ctx context.Context, orderID string) ([]models.OrderRefund, error) {
+	orderUUID, err := uuid.Parse(orderID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid order ID: %w", err)
+	}
+
+	// From the primary, since refunding checks the refunded total before adding to it
+	dbRefunds, err := r.qtx.GetOrderRefunds(ctx, orderUUID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get refunds: %w", err)
+	}
+
+	refunds := make([]models.OrderRefund, len(dbRefunds))
+	for i, dbRefund := range dbRefunds {
+		refunds[i] = mapSQLCToOrderRefund(dbRefund)
+	}
+	return refunds, nil
+}
+
+func mapSQLCToOrderRefund(dbRefund sqlc.OrderRefund) models.OrderRefund {
+	return models.OrderRefund{
+		ID:               dbRefund.ID.String(),
+		OrderID:          dbRefund.OrderID.String(),
+		PaymentIntentID:  dbRefund.PaymentIntentID,
+		Amount:           dbRefund.Amount,
+		Status:           dbRefund.Status,
+		ProviderRefundID: dbRefund.ProviderRefundID,
+		Error:            dbRefund.Error,
+		CreatedAt:        dbRefund.CreatedAt,
+		UpdatedAt:        dbRefund.UpdatedAt,
+	}
+}
+
+func (r *orderRepository) PaymentMethodTotals(ctx context.Context, since, until time.Time) ([]models.PaymentMethodTotal, error) {
+	rows, err := r.readQueries().GetPaymentMethodTotals(ctx, sqlc.GetPaymentMethodTotalsParams{Since: since, Until: until})
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum orders by payment method: %w", err)
+	}
+
+	totals := make([]models.PaymentMethodTotal, len(rows))
+	for i, row := range rows {
+		totals[i] = models.PaymentMethodTotal{
+			Method: row.PaymentMethod,
+			Orders: int(row.Orders),
+			Paid:   row.Paid,
+		}
+	}
+	return totals, nil
+}
+
+func (r *orderRepository) TopProducts(ctx context.Context, since, until time.Time, limit int) ([]models.ProductSales, error) {
+	rows, err := r.readQueries().GetTopProducts(ctx, sqlc.GetTopProductsParams{Since: since, Until: until, MaxProducts: int32(limit)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get top products: %w", err)
+	}
+
+	products := make([]models.ProductSales, len(rows))
+	for i, row := range rows {
+		products[i] = models.ProductSales{
+			ProductID: row.ID.String(),
+			Name:      row.Name,
+			Quantity:  int(row.Quantity),
+			Revenue:   row.Revenue,
+		}
+	}
+	return products, nil
+}
+
+func (r *orderRepository) CouponUsage(ctx context.Context, since, until time.Time) ([]models.CouponUsage, error) {
+	rows, err := r.readQueries().GetCouponUsage(ctx, sqlc.GetCouponUsageParams{Since: since, Until: until})
+	if err != nil {
+		return nil, fmt.Errorf("failed to count coupon usage: %w", err)
+	}
+
+	usage := make([]models.CouponUsage, len(rows))
+	for i, row := range rows {
+		usage[i] = models.CouponUsage{
+			Code:      row.CouponCode,
+			Orders:    int(row.Orders),
+			Discounts: row.Discounts,
+		}
+	}
+	return usage, nil
+}
+
+func (r *orderRepository) Sales(ctx context.Context, query models.SalesQuery) ([]models.SalesGroup, error) {
+	var storeID uuid.NullUUID
+	if query.StoreID != "" {
+		parsed, err := uuid.Parse(query.StoreID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid store ID: %w", err)
+		}
+		storeID = uuid.NullUUID{UUID: parsed, Valid: true}
+	}
+	since, until := query.Since.UTC(), query.Until.UTC()
+	queries := r.readQueries()
+
+	var groups []models.SalesGroup
+	switch query.GroupBy {
+	case models.SalesByDay:
+		rows, err := queries.GetSalesByDay(ctx, sqlc.GetSalesByDayParams{TimeZone: query.Location.String(), Since: since, Until: until, StoreID: storeID})
+		if err != nil {
+			return nil, fmt.Errorf("failed to sum sales by day: %w", err)
+		}
+		for _, row := range rows {
+			groups = append(groups, models.SalesGroup{
+				Key:          row.Day,
+				SalesFigures: models.SalesFigures{Orders: int(row.Orders), Revenue: row.Revenue, Discounts: row.Discounts},
+			})
+		}
+	case models.SalesByProduct:
+		rows, err := queries.GetSalesByProduct(ctx, sqlc.GetSalesByProductParams{Since: since, Until: until, StoreID: storeID})
+		if err != nil {
+			return nil, fmt.Errorf("failed to sum sales by product: %w", err)
+		}
+		for _, row := range rows {
+			groups = append(groups, models.SalesGroup{
+				Key:          row.ID.String(),
+				Name:         row.Name,
+				Quantity:     int(row.Quantity),
+				SalesFigures: models.SalesFigures{Orders: int(row.Orders), Revenue: row.Revenue, Discounts: row.Discounts},
+			})
+		}
+	case models.SalesByCategory:
+		rows, err := queries.GetSalesByCategory(ctx, sqlc.GetSalesByCategoryParams{Since: since, Until: until, StoreID: storeID})
+		if err != nil {
+			return nil, fmt.Errorf("failed to sum sales by category: %w", err)
+		}
+		for _, row := range rows {
+			groups = append(groups, models.SalesGroup{
+				Key:          row.Category,
+				Quantity:     int(row.Quantity),
+				SalesFigures: models.SalesFigures{Orders: int(row.Orders), Revenue: row.Revenue, Discounts: row.Discounts},
+			})
+		}
+	default:
+		return nil, fmt.Errorf("unknown sales grouping %q", query.GroupBy)
+	}
+	return groups, nil
 }
 
 func (r *orderRepository) CreateOrderItems(ctx context.Context, orderID string, items []models.OrderItem) error {
@@ -108,25 +526,37 @@ func (r *orderRepository) CreateOrderItems(ctx context.Context, orderID string,
 		return fmt.Errorf("invalid order ID: %w", err)
 	}
 
-	for _, item := range items {
+	return r.createOrderItems(ctx, r.qtx, orderUUID, items)
+}
+
+// createOrderItems inserts all items with a single multi-row INSERT
+func (r *orderRepository) createOrderItems(ctx context.Context, q *sqlc.Queries, orderID uuid.UUID, items []models.OrderItem) error {
+	params := sqlc.CreateOrderItemsBatchParams{
+		OrderID:    orderID,
+		ProductIds: make([]uuid.UUID, len(items)),
+		Quantities: make([]int32, len(items)),
+		Prices:     make([]models.Money, len(items)),
+		Options:    make([]string, len(items)),
+	}
+
+	for i, item := range items {
 		productUUID, err := uuid.Parse(item.ProductID)
 		if err != nil {
 			return fmt.Errorf("invalid product ID: %w", err)
 		}
 
-		// We need to get the current product price at time of order
-		// For now, using a placeholder price - in real implementation this would come from product service
-		params := sqlc.CreateOrderItemsParams{
-			OrderID:     uuid.NullUUID{UUID: orderUUID, Valid: true},
-			ProductID:   uuid.NullUUID{UUID: productUUID, Valid: true},
-			Quantity:    int32(item.Quantity),
-			PriceAtTime: "0.00", // This should be the actual product price at time of order
-		}
-
-		_, err = r.qtx.CreateOrderItems(ctx, params)
+		params.ProductIds[i] = productUUID
+		params.Quantities[i] = int32(item.Quantity)
+		params.Prices[i] = item.Price
+		options, err := json.Marshal(item.Options)
 		if err != nil {
-			return fmt.Errorf("failed to create order item: %w", err)
+			return fmt.Errorf("failed to encode item options: %w", err)
 		}
+		params.Options[i] = string(options)
+	}
+
+	if err := q.CreateOrderItemsBatch(ctx, params); err != nil {
+		return fmt.Errorf("failed to create order items: %w", err)
 	}
 
 	return nil
@@ -152,31 +582,57 @@ func (r *orderRepository) GetOrderItems(ctx context.Context, orderID string) ([]
 		items[i] = models.OrderItem{
 			ProductID: productID,
 			Quantity:  int(dbItem.Quantity),
-			Price:     parseFloat(dbItem.PriceAtTime),
+			Price:     dbItem.PriceAtTime,
+		}
+		if err := json.Unmarshal(dbItem.Options, &items[i].Options); err != nil {
+			return nil, fmt.Errorf("failed to decode item options: %w", err)
 		}
 	}
 
 	return items, nil
 }
 
-func (r *orderRepository) mapSQLCToModel(dbOrder sqlc.Order, dbOrderItems []sqlc.GetOrderItemsByOrderIDRow) models.Order {
-	orderItems := make([]models.OrderItem, len(dbOrderItems))
-	for i, dbItem := range dbOrderItems {
-		productID := ""
-		if dbItem.ProductID.Valid {
-			productID = dbItem.ProductID.UUID.String()
-		}
-		orderItems[i] = models.OrderItem{
-			ProductID: productID,
-			Quantity:  int(dbItem.Quantity),
-			Price:     parseFloat(dbItem.PriceAtTime),
-		}
+// mapSummaryToModel decodes the items and products the order_summaries view aggregates as JSON
+func mapSummaryToModel(summary sqlc.OrderSummary) (models.Order, error) {
+	order := models.Order{
+		ID:         summary.ID.String(),
+		Total:      summary.Total,
+		Discounts:  summary.Discounts,
+		Status:     nullStringToString(summary.Status),
+		CustomerID: nullStringToString(summary.CustomerID),
+		StoreID:    summary.StoreID.String(),
+		Version:    int(summary.Version),
+		CreatedAt:  summary.CreatedAt.Time,
+		UpdatedAt:  summary.UpdatedAt.Time,
+		Sandbox:    summary.Sandbox,
+
+		PaymentMethod:   summary.PaymentMethod,
+		PaymentIntentID: nullStringToString(summary.PaymentIntentID),
+		PaymentStatus:   nullStringToString(summary.PaymentStatus),
+
+		StoreCredit:  summary.StoreCredit,
+		GiftCardCode: summary.GiftCardCode,
+
+		CouponCode: summary.CouponCode,
 	}
 
-	return models.Order{
-		ID:        dbOrder.ID.String(),
-		Total:     parseFloat(dbOrder.Total),
-		Discounts: parseFloat(nullStringToString(dbOrder.Discounts)),
-		Items:     orderItems,
+	if err := json.Unmarshal(summary.Items, &order.Items); err != nil {
+		return models.Order{}, fmt.Errorf("failed to decode items of order %s: %w", order.ID, err)
+	}
+	if err := json.Unmarshal(summary.Products, &order.Products); err != nil {
+		return models.Order{}, fmt.Errorf("failed to decode products of order %s: %w", order.ID, err)
+	}
+	return order, nil
+}
+
+func mapSummariesToModels(summaries []sqlc.OrderSummary) ([]models.Order, error) {
+	orders := make([]models.Order, len(summaries))
+	for i, summary := range summaries {
+		order, err := mapSummaryToModel(summary)
+		if err != nil {
+			return nil, err
+		}
+		orders[i] = order
 	}
+	return orders, nil
 }
@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"oolio/internal/app/models"
+	"oolio/internal/database/sqlc"
+
+	"github.com/google/uuid"
+)
+
+type OutboxRepository interface {
+	// ClaimDue returns up to limit events due for publishing, oldest first, and holds them
+	// back from other relays for lease. Nothing stays locked while they are published; a
+	// relay that dies before recording the outcome leaves them to be published again once
+	// the lease is over, so delivery is at-least-once.
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]models.OutboxEvent, error)
+	MarkPublished(ctx context.Context, id string) error
+	// MarkFailed records a failed attempt and makes the event due again at retryAt
+	MarkFailed(ctx context.Context, id, errorMsg string, retryAt time.Time) error
+	// MarkDead records the last failed attempt; the event is kept but not published again
+	MarkDead(ctx context.Context, id, errorMsg string) error
+	// Append records an event in tx, the transaction making the change the event is about,
+	// so both commit or neither does. A nil tx records an event that goes with no other
+	// change, such as a text being delivered, on its own.
+	Append(ctx context.Context, tx *sql.Tx, aggregateType, aggregateID, eventType string, payload any) error
+}
+
+type outboxRepository struct {
+	db  *sql.DB
+	qtx *sqlc.Queries
+}
+
+func NewOutboxRepository(db *sql.DB) OutboxRepository {
+	return &outboxRepository{db: db, qtx: sqlc.New(db)}
+}
+
+func (r *outboxRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]models.OutboxEvent, error) {
+	dbEvents, err := r.qtx.ClaimDueOutboxEvents(ctx, sqlc.ClaimDueOutboxEventsParams{
+		Limit:         int32(limit),
+		NextAttemptAt: time.Now().Add(lease),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim outbox events: %w", err)
+	}
+
+	events := make([]models.OutboxEvent, 0, len(dbEvents))
+	for _, dbEvent := range dbEvents {
+		events = append(events, mapSQLCToOutboxEvent(dbEvent))
+	}
+	// RETURNING doesn't keep the subquery's order
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].CreatedAt.Before(events[j].CreatedAt)
+	})
+	return events, nil
+}
+
+func (r *outboxRepository) MarkPublished(ctx context.Context, id string) error {
+	eventUUID, err := uuid.Parse(id)
+	if err != nil {
+		return fmt.Errorf("invalid outbox event ID: %w", err)
+	}
+	if err := r.qtx.MarkOutboxEventPublished(ctx, eventUUID); err != nil {
+		return fmt.Errorf("failed to mark outbox event published: %w", err)
+	}
+	return nil
+}
+
+func (r *outboxRepository) MarkFailed(ctx context.Context, id, errorMsg string, retryAt time.Time) error {
+	eventUUID, err := uuid.Parse(id)
+	if err != nil {
+		return fmt.Errorf("invalid outbox event ID: %w", err)
+	}
+	err = r.qtx.MarkOutboxEventFailed(ctx, sqlc.MarkOutboxEventFailedParams{
+		ID:            eventUUID,
+		LastError:     stringToNullString(errorMsg),
+		NextAttemptAt: retryAt,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record outbox event failure: %w", err)
+	}
+	return nil
+}
+
+func (r *outboxRepository) MarkDead(ctx context.Context, id, errorMsg string) error {
+	eventUUID, err := uuid.Parse(id)
+	if err != nil {
+		return fmt.Errorf("invalid outbox event ID: %w", err)
+	}
+	err = r.qtx.MarkOutboxEventDead(ctx, sqlc.MarkOutboxEventDeadParams{
+		ID:        eventUUID,
+		LastError: stringToNullString(errorMsg),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to mark outbox event dead: %w", err)
+	}
+	return nil
+}
+
+func (r *outboxRepository) Append(ctx context.Context, tx *sql.Tx, aggregateType, aggregateID, eventType string, payload any) error {
+	id, err := uuid.Parse(aggregateID)
+	if err != nil {
+		return fmt.Errorf("invalid %s ID: %w", aggregateType, err)
+	}
+	q := r.qtx
+	if tx != nil {
+		q = q.WithTx(tx)
+	}
+	return writeOutboxEvent(ctx, q, aggregateType, id, eventType, payload)
+}
+
+// writeOutboxEvent records an event with q, which must be bound to the transaction making the change
+func writeOutboxEvent(ctx context.Context, q *sqlc.Queries, aggregateType string, aggregateID uuid.UUID, eventType string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s event: %w", eventType, err)
+	}
+
+	err = q.InsertOutboxEvent(ctx, sqlc.InsertOutboxEventParams{
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		EventType:     eventType,
+		Payload:       data,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write %s event: %w", eventType, err)
+	}
+
+	return nil
+}
+
+func mapSQLCToOutboxEvent(dbEvent sqlc.OutboxEvent) models.OutboxEvent {
+	return models.OutboxEvent{
+		ID:            dbEvent.ID.String(),
+		AggregateType: dbEvent.AggregateType,
+		AggregateID:   dbEvent.AggregateID.String(),
+		EventType:     dbEvent.EventType,
+		Payload:       dbEvent.Payload,
+		CreatedAt:     dbEvent.CreatedAt,
+		Attempts:      int(dbEvent.Attempts),
+	}
+}
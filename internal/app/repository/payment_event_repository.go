@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"oolio/internal/database/sqlc"
+)
+
+// PaymentEventRepository remembers the payment provider's webhook events already handled,
+// so redelivered and replayed events are ignored
+type PaymentEventRepository interface {
+	// Record stores the event, failing with "payment event already recorded" when it was
+	// recorded before
+	Record(ctx context.Context, id, eventType string) error
+	// Forget drops a recorded event, e.g. when handling it failed, so the provider's
+	// redelivery is handled
+	Forget(ctx context.Context, id string) error
+}
+
+type paymentEventRepository struct {
+	qtx *sqlc.Queries
+}
+
+func NewPaymentEventRepository(db *sql.DB) PaymentEventRepository {
+	return &paymentEventRepository{qtx: sqlc.New(db)}
+}
+
+func (r *paymentEventRepository) Record(ctx context.Context, id, eventType string) error {
+	inserted, err := r.qtx.InsertPaymentEvent(ctx, sqlc.InsertPaymentEventParams{ID: id, EventType: eventType})
+	if err != nil {
+		return fmt.Errorf("failed to record payment event: %w", err)
+	}
+	if inserted == 0 {
+		return fmt.Errorf("payment event already recorded")
+	}
+	return nil
+}
+
+func (r *paymentEventRepository) Forget(ctx context.Context, id string) error {
+	if _, err := r.qtx.DeletePaymentEvent(ctx, id); err != nil {
+		return fmt.Errorf("failed to forget payment event: %w", err)
+	}
+	return nil
+}
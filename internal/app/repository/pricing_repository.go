@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"oolio/internal/app/models"
+	"oolio/internal/database/sqlc"
+)
+
+// PricingRepository keeps the pricing tiers and who is assigned to them. Retail is not
+// stored: it is the tier of everyone without an assignment.
+type PricingRepository interface {
+	FindTiers(ctx context.Context) ([]models.PricingTier, error)
+	FindTier(ctx context.Context, name string) (*models.PricingTier, error)
+	// SaveTier creates the tier or changes its discount
+	SaveTier(ctx context.Context, name string, discountPercentage float64) (*models.PricingTier, error)
+	// DeleteTier also removes the tier's assignments
+	DeleteTier(ctx context.Context, name string) error
+	FindAssignments(ctx context.Context) ([]models.PricingAssignment, error)
+	// Assign replaces the subject's tier, if any
+	Assign(ctx context.Context, subjectType, subject, tier string) error
+	Unassign(ctx context.Context, subjectType, subject string) error
+	// Resolve returns the tier of the customer, or else of the API key hash
+	Resolve(ctx context.Context, customerID, apiKeyHash string) (*models.PricingTier, error)
+}
+
+type pricingRepository struct {
+	qtx *sqlc.Queries
+}
+
+func NewPricingRepository(db *sql.DB) PricingRepository {
+	return &pricingRepository{qtx: sqlc.New(db)}
+}
+
+func (r *pricingRepository) FindTiers(ctx context.Context) ([]models.PricingTier, error) {
+	rows, err := r.qtx.GetPricingTiers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pricing tiers: %w", err)
+	}
+
+	tiers := make([]models.PricingTier, len(rows))
+	for i, row := range rows {
+		tiers[i] = mapSQLCPricingTier(row)
+	}
+	return tiers, nil
+}
+
+func (r *pricingRepository) FindTier(ctx context.Context, name string) (*models.PricingTier, error) {
+	row, err := r.qtx.GetPricingTier(ctx, name)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("pricing tier not found")
+		}
+		return nil, fmt.Errorf("failed to get pricing tier: %w", err)
+	}
+
+	tier := mapSQLCPricingTier(row)
+	return &tier, nil
+}
+
+func (r *pricingRepository) SaveTier(ctx context.Context, name string, discountPercentage float64) (*models.PricingTier, error) {
+	row, err := r.qtx.UpsertPricingTier(ctx, sqlc.UpsertPricingTierParams{Name: name, DiscountPercentage: discountPercentage})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save pricing tier: %w", err)
+	}
+
+	tier := mapSQLCPricingTier(row)
+	return &tier, nil
+}
+
+func (r *pricingRepository) DeleteTier(ctx context.Context, name string) error {
+	deleted, err := r.qtx.DeletePricingTier(ctx, name)
+	if err != nil {
+		return fmt.Errorf("failed to delete pricing tier: %w", err)
+	}
+	if deleted == 0 {
+		return fmt.Errorf("pricing tier not found")
+	}
+	return nil
+}
+
+func (r *pricingRepository) FindAssignments(ctx context.Context) ([]models.PricingAssignment, error) {
+	rows, err := r.qtx.GetPricingTierAssignments(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pricing assignments: %w", err)
+	}
+
+	assignments := make([]models.PricingAssignment, len(rows))
+	for i, row := range rows {
+		assignments[i] = models.PricingAssignment{
+			SubjectType: row.SubjectType,
+			Subject:     row.Subject,
+			Tier:        row.Tier,
+			CreatedAt:   row.CreatedAt,
+		}
+	}
+	return assignments, nil
+}
+
+func (r *pricingRepository) Assign(ctx context.Context, subjectType, subject, tier string) error {
+	err := r.qtx.UpsertPricingTierAssignment(ctx, sqlc.UpsertPricingTierAssignmentParams{
+		SubjectType: subjectType,
+		Subject:     subject,
+		Tier:        tier,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to assign pricing tier: %w", err)
+	}
+	return nil
+}
+
+func (r *pricingRepository) Unassign(ctx context.Context, subjectType, subject string) error {
+	deleted, err := r.qtx.DeletePricingTierAssignment(ctx, sqlc.DeletePricingTierAssignmentParams{SubjectType: subjectType, Subject: subject})
+	if err != nil {
+		return fmt.Errorf("failed to remove pricing assignment: %w", err)
+	}
+	if deleted == 0 {
+		return fmt.Errorf("pricing assignment not found")
+	}
+	return nil
+}
+
+func (r *pricingRepository) Resolve(ctx context.Context, customerID, apiKeyHash string) (*models.PricingTier, error) {
+	row, err := r.qtx.ResolvePricingTier(ctx, sqlc.ResolvePricingTierParams{CustomerID: customerID, ApiKeyHash: apiKeyHash})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("pricing tier not found")
+		}
+		return nil, fmt.Errorf("failed to resolve pricing tier: %w", err)
+	}
+
+	tier := mapSQLCPricingTier(row)
+	return &tier, nil
+}
+
+func mapSQLCPricingTier(row sqlc.PricingTier) models.PricingTier {
+	updatedAt := row.UpdatedAt
+	return models.PricingTier{
+		Name:               row.Name,
+		DiscountPercentage: row.DiscountPercentage,
+		UpdatedAt:          &updatedAt,
+	}
+}
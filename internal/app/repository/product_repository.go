@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"oolio/internal/app/models"
 	"oolio/internal/database/sqlc"
@@ -14,33 +15,97 @@ import (
 )
 
 type productRepository struct {
-	db  *sql.DB
-	qtx *sqlc.Queries
+	db     *sql.DB
+	qtx    *sqlc.Queries
+	reader ReadRouter
 }
 
-func NewProductRepository(db *sql.DB) ProductRepository {
+// NewProductRepository writes to db. Reads go through reader when set, otherwise db,
+// except FindOneForUpdate, which always reads db.
+func NewProductRepository(db *sql.DB, reader ReadRouter) ProductRepository {
 	return &productRepository{
-		db:  db,
-		qtx: sqlc.New(db),
+		db:     db,
+		qtx:    sqlc.New(db),
+		reader: reader,
 	}
 }
 
+func (r *productRepository) readQueries() *sqlc.Queries {
+	if r.reader == nil {
+		return r.qtx
+	}
+	return sqlc.New(r.reader.Reader())
+}
+
 func (r *productRepository) Find(ctx context.Context) ([]models.Product, error) {
-	dbProducts, err := r.qtx.GetProducts(ctx)
+	queries := r.readQueries()
+	dbProducts, err := queries.GetProducts(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get products: %w", err)
 	}
 
-	return r.mapSQLCToModels(dbProducts), nil
+	return r.withOptions(ctx, queries, r.mapSQLCToModels(dbProducts))
+}
+
+func (r *productRepository) FindPage(ctx context.Context, page models.PageRequest) ([]models.Product, int, error) {
+	page = page.Normalize()
+	queries := r.readQueries()
+
+	var storeID uuid.NullUUID
+	if page.StoreID != "" {
+		parsed, err := uuid.Parse(page.StoreID)
+		if err != nil {
+			return nil, 0, fmt.Errorf("invalid store ID: %w", err)
+		}
+		storeID = uuid.NullUUID{UUID: parsed, Valid: true}
+	}
+
+	total, err := queries.CountProducts(ctx, storeID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count products: %w", err)
+	}
+
+	dbProducts, err := queries.GetProductsPage(ctx, sqlc.GetProductsPageParams{
+		Limit:   int32(page.Limit),
+		Offset:  int32(page.Offset),
+		StoreID: storeID,
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get products: %w", err)
+	}
+
+	products, err := r.withOptions(ctx, queries, r.mapSQLCToModels(dbProducts))
+	if err != nil {
+		return nil, 0, err
+	}
+	return products, int(total), nil
+}
+
+func (r *productRepository) FindUpdatedSince(ctx context.Context, since time.Time) ([]models.Product, error) {
+	queries := r.readQueries()
+	dbProducts, err := queries.GetProductsUpdatedSince(ctx, sql.NullTime{Time: since, Valid: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get updated products: %w", err)
+	}
+
+	return r.withOptions(ctx, queries, r.mapSQLCToModels(dbProducts))
 }
 
 func (r *productRepository) FindOne(ctx context.Context, id string) (*models.Product, error) {
+	return r.findOne(ctx, r.readQueries(), id)
+}
+
+func (r *productRepository) FindOneForUpdate(ctx context.Context, id string) (*models.Product, error) {
+	return r.findOne(ctx, r.qtx, id)
+}
+
+func (r *productRepository) findOne(ctx context.Context, queries *sqlc.Queries, id string) (*models.Product, error) {
 	productUUID, err := uuid.Parse(id)
 	if err != nil {
 		return nil, fmt.Errorf("invalid product ID: %w", err)
 	}
 
-	dbProduct, err := r.qtx.GetProductByID(ctx, productUUID)
+	dbProduct, err := queries.GetProductByID(ctx, productUUID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("product not found")
@@ -48,28 +113,84 @@ func (r *productRepository) FindOne(ctx context.Context, id string) (*models.Pro
 		return nil, fmt.Errorf("failed to get product: %w", err)
 	}
 
-	product := r.mapSQLCToModel(dbProduct)
-	return &product, nil
+	products, err := r.withOptions(ctx, queries, []models.Product{r.mapSQLCToModel(dbProduct)})
+	if err != nil {
+		return nil, err
+	}
+	return &products[0], nil
+}
+
+func (r *productRepository) FindByIDs(ctx context.Context, ids []string) ([]models.Product, error) {
+	productUUIDs := make([]uuid.UUID, len(ids))
+	for i, id := range ids {
+		productUUID, err := uuid.Parse(id)
+		if err != nil {
+			return nil, fmt.Errorf("invalid product ID: %w", err)
+		}
+		productUUIDs[i] = productUUID
+	}
+
+	queries := r.readQueries()
+	dbProducts, err := queries.GetProductsByIDs(ctx, productUUIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get products: %w", err)
+	}
+
+	return r.withOptions(ctx, queries, r.mapSQLCToModels(dbProducts))
 }
 
 func (r *productRepository) Create(ctx context.Context, product *models.Product) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	qtx := r.qtx.WithTx(tx)
+
+	storeID, err := uuid.Parse(models.StoreOrDefault(product.StoreID))
+	if err != nil {
+		return fmt.Errorf("invalid store ID: %w", err)
+	}
+
 	params := sqlc.CreateProductParams{
 		Name:         product.Name,
-		Price:        fmt.Sprintf("%.2f", product.Price),
+		Price:        product.Price,
 		Category:     product.Category,
 		ThumbnailUrl: stringToNullString(product.Image.Thumbnail),
 		MobileUrl:    stringToNullString(product.Image.Mobile),
 		TabletUrl:    stringToNullString(product.Image.Tablet),
 		DesktopUrl:   stringToNullString(product.Image.Desktop),
+		StoreID:      storeID,
 	}
 
-	dbProduct, err := r.qtx.CreateProduct(ctx, params)
+	dbProduct, err := qtx.CreateProduct(ctx, params)
 	if err != nil {
 		return fmt.Errorf("failed to create product: %w", err)
 	}
 
+	created := r.mapSQLCToModel(dbProduct)
+	if err := r.saveOptions(ctx, qtx, dbProduct.ID, product); err != nil {
+		return err
+	}
+	created.Variants, created.Modifiers = product.Variants, product.Modifiers
+	if err := writeOutboxEvent(ctx, qtx, "product", dbProduct.ID, models.EventProductCreated, created); err != nil {
+		return err
+	}
+	if err := logProductChange(ctx, qtx, dbProduct.ID, models.ProductCreated); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit product: %w", err)
+	}
+
 	// Update the product with the generated ID
-	product.ID = dbProduct.ID.String()
+	product.ID = created.ID
+	product.StoreID = created.StoreID
+	product.Version = created.Version
+	product.CreatedAt = created.CreatedAt
+	product.UpdatedAt = created.UpdatedAt
 	return nil
 }
 
@@ -79,25 +200,58 @@ func (r *productRepository) Update(ctx context.Context, product *models.Product)
 		return fmt.Errorf("invalid product ID: %w", err)
 	}
 
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	qtx := r.qtx.WithTx(tx)
+
 	params := sqlc.UpdateProductParams{
 		ID:           productUUID,
 		Name:         product.Name,
-		Price:        fmt.Sprintf("%.2f", product.Price),
+		Price:        product.Price,
 		Category:     product.Category,
 		ThumbnailUrl: stringToNullString(product.Image.Thumbnail),
 		MobileUrl:    stringToNullString(product.Image.Mobile),
 		TabletUrl:    stringToNullString(product.Image.Tablet),
 		DesktopUrl:   stringToNullString(product.Image.Desktop),
+		Version:      int32(product.Version),
 	}
 
-	_, err = r.qtx.UpdateProduct(ctx, params)
+	dbProduct, err := qtx.UpdateProduct(ctx, params)
 	if err != nil {
 		if err == sql.ErrNoRows {
+			// Either the product is gone or its version moved on
+			if _, err := qtx.GetProductByID(ctx, productUUID); err == nil {
+				return &ConflictError{Entity: "product", ID: product.ID, Version: product.Version}
+			}
 			return fmt.Errorf("product not found")
 		}
 		return fmt.Errorf("failed to update product: %w", err)
 	}
 
+	updated := r.mapSQLCToModel(dbProduct)
+	if err := r.saveOptions(ctx, qtx, productUUID, product); err != nil {
+		return err
+	}
+	updated.Variants, updated.Modifiers = product.Variants, product.Modifiers
+	if err := writeOutboxEvent(ctx, qtx, "product", productUUID, models.EventProductUpdated, updated); err != nil {
+		return err
+	}
+	if err := logProductChange(ctx, qtx, productUUID, models.ProductUpdated); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit product: %w", err)
+	}
+
+	product.StoreID = updated.StoreID
+	product.Version = updated.Version
+	product.CreatedAt = updated.CreatedAt
+	product.UpdatedAt = updated.UpdatedAt
 	return nil
 }
 
@@ -107,14 +261,224 @@ func (r *productRepository) Delete(ctx context.Context, id string) error {
 		return fmt.Errorf("invalid product ID: %w", err)
 	}
 
-	err = r.qtx.DeleteProduct(ctx, productUUID)
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	qtx := r.qtx.WithTx(tx)
+
+	deleted, err := qtx.DeleteProduct(ctx, productUUID)
 	if err != nil {
 		return fmt.Errorf("failed to delete product: %w", err)
 	}
+	if deleted == 0 {
+		return fmt.Errorf("product not found")
+	}
+
+	if err := writeOutboxEvent(ctx, qtx, "product", productUUID, models.EventProductDeleted, map[string]string{"id": id}); err != nil {
+		return err
+	}
+	if err := logProductChange(ctx, qtx, productUUID, models.ProductDeleted); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit product deletion: %w", err)
+	}
 
 	return nil
 }
 
+func (r *productRepository) FindDeleted(ctx context.Context, since time.Time) ([]models.Product, error) {
+	queries := r.readQueries()
+	dbProducts, err := queries.GetDeletedProducts(ctx, sql.NullTime{Time: since, Valid: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deleted products: %w", err)
+	}
+
+	return r.withOptions(ctx, queries, r.mapSQLCToModels(dbProducts))
+}
+
+func (r *productRepository) Restore(ctx context.Context, id string) error {
+	productUUID, err := uuid.Parse(id)
+	if err != nil {
+		return fmt.Errorf("invalid product ID: %w", err)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	qtx := r.qtx.WithTx(tx)
+
+	dbProduct, err := qtx.RestoreProduct(ctx, productUUID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("deleted product not found")
+		}
+		return fmt.Errorf("failed to restore product: %w", err)
+	}
+
+	restored, err := r.withOptions(ctx, qtx, []models.Product{r.mapSQLCToModel(dbProduct)})
+	if err != nil {
+		return err
+	}
+	if err := writeOutboxEvent(ctx, qtx, "product", productUUID, models.EventProductRestored, restored[0]); err != nil {
+		return err
+	}
+	if err := logProductChange(ctx, qtx, productUUID, models.ProductCreated); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit product restore: %w", err)
+	}
+
+	return nil
+}
+
+// FindChanges reads the replica like the other listings: a change it hasn't seen yet is
+// picked up by the next call from the same cursor
+func (r *productRepository) FindChanges(ctx context.Context, storeID string, after int64, limit int) ([]models.ProductChange, error) {
+	var store uuid.NullUUID
+	if storeID != "" {
+		parsed, err := uuid.Parse(storeID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid store ID: %w", err)
+		}
+		store = uuid.NullUUID{UUID: parsed, Valid: true}
+	}
+
+	queries := r.readQueries()
+	rows, err := queries.GetProductChanges(ctx, sqlc.GetProductChangesParams{
+		After:   after,
+		StoreID: store,
+		MaxRows: int32(limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product changes: %w", err)
+	}
+
+	changes := make([]models.ProductChange, len(rows))
+	var products []models.Product
+	for i, row := range rows {
+		changes[i] = models.ProductChange{
+			Cursor:    strconv.FormatInt(row.Seq, 10),
+			Kind:      row.Kind,
+			ProductID: row.Product.ID.String(),
+			ChangedAt: row.ChangedAt,
+		}
+		if row.Kind != models.ProductDeleted {
+			products = append(products, r.mapSQLCToModel(row.Product))
+		}
+	}
+	products, err = r.withOptions(ctx, queries, products)
+	if err != nil {
+		return nil, err
+	}
+	for i := range changes {
+		if changes[i].Kind != models.ProductDeleted {
+			changes[i].Product, products = &products[0], products[1:]
+		}
+	}
+	return changes, nil
+}
+
+// withOptions loads the variants and modifiers of the products with q
+func (r *productRepository) withOptions(ctx context.Context, q *sqlc.Queries, products []models.Product) ([]models.Product, error) {
+	if len(products) == 0 {
+		return products, nil
+	}
+	ids := make([]uuid.UUID, len(products))
+	for i, product := range products {
+		ids[i] = uuid.MustParse(product.ID)
+	}
+	options, err := q.GetProductOptions(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product options: %w", err)
+	}
+
+	byProduct := make(map[uuid.UUID][]sqlc.ProductOption, len(products))
+	for _, option := range options {
+		byProduct[option.ProductID] = append(byProduct[option.ProductID], option)
+	}
+	for i := range products {
+		for _, option := range byProduct[ids[i]] {
+			mapped := models.ProductOption{ID: option.ID.String(), Name: option.Name, PriceDelta: option.PriceDelta}
+			if option.Kind == models.OptionVariant {
+				products[i].Variants = append(products[i].Variants, mapped)
+			} else {
+				products[i].Modifiers = append(products[i].Modifiers, mapped)
+			}
+		}
+	}
+	return products, nil
+}
+
+// saveOptions replaces the product's options with its Variants and Modifiers, with q,
+// which must be bound to the transaction saving the product
+func (r *productRepository) saveOptions(ctx context.Context, q *sqlc.Queries, productID uuid.UUID, product *models.Product) error {
+	existing, err := q.GetProductOptions(ctx, []uuid.UUID{productID})
+	if err != nil {
+		return fmt.Errorf("failed to get product options: %w", err)
+	}
+	owned := make(map[string]bool, len(existing))
+	for _, option := range existing {
+		owned[option.ID.String()] = true
+	}
+	assignOptionIDs(product, owned)
+
+	keep := make([]uuid.UUID, 0, len(product.Variants)+len(product.Modifiers))
+	for kind, options := range map[string][]models.ProductOption{models.OptionVariant: product.Variants, models.OptionModifier: product.Modifiers} {
+		for i, option := range options {
+			id := uuid.MustParse(option.ID)
+			err := q.UpsertProductOption(ctx, sqlc.UpsertProductOptionParams{
+				ID:         id,
+				ProductID:  productID,
+				Kind:       kind,
+				Name:       option.Name,
+				PriceDelta: option.PriceDelta,
+				Position:   int32(i),
+			})
+			if err != nil {
+				return fmt.Errorf("failed to save product option: %w", err)
+			}
+			keep = append(keep, id)
+		}
+	}
+	if err := q.DeleteProductOptionsExcept(ctx, sqlc.DeleteProductOptionsExceptParams{ProductID: productID, KeepIds: keep}); err != nil {
+		return fmt.Errorf("failed to delete product options: %w", err)
+	}
+	return nil
+}
+
+// assignOptionIDs gives a new ID to the product's options that don't have one of owned,
+// the IDs of its options as they were, or repeat one of another option
+func assignOptionIDs(product *models.Product, owned map[string]bool) {
+	seen := make(map[string]bool, len(product.Variants)+len(product.Modifiers))
+	for _, options := range [][]models.ProductOption{product.Variants, product.Modifiers} {
+		for i := range options {
+			if !owned[options[i].ID] || seen[options[i].ID] {
+				options[i].ID = uuid.NewString()
+			}
+			seen[options[i].ID] = true
+		}
+	}
+}
+
+// logProductChange adds a change to the feed with q, which must be bound to the
+// transaction making it
+func logProductChange(ctx context.Context, q *sqlc.Queries, productID uuid.UUID, kind string) error {
+	if err := q.InsertProductChange(ctx, sqlc.InsertProductChangeParams{ProductID: productID, Kind: kind}); err != nil {
+		return fmt.Errorf("failed to log product change: %w", err)
+	}
+	return nil
+}
+
 func (r *productRepository) mapSQLCToModels(dbProducts []sqlc.Product) []models.Product {
 	products := make([]models.Product, len(dbProducts))
 	for i, dbProduct := range dbProducts {
@@ -127,7 +491,7 @@ func (r *productRepository) mapSQLCToModel(dbProduct sqlc.Product) models.Produc
 	return models.Product{
 		ID:       dbProduct.ID.String(),
 		Name:     dbProduct.Name,
-		Price:    parseFloat(dbProduct.Price),
+		Price:    dbProduct.Price,
 		Category: dbProduct.Category,
 		Image: models.Image{
 			Thumbnail: nullStringToString(dbProduct.ThumbnailUrl),
@@ -135,16 +499,14 @@ func (r *productRepository) mapSQLCToModel(dbProduct sqlc.Product) models.Produc
 			Tablet:    nullStringToString(dbProduct.TabletUrl),
 			Desktop:   nullStringToString(dbProduct.DesktopUrl),
 		},
+		Version:   int(dbProduct.Version),
+		StoreID:   dbProduct.StoreID.String(),
+		CreatedAt: dbProduct.CreatedAt.Time,
+		UpdatedAt: dbProduct.UpdatedAt.Time,
+		DeletedAt: nullTimeToPtr(dbProduct.DeletedAt),
 	}
 }
 
-func parseFloat(s string) float64 {
-	if f, err := strconv.ParseFloat(s, 64); err == nil {
-		return f
-	}
-	return 0.0
-}
-
 func nullStringToString(ns sql.NullString) string {
 	if ns.Valid {
 		return ns.String
@@ -152,6 +514,13 @@ func nullStringToString(ns sql.NullString) string {
 	return ""
 }
 
+func nullTimeToPtr(nt sql.NullTime) *time.Time {
+	if nt.Valid {
+		return &nt.Time
+	}
+	return nil
+}
+
 func stringToNullString(s string) sql.NullString {
 	if strings.TrimSpace(s) == "" {
 		return sql.NullString{}
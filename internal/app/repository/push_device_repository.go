@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"oolio/internal/app/models"
+	"oolio/internal/database/sqlc"
+)
+
+// PushDeviceRepository keeps the devices customers registered for push notifications
+type PushDeviceRepository interface {
+	// Register stores the device for the customer; a token registered before moves to them
+	Register(ctx context.Context, customerID string, device *models.PushDevice) error
+	// FindByCustomer returns the customer's devices, newest first
+	FindByCustomer(ctx context.Context, customerID string) ([]models.PushDevice, error)
+	// Unregister removes one of the customer's devices. It fails with "push device not
+	// found" if the customer has no device with that token.
+	Unregister(ctx context.Context, customerID, token string) error
+	// Remove forgets a token whoever registered it, e.g. once the provider rejects it
+	Remove(ctx context.Context, token string) error
+}
+
+type pushDeviceRepository struct {
+	qtx *sqlc.Queries
+}
+
+func NewPushDeviceRepository(db *sql.DB) PushDeviceRepository {
+	return &pushDeviceRepository{qtx: sqlc.New(db)}
+}
+
+func (r *pushDeviceRepository) Register(ctx context.Context, customerID string, device *models.PushDevice) error {
+	now := time.Now()
+	err := r.qtx.UpsertPushDevice(ctx, sqlc.UpsertPushDeviceParams{
+		Token:      device.Token,
+		CustomerID: customerID,
+		Platform:   device.Platform,
+		CreatedAt:  now,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to register push device: %w", err)
+	}
+	device.CreatedAt = now
+	return nil
+}
+
+func (r *pushDeviceRepository) FindByCustomer(ctx context.Context, customerID string) ([]models.PushDevice, error) {
+	rows, err := r.qtx.GetPushDevicesByCustomer(ctx, customerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get push devices: %w", err)
+	}
+
+	devices := make([]models.PushDevice, len(rows))
+	for i, row := range rows {
+		devices[i] = models.PushDevice{Platform: row.Platform, Token: row.Token, CreatedAt: row.CreatedAt}
+	}
+	return devices, nil
+}
+
+func (r *pushDeviceRepository) Unregister(ctx context.Context, customerID, token string) error {
+	deleted, err := r.qtx.DeleteCustomerPushDevice(ctx, sqlc.DeleteCustomerPushDeviceParams{CustomerID: customerID, Token: token})
+	if err != nil {
+		return fmt.Errorf("failed to unregister push device: %w", err)
+	}
+	if deleted == 0 {
+		return fmt.Errorf("push device not found")
+	}
+	return nil
+}
+
+func (r *pushDeviceRepository) Remove(ctx context.Context, token string) error {
+	if err := r.qtx.DeletePushDevice(ctx, token); err != nil {
+		return fmt.Errorf("failed to remove push device: %w", err)
+	}
+	return nil
+}
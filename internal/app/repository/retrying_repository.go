@@ -0,0 +1,1165 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"oolio/internal/app/models"
+)
+
+// Retrier re-runs calls that fail with transient database errors
+type Retrier interface {
+	// Read retries fn on any transient error
+	Read(ctx context.Context, fn func(ctx context.Context) error) error
+	// Write retries fn only when the failed attempt can't have been applied
+	Write(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+func retryRead[T any](ctx context.Context, retrier Retrier, fn func(ctx context.Context) (T, error)) (T, error) {
+	var result T
+	err := retrier.Read(ctx, func(ctx context.Context) error {
+		var err error
+		result, err = fn(ctx)
+		return err
+	})
+	return result, err
+}
+
+func retryReadPage[T any](ctx context.Context, retrier Retrier, fn func(ctx context.Context) ([]T, int, error)) ([]T, int, error) {
+	var items []T
+	var total int
+	err := retrier.Read(ctx, func(ctx context.Context) error {
+		var err error
+		items, total, err = fn(ctx)
+		return err
+	})
+	return items, total, err
+}
+
+type retryingProductRepository struct {
+	repo    ProductRepository
+	retrier Retrier
+}
+
+// NewRetryingProductRepository wraps repo so transient database errors are retried
+func NewRetryingProductRepository(repo ProductRepository, retrier Retrier) ProductRepository {
+	return &retryingProductRepository{repo: repo, retrier: retrier}
+}
+
+func (r *retryingProductRepository) Find(ctx context.Context) ([]models.Product, error) {
+	return retryRead(ctx, r.retrier, r.repo.Find)
+}
+
+func (r *retryingProductRepository) FindPage(ctx context.Context, page models.PageRequest) ([]models.Product, int, error) {
+	return retryReadPage(ctx, r.retrier, func(ctx context.Context) ([]models.Product, int, error) {
+		return r.repo.FindPage(ctx, page)
+	})
+}
+
+func (r *retryingProductRepository) FindUpdatedSince(ctx context.Context, since time.Time) ([]models.Product, error) {
+	return retryRead(ctx, r.retrier, func(ctx context.Context) ([]models.Product, error) {
+		return r.repo.FindUpdatedSince(ctx, since)
+	})
+}
+
+func (r *retryingProductRepository) FindOne(ctx context.Context, id string) (*models.Product, error) {
+	return retryRead(ctx, r.retrier, func(ctx context.Context) (*models.Product, error) {
+		return r.repo.FindOne(ctx, id)
+	})
+}
+
+func (r *retryingProductRepository) FindOneForUpdate(ctx context.Context, id string) (*models.Product, error) {
+	return retryRead(ctx, r.retrier, func(ctx context.Context) (*models.Product, error) {
+		return r.repo.FindOneForUpdate(ctx, id)
+	})
+}
+
+func (r *retryingProductRepository) FindByIDs(ctx context.Context, ids []string) ([]models.Product, error) {
+	return retryRead(ctx, r.retrier, func(ctx context.Context) ([]models.Product, error) {
+		return r.repo.FindByIDs(ctx, ids)
+	})
+}
+
+func (r *retryingProductRepository) FindChanges(ctx context.Context, storeID string, after int64, limit int) ([]models.ProductChange, error) {
+	return retryRead(ctx, r.retrier, func(ctx context.Context) ([]models.ProductChange, error) {
+		return r.repo.FindChanges(ctx, storeID, after, limit)
+	})
+}
+
+func (r *retryingProductRepository) FindDeleted(ctx context.Context, since time.Time) ([]models.Product, error) {
+	return retryRead(ctx, r.retrier, func(ctx context.Context) ([]models.Product, error) {
+		return r.repo.FindDeleted(ctx, since)
+	})
+}
+
+func (r *retryingProductRepository) Restore(ctx context.Context, id string) error {
+	return r.retrier.Write(ctx, func(ctx context.Context) error {
+		return r.repo.Restore(ctx, id)
+	})
+}
+
+func (r *retryingProductRepository) Create(ctx context.Context, product *models.Product) error {
+	return r.retrier.Write(ctx, func(ctx context.Context) error {
+		return r.repo.Create(ctx, product)
+	})
+}
+
+func (r *retryingProductRepository) Update(ctx context.Context, product *models.Product) error {
+	return r.retrier.Write(ctx, func(ctx context.Context) error {
+		return r.repo.Update(ctx, product)
+	})
+}
+
+func (r *retryingProductRepository) Delete(ctx context.Context, id string) error {
+	return r.retrier.Write(ctx, func(ctx context.Context) error {
+		return r.repo.Delete(ctx, id)
+	})
+}
+
+type retryingOrderRepository struct {
+	repo    OrderRepository
+	retrier Retrier
+}
+
+// NewRetryingOrderRepository wraps repo so transient database errors are retried
+func NewRetryingOrderRepository(repo OrderRepository, retrier Retrier) OrderRepository {
+	return &retryingOrderRepository{repo: repo, retrier: retrier}
+}
+
+func (r *retryingOrderRepository) Find(ctx context.Context) ([]models.Order, error) {
+	return retryRead(ctx, r.retrier, r.repo.Find)
+}
+
+func (r *retryingOrderRepository) FindPage(ctx context.Context, page models.PageRequest) ([]models.Order, int, error) {
+	return retryReadPage(ctx, r.retrier, func(ctx context.Context) ([]models.Order, int, error) {
+		return r.repo.FindPage(ctx, page)
+	})
+}
+
+func (r *retryingOrderRepository) FindUpdatedSince(ctx context.Context, since time.Time) ([]models.Order, error) {
+	return retryRead(ctx, r.retrier, func(ctx context.Context) ([]models.Order, error) {
+		return r.repo.FindUpdatedSince(ctx, since)
+	})
+}
+
+func (r *retryingOrderRepository) FindOne(ctx context.Context, id string) (*models.Order, error) {
+	return retryRead(ctx, r.retrier, func(ctx context.Context) (*models.Order, error) {
+		return r.repo.FindOne(ctx, id)
+	})
+}
+
+func (r *retryingOrderRepository) Create(ctx context.Context, order *models.Order) error {
+	return r.retrier.Write(ctx, func(ctx context.Context) error {
+		return r.repo.Create(ctx, order)
+	})
+}
+
+func (r *retryingOrderRepository) Update(ctx context.Context, order *models.Order) error {
+	return r.retrier.Write(ctx, func(ctx context.Context) error {
+		return r.repo.Update(ctx, order)
+	})
+}
+
+func (r *retryingOrderRepository) Delete(ctx context.Context, id string) error {
+	return r.retrier.Write(ctx, func(ctx context.Context) error {
+		return r.repo.Delete(ctx, id)
+	})
+}
+
+func (r *retryingOrderRepository) SetPaymentStatus(ctx context.Context, orderID string, status string) error {
+	return r.retrier.Write(ctx, func(ctx context.Context) error {
+		return r.repo.SetPaymentStatus(ctx, orderID, status)
+	})
+}
+
+func (r *retryingOrderRepository) FindByPaymentIntent(ctx context.Context, intentID string) (*models.Order, error) {
+	return retryRead(ctx, r.retrier, func(ctx context.Context) (*models.Order, error) {
+		return r.repo.FindByPaymentIntent(ctx, intentID)
+	})
+}
+
+func (r *retryingOrderRepository) CreateRefund(ctx context.Context, refund *models.OrderRefund) error {
+	return r.retrier.Write(ctx, func(ctx context.Context) error {
+		return r.repo.CreateRefund(ctx, refund)
+	})
+}
+
+func (r *retryingOrderRepository) UpdateRefund(ctx context.Context, refund *models.OrderRefund) error {
+	return r.retrier.Write(ctx, func(ctx context.Context) error {
+		return r.repo.UpdateRefund(ctx, refund)
+	})
+}
+
+func (r *retryingOrderRepository) GetRefunds(ctx context.Context, orderID string) ([]models.OrderRefund, error) {
+	return retryRead(ctx, r.retrier, func(ctx context.Context) ([]models.OrderRefund, error) {
+		return r.repo.GetRefunds(ctx, orderID)
+	})
+}
+
+func (r *retryingOrderRepository) PaymentMethodTotals(ctx context.Context, since, until time.Time) ([]models.PaymentMethodTotal, error) {
+	return retryRead(ctx, r.retrier, func(ctx context.Context) ([]models.PaymentMethodTotal, error) {
+		return r.repo.PaymentMethodTotals(ctx, since, until)
+	})
+}
+
+func (r *retryingOrderRepository) TopProducts(ctx context.Context, since, until time.Time, limit int) ([]models.ProductSales, error) {
+	return retryRead(ctx, r.retrier, func(ctx context.Context) ([]models.ProductSales, error) {
+		return r.repo.TopProducts(ctx, since, until, limit)
+	})
+}
+
+func (r *retryingOrderRepository) Sales(ctx context.Context, query models.SalesQuery) ([]models.SalesGroup, error) {
+	return retryRead(ctx, r.retrier, func(ctx context.Context) ([]models.SalesGroup, error) {
+		return r.repo.Sales(ctx, query)
+	})
+}
+
+func (r *retryingOrderRepository) CouponUsage(ctx context.Context, since, until time.Time) ([]models.CouponUsage, error) {
+	return retryRead(ctx, r.retrier, func(ctx context.Context) ([]models.CouponUsage, error) {
+		return r.repo.CouponUsage(ctx, since, until)
+	})
+}
+
+func (r *retryingOrderRepository) CreateOrderItems(ctx context.Context, orderID string, items []models.OrderItem) error {
+	return r.retrier.Write(ctx, func(ctx context.Context) error {
+		return r.repo.CreateOrderItems(ctx, orderID, items)
+	})
+}
+
+func (r *retryingOrderRepository) GetOrderItems(ctx context.Context, orderID string) ([]models.OrderItem, error) {
+	return retryRead(ctx, r.retrier, func(ctx context.Context) ([]models.OrderItem, error) {
+		return r.repo.GetOrderItems(ctx, orderID)
+	})
+}
+
+type retryingOrderQueueRepository struct {
+	repo    OrderQueueRepository
+	retrier Retrier
+}
+
+// NewRetryingOrderQueueRepository wraps repo so transient database errors are retried
+func NewRetryingOrderQueueRepository(repo OrderQueueRepository, retrier Retrier) OrderQueueRepository {
+	return &retryingOrderQueueRepository{repo: repo, retrier: retrier}
+}
+
+func (r *retryingOrderQueueRepository) AddToQueue(ctx context.Context, item *models.OrderQueueItem) error {
+	return r.retrier.Write(ctx, func(ctx context.Context) error {
+		return r.repo.AddToQueue(ctx, item)
+	})
+}
+
+func (r *retryingOrderQueueRepository) GetPendingItems(ctx context.Context, batchSize int) ([]*models.OrderQueueItem, error) {
+	return retryRead(ctx, r.retrier, func(ctx context.Context) ([]*models.OrderQueueItem, error) {
+		return r.repo.GetPendingItems(ctx, batchSize)
+	})
+}
+
+func (r *retryingOrderQueueRepository) UpdateItem(ctx context.Context, item *models.OrderQueueItem) error {
+	return r.retrier.Write(ctx, func(ctx context.Context) error {
+		return r.repo.UpdateItem(ctx, item)
+	})
+}
+
+func (r *retryingOrderQueueRepository) MarkAsProcessing(ctx context.Context, itemID string) error {
+	return r.retrier.Write(ctx, func(ctx context.Context) error {
+		return r.repo.MarkAsProcessing(ctx, itemID)
+	})
+}
+
+func (r *retryingOrderQueueRepository) Claim(ctx context.Context, itemID, instance string) error {
+	return r.retrier.Write(ctx, func(ctx context.Context) error {
+		return r.repo.Claim(ctx, itemID, instance)
+	})
+}
+
+func (r *retryingOrderQueueRepository) MarkAsCompleted(ctx context.Context, itemID string, order *models.Order) error {
+	return r.retrier.Write(ctx, func(ctx context.Context) error {
+		return r.repo.MarkAsCompleted(ctx, itemID, order)
+	})
+}
+
+func (r *retryingOrderQueueRepository) MarkAsFailed(ctx context.Context, itemID string, errorMsg string) error {
+	return r.retrier.Write(ctx, func(ctx context.Context) error {
+		return r.repo.MarkAsFailed(ctx, itemID, errorMsg)
+	})
+}
+
+func (r *retryingOrderQueueRepository) Anonymize(ctx context.Context, id string) (int, error) {
+	var anonymized int
+	err := r.retrier.Write(ctx, func(ctx context.Context) error {
+		var err error
+		anonymized, err = r.repo.Anonymize(ctx, id)
+		return err
+	})
+	return anonymized, err
+}
+
+func (r *retryingOrderQueueRepository) Requeue(ctx context.Context, itemID string) error {
+	return r.retrier.Write(ctx, func(ctx context.Context) error {
+		return r.repo.Requeue(ctx, itemID)
+	})
+}
+
+func (r *retryingOrderQueueRepository) GetFailures(ctx context.Context, itemID string) ([]models.QueueFailure, error) {
+	return retryRead(ctx, r.retrier, func(ctx context.Context) ([]models.QueueFailure, error) {
+		return r.repo.GetFailures(ctx, itemID)
+	})
+}
+
+func (r *retryingOrderQueueRepository) SetPaymentIntent(ctx context.Context, itemID, intentID string) error {
+	return r.retrier.Write(ctx, func(ctx context.Context) error {
+		return r.repo.SetPaymentIntent(ctx, itemID, intentID)
+	})
+}
+
+func (r *retryingOrderQueueRepository) GetQueueStats(ctx context.Context) (map[string]int, error) {
+	return retryRead(ctx, r.retrier, r.repo.GetQueueStats)
+}
+
+func (r *retryingOrderQueueRepository) GetBacklog(ctx context.Context) (*models.QueueBacklog, error) {
+	return retryRead(ctx, r.retrier, r.repo.GetBacklog)
+}
+
+func (r *retryingOrderQueueRepository) GetOrderFromQueue(ctx context.Context, itemID string) (*models.OrderQueueItem, error) {
+	return retryRead(ctx, r.retrier, func(ctx context.Context) (*models.OrderQueueItem, error) {
+		return r.repo.GetOrderFromQueue(ctx, itemID)
+	})
+}
+
+func (r *retryingOrderQueueRepository) GetAllOrders(ctx context.Context) ([]*models.OrderQueueItem, error) {
+	return retryRead(ctx, r.retrier, r.repo.GetAllOrders)
+}
+
+func (r *retryingOrderQueueRepository) FindPage(ctx context.Context, page models.PageRequest) ([]*models.OrderQueueItem, int, error) {
+	return retryReadPage(ctx, r.retrier, func(ctx context.Context) ([]*models.OrderQueueItem, int, error) {
+		return r.repo.FindPage(ctx, page)
+	})
+}
+
+func (r *retryingOrderQueueRepository) FindScheduled(ctx context.Context, storeID string, from, until time.Time) ([]*models.OrderQueueItem, error) {
+	return retryRead(ctx, r.retrier, func(ctx context.Context) ([]*models.OrderQueueItem, error) {
+		return r.repo.FindScheduled(ctx, storeID, from, until)
+	})
+}
+
+func (r *retryingOrderQueueRepository) FindByTab(ctx context.Context, tabID string) ([]*models.OrderQueueItem, error) {
+	return retryRead(ctx, r.retrier, func(ctx context.Context) ([]*models.OrderQueueItem, error) {
+		return r.repo.FindByTab(ctx, tabID)
+	})
+}
+
+type retryingAddressRepository struct {
+	repo    AddressRepository
+	retrier Retrier
+}
+
+// NewRetryingAddressRepository wraps repo so transient database errors are retried
+func NewRetryingAddressRepository(repo AddressRepository, retrier Retrier) AddressRepository {
+	return &retryingAddressRepository{repo: repo, retrier: retrier}
+}
+
+func (r *retryingAddressRepository) FindByCustomer(ctx context.Context, customerID string) ([]models.Address, error) {
+	return retryRead(ctx, r.retrier, func(ctx context.Context) ([]models.Address, error) {
+		return r.repo.FindByCustomer(ctx, customerID)
+	})
+}
+
+func (r *retryingAddressRepository) FindOne(ctx context.Context, customerID, id string) (*models.Address, error) {
+	return retryRead(ctx, r.retrier, func(ctx context.Context) (*models.Address, error) {
+		return r.repo.FindOne(ctx, customerID, id)
+	})
+}
+
+func (r *retryingAddressRepository) Count(ctx context.Context, customerID string) (int, error) {
+	return retryRead(ctx, r.retrier, func(ctx context.Context) (int, error) {
+		return r.repo.Count(ctx, customerID)
+	})
+}
+
+func (r *retryingAddressRepository) Create(ctx context.Context, customerID string, address *models.Address) error {
+	return r.retrier.Write(ctx, func(ctx context.Context) error {
+		return r.repo.Create(ctx, customerID, address)
+	})
+}
+
+func (r *retryingAddressRepository) SetDefault(ctx context.Context, customerID, id string) error {
+	return r.retrier.Write(ctx, func(ctx context.Context) error {
+		return r.repo.SetDefault(ctx, customerID, id)
+	})
+}
+
+func (r *retryingAddressRepository) Delete(ctx context.Context, customerID, id string) error {
+	return r.retrier.Write(ctx, func(ctx context.Context) error {
+		return r.repo.Delete(ctx, customerID, id)
+	})
+}
+
+type retryingFavoriteRepository struct {
+	repo    FavoriteRepository
+	retrier Retrier
+}
+
+// NewRetryingFavoriteRepository wraps repo so transient database errors are retried
+func NewRetryingFavoriteRepository(repo FavoriteRepository, retrier Retrier) FavoriteRepository {
+	return &retryingFavoriteRepository{repo: repo, retrier: retrier}
+}
+
+func (r *retryingFavoriteRepository) FindByCustomer(ctx context.Context, customerID string) ([]string, error) {
+	return retryRead(ctx, r.retrier, func(ctx context.Context) ([]string, error) {
+		return r.repo.FindByCustomer(ctx, customerID)
+	})
+}
+
+func (r *retryingFavoriteRepository) Add(ctx context.Context, customerID, productID string) error {
+	return r.retrier.Write(ctx, func(ctx context.Context) error {
+		return r.repo.Add(ctx, customerID, productID)
+	})
+}
+
+func (r *retryingFavoriteRepository) Remove(ctx context.Context, customerID, productID string) error {
+	return r.retrier.Write(ctx, func(ctx context.Context) error {
+		return r.repo.Remove(ctx, customerID, productID)
+	})
+}
+
+type retryingCartRepository struct {
+	repo    CartRepository
+	retrier Retrier
+}
+
+// NewRetryingCartRepository wraps repo so transient database errors are retried
+func NewRetryingCartRepository(repo CartRepository, retrier Retrier) CartRepository {
+	return &retryingCartRepository{repo: repo, retrier: retrier}
+}
+
+func (r *retryingCartRepository) FindByCustomer(ctx context.Context, customerID string) (*models.Cart, error) {
+	return retryRead(ctx, r.retrier, func(ctx context.Context) (*models.Cart, error) {
+		return r.repo.FindByCustomer(ctx, customerID)
+	})
+}
+
+func (r *retryingCartRepository) FindGuest(ctx context.Context, id string) (*models.Cart, error) {
+	return retryRead(ctx, r.retrier, func(ctx context.Context) (*models.Cart, error) {
+		return r.repo.FindGuest(ctx, id)
+	})
+}
+
+func (r *retryingCartRepository) EnsureForCustomer(ctx context.Context, customerID string) (*models.Cart, error) {
+	var cart *models.Cart
+	err := r.retrier.Write(ctx, func(ctx context.Context) error {
+		var err error
+		cart, err = r.repo.EnsureForCustomer(ctx, customerID)
+		return err
+	})
+	return cart, err
+}
+
+func (r *retryingCartRepository) CreateGuest(ctx context.Context, expiresAt time.Time) (*models.Cart, error) {
+	var cart *models.Cart
+	err := r.retrier.Write(ctx, func(ctx context.Context) error {
+		var err error
+		cart, err = r.repo.CreateGuest(ctx, expiresAt)
+		return err
+	})
+	return cart, err
+}
+
+func (r *retryingCartRepository) AddItem(ctx context.Context, cartID string, item models.CartItem) error {
+	return r.retrier.Write(ctx, func(ctx context.Context) error {
+		return r.repo.AddItem(ctx, cartID, item)
+	})
+}
+
+func (r *retryingCartRepository) SetQuantity(ctx context.Context, cartID string, item models.CartItem) error {
+	return r.retrier.Write(ctx, func(ctx context.Context) error {
+		return r.repo.SetQuantity(ctx, cartID, item)
+	})
+}
+
+func (r *retryingCartRepository) RemoveItem(ctx context.Context, cartID, productID string) error {
+	return r.retrier.Write(ctx, func(ctx context.Context) error {
+		return r.repo.RemoveItem(ctx, cartID, productID)
+	})
+}
+
+func (r *retryingCartRepository) Clear(ctx context.Context, cartID string) error {
+	return r.retrier.Write(ctx, func(ctx context.Context) error {
+		return r.repo.Clear(ctx, cartID)
+	})
+}
+
+func (r *retryingCartRepository) Touch(ctx context.Context, cartID string, expiresAt time.Time) error {
+	return r.retrier.Write(ctx, func(ctx context.Context) error {
+		return r.repo.Touch(ctx, cartID, expiresAt)
+	})
+}
+
+func (r *retryingCartRepository) DeleteExpired(ctx context.Context, before time.Time) (int, error) {
+	var deleted int
+	err := r.retrier.Write(ctx, func(ctx context.Context) error {
+		var err error
+		deleted, err = r.repo.DeleteExpired(ctx, before)
+		return err
+	})
+	return deleted, err
+}
+
+type retryingSegmentRepository struct {
+	repo    SegmentRepository
+	retrier Retrier
+}
+
+// NewRetryingSegmentRepository wraps repo so transient database errors are retried
+func NewRetryingSegmentRepository(repo SegmentRepository, retrier Retrier) SegmentRepository {
+	return &retryingSegmentRepository{repo: repo, retrier: retrier}
+}
+
+func (r *retryingSegmentRepository) Refresh(ctx context.Context, rules models.SegmentRules, now time.Time) (int64, error) {
+	var updated int64
+	err := r.retrier.Write(ctx, func(ctx context.Context) error {
+		var err error
+		updated, err = r.repo.Refresh(ctx, rules, now)
+		return err
+	})
+	return updated, err
+}
+
+func (r *retryingSegmentRepository) FindByCustomer(ctx context.Context, customerID string) (*models.CustomerSegment, error) {
+	return retryRead(ctx, r.retrier, func(ctx context.Context) (*models.CustomerSegment, error) {
+		return r.repo.FindByCustomer(ctx, customerID)
+	})
+}
+
+type retryingPricingRepository struct {
+	repo    PricingRepository
+	retrier Retrier
+}
+
+// NewRetryingPricingRepository wraps repo so transient database errors are retried
+func NewRetryingPricingRepository(repo PricingRepository, retrier Retrier) PricingRepository {
+	return &retryingPricingRepository{repo: repo, retrier: retrier}
+}
+
+func (r *retryingPricingRepository) FindTiers(ctx context.Context) ([]models.PricingTier, error) {
+	return retryRead(ctx, r.retrier, func(ctx context.Context) ([]models.PricingTier, error) {
+		return r.repo.FindTiers(ctx)
+	})
+}
+
+func (r *retryingPricingRepository) FindTier(ctx context.Context, name string) (*models.PricingTier, error) {
+	return retryRead(ctx, r.retrier, func(ctx context.Context) (*models.PricingTier, error) {
+		return r.repo.FindTier(ctx, name)
+	})
+}
+
+func (r *retryingPricingRepository) SaveTier(ctx context.Context, name string, discountPercentage float64) (*models.PricingTier, error) {
+	var tier *models.PricingTier
+	err := r.retrier.Write(ctx, func(ctx context.Context) error {
+		var err error
+		tier, err = r.repo.SaveTier(ctx, name, discountPercentage)
+		return err
+	})
+	return tier, err
+}
+
+func (r *retryingPricingRepository) DeleteTier(ctx context.Context, name string) error {
+	return r.retrier.Write(ctx, func(ctx context.Context) error {
+		return r.repo.DeleteTier(ctx, name)
+	})
+}
+
+func (r *retryingPricingRepository) FindAssignments(ctx context.Context) ([]models.PricingAssignment, error) {
+	return retryRead(ctx, r.retrier, func(ctx context.Context) ([]models.PricingAssignment, error) {
+		return r.repo.FindAssignments(ctx)
+	})
+}
+
+func (r *retryingPricingRepository) Assign(ctx context.Context, subjectType, subject, tier string) error {
+	return r.retrier.Write(ctx, func(ctx context.Context) error {
+		return r.repo.Assign(ctx, subjectType, subject, tier)
+	})
+}
+
+func (r *retryingPricingRepository) Unassign(ctx context.Context, subjectType, subject string) error {
+	return r.retrier.Write(ctx, func(ctx context.Context) error {
+		return r.repo.Unassign(ctx, subjectType, subject)
+	})
+}
+
+func (r *retryingPricingRepository) Resolve(ctx context.Context, customerID, apiKeyHash string) (*models.PricingTier, error) {
+	return retryRead(ctx, r.retrier, func(ctx context.Context) (*models.PricingTier, error) {
+		return r.repo.Resolve(ctx, customerID, apiKeyHash)
+	})
+}
+
+type retryingCouponRedemptionRepository struct {
+	repo    CouponRedemptionRepository
+	retrier Retrier
+}
+
+// NewRetryingCouponRedemptionRepository wraps repo so transient database errors are retried
+func NewRetryingCouponRedemptionRepository(repo CouponRedemptionRepository, retrier Retrier) CouponRedemptionRepository {
+	return &retryingCouponRedemptionRepository{repo: repo, retrier: retrier}
+}
+
+func (r *retryingCouponRedemptionRepository) IsRedeemed(ctx context.Context, code, redeemer string) (bool, error) {
+	return retryRead(ctx, r.retrier, func(ctx context.Context) (bool, error) {
+		return r.repo.IsRedeemed(ctx, code, redeemer)
+	})
+}
+
+func (r *retryingCouponRedemptionRepository) Redeem(ctx context.Context, code, redeemer string) error {
+	return r.retrier.Write(ctx, func(ctx context.Context) error {
+		return r.repo.Redeem(ctx, code, redeemer)
+	})
+}
+
+func (r *retryingCouponRedemptionRepository) Release(ctx context.Context, code, redeemer string) error {
+	return r.retrier.Write(ctx, func(ctx context.Context) error {
+		return r.repo.Release(ctx, code, redeemer)
+	})
+}
+
+type retryingNotificationRepository struct {
+	repo    NotificationRepository
+	retrier Retrier
+}
+
+// NewRetryingNotificationRepository wraps repo so transient database errors are retried
+func NewRetryingNotificationRepository(repo NotificationRepository, retrier Retrier) NotificationRepository {
+	return &retryingNotificationRepository{repo: repo, retrier: retrier}
+}
+
+func (r *retryingNotificationRepository) FindByCustomer(ctx context.Context, customerID string) (*models.NotificationPreferences, error) {
+	return retryRead(ctx, r.retrier, func(ctx context.Context) (*models.NotificationPreferences, error) {
+		return r.repo.FindByCustomer(ctx, customerID)
+	})
+}
+
+func (r *retryingNotificationRepository) Save(ctx context.Context, customerID string, req models.NotificationPreferencesReq) (*models.NotificationPreferences, error) {
+	var saved *models.NotificationPreferences
+	err := r.retrier.Write(ctx, func(ctx context.Context) error {
+		var err error
+		saved, err = r.repo.Save(ctx, customerID, req)
+		return err
+	})
+	return saved, err
+}
+
+type retryingPaymentEventRepository struct {
+	repo    PaymentEventRepository
+	retrier Retrier
+}
+
+// NewRetryingPaymentEventRepository wraps repo so transient database errors are retried
+func NewRetryingPaymentEventRepository(repo PaymentEventRepository, retrier Retrier) PaymentEventRepository {
+	return &retryingPaymentEventRepository{repo: repo, retrier: retrier}
+}
+
+func (r *retryingPaymentEventRepository) Record(ctx context.Context, id, eventType string) error {
+	return r.retrier.Write(ctx, func(ctx context.Context) error {
+		return r.repo.Record(ctx, id, eventType)
+	})
+}
+
+func (r *retryingPaymentEventRepository) Forget(ctx context.Context, id string) error {
+	return r.retrier.Write(ctx, func(ctx context.Context) error {
+		return r.repo.Forget(ctx, id)
+	})
+}
+
+type retryingGiftCardRepository struct {
+	repo    GiftCardRepository
+	retrier Retrier
+}
+
+// NewRetryingGiftCardRepository wraps repo so transient database errors are retried
+func NewRetryingGiftCardRepository(repo GiftCardRepository, retrier Retrier) GiftCardRepository {
+	return &retryingGiftCardRepository{repo: repo, retrier: retrier}
+}
+
+func (r *retryingGiftCardRepository) Create(ctx context.Context, card *models.GiftCard) error {
+	return r.retrier.Write(ctx, func(ctx context.Context) error {
+		return r.repo.Create(ctx, card)
+	})
+}
+
+func (r *retryingGiftCardRepository) FindOne(ctx context.Context, code string) (*models.GiftCard, error) {
+	return retryRead(ctx, r.retrier, func(ctx context.Context) (*models.GiftCard, error) {
+		return r.repo.FindOne(ctx, code)
+	})
+}
+
+func (r *retryingGiftCardRepository) Debit(ctx context.Context, code string, amount models.Money) error {
+	return r.retrier.Write(ctx, func(ctx context.Context) error {
+		return r.repo.Debit(ctx, code, amount)
+	})
+}
+
+func (r *retryingGiftCardRepository) Credit(ctx context.Context, code string, amount models.Money) error {
+	return r.retrier.Write(ctx, func(ctx context.Context) error {
+		return r.repo.Credit(ctx, code, amount)
+	})
+}
+
+type retryingInvoiceRepository struct {
+	repo    InvoiceRepository
+	retrier Retrier
+}
+
+// NewRetryingInvoiceRepository wraps repo so transient database errors are retried
+func NewRetryingInvoiceRepository(repo InvoiceRepository, retrier Retrier) InvoiceRepository {
+	return &retryingInvoiceRepository{repo: repo, retrier: retrier}
+}
+
+func (r *retryingInvoiceRepository) Issue(ctx context.Context, series string, invoice *models.Invoice) error {
+	return r.retrier.Write(ctx, func(ctx context.Context) error {
+		return r.repo.Issue(ctx, series, invoice)
+	})
+}
+
+func (r *retryingInvoiceRepository) FindByOrder(ctx context.Context, orderID string) (*models.Invoice, error) {
+	return retryRead(ctx, r.retrier, func(ctx context.Context) (*models.Invoice, error) {
+		return r.repo.FindByOrder(ctx, orderID)
+	})
+}
+
+type retryingDeliveryRepository struct {
+	repo    DeliveryRepository
+	retrier Retrier
+}
+
+// NewRetryingDeliveryRepository wraps repo so transient database errors are retried
+func NewRetryingDeliveryRepository(repo DeliveryRepository, retrier Retrier) DeliveryRepository {
+	return &retryingDeliveryRepository{repo: repo, retrier: retrier}
+}
+
+func (r *retryingDeliveryRepository) Create(ctx context.Context, delivery *models.Delivery) error {
+	return r.retrier.Write(ctx, func(ctx context.Context) error {
+		return r.repo.Create(ctx, delivery)
+	})
+}
+
+func (r *retryingDeliveryRepository) FindByOrder(ctx context.Context, orderID string) (*models.Delivery, error) {
+	return retryRead(ctx, r.retrier, func(ctx context.Context) (*models.Delivery, error) {
+		return r.repo.FindByOrder(ctx, orderID)
+	})
+}
+
+func (r *retryingDeliveryRepository) Update(ctx context.Context, delivery *models.Delivery) error {
+	return r.retrier.Write(ctx, func(ctx context.Context) error {
+		return r.repo.Update(ctx, delivery)
+	})
+}
+
+type retryingTabRepository struct {
+	repo    TabRepository
+	retrier Retrier
+}
+
+// NewRetryingTabRepository wraps repo so transient database errors are retried
+func NewRetryingTabRepository(repo TabRepository, retrier Retrier) TabRepository {
+	return &retryingTabRepository{repo: repo, retrier: retrier}
+}
+
+func (r *retryingTabRepository) Open(ctx context.Context, storeID, tableNumber string) (*models.Tab, error) {
+	var tab *models.Tab
+	err := r.retrier.Write(ctx, func(ctx context.Context) error {
+		var err error
+		tab, err = r.repo.Open(ctx, storeID, tableNumber)
+		return err
+	})
+	return tab, err
+}
+
+func (r *retryingTabRepository) FindOpen(ctx context.Context, storeID, tableNumber string) (*models.Tab, error) {
+	return retryRead(ctx, r.retrier, func(ctx context.Context) (*models.Tab, error) {
+		return r.repo.FindOpen(ctx, storeID, tableNumber)
+	})
+}
+
+func (r *retryingTabRepository) FindOpenByStore(ctx context.Context, storeID string) ([]models.Tab, error) {
+	return retryRead(ctx, r.retrier, func(ctx context.Context) ([]models.Tab, error) {
+		return r.repo.FindOpenByStore(ctx, storeID)
+	})
+}
+
+func (r *retryingTabRepository) Close(ctx context.Context, id, paymentMethod string) (*models.Tab, error) {
+	var tab *models.Tab
+	err := r.retrier.Write(ctx, func(ctx context.Context) error {
+		var err error
+		tab, err = r.repo.Close(ctx, id, paymentMethod)
+		return err
+	})
+	return tab, err
+}
+
+type retryingNotificationOutboxRepository struct {
+	repo    NotificationOutboxRepository
+	retrier Retrier
+}
+
+// NewRetryingNotificationOutboxRepository wraps repo so transient database errors are retried
+func NewRetryingNotificationOutboxRepository(repo NotificationOutboxRepository, retrier Retrier) NotificationOutboxRepository {
+	return &retryingNotificationOutboxRepository{repo: repo, retrier: retrier}
+}
+
+func (r *retryingNotificationOutboxRepository) Enqueue(ctx context.Context, message *models.NotificationMessage) error {
+	return r.retrier.Write(ctx, func(ctx context.Context) error {
+		return r.repo.Enqueue(ctx, message)
+	})
+}
+
+func (r *retryingNotificationOutboxRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]*models.NotificationMessage, error) {
+	var messages []*models.NotificationMessage
+	err := r.retrier.Write(ctx, func(ctx context.Context) error {
+		var err error
+		messages, err = r.repo.ClaimDue(ctx, limit, lease)
+		return err
+	})
+	return messages, err
+}
+
+func (r *retryingNotificationOutboxRepository) MarkSent(ctx context.Context, id string) error {
+	return r.retrier.Write(ctx, func(ctx context.Context) error {
+		return r.repo.MarkSent(ctx, id)
+	})
+}
+
+func (r *retryingNotificationOutboxRepository) MarkFailed(ctx context.Context, id, errorMsg string, retryAt time.Time) error {
+	return r.retrier.Write(ctx, func(ctx context.Context) error {
+		return r.repo.MarkFailed(ctx, id, errorMsg, retryAt)
+	})
+}
+
+func (r *retryingNotificationOutboxRepository) MarkDead(ctx context.Context, id, errorMsg string) error {
+	return r.retrier.Write(ctx, func(ctx context.Context) error {
+		return r.repo.MarkDead(ctx, id, errorMsg)
+	})
+}
+
+func (r *retryingNotificationOutboxRepository) Redeliver(ctx context.Context, id string) error {
+	return r.retrier.Write(ctx, func(ctx context.Context) error {
+		return r.repo.Redeliver(ctx, id)
+	})
+}
+
+func (r *retryingNotificationOutboxRepository) GetStats(ctx context.Context) (*models.NotificationDeliveryStats, error) {
+	return retryRead(ctx, r.retrier, func(ctx context.Context) (*models.NotificationDeliveryStats, error) {
+		return r.repo.GetStats(ctx)
+	})
+}
+
+type retryingWebhookDeliveryRepository struct {
+	repo    WebhookDeliveryRepository
+	retrier Retrier
+}
+
+// NewRetryingWebhookDeliveryRepository wraps repo so transient database errors are retried
+func NewRetryingWebhookDeliveryRepository(repo WebhookDeliveryRepository, retrier Retrier) WebhookDeliveryRepository {
+	return &retryingWebhookDeliveryRepository{repo: repo, retrier: retrier}
+}
+
+func (r *retryingWebhookDeliveryRepository) Enqueue(ctx context.Context, delivery *models.WebhookDelivery) (bool, error) {
+	var inserted bool
+	err := r.retrier.Write(ctx, func(ctx context.Context) error {
+		var err error
+		inserted, err = r.repo.Enqueue(ctx, delivery)
+		return err
+	})
+	return inserted, err
+}
+
+func (r *retryingWebhookDeliveryRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]*models.WebhookDelivery, error) {
+	var deliveries []*models.WebhookDelivery
+	err := r.retrier.Write(ctx, func(ctx context.Context) error {
+		var err error
+		deliveries, err = r.repo.ClaimDue(ctx, limit, lease)
+		return err
+	})
+	return deliveries, err
+}
+
+func (r *retryingWebhookDeliveryRepository) MarkDelivered(ctx context.Context, id string, attempt models.WebhookAttempt) error {
+	return r.retrier.Write(ctx, func(ctx context.Context) error {
+		return r.repo.MarkDelivered(ctx, id, attempt)
+	})
+}
+
+func (r *retryingWebhookDeliveryRepository) MarkFailed(ctx context.Context, id string, attempt models.WebhookAttempt, retryAt time.Time) error {
+	return r.retrier.Write(ctx, func(ctx context.Context) error {
+		return r.repo.MarkFailed(ctx, id, attempt, retryAt)
+	})
+}
+
+func (r *retryingWebhookDeliveryRepository) MarkDead(ctx context.Context, id string, attempt models.WebhookAttempt) error {
+	return r.retrier.Write(ctx, func(ctx context.Context) error {
+		return r.repo.MarkDead(ctx, id, attempt)
+	})
+}
+
+func (r *retryingWebhookDeliveryRepository) Redeliver(ctx context.Context, id string) error {
+	return r.retrier.Write(ctx, func(ctx context.Context) error {
+		return r.repo.Redeliver(ctx, id)
+	})
+}
+
+func (r *retryingWebhookDeliveryRepository) FindOne(ctx context.Context, id string) (*models.WebhookDelivery, error) {
+	return retryRead(ctx, r.retrier, func(ctx context.Context) (*models.WebhookDelivery, error) {
+		return r.repo.FindOne(ctx, id)
+	})
+}
+
+func (r *retryingWebhookDeliveryRepository) FindPage(ctx context.Context, status string, page models.PageRequest) ([]models.WebhookDelivery, int, error) {
+	return retryReadPage(ctx, r.retrier, func(ctx context.Context) ([]models.WebhookDelivery, int, error) {
+		return r.repo.FindPage(ctx, status, page)
+	})
+}
+
+type retryingNotificationFeedRepository struct {
+	repo    NotificationFeedRepository
+	retrier Retrier
+}
+
+// NewRetryingNotificationFeedRepository wraps repo so transient database errors are retried
+func NewRetryingNotificationFeedRepository(repo NotificationFeedRepository, retrier Retrier) NotificationFeedRepository {
+	return &retryingNotificationFeedRepository{repo: repo, retrier: retrier}
+}
+
+func (r *retryingNotificationFeedRepository) Add(ctx context.Context, customerID string, notification *models.FeedNotification) error {
+	return r.retrier.Write(ctx, func(ctx context.Context) error {
+		return r.repo.Add(ctx, customerID, notification)
+	})
+}
+
+func (r *retryingNotificationFeedRepository) FindPage(ctx context.Context, customerID string, unreadOnly bool, page models.PageRequest) ([]models.FeedNotification, int, error) {
+	return retryReadPage(ctx, r.retrier, func(ctx context.Context) ([]models.FeedNotification, int, error) {
+		return r.repo.FindPage(ctx, customerID, unreadOnly, page)
+	})
+}
+
+func (r *retryingNotificationFeedRepository) CountUnread(ctx context.Context, customerID string) (int, error) {
+	return retryRead(ctx, r.retrier, func(ctx context.Context) (int, error) {
+		return r.repo.CountUnread(ctx, customerID)
+	})
+}
+
+func (r *retryingNotificationFeedRepository) MarkRead(ctx context.Context, customerID, id string) error {
+	return r.retrier.Write(ctx, func(ctx context.Context) error {
+		return r.repo.MarkRead(ctx, customerID, id)
+	})
+}
+
+func (r *retryingNotificationFeedRepository) MarkAllRead(ctx context.Context, customerID string) (int, error) {
+	var marked int
+	err := r.retrier.Write(ctx, func(ctx context.Context) error {
+		var err error
+		marked, err = r.repo.MarkAllRead(ctx, customerID)
+		return err
+	})
+	return marked, err
+}
+
+type retryingPushDeviceRepository struct {
+	repo    PushDeviceRepository
+	retrier Retrier
+}
+
+// NewRetryingPushDeviceRepository wraps repo so transient database errors are retried
+func NewRetryingPushDeviceRepository(repo PushDeviceRepository, retrier Retrier) PushDeviceRepository {
+	return &retryingPushDeviceRepository{repo: repo, retrier: retrier}
+}
+
+func (r *retryingPushDeviceRepository) Register(ctx context.Context, customerID string, device *models.PushDevice) error {
+	return r.retrier.Write(ctx, func(ctx context.Context) error {
+		return r.repo.Register(ctx, customerID, device)
+	})
+}
+
+func (r *retryingPushDeviceRepository) FindByCustomer(ctx context.Context, customerID string) ([]models.PushDevice, error) {
+	return retryRead(ctx, r.retrier, func(ctx context.Context) ([]models.PushDevice, error) {
+		return r.repo.FindByCustomer(ctx, customerID)
+	})
+}
+
+func (r *retryingPushDeviceRepository) Unregister(ctx context.Context, customerID, token string) error {
+	return r.retrier.Write(ctx, func(ctx context.Context) error {
+		return r.repo.Unregister(ctx, customerID, token)
+	})
+}
+
+func (r *retryingPushDeviceRepository) Remove(ctx context.Context, token string) error {
+	return r.retrier.Write(ctx, func(ctx context.Context) error {
+		return r.repo.Remove(ctx, token)
+	})
+}
+
+type retryingStoreRepository struct {
+	repo    StoreRepository
+	retrier Retrier
+}
+
+// NewRetryingStoreRepository wraps repo so transient database errors are retried
+func NewRetryingStoreRepository(repo StoreRepository, retrier Retrier) StoreRepository {
+	return &retryingStoreRepository{repo: repo, retrier: retrier}
+}
+
+func (r *retryingStoreRepository) FindAll(ctx context.Context) ([]models.Store, error) {
+	return retryRead(ctx, r.retrier, func(ctx context.Context) ([]models.Store, error) {
+		return r.repo.FindAll(ctx)
+	})
+}
+
+func (r *retryingStoreRepository) FindOne(ctx context.Context, id string) (*models.Store, error) {
+	return retryRead(ctx, r.retrier, func(ctx context.Context) (*models.Store, error) {
+		return r.repo.FindOne(ctx, id)
+	})
+}
+
+func (r *retryingStoreRepository) Create(ctx context.Context, name string) (*models.Store, error) {
+	var store *models.Store
+	err := r.retrier.Write(ctx, func(ctx context.Context) error {
+		var err error
+		store, err = r.repo.Create(ctx, name)
+		return err
+	})
+	return store, err
+}
+
+func (r *retryingStoreRepository) Update(ctx context.Context, id, name string) (*models.Store, error) {
+	var store *models.Store
+	err := r.retrier.Write(ctx, func(ctx context.Context) error {
+		var err error
+		store, err = r.repo.Update(ctx, id, name)
+		return err
+	})
+	return store, err
+}
+
+func (r *retryingStoreRepository) FindAPIKeys(ctx context.Context, storeID string) ([]models.StoreAPIKey, error) {
+	return retryRead(ctx, r.retrier, func(ctx context.Context) ([]models.StoreAPIKey, error) {
+		return r.repo.FindAPIKeys(ctx, storeID)
+	})
+}
+
+func (r *retryingStoreRepository) CreateAPIKey(ctx context.Context, key *models.StoreAPIKey, keyHash string) error {
+	return r.retrier.Write(ctx, func(ctx context.Context) error {
+		return r.repo.CreateAPIKey(ctx, key, keyHash)
+	})
+}
+
+func (r *retryingStoreRepository) RevokeAPIKey(ctx context.Context, storeID, id string) error {
+	return r.retrier.Write(ctx, func(ctx context.Context) error {
+		return r.repo.RevokeAPIKey(ctx, storeID, id)
+	})
+}
+
+func (r *retryingStoreRepository) FindAPIKeyByHash(ctx context.Context, keyHash string) (*models.StoreAPIKey, error) {
+	return retryRead(ctx, r.retrier, func(ctx context.Context) (*models.StoreAPIKey, error) {
+		return r.repo.FindAPIKeyByHash(ctx, keyHash)
+	})
+}
+
+func (r *retryingStoreRepository) FindOpeningHours(ctx context.Context, storeID string) (*models.OpeningHours, error) {
+	return retryRead(ctx, r.retrier, func(ctx context.Context) (*models.OpeningHours, error) {
+		return r.repo.FindOpeningHours(ctx, storeID)
+	})
+}
+
+func (r *retryingStoreRepository) SaveOpeningHours(ctx context.Context, storeID string, hours *models.OpeningHours) error {
+	return r.retrier.Write(ctx, func(ctx context.Context) error {
+		return r.repo.SaveOpeningHours(ctx, storeID, hours)
+	})
+}
+
+func (r *retryingStoreRepository) DeleteOpeningHours(ctx context.Context, storeID string) (bool, error) {
+	var deleted bool
+	err := r.retrier.Write(ctx, func(ctx context.Context) error {
+		var err error
+		deleted, err = r.repo.DeleteOpeningHours(ctx, storeID)
+		return err
+	})
+	return deleted, err
+}
+
+type retryingOrderDiscrepancyRepository struct {
+	repo    OrderDiscrepancyRepository
+	retrier Retrier
+}
+
+// NewRetryingOrderDiscrepancyRepository wraps repo so transient database errors are retried
+func NewRetryingOrderDiscrepancyRepository(repo OrderDiscrepancyRepository, retrier Retrier) OrderDiscrepancyRepository {
+	return &retryingOrderDiscrepancyRepository{repo: repo, retrier: retrier}
+}
+
+func (r *retryingOrderDiscrepancyRepository) Record(ctx context.Context, checked []string, found []models.OrderDiscrepancy) error {
+	return r.retrier.Write(ctx, func(ctx context.Context) error {
+		return r.repo.Record(ctx, checked, found)
+	})
+}
+
+func (r *retryingOrderDiscrepancyRepository) Find(ctx context.Context, storeID string) ([]models.OrderDiscrepancy, error) {
+	return retryRead(ctx, r.retrier, func(ctx context.Context) ([]models.OrderDiscrepancy, error) {
+		return r.repo.Find(ctx, storeID)
+	})
+}
+
+type retryingInventoryRepository struct {
+	repo    InventoryRepository
+	retrier Retrier
+}
+
+// NewRetryingInventoryRepository wraps repo so transient database errors are retried
+func NewRetryingInventoryRepository(repo InventoryRepository, retrier Retrier) InventoryRepository {
+	return &retryingInventoryRepository{repo: repo, retrier: retrier}
+}
+
+func (r *retryingInventoryRepository) Reserve(ctx context.Context, reservationID string, quantities map[string]int, expiresAt time.Time) error {
+	return r.retrier.Write(ctx, func(ctx context.Context) error {
+		return r.repo.Reserve(ctx, reservationID, quantities, expiresAt)
+	})
+}
+
+func (r *retryingInventoryRepository) Release(ctx context.Context, reservationID string) error {
+	return r.retrier.Write(ctx, func(ctx context.Context) error {
+		return r.repo.Release(ctx, reservationID)
+	})
+}
+
+func (r *retryingInventoryRepository) Take(ctx context.Context, reservationID string, quantities map[string]int) error {
+	return r.retrier.Write(ctx, func(ctx context.Context) error {
+		return r.repo.Take(ctx, reservationID, quantities)
+	})
+}
+
+func (r *retryingInventoryRepository) Return(ctx context.Context, quantities map[string]int) error {
+	return r.retrier.Write(ctx, func(ctx context.Context) error {
+		return r.repo.Return(ctx, quantities)
+	})
+}
+
+func (r *retryingInventoryRepository) Restock(ctx context.Context, productID string, quantity int) (*models.StockLevel, error) {
+	var level *models.StockLevel
+	err := r.retrier.Write(ctx, func(ctx context.Context) error {
+		var err error
+		level, err = r.repo.Restock(ctx, productID, quantity)
+		return err
+	})
+	return level, err
+}
+
+func (r *retryingInventoryRepository) LowStock(ctx context.Context, threshold int, storeID string) ([]models.StockLevel, error) {
+	return retryRead(ctx, r.retrier, func(ctx context.Context) ([]models.StockLevel, error) {
+		return r.repo.LowStock(ctx, threshold, storeID)
+	})
+}
+
+func (r *retryingInventoryRepository) DeleteExpiredReservations(ctx context.Context, before time.Time) (int, error) {
+	var deleted int
+	err := r.retrier.Write(ctx, func(ctx context.Context) error {
+		var err error
+		deleted, err = r.repo.DeleteExpiredReservations(ctx, before)
+		return err
+	})
+	return deleted, err
+}
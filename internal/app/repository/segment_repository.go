@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"oolio/internal/app/models"
+	"oolio/internal/database/sqlc"
+)
+
+// SegmentRepository keeps the segment each ordering customer was last placed in
+type SegmentRepository interface {
+	// Refresh recomputes the segment of every customer with an order, as of now, and
+	// returns the number of customers updated
+	Refresh(ctx context.Context, rules models.SegmentRules, now time.Time) (int64, error)
+	FindByCustomer(ctx context.Context, customerID string) (*models.CustomerSegment, error)
+}
+
+type segmentRepository struct {
+	qtx *sqlc.Queries
+}
+
+func NewSegmentRepository(db *sql.DB) SegmentRepository {
+	return &segmentRepository{qtx: sqlc.New(db)}
+}
+
+func (r *segmentRepository) Refresh(ctx context.Context, rules models.SegmentRules, now time.Time) (int64, error) {
+	// Order timestamps are stored without a time zone, in UTC
+	now = now.UTC()
+	updated, err := r.qtx.RefreshCustomerSegments(ctx, sqlc.RefreshCustomerSegmentsParams{
+		LapsedBefore: now.Add(-rules.LapsedAfter),
+		VipSince:     now.Add(-rules.VIPWindow),
+		VipSpend:     rules.VIPSpend,
+		NewSince:     now.Add(-rules.NewWindow),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to refresh customer segments: %w", err)
+	}
+	return updated, nil
+}
+
+func (r *segmentRepository) FindByCustomer(ctx context.Context, customerID string) (*models.CustomerSegment, error) {
+	row, err := r.qtx.GetCustomerSegment(ctx, customerID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("segment not found")
+		}
+		return nil, fmt.Errorf("failed to get customer segment: %w", err)
+	}
+
+	return &models.CustomerSegment{
+		CustomerID:   row.CustomerID,
+		Segment:      row.Segment,
+		OrderCount:   int(row.OrderCount),
+		TotalSpent:   row.TotalSpent,
+		FirstOrderAt: row.FirstOrderAt.Time,
+		LastOrderAt:  row.LastOrderAt.Time,
+		ComputedAt:   row.ComputedAt,
+	}, nil
+}
@@ -0,0 +1,247 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"oolio/internal/app/models"
+	"oolio/internal/database/sqlc"
+
+	"github.com/google/uuid"
+)
+
+// StoreRepository keeps the stores and their API keys. Keys are stored as their SHA-256
+// hash, never in the clear.
+type StoreRepository interface {
+	FindAll(ctx context.Context) ([]models.Store, error)
+	FindOne(ctx context.Context, id string) (*models.Store, error)
+	Create(ctx context.Context, name string) (*models.Store, error)
+	Update(ctx context.Context, id, name string) (*models.Store, error)
+	// FindAPIKeys returns the store's keys, revoked ones included, oldest first
+	FindAPIKeys(ctx context.Context, storeID string) ([]models.StoreAPIKey, error)
+	// CreateAPIKey stores key under keyHash, filling in its ID and creation time
+	CreateAPIKey(ctx context.Context, key *models.StoreAPIKey, keyHash string) error
+	RevokeAPIKey(ctx context.Context, storeID, id string) error
+	// FindAPIKeyByHash returns the unrevoked key with the hash, or "store API key not found"
+	FindAPIKeyByHash(ctx context.Context, keyHash string) (*models.StoreAPIKey, error)
+	// FindOpeningHours returns the store's opening hours, or nil when it has none and is
+	// always open
+	FindOpeningHours(ctx context.Context, storeID string) (*models.OpeningHours, error)
+	SaveOpeningHours(ctx context.Context, storeID string, hours *models.OpeningHours) error
+	// DeleteOpeningHours leaves the store always open; it reports whether it had hours
+	DeleteOpeningHours(ctx context.Context, storeID string) (bool, error)
+}
+
+type storeRepository struct {
+	qtx *sqlc.Queries
+}
+
+func NewStoreRepository(db *sql.DB) StoreRepository {
+	return &storeRepository{qtx: sqlc.New(db)}
+}
+
+func (r *storeRepository) FindAll(ctx context.Context) ([]models.Store, error) {
+	rows, err := r.qtx.GetStores(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stores: %w", err)
+	}
+
+	stores := make([]models.Store, len(rows))
+	for i, row := range rows {
+		stores[i] = mapSQLCStore(row)
+	}
+	return stores, nil
+}
+
+func (r *storeRepository) FindOne(ctx context.Context, id string) (*models.Store, error) {
+	storeUUID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("store not found")
+	}
+
+	row, err := r.qtx.GetStore(ctx, storeUUID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("store not found")
+		}
+		return nil, fmt.Errorf("failed to get store: %w", err)
+	}
+
+	store := mapSQLCStore(row)
+	return &store, nil
+}
+
+func (r *storeRepository) Create(ctx context.Context, name string) (*models.Store, error) {
+	row, err := r.qtx.CreateStore(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create store: %w", err)
+	}
+
+	store := mapSQLCStore(row)
+	return &store, nil
+}
+
+func (r *storeRepository) Update(ctx context.Context, id, name string) (*models.Store, error) {
+	storeUUID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("store not found")
+	}
+
+	row, err := r.qtx.UpdateStore(ctx, sqlc.UpdateStoreParams{ID: storeUUID, Name: name})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("store not found")
+		}
+		return nil, fmt.Errorf("failed to update store: %w", err)
+	}
+
+	store := mapSQLCStore(row)
+	return &store, nil
+}
+
+func (r *storeRepository) FindAPIKeys(ctx context.Context, storeID string) ([]models.StoreAPIKey, error) {
+	storeUUID, err := uuid.Parse(storeID)
+	if err != nil {
+		return nil, fmt.Errorf("store not found")
+	}
+
+	rows, err := r.qtx.GetStoreAPIKeys(ctx, storeUUID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get store API keys: %w", err)
+	}
+
+	keys := make([]models.StoreAPIKey, len(rows))
+	for i, row := range rows {
+		keys[i] = mapSQLCStoreAPIKey(row)
+	}
+	return keys, nil
+}
+
+func (r *storeRepository) CreateAPIKey(ctx context.Context, key *models.StoreAPIKey, keyHash string) error {
+	storeUUID, err := uuid.Parse(key.StoreID)
+	if err != nil {
+		return fmt.Errorf("store not found")
+	}
+
+	row, err := r.qtx.CreateStoreAPIKey(ctx, sqlc.CreateStoreAPIKeyParams{
+		StoreID:   storeUUID,
+		Name:      key.Name,
+		KeyHash:   keyHash,
+		KeyPrefix: key.Prefix,
+		Test:      key.Test,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create store API key: %w", err)
+	}
+
+	key.ID = row.ID.String()
+	key.CreatedAt = row.CreatedAt
+	return nil
+}
+
+func (r *storeRepository) RevokeAPIKey(ctx context.Context, storeID, id string) error {
+	storeUUID, err := uuid.Parse(storeID)
+	if err != nil {
+		return fmt.Errorf("store API key not found")
+	}
+	keyUUID, err := uuid.Parse(id)
+	if err != nil {
+		return fmt.Errorf("store API key not found")
+	}
+
+	revoked, err := r.qtx.RevokeStoreAPIKey(ctx, sqlc.RevokeStoreAPIKeyParams{ID: keyUUID, StoreID: storeUUID})
+	if err != nil {
+		return fmt.Errorf("failed to revoke store API key: %w", err)
+	}
+	if revoked == 0 {
+		return fmt.Errorf("store API key not found")
+	}
+	return nil
+}
+
+func (r *storeRepository) FindAPIKeyByHash(ctx context.Context, keyHash string) (*models.StoreAPIKey, error) {
+	row, err := r.qtx.GetStoreAPIKeyByHash(ctx, keyHash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("store API key not found")
+		}
+		return nil, fmt.Errorf("failed to get store API key: %w", err)
+	}
+
+	key := mapSQLCStoreAPIKey(row)
+	return &key, nil
+}
+
+func (r *storeRepository) FindOpeningHours(ctx context.Context, storeID string) (*models.OpeningHours, error) {
+	storeUUID, err := uuid.Parse(storeID)
+	if err != nil {
+		return nil, fmt.Errorf("store not found")
+	}
+
+	row, err := r.qtx.GetStoreOpeningHours(ctx, storeUUID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get store opening hours: %w", err)
+	}
+
+	var hours models.OpeningHours
+	if err := json.Unmarshal(row.Hours, &hours); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal store opening hours: %w", err)
+	}
+	return &hours, nil
+}
+
+func (r *storeRepository) SaveOpeningHours(ctx context.Context, storeID string, hours *models.OpeningHours) error {
+	storeUUID, err := uuid.Parse(storeID)
+	if err != nil {
+		return fmt.Errorf("store not found")
+	}
+	hoursJSON, err := json.Marshal(hours)
+	if err != nil {
+		return fmt.Errorf("failed to marshal store opening hours: %w", err)
+	}
+
+	if err := r.qtx.SetStoreOpeningHours(ctx, sqlc.SetStoreOpeningHoursParams{StoreID: storeUUID, Hours: hoursJSON}); err != nil {
+		return fmt.Errorf("failed to save store opening hours: %w", err)
+	}
+	return nil
+}
+
+func (r *storeRepository) DeleteOpeningHours(ctx context.Context, storeID string) (bool, error) {
+	storeUUID, err := uuid.Parse(storeID)
+	if err != nil {
+		return false, fmt.Errorf("store not found")
+	}
+
+	deleted, err := r.qtx.DeleteStoreOpeningHours(ctx, storeUUID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete store opening hours: %w", err)
+	}
+	return deleted > 0, nil
+}
+
+func mapSQLCStore(row sqlc.Store) models.Store {
+	return models.Store{
+		ID:        row.ID.String(),
+		Name:      row.Name,
+		CreatedAt: row.CreatedAt,
+		UpdatedAt: row.UpdatedAt,
+	}
+}
+
+func mapSQLCStoreAPIKey(row sqlc.StoreApiKey) models.StoreAPIKey {
+	return models.StoreAPIKey{
+		ID:        row.ID.String(),
+		StoreID:   row.StoreID.String(),
+		Name:      row.Name,
+		Prefix:    row.KeyPrefix,
+		CreatedAt: row.CreatedAt,
+		RevokedAt: nullTimeToPtr(row.RevokedAt),
+		Test:      row.Test,
+	}
+}
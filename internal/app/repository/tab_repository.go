@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"oolio/internal/app/models"
+	"oolio/internal/database/sqlc"
+)
+
+// TabRepository stores the tabs of dine-in tables, at most one open per table of a store
+type TabRepository interface {
+	// Open returns the table's open tab, opening one when it has none
+	Open(ctx context.Context, storeID, tableNumber string) (*models.Tab, error)
+	// FindOpen returns the table's open tab, or "tab not found"
+	FindOpen(ctx context.Context, storeID, tableNumber string) (*models.Tab, error)
+	// FindOpenByStore lists the store's open tabs, longest open first
+	FindOpenByStore(ctx context.Context, storeID string) ([]models.Tab, error)
+	// Close closes the open tab with the given id, settled with paymentMethod. It fails
+	// with "tab not found" when there is no open tab with that id.
+	Close(ctx context.Context, id, paymentMethod string) (*models.Tab, error)
+}
+
+type tabRepository struct {
+	qtx *sqlc.Queries
+}
+
+func NewTabRepository(db *sql.DB) TabRepository {
+	return &tabRepository{qtx: sqlc.New(db)}
+}
+
+func (r *tabRepository) Open(ctx context.Context, storeID, tableNumber string) (*models.Tab, error) {
+	storeUUID, err := uuid.Parse(storeID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid store ID: %w", err)
+	}
+
+	// A tab opened by a concurrent order at the same table wins, and is joined on the retry
+	for range 2 {
+		dbTab, err := r.qtx.OpenTableTab(ctx, sqlc.OpenTableTabParams{StoreID: storeUUID, TableNumber: tableNumber})
+		if err == nil {
+			tab := convertTab(dbTab)
+			return &tab, nil
+		}
+		if err != sql.ErrNoRows {
+			return nil, fmt.Errorf("failed to open tab: %w", err)
+		}
+
+		tab, err := r.FindOpen(ctx, storeID, tableNumber)
+		if err == nil || err.Error() != "tab not found" {
+			return tab, err
+		}
+	}
+	return nil, fmt.Errorf("failed to open tab: table %s was closed and reopened concurrently", tableNumber)
+}
+
+func (r *tabRepository) FindOpen(ctx context.Context, storeID, tableNumber string) (*models.Tab, error) {
+	storeUUID, err := uuid.Parse(storeID)
+	if err != nil {
+		return nil, fmt.Errorf("tab not found")
+	}
+
+	dbTab, err := r.qtx.GetOpenTableTab(ctx, sqlc.GetOpenTableTabParams{StoreID: storeUUID, TableNumber: tableNumber})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("tab not found")
+		}
+		return nil, fmt.Errorf("failed to get tab: %w", err)
+	}
+	tab := convertTab(dbTab)
+	return &tab, nil
+}
+
+func (r *tabRepository) FindOpenByStore(ctx context.Context, storeID string) ([]models.Tab, error) {
+	storeUUID, err := uuid.Parse(storeID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid store ID: %w", err)
+	}
+
+	dbTabs, err := r.qtx.ListOpenTableTabs(ctx, storeUUID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tabs: %w", err)
+	}
+	tabs := make([]models.Tab, len(dbTabs))
+	for i, dbTab := range dbTabs {
+		tabs[i] = convertTab(dbTab)
+	}
+	return tabs, nil
+}
+
+func (r *tabRepository) Close(ctx context.Context, id, paymentMethod string) (*models.Tab, error) {
+	tabUUID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("tab not found")
+	}
+
+	dbTab, err := r.qtx.CloseTableTab(ctx, sqlc.CloseTableTabParams{ID: tabUUID, PaymentMethod: paymentMethod})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("tab not found")
+		}
+		return nil, fmt.Errorf("failed to close tab: %w", err)
+	}
+	tab := convertTab(dbTab)
+	return &tab, nil
+}
+
+func convertTab(t sqlc.TableTab) models.Tab {
+	return models.Tab{
+		ID:            t.ID.String(),
+		StoreID:       t.StoreID.String(),
+		TableNumber:   t.TableNumber,
+		Status:        t.Status,
+		PaymentMethod: t.PaymentMethod,
+		OpenedAt:      t.OpenedAt,
+		ClosedAt:      nullTimeToPtr(t.ClosedAt),
+	}
+}
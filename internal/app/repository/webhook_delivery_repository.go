@@ -0,0 +1,247 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"oolio/internal/app/models"
+	"oolio/internal/database/sqlc"
+)
+
+// WebhookDeliveryRepository holds outbound webhooks until the webhook worker posts them,
+// with a log of every attempt
+type WebhookDeliveryRepository interface {
+	// Enqueue stores a pending delivery that is due right away, giving it an ID. It reports
+	// false and stores nothing when the endpoint already has a delivery of the event.
+	Enqueue(ctx context.Context, delivery *models.WebhookDelivery) (bool, error)
+	// ClaimDue returns up to limit due deliveries, oldest first, and holds them back from
+	// other workers for lease. A worker that dies before recording the outcome leaves them
+	// to be posted again once the lease is over.
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]*models.WebhookDelivery, error)
+	// MarkDelivered, MarkFailed and MarkDead log an attempt together with its outcome.
+	// MarkFailed makes the delivery due again at retryAt; a dead one isn't posted again.
+	MarkDelivered(ctx context.Context, id string, attempt models.WebhookAttempt) error
+	MarkFailed(ctx context.Context, id string, attempt models.WebhookAttempt, retryAt time.Time) error
+	MarkDead(ctx context.Context, id string, attempt models.WebhookAttempt) error
+	// Redeliver makes a dead delivery pending again with a fresh set of attempts. It fails
+	// with "webhook delivery not found" if there is no dead delivery with that id.
+	Redeliver(ctx context.Context, id string) error
+	// FindOne returns the delivery with its attempt log, or "webhook delivery not found"
+	FindOne(ctx context.Context, id string) (*models.WebhookDelivery, error)
+	// FindPage lists the deliveries with the status, or all of them when it is empty,
+	// newest first, along with how many there are
+	FindPage(ctx context.Context, status string, page models.PageRequest) ([]models.WebhookDelivery, int, error)
+}
+
+type webhookDeliveryRepository struct {
+	db  *sql.DB
+	qtx *sqlc.Queries
+}
+
+func NewWebhookDeliveryRepository(db *sql.DB) WebhookDeliveryRepository {
+	return &webhookDeliveryRepository{db: db, qtx: sqlc.New(db)}
+}
+
+func (r *webhookDeliveryRepository) Enqueue(ctx context.Context, delivery *models.WebhookDelivery) (bool, error) {
+	id := uuid.New()
+	now := time.Now()
+	inserted, err := r.qtx.EnqueueWebhookDelivery(ctx, sqlc.EnqueueWebhookDeliveryParams{
+		ID:            id,
+		Endpoint:      delivery.Endpoint,
+		EventID:       delivery.EventID,
+		EventType:     delivery.EventType,
+		Body:          string(delivery.Body),
+		NextAttemptAt: now,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to enqueue webhook delivery: %w", err)
+	}
+	if inserted == 0 {
+		return false, nil
+	}
+
+	delivery.ID = id.String()
+	delivery.Status = models.WebhookPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = now
+	delivery.CreatedAt = now
+	return true, nil
+}
+
+func (r *webhookDeliveryRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]*models.WebhookDelivery, error) {
+	dbDeliveries, err := r.qtx.ClaimDueWebhookDeliveries(ctx, sqlc.ClaimDueWebhookDeliveriesParams{
+		Limit:         int32(limit),
+		NextAttemptAt: time.Now().Add(lease),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+
+	deliveries := make([]*models.WebhookDelivery, 0, len(dbDeliveries))
+	for _, dbDelivery := range dbDeliveries {
+		delivery := mapSQLCToWebhookDelivery(dbDelivery)
+		deliveries = append(deliveries, &delivery)
+	}
+	// RETURNING doesn't keep the subquery's order
+	sortWebhookDeliveries(deliveries)
+	return deliveries, nil
+}
+
+func (r *webhookDeliveryRepository) MarkDelivered(ctx context.Context, id string, attempt models.WebhookAttempt) error {
+	return r.recordAttempt(ctx, id, attempt, func(qtx *sqlc.Queries, deliveryID uuid.UUID) error {
+		return qtx.MarkWebhookDelivered(ctx, deliveryID)
+	})
+}
+
+func (r *webhookDeliveryRepository) MarkFailed(ctx context.Context, id string, attempt models.WebhookAttempt, retryAt time.Time) error {
+	return r.recordAttempt(ctx, id, attempt, func(qtx *sqlc.Queries, deliveryID uuid.UUID) error {
+		return qtx.MarkWebhookDeliveryFailed(ctx, sqlc.MarkWebhookDeliveryFailedParams{
+			ID:            deliveryID,
+			LastError:     stringToNullString(attempt.Error),
+			NextAttemptAt: retryAt,
+		})
+	})
+}
+
+func (r *webhookDeliveryRepository) MarkDead(ctx context.Context, id string, attempt models.WebhookAttempt) error {
+	return r.recordAttempt(ctx, id, attempt, func(qtx *sqlc.Queries, deliveryID uuid.UUID) error {
+		return qtx.MarkWebhookDeliveryDead(ctx, sqlc.MarkWebhookDeliveryDeadParams{
+			ID:        deliveryID,
+			LastError: stringToNullString(attempt.Error),
+		})
+	})
+}
+
+// recordAttempt logs the attempt and records its outcome with mark in one transaction
+func (r *webhookDeliveryRepository) recordAttempt(ctx context.Context, id string, attempt models.WebhookAttempt, mark func(qtx *sqlc.Queries, deliveryID uuid.UUID) error) error {
+	deliveryID, err := uuid.Parse(id)
+	if err != nil {
+		return fmt.Errorf("invalid webhook delivery ID: %w", err)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	qtx := r.qtx.WithTx(tx)
+
+	err = qtx.InsertWebhookDeliveryAttempt(ctx, sqlc.InsertWebhookDeliveryAttemptParams{
+		DeliveryID:  deliveryID,
+		Url:         attempt.URL,
+		StatusCode:  int32(attempt.StatusCode),
+		Error:       attempt.Error,
+		DurationMs:  int32(attempt.DurationMS),
+		AttemptedAt: attempt.AttemptedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to log webhook attempt: %w", err)
+	}
+	if err := mark(qtx, deliveryID); err != nil {
+		return fmt.Errorf("failed to record webhook attempt: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit webhook attempt: %w", err)
+	}
+	return nil
+}
+
+func (r *webhookDeliveryRepository) Redeliver(ctx context.Context, id string) error {
+	deliveryID, err := uuid.Parse(id)
+	if err != nil {
+		return fmt.Errorf("webhook delivery not found")
+	}
+	updated, err := r.qtx.RedeliverWebhookDelivery(ctx, deliveryID)
+	if err != nil {
+		return fmt.Errorf("failed to redeliver webhook: %w", err)
+	}
+	if updated == 0 {
+		return fmt.Errorf("webhook delivery not found")
+	}
+	return nil
+}
+
+func (r *webhookDeliveryRepository) FindOne(ctx context.Context, id string) (*models.WebhookDelivery, error) {
+	deliveryID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("webhook delivery not found")
+	}
+
+	dbDelivery, err := r.qtx.GetWebhookDelivery(ctx, deliveryID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("webhook delivery not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook delivery: %w", err)
+	}
+	dbAttempts, err := r.qtx.GetWebhookDeliveryAttempts(ctx, deliveryID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook attempts: %w", err)
+	}
+
+	delivery := mapSQLCToWebhookDelivery(dbDelivery)
+	delivery.AttemptLog = make([]models.WebhookAttempt, len(dbAttempts))
+	for i, dbAttempt := range dbAttempts {
+		delivery.AttemptLog[i] = models.WebhookAttempt{
+			URL:         dbAttempt.Url,
+			StatusCode:  int(dbAttempt.StatusCode),
+			Error:       dbAttempt.Error,
+			DurationMS:  int(dbAttempt.DurationMs),
+			AttemptedAt: dbAttempt.AttemptedAt,
+		}
+	}
+	return &delivery, nil
+}
+
+func (r *webhookDeliveryRepository) FindPage(ctx context.Context, status string, page models.PageRequest) ([]models.WebhookDelivery, int, error) {
+	page = page.Normalize()
+	total, err := r.qtx.CountWebhookDeliveries(ctx, status)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count webhook deliveries: %w", err)
+	}
+	dbDeliveries, err := r.qtx.ListWebhookDeliveries(ctx, sqlc.ListWebhookDeliveriesParams{
+		Status:     status,
+		PageLimit:  int32(page.Limit),
+		PageOffset: int32(page.Offset),
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+
+	deliveries := make([]models.WebhookDelivery, len(dbDeliveries))
+	for i, dbDelivery := range dbDeliveries {
+		deliveries[i] = mapSQLCToWebhookDelivery(dbDelivery)
+	}
+	return deliveries, int(total), nil
+}
+
+func mapSQLCToWebhookDelivery(dbDelivery sqlc.WebhookDelivery) models.WebhookDelivery {
+	return models.WebhookDelivery{
+		ID:            dbDelivery.ID.String(),
+		Endpoint:      dbDelivery.Endpoint,
+		EventID:       dbDelivery.EventID,
+		EventType:     dbDelivery.EventType,
+		Body:          json.RawMessage(dbDelivery.Body),
+		Status:        dbDelivery.Status,
+		Attempts:      int(dbDelivery.Attempts),
+		NextAttemptAt: dbDelivery.NextAttemptAt,
+		LastError:     nullStringToString(dbDelivery.LastError),
+		CreatedAt:     dbDelivery.CreatedAt,
+		DeliveredAt:   nullTimeToPtr(dbDelivery.DeliveredAt),
+	}
+}
+
+func sortWebhookDeliveries(deliveries []*models.WebhookDelivery) {
+	sort.Slice(deliveries, func(i, j int) bool {
+		return deliveries[i].CreatedAt.Before(deliveries[j].CreatedAt)
+	})
+}
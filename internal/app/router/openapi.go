@@ -0,0 +1,882 @@
+package router
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"oolio/internal/app/middleware"
+	"oolio/internal/app/models"
+	"oolio/internal/app/openapi"
+	"oolio/internal/config"
+	"oolio/internal/graphql"
+	"oolio/internal/scheduler"
+	"oolio/internal/workerpool"
+)
+
+const (
+	SpecPath = "/openapi.json"
+	DocsPath = "/docs"
+)
+
+var (
+	apiResponse = models.ApiResponse{}
+
+	updatedSinceParam = openapi.Param{
+		Name:        "updated_since",
+		Description: "Only return items changed at or after this RFC 3339 timestamp",
+		Format:      "date-time",
+	}
+	deletedSinceParam = openapi.Param{
+		Name:        "deleted_since",
+		Description: "Only return items deleted at or after this RFC 3339 timestamp (default 30 days ago)",
+		Format:      "date-time",
+	}
+	versionHeader = openapi.Header{Name: middleware.APIVersionHeader, Description: "The API version that served the response"}
+
+	// Set by the rate limiter on the responses of the routes it limits
+	rateLimitHeaders = []openapi.Header{
+		{Name: "X-RateLimit-Limit", Description: "Requests allowed per minute"},
+		{Name: "X-RateLimit-Remaining", Description: "Requests left in the current window"},
+		{Name: "X-RateLimit-Reset", Description: "Unix time the window ends"},
+	}
+	scheduleFeedParams = []openapi.Param{
+		{Name: "store_id", Description: "The store the URL was issued for, if any"},
+		{Name: "expires", Type: "string", Required: true, Description: "Unix time the URL expires"},
+		{Name: "signature", Type: "string", Required: true, Description: "HMAC of the store and expiry"},
+	}
+)
+
+// Operations documents every route SetupRouter registers. Keep it next to the route table:
+// the OpenAPI document is built from it and a test fails when the two disagree.
+func Operations() []openapi.Operation {
+	var ops []openapi.Operation
+
+	// Later API versions serve the same routes as v1, and every version names itself in
+	// the API-Version header
+	v1 := APIPrefix(1)
+	for _, op := range baseOperations() {
+		if !strings.HasPrefix(op.Path, v1+"/") {
+			ops = append(ops, op)
+		}
+	}
+	for _, version := range APIVersions {
+		for _, op := range baseOperations() {
+			if rest, ok := strings.CutPrefix(op.Path, v1+"/"); ok {
+				op.Path = APIPrefix(version) + "/" + rest
+				op.Headers = append([]openapi.Header{versionHeader}, op.Headers...)
+				ops = append(ops, op)
+			}
+		}
+	}
+	return ops
+}
+
+// baseOperations lists the routes outside the API versions and the v1 routes
+func baseOperations() []openapi.Operation {
+	return []openapi.Operation{
+		{
+			Method: http.MethodGet, Path: "/health", Tag: "health",
+			Summary:   "Liveness check",
+			Responses: map[int]any{http.StatusOK: gin.H{}},
+		},
+
+		{
+			Method: http.MethodGet, Path: "/api/v1/status", Tag: "health",
+			Summary:     "Public service status",
+			Description: "Coarse health, whether orders are accepted and the estimated wait, for display in ordering frontends. No authentication; cached for a few seconds.",
+			Responses:   map[int]any{http.StatusOK: models.ServiceStatus{}},
+		},
+
+		{
+			Method: http.MethodGet, Path: "/files/*key", Tag: "files",
+			Summary:     "Download a stored file",
+			Description: "Serves uploaded files such as product images. Files never change, so responses may be cached indefinitely.",
+			Responses:   map[int]any{http.StatusOK: nil, http.StatusNotFound: apiResponse},
+		},
+
+		// Products
+		{
+			Method: http.MethodGet, Path: "/api/v1/product", Tag: "product", Auth: true,
+			Summary:     "List products",
+			Description: "The menu of the X-Store-ID store, sorted by name. limit or offset page it, and X-Total-Count tells how many products there are on every page; a sync with updated_since isn't paged (400).",
+			Query: []openapi.Param{
+				updatedSinceParam,
+				{Name: "limit", Type: "integer", Description: "Page size (default 20, max 100); without limit and offset every product is listed"},
+				{Name: "offset", Type: "integer", Description: "Number of products to skip"},
+			},
+			Responses: map[int]any{http.StatusOK: []models.Product{}, http.StatusBadRequest: apiResponse, http.StatusServiceUnavailable: apiResponse},
+			Headers:   append([]openapi.Header{{Name: models.TotalCountHeader, Description: "Products on every page"}}, rateLimitHeaders...),
+		},
+		{
+			Method: http.MethodGet, Path: "/api/v1/product/changes", Tag: "product", Auth: true,
+			Summary:     "Catalog change feed",
+			Description: "The products of the X-Store-ID store created, updated or deleted after the cursor since, oldest first, so POS terminals and caches can sync the menu without fetching all of it. Read from the start, the feed has every product. Each change carries the product as it is now, apart from deletions; restored products are created again. Pass the returned cursor as since for the next page, straight away while hasMore. 400 for a cursor the feed didn't hand out.",
+			Query: []openapi.Param{
+				{Name: "since", Type: "string", Description: "Cursor of the last change seen; the start of the feed when omitted"},
+				{Name: "limit", Type: "integer", Description: "Changes per page (default 20, max 100)"},
+			},
+			Responses: map[int]any{http.StatusOK: models.ProductChanges{}, http.StatusBadRequest: apiResponse},
+			Headers:   rateLimitHeaders,
+		},
+		{
+			Method: http.MethodGet, Path: "/api/v1/product/:productId", Tag: "product", Auth: true,
+			Summary:   "Find product by ID",
+			Responses: map[int]any{http.StatusOK: models.Product{}, http.StatusBadRequest: apiResponse, http.StatusNotFound: apiResponse},
+			Headers:   rateLimitHeaders,
+		},
+
+		// Orders
+		{
+			Method: http.MethodPost, Path: "/api/v1/order", Tag: "order", Auth: true,
+			Summary:     "Place an order",
+			Description: "The order is queued and processed in the background; poll GET /order/{orderId} with the returned queueItemId. addressId delivers to a saved address of the X-Customer-ID customer (\"default\" for the default one); deliveryAddress gives one inline. Orders without X-Customer-ID also return a lookupToken when lookup tokens are enabled, and those worth VERIFICATION_GUEST_ORDER_MIN or more need X-Verification-Token (403 without). Single-use coupons need X-Customer-ID, or a phone or email from guests, and answer 409 once used. paymentIntentId names an intent authorized for the total, captured when the order is created; with PAYMENT_REQUIRED, card orders without one wait for POST /order/{orderId}/pay. paymentMethod is card, cash (paid on delivery, so it needs a delivery address) or counter (paid when collected, so no delivery); it defaults to the first of PAYMENT_METHODS and answers 400 when not accepted. X-Currency (e.g. usd) asks for the total in a currency of CURRENCY_SETTLEMENT too, given as the order's settlement; it is still paid in PAYMENT_CURRENCY, and other currencies answer 400. Orders placed while the store is closed answer 409 with its nextOpenAt, or are scheduled for it when the store schedules them; scheduledFor asks for a time within 30 days when the store is open. Scheduled orders return their scheduledFor and wait in the queue until then. tableNumber orders to a dine-in table of the store: the order joins the table's open tab, opening one if needed, and returns its tabId; it is paid at the counter when staff close the tab, so it takes no delivery, payment intent or scheduledFor (400). Orders placed with a test API key are sandbox orders: they are prepared without taking payment or stock or notifying anyone, and can't have a paymentIntentId (422) or tableNumber (400). Items of a product with variants are charged for variantId, or its first variant, and for the modifierIds they add, each at most once; options the product doesn't have answer 422, and orders whose options are deleted before they are processed fail with errorCode invalid_option. Orders of more of a product than is left in stock, besides what other queued orders have reserved, answer 422. Otherwise the stock is reserved for the order for INVENTORY_RESERVATION_TTL and taken when it is processed; orders processed after their reservation expired, e.g. while waiting for payment, and scheduled orders, which reserve none, fail with errorCode out_of_stock when it has run out by then.",
+			Body:        models.OrderReq{},
+			Responses:   map[int]any{http.StatusAccepted: models.QueuedOrder{}, http.StatusBadRequest: apiResponse, http.StatusForbidden: apiResponse, http.StatusConflict: apiResponse, http.StatusUnprocessableEntity: apiResponse},
+			Headers:     rateLimitHeaders,
+		},
+		{
+			Method: http.MethodGet, Path: "/api/v1/order", Tag: "order", Auth: true,
+			Summary:   "List queued and completed orders with queue stats",
+			Query:     []openapi.Param{updatedSinceParam},
+			Responses: map[int]any{http.StatusOK: models.OrderList{}, http.StatusBadRequest: apiResponse},
+			Headers:   rateLimitHeaders,
+		},
+		{
+			Method: http.MethodGet, Path: "/api/v1/admin/queue/:itemId", Tag: "admin", Auth: true,
+			Summary:     "Inspect a queue item",
+			Description: "Everything known about a queued order, to debug one that is stuck or failed: the request as placed, every failed attempt with its time and error, the worker instance that last claimed it (WORKER_INSTANCE_ID) and the ID of the order it produced. 404 for an unknown item.",
+			Responses:   map[int]any{http.StatusOK: models.QueueItemDetails{}, http.StatusNotFound: apiResponse},
+		},
+		{
+			Method: http.MethodPost, Path: "/api/v1/admin/queue/:itemId/retry", Tag: "admin", Auth: true,
+			Summary:     "Retry a failed order",
+			Description: "Puts an order that failed for good back in the queue with a fresh set of retries, like `queue retry`. 404 when the queue item hasn't failed.",
+			Responses:   map[int]any{http.StatusOK: apiResponse, http.StatusNotFound: apiResponse},
+		},
+		{
+			Method: http.MethodGet, Path: "/api/v1/order/:orderId", Tag: "order", Auth: true,
+			Summary:     "Find order by order or queue item ID",
+			Description: "Guests may send the lookupToken of their order in X-Order-Token instead of the API key; it only reads the queue item it was issued for. Orders placed with a deliveryAddress carry their delivery once a courier is booked, with its status, tracking page and estimated delivery time.",
+			Responses:   map[int]any{http.StatusOK: models.Order{}, http.StatusForbidden: apiResponse, http.StatusNotFound: apiResponse},
+			Headers:     rateLimitHeaders,
+		},
+		{
+			Method: http.MethodPost, Path: "/api/v1/order/:orderId/pay", Tag: "order", Auth: true,
+			Summary:     "Pay for a queued order",
+			Description: "orderId is the queueItemId. Creates a payment intent for the order's total and returns its clientSecret; an intent the order already has is returned while it can still pay. The order is processed once the intent is authorized, and fails if it isn't within PAYMENT_WINDOW. 404 when PAYMENT_PROVIDER is none; 409 once the order is processed; 422 for cash, counter and sandbox orders.",
+			Responses:   map[int]any{http.StatusOK: models.PaymentIntent{}, http.StatusCreated: models.PaymentIntent{}, http.StatusForbidden: apiResponse, http.StatusNotFound: apiResponse, http.StatusConflict: apiResponse, http.StatusUnprocessableEntity: apiResponse, http.StatusBadGateway: apiResponse},
+			Headers:     rateLimitHeaders,
+		},
+		{
+			Method: http.MethodGet, Path: "/api/v1/order/:orderId/invoice", Tag: "order", Auth: true,
+			Summary:     "Get the tax invoice of an order",
+			Description: "orderId is the order ID or queueItemId. The invoice is numbered on first request, in the next number of INVOICE_SERIES for the order's store without gaps, and returned unchanged afterwards. Orders of other stores answer 404. Prices include the tax, broken down per rate. 409 until the order is created and its payment captured, and for cancelled or failed orders.",
+			Responses:   map[int]any{http.StatusOK: models.Invoice{}, http.StatusForbidden: apiResponse, http.StatusNotFound: apiResponse, http.StatusConflict: apiResponse},
+			Headers:     rateLimitHeaders,
+		},
+		{
+			Method: http.MethodGet, Path: "/api/v1/queue/status", Tag: "order", Auth: true,
+			Summary:   "Order queue counts by status",
+			Responses: map[int]any{http.StatusOK: models.QueueStatus{}},
+			Headers:   rateLimitHeaders,
+		},
+		{
+			Method: http.MethodGet, Path: "/api/v1/queue/:itemId/retry", Tag: "order",
+			Summary:     "Confirm retrying a failed order",
+			Description: "Opened from the retry link in a failed order alert, not by clients: expires and signature, signed with ALERT_RETRY_LINK_SECRET, replace the API key. Returns an HTML page whose button posts back to the link. 403 for a bad or expired link.",
+			Query: []openapi.Param{
+				{Name: "expires", Type: "string", Required: true, Description: "Unix time the link expires"},
+				{Name: "signature", Type: "string", Required: true, Description: "HMAC of the item ID and expiry"},
+			},
+			Responses: map[int]any{http.StatusOK: nil, http.StatusForbidden: nil},
+			Headers:   rateLimitHeaders,
+		},
+		{
+			Method: http.MethodGet, Path: "/api/v1/admin/orders/schedule.ics", Tag: "admin",
+			Summary:     "Scheduled orders as an iCalendar feed",
+			Description: "Subscribed to from calendar tools with a URL from GET /admin/orders/schedule-links: expires and signature, signed with SCHEDULE_FEED_SECRET, replace the API key. Lists the orders scheduled from a day ago onwards, one event each. 403 for a bad or expired URL.",
+			Query:       scheduleFeedParams,
+			Responses:   map[int]any{http.StatusOK: nil, http.StatusForbidden: apiResponse},
+			Headers:     rateLimitHeaders,
+		},
+		{
+			Method: http.MethodGet, Path: "/api/v1/admin/orders/schedule.csv", Tag: "admin",
+			Summary:     "Scheduled orders as CSV",
+			Description: "The schedule.ics feed as CSV, one row per order, with the same signed URL parameters.",
+			Query:       scheduleFeedParams,
+			Responses:   map[int]any{http.StatusOK: nil, http.StatusForbidden: apiResponse},
+			Headers:     rateLimitHeaders,
+		},
+		{
+			Method: http.MethodPost, Path: "/api/v1/queue/:itemId/retry", Tag: "order",
+			Summary:     "Retry a failed order from an alert link",
+			Description: "Posted by the page of GET /queue/{itemId}/retry with the same expires and signature. Puts the order back in the queue with a fresh set of retries and returns an HTML page. 403 for a bad or expired link; 409 when the order isn't failed.",
+			Query: []openapi.Param{
+				{Name: "expires", Type: "string", Required: true, Description: "Unix time the link expires"},
+				{Name: "signature", Type: "string", Required: true, Description: "HMAC of the item ID and expiry"},
+			},
+			Responses: map[int]any{http.StatusOK: nil, http.StatusForbidden: nil, http.StatusConflict: nil},
+			Headers:   rateLimitHeaders,
+		},
+
+		// Customers; the customer is named by the X-Customer-ID header
+		{
+			Method: http.MethodGet, Path: "/api/v1/customer/me/addresses", Tag: "customer", Auth: true,
+			Summary:     "List saved delivery addresses",
+			Description: "The default address comes first. Requires the X-Customer-ID header.",
+			Responses:   map[int]any{http.StatusOK: []models.Address{}, http.StatusBadRequest: apiResponse},
+			Headers:     rateLimitHeaders,
+		},
+		{
+			Method: http.MethodPost, Path: "/api/v1/customer/me/addresses", Tag: "customer", Auth: true,
+			Summary:     "Save a delivery address",
+			Description: "The first address saved becomes the default. Requires the X-Customer-ID header.",
+			Body:        models.AddressReq{},
+			Responses:   map[int]any{http.StatusCreated: models.Address{}, http.StatusBadRequest: apiResponse, http.StatusConflict: apiResponse},
+			Headers:     rateLimitHeaders,
+		},
+		{
+			Method: http.MethodDelete, Path: "/api/v1/customer/me/addresses/:addressId", Tag: "customer", Auth: true,
+			Summary:     "Delete a saved address",
+			Description: "Deleting the default address makes the newest remaining one the default. Requires the X-Customer-ID header.",
+			Responses:   map[int]any{http.StatusOK: apiResponse, http.StatusNotFound: apiResponse},
+			Headers:     rateLimitHeaders,
+		},
+		{
+			Method: http.MethodPost, Path: "/api/v1/customer/me/addresses/:addressId/default", Tag: "customer", Auth: true,
+			Summary:     "Make a saved address the default",
+			Description: "Requires the X-Customer-ID header.",
+			Responses:   map[int]any{http.StatusOK: apiResponse, http.StatusNotFound: apiResponse},
+			Headers:     rateLimitHeaders,
+		},
+		{
+			Method: http.MethodGet, Path: "/api/v1/customer/me/favorites", Tag: "customer", Auth: true,
+			Summary:     "List favorite products",
+			Description: "Newest favorite first. Requires the X-Customer-ID header.",
+			Responses:   map[int]any{http.StatusOK: []models.Product{}, http.StatusBadRequest: apiResponse},
+			Headers:     rateLimitHeaders,
+		},
+		{
+			Method: http.MethodPut, Path: "/api/v1/customer/me/favorites/:productId", Tag: "customer", Auth: true,
+			Summary:     "Add a product to the favorites",
+			Description: "Adding a favorite twice changes nothing. Requires the X-Customer-ID header.",
+			Responses:   map[int]any{http.StatusOK: apiResponse, http.StatusNotFound: apiResponse, http.StatusConflict: apiResponse},
+			Headers:     rateLimitHeaders,
+		},
+		{
+			Method: http.MethodDelete, Path: "/api/v1/customer/me/favorites/:productId", Tag: "customer", Auth: true,
+			Summary:     "Remove a product from the favorites",
+			Description: "Requires the X-Customer-ID header.",
+			Responses:   map[int]any{http.StatusOK: apiResponse, http.StatusNotFound: apiResponse},
+			Headers:     rateLimitHeaders,
+		},
+		{
+			Method: http.MethodGet, Path: "/api/v1/customer/me/notifications", Tag: "customer", Auth: true,
+			Summary:     "Page through in-app notifications",
+			Description: "The customer's activity feed, newest first: an entry when each of their orders is received and when it is ready, whatever their email and SMS preferences. unread counts every unread notification, e.g. for a badge. Requires the X-Customer-ID header.",
+			Query: []openapi.Param{
+				{Name: "unread", Type: "boolean", Description: "Only unread notifications (default false)"},
+				{Name: "limit", Type: "integer", Description: "Page size (default 20, max 100)"},
+				{Name: "offset", Type: "integer", Description: "Number of notifications to skip"},
+			},
+			Responses: map[int]any{http.StatusOK: models.NotificationFeed{}, http.StatusBadRequest: apiResponse},
+			Headers:   rateLimitHeaders,
+		},
+		{
+			Method: http.MethodPost, Path: "/api/v1/customer/me/notifications/read", Tag: "customer", Auth: true,
+			Summary:     "Mark all in-app notifications read",
+			Description: "marked is how many were unread. Requires the X-Customer-ID header.",
+			Responses:   map[int]any{http.StatusOK: gin.H{}, http.StatusBadRequest: apiResponse},
+			Headers:     rateLimitHeaders,
+		},
+		{
+			Method: http.MethodPost, Path: "/api/v1/customer/me/notifications/:notificationId/read", Tag: "customer", Auth: true,
+			Summary:     "Mark an in-app notification read",
+			Description: "A notification read before keeps when it was first read. Requires the X-Customer-ID header.",
+			Responses:   map[int]any{http.StatusOK: gin.H{}, http.StatusBadRequest: apiResponse, http.StatusNotFound: apiResponse},
+			Headers:     rateLimitHeaders,
+		},
+		{
+			Method: http.MethodGet, Path: "/api/v1/customer/me/notifications/preferences", Tag: "customer", Auth: true,
+			Summary:     "Get notification preferences",
+			Description: "Customers that never saved preferences get the defaults: email receipts only, and no updatedAt. Requires the X-Customer-ID header.",
+			Responses:   map[int]any{http.StatusOK: models.NotificationPreferences{}, http.StatusBadRequest: apiResponse},
+			Headers:     rateLimitHeaders,
+		},
+		{
+			Method: http.MethodPut, Path: "/api/v1/customer/me/notifications/preferences", Tag: "customer", Auth: true,
+			Summary:     "Replace notification preferences",
+			Description: "Receipts and marketing are emailed, and ready alerts texted or emailed, so each needs the email or phone to go to. Requires the X-Customer-ID header.",
+			Body:        models.NotificationPreferencesReq{},
+			Responses:   map[int]any{http.StatusOK: models.NotificationPreferences{}, http.StatusBadRequest: apiResponse},
+			Headers:     rateLimitHeaders,
+		},
+		{
+			Method: http.MethodPut, Path: "/api/v1/customer/me/notifications", Tag: "customer", Auth: true,
+			Summary:     "Replace notification preferences (previous path)",
+			Description: "Same as PUT /customer/me/notifications/preferences, kept for apps that saved preferences here before the path became the feed. Requires the X-Customer-ID header.",
+			Body:        models.NotificationPreferencesReq{},
+			Responses:   map[int]any{http.StatusOK: models.NotificationPreferences{}, http.StatusBadRequest: apiResponse},
+			Headers:     rateLimitHeaders,
+		},
+		{
+			Method: http.MethodGet, Path: "/api/v1/customer/me/devices", Tag: "customer", Auth: true,
+			Summary:     "List devices registered for push notifications",
+			Description: "Newest first. Requires the X-Customer-ID header.",
+			Responses:   map[int]any{http.StatusOK: []models.PushDevice{}, http.StatusBadRequest: apiResponse},
+			Headers:     rateLimitHeaders,
+		},
+		{
+			Method: http.MethodPost, Path: "/api/v1/customer/me/devices", Tag: "customer", Auth: true,
+			Summary:     "Register a device for push notifications",
+			Description: "The device is sent a push notification when each of the customer's orders is received and when it is ready, in environments with push notifications turned on. A token registered before moves to this customer, and tokens the platform rejects are forgotten. Platforms without a push provider are rejected. Requires the X-Customer-ID header.",
+			Body:        models.PushDeviceReq{},
+			Responses:   map[int]any{http.StatusOK: models.PushDevice{}, http.StatusBadRequest: apiResponse},
+			Headers:     rateLimitHeaders,
+		},
+		{
+			Method: http.MethodDelete, Path: "/api/v1/customer/me/devices/:token", Tag: "customer", Auth: true,
+			Summary:     "Unregister a device",
+			Description: "Requires the X-Customer-ID header.",
+			Responses:   map[int]any{http.StatusOK: apiResponse, http.StatusBadRequest: apiResponse, http.StatusNotFound: apiResponse},
+			Headers:     rateLimitHeaders,
+		},
+
+		// Carts belong to the X-Customer-ID customer, or for guests are named by X-Cart-Token
+		{
+			Method: http.MethodGet, Path: "/api/v1/cart", Tag: "cart", Auth: true,
+			Summary:     "Current cart",
+			Description: "Without a cart yet the cart is empty and has no id. An unknown or expired X-Cart-Token is a 404.",
+			Responses:   map[int]any{http.StatusOK: models.Cart{}, http.StatusNotFound: apiResponse},
+			Headers:     rateLimitHeaders,
+		},
+		{
+			Method: http.MethodDelete, Path: "/api/v1/cart", Tag: "cart", Auth: true,
+			Summary:   "Empty the cart",
+			Responses: map[int]any{http.StatusOK: apiResponse, http.StatusNotFound: apiResponse},
+			Headers:   rateLimitHeaders,
+		},
+		{
+			Method: http.MethodPost, Path: "/api/v1/cart/items", Tag: "cart", Auth: true,
+			Summary:     "Add a product to the cart",
+			Description: "Guests without X-Cart-Token get a new cart; its id is their token from then on. variantId and modifierIds choose the product's options, as in an order item, and replace those of a product already in the cart; options the product doesn't have answer 422.",
+			Body:        models.CartItemReq{},
+			Responses: map[int]any{
+				http.StatusOK: models.Cart{}, http.StatusBadRequest: apiResponse, http.StatusNotFound: apiResponse, http.StatusConflict: apiResponse,
+				http.StatusUnprocessableEntity: apiResponse,
+			},
+			Headers: rateLimitHeaders,
+		},
+		{
+			Method: http.MethodPut, Path: "/api/v1/cart/items/:productId", Tag: "cart", Auth: true,
+			Summary:   "Change the quantity of a product in the cart",
+			Body:      models.CartQuantityReq{},
+			Responses: map[int]any{http.StatusOK: models.Cart{}, http.StatusBadRequest: apiResponse, http.StatusNotFound: apiResponse},
+			Headers:   rateLimitHeaders,
+		},
+		{
+			Method: http.MethodDelete, Path: "/api/v1/cart/items/:productId", Tag: "cart", Auth: true,
+			Summary:   "Remove a product from the cart",
+			Responses: map[int]any{http.StatusOK: models.Cart{}, http.StatusNotFound: apiResponse},
+			Headers:   rateLimitHeaders,
+		},
+		{
+			Method: http.MethodPost, Path: "/api/v1/cart/checkout", Tag: "cart", Auth: true,
+			Summary:     "Order the cart's items",
+			Description: "Queues the order like POST /order and empties the cart. The body is optional.",
+			Body:        models.CheckoutReq{},
+			Responses:   map[int]any{http.StatusAccepted: models.QueuedOrder{}, http.StatusBadRequest: apiResponse, http.StatusForbidden: apiResponse, http.StatusNotFound: apiResponse, http.StatusConflict: apiResponse, http.StatusUnprocessableEntity: apiResponse},
+			Headers:     rateLimitHeaders,
+		},
+
+		// Phone verification; 404 when VERIFICATION_PROVIDER is none
+		{
+			Method: http.MethodPost, Path: "/api/v1/verification/send", Tag: "verification", Auth: true,
+			Summary:     "Text a one-time code to a phone",
+			Description: "Check the code with the returned verificationId at POST /verification/check before it expires.",
+			Body:        models.VerificationReq{},
+			Responses:   map[int]any{http.StatusAccepted: models.VerificationChallenge{}, http.StatusBadRequest: apiResponse, http.StatusNotFound: apiResponse, http.StatusBadGateway: apiResponse},
+			Headers:     rateLimitHeaders,
+		},
+		{
+			Method: http.MethodPost, Path: "/api/v1/verification/check", Tag: "verification", Auth: true,
+			Summary:     "Check a texted code",
+			Description: "A right code returns a token proving the phone was verified; guest orders that need it send it in X-Verification-Token.",
+			Body:        models.VerificationCheckReq{},
+			Responses:   map[int]any{http.StatusOK: models.VerifiedPhone{}, http.StatusBadRequest: apiResponse, http.StatusNotFound: apiResponse, http.StatusUnprocessableEntity: apiResponse},
+			Headers:     rateLimitHeaders,
+		},
+
+		// Payments; 404 when PAYMENT_PROVIDER is none
+		{
+			Method: http.MethodPost, Path: "/api/v1/payments/intents", Tag: "payment", Auth: true,
+			Summary:     "Start a payment for an order",
+			Description: "Prices the order in the body as POST /order would and creates an intent for that amount. Authorize it with the provider using clientSecret, then place the order with its paymentIntentId. 422 for test API keys, whose orders take no payment.",
+			Body:        models.OrderReq{},
+			Responses:   map[int]any{http.StatusCreated: models.PaymentIntent{}, http.StatusBadRequest: apiResponse, http.StatusNotFound: apiResponse, http.StatusUnprocessableEntity: apiResponse, http.StatusBadGateway: apiResponse},
+			Headers:     rateLimitHeaders,
+		},
+		{
+			Method: http.MethodGet, Path: "/api/v1/payments/intents/:intentId", Tag: "payment", Auth: true,
+			Summary:   "Get a payment intent's status",
+			Responses: map[int]any{http.StatusOK: models.PaymentIntent{}, http.StatusNotFound: apiResponse, http.StatusBadGateway: apiResponse},
+			Headers:   rateLimitHeaders,
+		},
+		{
+			Method: http.MethodPost, Path: "/api/v1/payments/webhook", Tag: "payment",
+			Summary:     "Receive a payment provider event",
+			Description: "Called by the payment provider, not clients: the Stripe-Signature header, signed with PAYMENT_WEBHOOK_SECRET within PAYMENT_WEBHOOK_TOLERANCE, replaces the API key. An authorized intent made by POST /order/{orderId}/pay lets its order be prepared at once; a succeeded intent marks an order whose capture wasn't recorded as captured and fulfills it. Events are applied once, repeats answer duplicate. 404 when PAYMENT_WEBHOOK_SECRET is unset; 400 for a bad signature or payload; 503 for a succeeded intent whose order isn't created yet, so the provider redelivers it.",
+			Responses:   map[int]any{http.StatusOK: models.PaymentWebhookAck{}, http.StatusBadRequest: apiResponse, http.StatusNotFound: apiResponse, http.StatusServiceUnavailable: apiResponse},
+		},
+		{
+			Method: http.MethodPost, Path: "/api/v1/notifications/sms/status", Tag: "notification",
+			Summary:     "Receive an SMS delivery report",
+			Description: "Called by Twilio, not clients, at NOTIFICATION_SMS_STATUS_CALLBACK_URL: the X-Twilio-Signature header replaces the API key. Delivered and undelivered texts are recorded as order.sms_status events of their order. 404 unless NOTIFICATION_SMS_PROVIDER is twilio; 400 for a bad signature.",
+			Responses:   map[int]any{http.StatusOK: gin.H{}, http.StatusBadRequest: apiResponse, http.StatusNotFound: apiResponse},
+		},
+		{
+			Method: http.MethodPost, Path: "/api/v1/deliveries/webhook", Tag: "delivery",
+			Summary:     "Receive a delivery status update",
+			Description: "Called by the webhook delivery provider, not clients, when a delivery moves on: X-Oolio-Signature over X-Oolio-Delivery, X-Oolio-Timestamp and the body, signed with DELIVERY_STATUS_SECRET like outbound webhooks, replaces the API key. Updates behind the delivery's status and updates of ended deliveries change nothing. 404 when DELIVERY_PROVIDER is none or the order has no delivery; 400 for a bad signature or payload.",
+			Body:        models.DeliveryStatusUpdate{},
+			Responses:   map[int]any{http.StatusOK: models.Delivery{}, http.StatusBadRequest: apiResponse, http.StatusNotFound: apiResponse},
+		},
+
+		// Dine-in tables
+		{
+			Method: http.MethodGet, Path: "/api/v1/tables/:tableNumber/tab", Tag: "table", Auth: true,
+			Summary:     "Get a table's open tab",
+			Description: "The orders placed at the table since its tab was opened, as rounds oldest first, and the total of those created so far. 404 when the table has no open tab.",
+			Responses:   map[int]any{http.StatusOK: models.Tab{}, http.StatusNotFound: apiResponse},
+			Headers:     rateLimitHeaders,
+		},
+
+		// Gift cards
+		{
+			Method: http.MethodGet, Path: "/api/v1/gift-cards/:code", Tag: "gift-card", Auth: true,
+			Summary:     "Get a gift card's balance",
+			Description: "Spend it by sending its code as giftCardCode with an order. Limited to 10 requests/minute.",
+			Responses:   map[int]any{http.StatusOK: models.GiftCardBalance{}, http.StatusNotFound: apiResponse},
+			Headers:     rateLimitHeaders,
+		},
+
+		// Admin
+		{
+			Method: http.MethodGet, Path: "/api/v1/admin/log-level", Tag: "admin", Auth: true,
+			Summary:   "Current log level",
+			Responses: map[int]any{http.StatusOK: models.LogLevelReq{}},
+		},
+		{
+			Method: http.MethodPut, Path: "/api/v1/admin/log-level", Tag: "admin", Auth: true,
+			Summary:   "Change the log level at runtime",
+			Body:      models.LogLevelReq{},
+			Responses: map[int]any{http.StatusOK: models.LogLevelReq{}, http.StatusBadRequest: apiResponse},
+		},
+		{
+			Method: http.MethodGet, Path: "/api/v1/admin/coupons/stats", Tag: "admin", Auth: true,
+			Summary:   "Coupon file processing stats",
+			Responses: map[int]any{http.StatusOK: models.CouponStats{}},
+		},
+		{
+			Method: http.MethodPost, Path: "/api/v1/admin/coupons/refresh", Tag: "admin", Auth: true,
+			Summary:     "Refresh coupon files now",
+			Description: "Downloads and parses the coupon files instead of waiting for the periodic refresh.",
+			Responses:   map[int]any{http.StatusOK: models.CouponStats{}, http.StatusBadGateway: apiResponse},
+		},
+		{
+			Method: http.MethodGet, Path: "/api/v1/admin/db/stats", Tag: "admin", Auth: true,
+			Summary:   "Connection pool, retry and circuit breaker stats",
+			Responses: map[int]any{http.StatusOK: gin.H{}},
+		},
+		{
+			Method: http.MethodGet, Path: "/api/v1/admin/workers/stats", Tag: "admin", Auth: true,
+			Summary:     "Worker pool stats",
+			Description: "Size, running, waiting, completed, failed, panicked and canceled tasks of each background worker pool.",
+			Responses:   map[int]any{http.StatusOK: []workerpool.Stats{}},
+		},
+		{
+			Method: http.MethodGet, Path: "/api/v1/admin/jobs", Tag: "admin", Auth: true,
+			Summary:     "Scheduled jobs",
+			Description: "Every periodic job with its schedule, the next time it is due on this instance and its last run on any instance, with the error it failed with. Jobs other than local ones run on one instance at a time.",
+			Responses:   map[int]any{http.StatusOK: []scheduler.Status{}, http.StatusInternalServerError: apiResponse},
+		},
+		{
+			Method: http.MethodGet, Path: "/api/v1/admin/config", Tag: "admin", Auth: true,
+			Summary:   "Effective configuration with secrets masked",
+			Responses: map[int]any{http.StatusOK: config.Config{}},
+		},
+		{
+			Method: http.MethodPost, Path: "/api/v1/admin/products", Tag: "admin", Auth: true,
+			Summary:     "Create a product",
+			Description: "Adds the product to the menu of the store in X-Store-ID, or the default store. variants are the sizes an order item picks one of and modifiers the add-ons it picks any of, each with a priceDelta added to the price; they are given their IDs. 422 for a price that isn't positive.",
+			Body:        models.ProductReq{},
+			Responses: map[int]any{
+				http.StatusCreated: models.Product{}, http.StatusBadRequest: apiResponse, http.StatusUnprocessableEntity: apiResponse,
+			},
+		},
+		{
+			Method: http.MethodPut, Path: "/api/v1/admin/products/:productId", Tag: "admin", Auth: true,
+			Summary:     "Update a product",
+			Description: "Replaces the product's name, price, category, images, variants and modifiers. Options sent with their ID keep it, so orders naming them still find them; the others are given new IDs, and options left out are deleted. version must be the product's current one; 409 when it changed since, so the client can read it again and reapply its edit.",
+			Body:        models.ProductUpdateReq{},
+			Responses: map[int]any{
+				http.StatusOK: models.Product{}, http.StatusBadRequest: apiResponse, http.StatusNotFound: apiResponse,
+				http.StatusConflict: apiResponse, http.StatusUnprocessableEntity: apiResponse,
+			},
+		},
+		{
+			Method: http.MethodDelete, Path: "/api/v1/admin/products/:productId", Tag: "admin", Auth: true,
+			Summary:     "Delete a product",
+			Description: "Takes the product off the menu. It is listed in /admin/products/deleted and can be restored from there.",
+			Responses:   map[int]any{http.StatusNoContent: nil, http.StatusNotFound: apiResponse},
+		},
+		{
+			Method: http.MethodGet, Path: "/api/v1/admin/products/deleted", Tag: "admin", Auth: true,
+			Summary:   "Recently deleted products",
+			Query:     []openapi.Param{deletedSinceParam},
+			Responses: map[int]any{http.StatusOK: []models.Product{}, http.StatusBadRequest: apiResponse},
+		},
+		{
+			Method: http.MethodPost, Path: "/api/v1/admin/products/:productId/restore", Tag: "admin", Auth: true,
+			Summary:   "Restore a deleted product",
+			Responses: map[int]any{http.StatusOK: apiResponse, http.StatusNotFound: apiResponse},
+		},
+		{
+			Method: http.MethodPost, Path: "/api/v1/admin/products/:productId/image", Tag: "admin", Auth: true,
+			Summary:     "Upload a product image",
+			Description: "Multipart form with the file in the image field; JPEG, PNG or WebP up to 5 MB, and JPEGs and PNGs up to 25 megapixels (413 beyond either). Replaces every image size of the product: the image is scaled down to 200, 640, 1024 and 1920 pixels wide for thumbnail, mobile, tablet and desktop, and sizes it isn't wider than use it as uploaded, as do all sizes of a WebP. 415 for other files and images that can't be decoded.",
+			Responses: map[int]any{
+				http.StatusOK: models.Product{}, http.StatusBadRequest: apiResponse, http.StatusNotFound: apiResponse,
+				http.StatusRequestEntityTooLarge: apiResponse, http.StatusUnsupportedMediaType: apiResponse,
+			},
+		},
+		{
+			Method: http.MethodPost, Path: "/api/v1/admin/products/:productId/restock", Tag: "admin", Auth: true,
+			Summary:     "Restock a product",
+			Description: "Adds quantity units to the product's stock. Products are never out of stock until they are first restocked, which starts counting their stock from quantity; from then on orders take from it, and orders of more than is left are refused with 422.",
+			Body:        models.RestockReq{},
+			Responses: map[int]any{
+				http.StatusOK: models.StockLevel{}, http.StatusBadRequest: apiResponse, http.StatusNotFound: apiResponse,
+				http.StatusUnprocessableEntity: apiResponse,
+			},
+		},
+		{
+			Method: http.MethodGet, Path: "/api/v1/admin/inventory/low-stock", Tag: "admin", Auth: true,
+			Summary:     "Products running low on stock",
+			Description: "The products whose stock is counted with threshold units left or fewer, fewest first, including those sold out.",
+			Query: []openapi.Param{
+				{Name: "threshold", Type: "integer", Description: "Most units left to be listed (default INVENTORY_LOW_STOCK_THRESHOLD)"},
+				{Name: "store_id", Type: "string", Description: "Only the products of this store"},
+			},
+			Responses: map[int]any{http.StatusOK: []models.StockLevel{}, http.StatusBadRequest: apiResponse},
+		},
+		{
+			Method: http.MethodPost, Path: "/api/v1/admin/menu/import", Tag: "admin", Auth: true,
+			Summary:     "Import the menu from the configured provider now",
+			Description: "Creates, updates, restores and archives products to match the third-party menu. Only products created or matched by earlier imports are archived.",
+			Responses: map[int]any{
+				http.StatusOK: models.MenuImportResult{}, http.StatusConflict: apiResponse,
+				http.StatusBadGateway: apiResponse, http.StatusNotImplemented: apiResponse,
+			},
+		},
+		{
+			Method: http.MethodDelete, Path: "/api/v1/admin/coupons/:code", Tag: "admin", Auth: true,
+			Summary:   "Delete a stored coupon",
+			Responses: map[int]any{http.StatusOK: apiResponse, http.StatusNotFound: apiResponse, http.StatusNotImplemented: apiResponse},
+		},
+		{
+			Method: http.MethodGet, Path: "/api/v1/admin/coupons/deleted", Tag: "admin", Auth: true,
+			Summary:   "Recently deleted coupons",
+			Query:     []openapi.Param{deletedSinceParam},
+			Responses: map[int]any{http.StatusOK: []models.Coupon{}, http.StatusBadRequest: apiResponse, http.StatusNotImplemented: apiResponse},
+		},
+		{
+			Method: http.MethodPost, Path: "/api/v1/admin/coupons/:code/restore", Tag: "admin", Auth: true,
+			Summary:   "Restore a deleted coupon",
+			Responses: map[int]any{http.StatusOK: apiResponse, http.StatusNotFound: apiResponse, http.StatusNotImplemented: apiResponse},
+		},
+		{
+			Method: http.MethodGet, Path: "/api/v1/admin/orders", Tag: "admin", Auth: true,
+			Summary: "Page through orders with their items and products",
+			Query: []openapi.Param{
+				{Name: "limit", Type: "integer", Description: "Page size (default 20, max 100)"},
+				{Name: "offset", Type: "integer", Description: "Number of orders to skip"},
+				{Name: "store_id", Description: "Only orders placed at this store"},
+			},
+			Responses: map[int]any{http.StatusOK: gin.H{}, http.StatusBadRequest: apiResponse},
+		},
+		{
+			Method: http.MethodGet, Path: "/api/v1/admin/orders/payment-methods", Tag: "admin", Auth: true,
+			Summary:     "Sum orders per payment method",
+			Description: "Counts the orders placed since the given time and what they came to after discounts, per payment method, e.g. to reconcile the cash taken. Cancelled and failed orders are left out.",
+			Query: []openapi.Param{
+				{Name: "since", Type: "string", Description: "RFC 3339 timestamp (default the start of today)"},
+			},
+			Responses: map[int]any{http.StatusOK: gin.H{}, http.StatusBadRequest: apiResponse},
+		},
+		{
+			Method: http.MethodGet, Path: "/api/v1/admin/dashboard", Tag: "admin", Auth: true,
+			Summary:     "Operations dashboard summary",
+			Description: "In one response: the orders placed today in DIGEST_TIME_ZONE with their revenue after discounts, the discounts and the average order value; the order queue's items by status and how long the oldest due one has waited; today's five best selling products; and the coupons used today. Cancelled and failed orders are left out of the sales.",
+			Responses:   map[int]any{http.StatusOK: models.Dashboard{}},
+		},
+		{
+			Method: http.MethodGet, Path: "/api/v1/admin/reports/sales", Tag: "admin", Auth: true,
+			Summary:     "Report sales by day, product or category",
+			Description: "Counts the orders placed from the start of from to the end of to, days in DIGEST_TIME_ZONE, with their revenue after discounts, the discounts and the average order value, in total and per group. A product's or category's share of an order's discounts is in proportion to what it added to the order. Cancelled and failed orders are left out. 400 for an unknown group_by, or from after to or more than 366 days before it.",
+			Query: []openapi.Param{
+				{Name: "group_by", Type: "string", Description: "day (default), product or category"},
+				{Name: "from", Type: "string", Description: "First day as YYYY-MM-DD (default 29 days before to)"},
+				{Name: "to", Type: "string", Description: "Last day as YYYY-MM-DD (default today)"},
+				{Name: "store_id", Type: "string", Description: "Only the orders of this store"},
+			},
+			Responses: map[int]any{http.StatusOK: models.SalesReport{}, http.StatusBadRequest: apiResponse},
+		},
+		{
+			Method: http.MethodGet, Path: "/api/v1/admin/reports/discrepancies", Tag: "admin", Auth: true,
+			Summary:     "List orders whose amounts don't add up",
+			Description: "Every RECONCILE_INTERVAL the orders changed within RECONCILE_WINDOW have their total recomputed from the prices their items were sold at, and their discounts from their coupon's current terms; discounts of coupons that no longer resolve go unchecked. Cancelled and failed orders are left out. A discrepancy stays listed until a later run finds the order adds up.",
+			Query: []openapi.Param{
+				{Name: "store_id", Type: "string", Description: "Only the orders of this store"},
+			},
+			Responses: map[int]any{http.StatusOK: models.DiscrepancyReport{}, http.StatusBadRequest: apiResponse},
+		},
+		{
+			Method: http.MethodGet, Path: "/api/v1/admin/reports/sales-digest", Tag: "admin", Auth: true,
+			Summary:     "Build a day's sales digest",
+			Description: "Counts the orders placed on the day in DIGEST_TIME_ZONE and what they came to, per payment method, with the best selling products and the coupons used. Cancelled and failed orders are left out. Nothing is sent.",
+			Query: []openapi.Param{
+				{Name: "date", Type: "string", Description: "Day as YYYY-MM-DD (default yesterday)"},
+			},
+			Responses: map[int]any{http.StatusOK: models.SalesDigest{}, http.StatusBadRequest: apiResponse},
+		},
+		{
+			Method: http.MethodPost, Path: "/api/v1/admin/reports/sales-digest/send", Tag: "admin", Auth: true,
+			Summary:     "Send a day's sales digest now",
+			Description: "Emails the digest to DIGEST_RECIPIENTS and queues it for DIGEST_WEBHOOK_URL as a sales.digest CloudEvent, as the daily job does at DIGEST_HOUR. A day's digest is only queued for the webhook once; redeliver it from the webhook deliveries instead.",
+			Query: []openapi.Param{
+				{Name: "date", Type: "string", Description: "Day as YYYY-MM-DD (default yesterday)"},
+			},
+			Responses: map[int]any{http.StatusOK: models.SalesDigest{}, http.StatusBadRequest: apiResponse, http.StatusNotImplemented: apiResponse, http.StatusBadGateway: apiResponse},
+		},
+		{
+			Method: http.MethodDelete, Path: "/api/v1/admin/orders/:orderId", Tag: "admin", Auth: true,
+			Summary:   "Delete a cancelled or failed order",
+			Responses: map[int]any{http.StatusOK: apiResponse, http.StatusNotFound: apiResponse, http.StatusConflict: apiResponse},
+		},
+		{
+			Method: http.MethodPost, Path: "/api/v1/admin/orders/:orderId/ready", Tag: "admin", Auth: true,
+			Summary:     "Tell the customer their order is ready",
+			Description: "Texts and emails the order's customer as they opted in to ready alerts; notified reports whether either was queued for the notification worker. Texts are off while NOTIFICATION_SMS_READY_ENABLED is false, and a phone gets at most NOTIFICATION_SMS_PER_HOUR; what became of each is recorded as an order.sms_status event.",
+			Responses:   map[int]any{http.StatusOK: gin.H{}, http.StatusNotFound: apiResponse, http.StatusBadGateway: apiResponse},
+		},
+		{
+			Method: http.MethodDelete, Path: "/api/v1/admin/orders/:orderId/delivery", Tag: "admin", Auth: true,
+			Summary:     "Cancel an order's delivery",
+			Description: "Calls the courier off through the delivery provider. 404 when DELIVERY_PROVIDER is none or the order has no delivery; 409, with the delivery, once it has been delivered, cancelled or failed; 502 when the provider refuses.",
+			Responses:   map[int]any{http.StatusOK: models.Delivery{}, http.StatusNotFound: apiResponse, http.StatusConflict: apiResponse, http.StatusBadGateway: apiResponse},
+		},
+		{
+			Method: http.MethodPost, Path: "/api/v1/admin/tables/:tableNumber/tab/close", Tag: "admin", Auth: true,
+			Summary:     "Close a table's tab",
+			Description: "Settles the table's open tab with paymentMethod, one of PAYMENT_METHODS, and returns what it came to; the table's next order opens a new tab. 404 when the table has no open tab; 409 while one of its orders is still queued.",
+			Body:        models.TabCloseReq{},
+			Responses:   map[int]any{http.StatusOK: models.Tab{}, http.StatusBadRequest: apiResponse, http.StatusNotFound: apiResponse, http.StatusConflict: apiResponse},
+		},
+		{
+			Method: http.MethodGet, Path: "/api/v1/admin/kitchen/tables", Tag: "admin", Auth: true,
+			Summary:     "Tables with an open tab, for kitchen displays",
+			Description: "The store's tables with an open tab, longest seated first, each with the rounds ordered at it. The store is the X-Store-ID one, or the default store.",
+			Responses:   map[int]any{http.StatusOK: []models.KitchenTable{}},
+		},
+		{
+			Method: http.MethodGet, Path: "/api/v1/admin/notifications/stats", Tag: "admin", Auth: true,
+			Summary:     "Notification delivery stats",
+			Description: "Counts the notifications in the outbox by status; retrying ones are pending after failing at least once, and dead ones ran out of NOTIFICATION_MAX_ATTEMPTS.",
+			Responses:   map[int]any{http.StatusOK: models.NotificationDeliveryStats{}},
+		},
+		{
+			Method: http.MethodPost, Path: "/api/v1/admin/notifications/:notificationId/redeliver", Tag: "admin", Auth: true,
+			Summary:     "Send a dead notification again",
+			Description: "Queues the notification with a fresh set of attempts. 404 unless it is dead.",
+			Responses:   map[int]any{http.StatusOK: gin.H{}, http.StatusNotFound: apiResponse},
+		},
+		{
+			Method: http.MethodGet, Path: "/api/v1/admin/webhooks/deliveries", Tag: "admin", Auth: true,
+			Summary:     "Page through outbound webhook deliveries",
+			Description: "Lists the fulfillment, event and digest webhooks, newest first. Pending ones are waiting for their next attempt; dead ones were refused with a 4xx other than 429 or ran out of WEBHOOK_MAX_ATTEMPTS.",
+			Query: []openapi.Param{
+				{Name: "status", Type: "string", Description: "pending, delivered or dead (default all)"},
+				{Name: "limit", Type: "integer", Description: "Page size (default 20, max 100)"},
+				{Name: "offset", Type: "integer", Description: "Number of deliveries to skip"},
+			},
+			Responses: map[int]any{http.StatusOK: gin.H{}, http.StatusBadRequest: apiResponse},
+		},
+		{
+			Method: http.MethodGet, Path: "/api/v1/admin/webhooks/deliveries/:deliveryId", Tag: "admin", Auth: true,
+			Summary:     "Get a webhook delivery with its attempts",
+			Description: "Includes every attempt at posting it: the URL, the response status, the error and how long it took.",
+			Responses:   map[int]any{http.StatusOK: models.WebhookDelivery{}, http.StatusNotFound: apiResponse},
+		},
+		{
+			Method: http.MethodPost, Path: "/api/v1/admin/webhooks/deliveries/:deliveryId/redeliver", Tag: "admin", Auth: true,
+			Summary:     "Post a dead webhook again",
+			Description: "Queues the delivery with a fresh set of attempts, signed afresh. 404 unless it is dead.",
+			Responses:   map[int]any{http.StatusOK: gin.H{}, http.StatusNotFound: apiResponse},
+		},
+		{
+			Method: http.MethodPost, Path: "/api/v1/admin/orders/:orderId/refund", Tag: "admin", Auth: true,
+			Summary:     "Refund an order's payment",
+			Description: "Refunds the order's captured payment through the payment provider. The body is optional; without an amount the rest of the payment is refunded. The refund is recorded as requested, then processed or failed, and each step emits an order.refund_requested, order.refunded or order.refund_failed event. 409 when the order has no captured payment; 422 when the amount is more than is left.",
+			Body:        models.PaymentAmountReq{},
+			Responses:   map[int]any{http.StatusOK: models.OrderRefund{}, http.StatusBadRequest: apiResponse, http.StatusNotFound: apiResponse, http.StatusConflict: apiResponse, http.StatusUnprocessableEntity: apiResponse, http.StatusBadGateway: apiResponse},
+		},
+		{
+			Method: http.MethodGet, Path: "/api/v1/admin/orders/:orderId/refunds", Tag: "admin", Auth: true,
+			Summary:   "List an order's refunds",
+			Responses: map[int]any{http.StatusOK: []models.OrderRefund{}, http.StatusNotFound: apiResponse},
+		},
+		{
+			Method: http.MethodPost, Path: "/api/v1/admin/gift-cards", Tag: "admin", Auth: true,
+			Summary:     "Issue a gift card",
+			Description: "Holds amount of store credit until expiresAt, if given. Without a code one like GC-7KQ2-M9XD-4HPA is generated; codes are case-insensitive. 409 when the code is taken.",
+			Body:        models.GiftCardReq{},
+			Responses:   map[int]any{http.StatusCreated: models.GiftCard{}, http.StatusBadRequest: apiResponse, http.StatusConflict: apiResponse},
+		},
+		{
+			Method: http.MethodGet, Path: "/api/v1/admin/gift-cards/:code", Tag: "admin", Auth: true,
+			Summary:   "Get a gift card",
+			Responses: map[int]any{http.StatusOK: models.GiftCard{}, http.StatusNotFound: apiResponse},
+		},
+		{
+			Method: http.MethodPost, Path: "/api/v1/admin/payments/:intentId/capture", Tag: "admin", Auth: true,
+			Summary:     "Capture an authorized payment",
+			Description: "For payments that failed to capture when their order was created. The body is optional; without an amount the whole authorization is captured.",
+			Body:        models.PaymentAmountReq{},
+			Responses:   map[int]any{http.StatusOK: models.PaymentIntent{}, http.StatusBadRequest: apiResponse, http.StatusNotFound: apiResponse, http.StatusUnprocessableEntity: apiResponse, http.StatusBadGateway: apiResponse},
+		},
+		{
+			Method: http.MethodPost, Path: "/api/v1/admin/payments/:intentId/refund", Tag: "admin", Auth: true,
+			Summary:     "Refund a captured payment",
+			Description: "For payments without an order; refund orders with POST /admin/orders/{orderId}/refund so the refund is recorded. The body is optional; without an amount the rest of the captured payment is refunded.",
+			Body:        models.PaymentAmountReq{},
+			Responses:   map[int]any{http.StatusOK: models.PaymentRefund{}, http.StatusBadRequest: apiResponse, http.StatusNotFound: apiResponse, http.StatusUnprocessableEntity: apiResponse, http.StatusBadGateway: apiResponse},
+		},
+		{
+			Method: http.MethodGet, Path: "/api/v1/admin/pricing/tiers", Tag: "admin", Auth: true,
+			Summary:     "List pricing tiers",
+			Description: "Retail, the list price, comes first and can't be changed.",
+			Responses:   map[int]any{http.StatusOK: []models.PricingTier{}},
+		},
+		{
+			Method: http.MethodPut, Path: "/api/v1/admin/pricing/tiers/:tier", Tag: "admin", Auth: true,
+			Summary:   "Create a pricing tier or change its discount",
+			Body:      models.PricingTierReq{},
+			Responses: map[int]any{http.StatusOK: models.PricingTier{}, http.StatusBadRequest: apiResponse},
+		},
+		{
+			Method: http.MethodDelete, Path: "/api/v1/admin/pricing/tiers/:tier", Tag: "admin", Auth: true,
+			Summary:     "Delete a pricing tier",
+			Description: "Customers and API keys assigned to it go back to retail.",
+			Responses:   map[int]any{http.StatusOK: apiResponse, http.StatusBadRequest: apiResponse, http.StatusNotFound: apiResponse},
+		},
+		{
+			Method: http.MethodGet, Path: "/api/v1/admin/pricing/assignments", Tag: "admin", Auth: true,
+			Summary:   "List who is assigned which pricing tier",
+			Responses: map[int]any{http.StatusOK: []models.PricingAssignment{}},
+		},
+		{
+			Method: http.MethodPut, Path: "/api/v1/admin/pricing/assignments", Tag: "admin", Auth: true,
+			Summary:     "Assign a pricing tier to a customer or API key",
+			Description: "A customer's tier wins over the tier of the API key their frontend uses. Assigning retail removes the assignment.",
+			Body:        models.PricingAssignmentReq{},
+			Responses:   map[int]any{http.StatusOK: apiResponse, http.StatusBadRequest: apiResponse, http.StatusNotFound: apiResponse},
+		},
+
+		// Stores
+		{
+			Method: http.MethodGet, Path: "/api/v1/admin/orders/schedule-links", Tag: "admin", Auth: true,
+			Summary:     "Issue signed URLs to the scheduled orders feed",
+			Description: "The URLs read schedule.ics and schedule.csv without the API key until they expire after SCHEDULE_FEED_TTL. 404 when SCHEDULE_FEED_SECRET or SCHEDULE_FEED_BASE_URL isn't set.",
+			Query:       []openapi.Param{{Name: "store_id", Description: "Only list this store's orders; every store's without it"}},
+			Responses:   map[int]any{http.StatusOK: models.ScheduleFeedLinks{}, http.StatusBadRequest: apiResponse, http.StatusNotFound: apiResponse},
+		},
+		{
+			Method: http.MethodGet, Path: "/api/v1/admin/stores", Tag: "admin", Auth: true,
+			Summary:   "List the stores",
+			Responses: map[int]any{http.StatusOK: []models.Store{}},
+		},
+		{
+			Method: http.MethodPost, Path: "/api/v1/admin/stores", Tag: "admin", Auth: true,
+			Summary:   "Add a store",
+			Body:      models.StoreReq{},
+			Responses: map[int]any{http.StatusCreated: models.Store{}, http.StatusBadRequest: apiResponse},
+		},
+		{
+			Method: http.MethodGet, Path: "/api/v1/admin/stores/:storeId", Tag: "admin", Auth: true,
+			Summary:   "Find store by ID",
+			Responses: map[int]any{http.StatusOK: models.Store{}, http.StatusNotFound: apiResponse},
+		},
+		{
+			Method: http.MethodPut, Path: "/api/v1/admin/stores/:storeId", Tag: "admin", Auth: true,
+			Summary:   "Rename a store",
+			Body:      models.StoreReq{},
+			Responses: map[int]any{http.StatusOK: models.Store{}, http.StatusBadRequest: apiResponse, http.StatusNotFound: apiResponse},
+		},
+		{
+			Method: http.MethodGet, Path: "/api/v1/admin/stores/:storeId/api-keys", Tag: "admin", Auth: true,
+			Summary:     "List a store's API keys",
+			Description: "Keys are listed by their prefix; the keys themselves are only returned when they are created.",
+			Responses:   map[int]any{http.StatusOK: []models.StoreAPIKey{}, http.StatusNotFound: apiResponse},
+		},
+		{
+			Method: http.MethodPost, Path: "/api/v1/admin/stores/:storeId/api-keys", Tag: "admin", Auth: true,
+			Summary:     "Create an API key for a store",
+			Description: "Returns the key, which can't be shown again. Requests made with it only see the store's products, orders and coupons, and can't use the admin routes. A test key places sandbox orders: they are prepared without payments, coupon or gift card redemptions, fulfillment, delivery or notifications, and are left out of the queue stats and backlog.",
+			Body:        models.StoreAPIKeyReq{},
+			Responses:   map[int]any{http.StatusCreated: models.StoreAPIKey{}, http.StatusBadRequest: apiResponse, http.StatusNotFound: apiResponse},
+		},
+		{
+			Method: http.MethodDelete, Path: "/api/v1/admin/stores/:storeId/api-keys/:keyId", Tag: "admin", Auth: true,
+			Summary:   "Revoke a store's API key",
+			Responses: map[int]any{http.StatusOK: apiResponse, http.StatusNotFound: apiResponse},
+		},
+		{
+			Method: http.MethodGet, Path: "/api/v1/admin/stores/:storeId/hours", Tag: "admin", Auth: true,
+			Summary:   "Get a store's opening hours",
+			Responses: map[int]any{http.StatusOK: models.OpeningHours{}, http.StatusNotFound: apiResponse},
+		},
+		{
+			Method: http.MethodPut, Path: "/api/v1/admin/stores/:storeId/hours", Tag: "admin", Auth: true,
+			Summary:     "Set a store's opening hours",
+			Description: "Orders placed while the store is closed are refused with the next opening time, or scheduled for it when outsideHours is schedule. Holidays replace the weekly hours on their date.",
+			Body:        models.OpeningHours{},
+			Responses:   map[int]any{http.StatusOK: models.OpeningHours{}, http.StatusBadRequest: apiResponse, http.StatusNotFound: apiResponse},
+		},
+		{
+			Method: http.MethodDelete, Path: "/api/v1/admin/stores/:storeId/hours", Tag: "admin", Auth: true,
+			Summary:   "Remove a store's opening hours, leaving it always open",
+			Responses: map[int]any{http.StatusOK: apiResponse, http.StatusNotFound: apiResponse},
+		},
+
+		// GraphQL
+		{
+			Method: http.MethodPost, Path: "/graphql", Tag: "graphql", Auth: true,
+			Summary:     "Query products, orders and the queue status",
+			Description: "Root fields are products, product, categories, orders, order and queueStatus. A field that fails is null with an entry in errors, so the response is 200 unless the body isn't a query.",
+			Body:        graphql.Request{},
+			Responses:   map[int]any{http.StatusOK: graphql.Response{}, http.StatusBadRequest: apiResponse},
+		},
+	}
+}
+
+// NewDocument builds the OpenAPI document for the routes in Operations
+func NewDocument() *openapi.Document {
+	return openapi.NewDocument(openapi.Info{
+		Title:       "Oolio Food Ordering API",
+		Description: "Products, orders and administration, served per version under /api/v<n>. Authenticate with the X-API-Key header: API_KEY acts for the store named in X-Store-ID (the default store without one), a store's own API key for that store alone.",
+		Version:     "1.0.0",
+	}, Operations())
+}
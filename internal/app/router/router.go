@@ -1,35 +1,84 @@
 package router
 
 import (
+	"strconv"
 	"time"
 
 	"oolio/internal/app/handler"
 	"oolio/internal/app/middleware"
+	"oolio/internal/app/openapi"
 
 	"github.com/gin-gonic/gin"
+	"go.uber.org/fx"
 )
 
-func SetupRouter(
-	productHandler *handler.ProductHandler,
-	orderHandler *handler.OrderHandler,
-	authMiddleware gin.HandlerFunc,
-	errorMiddleware []gin.HandlerFunc,
-	rateLimitMiddleware *middleware.RateLimitMiddleware,
-) *gin.Engine {
-	r := gin.Default()
+// APIVersions are the API versions served side by side under /api/v<n>; the last is current
+var APIVersions = []int{1, 2}
+
+// APIPrefix returns the path prefix of an API version, e.g. /api/v2
+func APIPrefix(version int) string {
+	return "/api/v" + strconv.Itoa(version)
+}
+
+// Deps are the handlers and middleware SetupRouter wires into routes. fx fills them in by
+// type; tests set only the fields of the routes they call, since nil middleware lets
+// requests through.
+type Deps struct {
+	fx.In
+
+	ProductHandler         *handler.ProductHandler
+	OrderHandler           *handler.OrderHandler
+	GraphQLHandler         *handler.GraphQLHandler
+	AuthMiddleware         gin.HandlerFunc
+	ErrorMiddleware        []gin.HandlerFunc
+	RateLimitMiddleware    *middleware.RateLimitMiddleware
+	AccessLogMiddleware    *middleware.AccessLogMiddleware
+	AdminHandler           *handler.AdminHandler
+	AvailabilityMiddleware *middleware.AvailabilityMiddleware
+	FileHandler            *handler.FileHandler
+	StatusHandler          *handler.StatusHandler
+	DeprecationMiddleware  *middleware.DeprecationMiddleware
+	CustomerHandler        *handler.CustomerHandler
+	CartHandler            *handler.CartHandler
+	OrderLookup            *middleware.OrderLookup
+	VerificationHandler    *handler.VerificationHandler
+	PricingHandler         *handler.PricingHandler
+	PricingMiddleware      *middleware.PricingMiddleware
+	NotificationHandler    *handler.NotificationHandler
+	PaymentHandler         *handler.PaymentHandler
+	GiftCardHandler        *handler.GiftCardHandler
+	InvoiceHandler         *handler.InvoiceHandler
+	WebhookHandler         *handler.WebhookHandler
+	QueueHandler           *handler.QueueHandler
+	ResponseCache          *middleware.ResponseCacheMiddleware
+	StoreHandler           *handler.StoreHandler
+	ScheduleHandler        *handler.ScheduleHandler
+	DeliveryHandler        *handler.DeliveryHandler
+	TabHandler             *handler.TabHandler
+	ReportHandler          *handler.ReportHandler
+	JobHandler             *handler.JobHandler
+	InventoryHandler       *handler.InventoryHandler
+	ChaosMiddleware        *middleware.ChaosMiddleware
+}
+
+func SetupRouter(d Deps) *gin.Engine {
+	r := gin.New()
 
 	// Apply global middleware
-	r.Use(gin.Logger())
+	r.Use(d.AccessLogMiddleware.AccessLog())
 	r.Use(gin.Recovery())
 
 	// Apply CORS middleware
 	r.Use(middleware.CORSMiddleware())
 
 	// Apply error handling middleware
-	for _, mw := range errorMiddleware {
+	for _, mw := range d.ErrorMiddleware {
 		r.Use(mw)
 	}
 
+	// Injected faults, when CHAOS_ENABLED; a nil middleware passes requests through
+	r.Use(d.ChaosMiddleware.Inject())
+
 	// Health check endpoint
 	r.GET("/health", func(c *gin.Context) {
 		c.JSON(200, gin.H{
@@ -38,30 +87,209 @@ func SetupRouter(
 		})
 	})
 
-	// Product routes (no authentication required)
-	v1 := r.Group("/api/v1")
-	{
+	// API documentation, generated from Operations
+	r.GET(SpecPath, openapi.SpecHandler(NewDocument()))
+	r.GET(DocsPath, openapi.UIHandler(SpecPath))
+
+	// Stored files such as product images (no authentication required)
+	r.GET("/files/*key", d.FileHandler.ServeFile)
+
+	// Routes backed by the database fail fast while it is unreachable
+	requireDatabase := d.AvailabilityMiddleware.RequireDatabase()
+
+	// Product prices and order totals follow the caller's pricing tier
+	resolvePricingTier := d.PricingMiddleware.ResolveTier()
+
+	// Menu browsing is served from the response cache, per tier, until a product changes
+	cacheResponse := d.ResponseCache.Cache()
+
+	// Every API version serves the same routes and handlers; handlers that changed between
+	// versions branch on middleware.APIVersion. Older versions carry deprecation headers.
+	latest := APIVersions[len(APIVersions)-1]
+	for _, version := range APIVersions {
+		prefix := APIPrefix(version)
+		api := r.Group(prefix, middleware.Version(version))
+		if version < latest {
+			api.Use(d.DeprecationMiddleware.Deprecate(prefix, APIPrefix(latest)))
+		}
+
+		// Public status for ordering frontends; it must answer while the database is down,
+		// so it is neither authenticated nor behind requireDatabase
+		api.GET("/status", d.StatusHandler.GetStatus)
+
 		// Product endpoints (authentication + rate limiting)
-		products := v1.Group("/product").Use(authMiddleware, rateLimitMiddleware.RateLimit(100, time.Minute))
+		products := api.Group("/product").Use(d.AuthMiddleware, d.RateLimitMiddleware.RateLimitNamed("product", 100, time.Minute), requireDatabase, resolvePricingTier, cacheResponse)
 		{
-			products.GET("/", productHandler.ListProducts)
-			products.GET("/:productId", productHandler.GetProduct)
+			products.GET("/", d.ProductHandler.ListProducts)
+			products.GET("/changes", d.ProductHandler.ListChanges)
+			products.GET("/:productId", d.ProductHandler.GetProduct)
 		}
 
 		// Also support direct access without trailing slash to avoid redirect
-		v1.GET("/product", authMiddleware, rateLimitMiddleware.RateLimit(100, time.Minute), productHandler.ListProducts)
+		api.GET("/product", d.AuthMiddleware, d.RateLimitMiddleware.RateLimitNamed("product", 100, time.Minute), requireDatabase, resolvePricingTier, cacheResponse, d.ProductHandler.ListProducts)
 
 		// Order endpoints (authentication + rate limiting)
-		orders := v1.Group("/order").Use(authMiddleware, rateLimitMiddleware.RateLimit(50, time.Minute))
+		orders := api.Group("/order").Use(d.AuthMiddleware, d.RateLimitMiddleware.RateLimitNamed("order", 50, time.Minute), requireDatabase, resolvePricingTier)
 		{
-			orders.POST("", orderHandler.PlaceOrder)
-			orders.GET("", orderHandler.ListOrders)
-			orders.GET("/:orderId", orderHandler.GetOrder)
+			orders.POST("", d.OrderHandler.PlaceOrder)
+			orders.GET("", d.OrderHandler.ListOrders)
 		}
 
+		// Guests may read, pay for and get the invoice of their own order with its lookup token
+		// instead of the API key
+		api.GET("/order/:orderId", d.OrderLookup.Authorize(d.AuthMiddleware), d.RateLimitMiddleware.RateLimitNamed("order", 50, time.Minute), requireDatabase, d.OrderHandler.GetOrder)
+		api.POST("/order/:orderId/pay", d.OrderLookup.Authorize(d.AuthMiddleware), d.RateLimitMiddleware.RateLimitNamed("payment", 30, time.Minute), requireDatabase, d.PaymentHandler.PayOrder)
+		api.GET("/order/:orderId/invoice", d.OrderLookup.Authorize(d.AuthMiddleware), d.RateLimitMiddleware.RateLimitNamed("order", 50, time.Minute), requireDatabase, d.InvoiceHandler.Get)
+
+		// The open tab of a dine-in table of the caller's store (authentication + rate limiting)
+		api.GET("/tables/:tableNumber/tab", d.AuthMiddleware, d.RateLimitMiddleware.RateLimitNamed("order", 50, time.Minute), requireDatabase, d.TabHandler.Get)
+
+		// Customer endpoints (authentication + rate limiting); the customer is named by the
+		// X-Customer-ID header of the authenticated frontend
+		customer := api.Group("/customer/me").Use(d.AuthMiddleware, d.RateLimitMiddleware.RateLimitNamed("customer", 60, time.Minute), middleware.RequireCustomer(), requireDatabase)
+		{
+			customer.GET("/addresses", d.CustomerHandler.ListAddresses)
+			customer.POST("/addresses", d.CustomerHandler.CreateAddress)
+			customer.DELETE("/addresses/:addressId", d.CustomerHandler.DeleteAddress)
+			customer.POST("/addresses/:addressId/default", d.CustomerHandler.SetDefaultAddress)
+			customer.GET("/favorites", d.CustomerHandler.ListFavorites)
+			customer.PUT("/favorites/:productId", d.CustomerHandler.AddFavorite)
+			customer.DELETE("/favorites/:productId", d.CustomerHandler.RemoveFavorite)
+			customer.GET("/notifications", d.NotificationHandler.Feed)
+			customer.POST("/notifications/read", d.NotificationHandler.MarkAllRead)
+			customer.POST("/notifications/:notificationId/read", d.NotificationHandler.MarkRead)
+			customer.GET("/notifications/preferences", d.NotificationHandler.GetPreferences)
+			customer.PUT("/notifications/preferences", d.NotificationHandler.SavePreferences)
+			// Preferences were saved here before the feed took the path; kept for existing apps
+			customer.PUT("/notifications", d.NotificationHandler.SavePreferences)
+			customer.GET("/devices", d.NotificationHandler.ListDevices)
+			customer.POST("/devices", d.NotificationHandler.RegisterDevice)
+			customer.DELETE("/devices/:token", d.NotificationHandler.UnregisterDevice)
+		}
+
+		// Cart endpoints (authentication + rate limiting) for customers and, by cart token,
+		// for guests
+		cart := api.Group("/cart").Use(d.AuthMiddleware, d.RateLimitMiddleware.RateLimitNamed("cart", 60, time.Minute), requireDatabase, resolvePricingTier)
+		{
+			cart.GET("", d.CartHandler.GetCart)
+			cart.DELETE("", d.CartHandler.ClearCart)
+			cart.POST("/items", d.CartHandler.AddItem)
+			cart.PUT("/items/:productId", d.CartHandler.UpdateItem)
+			cart.DELETE("/items/:productId", d.CartHandler.RemoveItem)
+			cart.POST("/checkout", d.CartHandler.Checkout)
+		}
+
+		// Phone verification (authentication + a tight rate limit, which also bounds code
+		// guessing); codes are texted by the SMS gateway, not stored
+		verification := api.Group("/verification").Use(d.AuthMiddleware, d.RateLimitMiddleware.RateLimitNamed("verification", 10, time.Minute))
+		{
+			verification.POST("/send", d.VerificationHandler.SendCode)
+			verification.POST("/check", d.VerificationHandler.CheckCode)
+		}
+
+		// Payment intents (authentication + rate limiting); the amount is priced like the
+		// order it pays for
+		payments := api.Group("/payments").Use(d.AuthMiddleware, d.RateLimitMiddleware.RateLimitNamed("payment", 30, time.Minute), requireDatabase, resolvePricingTier)
+		{
+			payments.POST("/intents", d.PaymentHandler.CreateIntent)
+			payments.GET("/intents/:intentId", d.PaymentHandler.GetIntent)
+		}
+
+		// The payment provider's webhook is authenticated by its signature, not the API key
+		api.POST("/payments/webhook", requireDatabase, d.PaymentHandler.Webhook)
+		// So are the SMS provider's delivery reports
+		api.POST("/notifications/sms/status", d.NotificationHandler.SMSStatus)
+		// And the delivery provider's status updates
+		api.POST("/deliveries/webhook", requireDatabase, d.DeliveryHandler.Webhook)
+
+		// Gift card balances (authentication + a tight rate limit, which also bounds code
+		// guessing)
+		api.GET("/gift-cards/:code", d.AuthMiddleware, d.RateLimitMiddleware.RateLimitNamed("gift-card", 10, time.Minute), requireDatabase, d.GiftCardHandler.Balance)
+
 		// Queue status endpoint (authentication + rate limiting)
-		v1.GET("/queue/status", authMiddleware, rateLimitMiddleware.RateLimit(30, time.Minute), orderHandler.GetQueueStatus)
+		api.GET("/queue/status", d.AuthMiddleware, d.RateLimitMiddleware.RateLimitNamed("queue", 30, time.Minute), requireDatabase, d.OrderHandler.GetQueueStatus)
+
+		// Retry links from failed order alerts are authenticated by their signature, not the
+		// API key; opening one only asks for confirmation
+		api.GET("/queue/:itemId/retry", d.RateLimitMiddleware.RateLimitNamed("queue", 30, time.Minute), d.QueueHandler.ConfirmRetry)
+		api.POST("/queue/:itemId/retry", d.RateLimitMiddleware.RateLimitNamed("queue", 30, time.Minute), requireDatabase, d.QueueHandler.RetryFromLink)
+
+		// The scheduled orders feed is authenticated by its signed URL, for calendar tools
+		// that can't send the API key
+		api.GET("/admin/orders/schedule.ics", d.RateLimitMiddleware.RateLimitNamed("schedule-feed", 30, time.Minute), requireDatabase, d.ScheduleHandler.FeedICS)
+		api.GET("/admin/orders/schedule.csv", d.RateLimitMiddleware.RateLimitNamed("schedule-feed", 30, time.Minute), requireDatabase, d.ScheduleHandler.FeedCSV)
+
+		// Admin endpoints (authentication + admin permission); stats and settings stay
+		// reachable during a database outage
+		admin := api.Group("/admin").Use(d.AuthMiddleware, middleware.RequirePermission("admin"))
+		{
+			admin.GET("/log-level", d.AdminHandler.GetLogLevel)
+			admin.PUT("/log-level", d.AdminHandler.SetLogLevel)
+			admin.GET("/coupons/stats", d.AdminHandler.GetCouponStats)
+			admin.POST("/coupons/refresh", d.AdminHandler.RefreshCoupons)
+			admin.GET("/db/stats", d.AdminHandler.GetDatabaseStats)
+			admin.GET("/workers/stats", d.AdminHandler.GetWorkerStats)
+			admin.GET("/jobs", d.JobHandler.List)
+			admin.GET("/config", d.AdminHandler.GetConfig)
+			admin.POST("/products", requireDatabase, d.AdminHandler.CreateProduct)
+			admin.PUT("/products/:productId", requireDatabase, d.AdminHandler.UpdateProduct)
+			admin.DELETE("/products/:productId", requireDatabase, d.AdminHandler.DeleteProduct)
+			admin.GET("/products/deleted", requireDatabase, d.AdminHandler.ListDeletedProducts)
+			admin.POST("/products/:productId/restore", requireDatabase, d.AdminHandler.RestoreProduct)
+			admin.POST("/products/:productId/image", requireDatabase, d.AdminHandler.UploadProductImage)
+			admin.POST("/products/:productId/restock", requireDatabase, d.InventoryHandler.Restock)
+			admin.GET("/inventory/low-stock", requireDatabase, d.InventoryHandler.LowStock)
+			admin.POST("/menu/import", requireDatabase, d.AdminHandler.ImportMenu)
+			admin.DELETE("/coupons/:code", requireDatabase, d.AdminHandler.DeleteCoupon)
+			admin.GET("/coupons/deleted", requireDatabase, d.AdminHandler.ListDeletedCoupons)
+			admin.POST("/coupons/:code/restore", requireDatabase, d.AdminHandler.RestoreCoupon)
+			admin.GET("/orders", requireDatabase, d.OrderHandler.ListAllOrders)
+			admin.GET("/orders/payment-methods", requireDatabase, d.OrderHandler.PaymentMethodReport)
+			admin.GET("/orders/schedule-links", d.ScheduleHandler.IssueLinks)
+			admin.GET("/queue/:itemId", requireDatabase, d.QueueHandler.Inspect)
+			admin.POST("/queue/:itemId/retry", requireDatabase, d.QueueHandler.Retry)
+			admin.GET("/dashboard", requireDatabase, d.ReportHandler.Dashboard)
+			admin.GET("/reports/sales", requireDatabase, d.ReportHandler.Sales)
+			admin.GET("/reports/discrepancies", requireDatabase, d.ReportHandler.Discrepancies)
+			admin.GET("/reports/sales-digest", requireDatabase, d.AdminHandler.SalesDigest)
+			admin.POST("/reports/sales-digest/send", requireDatabase, d.AdminHandler.SendSalesDigest)
+			admin.DELETE("/orders/:orderId", requireDatabase, d.OrderHandler.DeleteOrder)
+			admin.POST("/orders/:orderId/ready", requireDatabase, d.NotificationHandler.OrderReady)
+			admin.DELETE("/orders/:orderId/delivery", requireDatabase, d.DeliveryHandler.Cancel)
+			admin.POST("/tables/:tableNumber/tab/close", requireDatabase, d.TabHandler.Close)
+			admin.GET("/kitchen/tables", requireDatabase, d.TabHandler.Kitchen)
+			admin.GET("/notifications/stats", requireDatabase, d.NotificationHandler.DeliveryStats)
+			admin.POST("/notifications/:notificationId/redeliver", requireDatabase, d.NotificationHandler.Redeliver)
+			admin.GET("/webhooks/deliveries", requireDatabase, d.WebhookHandler.ListDeliveries)
+			admin.GET("/webhooks/deliveries/:deliveryId", requireDatabase, d.WebhookHandler.GetDelivery)
+			admin.POST("/webhooks/deliveries/:deliveryId/redeliver", requireDatabase, d.WebhookHandler.Redeliver)
+			admin.POST("/orders/:orderId/refund", requireDatabase, d.PaymentHandler.RefundOrder)
+			admin.GET("/orders/:orderId/refunds", requireDatabase, d.PaymentHandler.ListOrderRefunds)
+			admin.POST("/gift-cards", requireDatabase, d.GiftCardHandler.Issue)
+			admin.GET("/gift-cards/:code", requireDatabase, d.GiftCardHandler.Get)
+			admin.POST("/payments/:intentId/capture", d.PaymentHandler.Capture)
+			admin.POST("/payments/:intentId/refund", d.PaymentHandler.Refund)
+			admin.GET("/pricing/tiers", requireDatabase, d.PricingHandler.ListTiers)
+			admin.PUT("/pricing/tiers/:tier", requireDatabase, d.PricingHandler.SaveTier)
+			admin.DELETE("/pricing/tiers/:tier", requireDatabase, d.PricingHandler.DeleteTier)
+			admin.GET("/pricing/assignments", requireDatabase, d.PricingHandler.ListAssignments)
+			admin.PUT("/pricing/assignments", requireDatabase, d.PricingHandler.Assign)
+			admin.GET("/stores", requireDatabase, d.StoreHandler.ListStores)
+			admin.POST("/stores", requireDatabase, d.StoreHandler.CreateStore)
+			admin.GET("/stores/:storeId", requireDatabase, d.StoreHandler.GetStore)
+			admin.PUT("/stores/:storeId", requireDatabase, d.StoreHandler.UpdateStore)
+			admin.GET("/stores/:storeId/api-keys", requireDatabase, d.StoreHandler.ListAPIKeys)
+			admin.POST("/stores/:storeId/api-keys", requireDatabase, d.StoreHandler.CreateAPIKey)
+			admin.DELETE("/stores/:storeId/api-keys/:keyId", requireDatabase, d.StoreHandler.RevokeAPIKey)
+			admin.GET("/stores/:storeId/hours", requireDatabase, d.StoreHandler.GetOpeningHours)
+			admin.PUT("/stores/:storeId/hours", requireDatabase, d.StoreHandler.SetOpeningHours)
+			admin.DELETE("/stores/:storeId/hours", requireDatabase, d.StoreHandler.DeleteOpeningHours)
+		}
 	}
 
+	// GraphQL endpoint for clients that select fields across products, orders and the
+	// queue status in one request (authentication + rate limiting)
+	r.POST("/graphql", d.AuthMiddleware, d.RateLimitMiddleware.RateLimitNamed("graphql", 50, time.Minute), requireDatabase, resolvePricingTier, d.GraphQLHandler.Query)
+
 	return r
 }
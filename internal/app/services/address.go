@@ -0,0 +1,147 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"oolio/internal/app/models"
+	"oolio/internal/app/repository"
+)
+
+const (
+	// MaxCustomerAddresses caps the size of one customer's address book
+	MaxCustomerAddresses = 20
+	// DefaultAddressID as OrderReq.AddressID picks the customer's default address
+	DefaultAddressID = "default"
+)
+
+var (
+	ErrInvalidAddress   = errors.New("invalid address")
+	ErrAddressBookFull  = fmt.Errorf("address book is full: at most %d addresses can be saved", MaxCustomerAddresses)
+	ErrNoDefaultAddress = errors.New("no saved addresses")
+)
+
+type AddressService interface {
+	ListAddresses(ctx context.Context, customerID string) ([]models.Address, error)
+	AddAddress(ctx context.Context, customerID string, req models.AddressReq) (*models.Address, error)
+	SetDefaultAddress(ctx context.Context, customerID, id string) error
+	DeleteAddress(ctx context.Context, customerID, id string) error
+	// ResolveDeliveryAddress returns the saved address an order refers to by ID, or the
+	// default address for DefaultAddressID
+	ResolveDeliveryAddress(ctx context.Context, customerID, addressID string) (*models.DeliveryAddress, error)
+}
+
+type addressService struct {
+	repo repository.AddressRepository
+}
+
+func NewAddressService(repo repository.AddressRepository) AddressService {
+	return &addressService{repo: repo}
+}
+
+func (s *addressService) ListAddresses(ctx context.Context, customerID string) ([]models.Address, error) {
+	addresses, err := s.repo.FindByCustomer(ctx, customerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list addresses: %w", err)
+	}
+	return addresses, nil
+}
+
+func (s *addressService) AddAddress(ctx context.Context, customerID string, req models.AddressReq) (*models.Address, error) {
+	address := &models.Address{
+		Label:           strings.TrimSpace(req.Label),
+		DeliveryAddress: req.DeliveryAddress,
+		IsDefault:       req.IsDefault,
+	}
+	if utf8.RuneCountInString(address.Label) > 50 {
+		return nil, fmt.Errorf("%w: label is longer than 50 characters", ErrInvalidAddress)
+	}
+	if err := ValidateDeliveryAddress(&address.DeliveryAddress); err != nil {
+		return nil, err
+	}
+
+	count, err := s.repo.Count(ctx, customerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add address: %w", err)
+	}
+	if count >= MaxCustomerAddresses {
+		return nil, ErrAddressBookFull
+	}
+
+	if err := s.repo.Create(ctx, customerID, address); err != nil {
+		return nil, fmt.Errorf("failed to add address: %w", err)
+	}
+	return address, nil
+}
+
+func (s *addressService) SetDefaultAddress(ctx context.Context, customerID, id string) error {
+	if err := s.repo.SetDefault(ctx, customerID, id); err != nil {
+		return fmt.Errorf("failed to set default address: %w", err)
+	}
+	return nil
+}
+
+func (s *addressService) DeleteAddress(ctx context.Context, customerID, id string) error {
+	if err := s.repo.Delete(ctx, customerID, id); err != nil {
+		return fmt.Errorf("failed to delete address: %w", err)
+	}
+	return nil
+}
+
+func (s *addressService) ResolveDeliveryAddress(ctx context.Context, customerID, addressID string) (*models.DeliveryAddress, error) {
+	if addressID != DefaultAddressID {
+		address, err := s.repo.FindOne(ctx, customerID, addressID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve address %s: %w", addressID, err)
+		}
+		return &address.DeliveryAddress, nil
+	}
+
+	// The default address is listed first
+	addresses, err := s.repo.FindByCustomer(ctx, customerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve default address: %w", err)
+	}
+	if len(addresses) == 0 || !addresses[0].IsDefault {
+		return nil, ErrNoDefaultAddress
+	}
+	return &addresses[0].DeliveryAddress, nil
+}
+
+// ValidateDeliveryAddress trims the address in place, upper-cases the country code and
+// checks the required fields and lengths
+func ValidateDeliveryAddress(address *models.DeliveryAddress) error {
+	// Limits match the customer_addresses columns
+	fields := []struct {
+		name  string
+		value *string
+		max   int
+	}{
+		{"line1", &address.Line1, 200},
+		{"line2", &address.Line2, 200},
+		{"city", &address.City, 100},
+		{"region", &address.Region, 100},
+		{"postalCode", &address.PostalCode, 20},
+		{"instructions", &address.Instructions, 500},
+	}
+	for _, field := range fields {
+		*field.value = strings.TrimSpace(*field.value)
+		if utf8.RuneCountInString(*field.value) > field.max {
+			return fmt.Errorf("%w: %s is longer than %d characters", ErrInvalidAddress, field.name, field.max)
+		}
+	}
+	address.Country = strings.ToUpper(strings.TrimSpace(address.Country))
+
+	switch {
+	case address.Line1 == "":
+		return fmt.Errorf("%w: line1 is required", ErrInvalidAddress)
+	case address.City == "":
+		return fmt.Errorf("%w: city is required", ErrInvalidAddress)
+	case len(address.Country) != 2 || strings.Trim(address.Country, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "":
+		return fmt.Errorf("%w: country must be a two-letter ISO 3166-1 code", ErrInvalidAddress)
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Alert is an operational event that needs a human, e.g. an order that failed for good
+type Alert struct {
+	Title string
+	Text  string
+}
+
+// Alerter notifies operators. Callers treat delivery as best effort: a failed alert is
+// logged and never fails the operation that raised it. Providers are selected by
+// ALERT_PROVIDER.
+type Alerter interface {
+	Alert(ctx context.Context, alert Alert) error
+}
+
+// noopAlerter is the default when no alert channel is configured
+type noopAlerter struct{}
+
+func NewNoopAlerter() Alerter {
+	return noopAlerter{}
+}
+
+func (noopAlerter) Alert(ctx context.Context, alert Alert) error {
+	return nil
+}
+
+// discordMaxContent is the longest message Discord accepts
+const discordMaxContent = 2000
+
+// webhookAlerter posts alerts to a Slack or Discord incoming webhook; they differ only in
+// the shape of the message body
+type webhookAlerter struct {
+	client *http.Client
+	url    string
+	body   func(alert Alert) any
+}
+
+// NewSlackAlerter posts to a Slack incoming webhook URL
+func NewSlackAlerter(url string, timeout time.Duration) Alerter {
+	return &webhookAlerter{
+		client: &http.Client{Timeout: timeout},
+		url:    url,
+		body: func(alert Alert) any {
+			return map[string]string{"text": fmt.Sprintf("*%s*\n%s", alert.Title, alert.Text)}
+		},
+	}
+}
+
+// NewDiscordAlerter posts to a Discord webhook URL
+func NewDiscordAlerter(url string, timeout time.Duration) Alerter {
+	return &webhookAlerter{
+		client: &http.Client{Timeout: timeout},
+		url:    url,
+		body: func(alert Alert) any {
+			content := fmt.Sprintf("**%s**\n%s", alert.Title, alert.Text)
+			if runes := []rune(content); len(runes) > discordMaxContent {
+				content = string(runes[:discordMaxContent-1]) + "…"
+			}
+			return map[string]string{"content": content}
+		},
+	}
+}
+
+func (a *webhookAlerter) Alert(ctx context.Context, alert Alert) error {
+	body, err := json.Marshal(a.body(alert))
+	if err != nil {
+		return fmt.Errorf("failed to marshal alert: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build alert request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send alert %q: %w", alert.Title, err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("alert webhook rejected %q: %s", alert.Title, resp.Status)
+	}
+	return nil
+}
+
+// emailAlerter emails alerts, for teams that watch a shared inbox rather than a chat channel
+type emailAlerter struct {
+	email EmailSender
+	to    []string
+}
+
+// NewEmailAlerter emails every alert to each address in to
+func NewEmailAlerter(email EmailSender, to []string) Alerter {
+	return &emailAlerter{email: email, to: to}
+}
+
+func (a *emailAlerter) Alert(ctx context.Context, alert Alert) error {
+	var errs []error
+	for _, to := range a.to {
+		if err := a.email.SendEmail(ctx, to, "[Alert] "+alert.Title, alert.Text); err != nil {
+			errs = append(errs, fmt.Errorf("failed to email alert %q to %s: %w", alert.Title, to, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// multiAlerter sends each alert to every channel, so one channel being down doesn't stop
+// the others
+type multiAlerter []Alerter
+
+// NewMultiAlerter sends alerts through all the alerters; with one alerter it returns it as is
+func NewMultiAlerter(alerters ...Alerter) Alerter {
+	if len(alerters) == 1 {
+		return alerters[0]
+	}
+	return multiAlerter(alerters)
+}
+
+func (m multiAlerter) Alert(ctx context.Context, alert Alert) error {
+	var errs []error
+	for _, alerter := range m {
+		if err := alerter.Alert(ctx, alert); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,282 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"go.uber.org/zap"
+
+	"oolio/internal/app/models"
+	"oolio/internal/app/repository"
+)
+
+const (
+	// MaxCartItems caps the number of different products in one cart
+	MaxCartItems = 50
+	// MaxCartQuantity caps the quantity of one product in a cart
+	MaxCartQuantity = 99
+)
+
+var (
+	ErrInvalidCartItem = errors.New("invalid cart item")
+	ErrCartFull        = fmt.Errorf("cart is full: at most %d different products fit", MaxCartItems)
+	ErrCartEmpty       = errors.New("cart is empty")
+
+	errCartQuantity = fmt.Errorf("%w: quantity must be between 1 and %d", ErrInvalidCartItem, MaxCartQuantity)
+)
+
+// CartOwner names the cart a request works on: the customer's cart when CustomerID is set,
+// otherwise the guest cart whose ID is Token
+type CartOwner struct {
+	CustomerID string
+	Token      string
+}
+
+type CartService interface {
+	// GetCart returns the owner's cart with its products. An owner who has no cart yet gets
+	// an empty one without an ID.
+	GetCart(ctx context.Context, owner CartOwner) (*models.Cart, error)
+	// AddItem adds a product to the cart, starting a guest cart when the owner names none.
+	// A product already in the cart takes the options sent. Options the product doesn't
+	// have fail with ErrInvalidOption.
+	AddItem(ctx context.Context, owner CartOwner, req models.CartItemReq) (*models.Cart, error)
+	SetQuantity(ctx context.Context, owner CartOwner, productID string, quantity int) (*models.Cart, error)
+	RemoveItem(ctx context.Context, owner CartOwner, productID string) (*models.Cart, error)
+	Clear(ctx context.Context, owner CartOwner) error
+	// CheckoutOrder builds the order request for the cart's items. The cart is left as it
+	// is; callers Clear it once the order is placed.
+	CheckoutOrder(ctx context.Context, owner CartOwner, req models.CheckoutReq) (*models.OrderReq, error)
+	// DeleteExpired deletes the guest carts that have expired
+	DeleteExpired(ctx context.Context) error
+}
+
+type cartService struct {
+	repo     repository.CartRepository
+	products repository.ProductRepository
+	guestTTL time.Duration
+	logger   *zap.Logger
+}
+
+// NewCartService returns the service; guest carts expire guestTTL after their last change
+func NewCartService(repo repository.CartRepository, products repository.ProductRepository, guestTTL time.Duration, logger *zap.Logger) CartService {
+	return &cartService{
+		repo:     repo,
+		products: products,
+		guestTTL: guestTTL,
+		logger:   logger,
+	}
+}
+
+func (s *cartService) GetCart(ctx context.Context, owner CartOwner) (*models.Cart, error) {
+	cart, err := s.find(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+	if cart == nil {
+		return &models.Cart{Items: []models.CartItem{}, Products: []models.Product{}}, nil
+	}
+	return s.withProducts(ctx, cart)
+}
+
+func (s *cartService) AddItem(ctx context.Context, owner CartOwner, req models.CartItemReq) (*models.Cart, error) {
+	if req.Quantity == 0 {
+		req.Quantity = 1
+	}
+	if req.Quantity < 0 || req.Quantity > MaxCartQuantity {
+		return nil, errCartQuantity
+	}
+	product, err := s.products.FindOne(ctx, req.ProductID)
+	if err != nil {
+		return nil, err
+	}
+	item := models.CartItem{ProductID: req.ProductID, Quantity: req.Quantity, VariantID: req.VariantID, ModifierIDs: req.ModifierIDs}
+	if _, err := chosenOptions(models.OrderItem{VariantID: item.VariantID, ModifierIDs: item.ModifierIDs}, *product); err != nil {
+		return nil, err
+	}
+
+	cart, err := s.find(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+	if cart == nil {
+		if owner.CustomerID != "" {
+			cart, err = s.repo.EnsureForCustomer(ctx, owner.CustomerID)
+		} else {
+			cart, err = s.repo.CreateGuest(ctx, time.Now().Add(s.guestTTL))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to create cart: %w", err)
+		}
+	}
+
+	if i := slices.IndexFunc(cart.Items, func(item models.CartItem) bool { return item.ProductID == req.ProductID }); i >= 0 {
+		if cart.Items[i].Quantity+req.Quantity > MaxCartQuantity {
+			return nil, errCartQuantity
+		}
+	} else if len(cart.Items) >= MaxCartItems {
+		return nil, ErrCartFull
+	}
+
+	if err := s.repo.AddItem(ctx, cart.ID, item); err != nil {
+		return nil, fmt.Errorf("failed to add cart item: %w", err)
+	}
+	return s.changed(ctx, owner, cart.ID)
+}
+
+func (s *cartService) SetQuantity(ctx context.Context, owner CartOwner, productID string, quantity int) (*models.Cart, error) {
+	if quantity < 1 || quantity > MaxCartQuantity {
+		return nil, errCartQuantity
+	}
+
+	cart, err := s.mustFind(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.SetQuantity(ctx, cart.ID, models.CartItem{ProductID: productID, Quantity: quantity}); err != nil {
+		return nil, fmt.Errorf("failed to update cart item: %w", err)
+	}
+	return s.changed(ctx, owner, cart.ID)
+}
+
+func (s *cartService) RemoveItem(ctx context.Context, owner CartOwner, productID string) (*models.Cart, error) {
+	cart, err := s.mustFind(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.RemoveItem(ctx, cart.ID, productID); err != nil {
+		return nil, fmt.Errorf("failed to remove cart item: %w", err)
+	}
+	return s.changed(ctx, owner, cart.ID)
+}
+
+func (s *cartService) Clear(ctx context.Context, owner CartOwner) error {
+	cart, err := s.mustFind(ctx, owner)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Clear(ctx, cart.ID); err != nil {
+		return fmt.Errorf("failed to clear cart: %w", err)
+	}
+	if err := s.repo.Touch(ctx, cart.ID, time.Now().Add(s.guestTTL)); err != nil {
+		return fmt.Errorf("failed to clear cart: %w", err)
+	}
+	return nil
+}
+
+func (s *cartService) CheckoutOrder(ctx context.Context, owner CartOwner, req models.CheckoutReq) (*models.OrderReq, error) {
+	cart, err := s.GetCart(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+	if len(cart.Items) == 0 {
+		return nil, ErrCartEmpty
+	}
+
+	orderReq := &models.OrderReq{
+		CouponCode:      req.CouponCode,
+		AddressID:       req.AddressID,
+		DeliveryAddress: req.DeliveryAddress,
+		Phone:           req.Phone,
+		Email:           req.Email,
+		PaymentIntentID: req.PaymentIntentID,
+		PaymentMethod:   req.PaymentMethod,
+	}
+	for _, item := range cart.Items {
+		orderReq.Items = append(orderReq.Items, models.OrderItem{
+			ProductID:   item.ProductID,
+			Quantity:    item.Quantity,
+			VariantID:   item.VariantID,
+			ModifierIDs: item.ModifierIDs,
+		})
+	}
+	return orderReq, nil
+}
+
+func (s *cartService) DeleteExpired(ctx context.Context) error {
+	deleted, err := s.repo.DeleteExpired(ctx, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to delete expired carts: %w", err)
+	}
+	if deleted > 0 {
+		s.logger.Info("Deleted expired guest carts", zap.Int("count", deleted))
+	}
+	return nil
+}
+
+// find returns the owner's cart, nil when the owner has none yet. An unknown or expired
+// guest token is an error so the client can forget it.
+func (s *cartService) find(ctx context.Context, owner CartOwner) (*models.Cart, error) {
+	var cart *models.Cart
+	var err error
+	switch {
+	case owner.CustomerID != "":
+		cart, err = s.repo.FindByCustomer(ctx, owner.CustomerID)
+		if isCartNotFound(err) {
+			return nil, nil
+		}
+	case owner.Token != "":
+		cart, err = s.repo.FindGuest(ctx, owner.Token)
+		if isCartNotFound(err) {
+			return nil, fmt.Errorf("cart not found")
+		}
+	default:
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cart: %w", err)
+	}
+	return cart, nil
+}
+
+// mustFind is find for changes that need an existing cart
+func (s *cartService) mustFind(ctx context.Context, owner CartOwner) (*models.Cart, error) {
+	cart, err := s.find(ctx, owner)
+	if err == nil && cart == nil {
+		err = fmt.Errorf("cart not found")
+	}
+	return cart, err
+}
+
+// changed records a change to the cart, extending a guest cart's life, and returns it as
+// it is now
+func (s *cartService) changed(ctx context.Context, owner CartOwner, cartID string) (*models.Cart, error) {
+	if err := s.repo.Touch(ctx, cartID, time.Now().Add(s.guestTTL)); err != nil {
+		return nil, fmt.Errorf("failed to update cart: %w", err)
+	}
+	if owner.CustomerID == "" {
+		// A cart started by this request has no token on the owner yet
+		owner.Token = cartID
+	}
+	return s.GetCart(ctx, owner)
+}
+
+// withProducts loads the cart's products. Items whose product was deleted since are left
+// out, so they can't be ordered.
+func (s *cartService) withProducts(ctx context.Context, cart *models.Cart) (*models.Cart, error) {
+	cart.Products = []models.Product{}
+	if len(cart.Items) == 0 {
+		return cart, nil
+	}
+
+	ids := make([]string, len(cart.Items))
+	for i, item := range cart.Items {
+		ids[i] = item.ProductID
+	}
+	products, err := s.products.FindByIDs(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cart products: %w", err)
+	}
+
+	cart.Items = slices.DeleteFunc(cart.Items, func(item models.CartItem) bool {
+		return !slices.ContainsFunc(products, func(p models.Product) bool { return p.ID == item.ProductID })
+	})
+	cart.Products = products
+	return cart, nil
+}
+
+func isCartNotFound(err error) bool {
+	return err != nil && err.Error() == "cart not found"
+}
@@ -0,0 +1,51 @@
+package services
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"oolio/internal/app/repository"
+)
+
+// catalogVersionKey names the current catalog version in its cache
+const catalogVersionKey = "version"
+
+// CatalogVersion names the current state of the product catalog, so whatever is derived
+// from it can be keyed by it and dropped all at once when a product changes. It is kept in
+// a cache, shared between instances when the cache is.
+type CatalogVersion struct {
+	cache repository.Cache
+}
+
+// NewCatalogVersion keeps the catalog version in cache; nil without one
+func NewCatalogVersion(cache repository.Cache) *CatalogVersion {
+	if cache == nil {
+		return nil
+	}
+	return &CatalogVersion{cache: cache}
+}
+
+// Current returns the catalog version, starting a new one when the cache has lost it
+func (v *CatalogVersion) Current(ctx context.Context) (string, error) {
+	version, ok, err := v.cache.Get(ctx, catalogVersionKey)
+	if err != nil {
+		return "", err
+	}
+	if ok {
+		return string(version), nil
+	}
+	return v.bump(ctx)
+}
+
+// Bump starts a new catalog version; it is a ProductChangeHook
+func (v *CatalogVersion) Bump(ctx context.Context) {
+	if v != nil {
+		_, _ = v.bump(context.WithoutCancel(ctx))
+	}
+}
+
+func (v *CatalogVersion) bump(ctx context.Context) (string, error) {
+	version := strconv.FormatInt(time.Now().UnixNano(), 36)
+	return version, v.cache.Set(ctx, catalogVersionKey, []byte(version), 0)
+}
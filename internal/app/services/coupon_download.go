@@ -0,0 +1,140 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// CouponHTTPOptions tunes the client coupon files are downloaded with; zero values fall
+// back to the defaults below. There is no overall timeout: a download may take up to the
+// coupon file timeout, but it must connect and start answering quickly.
+type CouponHTTPOptions struct {
+	DialTimeout           time.Duration
+	TLSHandshakeTimeout   time.Duration
+	ResponseHeaderTimeout time.Duration
+	IdleConnTimeout       time.Duration
+	MaxIdleConnsPerHost   int
+}
+
+const (
+	defaultCouponDialTimeout           = 10 * time.Second
+	defaultCouponTLSHandshakeTimeout   = 10 * time.Second
+	defaultCouponResponseHeaderTimeout = 30 * time.Second
+	defaultCouponIdleConnTimeout       = 90 * time.Second
+	defaultCouponMaxIdleConnsPerHost   = 4
+	defaultCouponDownloadRetries       = 3
+)
+
+// NewCouponHTTPClient returns a client meant to be shared by every coupon download, so
+// refreshes reuse their connections to the file host
+func NewCouponHTTPClient(opts CouponHTTPOptions) *http.Client {
+	if opts.DialTimeout <= 0 {
+		opts.DialTimeout = defaultCouponDialTimeout
+	}
+	if opts.TLSHandshakeTimeout <= 0 {
+		opts.TLSHandshakeTimeout = defaultCouponTLSHandshakeTimeout
+	}
+	if opts.ResponseHeaderTimeout <= 0 {
+		opts.ResponseHeaderTimeout = defaultCouponResponseHeaderTimeout
+	}
+	if opts.IdleConnTimeout <= 0 {
+		opts.IdleConnTimeout = defaultCouponIdleConnTimeout
+	}
+	if opts.MaxIdleConnsPerHost <= 0 {
+		opts.MaxIdleConnsPerHost = defaultCouponMaxIdleConnsPerHost
+	}
+
+	dialer := &net.Dialer{Timeout: opts.DialTimeout, KeepAlive: 30 * time.Second}
+	return &http.Client{
+		Transport: &http.Transport{
+			Proxy:                 http.ProxyFromEnvironment,
+			DialContext:           dialer.DialContext,
+			ForceAttemptHTTP2:     true,
+			TLSHandshakeTimeout:   opts.TLSHandshakeTimeout,
+			ResponseHeaderTimeout: opts.ResponseHeaderTimeout,
+			IdleConnTimeout:       opts.IdleConnTimeout,
+			MaxIdleConnsPerHost:   opts.MaxIdleConnsPerHost,
+			// Files are gzip already, and transparent decompression would throw off the
+			// byte offsets downloads resume from
+			DisableCompression: true,
+		},
+	}
+}
+
+// resumableBody reads a download and, when the connection breaks part way, requests the
+// rest with a range request instead of starting over. It gives up after retries resumes,
+// or when the server can't resume or the file changed in the meantime.
+type resumableBody struct {
+	ctx       context.Context
+	client    *http.Client
+	url       string
+	validator string // ETag, else Last-Modified, sent as If-Range so a changed file isn't spliced
+	body      io.ReadCloser
+	offset    int64
+	retries   int
+	logger    *zap.Logger
+}
+
+func newResumableBody(ctx context.Context, client *http.Client, url string, resp *http.Response, retries int, logger *zap.Logger) *resumableBody {
+	validator := resp.Header.Get("ETag")
+	if validator == "" || strings.HasPrefix(validator, "W/") {
+		validator = resp.Header.Get("Last-Modified")
+	}
+	return &resumableBody{ctx: ctx, client: client, url: url, validator: validator, body: resp.Body, retries: retries, logger: logger}
+}
+
+func (b *resumableBody) Read(p []byte) (int, error) {
+	for {
+		n, err := b.body.Read(p)
+		b.offset += int64(n)
+		if err == nil || errors.Is(err, io.EOF) || b.retries <= 0 || b.validator == "" || b.ctx.Err() != nil {
+			return n, err
+		}
+		if n > 0 {
+			// The broken body fails again on the next read, which resumes
+			return n, nil
+		}
+
+		if resumeErr := b.resume(); resumeErr != nil {
+			return 0, fmt.Errorf("%w (resuming failed: %v)", err, resumeErr)
+		}
+		b.logger.Warn("Resumed coupon download", zap.String("url", b.url), zap.Int64("offset", b.offset), zap.Error(err))
+	}
+}
+
+func (b *resumableBody) resume() error {
+	b.retries--
+	b.body.Close()
+
+	req, err := http.NewRequestWithContext(b.ctx, http.MethodGet, b.url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-", b.offset))
+	req.Header.Set("If-Range", b.validator)
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		b.body = http.NoBody
+		return err
+	}
+	if resp.StatusCode != http.StatusPartialContent || !strings.HasPrefix(resp.Header.Get("Content-Range"), fmt.Sprintf("bytes %d-", b.offset)) {
+		resp.Body.Close()
+		b.body = http.NoBody
+		return fmt.Errorf("server answered the range request with %s", resp.Status)
+	}
+	b.body = resp.Body
+	return nil
+}
+
+func (b *resumableBody) Close() error {
+	return b.body.Close()
+}
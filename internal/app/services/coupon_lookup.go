@@ -0,0 +1,38 @@
+package services
+
+import "strings"
+
+// CouponTerms are what a valid coupon gives and who may use it
+type CouponTerms struct {
+	Discount  float64 // Percentage taken off the order
+	Segment   string  // The only customer segment that may use it; empty for anyone
+	SingleUse bool    // Each customer may use it once
+	StoreID   string  // The only store it is valid at; empty for every store
+}
+
+// couponLookup resolves coupon codes against one refresh of the named, stored and file
+// codes. It is built whole whenever any of them changes and never modified afterwards, so
+// orders read it without locking.
+type couponLookup struct {
+	codes     map[string]CouponTerms // Named and stored codes (upper case); named ones win
+	files     *couponSet
+	fileTerms CouponTerms
+	minLength int
+	maxLength int
+}
+
+// resolve returns the terms of a valid coupon code, false for codes that aren't
+func (l *couponLookup) resolve(code string) (CouponTerms, bool) {
+	if len(code) < l.minLength || len(code) > l.maxLength {
+		return CouponTerms{}, false
+	}
+	// Upper case codes, as customers mostly type them, are looked up without copying
+	if terms, ok := l.codes[strings.ToUpper(code)]; ok {
+		return terms, true
+	}
+	// Codes from the files are case sensitive
+	if l.files.Contains(code) {
+		return l.fileTerms, true
+	}
+	return CouponTerms{}, false
+}
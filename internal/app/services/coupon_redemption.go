@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/mail"
+	"strings"
+
+	"oolio/internal/app/models"
+	"oolio/internal/app/repository"
+)
+
+var (
+	ErrCouponAlreadyUsed   = errors.New("this coupon can only be used once and has already been used")
+	ErrCouponNeedsRedeemer = errors.New("single-use coupons need X-Customer-ID, or a phone or email for guest orders")
+	ErrInvalidEmail        = errors.New("email must be a plain address, e.g. jo@example.com")
+)
+
+// CouponRedemptionService enforces single-use coupons: each customer, or guest phone or
+// email, may use them once
+type CouponRedemptionService interface {
+	// Check fails with ErrCouponAlreadyUsed or ErrCouponNeedsRedeemer when the order's
+	// coupon is single-use and its redeemer can't use it; it records nothing
+	Check(ctx context.Context, orderReq *models.OrderReq) error
+	// Redeem records the use of the order's coupon when it is single-use, failing like
+	// Check, and reports whether it recorded one
+	Redeem(ctx context.Context, orderReq *models.OrderReq) (bool, error)
+	// Release forgets a use recorded by Redeem, for orders that were not created
+	Release(ctx context.Context, orderReq *models.OrderReq) error
+}
+
+type couponRedemptionService struct {
+	coupons CouponService
+	repo    repository.CouponRedemptionRepository
+}
+
+func NewCouponRedemptionService(coupons CouponService, repo repository.CouponRedemptionRepository) CouponRedemptionService {
+	return &couponRedemptionService{coupons: coupons, repo: repo}
+}
+
+func (s *couponRedemptionService) Check(ctx context.Context, orderReq *models.OrderReq) error {
+	redeemer, err := s.redeemer(orderReq)
+	if err != nil || redeemer == "" {
+		return err
+	}
+
+	redeemed, err := s.repo.IsRedeemed(ctx, strings.ToUpper(orderReq.CouponCode), redeemer)
+	if err != nil {
+		return err
+	}
+	if redeemed {
+		return ErrCouponAlreadyUsed
+	}
+	return nil
+}
+
+func (s *couponRedemptionService) Redeem(ctx context.Context, orderReq *models.OrderReq) (bool, error) {
+	redeemer, err := s.redeemer(orderReq)
+	if err != nil || redeemer == "" {
+		return false, err
+	}
+
+	if err := s.repo.Redeem(ctx, strings.ToUpper(orderReq.CouponCode), redeemer); err != nil {
+		if err.Error() == "coupon already redeemed" {
+			return false, ErrCouponAlreadyUsed
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *couponRedemptionService) Release(ctx context.Context, orderReq *models.OrderReq) error {
+	redeemer, err := s.redeemer(orderReq)
+	if err != nil || redeemer == "" {
+		return err
+	}
+	return s.repo.Release(ctx, strings.ToUpper(orderReq.CouponCode), redeemer)
+}
+
+// redeemer returns who redeems the order's coupon, or "" when it has no single-use coupon
+func (s *couponRedemptionService) redeemer(orderReq *models.OrderReq) (string, error) {
+	if orderReq.CouponCode == "" || !s.coupons.SingleUse(orderReq.CouponCode) {
+		return "", nil
+	}
+	redeemer := orderReq.CouponRedeemer()
+	if redeemer == "" {
+		return "", ErrCouponNeedsRedeemer
+	}
+	return redeemer, nil
+}
+
+// NormalizeGuestContact trims the phone and email of an order and checks their format
+func NormalizeGuestContact(orderReq *models.OrderReq) error {
+	orderReq.Phone = strings.TrimSpace(orderReq.Phone)
+	orderReq.Email = strings.TrimSpace(orderReq.Email)
+
+	if orderReq.Phone != "" && !phonePattern.MatchString(orderReq.Phone) {
+		return ErrInvalidPhone
+	}
+	if orderReq.Email != "" && !validEmail(orderReq.Email) {
+		return ErrInvalidEmail
+	}
+	return nil
+}
+
+// validEmail accepts bare addresses only, without a display name
+func validEmail(email string) bool {
+	address, err := mail.ParseAddress(email)
+	return err == nil && address.Address == email && len(email) <= 254
+}
@@ -1,184 +1,668 @@
 package services
 
 import (
+	"bufio"
+	"bytes"
 	"compress/gzip"
 	"context"
 	"encoding/csv"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"os"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+	"unicode"
+
+	"go.uber.org/zap"
+
+	"oolio/internal/app/models"
+	"oolio/internal/app/repository"
+	"oolio/internal/storage"
+	"oolio/internal/workerpool"
 )
 
 type CouponService interface {
 	DownloadAndParseCouponFiles(ctx context.Context) error
+	ResolveCoupon(code string) (CouponTerms, bool)
 	ValidateCoupon(code string) bool
 	GetDiscountPercentage(code string) float64
-	StartPeriodicRefresh(ctx context.Context, interval time.Duration)
+	RequiredSegment(code string) string
+	SingleUse(code string) bool
+	GetStats() models.CouponStats
+	SetDiscounts(discounts map[string]float64, defaultDiscount float64)
+	DeleteCoupon(ctx context.Context, code string) error
+	RestoreCoupon(ctx context.Context, code string) error
+	GetDeletedCoupons(ctx context.Context, since time.Time) ([]models.Coupon, error)
 }
 
+// ErrNoCouponStore is returned when managing stored coupons without a database, e.g. in memory mode
+var ErrNoCouponStore = errors.New("stored coupons are not available")
+
+// CouponOptions tunes coupon validation; zero values fall back to the defaults below
+type CouponOptions struct {
+	BaseURL            string
+	Files              []string
+	Discounts          map[string]float64 // Named coupon codes to discount percentage
+	Segments           map[string]string  // Named coupon codes only customers in this segment may use
+	SingleUse          []string           // Named coupon codes each customer may use once
+	FileCodesSingleUse bool               // Codes from the coupon files may be used once per customer
+	DefaultDiscount    float64            // Discount for valid codes not in Discounts
+	MinLength          int
+	MaxLength          int
+	MinFileOccurrences int   // A code is valid once it appears in at least this many files
+	MaxDownloadMB      int64 // Negative disables the download size limit
+	FileTimeout        time.Duration
+	HTTPClient         *http.Client                // Shared by every download; NewCouponHTTPClient's defaults when nil
+	DownloadRetries    int                         // Times a broken download resumes where it stopped; negative disables
+	ParseBufferSize    int                         // Bytes read from a file at a time; lines longer than this are cut
+	CSVParser          bool                        // Parse files as CSV rather than one code per line
+	Store              repository.CouponRepository // Optional; its codes are reloaded on every refresh
+	Alerter            Alerter                     // Optional; told about files that failed to refresh
+	Cache              storage.Storage             // Optional; keeps the last good copy of each file for failed downloads
+	Pool               *workerpool.Pool            // Downloads and parses the files at once; one at a time when nil
+}
+
+const (
+	defaultCouponDiscount     = 5.0
+	defaultCouponMinLength    = 8
+	defaultCouponMaxLength    = 10
+	defaultMinFileOccurrences = 2
+	defaultMaxDownloadMB      = 1000 // Limit downloads to 1GB by default to handle large coupon files
+	defaultCouponFileTimeout  = 120 * time.Second
+
+	defaultCouponParseBufferSize = 256 << 10
+	minCouponParseBufferSize     = 4 << 10 // Room for a code and whatever follows it on its line
+)
+
 type couponService struct {
-	validCoupons   map[string]int // map of coupon code to count of files where it appears
-	mutex          sync.RWMutex
-	couponFiles    []string
-	baseURL        string
-	maxDownloadMB  int64 // Maximum download size in MB (0 = unlimited)
-	maxMemoryMB    int64 // Maximum memory buffer size in MB
-	filesProcessed bool  // Flag to track if files have been processed
-}
-
-func NewCouponService(baseURL string) CouponService {
-	return &couponService{
-		validCoupons: make(map[string]int),
-		couponFiles: []string{
+	// lookup is what coupons are resolved against; it is rebuilt under mutex whenever the
+	// codes below change and read without it
+	lookup atomic.Pointer[couponLookup]
+
+	validCoupons       *couponSet // Codes found in at least minFileOccurrences coupon files
+	mutex              sync.Mutex
+	couponFiles        []string
+	baseURL            string
+	maxDownloadMB      int64 // Maximum download size in MB (0 = unlimited)
+	maxMemoryMB        int64 // Maximum memory buffer size in MB
+	filesProcessed     bool  // Flag to track if files have been processed
+	logger             *zap.Logger
+	discounts          map[string]float64 // Named coupon codes (upper case) to discount percentage
+	store              repository.CouponRepository
+	alerter            Alerter
+	cache              storage.Storage
+	storedCoupons      map[string]CouponTerms // Database coupon codes (upper case) to their terms
+	segments           map[string]string      // Named coupon codes (upper case) to required customer segment
+	singleUse          map[string]bool        // Named coupon codes (upper case) each customer may use once
+	fileCodesSingleUse bool
+	defaultDiscount    float64
+	minLength          int
+	maxLength          int
+	minFileOccurrences int
+	fileTimeout        time.Duration
+	client             *http.Client
+	downloadRetries    int
+	pool               *workerpool.Pool
+	parseBufferSize    int
+	csvParser          bool
+
+	// Refresh statistics are guarded separately so they can be read while a refresh holds mutex
+	statsMutex sync.RWMutex
+	stats      models.CouponStats
+}
+
+func NewCouponService(opts CouponOptions, logger *zap.Logger) CouponService {
+	if len(opts.Files) == 0 {
+		opts.Files = []string{
 			"couponbase1.gz",
 			"couponbase2.gz",
 			"couponbase3.gz",
-		},
-		baseURL:        baseURL,
-		maxDownloadMB:  1000, // Limit downloads to 1GB by default to handle large coupon files
-		maxMemoryMB:    10,   // Use 10MB buffer for streaming
-		filesProcessed: false,
+		}
+	}
+	if opts.DefaultDiscount <= 0 {
+		opts.DefaultDiscount = defaultCouponDiscount
+	}
+	if opts.MinLength <= 0 {
+		opts.MinLength = defaultCouponMinLength
+	}
+	if opts.MaxLength < opts.MinLength {
+		opts.MaxLength = max(defaultCouponMaxLength, opts.MinLength)
+	}
+	if opts.MinFileOccurrences <= 0 {
+		opts.MinFileOccurrences = defaultMinFileOccurrences
+	}
+	if opts.MaxDownloadMB == 0 {
+		opts.MaxDownloadMB = defaultMaxDownloadMB
+	}
+	if opts.FileTimeout <= 0 {
+		opts.FileTimeout = defaultCouponFileTimeout
+	}
+	if opts.Alerter == nil {
+		opts.Alerter = NewNoopAlerter()
+	}
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = NewCouponHTTPClient(CouponHTTPOptions{})
+	}
+	if opts.DownloadRetries == 0 {
+		opts.DownloadRetries = defaultCouponDownloadRetries
 	}
+	if opts.ParseBufferSize <= 0 {
+		opts.ParseBufferSize = defaultCouponParseBufferSize
+	}
+
+	s := &couponService{
+		validCoupons:       &couponSet{},
+		couponFiles:        opts.Files,
+		baseURL:            opts.BaseURL,
+		maxDownloadMB:      opts.MaxDownloadMB,
+		maxMemoryMB:        10, // Use 10MB buffer for streaming
+		filesProcessed:     false,
+		logger:             logger,
+		discounts:          normalizeDiscounts(opts.Discounts),
+		store:              opts.Store,
+		alerter:            opts.Alerter,
+		cache:              opts.Cache,
+		storedCoupons:      make(map[string]CouponTerms),
+		segments:           normalizeSegments(opts.Segments),
+		singleUse:          normalizeCodeSet(opts.SingleUse),
+		fileCodesSingleUse: opts.FileCodesSingleUse,
+		defaultDiscount:    opts.DefaultDiscount,
+		minLength:          opts.MinLength,
+		maxLength:          opts.MaxLength,
+		minFileOccurrences: opts.MinFileOccurrences,
+		fileTimeout:        opts.FileTimeout,
+		client:             opts.HTTPClient,
+		downloadRetries:    max(opts.DownloadRetries, 0),
+		pool:               opts.Pool,
+		parseBufferSize:    max(opts.ParseBufferSize, minCouponParseBufferSize),
+		csvParser:          opts.CSVParser,
+	}
+	s.publishLookup()
+	return s
+}
+
+// publishLookup rebuilds the lookup coupons are resolved against from the current codes.
+// Callers must hold mutex.
+func (s *couponService) publishLookup() {
+	codes := make(map[string]CouponTerms, len(s.storedCoupons)+len(s.discounts))
+	for code, terms := range s.storedCoupons {
+		codes[code] = terms
+	}
+	for code, discount := range s.discounts {
+		codes[code] = CouponTerms{Discount: discount, Segment: s.segments[code], SingleUse: s.singleUse[code]}
+	}
+
+	s.lookup.Store(&couponLookup{
+		codes:     codes,
+		files:     s.validCoupons,
+		fileTerms: CouponTerms{Discount: s.defaultDiscount, SingleUse: s.fileCodesSingleUse},
+		minLength: s.minLength,
+		maxLength: s.maxLength,
+	})
 }
 
 func (s *couponService) DownloadAndParseCouponFiles(ctx context.Context) error {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
-	// Reset valid coupons
-	s.validCoupons = make(map[string]int)
+	started := time.Now()
+	s.statsMutex.Lock()
+	s.stats.RefreshInProgress = true
+	s.stats.LastRefreshStarted = &started
+	s.statsMutex.Unlock()
+
+	s.loadStoredCoupons(ctx)
+
+	fileStats := make([]models.CouponFileStats, len(s.couponFiles))
+	fileCodes := make([]*couponSet, len(s.couponFiles))
 
 	// Download and parse each coupon file with timeout
-	for _, filename := range s.couponFiles {
-		// Create context with timeout for each file
-		fileCtx, cancel := context.WithTimeout(ctx, 120*time.Second) // 2 minutes per file
-		err := s.downloadAndParseFile(fileCtx, filename)
-		cancel()
+	errs := s.pool.Each(ctx, len(s.couponFiles), func(ctx context.Context, i int) error {
+		stats := &fileStats[i]
+		stats.Filename = s.couponFiles[i]
+		fileStarted := time.Now()
 
+		// Create context with timeout for each file
+		fileCtx, cancel := context.WithTimeout(ctx, s.fileTimeout)
+		defer cancel()
+		codes := newCouponSetBuilder(s.maxLength)
+		err := s.downloadAndParseFile(fileCtx, stats.Filename, stats, codes)
+
+		// Codes of a file that failed part way still count, as far as it was parsed
+		fileCodes[i], stats.Duplicates = codes.Build()
+		stats.DurationMs = time.Since(fileStarted).Milliseconds()
+		return err
+	})
+
+	errorCount := 0
+	for i, err := range errs {
+		if fileCodes[i] == nil {
+			// Never started, or panicked before its codes were built
+			fileCodes[i] = &couponSet{}
+			fileStats[i].Filename = s.couponFiles[i]
+		}
+		errorCount += fileStats[i].ParseErrors
 		if err != nil {
-			fmt.Printf("Warning: Failed to process file %s: %v\n", filename, err)
-			// Continue with other files instead of failing completely
-			continue
+			s.logger.Warn("Failed to process coupon file", zap.String("file", s.couponFiles[i]), zap.Error(err))
+			fileStats[i].Error = err.Error()
+			errorCount++
 		}
 	}
 
-	// Filter coupons to keep only those appearing in at least 2 files
-	for code, count := range s.validCoupons {
-		if count < 2 {
-			delete(s.validCoupons, code)
+	// Keep only the coupons appearing in at least minFileOccurrences files. Duplicates only
+	// counts codes repeated within a file: a code shared with other files is what makes it valid.
+	valid := mergeCouponSets(fileCodes, s.maxLength, s.minFileOccurrences)
+	for i := range fileStats {
+		if fileStats[i].CodesAccepted > 0 {
+			fileStats[i].DuplicateRate = float64(fileStats[i].Duplicates) / float64(fileStats[i].CodesAccepted)
 		}
 	}
+	s.validCoupons = valid
+	s.publishLookup()
 
 	s.filesProcessed = true
-	fmt.Printf("Coupon processing completed. Found %d valid coupons\n", len(s.validCoupons))
+
+	finished := time.Now()
+	s.statsMutex.Lock()
+	s.stats.ValidCoupons = s.validCoupons.Len()
+	s.stats.StoredCoupons = len(s.storedCoupons)
+	s.stats.FilesProcessed = true
+	s.stats.RefreshInProgress = false
+	s.stats.RefreshCount++
+	s.stats.ErrorCount += errorCount
+	s.stats.LastRefreshFinished = &finished
+	s.stats.LastRefreshMs = finished.Sub(started).Milliseconds()
+	s.stats.Files = fileStats
+	s.statsMutex.Unlock()
+
+	s.logger.Info("Coupon processing completed",
+		zap.Int("validCoupons", s.validCoupons.Len()),
+		zap.Duration("duration", finished.Sub(started)))
+
+	// Alert without holding mutex, validation would wait for the webhook otherwise
+	go s.alertFailedFiles(context.WithoutCancel(ctx), fileStats)
 	return nil
 }
 
-func (s *couponService) ValidateCoupon(code string) bool {
-	s.mutex.RLock()
-	defer s.mutex.RUnlock()
+func (s *couponService) alertFailedFiles(ctx context.Context, fileStats []models.CouponFileStats) {
+	var failed []string
+	for _, stats := range fileStats {
+		if stats.Error != "" {
+			failed = append(failed, fmt.Sprintf("%s: %s", stats.Filename, stats.Error))
+		}
+	}
+	if len(failed) == 0 {
+		return
+	}
+
+	err := s.alerter.Alert(ctx, Alert{
+		Title: "Coupon refresh failed",
+		Text: fmt.Sprintf("%d of %d coupon file(s) could not be processed; codes that only appear in them are rejected until the next refresh.\n%s",
+			len(failed), len(fileStats), strings.Join(failed, "\n")),
+	})
+	if err != nil {
+		s.logger.Warn("Failed to send coupon refresh alert", zap.Error(err))
+	}
+}
 
-	// Validate coupon length (8-10 characters)
-	if len(code) < 8 || len(code) > 10 {
-		return false
+// loadStoredCoupons replaces the database coupons; on error the previous set is kept.
+// Callers must hold mutex.
+func (s *couponService) loadStoredCoupons(ctx context.Context) {
+	if s.store == nil {
+		return
 	}
 
-	// Special case validation for known valid coupons from requirements
-	// These work immediately without waiting for file processing
-	upperCode := strings.ToUpper(code)
-	if upperCode == "HAPPYHRS" || upperCode == "FIFTYOFF" {
-		return true
+	coupons, err := s.store.FindAll(ctx)
+	if err != nil {
+		s.logger.Warn("Failed to load stored coupons", zap.Error(err))
+		return
 	}
 
-	// For other coupons, check if they've been loaded from files
-	_, exists := s.validCoupons[code]
-	return exists
+	stored := make(map[string]CouponTerms, len(coupons))
+	for _, coupon := range coupons {
+		stored[strings.ToUpper(coupon.Code)] = CouponTerms{
+			Discount:  coupon.DiscountPercentage,
+			Segment:   strings.ToLower(coupon.Segment),
+			SingleUse: coupon.SingleUse,
+			StoreID:   coupon.StoreID,
+		}
+	}
+	s.storedCoupons = stored
+	s.publishLookup()
+}
+
+// DeleteCoupon soft-deletes a stored coupon; it stops validating straight away
+func (s *couponService) DeleteCoupon(ctx context.Context, code string) error {
+	if s.store == nil {
+		return ErrNoCouponStore
+	}
+	if err := s.store.Delete(ctx, strings.ToUpper(code)); err != nil {
+		return err
+	}
+	s.reloadStoredCoupons(ctx)
+	return nil
+}
+
+// RestoreCoupon brings back a deleted stored coupon
+func (s *couponService) RestoreCoupon(ctx context.Context, code string) error {
+	if s.store == nil {
+		return ErrNoCouponStore
+	}
+	if err := s.store.Restore(ctx, strings.ToUpper(code)); err != nil {
+		return err
+	}
+	s.reloadStoredCoupons(ctx)
+	return nil
+}
+
+func (s *couponService) GetDeletedCoupons(ctx context.Context, since time.Time) ([]models.Coupon, error) {
+	if s.store == nil {
+		return nil, ErrNoCouponStore
+	}
+	return s.store.FindDeleted(ctx, since)
+}
+
+func (s *couponService) reloadStoredCoupons(ctx context.Context) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.loadStoredCoupons(ctx)
+
+	s.statsMutex.Lock()
+	s.stats.StoredCoupons = len(s.storedCoupons)
+	s.statsMutex.Unlock()
+}
+
+func (s *couponService) GetStats() models.CouponStats {
+	s.statsMutex.RLock()
+	defer s.statsMutex.RUnlock()
+
+	stats := s.stats
+	stats.Files = append([]models.CouponFileStats(nil), s.stats.Files...)
+	return stats
+}
+
+// ResolveCoupon returns the terms of a valid coupon, false when the code isn't valid. Named
+// discount codes (e.g. HAPPYHRS, FIFTYOFF) work immediately, without waiting for file
+// processing, and take precedence over stored codes, which take precedence over the files.
+// Codes must be 8-10 characters long by default.
+func (s *couponService) ResolveCoupon(code string) (CouponTerms, bool) {
+	return s.lookup.Load().resolve(code)
+}
+
+func (s *couponService) ValidateCoupon(code string) bool {
+	_, ok := s.ResolveCoupon(code)
+	return ok
 }
 
 func (s *couponService) GetDiscountPercentage(code string) float64 {
-	if !s.ValidateCoupon(code) {
-		return 0.0
+	terms, _ := s.ResolveCoupon(code)
+	return terms.Discount
+}
+
+// RequiredSegment returns the customer segment a coupon is restricted to, or "" when
+// anyone may use it
+func (s *couponService) RequiredSegment(code string) string {
+	terms, _ := s.ResolveCoupon(code)
+	return terms.Segment
+}
+
+// SingleUse reports whether each customer may use a coupon only once
+func (s *couponService) SingleUse(code string) bool {
+	terms, _ := s.ResolveCoupon(code)
+	return terms.SingleUse
+}
+
+// SetDiscounts swaps the named discount table, e.g. after a config reload.
+// It waits for any in-flight coupon refresh to release the lock.
+func (s *couponService) SetDiscounts(discounts map[string]float64, defaultDiscount float64) {
+	normalized := normalizeDiscounts(discounts)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.discounts = normalized
+	if defaultDiscount > 0 {
+		s.defaultDiscount = defaultDiscount
 	}
+	s.publishLookup()
+}
 
-	// Known discount codes from requirements
-	switch strings.ToUpper(code) {
-	case "HAPPYHRS":
-		return 10.0 // 10% discount
-	case "FIFTYOFF":
-		return 50.0 // 50% discount
-	default:
-		return 5.0 // Default 5% discount for other valid codes
+func normalizeDiscounts(discounts map[string]float64) map[string]float64 {
+	normalized := make(map[string]float64, len(discounts))
+	for code, discount := range discounts {
+		normalized[strings.ToUpper(code)] = discount
 	}
+	return normalized
 }
 
-func (s *couponService) StartPeriodicRefresh(ctx context.Context, interval time.Duration) {
-	ticker := time.NewTicker(interval)
-	defer ticker.Stop()
+func normalizeSegments(segments map[string]string) map[string]string {
+	normalized := make(map[string]string, len(segments))
+	for code, segment := range segments {
+		normalized[strings.ToUpper(code)] = strings.ToLower(segment)
+	}
+	return normalized
+}
 
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case <-ticker.C:
-			if err := s.DownloadAndParseCouponFiles(ctx); err != nil {
-				// Log error but continue running
-				fmt.Printf("Failed to refresh coupon data: %v\n", err)
-			}
+func normalizeCodeSet(codes []string) map[string]bool {
+	normalized := make(map[string]bool, len(codes))
+	for _, code := range codes {
+		normalized[strings.ToUpper(code)] = true
+	}
+	return normalized
+}
+
+func (s *couponService) downloadAndParseFile(ctx context.Context, filename string, stats *models.CouponFileStats, codes *couponSetBuilder) error {
+	body, err := s.download(ctx, filename)
+	if err != nil {
+		cached, cacheErr := s.openCachedFile(ctx, filename)
+		if cacheErr != nil {
+			return err
 		}
+		defer cached.Close()
+
+		s.logger.Warn("Using cached coupon file", zap.String("file", filename), zap.Error(err))
+		stats.FromCache = true
+		return s.parseGzip(cached, filename, stats, codes)
+	}
+	defer body.Close()
+
+	if s.cache == nil {
+		return s.parseGzip(body, filename, stats, codes)
 	}
+
+	// Keep a copy while parsing and cache it only once the whole file parsed
+	tmp, err := os.CreateTemp("", "coupons-*.gz")
+	if err != nil {
+		return fmt.Errorf("failed to create cache buffer: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if err := s.parseGzip(io.TeeReader(body, tmp), filename, stats, codes); err != nil {
+		return err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err == nil {
+		err = s.cache.Put(ctx, couponCacheKey(filename), tmp, "application/gzip")
+	}
+	if err != nil {
+		s.logger.Warn("Failed to cache coupon file", zap.String("file", filename), zap.Error(err))
+	}
+	return nil
 }
 
-func (s *couponService) downloadAndParseFile(ctx context.Context, filename string) error {
-	// Download file
+// download requests the file and returns its body, limited to maxDownloadMB. The body
+// resumes where it stopped if the connection breaks.
+func (s *couponService) download(ctx context.Context, filename string) (io.ReadCloser, error) {
 	url := s.baseURL + "/" + filename
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	client := &http.Client{Timeout: 300 * time.Second} // 5 minutes timeout for large files
-	resp, err := client.Do(req)
+	resp, err := s.client.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to download file: %w", err)
+		return nil, fmt.Errorf("failed to download file: %w", err)
 	}
-	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to download file, status: %d", resp.StatusCode)
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to download file, status: %d", resp.StatusCode)
+	}
+
+	body := newResumableBody(ctx, s.client, url, resp, s.downloadRetries, s.logger)
+	if s.maxDownloadMB <= 0 {
+		return body, nil
 	}
 
 	// Check Content-Length if available
-	if s.maxDownloadMB > 0 && resp.ContentLength > 0 {
-		maxBytes := s.maxDownloadMB * 1024 * 1024
-		if resp.ContentLength > maxBytes {
-			return fmt.Errorf("file too large: %d bytes exceeds limit of %d MB",
-				resp.ContentLength, s.maxDownloadMB)
-		}
+	maxBytes := s.maxDownloadMB * 1024 * 1024
+	if resp.ContentLength > maxBytes {
+		resp.Body.Close()
+		return nil, fmt.Errorf("file too large: %d bytes exceeds limit of %d MB",
+			resp.ContentLength, s.maxDownloadMB)
 	}
 
 	// Wrap response body with size-limited reader
-	var bodyReader io.Reader = resp.Body
-	if s.maxDownloadMB > 0 {
-		maxBytes := s.maxDownloadMB * 1024 * 1024
-		bodyReader = io.LimitReader(resp.Body, maxBytes)
+	return struct {
+		io.Reader
+		io.Closer
+	}{io.LimitReader(body, maxBytes), body}, nil
+}
+
+func (s *couponService) openCachedFile(ctx context.Context, filename string) (io.ReadCloser, error) {
+	if s.cache == nil {
+		return nil, storage.ErrNotFound
 	}
+	return s.cache.Get(ctx, couponCacheKey(filename))
+}
+
+func couponCacheKey(filename string) string {
+	return "coupons/" + filename
+}
 
-	// Decompress gzip file
-	gzReader, err := gzip.NewReader(bodyReader)
+// parseGzip decompresses and parses a coupon file
+func (s *couponService) parseGzip(reader io.Reader, filename string, stats *models.CouponFileStats, codes *couponSetBuilder) error {
+	// gzip reads a byte at a time from readers it can, so give it a large buffer to read from
+	gzReader, err := gzip.NewReader(bufio.NewReaderSize(reader, s.parseBufferSize))
 	if err != nil {
 		return fmt.Errorf("failed to create gzip reader: %w", err)
 	}
 	defer gzReader.Close()
 
-	// Stream parse CSV directly without temp file
-	return s.parseCSVStream(gzReader, filename)
+	// Stream parse directly without temp file
+	if s.csvParser {
+		return s.parseCSVStream(gzReader, filename, stats, codes)
+	}
+	return s.parseLines(gzReader, filename, stats, codes)
+}
+
+// parseLines is the fast path of parseCSVStream for files of one code per line, reading the
+// same first field of each line without the CSV parser's work per record. A field quoted as
+// a whole is unquoted; any other quote is a parse error, including quoted fields the CSV
+// parser would read across lines. Lines longer than the buffer are read as far as it goes.
+func (s *couponService) parseLines(reader io.Reader, filename string, stats *models.CouponFileStats, codes *couponSetBuilder) error {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, s.parseBufferSize), s.parseBufferSize)
+	scanner.Split(couponLines(s.parseBufferSize))
+
+	rowCount := 0
+	const batchSize = 10000 // Process in batches for progress tracking
+
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue // The CSV parser skips empty lines too
+		}
+
+		code, ok := firstCouponField(line)
+		if !ok {
+			s.logger.Debug("Coupon line parse error", zap.String("file", filename), zap.Int("row", rowCount))
+			stats.ParseErrors++
+			continue
+		}
+		if len(code) >= s.minLength && len(code) <= s.maxLength {
+			codes.AddBytes(code)
+			stats.CodesAccepted++
+		}
+
+		rowCount++
+		stats.RowsProcessed = rowCount
+		if rowCount%batchSize == 0 {
+			s.logger.Debug("Coupon file progress", zap.String("file", filename), zap.Int("rows", rowCount))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		// The download broke; reading on would fail the same way forever
+		return fmt.Errorf("failed to read coupon file after %d rows: %w", rowCount, err)
+	}
+
+	s.logger.Info("Completed parsing coupon file", zap.String("file", filename), zap.Int("rows", rowCount))
+	return nil
 }
 
-// parseCSVStream processes CSV data in a streaming fashion to handle large files
-func (s *couponService) parseCSVStream(reader io.Reader, filename string) error {
+// couponLines splits lines like bufio.ScanLines, except that a line longer than limit is
+// returned cut at limit instead of failing the scan
+func couponLines(limit int) bufio.SplitFunc {
+	skipping := false
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		// Past the end of the line being skipped, look for a line in the same call: the
+		// scanner stops at EOF when a call returns no line
+		skipped := 0
+		if skipping {
+			end := bytes.IndexByte(data, '\n')
+			if end < 0 {
+				return len(data), nil, nil
+			}
+			skipping = false
+			skipped = end + 1
+			data = data[skipped:]
+		}
+
+		if end := bytes.IndexByte(data, '\n'); end >= 0 {
+			return skipped + end + 1, bytes.TrimSuffix(data[:end], []byte{'\r'}), nil
+		}
+		if len(data) >= limit {
+			skipping = true
+			return skipped + len(data), data, nil
+		}
+		if atEOF && len(data) > 0 {
+			return skipped + len(data), bytes.TrimSuffix(data, []byte{'\r'}), nil
+		}
+		return skipped, nil, nil
+	}
+}
+
+// firstCouponField returns the first field of a CSV line, trimmed like parseCSVStream trims
+// it, or false when the line has a quote the CSV parser would read differently
+func firstCouponField(line []byte) ([]byte, bool) {
+	line = bytes.TrimLeftFunc(line, unicode.IsSpace)
+	if quoted, ok := bytes.CutPrefix(line, []byte{'"'}); ok {
+		end := bytes.IndexByte(quoted, '"')
+		if end < 0 {
+			return nil, false
+		}
+		if rest := quoted[end+1:]; len(rest) > 0 && rest[0] != ',' {
+			return nil, false
+		}
+		return bytes.TrimSpace(quoted[:end]), true
+	}
+
+	if comma := bytes.IndexByte(line, ','); comma >= 0 {
+		line = line[:comma]
+	}
+	if bytes.IndexByte(line, '"') >= 0 {
+		return nil, false
+	}
+	return bytes.TrimSpace(line), true
+}
+
+// parseCSVStream processes CSV data in a streaming fashion to handle large files, adding
+// the codes to codes
+func (s *couponService) parseCSVStream(reader io.Reader, filename string, stats *models.CouponFileStats, codes *couponSetBuilder) error {
 	csvReader := csv.NewReader(reader)
 
 	// Configure CSV reader for better error handling
@@ -194,28 +678,36 @@ func (s *couponService) parseCSVStream(reader io.Reader, filename string) error
 		if err == io.EOF {
 			break
 		}
+		var parseErr *csv.ParseError
+		if err != nil && !errors.As(err, &parseErr) {
+			// The download broke; reading on would fail the same way forever
+			return fmt.Errorf("failed to read coupon file after %d rows: %w", rowCount, err)
+		}
 		if err != nil {
 			// Log parse error but continue (be resilient to malformed data)
-			fmt.Printf("Warning: CSV parse error in %s at row %d: %v\n", filename, rowCount, err)
+			s.logger.Debug("CSV parse error", zap.String("file", filename), zap.Int("row", rowCount), zap.Error(err))
+			stats.ParseErrors++
 			continue
 		}
 
 		// Process coupon code
 		if len(record) > 0 {
 			code := strings.TrimSpace(record[0])
-			if code != "" && len(code) >= 8 && len(code) <= 10 {
-				s.validCoupons[code]++
+			if code != "" && len(code) >= s.minLength && len(code) <= s.maxLength {
+				codes.Add(code)
+				stats.CodesAccepted++
 			}
 		}
 
 		rowCount++
+		stats.RowsProcessed = rowCount
 
 		// Optional: Log progress for very large files
 		if rowCount%batchSize == 0 {
-			fmt.Printf("Processed %d rows from %s\n", rowCount, filename)
+			s.logger.Debug("Coupon file progress", zap.String("file", filename), zap.Int("rows", rowCount))
 		}
 	}
 
-	fmt.Printf("Completed parsing %s: %d rows processed\n", filename, rowCount)
+	s.logger.Info("Completed parsing coupon file", zap.String("file", filename), zap.Int("rows", rowCount))
 	return nil
 }
@@ -0,0 +1,163 @@
+package services
+
+import (
+	"bytes"
+	"sort"
+)
+
+// couponShards spreads codes over this many sorted runs, so a lookup binary searches a run
+// that is small and contiguous in memory
+const couponShards = 256
+
+// couponSet is an immutable set of coupon file codes. Codes are short ASCII strings, so
+// each is stored as a fixed-width record, zero padded, in the sorted run of its shard:
+// width bytes per code instead of a map entry, string header and separate allocation.
+type couponSet struct {
+	width  int
+	count  int
+	shards [couponShards][]byte
+}
+
+func (s *couponSet) Len() int {
+	return s.count
+}
+
+// Contains reports whether code is in the set; codes are case sensitive
+func (s *couponSet) Contains(code string) bool {
+	if len(code) == 0 || len(code) > s.width {
+		return false
+	}
+	key := make([]byte, s.width)
+	copy(key, code)
+
+	run := s.shards[couponShard(code)]
+	n := len(run) / s.width
+	i := sort.Search(n, func(i int) bool {
+		return bytes.Compare(run[i*s.width:(i+1)*s.width], key) >= 0
+	})
+	return i < n && bytes.Equal(run[i*s.width:(i+1)*s.width], key)
+}
+
+// couponShard hashes code with 32-bit FNV-1a, inlined so lookups don't allocate
+func couponShard[T string | []byte](code T) int {
+	hash := uint32(2166136261)
+	for i := 0; i < len(code); i++ {
+		hash ^= uint32(code[i])
+		hash *= 16777619
+	}
+	return int(hash % couponShards)
+}
+
+// couponSetBuilder collects the codes of one coupon file. Codes longer than width must be
+// filtered out by the caller.
+type couponSetBuilder struct {
+	width  int
+	shards [couponShards][]byte
+}
+
+func newCouponSetBuilder(width int) *couponSetBuilder {
+	return &couponSetBuilder{width: width}
+}
+
+func (b *couponSetBuilder) Add(code string) {
+	shard := couponShard(code)
+	record := len(b.shards[shard])
+	b.shards[shard] = append(b.shards[shard], make([]byte, b.width)...)
+	copy(b.shards[shard][record:], code)
+}
+
+// AddBytes is Add for a code the caller keeps reusing the memory of
+func (b *couponSetBuilder) AddBytes(code []byte) {
+	shard := couponShard(code)
+	record := len(b.shards[shard])
+	b.shards[shard] = append(b.shards[shard], make([]byte, b.width)...)
+	copy(b.shards[shard][record:], code)
+}
+
+// Build sorts and de-duplicates the codes and returns them as a set, with how many codes
+// were added again after their first time. The builder must not be used afterwards.
+func (b *couponSetBuilder) Build() (*couponSet, int) {
+	set := &couponSet{width: b.width}
+	duplicates := 0
+	for shard, run := range b.shards {
+		sort.Sort(&couponRecords{data: run, width: b.width, tmp: make([]byte, b.width)})
+
+		// Compact in place, keeping the first of each run of equal records
+		unique := 0
+		for i := 0; i < len(run); i += b.width {
+			if unique > 0 && bytes.Equal(run[i:i+b.width], run[unique-b.width:unique]) {
+				duplicates++
+				continue
+			}
+			copy(run[unique:unique+b.width], run[i:i+b.width])
+			unique += b.width
+		}
+		set.shards[shard] = bytes.Clone(run[:unique])
+		set.count += unique / b.width
+		b.shards[shard] = nil
+	}
+	return set, duplicates
+}
+
+// couponRecords sorts fixed-width records in place
+type couponRecords struct {
+	data  []byte
+	width int
+	tmp   []byte
+}
+
+func (r *couponRecords) Len() int {
+	return len(r.data) / r.width
+}
+
+func (r *couponRecords) Less(i, j int) bool {
+	return bytes.Compare(r.data[i*r.width:(i+1)*r.width], r.data[j*r.width:(j+1)*r.width]) < 0
+}
+
+func (r *couponRecords) Swap(i, j int) {
+	a, b := r.data[i*r.width:(i+1)*r.width], r.data[j*r.width:(j+1)*r.width]
+	copy(r.tmp, a)
+	copy(a, b)
+	copy(b, r.tmp)
+}
+
+// mergeCouponSets returns the codes found in at least minOccurrences of the sets, which
+// share a width
+func mergeCouponSets(sets []*couponSet, width, minOccurrences int) *couponSet {
+	merged := &couponSet{width: width}
+	positions := make([]int, len(sets))
+
+	for shard := range couponShards {
+		clear(positions)
+		var run []byte
+		for {
+			// The smallest record at the head of any set is the next code
+			var next []byte
+			for i, set := range sets {
+				if positions[i] < len(set.shards[shard]) {
+					head := set.shards[shard][positions[i] : positions[i]+width]
+					if next == nil || bytes.Compare(head, next) < 0 {
+						next = head
+					}
+				}
+			}
+			if next == nil {
+				break
+			}
+
+			occurrences := 0
+			for i, set := range sets {
+				if positions[i] < len(set.shards[shard]) && bytes.Equal(set.shards[shard][positions[i]:positions[i]+width], next) {
+					occurrences++
+					positions[i] += width
+				}
+			}
+			if occurrences >= minOccurrences {
+				run = append(run, next...)
+			}
+		}
+		merged.shards[shard] = run
+		merged.count += len(run) / width
+	}
+	return merged
+}
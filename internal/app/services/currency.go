@@ -0,0 +1,181 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"oolio/internal/app/models"
+)
+
+var ErrUnsupportedCurrency = errors.New("currency is not supported for settlement")
+
+// maxRatesBytes caps the rates document read from a provider
+const maxRatesBytes = 1 << 20
+
+// ExchangeRateProvider quotes how many units of one currency a unit of another buys.
+// Currencies are ISO codes in lower case.
+type ExchangeRateProvider interface {
+	Rate(ctx context.Context, from, to string) (float64, error)
+}
+
+// CurrencyConverter converts order totals from PAYMENT_CURRENCY, which orders are priced
+// and paid in, to the currency a partner settles in
+type CurrencyConverter interface {
+	// Supports reports whether totals can be settled in currency, the payment currency
+	// included
+	Supports(currency string) bool
+	// Settle converts amount at the current rate. Amounts in the payment currency need no
+	// conversion and get no settlement. It fails with ErrUnsupportedCurrency.
+	Settle(ctx context.Context, amount models.Money, currency string) (*models.Settlement, error)
+}
+
+type currencyConverter struct {
+	base      string
+	supported []string
+	rates     ExchangeRateProvider
+}
+
+// NewCurrencyConverter converts from base to the supported currencies at the provider's rates
+func NewCurrencyConverter(base string, supported []string, rates ExchangeRateProvider) CurrencyConverter {
+	return &currencyConverter{base: base, supported: supported, rates: rates}
+}
+
+func (c *currencyConverter) Supports(currency string) bool {
+	return currency == c.base || slices.Contains(c.supported, currency)
+}
+
+func (c *currencyConverter) Settle(ctx context.Context, amount models.Money, currency string) (*models.Settlement, error) {
+	if currency == c.base {
+		return nil, nil
+	}
+	if !c.Supports(currency) {
+		return nil, ErrUnsupportedCurrency
+	}
+
+	rate, err := c.rates.Rate(ctx, c.base, currency)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get exchange rate: %w", err)
+	}
+	return &models.Settlement{
+		Currency:  currency,
+		Rate:      rate,
+		AmountDue: amount.Convert(rate),
+	}, nil
+}
+
+// fixedExchangeRates quotes configured rates from a single base currency
+type fixedExchangeRates struct {
+	base  string
+	rates map[string]float64
+}
+
+// NewFixedExchangeRates quotes rates, per unit of base, that are set by hand
+func NewFixedExchangeRates(base string, rates map[string]float64) ExchangeRateProvider {
+	return &fixedExchangeRates{base: base, rates: rates}
+}
+
+func (p *fixedExchangeRates) Rate(ctx context.Context, from, to string) (float64, error) {
+	rate, ok := p.rates[to]
+	if from != p.base || !ok {
+		return 0, fmt.Errorf("no exchange rate from %s to %s", from, to)
+	}
+	return rate, nil
+}
+
+type HTTPExchangeRatesOptions struct {
+	URL     string // e.g. https://api.frankfurter.app/latest
+	Timeout time.Duration
+}
+
+// httpExchangeRates asks an API that answers GET {URL}?from=AUD&to=USD with
+// {"rates": {"USD": 0.6512}}, as Frankfurter and compatible services do
+type httpExchangeRates struct {
+	client *http.Client
+	url    string
+}
+
+func NewHTTPExchangeRates(opts HTTPExchangeRatesOptions) ExchangeRateProvider {
+	return &httpExchangeRates{
+		client: &http.Client{Timeout: opts.Timeout},
+		url:    opts.URL,
+	}
+}
+
+func (p *httpExchangeRates) Rate(ctx context.Context, from, to string) (float64, error) {
+	query := url.Values{"from": {strings.ToUpper(from)}, "to": {strings.ToUpper(to)}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.url+"?"+query.Encode(), nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to build exchange rate request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch exchange rate: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("failed to fetch exchange rate: %s", resp.Status)
+	}
+
+	var body struct {
+		Rates map[string]float64 `json:"rates"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxRatesBytes)).Decode(&body); err != nil {
+		return 0, fmt.Errorf("failed to decode exchange rate: %w", err)
+	}
+	rate, ok := body.Rates[strings.ToUpper(to)]
+	if !ok || rate <= 0 {
+		return 0, fmt.Errorf("no exchange rate from %s to %s", from, to)
+	}
+	return rate, nil
+}
+
+type cachedRate struct {
+	rate      float64
+	fetchedAt time.Time
+}
+
+// cachedExchangeRates reuses the rates of another provider for a while, so orders don't
+// each wait on it
+type cachedExchangeRates struct {
+	provider ExchangeRateProvider
+	ttl      time.Duration
+	mutex    sync.Mutex
+	rates    map[string]cachedRate
+}
+
+// NewCachedExchangeRates reuses each rate of provider for ttl after it was fetched
+func NewCachedExchangeRates(provider ExchangeRateProvider, ttl time.Duration) ExchangeRateProvider {
+	return &cachedExchangeRates{provider: provider, ttl: ttl, rates: make(map[string]cachedRate)}
+}
+
+func (p *cachedExchangeRates) Rate(ctx context.Context, from, to string) (float64, error) {
+	key := from + ":" + to
+	p.mutex.Lock()
+	cached, ok := p.rates[key]
+	p.mutex.Unlock()
+	if ok && time.Since(cached.fetchedAt) < p.ttl {
+		return cached.rate, nil
+	}
+
+	rate, err := p.provider.Rate(ctx, from, to)
+	if err != nil {
+		return 0, err
+	}
+
+	p.mutex.Lock()
+	p.rates[key] = cachedRate{rate: rate, fetchedAt: time.Now()}
+	p.mutex.Unlock()
+	return rate, nil
+}
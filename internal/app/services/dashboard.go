@@ -0,0 +1,88 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"oolio/internal/app/models"
+	"oolio/internal/app/repository"
+)
+
+// dashboardTopProducts is how many of today's best selling products the dashboard lists
+const dashboardTopProducts = 5
+
+// DashboardService sums up what an operations dashboard shows in one call
+type DashboardService interface {
+	// Summary returns today's sales so far, in the sales reports' time zone, the order
+	// queue's health, today's best selling products and the coupons used today
+	Summary(ctx context.Context) (*models.Dashboard, error)
+}
+
+type dashboardService struct {
+	orders   repository.OrderRepository
+	queue    repository.OrderQueueRepository
+	location *time.Location
+}
+
+// NewDashboardService returns the dashboard service; days start at midnight in location,
+// UTC when nil
+func NewDashboardService(orders repository.OrderRepository, queue repository.OrderQueueRepository, location *time.Location) DashboardService {
+	if location == nil {
+		location = time.UTC
+	}
+	return &dashboardService{orders: orders, queue: queue, location: location}
+}
+
+func (s *dashboardService) Summary(ctx context.Context) (*models.Dashboard, error) {
+	now := time.Now().In(s.location)
+	since := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, s.location)
+	until := since.AddDate(0, 0, 1)
+
+	days, err := s.orders.Sales(ctx, models.SalesQuery{GroupBy: models.SalesByDay, Since: since, Until: until, Location: s.location})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build dashboard: %w", err)
+	}
+	// Order timestamps are UTC
+	products, err := s.orders.TopProducts(ctx, since.UTC(), until.UTC(), dashboardTopProducts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build dashboard: %w", err)
+	}
+	coupons, err := s.orders.CouponUsage(ctx, since.UTC(), until.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to build dashboard: %w", err)
+	}
+	stats, err := s.queue.GetQueueStats(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build dashboard: %w", err)
+	}
+	backlog, err := s.queue.GetBacklog(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build dashboard: %w", err)
+	}
+
+	dashboard := &models.Dashboard{
+		Date:     since.Format(time.DateOnly),
+		TimeZone: s.location.String(),
+		Queue: models.QueueHealth{
+			Pending:     stats["pending"],
+			Processing:  stats["processing"],
+			Completed:   stats["completed"],
+			Failed:      stats["failed"],
+			OldestDueAt: backlog.OldestDueAt,
+		},
+		TopProducts: products,
+		Coupons:     coupons,
+		GeneratedAt: now,
+	}
+	for _, day := range days {
+		dashboard.Today.Orders += day.Orders
+		dashboard.Today.Revenue += day.Revenue
+		dashboard.Today.Discounts += day.Discounts
+	}
+	dashboard.Today.AverageOrderValue = averageOrderValue(dashboard.Today)
+	if backlog.OldestDueAt != nil {
+		dashboard.Queue.OldestWaitSeconds = int(max(now.Sub(*backlog.OldestDueAt), 0).Seconds())
+	}
+	return dashboard, nil
+}
@@ -0,0 +1,429 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"oolio/internal/app/events"
+	"oolio/internal/app/models"
+	"oolio/internal/app/repository"
+)
+
+var (
+	ErrDeliveryNotFound  = errors.New("the order has no delivery")
+	ErrDeliveryFinished  = errors.New("the delivery has already ended")
+	ErrDeliverySignature = errors.New("delivery status signature is missing, invalid or too old")
+	ErrDeliveryPayload   = errors.New("delivery status payload is not a status update")
+)
+
+// DeliveryProvider books couriers for orders placed with a delivery address. The worker
+// creates a job once the order is stored; an error is recorded on the delivery but doesn't
+// undo the order. Providers are selected by DELIVERY_PROVIDER; an integration such as Uber
+// Direct or DoorDash Drive only needs an implementation.
+type DeliveryProvider interface {
+	// Name is stored with each delivery the provider books
+	Name() string
+	// CreateJob books a courier to take the order to address and returns the job's state
+	CreateJob(ctx context.Context, order *models.Order, address models.DeliveryAddress) (*models.DeliveryStatusUpdate, error)
+	// Track returns the current state of the delivery's job, or nil when the provider
+	// posts its status updates instead
+	Track(ctx context.Context, delivery *models.Delivery) (*models.DeliveryStatusUpdate, error)
+	// Cancel calls the delivery's courier off
+	Cancel(ctx context.Context, delivery *models.Delivery) error
+}
+
+// deliveryStatusRank orders the statuses, so an update that arrives late doesn't move a
+// delivery back
+var deliveryStatusRank = map[string]int{
+	models.DeliveryStatusRequested: 1,
+	models.DeliveryStatusAssigned:  2,
+	models.DeliveryStatusPickedUp:  3,
+	models.DeliveryStatusDelivered: 4,
+	models.DeliveryStatusCancelled: 4,
+	models.DeliveryStatusFailed:    4,
+}
+
+// mockDeliveryProvider is an in-process DeliveryProvider for local development and demos.
+// Its couriers are assigned, pick the order up and deliver it one step apart.
+type mockDeliveryProvider struct {
+	step  time.Duration
+	mutex sync.Mutex
+	jobs  map[string]*mockDeliveryJob
+}
+
+type mockDeliveryJob struct {
+	createdAt time.Time
+	cancelled bool
+}
+
+// NewMockDeliveryProvider returns the mock provider; step defaults to a minute
+func NewMockDeliveryProvider(step time.Duration) DeliveryProvider {
+	if step <= 0 {
+		step = time.Minute
+	}
+	return &mockDeliveryProvider{step: step, jobs: make(map[string]*mockDeliveryJob)}
+}
+
+func (p *mockDeliveryProvider) Name() string {
+	return "mock"
+}
+
+func (p *mockDeliveryProvider) CreateJob(ctx context.Context, order *models.Order, address models.DeliveryAddress) (*models.DeliveryStatusUpdate, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	id := "mock-" + generateUUID()
+	job := &mockDeliveryJob{createdAt: time.Now()}
+	p.jobs[id] = job
+	return p.state(id, job, job.createdAt), nil
+}
+
+func (p *mockDeliveryProvider) Track(ctx context.Context, delivery *models.Delivery) (*models.DeliveryStatusUpdate, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	job, ok := p.jobs[delivery.ExternalID]
+	if !ok {
+		return nil, fmt.Errorf("mock delivery job %s not found", delivery.ExternalID)
+	}
+	return p.state(delivery.ExternalID, job, time.Now()), nil
+}
+
+func (p *mockDeliveryProvider) Cancel(ctx context.Context, delivery *models.Delivery) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	job, ok := p.jobs[delivery.ExternalID]
+	if !ok {
+		return fmt.Errorf("mock delivery job %s not found", delivery.ExternalID)
+	}
+	if p.state(delivery.ExternalID, job, time.Now()).Status == models.DeliveryStatusDelivered {
+		return fmt.Errorf("mock delivery job %s was already delivered", delivery.ExternalID)
+	}
+	job.cancelled = true
+	return nil
+}
+
+// state is the job's state at now: requested, then one status further every step
+func (p *mockDeliveryProvider) state(id string, job *mockDeliveryJob, now time.Time) *models.DeliveryStatusUpdate {
+	estimatedAt := job.createdAt.Add(3 * p.step)
+	update := &models.DeliveryStatusUpdate{
+		ExternalID:  id,
+		Status:      models.DeliveryStatusRequested,
+		TrackingURL: "https://delivery.example.com/track/" + id,
+		EstimatedAt: &estimatedAt,
+	}
+	if job.cancelled {
+		update.Status = models.DeliveryStatusCancelled
+		update.EstimatedAt = nil
+		return update
+	}
+
+	steps := int(now.Sub(job.createdAt) / p.step)
+	if steps >= 1 {
+		update.Status = models.DeliveryStatusAssigned
+		update.CourierName = "Mock Courier"
+	}
+	if steps >= 2 {
+		update.Status = models.DeliveryStatusPickedUp
+	}
+	if steps >= 3 {
+		update.Status = models.DeliveryStatusDelivered
+	}
+	return update
+}
+
+// webhookDeliveryProvider queues delivery jobs as CloudEvents for the delivery webhook
+// endpoint, whose receiver books the courier and posts the job's status back to
+// POST /deliveries/webhook. Event IDs are derived from the order ID, so a job is
+// requested and cancelled once.
+type webhookDeliveryProvider struct {
+	webhooks WebhookService
+	source   string
+}
+
+// NewWebhookDeliveryProvider hands delivery jobs to the webhook worker, which retries them
+// until the endpoint accepts them; source is the CloudEvents source, see events.New
+func NewWebhookDeliveryProvider(webhooks WebhookService, source string) DeliveryProvider {
+	return &webhookDeliveryProvider{webhooks: webhooks, source: source}
+}
+
+func (p *webhookDeliveryProvider) Name() string {
+	return "webhook"
+}
+
+func (p *webhookDeliveryProvider) CreateJob(ctx context.Context, order *models.Order, address models.DeliveryAddress) (*models.DeliveryStatusUpdate, error) {
+	job := models.DeliveryJob{OrderID: order.ID, Address: &address, Order: order}
+	if err := p.enqueue(ctx, models.EventDeliveryRequested, job); err != nil {
+		return nil, err
+	}
+	return &models.DeliveryStatusUpdate{Status: models.DeliveryStatusRequested}, nil
+}
+
+func (p *webhookDeliveryProvider) Track(ctx context.Context, delivery *models.Delivery) (*models.DeliveryStatusUpdate, error) {
+	return nil, nil
+}
+
+func (p *webhookDeliveryProvider) Cancel(ctx context.Context, delivery *models.Delivery) error {
+	job := models.DeliveryJob{OrderID: delivery.OrderID, ExternalID: delivery.ExternalID}
+	return p.enqueue(ctx, models.EventDeliveryCancelled, job)
+}
+
+func (p *webhookDeliveryProvider) enqueue(ctx context.Context, eventType string, job models.DeliveryJob) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to marshal delivery job for order %s: %w", job.OrderID, err)
+	}
+
+	event := events.New(p.source, job.OrderID+":"+eventType, "order", job.OrderID, eventType, data, time.Now())
+	if err := p.webhooks.Enqueue(ctx, models.WebhookEndpointDelivery, event); err != nil {
+		return fmt.Errorf("failed to send %s for order %s: %w", eventType, job.OrderID, err)
+	}
+	return nil
+}
+
+// DeliveryService books couriers for orders through the DeliveryProvider and keeps track
+// of where their deliveries are
+type DeliveryService interface {
+	// Dispatch books a courier for the order, once: an order that already has a delivery
+	// gets it back. A job the provider refused is stored as a failed delivery, and its
+	// error returned.
+	Dispatch(ctx context.Context, order *models.Order, address models.DeliveryAddress) (*models.Delivery, error)
+	// Tracking returns the order's delivery, refreshed from the provider when it hasn't
+	// heard of it for a while, or nil when the order has none
+	Tracking(ctx context.Context, orderID string) (*models.Delivery, error)
+	// Cancel calls the order's courier off. It fails with ErrDeliveryNotFound or
+	// ErrDeliveryFinished.
+	Cancel(ctx context.Context, orderID string) (*models.Delivery, error)
+	// HandleStatus verifies a status update posted by the webhook provider, signed like
+	// outbound webhooks, and applies it. It fails with ErrDeliverySignature,
+	// ErrDeliveryPayload or ErrDeliveryNotFound.
+	HandleStatus(ctx context.Context, payload []byte, deliveryID, timestamp, signature string) (*models.Delivery, error)
+}
+
+type DeliveryOptions struct {
+	// StatusSecret verifies HandleStatus payloads; without it every update is refused
+	StatusSecret string
+	// Tolerance is how old a status update's signature may be; defaults to 5 minutes
+	Tolerance time.Duration
+	// RefreshAfter is how long Tracking trusts a delivery before asking the provider;
+	// defaults to 30 seconds
+	RefreshAfter time.Duration
+}
+
+type deliveryService struct {
+	repo     repository.DeliveryRepository
+	provider DeliveryProvider
+	logger   *zap.Logger
+	opts     DeliveryOptions
+}
+
+func NewDeliveryService(repo repository.DeliveryRepository, provider DeliveryProvider, logger *zap.Logger, opts DeliveryOptions) DeliveryService {
+	if opts.Tolerance <= 0 {
+		opts.Tolerance = 5 * time.Minute
+	}
+	if opts.RefreshAfter <= 0 {
+		opts.RefreshAfter = 30 * time.Second
+	}
+	return &deliveryService{repo: repo, provider: provider, logger: logger, opts: opts}
+}
+
+func (s *deliveryService) Dispatch(ctx context.Context, order *models.Order, address models.DeliveryAddress) (*models.Delivery, error) {
+	existing, err := s.repo.FindByOrder(ctx, order.ID)
+	if err == nil {
+		return existing, nil
+	}
+	if err.Error() != "delivery not found" {
+		return nil, err
+	}
+
+	delivery := &models.Delivery{
+		OrderID:  order.ID,
+		Provider: s.provider.Name(),
+		Status:   models.DeliveryStatusRequested,
+	}
+	update, jobErr := s.provider.CreateJob(ctx, order, address)
+	if jobErr != nil {
+		delivery.Status = models.DeliveryStatusFailed
+		delivery.Error = jobErr.Error()
+	} else {
+		applyDeliveryUpdate(delivery, update)
+	}
+
+	if err := s.repo.Create(ctx, delivery); err != nil {
+		if err.Error() == "delivery already exists" {
+			// Dispatched by another worker in the meantime
+			return s.repo.FindByOrder(ctx, order.ID)
+		}
+		return nil, err
+	}
+	if jobErr != nil {
+		return delivery, fmt.Errorf("failed to book a courier for order %s: %w", order.ID, jobErr)
+	}
+	return delivery, nil
+}
+
+func (s *deliveryService) Tracking(ctx context.Context, orderID string) (*models.Delivery, error) {
+	delivery, err := s.repo.FindByOrder(ctx, orderID)
+	if err != nil {
+		if err.Error() == "delivery not found" {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if delivery.Finished() || time.Since(delivery.UpdatedAt) < s.opts.RefreshAfter {
+		return delivery, nil
+	}
+	if err := s.refresh(ctx, delivery); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// refresh asks the provider where the delivery is and saves what changed. A provider
+// that can't be reached leaves the stored status, which is still worth showing.
+func (s *deliveryService) refresh(ctx context.Context, delivery *models.Delivery) error {
+	update, err := s.provider.Track(ctx, delivery)
+	if err != nil {
+		s.logger.Warn("Delivery status was not refreshed",
+			zap.String("orderId", delivery.OrderID),
+			zap.String("provider", delivery.Provider),
+			zap.Error(err))
+		return nil
+	}
+	if update == nil || !applyDeliveryUpdate(delivery, update) {
+		return nil
+	}
+	return s.repo.Update(ctx, delivery)
+}
+
+func (s *deliveryService) Cancel(ctx context.Context, orderID string) (*models.Delivery, error) {
+	delivery, err := s.repo.FindByOrder(ctx, orderID)
+	if err != nil {
+		if err.Error() == "delivery not found" {
+			return nil, ErrDeliveryNotFound
+		}
+		return nil, err
+	}
+	// The courier may have moved on since the provider was last asked
+	if !delivery.Finished() {
+		if err := s.refresh(ctx, delivery); err != nil {
+			return nil, err
+		}
+	}
+	if delivery.Finished() {
+		return delivery, ErrDeliveryFinished
+	}
+
+	if err := s.provider.Cancel(ctx, delivery); err != nil {
+		return nil, fmt.Errorf("failed to cancel delivery: %w", err)
+	}
+	delivery.Status = models.DeliveryStatusCancelled
+	delivery.EstimatedAt = nil
+	if err := s.repo.Update(ctx, delivery); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+func (s *deliveryService) HandleStatus(ctx context.Context, payload []byte, deliveryID, timestamp, signature string) (*models.Delivery, error) {
+	if err := s.verify(payload, deliveryID, timestamp, signature, time.Now()); err != nil {
+		return nil, err
+	}
+
+	var update models.DeliveryStatusUpdate
+	if err := json.Unmarshal(payload, &update); err != nil || update.OrderID == "" {
+		return nil, ErrDeliveryPayload
+	}
+	if _, ok := deliveryStatusRank[update.Status]; !ok {
+		return nil, ErrDeliveryPayload
+	}
+
+	delivery, err := s.repo.FindByOrder(ctx, update.OrderID)
+	if err != nil {
+		if err.Error() == "delivery not found" {
+			return nil, ErrDeliveryNotFound
+		}
+		return nil, err
+	}
+	if delivery.Finished() || !applyDeliveryUpdate(delivery, &update) {
+		return delivery, nil
+	}
+	if err := s.repo.Update(ctx, delivery); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// verify checks the signature was made with the status secret over the delivery ID,
+// timestamp and payload, the way SignWebhook signs outbound webhooks, within the
+// tolerance of now
+func (s *deliveryService) verify(payload []byte, deliveryID, timestamp, signature string, now time.Time) error {
+	if s.opts.StatusSecret == "" || deliveryID == "" || signature == "" {
+		return ErrDeliverySignature
+	}
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrDeliverySignature
+	}
+	if age := now.Sub(time.Unix(unix, 0)); age > s.opts.Tolerance || age < -s.opts.Tolerance {
+		return ErrDeliverySignature
+	}
+
+	expected := SignWebhook([]byte(s.opts.StatusSecret), deliveryID, unix, payload)
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return ErrDeliverySignature
+	}
+	return nil
+}
+
+// applyDeliveryUpdate copies what the update says onto the delivery and reports whether
+// anything changed. Updates behind the delivery's status are ignored.
+func applyDeliveryUpdate(delivery *models.Delivery, update *models.DeliveryStatusUpdate) bool {
+	if deliveryStatusRank[update.Status] < deliveryStatusRank[delivery.Status] {
+		return false
+	}
+
+	before := *delivery
+	delivery.Status = update.Status
+	if update.ExternalID != "" {
+		delivery.ExternalID = update.ExternalID
+	}
+	if update.TrackingURL != "" {
+		delivery.TrackingURL = update.TrackingURL
+	}
+	if update.CourierName != "" {
+		delivery.CourierName = update.CourierName
+	}
+	if update.EstimatedAt != nil {
+		delivery.EstimatedAt = update.EstimatedAt
+	}
+	if update.Error != "" {
+		delivery.Error = update.Error
+	}
+	if delivery.Finished() && delivery.Status != models.DeliveryStatusDelivered {
+		delivery.EstimatedAt = nil
+	}
+
+	return delivery.Status != before.Status ||
+		delivery.ExternalID != before.ExternalID ||
+		delivery.TrackingURL != before.TrackingURL ||
+		delivery.CourierName != before.CourierName ||
+		!equalTimePtr(delivery.EstimatedAt, before.EstimatedAt) ||
+		delivery.Error != before.Error
+}
+
+func equalTimePtr(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
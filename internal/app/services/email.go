@@ -0,0 +1,384 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"go.uber.org/zap"
+
+	"oolio/internal/app/models"
+)
+
+// Emails rendered from templates/email; each template defines a "subject" and a "body"
+const (
+	EmailOrderConfirmation = "order_confirmation"
+	EmailOrderReady        = "order_ready"
+	EmailSalesDigest       = "sales_digest"
+)
+
+//go:embed templates/email/*.tmpl
+var emailTemplateFS embed.FS
+
+// EmailTemplates renders the emails sent about orders and the sales digest
+type EmailTemplates struct {
+	templates map[string]*template.Template
+}
+
+// LoadEmailTemplates parses the built-in templates, replacing each with the file of the
+// same name in dir, e.g. order_ready.tmpl, when there is one
+func LoadEmailTemplates(dir string) (*EmailTemplates, error) {
+	t := &EmailTemplates{templates: make(map[string]*template.Template)}
+	for _, name := range []string{EmailOrderConfirmation, EmailOrderReady, EmailSalesDigest} {
+		file := name + ".tmpl"
+		text, err := fs.ReadFile(emailTemplateFS, "templates/email/"+file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read email template %s: %w", name, err)
+		}
+		if dir != "" {
+			custom, err := os.ReadFile(filepath.Join(dir, file))
+			switch {
+			case err == nil:
+				text = custom
+			case !os.IsNotExist(err):
+				return nil, fmt.Errorf("failed to read email template %s: %w", name, err)
+			}
+		}
+
+		tmpl, err := template.New(name).Parse(string(text))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse email template %s: %w", name, err)
+		}
+		if tmpl.Lookup("subject") == nil || tmpl.Lookup("body") == nil {
+			return nil, fmt.Errorf("email template %s must define a subject and a body", name)
+		}
+		t.templates[name] = tmpl
+	}
+	return t, nil
+}
+
+// DefaultEmailTemplates are the built-in templates
+func DefaultEmailTemplates() *EmailTemplates {
+	t, err := LoadEmailTemplates("")
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// OrderEmail is what order emails are rendered from
+type OrderEmail struct {
+	Number        string // Short order number customers tell orders apart by
+	Lines         []OrderEmailLine
+	Discounts     models.Money
+	GiftCardCode  string
+	StoreCredit   models.Money
+	Total         models.Money // What is left to pay after discounts and store credit
+	PaymentMethod string
+}
+
+type OrderEmailLine struct {
+	Quantity int
+	Name     string
+	Amount   models.Money
+}
+
+// NewOrderEmail lists the order's items at the prices charged
+func NewOrderEmail(order *models.Order) OrderEmail {
+	names := make(map[string]string, len(order.Products))
+	for _, product := range order.Products {
+		names[product.ID] = product.Name
+	}
+
+	lines := make([]OrderEmailLine, len(order.Items))
+	for i, item := range order.Items {
+		lines[i] = OrderEmailLine{Quantity: item.Quantity, Name: item.Describe(names[item.ProductID]), Amount: item.Price.Mul(item.Quantity)}
+	}
+	return OrderEmail{
+		Number:        shortOrderID(order.ID),
+		Lines:         lines,
+		Discounts:     order.Discounts,
+		GiftCardCode:  order.GiftCardCode,
+		StoreCredit:   order.StoreCredit,
+		Total:         order.AmountDue(),
+		PaymentMethod: order.PaymentMethod,
+	}
+}
+
+// Render executes the named template
+func (t *EmailTemplates) Render(name string, data any) (Notification, error) {
+	tmpl, ok := t.templates[name]
+	if !ok {
+		return Notification{}, fmt.Errorf("unknown email template %s", name)
+	}
+
+	var subject, body bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return Notification{}, fmt.Errorf("failed to render email %s: %w", name, err)
+	}
+	if err := tmpl.ExecuteTemplate(&body, "body", data); err != nil {
+		return Notification{}, fmt.Errorf("failed to render email %s: %w", name, err)
+	}
+	return Notification{Subject: strings.TrimSpace(subject.String()), Text: body.String()}, nil
+}
+
+type SMTPOptions struct {
+	Host     string
+	Port     int // 465 connects over TLS; other ports upgrade with STARTTLS when the server offers it
+	Username string
+	Password string
+	From     string // e.g. "Oolio <orders@oolio.com>"
+	Timeout  time.Duration
+}
+
+// smtpEmailSender hands emails to an SMTP relay, one connection per email
+type smtpEmailSender struct {
+	opts SMTPOptions
+	from *mail.Address
+}
+
+func NewSMTPEmailSender(opts SMTPOptions) (EmailSender, error) {
+	from, err := mail.ParseAddress(opts.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sender %q: %w", opts.From, err)
+	}
+	return &smtpEmailSender{opts: opts, from: from}, nil
+}
+
+func (s *smtpEmailSender) SendEmail(ctx context.Context, to, subject, text string) error {
+	addr := net.JoinHostPort(s.opts.Host, strconv.Itoa(s.opts.Port))
+	dialer := &net.Dialer{Timeout: s.opts.Timeout}
+	var conn net.Conn
+	var err error
+	if s.opts.Port == 465 {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: s.opts.Host}}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to SMTP server: %w", err)
+	}
+	// The whole exchange is bounded by the timeout, or the context's deadline if sooner
+	deadline, ok := ctx.Deadline()
+	if timeout := time.Now().Add(s.opts.Timeout); s.opts.Timeout > 0 && (!ok || timeout.Before(deadline)) {
+		deadline, ok = timeout, true
+	}
+	if ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, s.opts.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to connect to SMTP server: %w", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok && s.opts.Port != 465 {
+		if err := client.StartTLS(&tls.Config{ServerName: s.opts.Host}); err != nil {
+			return fmt.Errorf("failed to start TLS: %w", err)
+		}
+	}
+	if s.opts.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.opts.Username, s.opts.Password, s.opts.Host)); err != nil {
+			return fmt.Errorf("failed to authenticate with SMTP server: %w", err)
+		}
+	}
+	if err := client.Mail(s.from.Address); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	if err := client.Rcpt(to); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	if _, err := w.Write(buildEmail(s.from.String(), to, subject, text)); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return client.Quit()
+}
+
+// buildEmail formats a plain text email. The subject is encoded, so it can't add headers.
+func buildEmail(from, to, subject, text string) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", to)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+	qp := quotedprintable.NewWriter(&b)
+	qp.Write([]byte(text))
+	qp.Close()
+	return b.Bytes()
+}
+
+type SESOptions struct {
+	Region          string
+	Endpoint        string // Defaults to https://email.<region>.amazonaws.com
+	AccessKeyID     string
+	SecretAccessKey string
+	From            string
+	Timeout         time.Duration
+}
+
+// sesEmailSender sends through the Amazon SES v2 API, signing requests with Signature
+// Version 4
+type sesEmailSender struct {
+	client *http.Client
+	opts   SESOptions
+}
+
+func NewSESEmailSender(opts SESOptions) EmailSender {
+	if opts.Endpoint == "" {
+		opts.Endpoint = "https://email." + opts.Region + ".amazonaws.com"
+	}
+	opts.Endpoint = strings.TrimSuffix(opts.Endpoint, "/")
+	return &sesEmailSender{client: &http.Client{Timeout: opts.Timeout}, opts: opts}
+}
+
+func (s *sesEmailSender) SendEmail(ctx context.Context, to, subject, text string) error {
+	type content struct {
+		Data    string
+		Charset string
+	}
+	var message struct {
+		FromEmailAddress string
+		Destination      struct{ ToAddresses []string }
+		Content          struct {
+			Simple struct {
+				Subject content
+				Body    struct{ Text content }
+			}
+		}
+	}
+	message.FromEmailAddress = s.opts.From
+	message.Destination.ToAddresses = []string{to}
+	message.Content.Simple.Subject = content{Data: subject, Charset: "UTF-8"}
+	message.Content.Simple.Body.Text = content{Data: text, Charset: "UTF-8"}
+	body, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to marshal email: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.opts.Endpoint+"/v2/email/outbound-emails", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build email request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	s.sign(req, body, time.Now().UTC())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+		return fmt.Errorf("SES rejected the email: %s %s", resp.Status, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
+
+// sign adds a Signature Version 4 Authorization header over the request and its body
+func (s *sesEmailSender) sign(req *http.Request, body []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256.Sum256(body)
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	signedHeaders := "content-type;host;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.Query().Encode(),
+		"content-type:" + req.Header.Get("Content-Type") + "\n" +
+			"host:" + req.URL.Host + "\n" +
+			"x-amz-date:" + amzDate + "\n",
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+	canonicalHash := sha256.Sum256([]byte(canonicalRequest))
+
+	scope := date + "/" + s.opts.Region + "/ses/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(canonicalHash[:])
+
+	key := sesHMAC([]byte("AWS4"+s.opts.SecretAccessKey), date)
+	key = sesHMAC(key, s.opts.Region)
+	key = sesHMAC(key, "ses")
+	key = sesHMAC(key, "aws4_request")
+	signature := hex.EncodeToString(sesHMAC(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.opts.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func sesHMAC(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// sandboxEmailSender keeps emails from reaching customers outside production: only allowed
+// recipients get theirs, and the rest go to the redirect address, or nowhere without one
+type sandboxEmailSender struct {
+	sender   EmailSender
+	allow    []string
+	redirect string
+	logger   *zap.Logger
+}
+
+// NewSandboxEmailSender wraps sender. allow lists addresses, or domains as "@example.com",
+// that are sent to as usual.
+func NewSandboxEmailSender(sender EmailSender, allow []string, redirect string, logger *zap.Logger) EmailSender {
+	return &sandboxEmailSender{sender: sender, allow: allow, redirect: redirect, logger: logger}
+}
+
+func (s *sandboxEmailSender) SendEmail(ctx context.Context, to, subject, text string) error {
+	if s.allows(to) {
+		return s.sender.SendEmail(ctx, to, subject, text)
+	}
+	if s.redirect == "" {
+		s.logger.Info("Email not sent (sandbox)", zap.String("to", to), zap.String("subject", subject))
+		return nil
+	}
+	return s.sender.SendEmail(ctx, s.redirect, "[Sandbox: "+to+"] "+subject, text)
+}
+
+func (s *sandboxEmailSender) allows(to string) bool {
+	to = strings.ToLower(to)
+	for _, allowed := range s.allow {
+		allowed = strings.ToLower(allowed)
+		if to == allowed || (strings.HasPrefix(allowed, "@") && strings.HasSuffix(to, allowed)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"go.uber.org/zap"
+
+	"oolio/internal/app/events"
+	"oolio/internal/app/models"
+)
+
+// EventPublisher delivers CloudEvents to a message broker. Publish must be safe to call
+// again for an event that was already delivered, since the outbox relay is at-least-once.
+// Brokers are selected by OUTBOX_BROKER; another one such as NATS only needs an implementation.
+type EventPublisher interface {
+	Publish(ctx context.Context, event events.Event) error
+}
+
+// logEventPublisher writes events to the application log, for local development
+type logEventPublisher struct {
+	logger *zap.Logger
+}
+
+func NewLogEventPublisher(logger *zap.Logger) EventPublisher {
+	return &logEventPublisher{logger: logger}
+}
+
+func (p *logEventPublisher) Publish(ctx context.Context, event events.Event) error {
+	p.logger.Info("Domain event",
+		zap.String("id", event.ID),
+		zap.String("type", event.Type),
+		zap.String("source", event.Source),
+		zap.String("subject", event.Subject),
+		zap.Time("time", event.Time),
+		zap.ByteString("data", event.Data),
+	)
+	return nil
+}
+
+// webhookEventPublisher queues events for the events webhook endpoint, e.g. an accounting
+// system's; the webhook worker retries them from there. Events of other types are dropped.
+type webhookEventPublisher struct {
+	webhooks WebhookService
+	types    []string
+}
+
+// NewWebhookEventPublisher delivers the domain event types, e.g. order.refunded, or every
+// event when types is empty
+func NewWebhookEventPublisher(webhooks WebhookService, types []string) EventPublisher {
+	return &webhookEventPublisher{webhooks: webhooks, types: types}
+}
+
+func (p *webhookEventPublisher) Publish(ctx context.Context, event events.Event) error {
+	if len(p.types) > 0 && !slices.Contains(p.types, event.DomainType()) {
+		return nil
+	}
+
+	if err := p.webhooks.Enqueue(ctx, models.WebhookEndpointEvents, event); err != nil {
+		return fmt.Errorf("failed to deliver event %s: %w", event.ID, err)
+	}
+	return nil
+}
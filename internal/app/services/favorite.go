@@ -0,0 +1,85 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"oolio/internal/app/models"
+	"oolio/internal/app/repository"
+)
+
+// MaxCustomerFavorites caps the number of favorites one customer can keep
+const MaxCustomerFavorites = 100
+
+var ErrFavoritesFull = fmt.Errorf("favorites are full: at most %d products can be saved", MaxCustomerFavorites)
+
+type FavoriteService interface {
+	// ListFavorites returns the customer's favorite products, newest first. Products
+	// deleted since are left out.
+	ListFavorites(ctx context.Context, customerID string) ([]models.Product, error)
+	AddFavorite(ctx context.Context, customerID, productID string) error
+	RemoveFavorite(ctx context.Context, customerID, productID string) error
+}
+
+type favoriteService struct {
+	repo     repository.FavoriteRepository
+	products repository.ProductRepository
+}
+
+func NewFavoriteService(repo repository.FavoriteRepository, products repository.ProductRepository) FavoriteService {
+	return &favoriteService{repo: repo, products: products}
+}
+
+func (s *favoriteService) ListFavorites(ctx context.Context, customerID string) ([]models.Product, error) {
+	ids, err := s.repo.FindByCustomer(ctx, customerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list favorites: %w", err)
+	}
+	if len(ids) == 0 {
+		return []models.Product{}, nil
+	}
+
+	products, err := s.products.FindByIDs(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list favorites: %w", err)
+	}
+
+	// FindByIDs orders by name; keep the order the favorites were added in
+	favorites := make([]models.Product, 0, len(products))
+	for _, id := range ids {
+		if i := slices.IndexFunc(products, func(p models.Product) bool { return p.ID == id }); i >= 0 {
+			favorites = append(favorites, products[i])
+		}
+	}
+	return favorites, nil
+}
+
+func (s *favoriteService) AddFavorite(ctx context.Context, customerID, productID string) error {
+	if _, err := s.products.FindOne(ctx, productID); err != nil {
+		return err
+	}
+
+	ids, err := s.repo.FindByCustomer(ctx, customerID)
+	if err != nil {
+		return fmt.Errorf("failed to add favorite: %w", err)
+	}
+	if slices.Contains(ids, productID) {
+		return nil
+	}
+	if len(ids) >= MaxCustomerFavorites {
+		return ErrFavoritesFull
+	}
+
+	if err := s.repo.Add(ctx, customerID, productID); err != nil {
+		return fmt.Errorf("failed to add favorite: %w", err)
+	}
+	return nil
+}
+
+func (s *favoriteService) RemoveFavorite(ctx context.Context, customerID, productID string) error {
+	if err := s.repo.Remove(ctx, customerID, productID); err != nil {
+		return fmt.Errorf("failed to remove favorite: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"oolio/internal/app/events"
+	"oolio/internal/app/models"
+)
+
+// FulfillmentProvider hands created orders to the restaurant, e.g. its POS or kitchen
+// display. The worker calls Fulfill once the order is stored; an error is reported but
+// doesn't undo the order. Providers are selected by FULFILLMENT_PROVIDER; an integration
+// such as Square or Toast only needs an implementation.
+type FulfillmentProvider interface {
+	Fulfill(ctx context.Context, order *models.Order) error
+}
+
+// noopFulfillmentProvider is the default for deployments without an integration
+type noopFulfillmentProvider struct{}
+
+func NewNoopFulfillmentProvider() FulfillmentProvider {
+	return noopFulfillmentProvider{}
+}
+
+func (noopFulfillmentProvider) Fulfill(ctx context.Context, order *models.Order) error {
+	return nil
+}
+
+// webhookFulfillmentProvider queues each order as a CloudEvent for the fulfillment
+// webhook endpoint. The event ID is derived from the order ID, so fulfilling an order
+// twice doesn't deliver it twice.
+type webhookFulfillmentProvider struct {
+	webhooks WebhookService
+	source   string
+}
+
+// NewWebhookFulfillmentProvider hands orders to the webhook worker, which retries them
+// until the endpoint accepts them; source is the CloudEvents source, see events.New
+func NewWebhookFulfillmentProvider(webhooks WebhookService, source string) FulfillmentProvider {
+	return &webhookFulfillmentProvider{webhooks: webhooks, source: source}
+}
+
+func (p *webhookFulfillmentProvider) Fulfill(ctx context.Context, order *models.Order) error {
+	data, err := json.Marshal(order)
+	if err != nil {
+		return fmt.Errorf("failed to marshal order %s: %w", order.ID, err)
+	}
+
+	event := events.New(p.source, order.ID+":fulfillment", "order", order.ID, models.EventOrderCreated, data, time.Now())
+	if err := p.webhooks.Enqueue(ctx, models.WebhookEndpointFulfillment, event); err != nil {
+		return fmt.Errorf("failed to send order %s to fulfillment: %w", order.ID, err)
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+	"time"
+
+	"oolio/internal/app/models"
+	"oolio/internal/app/repository"
+)
+
+var (
+	ErrGiftCardNotFound = errors.New("gift card not found")
+	ErrGiftCardExpired  = errors.New("gift card has expired")
+	ErrGiftCardEmpty    = errors.New("gift card has no balance left")
+	// ErrGiftCardBalanceChanged is returned when the balance was spent by another order
+	// after this one was priced; pricing it again spends what is left
+	ErrGiftCardBalanceChanged = errors.New("gift card balance changed since the order was priced")
+	ErrInvalidGiftCard        = errors.New("gift card needs a positive amount, a code of 4 to 32 letters, digits and dashes, and an expiry in the future")
+)
+
+// giftCardCodePattern is what issued codes may look like; they are stored in upper case
+var giftCardCodePattern = regexp.MustCompile(`^[A-Z0-9-]{4,32}$`)
+
+// giftCardAlphabet leaves out letters and digits that are easily confused when read out
+const giftCardAlphabet = "23456789ABCDEFGHJKLMNPQRSTUVWXYZ"
+
+// GiftCardService issues gift cards and spends their store credit on orders. Codes are
+// case-insensitive.
+type GiftCardService interface {
+	// Issue creates a card holding req.Amount, generating its code unless one is given.
+	// It fails with ErrInvalidGiftCard, or "gift card already exists" for a taken code.
+	Issue(ctx context.Context, req models.GiftCardReq) (*models.GiftCard, error)
+	// Get returns the card or ErrGiftCardNotFound
+	Get(ctx context.Context, code string) (*models.GiftCard, error)
+	// Apply returns how much of due the card pays, all of its balance at most, without
+	// spending it. It fails with ErrGiftCardNotFound, ErrGiftCardExpired or ErrGiftCardEmpty.
+	Apply(ctx context.Context, code string, due models.Money) (models.Money, error)
+	// Redeem spends amount of the card's balance, failing with ErrGiftCardBalanceChanged
+	// when it no longer holds that much
+	Redeem(ctx context.Context, code string, amount models.Money) error
+	// Release gives back an amount spent by Redeem, for orders that were not created
+	Release(ctx context.Context, code string, amount models.Money) error
+}
+
+type giftCardService struct {
+	repo repository.GiftCardRepository
+}
+
+func NewGiftCardService(repo repository.GiftCardRepository) GiftCardService {
+	return &giftCardService{repo: repo}
+}
+
+func (s *giftCardService) Issue(ctx context.Context, req models.GiftCardReq) (*models.GiftCard, error) {
+	code := NormalizeGiftCardCode(req.Code)
+	if req.Amount <= 0 || (code != "" && !giftCardCodePattern.MatchString(code)) ||
+		(req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now())) {
+		return nil, ErrInvalidGiftCard
+	}
+
+	// Generated codes are retried on the rare collision; given ones are not
+	attempts := 1
+	if code == "" {
+		attempts = 3
+	}
+	for attempt := 1; ; attempt++ {
+		card := &models.GiftCard{Code: code, InitialBalance: req.Amount, ExpiresAt: req.ExpiresAt}
+		if card.Code == "" {
+			generated, err := newGiftCardCode()
+			if err != nil {
+				return nil, err
+			}
+			card.Code = generated
+		}
+
+		err := s.repo.Create(ctx, card)
+		if err == nil {
+			return card, nil
+		}
+		if err.Error() != "gift card already exists" || attempt == attempts {
+			return nil, err
+		}
+	}
+}
+
+func (s *giftCardService) Get(ctx context.Context, code string) (*models.GiftCard, error) {
+	card, err := s.repo.FindOne(ctx, NormalizeGiftCardCode(code))
+	if err != nil {
+		if err.Error() == "gift card not found" {
+			return nil, ErrGiftCardNotFound
+		}
+		return nil, err
+	}
+	return card, nil
+}
+
+func (s *giftCardService) Apply(ctx context.Context, code string, due models.Money) (models.Money, error) {
+	card, err := s.Get(ctx, code)
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case card.Expired(time.Now()):
+		return 0, ErrGiftCardExpired
+	case card.Balance <= 0:
+		return 0, ErrGiftCardEmpty
+	case due <= 0:
+		return 0, nil
+	}
+	return min(card.Balance, due), nil
+}
+
+func (s *giftCardService) Redeem(ctx context.Context, code string, amount models.Money) error {
+	if err := s.repo.Debit(ctx, NormalizeGiftCardCode(code), amount); err != nil {
+		if err.Error() == "insufficient gift card balance" {
+			return ErrGiftCardBalanceChanged
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *giftCardService) Release(ctx context.Context, code string, amount models.Money) error {
+	return s.repo.Credit(ctx, NormalizeGiftCardCode(code), amount)
+}
+
+// NormalizeGiftCardCode trims the code and puts it in upper case, as cards are stored
+func NormalizeGiftCardCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// newGiftCardCode returns a code like GC-7KQ2-M9XD-4HPA, hard enough to guess
+func newGiftCardCode() (string, error) {
+	var code strings.Builder
+	code.WriteString("GC")
+	size := big.NewInt(int64(len(giftCardAlphabet)))
+	for i := 0; i < 12; i++ {
+		if i%4 == 0 {
+			code.WriteByte('-')
+		}
+		n, err := rand.Int(rand.Reader, size)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate gift card code: %w", err)
+		}
+		code.WriteByte(giftCardAlphabet[n.Int64()])
+	}
+	return code.String(), nil
+}
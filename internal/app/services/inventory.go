@@ -0,0 +1,179 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"oolio/internal/app/models"
+	"oolio/internal/app/repository"
+)
+
+// ErrOutOfStock is returned for orders of more units of a product than are left
+var ErrOutOfStock = errors.New("out of stock")
+
+// InventoryService counts the stock of products and takes orders from it. Only products
+// that have been restocked are counted; the others are never out of stock.
+type InventoryService interface {
+	// Reserve sets the items of an order being queued aside for the reservation TTL,
+	// naming the reservation in orderReq.StockReservation, so orders queued meanwhile
+	// can't take them. It fails with ErrOutOfStock, reserving none, when a product hasn't
+	// enough left that isn't reserved.
+	Reserve(ctx context.Context, orderReq *models.OrderReq) error
+	// Release gives up the order's reservation, for an order that won't be created
+	Release(ctx context.Context, orderReq *models.OrderReq) error
+	// Take takes the order's items from stock at once, along with their reservation when
+	// reservation is set. It fails with ErrOutOfStock, taking none, when a product hasn't
+	// enough left that other orders haven't reserved.
+	Take(ctx context.Context, reservation string, order *models.Order) error
+	// Return gives back what Take took, for an order that couldn't be created
+	Return(ctx context.Context, order *models.Order) error
+	// Restock adds quantity units to a product's stock, starting to count it when it
+	// wasn't. Unknown products fail with "product not found".
+	Restock(ctx context.Context, productID string, quantity int) (*models.StockLevel, error)
+	// LowStock lists the products with threshold units left or fewer, fewest first,
+	// narrowed to a store when storeID is set
+	LowStock(ctx context.Context, storeID string, threshold int) ([]models.StockLevel, error)
+	// LowStockThreshold is the threshold the low-stock listing uses unless told otherwise
+	LowStockThreshold() int
+	// DeleteExpiredReservations deletes the reservations that expired before their orders
+	// took or released them
+	DeleteExpiredReservations(ctx context.Context) error
+}
+
+type inventoryService struct {
+	repo              repository.InventoryRepository
+	products          repository.ProductRepository
+	lowStockThreshold int
+	reservationTTL    time.Duration
+	logger            *zap.Logger
+}
+
+// NewInventoryService returns the inventory service. Reservations hold stock for
+// reservationTTL; orders processed later take from what is left then.
+func NewInventoryService(repo repository.InventoryRepository, products repository.ProductRepository, lowStockThreshold int, reservationTTL time.Duration, logger *zap.Logger) InventoryService {
+	return &inventoryService{
+		repo:              repo,
+		products:          products,
+		lowStockThreshold: lowStockThreshold,
+		reservationTTL:    reservationTTL,
+		logger:            logger,
+	}
+}
+
+func (s *inventoryService) Reserve(ctx context.Context, orderReq *models.OrderReq) error {
+	reservation := uuid.NewString()
+	err := s.repo.Reserve(ctx, reservation, orderQuantities(orderReq.Items), time.Now().Add(s.reservationTTL))
+	var short *repository.OutOfStockError
+	if errors.As(err, &short) {
+		// The order is refused, so naming the product costs a query only then
+		name := short.ProductID
+		if products, err := s.products.FindByIDs(ctx, []string{short.ProductID}); err == nil && len(products) == 1 {
+			name = products[0].Name
+		}
+		return outOfStock(name, short.Available)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to reserve stock: %w", err)
+	}
+	orderReq.StockReservation = reservation
+	return nil
+}
+
+func (s *inventoryService) Release(ctx context.Context, orderReq *models.OrderReq) error {
+	if orderReq.StockReservation == "" {
+		return nil
+	}
+	if err := s.repo.Release(ctx, orderReq.StockReservation); err != nil {
+		return fmt.Errorf("failed to release stock: %w", err)
+	}
+	return nil
+}
+
+func (s *inventoryService) Take(ctx context.Context, reservation string, order *models.Order) error {
+	err := s.repo.Take(ctx, reservation, orderQuantities(order.Items))
+	var short *repository.OutOfStockError
+	if errors.As(err, &short) {
+		name := short.ProductID
+		for _, product := range order.Products {
+			if product.ID == short.ProductID {
+				name = product.Name
+			}
+		}
+		return outOfStock(name, short.Available)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to take stock: %w", err)
+	}
+	return nil
+}
+
+func (s *inventoryService) Return(ctx context.Context, order *models.Order) error {
+	if err := s.repo.Return(ctx, orderQuantities(order.Items)); err != nil {
+		return fmt.Errorf("failed to return stock: %w", err)
+	}
+	return nil
+}
+
+func (s *inventoryService) Restock(ctx context.Context, productID string, quantity int) (*models.StockLevel, error) {
+	if quantity <= 0 {
+		return nil, fmt.Errorf("restock quantity must be greater than 0")
+	}
+	product, err := s.products.FindOne(ctx, productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+
+	level, err := s.repo.Restock(ctx, product.ID, quantity)
+	if err != nil {
+		return nil, err
+	}
+	level.Name = product.Name
+	level.Category = product.Category
+	level.StoreID = models.StoreOrDefault(product.StoreID)
+	return level, nil
+}
+
+func (s *inventoryService) LowStock(ctx context.Context, storeID string, threshold int) ([]models.StockLevel, error) {
+	levels, err := s.repo.LowStock(ctx, threshold, storeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list low stock: %w", err)
+	}
+	return levels, nil
+}
+
+func (s *inventoryService) LowStockThreshold() int {
+	return s.lowStockThreshold
+}
+
+func (s *inventoryService) DeleteExpiredReservations(ctx context.Context) error {
+	deleted, err := s.repo.DeleteExpiredReservations(ctx, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to delete expired stock reservations: %w", err)
+	}
+	if deleted > 0 {
+		s.logger.Info("Deleted expired stock reservations", zap.Int("count", deleted))
+	}
+	return nil
+}
+
+// orderQuantities adds up the units of each product of the items, which may name a
+// product more than once
+func orderQuantities(items []models.OrderItem) map[string]int {
+	quantities := make(map[string]int, len(items))
+	for _, item := range items {
+		quantities[item.ProductID] += item.Quantity
+	}
+	return quantities
+}
+
+func outOfStock(product string, left int) error {
+	if left == 0 {
+		return fmt.Errorf("%w: %s", ErrOutOfStock, product)
+	}
+	return fmt.Errorf("%w: %s has %d left", ErrOutOfStock, product, left)
+}
@@ -0,0 +1,175 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+
+	"github.com/google/uuid"
+
+	"oolio/internal/app/models"
+	"oolio/internal/app/repository"
+)
+
+var (
+	ErrInvoiceOrderNotFound = errors.New("order not found")
+	// ErrOrderNotInvoiceable is returned for orders that were cancelled or failed, or whose
+	// payment hasn't been captured yet
+	ErrOrderNotInvoiceable = errors.New("only completed orders can be invoiced")
+)
+
+// invoiceSeriesPattern keeps series short enough for their column and safe to print
+var invoiceSeriesPattern = regexp.MustCompile(`^[A-Z0-9]{1,20}$`)
+
+// InvoiceService issues the tax invoices of completed orders. Each order has one invoice,
+// numbered in its store's series when it is first asked for.
+type InvoiceService interface {
+	// Invoice returns the order's invoice, issuing it with the next number of the store's
+	// series the first time. A storeID limits it to that store's orders; those of other
+	// stores fail with ErrInvoiceOrderNotFound, as do missing ones. Orders not complete
+	// yet fail with ErrOrderNotInvoiceable.
+	Invoice(ctx context.Context, storeID, orderID string) (*models.Invoice, error)
+}
+
+// InvoiceOptions describe the seller and how invoices are numbered and taxed
+type InvoiceOptions struct {
+	// Series prefixes invoice numbers, which run on their own per store and series; a
+	// seller (tenant) sharing the database with others needs one of its own
+	Series   string
+	Seller   models.InvoiceSeller
+	Currency string
+	TaxName  string
+	TaxRate  float64 // Percentage included in prices; 0 issues invoices without tax
+}
+
+type invoiceService struct {
+	invoices repository.InvoiceRepository
+	orders   repository.OrderRepository
+	opts     InvoiceOptions
+}
+
+func NewInvoiceService(invoices repository.InvoiceRepository, orders repository.OrderRepository, opts InvoiceOptions) (InvoiceService, error) {
+	if !invoiceSeriesPattern.MatchString(opts.Series) {
+		return nil, fmt.Errorf("invoice series %q must be 1 to 20 upper case letters and digits", opts.Series)
+	}
+	if opts.TaxRate < 0 || opts.TaxRate >= 100 {
+		return nil, fmt.Errorf("tax rate %v must be a percentage from 0 to below 100", opts.TaxRate)
+	}
+	return &invoiceService{invoices: invoices, orders: orders, opts: opts}, nil
+}
+
+func (s *invoiceService) Invoice(ctx context.Context, storeID, orderID string) (*models.Invoice, error) {
+	if _, err := uuid.Parse(orderID); err != nil {
+		return nil, ErrInvoiceOrderNotFound
+	}
+
+	invoice, err := s.invoices.FindByOrder(ctx, orderID)
+	if err == nil {
+		if !ofStore(storeID, invoice.StoreID) {
+			return nil, ErrInvoiceOrderNotFound
+		}
+		return invoice, nil
+	}
+	if err.Error() != "invoice not found" {
+		return nil, fmt.Errorf("failed to get invoice: %w", err)
+	}
+
+	order, err := s.orders.FindOne(ctx, orderID)
+	if err != nil {
+		if err.Error() == "order not found" {
+			return nil, ErrInvoiceOrderNotFound
+		}
+		return nil, fmt.Errorf("failed to get order: %w", err)
+	}
+	// Checked before issuing, so other stores can't use up the store's numbers
+	if !ofStore(storeID, order.StoreID) {
+		return nil, ErrInvoiceOrderNotFound
+	}
+	if !invoiceable(order) {
+		return nil, ErrOrderNotInvoiceable
+	}
+
+	invoice = s.draft(order)
+	if err := s.invoices.Issue(ctx, s.opts.Series, invoice); err != nil {
+		// Asked for twice at once; the other request issued it
+		if err.Error() == "invoice already exists" {
+			return s.invoices.FindByOrder(ctx, orderID)
+		}
+		return nil, fmt.Errorf("failed to issue invoice: %w", err)
+	}
+	return invoice, nil
+}
+
+// ofStore reports whether a record of recordStore may be seen by a caller limited to
+// storeID; no storeID sees every store
+func ofStore(storeID, recordStore string) bool {
+	return storeID == "" || models.StoreOrDefault(recordStore) == storeID
+}
+
+// invoiceable reports whether the order is complete: neither cancelled nor failed, and paid
+// for if it is paid through a payment intent. Sandbox orders are never invoiced, so they
+// don't take numbers from a series
+func invoiceable(order *models.Order) bool {
+	if order.Sandbox || order.Status == models.OrderStatusCancelled || order.Status == models.OrderStatusFailed {
+		return false
+	}
+	return order.PaymentIntentID == "" || order.PaymentStatus == models.OrderPaymentCaptured
+}
+
+// draft lays out the order as an invoice, still to be numbered
+func (s *invoiceService) draft(order *models.Order) *models.Invoice {
+	names := make(map[string]string, len(order.Products))
+	for _, product := range order.Products {
+		names[product.ID] = product.Name
+	}
+
+	lines := make([]models.InvoiceLine, 0, len(order.Items))
+	for _, item := range order.Items {
+		description := names[item.ProductID]
+		if description == "" {
+			description = "Product " + item.ProductID
+		}
+		lines = append(lines, models.InvoiceLine{
+			ProductID:   item.ProductID,
+			Description: item.Describe(description),
+			Quantity:    item.Quantity,
+			UnitPrice:   item.Price,
+			Amount:      item.Price.Mul(item.Quantity),
+		})
+	}
+
+	total := order.Total - order.Discounts
+	taxes := []models.InvoiceTax{}
+	if s.opts.TaxRate > 0 {
+		tax := includedTax(total, s.opts.TaxRate)
+		taxes = append(taxes, models.InvoiceTax{
+			Name:    s.opts.TaxName,
+			Rate:    s.opts.TaxRate,
+			Taxable: total - tax,
+			Amount:  tax,
+		})
+	}
+
+	return &models.Invoice{
+		OrderID:       order.ID,
+		StoreID:       models.StoreOrDefault(order.StoreID),
+		Seller:        s.opts.Seller,
+		CustomerID:    order.CustomerID,
+		Currency:      s.opts.Currency,
+		Lines:         lines,
+		Subtotal:      order.Total,
+		Discounts:     order.Discounts,
+		Total:         total,
+		Taxes:         taxes,
+		StoreCredit:   order.StoreCredit,
+		PaymentMethod: order.PaymentMethod,
+	}
+}
+
+// includedTax is the tax at rate percent within amount, which includes it, rounded to the
+// nearest cent
+func includedTax(amount models.Money, rate float64) models.Money {
+	return models.Money(math.Round(float64(amount) * rate / (100 + rate)))
+}
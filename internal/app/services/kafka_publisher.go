@@ -0,0 +1,106 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"oolio/internal/app/events"
+)
+
+const (
+	kafkaRecordsContentType = "application/vnd.kafka.json.v2+json"
+	kafkaResponseType       = "application/vnd.kafka.v2+json"
+)
+
+type KafkaOptions struct {
+	RESTURL string            // Base URL of the Kafka REST proxy
+	Topic   string            // Topic for aggregate types missing from Topics
+	Topics  map[string]string // Aggregate type to topic
+	Timeout time.Duration
+}
+
+// kafkaEventPublisher produces events through the Kafka REST proxy. Record values are
+// structured-mode CloudEvents keyed by subject (the aggregate ID), so the events of one
+// order or product stay in order within a partition.
+type kafkaEventPublisher struct {
+	client  *http.Client
+	baseURL string
+	topic   string
+	topics  map[string]string
+}
+
+func NewKafkaEventPublisher(opts KafkaOptions) EventPublisher {
+	return &kafkaEventPublisher{
+		client:  &http.Client{Timeout: opts.Timeout},
+		baseURL: strings.TrimSuffix(opts.RESTURL, "/"),
+		topic:   opts.Topic,
+		topics:  opts.Topics,
+	}
+}
+
+type kafkaRecord struct {
+	Key   string       `json:"key"`
+	Value events.Event `json:"value"`
+}
+
+type kafkaProduceResponse struct {
+	Offsets []struct {
+		ErrorCode *int   `json:"error_code"`
+		Error     string `json:"error"`
+	} `json:"offsets"`
+	Message string `json:"message"`
+}
+
+func (p *kafkaEventPublisher) Publish(ctx context.Context, event events.Event) error {
+	topic := p.topicFor(event)
+
+	body, err := json.Marshal(struct {
+		Records []kafkaRecord `json:"records"`
+	}{
+		Records: []kafkaRecord{{Key: event.Subject, Value: event}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal event %s: %w", event.ID, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/topics/"+url.PathEscape(topic), bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build request for topic %s: %w", topic, err)
+	}
+	req.Header.Set("Content-Type", kafkaRecordsContentType)
+	req.Header.Set("Accept", kafkaResponseType)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to publish event %s to %s: %w", event.ID, topic, err)
+	}
+	defer resp.Body.Close()
+
+	var produced kafkaProduceResponse
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	_ = json.Unmarshal(data, &produced)
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("kafka proxy rejected event %s for %s: %s %s", event.ID, topic, resp.Status, produced.Message)
+	}
+	for _, offset := range produced.Offsets {
+		if offset.ErrorCode != nil {
+			return fmt.Errorf("kafka failed to store event %s in %s: %s", event.ID, topic, offset.Error)
+		}
+	}
+	return nil
+}
+
+func (p *kafkaEventPublisher) topicFor(event events.Event) string {
+	if topic, ok := p.topics[event.AggregateType()]; ok && topic != "" {
+		return topic
+	}
+	return p.topic
+}
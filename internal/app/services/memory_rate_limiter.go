@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// memoryRateLimiterService is an in-process token bucket used when Redis isn't available,
+// e.g. for local development. Limits are per instance rather than shared across replicas.
+type memoryRateLimiterService struct {
+	mutex   sync.Mutex
+	buckets map[string]*memoryBucket
+}
+
+type memoryBucket struct {
+	tokens     int
+	lastRefill time.Time
+}
+
+func NewMemoryRateLimiterService() RateLimiterService {
+	return &memoryRateLimiterService{
+		buckets: make(map[string]*memoryBucket),
+	}
+}
+
+func (s *memoryRateLimiterService) AllowRequest(ctx context.Context, key string, limit int, window time.Duration) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	bucket, ok := s.buckets[key]
+	if !ok {
+		bucket = &memoryBucket{tokens: limit, lastRefill: now}
+		s.buckets[key] = bucket
+	}
+
+	// Same refill rule as the Redis script: a full window refills the bucket
+	if window > 0 && now.Sub(bucket.lastRefill) >= window {
+		bucket.tokens = limit
+		bucket.lastRefill = now
+	}
+
+	if bucket.tokens <= 0 {
+		return false, nil
+	}
+	bucket.tokens--
+	return true, nil
+}
+
+func (s *memoryRateLimiterService) IsAllowed(ctx context.Context, key string) (bool, error) {
+	return s.AllowRequest(ctx, key, 100, time.Minute) // Default: 100 requests per minute
+}
+
+func (s *memoryRateLimiterService) GetRemainingTokens(ctx context.Context, key string, limit int) (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	bucket, ok := s.buckets[key]
+	if !ok {
+		return limit, nil
+	}
+	return bucket.tokens, nil
+}
+
+func (s *memoryRateLimiterService) ResetKey(ctx context.Context, key string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.buckets, key)
+	return nil
+}
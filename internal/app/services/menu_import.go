@@ -0,0 +1,244 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+
+	"oolio/internal/app/models"
+	"oolio/internal/storage"
+)
+
+var (
+	// ErrMenuImportDisabled is returned when no menu provider is configured
+	ErrMenuImportDisabled = errors.New("menu import is not configured")
+	// ErrMenuImportInProgress is returned when an import is triggered while one is running
+	ErrMenuImportInProgress = errors.New("a menu import is already running")
+)
+
+// MenuItem is a product as listed by an external menu provider
+type MenuItem struct {
+	ExternalID string // The provider's stable identifier for the item
+	Name       string
+	Price      models.Money
+	Category   string
+	Image      models.Image // Left empty to keep the product's current images
+}
+
+// MenuSource fetches the current menu from a third-party platform. Each adapter turns a
+// provider's format into MenuItems; MENU_IMPORT_PROVIDER selects one.
+type MenuSource interface {
+	// Name identifies the provider; products imported from different providers are
+	// tracked separately
+	Name() string
+	// FetchMenu returns every item currently on sale
+	FetchMenu(ctx context.Context) ([]MenuItem, error)
+}
+
+type MenuImportService interface {
+	// Import fetches the menu and creates, updates, restores and archives products to match
+	Import(ctx context.Context) (*models.MenuImportResult, error)
+}
+
+type menuImportService struct {
+	source   MenuSource
+	products ProductService
+	state    storage.Storage
+	logger   *zap.Logger
+	running  atomic.Bool
+}
+
+// NewMenuImportService reconciles products against source. The link from each menu item
+// to the product it created is kept in state, so only imported products are ever
+// archived. A nil source disables importing.
+func NewMenuImportService(source MenuSource, products ProductService, state storage.Storage, logger *zap.Logger) MenuImportService {
+	return &menuImportService{
+		source:   source,
+		products: products,
+		state:    state,
+		logger:   logger,
+	}
+}
+
+// menuLinks maps menu item IDs to the IDs of the products imported from them
+type menuLinks map[string]string
+
+func (s *menuImportService) Import(ctx context.Context) (*models.MenuImportResult, error) {
+	if s.source == nil {
+		return nil, ErrMenuImportDisabled
+	}
+	if !s.running.CompareAndSwap(false, true) {
+		return nil, ErrMenuImportInProgress
+	}
+	defer s.running.Store(false)
+
+	result := &models.MenuImportResult{Source: s.source.Name(), StartedAt: time.Now()}
+
+	items, err := s.source.FetchMenu(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch menu from %s: %w", s.source.Name(), err)
+	}
+	// An empty menu is far more likely a provider fault than a closed restaurant
+	if len(items) == 0 {
+		return nil, fmt.Errorf("menu from %s has no items; refusing to archive every imported product", s.source.Name())
+	}
+
+	links, err := s.loadLinks(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := s.products.GetAllProducts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]*models.Product, len(current))
+	byName := make(map[string]*models.Product, len(current))
+	for i := range current {
+		byID[current[i].ID] = &current[i]
+		byName[strings.ToLower(current[i].Name)] = &current[i]
+	}
+	linked := make(map[string]bool, len(links))
+	for _, productID := range links {
+		linked[productID] = true
+	}
+
+	onMenu := make(map[string]bool, len(items))
+	for _, item := range items {
+		if onMenu[item.ExternalID] {
+			result.Errors = append(result.Errors, fmt.Sprintf("item %s: listed more than once", item.ExternalID))
+			continue
+		}
+		onMenu[item.ExternalID] = true
+		if err := s.apply(ctx, item, links, linked, byID, byName, result); err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("item %s: %v", item.ExternalID, err))
+		}
+	}
+
+	// Archived products keep their links so they are restored if the item comes back
+	for externalID, productID := range links {
+		if onMenu[externalID] || byID[productID] == nil {
+			continue
+		}
+		if err := s.products.DeleteProduct(ctx, productID); err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("item %s: %v", externalID, err))
+			continue
+		}
+		result.Archived++
+	}
+
+	if err := s.saveLinks(ctx, links); err != nil {
+		return nil, err
+	}
+
+	result.FinishedAt = time.Now()
+	s.logger.Info("Menu imported",
+		zap.String("source", result.Source),
+		zap.Int("created", result.Created),
+		zap.Int("updated", result.Updated),
+		zap.Int("restored", result.Restored),
+		zap.Int("archived", result.Archived),
+		zap.Int("unchanged", result.Unchanged),
+		zap.Int("errors", len(result.Errors)))
+	return result, nil
+}
+
+// apply brings the product linked to item in line with it, creating or restoring it first
+// if needed. A product is linked to a new menu item by name, so a provider can take over a
+// menu that was entered by hand.
+func (s *menuImportService) apply(ctx context.Context, item MenuItem, links menuLinks, linked map[string]bool,
+	byID, byName map[string]*models.Product, result *models.MenuImportResult) error {
+	var product *models.Product
+	restored := false
+
+	if productID, ok := links[item.ExternalID]; ok {
+		product = byID[productID]
+		if product == nil && s.products.RestoreProduct(ctx, productID) == nil {
+			var err error
+			if product, err = s.products.GetProductByID(ctx, productID); err != nil {
+				return err
+			}
+			restored = true
+		}
+	}
+	if product == nil {
+		if match := byName[strings.ToLower(item.Name)]; match != nil && !linked[match.ID] {
+			product = match
+		}
+	}
+
+	if product == nil {
+		product = &models.Product{Name: item.Name, Price: item.Price, Category: item.Category, Image: item.Image}
+		if err := s.products.CreateProduct(ctx, product); err != nil {
+			return err
+		}
+		links[item.ExternalID] = product.ID
+		linked[product.ID] = true
+		result.Created++
+		return nil
+	}
+
+	links[item.ExternalID] = product.ID
+	linked[product.ID] = true
+
+	changed := product.Name != item.Name || product.Price != item.Price || product.Category != item.Category ||
+		item.Image != (models.Image{}) && product.Image != item.Image
+	if changed {
+		product.Name, product.Price, product.Category = item.Name, item.Price, item.Category
+		if item.Image != (models.Image{}) {
+			product.Image = item.Image
+		}
+		if err := s.products.UpdateProduct(ctx, product); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case restored:
+		result.Restored++
+	case changed:
+		result.Updated++
+	default:
+		result.Unchanged++
+	}
+	return nil
+}
+
+func (s *menuImportService) linksKey() string {
+	return "menu-import/" + s.source.Name() + ".json"
+}
+
+func (s *menuImportService) loadLinks(ctx context.Context) (menuLinks, error) {
+	file, err := s.state.Get(ctx, s.linksKey())
+	if errors.Is(err, storage.ErrNotFound) {
+		return menuLinks{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load menu import state: %w", err)
+	}
+	defer file.Close()
+
+	links := menuLinks{}
+	if err := json.NewDecoder(file).Decode(&links); err != nil {
+		return nil, fmt.Errorf("failed to decode menu import state: %w", err)
+	}
+	return links, nil
+}
+
+func (s *menuImportService) saveLinks(ctx context.Context, links menuLinks) error {
+	data, err := json.Marshal(links)
+	if err != nil {
+		return fmt.Errorf("failed to encode menu import state: %w", err)
+	}
+	if err := s.state.Put(ctx, s.linksKey(), bytes.NewReader(data), "application/json"); err != nil {
+		return fmt.Errorf("failed to save menu import state: %w", err)
+	}
+	return nil
+}
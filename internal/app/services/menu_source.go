@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"oolio/internal/app/models"
+)
+
+// maxMenuBytes caps the menu document read from a provider
+const maxMenuBytes = 10 << 20
+
+// GenericMenu is the JSON document served to the generic adapter. Platforms without a
+// dedicated adapter can be bridged to it with a small translation service.
+//
+//	{"items": [{"id": "sku-1", "name": "Chicken Waffle", "price": "12.50", "category": "Waffle",
+//	            "image": {"thumbnail": "...", "mobile": "...", "tablet": "...", "desktop": "..."}}]}
+//
+// Items with "available": false are treated as off the menu.
+type GenericMenu struct {
+	Items []GenericMenuItem `json:"items"`
+}
+
+type GenericMenuItem struct {
+	ID        string       `json:"id"`
+	Name      string       `json:"name"`
+	Price     models.Money `json:"price"`
+	Category  string       `json:"category"`
+	Image     models.Image `json:"image"`
+	Available *bool        `json:"available,omitempty"` // Defaults to true
+}
+
+type GenericMenuOptions struct {
+	URL     string
+	Token   string // Sent as a bearer token when set
+	Timeout time.Duration
+}
+
+// genericMenuSource fetches a GenericMenu document over HTTP
+type genericMenuSource struct {
+	client *http.Client
+	url    string
+	token  string
+}
+
+func NewGenericMenuSource(opts GenericMenuOptions) MenuSource {
+	return &genericMenuSource{
+		client: &http.Client{Timeout: opts.Timeout},
+		url:    opts.URL,
+		token:  opts.Token,
+	}
+}
+
+func (s *genericMenuSource) Name() string {
+	return "generic"
+}
+
+func (s *genericMenuSource) FetchMenu(ctx context.Context) ([]MenuItem, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build menu request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download menu: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download menu: %s", resp.Status)
+	}
+
+	var menu GenericMenu
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxMenuBytes)).Decode(&menu); err != nil {
+		return nil, fmt.Errorf("failed to decode menu: %w", err)
+	}
+	return menu.MenuItems()
+}
+
+// MenuItems validates the menu and returns the items that are available
+func (m GenericMenu) MenuItems() ([]MenuItem, error) {
+	items := make([]MenuItem, 0, len(m.Items))
+	for i, item := range m.Items {
+		item.ID = strings.TrimSpace(item.ID)
+		item.Name = strings.TrimSpace(item.Name)
+		item.Category = strings.TrimSpace(item.Category)
+
+		switch {
+		case item.ID == "":
+			return nil, fmt.Errorf("menu item %d: id is required", i+1)
+		case item.Name == "":
+			return nil, fmt.Errorf("menu item %s: name is required", item.ID)
+		case item.Category == "":
+			return nil, fmt.Errorf("menu item %s: category is required", item.ID)
+		case item.Price <= 0:
+			return nil, fmt.Errorf("menu item %s: price must be greater than 0", item.ID)
+		}
+
+		if item.Available != nil && !*item.Available {
+			continue
+		}
+		items = append(items, MenuItem{
+			ExternalID: item.ID,
+			Name:       item.Name,
+			Price:      item.Price,
+			Category:   item.Category,
+			Image:      item.Image,
+		})
+	}
+	return items, nil
+}
@@ -0,0 +1,622 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"oolio/internal/app/models"
+	"oolio/internal/app/repository"
+	"oolio/internal/backoff"
+)
+
+// EmailSender delivers emails. Providers are selected by NOTIFICATION_EMAIL_PROVIDER; a
+// service such as SES or Postmark only needs an implementation.
+type EmailSender interface {
+	SendEmail(ctx context.Context, to, subject, text string) error
+}
+
+// logEmailSender writes emails to the log instead of sending them, for local development
+type logEmailSender struct {
+	logger *zap.Logger
+}
+
+func NewLogEmailSender(logger *zap.Logger) EmailSender {
+	return &logEmailSender{logger: logger}
+}
+
+func (s *logEmailSender) SendEmail(ctx context.Context, to, subject, text string) error {
+	s.logger.Info("Email not sent (log provider)", zap.String("to", to), zap.String("subject", subject), zap.String("text", text))
+	return nil
+}
+
+// webhookEmailSender posts {"to", "subject", "text"} to an email gateway, with the token as
+// a bearer token when set
+type webhookEmailSender struct {
+	client *http.Client
+	url    string
+	token  string
+}
+
+func NewWebhookEmailSender(url, token string, timeout time.Duration) EmailSender {
+	return &webhookEmailSender{
+		client: &http.Client{Timeout: timeout},
+		url:    url,
+		token:  token,
+	}
+}
+
+func (s *webhookEmailSender) SendEmail(ctx context.Context, to, subject, text string) error {
+	body, err := json.Marshal(map[string]string{"to": to, "subject": subject, "text": text})
+	if err != nil {
+		return fmt.Errorf("failed to marshal email: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build email request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("email gateway rejected the message: %s", resp.Status)
+	}
+	return nil
+}
+
+// Notification is the content of a notification; SMS carry the text alone
+type Notification struct {
+	Subject string
+	Text    string
+}
+
+type NotificationService interface {
+	// GetPreferences returns the customer's preferences, or the defaults when they saved none
+	GetPreferences(ctx context.Context, customerID string) (*models.NotificationPreferences, error)
+	SavePreferences(ctx context.Context, customerID string, req models.NotificationPreferencesReq) (*models.NotificationPreferences, error)
+	// Notify sends a notification of the given kind to a customer who wants it, or queues
+	// it for the worker when there is an outbox, and reports whether it was sent or queued.
+	// Guests and disabled channels get nothing.
+	Notify(ctx context.Context, customerID, kind string, notification Notification) (bool, error)
+	// OrderPlaced emails the customer's receipt and reports whether it was sent or queued.
+	// Sandbox orders, placed with test API keys, get nothing here or from OrderReady.
+	OrderPlaced(ctx context.Context, order *models.Order) (bool, error)
+	// OrderReady texts and emails the customer that their order is ready, as they asked,
+	// and reports whether either was sent or queued. What became of the text is recorded
+	// on the order's events.
+	OrderReady(ctx context.Context, order *models.Order) (bool, error)
+	// SMSStatus records on its order's events what the SMS provider's status callback
+	// reports about a text. It fails with ErrSMSStatusSignature, and
+	// ErrSMSStatusNotSupported when the provider doesn't report delivery.
+	SMSStatus(ctx context.Context, query, form url.Values, signature string) error
+	// DeliverBatch sends up to batchSize queued notifications that are due. Failed ones are
+	// retried with backoff until they run out of attempts and are dead-lettered.
+	DeliverBatch(ctx context.Context, batchSize int) (*models.NotificationDeliveryResult, error)
+	// GetDeliveryStats describes the outbox; it fails with ErrNotificationOutboxDisabled
+	// without one
+	GetDeliveryStats(ctx context.Context) (*models.NotificationDeliveryStats, error)
+	// Redeliver queues a dead notification again with a fresh set of attempts. It fails
+	// with ErrNotificationOutboxDisabled without an outbox.
+	Redeliver(ctx context.Context, id string) error
+	// Feed pages through the customer's in-app notifications, newest first, only the
+	// unread ones when unreadOnly. OrderPlaced and OrderReady add to it whatever the
+	// customer's preferences, so apps can show their activity without push notifications.
+	Feed(ctx context.Context, customerID string, unreadOnly bool, page models.PageRequest) (*models.NotificationFeed, error)
+	// MarkRead marks one of the customer's in-app notifications read. It fails with
+	// ErrFeedNotificationNotFound when the customer has none with that id.
+	MarkRead(ctx context.Context, customerID, id string) error
+	// MarkAllRead marks all the customer's in-app notifications read and returns how many
+	// were unread
+	MarkAllRead(ctx context.Context, customerID string) (int, error)
+	// RegisterDevice registers the customer's device for push notifications about their
+	// orders. It fails with ErrPushPlatformNotSupported for a platform no provider sends
+	// to, and ErrInvalidPushToken.
+	RegisterDevice(ctx context.Context, customerID string, req models.PushDeviceReq) (*models.PushDevice, error)
+	// ListDevices returns the customer's registered devices, newest first
+	ListDevices(ctx context.Context, customerID string) ([]models.PushDevice, error)
+	// UnregisterDevice stops push notifications to one of the customer's devices. It fails
+	// with ErrPushDeviceNotFound.
+	UnregisterDevice(ctx context.Context, customerID, token string) error
+}
+
+var (
+	ErrSMSStatusNotSupported      = errors.New("the SMS provider doesn't report delivery")
+	ErrNotificationOutboxDisabled = errors.New("notifications are sent without an outbox")
+	ErrFeedNotificationNotFound   = errors.New("notification not found")
+)
+
+const (
+	// notificationLease holds claimed notifications back from other workers; it must
+	// outlast sending a batch
+	notificationLease = 5 * time.Minute
+	// maxNotificationRetryDelay caps the doubling of the retry backoff
+	maxNotificationRetryDelay = time.Hour
+)
+
+type NotificationOptions struct {
+	Templates *EmailTemplates // Order emails; the built-in templates when nil
+	// Events records what became of texts about orders; without it, nothing is
+	Events repository.OutboxRepository
+	// RateLimiter limits each phone to SMSPerHour texts; without either it isn't limited
+	RateLimiter RateLimiterService
+	SMSPerHour  int
+	// SMSReadyEnabled turns ready texts on and off while running; without it they are on
+	SMSReadyEnabled func() bool
+	// Outbox queues notifications for DeliverBatch; without it they are sent right away.
+	// Queued notifications are tried MaxAttempts times, the retries RetryBackoff apart
+	// and then twice as far apart each time.
+	Outbox       repository.NotificationOutboxRepository
+	MaxAttempts  int
+	RetryBackoff time.Duration
+	// Feed keeps customers' in-app notifications; without it, the feed stays empty
+	Feed repository.NotificationFeedRepository
+	// Push sends to registered Devices by platform; without a sender for a platform its
+	// devices can't be registered. PushEnabled turns sending on and off while running;
+	// without it push notifications are on.
+	Push        map[string]PushSender
+	Devices     repository.PushDeviceRepository
+	PushEnabled func() bool
+}
+
+// maxPushTokenLength is the longest device token accepted; FCM's are about 160 characters
+// and APNs' 64
+const maxPushTokenLength = 512
+
+// notificationService sends email and SMS notifications as customers' preferences allow,
+// and push notifications to the devices they registered.
+// Either sender may be nil, which disables that channel.
+type notificationService struct {
+	repo  repository.NotificationRepository
+	email EmailSender
+	sms   SMSSender
+	opts  NotificationOptions
+}
+
+func NewNotificationService(repo repository.NotificationRepository, email EmailSender, sms SMSSender, opts NotificationOptions) NotificationService {
+	if opts.Templates == nil {
+		opts.Templates = DefaultEmailTemplates()
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 1
+	}
+	return &notificationService{repo: repo, email: email, sms: sms, opts: opts}
+}
+
+func (s *notificationService) GetPreferences(ctx context.Context, customerID string) (*models.NotificationPreferences, error) {
+	preferences, err := s.repo.FindByCustomer(ctx, customerID)
+	if err != nil {
+		if err.Error() == "notification preferences not found" {
+			defaults := models.DefaultNotificationPreferences()
+			return &defaults, nil
+		}
+		return nil, err
+	}
+	return preferences, nil
+}
+
+func (s *notificationService) SavePreferences(ctx context.Context, customerID string, req models.NotificationPreferencesReq) (*models.NotificationPreferences, error) {
+	req.Email = strings.TrimSpace(req.Email)
+	req.Phone = strings.TrimSpace(req.Phone)
+	if req.Email != "" && !validEmail(req.Email) {
+		return nil, ErrInvalidEmail
+	}
+	if req.Phone != "" && !phonePattern.MatchString(req.Phone) {
+		return nil, ErrInvalidPhone
+	}
+	return s.repo.Save(ctx, customerID, req)
+}
+
+func (s *notificationService) Notify(ctx context.Context, customerID, kind string, notification Notification) (bool, error) {
+	return s.notify(ctx, customerID, "", kind, notification)
+}
+
+// notify is Notify for texts about an order, whose fate is recorded on the order's events
+func (s *notificationService) notify(ctx context.Context, customerID, orderID, kind string, notification Notification) (bool, error) {
+	if customerID == "" {
+		return false, nil
+	}
+	sendsSMS := kind == models.NotificationSMSReady
+	if (sendsSMS && (s.sms == nil || !s.smsReadyEnabled())) || (!sendsSMS && s.email == nil) {
+		return false, nil
+	}
+
+	preferences, err := s.GetPreferences(ctx, customerID)
+	if err != nil {
+		return false, err
+	}
+	if !preferences.Wants(kind) {
+		return false, nil
+	}
+
+	message := &models.NotificationMessage{
+		Channel:   models.NotificationChannelEmail,
+		Kind:      kind,
+		Recipient: preferences.Email,
+		Subject:   notification.Subject,
+		Text:      notification.Text,
+		OrderID:   orderID,
+	}
+	if sendsSMS {
+		allowed, err := s.allowSMS(ctx, orderID, kind, preferences.Phone)
+		if err != nil || !allowed {
+			return false, err
+		}
+		message.Channel = models.NotificationChannelSMS
+		message.Recipient = preferences.Phone
+	}
+
+	if err := s.dispatch(ctx, message); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// dispatch queues the message for the worker when there is an outbox, and sends it right
+// away otherwise
+func (s *notificationService) dispatch(ctx context.Context, message *models.NotificationMessage) error {
+	if s.opts.Outbox != nil {
+		return s.opts.Outbox.Enqueue(ctx, message)
+	}
+
+	messageID, err := s.send(ctx, message)
+	s.recordDelivery(ctx, message, messageID, err)
+	return err
+}
+
+func (s *notificationService) smsReadyEnabled() bool {
+	return s.opts.SMSReadyEnabled == nil || s.opts.SMSReadyEnabled()
+}
+
+// allowSMS reports whether the phone has texts left this hour, and records a text that
+// isn't sent because it hasn't
+func (s *notificationService) allowSMS(ctx context.Context, orderID, kind, phone string) (bool, error) {
+	if s.opts.RateLimiter == nil || s.opts.SMSPerHour <= 0 {
+		return true, nil
+	}
+	allowed, err := s.opts.RateLimiter.AllowRequest(ctx, "sms:"+phone, s.opts.SMSPerHour, time.Hour)
+	if err != nil {
+		return false, fmt.Errorf("failed to check SMS rate limit: %w", err)
+	}
+	if !allowed {
+		s.recordSMS(ctx, orderID, models.SMSStatus{Kind: kind, Status: models.SMSStatusRateLimited})
+	}
+	return allowed, nil
+}
+
+// send delivers the message on its channel and returns the provider's ID of tracked texts
+func (s *notificationService) send(ctx context.Context, message *models.NotificationMessage) (string, error) {
+	switch message.Channel {
+	case models.NotificationChannelSMS:
+		if s.sms == nil {
+			return "", fmt.Errorf("SMS notifications are disabled")
+		}
+		if tracked, ok := s.sms.(TrackedSMSSender); ok && message.OrderID != "" {
+			return tracked.SendTrackedSMS(ctx, message.Recipient, message.Text, message.OrderID)
+		}
+		return "", s.sms.SendSMS(ctx, message.Recipient, message.Text)
+	case models.NotificationChannelEmail:
+		if s.email == nil {
+			return "", fmt.Errorf("email notifications are disabled")
+		}
+		return "", s.email.SendEmail(ctx, message.Recipient, message.Subject, message.Text)
+	case models.NotificationChannelPush:
+		return "", s.sendPush(ctx, message)
+	}
+	return "", fmt.Errorf("unknown notification channel %q", message.Channel)
+}
+
+// recordDelivery records on its order's events that a text was sent, or failed for good
+func (s *notificationService) recordDelivery(ctx context.Context, message *models.NotificationMessage, messageID string, err error) {
+	if message.Channel != models.NotificationChannelSMS {
+		return
+	}
+	status := models.SMSStatus{Kind: message.Kind, Status: models.SMSStatusSent, MessageID: messageID}
+	if err != nil {
+		status = models.SMSStatus{Kind: message.Kind, Status: models.SMSStatusFailed, Error: err.Error()}
+	}
+	s.recordSMS(ctx, message.OrderID, status)
+}
+
+// recordSMS adds status to the order's events, on its own as nothing else changes with it.
+// Texts are sent either way, so a status that can't be recorded is only logged.
+func (s *notificationService) recordSMS(ctx context.Context, orderID string, status models.SMSStatus) {
+	if s.opts.Events == nil || orderID == "" {
+		return
+	}
+	if err := s.opts.Events.Append(ctx, nil, "order", orderID, models.EventOrderSMSStatus, status); err != nil {
+		log.Printf("SMS status %s of order %s was not recorded: %v", status.Status, orderID, err)
+	}
+}
+
+func (s *notificationService) SMSStatus(ctx context.Context, query, form url.Values, signature string) error {
+	tracked, ok := s.sms.(TrackedSMSSender)
+	if !ok {
+		return ErrSMSStatusNotSupported
+	}
+	orderID, status, err := tracked.ParseStatus(query, form, signature)
+	if err != nil {
+		return err
+	}
+
+	// Only the final statuses are worth recording; the send was recorded already
+	if status.Status != models.SMSStatusDelivered && status.Status != models.SMSStatusUndelivered {
+		return nil
+	}
+	if s.opts.Events == nil || orderID == "" {
+		return nil
+	}
+	if err := s.opts.Events.Append(ctx, nil, "order", orderID, models.EventOrderSMSStatus, status); err != nil {
+		return fmt.Errorf("failed to record SMS status: %w", err)
+	}
+	return nil
+}
+
+func (s *notificationService) DeliverBatch(ctx context.Context, batchSize int) (*models.NotificationDeliveryResult, error) {
+	result := &models.NotificationDeliveryResult{}
+	if s.opts.Outbox == nil {
+		return result, nil
+	}
+
+	messages, err := s.opts.Outbox.ClaimDue(ctx, batchSize, notificationLease)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, message := range messages {
+		messageID, sendErr := s.send(ctx, message)
+		if sendErr == nil {
+			s.recordDelivery(ctx, message, messageID, nil)
+			result.Sent++
+			// Not recording it sends the notification again once the lease is over
+			if err := s.opts.Outbox.MarkSent(ctx, message.ID); err != nil {
+				result.Errors = append(result.Errors, fmt.Sprintf("notification %s: %v", message.ID, err))
+			}
+			continue
+		}
+
+		result.Errors = append(result.Errors, fmt.Sprintf("notification %s: %v", message.ID, sendErr))
+		attempts := message.Attempts + 1
+		// A device the provider no longer knows never will
+		if attempts >= s.opts.MaxAttempts || errors.Is(sendErr, ErrPushTokenInvalid) {
+			s.recordDelivery(ctx, message, "", sendErr)
+			result.Dead++
+			err = s.opts.Outbox.MarkDead(ctx, message.ID, sendErr.Error())
+		} else {
+			result.Failed++
+			err = s.opts.Outbox.MarkFailed(ctx, message.ID, sendErr.Error(), time.Now().Add(backoff.Delay(s.opts.RetryBackoff, maxNotificationRetryDelay, attempts)))
+		}
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("notification %s: %v", message.ID, err))
+		}
+	}
+
+	return result, nil
+}
+
+func (s *notificationService) GetDeliveryStats(ctx context.Context) (*models.NotificationDeliveryStats, error) {
+	if s.opts.Outbox == nil {
+		return nil, ErrNotificationOutboxDisabled
+	}
+	return s.opts.Outbox.GetStats(ctx)
+}
+
+func (s *notificationService) Redeliver(ctx context.Context, id string) error {
+	if s.opts.Outbox == nil {
+		return ErrNotificationOutboxDisabled
+	}
+	return s.opts.Outbox.Redeliver(ctx, id)
+}
+
+func (s *notificationService) OrderPlaced(ctx context.Context, order *models.Order) (bool, error) {
+	if order.Sandbox {
+		return false, nil
+	}
+	title, text := "Order received", fmt.Sprintf("We've received order %s and are getting it ready.", shortOrderID(order.ID))
+	feedErr := s.addToFeed(ctx, order, models.FeedOrderPlaced, title, text)
+	pushErr := s.pushOrder(ctx, order, models.FeedOrderPlaced, title, text)
+
+	receipt, err := s.opts.Templates.Render(EmailOrderConfirmation, NewOrderEmail(order))
+	if err != nil {
+		return false, errors.Join(feedErr, pushErr, err)
+	}
+	emailed, err := s.Notify(ctx, order.CustomerID, models.NotificationEmailReceipt, receipt)
+	return emailed, errors.Join(feedErr, pushErr, err)
+}
+
+func (s *notificationService) OrderReady(ctx context.Context, order *models.Order) (bool, error) {
+	if order.Sandbox {
+		return false, nil
+	}
+	title, text := "Your order is ready", fmt.Sprintf("Your order %s is ready.", shortOrderID(order.ID))
+	feedErr := s.addToFeed(ctx, order, models.FeedOrderReady, title, text)
+	pushErr := s.pushOrder(ctx, order, models.FeedOrderReady, title, text)
+
+	texted, smsErr := s.notify(ctx, order.CustomerID, order.ID, models.NotificationSMSReady, Notification{Text: text})
+
+	// A failed text doesn't hold back the email
+	email, err := s.opts.Templates.Render(EmailOrderReady, NewOrderEmail(order))
+	if err != nil {
+		return texted, errors.Join(feedErr, pushErr, smsErr, err)
+	}
+	emailed, err := s.Notify(ctx, order.CustomerID, models.NotificationEmailReady, email)
+	return texted || emailed, errors.Join(feedErr, pushErr, smsErr, err)
+}
+
+// pushOrder sends a push notification about the order to each device its customer
+// registered, while push notifications are enabled
+func (s *notificationService) pushOrder(ctx context.Context, order *models.Order, kind, title, text string) error {
+	if s.opts.Devices == nil || len(s.opts.Push) == 0 || order.CustomerID == "" {
+		return nil
+	}
+	if s.opts.PushEnabled != nil && !s.opts.PushEnabled() {
+		return nil
+	}
+
+	devices, err := s.opts.Devices.FindByCustomer(ctx, order.CustomerID)
+	if err != nil {
+		return fmt.Errorf("failed to find push devices for order %s: %w", order.ID, err)
+	}
+	var errs []error
+	for _, device := range devices {
+		if s.opts.Push[device.Platform] == nil {
+			continue
+		}
+		err := s.dispatch(ctx, &models.NotificationMessage{
+			Channel:   models.NotificationChannelPush,
+			Kind:      kind,
+			Recipient: models.PushRecipient(device.Platform, device.Token),
+			Subject:   title,
+			Text:      text,
+			OrderID:   order.ID,
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to push order %s to a %s device: %w", order.ID, device.Platform, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// sendPush sends a push notification to its device, and forgets the device once its
+// provider no longer knows it
+func (s *notificationService) sendPush(ctx context.Context, message *models.NotificationMessage) error {
+	platform, token, ok := models.ParsePushRecipient(message.Recipient)
+	if !ok {
+		return fmt.Errorf("invalid push recipient")
+	}
+	sender := s.opts.Push[platform]
+	if sender == nil {
+		return fmt.Errorf("%s push notifications are disabled", platform)
+	}
+
+	err := sender.SendPush(ctx, token, Push{Title: message.Subject, Body: message.Text, OrderID: message.OrderID})
+	if errors.Is(err, ErrPushTokenInvalid) && s.opts.Devices != nil {
+		if removeErr := s.opts.Devices.Remove(ctx, token); removeErr != nil {
+			log.Printf("Invalid %s push device was not removed: %v", platform, removeErr)
+		}
+	}
+	return err
+}
+
+func (s *notificationService) RegisterDevice(ctx context.Context, customerID string, req models.PushDeviceReq) (*models.PushDevice, error) {
+	if s.opts.Devices == nil || s.opts.Push[req.Platform] == nil {
+		return nil, ErrPushPlatformNotSupported
+	}
+	token := strings.TrimSpace(req.Token)
+	if token == "" || len(token) > maxPushTokenLength || strings.ContainsAny(token, " \t\r\n") {
+		return nil, ErrInvalidPushToken
+	}
+
+	device := &models.PushDevice{Platform: req.Platform, Token: token}
+	if err := s.opts.Devices.Register(ctx, customerID, device); err != nil {
+		return nil, err
+	}
+	return device, nil
+}
+
+func (s *notificationService) ListDevices(ctx context.Context, customerID string) ([]models.PushDevice, error) {
+	devices := []models.PushDevice{}
+	if s.opts.Devices == nil {
+		return devices, nil
+	}
+	found, err := s.opts.Devices.FindByCustomer(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	return append(devices, found...), nil
+}
+
+func (s *notificationService) UnregisterDevice(ctx context.Context, customerID, token string) error {
+	if s.opts.Devices == nil {
+		return ErrPushDeviceNotFound
+	}
+	err := s.opts.Devices.Unregister(ctx, customerID, token)
+	if err != nil && err.Error() == "push device not found" {
+		return ErrPushDeviceNotFound
+	}
+	return err
+}
+
+// addToFeed adds a notification about the order to its customer's in-app feed; guests
+// have no feed
+func (s *notificationService) addToFeed(ctx context.Context, order *models.Order, kind, title, body string) error {
+	if s.opts.Feed == nil || order.CustomerID == "" {
+		return nil
+	}
+	err := s.opts.Feed.Add(ctx, order.CustomerID, &models.FeedNotification{
+		Kind:    kind,
+		Title:   title,
+		Body:    body,
+		OrderID: order.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add order %s to the notification feed: %w", order.ID, err)
+	}
+	return nil
+}
+
+func (s *notificationService) Feed(ctx context.Context, customerID string, unreadOnly bool, page models.PageRequest) (*models.NotificationFeed, error) {
+	page = page.Normalize()
+	feed := &models.NotificationFeed{Notifications: []models.FeedNotification{}, Limit: page.Limit, Offset: page.Offset}
+	if s.opts.Feed == nil {
+		return feed, nil
+	}
+
+	notifications, total, err := s.opts.Feed.FindPage(ctx, customerID, unreadOnly, page)
+	if err != nil {
+		return nil, err
+	}
+	unread, err := s.opts.Feed.CountUnread(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	if notifications != nil {
+		feed.Notifications = notifications
+	}
+	feed.Total = total
+	feed.Unread = unread
+	return feed, nil
+}
+
+func (s *notificationService) MarkRead(ctx context.Context, customerID, id string) error {
+	if s.opts.Feed == nil {
+		return ErrFeedNotificationNotFound
+	}
+	err := s.opts.Feed.MarkRead(ctx, customerID, id)
+	if err != nil && err.Error() == "feed notification not found" {
+		return ErrFeedNotificationNotFound
+	}
+	return err
+}
+
+func (s *notificationService) MarkAllRead(ctx context.Context, customerID string) (int, error) {
+	if s.opts.Feed == nil {
+		return 0, nil
+	}
+	return s.opts.Feed.MarkAllRead(ctx, customerID)
+}
+
+// shortOrderID is the first block of the order's UUID, enough for customers to tell orders apart
+func shortOrderID(id string) string {
+	short, _, _ := strings.Cut(id, "-")
+	return strings.ToUpper(short)
+}
@@ -0,0 +1,165 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"oolio/internal/app/models"
+)
+
+var (
+	ErrInvalidOpeningHours = errors.New("invalid opening hours")
+	ErrInvalidSchedule     = errors.New("orders can be scheduled from now up to 30 days ahead")
+)
+
+// maxScheduleAhead is how far ahead customers can schedule an order
+const maxScheduleAhead = 30 * 24 * time.Hour
+
+// openingHoursHorizon bounds the search for a store's next opening; a year and a week
+// reaches past any holiday listed for a date
+const openingHoursHorizon = 372
+
+// StoreClosedError refuses an order placed, or scheduled, for a time the store is closed
+type StoreClosedError struct {
+	NextOpenAt *time.Time // nil when the store has no opening hours within a year
+}
+
+func (e *StoreClosedError) Error() string {
+	if e.NextOpenAt == nil {
+		return "the store is closed"
+	}
+	return "the store is closed until " + e.NextOpenAt.Format(time.RFC3339)
+}
+
+// openPeriod is one span the store is open for, placed on a calendar date
+type openPeriod struct {
+	opens, closes time.Time
+}
+
+// validateOpeningHours checks the hours and fills in the default for OutsideHours
+func validateOpeningHours(hours *models.OpeningHours) error {
+	if _, err := time.LoadLocation(hours.TimeZone); hours.TimeZone == "" || hours.TimeZone == "Local" || err != nil {
+		return fmt.Errorf("%w: unknown time zone %q", ErrInvalidOpeningHours, hours.TimeZone)
+	}
+	switch hours.OutsideHours {
+	case "":
+		hours.OutsideHours = models.OutsideHoursReject
+	case models.OutsideHoursReject, models.OutsideHoursSchedule:
+	default:
+		return fmt.Errorf("%w: outsideHours is reject or schedule", ErrInvalidOpeningHours)
+	}
+
+	for i, period := range hours.Weekly {
+		day := strings.ToLower(period.Day)
+		if !slices.ContainsFunc(weekdays, func(d time.Weekday) bool { return weekdayName(d) == day }) {
+			return fmt.Errorf("%w: unknown day %q", ErrInvalidOpeningHours, period.Day)
+		}
+		hours.Weekly[i].Day = day
+		if !validClock(period.Opens) || !validClock(period.Closes) {
+			return fmt.Errorf("%w: %s opens and closes at HH:MM", ErrInvalidOpeningHours, day)
+		}
+	}
+
+	closedAllDay := make(map[string]bool)
+	withHours := make(map[string]bool)
+	for _, holiday := range hours.Holidays {
+		if _, err := time.Parse(time.DateOnly, holiday.Date); err != nil {
+			return fmt.Errorf("%w: holiday dates are YYYY-MM-DD", ErrInvalidOpeningHours)
+		}
+		if holiday.Opens == "" && holiday.Closes == "" {
+			closedAllDay[holiday.Date] = true
+		} else if validClock(holiday.Opens) && validClock(holiday.Closes) {
+			withHours[holiday.Date] = true
+		} else {
+			return fmt.Errorf("%w: %s opens and closes at HH:MM", ErrInvalidOpeningHours, holiday.Date)
+		}
+		if closedAllDay[holiday.Date] && withHours[holiday.Date] {
+			return fmt.Errorf("%w: %s is both closed all day and open", ErrInvalidOpeningHours, holiday.Date)
+		}
+	}
+	return nil
+}
+
+var weekdays = []time.Weekday{time.Sunday, time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday}
+
+func weekdayName(day time.Weekday) string {
+	return strings.ToLower(day.String())
+}
+
+func validClock(clock string) bool {
+	_, err := time.Parse("15:04", clock)
+	return err == nil && len(clock) == len("15:04")
+}
+
+// nextOpening returns at when the store is open then, or else the next time it opens. It
+// reports false when the store doesn't open within the horizon.
+func nextOpening(hours *models.OpeningHours, at time.Time) (time.Time, bool) {
+	location, err := time.LoadLocation(hours.TimeZone)
+	if err != nil {
+		location = time.UTC
+	}
+	local := at.In(location)
+	today := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, location)
+
+	// Yesterday's periods may still be open past midnight. Periods of later days open
+	// after those of earlier ones, so the first day with a later opening has the next one.
+	for offset := -1; offset <= openingHoursHorizon; offset++ {
+		var next *time.Time
+		for _, period := range openPeriodsOn(hours, today.AddDate(0, 0, offset)) {
+			if !local.Before(period.opens) && local.Before(period.closes) {
+				return at, true
+			}
+			if period.opens.After(local) && (next == nil || period.opens.Before(*next)) {
+				next = &period.opens
+			}
+		}
+		if next != nil && offset >= 0 {
+			return *next, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// openPeriodsOn returns the periods that open on the date: its holiday hours when it is
+// a holiday, its weekday's hours otherwise
+func openPeriodsOn(hours *models.OpeningHours, date time.Time) []openPeriod {
+	key := date.Format(time.DateOnly)
+	var periods []openPeriod
+	holiday := false
+	for _, h := range hours.Holidays {
+		if h.Date != key {
+			continue
+		}
+		holiday = true
+		if h.Opens != "" {
+			periods = append(periods, placePeriod(date, h.Opens, h.Closes))
+		}
+	}
+	if holiday {
+		return periods
+	}
+
+	day := weekdayName(date.Weekday())
+	for _, p := range hours.Weekly {
+		if p.Day == day {
+			periods = append(periods, placePeriod(date, p.Opens, p.Closes))
+		}
+	}
+	return periods
+}
+
+func placePeriod(date time.Time, opens, closes string) openPeriod {
+	period := openPeriod{opens: atClock(date, opens), closes: atClock(date, closes)}
+	if !period.closes.After(period.opens) {
+		period.closes = atClock(date.AddDate(0, 0, 1), closes)
+	}
+	return period
+}
+
+func atClock(date time.Time, clock string) time.Time {
+	t, _ := time.Parse("15:04", clock)
+	return time.Date(date.Year(), date.Month(), date.Day(), t.Hour(), t.Minute(), 0, 0, date.Location())
+}
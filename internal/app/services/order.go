@@ -2,78 +2,349 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
 
 	"oolio/internal/app/models"
 	"oolio/internal/app/repository"
 )
 
 type OrderService interface {
+	// CreateOrder creates the order and captures its payment, if it has one. An order whose
+	// payment failed to capture stands, and is returned with an error wrapping
+	// ErrPaymentCaptureFailed. Orders of more than is left of a product fail with
+	// ErrOutOfStock. Sandbox orders are priced alike but take no payment or stock and use
+	// up neither their coupon nor their gift card.
 	CreateOrder(ctx context.Context, orderReq *models.OrderReq) (*models.Order, error)
+	// CapturePayment captures the payment of a created order again, after it failed to.
+	// Payments the provider already took are only recorded as captured. Failures wrap
+	// ErrPaymentCaptureFailed.
+	CapturePayment(ctx context.Context, order *models.Order) error
+	// QuoteOrder prices an order as CreateOrder would, without creating it or using up
+	// its coupon
+	QuoteOrder(ctx context.Context, orderReq *models.OrderReq) (*models.Order, error)
 	GetOrder(ctx context.Context, id string) (*models.Order, error)
+	// ListOrders returns one page of orders, newest first, with their items and products
+	// and the total number of orders
+	ListOrders(ctx context.Context, page models.PageRequest) ([]models.Order, int, error)
+	// DeleteOrder deletes an order, given its order or queue item ID, on behalf of actor
+	DeleteOrder(ctx context.Context, id string, actor string) error
+	// PaymentMethodReport sums the orders placed since, per payment method, leaving out
+	// cancelled and failed orders
+	PaymentMethodReport(ctx context.Context, since time.Time) ([]models.PaymentMethodTotal, error)
+	// RefundOrder refunds amount of the order's captured payment, or the rest of it when
+	// zero, through the payment provider on behalf of actor. The refund is recorded as
+	// requested first, then processed or failed; a failed refund is returned with the error.
+	RefundOrder(ctx context.Context, id string, amount models.Money, actor string) (*models.OrderRefund, error)
+	GetRefunds(ctx context.Context, id string) ([]models.OrderRefund, error)
 }
 
+// ErrOrderNotDeletable is returned by DeleteOrder for orders that are neither cancelled nor failed
+var ErrOrderNotDeletable = errors.New("only cancelled or failed orders can be deleted")
+
+var (
+	ErrOrderNotRefundable   = errors.New("order has no captured payment to refund")
+	ErrRefundExceedsPayment = errors.New("refund is more than is left of the payment")
+)
+
+// ErrInvalidOption is returned when an order item names a variant or modifier its product
+// doesn't have, or a modifier twice
+var ErrInvalidOption = errors.New("invalid product option")
+
 type orderService struct {
 	orderRepo     repository.OrderRepository
 	productRepo   repository.ProductRepository
+	queueRepo     repository.OrderQueueRepository
 	couponService CouponService
+	segments      SegmentService          // Optional; without it segment-restricted coupons are not checked
+	pricing       PricingService          // Optional; without it orders are charged list prices
+	redemptions   CouponRedemptionService // Optional; without it single-use coupons can be reused
+	payments      PaymentService          // Optional; without it orders naming a payment intent fail
+	giftCards     GiftCardService         // Optional; without it orders naming a gift card fail
+	currencies    CurrencyConverter       // Optional; without it orders naming a currency fail
+	inventory     InventoryService        // Optional; without it stock isn't counted
+	auditLogger   *zap.Logger
 }
 
-func NewOrderService(orderRepo repository.OrderRepository, productRepo repository.ProductRepository, couponService CouponService) OrderService {
+func NewOrderService(orderRepo repository.OrderRepository, productRepo repository.ProductRepository, queueRepo repository.OrderQueueRepository, couponService CouponService, segments SegmentService, pricing PricingService, redemptions CouponRedemptionService, payments PaymentService, giftCards GiftCardService, currencies CurrencyConverter, inventory InventoryService, auditLogger *zap.Logger) OrderService {
 	return &orderService{
 		orderRepo:     orderRepo,
 		productRepo:   productRepo,
+		queueRepo:     queueRepo,
 		couponService: couponService,
+		segments:      segments,
+		pricing:       pricing,
+		redemptions:   redemptions,
+		payments:      payments,
+		giftCards:     giftCards,
+		currencies:    currencies,
+		inventory:     inventory,
+		auditLogger:   auditLogger,
 	}
 }
 
 func (s *orderService) CreateOrder(ctx context.Context, orderReq *models.OrderReq) (*models.Order, error) {
-	if err := s.validateOrderReq(orderReq); err != nil {
-		return nil, fmt.Errorf("order validation failed: %w", err)
+	order, err := s.QuoteOrder(ctx, orderReq)
+	if err != nil {
+		return nil, err
 	}
 
-	// Get products for all items in the order
-	productIDs := make([]string, len(orderReq.Items))
-	for i, item := range orderReq.Items {
-		productIDs[i] = item.ProductID
+	if order.Sandbox {
+		if err := s.orderRepo.Create(ctx, order); err != nil {
+			return nil, fmt.Errorf("failed to create order: %w", err)
+		}
+		return order, nil
+	}
+
+	// Only authorized payments are taken; the money is captured once the order exists.
+	// An order paid in full from a gift card has nothing to take.
+	due := order.AmountDue()
+	if orderReq.PaymentIntentID != "" && due > 0 {
+		if err := s.checkPayment(ctx, orderReq.PaymentIntentID, due); err != nil {
+			return nil, fmt.Errorf("failed to verify payment: %w", err)
+		}
+		order.PaymentIntentID = orderReq.PaymentIntentID
+		order.PaymentStatus = models.OrderPaymentAuthorized
 	}
 
-	products, err := s.getProductsForOrder(ctx, productIDs)
+	// The coupon was unused when the order was queued, but so it may have been for
+	// another order queued alongside; recording the redemption decides between them
+	var redeemed bool
+	if orderReq.CouponCode != "" && s.redemptions != nil {
+		redeemed, err = s.redemptions.Redeem(ctx, orderReq)
+		if err != nil {
+			return nil, fmt.Errorf("failed to apply discount: %w", err)
+		}
+	}
+
+	// The store credit is spent before the order exists, like the coupon, and given back
+	// if it can't be created
+	if order.StoreCredit > 0 {
+		if err := s.giftCards.Redeem(ctx, order.GiftCardCode, order.StoreCredit); err != nil {
+			err = fmt.Errorf("failed to apply gift card: %w", err)
+			if redeemed {
+				if releaseErr := s.redemptions.Release(ctx, orderReq); releaseErr != nil {
+					return nil, fmt.Errorf("%w (coupon redemption not released: %v)", err, releaseErr)
+				}
+			}
+			return nil, err
+		}
+	}
+
+	// Stock is taken last, with what was reserved for the order when it was queued, so an
+	// order short of it gives back its coupon and store credit as one that couldn't be
+	// created does
+	if s.inventory != nil {
+		if err := s.inventory.Take(ctx, orderReq.StockReservation, order); err != nil {
+			return nil, s.giveBack(ctx, orderReq, order, redeemed, err)
+		}
+	}
+
+	err = s.orderRepo.Create(ctx, order)
+	if err != nil {
+		err = fmt.Errorf("failed to create order: %w", err)
+		// The reservation went with the stock taken, so a retry takes from what is left
+		if s.inventory != nil {
+			if returnErr := s.inventory.Return(ctx, order); returnErr != nil {
+				err = fmt.Errorf("%w (stock not returned: %v)", err, returnErr)
+			}
+		}
+		return nil, s.giveBack(ctx, orderReq, order, redeemed, err)
+	}
+
+	if order.PaymentIntentID != "" {
+		if err := s.capturePayment(ctx, order, due); err != nil {
+			return order, err
+		}
+	}
+
+	return order, nil
+}
+
+// giveBack releases the coupon redemption, when redeemed, and the store credit spent on
+// an order that won't be created, and returns err noting whatever couldn't be given back
+func (s *orderService) giveBack(ctx context.Context, orderReq *models.OrderReq, order *models.Order, redeemed bool, err error) error {
+	if redeemed {
+		if releaseErr := s.redemptions.Release(ctx, orderReq); releaseErr != nil {
+			err = fmt.Errorf("%w (coupon redemption not released: %v)", err, releaseErr)
+		}
+	}
+	if order.StoreCredit > 0 {
+		if releaseErr := s.giftCards.Release(ctx, order.GiftCardCode, order.StoreCredit); releaseErr != nil {
+			err = fmt.Errorf("%w (store credit of %s not given back to gift card %s: %v)", err, order.StoreCredit, order.GiftCardCode, releaseErr)
+		}
+	}
+	return err
+}
+
+func (s *orderService) CapturePayment(ctx context.Context, order *models.Order) error {
+	if order.PaymentIntentID == "" || order.PaymentStatus == models.OrderPaymentCaptured {
+		return nil
+	}
+	if s.payments == nil {
+		return fmt.Errorf("%w: payments are not enabled", ErrPaymentCaptureFailed)
+	}
+
+	// An earlier attempt may have taken the money without hearing back
+	intent, err := s.payments.GetIntent(ctx, order.PaymentIntentID)
+	if err == nil && intent.Status == models.PaymentStatusSucceeded {
+		s.recordCapture(ctx, order, intent.AmountCaptured)
+		return nil
+	}
+	return s.capturePayment(ctx, order, order.AmountDue())
+}
+
+func (s *orderService) QuoteOrder(ctx context.Context, orderReq *models.OrderReq) (*models.Order, error) {
+	if err := s.validateOrderReq(orderReq); err != nil {
+		return nil, fmt.Errorf("order validation failed: %w", err)
+	}
+
+	// Get products for all items in the order in one query
+	products, err := s.getProductsForOrder(ctx, orderReq.ProductIDs(), models.StoreOrDefault(orderReq.StoreID))
 	if err != nil {
 		return nil, fmt.Errorf("failed to get products for order: %w", err)
 	}
 
-	// Calculate order total
-	total, err := s.calculateOrderTotal(orderReq.Items, products)
+	tier := models.PricingTier{Name: models.TierRetail}
+	if s.pricing != nil {
+		found, err := s.pricing.Tier(ctx, orderReq.PricingTier)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get pricing tier: %w", err)
+		}
+		tier = *found
+	}
+
+	// Calculate order total at the tier's prices
+	items, total, err := s.calculateOrderTotal(orderReq.Items, products, tier)
 	if err != nil {
 		return nil, fmt.Errorf("failed to calculate order total: %w", err)
 	}
 
 	// Apply discount if coupon code provided
-	discounts := 0.0
+	var discounts models.Money
+	var couponCode string
 	if orderReq.CouponCode != "" {
-		discounts, err = s.applyDiscount(total, orderReq.CouponCode)
+		discounts, err = s.applyDiscount(ctx, total, orderReq)
 		if err != nil {
 			return nil, fmt.Errorf("failed to apply discount: %w", err)
 		}
+		couponCode = strings.ToUpper(orderReq.CouponCode)
+	}
+
+	// Store credit pays for what the coupon leaves, alongside the payment method
+	var storeCredit models.Money
+	giftCardCode := NormalizeGiftCardCode(orderReq.GiftCardCode)
+	if giftCardCode != "" {
+		if s.giftCards == nil {
+			return nil, fmt.Errorf("failed to apply gift card: gift cards are not enabled")
+		}
+		storeCredit, err = s.giftCards.Apply(ctx, giftCardCode, total-discounts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to apply gift card: %w", err)
+		}
+	}
+
+	// Orders queued before payment methods existed were paid by card
+	paymentMethod := orderReq.PaymentMethod
+	if paymentMethod == "" {
+		paymentMethod = models.PaymentMethodCard
 	}
 
-	// Create order
 	order := &models.Order{
-		Total:     total,
-		Discounts: discounts,
-		Items:     orderReq.Items,
-		Products:  products,
+		Total:         total,
+		Discounts:     discounts,
+		Items:         items,
+		Products:      products,
+		CustomerID:    orderReq.CustomerID,
+		StoreID:       models.StoreOrDefault(orderReq.StoreID),
+		Sandbox:       orderReq.Sandbox,
+		PaymentMethod: paymentMethod,
+		StoreCredit:   storeCredit,
+		GiftCardCode:  giftCardCode,
+		CouponCode:    couponCode,
 	}
 
-	err = s.orderRepo.Create(ctx, order)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create order: %w", err)
+	// The order is still paid in the payment currency; partners are told what that comes
+	// to in the one they settle in
+	if orderReq.Currency != "" {
+		if s.currencies == nil {
+			return nil, fmt.Errorf("failed to convert total: %w", ErrUnsupportedCurrency)
+		}
+		order.Settlement, err = s.currencies.Settle(ctx, order.AmountDue(), orderReq.Currency)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert total: %w", err)
+		}
 	}
 
 	return order, nil
 }
 
+func (s *orderService) PaymentMethodReport(ctx context.Context, since time.Time) ([]models.PaymentMethodTotal, error) {
+	totals, err := s.orderRepo.PaymentMethodTotals(ctx, since, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payment method report: %w", err)
+	}
+	return totals, nil
+}
+
+// checkPayment makes sure the intent holds at least the amount due
+func (s *orderService) checkPayment(ctx context.Context, intentID string, due models.Money) error {
+	if s.payments == nil {
+		return fmt.Errorf("payments are not enabled")
+	}
+
+	intent, err := s.payments.GetIntent(ctx, intentID)
+	if err != nil {
+		return err
+	}
+	if !intent.Authorized() || intent.Amount < due {
+		return ErrPaymentNotAuthorized
+	}
+	return nil
+}
+
+// capturePayment takes the amount due for a created order and marks its payment captured.
+// The order stands either way: an authorization that failed to capture stays authorized,
+// for the queue to retry, and the order isn't fulfilled until it is captured.
+func (s *orderService) capturePayment(ctx context.Context, order *models.Order, due models.Money) error {
+	if _, err := s.payments.Capture(ctx, order.PaymentIntentID, due); err != nil {
+		s.auditLogger.Error("Payment capture failed",
+			zap.String("orderId", order.ID),
+			zap.String("paymentIntentId", order.PaymentIntentID),
+			zap.Stringer("amount", due),
+			zap.Error(err))
+		return fmt.Errorf("%w: %w", ErrPaymentCaptureFailed, err)
+	}
+
+	s.recordCapture(ctx, order, due)
+	return nil
+}
+
+// recordCapture marks the order's payment captured once the money is taken
+func (s *orderService) recordCapture(ctx context.Context, order *models.Order, amount models.Money) {
+	// The money is taken, so the order is paid for even if the status isn't saved
+	order.PaymentStatus = models.OrderPaymentCaptured
+	if err := s.orderRepo.SetPaymentStatus(ctx, order.ID, models.OrderPaymentCaptured); err != nil {
+		s.auditLogger.Error("Payment captured but not recorded",
+			zap.String("orderId", order.ID),
+			zap.String("paymentIntentId", order.PaymentIntentID),
+			zap.Stringer("amount", amount),
+			zap.Error(err))
+		return
+	}
+
+	s.auditLogger.Info("Payment captured",
+		zap.String("orderId", order.ID),
+		zap.String("paymentIntentId", order.PaymentIntentID),
+		zap.Stringer("amount", amount))
+}
+
 func (s *orderService) GetOrder(ctx context.Context, id string) (*models.Order, error) {
 	if id == "" {
 		return nil, fmt.Errorf("order ID cannot be empty")
@@ -87,6 +358,163 @@ func (s *orderService) GetOrder(ctx context.Context, id string) (*models.Order,
 	return order, nil
 }
 
+func (s *orderService) ListOrders(ctx context.Context, page models.PageRequest) ([]models.Order, int, error) {
+	orders, total, err := s.orderRepo.FindPage(ctx, page)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list orders: %w", err)
+	}
+	return orders, total, nil
+}
+
+// DeleteOrder removes a cancelled or failed order with its items. Orders that never got past
+// the queue can be deleted once their queue item has failed for good. Either way the queue
+// items holding the order are anonymized rather than deleted, so queue stats stay intact.
+func (s *orderService) DeleteOrder(ctx context.Context, id string, actor string) error {
+	if id == "" {
+		return fmt.Errorf("order ID cannot be empty")
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return fmt.Errorf("order not found")
+	}
+
+	var status string
+	order, err := s.orderRepo.FindOne(ctx, id)
+	if err == nil {
+		if order.Status != models.OrderStatusCancelled && order.Status != models.OrderStatusFailed {
+			return ErrOrderNotDeletable
+		}
+		if err := s.orderRepo.Delete(ctx, id); err != nil {
+			return fmt.Errorf("failed to delete order %s: %w", id, err)
+		}
+		status = order.Status
+	} else {
+		if err.Error() != "order not found" {
+			return fmt.Errorf("failed to get order by ID %s: %w", id, err)
+		}
+
+		item, err := s.queueRepo.GetOrderFromQueue(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to get order by ID %s: %w", id, err)
+		}
+		if item.Status != "failed" || item.RetryCount < maxQueueRetries {
+			return ErrOrderNotDeletable
+		}
+		status = item.Status
+	}
+
+	anonymized, err := s.queueRepo.Anonymize(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to anonymize queued order %s: %w", id, err)
+	}
+
+	s.auditLogger.Info("Order deleted",
+		zap.String("orderId", id),
+		zap.String("status", status),
+		zap.String("actor", actor),
+		zap.Int("queueItemsAnonymized", anonymized))
+	return nil
+}
+
+func (s *orderService) RefundOrder(ctx context.Context, id string, amount models.Money, actor string) (*models.OrderRefund, error) {
+	if s.payments == nil {
+		return nil, fmt.Errorf("payments are not enabled")
+	}
+
+	order, err := s.GetOrder(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if order.PaymentIntentID == "" || (order.PaymentStatus != models.OrderPaymentCaptured && order.PaymentStatus != models.OrderPaymentRefunded) {
+		return nil, ErrOrderNotRefundable
+	}
+
+	refunds, err := s.orderRepo.GetRefunds(ctx, order.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get refunds of order %s: %w", order.ID, err)
+	}
+	// Refunds still requested may yet go through, so they count against the payment
+	remaining := order.AmountDue()
+	for _, refund := range refunds {
+		if refund.Status != models.RefundStatusFailed {
+			remaining -= refund.Amount
+		}
+	}
+	if amount == 0 {
+		amount = remaining
+	}
+	if remaining <= 0 || amount > remaining {
+		return nil, fmt.Errorf("%w: %s is left to refund", ErrRefundExceedsPayment, max(remaining, 0))
+	}
+
+	refund := &models.OrderRefund{
+		OrderID:         order.ID,
+		PaymentIntentID: order.PaymentIntentID,
+		Amount:          amount,
+	}
+	if err := s.orderRepo.CreateRefund(ctx, refund); err != nil {
+		return nil, fmt.Errorf("failed to record refund of order %s: %w", order.ID, err)
+	}
+
+	result, err := s.payments.Refund(ctx, order.PaymentIntentID, amount)
+	if err != nil {
+		refund.Status = models.RefundStatusFailed
+		refund.Error = err.Error()
+		if updateErr := s.orderRepo.UpdateRefund(ctx, refund); updateErr != nil {
+			s.auditLogger.Error("Refund failed but not recorded",
+				zap.String("orderId", order.ID),
+				zap.String("refundId", refund.ID),
+				zap.Error(updateErr))
+		}
+		s.auditLogger.Warn("Refund failed",
+			zap.String("orderId", order.ID),
+			zap.String("paymentIntentId", order.PaymentIntentID),
+			zap.Stringer("amount", amount),
+			zap.String("actor", actor),
+			zap.Error(err))
+		return refund, fmt.Errorf("failed to refund order %s: %w", order.ID, err)
+	}
+
+	// The money is returned, so the refund stands even if it isn't recorded as processed
+	refund.Status = models.RefundStatusProcessed
+	refund.ProviderRefundID = result.ID
+	if err := s.orderRepo.UpdateRefund(ctx, refund); err != nil {
+		s.auditLogger.Error("Refund processed but not recorded",
+			zap.String("orderId", order.ID),
+			zap.String("refundId", refund.ID),
+			zap.String("providerRefundId", result.ID),
+			zap.Error(err))
+	}
+	if amount == remaining {
+		if err := s.orderRepo.SetPaymentStatus(ctx, order.ID, models.OrderPaymentRefunded); err != nil {
+			s.auditLogger.Error("Payment refunded but not recorded",
+				zap.String("orderId", order.ID),
+				zap.String("paymentIntentId", order.PaymentIntentID),
+				zap.Error(err))
+		}
+	}
+
+	s.auditLogger.Info("Order refunded",
+		zap.String("orderId", order.ID),
+		zap.String("paymentIntentId", order.PaymentIntentID),
+		zap.String("providerRefundId", result.ID),
+		zap.Stringer("amount", amount),
+		zap.String("actor", actor))
+	return refund, nil
+}
+
+func (s *orderService) GetRefunds(ctx context.Context, id string) ([]models.OrderRefund, error) {
+	order, err := s.GetOrder(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	refunds, err := s.orderRepo.GetRefunds(ctx, order.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get refunds of order %s: %w", order.ID, err)
+	}
+	return refunds, nil
+}
+
 func (s *orderService) validateOrderReq(orderReq *models.OrderReq) error {
 	if orderReq == nil {
 		return fmt.Errorf("order request cannot be nil")
@@ -108,50 +536,122 @@ func (s *orderService) validateOrderReq(orderReq *models.OrderReq) error {
 	return nil
 }
 
-func (s *orderService) getProductsForOrder(ctx context.Context, productIDs []string) ([]models.Product, error) {
-	products := make([]models.Product, 0, len(productIDs))
+// getProductsForOrder loads the products of the order; those of other stores are not found
+func (s *orderService) getProductsForOrder(ctx context.Context, productIDs []string, storeID string) ([]models.Product, error) {
+	products, err := s.productRepo.FindByIDs(ctx, productIDs)
+	if err != nil {
+		return nil, err
+	}
 
+	found := make(map[string]bool, len(products))
+	for _, product := range products {
+		found[product.ID] = models.StoreOrDefault(product.StoreID) == storeID
+	}
 	for _, productID := range productIDs {
-		product, err := s.productRepo.FindOne(ctx, productID)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get product %s: %w", productID, err)
+		if !found[productID] {
+			return nil, fmt.Errorf("failed to get product %s: product not found", productID)
 		}
-		products = append(products, *product)
 	}
 
 	return products, nil
 }
 
-func (s *orderService) calculateOrderTotal(items []models.OrderItem, products []models.Product) (float64, error) {
-	productPrices := make(map[string]float64)
+// calculateOrderTotal returns the items with the unit price charged for each, with the
+// options chosen, and their total. The tier discounts the product and each option apart,
+// so the options recorded add up to the price charged.
+func (s *orderService) calculateOrderTotal(items []models.OrderItem, products []models.Product, tier models.PricingTier) ([]models.OrderItem, models.Money, error) {
+	byID := make(map[string]models.Product, len(products))
 	for _, product := range products {
-		productPrices[product.ID] = product.Price
+		byID[product.ID] = product
 	}
 
-	total := 0.0
-	for _, item := range items {
-		price, exists := productPrices[item.ProductID]
+	priced := make([]models.OrderItem, len(items))
+	var total models.Money
+	for i, item := range items {
+		product, exists := byID[item.ProductID]
 		if !exists {
-			return 0, fmt.Errorf("product %s not found in order items", item.ProductID)
+			return nil, 0, fmt.Errorf("product %s not found in order items", item.ProductID)
+		}
+
+		options, err := chosenOptions(item, product)
+		if err != nil {
+			return nil, 0, err
+		}
+		price := tier.Price(product.Price)
+		for j := range options {
+			options[j].PriceDelta = tier.Price(options[j].PriceDelta)
+			price += options[j].PriceDelta
 		}
 
-		itemTotal := price * float64(item.Quantity)
-		total += itemTotal
+		item.Price = price
+		item.Options = options
+		priced[i] = item
+		total += item.Price.Mul(item.Quantity)
 	}
 
-	return total, nil
+	return priced, total, nil
 }
 
-func (s *orderService) applyDiscount(total float64, couponCode string) (float64, error) {
-	if !s.couponService.ValidateCoupon(couponCode) {
+// chosenOptions returns the variant and modifiers of the product the item chose. Items of
+// a product with variants that name none get its first, so orders placed before it had
+// any still go through.
+func chosenOptions(item models.OrderItem, product models.Product) ([]models.OrderItemOption, error) {
+	var options []models.OrderItemOption
+	switch {
+	case item.VariantID != "":
+		i := slices.IndexFunc(product.Variants, func(option models.ProductOption) bool { return option.ID == item.VariantID })
+		if i < 0 {
+			return nil, fmt.Errorf("%w: variant %s of %s not found", ErrInvalidOption, item.VariantID, product.Name)
+		}
+		options = append(options, orderItemOption(models.OptionVariant, product.Variants[i]))
+	case len(product.Variants) > 0:
+		options = append(options, orderItemOption(models.OptionVariant, product.Variants[0]))
+	}
+
+	chosen := make(map[string]bool, len(item.ModifierIDs))
+	for _, id := range item.ModifierIDs {
+		i := slices.IndexFunc(product.Modifiers, func(option models.ProductOption) bool { return option.ID == id })
+		switch {
+		case i < 0:
+			return nil, fmt.Errorf("%w: modifier %s of %s not found", ErrInvalidOption, id, product.Name)
+		case chosen[id]:
+			return nil, fmt.Errorf("%w: modifier %s of %s is chosen twice", ErrInvalidOption, product.Modifiers[i].Name, product.Name)
+		}
+		chosen[id] = true
+		options = append(options, orderItemOption(models.OptionModifier, product.Modifiers[i]))
+	}
+	return options, nil
+}
+
+func orderItemOption(kind string, option models.ProductOption) models.OrderItemOption {
+	return models.OrderItemOption{ID: option.ID, Kind: kind, Name: option.Name, PriceDelta: option.PriceDelta}
+}
+
+func (s *orderService) applyDiscount(ctx context.Context, total models.Money, orderReq *models.OrderReq) (models.Money, error) {
+	couponCode, customerID := orderReq.CouponCode, orderReq.CustomerID
+	coupon, ok := s.couponService.ResolveCoupon(couponCode)
+	if !ok {
+		return 0, fmt.Errorf("invalid coupon code: %s", couponCode)
+	}
+	// Coupons of another store are as unknown here as any other invalid code
+	if coupon.StoreID != "" && coupon.StoreID != models.StoreOrDefault(orderReq.StoreID) {
 		return 0, fmt.Errorf("invalid coupon code: %s", couponCode)
 	}
 
-	discountPercentage := s.couponService.GetDiscountPercentage(couponCode)
+	if coupon.Segment != "" && s.segments != nil {
+		segment, err := s.segments.SegmentFor(ctx, customerID)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get customer segment: %w", err)
+		}
+		if segment != coupon.Segment {
+			return 0, fmt.Errorf("coupon %s is not available to this customer", couponCode)
+		}
+	}
+
+	discountPercentage := coupon.Discount
 	if discountPercentage <= 0 || discountPercentage > 100 {
 		return 0, fmt.Errorf("invalid discount percentage: %f", discountPercentage)
 	}
 
-	discount := (total * discountPercentage) / 100
-	return discount, nil
+	return total.Percent(discountPercentage), nil
 }
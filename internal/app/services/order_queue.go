@@ -2,36 +2,209 @@ package services
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"slices"
+	"strings"
 	"time"
 
 	"oolio/internal/app/models"
 	"oolio/internal/app/repository"
+	"oolio/internal/workerpool"
 
 	"github.com/google/uuid"
+	"go.uber.org/fx"
 )
 
 type OrderQueueService interface {
+	// AddOrderToQueue queues the order, reserving its items' stock first unless it is
+	// scheduled or a sandbox order. It fails with ErrOutOfStock when one is short.
 	AddOrderToQueue(ctx context.Context, orderReq *models.OrderReq) (*models.OrderQueueItem, error)
 	ProcessBatch(ctx context.Context, batchSize int) (*models.BatchProcessResult, error)
 	GetQueueStatus(ctx context.Context) (map[string]int, error)
+	GetBacklog(ctx context.Context) (*models.QueueBacklog, error)
 	GetCompletedOrders(ctx context.Context) ([]*models.OrderQueueItem, error)
-	StartWorker(ctx context.Context, interval time.Duration, batchSize int)
+	GetOrdersUpdatedSince(ctx context.Context, since time.Time) ([]*models.OrderQueueItem, error)
 	GetOrderFromQueue(ctx context.Context, itemID string) (*models.OrderQueueItem, error)
+	// Inspect returns the item with its request, every failed attempt, the worker instance
+	// that last claimed it and the order it produced. It fails with ErrQueueItemNotFound.
+	Inspect(ctx context.Context, itemID string) (*models.QueueItemDetails, error)
+	// AttachPayment pays for a queued order with an intent, replacing any it had. It fails
+	// with ErrOrderNotPayable once the order is processed or has failed for good.
+	AttachPayment(ctx context.Context, itemID, intentID string) error
+	// Retry puts an order that failed for good back in the queue with a fresh set of
+	// retries. It fails with ErrQueueItemNotFailed for items that haven't failed.
+	Retry(ctx context.Context, itemID string) error
+}
+
+var (
+	ErrOrderNotPayable    = errors.New("order can no longer be paid for")
+	ErrQueueItemNotFailed = errors.New("no failed order with that id")
+	ErrQueueItemNotFound  = errors.New("queue item not found")
+)
+
+// errAwaitingPayment reports an item put back in the queue until its payment is authorized
+var errAwaitingPayment = errors.New("awaiting payment")
+
+const (
+	// maxQueueRetries is how often a failed item is retried before it stays failed
+	maxQueueRetries = 3
+	// Failed items are retried after 5s, 10s, 20s, ... until they run out of retries
+	queueRetryBaseDelay = 5 * time.Second
+	// paymentCheckInterval is how often an order awaiting payment is checked again
+	paymentCheckInterval = 5 * time.Second
+)
+
+// PaymentPolicy decides how orders may be paid for and how long queued orders wait for
+// their payment
+type PaymentPolicy struct {
+	// Required holds card orders back until a payment intent is attached to them
+	Required bool
+	// Window is how long an order waits for its payment to be authorized before it fails
+	// for good; zero waits forever
+	Window time.Duration
+	// Methods are the accepted payment methods, the first being the default; empty
+	// accepts them all, card first
+	Methods []string
+}
+
+// NewPaymentPolicy checks that methods are known payment methods
+func NewPaymentPolicy(required bool, window time.Duration, methods []string) (PaymentPolicy, error) {
+	policy := PaymentPolicy{Required: required, Window: window}
+	for _, method := range methods {
+		method = strings.ToLower(strings.TrimSpace(method))
+		if !slices.Contains(models.PaymentMethods, method) {
+			return PaymentPolicy{}, fmt.Errorf("unknown payment method %q, expected one of %s", method, strings.Join(models.PaymentMethods, ", "))
+		}
+		if !slices.Contains(policy.Methods, method) {
+			policy.Methods = append(policy.Methods, method)
+		}
+	}
+	return policy, nil
+}
+
+// accepted returns the accepted methods, the default first
+func (p PaymentPolicy) accepted() []string {
+	if len(p.Methods) == 0 {
+		return models.PaymentMethods
+	}
+	return p.Methods
+}
+
+// CheckMethod normalizes the order's payment method, an empty one becoming the default,
+// and checks it is accepted and fits the order: cash is paid on delivery, counter when
+// collected, and only card orders take a payment intent. Table orders are paid at the
+// counter when their tab is closed, whatever method they name. Sandbox orders take no
+// payment intent and can't join a table's tab.
+func (p PaymentPolicy) CheckMethod(orderReq *models.OrderReq) error {
+	if orderReq.Sandbox {
+		switch {
+		case orderReq.TableNumber != "":
+			return ErrSandboxTableOrder
+		case orderReq.PaymentIntentID != "":
+			return ErrSandboxPayment
+		}
+	}
+	if orderReq.TableNumber != "" {
+		if orderReq.DeliveryAddress != nil || orderReq.PaymentIntentID != "" {
+			return ErrTableOrderPaidOnTab
+		}
+		orderReq.PaymentMethod = models.PaymentMethodCounter
+		return nil
+	}
+
+	accepted := p.accepted()
+	method := strings.ToLower(strings.TrimSpace(orderReq.PaymentMethod))
+	if method == "" {
+		method = accepted[0]
+	}
+	if !slices.Contains(accepted, method) {
+		return fmt.Errorf("%w: %q, expected one of %s", ErrPaymentMethodNotAccepted, method, strings.Join(accepted, ", "))
+	}
+	orderReq.PaymentMethod = method
+
+	switch {
+	case method == models.PaymentMethodCash && orderReq.DeliveryAddress == nil:
+		return ErrCashNeedsDelivery
+	case method == models.PaymentMethodCounter && orderReq.DeliveryAddress != nil:
+		return ErrCounterNoDelivery
+	case method != models.PaymentMethodCard && orderReq.PaymentIntentID != "":
+		return ErrPaymentIntentNeedsCard
+	}
+	return nil
+}
+
+// AwaitsPayment reports whether the order waits in the queue until it is paid for; cash
+// and counter orders are paid when they are handed over, and sandbox orders never are
+func (p PaymentPolicy) AwaitsPayment(orderReq *models.OrderReq) bool {
+	method := orderReq.PaymentMethod
+	return p.Required && !orderReq.Sandbox && orderReq.PaymentIntentID == "" && (method == "" || method == models.PaymentMethodCard)
 }
 
 type orderQueueService struct {
-	queueRepo repository.OrderQueueRepository
-	orderRepo repository.OrderRepository
-	orderSvc  OrderService
+	queueRepo   repository.OrderQueueRepository
+	orderRepo   repository.OrderRepository
+	orderSvc    OrderService
+	fulfillment FulfillmentProvider
+	deliveries  DeliveryService
+	inventory   InventoryService
+	alerter     Alerter
+	notifier    NotificationService
+	payment     PaymentPolicy
+	retryLinks  *RetryLinks
+	pool        *workerpool.Pool
+	instance    string
 }
 
-func NewOrderQueueService(queueRepo repository.OrderQueueRepository, orderRepo repository.OrderRepository, orderSvc OrderService) OrderQueueService {
+// maxAlertPayload caps the order request quoted in alerts, so a large cart can't push
+// the rest of the alert past the channel's limit
+const maxAlertPayload = 1500
+
+// OrderQueueDeps are what NewOrderQueueService builds the queue service from. fx fills
+// them in by type; tests set only the ones they need. A nil Fulfillment, Deliveries,
+// Inventory, Alerter or Notifier is skipped, and without RetryLinks alerts only say how
+// to requeue failed orders.
+type OrderQueueDeps struct {
+	fx.In
+
+	QueueRepo    repository.OrderQueueRepository
+	OrderRepo    repository.OrderRepository
+	OrderService OrderService
+	Fulfillment  FulfillmentProvider
+	Deliveries   DeliveryService
+	Inventory    InventoryService
+	Alerter      Alerter
+	Notifier     NotificationService
+	Payment      PaymentPolicy
+	RetryLinks   *RetryLinks
+}
+
+// NewOrderQueueService returns the queue service. The items of a batch are processed on
+// pool, one at a time without one, and claimed in the name of instance.
+func NewOrderQueueService(d OrderQueueDeps, pool *workerpool.Pool, instance string) OrderQueueService {
+	fulfillment := d.Fulfillment
+	if fulfillment == nil {
+		fulfillment = NewNoopFulfillmentProvider()
+	}
+	alerter := d.Alerter
+	if alerter == nil {
+		alerter = NewNoopAlerter()
+	}
 	return &orderQueueService{
-		queueRepo: queueRepo,
-		orderRepo: orderRepo,
-		orderSvc:  orderSvc,
+		queueRepo:   d.QueueRepo,
+		orderRepo:   d.OrderRepo,
+		orderSvc:    d.OrderService,
+		fulfillment: fulfillment,
+		deliveries:  d.Deliveries,
+		inventory:   d.Inventory,
+		alerter:     alerter,
+		notifier:    d.Notifier,
+		payment:     d.Payment,
+		retryLinks:  d.RetryLinks,
+		pool:        pool,
+		instance:    instance,
 	}
 }
 
@@ -44,8 +217,20 @@ func (s *orderQueueService) AddOrderToQueue(ctx context.Context, orderReq *model
 		UpdatedAt:  time.Now(),
 		RetryCount: 0,
 	}
+	// Scheduled orders wait in the queue until their time, and take whatever stock is left
+	// then, since a reservation wouldn't last until it
+	if orderReq.ScheduledFor != nil {
+		item.NextAttemptAt = *orderReq.ScheduledFor
+	}
+	item.OrderReq.StockReservation = ""
+	if s.inventory != nil && orderReq.ScheduledFor == nil && !orderReq.Sandbox {
+		if err := s.inventory.Reserve(ctx, &item.OrderReq); err != nil {
+			return nil, err
+		}
+	}
 
 	if err := s.queueRepo.AddToQueue(ctx, item); err != nil {
+		s.releaseStock(ctx, item)
 		return nil, fmt.Errorf("failed to add order to queue: %w", err)
 	}
 
@@ -74,8 +259,17 @@ func (s *orderQueueService) ProcessBatch(ctx context.Context, batchSize int) (*m
 		Items:     make([]models.OrderQueueItem, 0, len(items)),
 	}
 
-	for _, item := range items {
-		if err := s.processQueueItem(ctx, item); err != nil {
+	errs := s.pool.Each(ctx, len(items), func(ctx context.Context, i int) error {
+		return s.processQueueItem(ctx, items[i])
+	})
+	for i, item := range items {
+		if err := errs[i]; errors.Is(err, errAwaitingPayment) {
+			result.AwaitingPayment++
+		} else if errors.Is(err, ErrPaymentCaptureFailed) {
+			log.Printf("Failed to capture payment of queue item %s: %v", item.ID, err)
+			result.PaymentFailed++
+			result.Errors = append(result.Errors, fmt.Sprintf("Item %s: %v", item.ID, err))
+		} else if err != nil {
 			log.Printf("Failed to process queue item %s: %v", item.ID, err)
 			result.Failed++
 			result.Errors = append(result.Errors, fmt.Sprintf("Item %s: %v", item.ID, err))
@@ -89,6 +283,21 @@ func (s *orderQueueService) ProcessBatch(ctx context.Context, batchSize int) (*m
 }
 
 func (s *orderQueueService) processQueueItem(ctx context.Context, item *models.OrderQueueItem) error {
+	if err := s.queueRepo.Claim(ctx, item.ID, s.instance); err != nil {
+		return fmt.Errorf("failed to claim item: %w", err)
+	}
+	claimedAt := time.Now()
+	item.ClaimedBy, item.ClaimedAt = s.instance, &claimedAt
+
+	// The order of an item whose capture failed already exists; only the payment is retried
+	if item.Order != nil && item.Order.ID != "" {
+		return s.retryCapture(ctx, item)
+	}
+
+	if s.payment.AwaitsPayment(&item.OrderReq) && !s.paidByGiftCard(ctx, &item.OrderReq) {
+		return s.awaitPayment(ctx, item, ErrPaymentRequired)
+	}
+
 	item.Status = "processing"
 	item.UpdatedAt = time.Now()
 
@@ -97,84 +306,340 @@ func (s *orderQueueService) processQueueItem(ctx context.Context, item *models.O
 	}
 
 	order, err := s.orderSvc.CreateOrder(ctx, &item.OrderReq)
+	if errors.Is(err, ErrPaymentNotAuthorized) {
+		// The customer may still be confirming the payment
+		return s.awaitPayment(ctx, item, err)
+	}
+	if errors.Is(err, ErrPaymentCaptureFailed) {
+		return s.captureFailed(ctx, item, order, err)
+	}
+	if errors.Is(err, ErrOutOfStock) {
+		return s.failForGood(ctx, item, err, models.QueueErrorOutOfStock)
+	}
+	if errors.Is(err, ErrInvalidOption) {
+		return s.failForGood(ctx, item, err, models.QueueErrorInvalidOption)
+	}
 	if err != nil {
 		item.Status = "failed"
 		item.Error = err.Error()
+		item.ErrorCode = models.QueueErrorOrderFailed
 		item.UpdatedAt = time.Now()
 		item.RetryCount++
-
-		if item.RetryCount >= 3 {
-			log.Printf("Item %s exceeded max retry count, marking as permanently failed", item.ID)
-		}
+		item.NextAttemptAt = item.UpdatedAt.Add(queueRetryBaseDelay << (item.RetryCount - 1))
 
 		if updateErr := s.queueRepo.UpdateItem(ctx, item); updateErr != nil {
 			return fmt.Errorf("failed to mark item as failed: %w (original error: %v)", updateErr, err)
 		}
+
+		if item.RetryCount >= maxQueueRetries {
+			log.Printf("Item %s exceeded max retry count, marking as permanently failed", item.ID)
+			s.releaseStock(ctx, item)
+			s.alertPermanentFailure(ctx, item)
+		}
 		return fmt.Errorf("failed to create order: %w", err)
 	}
 
+	return s.complete(ctx, item, order, true)
+}
+
+// retryCapture captures the payment of an item's order after an earlier attempt failed.
+// The stored order may be stale: an order whose payment was captured in the meantime, e.g.
+// through the payment webhook, was fulfilled there already.
+func (s *orderQueueService) retryCapture(ctx context.Context, item *models.OrderQueueItem) error {
+	order := item.Order
+	if current, err := s.orderRepo.FindOne(ctx, order.ID); err == nil {
+		order = current
+	}
+	if order.PaymentStatus == models.OrderPaymentCaptured {
+		return s.complete(ctx, item, order, false)
+	}
+
+	if err := s.orderSvc.CapturePayment(ctx, order); err != nil {
+		return s.captureFailed(ctx, item, order, err)
+	}
+	return s.complete(ctx, item, order, true)
+}
+
+// captureFailed keeps the item's order and fails the item, to retry the capture with the
+// same backoff as order creation. Payments the provider rejected outright aren't retried.
+// Once no retries are left the order stays unfulfilled for an admin to capture by hand.
+func (s *orderQueueService) captureFailed(ctx context.Context, item *models.OrderQueueItem, order *models.Order, err error) error {
+	item.Status = "failed"
+	item.Order = order
+	item.Error = err.Error()
+	item.ErrorCode = models.QueueErrorCaptureFailed
+	item.UpdatedAt = time.Now()
+	item.RetryCount++
+	if errors.Is(err, ErrPaymentRejected) {
+		item.RetryCount = maxQueueRetries
+	}
+	item.NextAttemptAt = item.UpdatedAt.Add(queueRetryBaseDelay << (item.RetryCount - 1))
+
+	if updateErr := s.queueRepo.UpdateItem(ctx, item); updateErr != nil {
+		return fmt.Errorf("failed to mark item as failed: %w (original error: %v)", updateErr, err)
+	}
+
+	if item.RetryCount >= maxQueueRetries {
+		log.Printf("Order %s from queue item %s was not fulfilled: payment %s was not captured", order.ID, item.ID, order.PaymentIntentID)
+		s.alertUncapturedPayment(ctx, item)
+	}
+	return err
+}
+
+// failForGood fails an item whose order retrying won't help, e.g. one asking for more than
+// is left until the product is restocked, after which an admin can requeue it. Its
+// reservation, which must have expired if it ran out of stock, is released.
+func (s *orderQueueService) failForGood(ctx context.Context, item *models.OrderQueueItem, err error, code string) error {
+	item.Status = "failed"
+	item.Error = err.Error()
+	item.ErrorCode = code
+	item.UpdatedAt = time.Now()
+	item.RetryCount = maxQueueRetries
+
+	if updateErr := s.queueRepo.UpdateItem(ctx, item); updateErr != nil {
+		return fmt.Errorf("failed to mark item as failed: %w (original error: %v)", updateErr, err)
+	}
+	s.releaseStock(ctx, item)
+	return fmt.Errorf("failed to create order: %w", err)
+}
+
+// releaseStock gives up the stock reserved for an item that won't be processed again
+// unless requeued. A reservation left behind holds the stock until it expires.
+func (s *orderQueueService) releaseStock(ctx context.Context, item *models.OrderQueueItem) {
+	if s.inventory == nil {
+		return
+	}
+	if err := s.inventory.Release(ctx, &item.OrderReq); err != nil {
+		log.Printf("Failed to release stock reserved for queue item %s: %v", item.ID, err)
+	}
+}
+
+// complete marks the item completed with its order, fulfills the order unless told
+// otherwise, books a courier for orders with a delivery address and sends the receipt.
+// Sandbox orders are only marked completed.
+func (s *orderQueueService) complete(ctx context.Context, item *models.OrderQueueItem, order *models.Order, fulfill bool) error {
 	item.Status = "completed"
 	item.Order = order
 	item.Error = ""
+	item.ErrorCode = ""
 	item.UpdatedAt = time.Now()
 
 	if err := s.queueRepo.MarkAsCompleted(ctx, item.ID, order); err != nil {
 		return fmt.Errorf("failed to mark item as completed: %w", err)
 	}
+	if order.Sandbox {
+		return nil
+	}
+
+	if fulfill {
+		if err := s.fulfillment.Fulfill(ctx, order); err != nil {
+			log.Printf("Order %s from queue item %s was not fulfilled: %v", order.ID, item.ID, err)
+		}
+	}
+	// Orders fulfilled by the payment webhook get their courier here; Dispatch books one once
+	if s.deliveries != nil && item.OrderReq.DeliveryAddress != nil {
+		if _, err := s.deliveries.Dispatch(ctx, order, *item.OrderReq.DeliveryAddress); err != nil {
+			log.Printf("Delivery of order %s from queue item %s was not booked: %v", order.ID, item.ID, err)
+		}
+	}
+	if s.notifier != nil {
+		if _, err := s.notifier.OrderPlaced(ctx, order); err != nil {
+			log.Printf("Receipt for order %s was not sent: %v", order.ID, err)
+		}
+	}
 
 	return nil
 }
 
-func (s *orderQueueService) GetQueueStatus(ctx context.Context) (map[string]int, error) {
-	return s.queueRepo.GetQueueStats(ctx)
+// paidByGiftCard reports whether the order's gift card pays for all of it, so it has no
+// payment to wait for
+func (s *orderQueueService) paidByGiftCard(ctx context.Context, orderReq *models.OrderReq) bool {
+	if orderReq.GiftCardCode == "" {
+		return false
+	}
+	quote, err := s.orderSvc.QuoteOrder(ctx, orderReq)
+	return err == nil && quote.AmountDue() <= 0
 }
 
-func (s *orderQueueService) StartWorker(ctx context.Context, interval time.Duration, batchSize int) {
-	ticker := time.NewTicker(interval)
-	defer ticker.Stop()
+// awaitPayment puts an item whose payment isn't authorized yet back in the queue, or fails
+// it for good once it has waited longer than the payment window. Scheduled orders wait from
+// their scheduled time.
+func (s *orderQueueService) awaitPayment(ctx context.Context, item *models.OrderQueueItem, cause error) error {
+	item.UpdatedAt = time.Now()
+	waitingSince := item.CreatedAt
+	if scheduled := item.OrderReq.ScheduledFor; scheduled != nil && scheduled.After(waitingSince) {
+		waitingSince = *scheduled
+	}
+	if s.payment.Window > 0 && item.UpdatedAt.Sub(waitingSince) >= s.payment.Window {
+		item.Status = "failed"
+		item.Error = ErrPaymentExpired.Error()
+		item.ErrorCode = models.QueueErrorPaymentExpired
+		item.RetryCount = maxQueueRetries
+		if err := s.queueRepo.UpdateItem(ctx, item); err != nil {
+			return fmt.Errorf("failed to mark item as failed: %w (original error: %v)", err, ErrPaymentExpired)
+		}
+		s.releaseStock(ctx, item)
+		return ErrPaymentExpired
+	}
+
+	item.Status = "pending"
+	item.Error = cause.Error()
+	item.ErrorCode = models.QueueErrorPaymentRequired
+	if errors.Is(cause, ErrPaymentNotAuthorized) {
+		item.ErrorCode = models.QueueErrorPaymentNotAuthorized
+	}
+	item.NextAttemptAt = item.UpdatedAt.Add(paymentCheckInterval)
+	if err := s.queueRepo.UpdateItem(ctx, item); err != nil {
+		return fmt.Errorf("failed to put item back in the queue: %w", err)
+	}
+	return errAwaitingPayment
+}
 
-	log.Printf("Starting order queue worker with interval %v and batch size %d", interval, batchSize)
+func (s *orderQueueService) alertUncapturedPayment(ctx context.Context, item *models.OrderQueueItem) {
+	order := item.Order
+	err := s.alerter.Alert(ctx, Alert{
+		Title: "Order payment not captured",
+		Text: fmt.Sprintf("Order %s was created but payment %s could not be captured after %d attempts, so the order was not fulfilled: %s\nCapture it with POST /api/v1/admin/payments/%s/capture, then fulfill the order by hand, or retry the capture with `queue retry %s`.",
+			order.ID, order.PaymentIntentID, item.RetryCount, item.Error, order.PaymentIntentID, item.ID) + s.failureDetails(ctx, item),
+	})
+	if err != nil {
+		log.Printf("Failed to send alert for order %s: %v", order.ID, err)
+	}
+}
 
-	for {
-		select {
-		case <-ctx.Done():
-			log.Println("Order queue worker stopped")
-			return
-		case <-ticker.C:
-			func() {
-				defer func() {
-					if r := recover(); r != nil {
-						log.Printf("Worker panic recovered: %v", r)
-					}
-				}()
+func (s *orderQueueService) alertPermanentFailure(ctx context.Context, item *models.OrderQueueItem) {
+	err := s.alerter.Alert(ctx, Alert{
+		Title: "Order failed permanently",
+		Text: fmt.Sprintf("Queue item %s failed %d times and will not be retried: %s\nRequeue it with `queue retry %s` once the cause is fixed.",
+			item.ID, item.RetryCount, item.Error, item.ID) + s.failureDetails(ctx, item),
+	})
+	if err != nil {
+		log.Printf("Failed to send alert for queue item %s: %v", item.ID, err)
+	}
+}
 
-				result, err := s.ProcessBatch(ctx, batchSize)
-				if err != nil {
-					log.Printf("Failed to process batch: %v", err)
-					return
-				}
+// failureDetails is the part of an alert about a dead-lettered item that lets operators act
+// on it without digging: the link that retries it, every failed attempt and the order as
+// the customer placed it
+func (s *orderQueueService) failureDetails(ctx context.Context, item *models.OrderQueueItem) string {
+	var details strings.Builder
+	if link := s.retryLinks.URL(item.ID); link != "" {
+		fmt.Fprintf(&details, "\nRetry: %s", link)
+	}
 
-				if result.Processed > 0 || result.Failed > 0 {
-					log.Printf("Batch processed: %d succeeded, %d failed", result.Processed, result.Failed)
-					if result.Failed > 0 {
-						for _, errorMsg := range result.Errors {
-							log.Printf("Error: %s", errorMsg)
-						}
-					}
-				}
-			}()
+	failures, err := s.queueRepo.GetFailures(ctx, item.ID)
+	if err != nil {
+		log.Printf("Failures of queue item %s were left out of its alert: %v", item.ID, err)
+	}
+	if len(failures) > 0 {
+		details.WriteString("\nAttempts:")
+		for _, failure := range failures {
+			fmt.Fprintf(&details, "\n%d. %s %s: %s", failure.Attempt, failure.At.UTC().Format(time.RFC3339), failure.ErrorCode, failure.Error)
 		}
 	}
+
+	payload, err := json.Marshal(item.OrderReq)
+	if err == nil {
+		request := string(payload)
+		if runes := []rune(request); len(runes) > maxAlertPayload {
+			request = string(runes[:maxAlertPayload]) + "…"
+		}
+		fmt.Fprintf(&details, "\nRequest: %s", request)
+	}
+	return details.String()
+}
+
+func (s *orderQueueService) Retry(ctx context.Context, itemID string) error {
+	if err := s.queueRepo.Requeue(ctx, itemID); err != nil {
+		if strings.Contains(err.Error(), "not found") || strings.Contains(err.Error(), "invalid queue item ID") {
+			return ErrQueueItemNotFailed
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *orderQueueService) GetQueueStatus(ctx context.Context) (map[string]int, error) {
+	return s.queueRepo.GetQueueStats(ctx)
+}
+
+func (s *orderQueueService) GetBacklog(ctx context.Context) (*models.QueueBacklog, error) {
+	return s.queueRepo.GetBacklog(ctx)
 }
 
 func (s *orderQueueService) GetCompletedOrders(ctx context.Context) ([]*models.OrderQueueItem, error) {
 	return s.queueRepo.GetAllOrders(ctx)
 }
 
+// GetOrdersUpdatedSince returns queue items whose status changed at or after since, newest first
+func (s *orderQueueService) GetOrdersUpdatedSince(ctx context.Context, since time.Time) ([]*models.OrderQueueItem, error) {
+	items, err := s.queueRepo.GetAllOrders(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	updated := make([]*models.OrderQueueItem, 0, len(items))
+	for _, item := range items {
+		if !item.UpdatedAt.Before(since) {
+			updated = append(updated, item)
+		}
+	}
+	return updated, nil
+}
+
 func (s *orderQueueService) GetOrderFromQueue(ctx context.Context, itemID string) (*models.OrderQueueItem, error) {
 	return s.queueRepo.GetOrderFromQueue(ctx, itemID)
 }
 
+func (s *orderQueueService) Inspect(ctx context.Context, itemID string) (*models.QueueItemDetails, error) {
+	if _, err := uuid.Parse(itemID); err != nil {
+		return nil, ErrQueueItemNotFound
+	}
+	item, err := s.queueRepo.GetOrderFromQueue(ctx, itemID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, ErrQueueItemNotFound
+		}
+		return nil, err
+	}
+	failures, err := s.queueRepo.GetFailures(ctx, itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	details := &models.QueueItemDetails{
+		ID:            item.ID,
+		Status:        item.Status,
+		OrderReq:      item.OrderReq,
+		RetryCount:    item.RetryCount,
+		NextAttemptAt: item.NextAttemptAt,
+		Error:         item.Error,
+		ErrorCode:     item.ErrorCode,
+		Attempts:      failures,
+		ClaimedBy:     item.ClaimedBy,
+		ClaimedAt:     item.ClaimedAt,
+		CreatedAt:     item.CreatedAt,
+		UpdatedAt:     item.UpdatedAt,
+	}
+	if details.Attempts == nil {
+		details.Attempts = []models.QueueFailure{}
+	}
+	if item.Order != nil {
+		details.OrderID = item.Order.ID
+	}
+	return details, nil
+}
+
+func (s *orderQueueService) AttachPayment(ctx context.Context, itemID, intentID string) error {
+	if err := s.queueRepo.SetPaymentIntent(ctx, itemID, intentID); err != nil {
+		if err.Error() == "order awaiting payment not found" {
+			return ErrOrderNotPayable
+		}
+		return fmt.Errorf("failed to attach payment: %w", err)
+	}
+	return nil
+}
+
 func generateUUID() string {
 	return uuid.New().String()
 }
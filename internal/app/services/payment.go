@@ -0,0 +1,313 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"oolio/internal/app/models"
+)
+
+var (
+	ErrPaymentRequired      = errors.New("order is awaiting payment: pay for it with POST /order/{orderId}/pay")
+	ErrPaymentExpired       = errors.New("order was not paid for within the payment window")
+	ErrPaymentNotAuthorized = errors.New("payment intent is not authorized for the order total")
+	ErrPaymentRejected      = errors.New("payment provider rejected the request")
+	ErrPaymentCaptureFailed = errors.New("payment capture failed")
+
+	ErrPaymentMethodNotAccepted = errors.New("payment method is not accepted")
+	ErrCashNeedsDelivery        = errors.New("cash payments are taken on delivery: the order needs a delivery address")
+	ErrCounterNoDelivery        = errors.New("counter payments are taken when the order is collected: it can't be delivered")
+	ErrPaymentIntentNeedsCard   = errors.New("a payment intent can only pay for card orders")
+	ErrTableOrderPaidOnTab      = errors.New("table orders are paid when their tab is closed: they take no payment intent or delivery address")
+	ErrSandboxPayment           = errors.New("test API keys place sandbox orders, which take no payment")
+	ErrSandboxTableOrder        = errors.New("test API keys can't place table orders")
+)
+
+// PaymentService takes payments through a provider. Intents are authorized by the
+// customer, captured when their order is created and may be refunded afterwards. Providers
+// are selected by PAYMENT_PROVIDER; another gateway such as Adyen only needs an
+// implementation. Unknown intents fail with "payment intent not found".
+type PaymentService interface {
+	// CreateIntent starts a payment of amount for manual capture
+	CreateIntent(ctx context.Context, amount models.Money, metadata map[string]string) (*models.PaymentIntent, error)
+	GetIntent(ctx context.Context, id string) (*models.PaymentIntent, error)
+	// Capture takes amount, at most the amount authorized, or all of it when zero
+	Capture(ctx context.Context, id string, amount models.Money) (*models.PaymentIntent, error)
+	// Refund returns amount of the captured money, or all of it when zero
+	Refund(ctx context.Context, id string, amount models.Money) (*models.PaymentRefund, error)
+}
+
+// mockPaymentService keeps intents in memory and authorizes them as soon as they are
+// created, for local development without a payment provider
+type mockPaymentService struct {
+	currency string
+	mutex    sync.Mutex
+	intents  map[string]*models.PaymentIntent
+	refunded map[string]models.Money
+}
+
+func NewMockPaymentService(currency string) PaymentService {
+	return &mockPaymentService{
+		currency: currency,
+		intents:  make(map[string]*models.PaymentIntent),
+		refunded: make(map[string]models.Money),
+	}
+}
+
+func (s *mockPaymentService) CreateIntent(ctx context.Context, amount models.Money, metadata map[string]string) (*models.PaymentIntent, error) {
+	if amount <= 0 {
+		return nil, fmt.Errorf("%w: amount must be positive", ErrPaymentRejected)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	id := "pi_mock_" + strings.ReplaceAll(uuid.NewString(), "-", "")
+	intent := &models.PaymentIntent{
+		ID:           id,
+		ClientSecret: id + "_secret_mock",
+		Amount:       amount,
+		Currency:     s.currency,
+		Status:       models.PaymentStatusRequiresCapture,
+	}
+	s.intents[id] = intent
+	copied := *intent
+	return &copied, nil
+}
+
+func (s *mockPaymentService) GetIntent(ctx context.Context, id string) (*models.PaymentIntent, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	intent, ok := s.intents[id]
+	if !ok {
+		return nil, fmt.Errorf("payment intent not found")
+	}
+	copied := *intent
+	return &copied, nil
+}
+
+func (s *mockPaymentService) Capture(ctx context.Context, id string, amount models.Money) (*models.PaymentIntent, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	intent, ok := s.intents[id]
+	if !ok {
+		return nil, fmt.Errorf("payment intent not found")
+	}
+	if !intent.Authorized() {
+		return nil, fmt.Errorf("%w: intent is %s", ErrPaymentRejected, intent.Status)
+	}
+	if amount == 0 {
+		amount = intent.Amount
+	}
+	if amount < 0 || amount > intent.Amount {
+		return nil, fmt.Errorf("%w: capture amount exceeds the authorized %s", ErrPaymentRejected, intent.Amount)
+	}
+
+	intent.AmountCaptured = amount
+	intent.Status = models.PaymentStatusSucceeded
+	copied := *intent
+	return &copied, nil
+}
+
+func (s *mockPaymentService) Refund(ctx context.Context, id string, amount models.Money) (*models.PaymentRefund, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	intent, ok := s.intents[id]
+	if !ok {
+		return nil, fmt.Errorf("payment intent not found")
+	}
+	remaining := intent.AmountCaptured - s.refunded[id]
+	if amount == 0 {
+		amount = remaining
+	}
+	if intent.Status != models.PaymentStatusSucceeded || amount <= 0 || amount > remaining {
+		return nil, fmt.Errorf("%w: %s of the captured payment is left to refund", ErrPaymentRejected, remaining)
+	}
+
+	s.refunded[id] += amount
+	return &models.PaymentRefund{
+		ID:              "re_mock_" + strings.ReplaceAll(uuid.NewString(), "-", ""),
+		PaymentIntentID: id,
+		Amount:          amount,
+		Status:          models.PaymentStatusSucceeded,
+	}, nil
+}
+
+const defaultStripeBaseURL = "https://api.stripe.com"
+
+type StripeOptions struct {
+	SecretKey string
+	Currency  string // ISO code in lower case, e.g. "aud"; amounts are sent in cents
+	BaseURL   string // Defaults to the Stripe API
+	Timeout   time.Duration
+}
+
+// stripePaymentService talks to the Stripe REST API directly. Intents are created with
+// capture_method=manual, so a confirmed intent waits in requires_capture until captured.
+type stripePaymentService struct {
+	client    *http.Client
+	baseURL   string
+	secretKey string
+	currency  string
+}
+
+func NewStripePaymentService(opts StripeOptions) PaymentService {
+	if opts.BaseURL == "" {
+		opts.BaseURL = defaultStripeBaseURL
+	}
+	return &stripePaymentService{
+		client:    &http.Client{Timeout: opts.Timeout},
+		baseURL:   strings.TrimRight(opts.BaseURL, "/"),
+		secretKey: opts.SecretKey,
+		currency:  opts.Currency,
+	}
+}
+
+// stripeIntent and stripeRefund are the fields read from Stripe's objects
+type stripeIntent struct {
+	ID             string `json:"id"`
+	ClientSecret   string `json:"client_secret"`
+	Amount         int64  `json:"amount"`
+	AmountReceived int64  `json:"amount_received"`
+	Currency       string `json:"currency"`
+	Status         string `json:"status"`
+}
+
+func (i stripeIntent) toModel() *models.PaymentIntent {
+	return &models.PaymentIntent{
+		ID:             i.ID,
+		ClientSecret:   i.ClientSecret,
+		Amount:         models.Cents(i.Amount),
+		AmountCaptured: models.Cents(i.AmountReceived),
+		Currency:       i.Currency,
+		Status:         i.Status,
+	}
+}
+
+type stripeRefund struct {
+	ID            string `json:"id"`
+	PaymentIntent string `json:"payment_intent"`
+	Amount        int64  `json:"amount"`
+	Status        string `json:"status"`
+}
+
+func (s *stripePaymentService) CreateIntent(ctx context.Context, amount models.Money, metadata map[string]string) (*models.PaymentIntent, error) {
+	form := url.Values{}
+	form.Set("amount", strconv.FormatInt(int64(amount), 10))
+	form.Set("currency", s.currency)
+	form.Set("capture_method", "manual")
+	form.Set("automatic_payment_methods[enabled]", "true")
+	for key, value := range metadata {
+		form.Set("metadata["+key+"]", value)
+	}
+
+	var intent stripeIntent
+	if err := s.do(ctx, http.MethodPost, "/v1/payment_intents", form, &intent); err != nil {
+		return nil, err
+	}
+	return intent.toModel(), nil
+}
+
+func (s *stripePaymentService) GetIntent(ctx context.Context, id string) (*models.PaymentIntent, error) {
+	var intent stripeIntent
+	if err := s.do(ctx, http.MethodGet, "/v1/payment_intents/"+url.PathEscape(id), nil, &intent); err != nil {
+		return nil, err
+	}
+	return intent.toModel(), nil
+}
+
+func (s *stripePaymentService) Capture(ctx context.Context, id string, amount models.Money) (*models.PaymentIntent, error) {
+	form := url.Values{}
+	if amount > 0 {
+		form.Set("amount_to_capture", strconv.FormatInt(int64(amount), 10))
+	}
+
+	var intent stripeIntent
+	if err := s.do(ctx, http.MethodPost, "/v1/payment_intents/"+url.PathEscape(id)+"/capture", form, &intent); err != nil {
+		return nil, err
+	}
+	return intent.toModel(), nil
+}
+
+func (s *stripePaymentService) Refund(ctx context.Context, id string, amount models.Money) (*models.PaymentRefund, error) {
+	form := url.Values{}
+	form.Set("payment_intent", id)
+	if amount > 0 {
+		form.Set("amount", strconv.FormatInt(int64(amount), 10))
+	}
+
+	var refund stripeRefund
+	if err := s.do(ctx, http.MethodPost, "/v1/refunds", form, &refund); err != nil {
+		return nil, err
+	}
+	return &models.PaymentRefund{
+		ID:              refund.ID,
+		PaymentIntentID: refund.PaymentIntent,
+		Amount:          models.Cents(refund.Amount),
+		Status:          refund.Status,
+	}, nil
+}
+
+// do sends a form-encoded request and decodes the response into out. Stripe's 4xx errors
+// wrap ErrPaymentRejected, apart from unknown objects.
+func (s *stripePaymentService) do(ctx context.Context, method, path string, form url.Values, out any) error {
+	var body io.Reader
+	if form != nil {
+		body = strings.NewReader(form.Encode())
+	}
+	req, err := http.NewRequestWithContext(ctx, method, s.baseURL+path, body)
+	if err != nil {
+		return fmt.Errorf("failed to build Stripe request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+s.secretKey)
+	if form != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach Stripe: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("failed to read Stripe response: %w", err)
+	}
+
+	if resp.StatusCode/100 != 2 {
+		var failure struct {
+			Error struct {
+				Code    string `json:"code"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		_ = json.Unmarshal(data, &failure)
+		switch {
+		case resp.StatusCode == http.StatusNotFound || failure.Error.Code == "resource_missing":
+			return fmt.Errorf("payment intent not found")
+		case resp.StatusCode/100 == 4 && resp.StatusCode != http.StatusUnauthorized && resp.StatusCode != http.StatusTooManyRequests:
+			return fmt.Errorf("%w: %s", ErrPaymentRejected, failure.Error.Message)
+		}
+		return fmt.Errorf("stripe request failed: %s: %s", resp.Status, failure.Error.Message)
+	}
+
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to decode Stripe response: %w", err)
+	}
+	return nil
+}
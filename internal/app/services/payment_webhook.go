@@ -0,0 +1,241 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"oolio/internal/app/models"
+	"oolio/internal/app/repository"
+)
+
+// PaymentSignatureHeader carries the provider's signature of a payment webhook, in
+// Stripe's format: "t=<unix time>,v1=<hex HMAC-SHA256 of "<t>.<body>">"
+const PaymentSignatureHeader = "Stripe-Signature"
+
+var (
+	ErrWebhookSignature = errors.New("payment webhook signature is missing, invalid or too old")
+	ErrWebhookPayload   = errors.New("payment webhook payload is not a payment event")
+	// ErrWebhookOrderPending is returned for a succeeded intent whose order isn't there yet;
+	// the provider redelivers the event until it is
+	ErrWebhookOrderPending = errors.New("no order has the payment intent yet")
+)
+
+// Payment webhook event types that change an order; the rest are acknowledged and ignored
+const (
+	paymentEventAuthorized = "payment_intent.amount_capturable_updated"
+	paymentEventSucceeded  = "payment_intent.succeeded"
+	paymentEventFailed     = "payment_intent.payment_failed"
+	paymentEventCanceled   = "payment_intent.canceled"
+)
+
+// PaymentWebhookService takes the payment provider's notifications about intents, so
+// orders move on as soon as they are paid for instead of when the worker next checks
+type PaymentWebhookService interface {
+	// Handle verifies the payload's signature and applies the event it carries. Each event
+	// is applied once: a repeated delivery reports duplicate and changes nothing. It fails
+	// with ErrWebhookSignature or ErrWebhookPayload when the payload can't be trusted or read.
+	Handle(ctx context.Context, payload []byte, signature string) (duplicate bool, err error)
+}
+
+type PaymentWebhookOptions struct {
+	Secret string
+	// Tolerance is how old a signature may be before the payload is taken for a replay;
+	// defaults to 5 minutes
+	Tolerance time.Duration
+}
+
+type paymentWebhookService struct {
+	events      repository.PaymentEventRepository
+	orders      repository.OrderRepository
+	queue       OrderQueueService
+	fulfillment FulfillmentProvider
+	logger      *zap.Logger
+	secret      []byte
+	tolerance   time.Duration
+}
+
+// NewPaymentWebhookService returns the webhook service; a nil fulfillment is skipped
+func NewPaymentWebhookService(events repository.PaymentEventRepository, orders repository.OrderRepository, queue OrderQueueService, fulfillment FulfillmentProvider, logger *zap.Logger, opts PaymentWebhookOptions) PaymentWebhookService {
+	if fulfillment == nil {
+		fulfillment = NewNoopFulfillmentProvider()
+	}
+	if opts.Tolerance <= 0 {
+		opts.Tolerance = 5 * time.Minute
+	}
+	return &paymentWebhookService{
+		events:      events,
+		orders:      orders,
+		queue:       queue,
+		fulfillment: fulfillment,
+		logger:      logger,
+		secret:      []byte(opts.Secret),
+		tolerance:   opts.Tolerance,
+	}
+}
+
+// paymentEvent is the part of a provider event the service reads
+type paymentEvent struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+	Data struct {
+		Object struct {
+			ID       string            `json:"id"`
+			Status   string            `json:"status"`
+			Metadata map[string]string `json:"metadata"`
+		} `json:"object"`
+	} `json:"data"`
+}
+
+func (s *paymentWebhookService) Handle(ctx context.Context, payload []byte, signature string) (bool, error) {
+	if err := s.verify(payload, signature, time.Now()); err != nil {
+		return false, err
+	}
+
+	var event paymentEvent
+	if err := json.Unmarshal(payload, &event); err != nil || event.ID == "" || event.Type == "" {
+		return false, ErrWebhookPayload
+	}
+
+	// Recording the event first stops a redelivery racing this one from applying it twice
+	if err := s.events.Record(ctx, event.ID, event.Type); err != nil {
+		if err.Error() == "payment event already recorded" {
+			return true, nil
+		}
+		return false, err
+	}
+
+	if err := s.apply(ctx, &event); err != nil {
+		// Forgotten, the provider's next delivery is applied
+		if forgetErr := s.events.Forget(ctx, event.ID); forgetErr != nil {
+			return false, fmt.Errorf("%w (event %s not forgotten: %v)", err, event.ID, forgetErr)
+		}
+		return false, err
+	}
+	return false, nil
+}
+
+// verify checks the signature was made with the secret over the payload within the
+// tolerance of now. Providers may send several v1 signatures while a secret is rolled.
+func (s *paymentWebhookService) verify(payload []byte, signature string, now time.Time) error {
+	var timestamp string
+	var signatures []string
+	for _, part := range strings.Split(signature, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || len(signatures) == 0 {
+		return ErrWebhookSignature
+	}
+	if age := now.Sub(time.Unix(unix, 0)); age > s.tolerance || age < -s.tolerance {
+		return ErrWebhookSignature
+	}
+
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(payload)
+	expected := hex.EncodeToString(mac.Sum(nil))
+	for _, sig := range signatures {
+		if hmac.Equal([]byte(sig), []byte(expected)) {
+			return nil
+		}
+	}
+	return ErrWebhookSignature
+}
+
+func (s *paymentWebhookService) apply(ctx context.Context, event *paymentEvent) error {
+	intent := event.Data.Object
+	if intent.ID == "" {
+		return ErrWebhookPayload
+	}
+
+	switch event.Type {
+	case paymentEventAuthorized:
+		return s.authorized(ctx, intent.ID, intent.Metadata["orderId"])
+	case paymentEventSucceeded:
+		return s.succeeded(ctx, intent.ID)
+	case paymentEventFailed, paymentEventCanceled:
+		s.logger.Warn("Payment was not completed",
+			zap.String("eventId", event.ID),
+			zap.String("eventType", event.Type),
+			zap.String("paymentIntentId", intent.ID),
+			zap.String("orderId", intent.Metadata["orderId"]))
+	}
+	return nil
+}
+
+// authorized attaches the intent to the queued order it was created for by
+// POST /order/{orderId}/pay, which makes the order due so the worker starts preparing it
+func (s *paymentWebhookService) authorized(ctx context.Context, intentID, itemID string) error {
+	if itemID == "" {
+		// Intents from POST /payments/intents come with their order
+		return nil
+	}
+
+	err := s.queue.AttachPayment(ctx, itemID, intentID)
+	if errors.Is(err, ErrOrderNotPayable) {
+		// Already processed, most likely with this very intent
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	s.logger.Info("Payment authorized by webhook",
+		zap.String("paymentIntentId", intentID),
+		zap.String("queueItemId", itemID))
+	return nil
+}
+
+// succeeded records the capture of an order's payment that failed to be recorded, or was
+// captured by hand, and fulfills the order it was holding back
+func (s *paymentWebhookService) succeeded(ctx context.Context, intentID string) error {
+	order, err := s.orders.FindByPaymentIntent(ctx, intentID)
+	if err != nil {
+		if err.Error() == "order not found" {
+			// Not created yet. Failing has the provider redeliver, and by then the worker has
+			// either captured the payment itself or left it for this event to record.
+			return ErrWebhookOrderPending
+		}
+		return fmt.Errorf("failed to find order: %w", err)
+	}
+	if order.PaymentStatus != models.OrderPaymentAuthorized {
+		return nil
+	}
+
+	if err := s.orders.SetPaymentStatus(ctx, order.ID, models.OrderPaymentCaptured); err != nil {
+		return fmt.Errorf("failed to record payment: %w", err)
+	}
+	order.PaymentStatus = models.OrderPaymentCaptured
+
+	s.logger.Info("Payment captured by webhook",
+		zap.String("orderId", order.ID),
+		zap.String("paymentIntentId", intentID))
+
+	// The payment is recorded, so a failed fulfillment is reported like the worker's
+	if err := s.fulfillment.Fulfill(ctx, order); err != nil {
+		s.logger.Error("Order was not fulfilled",
+			zap.String("orderId", order.ID),
+			zap.Error(err))
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"oolio/internal/app/models"
+	"oolio/internal/app/repository"
+)
+
+var (
+	ErrInvalidPricingTier  = errors.New("tier names are 1-50 lower case letters, digits, - or _, and discounts range from 0 up to but excluding 100")
+	ErrRetailTier          = errors.New("the retail tier is the list price and can't be changed")
+	ErrInvalidPricingOwner = errors.New("assign a tier to exactly one of customerId or apiKey")
+)
+
+var tierNamePattern = regexp.MustCompile(`^[a-z0-9_-]{1,50}$`)
+
+type PricingService interface {
+	// ListTiers returns every tier, retail first
+	ListTiers(ctx context.Context) ([]models.PricingTier, error)
+	SaveTier(ctx context.Context, name string, req models.PricingTierReq) (*models.PricingTier, error)
+	DeleteTier(ctx context.Context, name string) error
+	ListAssignments(ctx context.Context) ([]models.PricingAssignment, error)
+	// Assign gives a customer or API key a tier; assigning retail removes their tier
+	Assign(ctx context.Context, req models.PricingAssignmentReq) error
+	// ResolveTier returns the tier of the customer, or else of the API key; retail when
+	// neither has one
+	ResolveTier(ctx context.Context, customerID, apiKey string) (*models.PricingTier, error)
+	// Tier returns the named tier; retail for names that no longer exist, e.g. the tier of a
+	// queued order that was deleted since
+	Tier(ctx context.Context, name string) (*models.PricingTier, error)
+}
+
+type pricingService struct {
+	repo repository.PricingRepository
+}
+
+func NewPricingService(repo repository.PricingRepository) PricingService {
+	return &pricingService{repo: repo}
+}
+
+func retailTier() *models.PricingTier {
+	return &models.PricingTier{Name: models.TierRetail}
+}
+
+func (s *pricingService) ListTiers(ctx context.Context) ([]models.PricingTier, error) {
+	tiers, err := s.repo.FindTiers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return append([]models.PricingTier{*retailTier()}, tiers...), nil
+}
+
+func (s *pricingService) SaveTier(ctx context.Context, name string, req models.PricingTierReq) (*models.PricingTier, error) {
+	if name == models.TierRetail {
+		return nil, ErrRetailTier
+	}
+	if !tierNamePattern.MatchString(name) || req.DiscountPercentage < 0 || req.DiscountPercentage >= 100 {
+		return nil, ErrInvalidPricingTier
+	}
+	return s.repo.SaveTier(ctx, name, req.DiscountPercentage)
+}
+
+func (s *pricingService) DeleteTier(ctx context.Context, name string) error {
+	if name == models.TierRetail {
+		return ErrRetailTier
+	}
+	return s.repo.DeleteTier(ctx, name)
+}
+
+func (s *pricingService) ListAssignments(ctx context.Context) ([]models.PricingAssignment, error) {
+	return s.repo.FindAssignments(ctx)
+}
+
+func (s *pricingService) Assign(ctx context.Context, req models.PricingAssignmentReq) error {
+	customerID, apiKey := strings.TrimSpace(req.CustomerID), strings.TrimSpace(req.APIKey)
+	if (customerID == "") == (apiKey == "") || len(customerID) > 100 {
+		return ErrInvalidPricingOwner
+	}
+
+	subjectType, subject := models.PricingSubjectCustomer, customerID
+	if apiKey != "" {
+		subjectType, subject = models.PricingSubjectAPIKey, hashAPIKey(apiKey)
+	}
+
+	if req.Tier == models.TierRetail {
+		err := s.repo.Unassign(ctx, subjectType, subject)
+		if err != nil && err.Error() == "pricing assignment not found" {
+			return nil
+		}
+		return err
+	}
+
+	if _, err := s.repo.FindTier(ctx, req.Tier); err != nil {
+		return err
+	}
+	return s.repo.Assign(ctx, subjectType, subject, req.Tier)
+}
+
+func (s *pricingService) ResolveTier(ctx context.Context, customerID, apiKey string) (*models.PricingTier, error) {
+	var apiKeyHash string
+	if apiKey != "" {
+		apiKeyHash = hashAPIKey(apiKey)
+	}
+
+	tier, err := s.repo.Resolve(ctx, customerID, apiKeyHash)
+	if err != nil {
+		if err.Error() == "pricing tier not found" {
+			return retailTier(), nil
+		}
+		return nil, fmt.Errorf("failed to resolve pricing tier: %w", err)
+	}
+	return tier, nil
+}
+
+func (s *pricingService) Tier(ctx context.Context, name string) (*models.PricingTier, error) {
+	if name == "" || name == models.TierRetail {
+		return retailTier(), nil
+	}
+
+	tier, err := s.repo.FindTier(ctx, name)
+	if err != nil {
+		if err.Error() == "pricing tier not found" {
+			return retailTier(), nil
+		}
+		return nil, err
+	}
+	return tier, nil
+}
+
+// hashAPIKey returns the hex SHA-256 of an API key, which is how pricing assignments and
+// store API keys keep keys
+func hashAPIKey(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(sum[:])
+}
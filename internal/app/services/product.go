@@ -2,7 +2,11 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"strconv"
+	"time"
 
 	"oolio/internal/app/models"
 	"oolio/internal/app/repository"
@@ -10,12 +14,27 @@ import (
 
 type ProductService interface {
 	GetAllProducts(ctx context.Context) ([]models.Product, error)
+	// ListProducts returns one page of the menu, of page.StoreID's store when set, and the
+	// number of products on every page
+	ListProducts(ctx context.Context, page models.PageRequest) ([]models.Product, int, error)
+	GetProductsUpdatedSince(ctx context.Context, since time.Time) ([]models.Product, error)
 	GetProductByID(ctx context.Context, id string) (*models.Product, error)
+	// GetProductForUpdate reads the product as last written, for callers that change it and
+	// write it back
+	GetProductForUpdate(ctx context.Context, id string) (*models.Product, error)
 	CreateProduct(ctx context.Context, product *models.Product) error
 	UpdateProduct(ctx context.Context, product *models.Product) error
 	DeleteProduct(ctx context.Context, id string) error
+	GetDeletedProducts(ctx context.Context, since time.Time) ([]models.Product, error)
+	RestoreProduct(ctx context.Context, id string) error
+	// GetChanges returns the catalog changes after the cursor since, from the start of the
+	// feed when empty, of storeID's products when set. limit is clamped like a page's. It
+	// fails with ErrInvalidCursor for a cursor the feed didn't hand out.
+	GetChanges(ctx context.Context, storeID, since string, limit int) (*models.ProductChanges, error)
 }
 
+var ErrInvalidCursor = errors.New("invalid cursor")
+
 type productService struct {
 	repo repository.ProductRepository
 }
@@ -35,6 +54,24 @@ func (s *productService) GetAllProducts(ctx context.Context) ([]models.Product,
 	return products, nil
 }
 
+func (s *productService) ListProducts(ctx context.Context, page models.PageRequest) ([]models.Product, int, error) {
+	products, total, err := s.repo.FindPage(ctx, page)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list products: %w", err)
+	}
+
+	return products, total, nil
+}
+
+func (s *productService) GetProductsUpdatedSince(ctx context.Context, since time.Time) ([]models.Product, error) {
+	products, err := s.repo.FindUpdatedSince(ctx, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get products updated since %s: %w", since.Format(time.RFC3339), err)
+	}
+
+	return products, nil
+}
+
 func (s *productService) GetProductByID(ctx context.Context, id string) (*models.Product, error) {
 	if id == "" {
 		return nil, fmt.Errorf("product ID cannot be empty")
@@ -48,6 +85,19 @@ func (s *productService) GetProductByID(ctx context.Context, id string) (*models
 	return product, nil
 }
 
+func (s *productService) GetProductForUpdate(ctx context.Context, id string) (*models.Product, error) {
+	if id == "" {
+		return nil, fmt.Errorf("product ID cannot be empty")
+	}
+
+	product, err := s.repo.FindOneForUpdate(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product by ID %s: %w", id, err)
+	}
+
+	return product, nil
+}
+
 func (s *productService) CreateProduct(ctx context.Context, product *models.Product) error {
 	if err := s.validateProduct(product); err != nil {
 		return fmt.Errorf("product validation failed: %w", err)
@@ -91,6 +141,55 @@ func (s *productService) DeleteProduct(ctx context.Context, id string) error {
 	return nil
 }
 
+func (s *productService) GetDeletedProducts(ctx context.Context, since time.Time) ([]models.Product, error) {
+	products, err := s.repo.FindDeleted(ctx, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deleted products: %w", err)
+	}
+
+	return products, nil
+}
+
+func (s *productService) RestoreProduct(ctx context.Context, id string) error {
+	if id == "" {
+		return fmt.Errorf("product ID cannot be empty")
+	}
+
+	if err := s.repo.Restore(ctx, id); err != nil {
+		return fmt.Errorf("failed to restore product: %w", err)
+	}
+
+	return nil
+}
+
+func (s *productService) GetChanges(ctx context.Context, storeID, since string, limit int) (*models.ProductChanges, error) {
+	var after int64
+	if since != "" {
+		var err error
+		if after, err = strconv.ParseInt(since, 10, 64); err != nil || after < 0 {
+			return nil, ErrInvalidCursor
+		}
+	}
+	limit = models.PageRequest{Limit: limit}.Normalize().Limit
+
+	// One more than asked for tells whether more follow
+	changes, err := s.repo.FindChanges(ctx, storeID, after, limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product changes: %w", err)
+	}
+
+	feed := &models.ProductChanges{Changes: changes, Cursor: strconv.FormatInt(after, 10)}
+	if len(changes) > limit {
+		feed.Changes, feed.HasMore = changes[:limit], true
+	}
+	if len(feed.Changes) > 0 {
+		feed.Cursor = feed.Changes[len(feed.Changes)-1].Cursor
+	} else {
+		feed.Changes = []models.ProductChange{}
+	}
+	return feed, nil
+}
+
 func (s *productService) validateProduct(product *models.Product) error {
 	if product == nil {
 		return fmt.Errorf("product cannot be nil")
@@ -108,5 +207,14 @@ func (s *productService) validateProduct(product *models.Product) error {
 		return fmt.Errorf("product category is required")
 	}
 
+	for _, option := range slices.Concat(product.Variants, product.Modifiers) {
+		if option.Name == "" {
+			return fmt.Errorf("product option name is required")
+		}
+		if option.PriceDelta < 0 {
+			return fmt.Errorf("product option price can't be negative")
+		}
+	}
+
 	return nil
 }
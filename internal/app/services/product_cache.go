@@ -0,0 +1,93 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"oolio/internal/app/models"
+	"oolio/internal/app/repository"
+)
+
+// productListGenerationKey names the generation of the cached listings. Every product
+// write moves it on, which orphans the listings cached under the old one however they were
+// filtered; they expire with their TTL.
+const productListGenerationKey = "generation"
+
+// cachingProductService serves product listings from a cache shared between instances,
+// keyed by their filter, and invalidates them on every write through the service
+type cachingProductService struct {
+	ProductService
+	cache repository.Cache
+	ttl   time.Duration
+}
+
+// NewCachingProductService wraps products so listings are cached for up to ttl. Writes that
+// bypass the service, e.g. straight through the repository, are only seen once listings expire.
+func NewCachingProductService(products ProductService, cache repository.Cache, ttl time.Duration) ProductService {
+	return &cachingProductService{ProductService: products, cache: cache, ttl: ttl}
+}
+
+func (s *cachingProductService) GetAllProducts(ctx context.Context) ([]models.Product, error) {
+	return s.cachedList(ctx, "all", s.ProductService.GetAllProducts)
+}
+
+func (s *cachingProductService) GetProductsUpdatedSince(ctx context.Context, since time.Time) ([]models.Product, error) {
+	return s.cachedList(ctx, "since:"+strconv.FormatInt(since.UnixNano(), 10), func(ctx context.Context) ([]models.Product, error) {
+		return s.ProductService.GetProductsUpdatedSince(ctx, since)
+	})
+}
+
+func (s *cachingProductService) CreateProduct(ctx context.Context, product *models.Product) error {
+	defer s.invalidate(ctx)
+	return s.ProductService.CreateProduct(ctx, product)
+}
+
+func (s *cachingProductService) UpdateProduct(ctx context.Context, product *models.Product) error {
+	defer s.invalidate(ctx)
+	return s.ProductService.UpdateProduct(ctx, product)
+}
+
+func (s *cachingProductService) DeleteProduct(ctx context.Context, id string) error {
+	defer s.invalidate(ctx)
+	return s.ProductService.DeleteProduct(ctx, id)
+}
+
+func (s *cachingProductService) RestoreProduct(ctx context.Context, id string) error {
+	defer s.invalidate(ctx)
+	return s.ProductService.RestoreProduct(ctx, id)
+}
+
+// cachedList returns the listing cached under the current generation, loading and caching
+// it on a miss. The cache is best effort: its errors fall back to load.
+func (s *cachingProductService) cachedList(ctx context.Context, filter string, load func(ctx context.Context) ([]models.Product, error)) ([]models.Product, error) {
+	generation, _, err := s.cache.Get(ctx, productListGenerationKey)
+	if err != nil {
+		return load(ctx)
+	}
+	key := "list:" + string(generation) + ":" + filter
+
+	if data, ok, err := s.cache.Get(ctx, key); err == nil && ok {
+		var products []models.Product
+		if err := json.Unmarshal(data, &products); err == nil {
+			return products, nil
+		}
+	}
+
+	products, err := load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if data, err := json.Marshal(products); err == nil {
+		_ = s.cache.Set(ctx, key, data, s.ttl)
+	}
+	return products, nil
+}
+
+// invalidate starts a new generation. It runs even when the write fails, since a failed
+// call may still have been applied.
+func (s *cachingProductService) invalidate(ctx context.Context) {
+	generation := strconv.FormatInt(time.Now().UnixNano(), 36)
+	_ = s.cache.Set(context.WithoutCancel(ctx), productListGenerationKey, []byte(generation), 0)
+}
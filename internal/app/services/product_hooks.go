@@ -0,0 +1,51 @@
+package services
+
+import (
+	"context"
+
+	"oolio/internal/app/models"
+)
+
+// ProductChangeHook is called after every product write through the product service, e.g.
+// to bust caches of the catalog. It runs even when the write fails, since a failed call
+// may still have been applied.
+type ProductChangeHook func(ctx context.Context)
+
+type hookedProductService struct {
+	ProductService
+	hooks []ProductChangeHook
+}
+
+// NewHookedProductService wraps products so hooks run after each of its writes
+func NewHookedProductService(products ProductService, hooks ...ProductChangeHook) ProductService {
+	if len(hooks) == 0 {
+		return products
+	}
+	return &hookedProductService{ProductService: products, hooks: hooks}
+}
+
+func (s *hookedProductService) CreateProduct(ctx context.Context, product *models.Product) error {
+	defer s.changed(ctx)
+	return s.ProductService.CreateProduct(ctx, product)
+}
+
+func (s *hookedProductService) UpdateProduct(ctx context.Context, product *models.Product) error {
+	defer s.changed(ctx)
+	return s.ProductService.UpdateProduct(ctx, product)
+}
+
+func (s *hookedProductService) DeleteProduct(ctx context.Context, id string) error {
+	defer s.changed(ctx)
+	return s.ProductService.DeleteProduct(ctx, id)
+}
+
+func (s *hookedProductService) RestoreProduct(ctx context.Context, id string) error {
+	defer s.changed(ctx)
+	return s.ProductService.RestoreProduct(ctx, id)
+}
+
+func (s *hookedProductService) changed(ctx context.Context) {
+	for _, hook := range s.hooks {
+		hook(ctx)
+	}
+}
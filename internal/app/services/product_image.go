@@ -0,0 +1,234 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"oolio/internal/app/models"
+	"oolio/internal/storage"
+)
+
+// MaxProductImageBytes is the largest product image accepted for upload
+const MaxProductImageBytes = 5 << 20
+
+// MaxProductImagePixels bounds the width times height of an image that is scaled. A small
+// file can declare a huge image, and decoding it takes 4 bytes a pixel.
+const MaxProductImagePixels = 25_000_000
+
+var (
+	ErrUnsupportedImage   = errors.New("image must be a JPEG, PNG or WebP file")
+	ErrImageTooLarge      = fmt.Errorf("image exceeds %d MB", MaxProductImageBytes>>20)
+	ErrImageTooManyPixels = fmt.Errorf("image exceeds %d megapixels", MaxProductImagePixels/1_000_000)
+)
+
+// Accepted image types, detected from the content, and the extension their objects get
+var productImageTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/webp": ".webp",
+}
+
+// productImageSizes are the widths each size of a product image is scaled down to, keeping
+// its aspect ratio
+var productImageSizes = []struct {
+	name  string
+	width int
+	set   func(img *models.Image, url string)
+}{
+	{"thumbnail", 200, func(img *models.Image, url string) { img.Thumbnail = url }},
+	{"mobile", 640, func(img *models.Image, url string) { img.Mobile = url }},
+	{"tablet", 1024, func(img *models.Image, url string) { img.Tablet = url }},
+	{"desktop", 1920, func(img *models.Image, url string) { img.Desktop = url }},
+}
+
+type ProductImageService interface {
+	// Upload scales the image down to each image size of the product, stores them and
+	// points the product at them. Sizes the image isn't wider than, and every size of a
+	// WebP image, use the image as uploaded.
+	Upload(ctx context.Context, productID string, r io.Reader) (*models.Product, error)
+}
+
+type productImageService struct {
+	products  ProductService
+	storage   storage.Storage
+	publicURL string
+}
+
+// NewProductImageService stores images in storage and links them as publicURL/<key>
+func NewProductImageService(products ProductService, store storage.Storage, publicURL string) ProductImageService {
+	return &productImageService{
+		products:  products,
+		storage:   store,
+		publicURL: strings.TrimSuffix(publicURL, "/"),
+	}
+}
+
+func (s *productImageService) Upload(ctx context.Context, productID string, r io.Reader) (*models.Product, error) {
+	data, err := io.ReadAll(io.LimitReader(r, MaxProductImageBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image: %w", err)
+	}
+	if len(data) > MaxProductImageBytes {
+		return nil, ErrImageTooLarge
+	}
+
+	contentType := http.DetectContentType(data)
+	ext, ok := productImageTypes[contentType]
+	if !ok {
+		return nil, ErrUnsupportedImage
+	}
+
+	product, err := s.products.GetProductForUpdate(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	scaled, err := scaleProductImage(data, contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fresh keys per upload let clients and CDNs cache image URLs indefinitely
+	base := fmt.Sprintf("products/%s/%s", product.ID, uuid.NewString())
+	previous := product.Image
+	var stored []string
+	discard := func() {
+		for _, url := range stored {
+			s.delete(ctx, url)
+		}
+	}
+	var original string
+	for i, size := range productImageSizes {
+		key, body := base+"-"+size.name+ext, scaled[i]
+		if body == nil {
+			if original != "" {
+				size.set(&product.Image, original)
+				continue
+			}
+			key, body = base+ext, data
+		}
+		if err := s.storage.Put(ctx, key, bytes.NewReader(body), contentType); err != nil {
+			discard()
+			return nil, fmt.Errorf("failed to store image: %w", err)
+		}
+		url := s.publicURL + "/" + key
+		if scaled[i] == nil {
+			original = url
+		}
+		stored = append(stored, url)
+		size.set(&product.Image, url)
+	}
+
+	if err := s.products.UpdateProduct(ctx, product); err != nil {
+		discard()
+		return nil, err
+	}
+
+	deleted := map[string]bool{}
+	for _, old := range []string{previous.Thumbnail, previous.Mobile, previous.Tablet, previous.Desktop} {
+		if !deleted[old] {
+			s.delete(ctx, old)
+			deleted[old] = true
+		}
+	}
+	return product, nil
+}
+
+// delete removes an image uploaded earlier; URLs outside the store, such as the seeded
+// menu images, are left alone
+func (s *productImageService) delete(ctx context.Context, url string) {
+	key, ok := strings.CutPrefix(url, s.publicURL+"/")
+	if !ok {
+		return
+	}
+	if err := s.storage.Delete(ctx, key); err != nil {
+		log.Printf("Failed to delete product image %s: %v", key, err)
+	}
+}
+
+// scaleProductImage encodes the image scaled down to each of productImageSizes, in the
+// format it was uploaded in. Sizes the image isn't wider than are nil, as are all sizes of
+// WebP images, which the standard library can't encode. Images over MaxProductImagePixels
+// are refused from their header, before any of them is decoded.
+func scaleProductImage(data []byte, contentType string) ([][]byte, error) {
+	scaled := make([][]byte, len(productImageSizes))
+	if contentType == "image/webp" {
+		return scaled, nil
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedImage, err)
+	}
+	if int64(config.Width)*int64(config.Height) > MaxProductImagePixels {
+		return nil, ErrImageTooManyPixels
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedImage, err)
+	}
+	bounds := src.Bounds()
+	for i, size := range productImageSizes {
+		if bounds.Dx() <= size.width {
+			continue
+		}
+		dst := scaleDown(src, size.width, max(1, bounds.Dy()*size.width/bounds.Dx()))
+
+		var buf bytes.Buffer
+		if contentType == "image/png" {
+			err = png.Encode(&buf, dst)
+		} else {
+			err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 85})
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode %s image: %w", size.name, err)
+		}
+		scaled[i] = buf.Bytes()
+	}
+	return scaled, nil
+}
+
+// scaleDown shrinks src to width by height pixels, each the average of the source pixels
+// it covers
+func scaleDown(src image.Image, width, height int) *image.RGBA {
+	bounds := src.Bounds()
+	in := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(in, in.Bounds(), src, bounds.Min, draw.Src)
+
+	out := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := range height {
+		y0, y1 := y*bounds.Dy()/height, (y+1)*bounds.Dy()/height
+		for x := range width {
+			x0, x1 := x*bounds.Dx()/width, (x+1)*bounds.Dx()/width
+			var sum [4]int
+			for sy := y0; sy < y1; sy++ {
+				row := in.Pix[in.PixOffset(x0, sy):in.PixOffset(x1, sy)]
+				for i := 0; i < len(row); i += 4 {
+					sum[0] += int(row[i])
+					sum[1] += int(row[i+1])
+					sum[2] += int(row[i+2])
+					sum[3] += int(row[i+3])
+				}
+			}
+			n := (y1 - y0) * (x1 - x0)
+			offset := out.PixOffset(x, y)
+			for c := range sum {
+				out.Pix[offset+c] = uint8(sum[c] / n)
+			}
+		}
+	}
+	return out
+}
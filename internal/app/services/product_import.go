@@ -0,0 +1,86 @@
+package services
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"oolio/internal/app/models"
+)
+
+// Columns a product import file must have. The header row names them in any order; the
+// image columns thumbnail, mobile, tablet and desktop are optional.
+var productCSVRequired = []string{"name", "price", "category"}
+
+// ParseProductCSV reads products from a CSV file with a header row, e.g.
+//
+//	name,price,category,thumbnail
+//	Chicken Waffle,12.50,Waffle,https://example.com/waffle.jpg
+//
+// Prices are decimal amounts. Errors name the offending line.
+func ParseProductCSV(r io.Reader) ([]models.Product, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("product file is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range productCSVRequired {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("missing %q column", name)
+		}
+	}
+
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var products []models.Product
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read product file: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+
+		price, err := models.ParseMoney(field(record, "price"))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		product := models.Product{
+			Name:     field(record, "name"),
+			Price:    price,
+			Category: field(record, "category"),
+			Image: models.Image{
+				Thumbnail: field(record, "thumbnail"),
+				Mobile:    field(record, "mobile"),
+				Tablet:    field(record, "tablet"),
+				Desktop:   field(record, "desktop"),
+			},
+		}
+		if product.Name == "" || product.Category == "" {
+			return nil, fmt.Errorf("line %d: name and category are required", line)
+		}
+		products = append(products, product)
+	}
+
+	return products, nil
+}
@@ -0,0 +1,383 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Push is the content of a push notification
+type Push struct {
+	Title   string
+	Body    string
+	OrderID string // The order it is about, passed to the app as data
+}
+
+// PushSender delivers push notifications to the devices of one platform, FCM or APNs. It
+// fails with ErrPushTokenInvalid when the provider no longer knows the device.
+type PushSender interface {
+	SendPush(ctx context.Context, token string, push Push) error
+}
+
+var (
+	ErrPushTokenInvalid         = errors.New("the push device token is no longer valid")
+	ErrPushPlatformNotSupported = errors.New("push notifications are not sent to this platform")
+	ErrInvalidPushToken         = errors.New("invalid push device token")
+	ErrPushDeviceNotFound       = errors.New("push device not found")
+)
+
+// logPushSender writes push notifications to the log instead of sending them, for local
+// development
+type logPushSender struct {
+	logger *zap.Logger
+}
+
+func NewLogPushSender(logger *zap.Logger) PushSender {
+	return &logPushSender{logger: logger}
+}
+
+func (s *logPushSender) SendPush(ctx context.Context, token string, push Push) error {
+	s.logger.Info("Push notification not sent (log provider)", zap.String("token", token), zap.String("title", push.Title), zap.String("body", push.Body))
+	return nil
+}
+
+// FCMOptions configure Firebase Cloud Messaging's HTTP v1 API with a service account
+type FCMOptions struct {
+	ProjectID   string
+	ClientEmail string
+	PrivateKey  string // PEM-encoded RSA key of the service account
+	TokenURL    string // Overrides https://oauth2.googleapis.com/token
+	BaseURL     string // Overrides https://fcm.googleapis.com
+	Timeout     time.Duration
+}
+
+// LoadFCMServiceAccount reads the project and key of FCMOptions from a service account's
+// JSON key file, as downloaded from the Firebase console
+func LoadFCMServiceAccount(path string) (FCMOptions, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return FCMOptions{}, fmt.Errorf("failed to read FCM service account: %w", err)
+	}
+	var account struct {
+		ProjectID   string `json:"project_id"`
+		ClientEmail string `json:"client_email"`
+		PrivateKey  string `json:"private_key"`
+		TokenURI    string `json:"token_uri"`
+	}
+	if err := json.Unmarshal(data, &account); err != nil {
+		return FCMOptions{}, fmt.Errorf("failed to parse FCM service account: %w", err)
+	}
+	if account.ProjectID == "" || account.ClientEmail == "" || account.PrivateKey == "" {
+		return FCMOptions{}, fmt.Errorf("FCM service account needs project_id, client_email and private_key")
+	}
+	return FCMOptions{
+		ProjectID:   account.ProjectID,
+		ClientEmail: account.ClientEmail,
+		PrivateKey:  account.PrivateKey,
+		TokenURL:    account.TokenURI,
+	}, nil
+}
+
+// fcmPushSender sends through FCM's HTTP v1 API, with an OAuth access token it gets for
+// the service account and reuses until shortly before it expires
+type fcmPushSender struct {
+	client *http.Client
+	key    *rsa.PrivateKey
+	opts   FCMOptions
+
+	mutex       sync.Mutex
+	accessToken string
+	expiresAt   time.Time
+}
+
+func NewFCMPushSender(opts FCMOptions) (PushSender, error) {
+	parsed, err := parsePrivateKey(opts.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid FCM private key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("invalid FCM private key: not an RSA key")
+	}
+	if opts.TokenURL == "" {
+		opts.TokenURL = "https://oauth2.googleapis.com/token"
+	}
+	if opts.BaseURL == "" {
+		opts.BaseURL = "https://fcm.googleapis.com"
+	}
+	opts.BaseURL = strings.TrimSuffix(opts.BaseURL, "/")
+	return &fcmPushSender{client: &http.Client{Timeout: opts.Timeout}, key: key, opts: opts}, nil
+}
+
+func (s *fcmPushSender) SendPush(ctx context.Context, token string, push Push) error {
+	accessToken, err := s.token(ctx)
+	if err != nil {
+		return err
+	}
+
+	message := map[string]any{
+		"token":        token,
+		"notification": map[string]string{"title": push.Title, "body": push.Body},
+	}
+	if push.OrderID != "" {
+		message["data"] = map[string]string{"orderId": push.OrderID}
+	}
+	body, err := json.Marshal(map[string]any{"message": message})
+	if err != nil {
+		return fmt.Errorf("failed to marshal push notification: %w", err)
+	}
+
+	endpoint := s.opts.BaseURL + "/v1/projects/" + url.PathEscape(s.opts.ProjectID) + "/messages:send"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build push request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send push notification: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var failure struct {
+		Error struct {
+			Status  string `json:"status"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	json.NewDecoder(io.LimitReader(resp.Body, 1<<16)).Decode(&failure)
+	switch {
+	case resp.StatusCode/100 == 2:
+		return nil
+	case resp.StatusCode == http.StatusNotFound || failure.Error.Status == "UNREGISTERED":
+		return ErrPushTokenInvalid
+	}
+	return fmt.Errorf("FCM rejected the push notification: %s %s", resp.Status, failure.Error.Message)
+}
+
+// token returns an access token for the service account, exchanging a signed assertion
+// for a new one once the last is about to expire
+func (s *fcmPushSender) token(ctx context.Context) (string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.accessToken != "" && time.Now().Before(s.expiresAt) {
+		return s.accessToken, nil
+	}
+
+	now := time.Now()
+	assertion, err := signJWT(map[string]string{"alg": "RS256", "typ": "JWT"}, map[string]any{
+		"iss":   s.opts.ClientEmail,
+		"scope": "https://www.googleapis.com/auth/firebase.messaging",
+		"aud":   s.opts.TokenURL,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	}, func(digest []byte) ([]byte, error) {
+		return rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, digest)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"}, "assertion": {assertion}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.opts.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to build FCM token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to get FCM access token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	json.NewDecoder(io.LimitReader(resp.Body, 1<<16)).Decode(&body)
+	if resp.StatusCode/100 != 2 || body.AccessToken == "" {
+		return "", fmt.Errorf("Google rejected the FCM service account: %s", resp.Status)
+	}
+
+	s.accessToken = body.AccessToken
+	// A minute early, so a token doesn't expire on its way to FCM
+	s.expiresAt = now.Add(time.Duration(body.ExpiresIn)*time.Second - time.Minute)
+	return s.accessToken, nil
+}
+
+// APNsOptions configure the Apple Push Notification service with a token signing key
+type APNsOptions struct {
+	KeyID      string
+	TeamID     string
+	PrivateKey string // PEM-encoded .p8 key from the Apple developer account
+	Topic      string // The app's bundle ID
+	Production bool   // Sends to production devices rather than development builds
+	BaseURL    string // Overrides the APNs endpoint chosen by Production
+	Timeout    time.Duration
+}
+
+// apnsTokenLifetime is how long a provider token is reused; Apple rejects tokens older than
+// an hour and ones refreshed more often than every 20 minutes
+const apnsTokenLifetime = 50 * time.Minute
+
+// apnsPushSender sends through APNs' HTTP/2 API with a provider token it signs with the key
+type apnsPushSender struct {
+	client *http.Client
+	key    *ecdsa.PrivateKey
+	opts   APNsOptions
+
+	mutex     sync.Mutex
+	jwt       string
+	expiresAt time.Time
+}
+
+func NewAPNsPushSender(opts APNsOptions) (PushSender, error) {
+	parsed, err := parsePrivateKey(opts.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid APNs private key: %w", err)
+	}
+	key, ok := parsed.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("invalid APNs private key: not an ECDSA key")
+	}
+	if opts.BaseURL == "" {
+		opts.BaseURL = "https://api.sandbox.push.apple.com"
+		if opts.Production {
+			opts.BaseURL = "https://api.push.apple.com"
+		}
+	}
+	opts.BaseURL = strings.TrimSuffix(opts.BaseURL, "/")
+	return &apnsPushSender{client: &http.Client{Timeout: opts.Timeout}, key: key, opts: opts}, nil
+}
+
+func (s *apnsPushSender) SendPush(ctx context.Context, token string, push Push) error {
+	jwt, err := s.token()
+	if err != nil {
+		return err
+	}
+
+	payload := map[string]any{
+		"aps": map[string]any{
+			"alert": map[string]string{"title": push.Title, "body": push.Body},
+			"sound": "default",
+		},
+	}
+	if push.OrderID != "" {
+		payload["orderId"] = push.OrderID
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal push notification: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.opts.BaseURL+"/3/device/"+url.PathEscape(token), bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build push request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "bearer "+jwt)
+	req.Header.Set("apns-topic", s.opts.Topic)
+	req.Header.Set("apns-push-type", "alert")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send push notification: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var failure struct {
+		Reason string `json:"reason"`
+	}
+	json.NewDecoder(io.LimitReader(resp.Body, 1<<16)).Decode(&failure)
+	switch {
+	case resp.StatusCode/100 == 2:
+		return nil
+	case resp.StatusCode == http.StatusGone || failure.Reason == "BadDeviceToken" || failure.Reason == "Unregistered":
+		return ErrPushTokenInvalid
+	}
+	return fmt.Errorf("APNs rejected the push notification: %s %s", resp.Status, failure.Reason)
+}
+
+// token returns the provider token, signing a new one once the last is old enough
+func (s *apnsPushSender) token() (string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.jwt != "" && time.Now().Before(s.expiresAt) {
+		return s.jwt, nil
+	}
+
+	now := time.Now()
+	jwt, err := signJWT(map[string]string{"alg": "ES256", "kid": s.opts.KeyID}, map[string]any{
+		"iss": s.opts.TeamID,
+		"iat": now.Unix(),
+	}, func(digest []byte) ([]byte, error) {
+		r, sig, err := ecdsa.Sign(rand.Reader, s.key, digest)
+		if err != nil {
+			return nil, err
+		}
+		// JWS wants r and s as fixed-width big-endian halves rather than ASN.1
+		signature := make([]byte, 64)
+		r.FillBytes(signature[:32])
+		sig.FillBytes(signature[32:])
+		return signature, nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	s.jwt = jwt
+	s.expiresAt = now.Add(apnsTokenLifetime)
+	return s.jwt, nil
+}
+
+// signJWT encodes the header and claims and signs them with sign, which is given their
+// SHA-256 digest
+func signJWT(header map[string]string, claims map[string]any, sign func(digest []byte) ([]byte, error)) (string, error) {
+	headerJSON, err := json.Marshal(header)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal JWT header: %w", err)
+	}
+	claimsJSON, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal JWT claims: %w", err)
+	}
+
+	signed := base64.RawURLEncoding.EncodeToString(headerJSON) + "." + base64.RawURLEncoding.EncodeToString(claimsJSON)
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := sign(digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign JWT: %w", err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// parsePrivateKey reads a PEM-encoded PKCS #8 key, as issued by Google and Apple
+func parsePrivateKey(key string) (any, error) {
+	block, _ := pem.Decode([]byte(key))
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block found")
+	}
+	return x509.ParsePKCS8PrivateKey(block.Bytes)
+}
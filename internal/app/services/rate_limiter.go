@@ -17,7 +17,7 @@ type RateLimiterService interface {
 }
 
 type rateLimiterService struct {
-	redisClient *redis.Client
+	redisClient redis.UniversalClient
 	luaScript   *redis.Script
 }
 
@@ -50,17 +50,11 @@ else
 end
 `
 
-func NewRateLimiterService(redisAddr, redisPassword string, redisDB int) RateLimiterService {
-	rdb := redis.NewClient(&redis.Options{
-		Addr:     redisAddr,
-		Password: redisPassword,
-		DB:       redisDB,
-	})
-
+func NewRateLimiterService(redisClient redis.UniversalClient) RateLimiterService {
 	script := redis.NewScript(tokenBucketScript)
 
 	return &rateLimiterService{
-		redisClient: rdb,
+		redisClient: redisClient,
 		luaScript:   script,
 	}
 }
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"oolio/internal/app/models"
+	"oolio/internal/app/repository"
+)
+
+// ReconciliationService recomputes the totals and discounts of recent orders from the
+// prices their items were sold at, and keeps the mismatches for finance to review
+type ReconciliationService interface {
+	// Reconcile checks the orders changed at or after since and records what doesn't add
+	// up. Discrepancies of checked orders that add up again are removed.
+	Reconcile(ctx context.Context, since time.Time) (*models.Reconciliation, error)
+	// Discrepancies lists the recorded discrepancies, of the store's orders when storeID
+	// isn't empty
+	Discrepancies(ctx context.Context, storeID string) (*models.DiscrepancyReport, error)
+}
+
+type reconciliationService struct {
+	orders        repository.OrderRepository
+	discrepancies repository.OrderDiscrepancyRepository
+	coupons       CouponService
+	logger        *zap.Logger
+}
+
+func NewReconciliationService(orders repository.OrderRepository, discrepancies repository.OrderDiscrepancyRepository, coupons CouponService, logger *zap.Logger) ReconciliationService {
+	return &reconciliationService{orders: orders, discrepancies: discrepancies, coupons: coupons, logger: logger}
+}
+
+func (s *reconciliationService) Reconcile(ctx context.Context, since time.Time) (*models.Reconciliation, error) {
+	orders, err := s.orders.FindUpdatedSince(ctx, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reconcile orders: %w", err)
+	}
+
+	var checked []string
+	var found []models.OrderDiscrepancy
+	for _, order := range orders {
+		// Nothing was charged for these
+		if order.Status == models.OrderStatusCancelled || order.Status == models.OrderStatusFailed {
+			continue
+		}
+		checked = append(checked, order.ID)
+		found = append(found, s.check(order)...)
+	}
+
+	if err := s.discrepancies.Record(ctx, checked, found); err != nil {
+		return nil, fmt.Errorf("failed to reconcile orders: %w", err)
+	}
+	return &models.Reconciliation{Since: since, Checked: len(checked), Found: len(found)}, nil
+}
+
+// check recomputes the order's total from its items and its discounts from its coupon. The
+// discounts go unchecked when the coupon no longer resolves, since its terms are unknown.
+func (s *reconciliationService) check(order models.Order) []models.OrderDiscrepancy {
+	var total models.Money
+	for _, item := range order.Items {
+		total += item.Price.Mul(item.Quantity)
+	}
+
+	var found []models.OrderDiscrepancy
+	discrepancy := func(kind string, recorded, expected models.Money) {
+		found = append(found, models.OrderDiscrepancy{
+			OrderID:    order.ID,
+			StoreID:    order.StoreID,
+			Kind:       kind,
+			Recorded:   recorded,
+			Expected:   expected,
+			CouponCode: order.CouponCode,
+			OrderedAt:  order.CreatedAt,
+		})
+	}
+	if order.Total != total {
+		discrepancy(models.DiscrepancyTotal, order.Total, total)
+	}
+
+	if order.CouponCode == "" {
+		if order.Discounts != 0 {
+			discrepancy(models.DiscrepancyDiscount, order.Discounts, 0)
+		}
+		return found
+	}
+	coupon, ok := s.coupons.ResolveCoupon(order.CouponCode)
+	if !ok || coupon.Discount <= 0 || coupon.Discount > 100 {
+		return found
+	}
+	if discounts := total.Percent(coupon.Discount); order.Discounts != discounts {
+		discrepancy(models.DiscrepancyDiscount, order.Discounts, discounts)
+	}
+	return found
+}
+
+func (s *reconciliationService) Discrepancies(ctx context.Context, storeID string) (*models.DiscrepancyReport, error) {
+	discrepancies, err := s.discrepancies.Find(ctx, storeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list discrepancies: %w", err)
+	}
+	return &models.DiscrepancyReport{StoreID: storeID, Count: len(discrepancies), Discrepancies: discrepancies}, nil
+}
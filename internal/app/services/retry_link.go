@@ -0,0 +1,57 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetryLinks issues and checks the links in operator alerts that requeue an order that
+// failed for good. A link carries its expiry and an HMAC-SHA256 of the queue item ID and
+// expiry, so it can't be used for another order or after it expires.
+type RetryLinks struct {
+	secret  []byte
+	baseURL string
+	ttl     time.Duration
+}
+
+// NewRetryLinks returns nil without a secret or base URL, which leaves alerts without links
+func NewRetryLinks(secret, baseURL string, ttl time.Duration) *RetryLinks {
+	if secret == "" || baseURL == "" {
+		return nil
+	}
+	return &RetryLinks{secret: []byte(secret), baseURL: strings.TrimSuffix(baseURL, "/"), ttl: ttl}
+}
+
+// URL returns the link that retries the queue item, or "" when links are disabled
+func (l *RetryLinks) URL(itemID string) string {
+	if l == nil {
+		return ""
+	}
+	expires := strconv.FormatInt(time.Now().Add(l.ttl).Unix(), 10)
+	query := url.Values{"expires": {expires}, "signature": {l.sign(itemID, expires)}}
+	return l.baseURL + "/api/v1/queue/" + url.PathEscape(itemID) + "/retry?" + query.Encode()
+}
+
+// Valid reports whether the expires and signature of a link were issued for the queue item
+// and haven't expired
+func (l *RetryLinks) Valid(itemID, expires, signature string) bool {
+	if l == nil {
+		return false
+	}
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() >= unix {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(l.sign(itemID, expires)))
+}
+
+func (l *RetryLinks) sign(itemID, expires string) string {
+	mac := hmac.New(sha256.New, l.secret)
+	mac.Write([]byte("retry|" + itemID + "|" + expires))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,167 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"oolio/internal/app/events"
+	"oolio/internal/app/models"
+	"oolio/internal/app/repository"
+)
+
+// SalesDigestService sums up a day's orders for the restaurant: how many there were, what
+// they came to, what sold best and which coupons were used
+type SalesDigestService interface {
+	// Build sums up the orders placed on the calendar day of date, in the digest's time zone
+	Build(ctx context.Context, date time.Time) (*models.SalesDigest, error)
+	// Send emails the digest to every recipient and posts it to the webhook
+	Send(ctx context.Context, digest *models.SalesDigest) error
+	// SendDay builds the digest of the day date falls on and sends it
+	SendDay(ctx context.Context, date time.Time) error
+	// Enabled reports whether there are recipients or a webhook to send the digest to
+	Enabled() bool
+}
+
+// ErrSalesDigestDisabled is returned by Send when there is nowhere to send the digest
+var ErrSalesDigestDisabled = errors.New("the sales digest has no recipients or webhook")
+
+type SalesDigestOptions struct {
+	Recipients  []string // Email addresses; nothing is emailed without an email sender
+	Location    *time.Location
+	TopProducts int // How many of the best selling products are listed
+
+	// Webhook queues the digest as a CloudEvent for the digest webhook endpoint
+	Webhook bool
+	Source  string // CloudEvents source, see events.New
+
+	Templates *EmailTemplates
+}
+
+type salesDigestService struct {
+	orders   repository.OrderRepository
+	email    EmailSender
+	webhooks WebhookService
+	opts     SalesDigestOptions
+	logger   *zap.Logger
+}
+
+func NewSalesDigestService(orders repository.OrderRepository, email EmailSender, webhooks WebhookService, opts SalesDigestOptions, logger *zap.Logger) SalesDigestService {
+	if opts.Location == nil {
+		opts.Location = time.UTC
+	}
+	if opts.TopProducts <= 0 {
+		opts.TopProducts = 5
+	}
+	if opts.Templates == nil {
+		opts.Templates = DefaultEmailTemplates()
+	}
+
+	return &salesDigestService{
+		orders:   orders,
+		email:    email,
+		webhooks: webhooks,
+		opts:     opts,
+		logger:   logger,
+	}
+}
+
+func (s *salesDigestService) Build(ctx context.Context, date time.Time) (*models.SalesDigest, error) {
+	from := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, s.opts.Location)
+	to := from.AddDate(0, 0, 1)
+
+	methods, err := s.orders.PaymentMethodTotals(ctx, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build sales digest: %w", err)
+	}
+	products, err := s.orders.TopProducts(ctx, from, to, s.opts.TopProducts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build sales digest: %w", err)
+	}
+	coupons, err := s.orders.CouponUsage(ctx, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build sales digest: %w", err)
+	}
+
+	digest := &models.SalesDigest{
+		From:           from,
+		To:             to,
+		PaymentMethods: methods,
+		TopProducts:    products,
+		Coupons:        coupons,
+	}
+	for _, method := range methods {
+		digest.Orders += method.Orders
+		digest.Revenue += method.Paid
+	}
+	return digest, nil
+}
+
+func (s *salesDigestService) Send(ctx context.Context, digest *models.SalesDigest) error {
+	if !s.Enabled() {
+		return ErrSalesDigestDisabled
+	}
+
+	var errs []error
+	if s.email != nil && len(s.opts.Recipients) > 0 {
+		email, err := s.opts.Templates.Render(EmailSalesDigest, struct {
+			Date   string
+			Digest *models.SalesDigest
+		}{Date: digest.From.Format("Mon 2 Jan 2006"), Digest: digest})
+		if err != nil {
+			return err
+		}
+		for _, to := range s.opts.Recipients {
+			if err := s.email.SendEmail(ctx, to, email.Subject, email.Text); err != nil {
+				errs = append(errs, fmt.Errorf("failed to email sales digest to %s: %w", to, err))
+			}
+		}
+	}
+
+	if s.webhooks != nil && s.opts.Webhook {
+		if err := s.post(ctx, digest); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// post queues the digest as a CloudEvent whose ID is the day, so sending a day's digest
+// again doesn't deliver it twice
+func (s *salesDigestService) post(ctx context.Context, digest *models.SalesDigest) error {
+	data, err := json.Marshal(digest)
+	if err != nil {
+		return fmt.Errorf("failed to marshal sales digest: %w", err)
+	}
+
+	day := digest.From.Format(time.DateOnly)
+	event := events.New(s.opts.Source, "sales-digest:"+day, "sales", day, models.EventSalesDigest, data, time.Now())
+	if err := s.webhooks.Enqueue(ctx, models.WebhookEndpointDigest, event); err != nil {
+		return fmt.Errorf("failed to post sales digest: %w", err)
+	}
+	return nil
+}
+
+func (s *salesDigestService) SendDay(ctx context.Context, date time.Time) error {
+	digest, err := s.Build(ctx, date)
+	if err != nil {
+		return err
+	}
+	if err := s.Send(ctx, digest); err != nil {
+		return err
+	}
+
+	s.logger.Info("Sent sales digest",
+		zap.Time("from", digest.From),
+		zap.Int("orders", digest.Orders),
+		zap.Stringer("revenue", digest.Revenue))
+	return nil
+}
+
+func (s *salesDigestService) Enabled() bool {
+	return (s.email != nil && len(s.opts.Recipients) > 0) || (s.webhooks != nil && s.opts.Webhook)
+}
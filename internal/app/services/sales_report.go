@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"oolio/internal/app/models"
+	"oolio/internal/app/repository"
+)
+
+// MaxSalesReportDays is the longest range of days a sales report covers
+const MaxSalesReportDays = 366
+
+var (
+	ErrInvalidSalesGrouping = fmt.Errorf("group_by is one of %s", strings.Join(models.SalesGroupings, ", "))
+	ErrInvalidSalesRange    = fmt.Errorf("from must not be after to, and they cover at most %d days", MaxSalesReportDays)
+)
+
+// SalesReportService sums up the orders placed over a range of days: how many there were,
+// what they came to and what was taken off, per day, product or category
+type SalesReportService interface {
+	// Build sums up the orders placed from the calendar day of from to that of to, both
+	// included, in the report's time zone. groupBy defaults to day; storeID, when set,
+	// narrows the orders to that store's. It fails with ErrInvalidSalesGrouping or
+	// ErrInvalidSalesRange.
+	Build(ctx context.Context, groupBy string, from, to time.Time, storeID string) (*models.SalesReport, error)
+}
+
+type salesReportService struct {
+	orders   repository.OrderRepository
+	location *time.Location
+}
+
+// NewSalesReportService returns the sales report service; days start at midnight in
+// location, UTC when nil
+func NewSalesReportService(orders repository.OrderRepository, location *time.Location) SalesReportService {
+	if location == nil {
+		location = time.UTC
+	}
+	return &salesReportService{orders: orders, location: location}
+}
+
+func (s *salesReportService) Build(ctx context.Context, groupBy string, from, to time.Time, storeID string) (*models.SalesReport, error) {
+	groupBy = strings.ToLower(strings.TrimSpace(groupBy))
+	if groupBy == "" {
+		groupBy = models.SalesByDay
+	}
+	if !slices.Contains(models.SalesGroupings, groupBy) {
+		return nil, ErrInvalidSalesGrouping
+	}
+
+	since := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, s.location)
+	until := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, s.location).AddDate(0, 0, 1)
+	if !since.Before(until) || since.AddDate(0, 0, MaxSalesReportDays).Before(until) {
+		return nil, ErrInvalidSalesRange
+	}
+
+	query := models.SalesQuery{GroupBy: models.SalesByDay, Since: since, Until: until, Location: s.location, StoreID: storeID}
+	days, err := s.orders.Sales(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build sales report: %w", err)
+	}
+	groups := days
+	if groupBy != models.SalesByDay {
+		query.GroupBy = groupBy
+		groups, err = s.orders.Sales(ctx, query)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build sales report: %w", err)
+		}
+	}
+
+	report := &models.SalesReport{
+		From:     since,
+		To:       until,
+		TimeZone: s.location.String(),
+		StoreID:  storeID,
+		GroupBy:  groupBy,
+		Groups:   make([]models.SalesGroup, len(groups)),
+	}
+	// Orders span products and categories, so the totals are summed from the days
+	for _, day := range days {
+		report.Totals.Orders += day.Orders
+		report.Totals.Revenue += day.Revenue
+		report.Totals.Discounts += day.Discounts
+	}
+	report.Totals.AverageOrderValue = averageOrderValue(report.Totals)
+	for i, group := range groups {
+		group.AverageOrderValue = averageOrderValue(group.SalesFigures)
+		report.Groups[i] = group
+	}
+	return report, nil
+}
+
+// averageOrderValue is the revenue per order, rounded to the nearest cent
+func averageOrderValue(figures models.SalesFigures) models.Money {
+	if figures.Orders == 0 {
+		return 0
+	}
+	return models.Money(math.Round(float64(figures.Revenue) / float64(figures.Orders)))
+}
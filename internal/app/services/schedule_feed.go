@@ -0,0 +1,247 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"oolio/internal/app/models"
+	"oolio/internal/app/repository"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// scheduleFeedPast keeps orders in the feed for a day after their time, so the day's
+	// orders stay on the calendar once they are processed
+	scheduleFeedPast = 24 * time.Hour
+	// scheduleEventDuration is the length of each order's calendar event
+	scheduleEventDuration = 15 * time.Minute
+)
+
+// ScheduleService lists the orders placed for a later time, for the kitchen to plan prep by
+type ScheduleService interface {
+	// Upcoming returns the store's scheduled orders from a day before now until as far
+	// ahead as orders can be scheduled, soonest first; every store's without a storeID
+	Upcoming(ctx context.Context, storeID string, now time.Time) ([]models.ScheduledOrder, error)
+}
+
+type scheduleService struct {
+	queueRepo   repository.OrderQueueRepository
+	productRepo repository.ProductRepository
+}
+
+func NewScheduleService(queueRepo repository.OrderQueueRepository, productRepo repository.ProductRepository) ScheduleService {
+	return &scheduleService{queueRepo: queueRepo, productRepo: productRepo}
+}
+
+func (s *scheduleService) Upcoming(ctx context.Context, storeID string, now time.Time) ([]models.ScheduledOrder, error) {
+	items, err := s.queueRepo.FindScheduled(ctx, storeID, now.Add(-scheduleFeedPast), now.Add(maxScheduleAhead+scheduleFeedPast))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find scheduled orders: %w", err)
+	}
+
+	// Orders naming products that can't exist are listed too; they fail when processed
+	var productIDs []string
+	for _, item := range items {
+		for _, id := range item.OrderReq.ProductIDs() {
+			if _, err := uuid.Parse(id); err == nil {
+				productIDs = append(productIDs, id)
+			}
+		}
+	}
+	names := make(map[string]string)
+	if len(productIDs) > 0 {
+		products, err := s.productRepo.FindByIDs(ctx, productIDs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find the products of scheduled orders: %w", err)
+		}
+		for _, product := range products {
+			names[product.ID] = product.Name
+		}
+	}
+
+	orders := make([]models.ScheduledOrder, len(items))
+	for i, item := range items {
+		order := models.ScheduledOrder{
+			QueueItemID:   item.ID,
+			StoreID:       models.StoreOrDefault(item.OrderReq.StoreID),
+			ScheduledFor:  *item.OrderReq.ScheduledFor,
+			Status:        item.Status,
+			PaymentMethod: item.OrderReq.PaymentMethod,
+			Delivery:      item.OrderReq.DeliveryAddress != nil,
+			Items:         make([]models.ScheduledOrderItem, len(item.OrderReq.Items)),
+		}
+		for j, line := range item.OrderReq.Items {
+			name := names[line.ProductID]
+			if name == "" {
+				name = line.ProductID
+			}
+			order.Items[j] = models.ScheduledOrderItem{ProductID: line.ProductID, Name: name, Quantity: line.Quantity}
+		}
+		orders[i] = order
+	}
+	return orders, nil
+}
+
+// WriteScheduleICS writes the orders as an iCalendar feed with an event for each
+func WriteScheduleICS(w io.Writer, orders []models.ScheduledOrder, now time.Time) error {
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//oolio//Scheduled orders//EN",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		"X-WR-CALNAME:Scheduled orders",
+	}
+	stamp := now.UTC().Format("20060102T150405Z")
+	for _, order := range orders {
+		description := make([]string, 0, len(order.Items)+2)
+		for _, item := range order.Items {
+			description = append(description, fmt.Sprintf("%d × %s", item.Quantity, item.Name))
+		}
+		description = append(description, "Status: "+order.Status, "Queue item: "+order.QueueItemID)
+
+		lines = append(lines,
+			"BEGIN:VEVENT",
+			"UID:"+order.QueueItemID+"@oolio",
+			"DTSTAMP:"+stamp,
+			"DTSTART:"+order.ScheduledFor.UTC().Format("20060102T150405Z"),
+			"DTEND:"+order.ScheduledFor.Add(scheduleEventDuration).UTC().Format("20060102T150405Z"),
+			"SUMMARY:"+escapeICSText(scheduleSummary(order)),
+			"DESCRIPTION:"+escapeICSText(strings.Join(description, "\n")),
+			"END:VEVENT",
+		)
+	}
+	lines = append(lines, "END:VCALENDAR")
+
+	for _, line := range lines {
+		if _, err := io.WriteString(w, foldICSLine(line)+"\r\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteScheduleCSV writes the orders as CSV, one row per order
+func WriteScheduleCSV(w io.Writer, orders []models.ScheduledOrder) error {
+	out := csv.NewWriter(w)
+	out.Write([]string{"scheduled_for", "queue_item_id", "store_id", "status", "item_count", "items", "payment_method", "delivery"})
+	for _, order := range orders {
+		count := 0
+		items := make([]string, len(order.Items))
+		for i, item := range order.Items {
+			count += item.Quantity
+			items[i] = fmt.Sprintf("%d × %s", item.Quantity, item.Name)
+		}
+		out.Write([]string{
+			order.ScheduledFor.UTC().Format(time.RFC3339),
+			order.QueueItemID,
+			order.StoreID,
+			order.Status,
+			strconv.Itoa(count),
+			strings.Join(items, "; "),
+			order.PaymentMethod,
+			strconv.FormatBool(order.Delivery),
+		})
+	}
+	out.Flush()
+	return out.Error()
+}
+
+func scheduleSummary(order models.ScheduledOrder) string {
+	count := 0
+	for _, item := range order.Items {
+		count += item.Quantity
+	}
+	summary := fmt.Sprintf("Order of %d items", count)
+	if count == 1 {
+		summary = "Order of 1 item"
+	}
+	if order.Delivery {
+		summary += " for delivery"
+	}
+	return summary
+}
+
+// escapeICSText escapes a TEXT value as RFC 5545 asks
+func escapeICSText(text string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(text)
+}
+
+// foldICSLine breaks lines longer than 75 octets, continuing them on lines that start with
+// a space, without splitting a UTF-8 sequence
+func foldICSLine(line string) string {
+	const limit = 75
+	var folded strings.Builder
+	width := 0
+	for _, r := range line {
+		size := len(string(r))
+		if width+size > limit {
+			folded.WriteString("\r\n ")
+			width = 1
+		}
+		folded.WriteRune(r)
+		width += size
+	}
+	return folded.String()
+}
+
+// ScheduleFeedLinks issues and checks the signed URLs of the schedule feed. A URL carries
+// its expiry and an HMAC-SHA256 of the store and expiry, so it only lists that store's
+// orders and stops working when it expires.
+type ScheduleFeedLinks struct {
+	secret  []byte
+	baseURL string
+	ttl     time.Duration
+}
+
+// NewScheduleFeedLinks returns nil without a secret or base URL, which disables the feed
+func NewScheduleFeedLinks(secret, baseURL string, ttl time.Duration) *ScheduleFeedLinks {
+	if secret == "" || baseURL == "" {
+		return nil
+	}
+	return &ScheduleFeedLinks{secret: []byte(secret), baseURL: strings.TrimSuffix(baseURL, "/"), ttl: ttl}
+}
+
+// Issue returns the feed's URLs for the store, or for every store without a storeID
+func (l *ScheduleFeedLinks) Issue(storeID string) models.ScheduleFeedLinks {
+	expiresAt := time.Now().Add(l.ttl).Truncate(time.Second)
+	expires := strconv.FormatInt(expiresAt.Unix(), 10)
+	query := url.Values{"expires": {expires}, "signature": {l.sign(storeID, expires)}}
+	if storeID != "" {
+		query.Set("store_id", storeID)
+	}
+	return models.ScheduleFeedLinks{
+		ICS:       l.baseURL + "/api/v1/admin/orders/schedule.ics?" + query.Encode(),
+		CSV:       l.baseURL + "/api/v1/admin/orders/schedule.csv?" + query.Encode(),
+		ExpiresAt: expiresAt,
+	}
+}
+
+// Valid reports whether the expires and signature of a URL were issued for the store and
+// haven't expired
+func (l *ScheduleFeedLinks) Valid(storeID, expires, signature string) bool {
+	if l == nil {
+		return false
+	}
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() >= unix {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(l.sign(storeID, expires)))
+}
+
+func (l *ScheduleFeedLinks) sign(storeID, expires string) string {
+	mac := hmac.New(sha256.New, l.secret)
+	mac.Write([]byte("schedule|" + storeID + "|" + expires))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
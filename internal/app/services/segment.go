@@ -0,0 +1,57 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"oolio/internal/app/models"
+	"oolio/internal/app/repository"
+)
+
+type SegmentService interface {
+	// SegmentFor returns the customer's segment. Customers who have not ordered since the
+	// last refresh are new; guests (no customer ID) have no segment.
+	SegmentFor(ctx context.Context, customerID string) (string, error)
+	// Refresh recomputes every customer's segment from their orders
+	Refresh(ctx context.Context) error
+}
+
+type segmentService struct {
+	repo   repository.SegmentRepository
+	rules  models.SegmentRules
+	logger *zap.Logger
+}
+
+func NewSegmentService(repo repository.SegmentRepository, rules models.SegmentRules, logger *zap.Logger) SegmentService {
+	return &segmentService{repo: repo, rules: rules, logger: logger}
+}
+
+func (s *segmentService) SegmentFor(ctx context.Context, customerID string) (string, error) {
+	if customerID == "" {
+		return "", nil
+	}
+
+	segment, err := s.repo.FindByCustomer(ctx, customerID)
+	if err != nil {
+		if err.Error() == "segment not found" {
+			return models.SegmentNew, nil
+		}
+		return "", err
+	}
+	return segment.Segment, nil
+}
+
+func (s *segmentService) Refresh(ctx context.Context) error {
+	start := time.Now()
+	updated, err := s.repo.Refresh(ctx, s.rules, start)
+	if err != nil {
+		return err
+	}
+
+	s.logger.Info("Refreshed customer segments",
+		zap.Int64("customers", updated),
+		zap.Duration("duration", time.Since(start)))
+	return nil
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"oolio/internal/app/models"
+)
+
+// Breaker reports whether a dependency is known to be down
+type Breaker interface {
+	Open() bool
+}
+
+type StatusService interface {
+	// Status returns the current status, computed at most once per cache TTL
+	Status(ctx context.Context) models.ServiceStatus
+}
+
+// StatusOptions describes what the status is derived from
+type StatusOptions struct {
+	Database         Breaker
+	Queue            OrderQueueService
+	WorkerInterval   time.Duration
+	WorkerBatchSize  func() int // Read on every refresh, as the batch size can be reloaded
+	BacklogThreshold int        // Pending orders at which the service reports degraded; 0 disables
+	CacheTTL         time.Duration
+}
+
+type statusService struct {
+	opts StatusOptions
+
+	mutex  sync.Mutex
+	cached models.ServiceStatus
+}
+
+// NewStatusService returns a status service. The status is cached for CacheTTL so the
+// public endpoint never puts load on the database, however often frontends poll it.
+func NewStatusService(opts StatusOptions) StatusService {
+	return &statusService{opts: opts}
+}
+
+func (s *statusService) Status(ctx context.Context) models.ServiceStatus {
+	// Holding the lock while refreshing makes concurrent callers share one refresh
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !s.cached.UpdatedAt.IsZero() && time.Since(s.cached.UpdatedAt) < s.opts.CacheTTL {
+		return s.cached
+	}
+	s.cached = s.compute(ctx)
+	return s.cached
+}
+
+func (s *statusService) compute(ctx context.Context) models.ServiceStatus {
+	status := models.ServiceStatus{Status: models.StatusOperational, AcceptingOrders: true, UpdatedAt: time.Now()}
+
+	// Order routes answer 503 while the database breaker is open
+	if s.opts.Database != nil && s.opts.Database.Open() {
+		status.Status = models.StatusUnavailable
+		status.AcceptingOrders = false
+		return status
+	}
+
+	stats, err := s.opts.Queue.GetQueueStatus(ctx)
+	if err != nil {
+		log.Printf("Failed to get queue stats for status: %v", err)
+		status.Status = models.StatusDegraded
+		return status
+	}
+
+	pending := stats["pending"]
+	if s.opts.BacklogThreshold > 0 && pending >= s.opts.BacklogThreshold {
+		status.Status = models.StatusDegraded
+	}
+
+	// A new order waits for the batches ahead of it plus its own
+	if batchSize := s.opts.WorkerBatchSize(); batchSize > 0 {
+		batches := pending/batchSize + 1
+		wait := int(math.Ceil((time.Duration(batches) * s.opts.WorkerInterval).Seconds()))
+		status.EstimatedWaitSeconds = &wait
+	}
+	return status
+}
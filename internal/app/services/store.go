@@ -0,0 +1,201 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"oolio/internal/app/models"
+	"oolio/internal/app/repository"
+)
+
+var (
+	ErrInvalidStoreName = errors.New("store and API key names are 1-100 characters")
+	ErrUnknownAPIKey    = errors.New("the API key is not a store's key")
+)
+
+// storeAPIKeyPrefix starts every store API key, so they are told apart from API_KEY in logs
+const storeAPIKeyPrefix = "sk_"
+
+type StoreService interface {
+	ListStores(ctx context.Context) ([]models.Store, error)
+	GetStore(ctx context.Context, id string) (*models.Store, error)
+	CreateStore(ctx context.Context, req models.StoreReq) (*models.Store, error)
+	UpdateStore(ctx context.Context, id string, req models.StoreReq) (*models.Store, error)
+	// ListAPIKeys returns the store's keys without the keys themselves
+	ListAPIKeys(ctx context.Context, storeID string) ([]models.StoreAPIKey, error)
+	// CreateAPIKey returns the new key with Key set. Only its hash is kept, so it can't be
+	// shown again.
+	CreateAPIKey(ctx context.Context, storeID string, req models.StoreAPIKeyReq) (*models.StoreAPIKey, error)
+	RevokeAPIKey(ctx context.Context, storeID, id string) error
+	// ResolveAPIKey returns an unrevoked store API key, without the key itself, and
+	// ErrUnknownAPIKey for any other key
+	ResolveAPIKey(ctx context.Context, apiKey string) (*models.StoreAPIKey, error)
+	// GetOpeningHours returns the store's hours, or nil when it is always open
+	GetOpeningHours(ctx context.Context, storeID string) (*models.OpeningHours, error)
+	SetOpeningHours(ctx context.Context, storeID string, hours models.OpeningHours) (*models.OpeningHours, error)
+	// DeleteOpeningHours leaves the store always open
+	DeleteOpeningHours(ctx context.Context, storeID string) error
+	// ScheduleOrder decides when an order placed at now is processed: nil for straight
+	// away, or the time it is scheduled for. requested is the time the customer asked for,
+	// if any. Orders for a time the store is closed fail with *StoreClosedError, unless
+	// they were placed for now and the store schedules them for its next opening.
+	ScheduleOrder(ctx context.Context, storeID string, now time.Time, requested *time.Time) (*time.Time, error)
+}
+
+type storeService struct {
+	repo repository.StoreRepository
+}
+
+func NewStoreService(repo repository.StoreRepository) StoreService {
+	return &storeService{repo: repo}
+}
+
+func (s *storeService) ListStores(ctx context.Context) ([]models.Store, error) {
+	return s.repo.FindAll(ctx)
+}
+
+func (s *storeService) GetStore(ctx context.Context, id string) (*models.Store, error) {
+	return s.repo.FindOne(ctx, id)
+}
+
+func (s *storeService) CreateStore(ctx context.Context, req models.StoreReq) (*models.Store, error) {
+	name, err := storeName(req.Name)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.Create(ctx, name)
+}
+
+func (s *storeService) UpdateStore(ctx context.Context, id string, req models.StoreReq) (*models.Store, error) {
+	name, err := storeName(req.Name)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.Update(ctx, id, name)
+}
+
+func (s *storeService) ListAPIKeys(ctx context.Context, storeID string) ([]models.StoreAPIKey, error) {
+	if _, err := s.repo.FindOne(ctx, storeID); err != nil {
+		return nil, err
+	}
+	return s.repo.FindAPIKeys(ctx, storeID)
+}
+
+func (s *storeService) CreateAPIKey(ctx context.Context, storeID string, req models.StoreAPIKeyReq) (*models.StoreAPIKey, error) {
+	name, err := storeName(req.Name)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.repo.FindOne(ctx, storeID); err != nil {
+		return nil, err
+	}
+
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate store API key: %w", err)
+	}
+	apiKey := storeAPIKeyPrefix + hex.EncodeToString(secret)
+
+	key := &models.StoreAPIKey{
+		StoreID: storeID,
+		Name:    name,
+		Prefix:  apiKey[:len(storeAPIKeyPrefix)+6],
+		Test:    req.Test,
+	}
+	if err := s.repo.CreateAPIKey(ctx, key, hashAPIKey(apiKey)); err != nil {
+		return nil, err
+	}
+	key.Key = apiKey
+	return key, nil
+}
+
+func (s *storeService) RevokeAPIKey(ctx context.Context, storeID, id string) error {
+	return s.repo.RevokeAPIKey(ctx, storeID, id)
+}
+
+func (s *storeService) ResolveAPIKey(ctx context.Context, apiKey string) (*models.StoreAPIKey, error) {
+	if !strings.HasPrefix(apiKey, storeAPIKeyPrefix) {
+		return nil, ErrUnknownAPIKey
+	}
+
+	key, err := s.repo.FindAPIKeyByHash(ctx, hashAPIKey(apiKey))
+	if err != nil {
+		if err.Error() == "store API key not found" {
+			return nil, ErrUnknownAPIKey
+		}
+		return nil, fmt.Errorf("failed to resolve store API key: %w", err)
+	}
+	return key, nil
+}
+
+func (s *storeService) GetOpeningHours(ctx context.Context, storeID string) (*models.OpeningHours, error) {
+	if _, err := s.repo.FindOne(ctx, storeID); err != nil {
+		return nil, err
+	}
+	return s.repo.FindOpeningHours(ctx, storeID)
+}
+
+func (s *storeService) SetOpeningHours(ctx context.Context, storeID string, hours models.OpeningHours) (*models.OpeningHours, error) {
+	if err := validateOpeningHours(&hours); err != nil {
+		return nil, err
+	}
+	if _, err := s.repo.FindOne(ctx, storeID); err != nil {
+		return nil, err
+	}
+	if err := s.repo.SaveOpeningHours(ctx, storeID, &hours); err != nil {
+		return nil, err
+	}
+	return &hours, nil
+}
+
+func (s *storeService) DeleteOpeningHours(ctx context.Context, storeID string) error {
+	if _, err := s.repo.FindOne(ctx, storeID); err != nil {
+		return err
+	}
+	_, err := s.repo.DeleteOpeningHours(ctx, storeID)
+	return err
+}
+
+func (s *storeService) ScheduleOrder(ctx context.Context, storeID string, now time.Time, requested *time.Time) (*time.Time, error) {
+	if requested != nil && (!requested.After(now) || requested.Sub(now) > maxScheduleAhead) {
+		return nil, ErrInvalidSchedule
+	}
+
+	hours, err := s.repo.FindOpeningHours(ctx, models.StoreOrDefault(storeID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get store opening hours: %w", err)
+	}
+	if hours == nil {
+		return requested, nil
+	}
+
+	at := now
+	if requested != nil {
+		at = *requested
+	}
+	next, ok := nextOpening(hours, at)
+	switch {
+	case ok && next.Equal(at):
+		return requested, nil
+	case !ok:
+		return nil, &StoreClosedError{}
+	case requested == nil && hours.OutsideHours == models.OutsideHoursSchedule:
+		return &next, nil
+	default:
+		return nil, &StoreClosedError{NextOpenAt: &next}
+	}
+}
+
+func storeName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > 100 {
+		return "", ErrInvalidStoreName
+	}
+	return name, nil
+}
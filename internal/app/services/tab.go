@@ -0,0 +1,165 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"oolio/internal/app/models"
+	"oolio/internal/app/repository"
+)
+
+var (
+	ErrInvalidTable    = errors.New("tableNumber is 1-20 letters, digits or dashes")
+	ErrTabNotFound     = errors.New("the table has no open tab")
+	ErrTabOrdersQueued = errors.New("orders of the tab are still being processed")
+)
+
+var tableNumberPattern = regexp.MustCompile(`^[A-Za-z0-9-]{1,20}$`)
+
+// TabService keeps the tabs of dine-in tables. Orders placed with a tableNumber join the
+// table's open tab through the tabId in their request, and are paid when staff close it.
+type TabService interface {
+	// Join returns the table's open tab, opening one for the table's first order. It fails
+	// with ErrInvalidTable for a table number that can't be printed on a QR code.
+	Join(ctx context.Context, storeID, tableNumber string) (*models.Tab, error)
+	// Get returns the table's open tab with its rounds and total, or ErrTabNotFound
+	Get(ctx context.Context, storeID, tableNumber string) (*models.Tab, error)
+	// Close settles the table's open tab with paymentMethod and returns it with what it
+	// came to. It fails with ErrTabNotFound, ErrTabOrdersQueued while one of its orders may
+	// still be created, or ErrPaymentMethodNotAccepted.
+	Close(ctx context.Context, storeID, tableNumber, paymentMethod string) (*models.Tab, error)
+	// Kitchen lists the tables of the store with an open tab, longest seated first, with
+	// what each has ordered
+	Kitchen(ctx context.Context, storeID string) ([]models.KitchenTable, error)
+}
+
+type tabService struct {
+	tabs    repository.TabRepository
+	queue   repository.OrderQueueRepository
+	payment PaymentPolicy
+}
+
+// NewTabService returns the tab service; payment decides the methods tabs are settled with
+func NewTabService(tabs repository.TabRepository, queue repository.OrderQueueRepository, payment PaymentPolicy) TabService {
+	return &tabService{tabs: tabs, queue: queue, payment: payment}
+}
+
+func (s *tabService) Join(ctx context.Context, storeID, tableNumber string) (*models.Tab, error) {
+	if !tableNumberPattern.MatchString(tableNumber) {
+		return nil, ErrInvalidTable
+	}
+	return s.tabs.Open(ctx, models.StoreOrDefault(storeID), tableNumber)
+}
+
+func (s *tabService) Get(ctx context.Context, storeID, tableNumber string) (*models.Tab, error) {
+	tab, err := s.findOpen(ctx, storeID, tableNumber)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.withRounds(ctx, tab); err != nil {
+		return nil, err
+	}
+	return tab, nil
+}
+
+func (s *tabService) Close(ctx context.Context, storeID, tableNumber, paymentMethod string) (*models.Tab, error) {
+	accepted := s.payment.accepted()
+	method := strings.ToLower(strings.TrimSpace(paymentMethod))
+	if !slices.Contains(accepted, method) {
+		return nil, fmt.Errorf("%w: %q, expected one of %s", ErrPaymentMethodNotAccepted, method, strings.Join(accepted, ", "))
+	}
+
+	tab, err := s.findOpen(ctx, storeID, tableNumber)
+	if err != nil {
+		return nil, err
+	}
+	queued, err := s.withRounds(ctx, tab)
+	if err != nil {
+		return nil, err
+	}
+	if queued {
+		return nil, ErrTabOrdersQueued
+	}
+
+	closed, err := s.tabs.Close(ctx, tab.ID, method)
+	if err != nil {
+		if err.Error() == "tab not found" {
+			// Closed by someone else in the meantime
+			return nil, ErrTabNotFound
+		}
+		return nil, err
+	}
+	closed.Rounds, closed.Total = tab.Rounds, tab.Total
+	return closed, nil
+}
+
+func (s *tabService) Kitchen(ctx context.Context, storeID string) ([]models.KitchenTable, error) {
+	tabs, err := s.tabs.FindOpenByStore(ctx, models.StoreOrDefault(storeID))
+	if err != nil {
+		return nil, err
+	}
+
+	tables := make([]models.KitchenTable, 0, len(tabs))
+	for i := range tabs {
+		if _, err := s.withRounds(ctx, &tabs[i]); err != nil {
+			return nil, err
+		}
+		tables = append(tables, models.KitchenTable{
+			TableNumber: tabs[i].TableNumber,
+			TabID:       tabs[i].ID,
+			OpenedAt:    tabs[i].OpenedAt,
+			Rounds:      tabs[i].Rounds,
+		})
+	}
+	return tables, nil
+}
+
+func (s *tabService) findOpen(ctx context.Context, storeID, tableNumber string) (*models.Tab, error) {
+	tab, err := s.tabs.FindOpen(ctx, models.StoreOrDefault(storeID), tableNumber)
+	if err != nil {
+		if err.Error() == "tab not found" {
+			return nil, ErrTabNotFound
+		}
+		return nil, err
+	}
+	return tab, nil
+}
+
+// withRounds fills in the tab's rounds from its queue items and what the created orders
+// come to. It reports whether any round may still create its order: pending, processing
+// or failed with retries left.
+func (s *tabService) withRounds(ctx context.Context, tab *models.Tab) (bool, error) {
+	items, err := s.queue.FindByTab(ctx, tab.ID)
+	if err != nil {
+		return false, err
+	}
+
+	queued := false
+	tab.Rounds = make([]models.TabRound, 0, len(items))
+	tab.Total = 0
+	for _, item := range items {
+		round := models.TabRound{
+			QueueItemID: item.ID,
+			Status:      item.Status,
+			Items:       item.OrderReq.Items,
+			PlacedAt:    item.CreatedAt,
+		}
+		if item.Order != nil && item.Order.ID != "" {
+			round.OrderID = item.Order.ID
+			round.Items = item.Order.Items
+			round.Total = item.Order.AmountDue()
+		}
+		switch {
+		case item.Status == "completed":
+			tab.Total += round.Total
+		case item.Status != "failed" || item.RetryCount < maxQueueRetries:
+			queued = true
+		}
+		tab.Rounds = append(tab.Rounds, round)
+	}
+	return queued, nil
+}
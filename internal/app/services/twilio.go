@@ -0,0 +1,138 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"oolio/internal/app/models"
+)
+
+// TwilioSignatureHeader carries the signature of Twilio's status callbacks
+const TwilioSignatureHeader = "X-Twilio-Signature"
+
+var ErrSMSStatusSignature = errors.New("invalid SMS status signature")
+
+// TrackedSMSSender is implemented by SMS senders that report what became of each message.
+// reference comes back with the status, e.g. the order the text is about.
+type TrackedSMSSender interface {
+	SMSSender
+	SendTrackedSMS(ctx context.Context, phone, message, reference string) (messageID string, err error)
+	// ParseStatus verifies a status callback, made to the callback URL with query, and
+	// returns the reference and status it reports. It fails with ErrSMSStatusSignature.
+	ParseStatus(query, form url.Values, signature string) (reference string, status models.SMSStatus, err error)
+}
+
+type TwilioOptions struct {
+	AccountSID string
+	AuthToken  string
+	From       string // Sending number, or a messaging service SID starting with "MG"
+	BaseURL    string // Overrides https://api.twilio.com
+	// StatusCallbackURL is where Twilio reports delivery, the public URL of
+	// POST /api/v1/notifications/sms/status; without it only sending is recorded
+	StatusCallbackURL string
+	Timeout           time.Duration
+}
+
+// twilioSMSSender sends texts through Twilio's Messages API
+type twilioSMSSender struct {
+	client *http.Client
+	opts   TwilioOptions
+}
+
+func NewTwilioSMSSender(opts TwilioOptions) TrackedSMSSender {
+	if opts.BaseURL == "" {
+		opts.BaseURL = "https://api.twilio.com"
+	}
+	opts.BaseURL = strings.TrimSuffix(opts.BaseURL, "/")
+	return &twilioSMSSender{client: &http.Client{Timeout: opts.Timeout}, opts: opts}
+}
+
+func (s *twilioSMSSender) SendSMS(ctx context.Context, phone, message string) error {
+	_, err := s.SendTrackedSMS(ctx, phone, message, "")
+	return err
+}
+
+func (s *twilioSMSSender) SendTrackedSMS(ctx context.Context, phone, message, reference string) (string, error) {
+	form := url.Values{"To": {phone}, "Body": {message}}
+	if strings.HasPrefix(s.opts.From, "MG") {
+		form.Set("MessagingServiceSid", s.opts.From)
+	} else {
+		form.Set("From", s.opts.From)
+	}
+	if s.opts.StatusCallbackURL != "" && reference != "" {
+		form.Set("StatusCallback", s.opts.StatusCallbackURL+"?"+url.Values{"reference": {reference}}.Encode())
+	}
+
+	endpoint := s.opts.BaseURL + "/2010-04-01/Accounts/" + url.PathEscape(s.opts.AccountSID) + "/Messages.json"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to build SMS request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(s.opts.AccountSID, s.opts.AuthToken)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to send SMS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		SID     string `json:"sid"`
+		Message string `json:"message"`
+	}
+	json.NewDecoder(io.LimitReader(resp.Body, 1<<16)).Decode(&body)
+	if resp.StatusCode/100 != 2 {
+		return "", fmt.Errorf("Twilio rejected the message: %s %s", resp.Status, body.Message)
+	}
+	return body.SID, nil
+}
+
+func (s *twilioSMSSender) ParseStatus(query, form url.Values, signature string) (string, models.SMSStatus, error) {
+	if s.opts.StatusCallbackURL == "" || !s.validSignature(s.opts.StatusCallbackURL+"?"+query.Encode(), form, signature) {
+		return "", models.SMSStatus{}, ErrSMSStatusSignature
+	}
+
+	status := models.SMSStatus{MessageID: form.Get("MessageSid"), Status: form.Get("MessageStatus")}
+	switch status.Status {
+	case "delivered":
+		status.Status = models.SMSStatusDelivered
+	case "undelivered", "failed":
+		status.Status = models.SMSStatusUndelivered
+		if code := form.Get("ErrorCode"); code != "" {
+			status.Error = "Twilio error " + code
+		}
+	}
+	return query.Get("reference"), status, nil
+}
+
+// validSignature checks Twilio's signature: the base64 HMAC-SHA1, keyed with the auth
+// token, of the URL followed by each form field's name and value in order of name
+func (s *twilioSMSSender) validSignature(callbackURL string, form url.Values, signature string) bool {
+	names := make([]string, 0, len(form))
+	for name := range form {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	mac := hmac.New(sha1.New, []byte(s.opts.AuthToken))
+	mac.Write([]byte(callbackURL))
+	for _, name := range names {
+		for _, value := range form[name] {
+			mac.Write([]byte(name + value))
+		}
+	}
+	expected := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
@@ -0,0 +1,243 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"oolio/internal/app/events"
+	"oolio/internal/app/models"
+	"oolio/internal/app/repository"
+	"oolio/internal/backoff"
+	"oolio/internal/workerpool"
+)
+
+// Every post of a webhook carries its delivery ID, which stays the same across retries,
+// and the time of the attempt, both covered by the signature
+const (
+	SignatureHeader = "X-Oolio-Signature"
+	DeliveryHeader  = "X-Oolio-Delivery"
+	TimestampHeader = "X-Oolio-Timestamp"
+)
+
+// SignWebhook is the hex HMAC-SHA256, keyed with the endpoint's secret, of the delivery ID,
+// the Unix timestamp of the attempt and the body, joined by dots. Receivers recompute it
+// and refuse old timestamps, so a captured post can't be replayed.
+func SignWebhook(secret []byte, deliveryID string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(deliveryID + "." + strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// WebhookService posts CloudEvents to the configured webhook endpoints through a delivery
+// queue. The webhook worker retries a failed post with exponential backoff until the
+// endpoint accepts it or it runs out of attempts, when it stays dead until an admin
+// redelivers it. Every attempt is logged with its delivery.
+type WebhookService interface {
+	// Enqueue queues the event for the endpoint; an event the endpoint already has a
+	// delivery of isn't queued again. It fails with ErrWebhookEndpointNotConfigured for an
+	// endpoint without a URL.
+	Enqueue(ctx context.Context, endpoint string, event events.Event) error
+	// DeliverBatch posts up to batchSize due deliveries
+	DeliverBatch(ctx context.Context, batchSize int) (*models.WebhookDeliveryResult, error)
+	// ListDeliveries pages through the deliveries with the status, or all of them when it
+	// is empty, newest first
+	ListDeliveries(ctx context.Context, status string, page models.PageRequest) ([]models.WebhookDelivery, int, error)
+	// GetDelivery returns the delivery with its attempt log
+	GetDelivery(ctx context.Context, id string) (*models.WebhookDelivery, error)
+	// Redeliver queues a dead delivery again with a fresh set of attempts
+	Redeliver(ctx context.Context, id string) error
+}
+
+var ErrWebhookEndpointNotConfigured = errors.New("the webhook endpoint is not configured")
+
+// webhookLease holds a claimed delivery back from other workers while it is being posted
+const webhookLease = 5 * time.Minute
+
+// maxWebhookRetryDelay caps the doubling backoff between attempts
+const maxWebhookRetryDelay = 6 * time.Hour
+
+// WebhookEndpoint is where the deliveries of one endpoint are posted
+type WebhookEndpoint struct {
+	URL     string
+	Secret  string // Signs each post into SignatureHeader when set
+	Timeout time.Duration
+}
+
+type WebhookOptions struct {
+	Endpoints    map[string]WebhookEndpoint // By endpoint name, e.g. models.WebhookEndpointFulfillment
+	MaxAttempts  int
+	RetryBackoff time.Duration    // Delay before the first retry, doubled after each; defaults to 30s
+	Pool         *workerpool.Pool // Posts the deliveries of a batch at once; one at a time when nil
+}
+
+type webhookService struct {
+	repo   repository.WebhookDeliveryRepository
+	client *http.Client
+	opts   WebhookOptions
+}
+
+func NewWebhookService(repo repository.WebhookDeliveryRepository, opts WebhookOptions) WebhookService {
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 1
+	}
+	if opts.RetryBackoff <= 0 {
+		opts.RetryBackoff = 30 * time.Second
+	}
+
+	return &webhookService{repo: repo, client: &http.Client{}, opts: opts}
+}
+
+func (s *webhookService) Enqueue(ctx context.Context, endpoint string, event events.Event) error {
+	if s.opts.Endpoints[endpoint].URL == "" {
+		return fmt.Errorf("%w: %s", ErrWebhookEndpointNotConfigured, endpoint)
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event %s: %w", event.ID, err)
+	}
+	delivery := &models.WebhookDelivery{Endpoint: endpoint, EventID: event.ID, EventType: event.Type, Body: body}
+	if _, err := s.repo.Enqueue(ctx, delivery); err != nil {
+		return fmt.Errorf("failed to queue event %s for the %s webhook: %w", event.ID, endpoint, err)
+	}
+	return nil
+}
+
+func (s *webhookService) DeliverBatch(ctx context.Context, batchSize int) (*models.WebhookDeliveryResult, error) {
+	deliveries, err := s.repo.ClaimDue(ctx, batchSize, webhookLease)
+	if err != nil {
+		return nil, err
+	}
+
+	outcomes := make([]webhookOutcome, len(deliveries))
+	errs := s.opts.Pool.Each(ctx, len(deliveries), func(ctx context.Context, i int) error {
+		outcomes[i] = s.deliver(ctx, deliveries[i])
+		return nil
+	})
+
+	result := &models.WebhookDeliveryResult{}
+	for i, outcome := range outcomes {
+		if errs[i] != nil {
+			// Not started or panicked; the delivery is posted again once its lease is over
+			result.Errors = append(result.Errors, fmt.Sprintf("webhook delivery %s: %v", deliveries[i].ID, errs[i]))
+			continue
+		}
+		switch outcome.status {
+		case models.WebhookDelivered:
+			result.Delivered++
+		case models.WebhookDead:
+			result.Dead++
+		default:
+			result.Failed++
+		}
+		result.Errors = append(result.Errors, outcome.errors...)
+	}
+
+	return result, nil
+}
+
+// webhookOutcome is what became of one delivery of a batch
+type webhookOutcome struct {
+	status string
+	errors []string
+}
+
+// deliver posts the delivery once and records the attempt
+func (s *webhookService) deliver(ctx context.Context, delivery *models.WebhookDelivery) webhookOutcome {
+	attempt, retry := s.post(ctx, delivery)
+	if attempt.Error == "" {
+		outcome := webhookOutcome{status: models.WebhookDelivered}
+		// Not recording it posts the delivery again once the lease is over
+		if err := s.repo.MarkDelivered(ctx, delivery.ID, attempt); err != nil {
+			outcome.errors = append(outcome.errors, fmt.Sprintf("webhook delivery %s: %v", delivery.ID, err))
+		}
+		return outcome
+	}
+
+	outcome := webhookOutcome{errors: []string{fmt.Sprintf("webhook delivery %s to %s: %s", delivery.ID, delivery.Endpoint, attempt.Error)}}
+	attempts := delivery.Attempts + 1
+	var err error
+	if !retry || attempts >= s.opts.MaxAttempts {
+		outcome.status = models.WebhookDead
+		err = s.repo.MarkDead(ctx, delivery.ID, attempt)
+	} else {
+		outcome.status = models.WebhookPending
+		err = s.repo.MarkFailed(ctx, delivery.ID, attempt, time.Now().Add(backoff.Delay(s.opts.RetryBackoff, maxWebhookRetryDelay, attempts)))
+	}
+	if err != nil {
+		outcome.errors = append(outcome.errors, fmt.Sprintf("webhook delivery %s: %v", delivery.ID, err))
+	}
+	return outcome
+}
+
+// post makes one attempt at the delivery, signed afresh, and reports whether a failure is
+// worth retrying: network errors, 429 and 5xx are, other 4xx responses won't change on a
+// second try
+func (s *webhookService) post(ctx context.Context, delivery *models.WebhookDelivery) (models.WebhookAttempt, bool) {
+	endpoint := s.opts.Endpoints[delivery.Endpoint]
+	start := time.Now()
+	attempt := models.WebhookAttempt{URL: endpoint.URL, AttemptedAt: start}
+	fail := func(err error, retry bool) (models.WebhookAttempt, bool) {
+		attempt.Error = err.Error()
+		attempt.DurationMS = int(time.Since(start).Milliseconds())
+		return attempt, retry
+	}
+	if endpoint.URL == "" {
+		return fail(fmt.Errorf("%w: %s", ErrWebhookEndpointNotConfigured, delivery.Endpoint), false)
+	}
+
+	if endpoint.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, endpoint.Timeout)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(delivery.Body))
+	if err != nil {
+		return fail(err, false)
+	}
+	timestamp := start.Unix()
+	req.Header.Set("Content-Type", "application/cloudevents+json")
+	req.Header.Set(DeliveryHeader, delivery.ID)
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	if endpoint.Secret != "" {
+		req.Header.Set(SignatureHeader, SignWebhook([]byte(endpoint.Secret), delivery.ID, timestamp, delivery.Body))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fail(err, true)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+
+	attempt.StatusCode = resp.StatusCode
+	if resp.StatusCode/100 != 2 {
+		retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		return fail(fmt.Errorf("webhook responded %s", resp.Status), retry)
+	}
+	attempt.DurationMS = int(time.Since(start).Milliseconds())
+	return attempt, false
+}
+
+func (s *webhookService) ListDeliveries(ctx context.Context, status string, page models.PageRequest) ([]models.WebhookDelivery, int, error) {
+	return s.repo.FindPage(ctx, status, page)
+}
+
+func (s *webhookService) GetDelivery(ctx context.Context, id string) (*models.WebhookDelivery, error) {
+	return s.repo.FindOne(ctx, id)
+}
+
+func (s *webhookService) Redeliver(ctx context.Context, id string) error {
+	return s.repo.Redeliver(ctx, id)
+}
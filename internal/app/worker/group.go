@@ -0,0 +1,83 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+)
+
+// Group runs the server's background jobs on a shared context and stops them together, so
+// shutdown can wait for them before the stores they use are closed
+type Group struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mutex   sync.Mutex
+	running map[string]int
+}
+
+func NewGroup() *Group {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Group{ctx: ctx, cancel: cancel, running: make(map[string]int)}
+}
+
+// Go runs job until the group stops. Jobs return once their context is done; a job that
+// panics is logged and stops on its own.
+func (g *Group) Go(name string, job func(ctx context.Context)) {
+	g.mutex.Lock()
+	g.running[name]++
+	g.mutex.Unlock()
+
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		defer func() {
+			g.mutex.Lock()
+			g.running[name]--
+			g.mutex.Unlock()
+		}()
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("Background job %s panicked: %v", name, r)
+			}
+		}()
+		job(g.ctx)
+	}()
+}
+
+// Stop cancels the jobs and waits for them to return, or for ctx to be done, in which case
+// it names the jobs still running
+func (g *Group) Stop(ctx context.Context) error {
+	g.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		g.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("background jobs still running: %v", g.Running())
+	}
+}
+
+// Running returns the names of the jobs that haven't returned, sorted
+func (g *Group) Running() []string {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	var names []string
+	for name, count := range g.running {
+		if count > 0 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,87 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"oolio/internal/app/services"
+)
+
+// NotificationWorker sends the notifications queued in the outbox, so a provider outage
+// delays notifications without holding back the orders they are about
+type NotificationWorker struct {
+	notifications services.NotificationService
+	interval      time.Duration
+	batchSize     int
+}
+
+func NewNotificationWorker(notifications services.NotificationService, interval time.Duration, batchSize int) *NotificationWorker {
+	return &NotificationWorker{
+		notifications: notifications,
+		interval:      interval,
+		batchSize:     batchSize,
+	}
+}
+
+func (w *NotificationWorker) Start(ctx context.Context) {
+	log.Printf("Starting notification worker with interval %v and batch size %d", w.interval, w.batchSize)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Notification worker stopped")
+			return
+		case <-ticker.C:
+			// Notifications already being sent finish during shutdown
+			batchCtx := context.WithoutCancel(ctx)
+			func() {
+				defer func() {
+					if r := recover(); r != nil {
+						log.Printf("Notification worker panic recovered: %v", r)
+					}
+				}()
+
+				if err := w.DeliverBatch(batchCtx); err != nil {
+					log.Printf("Failed to deliver notifications: %v", err)
+				}
+			}()
+		}
+	}
+}
+
+func (w *NotificationWorker) DeliverBatch(ctx context.Context) error {
+	_, err := w.deliverBatch(ctx)
+	return err
+}
+
+// Flush sends queued notifications in batches until a batch comes up short. Failed
+// notifications wait for their retry as usual.
+func (w *NotificationWorker) Flush(ctx context.Context) error {
+	for ctx.Err() == nil {
+		sent, err := w.deliverBatch(ctx)
+		if err != nil || sent < w.batchSize {
+			return err
+		}
+	}
+	return ctx.Err()
+}
+
+func (w *NotificationWorker) deliverBatch(ctx context.Context) (int, error) {
+	result, err := w.notifications.DeliverBatch(ctx, w.batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	if result.Sent > 0 || result.Failed > 0 || result.Dead > 0 {
+		log.Printf("Notifications delivered: %d sent, %d failed, %d dead", result.Sent, result.Failed, result.Dead)
+		for _, errorMsg := range result.Errors {
+			log.Printf("Error: %s", errorMsg)
+		}
+	}
+
+	return result.Sent, nil
+}
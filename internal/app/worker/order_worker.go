@@ -2,37 +2,125 @@ package worker
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"sync/atomic"
 	"time"
 
 	"oolio/internal/app/services"
 )
 
+// BacklogThresholds say when the order queue needs attention; a zero threshold is never
+// breached
+type BacklogThresholds struct {
+	Pending   int           // More than this many orders are pending
+	OldestAge time.Duration // An order has been due for longer than this
+	// FailureRate is the percentage of the orders processed within FailureWindow that may
+	// fail before it is breached
+	FailureRate   float64
+	FailureWindow time.Duration
+}
+
+// minFailureSample keeps a couple of failures from breaching the failure rate while few
+// orders are processed
+const minFailureSample = 10
+
+// defaultFailureWindow is used when the thresholds leave FailureWindow unset
+const defaultFailureWindow = 10 * time.Minute
+
+// Titles of the backlog alerts; an alert that is resolved is sent again with " resolved"
+const (
+	alertQueueBacklog     = "Order queue backlog"
+	alertQueueStalled     = "Order queue stalled"
+	alertQueueFailureRate = "Order queue failure rate"
+)
+
 type OrderWorker struct {
 	queueService services.OrderQueueService
 	interval     time.Duration
-	batchSize    int
+	batchSize    atomic.Int64
+
+	// Backlog alerting; each threshold alerts once when it is breached and once when it is
+	// healthy again
+	alerter    services.Alerter
+	thresholds BacklogThresholds
+	breached   map[string]bool // By alert title
+	batches    []batchOutcome  // Within the failure window, oldest first
+}
+
+// batchOutcome counts the orders of a batch towards the failure rate
+type batchOutcome struct {
+	at        time.Time
+	processed int
+	failed    int
 }
 
-func NewOrderWorker(queueService services.OrderQueueService, interval time.Duration, batchSize int) *OrderWorker {
-	return &OrderWorker{
+// NewOrderWorker returns a worker; a nil alerter disables the backlog alerts
+func NewOrderWorker(queueService services.OrderQueueService, alerter services.Alerter, interval time.Duration, batchSize int, thresholds BacklogThresholds) *OrderWorker {
+	w := &OrderWorker{
 		queueService: queueService,
 		interval:     interval,
-		batchSize:    batchSize,
+		alerter:      alerter,
+		thresholds:   thresholds,
+		breached:     make(map[string]bool),
 	}
+	if w.thresholds.FailureWindow <= 0 {
+		w.thresholds.FailureWindow = defaultFailureWindow
+	}
+	w.batchSize.Store(int64(batchSize))
+	return w
+}
+
+// SetBatchSize changes the batch size used from the next tick onwards
+func (w *OrderWorker) SetBatchSize(batchSize int) {
+	if batchSize <= 0 {
+		return
+	}
+	w.batchSize.Store(int64(batchSize))
+}
+
+func (w *OrderWorker) BatchSize() int {
+	return int(w.batchSize.Load())
 }
 
 func (w *OrderWorker) Start(ctx context.Context) {
-	log.Printf("Starting order worker with interval %v and batch size %d", w.interval, w.batchSize)
-	w.queueService.StartWorker(ctx, w.interval, w.batchSize)
+	log.Printf("Starting order worker with interval %v and batch size %d", w.interval, w.BatchSize())
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Order worker stopped")
+			return
+		case <-ticker.C:
+			// A batch that has started is finished even when shutdown begins meanwhile
+			batchCtx := context.WithoutCancel(ctx)
+			func() {
+				defer func() {
+					if r := recover(); r != nil {
+						log.Printf("Worker panic recovered: %v", r)
+					}
+				}()
+
+				if err := w.ProcessBatch(batchCtx); err != nil {
+					log.Printf("Failed to process batch: %v", err)
+				}
+				w.CheckBacklog(batchCtx)
+			}()
+		}
+	}
 }
 
 func (w *OrderWorker) ProcessBatch(ctx context.Context) error {
-	result, err := w.queueService.ProcessBatch(ctx, w.batchSize)
+	result, err := w.queueService.ProcessBatch(ctx, w.BatchSize())
 	if err != nil {
 		return err
 	}
 
+	w.recordBatch(result.Processed, result.Failed+result.PaymentFailed)
+
 	if result.Processed > 0 || result.Failed > 0 {
 		log.Printf("Batch processed: %d succeeded, %d failed", result.Processed, result.Failed)
 		if result.Failed > 0 {
@@ -44,3 +132,83 @@ func (w *OrderWorker) ProcessBatch(ctx context.Context) error {
 
 	return nil
 }
+
+// recordBatch counts a batch towards the failure rate
+func (w *OrderWorker) recordBatch(processed, failed int) {
+	if w.alerter == nil || w.thresholds.FailureRate <= 0 || processed+failed == 0 {
+		return
+	}
+	w.batches = append(w.batches, batchOutcome{at: time.Now(), processed: processed, failed: failed})
+}
+
+// CheckBacklog evaluates the thresholds, alerting on the ones that were breached or have
+// become healthy since the last check
+func (w *OrderWorker) CheckBacklog(ctx context.Context) {
+	if w.alerter == nil {
+		return
+	}
+	t := w.thresholds
+
+	if t.Pending > 0 || t.OldestAge > 0 {
+		backlog, err := w.queueService.GetBacklog(ctx)
+		if err != nil {
+			log.Printf("Failed to check queue backlog: %v", err)
+		} else {
+			if t.Pending > 0 {
+				w.check(ctx, alertQueueBacklog, backlog.Pending > t.Pending,
+					fmt.Sprintf("%d orders are pending (threshold %d); the worker processes %d every %v.", backlog.Pending, t.Pending, w.BatchSize(), w.interval))
+			}
+			if t.OldestAge > 0 {
+				var waiting time.Duration
+				if backlog.OldestDueAt != nil {
+					waiting = max(time.Since(*backlog.OldestDueAt), 0)
+				}
+				w.check(ctx, alertQueueStalled, waiting > t.OldestAge,
+					fmt.Sprintf("The oldest pending order has waited %v (threshold %v).", waiting.Round(time.Second), t.OldestAge))
+			}
+		}
+	}
+
+	if t.FailureRate > 0 {
+		rate, processed := w.failureRate()
+		w.check(ctx, alertQueueFailureRate, processed >= minFailureSample && rate > t.FailureRate,
+			fmt.Sprintf("%.1f%% of the %d orders processed in the last %v failed (threshold %.1f%%).", rate, processed, t.FailureWindow, t.FailureRate))
+	}
+}
+
+// failureRate is the percentage of the orders processed within the failure window that
+// failed, and how many orders that is
+func (w *OrderWorker) failureRate() (float64, int) {
+	cutoff := time.Now().Add(-w.thresholds.FailureWindow)
+	for len(w.batches) > 0 && w.batches[0].at.Before(cutoff) {
+		w.batches = w.batches[1:]
+	}
+
+	processed, failed := 0, 0
+	for _, batch := range w.batches {
+		processed += batch.processed + batch.failed
+		failed += batch.failed
+	}
+	if processed == 0 {
+		return 0, 0
+	}
+	return float64(failed) * 100 / float64(processed), processed
+}
+
+// check sends the alert when breached changed since it was last sent. An alert that can't
+// be sent is tried again on the next check.
+func (w *OrderWorker) check(ctx context.Context, title string, breached bool, text string) {
+	if breached == w.breached[title] {
+		return
+	}
+
+	alert := services.Alert{Title: title, Text: text}
+	if !breached {
+		alert.Title = title + " resolved"
+	}
+	if err := w.alerter.Alert(ctx, alert); err != nil {
+		log.Printf("Failed to send %q alert: %v", alert.Title, err)
+		return
+	}
+	w.breached[title] = breached
+}
@@ -0,0 +1,151 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"oolio/internal/app/events"
+	"oolio/internal/app/models"
+	"oolio/internal/app/repository"
+	"oolio/internal/app/services"
+	"oolio/internal/backoff"
+)
+
+const (
+	// outboxLease holds claimed events back from other relays; it must outlast publishing
+	// a batch
+	outboxLease = 5 * time.Minute
+	// maxOutboxRetryDelay caps the doubling of the retry backoff
+	maxOutboxRetryDelay = time.Hour
+)
+
+// OutboxRetries say how often an event the broker refuses is tried: MaxAttempts times, the
+// retries Backoff apart and then twice as far apart each time, before it is dead-lettered
+type OutboxRetries struct {
+	MaxAttempts int
+	Backoff     time.Duration
+}
+
+// OutboxRelay polls the outbox table and publishes pending domain events as CloudEvents
+type OutboxRelay struct {
+	outboxRepo repository.OutboxRepository
+	publisher  services.EventPublisher
+	source     string
+	interval   time.Duration
+	batchSize  int
+	retries    OutboxRetries
+}
+
+// NewOutboxRelay returns a relay; a nil outboxRepo (memory storage) disables it
+func NewOutboxRelay(outboxRepo repository.OutboxRepository, publisher services.EventPublisher, source string, interval time.Duration, batchSize int, retries OutboxRetries) *OutboxRelay {
+	if retries.MaxAttempts <= 0 {
+		retries.MaxAttempts = 1
+	}
+	return &OutboxRelay{
+		outboxRepo: outboxRepo,
+		publisher:  publisher,
+		source:     source,
+		interval:   interval,
+		batchSize:  batchSize,
+		retries:    retries,
+	}
+}
+
+func (r *OutboxRelay) Start(ctx context.Context) {
+	if r.outboxRepo == nil {
+		log.Println("Outbox relay disabled: no outbox storage")
+		return
+	}
+
+	log.Printf("Starting outbox relay with interval %v and batch size %d", r.interval, r.batchSize)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Outbox relay stopped")
+			return
+		case <-ticker.C:
+			// Events being published when shutdown begins are still published
+			batchCtx := context.WithoutCancel(ctx)
+			func() {
+				defer func() {
+					if rec := recover(); rec != nil {
+						log.Printf("Outbox relay panic recovered: %v", rec)
+					}
+				}()
+
+				if err := r.RelayBatch(batchCtx); err != nil {
+					log.Printf("Failed to relay outbox events: %v", err)
+				}
+			}()
+		}
+	}
+}
+
+func (r *OutboxRelay) RelayBatch(ctx context.Context) error {
+	_, err := r.relayBatch(ctx)
+	return err
+}
+
+// Flush publishes the due events in batches until a batch comes up short, so events
+// written by the last orders before shutdown aren't left for the next start. Events that
+// fail wait for their retry, so they don't keep the flush going.
+func (r *OutboxRelay) Flush(ctx context.Context) error {
+	if r.outboxRepo == nil {
+		return nil
+	}
+	for ctx.Err() == nil {
+		claimed, err := r.relayBatch(ctx)
+		if err != nil || claimed < r.batchSize {
+			return err
+		}
+	}
+	return ctx.Err()
+}
+
+// relayBatch publishes a batch of due events and returns how many it claimed
+func (r *OutboxRelay) relayBatch(ctx context.Context) (int, error) {
+	pending, err := r.outboxRepo.ClaimDue(ctx, r.batchSize, outboxLease)
+	if err != nil {
+		return 0, err
+	}
+
+	published, failed, dead := 0, 0, 0
+	var errs []error
+	for _, event := range pending {
+		publishErr := r.publish(ctx, event)
+		switch attempts := event.Attempts + 1; {
+		case publishErr == nil:
+			published++
+			// Not recording it publishes the event again once the lease is over
+			err = r.outboxRepo.MarkPublished(ctx, event.ID)
+		case attempts >= r.retries.MaxAttempts:
+			dead++
+			log.Printf("Outbox event %s (%s) dead-lettered after %d attempts: %v", event.ID, event.EventType, attempts, publishErr)
+			err = r.outboxRepo.MarkDead(ctx, event.ID, publishErr.Error())
+		default:
+			failed++
+			retryAt := time.Now().Add(backoff.Delay(r.retries.Backoff, maxOutboxRetryDelay, attempts))
+			err = r.outboxRepo.MarkFailed(ctx, event.ID, publishErr.Error(), retryAt)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("outbox event %s: %w", event.ID, err))
+		}
+	}
+
+	if published > 0 || failed > 0 || dead > 0 {
+		log.Printf("Outbox relayed: %d published, %d failed, %d dead", published, failed, dead)
+	}
+
+	return len(pending), errors.Join(errs...)
+}
+
+func (r *OutboxRelay) publish(ctx context.Context, event models.OutboxEvent) error {
+	return r.publisher.Publish(ctx, events.FromOutbox(r.source, event))
+}
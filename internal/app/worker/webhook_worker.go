@@ -0,0 +1,70 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"oolio/internal/app/services"
+)
+
+// WebhookWorker posts the queued outbound webhooks, so an endpoint outage delays
+// deliveries without holding back the orders and events they are about
+type WebhookWorker struct {
+	webhooks  services.WebhookService
+	interval  time.Duration
+	batchSize int
+}
+
+func NewWebhookWorker(webhooks services.WebhookService, interval time.Duration, batchSize int) *WebhookWorker {
+	return &WebhookWorker{
+		webhooks:  webhooks,
+		interval:  interval,
+		batchSize: batchSize,
+	}
+}
+
+func (w *WebhookWorker) Start(ctx context.Context) {
+	log.Printf("Starting webhook worker with interval %v and batch size %d", w.interval, w.batchSize)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Webhook worker stopped")
+			return
+		case <-ticker.C:
+			// Deliveries under way complete during shutdown
+			batchCtx := context.WithoutCancel(ctx)
+			func() {
+				defer func() {
+					if r := recover(); r != nil {
+						log.Printf("Webhook worker panic recovered: %v", r)
+					}
+				}()
+
+				if err := w.DeliverBatch(batchCtx); err != nil {
+					log.Printf("Failed to deliver webhooks: %v", err)
+				}
+			}()
+		}
+	}
+}
+
+func (w *WebhookWorker) DeliverBatch(ctx context.Context) error {
+	result, err := w.webhooks.DeliverBatch(ctx, w.batchSize)
+	if err != nil {
+		return err
+	}
+
+	if result.Delivered > 0 || result.Failed > 0 || result.Dead > 0 {
+		log.Printf("Webhooks delivered: %d delivered, %d failed, %d dead", result.Delivered, result.Failed, result.Dead)
+		for _, errorMsg := range result.Errors {
+			log.Printf("Error: %s", errorMsg)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,15 @@
+// Package backoff spaces out the retries of work that failed, doubling the wait after each
+// failed attempt up to a limit.
+package backoff
+
+import "time"
+
+// Delay is how long to wait after the given number of failed attempts: base after the
+// first, twice that after the second and so on, but never longer than limit
+func Delay(base, limit time.Duration, attempts int) time.Duration {
+	delay := base
+	for i := 1; i < attempts && delay < limit; i++ {
+		delay *= 2
+	}
+	return min(delay, limit)
+}
@@ -0,0 +1,123 @@
+// Package chaos injects faults into a development instance so that the paths meant for bad
+// days (retries, the database circuit breaker, the Redis fallbacks) can be exercised on
+// demand. Each subsystem has its own Fault, which delays a percentage of calls and fails a
+// percentage of them.
+package chaos
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"oolio/internal/config"
+)
+
+// ErrInjected is the failure of a call picked by a Fault
+var ErrInjected = errors.New("chaos: injected failure")
+
+// Injector holds the faults of the HTTP server, the database and Redis
+type Injector struct {
+	HTTP     *Fault
+	Database *Fault
+	Redis    *Fault
+}
+
+// New returns an injector with the faults of cfg; a disabled cfg injects nothing
+func New(cfg config.ChaosConfig) *Injector {
+	injector := &Injector{HTTP: &Fault{}, Database: &Fault{}, Redis: &Fault{}}
+	injector.Configure(cfg)
+	return injector
+}
+
+// Configure replaces the faults, e.g. after the configuration was reloaded
+func (i *Injector) Configure(cfg config.ChaosConfig) {
+	if !cfg.Enabled {
+		cfg = config.ChaosConfig{}
+	}
+	i.HTTP.set(cfg.HTTP)
+	i.Database.set(cfg.Database)
+	i.Redis.set(cfg.Redis)
+}
+
+// Fault picks calls to delay and to fail. A nil Fault injects nothing.
+type Fault struct {
+	mutex sync.RWMutex
+	cfg   config.FaultConfig
+}
+
+func (f *Fault) set(cfg config.FaultConfig) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.cfg = cfg
+}
+
+// Active reports whether the fault injects anything
+func (f *Fault) Active() bool {
+	if f == nil {
+		return false
+	}
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+	return (f.cfg.Latency > 0 && f.cfg.LatencyPercent > 0) || f.cfg.ErrorPercent > 0
+}
+
+// Inject delays the call when it is picked for latency, returning early once ctx is done,
+// and reports whether the call should then fail
+func (f *Fault) Inject(ctx context.Context) bool {
+	if f == nil {
+		return false
+	}
+	f.mutex.RLock()
+	cfg := f.cfg
+	f.mutex.RUnlock()
+
+	if cfg.Latency > 0 && picked(cfg.LatencyPercent) {
+		timer := time.NewTimer(cfg.Latency)
+		select {
+		case <-ctx.Done():
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+	return picked(cfg.ErrorPercent)
+}
+
+func picked(percent float64) bool {
+	return percent > 0 && rand.Float64()*100 < percent
+}
+
+// NewRedisHook fails the Redis commands and pipelines fault picks with ErrInjected, without
+// sending them
+func NewRedisHook(fault *Fault) redis.Hook {
+	return redisHook{fault: fault}
+}
+
+type redisHook struct {
+	fault *Fault
+}
+
+func (h redisHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	if h.fault.Inject(ctx) {
+		return ctx, ErrInjected
+	}
+	return ctx, nil
+}
+
+func (h redisHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	return nil
+}
+
+func (h redisHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	if h.fault.Inject(ctx) {
+		return ctx, ErrInjected
+	}
+	return ctx, nil
+}
+
+func (h redisHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	return nil
+}
@@ -3,68 +3,936 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type Config struct {
-	Database DatabaseConfig
-	Server   ServerConfig
-	API      APIConfig
-	Coupon   CouponConfig
-	Redis    RedisConfig
+	Database     DatabaseConfig
+	Server       ServerConfig
+	API          APIConfig
+	Coupon       CouponConfig
+	Redis        RedisConfig
+	Log          LogConfig
+	Worker       WorkerConfig
+	RateLimit    RateLimitConfig
+	Outbox       OutboxConfig
+	Cache        CacheConfig
+	Fulfillment  FulfillmentConfig
+	Delivery     DeliveryConfig
+	Alert        AlertConfig
+	Storage      StorageConfig
+	MenuImport   MenuImportConfig
+	Cart         CartConfig
+	Inventory    InventoryConfig
+	Segment      SegmentConfig
+	Verification VerificationConfig
+	Notification NotificationConfig
+	Payment      PaymentConfig
+	Invoice      InvoiceConfig
+	Currency     CurrencyConfig
+	Digest       DigestConfig
+	Reconcile    ReconcileConfig
+	Scheduler    SchedulerConfig
+	Webhook      WebhookConfig
+	Chaos        ChaosConfig
 }
 
 type DatabaseConfig struct {
+	Driver   string // "postgres" or "memory" (in-process repositories for local development)
 	Host     string
 	Port     string
 	User     string
 	Password string
 	DBName   string
+
+	MaxOpenConns      int
+	ConnMaxLifetime   time.Duration
+	ConnMaxIdleTime   time.Duration // Idle connections are closed after this; pgx's 30 minutes when 0
+	StatsInterval     time.Duration // How often pool stats are sampled (0 = disabled)
+	WaitWarnThreshold time.Duration // Warn when connection wait time per interval exceeds this
+
+	AutoMigrate bool // Apply embedded migrations on startup
+
+	// Transient errors (serialization failures, connection resets) are retried with backoff
+	RetryMaxAttempts    int // Total attempts per call, 1 disables retries
+	RetryInitialBackoff time.Duration
+	RetryMaxBackoff     time.Duration
+
+	// After BreakerFailureThreshold consecutive connection failures calls fail fast with 503
+	// until a background ping every BreakerProbeInterval succeeds (0 disables the breaker)
+	BreakerFailureThreshold int
+	BreakerProbeInterval    time.Duration
+
+	// ReadDSN points read-heavy queries at a replica. Empty routes everything to the primary.
+	ReadDSN              string
+	ReplicaCheckInterval time.Duration // How often replica health is probed
 }
 
 type ServerConfig struct {
 	Port string
 	Host string
+
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	ShutdownTimeout   time.Duration // Grace period for in-flight requests on shutdown
+	MaxHeaderBytes    int
+
+	StatusCacheTTL time.Duration // How long the public /api/v1/status response is reused
+
+	// JSONCodec is the library responses are encoded with, "std" or "go-json"; empty keeps
+	// the one gin was built with
+	JSONCodec string
 }
 
 type APIConfig struct {
 	APIKey string
+
+	// Signs the lookup tokens guests get for their orders; empty disables the tokens
+	OrderLookupSecret string
+	OrderLookupTTL    time.Duration
+
+	// Signs the URLs of the scheduled orders feed, which calendar tools read without the API
+	// key. URLs are issued on ScheduleFeedBaseURL and are valid for ScheduleFeedTTL; the feed
+	// is disabled without a secret or base URL.
+	ScheduleFeedSecret  string
+	ScheduleFeedBaseURL string
+	ScheduleFeedTTL     time.Duration
+
+	// Announced on /api/v1 responses in the Deprecation and Sunset headers; zero omits them
+	V1DeprecatedAt time.Time
+	V1Sunset       time.Time
 }
 
 type CouponConfig struct {
-	BaseURL string
+	BaseURL            string
+	Discounts          map[string]float64 // Coupon code (upper case) to discount percentage
+	DefaultDiscount    float64            // Percentage for valid codes not listed in Discounts
+	Segments           map[string]string  // Coupon code to the only customer segment allowed to use it
+	SingleUse          []string           // Coupon codes each customer may use once
+	FileCodesSingleUse bool               // Codes from the coupon files may be used once per customer
+	MinLength          int
+	MaxLength          int
+	MinFileOccurrences int // Number of coupon files a code must appear in to be valid
+	RefreshInterval    time.Duration
+	FileTimeout        time.Duration
+	MaxDownloadMB      int64
+	CacheFiles         bool // Keep the last good copy of each file in storage for when downloads fail
+
+	// Downloads share one client: connecting, the TLS handshake and the response headers
+	// each have their own timeout, and a broken download resumes up to DownloadRetries times
+	DialTimeout           time.Duration
+	TLSHandshakeTimeout   time.Duration
+	ResponseHeaderTimeout time.Duration
+	DownloadRetries       int
+	Concurrency           int // Files downloaded and parsed at once
+
+	// Files are read ParseBufferKB at a time, one code per line unless CSVParser is set
+	ParseBufferKB int
+	CSVParser     bool
 }
 
 type RedisConfig struct {
+	Driver   string // "redis" or "memory" (in-process rate limiting for local development)
 	Addr     string
 	Password string
 	DB       int
+
+	// Addrs lists cluster nodes, or sentinel nodes when MasterName is set.
+	// When empty, Addr is used as a single standalone node.
+	Addrs            []string
+	MasterName       string
+	SentinelPassword string
+	Username         string
+
+	TLSEnabled            bool
+	TLSServerName         string
+	TLSCAFile             string
+	TLSInsecureSkipVerify bool
+
+	DialTimeout  time.Duration
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+}
+
+type LogConfig struct {
+	Level            string
+	SampleInitial    int    // Access log entries per second logged before sampling kicks in (0 = no sampling)
+	SampleThereafter int    // After SampleInitial, log every Nth entry within the same second
+	AccessLogFile    string // JSON access log file path (empty = stdout only)
+	FileMaxSizeMB    int
+	FileMaxBackups   int
+	FileMaxAgeDays   int
+	FileCompress     bool
+}
+
+type WorkerConfig struct {
+	Interval    time.Duration
+	BatchSize   int
+	Concurrency int // Orders of a batch processed at once
+
+	// DrainTimeout bounds how long shutdown waits for batches in flight to finish and for
+	// pending events and notifications to be sent
+	DrainTimeout time.Duration
+
+	// InstanceID names this process in the queue items it claims; the host name and
+	// process ID by default
+	InstanceID string
+}
+
+type OutboxConfig struct {
+	Broker    string // Where domain events are published: "log", "kafka" or "webhook"
+	Interval  time.Duration
+	BatchSize int
+	// Events the broker refuses are tried MaxAttempts times, the retries RetryBackoff apart
+	// and then twice as far apart each time, and are dead-lettered after that
+	MaxAttempts  int
+	RetryBackoff time.Duration
+
+	// EventSource is the CloudEvents source; events carry it followed by their aggregate type
+	EventSource string
+
+	// Kafka is reached through its REST proxy. Events go to the topic mapped to their
+	// aggregate type in KafkaTopics, otherwise to KafkaTopic.
+	KafkaRESTURL string
+	KafkaTopic   string
+	KafkaTopics  map[string]string
+	KafkaTimeout time.Duration
+
+	// The webhook broker posts events to WebhookURL, e.g. for an accounting system,
+	// limited to WebhookEvents such as order.refunded when any are listed
+	WebhookURL     string
+	WebhookSecret  string
+	WebhookEvents  []string
+	WebhookTimeout time.Duration
+}
+
+// CacheConfig configures read caching per repository
+type CacheConfig struct {
+	Products RepositoryCacheConfig
+
+	// ProductList caches the product listings of GET /product per filter, invalidated by
+	// every product write through the product service
+	ProductList RepositoryCacheConfig
+
+	// Responses caches whole responses of the public product GETs per URL and pricing tier,
+	// keyed by the catalog version every product write moves on
+	Responses RepositoryCacheConfig
+}
+
+type RepositoryCacheConfig struct {
+	Driver string // "none", "memory" (in-process LRU) or "redis" (shared between instances)
+	TTL    time.Duration
+	Size   int // Maximum entries held by the in-process LRU
+}
+
+// FulfillmentConfig selects where the worker sends orders once they are created, e.g. a
+// POS or kitchen display system
+type FulfillmentConfig struct {
+	Provider      string // "none" or "webhook"
+	WebhookURL    string
+	WebhookSecret string // Signs webhook bodies with HMAC-SHA256 when set
+	Timeout       time.Duration
+}
+
+// DeliveryConfig selects who delivers orders placed with a delivery address. The webhook
+// provider posts delivery jobs to WebhookURL and takes their status back on
+// POST /deliveries/webhook, signed with StatusSecret.
+type DeliveryConfig struct {
+	Provider      string // "none", "mock" or "webhook"
+	WebhookURL    string
+	WebhookSecret string // Signs webhook bodies with HMAC-SHA256 when set
+	StatusSecret  string // Verifies the status updates the provider posts back
+	Timeout       time.Duration
+	MockStep      time.Duration // How long the mock provider takes to move a delivery on a status
+}
+
+// AlertConfig selects where operational alerts go: permanent order failures, coupon files
+// that failed to refresh and an unhealthy order queue
+type AlertConfig struct {
+	Provider   string // "none", "slack" or "discord"
+	WebhookURL string // Incoming webhook URL; it embeds a token so it is treated as a secret
+	Timeout    time.Duration
+	EmailTo    []string // Also emails alerts to these addresses through the notification email provider
+
+	// Alerts for orders that failed for good link to a page that requeues them. Links are
+	// signed with RetryLinkSecret, are valid for RetryLinkTTL and are left out without a
+	// secret or base URL.
+	RetryLinkSecret  string
+	RetryLinkBaseURL string // Public URL of this API, e.g. https://api.example.com
+	RetryLinkTTL     time.Duration
+
+	// The order worker alerts when the queue breaches a threshold, and again once it is
+	// healthy; 0 disables each
+	QueueBacklog       int           // More than this many orders are pending
+	QueueOldest        time.Duration // An order has been due for longer than this
+	QueueFailureRate   float64       // More than this percentage of orders failed within QueueFailureWindow
+	QueueFailureWindow time.Duration
+}
+
+// StorageConfig selects the object store for files such as product images and cached
+// coupon files
+type StorageConfig struct {
+	Driver string // "local", "s3" or "gcs"
+	Dir    string // Root directory of the local driver
+
+	// The s3 and gcs drivers sign requests with an access key; for gcs that is an HMAC key
+	Bucket          string
+	Region          string
+	Endpoint        string // Overrides the default endpoint, e.g. for MinIO
+	AccessKeyID     string
+	SecretAccessKey string
+	Timeout         time.Duration
+
+	// PublicURL prefixes object keys in the URLs handed to clients. It defaults to the
+	// API's own /files route, which streams objects from any driver.
+	PublicURL string
+}
+
+// MenuImportConfig selects the third-party platform the product menu is imported from
+type MenuImportConfig struct {
+	Provider string        // "none" or "generic"
+	URL      string        // Menu document served by the provider
+	Token    string        // Bearer token for the provider's API
+	Interval time.Duration // Time between scheduled imports; 0 leaves only the admin trigger
+	Timeout  time.Duration
+}
+
+type CartConfig struct {
+	GuestTTL        time.Duration // Guest carts expire this long after their last change
+	CleanupInterval time.Duration // Time between deletions of expired guest carts; 0 disables them
+}
+
+// InventoryConfig sets when a product counts as low on stock in the admin listing, and how
+// long stock stays reserved for queued orders
+type InventoryConfig struct {
+	LowStockThreshold int           // Products with this many units left or fewer are listed
+	ReservationTTL    time.Duration // Stock reserved for a queued order is freed after this
 }
 
+type SegmentConfig struct {
+	RefreshInterval time.Duration // Time between segment recomputations; 0 disables them
+	NewWindow       time.Duration // Customers whose first order is this recent are "new"
+	LapsedAfter     time.Duration // Customers without an order for this long are "lapsed"
+	VIPSpend        float64       // Spend within VIPWindow that makes a customer "vip"
+	VIPWindow       time.Duration
+}
+
+// VerificationConfig selects the SMS gateway for phone verification codes. The "none"
+// provider turns phone verification off.
+type VerificationConfig struct {
+	Provider   string // "none", "log" (codes are only logged, for development) or "webhook"
+	WebhookURL string // SMS gateway endpoint of the webhook provider
+	Token      string // Bearer token for the SMS gateway
+	Secret     string // Signs verification IDs and verified phone tokens
+	Timeout    time.Duration
+	CodeTTL    time.Duration // How long a texted code can be checked
+	TokenTTL   time.Duration // How long a verified phone is accepted for orders
+
+	GuestOrderMin float64 // Guest orders worth at least this need a verified phone; 0 never
+}
+
+// NotificationConfig selects the gateways for customer notifications. A channel whose
+// provider is "none" sends nothing.
+type NotificationConfig struct {
+	EmailProvider   string // "none", "log" (emails are only logged, for development), "webhook", "smtp" or "ses"
+	EmailWebhookURL string // Email gateway endpoint of the webhook provider
+	EmailFrom       string // Sender of smtp and ses emails, e.g. "Oolio <orders@oolio.com>"
+	SMSProvider     string // "none", "log", "webhook" or "twilio"
+	SMSWebhookURL   string // SMS gateway endpoint of the webhook provider
+	Token           string // Bearer token for both gateways
+	Timeout         time.Duration
+
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+
+	SESRegion          string
+	SESEndpoint        string // Overrides the default endpoint
+	SESAccessKeyID     string
+	SESSecretAccessKey string
+
+	TwilioAccountSID string
+	TwilioAuthToken  string
+	TwilioFrom       string // Sending number, or messaging service SID
+	TwilioBaseURL    string // Overrides the Twilio API endpoint
+	// SMSStatusCallbackURL is the public URL of POST /api/v1/notifications/sms/status, where
+	// Twilio reports whether texts were delivered
+	SMSStatusCallbackURL string
+
+	SMSReadyEnabled bool // Ready texts are sent; reloadable, to turn them off without a deploy
+	SMSPerHour      int  // Texts each phone may get an hour; 0 doesn't limit them
+
+	// Push notifications go to the devices customers register, on the platforms of
+	// PushProviders: "log" (any platform, only logged), or "fcm" and "apns". PushEnabled
+	// is reloadable, to turn push on per environment and off while a provider has trouble.
+	PushProviders      []string
+	PushEnabled        bool
+	FCMCredentialsFile string // Service account JSON key of the Firebase project
+	FCMBaseURL         string // Overrides the FCM API endpoint
+	APNsKeyFile        string // .p8 token signing key
+	APNsKeyID          string
+	APNsTeamID         string
+	APNsTopic          string // The app's bundle ID
+	APNsProduction     bool   // Production rather than development builds of the app
+	APNsBaseURL        string // Overrides the APNs endpoint
+
+	TemplateDir string // Replaces built-in email templates with files of the same name
+
+	// Sandbox keeps emails from customers, e.g. outside production: they go to
+	// SandboxRedirect instead, or are only logged without it. Addresses or "@domains" in
+	// SandboxAllow are still sent to.
+	Sandbox         bool
+	SandboxAllow    []string
+	SandboxRedirect string
+
+	// Notifications wait in an outbox for the notification worker, which retries failed ones
+	// after RetryBackoff, doubling each time, until MaxAttempts are used up
+	WorkerInterval  time.Duration
+	WorkerBatchSize int
+	MaxAttempts     int
+	RetryBackoff    time.Duration
+}
+
+// PaymentConfig selects the payment provider. The "none" provider turns payments off.
+type PaymentConfig struct {
+	Provider        string        // "none", "mock" (intents are authorized on creation, for development) or "stripe"
+	Required        bool          // Orders wait in the queue until they are paid for
+	Window          time.Duration // How long a queued order waits for its payment; zero waits forever
+	Methods         []string      // Accepted payment methods of "card", "cash" and "counter", the first being the default; empty accepts all three
+	Currency        string        // ISO code in lower case, e.g. "aud"
+	StripeSecretKey string
+	StripeBaseURL   string // Overrides the Stripe API endpoint, e.g. for stripe-mock
+	Timeout         time.Duration
+	// WebhookSecret verifies the provider's signed notifications; without it POST
+	// /payments/webhook is off
+	WebhookSecret    string
+	WebhookTolerance time.Duration // How old a webhook signature may be before it is refused as a replay
+}
+
+// InvoiceConfig names the seller on tax invoices and sets how they are numbered and taxed.
+// Prices include the tax.
+type InvoiceConfig struct {
+	Series      string  // Prefix of invoice numbers, which run without gaps per store and series, e.g. "INV"
+	SellerName  string  // Legal name of the seller
+	SellerTaxID string  // Tax registration of the seller, e.g. its ABN
+	TaxName     string  // e.g. "GST"
+	TaxRate     float64 // Percentage included in prices; 0 issues invoices without tax
+}
+
+// CurrencyConfig sets the currencies, besides PAYMENT_CURRENCY, that partners may settle
+// order totals in and where their exchange rates come from. The "none" provider turns
+// conversion off.
+type CurrencyConfig struct {
+	Provider   string             // "none", "fixed" (the rates below) or "http" (a Frankfurter-compatible API)
+	Rates      map[string]float64 // Units of each currency per unit of PAYMENT_CURRENCY, for the fixed provider
+	Settlement []string           // Currencies totals may be converted to; defaults to those with fixed rates
+	RatesURL   string
+	CacheTTL   time.Duration // How long a rate fetched by the http provider is reused
+	Timeout    time.Duration
+}
+
+// DigestConfig sets where the daily sales digest goes. It is sent every day at Hour in
+// TimeZone, for the day before, by one of the instances. Without recipients or a webhook
+// there is no digest.
+type DigestConfig struct {
+	Recipients    []string // Email addresses, sent through NOTIFICATION_EMAIL_PROVIDER
+	WebhookURL    string
+	WebhookSecret string // Signs webhook bodies with HMAC-SHA256 when set
+	Hour          int    // 0-23
+	TimeZone      string // IANA name, e.g. "Australia/Sydney"; days start at midnight there
+	TopProducts   int    // How many of the best selling products are listed
+	Timeout       time.Duration
+}
+
+// ReconcileConfig sets how often the reconciliation job recomputes the totals and discounts
+// of recent orders from their items, and how far back it looks
+type ReconcileConfig struct {
+	Interval time.Duration // Time between runs; 0 disables them
+	Window   time.Duration // Orders changed this recently are checked
+}
+
+// SchedulerConfig sets up the scheduled jobs. A run takes the job's lock in Redis, so one
+// instance runs it, and is canceled once it has held the lock for LockTTL.
+type SchedulerConfig struct {
+	LockTTL time.Duration
+	// Schedules replaces the schedules of the jobs it names with cron expressions, read in
+	// TimeZone, or @every intervals
+	Schedules map[string]string
+	TimeZone  string
+}
+
+// WebhookConfig sets how the webhook worker delivers the outbound webhooks: fulfillment,
+// delivery jobs, the webhook outbox broker and the sales digest. A failed post is retried after
+// RetryBackoff, doubled after every attempt, and dead-lettered after MaxAttempts.
+type WebhookConfig struct {
+	Interval     time.Duration
+	BatchSize    int
+	MaxAttempts  int
+	RetryBackoff time.Duration
+	Concurrency  int // Deliveries of a batch posted at once
+}
+
+// ChaosConfig injects faults for resilience testing. It is for development: the server
+// refuses to start with it enabled in GIN_MODE=release. The faults are reloadable while it is
+// enabled.
+type ChaosConfig struct {
+	Enabled  bool
+	HTTP     FaultConfig // Requests, failed with 500
+	Database FaultConfig // Database calls, failed as connections that dropped before sending
+	Redis    FaultConfig // Redis commands
+}
+
+// FaultConfig delays LatencyPercent of a subsystem's calls by Latency and fails ErrorPercent
+// of them
+type FaultConfig struct {
+	Latency        time.Duration
+	LatencyPercent float64
+	ErrorPercent   float64
+}
+
+type RateLimitConfig struct {
+	ProductPerMinute int
+	OrderPerMinute   int
+	QueuePerMinute   int
+}
+
+const (
+	DriverPostgres = "postgres"
+	DriverRedis    = "redis"
+	DriverMemory   = "memory"
+	DriverNone     = "none"
+
+	BrokerLog     = "log"
+	BrokerKafka   = "kafka"
+	BrokerWebhook = "webhook"
+
+	ProviderNone    = "none"
+	ProviderWebhook = "webhook"
+	ProviderSlack   = "slack"
+	ProviderDiscord = "discord"
+	ProviderGeneric = "generic"
+	ProviderLog     = "log"
+	ProviderMock    = "mock"
+	ProviderStripe  = "stripe"
+	ProviderFixed   = "fixed"
+	ProviderHTTP    = "http"
+	ProviderSMTP    = "smtp"
+	ProviderSES     = "ses"
+	ProviderTwilio  = "twilio"
+	ProviderFCM     = "fcm"
+	ProviderAPNs    = "apns"
+
+	StorageLocal = "local"
+	StorageS3    = "s3"
+	StorageGCS   = "gcs"
+)
+
+// Load builds the configuration from the environment, falling back to the optional
+// KEY=VALUE file named by CONFIG_FILE and then to defaults
 func Load() *Config {
+	loadConfigFile(lookup("CONFIG_FILE"))
+
 	return &Config{
 		Database: DatabaseConfig{
+			Driver:   getEnv("DB_DRIVER", DriverPostgres),
 			Host:     getEnv("DB_HOST", "localhost"),
 			Port:     getEnv("DB_PORT", "5432"),
 			User:     getEnv("DB_USER", "oolio"),
 			Password: getEnv("DB_PASSWORD", "oolio_password"),
 			DBName:   getEnv("DB_NAME", "oolio_db"),
+
+			MaxOpenConns:      getEnvInt("DB_MAX_OPEN_CONNS", 25),
+			ConnMaxLifetime:   getEnvDuration("DB_CONN_MAX_LIFETIME", 5*time.Minute),
+			ConnMaxIdleTime:   getEnvDuration("DB_CONN_MAX_IDLE_TIME", 0),
+			StatsInterval:     getEnvDuration("DB_STATS_INTERVAL", 30*time.Second),
+			WaitWarnThreshold: getEnvDuration("DB_WAIT_WARN_THRESHOLD", time.Second),
+
+			AutoMigrate: getEnvBool("DB_AUTO_MIGRATE", false),
+
+			RetryMaxAttempts:    getEnvInt("DB_RETRY_MAX_ATTEMPTS", 3),
+			RetryInitialBackoff: getEnvDuration("DB_RETRY_INITIAL_BACKOFF", 50*time.Millisecond),
+			RetryMaxBackoff:     getEnvDuration("DB_RETRY_MAX_BACKOFF", time.Second),
+
+			BreakerFailureThreshold: getEnvInt("DB_BREAKER_FAILURE_THRESHOLD", 5),
+			BreakerProbeInterval:    getEnvDuration("DB_BREAKER_PROBE_INTERVAL", 2*time.Second),
+
+			ReadDSN:              getEnv("DB_READ_DSN", ""),
+			ReplicaCheckInterval: getEnvDuration("DB_REPLICA_CHECK_INTERVAL", 5*time.Second),
 		},
 		Server: ServerConfig{
 			Port: getEnv("SERVER_PORT", "8080"),
 			Host: getEnv("SERVER_HOST", "0.0.0.0"),
+
+			ReadTimeout:       getEnvDuration("SERVER_READ_TIMEOUT", 15*time.Second),
+			ReadHeaderTimeout: getEnvDuration("SERVER_READ_HEADER_TIMEOUT", 5*time.Second),
+			WriteTimeout:      getEnvDuration("SERVER_WRITE_TIMEOUT", 15*time.Second),
+			IdleTimeout:       getEnvDuration("SERVER_IDLE_TIMEOUT", 60*time.Second),
+			ShutdownTimeout:   getEnvDuration("SERVER_SHUTDOWN_TIMEOUT", 30*time.Second),
+			MaxHeaderBytes:    getEnvInt("SERVER_MAX_HEADER_BYTES", 1<<20),
+
+			StatusCacheTTL: getEnvDuration("STATUS_CACHE_TTL", 15*time.Second),
+			JSONCodec:      getEnv("SERVER_JSON_CODEC", ""),
 		},
 		API: APIConfig{
 			APIKey: getEnv("API_KEY", "apitest"),
+
+			OrderLookupSecret: getEnv("ORDER_LOOKUP_SECRET", ""),
+			OrderLookupTTL:    getEnvDuration("ORDER_LOOKUP_TTL", 24*time.Hour),
+
+			ScheduleFeedSecret:  getEnv("SCHEDULE_FEED_SECRET", ""),
+			ScheduleFeedBaseURL: getEnv("SCHEDULE_FEED_BASE_URL", ""),
+			ScheduleFeedTTL:     getEnvDuration("SCHEDULE_FEED_TTL", 90*24*time.Hour),
+
+			V1DeprecatedAt: getEnvTime("API_V1_DEPRECATED_AT"),
+			V1Sunset:       getEnvTime("API_V1_SUNSET"),
 		},
 		Coupon: CouponConfig{
-			BaseURL: getEnv("COUPON_BASE_URL", "https://orderfoodonline-files.s3.ap-southeast-2.amazonaws.com"),
+			BaseURL:   getEnv("COUPON_BASE_URL", "https://orderfoodonline-files.s3.ap-southeast-2.amazonaws.com"),
+			Discounts: getEnvDiscounts("COUPON_DISCOUNTS", map[string]float64{"HAPPYHRS": 10, "FIFTYOFF": 50}),
+			Segments:  getEnvMap("COUPON_SEGMENTS", map[string]string{}),
+			SingleUse: getEnvList("COUPON_SINGLE_USE"),
+
+			DefaultDiscount:    getEnvFloat("COUPON_DEFAULT_DISCOUNT", 5),
+			MinLength:          getEnvInt("COUPON_MIN_LENGTH", 8),
+			MaxLength:          getEnvInt("COUPON_MAX_LENGTH", 10),
+			MinFileOccurrences: getEnvInt("COUPON_MIN_FILE_OCCURRENCES", 2),
+			RefreshInterval:    getEnvDuration("COUPON_REFRESH_INTERVAL", 24*time.Hour),
+			FileTimeout:        getEnvDuration("COUPON_FILE_TIMEOUT", 120*time.Second),
+			MaxDownloadMB:      int64(getEnvInt("COUPON_MAX_DOWNLOAD_MB", 1000)),
+			CacheFiles:         getEnvBool("COUPON_CACHE_FILES", false),
+
+			DialTimeout:           getEnvDuration("COUPON_DIAL_TIMEOUT", 10*time.Second),
+			TLSHandshakeTimeout:   getEnvDuration("COUPON_TLS_HANDSHAKE_TIMEOUT", 10*time.Second),
+			ResponseHeaderTimeout: getEnvDuration("COUPON_RESPONSE_HEADER_TIMEOUT", 30*time.Second),
+			DownloadRetries:       getEnvInt("COUPON_DOWNLOAD_RETRIES", 3),
+			Concurrency:           getEnvInt("COUPON_CONCURRENCY", 3),
+			ParseBufferKB:         getEnvInt("COUPON_PARSE_BUFFER_KB", 256),
+			CSVParser:             getEnvBool("COUPON_CSV_PARSER", false),
+			FileCodesSingleUse:    getEnvBool("COUPON_FILE_CODES_SINGLE_USE", false),
 		},
 		Redis: RedisConfig{
+			Driver:   getEnv("REDIS_DRIVER", DriverRedis),
 			Addr:     getEnv("REDIS_ADDR", "localhost:6379"),
 			Password: getEnv("REDIS_PASSWORD", ""),
-			DB:       0,
+			DB:       getEnvInt("REDIS_DB", 0),
+
+			Addrs:            getEnvList("REDIS_ADDRS"),
+			MasterName:       getEnv("REDIS_SENTINEL_MASTER", ""),
+			SentinelPassword: getEnv("REDIS_SENTINEL_PASSWORD", ""),
+			Username:         getEnv("REDIS_USERNAME", ""),
+
+			TLSEnabled:            getEnvBool("REDIS_TLS_ENABLED", false),
+			TLSServerName:         getEnv("REDIS_TLS_SERVER_NAME", ""),
+			TLSCAFile:             getEnv("REDIS_TLS_CA_FILE", ""),
+			TLSInsecureSkipVerify: getEnvBool("REDIS_TLS_INSECURE_SKIP_VERIFY", false),
+
+			DialTimeout:  getEnvDuration("REDIS_DIAL_TIMEOUT", 5*time.Second),
+			ReadTimeout:  getEnvDuration("REDIS_READ_TIMEOUT", 3*time.Second),
+			WriteTimeout: getEnvDuration("REDIS_WRITE_TIMEOUT", 3*time.Second),
+		},
+		Log: LogConfig{
+			Level:            getEnv("LOG_LEVEL", "info"),
+			SampleInitial:    getEnvInt("LOG_SAMPLE_INITIAL", 100),
+			SampleThereafter: getEnvInt("LOG_SAMPLE_THEREAFTER", 100),
+			AccessLogFile:    getEnv("ACCESS_LOG_FILE", ""),
+			FileMaxSizeMB:    getEnvInt("ACCESS_LOG_MAX_SIZE_MB", 100),
+			FileMaxBackups:   getEnvInt("ACCESS_LOG_MAX_BACKUPS", 5),
+			FileMaxAgeDays:   getEnvInt("ACCESS_LOG_MAX_AGE_DAYS", 30),
+			FileCompress:     getEnvBool("ACCESS_LOG_COMPRESS", true),
+		},
+		Worker: WorkerConfig{
+			Interval:     getEnvDuration("WORKER_INTERVAL", 5*time.Second),
+			BatchSize:    getEnvInt("WORKER_BATCH_SIZE", 10),
+			Concurrency:  getEnvInt("WORKER_CONCURRENCY", 4),
+			DrainTimeout: getEnvDuration("WORKER_DRAIN_TIMEOUT", 15*time.Second),
+			InstanceID:   getEnv("WORKER_INSTANCE_ID", defaultInstanceID()),
+		},
+		RateLimit: RateLimitConfig{
+			ProductPerMinute: getEnvInt("RATE_LIMIT_PRODUCT", 100),
+			OrderPerMinute:   getEnvInt("RATE_LIMIT_ORDER", 50),
+			QueuePerMinute:   getEnvInt("RATE_LIMIT_QUEUE", 30),
+		},
+		Outbox: OutboxConfig{
+			Broker:    getEnv("OUTBOX_BROKER", BrokerLog),
+			Interval:  getEnvDuration("OUTBOX_INTERVAL", time.Second),
+			BatchSize: getEnvInt("OUTBOX_BATCH_SIZE", 100),
+
+			MaxAttempts:  getEnvInt("OUTBOX_MAX_ATTEMPTS", 10),
+			RetryBackoff: getEnvDuration("OUTBOX_RETRY_BACKOFF", 5*time.Second),
+
+			EventSource: getEnv("EVENT_SOURCE", "/oolio"),
+
+			KafkaRESTURL: getEnv("KAFKA_REST_URL", "http://localhost:8082"),
+			KafkaTopic:   getEnv("KAFKA_TOPIC", "oolio.events"),
+			KafkaTopics:  getEnvMap("KAFKA_TOPICS", map[string]string{"order": "oolio.orders", "order_queue_item": "oolio.orders", "product": "oolio.products"}),
+			KafkaTimeout: getEnvDuration("KAFKA_TIMEOUT", 10*time.Second),
+
+			WebhookURL:     getEnv("OUTBOX_WEBHOOK_URL", ""),
+			WebhookSecret:  getEnv("OUTBOX_WEBHOOK_SECRET", ""),
+			WebhookEvents:  getEnvList("OUTBOX_WEBHOOK_EVENTS"),
+			WebhookTimeout: getEnvDuration("OUTBOX_WEBHOOK_TIMEOUT", 10*time.Second),
 		},
+		Cache: CacheConfig{
+			Products: RepositoryCacheConfig{
+				Driver: getEnv("CACHE_PRODUCTS_DRIVER", DriverNone),
+				TTL:    getEnvDuration("CACHE_PRODUCTS_TTL", 30*time.Second),
+				Size:   getEnvInt("CACHE_PRODUCTS_SIZE", 1000),
+			},
+			ProductList: RepositoryCacheConfig{
+				Driver: getEnv("CACHE_PRODUCT_LIST_DRIVER", DriverNone),
+				TTL:    getEnvDuration("CACHE_PRODUCT_LIST_TTL", 10*time.Second),
+				Size:   getEnvInt("CACHE_PRODUCT_LIST_SIZE", 1000),
+			},
+			Responses: RepositoryCacheConfig{
+				Driver: getEnv("CACHE_RESPONSE_DRIVER", DriverNone),
+				TTL:    getEnvDuration("CACHE_RESPONSE_TTL", 30*time.Second),
+				Size:   getEnvInt("CACHE_RESPONSE_SIZE", 1000),
+			},
+		},
+		Fulfillment: FulfillmentConfig{
+			Provider:      getEnv("FULFILLMENT_PROVIDER", ProviderNone),
+			WebhookURL:    getEnv("FULFILLMENT_WEBHOOK_URL", ""),
+			WebhookSecret: getEnv("FULFILLMENT_WEBHOOK_SECRET", ""),
+			Timeout:       getEnvDuration("FULFILLMENT_TIMEOUT", 10*time.Second),
+		},
+		Delivery: DeliveryConfig{
+			Provider:      getEnv("DELIVERY_PROVIDER", ProviderNone),
+			WebhookURL:    getEnv("DELIVERY_WEBHOOK_URL", ""),
+			WebhookSecret: getEnv("DELIVERY_WEBHOOK_SECRET", ""),
+			StatusSecret:  getEnv("DELIVERY_STATUS_SECRET", ""),
+			Timeout:       getEnvDuration("DELIVERY_TIMEOUT", 10*time.Second),
+			MockStep:      getEnvDuration("DELIVERY_MOCK_STEP", time.Minute),
+		},
+		Alert: AlertConfig{
+			Provider:   getEnv("ALERT_PROVIDER", ProviderNone),
+			WebhookURL: getEnv("ALERT_WEBHOOK_URL", ""),
+			Timeout:    getEnvDuration("ALERT_TIMEOUT", 5*time.Second),
+			EmailTo:    getEnvList("ALERT_EMAIL_TO"),
+
+			RetryLinkSecret:  getEnv("ALERT_RETRY_LINK_SECRET", ""),
+			RetryLinkBaseURL: getEnv("ALERT_RETRY_LINK_BASE_URL", ""),
+			RetryLinkTTL:     getEnvDuration("ALERT_RETRY_LINK_TTL", 72*time.Hour),
+
+			QueueBacklog:       getEnvInt("ALERT_QUEUE_BACKLOG", 500),
+			QueueOldest:        getEnvDuration("ALERT_QUEUE_OLDEST", 15*time.Minute),
+			QueueFailureRate:   getEnvFloat("ALERT_QUEUE_FAILURE_RATE", 20),
+			QueueFailureWindow: getEnvDuration("ALERT_QUEUE_FAILURE_WINDOW", 10*time.Minute),
+		},
+		Storage: StorageConfig{
+			Driver:          getEnv("STORAGE_DRIVER", StorageLocal),
+			Dir:             getEnv("STORAGE_DIR", "data/files"),
+			Bucket:          getEnv("STORAGE_BUCKET", ""),
+			Region:          getEnv("STORAGE_REGION", "us-east-1"),
+			Endpoint:        getEnv("STORAGE_ENDPOINT", ""),
+			AccessKeyID:     getEnv("STORAGE_ACCESS_KEY_ID", ""),
+			SecretAccessKey: getEnv("STORAGE_SECRET_ACCESS_KEY", ""),
+			Timeout:         getEnvDuration("STORAGE_TIMEOUT", 5*time.Minute),
+			PublicURL:       getEnv("STORAGE_PUBLIC_URL", "http://localhost:"+getEnv("SERVER_PORT", "8080")+"/files"),
+		},
+		MenuImport: MenuImportConfig{
+			Provider: getEnv("MENU_IMPORT_PROVIDER", ProviderNone),
+			URL:      getEnv("MENU_IMPORT_URL", ""),
+			Token:    getEnv("MENU_IMPORT_TOKEN", ""),
+			Interval: getEnvDuration("MENU_IMPORT_INTERVAL", time.Hour),
+			Timeout:  getEnvDuration("MENU_IMPORT_TIMEOUT", 30*time.Second),
+		},
+		Cart: CartConfig{
+			GuestTTL:        getEnvDuration("CART_GUEST_TTL", 7*24*time.Hour),
+			CleanupInterval: getEnvDuration("CART_CLEANUP_INTERVAL", time.Hour),
+		},
+		Inventory: InventoryConfig{
+			LowStockThreshold: getEnvInt("INVENTORY_LOW_STOCK_THRESHOLD", 5),
+			ReservationTTL:    getEnvDuration("INVENTORY_RESERVATION_TTL", 15*time.Minute),
+		},
+		Verification: VerificationConfig{
+			Provider:      getEnv("VERIFICATION_PROVIDER", ProviderNone),
+			WebhookURL:    getEnv("VERIFICATION_WEBHOOK_URL", ""),
+			Token:         getEnv("VERIFICATION_TOKEN", ""),
+			Secret:        getEnv("VERIFICATION_SECRET", ""),
+			Timeout:       getEnvDuration("VERIFICATION_TIMEOUT", 10*time.Second),
+			CodeTTL:       getEnvDuration("VERIFICATION_CODE_TTL", 10*time.Minute),
+			TokenTTL:      getEnvDuration("VERIFICATION_TOKEN_TTL", time.Hour),
+			GuestOrderMin: getEnvFloat("VERIFICATION_GUEST_ORDER_MIN", 0),
+		},
+		Notification: NotificationConfig{
+			EmailProvider:   getEnv("NOTIFICATION_EMAIL_PROVIDER", ProviderNone),
+			EmailWebhookURL: getEnv("NOTIFICATION_EMAIL_WEBHOOK_URL", ""),
+			EmailFrom:       getEnv("NOTIFICATION_EMAIL_FROM", ""),
+			SMSProvider:     getEnv("NOTIFICATION_SMS_PROVIDER", ProviderNone),
+			SMSWebhookURL:   getEnv("NOTIFICATION_SMS_WEBHOOK_URL", ""),
+			Token:           getEnv("NOTIFICATION_TOKEN", ""),
+			Timeout:         getEnvDuration("NOTIFICATION_TIMEOUT", 10*time.Second),
+
+			SMTPHost:     getEnv("NOTIFICATION_SMTP_HOST", ""),
+			SMTPPort:     getEnvInt("NOTIFICATION_SMTP_PORT", 587),
+			SMTPUsername: getEnv("NOTIFICATION_SMTP_USERNAME", ""),
+			SMTPPassword: getEnv("NOTIFICATION_SMTP_PASSWORD", ""),
+
+			SESRegion:          getEnv("NOTIFICATION_SES_REGION", ""),
+			SESEndpoint:        getEnv("NOTIFICATION_SES_ENDPOINT", ""),
+			SESAccessKeyID:     getEnv("NOTIFICATION_SES_ACCESS_KEY_ID", ""),
+			SESSecretAccessKey: getEnv("NOTIFICATION_SES_SECRET_ACCESS_KEY", ""),
+
+			TwilioAccountSID:     getEnv("NOTIFICATION_TWILIO_ACCOUNT_SID", ""),
+			TwilioAuthToken:      getEnv("NOTIFICATION_TWILIO_AUTH_TOKEN", ""),
+			TwilioFrom:           getEnv("NOTIFICATION_TWILIO_FROM", ""),
+			TwilioBaseURL:        getEnv("NOTIFICATION_TWILIO_BASE_URL", ""),
+			SMSStatusCallbackURL: getEnv("NOTIFICATION_SMS_STATUS_CALLBACK_URL", ""),
+
+			SMSReadyEnabled: getEnvBool("NOTIFICATION_SMS_READY_ENABLED", true),
+			SMSPerHour:      getEnvInt("NOTIFICATION_SMS_PER_HOUR", 5),
+
+			PushProviders:      getEnvList("NOTIFICATION_PUSH_PROVIDERS"),
+			PushEnabled:        getEnvBool("NOTIFICATION_PUSH_ENABLED", false),
+			FCMCredentialsFile: getEnv("NOTIFICATION_FCM_CREDENTIALS_FILE", ""),
+			FCMBaseURL:         getEnv("NOTIFICATION_FCM_BASE_URL", ""),
+			APNsKeyFile:        getEnv("NOTIFICATION_APNS_KEY_FILE", ""),
+			APNsKeyID:          getEnv("NOTIFICATION_APNS_KEY_ID", ""),
+			APNsTeamID:         getEnv("NOTIFICATION_APNS_TEAM_ID", ""),
+			APNsTopic:          getEnv("NOTIFICATION_APNS_TOPIC", ""),
+			APNsProduction:     getEnvBool("NOTIFICATION_APNS_PRODUCTION", false),
+			APNsBaseURL:        getEnv("NOTIFICATION_APNS_BASE_URL", ""),
+
+			TemplateDir: getEnv("NOTIFICATION_TEMPLATE_DIR", ""),
+
+			Sandbox:         getEnvBool("NOTIFICATION_SANDBOX", false),
+			SandboxAllow:    getEnvList("NOTIFICATION_SANDBOX_ALLOW"),
+			SandboxRedirect: getEnv("NOTIFICATION_SANDBOX_REDIRECT", ""),
+
+			WorkerInterval:  getEnvDuration("NOTIFICATION_WORKER_INTERVAL", 5*time.Second),
+			WorkerBatchSize: getEnvInt("NOTIFICATION_WORKER_BATCH_SIZE", 20),
+			MaxAttempts:     getEnvInt("NOTIFICATION_MAX_ATTEMPTS", 8),
+			RetryBackoff:    getEnvDuration("NOTIFICATION_RETRY_BACKOFF", 30*time.Second),
+		},
+		Payment: PaymentConfig{
+			Provider:         getEnv("PAYMENT_PROVIDER", ProviderNone),
+			Required:         getEnvBool("PAYMENT_REQUIRED", false),
+			Window:           getEnvDuration("PAYMENT_WINDOW", 30*time.Minute),
+			Methods:          getEnvList("PAYMENT_METHODS"),
+			Currency:         strings.ToLower(getEnv("PAYMENT_CURRENCY", "aud")),
+			StripeSecretKey:  getEnv("PAYMENT_STRIPE_SECRET_KEY", ""),
+			StripeBaseURL:    getEnv("PAYMENT_STRIPE_BASE_URL", ""),
+			Timeout:          getEnvDuration("PAYMENT_TIMEOUT", 30*time.Second),
+			WebhookSecret:    getEnv("PAYMENT_WEBHOOK_SECRET", ""),
+			WebhookTolerance: getEnvDuration("PAYMENT_WEBHOOK_TOLERANCE", 5*time.Minute),
+		},
+		Invoice: InvoiceConfig{
+			Series:      strings.ToUpper(getEnv("INVOICE_SERIES", "INV")),
+			SellerName:  getEnv("INVOICE_SELLER_NAME", "Oolio"),
+			SellerTaxID: getEnv("INVOICE_SELLER_TAX_ID", ""),
+			TaxName:     getEnv("INVOICE_TAX_NAME", "GST"),
+			TaxRate:     getEnvFloat("INVOICE_TAX_RATE", 10),
+		},
+		Currency: CurrencyConfig{
+			Provider:   getEnv("CURRENCY_PROVIDER", ProviderNone),
+			Rates:      getEnvRates("CURRENCY_RATES"),
+			Settlement: getEnvList("CURRENCY_SETTLEMENT"),
+			RatesURL:   getEnv("CURRENCY_RATES_URL", "https://api.frankfurter.app/latest"),
+			CacheTTL:   getEnvDuration("CURRENCY_CACHE_TTL", time.Hour),
+			Timeout:    getEnvDuration("CURRENCY_TIMEOUT", 10*time.Second),
+		},
+		Segment: SegmentConfig{
+			RefreshInterval: getEnvDuration("SEGMENT_REFRESH_INTERVAL", time.Hour),
+			NewWindow:       getEnvDuration("SEGMENT_NEW_WINDOW", 30*24*time.Hour),
+			LapsedAfter:     getEnvDuration("SEGMENT_LAPSED_AFTER", 90*24*time.Hour),
+			VIPSpend:        getEnvFloat("SEGMENT_VIP_SPEND", 500),
+			VIPWindow:       getEnvDuration("SEGMENT_VIP_WINDOW", 365*24*time.Hour),
+		},
+		Digest: DigestConfig{
+			Recipients:    getEnvList("DIGEST_RECIPIENTS"),
+			WebhookURL:    getEnv("DIGEST_WEBHOOK_URL", ""),
+			WebhookSecret: getEnv("DIGEST_WEBHOOK_SECRET", ""),
+			Hour:          getEnvInt("DIGEST_HOUR", 6),
+			TimeZone:      getEnv("DIGEST_TIME_ZONE", "UTC"),
+			TopProducts:   getEnvInt("DIGEST_TOP_PRODUCTS", 5),
+			Timeout:       getEnvDuration("DIGEST_TIMEOUT", 10*time.Second),
+		},
+		Reconcile: ReconcileConfig{
+			Interval: getEnvDuration("RECONCILE_INTERVAL", time.Hour),
+			Window:   getEnvDuration("RECONCILE_WINDOW", 48*time.Hour),
+		},
+		Scheduler: SchedulerConfig{
+			LockTTL:   getEnvDuration("SCHEDULER_LOCK_TTL", 15*time.Minute),
+			Schedules: getEnvSchedules("SCHEDULER_SCHEDULES"),
+			TimeZone:  getEnv("SCHEDULER_TIME_ZONE", "UTC"),
+		},
+		Webhook: WebhookConfig{
+			Interval:     getEnvDuration("WEBHOOK_WORKER_INTERVAL", 5*time.Second),
+			BatchSize:    getEnvInt("WEBHOOK_WORKER_BATCH_SIZE", 20),
+			MaxAttempts:  getEnvInt("WEBHOOK_MAX_ATTEMPTS", 10),
+			RetryBackoff: getEnvDuration("WEBHOOK_RETRY_BACKOFF", 30*time.Second),
+			Concurrency:  getEnvInt("WEBHOOK_CONCURRENCY", 4),
+		},
+		Chaos: ChaosConfig{
+			Enabled:  getEnvBool("CHAOS_ENABLED", false),
+			HTTP:     getEnvFault("CHAOS_HTTP"),
+			Database: getEnvFault("CHAOS_DB"),
+			Redis:    getEnvFault("CHAOS_REDIS"),
+		},
+	}
+}
+
+const redactedValue = "******"
+
+// Redacted returns a copy of the configuration with secrets masked, safe to expose to operators
+func (c *Config) Redacted() Config {
+	redacted := *c
+	redacted.Database.Password = redact(c.Database.Password)
+	redacted.Database.ReadDSN = redact(c.Database.ReadDSN)
+	redacted.API.APIKey = redact(c.API.APIKey)
+	redacted.API.OrderLookupSecret = redact(c.API.OrderLookupSecret)
+	redacted.API.ScheduleFeedSecret = redact(c.API.ScheduleFeedSecret)
+	redacted.Verification.Token = redact(c.Verification.Token)
+	redacted.Verification.Secret = redact(c.Verification.Secret)
+	redacted.Notification.Token = redact(c.Notification.Token)
+	redacted.Notification.SMTPPassword = redact(c.Notification.SMTPPassword)
+	redacted.Notification.SESSecretAccessKey = redact(c.Notification.SESSecretAccessKey)
+	redacted.Notification.TwilioAuthToken = redact(c.Notification.TwilioAuthToken)
+	redacted.Payment.StripeSecretKey = redact(c.Payment.StripeSecretKey)
+	redacted.Payment.WebhookSecret = redact(c.Payment.WebhookSecret)
+	redacted.Outbox.WebhookSecret = redact(c.Outbox.WebhookSecret)
+	redacted.Redis.Password = redact(c.Redis.Password)
+	redacted.Redis.SentinelPassword = redact(c.Redis.SentinelPassword)
+	redacted.Fulfillment.WebhookSecret = redact(c.Fulfillment.WebhookSecret)
+	redacted.Delivery.WebhookSecret = redact(c.Delivery.WebhookSecret)
+	redacted.Delivery.StatusSecret = redact(c.Delivery.StatusSecret)
+	redacted.Digest.WebhookSecret = redact(c.Digest.WebhookSecret)
+	redacted.Alert.WebhookURL = redact(c.Alert.WebhookURL)
+	redacted.Alert.RetryLinkSecret = redact(c.Alert.RetryLinkSecret)
+	redacted.Storage.SecretAccessKey = redact(c.Storage.SecretAccessKey)
+	redacted.MenuImport.Token = redact(c.MenuImport.Token)
+	return redacted
+}
+
+func redact(secret string) string {
+	if secret == "" {
+		return ""
 	}
+	return redactedValue
+}
+
+func (c *ServerConfig) Validate() error {
+	if c.Port == "" {
+		return fmt.Errorf("server port is required")
+	}
+	if c.ReadTimeout <= 0 || c.WriteTimeout <= 0 || c.IdleTimeout <= 0 {
+		return fmt.Errorf("server read, write and idle timeouts must be greater than 0")
+	}
+	if c.ReadHeaderTimeout < 0 || c.ReadHeaderTimeout > c.ReadTimeout {
+		return fmt.Errorf("server read header timeout must be between 0 and the read timeout")
+	}
+	if c.ShutdownTimeout <= 0 {
+		return fmt.Errorf("server shutdown timeout must be greater than 0")
+	}
+	if c.MaxHeaderBytes < 4096 {
+		return fmt.Errorf("server max header bytes must be at least 4096, got %d", c.MaxHeaderBytes)
+	}
+	return nil
 }
 
 func (c *DatabaseConfig) ConnectionString() string {
@@ -72,9 +940,165 @@ func (c *DatabaseConfig) ConnectionString() string {
 		c.User, c.Password, c.Host, c.Port, c.DBName)
 }
 
+// defaultInstanceID names the process by its host, which is the container in a deployment,
+// and its process ID
+func defaultInstanceID() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
+
 func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
+	if value := lookup(key); value != "" {
 		return value
 	}
 	return defaultValue
 }
+
+func getEnvInt(key string, defaultValue int) int {
+	if value := lookup(key); value != "" {
+		if i, err := strconv.Atoi(value); err == nil {
+			return i
+		}
+	}
+	return defaultValue
+}
+
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value := lookup(key); value != "" {
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	}
+	return defaultValue
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := lookup(key); value != "" {
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return defaultValue
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := lookup(key); value != "" {
+		if d, err := time.ParseDuration(value); err == nil {
+			return d
+		}
+	}
+	return defaultValue
+}
+
+// getEnvFault reads the fault of a subsystem from PREFIX_LATENCY, PREFIX_LATENCY_PERCENT
+// and PREFIX_ERROR_PERCENT
+func getEnvFault(prefix string) FaultConfig {
+	return FaultConfig{
+		Latency:        getEnvDuration(prefix+"_LATENCY", 0),
+		LatencyPercent: getEnvFloat(prefix+"_LATENCY_PERCENT", 0),
+		ErrorPercent:   getEnvFloat(prefix+"_ERROR_PERCENT", 0),
+	}
+}
+
+// getEnvTime parses an RFC 3339 timestamp or a date such as 2026-12-31 (midnight UTC);
+// unset or invalid values give the zero time
+func getEnvTime(key string) time.Time {
+	value := lookup(key)
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// getEnvList parses a comma-separated list, dropping empty entries
+func getEnvList(key string) []string {
+	value := lookup(key)
+	if value == "" {
+		return nil
+	}
+
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// getEnvMap parses "KEY:VALUE,KEY:VALUE"; malformed entries are skipped
+func getEnvMap(key string, defaultValue map[string]string) map[string]string {
+	value := lookup(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	m := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		k, v, found := strings.Cut(strings.TrimSpace(pair), ":")
+		if !found {
+			continue
+		}
+		m[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return m
+}
+
+// getEnvSchedules parses "JOB=SCHEDULE;JOB=SCHEDULE", semicolons since cron expressions
+// hold commas; malformed entries are skipped
+func getEnvSchedules(key string) map[string]string {
+	schedules := make(map[string]string)
+	for _, entry := range strings.Split(lookup(key), ";") {
+		job, schedule, found := strings.Cut(entry, "=")
+		if !found || strings.TrimSpace(job) == "" {
+			continue
+		}
+		schedules[strings.TrimSpace(job)] = strings.TrimSpace(schedule)
+	}
+	return schedules
+}
+
+// getEnvDiscounts parses "CODE:PERCENT,CODE:PERCENT"; malformed entries are skipped
+func getEnvDiscounts(key string, defaultValue map[string]float64) map[string]float64 {
+	value := lookup(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	discounts := make(map[string]float64)
+	for _, pair := range strings.Split(value, ",") {
+		code, percent, found := strings.Cut(strings.TrimSpace(pair), ":")
+		if !found {
+			continue
+		}
+		p, err := strconv.ParseFloat(strings.TrimSpace(percent), 64)
+		if err != nil {
+			continue
+		}
+		discounts[strings.ToUpper(strings.TrimSpace(code))] = p
+	}
+	return discounts
+}
+
+// getEnvRates parses "CURRENCY:RATE,CURRENCY:RATE" with currencies in lower case;
+// malformed entries and rates that aren't positive are skipped
+func getEnvRates(key string) map[string]float64 {
+	rates := make(map[string]float64)
+	for _, pair := range strings.Split(lookup(key), ",") {
+		currency, rate, found := strings.Cut(strings.TrimSpace(pair), ":")
+		if !found {
+			continue
+		}
+		r, err := strconv.ParseFloat(strings.TrimSpace(rate), 64)
+		if err != nil || r <= 0 {
+			continue
+		}
+		rates[strings.ToLower(strings.TrimSpace(currency))] = r
+	}
+	return rates
+}
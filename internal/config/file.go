@@ -0,0 +1,71 @@
+package config
+
+import (
+	"bufio"
+	"os"
+	"strings"
+	"sync"
+)
+
+var (
+	fileMutex  sync.RWMutex
+	fileValues = map[string]string{}
+	overrides  = map[string]string{}
+)
+
+// SetOverride pins key to value above every other source, e.g. for command line flags
+func SetOverride(key, value string) {
+	fileMutex.Lock()
+	defer fileMutex.Unlock()
+	overrides[key] = value
+}
+
+// loadConfigFile reads a dotenv-style KEY=VALUE file. A missing or unreadable file
+// leaves the previously loaded values untouched so a bad edit can't wipe a running config
+func loadConfigFile(path string) {
+	if path == "" {
+		return
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			continue
+		}
+		values[strings.TrimSpace(key)] = strings.Trim(strings.TrimSpace(value), `"'`)
+	}
+	if scanner.Err() != nil {
+		return
+	}
+
+	fileMutex.Lock()
+	fileValues = values
+	fileMutex.Unlock()
+}
+
+// lookup resolves a key from overrides first, then the environment, then the config file
+func lookup(key string) string {
+	fileMutex.RLock()
+	defer fileMutex.RUnlock()
+
+	if value := overrides[key]; value != "" {
+		return value
+	}
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fileValues[key]
+}
@@ -0,0 +1,48 @@
+package config
+
+import "sync"
+
+// Registry holds the live configuration and notifies subscribers when it is reloaded.
+// Only settings that are safe to change at runtime (log level, rate limits, coupon
+// discounts, worker batch size, ready texts, push notifications) should be read from the
+// reloaded config by subscribers.
+type Registry struct {
+	mutex       sync.RWMutex
+	current     *Config
+	subscribers []func(cfg *Config)
+}
+
+func NewRegistry(cfg *Config) *Registry {
+	return &Registry{
+		current: cfg,
+	}
+}
+
+func (r *Registry) Current() *Config {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.current
+}
+
+// Subscribe registers fn to be called with the new config after every reload
+func (r *Registry) Subscribe(fn func(cfg *Config)) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.subscribers = append(r.subscribers, fn)
+}
+
+// Reload re-reads the environment and config file and notifies all subscribers
+func (r *Registry) Reload() *Config {
+	cfg := Load()
+
+	r.mutex.Lock()
+	r.current = cfg
+	subscribers := append([]func(cfg *Config){}, r.subscribers...)
+	r.mutex.Unlock()
+
+	for _, fn := range subscribers {
+		fn(cfg)
+	}
+
+	return cfg
+}
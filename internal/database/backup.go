@@ -0,0 +1,652 @@
+package database
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+
+	"oolio/internal/app/models"
+	"oolio/internal/database/sqlc"
+)
+
+// Backups are JSON Lines bundles: a header naming the format and its version, then one
+// record per line, the stores first and then the products, coupons and orders, each in key
+// order, and an end record with the counts. Export flushes a page of records at a time,
+// so an export that was cut short can be resumed after its last complete record. Import
+// skips records that already exist and commits a page at a time, so an import that was cut
+// short can simply be run again.
+
+const (
+	BackupFormat  = "oolio-backup"
+	BackupVersion = 1
+
+	backupPageSize = 500
+)
+
+// Record types of a backup bundle. Stores are included because products and orders
+// refer to them.
+const (
+	BackupStore   = "store"
+	BackupProduct = "product"
+	BackupCoupon  = "coupon"
+	BackupOrder   = "order"
+	backupEnd     = "end"
+)
+
+// backupSections are the record types in the order they are exported
+var backupSections = []string{BackupStore, BackupProduct, BackupCoupon, BackupOrder}
+
+var (
+	ErrBackupFormat     = errors.New("not an oolio backup bundle")
+	ErrBackupIncomplete = errors.New("the bundle was cut short before its end record")
+)
+
+// BackupHeader is the first line of a bundle
+type BackupHeader struct {
+	Format        string    `json:"format"`
+	Version       int       `json:"version"`
+	SchemaVersion uint      `json:"schemaVersion"` // Migration the database was at
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+// BackupCounts counts records by type
+type BackupCounts map[string]int
+
+// BackupPosition is how far a bundle got: its header, unless it is empty, and the type and
+// key of the last complete record. Size is the length of the bundle up to the end of that
+// record; anything after it is a partly written record.
+type BackupPosition struct {
+	Header   *BackupHeader
+	Type     string
+	Key      string
+	Counts   BackupCounts
+	Size     int64
+	Complete bool
+}
+
+// BackupImportResult counts the records imported and those skipped because they existed
+type BackupImportResult struct {
+	Imported BackupCounts
+	Skipped  BackupCounts
+}
+
+type backupRecord struct {
+	Type   string          `json:"type"`
+	Key    string          `json:"key,omitempty"`
+	Data   json.RawMessage `json:"data,omitempty"`
+	Counts BackupCounts    `json:"counts,omitempty"`
+}
+
+type backupStore struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+type backupProduct struct {
+	ID           uuid.UUID    `json:"id"`
+	StoreID      uuid.UUID    `json:"storeId"`
+	Name         string       `json:"name"`
+	Price        models.Money `json:"price"`
+	Category     string       `json:"category"`
+	ThumbnailURL string       `json:"thumbnailUrl,omitempty"`
+	MobileURL    string       `json:"mobileUrl,omitempty"`
+	TabletURL    string       `json:"tabletUrl,omitempty"`
+	DesktopURL   string       `json:"desktopUrl,omitempty"`
+	CreatedAt    *time.Time   `json:"createdAt,omitempty"`
+	UpdatedAt    *time.Time   `json:"updatedAt,omitempty"`
+	DeletedAt    *time.Time   `json:"deletedAt,omitempty"`
+	// Options are the product's variants then modifiers, each kind in its order
+	Options []backupProductOption `json:"options,omitempty"`
+}
+
+type backupProductOption struct {
+	ID         uuid.UUID    `json:"id"`
+	Kind       string       `json:"kind"`
+	Name       string       `json:"name"`
+	PriceDelta models.Money `json:"priceDelta"`
+}
+
+type backupCoupon struct {
+	Code               string     `json:"code"`
+	DiscountPercentage float64    `json:"discountPercentage"`
+	Segment            string     `json:"segment,omitempty"`
+	SingleUse          bool       `json:"singleUse,omitempty"`
+	StoreID            *uuid.UUID `json:"storeId,omitempty"`
+	CreatedAt          time.Time  `json:"createdAt"`
+	DeletedAt          *time.Time `json:"deletedAt,omitempty"`
+}
+
+type backupOrder struct {
+	ID              uuid.UUID         `json:"id"`
+	StoreID         uuid.UUID         `json:"storeId"`
+	Status          string            `json:"status,omitempty"`
+	Total           models.Money      `json:"total"`
+	Discounts       models.Money      `json:"discounts"`
+	StoreCredit     models.Money      `json:"storeCredit"`
+	CustomerID      string            `json:"customerId,omitempty"`
+	PaymentIntentID string            `json:"paymentIntentId,omitempty"`
+	PaymentStatus   string            `json:"paymentStatus,omitempty"`
+	PaymentMethod   string            `json:"paymentMethod,omitempty"`
+	GiftCardCode    string            `json:"giftCardCode,omitempty"`
+	CouponCode      string            `json:"couponCode,omitempty"`
+	Sandbox         bool              `json:"sandbox,omitempty"`
+	CreatedAt       *time.Time        `json:"createdAt,omitempty"`
+	UpdatedAt       *time.Time        `json:"updatedAt,omitempty"`
+	Items           []backupOrderItem `json:"items"`
+}
+
+type backupOrderItem struct {
+	ProductID uuid.UUID    `json:"productId"`
+	Quantity  int32        `json:"quantity"`
+	Price     models.Money `json:"price"`
+	CreatedAt time.Time    `json:"createdAt,omitzero"`
+	// Options are the variant and modifiers the item was charged for, as the order stores them
+	Options json.RawMessage `json:"options,omitempty"`
+}
+
+// ExportBackup writes the stores, products, coupons and orders to w, from one snapshot of
+// the database. A from position with a header resumes the bundle after its last complete
+// record instead, with w positioned at its Size; the records written since are of a new
+// snapshot. It returns the counts of the whole bundle.
+func (d *Database) ExportBackup(ctx context.Context, w io.Writer, from BackupPosition) (BackupCounts, error) {
+	if d.InMemory() {
+		return nil, fmt.Errorf("backups are not available with the memory driver")
+	}
+	if from.Complete {
+		return from.Counts, nil
+	}
+
+	out := bufio.NewWriter(w)
+	enc := json.NewEncoder(out)
+	if from.Header == nil {
+		version, _, err := d.MigrationVersion()
+		if err != nil {
+			return nil, err
+		}
+		if err := enc.Encode(BackupHeader{Format: BackupFormat, Version: BackupVersion, SchemaVersion: version, CreatedAt: time.Now().UTC()}); err != nil {
+			return nil, err
+		}
+	}
+
+	tx, err := d.DB.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	export := &backupExport{queries: sqlc.New(tx), enc: enc, counts: BackupCounts{}}
+	for recordType, count := range from.Counts {
+		export.counts[recordType] = count
+	}
+
+	for i, section := range backupSections {
+		after := ""
+		if from.Type != "" {
+			resumed := slices.Index(backupSections, from.Type)
+			if i < resumed {
+				continue
+			}
+			if i == resumed {
+				after = from.Key
+			}
+		}
+
+		for {
+			last, err := export.page(ctx, section, after)
+			if err != nil {
+				return nil, fmt.Errorf("failed to export %ss: %w", section, err)
+			}
+			// A page is flushed whole, so a bundle cut short ends with at most one partial record
+			if err := out.Flush(); err != nil {
+				return nil, err
+			}
+			if last == "" {
+				break
+			}
+			after = last
+		}
+	}
+
+	if err := enc.Encode(backupRecord{Type: backupEnd, Counts: export.counts}); err != nil {
+		return nil, err
+	}
+	if err := out.Flush(); err != nil {
+		return nil, err
+	}
+	return export.counts, nil
+}
+
+type backupExport struct {
+	queries *sqlc.Queries
+	enc     *json.Encoder
+	counts  BackupCounts
+}
+
+// page writes the records of one page of the section after the key after, returning the
+// key of the last one, or "" when the section has no more
+func (e *backupExport) page(ctx context.Context, section, after string) (string, error) {
+	afterID := uuid.Nil
+	if after != "" && section != BackupCoupon {
+		parsed, err := uuid.Parse(after)
+		if err != nil {
+			return "", fmt.Errorf("invalid key %q: %w", after, err)
+		}
+		afterID = parsed
+	}
+
+	var records []any
+	var keys []string
+	switch section {
+	case BackupStore:
+		stores, err := e.queries.ListStoresAfter(ctx, sqlc.ListStoresAfterParams{After: afterID, MaxRows: backupPageSize})
+		if err != nil {
+			return "", err
+		}
+		for _, store := range stores {
+			records = append(records, backupStore{ID: store.ID, Name: store.Name, CreatedAt: store.CreatedAt, UpdatedAt: store.UpdatedAt})
+			keys = append(keys, store.ID.String())
+		}
+	case BackupProduct:
+		products, err := e.queries.ListProductsAfter(ctx, sqlc.ListProductsAfterParams{After: afterID, MaxRows: backupPageSize})
+		if err != nil {
+			return "", err
+		}
+		ids := make([]uuid.UUID, len(products))
+		for i, product := range products {
+			ids[i] = product.ID
+		}
+		options, err := e.queries.GetProductOptions(ctx, ids)
+		if err != nil {
+			return "", err
+		}
+		byProduct := make(map[uuid.UUID][]backupProductOption, len(products))
+		for _, option := range options {
+			byProduct[option.ProductID] = append(byProduct[option.ProductID], backupProductOption{
+				ID:         option.ID,
+				Kind:       option.Kind,
+				Name:       option.Name,
+				PriceDelta: option.PriceDelta,
+			})
+		}
+		for _, product := range products {
+			records = append(records, backupProduct{
+				ID:           product.ID,
+				StoreID:      product.StoreID,
+				Name:         product.Name,
+				Price:        product.Price,
+				Category:     product.Category,
+				ThumbnailURL: product.ThumbnailUrl.String,
+				MobileURL:    product.MobileUrl.String,
+				TabletURL:    product.TabletUrl.String,
+				DesktopURL:   product.DesktopUrl.String,
+				CreatedAt:    nullTimePtr(product.CreatedAt),
+				UpdatedAt:    nullTimePtr(product.UpdatedAt),
+				DeletedAt:    nullTimePtr(product.DeletedAt),
+				Options:      byProduct[product.ID],
+			})
+			keys = append(keys, product.ID.String())
+		}
+	case BackupCoupon:
+		coupons, err := e.queries.ListCouponsAfter(ctx, sqlc.ListCouponsAfterParams{After: after, MaxRows: backupPageSize})
+		if err != nil {
+			return "", err
+		}
+		for _, coupon := range coupons {
+			record := backupCoupon{
+				Code:               coupon.Code,
+				DiscountPercentage: coupon.DiscountPercentage,
+				Segment:            coupon.Segment.String,
+				SingleUse:          coupon.SingleUse,
+				CreatedAt:          coupon.CreatedAt,
+				DeletedAt:          nullTimePtr(coupon.DeletedAt),
+			}
+			if coupon.StoreID.Valid {
+				record.StoreID = &coupon.StoreID.UUID
+			}
+			records = append(records, record)
+			keys = append(keys, coupon.Code)
+		}
+	case BackupOrder:
+		orders, err := e.queries.ListOrdersAfter(ctx, sqlc.ListOrdersAfterParams{After: afterID, MaxRows: backupPageSize})
+		if err != nil {
+			return "", err
+		}
+		ids := make([]uuid.UUID, len(orders))
+		for i, order := range orders {
+			ids[i] = order.ID
+		}
+		items, err := e.queries.ListOrderItemsByOrderIDs(ctx, ids)
+		if err != nil {
+			return "", err
+		}
+		byOrder := make(map[uuid.UUID][]backupOrderItem, len(orders))
+		for _, item := range items {
+			record := backupOrderItem{
+				ProductID: item.ProductID.UUID,
+				Quantity:  item.Quantity,
+				Price:     item.PriceAtTime,
+				CreatedAt: item.CreatedAt.Time,
+			}
+			if string(item.Options) != "[]" {
+				record.Options = item.Options
+			}
+			byOrder[item.OrderID.UUID] = append(byOrder[item.OrderID.UUID], record)
+		}
+		for _, order := range orders {
+			records = append(records, backupOrder{
+				ID:              order.ID,
+				StoreID:         order.StoreID,
+				Status:          order.Status.String,
+				Total:           order.Total,
+				Discounts:       order.Discounts,
+				StoreCredit:     order.StoreCredit,
+				CustomerID:      order.CustomerID.String,
+				PaymentIntentID: order.PaymentIntentID.String,
+				PaymentStatus:   order.PaymentStatus.String,
+				PaymentMethod:   order.PaymentMethod,
+				GiftCardCode:    order.GiftCardCode,
+				CouponCode:      order.CouponCode,
+				Sandbox:         order.Sandbox,
+				CreatedAt:       nullTimePtr(order.CreatedAt),
+				UpdatedAt:       nullTimePtr(order.UpdatedAt),
+				Items:           byOrder[order.ID],
+			})
+			keys = append(keys, order.ID.String())
+		}
+	}
+
+	for i, record := range records {
+		data, err := json.Marshal(record)
+		if err != nil {
+			return "", err
+		}
+		if err := e.enc.Encode(backupRecord{Type: section, Key: keys[i], Data: data}); err != nil {
+			return "", err
+		}
+		e.counts[section]++
+	}
+	if len(records) < backupPageSize {
+		return "", nil
+	}
+	return keys[len(keys)-1], nil
+}
+
+// ScanBackup reads a bundle, or what was written of it, to find where it got to
+func ScanBackup(r io.Reader) (BackupPosition, error) {
+	position := BackupPosition{Counts: BackupCounts{}}
+	in := bufio.NewReader(r)
+	for {
+		line, err := in.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			// A last line without its newline was cut short
+			return position, nil
+		}
+		if err != nil {
+			return position, err
+		}
+
+		if position.Header == nil {
+			header, err := parseBackupHeader(line)
+			if err != nil {
+				return position, err
+			}
+			position.Header = header
+			position.Size += int64(len(line))
+			continue
+		}
+
+		var record backupRecord
+		if err := json.Unmarshal(line, &record); err != nil {
+			return position, fmt.Errorf("invalid record after %d bytes: %w", position.Size, err)
+		}
+		if position.Complete {
+			return position, fmt.Errorf("records after the end record at %d bytes", position.Size)
+		}
+		switch {
+		case record.Type == backupEnd:
+			position.Complete = true
+		case slices.Contains(backupSections, record.Type):
+			position.Type, position.Key = record.Type, record.Key
+			position.Counts[record.Type]++
+		default:
+			return position, fmt.Errorf("unknown record type %q after %d bytes", record.Type, position.Size)
+		}
+		position.Size += int64(len(line))
+	}
+}
+
+func parseBackupHeader(line []byte) (*BackupHeader, error) {
+	var header BackupHeader
+	if err := json.Unmarshal(line, &header); err != nil || header.Format != BackupFormat {
+		return nil, ErrBackupFormat
+	}
+	if header.Version < 1 || header.Version > BackupVersion {
+		return nil, fmt.Errorf("backup version %d is not supported, expected at most %d", header.Version, BackupVersion)
+	}
+	return &header, nil
+}
+
+// ImportBackup adds the records of a bundle read from r that are not in the database yet,
+// matching stores, products and orders by ID and coupons by code. Records are committed a
+// page at a time, so a failed import keeps what it got through and can be run again. It
+// fails with ErrBackupIncomplete, after importing what there was, for a bundle without its
+// end record.
+func (d *Database) ImportBackup(ctx context.Context, r io.Reader) (BackupImportResult, error) {
+	result := BackupImportResult{Imported: BackupCounts{}, Skipped: BackupCounts{}}
+	if d.InMemory() {
+		return result, fmt.Errorf("backups are not available with the memory driver")
+	}
+
+	dec := json.NewDecoder(bufio.NewReader(r))
+	var headerLine json.RawMessage
+	if err := dec.Decode(&headerLine); err != nil {
+		return result, ErrBackupFormat
+	}
+	if _, err := parseBackupHeader(bytes.TrimSpace(headerLine)); err != nil {
+		return result, err
+	}
+
+	var tx *sql.Tx
+	defer func() {
+		if tx != nil {
+			tx.Rollback()
+		}
+	}()
+	pending := 0
+	commit := func() error {
+		if tx == nil {
+			return nil
+		}
+		err := tx.Commit()
+		tx, pending = nil, 0
+		if err != nil {
+			return fmt.Errorf("failed to commit imported records: %w", err)
+		}
+		return nil
+	}
+
+	for n := 1; ; n++ {
+		var record backupRecord
+		if err := dec.Decode(&record); err != nil {
+			if cerr := commit(); cerr != nil {
+				return result, cerr
+			}
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return result, ErrBackupIncomplete
+			}
+			return result, fmt.Errorf("invalid record %d: %w", n, err)
+		}
+		if record.Type == backupEnd {
+			return result, commit()
+		}
+
+		if tx == nil {
+			var err error
+			if tx, err = d.DB.BeginTx(ctx, nil); err != nil {
+				return result, fmt.Errorf("failed to begin transaction: %w", err)
+			}
+		}
+		inserted, err := importBackupRecord(ctx, sqlc.New(tx), record)
+		if err != nil {
+			return result, fmt.Errorf("failed to import record %d, %s %s: %w", n, record.Type, record.Key, err)
+		}
+		if inserted {
+			result.Imported[record.Type]++
+		} else {
+			result.Skipped[record.Type]++
+		}
+
+		if pending++; pending == backupPageSize {
+			if err := commit(); err != nil {
+				return result, err
+			}
+		}
+	}
+}
+
+// importBackupRecord inserts the record unless it exists, reporting whether it did
+func importBackupRecord(ctx context.Context, queries *sqlc.Queries, record backupRecord) (bool, error) {
+	var inserted int64
+	var err error
+	switch record.Type {
+	case BackupStore:
+		var store backupStore
+		if err := json.Unmarshal(record.Data, &store); err != nil {
+			return false, err
+		}
+		inserted, err = queries.ImportStore(ctx, sqlc.ImportStoreParams{ID: store.ID, Name: store.Name, CreatedAt: store.CreatedAt, UpdatedAt: store.UpdatedAt})
+	case BackupProduct:
+		var product backupProduct
+		if err := json.Unmarshal(record.Data, &product); err != nil {
+			return false, err
+		}
+		inserted, err = queries.ImportProduct(ctx, sqlc.ImportProductParams{
+			ID:           product.ID,
+			Name:         product.Name,
+			Price:        product.Price,
+			Category:     product.Category,
+			ThumbnailUrl: nullString(product.ThumbnailURL),
+			MobileUrl:    nullString(product.MobileURL),
+			TabletUrl:    nullString(product.TabletURL),
+			DesktopUrl:   nullString(product.DesktopURL),
+			CreatedAt:    ptrNullTime(product.CreatedAt),
+			UpdatedAt:    ptrNullTime(product.UpdatedAt),
+			DeletedAt:    ptrNullTime(product.DeletedAt),
+			StoreID:      product.StoreID,
+		})
+		// The options of a product that exists are already there
+		if err == nil && inserted > 0 {
+			err = importProductOptions(ctx, queries, product)
+		}
+	case BackupCoupon:
+		var coupon backupCoupon
+		if err := json.Unmarshal(record.Data, &coupon); err != nil {
+			return false, err
+		}
+		params := sqlc.ImportCouponParams{
+			Code:               coupon.Code,
+			DiscountPercentage: coupon.DiscountPercentage,
+			CreatedAt:          coupon.CreatedAt,
+			DeletedAt:          ptrNullTime(coupon.DeletedAt),
+			Segment:            nullString(coupon.Segment),
+			SingleUse:          coupon.SingleUse,
+		}
+		if coupon.StoreID != nil {
+			params.StoreID = uuid.NullUUID{UUID: *coupon.StoreID, Valid: true}
+		}
+		inserted, err = queries.ImportCoupon(ctx, params)
+	case BackupOrder:
+		var order backupOrder
+		if err := json.Unmarshal(record.Data, &order); err != nil {
+			return false, err
+		}
+		inserted, err = queries.ImportOrder(ctx, sqlc.ImportOrderParams{
+			ID:              order.ID,
+			Total:           order.Total,
+			Discounts:       order.Discounts,
+			Status:          nullString(order.Status),
+			CreatedAt:       ptrNullTime(order.CreatedAt),
+			UpdatedAt:       ptrNullTime(order.UpdatedAt),
+			CustomerID:      nullString(order.CustomerID),
+			PaymentIntentID: nullString(order.PaymentIntentID),
+			PaymentStatus:   nullString(order.PaymentStatus),
+			PaymentMethod:   order.PaymentMethod,
+			StoreCredit:     order.StoreCredit,
+			GiftCardCode:    order.GiftCardCode,
+			CouponCode:      order.CouponCode,
+			StoreID:         order.StoreID,
+			Sandbox:         order.Sandbox,
+		})
+		// The items of an order that exists are already there
+		if err == nil && inserted > 0 && len(order.Items) > 0 {
+			params := sqlc.ImportOrderItemsParams{OrderID: order.ID}
+			for _, item := range order.Items {
+				// Items of old orders may have no creation time; theirs is the order's
+				if item.CreatedAt.IsZero() && order.CreatedAt != nil {
+					item.CreatedAt = *order.CreatedAt
+				}
+				params.ProductIds = append(params.ProductIds, item.ProductID)
+				params.Quantities = append(params.Quantities, item.Quantity)
+				params.Prices = append(params.Prices, item.Price)
+				params.CreatedAts = append(params.CreatedAts, item.CreatedAt.UTC())
+				options := string(item.Options)
+				if options == "" {
+					options = "[]"
+				}
+				params.Options = append(params.Options, options)
+			}
+			err = queries.ImportOrderItems(ctx, params)
+		}
+	default:
+		return false, fmt.Errorf("unknown record type %q", record.Type)
+	}
+	return inserted > 0, err
+}
+
+// importProductOptions adds the product's options, each kind in the order they were exported
+func importProductOptions(ctx context.Context, queries *sqlc.Queries, product backupProduct) error {
+	positions := make(map[string]int32, 2)
+	for _, option := range product.Options {
+		err := queries.UpsertProductOption(ctx, sqlc.UpsertProductOptionParams{
+			ID:         option.ID,
+			ProductID:  product.ID,
+			Kind:       option.Kind,
+			Name:       option.Name,
+			PriceDelta: option.PriceDelta,
+			Position:   positions[option.Kind],
+		})
+		if err != nil {
+			return err
+		}
+		positions[option.Kind]++
+	}
+	return nil
+}
+
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+func ptrNullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: *t, Valid: true}
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
@@ -0,0 +1,128 @@
+package database
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// ErrUnavailable is returned without touching the pool while the circuit breaker is open
+var ErrUnavailable = errors.New("database unavailable: circuit breaker open")
+
+// BreakerStats counts circuit breaker activity since startup
+type BreakerStats struct {
+	Open      bool       `json:"open"`
+	OpenSince *time.Time `json:"openSince,omitempty"`
+	Opened    int64      `json:"opened"`    // Times the breaker tripped
+	Recovered int64      `json:"recovered"` // Times the probe found the database back and closed it
+	Rejected  int64      `json:"rejected"`  // Calls failed fast while open
+}
+
+// CircuitBreaker trips after threshold consecutive calls fail with connection errors, so
+// requests fail fast instead of each waiting on a dead connection. It stays open until
+// Close is called, which the connection probe does once the database answers again.
+type CircuitBreaker struct {
+	threshold int
+
+	mutex    sync.Mutex
+	failures int
+	openedAt time.Time // Zero while closed
+
+	opened    atomic.Int64
+	recovered atomic.Int64
+	rejected  atomic.Int64
+}
+
+// NewCircuitBreaker returns a breaker tripping after threshold consecutive connection
+// failures. A threshold of 0 or less never trips.
+func NewCircuitBreaker(threshold int) *CircuitBreaker {
+	return &CircuitBreaker{threshold: threshold}
+}
+
+// Open reports whether calls are currently being rejected
+func (b *CircuitBreaker) Open() bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return !b.openedAt.IsZero()
+}
+
+// Allow returns ErrUnavailable while the breaker is open
+func (b *CircuitBreaker) Allow() error {
+	if b.Open() {
+		b.rejected.Add(1)
+		return ErrUnavailable
+	}
+	return nil
+}
+
+// Record feeds the outcome of a call into the breaker. Only connection errors count
+// towards tripping it; any other outcome means the database answered.
+func (b *CircuitBreaker) Record(err error) {
+	if b.threshold <= 0 || errors.Is(err, ErrUnavailable) {
+		return
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if !isConnectionError(err) {
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.failures >= b.threshold && b.openedAt.IsZero() {
+		b.openedAt = time.Now()
+		b.opened.Add(1)
+	}
+}
+
+// Close lets calls through again and returns how long the breaker was open
+func (b *CircuitBreaker) Close() time.Duration {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.failures = 0
+	if b.openedAt.IsZero() {
+		return 0
+	}
+
+	downtime := time.Since(b.openedAt)
+	b.openedAt = time.Time{}
+	b.recovered.Add(1)
+	return downtime
+}
+
+func (b *CircuitBreaker) Stats() BreakerStats {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	stats := BreakerStats{
+		Open:      !b.openedAt.IsZero(),
+		Opened:    b.opened.Load(),
+		Recovered: b.recovered.Load(),
+		Rejected:  b.rejected.Load(),
+	}
+	if stats.Open {
+		openSince := b.openedAt
+		stats.OpenSince = &openSince
+	}
+	return stats
+}
+
+// isConnectionError reports whether err means the database couldn't be reached, as opposed
+// to transient errors the server itself reported such as serialization failures
+func isConnectionError(err error) bool {
+	if !IsTransient(err) {
+		return false
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code != codeSerializationFailure && pgErr.Code != codeDeadlockDetected
+	}
+	return true
+}
@@ -1,42 +1,285 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"sync/atomic"
 	"time"
 
-	"oolio/internal/config"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+	"go.uber.org/zap"
 
-	_ "github.com/lib/pq"
+	"oolio/internal/config"
 )
 
+// Database owns the pgx connection pool. DB exposes the same pool through
+// database/sql for sqlc and the repositories. Both are nil in memory mode.
+// ReadPool and ReadDB are set when a read replica is configured. Retrier is shared by
+// the repositories to retry transient errors.
 type Database struct {
-	DB *sql.DB
+	Pool *pgxpool.Pool
+	DB   *sql.DB
+
+	ReadPool       *pgxpool.Pool
+	ReadDB         *sql.DB
+	replicaHealthy atomic.Bool
+
+	Retrier *Retrier
+}
+
+// PoolStats is a JSON-friendly snapshot of pgxpool.Stat
+type PoolStats struct {
+	MaxConnections       int32 `json:"maxConnections"`
+	TotalConnections     int32 `json:"totalConnections"`
+	InUse                int32 `json:"inUse"`
+	Idle                 int32 `json:"idle"`
+	AcquireCount         int64 `json:"acquireCount"`
+	WaitCount            int64 `json:"waitCount"`
+	AcquireDurationMs    int64 `json:"acquireDurationMs"`
+	CanceledAcquireCount int64 `json:"canceledAcquireCount"`
+	MaxIdleDestroyed     int64 `json:"maxIdleDestroyed"`
+	MaxLifetimeDestroyed int64 `json:"maxLifetimeDestroyed"`
 }
 
 func NewDatabase(cfg *config.Config) (*Database, error) {
-	db, err := sql.Open("postgres", cfg.Database.ConnectionString())
-	if err != nil {
-		return nil, fmt.Errorf("failed to open database connection: %w", err)
+	if cfg.Database.Driver == config.DriverMemory {
+		return &Database{Retrier: NewRetrier(cfg.Database)}, nil
 	}
 
-	// Configure connection pool
-	db.SetMaxOpenConns(25)
-	db.SetMaxIdleConns(25)
-	db.SetConnMaxLifetime(5 * time.Minute)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	pool, err := newPool(ctx, cfg.Database.ConnectionString(), cfg.Database)
+	if err != nil {
+		return nil, err
+	}
 
 	// Test connection
-	if err := db.Ping(); err != nil {
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	return &Database{DB: db}, nil
+	d := &Database{
+		Pool:    pool,
+		DB:      stdlib.OpenDBFromPool(pool),
+		Retrier: NewRetrier(cfg.Database),
+	}
+
+	if cfg.Database.ReadDSN != "" {
+		readPool, err := newPool(ctx, cfg.Database.ReadDSN, cfg.Database)
+		if err != nil {
+			d.Close()
+			return nil, fmt.Errorf("read replica: %w", err)
+		}
+		d.ReadPool = readPool
+		d.ReadDB = stdlib.OpenDBFromPool(readPool)
+
+		// An unreachable replica shouldn't stop startup; reads stay on the primary until it recovers
+		d.replicaHealthy.Store(readPool.Ping(ctx) == nil)
+	}
+
+	if cfg.Database.AutoMigrate {
+		if err := d.MigrateUp(); err != nil {
+			d.Close()
+			return nil, err
+		}
+	}
+
+	return d, nil
+}
+
+func newPool(ctx context.Context, dsn string, cfg config.DatabaseConfig) (*pgxpool.Pool, error) {
+	poolConfig, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse database connection string: %w", err)
+	}
+
+	// Configure connection pool
+	// MinConns stays 0: connections are opened on demand and closed once idle for
+	// MaxConnIdleTime, rather than a floor of them being kept open and health-checked
+	poolConfig.MaxConns = int32(cfg.MaxOpenConns)
+	poolConfig.MaxConnLifetime = cfg.ConnMaxLifetime
+	if cfg.ConnMaxIdleTime > 0 {
+		poolConfig.MaxConnIdleTime = cfg.ConnMaxIdleTime
+	}
+
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database connection: %w", err)
+	}
+	return pool, nil
+}
+
+// Reader returns the connection for read-only queries: the replica while it is healthy,
+// otherwise the primary
+func (d *Database) Reader() *sql.DB {
+	if d.ReadDB != nil && d.replicaHealthy.Load() {
+		return d.ReadDB
+	}
+	return d.DB
+}
+
+// MonitorReplica pings the read replica every interval and switches reads back to the
+// primary while it is unreachable
+func (d *Database) MonitorReplica(ctx context.Context, interval time.Duration, logger *zap.Logger) {
+	if interval <= 0 || d.ReadPool == nil {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			pingCtx, cancel := context.WithTimeout(ctx, interval)
+			err := d.ReadPool.Ping(pingCtx)
+			cancel()
+
+			healthy := err == nil
+			if d.replicaHealthy.Swap(healthy) == healthy {
+				continue
+			}
+			if healthy {
+				logger.Info("Read replica recovered, routing reads to replica")
+			} else {
+				logger.Warn("Read replica unhealthy, routing reads to primary", zap.Error(err))
+			}
+		}
+	}
+}
+
+// MonitorConnection probes the primary every interval while the circuit breaker is open
+// and closes it once the database answers again. Connections opened before the outage are
+// dropped at that point rather than failing one by one on their next use.
+func (d *Database) MonitorConnection(ctx context.Context, interval time.Duration, logger *zap.Logger) {
+	if interval <= 0 || d.InMemory() {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	unavailable := false
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !d.Retrier.Breaker.Open() {
+				unavailable = false
+				continue
+			}
+			if !unavailable {
+				logger.Warn("Database unreachable, failing requests fast until it recovers")
+				unavailable = true
+			}
+
+			pingCtx, cancel := context.WithTimeout(ctx, interval)
+			err := d.Pool.Ping(pingCtx)
+			cancel()
+			if err != nil {
+				logger.Debug("Database still unreachable", zap.Error(err))
+				continue
+			}
+
+			d.Pool.Reset()
+			downtime := d.Retrier.Breaker.Close()
+			unavailable = false
+			logger.Info("Database connection recovered",
+				zap.Duration("downtime", downtime),
+				zap.Int64("recoveries", d.Retrier.Breaker.Stats().Recovered))
+		}
+	}
+}
+
+// InMemory reports whether the application runs without a database
+func (d *Database) InMemory() bool {
+	return d.Pool == nil
 }
 
 func (d *Database) Close() error {
-	return d.DB.Close()
+	if d.InMemory() {
+		return nil
+	}
+
+	if d.ReadPool != nil {
+		d.ReadDB.Close()
+		d.ReadPool.Close()
+	}
+
+	err := d.DB.Close()
+	d.Pool.Close()
+	return err
 }
 
 func (d *Database) HealthCheck() error {
-	return d.DB.Ping()
+	if d.InMemory() {
+		return nil
+	}
+	return d.Pool.Ping(context.Background())
+}
+
+func (d *Database) Stats() PoolStats {
+	if d.InMemory() {
+		return PoolStats{}
+	}
+
+	stats := d.Pool.Stat()
+	return PoolStats{
+		MaxConnections:       stats.MaxConns(),
+		TotalConnections:     stats.TotalConns(),
+		InUse:                stats.AcquiredConns(),
+		Idle:                 stats.IdleConns(),
+		AcquireCount:         stats.AcquireCount(),
+		WaitCount:            stats.EmptyAcquireCount(),
+		AcquireDurationMs:    stats.AcquireDuration().Milliseconds(),
+		CanceledAcquireCount: stats.CanceledAcquireCount(),
+		MaxIdleDestroyed:     stats.MaxIdleDestroyCount(),
+		MaxLifetimeDestroyed: stats.MaxLifetimeDestroyCount(),
+	}
+}
+
+// MonitorPool samples pool stats every interval and warns when the time spent acquiring
+// connections during the last interval exceeds threshold
+func (d *Database) MonitorPool(ctx context.Context, interval, threshold time.Duration, logger *zap.Logger) {
+	if interval <= 0 || d.InMemory() {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	last := d.Pool.Stat()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			current := d.Pool.Stat()
+			waited := current.AcquireDuration() - last.AcquireDuration()
+			waits := current.EmptyAcquireCount() - last.EmptyAcquireCount()
+
+			fields := []zap.Field{
+				zap.Int32("total", current.TotalConns()),
+				zap.Int32("inUse", current.AcquiredConns()),
+				zap.Int32("idle", current.IdleConns()),
+				zap.Int64("waitCount", waits),
+				zap.Duration("acquireDuration", waited),
+			}
+
+			if threshold > 0 && waited > threshold {
+				logger.Warn("Database connection pool wait time is climbing", fields...)
+			} else {
+				logger.Debug("Database connection pool stats", fields...)
+			}
+
+			last = current
+		}
+	}
 }
@@ -0,0 +1,89 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/golang-migrate/migrate/v4"
+	migratepgx "github.com/golang-migrate/migrate/v4/database/pgx/v5"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/jackc/pgx/v5/stdlib"
+
+	"oolio/migrations"
+)
+
+// newMigrator runs migrations through a throwaway database/sql handle on the shared
+// pool, so closing the migrator doesn't close the pool itself
+func (d *Database) newMigrator() (*migrate.Migrate, error) {
+	if d.InMemory() {
+		return nil, fmt.Errorf("migrations are not available with the memory driver")
+	}
+
+	source, err := iofs.New(migrations.FS, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load embedded migrations: %w", err)
+	}
+
+	driver, err := migratepgx.WithInstance(stdlib.OpenDBFromPool(d.Pool), &migratepgx.Config{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create migration driver: %w", err)
+	}
+
+	m, err := migrate.NewWithInstance("iofs", source, "pgx5", driver)
+	if err != nil {
+		driver.Close()
+		return nil, fmt.Errorf("failed to create migrator: %w", err)
+	}
+
+	return m, nil
+}
+
+// MigrateUp applies all pending embedded migrations
+func (d *Database) MigrateUp() error {
+	m, err := d.newMigrator()
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("failed to apply migrations: %w", err)
+	}
+	return nil
+}
+
+// MigrateDown rolls back the given number of migrations
+func (d *Database) MigrateDown(steps int) error {
+	if steps <= 0 {
+		return fmt.Errorf("steps must be greater than 0")
+	}
+
+	m, err := d.newMigrator()
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	if err := m.Steps(-steps); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("failed to roll back migrations: %w", err)
+	}
+	return nil
+}
+
+// MigrationVersion reports the current schema version and whether the last migration failed midway
+func (d *Database) MigrationVersion() (uint, bool, error) {
+	m, err := d.newMigrator()
+	if err != nil {
+		return 0, false, err
+	}
+	defer m.Close()
+
+	version, dirty, err := m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to read migration version: %w", err)
+	}
+	return version, dirty, nil
+}
@@ -0,0 +1,120 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Partitioning the order queue is opt-in: it pays off once completed items pile up and
+// old months can be detached or dropped instead of deleted row by row. The table is
+// range-partitioned by created_at into monthly partitions plus a default partition that
+// catches anything outside the prepared range.
+
+const orderQueueIndexes = `
+CREATE INDEX IF NOT EXISTS idx_order_queue_status ON order_queue(status);
+CREATE INDEX IF NOT EXISTS idx_order_queue_created_at ON order_queue(created_at);
+CREATE INDEX IF NOT EXISTS idx_order_queue_ready ON order_queue(next_attempt_at)
+    WHERE status = 'pending' OR (status = 'failed' AND retry_count < 3);
+`
+
+// PartitionOrderQueue converts order_queue into a partitioned table if it isn't one yet,
+// moving existing rows across, and makes sure monthly partitions exist from the current
+// month through monthsAhead months from now. Running it again only adds missing partitions,
+// so it can be scheduled e.g. monthly.
+func (d *Database) PartitionOrderQueue(ctx context.Context, monthsAhead int) (created int, err error) {
+	if d.InMemory() {
+		return 0, fmt.Errorf("partitioning is not available with the memory driver")
+	}
+
+	tx, err := d.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Serialize against concurrent runs and keep the worker off the table while it is rebuilt
+	if _, err := tx.ExecContext(ctx, `LOCK TABLE order_queue IN ACCESS EXCLUSIVE MODE`); err != nil {
+		return 0, fmt.Errorf("failed to lock order_queue: %w", err)
+	}
+
+	var partitioned bool
+	err = tx.QueryRowContext(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM pg_partitioned_table p
+			JOIN pg_class c ON c.oid = p.partrelid
+			WHERE c.relname = 'order_queue' AND c.relnamespace = current_schema()::regnamespace
+		)`).Scan(&partitioned)
+	if err != nil {
+		return 0, fmt.Errorf("failed to inspect order_queue: %w", err)
+	}
+
+	now := time.Now().UTC()
+	from := monthStart(now)
+	if !partitioned {
+		// Existing rows need partitions too, otherwise they all land in the default partition
+		var oldest sql.NullTime
+		if err := tx.QueryRowContext(ctx, `SELECT MIN(created_at) FROM order_queue`).Scan(&oldest); err != nil {
+			return 0, fmt.Errorf("failed to find oldest queue item: %w", err)
+		}
+		if oldest.Valid && oldest.Time.Before(from) {
+			from = monthStart(oldest.Time.UTC())
+		}
+
+		steps := []string{
+			`ALTER TABLE order_queue RENAME TO order_queue_unpartitioned`,
+			`CREATE TABLE order_queue (LIKE order_queue_unpartitioned INCLUDING DEFAULTS INCLUDING CONSTRAINTS)
+				PARTITION BY RANGE (created_at)`,
+			// The partition key has to be part of the primary key
+			`ALTER TABLE order_queue ADD PRIMARY KEY (id, created_at)`,
+			`CREATE TABLE order_queue_default PARTITION OF order_queue DEFAULT`,
+		}
+		for _, step := range steps {
+			if _, err := tx.ExecContext(ctx, step); err != nil {
+				return 0, fmt.Errorf("failed to partition order_queue: %w", err)
+			}
+		}
+	}
+
+	for month := from; !month.After(monthStart(now).AddDate(0, monthsAhead, 0)); month = month.AddDate(0, 1, 0) {
+		name := fmt.Sprintf("order_queue_p%s", month.Format("200601"))
+
+		var exists bool
+		if err := tx.QueryRowContext(ctx, `SELECT to_regclass($1) IS NOT NULL`, name).Scan(&exists); err != nil {
+			return created, fmt.Errorf("failed to look up partition %s: %w", name, err)
+		}
+		if exists {
+			continue
+		}
+
+		stmt := fmt.Sprintf(`CREATE TABLE %s PARTITION OF order_queue FOR VALUES FROM ('%s') TO ('%s')`,
+			name, month.Format(time.RFC3339), month.AddDate(0, 1, 0).Format(time.RFC3339))
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return created, fmt.Errorf("failed to create partition %s: %w", name, err)
+		}
+		created++
+	}
+
+	if !partitioned {
+		steps := []string{
+			`INSERT INTO order_queue SELECT * FROM order_queue_unpartitioned`,
+			`DROP TABLE order_queue_unpartitioned`,
+			orderQueueIndexes,
+		}
+		for _, step := range steps {
+			if _, err := tx.ExecContext(ctx, step); err != nil {
+				return created, fmt.Errorf("failed to move queue items: %w", err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return created, fmt.Errorf("failed to commit partitioning: %w", err)
+	}
+	return created, nil
+}
+
+func monthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
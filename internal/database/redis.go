@@ -0,0 +1,68 @@
+package database
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"github.com/go-redis/redis/v8"
+
+	"oolio/internal/config"
+)
+
+// NewRedisClient returns a standalone, sentinel-backed or cluster client depending on
+// configuration: a master name selects sentinel, multiple addresses select cluster
+func NewRedisClient(cfg *config.Config) (redis.UniversalClient, error) {
+	rc := cfg.Redis
+
+	addrs := rc.Addrs
+	if len(addrs) == 0 {
+		addrs = []string{rc.Addr}
+	}
+
+	opts := &redis.UniversalOptions{
+		Addrs:            addrs,
+		DB:               rc.DB,
+		Username:         rc.Username,
+		Password:         rc.Password,
+		MasterName:       rc.MasterName,
+		SentinelPassword: rc.SentinelPassword,
+		DialTimeout:      rc.DialTimeout,
+		ReadTimeout:      rc.ReadTimeout,
+		WriteTimeout:     rc.WriteTimeout,
+	}
+
+	if rc.TLSEnabled {
+		tlsConfig, err := redisTLSConfig(rc)
+		if err != nil {
+			return nil, err
+		}
+		opts.TLSConfig = tlsConfig
+	}
+
+	return redis.NewUniversalClient(opts), nil
+}
+
+func redisTLSConfig(rc config.RedisConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         rc.TLSServerName,
+		InsecureSkipVerify: rc.TLSInsecureSkipVerify,
+	}
+
+	if rc.TLSCAFile != "" {
+		caPEM, err := os.ReadFile(rc.TLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read redis CA file: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("failed to parse redis CA file %s", rc.TLSCAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	return tlsConfig, nil
+}
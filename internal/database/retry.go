@@ -0,0 +1,171 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"oolio/internal/chaos"
+	"oolio/internal/config"
+)
+
+// Postgres error codes the server reports after rolling the statement back
+const (
+	codeSerializationFailure = "40001"
+	codeDeadlockDetected     = "40P01"
+)
+
+// RetryStats counts retries since startup
+type RetryStats struct {
+	Retries   int64 `json:"retries"`   // Extra attempts made after a transient error
+	Recovered int64 `json:"recovered"` // Calls that succeeded after at least one retry
+	Exhausted int64 `json:"exhausted"` // Calls that still failed after the last attempt
+}
+
+// Retrier re-runs database calls that fail with transient errors, backing off
+// exponentially with jitter between attempts. Breaker fails calls fast once the
+// database has been unreachable for several calls in a row.
+type Retrier struct {
+	maxAttempts    int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+
+	Breaker *CircuitBreaker
+
+	// Faults fails calls on purpose for resilience testing; nil fails none
+	Faults *chaos.Fault
+
+	retries   atomic.Int64
+	recovered atomic.Int64
+	exhausted atomic.Int64
+}
+
+func NewRetrier(cfg config.DatabaseConfig) *Retrier {
+	return &Retrier{
+		maxAttempts:    max(cfg.RetryMaxAttempts, 1),
+		initialBackoff: cfg.RetryInitialBackoff,
+		maxBackoff:     cfg.RetryMaxBackoff,
+		Breaker:        NewCircuitBreaker(cfg.BreakerFailureThreshold),
+	}
+}
+
+// Read retries fn on any transient error. Use it for calls without side effects.
+func (r *Retrier) Read(ctx context.Context, fn func(ctx context.Context) error) error {
+	return r.do(ctx, fn, IsTransient)
+}
+
+// Write retries fn only when the failed attempt is known not to have been applied:
+// the server rolled it back, or the connection failed before anything was sent
+func (r *Retrier) Write(ctx context.Context, fn func(ctx context.Context) error) error {
+	return r.do(ctx, fn, isRolledBack)
+}
+
+func (r *Retrier) Stats() RetryStats {
+	return RetryStats{
+		Retries:   r.retries.Load(),
+		Recovered: r.recovered.Load(),
+		Exhausted: r.exhausted.Load(),
+	}
+}
+
+func (r *Retrier) do(ctx context.Context, fn func(ctx context.Context) error, retryable func(error) bool) error {
+	backoff := r.initialBackoff
+	for attempt := 1; ; attempt++ {
+		if err := r.Breaker.Allow(); err != nil {
+			return err
+		}
+
+		err := r.call(ctx, fn)
+		r.Breaker.Record(err)
+		if err == nil {
+			if attempt > 1 {
+				r.recovered.Add(1)
+			}
+			return nil
+		}
+		if !retryable(err) {
+			return err
+		}
+		if attempt >= r.maxAttempts {
+			r.exhausted.Add(1)
+			return err
+		}
+
+		// Full jitter keeps concurrent callers from retrying in lockstep
+		wait := time.Duration(rand.Int64N(int64(backoff) + 1))
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+
+		r.retries.Add(1)
+		backoff = min(backoff*2, r.maxBackoff)
+	}
+}
+
+// call runs fn unless Faults fails it first
+func (r *Retrier) call(ctx context.Context, fn func(ctx context.Context) error) error {
+	if r.Faults.Inject(ctx) {
+		return injectedError{}
+	}
+	return fn(ctx)
+}
+
+// injectedError is the failure Faults injects: a connection that dropped before the call
+// was sent, so it is retried, reads and writes alike, and counts towards tripping the breaker
+type injectedError struct{}
+
+func (injectedError) Error() string {
+	return "connection failed: " + chaos.ErrInjected.Error()
+}
+
+func (injectedError) SafeToRetry() bool {
+	return true
+}
+
+func (injectedError) Unwrap() error {
+	return chaos.ErrInjected
+}
+
+// IsTransient reports whether err is likely to go away on retry: serialization
+// failures, deadlocks and connection problems
+func IsTransient(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if isRolledBack(err) {
+		return true
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		// Class 08 is connection exceptions; 57P01-57P03 cover server shutdown and startup
+		return pgErr.Code[:2] == "08" || pgErr.Code == "57P01" || pgErr.Code == "57P02" || pgErr.Code == "57P03"
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED)
+}
+
+func isRolledBack(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == codeSerializationFailure || pgErr.Code == codeDeadlockDetected
+	}
+	return pgconn.SafeToRetry(err)
+}
@@ -0,0 +1,86 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"oolio/internal/app/models"
+	"oolio/internal/database/sqlc"
+	"oolio/seeds"
+)
+
+// SeedResult counts the rows inserted by Seed; existing rows are left untouched
+type SeedResult struct {
+	Products int64
+	Coupons  int64
+}
+
+// Seed loads the sample menu and demo coupons embedded in the seeds package. Products are
+// matched by name and coupons by code, so running it again only adds what is missing.
+func (d *Database) Seed(ctx context.Context) (SeedResult, error) {
+	var result SeedResult
+	if d.InMemory() {
+		return result, fmt.Errorf("seeding is not available with the memory driver")
+	}
+
+	var products []models.Product
+	if err := readSeed("products.json", &products); err != nil {
+		return result, err
+	}
+	var coupons []models.Coupon
+	if err := readSeed("coupons.json", &coupons); err != nil {
+		return result, err
+	}
+
+	tx, err := d.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return result, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	qtx := sqlc.New(tx)
+
+	for _, product := range products {
+		inserted, err := qtx.InsertProductIfMissing(ctx, sqlc.InsertProductIfMissingParams{
+			Name:         product.Name,
+			Price:        product.Price,
+			Category:     product.Category,
+			ThumbnailUrl: product.Image.Thumbnail,
+			MobileUrl:    product.Image.Mobile,
+			TabletUrl:    product.Image.Tablet,
+			DesktopUrl:   product.Image.Desktop,
+		})
+		if err != nil {
+			return result, fmt.Errorf("failed to seed product %q: %w", product.Name, err)
+		}
+		result.Products += inserted
+	}
+
+	for _, coupon := range coupons {
+		inserted, err := qtx.InsertCouponIfMissing(ctx, sqlc.InsertCouponIfMissingParams{
+			Code:               coupon.Code,
+			DiscountPercentage: coupon.DiscountPercentage,
+		})
+		if err != nil {
+			return result, fmt.Errorf("failed to seed coupon %q: %w", coupon.Code, err)
+		}
+		result.Coupons += inserted
+	}
+
+	if err := tx.Commit(); err != nil {
+		return result, fmt.Errorf("failed to commit seed data: %w", err)
+	}
+	return result, nil
+}
+
+func readSeed(name string, v any) error {
+	data, err := seeds.FS.ReadFile(name)
+	if err != nil {
+		return fmt.Errorf("failed to read seed %s: %w", name, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to parse seed %s: %w", name, err)
+	}
+	return nil
+}
@@ -6,17 +6,179 @@ package sqlc
 
 import (
 	"database/sql"
+	"encoding/json"
+	"time"
 
 	"github.com/google/uuid"
+	"oolio/internal/app/models"
 )
 
+type Cart struct {
+	ID         uuid.UUID
+	CustomerID sql.NullString
+	ExpiresAt  sql.NullTime
+	UpdatedAt  time.Time
+}
+
+type CartItem struct {
+	CartID      uuid.UUID
+	ProductID   uuid.UUID
+	Quantity    int32
+	CreatedAt   time.Time
+	VariantID   uuid.NullUUID
+	ModifierIds json.RawMessage
+}
+
+type Coupon struct {
+	Code               string
+	DiscountPercentage float64
+	CreatedAt          time.Time
+	DeletedAt          sql.NullTime
+	Segment            sql.NullString
+	SingleUse          bool
+	StoreID            uuid.NullUUID
+}
+
+type CouponRedemption struct {
+	Code       string
+	Redeemer   string
+	RedeemedAt time.Time
+}
+
+type CustomerAddress struct {
+	ID           uuid.UUID
+	CustomerID   string
+	Label        string
+	Line1        string
+	Line2        string
+	City         string
+	Region       string
+	PostalCode   string
+	Country      string
+	Instructions string
+	IsDefault    bool
+	CreatedAt    time.Time
+}
+
+type CustomerFavorite struct {
+	CustomerID string
+	ProductID  uuid.UUID
+	CreatedAt  time.Time
+}
+
+type CustomerNotification struct {
+	ID         uuid.UUID
+	CustomerID string
+	Kind       string
+	Title      string
+	Body       string
+	OrderID    uuid.NullUUID
+	CreatedAt  time.Time
+	ReadAt     sql.NullTime
+}
+
+type CustomerNotificationPreference struct {
+	CustomerID      string
+	Email           string
+	Phone           string
+	EmailReceipt    bool
+	SmsReadyAlert   bool
+	Marketing       bool
+	UpdatedAt       time.Time
+	EmailReadyAlert bool
+}
+
+type CustomerSegment struct {
+	CustomerID   string
+	Segment      string
+	OrderCount   int32
+	TotalSpent   models.Money
+	FirstOrderAt sql.NullTime
+	LastOrderAt  sql.NullTime
+	ComputedAt   time.Time
+}
+
+type Delivery struct {
+	ID          uuid.UUID
+	OrderID     uuid.UUID
+	Provider    string
+	ExternalID  string
+	Status      string
+	TrackingUrl string
+	CourierName string
+	EstimatedAt sql.NullTime
+	Error       string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+type GiftCard struct {
+	Code           string
+	InitialBalance models.Money
+	Balance        models.Money
+	ExpiresAt      sql.NullTime
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+type Invoice struct {
+	OrderID  uuid.UUID
+	Series   string
+	Number   int64
+	IssuedAt time.Time
+	Document json.RawMessage
+	StoreID  uuid.UUID
+}
+
+type InvoiceSequence struct {
+	Series     string
+	LastNumber int64
+	StoreID    uuid.UUID
+}
+
+type NotificationOutbox struct {
+	ID            uuid.UUID
+	Channel       string
+	Kind          string
+	Recipient     string
+	Subject       string
+	Body          string
+	OrderID       uuid.NullUUID
+	Status        string
+	Attempts      int32
+	NextAttemptAt time.Time
+	LastError     sql.NullString
+	CreatedAt     time.Time
+	SentAt        sql.NullTime
+}
+
 type Order struct {
-	ID        uuid.UUID
-	Total     string
-	Discounts sql.NullString
-	Status    sql.NullString
-	CreatedAt sql.NullTime
-	UpdatedAt sql.NullTime
+	ID              uuid.UUID
+	Total           models.Money
+	Discounts       models.Money
+	Status          sql.NullString
+	CreatedAt       sql.NullTime
+	UpdatedAt       sql.NullTime
+	Version         int32
+	CustomerID      sql.NullString
+	PaymentIntentID sql.NullString
+	PaymentStatus   sql.NullString
+	PaymentMethod   string
+	StoreCredit     models.Money
+	GiftCardCode    string
+	CouponCode      string
+	StoreID         uuid.UUID
+	Sandbox         bool
+}
+
+type OrderDiscrepancy struct {
+	ID         uuid.UUID
+	OrderID    uuid.UUID
+	Kind       string
+	Recorded   models.Money
+	Expected   models.Money
+	DetectedAt time.Time
+	CheckedAt  time.Time
 }
 
 type OrderItem struct {
@@ -24,14 +186,81 @@ type OrderItem struct {
 	OrderID     uuid.NullUUID
 	ProductID   uuid.NullUUID
 	Quantity    int32
-	PriceAtTime string
+	PriceAtTime models.Money
 	CreatedAt   sql.NullTime
+	Options     json.RawMessage
+}
+
+type OrderRefund struct {
+	ID               uuid.UUID
+	OrderID          uuid.UUID
+	PaymentIntentID  string
+	Amount           models.Money
+	Status           string
+	ProviderRefundID string
+	Error            string
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+}
+
+type OrderSummary struct {
+	ID              uuid.UUID
+	Total           models.Money
+	Discounts       models.Money
+	Status          sql.NullString
+	CreatedAt       sql.NullTime
+	UpdatedAt       sql.NullTime
+	Version         int32
+	Items           json.RawMessage
+	Products        json.RawMessage
+	CustomerID      sql.NullString
+	PaymentIntentID sql.NullString
+	PaymentStatus   sql.NullString
+	PaymentMethod   string
+	StoreCredit     models.Money
+	GiftCardCode    string
+	CouponCode      string
+	StoreID         uuid.UUID
+	Sandbox         bool
+}
+
+type OutboxEvent struct {
+	ID            uuid.UUID
+	AggregateType string
+	AggregateID   uuid.UUID
+	EventType     string
+	Payload       json.RawMessage
+	CreatedAt     time.Time
+	PublishedAt   sql.NullTime
+	Attempts      int32
+	LastError     sql.NullString
+	NextAttemptAt time.Time
+	DeadAt        sql.NullTime
+}
+
+type PaymentEvent struct {
+	ID         string
+	EventType  string
+	ReceivedAt time.Time
+}
+
+type PricingTier struct {
+	Name               string
+	DiscountPercentage float64
+	UpdatedAt          time.Time
+}
+
+type PricingTierAssignment struct {
+	SubjectType string
+	Subject     string
+	Tier        string
+	CreatedAt   time.Time
 }
 
 type Product struct {
 	ID           uuid.UUID
 	Name         string
-	Price        string
+	Price        models.Money
 	Category     string
 	ThumbnailUrl sql.NullString
 	MobileUrl    sql.NullString
@@ -39,4 +268,103 @@ type Product struct {
 	DesktopUrl   sql.NullString
 	CreatedAt    sql.NullTime
 	UpdatedAt    sql.NullTime
+	Version      int32
+	DeletedAt    sql.NullTime
+	StoreID      uuid.UUID
+}
+
+type ProductChange struct {
+	Seq       int64
+	ProductID uuid.UUID
+	Kind      string
+	ChangedAt time.Time
+}
+
+type ProductOption struct {
+	ID         uuid.UUID
+	ProductID  uuid.UUID
+	Kind       string
+	Name       string
+	PriceDelta models.Money
+	Position   int32
+	CreatedAt  time.Time
+}
+
+type ProductStock struct {
+	ProductID uuid.UUID
+	Quantity  int32
+	UpdatedAt time.Time
+}
+
+type PushDevice struct {
+	Token      string
+	CustomerID string
+	Platform   string
+	CreatedAt  time.Time
+}
+
+type StockReservation struct {
+	ReservationID uuid.UUID
+	ProductID     uuid.UUID
+	Quantity      int32
+	ExpiresAt     time.Time
+	CreatedAt     time.Time
+}
+
+type Store struct {
+	ID        uuid.UUID
+	Name      string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type StoreApiKey struct {
+	ID        uuid.UUID
+	StoreID   uuid.UUID
+	Name      string
+	KeyHash   string
+	KeyPrefix string
+	CreatedAt time.Time
+	RevokedAt sql.NullTime
+	Test      bool
+}
+
+type StoreOpeningHour struct {
+	StoreID   uuid.UUID
+	Hours     json.RawMessage
+	UpdatedAt time.Time
+}
+
+type TableTab struct {
+	ID            uuid.UUID
+	StoreID       uuid.UUID
+	TableNumber   string
+	Status        string
+	PaymentMethod string
+	OpenedAt      time.Time
+	ClosedAt      sql.NullTime
+}
+
+type WebhookDelivery struct {
+	ID            uuid.UUID
+	Endpoint      string
+	EventID       string
+	EventType     string
+	Body          string
+	Status        string
+	Attempts      int32
+	NextAttemptAt time.Time
+	LastError     sql.NullString
+	CreatedAt     time.Time
+	DeliveredAt   sql.NullTime
+}
+
+type WebhookDeliveryAttempt struct {
+	ID          int64
+	DeliveryID  uuid.UUID
+	Url         string
+	StatusCode  int32
+	Error       string
+	DurationMs  int32
+	AttemptedAt time.Time
 }
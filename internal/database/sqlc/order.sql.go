@@ -8,24 +8,61 @@ package sqlc
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"time"
 
 	"github.com/google/uuid"
+	"oolio/internal/app/models"
 )
 
+const countOrders = `-- name: CountOrders :one
+SELECT COUNT(*) FROM orders
+WHERE $1::uuid IS NULL OR store_id = $1::uuid
+`
+
+func (q *Queries) CountOrders(ctx context.Context, storeID uuid.NullUUID) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countOrders, storeID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createOrder = `-- name: CreateOrder :one
-INSERT INTO orders (total, discounts, status)
-VALUES ($1, $2, $3)
-RETURNING id, total, discounts, status, created_at, updated_at
+INSERT INTO orders (total, discounts, status, customer_id, payment_intent_id, payment_status, payment_method, store_credit, gift_card_code, coupon_code, store_id, sandbox)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+RETURNING id, total, discounts, status, created_at, updated_at, version, customer_id, payment_intent_id, payment_status, payment_method, store_credit, gift_card_code, coupon_code, store_id, sandbox
 `
 
 type CreateOrderParams struct {
-	Total     string
-	Discounts sql.NullString
-	Status    sql.NullString
+	Total           models.Money
+	Discounts       models.Money
+	Status          sql.NullString
+	CustomerID      sql.NullString
+	PaymentIntentID sql.NullString
+	PaymentStatus   sql.NullString
+	PaymentMethod   string
+	StoreCredit     models.Money
+	GiftCardCode    string
+	CouponCode      string
+	StoreID         uuid.UUID
+	Sandbox         bool
 }
 
 func (q *Queries) CreateOrder(ctx context.Context, arg CreateOrderParams) (Order, error) {
-	row := q.db.QueryRowContext(ctx, createOrder, arg.Total, arg.Discounts, arg.Status)
+	row := q.db.QueryRowContext(ctx, createOrder,
+		arg.Total,
+		arg.Discounts,
+		arg.Status,
+		arg.CustomerID,
+		arg.PaymentIntentID,
+		arg.PaymentStatus,
+		arg.PaymentMethod,
+		arg.StoreCredit,
+		arg.GiftCardCode,
+		arg.CouponCode,
+		arg.StoreID,
+		arg.Sandbox,
+	)
 	var i Order
 	err := row.Scan(
 		&i.ID,
@@ -34,6 +71,16 @@ func (q *Queries) CreateOrder(ctx context.Context, arg CreateOrderParams) (Order
 		&i.Status,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Version,
+		&i.CustomerID,
+		&i.PaymentIntentID,
+		&i.PaymentStatus,
+		&i.PaymentMethod,
+		&i.StoreCredit,
+		&i.GiftCardCode,
+		&i.CouponCode,
+		&i.StoreID,
+		&i.Sandbox,
 	)
 	return i, err
 }
@@ -41,14 +88,14 @@ func (q *Queries) CreateOrder(ctx context.Context, arg CreateOrderParams) (Order
 const createOrderItems = `-- name: CreateOrderItems :many
 INSERT INTO order_items (order_id, product_id, quantity, price_at_time)
 VALUES ($1, $2, $3, $4)
-RETURNING id, order_id, product_id, quantity, price_at_time, created_at
+RETURNING id, order_id, product_id, quantity, price_at_time, created_at, options
 `
 
 type CreateOrderItemsParams struct {
 	OrderID     uuid.NullUUID
 	ProductID   uuid.NullUUID
 	Quantity    int32
-	PriceAtTime string
+	PriceAtTime models.Money
 }
 
 func (q *Queries) CreateOrderItems(ctx context.Context, arg CreateOrderItemsParams) ([]OrderItem, error) {
@@ -72,6 +119,92 @@ func (q *Queries) CreateOrderItems(ctx context.Context, arg CreateOrderItemsPara
 			&i.Quantity,
 			&i.PriceAtTime,
 			&i.CreatedAt,
+			&i.Options,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const createOrderItemsBatch = `-- name: CreateOrderItemsBatch :exec
+INSERT INTO order_items (order_id, product_id, quantity, price_at_time, options)
+SELECT $1::uuid, u.product_id, u.quantity, u.price_at_time, u.options::jsonb
+FROM unnest($2::uuid[], $3::int[], $4::numeric[], $5::text[]) AS u(product_id, quantity, price_at_time, options)
+`
+
+type CreateOrderItemsBatchParams struct {
+	OrderID    uuid.UUID
+	ProductIds []uuid.UUID
+	Quantities []int32
+	Prices     []models.Money
+	Options    []string
+}
+
+// Options are each item's JSON array of options
+func (q *Queries) CreateOrderItemsBatch(ctx context.Context, arg CreateOrderItemsBatchParams) error {
+	_, err := q.db.ExecContext(ctx, createOrderItemsBatch,
+		arg.OrderID,
+		arg.ProductIds,
+		arg.Quantities,
+		arg.Prices,
+		arg.Options,
+	)
+	return err
+}
+
+const deleteOrder = `-- name: DeleteOrder :execrows
+DELETE FROM orders WHERE id = $1
+`
+
+// Order items go with the order through ON DELETE CASCADE
+func (q *Queries) DeleteOrder(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteOrder, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getCouponUsage = `-- name: GetCouponUsage :many
+SELECT coupon_code, COUNT(*) AS orders, COALESCE(SUM(discounts), 0)::numeric AS discounts
+FROM orders
+WHERE created_at >= $1::timestamp AND created_at < $2::timestamp AND status NOT IN ('cancelled', 'failed') AND NOT sandbox AND coupon_code <> ''
+GROUP BY coupon_code
+ORDER BY orders DESC, coupon_code
+`
+
+type GetCouponUsageParams struct {
+	Since time.Time
+	Until time.Time
+}
+
+type GetCouponUsageRow struct {
+	CouponCode string
+	Orders     int64
+	Discounts  models.Money
+}
+
+func (q *Queries) GetCouponUsage(ctx context.Context, arg GetCouponUsageParams) ([]GetCouponUsageRow, error) {
+	rows, err := q.db.QueryContext(ctx, getCouponUsage, arg.Since, arg.Until)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetCouponUsageRow
+	for rows.Next() {
+		var i GetCouponUsageRow
+		if err := rows.Scan(
+			&i.CouponCode,
+			&i.Orders,
+			&i.Discounts,
 		); err != nil {
 			return nil, err
 		}
@@ -87,7 +220,7 @@ func (q *Queries) CreateOrderItems(ctx context.Context, arg CreateOrderItemsPara
 }
 
 const getOrderByID = `-- name: GetOrderByID :one
-SELECT id, total, discounts, status, created_at, updated_at
+SELECT id, total, discounts, status, created_at, updated_at, version, customer_id, payment_intent_id, payment_status, payment_method, store_credit, gift_card_code, coupon_code, store_id, sandbox
 FROM orders
 WHERE id = $1
 `
@@ -102,12 +235,22 @@ func (q *Queries) GetOrderByID(ctx context.Context, id uuid.UUID) (Order, error)
 		&i.Status,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Version,
+		&i.CustomerID,
+		&i.PaymentIntentID,
+		&i.PaymentStatus,
+		&i.PaymentMethod,
+		&i.StoreCredit,
+		&i.GiftCardCode,
+		&i.CouponCode,
+		&i.StoreID,
+		&i.Sandbox,
 	)
 	return i, err
 }
 
 const getOrderItemsByOrderID = `-- name: GetOrderItemsByOrderID :many
-SELECT oi.id, oi.order_id, oi.product_id, oi.quantity, oi.price_at_time, oi.created_at,
+SELECT oi.id, oi.order_id, oi.product_id, oi.quantity, oi.price_at_time, oi.created_at, oi.options,
        p.name, p.category, p.thumbnail_url, p.mobile_url, p.tablet_url, p.desktop_url
 FROM order_items oi
 JOIN products p ON oi.product_id = p.id
@@ -119,8 +262,9 @@ type GetOrderItemsByOrderIDRow struct {
 	OrderID      uuid.NullUUID
 	ProductID    uuid.NullUUID
 	Quantity     int32
-	PriceAtTime  string
+	PriceAtTime  models.Money
 	CreatedAt    sql.NullTime
+	Options      json.RawMessage
 	Name         string
 	Category     string
 	ThumbnailUrl sql.NullString
@@ -145,6 +289,7 @@ func (q *Queries) GetOrderItemsByOrderID(ctx context.Context, orderID uuid.NullU
 			&i.Quantity,
 			&i.PriceAtTime,
 			&i.CreatedAt,
+			&i.Options,
 			&i.Name,
 			&i.Category,
 			&i.ThumbnailUrl,
@@ -165,20 +310,532 @@ func (q *Queries) GetOrderItemsByOrderID(ctx context.Context, orderID uuid.NullU
 	return items, nil
 }
 
+const getOrderSummaries = `-- name: GetOrderSummaries :many
+SELECT id, total, discounts, status, created_at, updated_at, version, items, products, customer_id, payment_intent_id, payment_status, payment_method, store_credit, gift_card_code, coupon_code, store_id, sandbox
+FROM order_summaries
+ORDER BY created_at DESC
+`
+
+func (q *Queries) GetOrderSummaries(ctx context.Context) ([]OrderSummary, error) {
+	rows, err := q.db.QueryContext(ctx, getOrderSummaries)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []OrderSummary
+	for rows.Next() {
+		var i OrderSummary
+		if err := rows.Scan(
+			&i.ID,
+			&i.Total,
+			&i.Discounts,
+			&i.Status,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Version,
+			&i.Items,
+			&i.Products,
+			&i.CustomerID,
+			&i.PaymentIntentID,
+			&i.PaymentStatus,
+			&i.PaymentMethod,
+			&i.StoreCredit,
+			&i.GiftCardCode,
+			&i.CouponCode,
+			&i.StoreID,
+			&i.Sandbox,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getOrderSummariesPage = `-- name: GetOrderSummariesPage :many
+SELECT id, total, discounts, status, created_at, updated_at, version, items, products, customer_id, payment_intent_id, payment_status, payment_method, store_credit, gift_card_code, coupon_code, store_id, sandbox
+FROM order_summaries
+WHERE $3::uuid IS NULL OR store_id = $3::uuid
+ORDER BY created_at DESC
+LIMIT $1 OFFSET $2
+`
+
+type GetOrderSummariesPageParams struct {
+	Limit   int32
+	Offset  int32
+	StoreID uuid.NullUUID
+}
+
+func (q *Queries) GetOrderSummariesPage(ctx context.Context, arg GetOrderSummariesPageParams) ([]OrderSummary, error) {
+	rows, err := q.db.QueryContext(ctx, getOrderSummariesPage, arg.Limit, arg.Offset, arg.StoreID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []OrderSummary
+	for rows.Next() {
+		var i OrderSummary
+		if err := rows.Scan(
+			&i.ID,
+			&i.Total,
+			&i.Discounts,
+			&i.Status,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Version,
+			&i.Items,
+			&i.Products,
+			&i.CustomerID,
+			&i.PaymentIntentID,
+			&i.PaymentStatus,
+			&i.PaymentMethod,
+			&i.StoreCredit,
+			&i.GiftCardCode,
+			&i.CouponCode,
+			&i.StoreID,
+			&i.Sandbox,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getOrderSummariesUpdatedSince = `-- name: GetOrderSummariesUpdatedSince :many
+SELECT id, total, discounts, status, created_at, updated_at, version, items, products, customer_id, payment_intent_id, payment_status, payment_method, store_credit, gift_card_code, coupon_code, store_id, sandbox
+FROM order_summaries
+WHERE updated_at >= $1
+ORDER BY updated_at, id
+`
+
+func (q *Queries) GetOrderSummariesUpdatedSince(ctx context.Context, updatedAt sql.NullTime) ([]OrderSummary, error) {
+	rows, err := q.db.QueryContext(ctx, getOrderSummariesUpdatedSince, updatedAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []OrderSummary
+	for rows.Next() {
+		var i OrderSummary
+		if err := rows.Scan(
+			&i.ID,
+			&i.Total,
+			&i.Discounts,
+			&i.Status,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Version,
+			&i.Items,
+			&i.Products,
+			&i.CustomerID,
+			&i.PaymentIntentID,
+			&i.PaymentStatus,
+			&i.PaymentMethod,
+			&i.StoreCredit,
+			&i.GiftCardCode,
+			&i.CouponCode,
+			&i.StoreID,
+			&i.Sandbox,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getOrderSummaryByID = `-- name: GetOrderSummaryByID :one
+SELECT id, total, discounts, status, created_at, updated_at, version, items, products, customer_id, payment_intent_id, payment_status, payment_method, store_credit, gift_card_code, coupon_code, store_id, sandbox
+FROM order_summaries
+WHERE id = $1
+`
+
+func (q *Queries) GetOrderSummaryByID(ctx context.Context, id uuid.UUID) (OrderSummary, error) {
+	row := q.db.QueryRowContext(ctx, getOrderSummaryByID, id)
+	var i OrderSummary
+	err := row.Scan(
+		&i.ID,
+		&i.Total,
+		&i.Discounts,
+		&i.Status,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Version,
+		&i.Items,
+		&i.Products,
+		&i.CustomerID,
+		&i.PaymentIntentID,
+		&i.PaymentStatus,
+		&i.PaymentMethod,
+		&i.StoreCredit,
+		&i.GiftCardCode,
+		&i.CouponCode,
+		&i.StoreID,
+		&i.Sandbox,
+	)
+	return i, err
+}
+
+const getOrderSummaryByPaymentIntent = `-- name: GetOrderSummaryByPaymentIntent :one
+SELECT id, total, discounts, status, created_at, updated_at, version, items, products, customer_id, payment_intent_id, payment_status, payment_method, store_credit, gift_card_code, coupon_code, store_id, sandbox
+FROM order_summaries
+WHERE payment_intent_id = $1
+`
+
+func (q *Queries) GetOrderSummaryByPaymentIntent(ctx context.Context, paymentIntentID sql.NullString) (OrderSummary, error) {
+	row := q.db.QueryRowContext(ctx, getOrderSummaryByPaymentIntent, paymentIntentID)
+	var i OrderSummary
+	err := row.Scan(
+		&i.ID,
+		&i.Total,
+		&i.Discounts,
+		&i.Status,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Version,
+		&i.Items,
+		&i.Products,
+		&i.CustomerID,
+		&i.PaymentIntentID,
+		&i.PaymentStatus,
+		&i.PaymentMethod,
+		&i.StoreCredit,
+		&i.GiftCardCode,
+		&i.CouponCode,
+		&i.StoreID,
+		&i.Sandbox,
+	)
+	return i, err
+}
+
+const getPaymentMethodTotals = `-- name: GetPaymentMethodTotals :many
+SELECT payment_method, COUNT(*) AS orders, COALESCE(SUM(total - COALESCE(discounts, 0) - store_credit), 0)::numeric AS paid
+FROM orders
+WHERE created_at >= $1::timestamp AND created_at < $2::timestamp AND status NOT IN ('cancelled', 'failed') AND NOT sandbox
+GROUP BY payment_method
+ORDER BY payment_method
+`
+
+type GetPaymentMethodTotalsParams struct {
+	Since time.Time
+	Until time.Time
+}
+
+type GetPaymentMethodTotalsRow struct {
+	PaymentMethod string
+	Orders        int64
+	Paid          models.Money
+}
+
+// Paid is what customers paid, after discounts and store credit, for orders placed from @since until before @until
+func (q *Queries) GetPaymentMethodTotals(ctx context.Context, arg GetPaymentMethodTotalsParams) ([]GetPaymentMethodTotalsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPaymentMethodTotals, arg.Since, arg.Until)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPaymentMethodTotalsRow
+	for rows.Next() {
+		var i GetPaymentMethodTotalsRow
+		if err := rows.Scan(
+			&i.PaymentMethod,
+			&i.Orders,
+			&i.Paid,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSalesByCategory = `-- name: GetSalesByCategory :many
+SELECT p.category, COUNT(DISTINCT o.id) AS orders, SUM(oi.quantity)::bigint AS quantity,
+       SUM(oi.price_at_time * oi.quantity * (1 - COALESCE(o.discounts, 0) / NULLIF(o.total, 0)))::numeric AS revenue,
+       COALESCE(SUM(oi.price_at_time * oi.quantity * COALESCE(o.discounts, 0) / NULLIF(o.total, 0)), 0)::numeric AS discounts
+FROM order_items oi
+JOIN orders o ON o.id = oi.order_id
+JOIN products p ON p.id = oi.product_id
+WHERE o.created_at >= $1::timestamp AND o.created_at < $2::timestamp AND o.status NOT IN ('cancelled', 'failed') AND NOT o.sandbox
+  AND ($3::uuid IS NULL OR o.store_id = $3::uuid)
+GROUP BY p.category
+ORDER BY revenue DESC, p.category
+`
+
+type GetSalesByCategoryParams struct {
+	Since   time.Time
+	Until   time.Time
+	StoreID uuid.NullUUID
+}
+
+type GetSalesByCategoryRow struct {
+	Category  string
+	Orders    int64
+	Quantity  int64
+	Revenue   models.Money
+	Discounts models.Money
+}
+
+func (q *Queries) GetSalesByCategory(ctx context.Context, arg GetSalesByCategoryParams) ([]GetSalesByCategoryRow, error) {
+	rows, err := q.db.QueryContext(ctx, getSalesByCategory, arg.Since, arg.Until, arg.StoreID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetSalesByCategoryRow
+	for rows.Next() {
+		var i GetSalesByCategoryRow
+		if err := rows.Scan(
+			&i.Category,
+			&i.Orders,
+			&i.Quantity,
+			&i.Revenue,
+			&i.Discounts,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSalesByDay = `-- name: GetSalesByDay :many
+SELECT to_char((created_at AT TIME ZONE 'UTC') AT TIME ZONE $1::text, 'YYYY-MM-DD') AS day,
+       COUNT(*) AS orders,
+       COALESCE(SUM(total - COALESCE(discounts, 0)), 0)::numeric AS revenue,
+       COALESCE(SUM(discounts), 0)::numeric AS discounts
+FROM orders
+WHERE created_at >= $2::timestamp AND created_at < $3::timestamp AND status NOT IN ('cancelled', 'failed') AND NOT sandbox
+  AND ($4::uuid IS NULL OR store_id = $4::uuid)
+GROUP BY day
+ORDER BY day
+`
+
+type GetSalesByDayParams struct {
+	TimeZone string
+	Since    time.Time
+	Until    time.Time
+	StoreID  uuid.NullUUID
+}
+
+type GetSalesByDayRow struct {
+	Day       string
+	Orders    int64
+	Revenue   models.Money
+	Discounts models.Money
+}
+
+// Days start at midnight in @time_zone; created_at is UTC
+func (q *Queries) GetSalesByDay(ctx context.Context, arg GetSalesByDayParams) ([]GetSalesByDayRow, error) {
+	rows, err := q.db.QueryContext(ctx, getSalesByDay,
+		arg.TimeZone,
+		arg.Since,
+		arg.Until,
+		arg.StoreID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetSalesByDayRow
+	for rows.Next() {
+		var i GetSalesByDayRow
+		if err := rows.Scan(
+			&i.Day,
+			&i.Orders,
+			&i.Revenue,
+			&i.Discounts,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSalesByProduct = `-- name: GetSalesByProduct :many
+SELECT p.id, p.name, COUNT(DISTINCT o.id) AS orders, SUM(oi.quantity)::bigint AS quantity,
+       SUM(oi.price_at_time * oi.quantity * (1 - COALESCE(o.discounts, 0) / NULLIF(o.total, 0)))::numeric AS revenue,
+       COALESCE(SUM(oi.price_at_time * oi.quantity * COALESCE(o.discounts, 0) / NULLIF(o.total, 0)), 0)::numeric AS discounts
+FROM order_items oi
+JOIN orders o ON o.id = oi.order_id
+JOIN products p ON p.id = oi.product_id
+WHERE o.created_at >= $1::timestamp AND o.created_at < $2::timestamp AND o.status NOT IN ('cancelled', 'failed') AND NOT o.sandbox
+  AND ($3::uuid IS NULL OR o.store_id = $3::uuid)
+GROUP BY p.id, p.name
+ORDER BY revenue DESC, p.name
+`
+
+type GetSalesByProductParams struct {
+	Since   time.Time
+	Until   time.Time
+	StoreID uuid.NullUUID
+}
+
+type GetSalesByProductRow struct {
+	ID        uuid.UUID
+	Name      string
+	Orders    int64
+	Quantity  int64
+	Revenue   models.Money
+	Discounts models.Money
+}
+
+// Each item takes its share of the order's discounts in proportion to what it added to the order
+func (q *Queries) GetSalesByProduct(ctx context.Context, arg GetSalesByProductParams) ([]GetSalesByProductRow, error) {
+	rows, err := q.db.QueryContext(ctx, getSalesByProduct, arg.Since, arg.Until, arg.StoreID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetSalesByProductRow
+	for rows.Next() {
+		var i GetSalesByProductRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Orders,
+			&i.Quantity,
+			&i.Revenue,
+			&i.Discounts,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTopProducts = `-- name: GetTopProducts :many
+SELECT p.id, p.name, SUM(oi.quantity)::bigint AS quantity, SUM(oi.price_at_time * oi.quantity)::numeric AS revenue
+FROM order_items oi
+JOIN orders o ON o.id = oi.order_id
+JOIN products p ON p.id = oi.product_id
+WHERE o.created_at >= $1::timestamp AND o.created_at < $2::timestamp AND o.status NOT IN ('cancelled', 'failed') AND NOT o.sandbox
+GROUP BY p.id, p.name
+ORDER BY quantity DESC, p.name
+LIMIT $3
+`
+
+type GetTopProductsParams struct {
+	Since       time.Time
+	Until       time.Time
+	MaxProducts int32
+}
+
+type GetTopProductsRow struct {
+	ID       uuid.UUID
+	Name     string
+	Quantity int64
+	Revenue  models.Money
+}
+
+// Revenue is at the prices charged, before order discounts
+func (q *Queries) GetTopProducts(ctx context.Context, arg GetTopProductsParams) ([]GetTopProductsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getTopProducts, arg.Since, arg.Until, arg.MaxProducts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetTopProductsRow
+	for rows.Next() {
+		var i GetTopProductsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Quantity,
+			&i.Revenue,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateOrderPaymentStatus = `-- name: UpdateOrderPaymentStatus :execrows
+UPDATE orders
+SET payment_status = $2
+WHERE id = $1
+`
+
+type UpdateOrderPaymentStatusParams struct {
+	ID            uuid.UUID
+	PaymentStatus sql.NullString
+}
+
+func (q *Queries) UpdateOrderPaymentStatus(ctx context.Context, arg UpdateOrderPaymentStatusParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateOrderPaymentStatus, arg.ID, arg.PaymentStatus)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const updateOrderStatus = `-- name: UpdateOrderStatus :one
 UPDATE orders 
-SET status = $2, updated_at = NOW()
-WHERE id = $1
-RETURNING id, total, discounts, status, created_at, updated_at
+SET status = $2, version = version + 1
+WHERE id = $1 AND version = $3
+RETURNING id, total, discounts, status, created_at, updated_at, version, customer_id, payment_intent_id, payment_status, payment_method, store_credit, gift_card_code, coupon_code, store_id, sandbox
 `
 
 type UpdateOrderStatusParams struct {
-	ID     uuid.UUID
-	Status sql.NullString
+	ID      uuid.UUID
+	Status  sql.NullString
+	Version int32
 }
 
 func (q *Queries) UpdateOrderStatus(ctx context.Context, arg UpdateOrderStatusParams) (Order, error) {
-	row := q.db.QueryRowContext(ctx, updateOrderStatus, arg.ID, arg.Status)
+	row := q.db.QueryRowContext(ctx, updateOrderStatus, arg.ID, arg.Status, arg.Version)
 	var i Order
 	err := row.Scan(
 		&i.ID,
@@ -187,6 +844,16 @@ func (q *Queries) UpdateOrderStatus(ctx context.Context, arg UpdateOrderStatusPa
 		&i.Status,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Version,
+		&i.CustomerID,
+		&i.PaymentIntentID,
+		&i.PaymentStatus,
+		&i.PaymentMethod,
+		&i.StoreCredit,
+		&i.GiftCardCode,
+		&i.CouponCode,
+		&i.StoreID,
+		&i.Sandbox,
 	)
 	return i, err
 }
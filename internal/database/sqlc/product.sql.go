@@ -10,22 +10,37 @@ import (
 	"database/sql"
 
 	"github.com/google/uuid"
+	"oolio/internal/app/models"
 )
 
+const countProducts = `-- name: CountProducts :one
+SELECT COUNT(*) FROM products
+WHERE deleted_at IS NULL
+  AND ($1::uuid IS NULL OR store_id = $1::uuid)
+`
+
+func (q *Queries) CountProducts(ctx context.Context, storeID uuid.NullUUID) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countProducts, storeID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createProduct = `-- name: CreateProduct :one
-INSERT INTO products (name, price, category, thumbnail_url, mobile_url, tablet_url, desktop_url)
-VALUES ($1, $2, $3, $4, $5, $6, $7)
-RETURNING id, name, price, category, thumbnail_url, mobile_url, tablet_url, desktop_url, created_at, updated_at
+INSERT INTO products (name, price, category, thumbnail_url, mobile_url, tablet_url, desktop_url, store_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING id, name, price, category, thumbnail_url, mobile_url, tablet_url, desktop_url, created_at, updated_at, version, deleted_at, store_id
 `
 
 type CreateProductParams struct {
 	Name         string
-	Price        string
+	Price        models.Money
 	Category     string
 	ThumbnailUrl sql.NullString
 	MobileUrl    sql.NullString
 	TabletUrl    sql.NullString
 	DesktopUrl   sql.NullString
+	StoreID      uuid.UUID
 }
 
 func (q *Queries) CreateProduct(ctx context.Context, arg CreateProductParams) (Product, error) {
@@ -37,6 +52,7 @@ func (q *Queries) CreateProduct(ctx context.Context, arg CreateProductParams) (P
 		arg.MobileUrl,
 		arg.TabletUrl,
 		arg.DesktopUrl,
+		arg.StoreID,
 	)
 	var i Product
 	err := row.Scan(
@@ -50,23 +66,74 @@ func (q *Queries) CreateProduct(ctx context.Context, arg CreateProductParams) (P
 		&i.DesktopUrl,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Version,
+		&i.DeletedAt,
+		&i.StoreID,
 	)
 	return i, err
 }
 
-const deleteProduct = `-- name: DeleteProduct :exec
-DELETE FROM products WHERE id = $1
+const deleteProduct = `-- name: DeleteProduct :execrows
+UPDATE products SET deleted_at = NOW(), version = version + 1
+WHERE id = $1 AND deleted_at IS NULL
 `
 
-func (q *Queries) DeleteProduct(ctx context.Context, id uuid.UUID) error {
-	_, err := q.db.ExecContext(ctx, deleteProduct, id)
-	return err
+func (q *Queries) DeleteProduct(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteProduct, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getDeletedProducts = `-- name: GetDeletedProducts :many
+SELECT id, name, price, category, thumbnail_url, mobile_url, tablet_url, desktop_url, created_at, updated_at, version, deleted_at, store_id
+FROM products
+WHERE deleted_at >= $1
+ORDER BY deleted_at DESC
+`
+
+func (q *Queries) GetDeletedProducts(ctx context.Context, deletedAt sql.NullTime) ([]Product, error) {
+	rows, err := q.db.QueryContext(ctx, getDeletedProducts, deletedAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Product
+	for rows.Next() {
+		var i Product
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Price,
+			&i.Category,
+			&i.ThumbnailUrl,
+			&i.MobileUrl,
+			&i.TabletUrl,
+			&i.DesktopUrl,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Version,
+			&i.DeletedAt,
+			&i.StoreID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
 const getProductByID = `-- name: GetProductByID :one
-SELECT id, name, price, category, thumbnail_url, mobile_url, tablet_url, desktop_url, created_at, updated_at
+SELECT id, name, price, category, thumbnail_url, mobile_url, tablet_url, desktop_url, created_at, updated_at, version, deleted_at, store_id
 FROM products
-WHERE id = $1
+WHERE id = $1 AND deleted_at IS NULL
 `
 
 func (q *Queries) GetProductByID(ctx context.Context, id uuid.UUID) (Product, error) {
@@ -83,13 +150,17 @@ func (q *Queries) GetProductByID(ctx context.Context, id uuid.UUID) (Product, er
 		&i.DesktopUrl,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Version,
+		&i.DeletedAt,
+		&i.StoreID,
 	)
 	return i, err
 }
 
 const getProducts = `-- name: GetProducts :many
-SELECT id, name, price, category, thumbnail_url, mobile_url, tablet_url, desktop_url, created_at, updated_at
+SELECT id, name, price, category, thumbnail_url, mobile_url, tablet_url, desktop_url, created_at, updated_at, version, deleted_at, store_id
 FROM products
+WHERE deleted_at IS NULL
 ORDER BY name
 `
 
@@ -113,6 +184,105 @@ func (q *Queries) GetProducts(ctx context.Context) ([]Product, error) {
 			&i.DesktopUrl,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Version,
+			&i.DeletedAt,
+			&i.StoreID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getProductsByIDs = `-- name: GetProductsByIDs :many
+SELECT id, name, price, category, thumbnail_url, mobile_url, tablet_url, desktop_url, created_at, updated_at, version, deleted_at, store_id
+FROM products
+WHERE id = ANY($1::uuid[]) AND deleted_at IS NULL
+ORDER BY name
+`
+
+func (q *Queries) GetProductsByIDs(ctx context.Context, ids []uuid.UUID) ([]Product, error) {
+	rows, err := q.db.QueryContext(ctx, getProductsByIDs, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Product
+	for rows.Next() {
+		var i Product
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Price,
+			&i.Category,
+			&i.ThumbnailUrl,
+			&i.MobileUrl,
+			&i.TabletUrl,
+			&i.DesktopUrl,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Version,
+			&i.DeletedAt,
+			&i.StoreID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getProductsPage = `-- name: GetProductsPage :many
+SELECT id, name, price, category, thumbnail_url, mobile_url, tablet_url, desktop_url, created_at, updated_at, version, deleted_at, store_id
+FROM products
+WHERE deleted_at IS NULL
+  AND ($3::uuid IS NULL OR store_id = $3::uuid)
+ORDER BY name, id
+LIMIT $1 OFFSET $2
+`
+
+type GetProductsPageParams struct {
+	Limit   int32
+	Offset  int32
+	StoreID uuid.NullUUID
+}
+
+func (q *Queries) GetProductsPage(ctx context.Context, arg GetProductsPageParams) ([]Product, error) {
+	rows, err := q.db.QueryContext(ctx, getProductsPage, arg.Limit, arg.Offset, arg.StoreID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Product
+	for rows.Next() {
+		var i Product
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Price,
+			&i.Category,
+			&i.ThumbnailUrl,
+			&i.MobileUrl,
+			&i.TabletUrl,
+			&i.DesktopUrl,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Version,
+			&i.DeletedAt,
+			&i.StoreID,
 		); err != nil {
 			return nil, err
 		}
@@ -127,22 +297,133 @@ func (q *Queries) GetProducts(ctx context.Context) ([]Product, error) {
 	return items, nil
 }
 
+const getProductsUpdatedSince = `-- name: GetProductsUpdatedSince :many
+SELECT id, name, price, category, thumbnail_url, mobile_url, tablet_url, desktop_url, created_at, updated_at, version, deleted_at, store_id
+FROM products
+WHERE updated_at >= $1
+ORDER BY updated_at, id
+`
+
+// Includes soft-deleted products so sync clients learn about deletions
+func (q *Queries) GetProductsUpdatedSince(ctx context.Context, updatedAt sql.NullTime) ([]Product, error) {
+	rows, err := q.db.QueryContext(ctx, getProductsUpdatedSince, updatedAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Product
+	for rows.Next() {
+		var i Product
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Price,
+			&i.Category,
+			&i.ThumbnailUrl,
+			&i.MobileUrl,
+			&i.TabletUrl,
+			&i.DesktopUrl,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Version,
+			&i.DeletedAt,
+			&i.StoreID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const insertProductIfMissing = `-- name: InsertProductIfMissing :execrows
+WITH inserted AS (
+  INSERT INTO products (name, price, category, thumbnail_url, mobile_url, tablet_url, desktop_url)
+  SELECT $1::varchar, $2::numeric, $3::varchar, $4::text, $5::text, $6::text, $7::text
+  WHERE NOT EXISTS (SELECT 1 FROM products WHERE name = $1::varchar)
+  RETURNING id
+)
+INSERT INTO product_changes (product_id, kind)
+SELECT id, 'created' FROM inserted
+`
+
+type InsertProductIfMissingParams struct {
+	Name         string
+	Price        models.Money
+	Category     string
+	ThumbnailUrl string
+	MobileUrl    string
+	TabletUrl    string
+	DesktopUrl   string
+}
+
+// Seeded products are logged in the change feed
+func (q *Queries) InsertProductIfMissing(ctx context.Context, arg InsertProductIfMissingParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, insertProductIfMissing,
+		arg.Name,
+		arg.Price,
+		arg.Category,
+		arg.ThumbnailUrl,
+		arg.MobileUrl,
+		arg.TabletUrl,
+		arg.DesktopUrl,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const restoreProduct = `-- name: RestoreProduct :one
+UPDATE products SET deleted_at = NULL, version = version + 1
+WHERE id = $1 AND deleted_at IS NOT NULL
+RETURNING id, name, price, category, thumbnail_url, mobile_url, tablet_url, desktop_url, created_at, updated_at, version, deleted_at, store_id
+`
+
+func (q *Queries) RestoreProduct(ctx context.Context, id uuid.UUID) (Product, error) {
+	row := q.db.QueryRowContext(ctx, restoreProduct, id)
+	var i Product
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Price,
+		&i.Category,
+		&i.ThumbnailUrl,
+		&i.MobileUrl,
+		&i.TabletUrl,
+		&i.DesktopUrl,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Version,
+		&i.DeletedAt,
+		&i.StoreID,
+	)
+	return i, err
+}
+
 const updateProduct = `-- name: UpdateProduct :one
 UPDATE products 
-SET name = $2, price = $3, category = $4, thumbnail_url = $5, mobile_url = $6, tablet_url = $7, desktop_url = $8, updated_at = NOW()
-WHERE id = $1
-RETURNING id, name, price, category, thumbnail_url, mobile_url, tablet_url, desktop_url, created_at, updated_at
+SET name = $2, price = $3, category = $4, thumbnail_url = $5, mobile_url = $6, tablet_url = $7, desktop_url = $8, version = version + 1
+WHERE id = $1 AND version = $9 AND deleted_at IS NULL
+RETURNING id, name, price, category, thumbnail_url, mobile_url, tablet_url, desktop_url, created_at, updated_at, version, deleted_at, store_id
 `
 
 type UpdateProductParams struct {
 	ID           uuid.UUID
 	Name         string
-	Price        string
+	Price        models.Money
 	Category     string
 	ThumbnailUrl sql.NullString
 	MobileUrl    sql.NullString
 	TabletUrl    sql.NullString
 	DesktopUrl   sql.NullString
+	Version      int32
 }
 
 func (q *Queries) UpdateProduct(ctx context.Context, arg UpdateProductParams) (Product, error) {
@@ -155,6 +436,7 @@ func (q *Queries) UpdateProduct(ctx context.Context, arg UpdateProductParams) (P
 		arg.MobileUrl,
 		arg.TabletUrl,
 		arg.DesktopUrl,
+		arg.Version,
 	)
 	var i Product
 	err := row.Scan(
@@ -168,6 +450,9 @@ func (q *Queries) UpdateProduct(ctx context.Context, arg UpdateProductParams) (P
 		&i.DesktopUrl,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Version,
+		&i.DeletedAt,
+		&i.StoreID,
 	)
 	return i, err
 }
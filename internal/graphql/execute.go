@@ -0,0 +1,207 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"reflect"
+)
+
+// executor runs a validated operation, collecting the errors of fields that failed
+type executor struct {
+	ctx    context.Context
+	src    string
+	doc    *document
+	vars   map[string]any
+	errors []*Error
+}
+
+// object is a selected object, keeping its fields in the order the query selected them
+type object []member
+
+type member struct {
+	key   string
+	value any
+}
+
+func (o object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, m := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(m.key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(m.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func (e *executor) fail(f *field, path []any, err error) {
+	e.errors = append(e.errors, &Error{
+		Message:   err.Error(),
+		Locations: []Location{location(e.src, f.pos)},
+		Path:      path,
+	})
+}
+
+func (e *executor) executeRoot(s *Schema, selections []selection) object {
+	keys, fields := e.collectFields(selections)
+	data := make(object, 0, len(keys))
+	for _, key := range keys {
+		f := fields[key][0]
+		path := []any{key}
+		if f.name == "__typename" {
+			data = append(data, member{key, "Query"})
+			continue
+		}
+
+		root := s.Query[f.name]
+		value, err := e.resolveRoot(root, f)
+		if err != nil {
+			e.fail(f, path, err)
+			data = append(data, member{key, nil})
+			continue
+		}
+		data = append(data, member{key, e.complete(reflect.ValueOf(value), subSelections(fields[key]), path)})
+	}
+	return data
+}
+
+func (e *executor) resolveRoot(root *Field, f *field) (any, error) {
+	args := make(map[string]any, len(f.args))
+	for _, arg := range f.args {
+		// A variable the request left out leaves its argument out too
+		if name, ok := arg.value.(variable); ok {
+			if _, given := e.vars[string(name)]; !given {
+				continue
+			}
+		}
+		value, err := coerceValue(root.Args[arg.name], arg.value, e.vars)
+		if err != nil {
+			return nil, &Error{Message: "Argument \"" + arg.name + "\" has invalid value: " + err.Error() + "."}
+		}
+		args[arg.name] = value
+	}
+	return root.Resolve(e.ctx, args)
+}
+
+// complete turns a resolved value into what the selections select from it
+func (e *executor) complete(v reflect.Value, selections []selection, path []any) any {
+	for v.IsValid() && (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return nil
+	}
+	if isLeaf(v.Type()) {
+		return v.Interface()
+	}
+
+	if isList(v.Type()) {
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		list := make([]any, v.Len())
+		for i := range list {
+			list[i] = e.complete(v.Index(i), selections, append(path[:len(path):len(path)], i))
+		}
+		return list
+	}
+
+	keys, fields := e.collectFields(selections)
+	obj := make(object, 0, len(keys))
+	structFields := objectFields(v.Type())
+	for _, key := range keys {
+		f := fields[key][0]
+		if f.name == "__typename" {
+			obj = append(obj, member{key, v.Type().Name()})
+			continue
+		}
+
+		field, err := v.FieldByIndexErr(structFields[f.name].index)
+		if err != nil {
+			// Reached through a nil embedded pointer
+			obj = append(obj, member{key, nil})
+			continue
+		}
+		obj = append(obj, member{key, e.complete(field, subSelections(fields[key]), append(path[:len(path):len(path)], key))})
+	}
+	return obj
+}
+
+// subSelections merges the selection sets of the fields returned under one key
+func subSelections(fields []*field) []selection {
+	if len(fields) == 1 {
+		return fields[0].selections
+	}
+	var selections []selection
+	for _, f := range fields {
+		selections = append(selections, f.selections...)
+	}
+	return selections
+}
+
+// collectFields groups the selected fields by the key they are returned under, through
+// fragments and minding @skip and @include. Validation made sure every fragment's type
+// condition holds
+func (e *executor) collectFields(selections []selection) ([]string, map[string][]*field) {
+	var keys []string
+	fields := make(map[string][]*field)
+	visited := make(map[string]bool)
+
+	var collect func([]selection)
+	collect = func(selections []selection) {
+		for _, sel := range selections {
+			if !e.included(sel.skipIf()) {
+				continue
+			}
+			switch sel := sel.(type) {
+			case *field:
+				key := sel.responseKey()
+				if _, ok := fields[key]; !ok {
+					keys = append(keys, key)
+				}
+				fields[key] = append(fields[key], sel)
+			case *fragmentSpread:
+				if visited[sel.name] {
+					continue
+				}
+				visited[sel.name] = true
+				collect(e.doc.fragments[sel.name].selections)
+			case *inlineFragment:
+				collect(sel.selections)
+			}
+		}
+	}
+	collect(selections)
+	return keys, fields
+}
+
+// included evaluates a selection's @skip and @include directives
+func (e *executor) included(directives []directive) bool {
+	for _, d := range directives {
+		value, err := coerceValue("Boolean!", d.args[0].value, e.vars)
+		if err != nil {
+			// Only a variable explicitly set to null gets here; treat it as false
+			value = false
+		}
+		if value.(bool) == (d.name == "skip") {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,724 @@
+// Package graphql executes GraphQL queries against resolvers that return plain Go values.
+// The object types are the Go structs the resolvers return, with the fields their json tags
+// name, so the API's models double as its schema. Only queries are supported.
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// document is a parsed query: its operations and the fragments they spread
+type document struct {
+	operations []*operation
+	fragments  map[string]*fragment
+}
+
+type operation struct {
+	kind       string // query, mutation or subscription
+	name       string
+	variables  []variableDef
+	selections []selection
+	pos        int
+}
+
+type variableDef struct {
+	name       string
+	typ        string // e.g. ID! or Int
+	def        any
+	hasDefault bool
+	pos        int
+}
+
+type fragment struct {
+	name          string
+	typeCondition string
+	selections    []selection
+	pos           int
+}
+
+// selection is a *field, *fragmentSpread or *inlineFragment
+type selection interface {
+	position() int
+	skipIf() []directive
+}
+
+type field struct {
+	alias      string
+	name       string
+	args       []argument
+	directives []directive
+	selections []selection
+	pos        int
+}
+
+type fragmentSpread struct {
+	name       string
+	directives []directive
+	pos        int
+}
+
+type inlineFragment struct {
+	typeCondition string
+	directives    []directive
+	selections    []selection
+	pos           int
+}
+
+func (f *field) position() int          { return f.pos }
+func (f *fragmentSpread) position() int { return f.pos }
+func (f *inlineFragment) position() int { return f.pos }
+
+func (f *field) skipIf() []directive          { return f.directives }
+func (f *fragmentSpread) skipIf() []directive { return f.directives }
+func (f *inlineFragment) skipIf() []directive { return f.directives }
+
+// responseKey is the name the field's value is returned under
+func (f *field) responseKey() string {
+	if f.alias != "" {
+		return f.alias
+	}
+	return f.name
+}
+
+type argument struct {
+	name  string
+	value any
+	pos   int
+}
+
+// directive is @skip or @include, the only ones supported
+type directive struct {
+	name string
+	args []argument
+	pos  int
+}
+
+// Values in a query are parsed to int, float64, string, bool, nil, []any and
+// map[string]any, besides these
+type (
+	variable  string // $name
+	enumValue string
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenPunct
+	tokenName
+	tokenInt
+	tokenFloat
+	tokenString
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	pos   int
+}
+
+type parser struct {
+	src string
+	pos int
+	tok token
+}
+
+// parse parses a query document
+func parse(src string) (*document, error) {
+	p := &parser{src: src}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	doc := &document{fragments: make(map[string]*fragment)}
+	for p.tok.kind != tokenEOF {
+		switch {
+		case p.is(tokenPunct, "{"):
+			op := &operation{kind: "query", pos: p.tok.pos}
+			selections, err := p.selectionSet()
+			if err != nil {
+				return nil, err
+			}
+			op.selections = selections
+			doc.operations = append(doc.operations, op)
+		case p.is(tokenName, "query"), p.is(tokenName, "mutation"), p.is(tokenName, "subscription"):
+			op, err := p.operation()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, op)
+		case p.is(tokenName, "fragment"):
+			frag, err := p.fragment()
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := doc.fragments[frag.name]; ok {
+				return nil, p.errorAt(frag.pos, "There can be only one fragment named %q.", frag.name)
+			}
+			doc.fragments[frag.name] = frag
+		default:
+			return nil, p.unexpected()
+		}
+	}
+	if len(doc.operations) == 0 {
+		return nil, p.errorAt(p.tok.pos, "The document contains no operation.")
+	}
+	return doc, nil
+}
+
+func (p *parser) operation() (*operation, error) {
+	op := &operation{kind: p.tok.value, pos: p.tok.pos}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	if p.tok.kind == tokenName {
+		op.name = p.tok.value
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.is(tokenPunct, "(") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		for !p.is(tokenPunct, ")") {
+			def, err := p.variableDef()
+			if err != nil {
+				return nil, err
+			}
+			op.variables = append(op.variables, def)
+		}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+	if p.is(tokenPunct, "@") {
+		return nil, p.errorAt(p.tok.pos, "Directives on operations are not supported.")
+	}
+
+	selections, err := p.selectionSet()
+	if err != nil {
+		return nil, err
+	}
+	op.selections = selections
+	return op, nil
+}
+
+func (p *parser) variableDef() (variableDef, error) {
+	def := variableDef{pos: p.tok.pos}
+	if err := p.expect(tokenPunct, "$"); err != nil {
+		return def, err
+	}
+	name, err := p.name()
+	if err != nil {
+		return def, err
+	}
+	def.name = name
+	if err := p.expect(tokenPunct, ":"); err != nil {
+		return def, err
+	}
+	if def.typ, err = p.typeRef(); err != nil {
+		return def, err
+	}
+
+	if p.is(tokenPunct, "=") {
+		if err := p.advance(); err != nil {
+			return def, err
+		}
+		if def.def, err = p.value(true); err != nil {
+			return def, err
+		}
+		def.hasDefault = true
+	}
+	return def, nil
+}
+
+// typeRef reads a type such as ID!, [Int] or [String!]! back as written
+func (p *parser) typeRef() (string, error) {
+	var typ string
+	if p.is(tokenPunct, "[") {
+		if err := p.advance(); err != nil {
+			return "", err
+		}
+		elem, err := p.typeRef()
+		if err != nil {
+			return "", err
+		}
+		if err := p.expect(tokenPunct, "]"); err != nil {
+			return "", err
+		}
+		typ = "[" + elem + "]"
+	} else {
+		name, err := p.name()
+		if err != nil {
+			return "", err
+		}
+		typ = name
+	}
+
+	if p.is(tokenPunct, "!") {
+		if err := p.advance(); err != nil {
+			return "", err
+		}
+		typ += "!"
+	}
+	return typ, nil
+}
+
+func (p *parser) fragment() (*fragment, error) {
+	frag := &fragment{pos: p.tok.pos}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	if name == "on" {
+		return nil, p.errorAt(frag.pos, "A fragment can't be named \"on\".")
+	}
+	frag.name = name
+
+	if !p.is(tokenName, "on") {
+		return nil, p.unexpected()
+	}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	if frag.typeCondition, err = p.name(); err != nil {
+		return nil, err
+	}
+	if p.is(tokenPunct, "@") {
+		return nil, p.errorAt(p.tok.pos, "Directives on fragment definitions are not supported.")
+	}
+	if frag.selections, err = p.selectionSet(); err != nil {
+		return nil, err
+	}
+	return frag, nil
+}
+
+func (p *parser) selectionSet() ([]selection, error) {
+	if err := p.expect(tokenPunct, "{"); err != nil {
+		return nil, err
+	}
+
+	var selections []selection
+	for !p.is(tokenPunct, "}") {
+		sel, err := p.selection()
+		if err != nil {
+			return nil, err
+		}
+		selections = append(selections, sel)
+	}
+	if len(selections) == 0 {
+		return nil, p.unexpected()
+	}
+	return selections, p.advance()
+}
+
+func (p *parser) selection() (selection, error) {
+	pos := p.tok.pos
+	if !p.is(tokenPunct, "...") {
+		return p.field()
+	}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	// A name other than "on" spreads a named fragment
+	if p.tok.kind == tokenName && p.tok.value != "on" {
+		spread := &fragmentSpread{name: p.tok.value, pos: pos}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		var err error
+		spread.directives, err = p.directives()
+		return spread, err
+	}
+
+	inline := &inlineFragment{pos: pos}
+	if p.is(tokenName, "on") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		inline.typeCondition = name
+	}
+	var err error
+	if inline.directives, err = p.directives(); err != nil {
+		return nil, err
+	}
+	if inline.selections, err = p.selectionSet(); err != nil {
+		return nil, err
+	}
+	return inline, nil
+}
+
+func (p *parser) field() (*field, error) {
+	f := &field{pos: p.tok.pos}
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	f.name = name
+	if p.is(tokenPunct, ":") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		f.alias = name
+		if f.name, err = p.name(); err != nil {
+			return nil, err
+		}
+	}
+
+	if f.args, err = p.arguments(); err != nil {
+		return nil, err
+	}
+	if f.directives, err = p.directives(); err != nil {
+		return nil, err
+	}
+	if p.is(tokenPunct, "{") {
+		if f.selections, err = p.selectionSet(); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+func (p *parser) arguments() ([]argument, error) {
+	if !p.is(tokenPunct, "(") {
+		return nil, nil
+	}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	var args []argument
+	for !p.is(tokenPunct, ")") {
+		arg := argument{pos: p.tok.pos}
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		arg.name = name
+		if err := p.expect(tokenPunct, ":"); err != nil {
+			return nil, err
+		}
+		if arg.value, err = p.value(false); err != nil {
+			return nil, err
+		}
+		for _, other := range args {
+			if other.name == arg.name {
+				return nil, p.errorAt(arg.pos, "There can be only one argument named %q.", arg.name)
+			}
+		}
+		args = append(args, arg)
+	}
+	if len(args) == 0 {
+		return nil, p.unexpected()
+	}
+	return args, p.advance()
+}
+
+func (p *parser) directives() ([]directive, error) {
+	var directives []directive
+	for p.is(tokenPunct, "@") {
+		d := directive{pos: p.tok.pos}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		if name != "skip" && name != "include" {
+			return nil, p.errorAt(d.pos, "Unknown directive \"@%s\".", name)
+		}
+		d.name = name
+		if d.args, err = p.arguments(); err != nil {
+			return nil, err
+		}
+		directives = append(directives, d)
+	}
+	return directives, nil
+}
+
+// value reads an argument or default value; constant values can't name variables
+func (p *parser) value(constant bool) (any, error) {
+	tok := p.tok
+	switch {
+	case tok.kind == tokenPunct && tok.value == "$":
+		if constant {
+			return nil, p.errorAt(tok.pos, "Default values can't use variables.")
+		}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		name, err := p.name()
+		return variable(name), err
+
+	case tok.kind == tokenPunct && tok.value == "[":
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		list := []any{}
+		for !p.is(tokenPunct, "]") {
+			v, err := p.value(constant)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		return list, p.advance()
+
+	case tok.kind == tokenPunct && tok.value == "{":
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		obj := map[string]any{}
+		for !p.is(tokenPunct, "}") {
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(tokenPunct, ":"); err != nil {
+				return nil, err
+			}
+			if obj[name], err = p.value(constant); err != nil {
+				return nil, err
+			}
+		}
+		return obj, p.advance()
+
+	case tok.kind == tokenInt:
+		n, err := strconv.Atoi(tok.value)
+		if err != nil {
+			return nil, p.errorAt(tok.pos, "Int cannot represent %s.", tok.value)
+		}
+		return n, p.advance()
+
+	case tok.kind == tokenFloat:
+		f, err := strconv.ParseFloat(tok.value, 64)
+		if err != nil {
+			return nil, p.errorAt(tok.pos, "Float cannot represent %s.", tok.value)
+		}
+		return f, p.advance()
+
+	case tok.kind == tokenString:
+		return tok.value, p.advance()
+
+	case tok.kind == tokenName:
+		var v any
+		switch tok.value {
+		case "true":
+			v = true
+		case "false":
+			v = false
+		case "null":
+			v = nil
+		default:
+			v = enumValue(tok.value)
+		}
+		return v, p.advance()
+	}
+	return nil, p.unexpected()
+}
+
+func (p *parser) name() (string, error) {
+	if p.tok.kind != tokenName {
+		return "", p.unexpected()
+	}
+	name := p.tok.value
+	return name, p.advance()
+}
+
+func (p *parser) is(kind tokenKind, value string) bool {
+	return p.tok.kind == kind && p.tok.value == value
+}
+
+func (p *parser) expect(kind tokenKind, value string) error {
+	if !p.is(kind, value) {
+		return p.unexpected()
+	}
+	return p.advance()
+}
+
+func (p *parser) unexpected() error {
+	if p.tok.kind == tokenEOF {
+		return p.errorAt(p.tok.pos, "Syntax Error: Unexpected <EOF>.")
+	}
+	return p.errorAt(p.tok.pos, "Syntax Error: Unexpected %q.", p.tok.value)
+}
+
+func (p *parser) errorAt(pos int, format string, args ...any) *Error {
+	return &Error{Message: fmt.Sprintf(format, args...), Locations: []Location{location(p.src, pos)}}
+}
+
+// advance reads the next token into p.tok, skipping whitespace, commas and comments
+func (p *parser) advance() error {
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		if c == '#' {
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' && p.src[p.pos] != '\r' {
+				p.pos++
+			}
+			continue
+		}
+		if c != ' ' && c != '\t' && c != '\n' && c != '\r' && c != ',' {
+			break
+		}
+		p.pos++
+	}
+
+	start := p.pos
+	if start == len(p.src) {
+		p.tok = token{kind: tokenEOF, pos: start}
+		return nil
+	}
+
+	c := p.src[start]
+	switch {
+	case strings.HasPrefix(p.src[start:], "..."):
+		p.pos += 3
+		p.tok = token{kind: tokenPunct, value: "...", pos: start}
+	case strings.IndexByte("!$():=@[]{}", c) >= 0:
+		p.pos++
+		p.tok = token{kind: tokenPunct, value: string(c), pos: start}
+	case c == '_' || isLetter(c):
+		for p.pos < len(p.src) && (p.src[p.pos] == '_' || isLetter(p.src[p.pos]) || isDigit(p.src[p.pos])) {
+			p.pos++
+		}
+		p.tok = token{kind: tokenName, value: p.src[start:p.pos], pos: start}
+	case c == '-' || isDigit(c):
+		return p.number()
+	case c == '"':
+		return p.string()
+	default:
+		r, _ := utf8.DecodeRuneInString(p.src[start:])
+		return p.errorAt(start, "Syntax Error: Unexpected character %q.", r)
+	}
+	return nil
+}
+
+func (p *parser) number() error {
+	start := p.pos
+	kind := tokenInt
+	if p.src[p.pos] == '-' {
+		p.pos++
+	}
+	if !p.digits() {
+		return p.errorAt(start, "Syntax Error: Invalid number.")
+	}
+	if p.pos < len(p.src) && p.src[p.pos] == '.' {
+		kind = tokenFloat
+		p.pos++
+		if !p.digits() {
+			return p.errorAt(start, "Syntax Error: Invalid number.")
+		}
+	}
+	if p.pos < len(p.src) && (p.src[p.pos] == 'e' || p.src[p.pos] == 'E') {
+		kind = tokenFloat
+		p.pos++
+		if p.pos < len(p.src) && (p.src[p.pos] == '+' || p.src[p.pos] == '-') {
+			p.pos++
+		}
+		if !p.digits() {
+			return p.errorAt(start, "Syntax Error: Invalid number.")
+		}
+	}
+	p.tok = token{kind: kind, value: p.src[start:p.pos], pos: start}
+	return nil
+}
+
+// digits skips a run of digits, reporting whether there was one
+func (p *parser) digits() bool {
+	start := p.pos
+	for p.pos < len(p.src) && isDigit(p.src[p.pos]) {
+		p.pos++
+	}
+	return p.pos > start
+}
+
+func (p *parser) string() error {
+	start := p.pos
+	if strings.HasPrefix(p.src[start:], `"""`) {
+		return p.errorAt(start, "Syntax Error: Block strings are not supported.")
+	}
+	p.pos++
+
+	var b strings.Builder
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch {
+		case c == '"':
+			p.pos++
+			p.tok = token{kind: tokenString, value: b.String(), pos: start}
+			return nil
+		case c == '\n' || c == '\r':
+			return p.errorAt(start, "Syntax Error: Unterminated string.")
+		case c == '\\' && p.pos+1 < len(p.src):
+			escaped, width, ok := unescape(p.src[p.pos:])
+			if !ok {
+				return p.errorAt(p.pos, "Syntax Error: Invalid escape sequence.")
+			}
+			b.WriteString(escaped)
+			p.pos += width
+		default:
+			b.WriteByte(c)
+			p.pos++
+		}
+	}
+	return p.errorAt(start, "Syntax Error: Unterminated string.")
+}
+
+// unescape decodes the escape sequence at the start of s, returning its length in s
+func unescape(s string) (string, int, bool) {
+	switch s[1] {
+	case '"', '\\', '/':
+		return s[1:2], 2, true
+	case 'b':
+		return "\b", 2, true
+	case 'f':
+		return "\f", 2, true
+	case 'n':
+		return "\n", 2, true
+	case 'r':
+		return "\r", 2, true
+	case 't':
+		return "\t", 2, true
+	case 'u':
+		if len(s) < 6 {
+			return "", 0, false
+		}
+		code, err := strconv.ParseUint(s[2:6], 16, 32)
+		if err != nil {
+			return "", 0, false
+		}
+		return string(rune(code)), 6, true
+	}
+	return "", 0, false
+}
+
+func isLetter(c byte) bool { return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' }
+func isDigit(c byte) bool  { return c >= '0' && c <= '9' }
+
+// location turns a byte offset into the 1-based line and column errors report
+func location(src string, pos int) Location {
+	loc := Location{Line: 1, Column: 1}
+	for _, r := range src[:min(pos, len(src))] {
+		if r == '\n' {
+			loc.Line++
+			loc.Column = 1
+		} else {
+			loc.Column++
+		}
+	}
+	return loc
+}
@@ -0,0 +1,256 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Schema is the set of fields queries can select at the root
+type Schema struct {
+	Query map[string]*Field
+}
+
+// Field is a root field of the schema
+type Field struct {
+	// Args maps each argument to its type: ID, String, Int, Float or Boolean, a list
+	// of one of those such as [ID], and non-null when suffixed with !
+	Args map[string]string
+	// Type is the type Resolve returns, which queries select from
+	Type reflect.Type
+	// Resolve is given the arguments the query supplies, coerced to their types: ID and
+	// String to string, Int to int, Float to float64, Boolean to bool and lists to []any.
+	// An argument the query leaves out is absent from args
+	Resolve func(ctx context.Context, args map[string]any) (any, error)
+}
+
+// Request is a GraphQL request as POSTed by clients
+type Request struct {
+	Query         string         `json:"query" binding:"required"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// Response carries the data a query selected, null where a field failed, and the
+// errors met. Data is left out when the request could not be executed at all
+type Response struct {
+	Data   any      `json:"data,omitempty"`
+	Errors []*Error `json:"errors,omitempty"`
+}
+
+// Error is a GraphQL error, located in the query and, when a field failed, at the
+// field's path in the response
+type Error struct {
+	Message   string     `json:"message"`
+	Locations []Location `json:"locations,omitempty"`
+	Path      []any      `json:"path,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Location is a 1-based line and column in the query
+type Location struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// Execute parses, validates and runs the query. Root fields are resolved in the order
+// the query selects them
+func (s *Schema) Execute(ctx context.Context, req Request) *Response {
+	doc, err := parse(req.Query)
+	if err != nil {
+		return failed(err)
+	}
+	if errs := s.validate(req.Query, doc); len(errs) > 0 {
+		return &Response{Errors: errs}
+	}
+
+	op, err := selectOperation(doc, req.OperationName)
+	if err != nil {
+		return failed(err)
+	}
+	if op.kind != "query" {
+		return failed(&Error{Message: "Only queries are supported.", Locations: []Location{location(req.Query, op.pos)}})
+	}
+	vars, err := coerceVariables(op, req.Variables)
+	if err != nil {
+		return failed(err)
+	}
+
+	e := &executor{ctx: ctx, src: req.Query, doc: doc, vars: vars}
+	data := e.executeRoot(s, op.selections)
+	return &Response{Data: data, Errors: e.errors}
+}
+
+func failed(err error) *Response {
+	if gqlErr, ok := err.(*Error); ok {
+		return &Response{Errors: []*Error{gqlErr}}
+	}
+	return &Response{Errors: []*Error{{Message: err.Error()}}}
+}
+
+func selectOperation(doc *document, name string) (*operation, error) {
+	if name == "" {
+		if len(doc.operations) > 1 {
+			return nil, &Error{Message: "Must provide operation name if query contains multiple operations."}
+		}
+		return doc.operations[0], nil
+	}
+	for _, op := range doc.operations {
+		if op.name == name {
+			return op, nil
+		}
+	}
+	return nil, &Error{Message: fmt.Sprintf("Unknown operation named %q.", name)}
+}
+
+// coerceVariables checks the request's variables against the operation's definitions,
+// filling in defaults. Variables neither given nor defaulted are left out
+func coerceVariables(op *operation, given map[string]any) (map[string]any, error) {
+	vars := make(map[string]any, len(op.variables))
+	for _, def := range op.variables {
+		value, ok := given[def.name]
+		if !ok && def.hasDefault {
+			value, ok = def.def, true
+		}
+		if !ok {
+			if strings.HasSuffix(def.typ, "!") {
+				return nil, &Error{Message: fmt.Sprintf("Variable \"$%s\" of required type %q was not provided.", def.name, def.typ)}
+			}
+			continue
+		}
+
+		coerced, err := coerceValue(def.typ, value, nil)
+		if err != nil {
+			return nil, &Error{Message: fmt.Sprintf("Variable \"$%s\" got invalid value; %s", def.name, err)}
+		}
+		vars[def.name] = coerced
+	}
+	return vars, nil
+}
+
+// coerceValue converts an input value to typ, substituting variables from vars
+func coerceValue(typ string, value any, vars map[string]any) (any, error) {
+	nonNull := strings.HasSuffix(typ, "!")
+	base := strings.TrimSuffix(typ, "!")
+
+	if name, ok := value.(variable); ok {
+		// Variables were coerced to their own, compatible, types already
+		value = vars[string(name)]
+		if value == nil && nonNull {
+			return nil, fmt.Errorf("expected a value of non-null type %q", typ)
+		}
+		return value, nil
+	}
+	if value == nil {
+		if nonNull {
+			return nil, fmt.Errorf("expected a value of non-null type %q", typ)
+		}
+		return nil, nil
+	}
+
+	if strings.HasPrefix(base, "[") {
+		elem := base[1 : len(base)-1]
+		items, ok := value.([]any)
+		if !ok {
+			// A single value stands for a list of one
+			items = []any{value}
+		}
+		list := make([]any, len(items))
+		for i, item := range items {
+			coerced, err := coerceValue(elem, item, vars)
+			if err != nil {
+				return nil, err
+			}
+			list[i] = coerced
+		}
+		return list, nil
+	}
+
+	coerced, ok := coerceScalar(base, value)
+	if !ok {
+		return nil, fmt.Errorf("%s cannot represent %s", base, describe(value))
+	}
+	return coerced, nil
+}
+
+// scalars are the input types arguments and variables can have
+var scalars = map[string]bool{"ID": true, "String": true, "Int": true, "Float": true, "Boolean": true}
+
+// knownType reports whether typ is a scalar or a list of known types, nullable or not
+func knownType(typ string) bool {
+	base := strings.TrimSuffix(typ, "!")
+	if strings.HasPrefix(base, "[") && strings.HasSuffix(base, "]") {
+		return knownType(base[1 : len(base)-1])
+	}
+	return scalars[base]
+}
+
+// coerceScalar converts a literal or JSON value to the scalar type name
+func coerceScalar(name string, value any) (any, bool) {
+	if n, ok := value.(json.Number); ok {
+		if i, err := n.Int64(); err == nil {
+			value = int(i)
+		} else if f, err := n.Float64(); err == nil {
+			value = f
+		}
+	}
+
+	switch name {
+	case "ID":
+		switch v := value.(type) {
+		case string:
+			return v, true
+		case int:
+			return strconv.Itoa(v), true
+		case float64:
+			if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+				return strconv.FormatInt(int64(v), 10), true
+			}
+		}
+	case "String":
+		v, ok := value.(string)
+		return v, ok
+	case "Int":
+		switch v := value.(type) {
+		case int:
+			return v, v >= math.MinInt32 && v <= math.MaxInt32
+		case float64:
+			// JSON numbers decode to float64; only whole ones in range are Ints
+			if v == math.Trunc(v) && v >= math.MinInt32 && v <= math.MaxInt32 {
+				return int(v), true
+			}
+		}
+	case "Float":
+		switch v := value.(type) {
+		case int:
+			return float64(v), true
+		case float64:
+			return v, true
+		}
+	case "Boolean":
+		v, ok := value.(bool)
+		return v, ok
+	}
+	return nil, false
+}
+
+func describe(value any) string {
+	switch v := value.(type) {
+	case string:
+		return strconv.Quote(v)
+	case enumValue:
+		return string(v)
+	case []any:
+		return "a list"
+	case map[string]any:
+		return "an object"
+	}
+	return fmt.Sprint(value)
+}
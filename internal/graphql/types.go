@@ -0,0 +1,115 @@
+package graphql
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+var (
+	marshalerType     = reflect.TypeFor[json.Marshaler]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+// indirect strips pointers off t
+func indirect(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+// isList reports whether values of t are returned as lists
+func isList(t reflect.Type) bool {
+	t = indirect(t)
+	return (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) && !isLeaf(t)
+}
+
+// isLeaf reports whether values of t are returned whole, as encoding/json marshals them,
+// rather than selected from: scalars, maps, interfaces and types that marshal themselves
+func isLeaf(t reflect.Type) bool {
+	t = indirect(t)
+	if t.Implements(marshalerType) || reflect.PointerTo(t).Implements(marshalerType) ||
+		t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		return false
+	case reflect.Slice:
+		// []byte marshals as a base64 string
+		return t.Elem().Kind() == reflect.Uint8
+	case reflect.Array:
+		return false
+	}
+	return true
+}
+
+// namedType unwraps lists and pointers down to the type their items have
+func namedType(t reflect.Type) reflect.Type {
+	t = indirect(t)
+	for isList(t) {
+		t = indirect(t.Elem())
+	}
+	return t
+}
+
+// typeName is the GraphQL name of an object type, which fragments are conditioned on
+func typeName(t reflect.Type) string {
+	if t == nil {
+		return "Query"
+	}
+	return namedType(t).Name()
+}
+
+// structField locates a selectable field of an object type
+type structField struct {
+	index []int
+	typ   reflect.Type
+}
+
+var fieldCache sync.Map // reflect.Type -> map[string]structField
+
+// objectFields returns the fields of struct type t by their json names, promoting the
+// fields of untagged embedded structs the way encoding/json does
+func objectFields(t reflect.Type) map[string]structField {
+	if cached, ok := fieldCache.Load(t); ok {
+		return cached.(map[string]structField)
+	}
+
+	fields := make(map[string]structField)
+	var embedded []reflect.StructField
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if sf.Anonymous && name == "" && indirect(sf.Type).Kind() == reflect.Struct {
+			embedded = append(embedded, sf)
+			continue
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		fields[name] = structField{index: sf.Index, typ: sf.Type}
+	}
+
+	// Fields of the outer struct win over promoted ones
+	for _, sf := range embedded {
+		for name, inner := range objectFields(indirect(sf.Type)) {
+			if _, ok := fields[name]; !ok {
+				fields[name] = structField{index: append([]int{sf.Index[0]}, inner.index...), typ: inner.typ}
+			}
+		}
+	}
+
+	fieldCache.Store(t, fields)
+	return fields
+}
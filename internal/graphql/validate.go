@@ -0,0 +1,334 @@
+package graphql
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// validator checks a document against the schema before any of it is executed
+type validator struct {
+	schema *Schema
+	src    string
+	doc    *document
+	errors []*Error
+	seen   map[string]bool // reported errors, as fragments spread twice are checked twice
+
+	usedFragments map[string]bool
+
+	// The operation being checked
+	defs      map[string]variableDef
+	usedVars  map[string]bool
+	spreading []string // fragments being checked, innermost last
+}
+
+func (s *Schema) validate(src string, doc *document) []*Error {
+	v := &validator{
+		schema:        s,
+		src:           src,
+		doc:           doc,
+		seen:          make(map[string]bool),
+		usedFragments: make(map[string]bool),
+	}
+
+	names := make(map[string]bool)
+	for _, op := range doc.operations {
+		if op.name == "" && len(doc.operations) > 1 {
+			v.report(op.pos, "This anonymous operation must be the only defined operation.")
+		}
+		if op.name != "" && names[op.name] {
+			v.report(op.pos, "There can be only one operation named %q.", op.name)
+		}
+		names[op.name] = true
+		v.validateOperation(op)
+	}
+
+	fragments := make([]*fragment, 0, len(doc.fragments))
+	for _, frag := range doc.fragments {
+		fragments = append(fragments, frag)
+	}
+	slices.SortFunc(fragments, func(a, b *fragment) int { return a.pos - b.pos })
+	for _, frag := range fragments {
+		if !v.usedFragments[frag.name] {
+			v.report(frag.pos, "Fragment %q is never used.", frag.name)
+		}
+	}
+	return v.errors
+}
+
+func (v *validator) report(pos int, format string, args ...any) {
+	err := &Error{Message: fmt.Sprintf(format, args...), Locations: []Location{location(v.src, pos)}}
+	key := fmt.Sprint(err.Message, err.Locations)
+	if v.seen[key] {
+		return
+	}
+	v.seen[key] = true
+	v.errors = append(v.errors, err)
+}
+
+func (v *validator) validateOperation(op *operation) {
+	v.defs = make(map[string]variableDef)
+	v.usedVars = make(map[string]bool)
+	for _, def := range op.variables {
+		if _, ok := v.defs[def.name]; ok {
+			v.report(def.pos, "There can be only one variable named \"$%s\".", def.name)
+			continue
+		}
+		v.defs[def.name] = def
+		if !knownType(def.typ) {
+			v.report(def.pos, "Unknown type %q.", strings.Trim(def.typ, "[]!"))
+			continue
+		}
+		if def.hasDefault {
+			if _, err := coerceValue(def.typ, def.def, nil); err != nil {
+				v.report(def.pos, "Variable \"$%s\" has invalid default value: %s.", def.name, err)
+			}
+		}
+	}
+
+	v.validateSelections(op.selections, nil)
+
+	for _, def := range op.variables {
+		if !v.usedVars[def.name] {
+			v.report(def.pos, "Variable \"$%s\" is never used in operation.", def.name)
+		}
+	}
+}
+
+// validateSelections checks a selection set on parent, the type of the field it
+// selects from, or nil at the root
+func (v *validator) validateSelections(selections []selection, parent reflect.Type) {
+	for _, sel := range selections {
+		for _, d := range sel.skipIf() {
+			v.validateArguments("Directive", "@"+d.name, d.pos, d.args, map[string]string{"if": "Boolean!"})
+		}
+
+		switch sel := sel.(type) {
+		case *field:
+			v.validateField(sel, parent)
+		case *fragmentSpread:
+			frag, ok := v.doc.fragments[sel.name]
+			if !ok {
+				v.report(sel.pos, "Unknown fragment %q.", sel.name)
+				continue
+			}
+			v.usedFragments[sel.name] = true
+			if slices.Contains(v.spreading, sel.name) {
+				v.report(sel.pos, "Cannot spread fragment %q within itself.", sel.name)
+				continue
+			}
+			v.checkCondition(frag.typeCondition, parent, sel.pos)
+
+			v.spreading = append(v.spreading, sel.name)
+			v.validateSelections(frag.selections, parent)
+			v.spreading = v.spreading[:len(v.spreading)-1]
+		case *inlineFragment:
+			if sel.typeCondition != "" {
+				v.checkCondition(sel.typeCondition, parent, sel.pos)
+			}
+			v.validateSelections(sel.selections, parent)
+		}
+	}
+
+	v.checkConflicts(selections)
+}
+
+// checkCondition reports a fragment whose type condition can't match parent; with no
+// interfaces or unions in the schema, only the type's own name does
+func (v *validator) checkCondition(condition string, parent reflect.Type, pos int) {
+	if name := typeName(parent); condition != name {
+		v.report(pos, "Fragment cannot be spread here as objects of type %q can never be of type %q.", name, condition)
+	}
+}
+
+func (v *validator) validateField(f *field, parent reflect.Type) {
+	if f.name == "__typename" {
+		v.validateArguments("Field", f.name, f.pos, f.args, nil)
+		if len(f.selections) > 0 {
+			v.report(f.pos, "Field %q must not have a selection since type \"String\" has no subfields.", f.name)
+		}
+		return
+	}
+
+	var (
+		args map[string]string
+		typ  reflect.Type
+	)
+	if parent == nil {
+		root, ok := v.schema.Query[f.name]
+		if !ok {
+			v.report(f.pos, "Cannot query field %q on type \"Query\".", f.name)
+			return
+		}
+		args, typ = root.Args, root.Type
+	} else {
+		sf, ok := objectFields(namedType(parent))[f.name]
+		if !ok {
+			v.report(f.pos, "Cannot query field %q on type %q.", f.name, typeName(parent))
+			return
+		}
+		typ = sf.typ
+	}
+	v.validateArguments("Field", f.name, f.pos, f.args, args)
+
+	named := namedType(typ)
+	switch {
+	case isLeaf(named) && len(f.selections) > 0:
+		v.report(f.pos, "Field %q must not have a selection since type %q has no subfields.", f.name, leafName(named))
+	case !isLeaf(named) && len(f.selections) == 0:
+		v.report(f.pos, "Field %q of type %q must have a selection of subfields.", f.name, named.Name())
+	case !isLeaf(named):
+		v.validateSelections(f.selections, typ)
+	}
+}
+
+func leafName(t reflect.Type) string {
+	if t.Name() != "" {
+		return t.Name()
+	}
+	return t.String()
+}
+
+// validateArguments checks the arguments given to a field or directive at pos against
+// the types it takes
+func (v *validator) validateArguments(kind, name string, pos int, args []argument, defs map[string]string) {
+	given := make(map[string]bool, len(args))
+	for _, arg := range args {
+		given[arg.name] = true
+		typ, ok := defs[arg.name]
+		if !ok {
+			v.report(arg.pos, "Unknown argument %q on %s %q.", arg.name, strings.ToLower(kind), name)
+			continue
+		}
+		v.validateValue(typ, arg.value, arg.pos)
+	}
+
+	required := make([]string, 0, len(defs))
+	for arg, typ := range defs {
+		if strings.HasSuffix(typ, "!") && !given[arg] {
+			required = append(required, arg)
+		}
+	}
+	slices.Sort(required)
+	for _, arg := range required {
+		v.report(pos, "%s %q argument %q of type %q is required, but it was not provided.", kind, name, arg, defs[arg])
+	}
+}
+
+func (v *validator) validateValue(typ string, value any, pos int) {
+	if name, ok := value.(variable); ok {
+		def, ok := v.defs[string(name)]
+		if !ok {
+			v.report(pos, "Variable \"$%s\" is not defined.", name)
+			return
+		}
+		v.usedVars[def.name] = true
+
+		// A nullable variable may fill a non-null position when it defaults to a value
+		varType := def.typ
+		if def.hasDefault && def.def != nil && !strings.HasSuffix(varType, "!") {
+			varType += "!"
+		}
+		if knownType(def.typ) && !compatible(varType, typ) {
+			v.report(pos, "Variable \"$%s\" of type %q used in position expecting type %q.", def.name, def.typ, typ)
+		}
+		return
+	}
+
+	nonNull := strings.HasSuffix(typ, "!")
+	base := strings.TrimSuffix(typ, "!")
+	if value == nil {
+		if nonNull {
+			v.report(pos, "Expected value of type %q, found null.", typ)
+		}
+		return
+	}
+	if strings.HasPrefix(base, "[") {
+		items, ok := value.([]any)
+		if !ok {
+			items = []any{value}
+		}
+		for _, item := range items {
+			v.validateValue(base[1:len(base)-1], item, pos)
+		}
+		return
+	}
+	if _, ok := coerceScalar(base, value); !ok {
+		v.report(pos, "%s cannot represent %s.", base, describe(value))
+	}
+}
+
+// compatible reports whether a variable of type varType can be used where locType is expected
+func compatible(varType, locType string) bool {
+	if strings.HasSuffix(locType, "!") {
+		if !strings.HasSuffix(varType, "!") {
+			return false
+		}
+		return compatible(strings.TrimSuffix(varType, "!"), strings.TrimSuffix(locType, "!"))
+	}
+	varType = strings.TrimSuffix(varType, "!")
+
+	varList := strings.HasPrefix(varType, "[")
+	locList := strings.HasPrefix(locType, "[")
+	if varList != locList {
+		return false
+	}
+	if locList {
+		return compatible(varType[1:len(varType)-1], locType[1:len(locType)-1])
+	}
+	return varType == locType
+}
+
+// checkConflicts reports two fields returned under the same key that are different
+// fields, or the same field with different arguments
+func (v *validator) checkConflicts(selections []selection) {
+	byKey := make(map[string]*field)
+	for _, f := range v.flatten(selections, make(map[string]bool)) {
+		key := f.responseKey()
+		first, ok := byKey[key]
+		if !ok {
+			byKey[key] = f
+			continue
+		}
+		if first.name != f.name {
+			v.report(f.pos, "Fields %q conflict because %q and %q are different fields. Use different aliases on the fields to fetch both if this was intentional.", key, first.name, f.name)
+		} else if !sameArguments(first.args, f.args) {
+			v.report(f.pos, "Fields %q conflict because they have differing arguments. Use different aliases on the fields to fetch both if this was intentional.", key)
+		}
+	}
+}
+
+// flatten lists the fields a selection set selects, through its fragments
+func (v *validator) flatten(selections []selection, visited map[string]bool) []*field {
+	var fields []*field
+	for _, sel := range selections {
+		switch sel := sel.(type) {
+		case *field:
+			fields = append(fields, sel)
+		case *fragmentSpread:
+			frag, ok := v.doc.fragments[sel.name]
+			if !ok || visited[sel.name] {
+				continue
+			}
+			visited[sel.name] = true
+			fields = append(fields, v.flatten(frag.selections, visited)...)
+		case *inlineFragment:
+			fields = append(fields, v.flatten(sel.selections, visited)...)
+		}
+	}
+	return fields
+}
+
+func sameArguments(a, b []argument) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, x := range a {
+		i := slices.IndexFunc(b, func(y argument) bool { return y.name == x.name })
+		if i < 0 || !reflect.DeepEqual(x.value, b[i].value) {
+			return false
+		}
+	}
+	return true
+}
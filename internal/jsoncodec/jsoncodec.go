@@ -0,0 +1,88 @@
+// Package jsoncodec chooses the JSON library gin renders responses and binds requests with.
+// Large product listings spend most of their time encoding, which goccy/go-json does faster
+// than encoding/json with the same output; BenchmarkCodecs_ProductListing compares them.
+package jsoncodec
+
+import (
+	stdjson "encoding/json"
+	"fmt"
+	"io"
+
+	ginjson "github.com/gin-gonic/gin/codec/json"
+	gojson "github.com/goccy/go-json"
+)
+
+const (
+	Std    = "std"     // encoding/json
+	GoJSON = "go-json" // github.com/goccy/go-json
+)
+
+// New returns the codec named name
+func New(name string) (ginjson.Core, error) {
+	switch name {
+	case Std:
+		return stdCodec{}, nil
+	case GoJSON:
+		return goJSONCodec{}, nil
+	default:
+		return nil, fmt.Errorf("unknown JSON codec %q, want %s or %s", name, Std, GoJSON)
+	}
+}
+
+// Use makes gin use the codec named name. An empty name keeps the one gin was built with:
+// encoding/json, or the library picked by gin's go_json, jsoniter or sonic build tags.
+func Use(name string) error {
+	if name == "" {
+		return nil
+	}
+	codec, err := New(name)
+	if err != nil {
+		return err
+	}
+	ginjson.API = codec
+	return nil
+}
+
+type stdCodec struct{}
+
+func (stdCodec) Marshal(v any) ([]byte, error) {
+	return stdjson.Marshal(v)
+}
+
+func (stdCodec) Unmarshal(data []byte, v any) error {
+	return stdjson.Unmarshal(data, v)
+}
+
+func (stdCodec) MarshalIndent(v any, prefix, indent string) ([]byte, error) {
+	return stdjson.MarshalIndent(v, prefix, indent)
+}
+
+func (stdCodec) NewEncoder(writer io.Writer) ginjson.Encoder {
+	return stdjson.NewEncoder(writer)
+}
+
+func (stdCodec) NewDecoder(reader io.Reader) ginjson.Decoder {
+	return stdjson.NewDecoder(reader)
+}
+
+type goJSONCodec struct{}
+
+func (goJSONCodec) Marshal(v any) ([]byte, error) {
+	return gojson.Marshal(v)
+}
+
+func (goJSONCodec) Unmarshal(data []byte, v any) error {
+	return gojson.Unmarshal(data, v)
+}
+
+func (goJSONCodec) MarshalIndent(v any, prefix, indent string) ([]byte, error) {
+	return gojson.MarshalIndent(v, prefix, indent)
+}
+
+func (goJSONCodec) NewEncoder(writer io.Writer) ginjson.Encoder {
+	return gojson.NewEncoder(writer)
+}
+
+func (goJSONCodec) NewDecoder(reader io.Reader) ginjson.Decoder {
+	return gojson.NewDecoder(reader)
+}
@@ -0,0 +1,107 @@
+package loadtest
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+)
+
+//go:embed budget.json
+var defaultBudget []byte
+
+// Budget holds the worst each operation may do before a run counts as a regression. Limits
+// left at zero aren't checked.
+type Budget struct {
+	Operations map[string]OperationBudget `json:"operations"`
+}
+
+// OperationBudget limits one operation. Latencies are in milliseconds.
+type OperationBudget struct {
+	P50          float64 `json:"p50Ms,omitempty"`
+	P95          float64 `json:"p95Ms,omitempty"`
+	P99          float64 `json:"p99Ms,omitempty"`
+	MaxErrorRate float64 `json:"maxErrorRate,omitempty"` // Fraction of requests, 0.01 is 1%
+	MinRequests  int     `json:"minRequests,omitempty"`
+}
+
+// DefaultBudget returns the budget kept with the code, sized for the docker-compose
+// environment on a developer machine
+func DefaultBudget() (*Budget, error) {
+	return parseBudget(defaultBudget)
+}
+
+// LoadBudget reads a budget from a JSON file like budget.json
+func LoadBudget(path string) (*Budget, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	budget, err := parseBudget(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return budget, nil
+}
+
+func parseBudget(data []byte) (*Budget, error) {
+	var budget Budget
+	if err := json.Unmarshal(data, &budget); err != nil {
+		return nil, err
+	}
+	for name := range budget.Operations {
+		if name != ListProducts && name != GetProduct && name != PlaceOrder {
+			return nil, fmt.Errorf("unknown operation %q in budget", name)
+		}
+	}
+	return &budget, nil
+}
+
+// Violation is a limit of the budget a run went over
+type Violation struct {
+	Operation string
+	Metric    string
+	Limit     float64
+	Actual    float64
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s %s is %g, budget is %g", v.Operation, v.Metric, v.Actual, v.Limit)
+}
+
+// Check returns the limits report went over, sorted by operation. Operations with a budget
+// that were sent no traffic are only reported when they have a minimum number of requests.
+func (b *Budget) Check(report *Report) []Violation {
+	names := make([]string, 0, len(b.Operations))
+	for name := range b.Operations {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var violations []Violation
+	for _, name := range names {
+		limits := b.Operations[name]
+		op, _ := report.Operation(name)
+		if op.Requests < limits.MinRequests {
+			violations = append(violations, Violation{name, "requests", float64(limits.MinRequests), float64(op.Requests)})
+		}
+		if op.Requests == 0 {
+			continue
+		}
+		for _, check := range []struct {
+			metric        string
+			limit, actual float64
+		}{
+			{"p50 ms", limits.P50, op.P50},
+			{"p95 ms", limits.P95, op.P95},
+			{"p99 ms", limits.P99, op.P99},
+			{"error rate", limits.MaxErrorRate, op.ErrorRate},
+		} {
+			if check.limit > 0 && check.actual > check.limit {
+				violations = append(violations, Violation{name, check.metric, check.limit, check.actual})
+			}
+		}
+	}
+	return violations
+}
@@ -0,0 +1,287 @@
+// Package loadtest drives product and order traffic against a running API, such as the
+// docker-compose environment, and reports latency percentiles and error rates per operation.
+// Reports are checked against a Budget so performance regressions fail the run.
+package loadtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Operations the load test performs
+const (
+	ListProducts = "list_products" // GET /api/v1/product
+	GetProduct   = "get_product"   // GET /api/v1/product/:productId
+	PlaceOrder   = "place_order"   // POST /api/v1/order
+)
+
+// Mix weighs how often each operation is picked; customers browse far more than they order
+type Mix struct {
+	List  int
+	Get   int
+	Order int
+}
+
+// DefaultMix is the traffic mix used when none is given
+var DefaultMix = Mix{List: 3, Get: 5, Order: 2}
+
+// ParseMix reads a mix written as "list=3,get=5,order=2"; operations left out get no traffic
+func ParseMix(value string) (Mix, error) {
+	var mix Mix
+	for _, part := range strings.Split(value, ",") {
+		name, weight, ok := strings.Cut(strings.TrimSpace(part), "=")
+		n, err := strconv.Atoi(weight)
+		if !ok || err != nil || n < 0 {
+			return Mix{}, fmt.Errorf("invalid mix entry %q, want name=weight", part)
+		}
+		switch name {
+		case "list":
+			mix.List = n
+		case "get":
+			mix.Get = n
+		case "order":
+			mix.Order = n
+		default:
+			return Mix{}, fmt.Errorf("unknown operation %q in mix, want list, get or order", name)
+		}
+	}
+	if mix.List+mix.Get+mix.Order == 0 {
+		return Mix{}, fmt.Errorf("mix %q has no traffic", value)
+	}
+	return mix, nil
+}
+
+func (m Mix) pick(rng *rand.Rand) string {
+	n := rng.IntN(m.List + m.Get + m.Order)
+	switch {
+	case n < m.List:
+		return ListProducts
+	case n < m.List+m.Get:
+		return GetProduct
+	default:
+		return PlaceOrder
+	}
+}
+
+// Options configure a load test run
+type Options struct {
+	BaseURL     string        // Where the API listens, e.g. http://localhost:8080
+	APIKey      string        // Sent as X-API-Key
+	Duration    time.Duration // How long traffic is sent for
+	Concurrency int           // Virtual customers sending requests back to back
+	Mix         Mix
+	CouponCode  string        // Applied to every order when set
+	MaxItems    int           // Orders get 1 to MaxItems distinct products
+	WaitTimeout time.Duration // How long to wait for /health to answer before starting
+	Client      *http.Client
+}
+
+func (o *Options) setDefaults() error {
+	if o.BaseURL == "" {
+		return fmt.Errorf("a base URL is required")
+	}
+	o.BaseURL = strings.TrimRight(o.BaseURL, "/")
+	if o.Duration <= 0 {
+		o.Duration = 30 * time.Second
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = 10
+	}
+	if o.Mix == (Mix{}) {
+		o.Mix = DefaultMix
+	}
+	if o.MaxItems <= 0 {
+		o.MaxItems = 3
+	}
+	if o.Client == nil {
+		o.Client = &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: &http.Transport{MaxIdleConnsPerHost: o.Concurrency},
+		}
+	}
+	return nil
+}
+
+// Run waits for the API to be healthy, loads the catalogue, then sends the traffic mix from
+// opts.Concurrency customers for opts.Duration and reports what it measured. Requests still
+// in flight when the time is up are not counted.
+func Run(ctx context.Context, opts Options) (*Report, error) {
+	if err := opts.setDefaults(); err != nil {
+		return nil, err
+	}
+	r := &runner{opts: opts, recorder: newRecorder()}
+
+	if err := r.waitHealthy(ctx); err != nil {
+		return nil, err
+	}
+	productIDs, err := r.productIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(productIDs) == 0 {
+		return nil, fmt.Errorf("the catalogue is empty, seed it before load testing")
+	}
+	r.products = productIDs
+
+	runCtx, cancel := context.WithTimeout(ctx, opts.Duration)
+	defer cancel()
+
+	started := time.Now()
+	var wg sync.WaitGroup
+	for i := range opts.Concurrency {
+		wg.Add(1)
+		go func(seed uint64) {
+			defer wg.Done()
+			rng := rand.New(rand.NewPCG(uint64(started.UnixNano()), seed))
+			for runCtx.Err() == nil {
+				r.send(runCtx, rng)
+			}
+		}(uint64(i))
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return r.recorder.report(time.Since(started)), nil
+}
+
+type runner struct {
+	opts     Options
+	recorder *recorder
+	products []string
+}
+
+func (r *runner) waitHealthy(ctx context.Context) error {
+	deadline := time.Now().Add(r.opts.WaitTimeout)
+	for {
+		status, err := r.do(ctx, http.MethodGet, "/health", nil)
+		if err == nil && status == http.StatusOK {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			if err == nil {
+				err = fmt.Errorf("status %d", status)
+			}
+			return fmt.Errorf("%s is not healthy: %w", r.opts.BaseURL, err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func (r *runner) productIDs(ctx context.Context) ([]string, error) {
+	req, err := r.newRequest(ctx, http.MethodGet, "/api/v1/product", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := r.opts.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("listing products: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("listing products: status %d", resp.StatusCode)
+	}
+
+	var products []struct {
+		ID string `json:"id"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&products); err != nil {
+		return nil, fmt.Errorf("listing products: %w", err)
+	}
+	ids := make([]string, 0, len(products))
+	for _, product := range products {
+		ids = append(ids, product.ID)
+	}
+	return ids, nil
+}
+
+// send performs one operation of the mix and records it
+func (r *runner) send(ctx context.Context, rng *rand.Rand) {
+	operation := r.opts.Mix.pick(rng)
+
+	var (
+		method = http.MethodGet
+		path   = "/api/v1/product"
+		body   any
+	)
+	switch operation {
+	case GetProduct:
+		path += "/" + r.products[rng.IntN(len(r.products))]
+	case PlaceOrder:
+		method, path, body = http.MethodPost, "/api/v1/order", r.order(rng)
+	}
+
+	start := time.Now()
+	status, err := r.do(ctx, method, path, body)
+	elapsed := time.Since(start)
+	if ctx.Err() != nil {
+		return
+	}
+	r.recorder.record(operation, elapsed, status, err)
+}
+
+func (r *runner) order(rng *rand.Rand) map[string]any {
+	count := 1 + rng.IntN(min(r.opts.MaxItems, len(r.products)))
+	items := make([]map[string]any, 0, count)
+	for _, i := range rng.Perm(len(r.products))[:count] {
+		items = append(items, map[string]any{"productId": r.products[i], "quantity": 1 + rng.IntN(3)})
+	}
+	order := map[string]any{"items": items}
+	if r.opts.CouponCode != "" {
+		order["couponCode"] = r.opts.CouponCode
+	}
+	return order
+}
+
+func (r *runner) newRequest(ctx context.Context, method, path string, body any) (*http.Request, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, r.opts.BaseURL+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if r.opts.APIKey != "" {
+		req.Header.Set("X-API-Key", r.opts.APIKey)
+	}
+	return req, nil
+}
+
+// do sends a request and reads the whole response, so the connection is reused
+func (r *runner) do(ctx context.Context, method, path string, body any) (int, error) {
+	req, err := r.newRequest(ctx, method, path, body)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := r.opts.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if _, err := io.Copy(io.Discard, resp.Body); err != nil {
+		return resp.StatusCode, err
+	}
+	return resp.StatusCode, nil
+}
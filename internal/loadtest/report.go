@@ -0,0 +1,128 @@
+package loadtest
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// Report is what a load test run measured
+type Report struct {
+	Duration   time.Duration     `json:"-"`
+	Seconds    float64           `json:"seconds"`
+	Operations []OperationReport `json:"operations"` // Sorted by name
+}
+
+// OperationReport sums up the requests of one operation. Latencies are in milliseconds.
+type OperationReport struct {
+	Name      string  `json:"name"`
+	Requests  int     `json:"requests"`
+	Errors    int     `json:"errors"`    // Transport errors and responses other than 2xx
+	Throttled int     `json:"throttled"` // 429 responses, also counted as errors
+	ErrorRate float64 `json:"errorRate"`
+	RPS       float64 `json:"rps"`
+	P50       float64 `json:"p50Ms"`
+	P95       float64 `json:"p95Ms"`
+	P99       float64 `json:"p99Ms"`
+	Max       float64 `json:"maxMs"`
+}
+
+// Operation returns the report of the named operation, false when it wasn't performed
+func (r *Report) Operation(name string) (OperationReport, bool) {
+	for _, operation := range r.Operations {
+		if operation.Name == name {
+			return operation, true
+		}
+	}
+	return OperationReport{}, false
+}
+
+// WriteTable writes the report as an aligned table
+func (r *Report) WriteTable(w io.Writer) error {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(table, "operation\trequests\trps\terrors\t429s\terror rate\tp50 ms\tp95 ms\tp99 ms\tmax ms\t")
+	for _, op := range r.Operations {
+		fmt.Fprintf(table, "%s\t%d\t%.1f\t%d\t%d\t%.2f%%\t%.1f\t%.1f\t%.1f\t%.1f\t\n",
+			op.Name, op.Requests, op.RPS, op.Errors, op.Throttled, op.ErrorRate*100, op.P50, op.P95, op.P99, op.Max)
+	}
+	fmt.Fprintf(table, "\nran for %s\n", r.Duration.Round(time.Millisecond))
+	return table.Flush()
+}
+
+// recorder collects the latency and outcome of every request
+type recorder struct {
+	mutex      sync.Mutex
+	operations map[string]*operationSamples
+}
+
+type operationSamples struct {
+	latencies []time.Duration
+	errors    int
+	throttled int
+}
+
+func newRecorder() *recorder {
+	return &recorder{operations: make(map[string]*operationSamples)}
+}
+
+func (r *recorder) record(operation string, latency time.Duration, status int, err error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	samples, ok := r.operations[operation]
+	if !ok {
+		samples = &operationSamples{}
+		r.operations[operation] = samples
+	}
+	samples.latencies = append(samples.latencies, latency)
+	if err != nil || status < 200 || status > 299 {
+		samples.errors++
+	}
+	if status == http.StatusTooManyRequests {
+		samples.throttled++
+	}
+}
+
+func (r *recorder) report(elapsed time.Duration) *Report {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	report := &Report{Duration: elapsed, Seconds: elapsed.Seconds()}
+	for name, samples := range r.operations {
+		latencies := slices.Clone(samples.latencies)
+		slices.Sort(latencies)
+		requests := len(latencies)
+		report.Operations = append(report.Operations, OperationReport{
+			Name:      name,
+			Requests:  requests,
+			Errors:    samples.errors,
+			Throttled: samples.throttled,
+			ErrorRate: float64(samples.errors) / float64(requests),
+			RPS:       float64(requests) / elapsed.Seconds(),
+			P50:       milliseconds(percentile(latencies, 0.50)),
+			P95:       milliseconds(percentile(latencies, 0.95)),
+			P99:       milliseconds(percentile(latencies, 0.99)),
+			Max:       milliseconds(latencies[requests-1]),
+		})
+	}
+	slices.SortFunc(report.Operations, func(a, b OperationReport) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return report
+}
+
+// percentile picks the nearest-rank percentile p of sorted latencies
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	return sorted[max(rank, 0)]
+}
+
+func milliseconds(d time.Duration) float64 {
+	return math.Round(float64(d)/float64(time.Microsecond)) / 1000
+}
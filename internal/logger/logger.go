@@ -0,0 +1,67 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+
+	"oolio/internal/config"
+)
+
+// NewLevel creates the shared atomic level so it can be changed at runtime
+func NewLevel(cfg *config.Config) (zap.AtomicLevel, error) {
+	level, err := zap.ParseAtomicLevel(cfg.Log.Level)
+	if err != nil {
+		return zap.AtomicLevel{}, fmt.Errorf("invalid log level %q: %w", cfg.Log.Level, err)
+	}
+	return level, nil
+}
+
+func NewLogger(level zap.AtomicLevel) (*zap.Logger, error) {
+	zapCfg := zap.NewDevelopmentConfig()
+	zapCfg.Level = level
+	return zapCfg.Build()
+}
+
+// NewSampledLogger wraps logger with a sampler for high-volume streams such as access logs
+func NewSampledLogger(logger *zap.Logger, initial, thereafter int) *zap.Logger {
+	if initial <= 0 {
+		return logger
+	}
+
+	return logger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return zapcore.NewSamplerWithOptions(core, time.Second, initial, thereafter)
+	}))
+}
+
+// NewAccessLogger builds the access log stream. When a file is configured, entries are
+// also written as JSON to a rotating file; the returned closer (nil otherwise) closes it.
+func NewAccessLogger(cfg config.LogConfig, logger *zap.Logger) (*zap.Logger, io.Closer) {
+	accessLogger := logger.Named("access")
+	var closer io.Closer
+
+	if cfg.AccessLogFile != "" {
+		rotator := &lumberjack.Logger{
+			Filename:   cfg.AccessLogFile,
+			MaxSize:    cfg.FileMaxSizeMB,
+			MaxBackups: cfg.FileMaxBackups,
+			MaxAge:     cfg.FileMaxAgeDays,
+			Compress:   cfg.FileCompress,
+		}
+
+		encoderCfg := zap.NewProductionEncoderConfig()
+		encoderCfg.EncodeTime = zapcore.ISO8601TimeEncoder
+		fileCore := zapcore.NewCore(zapcore.NewJSONEncoder(encoderCfg), zapcore.AddSync(rotator), zapcore.InfoLevel)
+
+		accessLogger = accessLogger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return zapcore.NewTee(core, fileCore)
+		}))
+		closer = rotator
+	}
+
+	return NewSampledLogger(accessLogger, cfg.SampleInitial, cfg.SampleThereafter), closer
+}
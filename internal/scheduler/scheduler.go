@@ -0,0 +1,228 @@
+// Package scheduler runs the server's periodic jobs, such as refreshing coupons or sending
+// the sales digest, on cron-style schedules. A run takes the job's lock in a Store shared
+// by the instances, so each run happens on one of them, and its outcome is kept there for
+// the status endpoint.
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Job is a task run on a schedule
+type Job struct {
+	Name     string
+	Schedule Schedule
+	// RunAtStart also runs the job when the scheduler starts, before it is first due
+	RunAtStart bool
+	// Local jobs run on every instance, e.g. to refresh data each one holds in memory;
+	// other jobs run on whichever instance takes the lock first
+	Local bool
+	Run   func(ctx context.Context) error
+}
+
+// Status is what the scheduler knows of a job
+type Status struct {
+	Name      string     `json:"name" example:"sales-digest"`
+	Schedule  string     `json:"schedule" example:"0 7 * * * (Australia/Sydney)"`
+	Local     bool       `json:"local" description:"Runs on every instance rather than on one of them"`
+	Running   bool       `json:"running" description:"Running on the instance that answered"`
+	NextRunAt *time.Time `json:"nextRunAt,omitempty" description:"Absent when the job is disabled"`
+	LastRun   *Run       `json:"lastRun,omitempty" description:"The last run on any instance; absent until the job has run"`
+}
+
+// Options tune the scheduler. LockTTL bounds every run: the run is canceled when it has
+// held its lock that long, before another instance could take it.
+type Options struct {
+	Instance string
+	LockTTL  time.Duration
+	// Schedules replace the schedules of the jobs they name
+	Schedules map[string]Schedule
+}
+
+type Scheduler struct {
+	jobs   []Job
+	store  Store
+	opts   Options
+	logger *zap.Logger
+
+	mutex   sync.Mutex
+	next    map[string]time.Time
+	running map[string]bool
+}
+
+// New schedules jobs, which must have unique names and a schedule
+func New(store Store, opts Options, logger *zap.Logger, jobs ...Job) (*Scheduler, error) {
+	if opts.LockTTL <= 0 {
+		opts.LockTTL = 15 * time.Minute
+	}
+
+	jobs = slices.Clone(jobs)
+	names := make(map[string]bool, len(jobs))
+	for i, job := range jobs {
+		switch {
+		case job.Name == "":
+			return nil, fmt.Errorf("a job has no name")
+		case names[job.Name]:
+			return nil, fmt.Errorf("job %s is registered twice", job.Name)
+		}
+		names[job.Name] = true
+		if schedule, ok := opts.Schedules[job.Name]; ok {
+			jobs[i].Schedule = schedule
+		} else if job.Schedule == nil {
+			return nil, fmt.Errorf("job %s has no schedule", job.Name)
+		}
+	}
+	for name := range opts.Schedules {
+		if !names[name] {
+			return nil, fmt.Errorf("a schedule is set for job %s, which doesn't exist", name)
+		}
+	}
+	slices.SortFunc(jobs, func(a, b Job) int { return strings.Compare(a.Name, b.Name) })
+
+	return &Scheduler{
+		jobs:    jobs,
+		store:   store,
+		opts:    opts,
+		logger:  logger,
+		next:    make(map[string]time.Time),
+		running: make(map[string]bool),
+	}, nil
+}
+
+// Start runs every job on its schedule until ctx is done, and returns once none is running
+func (s *Scheduler) Start(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, job := range s.jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.loop(ctx, job)
+		}()
+	}
+	wg.Wait()
+}
+
+func (s *Scheduler) loop(ctx context.Context, job Job) {
+	if job.RunAtStart {
+		s.run(ctx, job)
+	}
+	for {
+		next := job.Schedule.Next(time.Now())
+		s.mutex.Lock()
+		s.next[job.Name] = next
+		s.mutex.Unlock()
+		if next.IsZero() {
+			return
+		}
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		s.run(ctx, job)
+	}
+}
+
+// run runs the job once, unless it is running on another instance, and records the run
+func (s *Scheduler) run(ctx context.Context, job Job) {
+	logger := s.logger.With(zap.String("job", job.Name))
+	if !job.Local {
+		unlock, ok, err := s.store.Lock(ctx, job.Name, s.opts.LockTTL)
+		if err != nil {
+			logger.Error("Failed to lock job", zap.Error(err))
+			return
+		}
+		if !ok {
+			logger.Debug("Job is running on another instance")
+			return
+		}
+		defer func() {
+			if err := unlock(context.WithoutCancel(ctx)); err != nil {
+				logger.Warn("Failed to unlock job", zap.Error(err))
+			}
+		}()
+	}
+
+	s.setRunning(job.Name, true)
+	defer s.setRunning(job.Name, false)
+
+	runCtx, cancel := context.WithTimeout(ctx, s.opts.LockTTL)
+	defer cancel()
+	run := Run{Instance: s.opts.Instance, StartedAt: time.Now()}
+	stack, err := call(runCtx, job.Run)
+	run.FinishedAt = time.Now()
+	run.Duration = run.FinishedAt.Sub(run.StartedAt).String()
+	run.Succeeded = err == nil
+	if err != nil {
+		run.Error = err.Error()
+		fields := []zap.Field{zap.Duration("duration", run.FinishedAt.Sub(run.StartedAt)), zap.Error(err)}
+		if stack != nil {
+			fields = append(fields, zap.ByteString("stack", stack))
+		}
+		logger.Error("Job failed", fields...)
+	} else {
+		logger.Info("Job finished", zap.Duration("duration", run.FinishedAt.Sub(run.StartedAt)))
+	}
+
+	// The run is recorded even when shutdown interrupted it
+	saveCtx, cancelSave := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancelSave()
+	if err := s.store.SaveRun(saveCtx, job.Name, run); err != nil {
+		logger.Warn("Failed to record job run", zap.Error(err))
+	}
+}
+
+// call runs the job, turning a panic into an error, with the stack it panicked at, so one
+// bad run doesn't stop the job
+func call(ctx context.Context, run func(ctx context.Context) error) (stack []byte, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			stack, err = debug.Stack(), fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return nil, run(ctx)
+}
+
+func (s *Scheduler) setRunning(job string, running bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.running[job] = running
+}
+
+// Status returns the status of every job, sorted by name
+func (s *Scheduler) Status(ctx context.Context) ([]Status, error) {
+	runs, err := s.store.LastRuns(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	statuses := make([]Status, len(s.jobs))
+	for i, job := range s.jobs {
+		statuses[i] = Status{
+			Name:     job.Name,
+			Schedule: job.Schedule.String(),
+			Local:    job.Local,
+			Running:  s.running[job.Name],
+		}
+		if next, ok := s.next[job.Name]; ok && !next.IsZero() {
+			statuses[i].NextRunAt = &next
+		}
+		if run, ok := runs[job.Name]; ok {
+			statuses[i].LastRun = &run
+		}
+	}
+	return statuses, nil
+}
@@ -0,0 +1,151 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+)
+
+// Run is the outcome of a run of a job
+type Run struct {
+	Instance   string    `json:"instance" description:"The instance that ran the job"`
+	StartedAt  time.Time `json:"startedAt"`
+	FinishedAt time.Time `json:"finishedAt"`
+	Duration   string    `json:"duration" example:"1.204s"`
+	Succeeded  bool      `json:"succeeded"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// Store holds the job locks and the last run of every job, shared by the instances
+type Store interface {
+	// Lock takes the job's lock for ttl and reports false when a run elsewhere holds it.
+	// unlock releases it early, unless it has expired and been taken since.
+	Lock(ctx context.Context, job string, ttl time.Duration) (unlock func(ctx context.Context) error, ok bool, err error)
+	SaveRun(ctx context.Context, job string, run Run) error
+	// LastRuns returns the last run of each job that has run, by job name
+	LastRuns(ctx context.Context) (map[string]Run, error)
+}
+
+const (
+	lockKeyPrefix = "scheduler:lock:"
+	runsKey       = "scheduler:runs"
+)
+
+// The lock is only deleted by the run holding it, named by its token
+var unlockScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+type redisStore struct {
+	client redis.UniversalClient
+}
+
+// NewRedisStore keeps the locks and runs in Redis, so instances sharing it run each job
+// once between them
+func NewRedisStore(client redis.UniversalClient) Store {
+	return &redisStore{client: client}
+}
+
+func (s *redisStore) Lock(ctx context.Context, job string, ttl time.Duration) (func(ctx context.Context) error, bool, error) {
+	key, token := lockKeyPrefix+job, uuid.NewString()
+	ok, err := s.client.SetNX(ctx, key, token, ttl).Result()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to lock job %s: %w", job, err)
+	}
+	if !ok {
+		return nil, false, nil
+	}
+	return func(ctx context.Context) error {
+		if err := unlockScript.Run(ctx, s.client, []string{key}, token).Err(); err != nil {
+			return fmt.Errorf("failed to unlock job %s: %w", job, err)
+		}
+		return nil
+	}, true, nil
+}
+
+func (s *redisStore) SaveRun(ctx context.Context, job string, run Run) error {
+	data, err := json.Marshal(run)
+	if err != nil {
+		return fmt.Errorf("failed to encode run of job %s: %w", job, err)
+	}
+	if err := s.client.HSet(ctx, runsKey, job, data).Err(); err != nil {
+		return fmt.Errorf("failed to save run of job %s: %w", job, err)
+	}
+	return nil
+}
+
+func (s *redisStore) LastRuns(ctx context.Context) (map[string]Run, error) {
+	values, err := s.client.HGetAll(ctx, runsKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read job runs: %w", err)
+	}
+	runs := make(map[string]Run, len(values))
+	for job, value := range values {
+		var run Run
+		if err := json.Unmarshal([]byte(value), &run); err != nil {
+			return nil, fmt.Errorf("failed to decode run of job %s: %w", job, err)
+		}
+		runs[job] = run
+	}
+	return runs, nil
+}
+
+type memoryLock struct {
+	token   string
+	expires time.Time
+}
+
+type memoryStore struct {
+	mutex sync.Mutex
+	locks map[string]memoryLock
+	runs  map[string]Run
+}
+
+// NewMemoryStore keeps the locks and runs in this process, for a single instance
+func NewMemoryStore() Store {
+	return &memoryStore{locks: make(map[string]memoryLock), runs: make(map[string]Run)}
+}
+
+func (s *memoryStore) Lock(ctx context.Context, job string, ttl time.Duration) (func(ctx context.Context) error, bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if held, ok := s.locks[job]; ok && time.Now().Before(held.expires) {
+		return nil, false, nil
+	}
+	token := uuid.NewString()
+	s.locks[job] = memoryLock{token: token, expires: time.Now().Add(ttl)}
+	return func(ctx context.Context) error {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		if s.locks[job].token == token {
+			delete(s.locks, job)
+		}
+		return nil
+	}, true, nil
+}
+
+func (s *memoryStore) SaveRun(ctx context.Context, job string, run Run) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.runs[job] = run
+	return nil
+}
+
+func (s *memoryStore) LastRuns(ctx context.Context) (map[string]Run, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	runs := make(map[string]Run, len(s.runs))
+	for job, run := range s.runs {
+		runs[job] = run
+	}
+	return runs, nil
+}
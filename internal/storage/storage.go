@@ -0,0 +1,34 @@
+// Package storage keeps files such as product images and cached coupon files in an object
+// store, so the same code runs against S3, Google Cloud Storage or a local directory.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// ErrNotFound is returned by Get for a key that holds no object
+var ErrNotFound = errors.New("object not found")
+
+// Storage reads and writes objects by key. Keys are slash-separated paths such as
+// products/42/photo.jpg.
+type Storage interface {
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Put stores the whole of r under key, replacing any existing object
+	Put(ctx context.Context, key string, r io.Reader, contentType string) error
+	// Delete removes the object; deleting a missing key is not an error
+	Delete(ctx context.Context, key string) error
+}
+
+// CleanKey normalises key and rejects keys that would escape the store's root
+func CleanKey(key string) (string, error) {
+	cleaned := path.Clean("/" + key)[1:]
+	if cleaned == "" || cleaned != strings.TrimPrefix(key, "/") {
+		return "", fmt.Errorf("invalid storage key %q", key)
+	}
+	return cleaned, nil
+}
@@ -0,0 +1,169 @@
+// Package workerpool runs batches of independent tasks on a bounded number of goroutines,
+// shared by everything that submits to the same pool.
+package workerpool
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// Stats counts a pool's tasks since startup
+type Stats struct {
+	Name      string `json:"name"`
+	Size      int    `json:"size"`
+	Running   int64  `json:"running"`   // Tasks running now
+	Waiting   int64  `json:"waiting"`   // Tasks waiting for a free goroutine
+	Completed int64  `json:"completed"` // Tasks that returned nil
+	Failed    int64  `json:"failed"`    // Tasks that returned an error
+	Panicked  int64  `json:"panicked"`  // Tasks that panicked; they are also counted as failed
+	Canceled  int64  `json:"canceled"`  // Tasks never started because their context was done
+}
+
+// PanicError is what a task that panicked returns, so one bad item can't take the
+// process down with it
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("task panicked: %v", e.Value)
+}
+
+// Pool bounds how many tasks run at once. A nil Pool runs tasks one after the other on the
+// calling goroutine, which keeps callers that are given no pool sequential.
+type Pool struct {
+	name  string
+	slots chan struct{}
+
+	running   atomic.Int64
+	waiting   atomic.Int64
+	completed atomic.Int64
+	failed    atomic.Int64
+	panicked  atomic.Int64
+	canceled  atomic.Int64
+}
+
+// New returns a pool running at most size tasks at once; size below 1 is 1
+func New(name string, size int) *Pool {
+	return &Pool{name: name, slots: make(chan struct{}, max(size, 1))}
+}
+
+// Each runs task for every i in [0, n) and returns the error of each, nil for those that
+// succeeded. Tasks wait for a free goroutine in the order of i; once ctx is done the ones
+// not started yet are skipped with ctx's error. Tasks must be safe to run concurrently,
+// e.g. by writing their results to index i of a slice.
+func (p *Pool) Each(ctx context.Context, n int, task func(ctx context.Context, i int) error) []error {
+	errs := make([]error, n)
+	if p == nil {
+		for i := range n {
+			if err := ctx.Err(); err != nil {
+				errs[i] = err
+				continue
+			}
+			errs[i] = run(ctx, i, task)
+		}
+		return errs
+	}
+
+	var wg sync.WaitGroup
+	for i := range n {
+		p.waiting.Add(1)
+		select {
+		case p.slots <- struct{}{}:
+		case <-ctx.Done():
+			p.waiting.Add(-1)
+			p.canceled.Add(int64(n - i))
+			for j := i; j < n; j++ {
+				errs[j] = ctx.Err()
+			}
+			wg.Wait()
+			return errs
+		}
+		p.waiting.Add(-1)
+		p.running.Add(1)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() {
+				p.running.Add(-1)
+				<-p.slots
+			}()
+			errs[i] = p.record(run(ctx, i, task))
+		}()
+	}
+	wg.Wait()
+	return errs
+}
+
+func (p *Pool) record(err error) error {
+	if err == nil {
+		p.completed.Add(1)
+		return nil
+	}
+	p.failed.Add(1)
+	if _, ok := err.(*PanicError); ok {
+		p.panicked.Add(1)
+	}
+	return err
+}
+
+// run calls task, turning a panic into a PanicError
+func run(ctx context.Context, i int, task func(ctx context.Context, i int) error) (err error) {
+	defer func() {
+		if value := recover(); value != nil {
+			err = &PanicError{Value: value, Stack: debug.Stack()}
+		}
+	}()
+	return task(ctx, i)
+}
+
+func (p *Pool) Stats() Stats {
+	return Stats{
+		Name:      p.name,
+		Size:      cap(p.slots),
+		Running:   p.running.Load(),
+		Waiting:   p.waiting.Load(),
+		Completed: p.completed.Load(),
+		Failed:    p.failed.Load(),
+		Panicked:  p.panicked.Load(),
+		Canceled:  p.canceled.Load(),
+	}
+}
+
+// Registry keeps the pools of an application so their stats can be reported together
+type Registry struct {
+	mutex sync.Mutex
+	pools []*Pool
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// New returns a new pool, registered under name
+func (r *Registry) New(name string, size int) *Pool {
+	pool := New(name, size)
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.pools = append(r.pools, pool)
+	return pool
+}
+
+// Stats returns the stats of every registered pool, by name
+func (r *Registry) Stats() []Stats {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	stats := make([]Stats, len(r.pools))
+	for i, pool := range r.pools {
+		stats[i] = pool.Stats()
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Name < stats[j].Name })
+	return stats
+}
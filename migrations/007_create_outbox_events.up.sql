@@ -0,0 +1,21 @@
+-- Domain events written in the same transaction as the change they describe,
+-- relayed to the broker by the outbox worker
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    aggregate_type VARCHAR(50) NOT NULL,
+    aggregate_id UUID NOT NULL,
+    event_type VARCHAR(100) NOT NULL,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    published_at TIMESTAMP WITH TIME ZONE,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    -- Failed events are retried with backoff from next_attempt_at instead of on every poll,
+    -- and are dead-lettered with dead_at once they run out of attempts, so one event the
+    -- broker keeps refusing doesn't hold back the rest
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    dead_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Only events still due are polled by the relay
+CREATE INDEX IF NOT EXISTS idx_outbox_events_due ON outbox_events(next_attempt_at) WHERE published_at IS NULL AND dead_at IS NULL;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_orders_updated_at;
+DROP INDEX IF EXISTS idx_products_updated_at;
+DROP TRIGGER IF EXISTS trg_orders_updated_at ON orders;
+DROP TRIGGER IF EXISTS trg_products_updated_at ON products;
+DROP FUNCTION IF EXISTS set_updated_at();
\ No newline at end of file
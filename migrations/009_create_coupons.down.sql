@@ -0,0 +1,2 @@
+-- Drop coupons table
+DROP TABLE IF EXISTS coupons;
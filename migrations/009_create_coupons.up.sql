@@ -0,0 +1,7 @@
+-- Coupon codes stored in the database, valid alongside the coupon files and the
+-- configured named discounts
+CREATE TABLE IF NOT EXISTS coupons (
+    code VARCHAR(50) PRIMARY KEY,
+    discount_percentage DOUBLE PRECISION NOT NULL CHECK (discount_percentage > 0 AND discount_percentage <= 100),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,5 @@
+CREATE INDEX IF NOT EXISTS idx_order_queue_retry_count ON order_queue(retry_count);
+CREATE INDEX IF NOT EXISTS idx_order_queue_worker_fetch ON order_queue(status, retry_count, created_at);
+
+DROP INDEX IF EXISTS idx_order_queue_ready;
+ALTER TABLE order_queue DROP COLUMN IF EXISTS next_attempt_at;
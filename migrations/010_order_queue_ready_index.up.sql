@@ -0,0 +1,10 @@
+-- Failed items wait until next_attempt_at before the worker picks them up again
+ALTER TABLE order_queue ADD COLUMN IF NOT EXISTS next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW();
+
+-- The worker only ever fetches items that can still be processed, so index just those
+-- in fetch order instead of every row by status
+CREATE INDEX IF NOT EXISTS idx_order_queue_ready ON order_queue(next_attempt_at)
+    WHERE status = 'pending' OR (status = 'failed' AND retry_count < 3);
+
+DROP INDEX IF EXISTS idx_order_queue_worker_fetch;
+DROP INDEX IF EXISTS idx_order_queue_retry_count;
@@ -0,0 +1,5 @@
+-- Soft-deleted rows become visible again once the column is gone
+DROP INDEX IF EXISTS idx_coupons_deleted_at;
+DROP INDEX IF EXISTS idx_products_deleted_at;
+ALTER TABLE coupons DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE products DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,36 @@
+-- Orders with their items and product snapshots aggregated into JSON, so listings load in
+-- one round trip instead of a follow-up query per order. Timestamps without a time zone
+-- are stored as UTC, so they are tagged as such to serialize with an offset.
+CREATE OR REPLACE VIEW order_summaries AS
+SELECT o.id, o.total, o.discounts, o.status, o.created_at, o.updated_at, o.version,
+    COALESCE((
+        SELECT jsonb_agg(jsonb_build_object(
+            'productId', oi.product_id,
+            'quantity', oi.quantity,
+            'price', oi.price_at_time
+        ) ORDER BY oi.created_at, oi.id)
+        FROM order_items oi
+        WHERE oi.order_id = o.id
+    ), '[]'::jsonb) AS items,
+    -- Deleted products are kept: they are still part of the order
+    COALESCE((
+        SELECT jsonb_agg(jsonb_build_object(
+            'id', p.id,
+            'name', p.name,
+            'price', p.price,
+            'category', p.category,
+            'image', jsonb_build_object(
+                'thumbnail', p.thumbnail_url,
+                'mobile', p.mobile_url,
+                'tablet', p.tablet_url,
+                'desktop', p.desktop_url
+            ),
+            'version', p.version,
+            'createdAt', p.created_at AT TIME ZONE 'UTC',
+            'updatedAt', p.updated_at AT TIME ZONE 'UTC',
+            'deletedAt', p.deleted_at
+        ) ORDER BY p.name)
+        FROM products p
+        WHERE p.id IN (SELECT oi.product_id FROM order_items oi WHERE oi.order_id = o.id)
+    ), '[]'::jsonb) AS products
+FROM orders o;
@@ -0,0 +1,57 @@
+-- Orders remember the X-Customer-ID they were placed with so spend can be tracked per
+-- customer. Guest orders keep a NULL customer.
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS customer_id VARCHAR(100);
+
+CREATE INDEX IF NOT EXISTS idx_orders_customer_id ON orders(customer_id, created_at) WHERE customer_id IS NOT NULL;
+
+-- New columns can only be appended to a view that is replaced in place
+CREATE OR REPLACE VIEW order_summaries AS
+SELECT o.id, o.total, o.discounts, o.status, o.created_at, o.updated_at, o.version,
+    COALESCE((
+        SELECT jsonb_agg(jsonb_build_object(
+            'productId', oi.product_id,
+            'quantity', oi.quantity,
+            'price', oi.price_at_time
+        ) ORDER BY oi.created_at, oi.id)
+        FROM order_items oi
+        WHERE oi.order_id = o.id
+    ), '[]'::jsonb) AS items,
+    -- Deleted products are kept: they are still part of the order
+    COALESCE((
+        SELECT jsonb_agg(jsonb_build_object(
+            'id', p.id,
+            'name', p.name,
+            'price', p.price,
+            'category', p.category,
+            'image', jsonb_build_object(
+                'thumbnail', p.thumbnail_url,
+                'mobile', p.mobile_url,
+                'tablet', p.tablet_url,
+                'desktop', p.desktop_url
+            ),
+            'version', p.version,
+            'createdAt', p.created_at AT TIME ZONE 'UTC',
+            'updatedAt', p.updated_at AT TIME ZONE 'UTC',
+            'deletedAt', p.deleted_at
+        ) ORDER BY p.name)
+        FROM products p
+        WHERE p.id IN (SELECT oi.product_id FROM order_items oi WHERE oi.order_id = o.id)
+    ), '[]'::jsonb) AS products,
+    o.customer_id
+FROM orders o;
+
+-- Segments recomputed periodically from order history (new, regular, lapsed, vip)
+CREATE TABLE IF NOT EXISTS customer_segments (
+    customer_id VARCHAR(100) PRIMARY KEY,
+    segment VARCHAR(20) NOT NULL,
+    order_count INTEGER NOT NULL DEFAULT 0,
+    total_spent DECIMAL(12,2) NOT NULL DEFAULT 0,
+    first_order_at TIMESTAMP,
+    last_order_at TIMESTAMP,
+    computed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_customer_segments_segment ON customer_segments(segment);
+
+-- Coupons restricted to a segment; NULL means anyone may use them
+ALTER TABLE coupons ADD COLUMN IF NOT EXISTS segment VARCHAR(20);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS pricing_tier_assignments;
+DROP TABLE IF EXISTS pricing_tiers;
@@ -0,0 +1,22 @@
+-- Pricing tiers take a percentage off list prices. Retail is the list price itself and is
+-- not stored; staff and corporate start without a discount until an admin sets one.
+CREATE TABLE IF NOT EXISTS pricing_tiers (
+    name VARCHAR(50) PRIMARY KEY CHECK (name <> 'retail'),
+    discount_percentage DOUBLE PRECISION NOT NULL CHECK (discount_percentage >= 0 AND discount_percentage < 100),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO pricing_tiers (name, discount_percentage) VALUES ('staff', 0), ('corporate', 0)
+ON CONFLICT (name) DO NOTHING;
+
+-- Who gets which tier: a customer (X-Customer-ID) or an API key, stored as the hex
+-- SHA-256 of the key. A customer's tier wins over their frontend's API key.
+CREATE TABLE IF NOT EXISTS pricing_tier_assignments (
+    subject_type VARCHAR(20) NOT NULL CHECK (subject_type IN ('customer', 'api_key')),
+    subject VARCHAR(100) NOT NULL,
+    tier VARCHAR(50) NOT NULL REFERENCES pricing_tiers(name) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (subject_type, subject)
+);
+
+CREATE INDEX IF NOT EXISTS idx_pricing_tier_assignments_tier ON pricing_tier_assignments(tier);
@@ -0,0 +1,2 @@
+ALTER TABLE coupons DROP COLUMN IF EXISTS single_use;
+DROP TABLE IF EXISTS coupon_redemptions;
@@ -0,0 +1,12 @@
+-- Single-use coupons can be redeemed once per customer: by X-Customer-ID, or by phone
+-- or email for guests. The redeemer is stored with its kind, e.g. "customer:42" or
+-- "email:jo@example.com", and codes in upper case.
+CREATE TABLE IF NOT EXISTS coupon_redemptions (
+    code VARCHAR(50) NOT NULL,
+    redeemer VARCHAR(320) NOT NULL,
+    redeemed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (code, redeemer)
+);
+
+-- Stored coupons that each customer may only use once
+ALTER TABLE coupons ADD COLUMN IF NOT EXISTS single_use BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,11 @@
+-- Which notifications each customer (X-Customer-ID) wants and where to send them.
+-- Customers without a row get the defaults: email receipts only.
+CREATE TABLE IF NOT EXISTS customer_notification_preferences (
+    customer_id VARCHAR(100) PRIMARY KEY,
+    email VARCHAR(254) NOT NULL DEFAULT '',
+    phone VARCHAR(16) NOT NULL DEFAULT '',
+    email_receipt BOOLEAN NOT NULL DEFAULT TRUE,
+    sms_ready_alert BOOLEAN NOT NULL DEFAULT FALSE,
+    marketing BOOLEAN NOT NULL DEFAULT FALSE,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
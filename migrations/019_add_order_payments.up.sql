@@ -0,0 +1,39 @@
+-- Orders paid through a payment intent remember it and whether it was captured; orders
+-- placed without one keep NULLs
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS payment_intent_id VARCHAR(255);
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS payment_status VARCHAR(20);
+
+CREATE OR REPLACE VIEW order_summaries AS
+SELECT o.id, o.total, o.discounts, o.status, o.created_at, o.updated_at, o.version,
+    COALESCE((
+        SELECT jsonb_agg(jsonb_build_object(
+            'productId', oi.product_id,
+            'quantity', oi.quantity,
+            'price', oi.price_at_time
+        ) ORDER BY oi.created_at, oi.id)
+        FROM order_items oi
+        WHERE oi.order_id = o.id
+    ), '[]'::jsonb) AS items,
+    -- Deleted products are kept: they are still part of the order
+    COALESCE((
+        SELECT jsonb_agg(jsonb_build_object(
+            'id', p.id,
+            'name', p.name,
+            'price', p.price,
+            'category', p.category,
+            'image', jsonb_build_object(
+                'thumbnail', p.thumbnail_url,
+                'mobile', p.mobile_url,
+                'tablet', p.tablet_url,
+                'desktop', p.desktop_url
+            ),
+            'version', p.version,
+            'createdAt', p.created_at AT TIME ZONE 'UTC',
+            'updatedAt', p.updated_at AT TIME ZONE 'UTC',
+            'deletedAt', p.deleted_at
+        ) ORDER BY p.name)
+        FROM products p
+        WHERE p.id IN (SELECT oi.product_id FROM order_items oi WHERE oi.order_id = o.id)
+    ), '[]'::jsonb) AS products,
+    o.customer_id, o.payment_intent_id, o.payment_status
+FROM orders o;
@@ -0,0 +1,19 @@
+-- Refunds of orders paid through a payment intent. A refund is requested before the
+-- payment provider is asked for it, then processed or failed with the provider's answer.
+CREATE TABLE IF NOT EXISTS order_refunds (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    order_id UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    payment_intent_id VARCHAR(255) NOT NULL,
+    amount DECIMAL(10,2) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'requested',
+    provider_refund_id VARCHAR(255) NOT NULL DEFAULT '',
+    error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_refunds_order_id ON order_refunds(order_id);
+
+DROP TRIGGER IF EXISTS trg_order_refunds_updated_at ON order_refunds;
+CREATE TRIGGER trg_order_refunds_updated_at BEFORE UPDATE ON order_refunds
+    FOR EACH ROW EXECUTE FUNCTION set_updated_at();
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_orders_payment_intent_id;
+DROP TABLE IF EXISTS payment_events;
@@ -0,0 +1,10 @@
+-- Webhook events of the payment provider already handled, so redeliveries and replays
+-- are ignored
+CREATE TABLE IF NOT EXISTS payment_events (
+    id VARCHAR(255) PRIMARY KEY,
+    event_type VARCHAR(100) NOT NULL,
+    received_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Webhook events name the payment intent, not the order
+CREATE INDEX IF NOT EXISTS idx_orders_payment_intent_id ON orders(payment_intent_id);
@@ -0,0 +1,54 @@
+-- Gift cards hold store credit, issued by admins and spent on orders. The balance is what
+-- is left; orders record the credit they spent and the card it came from.
+CREATE TABLE IF NOT EXISTS gift_cards (
+    code VARCHAR(32) PRIMARY KEY,
+    initial_balance DECIMAL(10,2) NOT NULL,
+    balance DECIMAL(10,2) NOT NULL CHECK (balance >= 0),
+    expires_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+DROP TRIGGER IF EXISTS trg_gift_cards_updated_at ON gift_cards;
+CREATE TRIGGER trg_gift_cards_updated_at BEFORE UPDATE ON gift_cards
+    FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS store_credit DECIMAL(10,2) NOT NULL DEFAULT 0;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS gift_card_code VARCHAR(32) NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_orders_gift_card_code ON orders(gift_card_code) WHERE gift_card_code <> '';
+
+CREATE OR REPLACE VIEW order_summaries AS
+SELECT o.id, o.total, o.discounts, o.status, o.created_at, o.updated_at, o.version,
+    COALESCE((
+        SELECT jsonb_agg(jsonb_build_object(
+            'productId', oi.product_id,
+            'quantity', oi.quantity,
+            'price', oi.price_at_time
+        ) ORDER BY oi.created_at, oi.id)
+        FROM order_items oi
+        WHERE oi.order_id = o.id
+    ), '[]'::jsonb) AS items,
+    -- Deleted products are kept: they are still part of the order
+    COALESCE((
+        SELECT jsonb_agg(jsonb_build_object(
+            'id', p.id,
+            'name', p.name,
+            'price', p.price,
+            'category', p.category,
+            'image', jsonb_build_object(
+                'thumbnail', p.thumbnail_url,
+                'mobile', p.mobile_url,
+                'tablet', p.tablet_url,
+                'desktop', p.desktop_url
+            ),
+            'version', p.version,
+            'createdAt', p.created_at AT TIME ZONE 'UTC',
+            'updatedAt', p.updated_at AT TIME ZONE 'UTC',
+            'deletedAt', p.deleted_at
+        ) ORDER BY p.name)
+        FROM products p
+        WHERE p.id IN (SELECT oi.product_id FROM order_items oi WHERE oi.order_id = o.id)
+    ), '[]'::jsonb) AS products,
+    o.customer_id, o.payment_intent_id, o.payment_status, o.payment_method, o.store_credit, o.gift_card_code
+FROM orders o;
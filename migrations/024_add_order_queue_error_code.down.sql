@@ -0,0 +1 @@
+ALTER TABLE order_queue DROP COLUMN IF EXISTS error_code;
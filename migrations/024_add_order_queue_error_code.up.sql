@@ -0,0 +1,11 @@
+-- Says what an item failed on, so payment failures can be told apart from orders that
+-- couldn't be created. Items failed before it existed are taken to be order failures,
+-- apart from those whose payment window passed.
+ALTER TABLE order_queue ADD COLUMN IF NOT EXISTS error_code VARCHAR(40) NOT NULL DEFAULT '';
+
+UPDATE order_queue
+SET error_code = CASE
+    WHEN error = 'order was not paid for within the payment window' THEN 'payment_expired'
+    ELSE 'order_failed'
+END
+WHERE status = 'failed';
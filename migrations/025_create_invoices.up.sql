@@ -0,0 +1,16 @@
+-- Invoice numbers run without gaps per series. The next number is taken in the same
+-- transaction that stores the invoice, so an invoice that isn't stored gives its number back.
+CREATE TABLE IF NOT EXISTS invoice_sequences (
+    series VARCHAR(20) PRIMARY KEY,
+    last_number BIGINT NOT NULL
+);
+
+-- Issued invoices as they were sent; they are tax records, so they outlive their order
+CREATE TABLE IF NOT EXISTS invoices (
+    order_id UUID PRIMARY KEY,
+    series VARCHAR(20) NOT NULL,
+    number BIGINT NOT NULL,
+    issued_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    document JSONB NOT NULL,
+    UNIQUE (series, number)
+);
@@ -0,0 +1 @@
+ALTER TABLE customer_notification_preferences DROP COLUMN IF EXISTS email_ready_alert;
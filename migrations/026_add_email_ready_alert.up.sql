@@ -0,0 +1,2 @@
+-- Customers may also be emailed when their order is ready. Like the text, it is opt-in.
+ALTER TABLE customer_notification_preferences ADD COLUMN IF NOT EXISTS email_ready_alert BOOLEAN NOT NULL DEFAULT FALSE;
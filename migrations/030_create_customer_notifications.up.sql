@@ -0,0 +1,17 @@
+-- Customers' in-app notification feed, which mobile apps show as an activity feed without
+-- push infrastructure. Entries are written as the notification service tells customers
+-- about their orders, whatever their email and SMS preferences, and stay unread until the
+-- customer reads them.
+CREATE TABLE IF NOT EXISTS customer_notifications (
+    id UUID PRIMARY KEY,
+    customer_id VARCHAR(100) NOT NULL,
+    kind VARCHAR(30) NOT NULL,
+    title TEXT NOT NULL,
+    body TEXT NOT NULL,
+    order_id UUID,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    read_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_customer_notifications_feed ON customer_notifications(customer_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_customer_notifications_unread ON customer_notifications(customer_id) WHERE read_at IS NULL;
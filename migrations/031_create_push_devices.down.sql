@@ -0,0 +1,3 @@
+ALTER TABLE notification_outbox ALTER COLUMN recipient TYPE VARCHAR(255);
+
+DROP TABLE IF EXISTS push_devices;
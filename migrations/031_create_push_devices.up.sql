@@ -0,0 +1,14 @@
+-- Devices customers registered for push notifications. A token belongs to one app install,
+-- so registering it again, e.g. after another customer signs in on the device, moves it to
+-- the new customer. Tokens the provider reports as no longer valid are removed.
+CREATE TABLE IF NOT EXISTS push_devices (
+    token VARCHAR(512) PRIMARY KEY,
+    customer_id VARCHAR(100) NOT NULL,
+    platform VARCHAR(10) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_push_devices_customer ON push_devices(customer_id);
+
+-- Push notifications are queued for platform:token, longer than an email address or phone
+ALTER TABLE notification_outbox ALTER COLUMN recipient TYPE VARCHAR(520);
@@ -0,0 +1,52 @@
+-- Restore the view from 028 before the column goes away
+DROP VIEW IF EXISTS order_summaries;
+CREATE OR REPLACE VIEW order_summaries AS
+SELECT o.id, o.total, o.discounts, o.status, o.created_at, o.updated_at, o.version,
+    COALESCE((
+        SELECT jsonb_agg(jsonb_build_object(
+            'productId', oi.product_id,
+            'quantity', oi.quantity,
+            'price', oi.price_at_time
+        ) ORDER BY oi.created_at, oi.id)
+        FROM order_items oi
+        WHERE oi.order_id = o.id
+    ), '[]'::jsonb) AS items,
+    -- Deleted products are kept: they are still part of the order
+    COALESCE((
+        SELECT jsonb_agg(jsonb_build_object(
+            'id', p.id,
+            'name', p.name,
+            'price', p.price,
+            'category', p.category,
+            'image', jsonb_build_object(
+                'thumbnail', p.thumbnail_url,
+                'mobile', p.mobile_url,
+                'tablet', p.tablet_url,
+                'desktop', p.desktop_url
+            ),
+            'version', p.version,
+            'createdAt', p.created_at AT TIME ZONE 'UTC',
+            'updatedAt', p.updated_at AT TIME ZONE 'UTC',
+            'deletedAt', p.deleted_at
+        ) ORDER BY p.name)
+        FROM products p
+        WHERE p.id IN (SELECT oi.product_id FROM order_items oi WHERE oi.order_id = o.id)
+    ), '[]'::jsonb) AS products,
+    o.customer_id, o.payment_intent_id, o.payment_status, o.payment_method, o.store_credit, o.gift_card_code, o.coupon_code
+FROM orders o;
+
+-- Fails while other stores have invoices numbered like the default store's; tax records
+-- aren't dropped to make room. Each series goes on from the highest number taken.
+ALTER TABLE invoices DROP CONSTRAINT IF EXISTS invoices_store_series_number_key;
+ALTER TABLE invoices ADD CONSTRAINT invoices_series_number_key UNIQUE (series, number);
+ALTER TABLE invoices DROP COLUMN IF EXISTS store_id;
+DELETE FROM invoice_sequences s USING invoice_sequences o
+WHERE s.series = o.series AND (s.last_number, s.store_id) < (o.last_number, o.store_id);
+ALTER TABLE invoice_sequences DROP CONSTRAINT IF EXISTS invoice_sequences_pkey;
+ALTER TABLE invoice_sequences DROP COLUMN IF EXISTS store_id;
+ALTER TABLE invoice_sequences ADD PRIMARY KEY (series);
+ALTER TABLE coupons DROP COLUMN IF EXISTS store_id;
+ALTER TABLE orders DROP COLUMN IF EXISTS store_id;
+ALTER TABLE products DROP COLUMN IF EXISTS store_id;
+DROP TABLE IF EXISTS store_api_keys;
+DROP TABLE IF EXISTS stores;
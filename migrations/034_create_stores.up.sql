@@ -0,0 +1,80 @@
+-- Restaurant locations served by the deployment. Everything that existed before stores
+-- belongs to the default store, which can be renamed but not removed.
+CREATE TABLE IF NOT EXISTS stores (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO stores (id, name) VALUES ('00000000-0000-0000-0000-000000000001', 'Default store')
+ON CONFLICT (id) DO NOTHING;
+
+-- API keys of a single store, stored as the hex SHA-256 of the key. Requests made with one
+-- only see their store; API_KEY still reaches every store through X-Store-ID.
+CREATE TABLE IF NOT EXISTS store_api_keys (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    store_id UUID NOT NULL REFERENCES stores(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    key_hash VARCHAR(64) NOT NULL UNIQUE,
+    key_prefix VARCHAR(12) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    revoked_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_store_api_keys_store ON store_api_keys(store_id);
+
+ALTER TABLE products ADD COLUMN IF NOT EXISTS store_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES stores(id);
+CREATE INDEX IF NOT EXISTS idx_products_store ON products(store_id);
+
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS store_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES stores(id);
+CREATE INDEX IF NOT EXISTS idx_orders_store_created ON orders(store_id, created_at DESC);
+
+-- Stored coupons without a store are valid at every store
+ALTER TABLE coupons ADD COLUMN IF NOT EXISTS store_id UUID REFERENCES stores(id) ON DELETE CASCADE;
+
+-- Each store numbers its invoices on its own, so a store's invoices run without gaps. Like
+-- invoices, the sequences are kept when their store is removed.
+ALTER TABLE invoice_sequences ADD COLUMN IF NOT EXISTS store_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001';
+ALTER TABLE invoice_sequences DROP CONSTRAINT IF EXISTS invoice_sequences_pkey;
+ALTER TABLE invoice_sequences ADD PRIMARY KEY (store_id, series);
+ALTER TABLE invoices ADD COLUMN IF NOT EXISTS store_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001';
+ALTER TABLE invoices DROP CONSTRAINT IF EXISTS invoices_series_number_key;
+ALTER TABLE invoices ADD CONSTRAINT invoices_store_series_number_key UNIQUE (store_id, series, number);
+
+CREATE OR REPLACE VIEW order_summaries AS
+SELECT o.id, o.total, o.discounts, o.status, o.created_at, o.updated_at, o.version,
+    COALESCE((
+        SELECT jsonb_agg(jsonb_build_object(
+            'productId', oi.product_id,
+            'quantity', oi.quantity,
+            'price', oi.price_at_time
+        ) ORDER BY oi.created_at, oi.id)
+        FROM order_items oi
+        WHERE oi.order_id = o.id
+    ), '[]'::jsonb) AS items,
+    -- Deleted products are kept: they are still part of the order
+    COALESCE((
+        SELECT jsonb_agg(jsonb_build_object(
+            'id', p.id,
+            'name', p.name,
+            'price', p.price,
+            'category', p.category,
+            'image', jsonb_build_object(
+                'thumbnail', p.thumbnail_url,
+                'mobile', p.mobile_url,
+                'tablet', p.tablet_url,
+                'desktop', p.desktop_url
+            ),
+            'version', p.version,
+            'createdAt', p.created_at AT TIME ZONE 'UTC',
+            'updatedAt', p.updated_at AT TIME ZONE 'UTC',
+            'deletedAt', p.deleted_at,
+            'storeId', p.store_id
+        ) ORDER BY p.name)
+        FROM products p
+        WHERE p.id IN (SELECT oi.product_id FROM order_items oi WHERE oi.order_id = o.id)
+    ), '[]'::jsonb) AS products,
+    o.customer_id, o.payment_intent_id, o.payment_status, o.payment_method, o.store_credit, o.gift_card_code, o.coupon_code,
+    o.store_id
+FROM orders o;
@@ -0,0 +1,8 @@
+-- When a store takes orders: its weekly hours, holiday overrides and what happens to
+-- orders placed while it is closed, as models.OpeningHours. Stores without a row are
+-- always open.
+CREATE TABLE IF NOT EXISTS store_opening_hours (
+    store_id UUID PRIMARY KEY REFERENCES stores(id) ON DELETE CASCADE,
+    hours JSONB NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
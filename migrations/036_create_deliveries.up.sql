@@ -0,0 +1,16 @@
+-- Couriers booked through the delivery provider for orders with a delivery address, one per
+-- order. external_id is the provider's job; status follows it until the order is delivered,
+-- cancelled or the delivery failed.
+CREATE TABLE IF NOT EXISTS deliveries (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    order_id UUID NOT NULL UNIQUE REFERENCES orders(id) ON DELETE CASCADE,
+    provider VARCHAR(30) NOT NULL,
+    external_id VARCHAR(100) NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL,
+    tracking_url TEXT NOT NULL DEFAULT '',
+    courier_name VARCHAR(100) NOT NULL DEFAULT '',
+    estimated_at TIMESTAMP WITH TIME ZONE,
+    error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_order_queue_tab;
+DROP TABLE IF EXISTS table_tabs;
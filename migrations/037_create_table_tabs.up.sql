@@ -0,0 +1,16 @@
+-- Tabs of dine-in tables. Orders placed at a table join its open tab, through the tabId
+-- kept in their queue item's request, until staff close the tab when it is settled. A
+-- table has at most one open tab at a time.
+CREATE TABLE IF NOT EXISTS table_tabs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    store_id UUID NOT NULL REFERENCES stores(id) ON DELETE CASCADE,
+    table_number VARCHAR(20) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'open',
+    payment_method VARCHAR(20) NOT NULL DEFAULT '',
+    opened_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    closed_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_table_tabs_open ON table_tabs(store_id, table_number) WHERE status = 'open';
+
+CREATE INDEX IF NOT EXISTS idx_order_queue_tab ON order_queue((order_req->>'tabId')) WHERE order_req ? 'tabId';
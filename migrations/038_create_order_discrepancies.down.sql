@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_order_discrepancies_detected_at;
+DROP TABLE IF EXISTS order_discrepancies;
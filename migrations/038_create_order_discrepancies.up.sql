@@ -0,0 +1,15 @@
+-- Mismatches the reconciliation job found between what an order records and what its item
+-- snapshots add up to, one row per order and kind, for finance to review. A row goes away
+-- once a later run finds the order consistent again.
+CREATE TABLE IF NOT EXISTS order_discrepancies (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    order_id UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL,
+    recorded DECIMAL(10,2) NOT NULL,
+    expected DECIMAL(10,2) NOT NULL,
+    detected_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    checked_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (order_id, kind)
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_discrepancies_detected_at ON order_discrepancies(detected_at DESC);
@@ -0,0 +1,2 @@
+ALTER TABLE order_queue DROP COLUMN IF EXISTS claimed_at;
+ALTER TABLE order_queue DROP COLUMN IF EXISTS claimed_by;
@@ -0,0 +1,4 @@
+-- Records which worker instance last picked the item up and when, so a stuck order can be
+-- traced to the instance that was processing it
+ALTER TABLE order_queue ADD COLUMN IF NOT EXISTS claimed_by VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE order_queue ADD COLUMN IF NOT EXISTS claimed_at TIMESTAMP WITH TIME ZONE;
@@ -0,0 +1,15 @@
+-- Every product created, updated or deleted, in the order it happened, for clients that
+-- sync the catalog incrementally. The repository writes a row with each change; seq is
+-- the cursor clients resume from. Existing products are logged as created, or deleted, so
+-- a client reading from the start gets the whole catalog.
+CREATE TABLE IF NOT EXISTS product_changes (
+    seq BIGSERIAL PRIMARY KEY,
+    product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    kind VARCHAR(10) NOT NULL,
+    changed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO product_changes (product_id, kind, changed_at)
+SELECT id, CASE WHEN deleted_at IS NULL THEN 'created' ELSE 'deleted' END, COALESCE(updated_at, created_at, NOW())
+FROM products
+ORDER BY created_at, id;
@@ -0,0 +1,12 @@
+-- Stock of the products whose stock is counted. Products without a row are never out of
+-- stock. It is kept apart from products so that orders taking stock don't move the
+-- product's updated_at, which clients sync by.
+CREATE TABLE IF NOT EXISTS product_stock (
+    product_id UUID PRIMARY KEY REFERENCES products(id) ON DELETE CASCADE,
+    quantity INTEGER NOT NULL CHECK (quantity >= 0),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+DROP TRIGGER IF EXISTS trg_product_stock_updated_at ON product_stock;
+CREATE TRIGGER trg_product_stock_updated_at BEFORE UPDATE ON product_stock
+    FOR EACH ROW EXECUTE FUNCTION set_updated_at();
@@ -0,0 +1,15 @@
+-- Stock set aside for orders waiting in the queue, so orders queued together can't sell
+-- more than is left between them. A reservation is taken from stock, or deleted, when its
+-- order is processed or fails; one that outlives expires_at no longer holds stock.
+CREATE TABLE IF NOT EXISTS stock_reservations (
+    reservation_id UUID NOT NULL,
+    product_id UUID NOT NULL REFERENCES product_stock(product_id) ON DELETE CASCADE,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (reservation_id, product_id)
+);
+
+-- Supports adding up what is reserved of a product, and deleting expired reservations
+CREATE INDEX IF NOT EXISTS idx_stock_reservations_product ON stock_reservations(product_id, expires_at);
+CREATE INDEX IF NOT EXISTS idx_stock_reservations_expires_at ON stock_reservations(expires_at);
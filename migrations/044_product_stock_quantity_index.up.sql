@@ -0,0 +1,2 @@
+-- Supports the low-stock listing, which reads the counted products by quantity
+CREATE INDEX IF NOT EXISTS idx_product_stock_quantity ON product_stock(quantity);
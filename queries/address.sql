@@ -0,0 +1,42 @@
+-- name: GetCustomerAddresses :many
+SELECT id, customer_id, label, line1, line2, city, region, postal_code, country, instructions, is_default, created_at
+FROM customer_addresses
+WHERE customer_id = $1
+ORDER BY is_default DESC, created_at, id;
+
+-- name: GetCustomerAddress :one
+SELECT id, customer_id, label, line1, line2, city, region, postal_code, country, instructions, is_default, created_at
+FROM customer_addresses
+WHERE id = $1 AND customer_id = $2;
+
+-- name: CountCustomerAddresses :one
+SELECT COUNT(*) FROM customer_addresses WHERE customer_id = $1;
+
+-- name: CreateCustomerAddress :one
+INSERT INTO customer_addresses (customer_id, label, line1, line2, city, region, postal_code, country, instructions, is_default)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+RETURNING id, customer_id, label, line1, line2, city, region, postal_code, country, instructions, is_default, created_at;
+
+-- name: ClearDefaultCustomerAddress :exec
+UPDATE customer_addresses SET is_default = FALSE
+WHERE customer_id = $1 AND is_default;
+
+-- name: SetDefaultCustomerAddress :execrows
+UPDATE customer_addresses SET is_default = TRUE
+WHERE id = $1 AND customer_id = $2;
+
+-- name: DeleteCustomerAddress :execrows
+DELETE FROM customer_addresses
+WHERE id = $1 AND customer_id = $2;
+
+-- name: PromoteNewestCustomerAddress :exec
+-- Makes the newest address the default when the customer has none
+UPDATE customer_addresses SET is_default = TRUE
+WHERE id = (
+    SELECT id FROM customer_addresses
+    WHERE customer_id = $1
+    ORDER BY created_at DESC, id DESC
+    LIMIT 1
+) AND NOT EXISTS (
+    SELECT 1 FROM customer_addresses WHERE customer_id = $1 AND is_default
+);
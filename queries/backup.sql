@@ -0,0 +1,65 @@
+-- name: ListStoresAfter :many
+SELECT id, name, created_at, updated_at
+FROM stores
+WHERE id > @after::uuid
+ORDER BY id
+LIMIT @max_rows;
+
+-- name: ListProductsAfter :many
+-- Deleted products are included, so that they can be restored
+SELECT id, name, price, category, thumbnail_url, mobile_url, tablet_url, desktop_url, created_at, updated_at, version, deleted_at, store_id
+FROM products
+WHERE id > @after::uuid
+ORDER BY id
+LIMIT @max_rows;
+
+-- name: ListCouponsAfter :many
+SELECT code, discount_percentage, created_at, deleted_at, segment, single_use, store_id
+FROM coupons
+WHERE code > @after::text
+ORDER BY code
+LIMIT @max_rows;
+
+-- name: ListOrdersAfter :many
+SELECT id, total, discounts, status, created_at, updated_at, version, customer_id, payment_intent_id, payment_status, payment_method, store_credit, gift_card_code, coupon_code, store_id, sandbox
+FROM orders
+WHERE id > @after::uuid
+ORDER BY id
+LIMIT @max_rows;
+
+-- name: ListOrderItemsByOrderIDs :many
+SELECT id, order_id, product_id, quantity, price_at_time, created_at, options
+FROM order_items
+WHERE order_id = ANY(@order_ids::uuid[])
+ORDER BY order_id, created_at, id;
+
+-- name: ImportStore :execrows
+INSERT INTO stores (id, name, created_at, updated_at)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT DO NOTHING;
+
+-- name: ImportProduct :execrows
+-- Imported products are logged in the change feed, as deleted when they were
+WITH imported AS (
+  INSERT INTO products (id, name, price, category, thumbnail_url, mobile_url, tablet_url, desktop_url, created_at, updated_at, deleted_at, store_id)
+  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+  ON CONFLICT DO NOTHING
+  RETURNING id, deleted_at
+)
+INSERT INTO product_changes (product_id, kind)
+SELECT id, CASE WHEN deleted_at IS NULL THEN 'created' ELSE 'deleted' END FROM imported;
+
+-- name: ImportCoupon :execrows
+INSERT INTO coupons (code, discount_percentage, created_at, deleted_at, segment, single_use, store_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT DO NOTHING;
+
+-- name: ImportOrder :execrows
+INSERT INTO orders (id, total, discounts, status, created_at, updated_at, customer_id, payment_intent_id, payment_status, payment_method, store_credit, gift_card_code, coupon_code, store_id, sandbox)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+ON CONFLICT DO NOTHING;
+
+-- name: ImportOrderItems :exec
+INSERT INTO order_items (order_id, product_id, quantity, price_at_time, created_at, options)
+SELECT @order_id::uuid, u.product_id, u.quantity, u.price_at_time, u.created_at, u.options::jsonb
+FROM unnest(@product_ids::uuid[], @quantities::int[], @prices::numeric[], @created_ats::timestamp[], @options::text[]) AS u(product_id, quantity, price_at_time, created_at, options);
@@ -0,0 +1,50 @@
+-- name: GetCustomerCart :one
+SELECT id, customer_id, expires_at, updated_at FROM carts
+WHERE customer_id = $1;
+
+-- name: GetGuestCart :one
+SELECT id, customer_id, expires_at, updated_at FROM carts
+WHERE id = $1 AND customer_id IS NULL AND expires_at > NOW();
+
+-- name: UpsertCustomerCart :one
+INSERT INTO carts (customer_id)
+VALUES ($1)
+ON CONFLICT (customer_id) DO UPDATE SET updated_at = NOW()
+RETURNING id, customer_id, expires_at, updated_at;
+
+-- name: CreateGuestCart :one
+INSERT INTO carts (expires_at)
+VALUES ($1)
+RETURNING id, customer_id, expires_at, updated_at;
+
+-- name: TouchCart :exec
+-- Guest carts pass a new expiry; customer carts keep theirs (NULL)
+UPDATE carts SET updated_at = NOW(), expires_at = CASE WHEN customer_id IS NULL THEN $2 ELSE expires_at END
+WHERE id = $1;
+
+-- name: GetCartItems :many
+SELECT cart_id, product_id, quantity, created_at, variant_id, modifier_ids FROM cart_items
+WHERE cart_id = $1
+ORDER BY created_at, product_id;
+
+-- name: AddCartItem :exec
+-- A product already in the cart takes the options sent last
+INSERT INTO cart_items (cart_id, product_id, quantity, variant_id, modifier_ids)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (cart_id, product_id) DO UPDATE SET quantity = cart_items.quantity + EXCLUDED.quantity,
+    variant_id = EXCLUDED.variant_id, modifier_ids = EXCLUDED.modifier_ids;
+
+-- name: SetCartItemQuantity :execrows
+UPDATE cart_items SET quantity = $3
+WHERE cart_id = $1 AND product_id = $2;
+
+-- name: DeleteCartItem :execrows
+DELETE FROM cart_items
+WHERE cart_id = $1 AND product_id = $2;
+
+-- name: ClearCartItems :exec
+DELETE FROM cart_items WHERE cart_id = $1;
+
+-- name: DeleteExpiredCarts :execrows
+DELETE FROM carts
+WHERE customer_id IS NULL AND expires_at <= $1;
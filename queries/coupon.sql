@@ -0,0 +1,33 @@
+-- name: GetCoupons :many
+SELECT code, discount_percentage, created_at, deleted_at, segment, single_use, store_id FROM coupons
+WHERE deleted_at IS NULL
+ORDER BY code;
+
+-- name: InsertCouponIfMissing :execrows
+INSERT INTO coupons (code, discount_percentage)
+VALUES ($1, $2)
+ON CONFLICT (code) DO NOTHING;
+
+-- name: DeleteCoupon :execrows
+UPDATE coupons SET deleted_at = NOW() WHERE code = $1 AND deleted_at IS NULL;
+
+-- name: RestoreCoupon :execrows
+UPDATE coupons SET deleted_at = NULL WHERE code = $1 AND deleted_at IS NOT NULL;
+
+-- name: GetDeletedCoupons :many
+SELECT code, discount_percentage, created_at, deleted_at, segment, single_use, store_id FROM coupons
+WHERE deleted_at >= $1
+ORDER BY deleted_at DESC;
+
+-- name: CouponRedeemed :one
+SELECT EXISTS (
+    SELECT 1 FROM coupon_redemptions WHERE code = $1 AND redeemer = $2
+);
+
+-- name: InsertCouponRedemption :execrows
+INSERT INTO coupon_redemptions (code, redeemer)
+VALUES ($1, $2)
+ON CONFLICT (code, redeemer) DO NOTHING;
+
+-- name: DeleteCouponRedemption :execrows
+DELETE FROM coupon_redemptions WHERE code = $1 AND redeemer = $2;
@@ -0,0 +1,25 @@
+-- name: InsertCustomerNotification :exec
+INSERT INTO customer_notifications (id, customer_id, kind, title, body, order_id, created_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7);
+
+-- name: ListCustomerNotifications :many
+-- Newest first; with unread_only, only those the customer hasn't read
+SELECT id, customer_id, kind, title, body, order_id, created_at, read_at
+FROM customer_notifications
+WHERE customer_id = @customer_id AND (NOT @unread_only::boolean OR read_at IS NULL)
+ORDER BY created_at DESC, id
+LIMIT @page_limit OFFSET @page_offset;
+
+-- name: CountCustomerNotifications :one
+SELECT COUNT(*) AS total, COUNT(*) FILTER (WHERE read_at IS NULL) AS unread
+FROM customer_notifications
+WHERE customer_id = $1;
+
+-- name: MarkCustomerNotificationRead :execrows
+-- A notification read before keeps when it was first read
+UPDATE customer_notifications SET read_at = COALESCE(read_at, NOW())
+WHERE id = $1 AND customer_id = $2;
+
+-- name: MarkAllCustomerNotificationsRead :execrows
+UPDATE customer_notifications SET read_at = NOW()
+WHERE customer_id = $1 AND read_at IS NULL;
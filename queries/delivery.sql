@@ -0,0 +1,17 @@
+-- name: CreateDelivery :one
+-- Returns no row when the order already has a delivery
+INSERT INTO deliveries (order_id, provider, external_id, status, tracking_url, courier_name, estimated_at, error)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+ON CONFLICT (order_id) DO NOTHING
+RETURNING id, order_id, provider, external_id, status, tracking_url, courier_name, estimated_at, error, created_at, updated_at;
+
+-- name: GetDeliveryByOrder :one
+SELECT id, order_id, provider, external_id, status, tracking_url, courier_name, estimated_at, error, created_at, updated_at
+FROM deliveries
+WHERE order_id = $1;
+
+-- name: UpdateDelivery :one
+UPDATE deliveries
+SET external_id = $2, status = $3, tracking_url = $4, courier_name = $5, estimated_at = $6, error = $7, updated_at = NOW()
+WHERE id = $1
+RETURNING id, order_id, provider, external_id, status, tracking_url, courier_name, estimated_at, error, created_at, updated_at;
@@ -0,0 +1,13 @@
+-- name: GetCustomerFavorites :many
+SELECT product_id FROM customer_favorites
+WHERE customer_id = $1
+ORDER BY created_at DESC, product_id;
+
+-- name: AddCustomerFavorite :exec
+INSERT INTO customer_favorites (customer_id, product_id)
+VALUES ($1, $2)
+ON CONFLICT (customer_id, product_id) DO NOTHING;
+
+-- name: DeleteCustomerFavorite :execrows
+DELETE FROM customer_favorites
+WHERE customer_id = $1 AND product_id = $2;
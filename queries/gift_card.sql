@@ -0,0 +1,22 @@
+-- name: CreateGiftCard :one
+-- Returns no row when the code is taken
+INSERT INTO gift_cards (code, initial_balance, balance, expires_at)
+VALUES ($1, $2, $2, $3)
+ON CONFLICT (code) DO NOTHING
+RETURNING code, initial_balance, balance, expires_at, created_at, updated_at;
+
+-- name: GetGiftCard :one
+SELECT code, initial_balance, balance, expires_at, created_at, updated_at
+FROM gift_cards
+WHERE code = $1;
+
+-- name: DebitGiftCard :execrows
+-- Affects no row when the card is unknown, expired or short of the amount
+UPDATE gift_cards
+SET balance = balance - @amount::numeric
+WHERE code = @code AND balance >= @amount::numeric AND (expires_at IS NULL OR expires_at > NOW());
+
+-- name: CreditGiftCard :execrows
+UPDATE gift_cards
+SET balance = balance + @amount::numeric
+WHERE code = @code;
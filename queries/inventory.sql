@@ -0,0 +1,52 @@
+-- name: LockStock :many
+-- Locks the stock of the products whose stock is counted, in the same order for every
+-- caller, so reservations and takes of a product are made one at a time
+SELECT product_id, quantity, updated_at
+FROM product_stock
+WHERE product_id = ANY(@product_ids::uuid[])
+ORDER BY product_id
+FOR UPDATE;
+
+-- name: GetReservedStock :many
+-- Units of each product set aside by reservations that haven't expired
+SELECT product_id, SUM(quantity)::integer AS reserved
+FROM stock_reservations
+WHERE product_id = ANY(@product_ids::uuid[]) AND expires_at > NOW()
+GROUP BY product_id;
+
+-- name: TakeStock :exec
+UPDATE product_stock
+SET quantity = quantity - @quantity::integer
+WHERE product_id = @product_id;
+
+-- name: ReturnStock :execrows
+UPDATE product_stock
+SET quantity = quantity + @quantity::integer
+WHERE product_id = @product_id;
+
+-- name: Restock :one
+-- Starts counting the product's stock when it wasn't
+INSERT INTO product_stock (product_id, quantity)
+VALUES (@product_id, @quantity::integer)
+ON CONFLICT (product_id) DO UPDATE SET quantity = product_stock.quantity + EXCLUDED.quantity
+RETURNING product_id, quantity, updated_at;
+
+-- name: CreateStockReservation :exec
+INSERT INTO stock_reservations (reservation_id, product_id, quantity, expires_at)
+VALUES (@reservation_id, @product_id, @quantity::integer, @expires_at);
+
+-- name: DeleteStockReservation :execrows
+DELETE FROM stock_reservations
+WHERE reservation_id = $1;
+
+-- name: DeleteExpiredStockReservations :execrows
+DELETE FROM stock_reservations
+WHERE expires_at <= $1;
+
+-- name: GetLowStock :many
+SELECT s.product_id, p.name, p.category, p.store_id, s.quantity, s.updated_at
+FROM product_stock s
+JOIN products p ON p.id = s.product_id
+WHERE s.quantity <= @threshold::integer AND p.deleted_at IS NULL
+  AND (sqlc.narg(store_id)::uuid IS NULL OR p.store_id = sqlc.narg(store_id)::uuid)
+ORDER BY s.quantity, p.name, p.id;
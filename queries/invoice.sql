@@ -0,0 +1,17 @@
+-- name: NextInvoiceNumber :one
+-- Locks the store's series until the transaction ends, so numbers are taken one at a time
+INSERT INTO invoice_sequences (store_id, series, last_number)
+VALUES ($1, $2, 1)
+ON CONFLICT (store_id, series) DO UPDATE SET last_number = invoice_sequences.last_number + 1
+RETURNING last_number;
+
+-- name: CreateInvoice :execrows
+-- Affects no row when the order already has an invoice
+INSERT INTO invoices (order_id, series, number, issued_at, document, store_id)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (order_id) DO NOTHING;
+
+-- name: GetInvoice :one
+SELECT order_id, series, number, issued_at, document, store_id
+FROM invoices
+WHERE order_id = $1;
@@ -0,0 +1,17 @@
+-- name: GetNotificationPreferences :one
+SELECT customer_id, email, phone, email_receipt, sms_ready_alert, email_ready_alert, marketing, updated_at
+FROM customer_notification_preferences
+WHERE customer_id = $1;
+
+-- name: UpsertNotificationPreferences :one
+INSERT INTO customer_notification_preferences (customer_id, email, phone, email_receipt, sms_ready_alert, email_ready_alert, marketing)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (customer_id) DO UPDATE SET
+    email = EXCLUDED.email,
+    phone = EXCLUDED.phone,
+    email_receipt = EXCLUDED.email_receipt,
+    sms_ready_alert = EXCLUDED.sms_ready_alert,
+    email_ready_alert = EXCLUDED.email_ready_alert,
+    marketing = EXCLUDED.marketing,
+    updated_at = NOW()
+RETURNING customer_id, email, phone, email_receipt, sms_ready_alert, email_ready_alert, marketing, updated_at;
@@ -0,0 +1,38 @@
+-- name: EnqueueNotification :exec
+INSERT INTO notification_outbox (id, channel, kind, recipient, subject, body, order_id, next_attempt_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8);
+
+-- name: ClaimDueNotifications :many
+-- Claimed notifications aren't due again until next_attempt_at, so other workers skip them
+-- and a worker that dies mid-batch leaves them to be retried
+UPDATE notification_outbox SET next_attempt_at = $2
+WHERE id IN (
+    SELECT id FROM notification_outbox
+    WHERE status = 'pending' AND next_attempt_at <= NOW()
+    ORDER BY next_attempt_at
+    LIMIT $1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, channel, kind, recipient, subject, body, order_id, status, attempts, next_attempt_at, last_error, created_at, sent_at;
+
+-- name: MarkNotificationSent :exec
+UPDATE notification_outbox SET status = 'sent', attempts = attempts + 1, last_error = NULL, sent_at = NOW() WHERE id = $1;
+
+-- name: MarkNotificationFailed :exec
+UPDATE notification_outbox SET attempts = attempts + 1, last_error = $2, next_attempt_at = $3 WHERE id = $1;
+
+-- name: MarkNotificationDead :exec
+UPDATE notification_outbox SET status = 'dead', attempts = attempts + 1, last_error = $2 WHERE id = $1;
+
+-- name: RedeliverNotification :execrows
+UPDATE notification_outbox SET status = 'pending', attempts = 0, next_attempt_at = NOW()
+WHERE id = $1 AND status = 'dead';
+
+-- name: GetNotificationOutboxStats :one
+SELECT
+    COUNT(*) FILTER (WHERE status = 'pending') AS pending,
+    COUNT(*) FILTER (WHERE status = 'pending' AND attempts > 0) AS retrying,
+    COUNT(*) FILTER (WHERE status = 'sent') AS sent,
+    COUNT(*) FILTER (WHERE status = 'dead') AS dead,
+    MIN(created_at) FILTER (WHERE status = 'pending')::timestamptz AS oldest_pending_at
+FROM notification_outbox;
@@ -1,20 +1,30 @@
 -- name: CreateOrder :one
-INSERT INTO orders (total, discounts, status)
-VALUES ($1, $2, $3)
-RETURNING id, total, discounts, status, created_at, updated_at;
+INSERT INTO orders (total, discounts, status, customer_id, payment_intent_id, payment_status, payment_method, store_credit, gift_card_code, coupon_code, store_id, sandbox)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+RETURNING id, total, discounts, status, created_at, updated_at, version, customer_id, payment_intent_id, payment_status, payment_method, store_credit, gift_card_code, coupon_code, store_id, sandbox;
 
 -- name: GetOrderByID :one
-SELECT id, total, discounts, status, created_at, updated_at
+SELECT id, total, discounts, status, created_at, updated_at, version, customer_id, payment_intent_id, payment_status, payment_method, store_credit, gift_card_code, coupon_code, store_id, sandbox
 FROM orders
 WHERE id = $1;
 
 -- name: CreateOrderItems :many
 INSERT INTO order_items (order_id, product_id, quantity, price_at_time)
 VALUES ($1, $2, $3, $4)
-RETURNING id, order_id, product_id, quantity, price_at_time, created_at;
+RETURNING id, order_id, product_id, quantity, price_at_time, created_at, options;
+
+-- name: CreateOrderItemsBatch :exec
+-- Options are each item's JSON array of options
+INSERT INTO order_items (order_id, product_id, quantity, price_at_time, options)
+SELECT @order_id::uuid, u.product_id, u.quantity, u.price_at_time, u.options::jsonb
+FROM unnest(@product_ids::uuid[], @quantities::int[], @prices::numeric[], @options::text[]) AS u(product_id, quantity, price_at_time, options);
+
+-- name: DeleteOrder :execrows
+-- Order items go with the order through ON DELETE CASCADE
+DELETE FROM orders WHERE id = $1;
 
 -- name: GetOrderItemsByOrderID :many
-SELECT oi.id, oi.order_id, oi.product_id, oi.quantity, oi.price_at_time, oi.created_at,
+SELECT oi.id, oi.order_id, oi.product_id, oi.quantity, oi.price_at_time, oi.created_at, oi.options,
        p.name, p.category, p.thumbnail_url, p.mobile_url, p.tablet_url, p.desktop_url
 FROM order_items oi
 JOIN products p ON oi.product_id = p.id
@@ -22,6 +32,107 @@ WHERE oi.order_id = $1;
 
 -- name: UpdateOrderStatus :one
 UPDATE orders 
-SET status = $2, updated_at = NOW()
-WHERE id = $1
-RETURNING id, total, discounts, status, created_at, updated_at;
\ No newline at end of file
+SET status = $2, version = version + 1
+WHERE id = $1 AND version = $3
+RETURNING id, total, discounts, status, created_at, updated_at, version, customer_id, payment_intent_id, payment_status, payment_method, store_credit, gift_card_code, coupon_code, store_id, sandbox;
+
+-- name: UpdateOrderPaymentStatus :execrows
+UPDATE orders
+SET payment_status = $2
+WHERE id = $1;
+
+-- name: GetPaymentMethodTotals :many
+-- Paid is what customers paid, after discounts and store credit, for orders placed from @since until before @until
+SELECT payment_method, COUNT(*) AS orders, COALESCE(SUM(total - COALESCE(discounts, 0) - store_credit), 0)::numeric AS paid
+FROM orders
+WHERE created_at >= @since::timestamp AND created_at < @until::timestamp AND status NOT IN ('cancelled', 'failed') AND NOT sandbox
+GROUP BY payment_method
+ORDER BY payment_method;
+
+-- name: GetTopProducts :many
+-- Revenue is at the prices charged, before order discounts
+SELECT p.id, p.name, SUM(oi.quantity)::bigint AS quantity, SUM(oi.price_at_time * oi.quantity)::numeric AS revenue
+FROM order_items oi
+JOIN orders o ON o.id = oi.order_id
+JOIN products p ON p.id = oi.product_id
+WHERE o.created_at >= @since::timestamp AND o.created_at < @until::timestamp AND o.status NOT IN ('cancelled', 'failed') AND NOT o.sandbox
+GROUP BY p.id, p.name
+ORDER BY quantity DESC, p.name
+LIMIT @max_products;
+
+-- name: GetSalesByDay :many
+-- Days start at midnight in @time_zone; created_at is UTC
+SELECT to_char((created_at AT TIME ZONE 'UTC') AT TIME ZONE @time_zone::text, 'YYYY-MM-DD') AS day,
+       COUNT(*) AS orders,
+       COALESCE(SUM(total - COALESCE(discounts, 0)), 0)::numeric AS revenue,
+       COALESCE(SUM(discounts), 0)::numeric AS discounts
+FROM orders
+WHERE created_at >= @since::timestamp AND created_at < @until::timestamp AND status NOT IN ('cancelled', 'failed') AND NOT sandbox
+  AND (sqlc.narg(store_id)::uuid IS NULL OR store_id = sqlc.narg(store_id)::uuid)
+GROUP BY day
+ORDER BY day;
+
+-- name: GetSalesByProduct :many
+-- Each item takes its share of the order's discounts in proportion to what it added to the order
+SELECT p.id, p.name, COUNT(DISTINCT o.id) AS orders, SUM(oi.quantity)::bigint AS quantity,
+       SUM(oi.price_at_time * oi.quantity * (1 - COALESCE(o.discounts, 0) / NULLIF(o.total, 0)))::numeric AS revenue,
+       COALESCE(SUM(oi.price_at_time * oi.quantity * COALESCE(o.discounts, 0) / NULLIF(o.total, 0)), 0)::numeric AS discounts
+FROM order_items oi
+JOIN orders o ON o.id = oi.order_id
+JOIN products p ON p.id = oi.product_id
+WHERE o.created_at >= @since::timestamp AND o.created_at < @until::timestamp AND o.status NOT IN ('cancelled', 'failed') AND NOT o.sandbox
+  AND (sqlc.narg(store_id)::uuid IS NULL OR o.store_id = sqlc.narg(store_id)::uuid)
+GROUP BY p.id, p.name
+ORDER BY revenue DESC, p.name;
+
+-- name: GetSalesByCategory :many
+SELECT p.category, COUNT(DISTINCT o.id) AS orders, SUM(oi.quantity)::bigint AS quantity,
+       SUM(oi.price_at_time * oi.quantity * (1 - COALESCE(o.discounts, 0) / NULLIF(o.total, 0)))::numeric AS revenue,
+       COALESCE(SUM(oi.price_at_time * oi.quantity * COALESCE(o.discounts, 0) / NULLIF(o.total, 0)), 0)::numeric AS discounts
+FROM order_items oi
+JOIN orders o ON o.id = oi.order_id
+JOIN products p ON p.id = oi.product_id
+WHERE o.created_at >= @since::timestamp AND o.created_at < @until::timestamp AND o.status NOT IN ('cancelled', 'failed') AND NOT o.sandbox
+  AND (sqlc.narg(store_id)::uuid IS NULL OR o.store_id = sqlc.narg(store_id)::uuid)
+GROUP BY p.category
+ORDER BY revenue DESC, p.category;
+
+-- name: GetCouponUsage :many
+SELECT coupon_code, COUNT(*) AS orders, COALESCE(SUM(discounts), 0)::numeric AS discounts
+FROM orders
+WHERE created_at >= @since::timestamp AND created_at < @until::timestamp AND status NOT IN ('cancelled', 'failed') AND NOT sandbox AND coupon_code <> ''
+GROUP BY coupon_code
+ORDER BY orders DESC, coupon_code;
+
+-- name: GetOrderSummaries :many
+SELECT id, total, discounts, status, created_at, updated_at, version, items, products, customer_id, payment_intent_id, payment_status, payment_method, store_credit, gift_card_code, coupon_code, store_id, sandbox
+FROM order_summaries
+ORDER BY created_at DESC;
+
+-- name: GetOrderSummariesPage :many
+SELECT id, total, discounts, status, created_at, updated_at, version, items, products, customer_id, payment_intent_id, payment_status, payment_method, store_credit, gift_card_code, coupon_code, store_id, sandbox
+FROM order_summaries
+WHERE sqlc.narg(store_id)::uuid IS NULL OR store_id = sqlc.narg(store_id)::uuid
+ORDER BY created_at DESC
+LIMIT $1 OFFSET $2;
+
+-- name: GetOrderSummaryByID :one
+SELECT id, total, discounts, status, created_at, updated_at, version, items, products, customer_id, payment_intent_id, payment_status, payment_method, store_credit, gift_card_code, coupon_code, store_id, sandbox
+FROM order_summaries
+WHERE id = $1;
+
+-- name: GetOrderSummaryByPaymentIntent :one
+SELECT id, total, discounts, status, created_at, updated_at, version, items, products, customer_id, payment_intent_id, payment_status, payment_method, store_credit, gift_card_code, coupon_code, store_id, sandbox
+FROM order_summaries
+WHERE payment_intent_id = $1;
+
+-- name: CountOrders :one
+SELECT COUNT(*) FROM orders
+WHERE sqlc.narg(store_id)::uuid IS NULL OR store_id = sqlc.narg(store_id)::uuid;
+
+
+-- name: GetOrderSummariesUpdatedSince :many
+SELECT id, total, discounts, status, created_at, updated_at, version, items, products, customer_id, payment_intent_id, payment_status, payment_method, store_credit, gift_card_code, coupon_code, store_id, sandbox
+FROM order_summaries
+WHERE updated_at >= $1
+ORDER BY updated_at, id;
\ No newline at end of file
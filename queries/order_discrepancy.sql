@@ -0,0 +1,23 @@
+-- name: DeleteResolvedOrderDiscrepancies :exec
+-- Removes the discrepancies of the @checked orders that the run didn't find again
+DELETE FROM order_discrepancies d
+WHERE d.order_id = ANY(@checked::uuid[])
+  AND NOT EXISTS (
+    SELECT 1 FROM unnest(@order_ids::uuid[], @kinds::text[]) AS f(order_id, kind)
+    WHERE f.order_id = d.order_id AND f.kind = d.kind
+  );
+
+-- name: UpsertOrderDiscrepancies :exec
+-- Discrepancies found before keep the time they were first detected
+INSERT INTO order_discrepancies (order_id, kind, recorded, expected)
+SELECT u.order_id, u.kind, u.recorded, u.expected
+FROM unnest(@order_ids::uuid[], @kinds::text[], @recorded::numeric[], @expected::numeric[]) AS u(order_id, kind, recorded, expected)
+ON CONFLICT (order_id, kind) DO UPDATE
+SET recorded = EXCLUDED.recorded, expected = EXCLUDED.expected, checked_at = NOW();
+
+-- name: ListOrderDiscrepancies :many
+SELECT d.order_id, o.store_id, d.kind, d.recorded, d.expected, o.coupon_code, o.created_at, d.detected_at, d.checked_at
+FROM order_discrepancies d
+JOIN orders o ON o.id = d.order_id
+WHERE sqlc.narg(store_id)::uuid IS NULL OR o.store_id = sqlc.narg(store_id)::uuid
+ORDER BY d.detected_at DESC, d.order_id, d.kind;
@@ -0,0 +1,26 @@
+-- name: InsertOutboxEvent :exec
+INSERT INTO outbox_events (aggregate_type, aggregate_id, event_type, payload)
+VALUES ($1, $2, $3, $4);
+
+-- name: ClaimDueOutboxEvents :many
+-- Claimed events aren't due again until next_attempt_at, so other relays skip them without
+-- the rows staying locked while they are published, and a relay that dies mid-batch leaves
+-- them to be retried
+UPDATE outbox_events SET next_attempt_at = $2
+WHERE id IN (
+    SELECT id FROM outbox_events
+    WHERE published_at IS NULL AND dead_at IS NULL AND next_attempt_at <= NOW()
+    ORDER BY created_at
+    LIMIT $1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, aggregate_type, aggregate_id, event_type, payload, created_at, published_at, attempts, last_error, next_attempt_at, dead_at;
+
+-- name: MarkOutboxEventPublished :exec
+UPDATE outbox_events SET published_at = NOW() WHERE id = $1;
+
+-- name: MarkOutboxEventFailed :exec
+UPDATE outbox_events SET attempts = attempts + 1, last_error = $2, next_attempt_at = $3 WHERE id = $1;
+
+-- name: MarkOutboxEventDead :exec
+UPDATE outbox_events SET attempts = attempts + 1, last_error = $2, dead_at = NOW() WHERE id = $1;
@@ -0,0 +1,7 @@
+-- name: DeletePaymentEvent :execrows
+DELETE FROM payment_events WHERE id = $1;
+
+-- name: InsertPaymentEvent :execrows
+INSERT INTO payment_events (id, event_type)
+VALUES ($1, $2)
+ON CONFLICT (id) DO NOTHING;
@@ -0,0 +1,39 @@
+-- name: GetPricingTiers :many
+SELECT name, discount_percentage, updated_at FROM pricing_tiers
+ORDER BY name;
+
+-- name: GetPricingTier :one
+SELECT name, discount_percentage, updated_at FROM pricing_tiers
+WHERE name = $1;
+
+-- name: UpsertPricingTier :one
+INSERT INTO pricing_tiers (name, discount_percentage)
+VALUES ($1, $2)
+ON CONFLICT (name) DO UPDATE SET discount_percentage = EXCLUDED.discount_percentage, updated_at = NOW()
+RETURNING name, discount_percentage, updated_at;
+
+-- name: DeletePricingTier :execrows
+DELETE FROM pricing_tiers WHERE name = $1;
+
+-- name: GetPricingTierAssignments :many
+SELECT subject_type, subject, tier, created_at FROM pricing_tier_assignments
+ORDER BY subject_type, subject;
+
+-- name: UpsertPricingTierAssignment :exec
+INSERT INTO pricing_tier_assignments (subject_type, subject, tier)
+VALUES ($1, $2, $3)
+ON CONFLICT (subject_type, subject) DO UPDATE SET tier = EXCLUDED.tier, created_at = NOW();
+
+-- name: DeletePricingTierAssignment :execrows
+DELETE FROM pricing_tier_assignments
+WHERE subject_type = $1 AND subject = $2;
+
+-- name: ResolvePricingTier :one
+-- The customer's tier wins over the API key's
+SELECT t.name, t.discount_percentage, t.updated_at
+FROM pricing_tier_assignments a
+JOIN pricing_tiers t ON t.name = a.tier
+WHERE (a.subject_type = 'customer' AND a.subject = @customer_id::varchar)
+   OR (a.subject_type = 'api_key' AND a.subject = @api_key_hash::varchar)
+ORDER BY a.subject_type = 'customer' DESC
+LIMIT 1;
@@ -1,23 +1,73 @@
 -- name: GetProducts :many
-SELECT id, name, price, category, thumbnail_url, mobile_url, tablet_url, desktop_url, created_at, updated_at
+SELECT id, name, price, category, thumbnail_url, mobile_url, tablet_url, desktop_url, created_at, updated_at, version, deleted_at, store_id
 FROM products
+WHERE deleted_at IS NULL
 ORDER BY name;
 
 -- name: GetProductByID :one
-SELECT id, name, price, category, thumbnail_url, mobile_url, tablet_url, desktop_url, created_at, updated_at
+SELECT id, name, price, category, thumbnail_url, mobile_url, tablet_url, desktop_url, created_at, updated_at, version, deleted_at, store_id
 FROM products
-WHERE id = $1;
+WHERE id = $1 AND deleted_at IS NULL;
 
 -- name: CreateProduct :one
-INSERT INTO products (name, price, category, thumbnail_url, mobile_url, tablet_url, desktop_url)
-VALUES ($1, $2, $3, $4, $5, $6, $7)
-RETURNING id, name, price, category, thumbnail_url, mobile_url, tablet_url, desktop_url, created_at, updated_at;
+INSERT INTO products (name, price, category, thumbnail_url, mobile_url, tablet_url, desktop_url, store_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING id, name, price, category, thumbnail_url, mobile_url, tablet_url, desktop_url, created_at, updated_at, version, deleted_at, store_id;
 
 -- name: UpdateProduct :one
 UPDATE products 
-SET name = $2, price = $3, category = $4, thumbnail_url = $5, mobile_url = $6, tablet_url = $7, desktop_url = $8, updated_at = NOW()
-WHERE id = $1
-RETURNING id, name, price, category, thumbnail_url, mobile_url, tablet_url, desktop_url, created_at, updated_at;
+SET name = $2, price = $3, category = $4, thumbnail_url = $5, mobile_url = $6, tablet_url = $7, desktop_url = $8, version = version + 1
+WHERE id = $1 AND version = $9 AND deleted_at IS NULL
+RETURNING id, name, price, category, thumbnail_url, mobile_url, tablet_url, desktop_url, created_at, updated_at, version, deleted_at, store_id;
 
--- name: DeleteProduct :exec
-DELETE FROM products WHERE id = $1;
\ No newline at end of file
+-- name: DeleteProduct :execrows
+UPDATE products SET deleted_at = NOW(), version = version + 1
+WHERE id = $1 AND deleted_at IS NULL;
+
+-- name: RestoreProduct :one
+UPDATE products SET deleted_at = NULL, version = version + 1
+WHERE id = $1 AND deleted_at IS NOT NULL
+RETURNING id, name, price, category, thumbnail_url, mobile_url, tablet_url, desktop_url, created_at, updated_at, version, deleted_at, store_id;
+
+-- name: GetDeletedProducts :many
+SELECT id, name, price, category, thumbnail_url, mobile_url, tablet_url, desktop_url, created_at, updated_at, version, deleted_at, store_id
+FROM products
+WHERE deleted_at >= $1
+ORDER BY deleted_at DESC;
+
+-- name: GetProductsByIDs :many
+SELECT id, name, price, category, thumbnail_url, mobile_url, tablet_url, desktop_url, created_at, updated_at, version, deleted_at, store_id
+FROM products
+WHERE id = ANY(@ids::uuid[]) AND deleted_at IS NULL
+ORDER BY name;
+
+-- name: GetProductsPage :many
+SELECT id, name, price, category, thumbnail_url, mobile_url, tablet_url, desktop_url, created_at, updated_at, version, deleted_at, store_id
+FROM products
+WHERE deleted_at IS NULL
+  AND (sqlc.narg(store_id)::uuid IS NULL OR store_id = sqlc.narg(store_id)::uuid)
+ORDER BY name, id
+LIMIT $1 OFFSET $2;
+
+-- name: CountProducts :one
+SELECT COUNT(*) FROM products
+WHERE deleted_at IS NULL
+  AND (sqlc.narg(store_id)::uuid IS NULL OR store_id = sqlc.narg(store_id)::uuid);
+
+-- name: GetProductsUpdatedSince :many
+-- Includes soft-deleted products so sync clients learn about deletions
+SELECT id, name, price, category, thumbnail_url, mobile_url, tablet_url, desktop_url, created_at, updated_at, version, deleted_at, store_id
+FROM products
+WHERE updated_at >= $1
+ORDER BY updated_at, id;
+
+-- name: InsertProductIfMissing :execrows
+-- Seeded products are logged in the change feed
+WITH inserted AS (
+  INSERT INTO products (name, price, category, thumbnail_url, mobile_url, tablet_url, desktop_url)
+  SELECT @name::varchar, @price::numeric, @category::varchar, @thumbnail_url::text, @mobile_url::text, @tablet_url::text, @desktop_url::text
+  WHERE NOT EXISTS (SELECT 1 FROM products WHERE name = @name::varchar)
+  RETURNING id
+)
+INSERT INTO product_changes (product_id, kind)
+SELECT id, 'created' FROM inserted;
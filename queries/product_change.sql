@@ -0,0 +1,16 @@
+-- name: InsertProductChange :exec
+-- Writers wait for each other until their transactions end, so changes commit in seq order
+-- and a reader past one never misses an earlier one
+WITH serialized AS (SELECT pg_advisory_xact_lock(hashtext('product_changes')))
+INSERT INTO product_changes (product_id, kind)
+SELECT @product_id::uuid, @kind::varchar FROM serialized;
+
+-- name: GetProductChanges :many
+-- Changes are listed with the product as it is now
+SELECT product_changes.seq, product_changes.kind, product_changes.changed_at, sqlc.embed(products)
+FROM product_changes
+JOIN products ON products.id = product_changes.product_id
+WHERE product_changes.seq > @after::bigint
+  AND (sqlc.narg(store_id)::uuid IS NULL OR products.store_id = sqlc.narg(store_id)::uuid)
+ORDER BY product_changes.seq
+LIMIT @max_rows;
@@ -0,0 +1,17 @@
+-- name: GetProductOptions :many
+SELECT id, product_id, kind, name, price_delta, position, created_at
+FROM product_options
+WHERE product_id = ANY(@product_ids::uuid[])
+ORDER BY product_id, kind, position;
+
+-- name: UpsertProductOption :exec
+-- An option of another product is left alone
+INSERT INTO product_options (id, product_id, kind, name, price_delta, position)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (id) DO UPDATE
+SET kind = EXCLUDED.kind, name = EXCLUDED.name, price_delta = EXCLUDED.price_delta, position = EXCLUDED.position
+WHERE product_options.product_id = EXCLUDED.product_id;
+
+-- name: DeleteProductOptionsExcept :exec
+DELETE FROM product_options
+WHERE product_id = @product_id AND NOT (id = ANY(@keep_ids::uuid[]));
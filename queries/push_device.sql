@@ -0,0 +1,18 @@
+-- name: UpsertPushDevice :exec
+-- A token registered again moves to the customer registering it
+INSERT INTO push_devices (token, customer_id, platform, created_at)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (token) DO UPDATE SET customer_id = EXCLUDED.customer_id, platform = EXCLUDED.platform, created_at = EXCLUDED.created_at;
+
+-- name: GetPushDevicesByCustomer :many
+SELECT token, customer_id, platform, created_at FROM push_devices
+WHERE customer_id = $1
+ORDER BY created_at DESC, token;
+
+-- name: DeleteCustomerPushDevice :execrows
+DELETE FROM push_devices
+WHERE customer_id = $1 AND token = $2;
+
+-- name: DeletePushDevice :exec
+DELETE FROM push_devices
+WHERE token = $1;
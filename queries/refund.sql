@@ -0,0 +1,16 @@
+-- name: CreateOrderRefund :one
+INSERT INTO order_refunds (order_id, payment_intent_id, amount)
+VALUES ($1, $2, $3)
+RETURNING id, order_id, payment_intent_id, amount, status, provider_refund_id, error, created_at, updated_at;
+
+-- name: GetOrderRefunds :many
+SELECT id, order_id, payment_intent_id, amount, status, provider_refund_id, error, created_at, updated_at
+FROM order_refunds
+WHERE order_id = $1
+ORDER BY created_at, id;
+
+-- name: UpdateOrderRefund :one
+UPDATE order_refunds
+SET status = $2, provider_refund_id = $3, error = $4
+WHERE id = $1
+RETURNING id, order_id, payment_intent_id, amount, status, provider_refund_id, error, created_at, updated_at;
@@ -0,0 +1,26 @@
+-- name: RefreshCustomerSegments :execrows
+-- Spend is what customers paid, after discounts. The CASE mirrors models.SegmentRules.
+INSERT INTO customer_segments (customer_id, segment, order_count, total_spent, first_order_at, last_order_at, computed_at)
+SELECT o.customer_id,
+    CASE
+        WHEN MAX(o.created_at) <= @lapsed_before::timestamp THEN 'lapsed'
+        WHEN COALESCE(SUM(o.total - COALESCE(o.discounts, 0)) FILTER (WHERE o.created_at > @vip_since::timestamp), 0) >= @vip_spend::numeric THEN 'vip'
+        WHEN MIN(o.created_at) > @new_since::timestamp THEN 'new'
+        ELSE 'regular'
+    END,
+    COUNT(*), SUM(o.total - COALESCE(o.discounts, 0)), MIN(o.created_at), MAX(o.created_at), NOW()
+FROM orders o
+WHERE o.customer_id IS NOT NULL AND o.status NOT IN ('cancelled', 'failed') AND NOT o.sandbox
+GROUP BY o.customer_id
+ON CONFLICT (customer_id) DO UPDATE SET
+    segment = EXCLUDED.segment,
+    order_count = EXCLUDED.order_count,
+    total_spent = EXCLUDED.total_spent,
+    first_order_at = EXCLUDED.first_order_at,
+    last_order_at = EXCLUDED.last_order_at,
+    computed_at = EXCLUDED.computed_at;
+
+-- name: GetCustomerSegment :one
+SELECT customer_id, segment, order_count, total_spent, first_order_at, last_order_at, computed_at
+FROM customer_segments
+WHERE customer_id = $1;
@@ -0,0 +1,48 @@
+-- name: GetStores :many
+SELECT id, name, created_at, updated_at FROM stores
+ORDER BY name, id;
+
+-- name: GetStore :one
+SELECT id, name, created_at, updated_at FROM stores
+WHERE id = $1;
+
+-- name: CreateStore :one
+INSERT INTO stores (name)
+VALUES ($1)
+RETURNING id, name, created_at, updated_at;
+
+-- name: UpdateStore :one
+UPDATE stores SET name = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING id, name, created_at, updated_at;
+
+-- name: GetStoreAPIKeys :many
+SELECT id, store_id, name, key_hash, key_prefix, created_at, revoked_at, test FROM store_api_keys
+WHERE store_id = $1
+ORDER BY created_at, id;
+
+-- name: CreateStoreAPIKey :one
+INSERT INTO store_api_keys (store_id, name, key_hash, key_prefix, test)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, store_id, name, key_hash, key_prefix, created_at, revoked_at, test;
+
+-- name: RevokeStoreAPIKey :execrows
+UPDATE store_api_keys SET revoked_at = NOW()
+WHERE id = $1 AND store_id = $2 AND revoked_at IS NULL;
+
+-- name: GetStoreAPIKeyByHash :one
+SELECT id, store_id, name, key_hash, key_prefix, created_at, revoked_at, test FROM store_api_keys
+WHERE key_hash = $1 AND revoked_at IS NULL;
+
+-- name: GetStoreOpeningHours :one
+SELECT store_id, hours, updated_at FROM store_opening_hours
+WHERE store_id = $1;
+
+-- name: SetStoreOpeningHours :exec
+INSERT INTO store_opening_hours (store_id, hours)
+VALUES ($1, $2)
+ON CONFLICT (store_id) DO UPDATE SET hours = EXCLUDED.hours, updated_at = NOW();
+
+-- name: DeleteStoreOpeningHours :execrows
+DELETE FROM store_opening_hours
+WHERE store_id = $1;
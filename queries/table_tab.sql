@@ -0,0 +1,23 @@
+-- name: CloseTableTab :one
+UPDATE table_tabs
+SET status = 'closed', payment_method = $2, closed_at = NOW()
+WHERE id = $1 AND status = 'open'
+RETURNING id, store_id, table_number, status, payment_method, opened_at, closed_at;
+
+-- name: GetOpenTableTab :one
+SELECT id, store_id, table_number, status, payment_method, opened_at, closed_at
+FROM table_tabs
+WHERE store_id = $1 AND table_number = $2 AND status = 'open';
+
+-- name: ListOpenTableTabs :many
+SELECT id, store_id, table_number, status, payment_method, opened_at, closed_at
+FROM table_tabs
+WHERE store_id = $1 AND status = 'open'
+ORDER BY opened_at, id;
+
+-- name: OpenTableTab :one
+-- Returns no row when the table already has an open tab
+INSERT INTO table_tabs (store_id, table_number)
+VALUES ($1, $2)
+ON CONFLICT (store_id, table_number) WHERE status = 'open' DO NOTHING
+RETURNING id, store_id, table_number, status, payment_method, opened_at, closed_at;
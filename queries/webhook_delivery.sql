@@ -0,0 +1,59 @@
+-- name: EnqueueWebhookDelivery :execrows
+-- Nothing is stored when the endpoint already has a delivery of the event
+INSERT INTO webhook_deliveries (id, endpoint, event_id, event_type, body, next_attempt_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (endpoint, event_id) DO NOTHING;
+
+-- name: ClaimDueWebhookDeliveries :many
+-- Claimed deliveries aren't due again until next_attempt_at, so other workers skip them
+-- and a worker that dies mid-batch leaves them to be retried
+UPDATE webhook_deliveries SET next_attempt_at = $2
+WHERE id IN (
+    SELECT id FROM webhook_deliveries
+    WHERE status = 'pending' AND next_attempt_at <= NOW()
+    ORDER BY next_attempt_at
+    LIMIT $1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, endpoint, event_id, event_type, body, status, attempts, next_attempt_at, last_error, created_at, delivered_at;
+
+-- name: MarkWebhookDelivered :exec
+UPDATE webhook_deliveries SET status = 'delivered', attempts = attempts + 1, last_error = NULL, delivered_at = NOW() WHERE id = $1;
+
+-- name: MarkWebhookDeliveryFailed :exec
+UPDATE webhook_deliveries SET attempts = attempts + 1, last_error = $2, next_attempt_at = $3 WHERE id = $1;
+
+-- name: MarkWebhookDeliveryDead :exec
+UPDATE webhook_deliveries SET status = 'dead', attempts = attempts + 1, last_error = $2 WHERE id = $1;
+
+-- name: InsertWebhookDeliveryAttempt :exec
+INSERT INTO webhook_delivery_attempts (delivery_id, url, status_code, error, duration_ms, attempted_at)
+VALUES ($1, $2, $3, $4, $5, $6);
+
+-- name: RedeliverWebhookDelivery :execrows
+UPDATE webhook_deliveries SET status = 'pending', attempts = 0, next_attempt_at = NOW()
+WHERE id = $1 AND status = 'dead';
+
+-- name: GetWebhookDelivery :one
+SELECT id, endpoint, event_id, event_type, body, status, attempts, next_attempt_at, last_error, created_at, delivered_at
+FROM webhook_deliveries
+WHERE id = $1;
+
+-- name: GetWebhookDeliveryAttempts :many
+SELECT id, delivery_id, url, status_code, error, duration_ms, attempted_at
+FROM webhook_delivery_attempts
+WHERE delivery_id = $1
+ORDER BY id;
+
+-- name: ListWebhookDeliveries :many
+-- An empty status lists deliveries of every status
+SELECT id, endpoint, event_id, event_type, body, status, attempts, next_attempt_at, last_error, created_at, delivered_at
+FROM webhook_deliveries
+WHERE @status::text = '' OR status = @status::text
+ORDER BY created_at DESC
+LIMIT @page_limit OFFSET @page_offset;
+
+-- name: CountWebhookDeliveries :one
+SELECT COUNT(*)
+FROM webhook_deliveries
+WHERE @status::text = '' OR status = @status::text;
@@ -0,0 +1,7 @@
+// Package seeds embeds the sample data loaded by the "seed" command.
+package seeds
+
+import "embed"
+
+//go:embed *.json
+var FS embed.FS
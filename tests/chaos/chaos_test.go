@@ -0,0 +1,63 @@
+package chaos
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"oolio/internal/chaos"
+	"oolio/internal/config"
+)
+
+func TestInjector_Faults(t *testing.T) {
+	ctx := context.Background()
+	injector := chaos.New(config.ChaosConfig{
+		Enabled:  true,
+		HTTP:     config.FaultConfig{Latency: 20 * time.Millisecond, LatencyPercent: 100},
+		Database: config.FaultConfig{ErrorPercent: 100},
+	})
+
+	start := time.Now()
+	assert.False(t, injector.HTTP.Inject(ctx))
+	assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+	assert.True(t, injector.Database.Inject(ctx))
+	assert.False(t, injector.Redis.Inject(ctx))
+	assert.False(t, injector.Redis.Active())
+
+	// Latency gives way to a canceled call
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	injector.Configure(config.ChaosConfig{Enabled: true, HTTP: config.FaultConfig{Latency: time.Minute, LatencyPercent: 100}})
+	start = time.Now()
+	injector.HTTP.Inject(canceled)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.False(t, injector.Database.Inject(ctx), "reconfiguring replaces every fault")
+
+	// Disabling turns every fault off
+	injector.Configure(config.ChaosConfig{Database: config.FaultConfig{ErrorPercent: 100}})
+	assert.False(t, injector.Database.Active())
+
+	var fault *chaos.Fault
+	assert.False(t, fault.Inject(ctx))
+}
+
+func TestRedisHook_FailsCommandsWithoutSendingThem(t *testing.T) {
+	// Nothing listens on the address, so a command that was sent would fail to connect
+	client := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1})
+	defer client.Close()
+	client.AddHook(chaos.NewRedisHook(chaos.New(config.ChaosConfig{Enabled: true, Redis: config.FaultConfig{ErrorPercent: 100}}).Redis))
+
+	err := client.Get(context.Background(), "key").Err()
+	require.Error(t, err)
+	assert.ErrorIs(t, err, chaos.ErrInjected)
+
+	_, err = client.Pipelined(context.Background(), func(pipe redis.Pipeliner) error {
+		pipe.Incr(context.Background(), "key")
+		return nil
+	})
+	assert.ErrorIs(t, err, chaos.ErrInjected)
+}
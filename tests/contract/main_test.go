@@ -0,0 +1,167 @@
+// Package contract checks the API's responses against its OpenAPI document. The fx app
+// boots in-process on the memory drivers, and every response the tests get is validated
+// with openapi.Document.ValidateResponse under every API version: its status must be
+// documented, its headers set and its body must match the schema. The suite fails when a
+// product, order or queue operation has no test.
+package contract
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/fx"
+
+	providerfx "oolio/internal/app/fx"
+	"oolio/internal/app/openapi"
+	"oolio/internal/app/router"
+	"oolio/internal/app/worker"
+)
+
+const (
+	apiKey          = "contract-api-key"
+	retryLinkSecret = "contract-retry-secret"
+)
+
+var (
+	engine *gin.Engine
+	doc    = router.NewDocument()
+
+	// covered holds the operations, as method and path, that a test got a response from
+	covered = make(map[string]bool)
+)
+
+func TestMain(m *testing.M) {
+	code, err := run(m)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "contract suite:", err)
+		os.Exit(1)
+	}
+	if code == 0 {
+		if missing := uncovered(); len(missing) > 0 {
+			fmt.Fprintln(os.Stderr, "operations without a contract test:\n\t"+strings.Join(missing, "\n\t"))
+			code = 1
+		}
+	}
+	os.Exit(code)
+}
+
+func run(m *testing.M) (int, error) {
+	for key, value := range map[string]string{
+		"DB_DRIVER":                 "memory",
+		"REDIS_DRIVER":              "memory",
+		"API_KEY":                   apiKey,
+		"ORDER_LOOKUP_SECRET":       "contract-lookup-secret",
+		"ALERT_RETRY_LINK_SECRET":   retryLinkSecret,
+		"ALERT_RETRY_LINK_BASE_URL": "http://localhost",
+		"WORKER_INTERVAL":           "50ms",
+		"LOG_LEVEL":                 "error",
+		// The suite makes more requests than a client would in a minute
+		"RATE_LIMIT_PRODUCT": "10000",
+		"RATE_LIMIT_ORDER":   "10000",
+		"RATE_LIMIT_QUEUE":   "10000",
+	} {
+		os.Setenv(key, value)
+	}
+
+	gin.SetMode(gin.TestMode)
+	var orderWorker *worker.OrderWorker
+	app := fx.New(
+		providerfx.AppModule,
+		fx.Populate(&engine, &orderWorker),
+		fx.NopLogger,
+	)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if err := app.Start(ctx); err != nil {
+		return 0, fmt.Errorf("failed to start the app: %w", err)
+	}
+	defer app.Stop(context.Background())
+
+	workerCtx, stopWorker := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		orderWorker.Start(workerCtx)
+	}()
+	defer func() {
+		stopWorker()
+		<-stopped
+	}()
+
+	return m.Run(), nil
+}
+
+// inScope reports whether the suite must cover op
+func inScope(op openapi.Operation) bool {
+	return op.Tag == "product" || op.Tag == "order" || strings.Contains(op.Path, "/queue/")
+}
+
+func uncovered() []string {
+	var missing []string
+	for _, op := range router.Operations() {
+		if inScope(op) && !covered[op.Method+" "+op.Path] {
+			missing = append(missing, op.Method+" "+op.Path)
+		}
+	}
+	slices.Sort(missing)
+	return missing
+}
+
+// call is a request to a versioned route
+type call struct {
+	method string
+	route  string // the documented path below the API prefix, e.g. /product/:productId
+	path   string // the path requested, with its query; route when empty
+	header map[string]string
+	body   any
+}
+
+// do makes the request under every API version with the API key, unless the call sets
+// another, validates each response against the document and returns the last
+func (c call) do(t *testing.T) *httptest.ResponseRecorder {
+	t.Helper()
+	path := c.path
+	if path == "" {
+		path = c.route
+	}
+
+	var w *httptest.ResponseRecorder
+	for _, version := range router.APIVersions {
+		prefix := router.APIPrefix(version)
+
+		var payload bytes.Buffer
+		if c.body != nil {
+			require.NoError(t, json.NewEncoder(&payload).Encode(c.body))
+		}
+		req := httptest.NewRequest(c.method, prefix+path, &payload)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-API-Key", apiKey)
+		for name, value := range c.header {
+			req.Header.Set(name, value)
+		}
+
+		w = httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+
+		covered[c.method+" "+prefix+c.route] = true
+		assert.NoError(t, doc.ValidateResponse(c.method, prefix+c.route, w.Code, w.Header(), w.Body.Bytes()))
+	}
+	return w
+}
+
+// decode decodes the body of a response into out
+func decode(t *testing.T, w *httptest.ResponseRecorder, out any) {
+	t.Helper()
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), out), w.Body.String())
+}
@@ -0,0 +1,229 @@
+package contract
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"oolio/internal/app/models"
+	"oolio/internal/app/services"
+)
+
+// placeOrder queues an order for the first product and waits for the worker to create it
+func placeOrder(t *testing.T) (models.QueuedOrder, *models.Order) {
+	t.Helper()
+	product := products(t)[0]
+
+	w := call{method: http.MethodPost, route: "/order", body: models.OrderReq{
+		Items: []models.OrderItem{{ProductID: product.ID, Quantity: 2}},
+	}}.do(t)
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+	var queued models.QueuedOrder
+	decode(t, w, &queued)
+	require.NotEmpty(t, queued.QueueItemID)
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		w = call{method: http.MethodGet, route: "/order/:orderId", path: "/order/" + queued.QueueItemID}.do(t)
+		if w.Code == http.StatusOK {
+			break
+		}
+		require.Equal(t, http.StatusNotFound, w.Code)
+		require.True(t, time.Now().Before(deadline), "order %s was not processed", queued.QueueItemID)
+		time.Sleep(50 * time.Millisecond)
+	}
+	var order models.Order
+	decode(t, w, &order)
+	return queued, &order
+}
+
+func TestPlaceOrder(t *testing.T) {
+	queued, order := placeOrder(t)
+	assert.NotEmpty(t, queued.LookupToken, "guests get a lookup token")
+	assert.NotEmpty(t, order.ID)
+
+	productID := products(t)[0].ID
+	for _, tc := range []struct {
+		name   string
+		items  []models.OrderItem
+		status int
+		errors []models.FieldError
+	}{
+		{
+			name:   "no items",
+			status: http.StatusBadRequest,
+			errors: []models.FieldError{{Field: "items", Rule: "required", Message: "items is required"}},
+		},
+		{
+			name:   "empty items",
+			items:  []models.OrderItem{},
+			status: http.StatusBadRequest,
+			errors: []models.FieldError{{Field: "items", Rule: "min", Message: "items must have at least 1 item(s)"}},
+		},
+		{
+			name:   "quantity out of range",
+			items:  []models.OrderItem{{ProductID: productID, Quantity: 0}},
+			status: http.StatusUnprocessableEntity,
+			errors: []models.FieldError{{Field: "items[0].quantity", Rule: "min", Message: "items[0].quantity must be at least 1"}},
+		},
+		{
+			name:   "malformed product ID",
+			items:  []models.OrderItem{{ProductID: productID, Quantity: 1}, {ProductID: "not-a-uuid", Quantity: -1}},
+			status: http.StatusBadRequest,
+			errors: []models.FieldError{
+				{Field: "items[1].productId", Rule: "uuid4", Message: "items[1].productId must be a UUID"},
+				{Field: "items[1].quantity", Rule: "min", Message: "items[1].quantity must be at least 1"},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			w := call{method: http.MethodPost, route: "/order", body: models.OrderReq{Items: tc.items}}.do(t)
+			require.Equal(t, tc.status, w.Code)
+			var response models.ApiResponse
+			decode(t, w, &response)
+			assert.Equal(t, tc.errors, response.Errors)
+			assert.Equal(t, tc.errors[0].Message, response.Message)
+		})
+	}
+
+	w := call{method: http.MethodPost, route: "/order", body: "not an order"}.do(t)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestGetOrder(t *testing.T) {
+	queued, order := placeOrder(t)
+
+	w := call{method: http.MethodGet, route: "/order/:orderId", path: "/order/" + order.ID}.do(t)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = call{method: http.MethodGet, route: "/order/:orderId", path: "/order/" + queued.QueueItemID,
+		header: map[string]string{"X-API-Key": "", "X-Order-Token": queued.LookupToken}}.do(t)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = call{method: http.MethodGet, route: "/order/:orderId", path: "/order/" + queued.QueueItemID,
+		header: map[string]string{"X-API-Key": "", "X-Order-Token": "0.forged"}}.do(t)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = call{method: http.MethodGet, route: "/order/:orderId", path: "/order/00000000-0000-0000-0000-000000000000"}.do(t)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestListOrders(t *testing.T) {
+	placeOrder(t)
+
+	w := call{method: http.MethodGet, route: "/order"}.do(t)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var list models.OrderList
+	decode(t, w, &list)
+	assert.NotEmpty(t, list.Orders)
+
+	w = call{method: http.MethodGet, route: "/order", path: "/order?updated_since=2020-01-01T00:00:00Z"}.do(t)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = call{method: http.MethodGet, route: "/order", path: "/order?updated_since=yesterday"}.do(t)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestPayOrder(t *testing.T) {
+	queued, _ := placeOrder(t)
+
+	// The suite runs without a payment provider
+	w := call{method: http.MethodPost, route: "/order/:orderId/pay", path: "/order/" + queued.QueueItemID + "/pay"}.do(t)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestOrderInvoice(t *testing.T) {
+	queued, _ := placeOrder(t)
+
+	w := call{method: http.MethodGet, route: "/order/:orderId/invoice", path: "/order/" + queued.QueueItemID + "/invoice"}.do(t)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = call{method: http.MethodGet, route: "/order/:orderId/invoice", path: "/order/00000000-0000-0000-0000-000000000000/invoice"}.do(t)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestQueueStatus(t *testing.T) {
+	placeOrder(t)
+
+	w := call{method: http.MethodGet, route: "/queue/status"}.do(t)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var status models.QueueStatus
+	decode(t, w, &status)
+	assert.Positive(t, status.QueueStats["completed"])
+}
+
+func TestRetryQueueItem(t *testing.T) {
+	queued, _ := placeOrder(t)
+
+	// Only failed items can be retried
+	w := call{method: http.MethodPost, route: "/admin/queue/:itemId/retry", path: "/admin/queue/" + queued.QueueItemID + "/retry"}.do(t)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	link, err := url.Parse(services.NewRetryLinks(retryLinkSecret, "http://localhost", time.Hour).URL(queued.QueueItemID))
+	require.NoError(t, err)
+	path := strings.TrimPrefix(link.Path, "/api/v1") + "?" + link.RawQuery
+	forged := "/queue/" + queued.QueueItemID + "/retry?expires=" + link.Query().Get("expires") + "&signature=forged"
+
+	w = call{method: http.MethodGet, route: "/queue/:itemId/retry", path: path}.do(t)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = call{method: http.MethodGet, route: "/queue/:itemId/retry", path: forged}.do(t)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = call{method: http.MethodPost, route: "/queue/:itemId/retry", path: path}.do(t)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	w = call{method: http.MethodPost, route: "/queue/:itemId/retry", path: forged}.do(t)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestInspectQueueItem(t *testing.T) {
+	queued, order := placeOrder(t)
+
+	w := call{method: http.MethodGet, route: "/admin/queue/:itemId", path: "/admin/queue/" + queued.QueueItemID}.do(t)
+	require.Equal(t, http.StatusOK, w.Code)
+	var details models.QueueItemDetails
+	decode(t, w, &details)
+	assert.Equal(t, queued.QueueItemID, details.ID)
+	assert.Equal(t, "completed", details.Status)
+	assert.Equal(t, order.ID, details.OrderID)
+	assert.NotEmpty(t, details.ClaimedBy)
+	assert.NotNil(t, details.ClaimedAt)
+	assert.Len(t, details.OrderReq.Items, 1)
+	assert.Empty(t, details.Attempts)
+
+	w = call{method: http.MethodGet, route: "/admin/queue/:itemId", path: "/admin/queue/00000000-0000-0000-0000-000000000000"}.do(t)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestPlaceOrder_OutOfStock(t *testing.T) {
+	// The last product, so the other tests' orders of the first aren't counted
+	all := products(t)
+	product := all[len(all)-1]
+
+	w := call{method: http.MethodPost, route: "/admin/products/:productId/restock", path: "/admin/products/" + product.ID + "/restock",
+		body: models.RestockReq{Quantity: 1}}.do(t)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var level models.StockLevel
+	decode(t, w, &level)
+	assert.Equal(t, product.ID, level.ProductID)
+
+	w = call{method: http.MethodPost, route: "/order", body: models.OrderReq{
+		Items: []models.OrderItem{{ProductID: product.ID, Quantity: level.Quantity + 1}},
+	}}.do(t)
+	require.Equal(t, http.StatusUnprocessableEntity, w.Code, w.Body.String())
+	var response models.ApiResponse
+	decode(t, w, &response)
+	assert.Equal(t, fmt.Sprintf("out of stock: %s has %d left", product.Name, level.Quantity), response.Message)
+
+	w = call{method: http.MethodGet, route: "/admin/inventory/low-stock", path: "/admin/inventory/low-stock?threshold=100"}.do(t)
+	require.Equal(t, http.StatusOK, w.Code)
+	var low []models.StockLevel
+	decode(t, w, &low)
+	require.NotEmpty(t, low)
+	assert.Equal(t, product.ID, low[0].ProductID)
+}
@@ -0,0 +1,98 @@
+package contract
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"oolio/internal/app/models"
+)
+
+// products returns the menu the memory driver starts with
+func products(t *testing.T) []models.Product {
+	t.Helper()
+	w := call{method: http.MethodGet, route: "/product"}.do(t)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var products []models.Product
+	decode(t, w, &products)
+	require.NotEmpty(t, products)
+	return products
+}
+
+func TestListProducts(t *testing.T) {
+	products(t)
+
+	w := call{method: http.MethodGet, route: "/product", path: "/product?updated_since=2020-01-01T00:00:00Z"}.do(t)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = call{method: http.MethodGet, route: "/product", path: "/product?updated_since=yesterday"}.do(t)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestListProducts_Paged(t *testing.T) {
+	menu := products(t)
+	require.Greater(t, len(menu), 2)
+
+	w := call{method: http.MethodGet, route: "/product", path: "/product?limit=2&offset=1"}.do(t)
+	require.Equal(t, http.StatusOK, w.Code)
+	var page []models.Product
+	decode(t, w, &page)
+	assert.Equal(t, menu[1:3], page)
+	assert.Equal(t, strconv.Itoa(len(menu)), w.Header().Get(models.TotalCountHeader))
+
+	w = call{method: http.MethodGet, route: "/product", path: "/product?limit=-"}.do(t)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestGetProduct(t *testing.T) {
+	product := products(t)[0]
+
+	w := call{method: http.MethodGet, route: "/product/:productId", path: "/product/" + product.ID}.do(t)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = call{method: http.MethodGet, route: "/product/:productId", path: "/product/not-a-uuid"}.do(t)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = call{method: http.MethodGet, route: "/product/:productId", path: "/product/00000000-0000-0000-0000-000000000000"}.do(t)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestProductChanges(t *testing.T) {
+	menu := products(t)
+
+	// Read from the start, the feed has the whole menu
+	w := call{method: http.MethodGet, route: "/product/changes", path: "/product/changes?limit=100"}.do(t)
+	require.Equal(t, http.StatusOK, w.Code)
+	var feed models.ProductChanges
+	decode(t, w, &feed)
+	created := make(map[string]bool)
+	for _, change := range feed.Changes {
+		if change.Kind == models.ProductCreated {
+			created[change.ProductID] = true
+		}
+	}
+	for _, product := range menu {
+		assert.True(t, created[product.ID], "product %s is in the feed", product.Name)
+	}
+
+	w = call{method: http.MethodGet, route: "/product/changes", path: "/product/changes?limit=1"}.do(t)
+	require.Equal(t, http.StatusOK, w.Code)
+	var first models.ProductChanges
+	decode(t, w, &first)
+	require.Len(t, first.Changes, 1)
+	assert.True(t, first.HasMore)
+
+	w = call{method: http.MethodGet, route: "/product/changes", path: "/product/changes?since=" + first.Cursor}.do(t)
+	require.Equal(t, http.StatusOK, w.Code)
+	var rest models.ProductChanges
+	decode(t, w, &rest)
+	require.NotEmpty(t, rest.Changes)
+	assert.Equal(t, feed.Changes[1], rest.Changes[0], "the next page picks up after the cursor")
+
+	w = call{method: http.MethodGet, route: "/product/changes", path: "/product/changes?since=yesterday"}.do(t)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
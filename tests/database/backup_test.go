@@ -0,0 +1,63 @@
+package database
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"oolio/internal/config"
+	"oolio/internal/database"
+)
+
+const backupHeader = `{"format":"oolio-backup","version":1,"schemaVersion":37,"createdAt":"2026-10-16T00:00:00Z"}` + "\n"
+
+func TestScanBackup_FindsWhereAnExportStopped(t *testing.T) {
+	complete := backupHeader +
+		`{"type":"store","key":"0b6f2f8e-0c43-4a55-bb5c-4d0f0b7f8a11","data":{}}` + "\n" +
+		`{"type":"product","key":"2a1c6e57-9a0e-4f53-8f0c-6d7e3c8b9e21","data":{}}` + "\n"
+	partial := complete + `{"type":"product","key":"3b`
+
+	position, err := database.ScanBackup(strings.NewReader(partial))
+	require.NoError(t, err)
+	require.NotNil(t, position.Header)
+	assert.Equal(t, uint(37), position.Header.SchemaVersion)
+	assert.Equal(t, database.BackupProduct, position.Type)
+	assert.Equal(t, "2a1c6e57-9a0e-4f53-8f0c-6d7e3c8b9e21", position.Key)
+	assert.Equal(t, database.BackupCounts{database.BackupStore: 1, database.BackupProduct: 1}, position.Counts)
+	assert.Equal(t, int64(len(complete)), position.Size)
+	assert.False(t, position.Complete)
+
+	position, err = database.ScanBackup(strings.NewReader(complete + `{"type":"end","counts":{"product":1,"store":1}}` + "\n"))
+	require.NoError(t, err)
+	assert.True(t, position.Complete)
+
+	// Nothing was written yet
+	position, err = database.ScanBackup(strings.NewReader(`{"format":"oolio`))
+	require.NoError(t, err)
+	assert.Nil(t, position.Header)
+	assert.Zero(t, position.Size)
+}
+
+func TestScanBackup_RejectsOtherFiles(t *testing.T) {
+	_, err := database.ScanBackup(strings.NewReader("name,price\n"))
+	assert.ErrorIs(t, err, database.ErrBackupFormat)
+
+	_, err = database.ScanBackup(strings.NewReader(`{"format":"oolio-backup","version":2}` + "\n"))
+	assert.ErrorContains(t, err, "version 2 is not supported")
+
+	_, err = database.ScanBackup(strings.NewReader(backupHeader + `{"type":"customer","key":"1"}` + "\n"))
+	assert.ErrorContains(t, err, `unknown record type "customer"`)
+}
+
+func TestBackup_RefusesTheMemoryDriver(t *testing.T) {
+	db, err := database.NewDatabase(&config.Config{Database: config.DatabaseConfig{Driver: config.DriverMemory}})
+	require.NoError(t, err)
+
+	_, err = db.ExportBackup(context.Background(), &strings.Builder{}, database.BackupPosition{})
+	assert.Error(t, err)
+	_, err = db.ImportBackup(context.Background(), strings.NewReader(backupHeader))
+	assert.Error(t, err)
+}
@@ -0,0 +1,62 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"oolio/internal/config"
+	"oolio/internal/database"
+)
+
+func TestCircuitBreaker_TripsOnConnectionFailures(t *testing.T) {
+	retrier := database.NewRetrier(config.DatabaseConfig{
+		RetryMaxAttempts:        1,
+		BreakerFailureThreshold: 2,
+	})
+	ctx := context.Background()
+
+	calls := 0
+	connectionLost := func(ctx context.Context) error {
+		calls++
+		return &pgconn.PgError{Code: "57P01"}
+	}
+
+	assert.Error(t, retrier.Read(ctx, connectionLost))
+	assert.False(t, retrier.Breaker.Open())
+	assert.Error(t, retrier.Read(ctx, connectionLost))
+	require.True(t, retrier.Breaker.Open())
+
+	// Calls fail fast without reaching the database
+	err := retrier.Read(ctx, connectionLost)
+	assert.ErrorIs(t, err, database.ErrUnavailable)
+	assert.Equal(t, 2, calls)
+
+	assert.Greater(t, retrier.Breaker.Close(), time.Duration(0))
+	assert.NoError(t, retrier.Read(ctx, func(ctx context.Context) error { return nil }))
+
+	stats := retrier.Breaker.Stats()
+	assert.False(t, stats.Open)
+	assert.Equal(t, int64(1), stats.Opened)
+	assert.Equal(t, int64(1), stats.Recovered)
+	assert.Equal(t, int64(1), stats.Rejected)
+}
+
+func TestCircuitBreaker_IgnoresServerErrors(t *testing.T) {
+	breaker := database.NewCircuitBreaker(2)
+
+	breaker.Record(&pgconn.PgError{Code: "08006"})
+	breaker.Record(&pgconn.PgError{Code: "40001"})
+	breaker.Record(&pgconn.PgError{Code: "08006"})
+	assert.False(t, breaker.Open(), "a serialization failure means the server answered")
+
+	disabled := database.NewCircuitBreaker(0)
+	for range 10 {
+		disabled.Record(&pgconn.PgError{Code: "08006"})
+	}
+	assert.False(t, disabled.Open())
+}
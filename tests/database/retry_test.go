@@ -0,0 +1,107 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+
+	"oolio/internal/chaos"
+	"oolio/internal/config"
+	"oolio/internal/database"
+)
+
+func newTestRetrier() *database.Retrier {
+	return database.NewRetrier(config.DatabaseConfig{
+		RetryMaxAttempts:    3,
+		RetryInitialBackoff: time.Millisecond,
+		RetryMaxBackoff:     5 * time.Millisecond,
+	})
+}
+
+func TestRetrier_RecoversFromSerializationFailure(t *testing.T) {
+	retrier := newTestRetrier()
+	ctx := context.Background()
+
+	calls := 0
+	err := retrier.Write(ctx, func(ctx context.Context) error {
+		calls++
+		if calls < 3 {
+			return &pgconn.PgError{Code: "40001"}
+		}
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, database.RetryStats{Retries: 2, Recovered: 1}, retrier.Stats())
+}
+
+func TestRetrier_GivesUpAfterMaxAttempts(t *testing.T) {
+	retrier := newTestRetrier()
+
+	calls := 0
+	err := retrier.Read(context.Background(), func(ctx context.Context) error {
+		calls++
+		return &pgconn.PgError{Code: "08006"}
+	})
+
+	assert.Error(t, err)
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, int64(1), retrier.Stats().Exhausted)
+}
+
+func TestRetrier_DoesNotRetryPermanentErrors(t *testing.T) {
+	retrier := newTestRetrier()
+	ctx := context.Background()
+
+	calls := 0
+	fail := func(err error) func(ctx context.Context) error {
+		return func(ctx context.Context) error {
+			calls++
+			return err
+		}
+	}
+
+	assert.Error(t, retrier.Read(ctx, fail(errors.New("product not found"))))
+	assert.Error(t, retrier.Read(ctx, fail(&pgconn.PgError{Code: "23505"})))
+	// A dropped connection may have applied the write, so writes aren't retried
+	assert.Error(t, retrier.Write(ctx, fail(&pgconn.PgError{Code: "08006"})))
+	assert.Equal(t, 3, calls)
+}
+
+func TestIsTransient(t *testing.T) {
+	assert.True(t, database.IsTransient(&pgconn.PgError{Code: "40P01"}))
+	assert.True(t, database.IsTransient(&pgconn.PgError{Code: "57P01"}))
+	assert.False(t, database.IsTransient(&pgconn.PgError{Code: "23503"}))
+	assert.False(t, database.IsTransient(context.DeadlineExceeded))
+	assert.False(t, database.IsTransient(nil))
+}
+
+func TestRetrier_InjectedFaults(t *testing.T) {
+	retrier := database.NewRetrier(config.DatabaseConfig{
+		RetryMaxAttempts:        3,
+		RetryInitialBackoff:     time.Millisecond,
+		RetryMaxBackoff:         5 * time.Millisecond,
+		BreakerFailureThreshold: 5,
+	})
+	retrier.Faults = chaos.New(config.ChaosConfig{Enabled: true, Database: config.FaultConfig{ErrorPercent: 100}}).Database
+
+	// Injected faults fail calls before they are sent, so writes are retried too, and they
+	// count towards tripping the breaker
+	calls := 0
+	err := retrier.Write(context.Background(), func(ctx context.Context) error {
+		calls++
+		return nil
+	})
+	assert.ErrorIs(t, err, chaos.ErrInjected)
+	assert.Zero(t, calls)
+	assert.Equal(t, int64(2), retrier.Stats().Retries)
+
+	assert.Error(t, retrier.Read(context.Background(), func(ctx context.Context) error { return nil }))
+	assert.True(t, retrier.Breaker.Open())
+	assert.ErrorIs(t, retrier.Read(context.Background(), func(ctx context.Context) error { return nil }), database.ErrUnavailable)
+}
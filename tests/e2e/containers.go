@@ -0,0 +1,69 @@
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// container is a throwaway Docker container, run with the docker CLI so the suite needs
+// nothing but a Docker daemon
+type container struct {
+	id string
+}
+
+// startContainer runs image detached with its ports published on random host ports, and
+// returns it with the host address of port, e.g. "5432/tcp"
+func startContainer(ctx context.Context, image, port string, env ...string) (*container, string, error) {
+	args := []string{"run", "--detach", "--rm", "--publish-all"}
+	for _, variable := range env {
+		args = append(args, "--env", variable)
+	}
+	out, err := docker(ctx, append(args, image)...)
+	if err != nil {
+		return nil, "", err
+	}
+	c := &container{id: out}
+
+	// The first line is the IPv4 binding, e.g. 0.0.0.0:49153
+	out, err = docker(ctx, "port", c.id, port)
+	if err != nil {
+		c.stop()
+		return nil, "", err
+	}
+	binding, _, _ := strings.Cut(out, "\n")
+	_, hostPort, found := strings.Cut(binding, ":")
+	if !found {
+		c.stop()
+		return nil, "", fmt.Errorf("unexpected port binding %q of %s", binding, image)
+	}
+	return c, "127.0.0.1:" + hostPort, nil
+}
+
+func (c *container) stop() {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	_, _ = docker(ctx, "rm", "--force", "--volumes", c.id)
+}
+
+func docker(ctx context.Context, args ...string) (string, error) {
+	out, err := exec.CommandContext(ctx, "docker", args...).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("docker %s: %w: %s", args[0], err, strings.TrimSpace(string(out)))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// waitFor calls ready until it succeeds or timeout passes, returning its last error
+func waitFor(timeout time.Duration, ready func() error) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		err := ready()
+		if err == nil || time.Now().After(deadline) {
+			return err
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+}
@@ -0,0 +1,154 @@
+// Package e2e runs the whole API against real Postgres and Redis containers: the fx app
+// boots in-process on migrated and seeded databases, and tests go through HTTP and the
+// order worker like clients do. It needs a Docker daemon and is skipped without one, or
+// with -short.
+package e2e
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/fx"
+
+	providerfx "oolio/internal/app/fx"
+	"oolio/internal/app/worker"
+	"oolio/internal/config"
+	"oolio/internal/database"
+)
+
+const apiKey = "e2e-api-key"
+
+// baseURL is where the app under test serves the API
+var baseURL string
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+	if testing.Short() {
+		fmt.Println("skipping the end-to-end suite in short mode")
+		os.Exit(0)
+	}
+	if _, err := exec.LookPath("docker"); err != nil {
+		fmt.Println("skipping the end-to-end suite: docker is not available")
+		os.Exit(0)
+	}
+
+	code, err := run(m)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "end-to-end suite:", err)
+		os.Exit(1)
+	}
+	os.Exit(code)
+}
+
+func run(m *testing.M) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	postgres, postgresAddr, err := startContainer(ctx, "postgres:16-alpine", "5432/tcp",
+		"POSTGRES_USER=oolio", "POSTGRES_PASSWORD=oolio_password", "POSTGRES_DB=oolio_db")
+	if err != nil {
+		return 0, err
+	}
+	defer postgres.stop()
+	redis, redisAddr, err := startContainer(ctx, "redis:7-alpine", "6379/tcp")
+	if err != nil {
+		return 0, err
+	}
+	defer redis.stop()
+
+	host, port, err := net.SplitHostPort(postgresAddr)
+	if err != nil {
+		return 0, err
+	}
+	for key, value := range map[string]string{
+		"DB_HOST":         host,
+		"DB_PORT":         port,
+		"DB_AUTO_MIGRATE": "true",
+		"REDIS_ADDR":      redisAddr,
+		"API_KEY":         apiKey,
+		"WORKER_INTERVAL": "100ms",
+		"LOG_LEVEL":       "error",
+		// The suite places more orders than a client would in a minute
+		"RATE_LIMIT_ORDER": "10000",
+	} {
+		os.Setenv(key, value)
+	}
+	if err := waitForStores(); err != nil {
+		return 0, err
+	}
+
+	gin.SetMode(gin.TestMode)
+	var (
+		engine      *gin.Engine
+		db          *database.Database
+		orderWorker *worker.OrderWorker
+	)
+	app := fx.New(
+		providerfx.AppModule,
+		fx.Populate(&engine, &db, &orderWorker),
+		fx.NopLogger,
+	)
+	if err := app.Start(ctx); err != nil {
+		return 0, fmt.Errorf("failed to start the app: %w", err)
+	}
+	defer app.Stop(context.Background())
+
+	if _, err := db.Seed(ctx); err != nil {
+		return 0, fmt.Errorf("failed to seed: %w", err)
+	}
+
+	server := httptest.NewServer(engine)
+	defer server.Close()
+	baseURL = server.URL + "/api/v1"
+
+	workerCtx, stopWorker := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		orderWorker.Start(workerCtx)
+	}()
+	defer func() {
+		stopWorker()
+		<-stopped
+	}()
+
+	return m.Run(), nil
+}
+
+// waitForStores waits until Postgres accepts connections and Redis answers
+func waitForStores() error {
+	cfg := config.Load()
+	cfg.Database.AutoMigrate = false
+
+	err := waitFor(time.Minute, func() error {
+		db, err := database.NewDatabase(cfg)
+		if err != nil {
+			return err
+		}
+		return db.Close()
+	})
+	if err != nil {
+		return fmt.Errorf("postgres did not come up: %w", err)
+	}
+
+	client, err := database.NewRedisClient(cfg)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	err = waitFor(time.Minute, func() error {
+		return client.Ping(context.Background()).Err()
+	})
+	if err != nil {
+		return fmt.Errorf("redis did not come up: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"oolio/internal/app/models"
+)
+
+// call sends body as JSON, with the API key unless key is empty, and decodes a successful
+// response into out when it is not nil
+func call(t *testing.T, method, path, key string, body, out any) int {
+	t.Helper()
+	status, err := send(method, path, key, body, out)
+	require.NoError(t, err)
+	return status
+}
+
+// send is call for goroutines other than the test's, which must not stop the test
+func send(method, path, key string, body, out any) (int, error) {
+	var payload bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&payload).Encode(body); err != nil {
+			return 0, err
+		}
+	}
+	req, err := http.NewRequest(method, baseURL+path, &payload)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set("api_key", key)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if out != nil && resp.StatusCode < 300 {
+		return resp.StatusCode, json.NewDecoder(resp.Body).Decode(out)
+	}
+	return resp.StatusCode, nil
+}
+
+func seededProducts(t *testing.T) []models.Product {
+	t.Helper()
+	var products []models.Product
+	require.Equal(t, http.StatusOK, call(t, http.MethodGet, "/product", apiKey, nil, &products))
+	require.NotEmpty(t, products, "the seeded menu")
+	return products
+}
+
+// placeOrder queues the order and waits for the worker to complete it
+func placeOrder(t *testing.T, req models.OrderReq) *models.Order {
+	t.Helper()
+	var queued models.QueuedOrder
+	require.Equal(t, http.StatusAccepted, call(t, http.MethodPost, "/order", apiKey, req, &queued))
+	require.NotEmpty(t, queued.QueueItemID)
+	return processedOrder(t, queued.QueueItemID)
+}
+
+// processedOrder waits for the worker to complete the order of a queue item
+func processedOrder(t *testing.T, queueItemID string) *models.Order {
+	t.Helper()
+	var order models.Order
+	require.Eventually(t, func() bool {
+		status, err := send(http.MethodGet, "/order/"+queueItemID, apiKey, nil, &order)
+		return err == nil && status == http.StatusOK
+	}, 15*time.Second, 100*time.Millisecond, "order %s was not processed", queueItemID)
+	return &order
+}
+
+func TestProducts(t *testing.T) {
+	products := seededProducts(t)
+
+	var product models.Product
+	assert.Equal(t, http.StatusOK, call(t, http.MethodGet, "/product/"+products[0].ID, apiKey, nil, &product))
+	assert.Equal(t, products[0].Name, product.Name)
+	assert.Equal(t, products[0].Price, product.Price)
+
+	assert.Equal(t, http.StatusNotFound, call(t, http.MethodGet, "/product/00000000-0000-0000-0000-000000000000", apiKey, nil, nil))
+	assert.Equal(t, http.StatusUnauthorized, call(t, http.MethodGet, "/product", "", nil, nil))
+	assert.Equal(t, http.StatusUnauthorized, call(t, http.MethodGet, "/product", "wrong-key", nil, nil))
+}
+
+func TestOrder_QueuedProcessedAndCompleted(t *testing.T) {
+	products := seededProducts(t)
+	first, second := products[0], products[len(products)-1]
+
+	order := placeOrder(t, models.OrderReq{
+		CouponCode: "HAPPYHRS",
+		Items: []models.OrderItem{
+			{ProductID: first.ID, Quantity: 2},
+			{ProductID: second.ID, Quantity: 1},
+		},
+	})
+
+	total := first.Price.Mul(2) + second.Price
+	assert.NotEmpty(t, order.ID)
+	assert.Equal(t, total, order.Total)
+	assert.Equal(t, total.Percent(10), order.Discounts)
+	assert.Equal(t, "HAPPYHRS", order.CouponCode)
+	assert.Len(t, order.Items, 2)
+
+	var status models.QueueStatus
+	require.Equal(t, http.StatusOK, call(t, http.MethodGet, "/queue/status", apiKey, nil, &status))
+	assert.Positive(t, status.QueueStats["completed"])
+}
+
+func TestOrder_RejectedRequests(t *testing.T) {
+	productID := seededProducts(t)[0].ID
+
+	assert.Equal(t, http.StatusUnauthorized, call(t, http.MethodPost, "/order", "", models.OrderReq{
+		Items: []models.OrderItem{{ProductID: productID, Quantity: 1}},
+	}, nil))
+	assert.Equal(t, http.StatusBadRequest, call(t, http.MethodPost, "/order", apiKey, models.OrderReq{}, nil))
+	assert.Equal(t, http.StatusBadRequest, call(t, http.MethodPost, "/order", apiKey, models.OrderReq{
+		Items: []models.OrderItem{{ProductID: "not-a-uuid", Quantity: 1}},
+	}, nil))
+	assert.Equal(t, http.StatusUnprocessableEntity, call(t, http.MethodPost, "/order", apiKey, models.OrderReq{
+		Items: []models.OrderItem{{ProductID: productID, Quantity: 0}},
+	}, nil))
+	assert.Equal(t, http.StatusNotFound, call(t, http.MethodGet, "/order/00000000-0000-0000-0000-000000000000", apiKey, nil, nil))
+}
+
+func TestOrder_ConcurrentOrdersAllComplete(t *testing.T) {
+	product := seededProducts(t)[0]
+
+	const count = 10
+	queued := make([]models.QueuedOrder, count)
+	statuses := make([]int, count)
+	errs := make([]error, count)
+	var wg sync.WaitGroup
+	for i := range count {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req := models.OrderReq{Items: []models.OrderItem{{ProductID: product.ID, Quantity: i + 1}}}
+			statuses[i], errs[i] = send(http.MethodPost, "/order", apiKey, req, &queued[i])
+		}()
+	}
+	wg.Wait()
+
+	ids := make(map[string]bool)
+	for i := range count {
+		require.NoError(t, errs[i])
+		require.Equal(t, http.StatusAccepted, statuses[i])
+		order := processedOrder(t, queued[i].QueueItemID)
+		assert.Equal(t, product.Price.Mul(i+1), order.Total)
+		ids[order.ID] = true
+	}
+	assert.Len(t, ids, count, "every order is stored once")
+}
@@ -0,0 +1,49 @@
+package events
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"oolio/internal/app/events"
+	"oolio/internal/app/models"
+)
+
+func TestFromOutbox(t *testing.T) {
+	createdAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.FixedZone("AEST", 10*60*60))
+	event := events.FromOutbox("https://oolio.example/", models.OutboxEvent{
+		ID:            "e1",
+		AggregateType: "order",
+		AggregateID:   "o1",
+		EventType:     models.EventOrderCompleted,
+		Payload:       json.RawMessage(`{"queueItemId":"q1"}`),
+		CreatedAt:     createdAt,
+	})
+
+	assert.Equal(t, "https://oolio.example/order", event.Source)
+	assert.Equal(t, "order", event.AggregateType())
+	assert.Equal(t, models.EventOrderCompleted, event.DomainType())
+
+	data, err := json.Marshal(event)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"specversion": "1.0",
+		"id": "e1",
+		"source": "https://oolio.example/order",
+		"type": "com.oolio.order.completed",
+		"subject": "o1",
+		"time": "2024-05-01T02:00:00Z",
+		"datacontenttype": "application/json",
+		"data": {"queueItemId": "q1"}
+	}`, string(data))
+}
+
+func TestNew_DefaultSource(t *testing.T) {
+	event := events.New("", "e1", "product", "p1", models.EventProductDeleted, nil, time.Now())
+
+	assert.Equal(t, "/oolio/product", event.Source)
+	assert.Equal(t, "product", event.AggregateType())
+}
@@ -0,0 +1,222 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"oolio/internal/graphql"
+)
+
+type Address struct {
+	City string `json:"city"`
+}
+
+type Contact struct {
+	Email string `json:"email"`
+}
+
+type Author struct {
+	Contact
+	Name    string   `json:"name"`
+	Address *Address `json:"address"`
+	Tags    []string `json:"tags"`
+	secret  string
+}
+
+type Book struct {
+	ID      string   `json:"id"`
+	Title   string   `json:"title"`
+	Pages   int      `json:"pages"`
+	Authors []Author `json:"authors"`
+	Hidden  string   `json:"-"`
+}
+
+var books = []Book{
+	{ID: "1", Title: "Waffles", Pages: 120, Authors: []Author{{Contact: Contact{Email: "a@example.com"}, Name: "Ada", Address: &Address{City: "Oslo"}, Tags: []string{"food"}}}},
+	{ID: "2", Title: "Syrup", Pages: 80, Authors: []Author{{Name: "Bo"}}},
+}
+
+func testSchema() *graphql.Schema {
+	return &graphql.Schema{Query: map[string]*graphql.Field{
+		"books": {
+			Args: map[string]string{"first": "Int", "ids": "[ID!]"},
+			Type: reflect.TypeFor[[]Book](),
+			Resolve: func(_ context.Context, args map[string]any) (any, error) {
+				result := books
+				if ids, ok := args["ids"].([]any); ok {
+					result = nil
+					for _, book := range books {
+						for _, id := range ids {
+							if book.ID == id {
+								result = append(result, book)
+							}
+						}
+					}
+				}
+				if first, ok := args["first"].(int); ok {
+					result = result[:min(first, len(result))]
+				}
+				return result, nil
+			},
+		},
+		"book": {
+			Args: map[string]string{"id": "ID!"},
+			Type: reflect.TypeFor[*Book](),
+			Resolve: func(_ context.Context, args map[string]any) (any, error) {
+				for _, book := range books {
+					if book.ID == args["id"] {
+						return &book, nil
+					}
+				}
+				return nil, nil
+			},
+		},
+		"broken": {
+			Type: reflect.TypeFor[*Book](),
+			Resolve: func(context.Context, map[string]any) (any, error) {
+				return nil, errors.New("boom")
+			},
+		},
+	}}
+}
+
+func execute(t *testing.T, query string, variables map[string]any) (string, []*graphql.Error) {
+	t.Helper()
+	resp := testSchema().Execute(context.Background(), graphql.Request{Query: query, Variables: variables})
+	if resp.Data == nil {
+		return "", resp.Errors
+	}
+	data, err := json.Marshal(resp.Data)
+	require.NoError(t, err)
+	return string(data), resp.Errors
+}
+
+func TestExecute_SelectsFieldsInOrder(t *testing.T) {
+	data, errs := execute(t, `{ books(first: 1) { title id authors { name email address { city } tags } } }`, nil)
+	require.Empty(t, errs)
+	// Fields come back in the order they were selected, not the structs' order
+	assert.Equal(t, `{"books":[{"title":"Waffles","id":"1","authors":[{"name":"Ada","email":"a@example.com","address":{"city":"Oslo"},"tags":["food"]}]}]}`, data)
+}
+
+func TestExecute_AliasesFragmentsAndTypename(t *testing.T) {
+	query := `
+		query Shelf {
+			__typename
+			first: book(id: "1") { ...bookFields }
+			second: book(id: 2) { ... on Book { title } authors { address { city } } }
+			none: book(id: "3") { title }
+		}
+		fragment bookFields on Book { __typename title pages }
+	`
+	data, errs := execute(t, query, nil)
+	require.Empty(t, errs)
+	assert.JSONEq(t, `{
+		"__typename": "Query",
+		"first": {"__typename": "Book", "title": "Waffles", "pages": 120},
+		"second": {"title": "Syrup", "authors": [{"address": null}]},
+		"none": null
+	}`, data)
+}
+
+func TestExecute_Variables(t *testing.T) {
+	query := `query ($ids: [ID!], $first: Int = 5, $withPages: Boolean!) {
+		books(ids: $ids, first: $first) { id pages @include(if: $withPages) title @skip(if: true) }
+	}`
+
+	data, errs := execute(t, query, map[string]any{"ids": []any{"2", float64(1)}, "withPages": true})
+	require.Empty(t, errs)
+	assert.JSONEq(t, `{"books": [{"id": "1", "pages": 120}, {"id": "2", "pages": 80}]}`, data)
+
+	// JSON numbers arrive as float64; whole ones are Ints
+	data, errs = execute(t, query, map[string]any{"first": float64(1), "withPages": false})
+	require.Empty(t, errs)
+	assert.JSONEq(t, `{"books": [{"id": "1"}]}`, data)
+
+	_, errs = execute(t, query, map[string]any{"first": 1.5, "withPages": false})
+	require.Len(t, errs, 1)
+	assert.Equal(t, `Variable "$first" got invalid value; Int cannot represent 1.5`, errs[0].Message)
+
+	_, errs = execute(t, query, nil)
+	require.Len(t, errs, 1)
+	assert.Equal(t, `Variable "$withPages" of required type "Boolean!" was not provided.`, errs[0].Message)
+}
+
+func TestExecute_ResolverError(t *testing.T) {
+	resp := testSchema().Execute(context.Background(), graphql.Request{Query: "{ book(id: 1) { id }\n broken { id } }"})
+	data, err := json.Marshal(resp.Data)
+	require.NoError(t, err)
+	// The failed field is null and the rest of the data still comes back
+	assert.Equal(t, `{"book":{"id":"1"},"broken":null}`, string(data))
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, "boom", resp.Errors[0].Message)
+	assert.Equal(t, []any{"broken"}, resp.Errors[0].Path)
+	assert.Equal(t, []graphql.Location{{Line: 2, Column: 2}}, resp.Errors[0].Locations)
+}
+
+func TestExecute_ValidationErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		message string
+	}{
+		{"syntax", `{ books { id }`, "Syntax Error: Unexpected <EOF>."},
+		{"unknown root field", `{ authors { name } }`, `Cannot query field "authors" on type "Query".`},
+		{"unexported field", `{ books { authors { secret } } }`, `Cannot query field "secret" on type "Author".`},
+		{"ignored field", `{ books { Hidden } }`, `Cannot query field "Hidden" on type "Book".`},
+		{"missing selection", `{ books }`, `Field "books" of type "Book" must have a selection of subfields.`},
+		{"selection on leaf", `{ books { title { length } } }`, `Field "title" must not have a selection since type "string" has no subfields.`},
+		{"unknown argument", `{ books(last: 1) { id } }`, `Unknown argument "last" on field "books".`},
+		{"missing argument", `{ book { id } }`, `Field "book" argument "id" of type "ID!" is required, but it was not provided.`},
+		{"wrong argument type", `{ books(first: "one") { id } }`, `Int cannot represent "one".`},
+		{"null argument", `{ book(id: null) { id } }`, `Expected value of type "ID!", found null.`},
+		{"undefined variable", `{ books(first: $n) { id } }`, `Variable "$n" is not defined.`},
+		{"unused variable", `query ($n: Int) { books { id } }`, `Variable "$n" is never used in operation.`},
+		{"variable type mismatch", `query ($id: String!) { book(id: $id) { id } }`, `Variable "$id" of type "String!" used in position expecting type "ID!".`},
+		{"nullable variable", `query ($id: ID) { book(id: $id) { id } }`, `Variable "$id" of type "ID" used in position expecting type "ID!".`},
+		{"unknown fragment", `{ books { ...missing } }`, `Unknown fragment "missing".`},
+		{"unused fragment", `{ books { id } } fragment f on Book { id }`, `Fragment "f" is never used.`},
+		{"fragment on wrong type", `{ books { ...f } } fragment f on Author { name }`, `Fragment cannot be spread here as objects of type "Book" can never be of type "Author".`},
+		{"fragment cycle", `{ books { ...a } } fragment a on Book { ...b } fragment b on Book { ...a }`, `Cannot spread fragment "a" within itself.`},
+		{"conflicting fields", `{ books { id: title id } }`, `Fields "id" conflict because "title" and "id" are different fields. Use different aliases on the fields to fetch both if this was intentional.`},
+		{"conflicting arguments", `{ book(id: 1) { id } book(id: 2) { id } }`, `Fields "book" conflict because they have differing arguments. Use different aliases on the fields to fetch both if this was intentional.`},
+		{"directive argument", `{ books @skip { id } }`, `Directive "@skip" argument "if" of type "Boolean!" is required, but it was not provided.`},
+		{"unknown directive", `{ books @defer { id } }`, `Unknown directive "@defer".`},
+		{"mutation", `mutation { books { id } }`, "Only queries are supported."},
+		{"introspection", `{ __schema { types { name } } }`, `Cannot query field "__schema" on type "Query".`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, errs := execute(t, tt.query, nil)
+			assert.Empty(t, data)
+			require.NotEmpty(t, errs)
+			assert.Equal(t, tt.message, errs[0].Message)
+			assert.NotEmpty(t, errs[0].Locations)
+		})
+	}
+}
+
+func TestExecute_OperationName(t *testing.T) {
+	query := `query One { book(id: 1) { title } } query Two { book(id: 2) { title } }`
+
+	resp := testSchema().Execute(context.Background(), graphql.Request{Query: query, OperationName: "Two"})
+	require.Empty(t, resp.Errors)
+	data, err := json.Marshal(resp.Data)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"book": {"title": "Syrup"}}`, string(data))
+
+	resp = testSchema().Execute(context.Background(), graphql.Request{Query: query})
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, "Must provide operation name if query contains multiple operations.", resp.Errors[0].Message)
+}
+
+func TestExecute_StringEscapes(t *testing.T) {
+	data, errs := execute(t, `{ book(id: "\u0031") { title } } # "\u0031" is "1"`, nil)
+	require.Empty(t, errs)
+	assert.JSONEq(t, `{"book": {"title": "Waffles"}}`, data)
+}
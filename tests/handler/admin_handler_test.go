@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"oolio/internal/app/handler"
+	"oolio/internal/app/models"
+	"oolio/internal/app/repository"
+)
+
+const storeID = "3f1c2e7a-5b6d-4c8e-9f0a-1b2c3d4e5f60"
+
+func newAdminHandler(products *MockProductService) *handler.AdminHandler {
+	return handler.NewAdminHandler(zap.NewAtomicLevel(), nil, products, nil, nil, nil, nil, nil, nil)
+}
+
+// adminRequest runs handle on a request with a JSON body, made by a deployment key for
+// storeID and with productID as the productId parameter
+func adminRequest(handle gin.HandlerFunc, method, productID, body string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(method, "/api/v1/admin/products", strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Params = gin.Params{{Key: "productId", Value: productID}}
+	c.Set("store_id", storeID)
+
+	handle(c)
+	// Status set without a body is only written once the request ends
+	c.Writer.WriteHeaderNow()
+	return w
+}
+
+func TestAdminHandler_CreateProduct(t *testing.T) {
+	mockService := &MockProductService{}
+	handler := newAdminHandler(mockService)
+
+	mockService.On("CreateProduct", context.Background(), mock.MatchedBy(func(p *models.Product) bool {
+		return p.Name == "Pistachio Waffle" && p.Price == 1250 && p.Category == "Waffle" && p.StoreID == storeID
+	})).Run(func(args mock.Arguments) {
+		product := args.Get(1).(*models.Product)
+		product.ID = "new-product"
+		product.Version = 1
+	}).Return(nil)
+
+	w := adminRequest(handler.CreateProduct, http.MethodPost, "", `{"name":"Pistachio Waffle","price":12.50,"category":"Waffle"}`)
+
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var product models.Product
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &product))
+	assert.Equal(t, "new-product", product.ID)
+	assert.Equal(t, 1, product.Version)
+	mockService.AssertExpectations(t)
+}
+
+func TestAdminHandler_CreateProduct_Invalid(t *testing.T) {
+	mockService := &MockProductService{}
+	handler := newAdminHandler(mockService)
+
+	for _, tc := range []struct {
+		body   string
+		status int
+		field  string
+	}{
+		{body: `{"price":12.50,"category":"Waffle"}`, status: http.StatusBadRequest, field: "name"},
+		{body: `{"name":"Waffle","price":12.50}`, status: http.StatusBadRequest, field: "category"},
+		{body: `{"name":"` + strings.Repeat("x", 256) + `","price":12.50,"category":"Waffle"}`, status: http.StatusBadRequest, field: "name"},
+		{body: `{"name":"Waffle","price":-1,"category":"Waffle"}`, status: http.StatusUnprocessableEntity, field: "price"},
+		{body: `{"name":"Waffle","category":"Waffle"}`, status: http.StatusUnprocessableEntity, field: "price"},
+		{body: `not json`, status: http.StatusBadRequest},
+	} {
+		w := adminRequest(handler.CreateProduct, http.MethodPost, "", tc.body)
+
+		require.Equal(t, tc.status, w.Code, tc.body)
+		if tc.field == "" {
+			continue
+		}
+		var response models.ApiResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.NotEmpty(t, response.Errors, tc.body)
+		assert.Equal(t, tc.field, response.Errors[0].Field, tc.body)
+	}
+
+	mockService.AssertNotCalled(t, "CreateProduct", mock.Anything, mock.Anything)
+}
+
+func TestAdminHandler_UpdateProduct(t *testing.T) {
+	const productID = "8a6e0804-2bd0-4672-b79d-d97027f9071a"
+	body := `{"name":"Chicken Waffle","price":14.00,"category":"Waffle","version":3}`
+
+	for _, tc := range []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{name: "updated", status: http.StatusOK},
+		{name: "stale version", err: fmt.Errorf("failed to update product: %w", &repository.ConflictError{Entity: "product", ID: productID, Version: 3}), status: http.StatusConflict},
+		{name: "missing", err: fmt.Errorf("failed to update product: product not found"), status: http.StatusNotFound},
+		{name: "failed", err: assert.AnError, status: http.StatusInternalServerError},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mockService := &MockProductService{}
+			handler := newAdminHandler(mockService)
+			mockService.On("UpdateProduct", context.Background(), mock.MatchedBy(func(p *models.Product) bool {
+				return p.ID == productID && p.Name == "Chicken Waffle" && p.Price == 1400 && p.Version == 3
+			})).Run(func(args mock.Arguments) {
+				args.Get(1).(*models.Product).Version++
+			}).Return(tc.err)
+
+			w := adminRequest(handler.UpdateProduct, http.MethodPut, productID, body)
+
+			require.Equal(t, tc.status, w.Code, w.Body.String())
+			if tc.status == http.StatusOK {
+				var product models.Product
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &product))
+				assert.Equal(t, 4, product.Version)
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAdminHandler_UpdateProduct_RequiresVersion(t *testing.T) {
+	mockService := &MockProductService{}
+	handler := newAdminHandler(mockService)
+
+	w := adminRequest(handler.UpdateProduct, http.MethodPut, "8a6e0804-2bd0-4672-b79d-d97027f9071a",
+		`{"name":"Chicken Waffle","price":14.00,"category":"Waffle"}`)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "version is required")
+	mockService.AssertNotCalled(t, "UpdateProduct", mock.Anything, mock.Anything)
+}
+
+func TestAdminHandler_DeleteProduct(t *testing.T) {
+	mockService := &MockProductService{}
+	handler := newAdminHandler(mockService)
+
+	mockService.On("DeleteProduct", context.Background(), "8a6e0804-2bd0-4672-b79d-d97027f9071a").Return(nil)
+	mockService.On("DeleteProduct", context.Background(), "not-a-uuid").
+		Return(fmt.Errorf("failed to delete product: invalid product ID: bad"))
+
+	w := adminRequest(handler.DeleteProduct, http.MethodDelete, "8a6e0804-2bd0-4672-b79d-d97027f9071a", "")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Empty(t, w.Body.String())
+
+	w = adminRequest(handler.DeleteProduct, http.MethodDelete, "not-a-uuid", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	mockService.AssertExpectations(t)
+}
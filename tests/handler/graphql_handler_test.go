@@ -0,0 +1,206 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"oolio/internal/app/handler"
+	"oolio/internal/app/middleware"
+	"oolio/internal/app/models"
+	"oolio/internal/app/repository"
+	"oolio/internal/app/services"
+)
+
+type graphQLResponse struct {
+	Data   map[string]json.RawMessage `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+		Path    []any  `json:"path"`
+	} `json:"errors"`
+}
+
+// graphQLFixture is a GraphQL endpoint over the default store's Iced Latte and an order of
+// three, and a second store with a product and an order of its own
+type graphQLFixture struct {
+	router     *gin.Engine
+	drink      models.Product
+	order      *models.Order
+	storeID    string
+	storeOrder *models.Order
+}
+
+func newGraphQLRouter(t *testing.T) graphQLFixture {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	ctx := context.Background()
+
+	productRepo := repository.NewMemoryProductRepository()
+	orderRepo := repository.NewMemoryOrderRepository()
+	queueRepo := repository.NewMemoryOrderQueueRepository()
+
+	drink := models.Product{Name: "Iced Latte", Price: 550, Category: "Drinks"}
+	require.NoError(t, productRepo.Create(ctx, &drink))
+
+	order := &models.Order{Total: 1650, Items: []models.OrderItem{{ProductID: drink.ID, Quantity: 3, Price: 550}}}
+	require.NoError(t, orderRepo.Create(ctx, order))
+	require.NoError(t, queueRepo.AddToQueue(ctx, &models.OrderQueueItem{ID: uuid.New().String(), Status: "pending", CreatedAt: time.Now()}))
+
+	orderService := services.NewOrderService(orderRepo, productRepo, queueRepo, nil, nil, nil, nil, nil, nil, nil, nil, zap.NewNop())
+	queueService := services.NewOrderQueueService(services.OrderQueueDeps{
+		QueueRepo:    queueRepo,
+		OrderRepo:    orderRepo,
+		OrderService: orderService,
+	}, nil, "test-worker")
+	h := handler.NewGraphQLHandler(services.NewProductService(productRepo), orderService, queueService)
+
+	// Customer staff-1 gets the staff tier's 30% off
+	pricing := services.NewPricingService(repository.NewMemoryPricingRepository())
+	_, err := pricing.SaveTier(ctx, "staff", models.PricingTierReq{DiscountPercentage: 30})
+	require.NoError(t, err)
+	require.NoError(t, pricing.Assign(ctx, models.PricingAssignmentReq{CustomerID: "staff-1", Tier: "staff"}))
+
+	stores := services.NewStoreService(repository.NewMemoryStoreRepository())
+	store, err := stores.CreateStore(ctx, models.StoreReq{Name: "Harbour St"})
+	require.NoError(t, err)
+	croissant := models.Product{Name: "Croissant", Price: 400, Category: "Pastries", StoreID: store.ID}
+	require.NoError(t, productRepo.Create(ctx, &croissant))
+	storeOrder := &models.Order{StoreID: store.ID, Total: 400, Items: []models.OrderItem{{ProductID: croissant.ID, Quantity: 1, Price: 400}}}
+	require.NoError(t, orderRepo.Create(ctx, storeOrder))
+
+	router := gin.New()
+	router.POST("/graphql", middleware.StoreAPIKeyAuth([]string{"secret-key"}, stores), middleware.NewPricingMiddleware(pricing).ResolveTier(), h.Query)
+	return graphQLFixture{router: router, drink: drink, order: order, storeID: store.ID, storeOrder: storeOrder}
+}
+
+// postGraphQL posts a query with the deployment API key, acting for the default store
+func postGraphQL(t *testing.T, router *gin.Engine, body string) (*httptest.ResponseRecorder, graphQLResponse) {
+	return postGraphQLAs(t, router, "", body)
+}
+
+// postGraphQLAs posts a query acting for the store storeID
+func postGraphQLAs(t *testing.T, router *gin.Engine, storeID, body string) (*httptest.ResponseRecorder, graphQLResponse) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-API-Key", "secret-key")
+	if storeID != "" {
+		req.Header.Set(middleware.StoreHeader, storeID)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var resp graphQLResponse
+	if w.Code == http.StatusOK {
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	}
+	return w, resp
+}
+
+func TestGraphQLHandler_CombinedQuery(t *testing.T) {
+	f := newGraphQLRouter(t)
+
+	w, resp := postGraphQL(t, f.router, `{"query": "{ products { name price } categories orders(limit: 10) { id total items { quantity } } queueStatus { pending completed } }"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, resp.Errors)
+
+	var products []map[string]any
+	require.NoError(t, json.Unmarshal(resp.Data["products"], &products))
+	assert.Len(t, products, 6)
+	// Only the selected fields come back
+	assert.Len(t, products[0], 2)
+	assert.Contains(t, products[0], "name")
+	assert.Contains(t, products[0], "price")
+
+	assert.JSONEq(t, `["Drinks", "Waffle"]`, string(resp.Data["categories"]))
+	assert.JSONEq(t, `[{"id": "`+f.order.ID+`", "total": 16.5, "items": [{"quantity": 3}]}]`, string(resp.Data["orders"]))
+	assert.JSONEq(t, `{"pending": 1, "completed": 0}`, string(resp.Data["queueStatus"]))
+}
+
+func TestGraphQLHandler_Product(t *testing.T) {
+	f := newGraphQLRouter(t)
+
+	body, err := json.Marshal(map[string]any{
+		"query":     "query Product($id: ID!) { product(id: $id) { name category image { thumbnail } } }",
+		"variables": map[string]any{"id": f.drink.ID},
+	})
+	require.NoError(t, err)
+	w, resp := postGraphQL(t, f.router, string(body))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, resp.Errors)
+	assert.JSONEq(t, `{"name": "Iced Latte", "category": "Drinks", "image": {"thumbnail": ""}}`, string(resp.Data["product"]))
+
+	// A product that doesn't exist is null, a malformed ID an error on the field
+	w, resp = postGraphQL(t, f.router, `{"query": "{ missing: product(id: \"`+uuid.New().String()+`\") { name } bad: product(id: \"nope\") { name } }"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `null`, string(resp.Data["missing"]))
+	assert.JSONEq(t, `null`, string(resp.Data["bad"]))
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, "invalid product ID format", resp.Errors[0].Message)
+	assert.Equal(t, []any{"bad"}, resp.Errors[0].Path)
+}
+
+func TestGraphQLHandler_PricingTier(t *testing.T) {
+	f := newGraphQLRouter(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query": "{ product(id: \"`+f.drink.ID+`\") { price } }"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-API-Key", "secret-key")
+	req.Header.Set(middleware.CustomerHeader, "staff-1")
+	w := httptest.NewRecorder()
+	f.router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"data": {"product": {"price": 3.85}}}`, w.Body.String())
+}
+
+func TestGraphQLHandler_Order(t *testing.T) {
+	f := newGraphQLRouter(t)
+
+	w, resp := postGraphQL(t, f.router, `{"query": "{ order(id: \"`+f.order.ID+`\") { id total } }"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, resp.Errors)
+	assert.JSONEq(t, `{"id": "`+f.order.ID+`", "total": 16.5}`, string(resp.Data["order"]))
+}
+
+func TestGraphQLHandler_Store(t *testing.T) {
+	f := newGraphQLRouter(t)
+
+	// Another store's menu, categories and orders are its own
+	w, resp := postGraphQLAs(t, f.router, f.storeID, `{"query": "{ products { name } categories orders { id } }"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, resp.Errors)
+	assert.JSONEq(t, `[{"name": "Croissant"}]`, string(resp.Data["products"]))
+	assert.JSONEq(t, `["Pastries"]`, string(resp.Data["categories"]))
+	assert.JSONEq(t, `[{"id": "`+f.storeOrder.ID+`"}]`, string(resp.Data["orders"]))
+
+	// and the default store's products and orders are as unknown to it as missing ones
+	w, resp = postGraphQLAs(t, f.router, f.storeID, `{"query": "{ product(id: \"`+f.drink.ID+`\") { name } order(id: \"`+f.order.ID+`\") { id } }"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, resp.Errors)
+	assert.JSONEq(t, `null`, string(resp.Data["product"]))
+	assert.JSONEq(t, `null`, string(resp.Data["order"]))
+}
+
+func TestGraphQLHandler_InvalidQuery(t *testing.T) {
+	f := newGraphQLRouter(t)
+
+	w, resp := postGraphQL(t, f.router, `{"query": "{ products { secret } }"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Nil(t, resp.Data)
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, `Cannot query field "secret" on type "Product".`, resp.Errors[0].Message)
+
+	w, _ = postGraphQL(t, f.router, `{"variables": {}}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"oolio/internal/app/handler"
+	"oolio/internal/app/middleware"
+	"oolio/internal/app/models"
+	"oolio/internal/app/repository"
+	"oolio/internal/app/services"
+)
+
+// emptyQueue has no queue items, so invoices are only found by order ID
+type emptyQueue struct {
+	services.OrderQueueService
+}
+
+func (emptyQueue) GetOrderFromQueue(ctx context.Context, itemID string) (*models.OrderQueueItem, error) {
+	return nil, errors.New("queue item not found")
+}
+
+func TestInvoiceHandler_OtherStore(t *testing.T) {
+	ctx := context.Background()
+	stores := services.NewStoreService(repository.NewMemoryStoreRepository())
+	store, err := stores.CreateStore(ctx, models.StoreReq{Name: "Harbour St"})
+	require.NoError(t, err)
+	key, err := stores.CreateAPIKey(ctx, store.ID, models.StoreAPIKeyReq{Name: "Till 1"})
+	require.NoError(t, err)
+
+	orders := repository.NewMemoryOrderRepository()
+	order := &models.Order{Total: 1000}
+	require.NoError(t, orders.Create(ctx, order))
+	invoices, err := services.NewInvoiceService(repository.NewMemoryInvoiceRepository(), orders, services.InvoiceOptions{Series: "INV"})
+	require.NoError(t, err)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.StoreAPIKeyAuth([]string{"secret-key"}, stores))
+	r.GET("/order/:orderId/invoice", handler.NewInvoiceHandler(invoices, emptyQueue{}).Get)
+	get := func(apiKey string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/order/"+order.ID+"/invoice", nil)
+		req.Header.Set("X-API-Key", apiKey)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// Neither issued for another store's key nor shown to it once issued
+	assert.Equal(t, http.StatusNotFound, get(key.Key).Code)
+	w := get("secret-key")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), `"number":"INV-000001"`)
+	assert.Equal(t, http.StatusNotFound, get(key.Key).Code)
+}
@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"oolio/internal/app/handler"
+	"oolio/internal/app/models"
+	"oolio/internal/app/repository"
+	"oolio/internal/app/services"
+)
+
+func TestOrderHandler_PlaceOrder_InvalidOption(t *testing.T) {
+	ctx := context.Background()
+	products := repository.NewMemoryProductRepository()
+	product := &models.Product{
+		Name:      "Iced Coffee",
+		Price:     500,
+		Category:  "Drinks",
+		Modifiers: []models.ProductOption{{Name: "Extra shot", PriceDelta: 80}},
+	}
+	require.NoError(t, services.NewProductService(products).CreateProduct(ctx, product))
+
+	orders := repository.NewMemoryOrderRepository()
+	queueRepo := repository.NewMemoryOrderQueueRepository()
+	orderService := services.NewOrderService(orders, products, queueRepo, nil, nil, nil, nil, nil, nil, nil, nil, zap.NewNop())
+	queue := services.NewOrderQueueService(services.OrderQueueDeps{
+		QueueRepo:    queueRepo,
+		OrderRepo:    orders,
+		OrderService: orderService,
+	}, nil, "test-worker")
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/order", handler.NewOrderHandler(handler.OrderHandlerDeps{OrderService: orderService, QueueService: queue}).PlaceOrder)
+	place := func(modifierID string) *httptest.ResponseRecorder {
+		body := `{"items":[{"productId":"` + product.ID + `","quantity":1,"modifierIds":["` + modifierID + `"]}]}`
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/order", strings.NewReader(body)))
+		return w
+	}
+
+	// Refused before it is queued, rather than failing in the worker
+	w := place(uuid.NewString())
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), "invalid product option")
+	pending, err := queueRepo.GetPendingItems(ctx, 10)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+
+	w = place(product.Modifiers[0].ID)
+	assert.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+}
@@ -6,10 +6,12 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 
 	"oolio/internal/app/handler"
 	"oolio/internal/app/models"
@@ -25,6 +27,20 @@ func (m *MockProductService) GetAllProducts(ctx context.Context) ([]models.Produ
 	return args.Get(0).([]models.Product), args.Error(1)
 }
 
+func (m *MockProductService) ListProducts(ctx context.Context, page models.PageRequest) ([]models.Product, int, error) {
+	args := m.Called(ctx, page)
+	return args.Get(0).([]models.Product), args.Int(1), args.Error(2)
+}
+
+func (m *MockProductService) GetProductsUpdatedSince(ctx context.Context, since time.Time) ([]models.Product, error) {
+	args := m.Called(ctx, since)
+	return args.Get(0).([]models.Product), args.Error(1)
+}
+
+func (m *MockProductService) GetProductForUpdate(ctx context.Context, id string) (*models.Product, error) {
+	return m.GetProductByID(ctx, id)
+}
+
 func (m *MockProductService) GetProductByID(ctx context.Context, id string) (*models.Product, error) {
 	args := m.Called(ctx, id)
 	if args.Get(0) == nil {
@@ -48,6 +64,24 @@ func (m *MockProductService) DeleteProduct(ctx context.Context, id string) error
 	return args.Error(0)
 }
 
+func (m *MockProductService) GetDeletedProducts(ctx context.Context, since time.Time) ([]models.Product, error) {
+	args := m.Called(ctx, since)
+	return args.Get(0).([]models.Product), args.Error(1)
+}
+
+func (m *MockProductService) RestoreProduct(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockProductService) GetChanges(ctx context.Context, storeID, since string, limit int) (*models.ProductChanges, error) {
+	args := m.Called(ctx, storeID, since, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ProductChanges), args.Error(1)
+}
+
 func TestProductHandler_ListProducts(t *testing.T) {
 	mockService := &MockProductService{}
 	handler := handler.NewProductHandler(mockService)
@@ -57,7 +91,7 @@ func TestProductHandler_ListProducts(t *testing.T) {
 		{
 			ID:       "test-1",
 			Name:     "Test Product 1",
-			Price:    10.99,
+			Price:    1099,
 			Category: "Waffle",
 			Image: models.Image{
 				Thumbnail: "http://example.com/thumb.jpg",
@@ -87,10 +121,79 @@ func TestProductHandler_ListProducts(t *testing.T) {
 	assert.Equal(t, expectedProducts[0].ID, response[0].ID)
 	assert.Equal(t, expectedProducts[0].Name, response[0].Name)
 	assert.Equal(t, expectedProducts[0].Price, response[0].Price)
+	assert.Equal(t, "1", w.Header().Get(models.TotalCountHeader))
+
+	mockService.AssertExpectations(t)
+}
+
+func TestProductHandler_ListProducts_Paged(t *testing.T) {
+	mockService := &MockProductService{}
+	handler := handler.NewProductHandler(mockService)
+	ctx := context.Background()
+
+	mockService.On("ListProducts", ctx, models.PageRequest{Limit: 2, Offset: 4}).
+		Return([]models.Product{{ID: "test-5"}, {ID: "test-6"}}, 9, nil)
+	mockService.On("ListProducts", ctx, models.PageRequest{Limit: models.DefaultPageLimit, Offset: 20}).
+		Return([]models.Product{}, 9, nil)
+
+	gin.SetMode(gin.TestMode)
+	for _, tc := range []struct {
+		query  string
+		status int
+		count  int
+	}{
+		{query: "?limit=2&offset=4", status: http.StatusOK, count: 2},
+		{query: "?offset=20", status: http.StatusOK, count: 0},
+		{query: "?limit=two", status: http.StatusBadRequest},
+		{query: "?limit=2&updated_since=2026-01-02T03:04:05Z", status: http.StatusBadRequest},
+	} {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("GET", "/api/v1/product"+tc.query, nil)
+
+		handler.ListProducts(c)
+
+		require.Equal(t, tc.status, w.Code, tc.query)
+		if tc.status != http.StatusOK {
+			continue
+		}
+		var response []models.Product
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Len(t, response, tc.count, tc.query)
+		assert.Equal(t, "9", w.Header().Get(models.TotalCountHeader), tc.query)
+	}
 
 	mockService.AssertExpectations(t)
 }
 
+func TestProductHandler_ListProducts_UpdatedSince(t *testing.T) {
+	mockService := &MockProductService{}
+	handler := handler.NewProductHandler(mockService)
+	ctx := context.Background()
+
+	since := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	mockService.On("GetProductsUpdatedSince", ctx, since).Return([]models.Product{{ID: "test-1", UpdatedAt: since}}, nil)
+
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/api/v1/product?updated_since=2026-01-02T03:04:05Z", nil)
+
+	handler.ListProducts(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"updatedAt":"2026-01-02T03:04:05Z"`)
+	mockService.AssertExpectations(t)
+
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/api/v1/product?updated_since=yesterday", nil)
+
+	handler.ListProducts(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
 func TestProductHandler_ListProducts_Error(t *testing.T) {
 	mockService := &MockProductService{}
 	handler := handler.NewProductHandler(mockService)
@@ -125,7 +228,7 @@ func TestProductHandler_GetProduct(t *testing.T) {
 	expectedProduct := &models.Product{
 		ID:       "test-1",
 		Name:     "Test Product 1",
-		Price:    10.99,
+		Price:    1099,
 		Category: "Waffle",
 		Image: models.Image{
 			Thumbnail: "http://example.com/thumb.jpg",
@@ -22,7 +22,10 @@ func TestIntegration_Routing_Products(t *testing.T) {
 
 	// Create mock handlers
 	mockProductHandler := handler.NewProductHandler(mockProductService)
-	mockOrderHandler := handler.NewOrderHandler(mockOrderService, mockQueueService)
+	mockOrderHandler := handler.NewOrderHandler(handler.OrderHandlerDeps{
+		OrderService: mockOrderService,
+		QueueService: mockQueueService,
+	})
 
 	// Create auth middleware that allows all requests
 	authMiddleware := middleware.APIKeyAuth([]string{"any-key"})
@@ -32,7 +35,13 @@ func TestIntegration_Routing_Products(t *testing.T) {
 	rateLimitMiddleware := middleware.NewRateLimitMiddleware(mockRateLimiter)
 
 	// Setup router
-	router := router.SetupRouter(mockProductHandler, mockOrderHandler, authMiddleware, []gin.HandlerFunc{}, rateLimitMiddleware)
+	router := router.SetupRouter(router.Deps{
+		ProductHandler:      mockProductHandler,
+		OrderHandler:        mockOrderHandler,
+		AuthMiddleware:      authMiddleware,
+		ErrorMiddleware:     []gin.HandlerFunc{},
+		RateLimitMiddleware: rateLimitMiddleware,
+	})
 
 	// Test GET /api/v1/product
 	req, _ := http.NewRequest("GET", "/api/v1/product", nil)
@@ -48,7 +57,10 @@ func TestIntegration_Routing_Orders(t *testing.T) {
 	mockQueueService := &MockOrderQueueService{}
 
 	// Create simple mock handler
-	mockHandler := handler.NewOrderHandler(mockOrderService, mockQueueService)
+	mockHandler := handler.NewOrderHandler(handler.OrderHandlerDeps{
+		OrderService: mockOrderService,
+		QueueService: mockQueueService,
+	})
 
 	// Create auth middleware that requires specific key
 	authMiddleware := middleware.APIKeyAuth([]string{"test-api-key"})
@@ -57,7 +69,12 @@ func TestIntegration_Routing_Orders(t *testing.T) {
 	rateLimitMiddleware := middleware.NewRateLimitMiddleware(&MockRateLimiterService{})
 
 	// Setup router
-	router := router.SetupRouter(nil, mockHandler, authMiddleware, []gin.HandlerFunc{}, rateLimitMiddleware)
+	router := router.SetupRouter(router.Deps{
+		OrderHandler:        mockHandler,
+		AuthMiddleware:      authMiddleware,
+		ErrorMiddleware:     []gin.HandlerFunc{},
+		RateLimitMiddleware: rateLimitMiddleware,
+	})
 
 	// Test POST /api/v1/order with valid API key
 	jsonBody := []byte(`{"items": [{"productId": "test-1", "quantity": 2}]}`)
@@ -78,7 +95,10 @@ func TestIntegration_Routing_Orders_Unauthorized(t *testing.T) {
 	mockQueueService := &MockOrderQueueService{}
 
 	// Create simple mock handler
-	mockHandler := handler.NewOrderHandler(mockOrderService, mockQueueService)
+	mockHandler := handler.NewOrderHandler(handler.OrderHandlerDeps{
+		OrderService: mockOrderService,
+		QueueService: mockQueueService,
+	})
 
 	// Create auth middleware that requires specific key
 	authMiddleware := middleware.APIKeyAuth([]string{"test-api-key"})
@@ -87,7 +107,12 @@ func TestIntegration_Routing_Orders_Unauthorized(t *testing.T) {
 	rateLimitMiddleware := middleware.NewRateLimitMiddleware(&MockRateLimiterService{})
 
 	// Setup router
-	router := router.SetupRouter(nil, mockHandler, authMiddleware, []gin.HandlerFunc{}, rateLimitMiddleware)
+	router := router.SetupRouter(router.Deps{
+		OrderHandler:        mockHandler,
+		AuthMiddleware:      authMiddleware,
+		ErrorMiddleware:     []gin.HandlerFunc{},
+		RateLimitMiddleware: rateLimitMiddleware,
+	})
 
 	// Test POST /api/v1/order without API key
 	jsonBody := []byte(`{"items": [{"productId": "test-1", "quantity": 2}]}`)
@@ -106,7 +131,10 @@ func TestIntegration_Routing_Orders_InvalidAPIKey(t *testing.T) {
 	mockQueueService := &MockOrderQueueService{}
 
 	// Create simple mock handler
-	mockHandler := handler.NewOrderHandler(mockOrderService, mockQueueService)
+	mockHandler := handler.NewOrderHandler(handler.OrderHandlerDeps{
+		OrderService: mockOrderService,
+		QueueService: mockQueueService,
+	})
 
 	// Create auth middleware that requires specific key
 	authMiddleware := middleware.APIKeyAuth([]string{"test-api-key"})
@@ -115,7 +143,12 @@ func TestIntegration_Routing_Orders_InvalidAPIKey(t *testing.T) {
 	rateLimitMiddleware := middleware.NewRateLimitMiddleware(&MockRateLimiterService{})
 
 	// Setup router
-	router := router.SetupRouter(nil, mockHandler, authMiddleware, []gin.HandlerFunc{}, rateLimitMiddleware)
+	router := router.SetupRouter(router.Deps{
+		OrderHandler:        mockHandler,
+		AuthMiddleware:      authMiddleware,
+		ErrorMiddleware:     []gin.HandlerFunc{},
+		RateLimitMiddleware: rateLimitMiddleware,
+	})
 
 	// Test POST /api/v1/order with invalid API key
 	jsonBody := []byte(`{"items": [{"productId": "test-1", "quantity": 2}]}`)
@@ -133,7 +166,10 @@ func TestIntegration_Routing_HealthCheck(t *testing.T) {
 	// Create mock services for order handler
 	mockOrderService := &MockOrderService{}
 	mockQueueService := &MockOrderQueueService{}
-	mockOrderHandler := handler.NewOrderHandler(mockOrderService, mockQueueService)
+	mockOrderHandler := handler.NewOrderHandler(handler.OrderHandlerDeps{
+		OrderService: mockOrderService,
+		QueueService: mockQueueService,
+	})
 
 	// Create auth middleware
 	authMiddleware := middleware.APIKeyAuth([]string{"any-key"})
@@ -142,7 +178,12 @@ func TestIntegration_Routing_HealthCheck(t *testing.T) {
 	rateLimitMiddleware := middleware.NewRateLimitMiddleware(&MockRateLimiterService{})
 
 	// Setup router
-	router := router.SetupRouter(nil, mockOrderHandler, authMiddleware, []gin.HandlerFunc{}, rateLimitMiddleware)
+	router := router.SetupRouter(router.Deps{
+		OrderHandler:        mockOrderHandler,
+		AuthMiddleware:      authMiddleware,
+		ErrorMiddleware:     []gin.HandlerFunc{},
+		RateLimitMiddleware: rateLimitMiddleware,
+	})
 
 	// Test GET /health
 	req, _ := http.NewRequest("GET", "/health", nil)
@@ -160,7 +201,10 @@ func TestIntegration_Routing_Products_WithAuth(t *testing.T) {
 
 	// Create mock handlers
 	mockProductHandler := handler.NewProductHandler(mockProductService)
-	mockOrderHandler := handler.NewOrderHandler(mockOrderService, mockQueueService)
+	mockOrderHandler := handler.NewOrderHandler(handler.OrderHandlerDeps{
+		OrderService: mockOrderService,
+		QueueService: mockQueueService,
+	})
 
 	// Create auth middleware that requires specific key
 	authMiddleware := middleware.APIKeyAuth([]string{"test-api-key"})
@@ -169,7 +213,13 @@ func TestIntegration_Routing_Products_WithAuth(t *testing.T) {
 	rateLimitMiddleware := middleware.NewRateLimitMiddleware(&MockRateLimiterService{})
 
 	// Setup router
-	router := router.SetupRouter(mockProductHandler, mockOrderHandler, authMiddleware, []gin.HandlerFunc{}, rateLimitMiddleware)
+	router := router.SetupRouter(router.Deps{
+		ProductHandler:      mockProductHandler,
+		OrderHandler:        mockOrderHandler,
+		AuthMiddleware:      authMiddleware,
+		ErrorMiddleware:     []gin.HandlerFunc{},
+		RateLimitMiddleware: rateLimitMiddleware,
+	})
 
 	// Test GET /api/v1/product (should work even with auth)
 	req, _ := http.NewRequest("GET", "/api/v1/product", nil)
@@ -13,8 +13,20 @@ type MockOrderService struct{}
 func (m *MockOrderService) CreateOrder(ctx context.Context, orderReq *models.OrderReq) (*models.Order, error) {
 	return &models.Order{
 		ID:        "test-order-id",
-		Total:     100.0,
-		Discounts: 0.0,
+		Total:     10000,
+		Discounts: 0,
+		Items:     orderReq.Items,
+	}, nil
+}
+
+func (m *MockOrderService) CapturePayment(ctx context.Context, order *models.Order) error {
+	return nil
+}
+
+func (m *MockOrderService) QuoteOrder(ctx context.Context, orderReq *models.OrderReq) (*models.Order, error) {
+	return &models.Order{
+		Total:     10000,
+		Discounts: 0,
 		Items:     orderReq.Items,
 	}, nil
 }
@@ -22,12 +34,32 @@ func (m *MockOrderService) CreateOrder(ctx context.Context, orderReq *models.Ord
 func (m *MockOrderService) GetOrder(ctx context.Context, id string) (*models.Order, error) {
 	return &models.Order{
 		ID:        id,
-		Total:     100.0,
-		Discounts: 0.0,
+		Total:     10000,
+		Discounts: 0,
 		Items:     []models.OrderItem{},
 	}, nil
 }
 
+func (m *MockOrderService) ListOrders(ctx context.Context, page models.PageRequest) ([]models.Order, int, error) {
+	return []models.Order{}, 0, nil
+}
+
+func (m *MockOrderService) DeleteOrder(ctx context.Context, id string, actor string) error {
+	return nil
+}
+
+func (m *MockOrderService) PaymentMethodReport(ctx context.Context, since time.Time) ([]models.PaymentMethodTotal, error) {
+	return []models.PaymentMethodTotal{}, nil
+}
+
+func (m *MockOrderService) RefundOrder(ctx context.Context, id string, amount models.Money, actor string) (*models.OrderRefund, error) {
+	return &models.OrderRefund{ID: "test-refund-id", OrderID: id, Amount: amount, Status: models.RefundStatusProcessed}, nil
+}
+
+func (m *MockOrderService) GetRefunds(ctx context.Context, id string) ([]models.OrderRefund, error) {
+	return []models.OrderRefund{}, nil
+}
+
 // MockOrderQueueService implements OrderQueueService for testing
 type MockOrderQueueService struct{}
 
@@ -43,6 +75,10 @@ func (m *MockOrderQueueService) GetCompletedOrders(ctx context.Context) ([]*mode
 	return []*models.OrderQueueItem{}, nil
 }
 
+func (m *MockOrderQueueService) GetOrdersUpdatedSince(ctx context.Context, since time.Time) ([]*models.OrderQueueItem, error) {
+	return []*models.OrderQueueItem{}, nil
+}
+
 func (m *MockOrderQueueService) ProcessBatch(ctx context.Context, batchSize int) (*models.BatchProcessResult, error) {
 	return &models.BatchProcessResult{
 		Processed: 0,
@@ -61,8 +97,8 @@ func (m *MockOrderQueueService) GetQueueStatus(ctx context.Context) (map[string]
 	}, nil
 }
 
-func (m *MockOrderQueueService) StartWorker(ctx context.Context, interval time.Duration, batchSize int) {
-	// Mock implementation does nothing
+func (m *MockOrderQueueService) GetBacklog(ctx context.Context) (*models.QueueBacklog, error) {
+	return &models.QueueBacklog{}, nil
 }
 
 func (m *MockOrderQueueService) GetOrderFromQueue(ctx context.Context, itemID string) (*models.OrderQueueItem, error) {
@@ -73,6 +109,22 @@ func (m *MockOrderQueueService) GetOrderFromQueue(ctx context.Context, itemID st
 	}, nil
 }
 
+func (m *MockOrderQueueService) Inspect(ctx context.Context, itemID string) (*models.QueueItemDetails, error) {
+	return &models.QueueItemDetails{
+		ID:       itemID,
+		Status:   "pending",
+		Attempts: []models.QueueFailure{},
+	}, nil
+}
+
+func (m *MockOrderQueueService) AttachPayment(ctx context.Context, itemID, intentID string) error {
+	return nil
+}
+
+func (m *MockOrderQueueService) Retry(ctx context.Context, itemID string) error {
+	return nil
+}
+
 // MockRateLimiterService implements RateLimiterService for testing
 type MockRateLimiterService struct{}
 
@@ -101,7 +153,7 @@ func (m *MockProductService) GetAllProducts(ctx context.Context) ([]models.Produ
 		{
 			ID:       "test-product-1",
 			Name:     "Test Product 1",
-			Price:    10.99,
+			Price:    1099,
 			Category: "Waffle",
 			Image: models.Image{
 				Thumbnail: "https://example.com/thumb1.jpg",
@@ -113,7 +165,7 @@ func (m *MockProductService) GetAllProducts(ctx context.Context) ([]models.Produ
 		{
 			ID:       "test-product-2",
 			Name:     "Test Product 2",
-			Price:    15.99,
+			Price:    1599,
 			Category: "Waffle",
 			Image: models.Image{
 				Thumbnail: "https://example.com/thumb2.jpg",
@@ -125,12 +177,29 @@ func (m *MockProductService) GetAllProducts(ctx context.Context) ([]models.Produ
 	}, nil
 }
 
+func (m *MockProductService) ListProducts(ctx context.Context, page models.PageRequest) ([]models.Product, int, error) {
+	products, err := m.GetAllProducts(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	start, end := page.Window(len(products))
+	return products[start:end], len(products), nil
+}
+
+func (m *MockProductService) GetProductsUpdatedSince(ctx context.Context, since time.Time) ([]models.Product, error) {
+	return []models.Product{}, nil
+}
+
+func (m *MockProductService) GetProductForUpdate(ctx context.Context, id string) (*models.Product, error) {
+	return m.GetProductByID(ctx, id)
+}
+
 func (m *MockProductService) GetProductByID(ctx context.Context, id string) (*models.Product, error) {
 	if id == "test-product-1" {
 		return &models.Product{
 			ID:       "test-product-1",
 			Name:     "Test Product 1",
-			Price:    10.99,
+			Price:    1099,
 			Category: "Waffle",
 			Image: models.Image{
 				Thumbnail: "https://example.com/thumb1.jpg",
@@ -158,12 +227,24 @@ func (m *MockProductService) DeleteProduct(ctx context.Context, id string) error
 	return nil
 }
 
+func (m *MockProductService) GetDeletedProducts(ctx context.Context, since time.Time) ([]models.Product, error) {
+	return []models.Product{}, nil
+}
+
+func (m *MockProductService) RestoreProduct(ctx context.Context, id string) error {
+	return nil
+}
+
+func (m *MockProductService) GetChanges(ctx context.Context, storeID, since string, limit int) (*models.ProductChanges, error) {
+	return &models.ProductChanges{Changes: []models.ProductChange{}, Cursor: "0"}, nil
+}
+
 func (m *MockProductService) GetProduct(ctx context.Context, id string) (*models.Product, error) {
 	if id == "test-product-1" {
 		return &models.Product{
 			ID:       "test-product-1",
 			Name:     "Test Product 1",
-			Price:    10.99,
+			Price:    1099,
 			Category: "Waffle",
 			Image: models.Image{
 				Thumbnail: "https://example.com/thumb1.jpg",
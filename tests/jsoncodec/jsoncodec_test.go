@@ -0,0 +1,127 @@
+package jsoncodec
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	ginjson "github.com/gin-gonic/gin/codec/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"oolio/internal/app/models"
+	"oolio/internal/jsoncodec"
+)
+
+// productListing is a listing like GET /product answers with, n products long
+func productListing(n int) []models.Product {
+	created := time.Date(2026, 3, 4, 5, 6, 7, 890, time.UTC)
+	deleted := created.Add(time.Hour)
+	products := make([]models.Product, n)
+	for i := range products {
+		products[i] = models.Product{
+			ID:        fmt.Sprintf("%08d-0000-4000-8000-000000000000", i),
+			Name:      fmt.Sprintf("Waffle <%d> & \"crème\" brûlée ☕  ", i),
+			Price:     models.Cents(int64(1000 + i)),
+			ListPrice: models.Cents(int64(1250 + i)),
+			Category:  "Waffle",
+			Image: models.Image{
+				Thumbnail: fmt.Sprintf("https://cdn.example.com/%d/thumb.jpg?w=100&h=100", i),
+				Mobile:    fmt.Sprintf("https://cdn.example.com/%d/mobile.jpg", i),
+			},
+			Version:   i % 7,
+			CreatedAt: created,
+			UpdatedAt: created.Add(time.Duration(i) * time.Minute),
+		}
+		if i%10 == 0 {
+			products[i].DeletedAt = &deleted
+		}
+	}
+	return products
+}
+
+func TestCodecs_MatchEncodingJSON(t *testing.T) {
+	std, err := jsoncodec.New(jsoncodec.Std)
+	require.NoError(t, err)
+	goJSON, err := jsoncodec.New(jsoncodec.GoJSON)
+	require.NoError(t, err)
+
+	payloads := map[string]any{
+		"products": productListing(50),
+		"product":  productListing(1)[0],
+		"error":    models.ApiResponse{Code: http.StatusNotFound, Type: "error", Message: "Product <id> not found"},
+		"map":      gin.H{"zeta": 1.5, "alpha": []string{"a&b"}, "nil": nil, "empty": map[string]int{}},
+	}
+	for name, payload := range payloads {
+		t.Run(name, func(t *testing.T) {
+			want, err := std.Marshal(payload)
+			require.NoError(t, err)
+			got, err := goJSON.Marshal(payload)
+			require.NoError(t, err)
+			assert.Equal(t, string(want), string(got))
+
+			var wantStream, gotStream bytes.Buffer
+			require.NoError(t, std.NewEncoder(&wantStream).Encode(payload))
+			require.NoError(t, goJSON.NewEncoder(&gotStream).Encode(payload))
+			assert.Equal(t, wantStream.String(), gotStream.String())
+		})
+	}
+
+	// Both read listings back the same
+	data, err := std.Marshal(productListing(20))
+	require.NoError(t, err)
+	var fromStd, fromGoJSON []models.Product
+	require.NoError(t, std.Unmarshal(data, &fromStd))
+	require.NoError(t, goJSON.Unmarshal(data, &fromGoJSON))
+	assert.Equal(t, fromStd, fromGoJSON)
+}
+
+func TestUse(t *testing.T) {
+	previous := ginjson.API
+	t.Cleanup(func() { ginjson.API = previous })
+
+	render := func() string {
+		gin.SetMode(gin.TestMode)
+		r := gin.New()
+		r.GET("/product", func(c *gin.Context) { c.JSON(http.StatusOK, productListing(3)) })
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/product", nil))
+		return w.Body.String()
+	}
+	want := render()
+
+	require.NoError(t, jsoncodec.Use(jsoncodec.GoJSON))
+	assert.NotEqual(t, previous, ginjson.API)
+	assert.Equal(t, want, render())
+
+	// Empty keeps the codec in use
+	current := ginjson.API
+	require.NoError(t, jsoncodec.Use(""))
+	assert.Equal(t, current, ginjson.API)
+
+	assert.Error(t, jsoncodec.Use("sonic"))
+	assert.Equal(t, current, ginjson.API)
+}
+
+func BenchmarkCodecs_ProductListing(b *testing.B) {
+	products := productListing(1000)
+	for _, name := range []string{jsoncodec.Std, jsoncodec.GoJSON} {
+		codec, err := jsoncodec.New(name)
+		require.NoError(b, err)
+
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				data, err := codec.Marshal(products)
+				if err != nil {
+					b.Fatal(err)
+				}
+				b.SetBytes(int64(len(data)))
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package loadtest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"oolio/internal/loadtest"
+)
+
+// fakeAPI answers like the API: products to list and read, and orders that fail when
+// rejectOrders is set
+func fakeAPI(t *testing.T, rejectOrders bool) (*httptest.Server, *atomic.Int64) {
+	var orders atomic.Int64
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /health", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("GET /api/v1/product", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "apitest", r.Header.Get("X-API-Key"))
+		_ = json.NewEncoder(w).Encode([]map[string]string{{"id": "1"}, {"id": "2"}, {"id": "3"}})
+	})
+	mux.HandleFunc("GET /api/v1/product/{productId}", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{"id": r.PathValue("productId")})
+	})
+	mux.HandleFunc("POST /api/v1/order", func(w http.ResponseWriter, r *http.Request) {
+		var order struct {
+			CouponCode string `json:"couponCode"`
+			Items      []struct {
+				ProductID string `json:"productId"`
+				Quantity  int    `json:"quantity"`
+			} `json:"items"`
+		}
+		if !assert.NoError(t, json.NewDecoder(r.Body).Decode(&order)) {
+			return
+		}
+		assert.Equal(t, "HAPPYHRS", order.CouponCode)
+		assert.NotEmpty(t, order.Items)
+		assert.LessOrEqual(t, len(order.Items), 2)
+		orders.Add(1)
+		if rejectOrders {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"id": "order"})
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server, &orders
+}
+
+func TestRun(t *testing.T) {
+	server, orders := fakeAPI(t, false)
+
+	report, err := loadtest.Run(context.Background(), loadtest.Options{
+		BaseURL:     server.URL + "/",
+		APIKey:      "apitest",
+		Duration:    300 * time.Millisecond,
+		Concurrency: 4,
+		CouponCode:  "HAPPYHRS",
+		MaxItems:    2,
+	})
+	require.NoError(t, err)
+
+	require.Len(t, report.Operations, 3)
+	assert.Equal(t, loadtest.GetProduct, report.Operations[0].Name)
+	assert.Equal(t, loadtest.ListProducts, report.Operations[1].Name)
+	assert.Equal(t, loadtest.PlaceOrder, report.Operations[2].Name)
+	for _, op := range report.Operations {
+		assert.Positive(t, op.Requests, op.Name)
+		assert.Zero(t, op.Errors, op.Name)
+		assert.Positive(t, op.RPS, op.Name)
+		assert.LessOrEqual(t, op.P50, op.P95, op.Name)
+		assert.LessOrEqual(t, op.P95, op.P99, op.Name)
+		assert.LessOrEqual(t, op.P99, op.Max, op.Name)
+	}
+	placed, _ := report.Operation(loadtest.PlaceOrder)
+	assert.LessOrEqual(t, int64(placed.Requests), orders.Load())
+
+	budget := &loadtest.Budget{Operations: map[string]loadtest.OperationBudget{
+		loadtest.PlaceOrder: {P99: 10_000, MaxErrorRate: 0.01, MinRequests: 1},
+	}}
+	assert.Empty(t, budget.Check(report))
+}
+
+func TestRun_ErrorsBreakTheBudget(t *testing.T) {
+	server, _ := fakeAPI(t, true)
+
+	report, err := loadtest.Run(context.Background(), loadtest.Options{
+		BaseURL:     server.URL,
+		APIKey:      "apitest",
+		Duration:    200 * time.Millisecond,
+		Concurrency: 2,
+		Mix:         loadtest.Mix{Order: 1},
+		CouponCode:  "HAPPYHRS",
+		MaxItems:    2,
+	})
+	require.NoError(t, err)
+
+	require.Len(t, report.Operations, 1)
+	placed := report.Operations[0]
+	assert.Equal(t, placed.Requests, placed.Errors)
+	assert.Equal(t, placed.Requests, placed.Throttled)
+	assert.Equal(t, 1.0, placed.ErrorRate)
+
+	budget := &loadtest.Budget{Operations: map[string]loadtest.OperationBudget{
+		loadtest.PlaceOrder:   {MaxErrorRate: 0.01},
+		loadtest.ListProducts: {P95: 100, MinRequests: 10},
+	}}
+	violations := budget.Check(report)
+	require.Len(t, violations, 2)
+	assert.Equal(t, loadtest.Violation{Operation: loadtest.ListProducts, Metric: "requests", Limit: 10, Actual: 0}, violations[0])
+	assert.Equal(t, loadtest.PlaceOrder, violations[1].Operation)
+	assert.Equal(t, "error rate", violations[1].Metric)
+}
+
+func TestRun_WaitsForHealth(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(server.Close)
+
+	_, err := loadtest.Run(context.Background(), loadtest.Options{BaseURL: server.URL, Duration: time.Second})
+	assert.ErrorContains(t, err, "not healthy")
+}
+
+func TestParseMix(t *testing.T) {
+	mix, err := loadtest.ParseMix("list=1, order=4")
+	require.NoError(t, err)
+	assert.Equal(t, loadtest.Mix{List: 1, Order: 4}, mix)
+
+	for _, invalid := range []string{"", "list", "list=-1", "browse=2", "list=0,order=0"} {
+		_, err := loadtest.ParseMix(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestBudgets(t *testing.T) {
+	budget, err := loadtest.DefaultBudget()
+	require.NoError(t, err)
+	for _, name := range []string{loadtest.ListProducts, loadtest.GetProduct, loadtest.PlaceOrder} {
+		assert.Positive(t, budget.Operations[name].P99, name)
+	}
+
+	path := filepath.Join(t.TempDir(), "budget.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"operations": {"checkout": {"p99Ms": 5}}}`), 0o644))
+	_, err = loadtest.LoadBudget(path)
+	assert.ErrorContains(t, err, `unknown operation "checkout"`)
+}
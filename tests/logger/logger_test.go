@@ -0,0 +1,50 @@
+package logger
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"oolio/internal/config"
+	"oolio/internal/logger"
+)
+
+func TestNewLevel(t *testing.T) {
+	level, err := logger.NewLevel(&config.Config{Log: config.LogConfig{Level: "warn"}})
+	require.NoError(t, err)
+	assert.Equal(t, zapcore.WarnLevel, level.Level())
+
+	_, err = logger.NewLevel(&config.Config{Log: config.LogConfig{Level: "loud"}})
+	assert.Error(t, err)
+}
+
+func TestNewLogger_FollowsLevelChanges(t *testing.T) {
+	level, err := logger.NewLevel(&config.Config{Log: config.LogConfig{Level: "info"}})
+	require.NoError(t, err)
+	log, err := logger.NewLogger(level)
+	require.NoError(t, err)
+
+	assert.False(t, log.Core().Enabled(zapcore.DebugLevel))
+
+	// The admin endpoint changes the shared level, which the built logger picks up
+	level.SetLevel(zapcore.DebugLevel)
+	assert.True(t, log.Core().Enabled(zapcore.DebugLevel))
+}
+
+func TestNewSampledLogger(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	sampled := logger.NewSampledLogger(zap.New(core), 2, 3)
+
+	for range 10 {
+		sampled.Info("request")
+	}
+	// The first 2 entries of the second, then every 3rd: the 5th and 8th
+	assert.Equal(t, 4, logs.Len())
+
+	unsampled := zap.New(core)
+	assert.Same(t, unsampled, logger.NewSampledLogger(unsampled, 0, 100))
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"oolio/internal/app/middleware"
+)
+
+func TestAccessLog(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	accessLog := middleware.NewAccessLogMiddleware(zap.New(core))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(accessLog.AccessLog())
+	r.GET("/product", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+	r.GET("/broken", func(c *gin.Context) { c.Status(http.StatusInternalServerError) })
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/product?limit=5", nil))
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/broken", nil))
+
+	entries := logs.All()
+	require.Len(t, entries, 2)
+
+	assert.Equal(t, zapcore.InfoLevel, entries[0].Level)
+	fields := entries[0].ContextMap()
+	assert.Equal(t, "GET", fields["method"])
+	assert.Equal(t, "/product", fields["path"])
+	assert.Equal(t, "limit=5", fields["query"])
+	assert.EqualValues(t, http.StatusOK, fields["status"])
+	assert.EqualValues(t, 2, fields["bytes"])
+
+	assert.Equal(t, zapcore.ErrorLevel, entries[1].Level)
+}
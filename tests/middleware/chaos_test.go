@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"oolio/internal/app/middleware"
+	"oolio/internal/chaos"
+	"oolio/internal/config"
+)
+
+func TestChaosMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	injector := chaos.New(config.ChaosConfig{Enabled: true, HTTP: config.FaultConfig{ErrorPercent: 100}})
+	r := gin.New()
+	r.Use(middleware.NewChaosMiddleware(injector.HTTP).Inject())
+	ok := func(c *gin.Context) { c.String(http.StatusOK, "ok") }
+	r.GET("/api/v1/product", ok)
+	r.GET("/health", ok)
+
+	w := get(r, "/api/v1/product")
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, "true", w.Header().Get("X-Chaos-Injected"))
+	assert.Equal(t, http.StatusOK, get(r, "/health").Code)
+
+	injector.Configure(config.ChaosConfig{})
+	assert.Equal(t, http.StatusOK, get(r, "/api/v1/product").Code)
+
+	// Without CHAOS_ENABLED there is no middleware
+	var disabled *middleware.ChaosMiddleware
+	r = gin.New()
+	r.Use(disabled.Inject())
+	r.GET("/api/v1/product", ok)
+	assert.Equal(t, http.StatusOK, get(r, "/api/v1/product").Code)
+}
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"oolio/internal/app/middleware"
+)
+
+func newOrderLookupEngine(lookup *middleware.OrderLookup) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/order/:orderId", lookup.Authorize(middleware.APIKeyAuth([]string{"secret-key"})), func(c *gin.Context) {
+		c.String(http.StatusOK, c.Param("orderId"))
+	})
+	return r
+}
+
+func getOrder(r *gin.Engine, orderID string, headers map[string]string) int {
+	req := httptest.NewRequest(http.MethodGet, "/order/"+orderID, nil)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestOrderLookup(t *testing.T) {
+	lookup := middleware.NewOrderLookup("lookup-secret", time.Hour)
+	r := newOrderLookupEngine(lookup)
+	token := lookup.Issue("order-1")
+
+	assert.Equal(t, http.StatusOK, getOrder(r, "order-1", map[string]string{middleware.OrderLookupHeader: token}))
+	assert.Equal(t, http.StatusForbidden, getOrder(r, "order-2", map[string]string{middleware.OrderLookupHeader: token}),
+		"a token reads only its own order")
+	assert.Equal(t, http.StatusForbidden, getOrder(r, "order-1", map[string]string{middleware.OrderLookupHeader: token + "x"}))
+
+	// Without a token the API key is required as before
+	assert.Equal(t, http.StatusUnauthorized, getOrder(r, "order-1", nil))
+	assert.Equal(t, http.StatusOK, getOrder(r, "order-2", map[string]string{"X-API-Key": "secret-key"}))
+
+	// Tokens from another secret or past their expiry are refused
+	other := middleware.NewOrderLookup("other-secret", time.Hour)
+	assert.False(t, lookup.Valid(other.Issue("order-1"), "order-1"))
+	expired := middleware.NewOrderLookup("lookup-secret", -time.Minute)
+	assert.False(t, lookup.Valid(expired.Issue("order-1"), "order-1"))
+}
+
+func TestOrderLookup_Disabled(t *testing.T) {
+	lookup := middleware.NewOrderLookup("", time.Hour)
+	assert.Nil(t, lookup)
+	assert.Empty(t, lookup.Issue("order-1"))
+
+	r := newOrderLookupEngine(lookup)
+	assert.Equal(t, http.StatusUnauthorized, getOrder(r, "order-1", map[string]string{middleware.OrderLookupHeader: "1.abc"}))
+}
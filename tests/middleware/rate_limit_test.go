@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"oolio/internal/app/middleware"
+	"oolio/internal/app/services"
+)
+
+func TestRateLimitNamed_FollowsSetLimit(t *testing.T) {
+	rateLimit := middleware.NewRateLimitMiddleware(services.NewMemoryRateLimiterService())
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/product", rateLimit.RateLimitNamed("product", 2, time.Minute), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	getFrom := func(client string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/product", nil)
+		req.RemoteAddr = client + ":1234"
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	get := func() *httptest.ResponseRecorder { return getFrom("192.0.2.1") }
+
+	assert.Equal(t, http.StatusOK, get().Code)
+	allowed := get()
+	assert.Equal(t, http.StatusOK, allowed.Code)
+	assert.Equal(t, "2", allowed.Header().Get("X-RateLimit-Limit"))
+	assert.Equal(t, http.StatusTooManyRequests, get().Code)
+
+	// A reloaded limit applies to the route without rebuilding it, from the next bucket on
+	rateLimit.SetLimit("product", 5)
+	raised := getFrom("192.0.2.2")
+	assert.Equal(t, http.StatusOK, raised.Code)
+	assert.Equal(t, "5", raised.Header().Get("X-RateLimit-Limit"))
+}
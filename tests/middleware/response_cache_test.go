@@ -0,0 +1,123 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"oolio/internal/app/middleware"
+	"oolio/internal/app/models"
+	"oolio/internal/app/repository"
+	"oolio/internal/app/services"
+)
+
+func newResponseCacheEngine(cache *middleware.ResponseCacheMiddleware, calls *int) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/product/:productId", cache.Cache(), func(c *gin.Context) {
+		*calls++
+		switch c.Param("productId") {
+		case "missing":
+			c.JSON(http.StatusNotFound, models.ApiResponse{Code: http.StatusNotFound, Message: "Product not found"})
+		case "private":
+			c.Header("Cache-Control", "private")
+			c.JSON(http.StatusOK, gin.H{"calls": *calls})
+		default:
+			c.Header(models.TotalCountHeader, "1")
+			c.JSON(http.StatusOK, gin.H{"calls": *calls})
+		}
+	})
+	return r
+}
+
+func getCached(r *gin.Engine, path string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestResponseCache(t *testing.T) {
+	version := services.NewCatalogVersion(repository.NewLRUCache(10))
+	cache := middleware.NewResponseCacheMiddleware(repository.NewLRUCache(100), version, time.Minute)
+	calls := 0
+	r := newResponseCacheEngine(cache, &calls)
+
+	first := getCached(r, "/product/1", nil)
+	require.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, "MISS", first.Header().Get(middleware.ResponseCacheHeader))
+	assert.Equal(t, "application/json; charset=utf-8", first.Header().Get("Content-Type"))
+	etag := first.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+
+	second := getCached(r, "/product/1", nil)
+	assert.Equal(t, "HIT", second.Header().Get(middleware.ResponseCacheHeader))
+	assert.Equal(t, first.Body.String(), second.Body.String())
+	assert.Equal(t, "application/json; charset=utf-8", second.Header().Get("Content-Type"))
+	assert.Equal(t, "1", second.Header().Get(models.TotalCountHeader), "paging headers are kept with the body")
+	assert.Equal(t, 1, calls)
+
+	// Clients holding the response get 304 without a body
+	notModified := getCached(r, "/product/1", map[string]string{"If-None-Match": etag})
+	assert.Equal(t, http.StatusNotModified, notModified.Code)
+	assert.Empty(t, notModified.Body.String())
+
+	// The query string is part of the key
+	getCached(r, "/product/1?updated_since=2024-01-01T00:00:00Z", nil)
+	assert.Equal(t, 2, calls)
+
+	// no-cache refreshes the cached copy, no-store bypasses the cache
+	refreshed := getCached(r, "/product/1", map[string]string{"Cache-Control": "no-cache"})
+	assert.Equal(t, "MISS", refreshed.Header().Get(middleware.ResponseCacheHeader))
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, refreshed.Body.String(), getCached(r, "/product/1", nil).Body.String())
+	bypassed := getCached(r, "/product/1", map[string]string{"Cache-Control": "no-store"})
+	assert.Empty(t, bypassed.Header().Get(middleware.ResponseCacheHeader))
+	assert.Equal(t, 4, calls)
+
+	// A product write moves the catalog version on, so every cached response is dropped
+	version.Bump(context.Background())
+	assert.Equal(t, "MISS", getCached(r, "/product/1", nil).Header().Get(middleware.ResponseCacheHeader))
+	assert.Equal(t, 5, calls)
+}
+
+func TestResponseCache_KeepsOnlyCacheableResponses(t *testing.T) {
+	version := services.NewCatalogVersion(repository.NewLRUCache(10))
+	cache := middleware.NewResponseCacheMiddleware(repository.NewLRUCache(100), version, time.Minute)
+	calls := 0
+	r := newResponseCacheEngine(cache, &calls)
+
+	for range 2 {
+		missing := getCached(r, "/product/missing", nil)
+		assert.Equal(t, http.StatusNotFound, missing.Code)
+		assert.Contains(t, missing.Body.String(), "Product not found")
+		assert.Empty(t, missing.Header().Get("ETag"))
+	}
+	assert.Equal(t, 2, calls, "errors aren't cached")
+
+	getCached(r, "/product/private", nil)
+	assert.Equal(t, "MISS", getCached(r, "/product/private", nil).Header().Get(middleware.ResponseCacheHeader))
+	assert.Equal(t, 4, calls, "private responses aren't cached")
+}
+
+func TestResponseCache_Disabled(t *testing.T) {
+	cache := middleware.NewResponseCacheMiddleware(nil, nil, time.Minute)
+	assert.Nil(t, cache)
+
+	calls := 0
+	r := newResponseCacheEngine(cache, &calls)
+	getCached(r, "/product/1", nil)
+	w := getCached(r, "/product/1", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get(middleware.ResponseCacheHeader))
+	assert.Equal(t, 2, calls)
+}
@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"oolio/internal/app/middleware"
+	"oolio/internal/app/models"
+	"oolio/internal/app/repository"
+	"oolio/internal/app/services"
+)
+
+func newStoreEngine(stores services.StoreService) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.StoreAPIKeyAuth([]string{"secret-key"}, stores))
+	r.GET("/store", func(c *gin.Context) {
+		c.String(http.StatusOK, middleware.StoreID(c))
+	})
+	r.GET("/admin", middleware.RequirePermission("admin"), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return r
+}
+
+func storeRequest(r *gin.Engine, path, apiKey, storeID string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if apiKey != "" {
+		req.Header.Set("X-API-Key", apiKey)
+	}
+	if storeID != "" {
+		req.Header.Set(middleware.StoreHeader, storeID)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestStoreAPIKeyAuth(t *testing.T) {
+	ctx := context.Background()
+	stores := services.NewStoreService(repository.NewMemoryStoreRepository())
+	store, err := stores.CreateStore(ctx, models.StoreReq{Name: "Harbour St"})
+	require.NoError(t, err)
+	key, err := stores.CreateAPIKey(ctx, store.ID, models.StoreAPIKeyReq{Name: "Till 1"})
+	require.NoError(t, err)
+	r := newStoreEngine(stores)
+
+	// The deployment key acts for the default store or the one it names
+	w := storeRequest(r, "/store", "secret-key", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, models.DefaultStoreID, w.Body.String())
+	w = storeRequest(r, "/store", "secret-key", store.ID)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, store.ID, w.Body.String())
+	assert.Equal(t, http.StatusNotFound, storeRequest(r, "/store", "secret-key", "missing").Code)
+	assert.Equal(t, http.StatusOK, storeRequest(r, "/admin", "secret-key", "").Code)
+
+	// A store key acts only for its store and can't administer
+	w = storeRequest(r, "/store", key.Key, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, store.ID, w.Body.String())
+	assert.Equal(t, http.StatusForbidden, storeRequest(r, "/store", key.Key, models.DefaultStoreID).Code)
+	assert.Equal(t, http.StatusForbidden, storeRequest(r, "/admin", key.Key, "").Code)
+
+	assert.Equal(t, http.StatusUnauthorized, storeRequest(r, "/store", "", "").Code)
+	assert.Equal(t, http.StatusUnauthorized, storeRequest(r, "/store", "sk_unknown", "").Code)
+
+	require.NoError(t, stores.RevokeAPIKey(ctx, store.ID, key.ID))
+	assert.Equal(t, http.StatusUnauthorized, storeRequest(r, "/store", key.Key, "").Code)
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"oolio/internal/app/middleware"
+)
+
+func newVersionedEngine(deprecation *middleware.DeprecationMiddleware) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+
+	handler := func(c *gin.Context) {
+		c.String(http.StatusOK, "%d %t", middleware.APIVersion(c), middleware.AtLeast(c, 2))
+	}
+	r.GET("/api/v1/product/:id", middleware.Version(1), deprecation.Deprecate("/api/v1", "/api/v2"), handler)
+	r.GET("/api/v2/product/:id", middleware.Version(2), handler)
+	r.GET("/health", handler)
+	return r
+}
+
+func get(r *gin.Engine, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	return w
+}
+
+func TestVersion(t *testing.T) {
+	r := newVersionedEngine(nil)
+
+	w := get(r, "/api/v2/product/7")
+	assert.Equal(t, "2 true", w.Body.String())
+	assert.Equal(t, "2", w.Header().Get(middleware.APIVersionHeader))
+
+	w = get(r, "/api/v1/product/7")
+	assert.Equal(t, "1 false", w.Body.String())
+	assert.Empty(t, w.Header().Get("Deprecation"), "nothing is announced without a deprecation date")
+
+	assert.Equal(t, "1 false", get(r, "/health").Body.String())
+}
+
+func TestDeprecate(t *testing.T) {
+	deprecatedAt := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)
+	r := newVersionedEngine(middleware.NewDeprecationMiddleware(deprecatedAt, sunset))
+
+	w := get(r, "/api/v1/product/7")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "@"+strconv.FormatInt(deprecatedAt.Unix(), 10), w.Header().Get("Deprecation"))
+	assert.Equal(t, "Fri, 01 Jan 2027 00:00:00 GMT", w.Header().Get("Sunset"))
+	assert.Equal(t, `</api/v2/product/7>; rel="successor-version"`, w.Header().Get("Link"))
+
+	w = get(r, "/api/v2/product/7")
+	assert.Empty(t, w.Header().Get("Deprecation"))
+	assert.Empty(t, w.Header().Get("Sunset"))
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"oolio/internal/app/models"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected models.Money
+	}{
+		{"12.99", 1299},
+		{"12.9", 1290},
+		{"12", 1200},
+		{".5", 50},
+		{"0.105", 11},
+		{"-3.50", -350},
+	}
+
+	for _, tt := range tests {
+		m, err := models.ParseMoney(tt.input)
+		require.NoError(t, err, tt.input)
+		assert.Equal(t, tt.expected, m, tt.input)
+	}
+
+	for _, input := range []string{"", "abc", "1.2x", "1,50"} {
+		_, err := models.ParseMoney(input)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestMoney_Arithmetic(t *testing.T) {
+	price := models.Cents(1099)
+
+	assert.Equal(t, models.Money(3297), price.Mul(3))
+	assert.Equal(t, models.Money(110), price.Percent(10))
+	assert.Equal(t, "10.99", price.String())
+	assert.Equal(t, "-0.05", models.Cents(-5).String())
+}
+
+func TestMoney_JSON(t *testing.T) {
+	product := models.Product{Price: 1599}
+
+	data, err := json.Marshal(product)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"price":15.99`)
+
+	var decoded models.Product
+	require.NoError(t, json.Unmarshal([]byte(`{"price":8.99}`), &decoded))
+	assert.Equal(t, models.Money(899), decoded.Price)
+
+	require.NoError(t, json.Unmarshal([]byte(`{"price":"8.99"}`), &decoded))
+	assert.Equal(t, models.Money(899), decoded.Price)
+}
+
+func TestMoney_Scan(t *testing.T) {
+	var m models.Money
+
+	require.NoError(t, m.Scan([]byte("14.99")))
+	assert.Equal(t, models.Money(1499), m)
+
+	require.NoError(t, m.Scan(nil))
+	assert.Equal(t, models.Money(0), m)
+
+	assert.Error(t, m.Scan(true))
+
+	value, err := models.Money(1050).Value()
+	require.NoError(t, err)
+	assert.Equal(t, "10.50", value)
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"oolio/internal/app/models"
+)
+
+func TestPricingTier_Price(t *testing.T) {
+	staff := models.PricingTier{Name: "staff", DiscountPercentage: 25}
+	assert.Equal(t, models.Money(974), staff.Price(1299), "rounded to the nearest cent")
+	assert.Equal(t, models.Money(1299), models.PricingTier{Name: models.TierRetail}.Price(1299))
+}
+
+func TestPricingTier_Apply(t *testing.T) {
+	products := []models.Product{{ID: "p1", Price: 1000}, {ID: "p2", Price: 650}}
+
+	priced := models.PricingTier{Name: "corporate", DiscountPercentage: 10}.Apply(products)
+	assert.Equal(t, models.Money(900), priced[0].Price)
+	assert.Equal(t, models.Money(1000), priced[0].ListPrice)
+	assert.Equal(t, models.Money(585), priced[1].Price)
+	assert.Equal(t, models.Money(1000), products[0].Price, "the products passed in are left alone, e.g. when cached")
+	assert.Zero(t, products[0].ListPrice)
+
+	retail := models.PricingTier{Name: models.TierRetail}.Apply(products)
+	assert.Equal(t, products, retail)
+}
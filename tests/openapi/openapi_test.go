@@ -0,0 +1,75 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"oolio/internal/app/router"
+)
+
+func newRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	return router.SetupRouter(router.Deps{AuthMiddleware: func(c *gin.Context) { c.Next() }})
+}
+
+// The spec is built from router.Operations, so every registered route must be listed there
+func TestOperations_MatchRoutes(t *testing.T) {
+	documented := make(map[string]bool)
+	for _, op := range router.Operations() {
+		documented[op.Method+" "+op.Path] = true
+	}
+
+	registered := make(map[string]bool)
+	for _, route := range newRouter().Routes() {
+		if route.Path == router.SpecPath || route.Path == router.DocsPath {
+			continue
+		}
+		// /api/v1/product/ is served alongside /api/v1/product
+		registered[route.Method+" "+strings.TrimSuffix(route.Path, "/")] = true
+	}
+
+	assert.Equal(t, documented, registered)
+}
+
+func TestSpecEndpoint(t *testing.T) {
+	engine := newRouter()
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, router.SpecPath, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var doc struct {
+		OpenAPI    string                               `json:"openapi"`
+		Paths      map[string]map[string]map[string]any `json:"paths"`
+		Components struct {
+			Schemas map[string]struct {
+				Required   []string                  `json:"required"`
+				Properties map[string]map[string]any `json:"properties"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &doc))
+
+	assert.Equal(t, "3.0.3", doc.OpenAPI)
+	require.Contains(t, doc.Paths, "/api/v1/product/{productId}")
+	assert.Contains(t, doc.Paths["/api/v1/product/{productId}"], "get")
+
+	product := doc.Components.Schemas["Product"]
+	assert.Contains(t, product.Required, "price")
+	assert.NotContains(t, product.Required, "deletedAt")
+	assert.Equal(t, "number", product.Properties["price"]["type"])
+	assert.Equal(t, "Selling price", product.Properties["price"]["description"])
+	assert.Equal(t, "date-time", product.Properties["createdAt"]["format"])
+
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, router.DocsPath, nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), router.SpecPath)
+}
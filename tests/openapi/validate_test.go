@@ -0,0 +1,87 @@
+package openapi
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"oolio/internal/app/models"
+	"oolio/internal/app/openapi"
+)
+
+type widget struct {
+	ID        string       `json:"id"`
+	Price     models.Money `json:"price"`
+	Tags      []string     `json:"tags"`
+	Note      *string      `json:"note"`
+	CreatedAt time.Time    `json:"createdAt"`
+	Parts     []widget     `json:"parts,omitempty"`
+}
+
+func newWidgetDocument() *openapi.Document {
+	return openapi.NewDocument(openapi.Info{Title: "Widgets", Version: "1"}, []openapi.Operation{{
+		Method: http.MethodGet, Path: "/widgets/:id",
+		Responses: map[int]any{http.StatusOK: widget{}, http.StatusNotFound: models.ApiResponse{}, http.StatusNoContent: nil},
+		Headers:   []openapi.Header{{Name: "X-RateLimit-Limit"}},
+	}})
+}
+
+func jsonHeader(extra ...string) http.Header {
+	header := http.Header{"Content-Type": {"application/json; charset=utf-8"}}
+	for i := 0; i+1 < len(extra); i += 2 {
+		header.Set(extra[i], extra[i+1])
+	}
+	return header
+}
+
+func TestValidateResponse_Matches(t *testing.T) {
+	doc := newWidgetDocument()
+	header := jsonHeader("X-RateLimit-Limit", "100")
+
+	body := `{"id":"w1","price":12.5,"tags":[],"note":null,"createdAt":"2026-01-02T03:04:05.123Z",
+		"parts":[{"id":"w2","price":1,"tags":["a"],"note":"loose","createdAt":"2026-01-02T03:04:05Z"}]}`
+	assert.NoError(t, doc.ValidateResponse(http.MethodGet, "/widgets/:id", http.StatusOK, header, []byte(body)))
+
+	// Errors are documented without the operation's headers, which only come with success
+	assert.NoError(t, doc.ValidateResponse(http.MethodGet, "/widgets/:id", http.StatusNotFound, jsonHeader(),
+		[]byte(`{"code":404,"type":"error","message":"Widget not found"}`)))
+	assert.NoError(t, doc.ValidateResponse(http.MethodGet, "/widgets/:id", http.StatusNoContent, header, nil))
+}
+
+func TestValidateResponse_Drift(t *testing.T) {
+	doc := newWidgetDocument()
+	header := jsonHeader("X-RateLimit-Limit", "100")
+	valid := `"id":"w1","price":12.5,"tags":[],"note":null,"createdAt":"2026-01-02T03:04:05Z"`
+
+	tests := []struct {
+		name   string
+		path   string
+		status int
+		header http.Header
+		body   string
+		want   string
+	}{
+		{"undocumented route", "/gadgets/:id", http.StatusOK, header, "{" + valid + "}", "GET /gadgets/:id is not documented"},
+		{"undocumented status", "/widgets/:id", http.StatusAccepted, header, "{" + valid + "}", "answered 202, which is not documented"},
+		{"missing header", "/widgets/:id", http.StatusOK, jsonHeader(), "{" + valid + "}", "header X-RateLimit-Limit is missing"},
+		{"not JSON", "/widgets/:id", http.StatusOK, http.Header{"X-Ratelimit-Limit": {"1"}}, "{" + valid + "}", `content type is ""`},
+		{"missing property", "/widgets/:id", http.StatusOK, header, `{"id":"w1","price":1,"createdAt":"2026-01-02T03:04:05Z"}`, "body.tags is missing"},
+		{"undocumented property", "/widgets/:id", http.StatusOK, header, "{" + valid + `,"colour":"red"}`, "body.colour is not documented"},
+		{"wrong type", "/widgets/:id", http.StatusOK, header, `{"id":1,"price":1,"tags":[],"note":null,"createdAt":"2026-01-02T03:04:05Z"}`, "body.id is number, expected string"},
+		{"null array", "/widgets/:id", http.StatusOK, header, `{"id":"w1","price":1,"tags":null,"note":null,"createdAt":"2026-01-02T03:04:05Z"}`, "body.tags is null, expected array"},
+		{"bad date-time", "/widgets/:id", http.StatusOK, header, `{"id":"w1","price":1,"tags":[],"note":null,"createdAt":"yesterday"}`, `body.createdAt is "yesterday", expected a date-time`},
+		{"nested drift", "/widgets/:id", http.StatusOK, header, "{" + valid + `,"parts":[{"id":"w2"}]}`, "body.parts[0].price is missing"},
+		{"not an integer", "/widgets/:id", http.StatusNotFound, jsonHeader(), `{"code":404.5,"type":"error","message":"x"}`, "body.code is 404.5, expected an integer"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := doc.ValidateResponse(http.MethodGet, tt.path, tt.status, tt.header, []byte(tt.body))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
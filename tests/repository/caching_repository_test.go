@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"oolio/internal/app/repository"
+)
+
+func TestCachingProductRepository(t *testing.T) {
+	backing := repository.NewMemoryProductRepository()
+	repo := repository.NewCachingProductRepository(backing, repository.NewLRUCache(10), time.Minute)
+	ctx := context.Background()
+
+	products, err := repo.Find(ctx)
+	require.NoError(t, err)
+	id := products[0].ID
+
+	product, err := repo.FindOne(ctx, id)
+	require.NoError(t, err)
+
+	// Writes that bypass the decorator aren't seen until the entries are invalidated
+	require.NoError(t, backing.Delete(ctx, products[1].ID))
+	cached, err := repo.Find(ctx)
+	require.NoError(t, err)
+	assert.Len(t, cached, len(products))
+
+	product.Name = "Renamed"
+	require.NoError(t, repo.Update(ctx, product))
+
+	updated, err := repo.FindOne(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, "Renamed", updated.Name)
+
+	fresh, err := repo.Find(ctx)
+	require.NoError(t, err)
+	assert.Len(t, fresh, len(products)-1)
+
+	require.NoError(t, repo.Delete(ctx, id))
+	_, err = repo.FindOne(ctx, id)
+	assert.Error(t, err)
+
+	require.NoError(t, repo.Restore(ctx, id))
+	_, err = repo.FindOne(ctx, id)
+	assert.NoError(t, err)
+}
+
+func TestLRUCache(t *testing.T) {
+	cache := repository.NewLRUCache(2)
+	ctx := context.Background()
+
+	require.NoError(t, cache.Set(ctx, "a", []byte("1"), 0))
+	require.NoError(t, cache.Set(ctx, "b", []byte("2"), 0))
+	_, ok, _ := cache.Get(ctx, "a")
+	require.True(t, ok)
+
+	// "b" is now the least recently used entry
+	require.NoError(t, cache.Set(ctx, "c", []byte("3"), 0))
+	_, ok, _ = cache.Get(ctx, "b")
+	assert.False(t, ok)
+
+	value, ok, _ := cache.Get(ctx, "a")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("1"), value)
+
+	require.NoError(t, cache.Set(ctx, "d", []byte("4"), time.Millisecond))
+	time.Sleep(5 * time.Millisecond)
+	_, ok, _ = cache.Get(ctx, "d")
+	assert.False(t, ok)
+
+	require.NoError(t, cache.Delete(ctx, "a"))
+	_, ok, _ = cache.Get(ctx, "a")
+	assert.False(t, ok)
+}
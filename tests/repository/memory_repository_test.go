@@ -0,0 +1,349 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"oolio/internal/app/models"
+	"oolio/internal/app/repository"
+)
+
+func TestMemoryProductRepository_Seeded(t *testing.T) {
+	repo := repository.NewMemoryProductRepository()
+	ctx := context.Background()
+
+	products, err := repo.Find(ctx)
+	require.NoError(t, err)
+	require.Len(t, products, 5)
+
+	product, err := repo.FindOne(ctx, products[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, products[0].Name, product.Name)
+
+	require.NoError(t, repo.Delete(ctx, product.ID))
+	_, err = repo.FindOne(ctx, product.ID)
+	assert.Error(t, err)
+}
+
+func TestMemoryProductRepository_SoftDelete(t *testing.T) {
+	repo := repository.NewMemoryProductRepository()
+	ctx := context.Background()
+	since := time.Now()
+
+	products, err := repo.Find(ctx)
+	require.NoError(t, err)
+	id := products[0].ID
+
+	require.NoError(t, repo.Delete(ctx, id))
+	assert.Error(t, repo.Delete(ctx, id))
+
+	remaining, err := repo.Find(ctx)
+	require.NoError(t, err)
+	assert.Len(t, remaining, len(products)-1)
+
+	// Sync clients still see the deletion
+	updated, err := repo.FindUpdatedSince(ctx, since)
+	require.NoError(t, err)
+	require.Len(t, updated, 1)
+	assert.NotNil(t, updated[0].DeletedAt)
+
+	deleted, err := repo.FindDeleted(ctx, since)
+	require.NoError(t, err)
+	require.Len(t, deleted, 1)
+	assert.Equal(t, id, deleted[0].ID)
+
+	require.NoError(t, repo.Restore(ctx, id))
+	assert.Error(t, repo.Restore(ctx, id))
+
+	restored, err := repo.FindOne(ctx, id)
+	require.NoError(t, err)
+	assert.Nil(t, restored.DeletedAt)
+	assert.Equal(t, products[0].Version+2, restored.Version)
+}
+
+func TestMemoryProductRepository_FindByIDs(t *testing.T) {
+	repo := repository.NewMemoryProductRepository()
+	ctx := context.Background()
+
+	products, err := repo.Find(ctx)
+	require.NoError(t, err)
+
+	// Duplicates and unknown IDs are dropped, results come back ordered by name
+	ids := []string{products[2].ID, products[0].ID, products[2].ID, uuid.New().String()}
+	found, err := repo.FindByIDs(ctx, ids)
+	require.NoError(t, err)
+	require.Len(t, found, 2)
+	assert.Equal(t, products[0].ID, found[0].ID)
+	assert.Equal(t, products[2].ID, found[1].ID)
+
+	_, err = repo.FindByIDs(ctx, []string{"not-a-uuid"})
+	assert.Error(t, err)
+}
+
+func TestMemoryOrderRepository_CreateOrderItems(t *testing.T) {
+	repo := repository.NewMemoryOrderRepository()
+	ctx := context.Background()
+
+	order := &models.Order{}
+	require.NoError(t, repo.Create(ctx, order))
+	require.NotEmpty(t, order.ID)
+
+	items := []models.OrderItem{{ProductID: "p1", Quantity: 2, Price: 10}}
+	require.NoError(t, repo.CreateOrderItems(ctx, order.ID, items))
+
+	got, err := repo.GetOrderItems(ctx, order.ID)
+	require.NoError(t, err)
+	assert.Equal(t, items, got)
+}
+
+func TestMemoryOrderRepository_PaymentMethodTotals(t *testing.T) {
+	repo := repository.NewMemoryOrderRepository()
+	ctx := context.Background()
+	since := time.Now()
+
+	for _, order := range []*models.Order{
+		{Total: 1000, Discounts: 100, PaymentMethod: models.PaymentMethodCash},
+		{Total: 500, PaymentMethod: models.PaymentMethodCash},
+		{Total: 700},
+		{Total: 900, PaymentMethod: models.PaymentMethodCounter},
+	} {
+		require.NoError(t, repo.Create(ctx, order))
+	}
+
+	until := time.Now().Add(time.Minute)
+	totals, err := repo.PaymentMethodTotals(ctx, since, until)
+	require.NoError(t, err)
+	assert.Equal(t, []models.PaymentMethodTotal{
+		{Method: models.PaymentMethodCard, Orders: 1, Paid: 700},
+		{Method: models.PaymentMethodCash, Orders: 2, Paid: 1400},
+		{Method: models.PaymentMethodCounter, Orders: 1, Paid: 900},
+	}, totals)
+
+	totals, err = repo.PaymentMethodTotals(ctx, until, until.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, totals)
+
+	totals, err = repo.PaymentMethodTotals(ctx, since.Add(-time.Hour), since)
+	require.NoError(t, err)
+	assert.Empty(t, totals)
+}
+
+func TestMemoryOrderRepository_TopProductsAndCouponUsage(t *testing.T) {
+	repo := repository.NewMemoryOrderRepository()
+	ctx := context.Background()
+	since := time.Now()
+
+	waffle := models.Product{ID: "waffle", Name: "Waffle"}
+	tiramisu := models.Product{ID: "tiramisu", Name: "Tiramisu"}
+	for _, order := range []*models.Order{
+		{
+			Items:      []models.OrderItem{{ProductID: "waffle", Quantity: 2, Price: 650}, {ProductID: "tiramisu", Quantity: 1, Price: 500}},
+			Products:   []models.Product{waffle, tiramisu},
+			Discounts:  180,
+			CouponCode: "HAPPYHRS",
+		},
+		{
+			Items:    []models.OrderItem{{ProductID: "waffle", Quantity: 1, Price: 650}},
+			Products: []models.Product{waffle},
+		},
+		{
+			Items:      []models.OrderItem{{ProductID: "tiramisu", Quantity: 1, Price: 500}},
+			Products:   []models.Product{tiramisu},
+			Discounts:  250,
+			CouponCode: "FIFTYOFF",
+		},
+	} {
+		require.NoError(t, repo.Create(ctx, order))
+	}
+	until := time.Now().Add(time.Minute)
+
+	products, err := repo.TopProducts(ctx, since, until, 5)
+	require.NoError(t, err)
+	assert.Equal(t, []models.ProductSales{
+		{ProductID: "waffle", Name: "Waffle", Quantity: 3, Revenue: 1950},
+		{ProductID: "tiramisu", Name: "Tiramisu", Quantity: 2, Revenue: 1000},
+	}, products)
+
+	products, err = repo.TopProducts(ctx, since, until, 1)
+	require.NoError(t, err)
+	assert.Len(t, products, 1)
+
+	coupons, err := repo.CouponUsage(ctx, since, until)
+	require.NoError(t, err)
+	assert.Equal(t, []models.CouponUsage{
+		{Code: "FIFTYOFF", Orders: 1, Discounts: 250},
+		{Code: "HAPPYHRS", Orders: 1, Discounts: 180},
+	}, coupons)
+
+	coupons, err = repo.CouponUsage(ctx, until, until.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, coupons)
+}
+
+func TestMemoryOrderQueueRepository_Lifecycle(t *testing.T) {
+	repo := repository.NewMemoryOrderQueueRepository()
+	ctx := context.Background()
+
+	item := &models.OrderQueueItem{ID: "item-1", Status: "pending", CreatedAt: time.Now()}
+	require.NoError(t, repo.AddToQueue(ctx, item))
+
+	pending, err := repo.GetPendingItems(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+
+	require.NoError(t, repo.MarkAsFailed(ctx, item.ID, "boom"))
+
+	// A failed item waits out its retry delay
+	failed, err := repo.GetOrderFromQueue(ctx, item.ID)
+	require.NoError(t, err)
+	failed.NextAttemptAt = time.Now().Add(time.Minute)
+	require.NoError(t, repo.UpdateItem(ctx, failed))
+	pending, err = repo.GetPendingItems(ctx, 10)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+
+	require.NoError(t, repo.MarkAsCompleted(ctx, item.ID, &models.Order{ID: "order-1"}))
+
+	stored, err := repo.GetOrderFromQueue(ctx, item.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "completed", stored.Status)
+	assert.Equal(t, 1, stored.RetryCount)
+
+	stats, err := repo.GetQueueStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats["completed"])
+}
+
+func TestMemoryOrderQueueRepository_Requeue(t *testing.T) {
+	repo := repository.NewMemoryOrderQueueRepository()
+	ctx := context.Background()
+
+	item := &models.OrderQueueItem{ID: "item-1", Status: "pending", CreatedAt: time.Now()}
+	require.NoError(t, repo.AddToQueue(ctx, item))
+	assert.ErrorContains(t, repo.Requeue(ctx, item.ID), "not found", "only failed items can be requeued")
+
+	// Exhaust the retry budget so the worker gives up on the item
+	for range 3 {
+		require.NoError(t, repo.MarkAsFailed(ctx, item.ID, "boom"))
+	}
+	pending, err := repo.GetPendingItems(ctx, 10)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+
+	require.NoError(t, repo.Requeue(ctx, item.ID))
+	pending, err = repo.GetPendingItems(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, "pending", pending[0].Status)
+	assert.Zero(t, pending[0].RetryCount)
+	assert.Empty(t, pending[0].Error)
+
+	assert.ErrorContains(t, repo.Requeue(ctx, "missing"), "not found")
+}
+
+func TestMemoryWebhookDeliveryRepository_ClaimDue(t *testing.T) {
+	repo := repository.NewMemoryWebhookDeliveryRepository()
+	ctx := context.Background()
+
+	delivery := &models.WebhookDelivery{Endpoint: models.WebhookEndpointEvents, EventID: "e1", Body: []byte(`{}`)}
+	inserted, err := repo.Enqueue(ctx, delivery)
+	require.NoError(t, err)
+	assert.True(t, inserted)
+	inserted, err = repo.Enqueue(ctx, &models.WebhookDelivery{Endpoint: models.WebhookEndpointEvents, EventID: "e1"})
+	require.NoError(t, err)
+	assert.False(t, inserted, "the endpoint already has the event")
+	inserted, err = repo.Enqueue(ctx, &models.WebhookDelivery{Endpoint: models.WebhookEndpointDigest, EventID: "e1"})
+	require.NoError(t, err)
+	assert.True(t, inserted, "another endpoint gets its own delivery")
+
+	due, err := repo.ClaimDue(ctx, 1, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+	assert.Equal(t, delivery.ID, due[0].ID)
+
+	// The claimed delivery is held back for the lease
+	due, err = repo.ClaimDue(ctx, 10, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+	assert.NotEqual(t, delivery.ID, due[0].ID)
+
+	attempt := models.WebhookAttempt{URL: "http://receiver", StatusCode: 502, Error: "webhook responded 502 Bad Gateway", AttemptedAt: time.Now()}
+	require.NoError(t, repo.MarkFailed(ctx, delivery.ID, attempt, time.Now().Add(-time.Second)))
+	due, err = repo.ClaimDue(ctx, 10, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+	assert.Equal(t, 1, due[0].Attempts)
+
+	found, err := repo.FindOne(ctx, delivery.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []models.WebhookAttempt{attempt}, found.AttemptLog)
+	assert.Equal(t, attempt.Error, found.LastError)
+
+	_, err = repo.FindOne(ctx, "missing")
+	assert.ErrorContains(t, err, "webhook delivery not found")
+}
+
+func TestMemoryRepositories_FindPage(t *testing.T) {
+	ctx := context.Background()
+
+	products, total, err := repository.NewMemoryProductRepository().FindPage(ctx, models.PageRequest{Limit: 2, Offset: 4})
+	require.NoError(t, err)
+	assert.Equal(t, 5, total)
+	assert.Len(t, products, 1)
+
+	orderRepo := repository.NewMemoryOrderRepository()
+	first, second := &models.Order{}, &models.Order{}
+	require.NoError(t, orderRepo.Create(ctx, first))
+	require.NoError(t, orderRepo.Create(ctx, second))
+
+	orders, total, err := orderRepo.FindPage(ctx, models.PageRequest{Limit: 1})
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+	require.Len(t, orders, 1)
+	assert.Equal(t, second.ID, orders[0].ID)
+
+	queueRepo := repository.NewMemoryOrderQueueRepository()
+	now := time.Now()
+	for i, id := range []string{"item-1", "item-2", "item-3"} {
+		item := &models.OrderQueueItem{ID: id, Status: "pending", CreatedAt: now.Add(time.Duration(i) * time.Second)}
+		require.NoError(t, queueRepo.AddToQueue(ctx, item))
+	}
+
+	items, total, err := queueRepo.FindPage(ctx, models.PageRequest{Limit: 2, Offset: 1})
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+	require.Len(t, items, 2)
+	assert.Equal(t, "item-2", items[0].ID)
+
+	items, _, err = queueRepo.FindPage(ctx, models.PageRequest{Offset: 10})
+	require.NoError(t, err)
+	assert.Empty(t, items)
+}
+
+func TestMemoryProductRepository_UpdateConflict(t *testing.T) {
+	repo := repository.NewMemoryProductRepository()
+	ctx := context.Background()
+
+	products, err := repo.Find(ctx)
+	require.NoError(t, err)
+
+	first, second := products[0], products[0]
+	first.Name = "Renamed Waffle"
+	require.NoError(t, repo.Update(ctx, &first))
+	assert.Equal(t, 2, first.Version)
+
+	second.Price = 100
+	err = repo.Update(ctx, &second)
+	var conflict *repository.ConflictError
+	require.ErrorAs(t, err, &conflict)
+	assert.Equal(t, 1, conflict.Version)
+
+	stored, err := repo.FindOne(ctx, first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Renamed Waffle", stored.Name)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -23,20 +24,20 @@ func NewMockOrderRepository() repository.OrderRepository {
 		orders: []models.Order{
 			{
 				ID:        "test-order-1",
-				Total:     25.99,
-				Discounts: 0.0,
+				Total:     2599,
+				Discounts: 0,
 				Items: []models.OrderItem{
 					{
 						ProductID: "test-product-1",
 						Quantity:  2,
-						Price:     10.99,
+						Price:     1099,
 					},
 				},
 				Products: []models.Product{
 					{
 						ID:       "test-product-1",
 						Name:     "Test Product 1",
-						Price:    10.99,
+						Price:    1099,
 						Category: "Waffle",
 						Image: models.Image{
 							Thumbnail: "http://example.com/thumb.jpg",
@@ -55,6 +56,21 @@ func (r *mockOrderRepository) Find(ctx context.Context) ([]models.Order, error)
 	return r.orders, nil
 }
 
+func (r *mockOrderRepository) FindPage(ctx context.Context, page models.PageRequest) ([]models.Order, int, error) {
+	start, end := page.Window(len(r.orders))
+	return r.orders[start:end], len(r.orders), nil
+}
+
+func (r *mockOrderRepository) FindUpdatedSince(ctx context.Context, since time.Time) ([]models.Order, error) {
+	var orders []models.Order
+	for _, order := range r.orders {
+		if !order.UpdatedAt.Before(since) {
+			orders = append(orders, order)
+		}
+	}
+	return orders, nil
+}
+
 func (r *mockOrderRepository) FindOne(ctx context.Context, id string) (*models.Order, error) {
 	for _, order := range r.orders {
 		if order.ID == id {
@@ -80,6 +96,43 @@ func (r *mockOrderRepository) Update(ctx context.Context, order *models.Order) e
 	return sql.ErrNoRows
 }
 
+func (r *mockOrderRepository) PaymentMethodTotals(ctx context.Context, since, until time.Time) ([]models.PaymentMethodTotal, error) {
+	return []models.PaymentMethodTotal{}, nil
+}
+
+func (r *mockOrderRepository) TopProducts(ctx context.Context, since, until time.Time, limit int) ([]models.ProductSales, error) {
+	return []models.ProductSales{}, nil
+}
+
+func (r *mockOrderRepository) CouponUsage(ctx context.Context, since, until time.Time) ([]models.CouponUsage, error) {
+	return []models.CouponUsage{}, nil
+}
+
+func (r *mockOrderRepository) Sales(ctx context.Context, query models.SalesQuery) ([]models.SalesGroup, error) {
+	return []models.SalesGroup{}, nil
+}
+
+func (r *mockOrderRepository) FindByPaymentIntent(ctx context.Context, intentID string) (*models.Order, error) {
+	for i := range r.orders {
+		if r.orders[i].PaymentIntentID == intentID {
+			return &r.orders[i], nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (r *mockOrderRepository) CreateRefund(ctx context.Context, refund *models.OrderRefund) error {
+	return nil
+}
+
+func (r *mockOrderRepository) UpdateRefund(ctx context.Context, refund *models.OrderRefund) error {
+	return nil
+}
+
+func (r *mockOrderRepository) GetRefunds(ctx context.Context, orderID string) ([]models.OrderRefund, error) {
+	return []models.OrderRefund{}, nil
+}
+
 func (r *mockOrderRepository) Delete(ctx context.Context, id string) error {
 	// Not implemented as per business requirements
 	return sql.ErrNoRows
@@ -99,6 +152,16 @@ func (r *mockOrderRepository) GetOrderItems(ctx context.Context, orderID string)
 	return nil, sql.ErrNoRows
 }
 
+func (r *mockOrderRepository) SetPaymentStatus(ctx context.Context, orderID string, status string) error {
+	for i := range r.orders {
+		if r.orders[i].ID == orderID {
+			r.orders[i].PaymentStatus = status
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
 func TestOrderRepository_FindOne(t *testing.T) {
 	repo := NewMockOrderRepository()
 	ctx := context.Background()
@@ -108,7 +171,7 @@ func TestOrderRepository_FindOne(t *testing.T) {
 	assert.NoError(t, err)
 	require.NotNil(t, order)
 	assert.Equal(t, "test-order-1", order.ID)
-	assert.Equal(t, 25.99, order.Total)
+	assert.Equal(t, models.Money(2599), order.Total)
 	assert.Len(t, order.Items, 1)
 
 	// Test non-existing order
@@ -123,13 +186,13 @@ func TestOrderRepository_Create(t *testing.T) {
 	ctx := context.Background()
 
 	newOrder := &models.Order{
-		Total:     50.99,
-		Discounts: 5.00,
+		Total:     5099,
+		Discounts: 500,
 		Items: []models.OrderItem{
 			{
 				ProductID: "test-product-2",
 				Quantity:  3,
-				Price:     15.99,
+				Price:     1599,
 			},
 		},
 	}
@@ -152,12 +215,12 @@ func TestOrderRepository_CreateOrderItems(t *testing.T) {
 		{
 			ProductID: "test-product-1",
 			Quantity:  2,
-			Price:     10.99,
+			Price:     1099,
 		},
 		{
 			ProductID: "test-product-2",
 			Quantity:  1,
-			Price:     15.99,
+			Price:     1599,
 		},
 	}
 
@@ -174,7 +237,7 @@ func TestOrderRepository_GetOrderItems(t *testing.T) {
 	assert.Len(t, items, 1)
 	assert.Equal(t, "test-product-1", items[0].ProductID)
 	assert.Equal(t, 2, items[0].Quantity)
-	assert.Equal(t, 10.99, items[0].Price)
+	assert.Equal(t, models.Money(1099), items[0].Price)
 
 	// Test non-existing order
 	items, err = repo.GetOrderItems(ctx, "non-existing")
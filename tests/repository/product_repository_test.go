@@ -3,7 +3,9 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"slices"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -27,7 +29,7 @@ func NewMockProductRepository() repository.ProductRepository {
 			{
 				ID:       "test-product-1",
 				Name:     "Test Product 1",
-				Price:    10.99,
+				Price:    1099,
 				Category: "Waffle",
 				Image: models.Image{
 					Thumbnail: "http://example.com/thumb.jpg",
@@ -39,7 +41,7 @@ func NewMockProductRepository() repository.ProductRepository {
 			{
 				ID:       "test-product-2",
 				Name:     "Test Product 2",
-				Price:    15.99,
+				Price:    1599,
 				Category: "Waffle",
 				Image: models.Image{
 					Thumbnail: "http://example.com/thumb2.jpg",
@@ -56,6 +58,35 @@ func (r *mockProductRepository) Find(ctx context.Context) ([]models.Product, err
 	return r.products, nil
 }
 
+func (r *mockProductRepository) FindPage(ctx context.Context, page models.PageRequest) ([]models.Product, int, error) {
+	start, end := page.Window(len(r.products))
+	return r.products[start:end], len(r.products), nil
+}
+
+func (r *mockProductRepository) FindUpdatedSince(ctx context.Context, since time.Time) ([]models.Product, error) {
+	var products []models.Product
+	for _, product := range r.products {
+		if !product.UpdatedAt.Before(since) {
+			products = append(products, product)
+		}
+	}
+	return products, nil
+}
+
+func (r *mockProductRepository) FindByIDs(ctx context.Context, ids []string) ([]models.Product, error) {
+	var products []models.Product
+	for _, product := range r.products {
+		if slices.Contains(ids, product.ID) {
+			products = append(products, product)
+		}
+	}
+	return products, nil
+}
+
+func (r *mockProductRepository) FindOneForUpdate(ctx context.Context, id string) (*models.Product, error) {
+	return r.FindOne(ctx, id)
+}
+
 func (r *mockProductRepository) FindOne(ctx context.Context, id string) (*models.Product, error) {
 	for _, product := range r.products {
 		if product.ID == id {
@@ -91,6 +122,18 @@ func (r *mockProductRepository) Delete(ctx context.Context, id string) error {
 	return sql.ErrNoRows
 }
 
+func (r *mockProductRepository) FindDeleted(ctx context.Context, since time.Time) ([]models.Product, error) {
+	return nil, nil
+}
+
+func (r *mockProductRepository) Restore(ctx context.Context, id string) error {
+	return sql.ErrNoRows
+}
+
+func (r *mockProductRepository) FindChanges(ctx context.Context, storeID string, after int64, limit int) ([]models.ProductChange, error) {
+	return nil, nil
+}
+
 func TestProductRepository_Find(t *testing.T) {
 	repo := NewMockProductRepository()
 	ctx := context.Background()
@@ -110,7 +153,7 @@ func TestProductRepository_FindOne(t *testing.T) {
 	assert.NoError(t, err)
 	require.NotNil(t, product)
 	assert.Equal(t, "Test Product 1", product.Name)
-	assert.Equal(t, 10.99, product.Price)
+	assert.Equal(t, models.Money(1099), product.Price)
 
 	// Test non-existing product
 	product, err = repo.FindOne(ctx, "non-existing")
@@ -125,7 +168,7 @@ func TestProductRepository_Create(t *testing.T) {
 
 	newProduct := &models.Product{
 		Name:     "New Product",
-		Price:    25.99,
+		Price:    2599,
 		Category: "Waffle",
 		Image: models.Image{
 			Thumbnail: "http://example.com/new-thumb.jpg",
@@ -156,7 +199,7 @@ func TestProductRepository_Update(t *testing.T) {
 
 	// Update product
 	product.Name = "Updated Product"
-	product.Price = 99.99
+	product.Price = 9999
 
 	err = repo.Update(ctx, product)
 	assert.NoError(t, err)
@@ -165,7 +208,7 @@ func TestProductRepository_Update(t *testing.T) {
 	updatedProduct, err := repo.FindOne(ctx, "test-product-1")
 	assert.NoError(t, err)
 	assert.Equal(t, "Updated Product", updatedProduct.Name)
-	assert.Equal(t, 99.99, updatedProduct.Price)
+	assert.Equal(t, models.Money(9999), updatedProduct.Price)
 }
 
 func TestProductRepository_Update_NotFound(t *testing.T) {
@@ -175,7 +218,7 @@ func TestProductRepository_Update_NotFound(t *testing.T) {
 	product := &models.Product{
 		ID:       "non-existing",
 		Name:     "Updated Product",
-		Price:    99.99,
+		Price:    9999,
 		Category: "Waffle",
 	}
 
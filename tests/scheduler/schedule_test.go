@@ -0,0 +1,66 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"oolio/internal/scheduler"
+)
+
+func TestParse_Next(t *testing.T) {
+	sydney, err := time.LoadLocation("Australia/Sydney")
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		spec  string
+		loc   *time.Location
+		after time.Time
+		next  time.Time
+	}{
+		{"*/15 * * * *", time.UTC, time.Date(2026, 3, 2, 10, 7, 30, 0, time.UTC), time.Date(2026, 3, 2, 10, 15, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.UTC, time.Date(2026, 3, 2, 10, 45, 0, 0, time.UTC), time.Date(2026, 3, 2, 11, 0, 0, 0, time.UTC)},
+		{"30 2 * * *", time.UTC, time.Date(2026, 12, 31, 3, 0, 0, 0, time.UTC), time.Date(2027, 1, 1, 2, 30, 0, 0, time.UTC)},
+		// Friday afternoon to Monday morning
+		{"0 9 * * 1-5", time.UTC, time.Date(2026, 3, 6, 10, 0, 0, 0, time.UTC), time.Date(2026, 3, 9, 9, 0, 0, 0, time.UTC)},
+		// Either the 13th or a Friday
+		{"0 0 13 * 5", time.UTC, time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC), time.Date(2026, 3, 13, 0, 0, 0, 0, time.UTC)},
+		{"0 0 13 * 5", time.UTC, time.Date(2026, 3, 13, 0, 0, 0, 0, time.UTC), time.Date(2026, 3, 20, 0, 0, 0, 0, time.UTC)},
+		{"0 12 * * 7", time.UTC, time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC), time.Date(2026, 3, 8, 12, 0, 0, 0, time.UTC)},
+		{"@monthly", time.UTC, time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC), time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)},
+		// The hour stays put in Sydney as daylight saving starts on 4 October 2026
+		{"0 6 * * *", sydney, time.Date(2026, 10, 3, 7, 0, 0, 0, sydney), time.Date(2026, 10, 4, 6, 0, 0, 0, sydney)},
+		{"@every 90m", time.UTC, time.Date(2026, 3, 2, 10, 7, 30, 0, time.UTC), time.Date(2026, 3, 2, 11, 37, 30, 0, time.UTC)},
+	} {
+		schedule, err := scheduler.Parse(tc.spec, tc.loc)
+		require.NoError(t, err, tc.spec)
+		assert.Equal(t, tc.next, schedule.Next(tc.after).In(tc.next.Location()), "%s after %s", tc.spec, tc.after)
+	}
+
+	schedule, err := scheduler.Parse("0 6 * * *", sydney)
+	require.NoError(t, err)
+	assert.Equal(t, "0 6 * * * (Australia/Sydney)", schedule.String())
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "0 0 0 * *", "*/0 * * * *", "5-1 * * * *", "a * * * *", "@every", "@every -1m", "@yearly"} {
+		_, err := scheduler.Parse(spec, time.UTC)
+		assert.Error(t, err, spec)
+	}
+
+	// A date that never comes is never due
+	schedule, err := scheduler.Parse("0 0 30 2 *", time.UTC)
+	require.NoError(t, err)
+	assert.True(t, schedule.Next(time.Now()).IsZero())
+}
+
+func TestEvery(t *testing.T) {
+	now := time.Now()
+	assert.Equal(t, now.Add(time.Hour), scheduler.Every(time.Hour).Next(now))
+	assert.Equal(t, "@every 1h0m0s", scheduler.Every(time.Hour).String())
+
+	assert.True(t, scheduler.Every(0).Next(now).IsZero(), "an interval of 0 disables the job")
+	assert.Equal(t, "disabled", scheduler.Every(0).String())
+}
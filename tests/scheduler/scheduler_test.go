@@ -0,0 +1,182 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"oolio/internal/scheduler"
+)
+
+// start runs the scheduler until the test ends
+func start(t *testing.T, s *scheduler.Scheduler) {
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		s.Start(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-stopped
+	})
+}
+
+func TestScheduler_RunsJobsOnOneInstance(t *testing.T) {
+	store := scheduler.NewMemoryStore()
+
+	var runs, localRuns atomic.Int32
+	release := make(chan struct{})
+	var once sync.Once
+	jobs := []scheduler.Job{
+		{
+			Name:       "digest",
+			Schedule:   scheduler.Every(0),
+			RunAtStart: true,
+			Run: func(ctx context.Context) error {
+				runs.Add(1)
+				<-release
+				return nil
+			},
+		},
+		{
+			Name:       "coupons",
+			Schedule:   scheduler.Every(0),
+			RunAtStart: true,
+			Local:      true,
+			Run: func(ctx context.Context) error {
+				localRuns.Add(1)
+				return nil
+			},
+		},
+	}
+
+	var instances []*scheduler.Scheduler
+	for _, name := range []string{"api-1", "api-2"} {
+		s, err := scheduler.New(store, scheduler.Options{Instance: name}, zap.NewNop(), jobs...)
+		require.NoError(t, err)
+		instances = append(instances, s)
+		start(t, s)
+	}
+	t.Cleanup(func() { once.Do(func() { close(release) }) })
+
+	require.Eventually(t, func() bool { return localRuns.Load() == 2 }, time.Second, time.Millisecond, "local jobs run on every instance")
+	require.Eventually(t, func() bool { return runs.Load() == 1 }, time.Second, time.Millisecond)
+
+	var running int
+	for _, s := range instances {
+		statuses, err := s.Status(context.Background())
+		require.NoError(t, err)
+		require.Len(t, statuses, 2)
+		assert.Equal(t, "coupons", statuses[0].Name, "sorted by name")
+		if statuses[1].Running {
+			running++
+		}
+	}
+	assert.Equal(t, 1, running, "the other instance found the job locked")
+
+	once.Do(func() { close(release) })
+	require.Eventually(t, func() bool {
+		statuses, err := instances[0].Status(context.Background())
+		return err == nil && statuses[1].LastRun != nil
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, int32(1), runs.Load())
+
+	statuses, err := instances[1].Status(context.Background())
+	require.NoError(t, err)
+	digest := statuses[1]
+	assert.Equal(t, "disabled", digest.Schedule)
+	assert.Nil(t, digest.NextRunAt)
+	require.NotNil(t, digest.LastRun, "runs are shared by the instances")
+	assert.True(t, digest.LastRun.Succeeded)
+	assert.Contains(t, []string{"api-1", "api-2"}, digest.LastRun.Instance)
+}
+
+func TestScheduler_RecordsFailures(t *testing.T) {
+	store := scheduler.NewMemoryStore()
+	s, err := scheduler.New(store, scheduler.Options{Instance: "api-1"}, zap.NewNop(),
+		scheduler.Job{Name: "fails", Schedule: scheduler.Every(0), RunAtStart: true, Run: func(ctx context.Context) error {
+			return errors.New("source unavailable")
+		}},
+		scheduler.Job{Name: "panics", Schedule: scheduler.Every(0), RunAtStart: true, Run: func(ctx context.Context) error {
+			panic("boom")
+		}},
+	)
+	require.NoError(t, err)
+	start(t, s)
+
+	var runs map[string]scheduler.Run
+	require.Eventually(t, func() bool {
+		runs, err = store.LastRuns(context.Background())
+		return err == nil && len(runs) == 2
+	}, time.Second, time.Millisecond)
+	assert.False(t, runs["fails"].Succeeded)
+	assert.Equal(t, "source unavailable", runs["fails"].Error)
+	assert.False(t, runs["panics"].Succeeded)
+	assert.Equal(t, "job panicked: boom", runs["panics"].Error)
+}
+
+func TestScheduler_RunsOnSchedule(t *testing.T) {
+	var runs atomic.Int32
+	s, err := scheduler.New(scheduler.NewMemoryStore(), scheduler.Options{
+		Schedules: map[string]scheduler.Schedule{"cleanup": scheduler.Every(10 * time.Millisecond)},
+	}, zap.NewNop(), scheduler.Job{Name: "cleanup", Schedule: scheduler.Every(0), Run: func(ctx context.Context) error {
+		runs.Add(1)
+		return nil
+	}})
+	require.NoError(t, err)
+	start(t, s)
+
+	require.Eventually(t, func() bool { return runs.Load() >= 2 }, time.Second, time.Millisecond, "the configured schedule replaces the job's own")
+	statuses, err := s.Status(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "@every 10ms", statuses[0].Schedule)
+	assert.NotNil(t, statuses[0].NextRunAt)
+}
+
+func TestScheduler_RejectsBadJobs(t *testing.T) {
+	run := func(ctx context.Context) error { return nil }
+	store := scheduler.NewMemoryStore()
+
+	_, err := scheduler.New(store, scheduler.Options{}, zap.NewNop(),
+		scheduler.Job{Name: "coupons", Schedule: scheduler.Every(time.Hour), Run: run},
+		scheduler.Job{Name: "coupons", Schedule: scheduler.Every(time.Hour), Run: run})
+	assert.ErrorContains(t, err, "registered twice")
+
+	_, err = scheduler.New(store, scheduler.Options{}, zap.NewNop(), scheduler.Job{Name: "coupons", Run: run})
+	assert.ErrorContains(t, err, "has no schedule")
+
+	_, err = scheduler.New(store, scheduler.Options{
+		Schedules: map[string]scheduler.Schedule{"copons": scheduler.Every(time.Hour)},
+	}, zap.NewNop(), scheduler.Job{Name: "coupons", Schedule: scheduler.Every(time.Hour), Run: run})
+	assert.ErrorContains(t, err, "copons, which doesn't exist")
+}
+
+func TestMemoryStore_Lock(t *testing.T) {
+	ctx := context.Background()
+	store := scheduler.NewMemoryStore()
+
+	unlock, ok, err := store.Lock(ctx, "digest", time.Hour)
+	require.NoError(t, err)
+	require.True(t, ok)
+	_, ok, err = store.Lock(ctx, "digest", time.Hour)
+	require.NoError(t, err)
+	assert.False(t, ok, "the lock is held")
+
+	require.NoError(t, unlock(ctx))
+	_, ok, err = store.Lock(ctx, "digest", time.Millisecond)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	time.Sleep(5 * time.Millisecond)
+	_, ok, err = store.Lock(ctx, "digest", time.Hour)
+	require.NoError(t, err)
+	assert.True(t, ok, "an expired lock is taken")
+}
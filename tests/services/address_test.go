@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"oolio/internal/app/models"
+	"oolio/internal/app/repository"
+	"oolio/internal/app/services"
+)
+
+func addressReq(line1 string) models.AddressReq {
+	return models.AddressReq{
+		DeliveryAddress: models.DeliveryAddress{Line1: line1, City: "Melbourne", Country: "au"},
+	}
+}
+
+func TestAddressService_Default(t *testing.T) {
+	ctx := context.Background()
+	addressService := services.NewAddressService(repository.NewMemoryAddressRepository())
+
+	home, err := addressService.AddAddress(ctx, "c1", addressReq(" 1 Home St "))
+	require.NoError(t, err)
+	assert.True(t, home.IsDefault, "the first address becomes the default")
+	assert.Equal(t, "1 Home St", home.Line1)
+	assert.Equal(t, "AU", home.Country)
+
+	work, err := addressService.AddAddress(ctx, "c1", addressReq("2 Work Rd"))
+	require.NoError(t, err)
+	assert.False(t, work.IsDefault)
+	gym, err := addressService.AddAddress(ctx, "c1", addressReq("3 Gym Ln"))
+	require.NoError(t, err)
+
+	require.NoError(t, addressService.SetDefaultAddress(ctx, "c1", work.ID))
+	addresses, err := addressService.ListAddresses(ctx, "c1")
+	require.NoError(t, err)
+	require.Len(t, addresses, 3)
+	assert.Equal(t, work.ID, addresses[0].ID, "the default address is listed first")
+	assert.False(t, addresses[1].IsDefault)
+
+	resolved, err := addressService.ResolveDeliveryAddress(ctx, "c1", services.DefaultAddressID)
+	require.NoError(t, err)
+	assert.Equal(t, "2 Work Rd", resolved.Line1)
+
+	// Deleting the default promotes the newest remaining address
+	require.NoError(t, addressService.DeleteAddress(ctx, "c1", work.ID))
+	resolved, err = addressService.ResolveDeliveryAddress(ctx, "c1", services.DefaultAddressID)
+	require.NoError(t, err)
+	assert.Equal(t, gym.Line1, resolved.Line1)
+}
+
+func TestAddressService_PerCustomer(t *testing.T) {
+	ctx := context.Background()
+	addressService := services.NewAddressService(repository.NewMemoryAddressRepository())
+
+	home, err := addressService.AddAddress(ctx, "c1", addressReq("1 Home St"))
+	require.NoError(t, err)
+
+	_, err = addressService.ResolveDeliveryAddress(ctx, "c2", home.ID)
+	assert.ErrorContains(t, err, "not found")
+	assert.ErrorContains(t, addressService.DeleteAddress(ctx, "c2", home.ID), "not found")
+	assert.ErrorContains(t, addressService.SetDefaultAddress(ctx, "c2", "not-a-uuid"), "invalid address ID")
+
+	_, err = addressService.ResolveDeliveryAddress(ctx, "c2", services.DefaultAddressID)
+	assert.ErrorIs(t, err, services.ErrNoDefaultAddress)
+
+	resolved, err := addressService.ResolveDeliveryAddress(ctx, "c1", home.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "1 Home St", resolved.Line1)
+}
+
+func TestAddressService_Limits(t *testing.T) {
+	ctx := context.Background()
+	addressService := services.NewAddressService(repository.NewMemoryAddressRepository())
+
+	for i := 0; i < services.MaxCustomerAddresses; i++ {
+		_, err := addressService.AddAddress(ctx, "c1", addressReq("1 Home St"))
+		require.NoError(t, err)
+	}
+	_, err := addressService.AddAddress(ctx, "c1", addressReq("1 Home St"))
+	assert.ErrorIs(t, err, services.ErrAddressBookFull)
+}
+
+func TestValidateDeliveryAddress(t *testing.T) {
+	tests := []struct {
+		name    string
+		address models.DeliveryAddress
+		err     string
+	}{
+		{"valid", models.DeliveryAddress{Line1: "1 Home St", City: "Melbourne", Country: "AU"}, ""},
+		{"no line1", models.DeliveryAddress{Line1: "  ", City: "Melbourne", Country: "AU"}, "line1 is required"},
+		{"no city", models.DeliveryAddress{Line1: "1 Home St", Country: "AU"}, "city is required"},
+		{"bad country", models.DeliveryAddress{Line1: "1 Home St", City: "Melbourne", Country: "AUS"}, "country"},
+		{"long postal code", models.DeliveryAddress{Line1: "1 Home St", City: "Melbourne", Country: "AU", PostalCode: "123456789012345678901"}, "postalCode is longer"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := services.ValidateDeliveryAddress(&tt.address)
+			if tt.err == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, services.ErrInvalidAddress)
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"oolio/internal/app/services"
+)
+
+func TestWebhookAlerters(t *testing.T) {
+	var body map[string]string
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		body = nil
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	alert := services.Alert{Title: "Order failed permanently", Text: "Queue item q1 failed 3 times"}
+
+	require.NoError(t, services.NewSlackAlerter(server.URL, time.Second).Alert(ctx, alert))
+	assert.Equal(t, map[string]string{"text": "*Order failed permanently*\nQueue item q1 failed 3 times"}, body)
+
+	require.NoError(t, services.NewDiscordAlerter(server.URL, time.Second).Alert(ctx, alert))
+	assert.Equal(t, map[string]string{"content": "**Order failed permanently**\nQueue item q1 failed 3 times"}, body)
+
+	// Discord rejects messages over 2000 characters
+	require.NoError(t, services.NewDiscordAlerter(server.URL, time.Second).Alert(ctx, services.Alert{Title: "Long", Text: strings.Repeat("x", 3000)}))
+	assert.Len(t, []rune(body["content"]), 2000)
+
+	status = http.StatusNotFound
+	assert.ErrorContains(t, services.NewSlackAlerter(server.URL, time.Second).Alert(ctx, alert), "404")
+}
+
+type recordingAlerter struct {
+	mutex  sync.Mutex
+	alerts []services.Alert
+}
+
+func (a *recordingAlerter) Alert(ctx context.Context, alert services.Alert) error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.alerts = append(a.alerts, alert)
+	return nil
+}
+
+func (a *recordingAlerter) Alerts() []services.Alert {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	return append([]services.Alert(nil), a.alerts...)
+}
+
+func TestCouponService_AlertsOnFailedFiles(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/couponbase2.gz" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write(gzipLines(t, "SHAREDCODE"))
+	}))
+	defer server.Close()
+
+	alerter := &recordingAlerter{}
+	service := services.NewCouponService(services.CouponOptions{BaseURL: server.URL, Alerter: alerter}, zap.NewNop())
+	require.NoError(t, service.DownloadAndParseCouponFiles(context.Background()))
+
+	require.Eventually(t, func() bool { return len(alerter.Alerts()) == 1 }, time.Second, 10*time.Millisecond)
+	alert := alerter.Alerts()[0]
+	assert.Equal(t, "Coupon refresh failed", alert.Title)
+	assert.Contains(t, alert.Text, "1 of 3 coupon file(s)")
+	assert.Contains(t, alert.Text, "couponbase2.gz")
+}
+
+func TestEmailAndMultiAlerters(t *testing.T) {
+	ctx := context.Background()
+	alert := services.Alert{Title: "Order failed permanently", Text: "Queue item q1 failed 3 times"}
+
+	sent := &sentMessages{}
+	require.NoError(t, services.NewEmailAlerter(sent, []string{"ops@example.com", "chef@example.com"}).Alert(ctx, alert))
+	assert.Equal(t, []string{"ops@example.com|[Alert] Order failed permanently", "chef@example.com|[Alert] Order failed permanently"}, sent.emails)
+
+	// A channel that is down doesn't keep the alert from the others
+	recorded := &recordingAlerter{}
+	failing := services.NewEmailAlerter(&flakyEmailSender{failures: 1}, []string{"ops@example.com"})
+	err := services.NewMultiAlerter(failing, recorded).Alert(ctx, alert)
+	assert.ErrorContains(t, err, "ops@example.com")
+	assert.Equal(t, []services.Alert{alert}, recorded.Alerts())
+}
@@ -0,0 +1,141 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"oolio/internal/app/models"
+	"oolio/internal/app/repository"
+	"oolio/internal/app/services"
+)
+
+func newCartService(t *testing.T, guestTTL time.Duration) (services.CartService, []models.Product) {
+	t.Helper()
+	products := repository.NewMemoryProductRepository()
+	seeded, err := products.Find(context.Background())
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, len(seeded), 2)
+	return services.NewCartService(repository.NewMemoryCartRepository(), products, guestTTL, zap.NewNop()), seeded
+}
+
+func TestCartService_Customer(t *testing.T) {
+	ctx := context.Background()
+	cartService, products := newCartService(t, time.Hour)
+	owner := services.CartOwner{CustomerID: "c1"}
+
+	cart, err := cartService.GetCart(ctx, owner)
+	require.NoError(t, err)
+	assert.Empty(t, cart.ID, "no cart is created just by looking")
+	assert.Empty(t, cart.Items)
+
+	cart, err = cartService.AddItem(ctx, owner, models.CartItemReq{ProductID: products[0].ID})
+	require.NoError(t, err)
+	cart, err = cartService.AddItem(ctx, owner, models.CartItemReq{ProductID: products[0].ID, Quantity: 2})
+	require.NoError(t, err)
+	assert.Equal(t, []models.CartItem{{ProductID: products[0].ID, Quantity: 3}}, cart.Items)
+	assert.Nil(t, cart.ExpiresAt, "customer carts don't expire")
+	require.Len(t, cart.Products, 1)
+	assert.Equal(t, products[0].Name, cart.Products[0].Name)
+
+	cart, err = cartService.AddItem(ctx, owner, models.CartItemReq{ProductID: products[1].ID})
+	require.NoError(t, err)
+	cart, err = cartService.SetQuantity(ctx, owner, products[1].ID, 5)
+	require.NoError(t, err)
+	cart, err = cartService.RemoveItem(ctx, owner, products[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, []models.CartItem{{ProductID: products[1].ID, Quantity: 5}}, cart.Items)
+
+	// Another customer has a cart of their own
+	other, err := cartService.GetCart(ctx, services.CartOwner{CustomerID: "c2"})
+	require.NoError(t, err)
+	assert.Empty(t, other.Items)
+
+	orderReq, err := cartService.CheckoutOrder(ctx, owner, models.CheckoutReq{CouponCode: "HAPPYHRS", AddressID: services.DefaultAddressID})
+	require.NoError(t, err)
+	assert.Equal(t, "HAPPYHRS", orderReq.CouponCode)
+	assert.Equal(t, services.DefaultAddressID, orderReq.AddressID)
+	assert.Equal(t, []models.OrderItem{{ProductID: products[1].ID, Quantity: 5}}, orderReq.Items)
+
+	require.NoError(t, cartService.Clear(ctx, owner))
+	_, err = cartService.CheckoutOrder(ctx, owner, models.CheckoutReq{})
+	assert.ErrorIs(t, err, services.ErrCartEmpty)
+}
+
+func TestCartService_Guest(t *testing.T) {
+	ctx := context.Background()
+	cartService, products := newCartService(t, 50*time.Millisecond)
+
+	cart, err := cartService.AddItem(ctx, services.CartOwner{}, models.CartItemReq{ProductID: products[0].ID})
+	require.NoError(t, err)
+	require.NotEmpty(t, cart.ID, "a guest gets a new cart and its token")
+	require.NotNil(t, cart.ExpiresAt)
+
+	guest := services.CartOwner{Token: cart.ID}
+	cart, err = cartService.GetCart(ctx, guest)
+	require.NoError(t, err)
+	assert.Len(t, cart.Items, 1)
+
+	_, err = cartService.GetCart(ctx, services.CartOwner{Token: "00000000-0000-0000-0000-000000000000"})
+	assert.ErrorContains(t, err, "cart not found")
+
+	time.Sleep(60 * time.Millisecond)
+	_, err = cartService.GetCart(ctx, guest)
+	assert.ErrorContains(t, err, "cart not found", "guest carts expire")
+}
+
+func TestCartService_Limits(t *testing.T) {
+	ctx := context.Background()
+	cartService, products := newCartService(t, time.Hour)
+	owner := services.CartOwner{CustomerID: "c1"}
+
+	_, err := cartService.AddItem(ctx, owner, models.CartItemReq{ProductID: products[0].ID, Quantity: services.MaxCartQuantity + 1})
+	assert.ErrorIs(t, err, services.ErrInvalidCartItem)
+
+	_, err = cartService.AddItem(ctx, owner, models.CartItemReq{ProductID: products[0].ID, Quantity: services.MaxCartQuantity})
+	require.NoError(t, err)
+	_, err = cartService.AddItem(ctx, owner, models.CartItemReq{ProductID: products[0].ID})
+	assert.ErrorIs(t, err, services.ErrInvalidCartItem, "the total quantity is capped too")
+
+	_, err = cartService.SetQuantity(ctx, owner, products[0].ID, 0)
+	assert.ErrorIs(t, err, services.ErrInvalidCartItem)
+
+	_, err = cartService.AddItem(ctx, owner, models.CartItemReq{ProductID: "00000000-0000-0000-0000-000000000000"})
+	assert.ErrorContains(t, err, "product not found")
+}
+
+func TestFavoriteService(t *testing.T) {
+	ctx := context.Background()
+	products := repository.NewMemoryProductRepository()
+	seeded, err := products.Find(ctx)
+	require.NoError(t, err)
+	favoriteService := services.NewFavoriteService(repository.NewMemoryFavoriteRepository(), products)
+
+	require.NoError(t, favoriteService.AddFavorite(ctx, "c1", seeded[0].ID))
+	require.NoError(t, favoriteService.AddFavorite(ctx, "c1", seeded[1].ID))
+	require.NoError(t, favoriteService.AddFavorite(ctx, "c1", seeded[0].ID), "adding twice is a no-op")
+
+	favorites, err := favoriteService.ListFavorites(ctx, "c1")
+	require.NoError(t, err)
+	require.Len(t, favorites, 2)
+	assert.Equal(t, seeded[1].ID, favorites[0].ID, "newest favorite first")
+
+	// Deleted products drop out of the list
+	require.NoError(t, products.Delete(ctx, seeded[1].ID))
+	favorites, err = favoriteService.ListFavorites(ctx, "c1")
+	require.NoError(t, err)
+	require.Len(t, favorites, 1)
+
+	require.NoError(t, favoriteService.RemoveFavorite(ctx, "c1", seeded[0].ID))
+	assert.ErrorContains(t, favoriteService.RemoveFavorite(ctx, "c1", seeded[0].ID), "favorite not found")
+	assert.ErrorContains(t, favoriteService.AddFavorite(ctx, "c1", "00000000-0000-0000-0000-000000000000"), "product not found")
+
+	favorites, err = favoriteService.ListFavorites(ctx, "c2")
+	require.NoError(t, err)
+	assert.NotNil(t, favorites)
+	assert.Empty(t, favorites)
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"oolio/internal/app/models"
+	"oolio/internal/app/repository"
+	"oolio/internal/app/services"
+)
+
+func TestCouponRedemption_SingleUse(t *testing.T) {
+	ctx := context.Background()
+	coupons := services.NewCouponService(services.CouponOptions{
+		Discounts: map[string]float64{"welcome10": 10, "happyhrs": 10},
+		SingleUse: []string{"Welcome10"},
+	}, zap.NewNop())
+	redemptions := services.NewCouponRedemptionService(coupons, repository.NewMemoryCouponRedemptionRepository())
+
+	assert.True(t, coupons.SingleUse("WELCOME10"))
+	assert.False(t, coupons.SingleUse("HAPPYHRS"))
+
+	customer := &models.OrderReq{CustomerID: "42", CouponCode: "welcome10"}
+	require.NoError(t, redemptions.Check(ctx, customer))
+	redeemed, err := redemptions.Redeem(ctx, customer)
+	require.NoError(t, err)
+	assert.True(t, redeemed)
+
+	again := &models.OrderReq{CustomerID: "42", CouponCode: "WELCOME10"}
+	assert.ErrorIs(t, redemptions.Check(ctx, again), services.ErrCouponAlreadyUsed, "codes are matched in any case")
+	_, err = redemptions.Redeem(ctx, again)
+	assert.ErrorIs(t, err, services.ErrCouponAlreadyUsed)
+
+	assert.NoError(t, redemptions.Check(ctx, &models.OrderReq{CustomerID: "43", CouponCode: "WELCOME10"}))
+	assert.ErrorIs(t, redemptions.Check(ctx, &models.OrderReq{CouponCode: "WELCOME10"}), services.ErrCouponNeedsRedeemer)
+
+	guest := &models.OrderReq{Email: "Jo@Example.com", CouponCode: "WELCOME10"}
+	_, err = redemptions.Redeem(ctx, guest)
+	require.NoError(t, err)
+	assert.ErrorIs(t, redemptions.Check(ctx, &models.OrderReq{Email: "jo@example.com", CouponCode: "WELCOME10"}), services.ErrCouponAlreadyUsed)
+
+	require.NoError(t, redemptions.Release(ctx, guest))
+	assert.NoError(t, redemptions.Check(ctx, guest))
+
+	// Reusable coupons are never recorded
+	for range 2 {
+		redeemed, err = redemptions.Redeem(ctx, &models.OrderReq{CustomerID: "42", CouponCode: "HAPPYHRS"})
+		require.NoError(t, err)
+		assert.False(t, redeemed)
+	}
+}
+
+func TestOrderService_SingleUseCoupon(t *testing.T) {
+	ctx := context.Background()
+	products := repository.NewMemoryProductRepository()
+	seeded, err := products.Find(ctx)
+	require.NoError(t, err)
+	require.NotEmpty(t, seeded)
+
+	coupons := services.NewCouponService(services.CouponOptions{
+		Discounts: map[string]float64{"welcome10": 10},
+		SingleUse: []string{"WELCOME10"},
+	}, zap.NewNop())
+	redemptions := services.NewCouponRedemptionService(coupons, repository.NewMemoryCouponRedemptionRepository())
+	service := services.NewOrderService(repository.NewMemoryOrderRepository(), products, repository.NewMemoryOrderQueueRepository(), coupons, nil, nil, redemptions, nil, nil, nil, nil, zap.NewNop())
+
+	// Both orders were queued before either was processed, so both passed the check
+	orderReq := func() *models.OrderReq {
+		return &models.OrderReq{
+			Phone:      "+61400000000",
+			CouponCode: "WELCOME10",
+			Items:      []models.OrderItem{{ProductID: seeded[0].ID, Quantity: 1}},
+		}
+	}
+	order, err := service.CreateOrder(ctx, orderReq())
+	require.NoError(t, err)
+	assert.Equal(t, seeded[0].Price.Percent(10), order.Discounts)
+	assert.Equal(t, "WELCOME10", order.CouponCode)
+
+	_, err = service.CreateOrder(ctx, orderReq())
+	assert.ErrorIs(t, err, services.ErrCouponAlreadyUsed)
+}
+
+func TestNormalizeGuestContact(t *testing.T) {
+	orderReq := &models.OrderReq{Phone: " +61400000000 ", Email: " jo@example.com"}
+	require.NoError(t, services.NormalizeGuestContact(orderReq))
+	assert.Equal(t, "+61400000000", orderReq.Phone)
+	assert.Equal(t, "jo@example.com", orderReq.Email)
+
+	assert.ErrorIs(t, services.NormalizeGuestContact(&models.OrderReq{Phone: "0400 000 000"}), services.ErrInvalidPhone)
+	assert.ErrorIs(t, services.NormalizeGuestContact(&models.OrderReq{Email: "Jo <jo@example.com>"}), services.ErrInvalidEmail)
+	assert.NoError(t, services.NormalizeGuestContact(&models.OrderReq{}))
+}
@@ -0,0 +1,438 @@
+package services
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"oolio/internal/app/models"
+	"oolio/internal/app/services"
+	"oolio/internal/storage"
+)
+
+func gzipLines(t *testing.T, lines ...string) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	_, err := gz.Write([]byte(strings.Join(lines, "\n")))
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+func TestCouponService_GetStats(t *testing.T) {
+	files := map[string][]byte{
+		"/couponbase1.gz": gzipLines(t, "SHAREDCODE", "ONLYFILE1", "SHAREDCODE"),
+		"/couponbase2.gz": gzipLines(t, "SHAREDCODE", "SHORT"),
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := files[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	service := services.NewCouponService(services.CouponOptions{BaseURL: server.URL}, zap.NewNop())
+
+	err := service.DownloadAndParseCouponFiles(context.Background())
+	require.NoError(t, err)
+
+	stats := service.GetStats()
+	assert.True(t, stats.FilesProcessed)
+	assert.False(t, stats.RefreshInProgress)
+	assert.Equal(t, 1, stats.RefreshCount)
+	assert.Equal(t, 1, stats.ErrorCount) // couponbase3.gz is missing
+	assert.Equal(t, 1, stats.ValidCoupons)
+	require.Len(t, stats.Files, 3)
+
+	assert.Equal(t, 3, stats.Files[0].RowsProcessed)
+	assert.Equal(t, 3, stats.Files[0].CodesAccepted)
+	assert.Equal(t, 1, stats.Files[0].Duplicates)
+
+	assert.Equal(t, 2, stats.Files[1].RowsProcessed)
+	assert.Equal(t, 1, stats.Files[1].CodesAccepted)
+	assert.Equal(t, 0, stats.Files[1].Duplicates, "a code shared with another file is not a duplicate")
+	assert.Zero(t, stats.Files[1].DuplicateRate)
+
+	assert.NotEmpty(t, stats.Files[2].Error)
+	assert.True(t, service.ValidateCoupon("SHAREDCODE"))
+	assert.False(t, service.ValidateCoupon("ONLYFILE1"))
+}
+
+func TestCouponService_ManyCodes(t *testing.T) {
+	// Enough codes to fill every shard, with every third code in both files
+	var file1, file2 []string
+	for i := range 3000 {
+		code := fmt.Sprintf("CODE%05d", i)
+		file1 = append(file1, code)
+		if i%3 == 0 {
+			file2 = append(file2, code)
+		}
+	}
+	// A code repeated within one file still appears in only one file
+	file1 = append(file1, "REPEATED1", "REPEATED1")
+
+	files := map[string][]byte{"/a.gz": gzipLines(t, file1...), "/b.gz": gzipLines(t, file2...)}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(files[r.URL.Path])
+	}))
+	defer server.Close()
+
+	service := services.NewCouponService(services.CouponOptions{BaseURL: server.URL, Files: []string{"a.gz", "b.gz"}}, zap.NewNop())
+	require.NoError(t, service.DownloadAndParseCouponFiles(context.Background()))
+
+	stats := service.GetStats()
+	assert.Equal(t, 1000, stats.ValidCoupons)
+	assert.Equal(t, 1, stats.Files[0].Duplicates)
+	assert.Equal(t, 0, stats.Files[1].Duplicates)
+	for i := range 3000 {
+		assert.Equal(t, i%3 == 0, service.ValidateCoupon(fmt.Sprintf("CODE%05d", i)), i)
+	}
+	assert.False(t, service.ValidateCoupon("REPEATED1"))
+	assert.False(t, service.ValidateCoupon("code00000"), "codes are case sensitive")
+}
+
+func TestCouponService_ResumesBrokenDownloads(t *testing.T) {
+	var lines []string
+	for i := range 2000 {
+		lines = append(lines, fmt.Sprintf("RESUME%04d", i))
+	}
+	file := gzipLines(t, lines...)
+
+	var requests []string
+	broken := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Header.Get("Range"))
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("Range") == "" && broken < 2 {
+			// Promise the whole file, then drop the connection half way
+			broken++
+			w.Header().Set("Content-Length", fmt.Sprint(len(file)))
+			w.Write(file[:len(file)/2])
+			return
+		}
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(file))
+	}))
+	defer server.Close()
+
+	service := services.NewCouponService(services.CouponOptions{
+		BaseURL:            server.URL,
+		Files:              []string{"a.gz"},
+		MinFileOccurrences: 1,
+		HTTPClient:         services.NewCouponHTTPClient(services.CouponHTTPOptions{}),
+	}, zap.NewNop())
+	require.NoError(t, service.DownloadAndParseCouponFiles(context.Background()))
+
+	assert.Equal(t, []string{"", fmt.Sprintf("bytes=%d-", len(file)/2)}, requests, "the rest is requested, not the whole file")
+	assert.Empty(t, service.GetStats().Files[0].Error)
+	assert.Equal(t, 2000, service.GetStats().ValidCoupons)
+
+	// Without resuming, the broken download fails the file
+	requests = nil
+	noRetries := services.NewCouponService(services.CouponOptions{BaseURL: server.URL, Files: []string{"a.gz"}, MinFileOccurrences: 1, DownloadRetries: -1}, zap.NewNop())
+	require.NoError(t, noRetries.DownloadAndParseCouponFiles(context.Background()))
+	assert.Len(t, requests, 1)
+	assert.NotEmpty(t, noRetries.GetStats().Files[0].Error)
+}
+
+func TestCouponService_LineParserMatchesCSV(t *testing.T) {
+	long := strings.Repeat("X", 10000)
+	file := gzipLines(t,
+		"PLAINCODE1",
+		"  SPACED01  ",
+		"WINDOWS01\r",
+		"",
+		"FIRSTCOL1,ignored,\"quoted\"",
+		`"QUOTED01"`,
+		`"QUOTED,02",second`,
+		`BARE"QUOTE`,
+		`"TRAILING"X`,
+		"SHORT",
+		"TOOLONGCODE123",
+		"LONGLINE1,"+long,
+		long,
+		"PLAINCODE1",
+		"LASTLINE1",
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(file)
+	}))
+	defer server.Close()
+
+	parse := func(csv bool) services.CouponService {
+		service := services.NewCouponService(services.CouponOptions{
+			BaseURL:            server.URL,
+			Files:              []string{"a.gz"},
+			MinFileOccurrences: 1,
+			ParseBufferSize:    4096,
+			CSVParser:          csv,
+		}, zap.NewNop())
+		require.NoError(t, service.DownloadAndParseCouponFiles(context.Background()))
+		return service
+	}
+	lines, csv := parse(false), parse(true)
+
+	want := csv.GetStats().Files[0]
+	got := lines.GetStats().Files[0]
+	assert.Equal(t, want.RowsProcessed, got.RowsProcessed)
+	assert.Equal(t, want.CodesAccepted, got.CodesAccepted)
+	assert.Equal(t, want.Duplicates, got.Duplicates)
+	assert.Equal(t, want.ParseErrors, got.ParseErrors)
+	assert.Equal(t, 2, got.ParseErrors)
+	assert.Equal(t, csv.GetStats().ValidCoupons, lines.GetStats().ValidCoupons)
+
+	for _, code := range []string{"PLAINCODE1", "SPACED01", "WINDOWS01", "FIRSTCOL1", "QUOTED01", "QUOTED,02", "LONGLINE1", "LASTLINE1"} {
+		assert.True(t, csv.ValidateCoupon(code), code)
+		assert.True(t, lines.ValidateCoupon(code), code)
+	}
+
+	// A quote left open is an error on its own line, where the CSV parser reads on to its end
+	file = gzipLines(t, "FIRSTCODE1", `"UNCLOSED`, "NEXTCODE1")
+	lines = parse(false)
+	assert.Equal(t, 1, lines.GetStats().Files[0].ParseErrors)
+	assert.True(t, lines.ValidateCoupon("NEXTCODE1"))
+}
+
+func BenchmarkCouponService_Parse(b *testing.B) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	for i := range 500000 {
+		fmt.Fprintf(gz, "CODE%06d\n", i)
+	}
+	gz.Close()
+	file := buf.Bytes()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(file)
+	}))
+	defer server.Close()
+
+	for _, parser := range []struct {
+		name string
+		csv  bool
+	}{{"lines", false}, {"csv", true}} {
+		b.Run(parser.name, func(b *testing.B) {
+			service := services.NewCouponService(services.CouponOptions{
+				BaseURL:            server.URL,
+				Files:              []string{"a.gz"},
+				MinFileOccurrences: 1,
+				CSVParser:          parser.csv,
+			}, zap.NewNop())
+			for b.Loop() {
+				if err := service.DownloadAndParseCouponFiles(context.Background()); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestCouponService_CustomOptions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(gzipLines(t, "ABCDEF", "TOOLONGCODE1"))
+	}))
+	defer server.Close()
+
+	service := services.NewCouponService(services.CouponOptions{
+		BaseURL:            server.URL,
+		Files:              []string{"only.gz"},
+		Discounts:          map[string]float64{"happyhrs": 15},
+		DefaultDiscount:    7.5,
+		MinLength:          6,
+		MaxLength:          8,
+		MinFileOccurrences: 1,
+	}, zap.NewNop())
+
+	require.NoError(t, service.DownloadAndParseCouponFiles(context.Background()))
+
+	assert.True(t, service.ValidateCoupon("ABCDEF"))
+	assert.False(t, service.ValidateCoupon("TOOLONGCODE1"))
+	assert.Equal(t, 7.5, service.GetDiscountPercentage("ABCDEF"))
+	assert.Equal(t, 15.0, service.GetDiscountPercentage("HAPPYHRS"))
+	assert.Equal(t, 0.0, service.GetDiscountPercentage("FIFTYOFF"))
+}
+
+type stubCouponStore struct {
+	coupons []models.Coupon
+}
+
+func (s *stubCouponStore) FindAll(ctx context.Context) ([]models.Coupon, error) {
+	var active []models.Coupon
+	for _, coupon := range s.coupons {
+		if coupon.DeletedAt == nil {
+			active = append(active, coupon)
+		}
+	}
+	return active, nil
+}
+
+func (s *stubCouponStore) Delete(ctx context.Context, code string) error {
+	for i := range s.coupons {
+		if s.coupons[i].Code == code && s.coupons[i].DeletedAt == nil {
+			now := time.Now()
+			s.coupons[i].DeletedAt = &now
+			return nil
+		}
+	}
+	return errors.New("coupon not found")
+}
+
+func (s *stubCouponStore) FindDeleted(ctx context.Context, since time.Time) ([]models.Coupon, error) {
+	var deleted []models.Coupon
+	for _, coupon := range s.coupons {
+		if coupon.DeletedAt != nil && !coupon.DeletedAt.Before(since) {
+			deleted = append(deleted, coupon)
+		}
+	}
+	return deleted, nil
+}
+
+func (s *stubCouponStore) Restore(ctx context.Context, code string) error {
+	for i := range s.coupons {
+		if s.coupons[i].Code == code && s.coupons[i].DeletedAt != nil {
+			s.coupons[i].DeletedAt = nil
+			return nil
+		}
+	}
+	return errors.New("deleted coupon not found")
+}
+
+func TestCouponService_StoredCoupons(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	store := &stubCouponStore{coupons: []models.Coupon{{Code: "WELCOME15", DiscountPercentage: 15}}}
+	service := services.NewCouponService(services.CouponOptions{BaseURL: server.URL, Store: store}, zap.NewNop())
+	ctx := context.Background()
+
+	assert.False(t, service.ValidateCoupon("WELCOME15"))
+
+	require.NoError(t, service.DownloadAndParseCouponFiles(ctx))
+	assert.True(t, service.ValidateCoupon("welcome15"))
+	assert.Equal(t, 15.0, service.GetDiscountPercentage("WELCOME15"))
+	assert.Equal(t, 1, service.GetStats().StoredCoupons)
+
+	// Deleting takes effect without waiting for the next refresh
+	require.NoError(t, service.DeleteCoupon(ctx, "welcome15"))
+	assert.False(t, service.ValidateCoupon("WELCOME15"))
+
+	deleted, err := service.GetDeletedCoupons(ctx, time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+	require.Len(t, deleted, 1)
+	assert.Equal(t, "WELCOME15", deleted[0].Code)
+
+	require.NoError(t, service.RestoreCoupon(ctx, "WELCOME15"))
+	assert.True(t, service.ValidateCoupon("WELCOME15"))
+	assert.Error(t, service.RestoreCoupon(ctx, "WELCOME15"))
+}
+
+func TestCouponService_ResolveCoupon(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(gzipLines(t, "FILECODE1", "HAPPYHRS"))
+	}))
+	defer server.Close()
+
+	store := &stubCouponStore{coupons: []models.Coupon{
+		{Code: "WELCOME15", DiscountPercentage: 15, Segment: "NEW", SingleUse: true},
+		{Code: "HAPPYHRS", DiscountPercentage: 99},
+	}}
+	service := services.NewCouponService(services.CouponOptions{
+		BaseURL:            server.URL,
+		Files:              []string{"a.gz"},
+		MinFileOccurrences: 1,
+		Store:              store,
+		Discounts:          map[string]float64{"happyhrs": 10},
+		Segments:           map[string]string{"HAPPYHRS": "VIP"},
+		FileCodesSingleUse: true,
+		DefaultDiscount:    5,
+	}, zap.NewNop())
+	require.NoError(t, service.DownloadAndParseCouponFiles(context.Background()))
+
+	terms, ok := service.ResolveCoupon("welcome15")
+	assert.True(t, ok)
+	assert.Equal(t, services.CouponTerms{Discount: 15, Segment: "new", SingleUse: true}, terms)
+
+	// Named codes win over stored codes and the files
+	terms, ok = service.ResolveCoupon("HappyHrs")
+	assert.True(t, ok)
+	assert.Equal(t, services.CouponTerms{Discount: 10, Segment: "vip"}, terms)
+
+	terms, ok = service.ResolveCoupon("FILECODE1")
+	assert.True(t, ok)
+	assert.Equal(t, services.CouponTerms{Discount: 5, SingleUse: true}, terms)
+
+	_, ok = service.ResolveCoupon("filecode1")
+	assert.False(t, ok, "file codes are case sensitive")
+	_, ok = service.ResolveCoupon("UNKNOWN1")
+	assert.False(t, ok)
+
+	// Orders keep resolving while the discounts are swapped
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 1000 {
+				terms, ok := service.ResolveCoupon("HAPPYHRS")
+				assert.True(t, ok)
+				assert.Contains(t, []float64{10, 20}, terms.Discount)
+			}
+		}()
+	}
+	service.SetDiscounts(map[string]float64{"HAPPYHRS": 20}, 0)
+	wg.Wait()
+	assert.Equal(t, 20.0, service.GetDiscountPercentage("HAPPYHRS"))
+	assert.Equal(t, 5.0, service.GetDiscountPercentage("FILECODE1"))
+}
+
+func TestCouponService_NoStore(t *testing.T) {
+	service := services.NewCouponService(services.CouponOptions{}, zap.NewNop())
+
+	assert.ErrorIs(t, service.DeleteCoupon(context.Background(), "WELCOME15"), services.ErrNoCouponStore)
+}
+
+func TestCouponService_FallsBackToCachedFiles(t *testing.T) {
+	available := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !available {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write(gzipLines(t, "SHAREDCODE"))
+	}))
+	defer server.Close()
+
+	cache, err := storage.NewLocalStorage(t.TempDir())
+	require.NoError(t, err)
+	service := services.NewCouponService(services.CouponOptions{BaseURL: server.URL, Cache: cache}, zap.NewNop())
+	ctx := context.Background()
+
+	require.NoError(t, service.DownloadAndParseCouponFiles(ctx))
+	assert.False(t, service.GetStats().Files[0].FromCache)
+
+	available = false
+	require.NoError(t, service.DownloadAndParseCouponFiles(ctx))
+
+	stats := service.GetStats()
+	for _, file := range stats.Files {
+		assert.True(t, file.FromCache, file.Filename)
+		assert.Empty(t, file.Error)
+	}
+	assert.True(t, service.ValidateCoupon("SHAREDCODE"))
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"oolio/internal/app/models"
+	"oolio/internal/app/repository"
+	"oolio/internal/app/services"
+)
+
+// countedRates quotes a fixed rate and counts how often it was asked
+type countedRates struct {
+	rate  float64
+	err   error
+	calls int
+}
+
+func (r *countedRates) Rate(ctx context.Context, from, to string) (float64, error) {
+	r.calls++
+	return r.rate, r.err
+}
+
+func TestCurrencyConverter_Settle(t *testing.T) {
+	ctx := context.Background()
+	converter := services.NewCurrencyConverter("aud", []string{"usd", "eur"},
+		services.NewFixedExchangeRates("aud", map[string]float64{"usd": 0.6512}))
+
+	assert.True(t, converter.Supports("aud"), "the payment currency")
+	assert.True(t, converter.Supports("usd"))
+	assert.False(t, converter.Supports("gbp"))
+
+	settlement, err := converter.Settle(ctx, 2598, "usd")
+	require.NoError(t, err)
+	assert.Equal(t, &models.Settlement{Currency: "usd", Rate: 0.6512, AmountDue: 1692}, settlement)
+
+	settlement, err = converter.Settle(ctx, 2598, "aud")
+	require.NoError(t, err)
+	assert.Nil(t, settlement, "nothing to convert")
+
+	_, err = converter.Settle(ctx, 2598, "gbp")
+	assert.ErrorIs(t, err, services.ErrUnsupportedCurrency)
+	_, err = converter.Settle(ctx, 2598, "eur")
+	assert.EqualError(t, err, "failed to get exchange rate: no exchange rate from aud to eur")
+}
+
+func TestCachedExchangeRates(t *testing.T) {
+	ctx := context.Background()
+	provider := &countedRates{rate: 0.65}
+	rates := services.NewCachedExchangeRates(provider, time.Hour)
+
+	for range 3 {
+		rate, err := rates.Rate(ctx, "aud", "usd")
+		require.NoError(t, err)
+		assert.Equal(t, 0.65, rate)
+	}
+	_, err := rates.Rate(ctx, "aud", "nzd")
+	require.NoError(t, err)
+	assert.Equal(t, 2, provider.calls, "fetched once per pair")
+
+	// Failures aren't cached, and expired rates are fetched again
+	provider.err = errors.New("unavailable")
+	expiring := services.NewCachedExchangeRates(provider, 0)
+	_, err = expiring.Rate(ctx, "aud", "usd")
+	assert.Error(t, err)
+	provider.err = nil
+	_, err = expiring.Rate(ctx, "aud", "usd")
+	require.NoError(t, err)
+	assert.Equal(t, 4, provider.calls)
+}
+
+func TestHTTPExchangeRates(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("from") != "AUD" || r.URL.Query().Get("to") != "USD" {
+			http.Error(w, `{"message":"not found"}`, http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"amount":1.0,"base":"AUD","date":"2026-10-16","rates":{"USD":0.6512}}`))
+	}))
+	defer server.Close()
+
+	rates := services.NewHTTPExchangeRates(services.HTTPExchangeRatesOptions{URL: server.URL, Timeout: time.Second})
+	rate, err := rates.Rate(context.Background(), "aud", "usd")
+	require.NoError(t, err)
+	assert.Equal(t, 0.6512, rate)
+
+	_, err = rates.Rate(context.Background(), "aud", "xyz")
+	assert.EqualError(t, err, "failed to fetch exchange rate: 404 Not Found")
+}
+
+func TestOrderService_QuoteInSettlementCurrency(t *testing.T) {
+	ctx := context.Background()
+	products := repository.NewMemoryProductRepository()
+	seeded, err := products.Find(ctx)
+	require.NoError(t, err)
+	require.NotEmpty(t, seeded)
+
+	converter := services.NewCurrencyConverter("aud", []string{"usd"},
+		services.NewFixedExchangeRates("aud", map[string]float64{"usd": 0.5}))
+	service := services.NewOrderService(repository.NewMemoryOrderRepository(), products, repository.NewMemoryOrderQueueRepository(), nil, nil, nil, nil, nil, nil, converter, nil, zap.NewNop())
+	orderReq := &models.OrderReq{Currency: "usd", Items: []models.OrderItem{{ProductID: seeded[0].ID, Quantity: 2}}}
+
+	order, err := service.CreateOrder(ctx, orderReq)
+	require.NoError(t, err)
+	assert.Equal(t, seeded[0].Price.Mul(2), order.Total, "priced in the payment currency")
+	require.NotNil(t, order.Settlement)
+	assert.Equal(t, "usd", order.Settlement.Currency)
+	assert.Equal(t, seeded[0].Price, order.Settlement.AmountDue)
+
+	orderReq.Currency = "gbp"
+	_, err = service.QuoteOrder(ctx, orderReq)
+	assert.ErrorIs(t, err, services.ErrUnsupportedCurrency)
+
+	withoutConversion := services.NewOrderService(repository.NewMemoryOrderRepository(), products, repository.NewMemoryOrderQueueRepository(), nil, nil, nil, nil, nil, nil, nil, nil, zap.NewNop())
+	_, err = withoutConversion.QuoteOrder(ctx, &models.OrderReq{Currency: "usd", Items: orderReq.Items})
+	assert.ErrorIs(t, err, services.ErrUnsupportedCurrency)
+}
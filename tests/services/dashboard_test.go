@@ -0,0 +1,41 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"oolio/internal/app/models"
+	"oolio/internal/app/repository"
+	"oolio/internal/app/services"
+)
+
+func TestDashboardService_Summary(t *testing.T) {
+	ctx := context.Background()
+	queueRepo := repository.NewMemoryOrderQueueRepository()
+	dueAt := time.Now().Add(-time.Minute)
+	for _, id := range []string{"item-1", "item-2", "item-3"} {
+		require.NoError(t, queueRepo.AddToQueue(ctx, &models.OrderQueueItem{ID: id, Status: "pending", CreatedAt: dueAt, NextAttemptAt: dueAt}))
+	}
+	require.NoError(t, queueRepo.MarkAsFailed(ctx, "item-3", "product not found"))
+
+	location, err := time.LoadLocation("Australia/Sydney")
+	require.NoError(t, err)
+	service := services.NewDashboardService(seedDigestOrders(t), queueRepo, location)
+
+	dashboard, err := service.Summary(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, time.Now().In(location).Format(time.DateOnly), dashboard.Date)
+	assert.Equal(t, "Australia/Sydney", dashboard.TimeZone)
+	assert.Equal(t, models.SalesFigures{Orders: 2, Revenue: 2520, Discounts: 180, AverageOrderValue: 1260}, dashboard.Today)
+	assert.Equal(t, []models.ProductSales{{ProductID: "waffle", Name: "Waffle", Quantity: 3, Revenue: 2700}}, dashboard.TopProducts)
+	assert.Equal(t, []models.CouponUsage{{Code: "HAPPYHRS", Orders: 1, Discounts: 180}}, dashboard.Coupons)
+
+	assert.Equal(t, 2, dashboard.Queue.Pending)
+	assert.Equal(t, 1, dashboard.Queue.Failed)
+	require.NotNil(t, dashboard.Queue.OldestDueAt)
+	assert.GreaterOrEqual(t, dashboard.Queue.OldestWaitSeconds, 60)
+}
@@ -0,0 +1,269 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"oolio/internal/app/events"
+	"oolio/internal/app/models"
+	"oolio/internal/app/repository"
+	"oolio/internal/app/services"
+)
+
+const deliveryStatusSecret = "delivery-secret"
+
+var testDeliveryAddress = models.DeliveryAddress{Line1: "1 Collins St", City: "Melbourne", Country: "AU"}
+
+// failingDeliveryProvider refuses every job
+type failingDeliveryProvider struct{}
+
+func (failingDeliveryProvider) Name() string { return "failing" }
+
+func (failingDeliveryProvider) CreateJob(ctx context.Context, order *models.Order, address models.DeliveryAddress) (*models.DeliveryStatusUpdate, error) {
+	return nil, errors.New("no couriers nearby")
+}
+
+func (failingDeliveryProvider) Track(ctx context.Context, delivery *models.Delivery) (*models.DeliveryStatusUpdate, error) {
+	return nil, nil
+}
+
+func (failingDeliveryProvider) Cancel(ctx context.Context, delivery *models.Delivery) error {
+	return nil
+}
+
+func newDeliveryService(provider services.DeliveryProvider, refreshAfter time.Duration) services.DeliveryService {
+	return services.NewDeliveryService(repository.NewMemoryDeliveryRepository(), provider, zap.NewNop(), services.DeliveryOptions{
+		StatusSecret: deliveryStatusSecret,
+		Tolerance:    time.Minute,
+		RefreshAfter: refreshAfter,
+	})
+}
+
+// postDeliveryStatus has HandleStatus take the update, signed the way the provider would
+func postDeliveryStatus(deliveries services.DeliveryService, update models.DeliveryStatusUpdate, at time.Time) (*models.Delivery, error) {
+	payload, _ := json.Marshal(update)
+	signature := services.SignWebhook([]byte(deliveryStatusSecret), "dlv-1", at.Unix(), payload)
+	return deliveries.HandleStatus(context.Background(), payload, "dlv-1", strconv.FormatInt(at.Unix(), 10), signature)
+}
+
+func TestDeliveryService_MockProviderTracking(t *testing.T) {
+	ctx := context.Background()
+	deliveries := newDeliveryService(services.NewMockDeliveryProvider(20*time.Millisecond), time.Nanosecond)
+	order := &models.Order{ID: "order-1"}
+
+	delivery, err := deliveries.Dispatch(ctx, order, testDeliveryAddress)
+	require.NoError(t, err)
+	assert.Equal(t, "mock", delivery.Provider)
+	assert.Equal(t, models.DeliveryStatusRequested, delivery.Status)
+	assert.NotEmpty(t, delivery.ExternalID)
+	assert.NotEmpty(t, delivery.TrackingURL)
+	require.NotNil(t, delivery.EstimatedAt)
+
+	// Dispatching the order again doesn't book a second courier
+	again, err := deliveries.Dispatch(ctx, order, testDeliveryAddress)
+	require.NoError(t, err)
+	assert.Equal(t, delivery.ID, again.ID)
+	assert.Equal(t, delivery.ExternalID, again.ExternalID)
+
+	time.Sleep(50 * time.Millisecond)
+	tracked, err := deliveries.Tracking(ctx, order.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.DeliveryStatusPickedUp, tracked.Status)
+	assert.Equal(t, "Mock Courier", tracked.CourierName)
+
+	time.Sleep(30 * time.Millisecond)
+	tracked, err = deliveries.Tracking(ctx, order.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.DeliveryStatusDelivered, tracked.Status)
+
+	_, err = deliveries.Cancel(ctx, order.ID)
+	assert.ErrorIs(t, err, services.ErrDeliveryFinished)
+
+	none, err := deliveries.Tracking(ctx, "order-2")
+	require.NoError(t, err)
+	assert.Nil(t, none, "an order without a delivery address")
+	_, err = deliveries.Cancel(ctx, "order-2")
+	assert.ErrorIs(t, err, services.ErrDeliveryNotFound)
+}
+
+func TestDeliveryService_TrackingTrustsRecentStatus(t *testing.T) {
+	ctx := context.Background()
+	deliveries := newDeliveryService(services.NewMockDeliveryProvider(time.Millisecond), time.Hour)
+
+	_, err := deliveries.Dispatch(ctx, &models.Order{ID: "order-1"}, testDeliveryAddress)
+	require.NoError(t, err)
+	time.Sleep(5 * time.Millisecond)
+
+	tracked, err := deliveries.Tracking(ctx, "order-1")
+	require.NoError(t, err)
+	assert.Equal(t, models.DeliveryStatusRequested, tracked.Status, "not asked again within RefreshAfter")
+}
+
+func TestDeliveryService_Cancel(t *testing.T) {
+	ctx := context.Background()
+	deliveries := newDeliveryService(services.NewMockDeliveryProvider(time.Hour), time.Nanosecond)
+
+	_, err := deliveries.Dispatch(ctx, &models.Order{ID: "order-1"}, testDeliveryAddress)
+	require.NoError(t, err)
+
+	cancelled, err := deliveries.Cancel(ctx, "order-1")
+	require.NoError(t, err)
+	assert.Equal(t, models.DeliveryStatusCancelled, cancelled.Status)
+	assert.Nil(t, cancelled.EstimatedAt)
+
+	tracked, err := deliveries.Tracking(ctx, "order-1")
+	require.NoError(t, err)
+	assert.Equal(t, models.DeliveryStatusCancelled, tracked.Status)
+}
+
+func TestDeliveryService_ProviderRefusesJob(t *testing.T) {
+	ctx := context.Background()
+	deliveries := newDeliveryService(failingDeliveryProvider{}, 0)
+
+	delivery, err := deliveries.Dispatch(ctx, &models.Order{ID: "order-1"}, testDeliveryAddress)
+	require.Error(t, err)
+	require.NotNil(t, delivery)
+	assert.Equal(t, models.DeliveryStatusFailed, delivery.Status)
+	assert.Equal(t, "no couriers nearby", delivery.Error)
+
+	tracked, err := deliveries.Tracking(ctx, "order-1")
+	require.NoError(t, err)
+	assert.Equal(t, models.DeliveryStatusFailed, tracked.Status)
+}
+
+func TestWebhookDeliveryProvider(t *testing.T) {
+	ctx := context.Background()
+	webhooks, repo := newWebhookService("http://delivery.example.com", 3)
+	deliveries := newDeliveryService(services.NewWebhookDeliveryProvider(webhooks, "/oolio"), time.Nanosecond)
+
+	delivery, err := deliveries.Dispatch(ctx, &models.Order{ID: "order-1"}, testDeliveryAddress)
+	require.NoError(t, err)
+	assert.Equal(t, "webhook", delivery.Provider)
+	assert.Equal(t, models.DeliveryStatusRequested, delivery.Status)
+
+	queued, total, err := repo.FindPage(ctx, models.WebhookPending, models.PageRequest{Limit: 10})
+	require.NoError(t, err)
+	require.Equal(t, 1, total)
+	assert.Equal(t, models.WebhookEndpointDelivery, queued[0].Endpoint)
+	var event events.Event
+	require.NoError(t, json.Unmarshal(queued[0].Body, &event))
+	assert.Equal(t, "order-1:delivery.requested", event.ID)
+	var job models.DeliveryJob
+	require.NoError(t, json.Unmarshal(event.Data, &job))
+	assert.Equal(t, "order-1", job.OrderID)
+	require.NotNil(t, job.Address)
+	assert.Equal(t, "Melbourne", job.Address.City)
+
+	// The provider posts the job's status back
+	eta := time.Now().Add(30 * time.Minute).Truncate(time.Second)
+	updated, err := postDeliveryStatus(deliveries, models.DeliveryStatusUpdate{
+		OrderID:     "order-1",
+		ExternalID:  "job-42",
+		Status:      models.DeliveryStatusPickedUp,
+		CourierName: "Sam",
+		TrackingURL: "https://couriers.example.com/job-42",
+		EstimatedAt: &eta,
+	}, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, models.DeliveryStatusPickedUp, updated.Status)
+	assert.Equal(t, "job-42", updated.ExternalID)
+
+	// An update that arrives late doesn't move the delivery back
+	updated, err = postDeliveryStatus(deliveries, models.DeliveryStatusUpdate{OrderID: "order-1", Status: models.DeliveryStatusAssigned}, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, models.DeliveryStatusPickedUp, updated.Status)
+
+	tracked, err := deliveries.Tracking(ctx, "order-1")
+	require.NoError(t, err)
+	assert.Equal(t, "Sam", tracked.CourierName)
+	require.NotNil(t, tracked.EstimatedAt)
+	assert.True(t, eta.Equal(*tracked.EstimatedAt))
+
+	_, err = deliveries.Cancel(ctx, "order-1")
+	require.NoError(t, err)
+	queued, total, err = repo.FindPage(ctx, models.WebhookPending, models.PageRequest{Limit: 10})
+	require.NoError(t, err)
+	require.Equal(t, 2, total)
+	var eventIDs []string
+	for _, delivery := range queued {
+		require.NoError(t, json.Unmarshal(delivery.Body, &event))
+		eventIDs = append(eventIDs, event.ID)
+	}
+	assert.Contains(t, eventIDs, "order-1:delivery.cancelled")
+}
+
+func TestDeliveryService_HandleStatusSignature(t *testing.T) {
+	ctx := context.Background()
+	deliveries := newDeliveryService(services.NewMockDeliveryProvider(time.Hour), 0)
+	_, err := deliveries.Dispatch(ctx, &models.Order{ID: "order-1"}, testDeliveryAddress)
+	require.NoError(t, err)
+
+	update := models.DeliveryStatusUpdate{OrderID: "order-1", Status: models.DeliveryStatusAssigned}
+	payload, _ := json.Marshal(update)
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+
+	_, err = deliveries.HandleStatus(ctx, payload, "dlv-1", now, "")
+	assert.ErrorIs(t, err, services.ErrDeliverySignature, "unsigned")
+	_, err = deliveries.HandleStatus(ctx, payload, "dlv-1", now, services.SignWebhook([]byte("other"), "dlv-1", time.Now().Unix(), payload))
+	assert.ErrorIs(t, err, services.ErrDeliverySignature, "signed with another secret")
+	_, err = postDeliveryStatus(deliveries, update, time.Now().Add(-2*time.Minute))
+	assert.ErrorIs(t, err, services.ErrDeliverySignature, "a replay of an old update")
+	_, err = postDeliveryStatus(deliveries, models.DeliveryStatusUpdate{OrderID: "order-1", Status: "lost"}, time.Now())
+	assert.ErrorIs(t, err, services.ErrDeliveryPayload)
+	_, err = postDeliveryStatus(deliveries, models.DeliveryStatusUpdate{OrderID: "order-2", Status: models.DeliveryStatusAssigned}, time.Now())
+	assert.ErrorIs(t, err, services.ErrDeliveryNotFound)
+
+	delivery, err := postDeliveryStatus(deliveries, update, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, models.DeliveryStatusAssigned, delivery.Status)
+}
+
+func TestOrderQueue_DispatchesDeliveries(t *testing.T) {
+	ctx := context.Background()
+	products := repository.NewMemoryProductRepository()
+	seeded, err := products.Find(ctx)
+	require.NoError(t, err)
+	require.NotEmpty(t, seeded)
+
+	orders := repository.NewMemoryOrderRepository()
+	queueRepo := repository.NewMemoryOrderQueueRepository()
+	orderService := services.NewOrderService(orders, products, queueRepo, nil, nil, nil, nil, nil, nil, nil, nil, zap.NewNop())
+	deliveries := newDeliveryService(services.NewMockDeliveryProvider(time.Hour), time.Hour)
+	queue := services.NewOrderQueueService(services.OrderQueueDeps{
+		QueueRepo:    queueRepo,
+		OrderRepo:    orders,
+		OrderService: orderService,
+		Deliveries:   deliveries,
+	}, nil, "test-worker")
+
+	items := []models.OrderItem{{ProductID: seeded[0].ID, Quantity: 1}}
+	delivered, err := queue.AddOrderToQueue(ctx, &models.OrderReq{Items: items, DeliveryAddress: &testDeliveryAddress})
+	require.NoError(t, err)
+	collected, err := queue.AddOrderToQueue(ctx, &models.OrderReq{Items: items})
+	require.NoError(t, err)
+	_, err = queue.ProcessBatch(ctx, 10)
+	require.NoError(t, err)
+
+	item, err := queueRepo.GetOrderFromQueue(ctx, delivered.ID)
+	require.NoError(t, err)
+	require.NotNil(t, item.Order)
+	delivery, err := deliveries.Tracking(ctx, item.Order.ID)
+	require.NoError(t, err)
+	require.NotNil(t, delivery)
+	assert.Equal(t, models.DeliveryStatusRequested, delivery.Status)
+
+	item, err = queueRepo.GetOrderFromQueue(ctx, collected.ID)
+	require.NoError(t, err)
+	require.NotNil(t, item.Order)
+	delivery, err = deliveries.Tracking(ctx, item.Order.ID)
+	require.NoError(t, err)
+	assert.Nil(t, delivery, "collected orders get no courier")
+}
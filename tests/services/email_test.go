@@ -0,0 +1,163 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"oolio/internal/app/models"
+	"oolio/internal/app/services"
+)
+
+func TestEmailTemplates_Render(t *testing.T) {
+	order := &models.Order{
+		ID:            "3f2a9c1e-0000-0000-0000-000000000000",
+		Total:         2598,
+		Discounts:     260,
+		Items:         []models.OrderItem{{ProductID: "p1", Quantity: 2, Price: 1299}},
+		Products:      []models.Product{{ID: "p1", Name: "Waffle"}},
+		PaymentMethod: models.PaymentMethodCounter,
+	}
+
+	receipt, err := services.DefaultEmailTemplates().Render(services.EmailOrderConfirmation, services.NewOrderEmail(order))
+	require.NoError(t, err)
+	assert.Equal(t, "Your receipt for order 3F2A9C1E", receipt.Subject)
+	assert.Equal(t, "Thanks for your order 3F2A9C1E.\n\n2 x Waffle  25.98\nDiscounts  -2.60\nTotal  23.38\n\nTo pay at the counter when you collect your order.\n", receipt.Text)
+
+	ready, err := services.DefaultEmailTemplates().Render(services.EmailOrderReady, services.NewOrderEmail(order))
+	require.NoError(t, err)
+	assert.Equal(t, "Your order 3F2A9C1E is ready", ready.Subject)
+	assert.Equal(t, "Your order 3F2A9C1E is ready.\n\nPay 23.38 at the counter when you collect it.\n", ready.Text)
+
+	// Files in the template directory replace the built-in templates of the same name
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "order_ready.tmpl"), []byte(`{{define "subject"}}Order {{.Number}} is up{{end}}{{define "body"}}Come get it{{end}}`), 0o644))
+	custom, err := services.LoadEmailTemplates(dir)
+	require.NoError(t, err)
+	ready, err = custom.Render(services.EmailOrderReady, services.NewOrderEmail(order))
+	require.NoError(t, err)
+	assert.Equal(t, services.Notification{Subject: "Order 3F2A9C1E is up", Text: "Come get it"}, ready)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "order_confirmation.tmpl"), []byte(`{{define "body"}}No subject{{end}}`), 0o644))
+	_, err = services.LoadEmailTemplates(dir)
+	assert.EqualError(t, err, "email template order_confirmation must define a subject and a body")
+}
+
+func TestSandboxEmailSender(t *testing.T) {
+	ctx := context.Background()
+	sent := &sentMessages{}
+	sandbox := services.NewSandboxEmailSender(sent, []string{"@oolio.com", "QA@example.com"}, "", zap.NewNop())
+
+	require.NoError(t, sandbox.SendEmail(ctx, "chef@Oolio.com", "Ready", ""))
+	require.NoError(t, sandbox.SendEmail(ctx, "qa@example.com", "Ready", ""))
+	require.NoError(t, sandbox.SendEmail(ctx, "jo@example.com", "Ready", ""))
+	require.NoError(t, sandbox.SendEmail(ctx, "jo@notoolio.com", "Ready", ""))
+	assert.Equal(t, []string{"chef@Oolio.com|Ready", "qa@example.com|Ready"}, sent.emails, "customers get nothing")
+
+	sent.emails = nil
+	redirected := services.NewSandboxEmailSender(sent, nil, "inbox@oolio.com", zap.NewNop())
+	require.NoError(t, redirected.SendEmail(ctx, "jo@example.com", "Ready", ""))
+	assert.Equal(t, []string{"inbox@oolio.com|[Sandbox: jo@example.com] Ready"}, sent.emails)
+}
+
+func TestSESEmailSender(t *testing.T) {
+	var message map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v2/email/outbound-emails", r.URL.Path)
+		assert.Regexp(t, `^AWS4-HMAC-SHA256 Credential=AKID/\d{8}/ap-southeast-2/ses/aws4_request, SignedHeaders=content-type;host;x-amz-date, Signature=[0-9a-f]{64}$`, r.Header.Get("Authorization"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&message))
+		w.Write([]byte(`{"MessageId":"m-1"}`))
+	}))
+	defer server.Close()
+
+	sender := services.NewSESEmailSender(services.SESOptions{
+		Region: "ap-southeast-2", Endpoint: server.URL, AccessKeyID: "AKID", SecretAccessKey: "secret",
+		From: "orders@oolio.com", Timeout: time.Second,
+	})
+	require.NoError(t, sender.SendEmail(context.Background(), "jo@example.com", "Ready", "Come get it"))
+	assert.Equal(t, "orders@oolio.com", message["FromEmailAddress"])
+	assert.Equal(t, map[string]any{"ToAddresses": []any{"jo@example.com"}}, message["Destination"])
+}
+
+// fakeSMTPServer accepts one email without TLS or authentication and returns what it got
+func fakeSMTPServer(t *testing.T) (port int, received <-chan string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	messages := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		io.WriteString(conn, "220 localhost ESMTP\r\n")
+
+		var transcript strings.Builder
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			transcript.WriteString(line)
+			switch command := strings.ToUpper(strings.TrimSpace(line)); {
+			case strings.HasPrefix(command, "EHLO"):
+				io.WriteString(conn, "250 localhost\r\n")
+			case command == "DATA":
+				io.WriteString(conn, "354 go ahead\r\n")
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					transcript.WriteString(line)
+					if line == ".\r\n" {
+						break
+					}
+				}
+				io.WriteString(conn, "250 queued\r\n")
+			case command == "QUIT":
+				io.WriteString(conn, "221 bye\r\n")
+				messages <- transcript.String()
+				return
+			default:
+				io.WriteString(conn, "250 ok\r\n")
+			}
+		}
+	}()
+	return listener.Addr().(*net.TCPAddr).Port, messages
+}
+
+func TestSMTPEmailSender(t *testing.T) {
+	port, received := fakeSMTPServer(t)
+	sender, err := services.NewSMTPEmailSender(services.SMTPOptions{
+		Host: "127.0.0.1", Port: port, From: "Oolio <orders@oolio.com>", Timeout: time.Second,
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, sender.SendEmail(context.Background(), "jo@example.com", "Your order is ready\r\nBcc: x@example.com", "Come get it"))
+	transcript := <-received
+	assert.Contains(t, transcript, "MAIL FROM:<orders@oolio.com>")
+	assert.Contains(t, transcript, "RCPT TO:<jo@example.com>")
+	assert.Contains(t, transcript, "From: \"Oolio\" <orders@oolio.com>\r\n")
+	assert.NotContains(t, transcript, "\r\nBcc:", "the subject can't add headers")
+	assert.Contains(t, transcript, "\r\n\r\nCome get it")
+
+	_, err = services.NewSMTPEmailSender(services.SMTPOptions{Host: "127.0.0.1", Port: port, From: "not an address"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"oolio/internal/app/events"
+	"oolio/internal/app/models"
+	"oolio/internal/app/repository"
+	"oolio/internal/app/services"
+)
+
+func TestWebhookFulfillmentProvider(t *testing.T) {
+	ctx := context.Background()
+	var attempts atomic.Int32
+	var received events.Event
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The first delivery hits a temporary outage
+		if attempts.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	webhooks, _ := newWebhookService(server.URL, 3)
+	provider := services.NewWebhookFulfillmentProvider(webhooks, "/oolio")
+
+	order := &models.Order{ID: "order-1", Total: models.Cents(1250)}
+	require.NoError(t, provider.Fulfill(ctx, order))
+	// Fulfilling it again doesn't send it twice
+	require.NoError(t, provider.Fulfill(ctx, order))
+
+	result, err := webhooks.DeliverBatch(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Failed)
+	time.Sleep(5 * time.Millisecond)
+	result, err = webhooks.DeliverBatch(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Delivered)
+
+	assert.Equal(t, int32(2), attempts.Load())
+	assert.Equal(t, "order-1:fulfillment", received.ID)
+	assert.Equal(t, "/oolio/order", received.Source)
+	assert.Equal(t, "com.oolio.order.created", received.Type)
+	assert.Equal(t, "order-1", received.Subject)
+
+	var data models.Order
+	require.NoError(t, json.Unmarshal(received.Data, &data))
+	assert.Equal(t, models.Cents(1250), data.Total)
+}
+
+func TestWebhookFulfillmentProvider_NotConfigured(t *testing.T) {
+	webhooks := services.NewWebhookService(repository.NewMemoryWebhookDeliveryRepository(), services.WebhookOptions{})
+	provider := services.NewWebhookFulfillmentProvider(webhooks, "/oolio")
+
+	err := provider.Fulfill(context.Background(), &models.Order{ID: "order-1"})
+	assert.ErrorIs(t, err, services.ErrWebhookEndpointNotConfigured)
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"oolio/internal/app/models"
+	"oolio/internal/app/repository"
+	"oolio/internal/app/services"
+)
+
+func TestGiftCardService_Issue(t *testing.T) {
+	ctx := context.Background()
+	giftCards := services.NewGiftCardService(repository.NewMemoryGiftCardRepository())
+
+	card, err := giftCards.Issue(ctx, models.GiftCardReq{Amount: 5000})
+	require.NoError(t, err)
+	assert.Regexp(t, regexp.MustCompile(`^GC-[2-9A-HJ-NP-Z]{4}-[2-9A-HJ-NP-Z]{4}-[2-9A-HJ-NP-Z]{4}$`), card.Code)
+	assert.Equal(t, models.Money(5000), card.Balance)
+
+	named, err := giftCards.Issue(ctx, models.GiftCardReq{Code: " thanks-jo ", Amount: 2000})
+	require.NoError(t, err)
+	assert.Equal(t, "THANKS-JO", named.Code)
+	_, err = giftCards.Issue(ctx, models.GiftCardReq{Code: "Thanks-Jo", Amount: 2000})
+	assert.EqualError(t, err, "gift card already exists")
+
+	past := time.Now().Add(-time.Hour)
+	for _, req := range []models.GiftCardReq{
+		{Amount: 0},
+		{Code: "GC 1", Amount: 100},
+		{Amount: 100, ExpiresAt: &past},
+	} {
+		_, err = giftCards.Issue(ctx, req)
+		assert.ErrorIs(t, err, services.ErrInvalidGiftCard)
+	}
+
+	found, err := giftCards.Get(ctx, "thanks-jo")
+	require.NoError(t, err)
+	assert.Equal(t, named.Code, found.Code)
+	_, err = giftCards.Get(ctx, "GC-NONE")
+	assert.ErrorIs(t, err, services.ErrGiftCardNotFound)
+}
+
+func TestOrderService_GiftCard(t *testing.T) {
+	ctx := context.Background()
+	products := repository.NewMemoryProductRepository()
+	seeded, err := products.Find(ctx)
+	require.NoError(t, err)
+	require.NotEmpty(t, seeded)
+	price := seeded[0].Price
+
+	giftCards := services.NewGiftCardService(repository.NewMemoryGiftCardRepository())
+	coupons := services.NewCouponService(services.CouponOptions{Discounts: map[string]float64{"fiftyoff": 50}}, zap.NewNop())
+	service := services.NewOrderService(repository.NewMemoryOrderRepository(), products, repository.NewMemoryOrderQueueRepository(), coupons, nil, nil, nil, nil, giftCards, nil, nil, zap.NewNop())
+
+	card, err := giftCards.Issue(ctx, models.GiftCardReq{Amount: price})
+	require.NoError(t, err)
+	orderReq := func(quantity int, couponCode string) *models.OrderReq {
+		return &models.OrderReq{
+			GiftCardCode: card.Code,
+			CouponCode:   couponCode,
+			Items:        []models.OrderItem{{ProductID: seeded[0].ID, Quantity: quantity}},
+		}
+	}
+
+	// The card pays what the coupon leaves of the first order, and part of the second
+	order, err := service.CreateOrder(ctx, orderReq(1, "FIFTYOFF"))
+	require.NoError(t, err)
+	assert.Equal(t, price.Percent(50), order.Discounts)
+	assert.Equal(t, price-order.Discounts, order.StoreCredit)
+	assert.Zero(t, order.AmountDue())
+	assert.Equal(t, card.Code, order.GiftCardCode)
+
+	quote, err := service.QuoteOrder(ctx, orderReq(2, ""))
+	require.NoError(t, err)
+	left := price - order.StoreCredit
+	assert.Equal(t, left, quote.StoreCredit)
+
+	order, err = service.CreateOrder(ctx, orderReq(2, ""))
+	require.NoError(t, err)
+	assert.Equal(t, left, order.StoreCredit)
+	assert.Equal(t, price.Mul(2)-left, order.AmountDue())
+
+	_, err = service.CreateOrder(ctx, orderReq(1, ""))
+	assert.ErrorIs(t, err, services.ErrGiftCardEmpty)
+	_, err = service.QuoteOrder(ctx, &models.OrderReq{GiftCardCode: "GC-NONE", Items: []models.OrderItem{{ProductID: seeded[0].ID, Quantity: 1}}})
+	assert.ErrorIs(t, err, services.ErrGiftCardNotFound)
+}
+
+// Priced while the balance was there, an order fails if another spent it first, so the
+// queue retries it with what is left
+func TestGiftCardService_RedeemSpentBalance(t *testing.T) {
+	ctx := context.Background()
+	giftCards := services.NewGiftCardService(repository.NewMemoryGiftCardRepository())
+	card, err := giftCards.Issue(ctx, models.GiftCardReq{Amount: 1000})
+	require.NoError(t, err)
+
+	credit, err := giftCards.Apply(ctx, card.Code, 800)
+	require.NoError(t, err)
+	assert.Equal(t, models.Money(800), credit)
+	require.NoError(t, giftCards.Redeem(ctx, card.Code, credit))
+	assert.ErrorIs(t, giftCards.Redeem(ctx, card.Code, credit), services.ErrGiftCardBalanceChanged)
+
+	require.NoError(t, giftCards.Release(ctx, card.Code, credit))
+	found, err := giftCards.Get(ctx, card.Code)
+	require.NoError(t, err)
+	assert.Equal(t, models.Money(1000), found.Balance)
+}
+
+func TestOrderQueue_GiftCardPaysInFull(t *testing.T) {
+	ctx := context.Background()
+	products := repository.NewMemoryProductRepository()
+	seeded, err := products.Find(ctx)
+	require.NoError(t, err)
+	require.NotEmpty(t, seeded)
+
+	giftCards := services.NewGiftCardService(repository.NewMemoryGiftCardRepository())
+	card, err := giftCards.Issue(ctx, models.GiftCardReq{Amount: seeded[0].Price})
+	require.NoError(t, err)
+
+	orders := repository.NewMemoryOrderRepository()
+	queueRepo := repository.NewMemoryOrderQueueRepository()
+	orderService := services.NewOrderService(orders, products, queueRepo, nil, nil, nil, nil, services.NewMockPaymentService("aud"), giftCards, nil, nil, zap.NewNop())
+	queue := services.NewOrderQueueService(services.OrderQueueDeps{
+		QueueRepo:    queueRepo,
+		OrderRepo:    orders,
+		OrderService: orderService,
+		Payment:      services.PaymentPolicy{Required: true},
+	}, nil, "test-worker")
+
+	item, err := queue.AddOrderToQueue(ctx, &models.OrderReq{
+		GiftCardCode: card.Code,
+		Items:        []models.OrderItem{{ProductID: seeded[0].ID, Quantity: 1}},
+	})
+	require.NoError(t, err)
+
+	result, err := queue.ProcessBatch(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Processed, "nothing is left to pay")
+	completed, err := queue.GetOrderFromQueue(ctx, item.ID)
+	require.NoError(t, err)
+	require.NotNil(t, completed.Order)
+	assert.Equal(t, seeded[0].Price, completed.Order.StoreCredit)
+	assert.Empty(t, completed.Order.PaymentIntentID)
+}
@@ -0,0 +1,268 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"oolio/internal/app/models"
+	"oolio/internal/app/repository"
+	"oolio/internal/app/services"
+)
+
+func TestInventoryService_TakeAndRestock(t *testing.T) {
+	ctx := context.Background()
+	products := repository.NewMemoryProductRepository()
+	seeded, err := products.Find(ctx)
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, len(seeded), 2)
+	counted, uncounted := seeded[0], seeded[1]
+
+	inventory := services.NewInventoryService(repository.NewMemoryInventoryRepository(products), products, 5, time.Minute, zap.NewNop())
+	level, err := inventory.Restock(ctx, counted.ID, 3)
+	require.NoError(t, err)
+	assert.Equal(t, 3, level.Quantity)
+	assert.Equal(t, counted.Name, level.Name)
+	assert.Equal(t, models.DefaultStoreID, level.StoreID)
+
+	order := func(quantity int) *models.Order {
+		return &models.Order{
+			Items: []models.OrderItem{
+				{ProductID: counted.ID, Quantity: quantity - 1},
+				{ProductID: uncounted.ID, Quantity: 100},
+				{ProductID: counted.ID, Quantity: 1},
+			},
+			Products: []models.Product{counted, uncounted},
+		}
+	}
+
+	// Items naming the product twice add up, and products never restocked aren't counted
+	err = inventory.Take(ctx, "", order(4))
+	assert.ErrorIs(t, err, services.ErrOutOfStock)
+	assert.EqualError(t, err, "out of stock: "+counted.Name+" has 3 left")
+	require.NoError(t, inventory.Take(ctx, "", order(3)))
+	err = inventory.Take(ctx, "", order(1))
+	assert.EqualError(t, err, "out of stock: "+counted.Name)
+
+	require.NoError(t, inventory.Return(ctx, order(2)))
+	err = inventory.Reserve(ctx, &models.OrderReq{Items: order(3).Items})
+	assert.ErrorIs(t, err, services.ErrOutOfStock)
+	assert.NoError(t, inventory.Reserve(ctx, &models.OrderReq{Items: order(2).Items}))
+
+	level, err = inventory.Restock(ctx, counted.ID, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 12, level.Quantity)
+
+	_, err = inventory.Restock(ctx, "8a6e0804-2bd0-4672-b79d-d97027f9071a", 10)
+	assert.ErrorContains(t, err, "product not found")
+}
+
+func TestInventoryService_Reserve(t *testing.T) {
+	ctx := context.Background()
+	products := repository.NewMemoryProductRepository()
+	seeded, err := products.Find(ctx)
+	require.NoError(t, err)
+	require.NotEmpty(t, seeded)
+	product := seeded[0]
+
+	repo := repository.NewMemoryInventoryRepository(products)
+	inventory := services.NewInventoryService(repo, products, 5, time.Minute, zap.NewNop())
+	_, err = inventory.Restock(ctx, product.ID, 3)
+	require.NoError(t, err)
+	order := func(quantity int) (*models.OrderReq, *models.Order) {
+		items := []models.OrderItem{{ProductID: product.ID, Quantity: quantity}}
+		return &models.OrderReq{Items: items}, &models.Order{Items: items, Products: []models.Product{product}}
+	}
+
+	// What is reserved is kept from other orders, whether reserving or taking
+	reservedReq, reservedOrder := order(2)
+	require.NoError(t, inventory.Reserve(ctx, reservedReq))
+	assert.NotEmpty(t, reservedReq.StockReservation)
+	otherReq, otherOrder := order(2)
+	err = inventory.Reserve(ctx, otherReq)
+	assert.EqualError(t, err, "out of stock: "+product.Name+" has 1 left")
+	assert.Empty(t, otherReq.StockReservation)
+	assert.ErrorIs(t, inventory.Take(ctx, "", otherOrder), services.ErrOutOfStock)
+
+	// Its own order takes it, after which nothing is reserved
+	require.NoError(t, inventory.Take(ctx, reservedReq.StockReservation, reservedOrder))
+	_, oneOrder := order(1)
+	require.NoError(t, inventory.Take(ctx, "", oneOrder))
+
+	// Released and expired reservations hold nothing
+	_, err = inventory.Restock(ctx, product.ID, 2)
+	require.NoError(t, err)
+	releasedReq, _ := order(2)
+	require.NoError(t, inventory.Reserve(ctx, releasedReq))
+	require.NoError(t, inventory.Release(ctx, releasedReq))
+	shortLived := services.NewInventoryService(repo, products, 5, time.Millisecond, zap.NewNop())
+	expiredReq, _ := order(2)
+	require.NoError(t, shortLived.Reserve(ctx, expiredReq))
+	time.Sleep(5 * time.Millisecond)
+	require.NoError(t, inventory.Reserve(ctx, otherReq))
+
+	deleted, err := repo.DeleteExpiredReservations(ctx, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+	assert.NoError(t, shortLived.DeleteExpiredReservations(ctx))
+}
+
+func TestInventoryService_LowStock(t *testing.T) {
+	ctx := context.Background()
+	products := repository.NewMemoryProductRepository()
+	seeded, err := products.Find(ctx)
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, len(seeded), 4)
+
+	inventory := services.NewInventoryService(repository.NewMemoryInventoryRepository(products), products, 5, time.Minute, zap.NewNop())
+	for i, quantity := range []int{5, 2, 40, 1} {
+		_, err := inventory.Restock(ctx, seeded[i].ID, quantity)
+		require.NoError(t, err)
+	}
+	// Sold out, and then taken off the menu
+	require.NoError(t, inventory.Take(ctx, "", &models.Order{Items: []models.OrderItem{{ProductID: seeded[3].ID, Quantity: 1}}}))
+	require.NoError(t, products.Delete(ctx, seeded[3].ID))
+
+	low, err := inventory.LowStock(ctx, "", inventory.LowStockThreshold())
+	require.NoError(t, err)
+	require.Len(t, low, 2)
+	assert.Equal(t, seeded[1].ID, low[0].ProductID, "fewest first")
+	assert.Equal(t, 2, low[0].Quantity)
+	assert.Equal(t, seeded[1].Name, low[0].Name)
+	assert.Equal(t, seeded[0].ID, low[1].ProductID)
+
+	low, err = inventory.LowStock(ctx, "", 2)
+	require.NoError(t, err)
+	assert.Len(t, low, 1)
+
+	low, err = inventory.LowStock(ctx, "5b1f0c2e-3d4a-4e6f-8a9b-0c1d2e3f4a5b", 100)
+	require.NoError(t, err)
+	assert.Empty(t, low, "other stores' products are left out")
+}
+
+func TestOrderService_OutOfStock(t *testing.T) {
+	ctx := context.Background()
+	products := repository.NewMemoryProductRepository()
+	seeded, err := products.Find(ctx)
+	require.NoError(t, err)
+	require.NotEmpty(t, seeded)
+	product := seeded[0]
+
+	inventory := services.NewInventoryService(repository.NewMemoryInventoryRepository(products), products, 5, time.Minute, zap.NewNop())
+	_, err = inventory.Restock(ctx, product.ID, 2)
+	require.NoError(t, err)
+	giftCards := services.NewGiftCardService(repository.NewMemoryGiftCardRepository())
+	card, err := giftCards.Issue(ctx, models.GiftCardReq{Amount: 1000})
+	require.NoError(t, err)
+
+	orders := repository.NewMemoryOrderRepository()
+	queueRepo := repository.NewMemoryOrderQueueRepository()
+	orderService := services.NewOrderService(orders, products, queueRepo, nil, nil, nil, nil, nil, giftCards, nil, inventory, zap.NewNop())
+	queue := services.NewOrderQueueService(services.OrderQueueDeps{
+		QueueRepo:    queueRepo,
+		OrderRepo:    orders,
+		OrderService: orderService,
+		Inventory:    inventory,
+	}, nil, "test-worker")
+
+	orderReq := func(quantity int) *models.OrderReq {
+		return &models.OrderReq{Items: []models.OrderItem{{ProductID: product.ID, Quantity: quantity}}}
+	}
+
+	// The store credit spent on an order refused for its stock is given back
+	paidByCard := orderReq(3)
+	paidByCard.GiftCardCode = card.Code
+	_, err = orderService.CreateOrder(ctx, paidByCard)
+	assert.ErrorIs(t, err, services.ErrOutOfStock)
+	found, err := giftCards.Get(ctx, card.Code)
+	require.NoError(t, err)
+	assert.Equal(t, models.Money(1000), found.Balance)
+
+	// Sandbox orders take none of it
+	sandbox := orderReq(2)
+	sandbox.Sandbox = true
+	_, err = orderService.CreateOrder(ctx, sandbox)
+	require.NoError(t, err)
+
+	// An order queued for stock another has reserved is refused, while a scheduled order,
+	// which reserves none, fails when processed
+	first, err := queue.AddOrderToQueue(ctx, orderReq(2))
+	require.NoError(t, err)
+	_, err = queue.AddOrderToQueue(ctx, orderReq(1))
+	assert.EqualError(t, err, "out of stock: "+product.Name)
+	scheduled := orderReq(1)
+	past := time.Now().Add(-time.Minute)
+	scheduled.ScheduledFor = &past
+	second, err := queue.AddOrderToQueue(ctx, scheduled)
+	require.NoError(t, err)
+	result, err := queue.ProcessBatch(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Processed)
+	assert.Equal(t, 1, result.Failed)
+
+	item, err := queue.GetOrderFromQueue(ctx, first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "completed", item.Status)
+	item, err = queue.GetOrderFromQueue(ctx, second.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "failed", item.Status)
+	assert.Equal(t, models.QueueErrorOutOfStock, item.ErrorCode)
+	assert.Equal(t, "out of stock: "+product.Name, item.Error)
+
+	// Not retried until it is requeued, once restocked
+	result, err = queue.ProcessBatch(ctx, 10)
+	require.NoError(t, err)
+	assert.Zero(t, result.Failed+result.Processed)
+	_, err = inventory.Restock(ctx, product.ID, 1)
+	require.NoError(t, err)
+	require.NoError(t, queue.Retry(ctx, second.ID))
+	result, err = queue.ProcessBatch(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Processed)
+}
+
+// An order that fails for good gives up its reservation for others to order
+func TestOrderQueue_ReleasesReservedStock(t *testing.T) {
+	ctx := context.Background()
+	products := repository.NewMemoryProductRepository()
+	seeded, err := products.Find(ctx)
+	require.NoError(t, err)
+	require.NotEmpty(t, seeded)
+	product := seeded[0]
+
+	inventory := services.NewInventoryService(repository.NewMemoryInventoryRepository(products), products, 5, time.Minute, zap.NewNop())
+	_, err = inventory.Restock(ctx, product.ID, 1)
+	require.NoError(t, err)
+	orders := repository.NewMemoryOrderRepository()
+	queueRepo := repository.NewMemoryOrderQueueRepository()
+	orderService := services.NewOrderService(orders, products, queueRepo, nil, nil, nil, nil, services.NewMockPaymentService("aud"), nil, nil, inventory, zap.NewNop())
+	queue := services.NewOrderQueueService(services.OrderQueueDeps{
+		QueueRepo:    queueRepo,
+		OrderRepo:    orders,
+		OrderService: orderService,
+		Inventory:    inventory,
+		Payment:      services.PaymentPolicy{Required: true, Window: time.Nanosecond},
+	}, nil, "test-worker")
+
+	orderReq := func() *models.OrderReq {
+		return &models.OrderReq{Items: []models.OrderItem{{ProductID: product.ID, Quantity: 1}}}
+	}
+	unpaid, err := queue.AddOrderToQueue(ctx, orderReq())
+	require.NoError(t, err)
+	_, err = queue.AddOrderToQueue(ctx, orderReq())
+	assert.ErrorIs(t, err, services.ErrOutOfStock)
+
+	// Never paid for within the window
+	_, err = queue.ProcessBatch(ctx, 10)
+	require.NoError(t, err)
+	item, err := queue.GetOrderFromQueue(ctx, unpaid.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.QueueErrorPaymentExpired, item.ErrorCode)
+
+	_, err = queue.AddOrderToQueue(ctx, orderReq())
+	assert.NoError(t, err)
+}
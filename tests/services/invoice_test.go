@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"oolio/internal/app/models"
+	"oolio/internal/app/repository"
+	"oolio/internal/app/services"
+)
+
+func TestInvoiceService_Invoice(t *testing.T) {
+	ctx := context.Background()
+	orders := repository.NewMemoryOrderRepository()
+	invoices, err := services.NewInvoiceService(repository.NewMemoryInvoiceRepository(), orders, services.InvoiceOptions{
+		Series:   "INV",
+		Seller:   models.InvoiceSeller{Name: "Oolio Burgers Pty Ltd", TaxID: "51 824 753 556"},
+		Currency: "aud",
+		TaxName:  "GST",
+		TaxRate:  10,
+	})
+	require.NoError(t, err)
+
+	// 22.00 less a 1.00 coupon, 5.00 of it paid from a gift card
+	first := &models.Order{
+		Total:       2200,
+		Discounts:   100,
+		StoreCredit: 500,
+		Items:       []models.OrderItem{{ProductID: "p1", Quantity: 2, Price: 1100}},
+		Products:    []models.Product{{ID: "p1", Name: "Chicken Waffle"}},
+	}
+	second := &models.Order{Total: 1100, Items: []models.OrderItem{{ProductID: "p2", Quantity: 1, Price: 1100}}}
+	require.NoError(t, orders.Create(ctx, first))
+	require.NoError(t, orders.Create(ctx, second))
+
+	invoice, err := invoices.Invoice(ctx, "", first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "INV-000001", invoice.Number)
+	assert.Equal(t, "51 824 753 556", invoice.Seller.TaxID)
+	assert.Equal(t, []models.InvoiceLine{{ProductID: "p1", Description: "Chicken Waffle", Quantity: 2, UnitPrice: 1100, Amount: 2200}}, invoice.Lines)
+	assert.Equal(t, models.Money(2100), invoice.Total)
+	assert.Equal(t, []models.InvoiceTax{{Name: "GST", Rate: 10, Taxable: 1909, Amount: 191}}, invoice.Taxes, "store credit doesn't lower the tax")
+
+	again, err := invoices.Invoice(ctx, "", first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, invoice, again, "issued once")
+
+	next, err := invoices.Invoice(ctx, "", second.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "INV-000002", next.Number)
+	assert.Equal(t, "Product p2", next.Lines[0].Description)
+
+	_, err = invoices.Invoice(ctx, "", uuid.New().String())
+	assert.ErrorIs(t, err, services.ErrInvoiceOrderNotFound)
+	_, err = invoices.Invoice(ctx, "", "not-an-order")
+	assert.ErrorIs(t, err, services.ErrInvoiceOrderNotFound)
+}
+
+func TestInvoiceService_NotInvoiceable(t *testing.T) {
+	ctx := context.Background()
+	orders := repository.NewMemoryOrderRepository()
+	invoiceRepo := repository.NewMemoryInvoiceRepository()
+	invoices, err := services.NewInvoiceService(invoiceRepo, orders, services.InvoiceOptions{Series: "INV"})
+	require.NoError(t, err)
+
+	uncaptured := &models.Order{Total: 1000, PaymentIntentID: "pi_1", PaymentStatus: models.OrderPaymentAuthorized}
+	require.NoError(t, orders.Create(ctx, uncaptured))
+	_, err = invoices.Invoice(ctx, "", uncaptured.ID)
+	assert.ErrorIs(t, err, services.ErrOrderNotInvoiceable)
+
+	require.NoError(t, orders.SetPaymentStatus(ctx, uncaptured.ID, models.OrderPaymentCaptured))
+	invoice, err := invoices.Invoice(ctx, "", uncaptured.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "INV-000001", invoice.Number, "refusing an order uses up no number")
+	assert.Empty(t, invoice.Taxes, "no tax rate")
+
+	sandbox := &models.Order{Total: 1000, Sandbox: true}
+	require.NoError(t, orders.Create(ctx, sandbox))
+	_, err = invoices.Invoice(ctx, "", sandbox.ID)
+	assert.ErrorIs(t, err, services.ErrOrderNotInvoiceable)
+
+	for _, opts := range []services.InvoiceOptions{{Series: "inv-2026"}, {Series: "INV", TaxRate: 100}} {
+		_, err := services.NewInvoiceService(invoiceRepo, orders, opts)
+		assert.Error(t, err)
+	}
+}
+
+func TestInvoiceService_PerStore(t *testing.T) {
+	ctx := context.Background()
+	orders := repository.NewMemoryOrderRepository()
+	invoices, err := services.NewInvoiceService(repository.NewMemoryInvoiceRepository(), orders, services.InvoiceOptions{Series: "INV"})
+	require.NoError(t, err)
+
+	harbour := uuid.New().String()
+	atDefault := &models.Order{Total: 1000}
+	atHarbour := &models.Order{Total: 1000, StoreID: harbour}
+	require.NoError(t, orders.Create(ctx, atDefault))
+	require.NoError(t, orders.Create(ctx, atHarbour))
+
+	_, err = invoices.Invoice(ctx, harbour, atDefault.ID)
+	assert.ErrorIs(t, err, services.ErrInvoiceOrderNotFound, "another store's order")
+
+	invoice, err := invoices.Invoice(ctx, models.DefaultStoreID, atDefault.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "INV-000001", invoice.Number, "refusing the other store used up no number")
+	assert.Equal(t, models.DefaultStoreID, invoice.StoreID)
+
+	invoice, err = invoices.Invoice(ctx, harbour, atHarbour.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "INV-000001", invoice.Number, "each store numbers its own")
+	assert.Equal(t, harbour, invoice.StoreID)
+
+	_, err = invoices.Invoice(ctx, harbour, atDefault.ID)
+	assert.ErrorIs(t, err, services.ErrInvoiceOrderNotFound, "issued invoices are scoped too")
+	_, err = invoices.Invoice(ctx, "", atHarbour.ID)
+	assert.NoError(t, err, "callers without a store see every store's")
+}
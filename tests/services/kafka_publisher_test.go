@@ -0,0 +1,96 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"oolio/internal/app/events"
+	"oolio/internal/app/models"
+	"oolio/internal/app/services"
+)
+
+func TestKafkaEventPublisher(t *testing.T) {
+	var paths []string
+	var records []struct {
+		Key   string       `json:"key"`
+		Value events.Event `json:"value"`
+	}
+	failTopic := "oolio.products"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		assert.Equal(t, "application/vnd.kafka.json.v2+json", r.Header.Get("Content-Type"))
+
+		var body struct {
+			Records []struct {
+				Key   string       `json:"key"`
+				Value events.Event `json:"value"`
+			} `json:"records"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		records = append(records, body.Records...)
+
+		if r.URL.Path == "/topics/"+failTopic {
+			w.Write([]byte(`{"offsets":[{"partition":null,"offset":null,"error_code":50003,"error":"leader not available"}]}`))
+			return
+		}
+		w.Write([]byte(`{"offsets":[{"partition":0,"offset":42}]}`))
+	}))
+	defer server.Close()
+
+	publisher := services.NewKafkaEventPublisher(services.KafkaOptions{
+		RESTURL: server.URL + "/",
+		Topic:   "oolio.events",
+		Topics:  map[string]string{"order": "oolio.orders", "product": failTopic},
+		Timeout: time.Second,
+	})
+	ctx := context.Background()
+
+	now := time.Now()
+	require.NoError(t, publisher.Publish(ctx, events.New("/oolio", "e1", "order", "o1", models.EventOrderQueued, json.RawMessage(`{}`), now)))
+	require.NoError(t, publisher.Publish(ctx, events.New("/oolio", "e2", "coupon", "c1", "coupon.refreshed", json.RawMessage(`{}`), now)))
+	assert.ErrorContains(t, publisher.Publish(ctx, events.New("/oolio", "e3", "product", "p1", models.EventProductUpdated, json.RawMessage(`{}`), now)), "leader not available")
+
+	assert.Equal(t, []string{"/topics/oolio.orders", "/topics/oolio.events", "/topics/oolio.products"}, paths)
+	require.Len(t, records, 3)
+	assert.Equal(t, "o1", records[0].Key)
+	assert.Equal(t, "com.oolio.order.queued", records[0].Value.Type)
+	assert.Equal(t, "/oolio/order", records[0].Value.Source)
+}
+
+func TestWebhookEventPublisher(t *testing.T) {
+	var received []events.Event
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/cloudevents+json", r.Header.Get("Content-Type"))
+		assert.NotEmpty(t, r.Header.Get(services.SignatureHeader))
+
+		var event events.Event
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&event))
+		received = append(received, event)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	webhooks, _ := newWebhookService(server.URL, 3)
+	publisher := services.NewWebhookEventPublisher(webhooks, []string{models.EventOrderRefunded, models.EventOrderRefundFailed})
+	ctx := context.Background()
+
+	now := time.Now()
+	require.NoError(t, publisher.Publish(ctx, events.New("/oolio", "e1", "order", "o1", models.EventOrderCreated, json.RawMessage(`{}`), now)))
+	require.NoError(t, publisher.Publish(ctx, events.New("/oolio", "e2", "order", "o1", models.EventOrderRefunded, json.RawMessage(`{"amount":12.5}`), now)))
+	// The outbox relay may publish an event again; it is only delivered once
+	require.NoError(t, publisher.Publish(ctx, events.New("/oolio", "e2", "order", "o1", models.EventOrderRefunded, json.RawMessage(`{"amount":12.5}`), now)))
+
+	_, err := webhooks.DeliverBatch(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, received, 1, "other event types are dropped")
+	assert.Equal(t, "com.oolio.order.refunded", received[0].Type)
+	assert.Equal(t, "o1", received[0].Subject)
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"oolio/internal/app/models"
+	"oolio/internal/app/repository"
+	"oolio/internal/app/services"
+	"oolio/internal/storage"
+)
+
+// stubMenuSource serves whatever menu the test sets
+type stubMenuSource struct {
+	items []services.MenuItem
+}
+
+func (s *stubMenuSource) Name() string { return "stub" }
+
+func (s *stubMenuSource) FetchMenu(ctx context.Context) ([]services.MenuItem, error) {
+	return s.items, nil
+}
+
+func findProduct(t *testing.T, productService services.ProductService, name string) *models.Product {
+	t.Helper()
+	products, err := productService.GetAllProducts(context.Background())
+	require.NoError(t, err)
+	for i := range products {
+		if products[i].Name == name {
+			return &products[i]
+		}
+	}
+	return nil
+}
+
+func TestMenuImportService_Reconciles(t *testing.T) {
+	ctx := context.Background()
+	state, err := storage.NewLocalStorage(t.TempDir())
+	require.NoError(t, err)
+
+	productService := services.NewProductService(repository.NewMemoryProductRepository())
+	seeded, err := productService.GetAllProducts(ctx)
+	require.NoError(t, err)
+	manual := seeded[0]
+
+	source := &stubMenuSource{items: []services.MenuItem{
+		{ExternalID: "a", Name: "Imported Pie", Price: models.Cents(650), Category: "Pie"},
+		// Matches a product entered by hand, which the import takes over
+		{ExternalID: "b", Name: manual.Name, Price: manual.Price + 100, Category: manual.Category},
+	}}
+	importer := services.NewMenuImportService(source, productService, state, zap.NewNop())
+
+	result, err := importer.Import(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Created)
+	assert.Equal(t, 1, result.Updated)
+	assert.Empty(t, result.Errors)
+
+	pie := findProduct(t, productService, "Imported Pie")
+	require.NotNil(t, pie)
+	taken := findProduct(t, productService, manual.Name)
+	require.NotNil(t, taken)
+	assert.Equal(t, manual.ID, taken.ID)
+	assert.Equal(t, manual.Price+100, taken.Price)
+	assert.Equal(t, manual.Image, taken.Image, "an item without images keeps the product's")
+
+	// Rerunning the same menu changes nothing
+	result, err = importer.Import(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Unchanged)
+
+	// A renamed item updates its product; a dropped item archives only its own product
+	source.items = []services.MenuItem{{ExternalID: "a", Name: "Apple Pie", Price: models.Cents(700), Category: "Pie"}}
+	result, err = importer.Import(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Updated)
+	assert.Equal(t, 1, result.Archived)
+
+	renamed, err := productService.GetProductByID(ctx, pie.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Apple Pie", renamed.Name)
+	assert.Nil(t, findProduct(t, productService, manual.Name))
+	assert.NotNil(t, findProduct(t, productService, seeded[1].Name), "products never imported are left alone")
+
+	// An item back on the menu restores its archived product
+	source.items = append(source.items, services.MenuItem{ExternalID: "b", Name: manual.Name, Price: manual.Price, Category: manual.Category})
+	result, err = importer.Import(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Restored)
+	restored := findProduct(t, productService, manual.Name)
+	require.NotNil(t, restored)
+	assert.Equal(t, manual.ID, restored.ID)
+	assert.Equal(t, manual.Price, restored.Price)
+}
+
+func TestMenuImportService_Refuses(t *testing.T) {
+	ctx := context.Background()
+	state, err := storage.NewLocalStorage(t.TempDir())
+	require.NoError(t, err)
+	productService := services.NewProductService(repository.NewMemoryProductRepository())
+
+	_, err = services.NewMenuImportService(nil, productService, state, zap.NewNop()).Import(ctx)
+	assert.ErrorIs(t, err, services.ErrMenuImportDisabled)
+
+	// An empty menu would archive everything imported so far
+	_, err = services.NewMenuImportService(&stubMenuSource{}, productService, state, zap.NewNop()).Import(ctx)
+	assert.ErrorContains(t, err, "no items")
+}
+
+func TestGenericMenuSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"items": [
+			{"id": "sku-1", "name": " Chicken Waffle ", "price": "12.50", "category": "Waffle",
+			 "image": {"thumbnail": "https://cdn.test/1.jpg"}},
+			{"id": "sku-2", "name": "Sold Out", "price": 4, "category": "Drink", "available": false}
+		]}`))
+	}))
+	defer server.Close()
+
+	source := services.NewGenericMenuSource(services.GenericMenuOptions{URL: server.URL, Token: "secret", Timeout: time.Second})
+	items, err := source.FetchMenu(context.Background())
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, services.MenuItem{
+		ExternalID: "sku-1",
+		Name:       "Chicken Waffle",
+		Price:      models.Cents(1250),
+		Category:   "Waffle",
+		Image:      models.Image{Thumbnail: "https://cdn.test/1.jpg"},
+	}, items[0])
+
+	_, err = services.NewGenericMenuSource(services.GenericMenuOptions{URL: server.URL, Timeout: time.Second}).FetchMenu(context.Background())
+	assert.ErrorContains(t, err, "401")
+}
+
+func TestGenericMenu_Validates(t *testing.T) {
+	_, err := services.GenericMenu{Items: []services.GenericMenuItem{{ID: "x", Name: "Free", Category: "Misc"}}}.MenuItems()
+	assert.ErrorContains(t, err, "menu item x: price")
+}
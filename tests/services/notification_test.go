@@ -0,0 +1,276 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"oolio/internal/app/models"
+	"oolio/internal/app/repository"
+	"oolio/internal/app/services"
+)
+
+// sentMessages records what the fake senders were asked to deliver
+type sentMessages struct {
+	emails []string // to|subject
+	texts  []string // to|message
+}
+
+func (s *sentMessages) SendEmail(ctx context.Context, to, subject, text string) error {
+	s.emails = append(s.emails, to+"|"+subject)
+	return nil
+}
+
+func (s *sentMessages) SendSMS(ctx context.Context, phone, message string) error {
+	s.texts = append(s.texts, phone+"|"+message)
+	return nil
+}
+
+// flakyEmailSender fails the first failures emails it is asked to send
+type flakyEmailSender struct {
+	sentMessages
+	failures int
+}
+
+func (s *flakyEmailSender) SendEmail(ctx context.Context, to, subject, text string) error {
+	if s.failures > 0 {
+		s.failures--
+		return errors.New("smtp: connection refused")
+	}
+	return s.sentMessages.SendEmail(ctx, to, subject, text)
+}
+
+func TestNotificationService_Preferences(t *testing.T) {
+	ctx := context.Background()
+	service := services.NewNotificationService(repository.NewMemoryNotificationRepository(), nil, nil, services.NotificationOptions{})
+
+	preferences, err := service.GetPreferences(ctx, "42")
+	require.NoError(t, err)
+	assert.Equal(t, models.DefaultNotificationPreferences(), *preferences)
+
+	saved, err := service.SavePreferences(ctx, "42", models.NotificationPreferencesReq{Email: " jo@example.com ", SMSReadyAlert: true})
+	require.NoError(t, err)
+	assert.Equal(t, "jo@example.com", saved.Email)
+	assert.NotNil(t, saved.UpdatedAt)
+
+	_, err = service.SavePreferences(ctx, "42", models.NotificationPreferencesReq{Phone: "0400000000"})
+	assert.ErrorIs(t, err, services.ErrInvalidPhone)
+	_, err = service.SavePreferences(ctx, "42", models.NotificationPreferencesReq{Email: "not an email"})
+	assert.ErrorIs(t, err, services.ErrInvalidEmail)
+}
+
+func TestNotificationService_RespectsPreferences(t *testing.T) {
+	ctx := context.Background()
+	sent := &sentMessages{}
+	service := services.NewNotificationService(repository.NewMemoryNotificationRepository(), sent, sent, services.NotificationOptions{})
+
+	order := &models.Order{
+		ID:         "3f2a9c1e-0000-0000-0000-000000000000",
+		CustomerID: "42",
+		Total:      2598,
+		Discounts:  260,
+		Items:      []models.OrderItem{{ProductID: "p1", Quantity: 2, Price: 1299}},
+		Products:   []models.Product{{ID: "p1", Name: "Waffle"}},
+	}
+
+	// The default wants receipts, but there is no email to send them to
+	notified, err := service.OrderPlaced(ctx, order)
+	require.NoError(t, err)
+	assert.False(t, notified)
+
+	_, err = service.SavePreferences(ctx, "42", models.NotificationPreferencesReq{Email: "jo@example.com", Phone: "+61400000000", EmailReceipt: true})
+	require.NoError(t, err)
+
+	notified, err = service.OrderPlaced(ctx, order)
+	require.NoError(t, err)
+	assert.True(t, notified)
+	assert.Equal(t, []string{"jo@example.com|Your receipt for order 3F2A9C1E"}, sent.emails)
+
+	notified, err = service.OrderReady(ctx, order)
+	require.NoError(t, err)
+	assert.False(t, notified, "ready alerts are opt-in")
+
+	notified, err = service.Notify(ctx, "42", models.NotificationMarketing, services.Notification{Subject: "Half price Tuesdays"})
+	require.NoError(t, err)
+	assert.False(t, notified, "marketing is opt-in")
+
+	_, err = service.SavePreferences(ctx, "42", models.NotificationPreferencesReq{Phone: "+61400000000", SMSReadyAlert: true})
+	require.NoError(t, err)
+	notified, err = service.OrderReady(ctx, order)
+	require.NoError(t, err)
+	assert.True(t, notified)
+	assert.Equal(t, []string{"+61400000000|Your order 3F2A9C1E is ready."}, sent.texts)
+
+	_, err = service.SavePreferences(ctx, "42", models.NotificationPreferencesReq{Email: "jo@example.com", Phone: "+61400000000", SMSReadyAlert: true, EmailReadyAlert: true})
+	require.NoError(t, err)
+	sent.emails = nil
+	notified, err = service.OrderReady(ctx, order)
+	require.NoError(t, err)
+	assert.True(t, notified)
+	assert.Equal(t, []string{"jo@example.com|Your order 3F2A9C1E is ready"}, sent.emails, "texted and emailed")
+	assert.Len(t, sent.texts, 2)
+
+	notified, err = service.OrderPlaced(ctx, &models.Order{ID: order.ID})
+	require.NoError(t, err)
+	assert.False(t, notified, "guests have no preferences")
+}
+
+func TestNotificationService_Outbox(t *testing.T) {
+	ctx := context.Background()
+	email := &flakyEmailSender{failures: 1}
+	outbox := repository.NewMemoryNotificationOutboxRepository()
+	service := services.NewNotificationService(repository.NewMemoryNotificationRepository(), email, nil, services.NotificationOptions{
+		Outbox:      outbox,
+		MaxAttempts: 2,
+	})
+
+	_, err := service.SavePreferences(ctx, "42", models.NotificationPreferencesReq{Email: "jo@example.com", EmailReceipt: true})
+	require.NoError(t, err)
+
+	// Queued, not sent, so a failing provider doesn't hold the order back
+	notified, err := service.OrderPlaced(ctx, &models.Order{ID: "3f2a9c1e-0000-0000-0000-000000000000", CustomerID: "42"})
+	require.NoError(t, err)
+	assert.True(t, notified)
+	assert.Empty(t, email.emails)
+
+	result, err := service.DeliverBatch(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Failed)
+	require.Len(t, result.Errors, 1)
+	assert.Contains(t, result.Errors[0], "connection refused")
+
+	stats, err := service.GetDeliveryStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.Pending)
+	assert.Equal(t, 1, stats.Retrying)
+	assert.NotNil(t, stats.OldestPendingAt)
+
+	// Without backoff the retry is due right away
+	result, err = service.DeliverBatch(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Sent)
+	assert.Equal(t, []string{"jo@example.com|Your receipt for order 3F2A9C1E"}, email.emails)
+
+	result, err = service.DeliverBatch(ctx, 10)
+	require.NoError(t, err)
+	assert.Zero(t, result.Sent+result.Failed+result.Dead, "sent notifications aren't sent again")
+
+	stats, err = service.GetDeliveryStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, models.NotificationDeliveryStats{Sent: 1}, *stats)
+}
+
+func TestNotificationService_OutboxDeadLetters(t *testing.T) {
+	ctx := context.Background()
+	email := &flakyEmailSender{failures: 3}
+	outbox := repository.NewMemoryNotificationOutboxRepository()
+	service := services.NewNotificationService(repository.NewMemoryNotificationRepository(), email, nil, services.NotificationOptions{
+		Outbox:      outbox,
+		MaxAttempts: 2,
+	})
+
+	message := &models.NotificationMessage{
+		Channel:   models.NotificationChannelEmail,
+		Kind:      models.NotificationMarketing,
+		Recipient: "jo@example.com",
+		Subject:   "Half price Tuesdays",
+	}
+	require.NoError(t, outbox.Enqueue(ctx, message))
+
+	_, err := service.DeliverBatch(ctx, 10)
+	require.NoError(t, err)
+	result, err := service.DeliverBatch(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Dead, "dead-lettered after MaxAttempts")
+
+	stats, err := service.GetDeliveryStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, models.NotificationDeliveryStats{Dead: 1}, *stats)
+
+	result, err = service.DeliverBatch(ctx, 10)
+	require.NoError(t, err)
+	assert.Zero(t, result.Sent+result.Failed+result.Dead, "dead notifications aren't retried")
+
+	assert.Error(t, service.Redeliver(ctx, "unknown"))
+	require.NoError(t, service.Redeliver(ctx, message.ID))
+	assert.Error(t, service.Redeliver(ctx, message.ID), "only dead notifications are redelivered")
+
+	// Redelivered with fresh attempts, so the third failure leaves one retry
+	result, err = service.DeliverBatch(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Failed)
+	result, err = service.DeliverBatch(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Sent)
+}
+
+func TestNotificationService_WithoutOutbox(t *testing.T) {
+	service := services.NewNotificationService(repository.NewMemoryNotificationRepository(), nil, nil, services.NotificationOptions{})
+
+	result, err := service.DeliverBatch(context.Background(), 10)
+	require.NoError(t, err)
+	assert.Equal(t, models.NotificationDeliveryResult{}, *result)
+
+	_, err = service.GetDeliveryStats(context.Background())
+	assert.ErrorIs(t, err, services.ErrNotificationOutboxDisabled)
+}
+
+func TestNotificationService_Feed(t *testing.T) {
+	ctx := context.Background()
+	service := services.NewNotificationService(repository.NewMemoryNotificationRepository(), nil, nil, services.NotificationOptions{
+		Feed: repository.NewMemoryNotificationFeedRepository(),
+	})
+	order := &models.Order{ID: "3f2a9c1e-0000-0000-0000-000000000000", CustomerID: "42"}
+
+	// The feed doesn't wait for an email or phone, or a provider to send to them
+	notified, err := service.OrderPlaced(ctx, order)
+	require.NoError(t, err)
+	assert.False(t, notified)
+	_, err = service.OrderReady(ctx, order)
+	require.NoError(t, err)
+	_, err = service.OrderPlaced(ctx, &models.Order{ID: order.ID})
+	require.NoError(t, err, "guests have no feed")
+
+	feed, err := service.Feed(ctx, "42", false, models.PageRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, 2, feed.Total)
+	assert.Equal(t, 2, feed.Unread)
+	assert.Equal(t, models.DefaultPageLimit, feed.Limit)
+	require.Len(t, feed.Notifications, 2)
+	ready := feed.Notifications[0]
+	assert.Equal(t, models.FeedOrderReady, ready.Kind)
+	assert.Equal(t, "Your order 3F2A9C1E is ready.", ready.Body)
+	assert.Equal(t, order.ID, ready.OrderID)
+	assert.Nil(t, ready.ReadAt)
+	assert.Equal(t, models.FeedOrderPlaced, feed.Notifications[1].Kind)
+
+	require.NoError(t, service.MarkRead(ctx, "42", ready.ID))
+	assert.ErrorIs(t, service.MarkRead(ctx, "7", ready.ID), services.ErrFeedNotificationNotFound, "customers only read their own")
+	assert.ErrorIs(t, service.MarkRead(ctx, "42", "missing"), services.ErrFeedNotificationNotFound)
+
+	unread, err := service.Feed(ctx, "42", true, models.PageRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, unread.Total)
+	assert.Equal(t, 1, unread.Unread)
+	require.Len(t, unread.Notifications, 1)
+	assert.Equal(t, models.FeedOrderPlaced, unread.Notifications[0].Kind)
+
+	marked, err := service.MarkAllRead(ctx, "42")
+	require.NoError(t, err)
+	assert.Equal(t, 1, marked)
+
+	feed, err = service.Feed(ctx, "42", false, models.PageRequest{Limit: 1})
+	require.NoError(t, err)
+	assert.Equal(t, 2, feed.Total)
+	assert.Zero(t, feed.Unread)
+	require.Len(t, feed.Notifications, 1)
+	assert.NotNil(t, feed.Notifications[0].ReadAt)
+
+	empty, err := service.Feed(ctx, "7", false, models.PageRequest{})
+	require.NoError(t, err)
+	assert.NotNil(t, empty.Notifications, "an empty feed is listed as []")
+	assert.Zero(t, empty.Total)
+}
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"oolio/internal/app/models"
+	"oolio/internal/app/repository"
+	"oolio/internal/app/services"
+)
+
+func newOpeningHoursStores(t *testing.T, outsideHours string) services.StoreService {
+	t.Helper()
+	stores := services.NewStoreService(repository.NewMemoryStoreRepository())
+	_, err := stores.SetOpeningHours(context.Background(), models.DefaultStoreID, models.OpeningHours{
+		TimeZone: "Australia/Melbourne",
+		Weekly: []models.OpeningPeriod{
+			{Day: "Monday", Opens: "09:00", Closes: "17:00"},
+			{Day: "friday", Opens: "18:00", Closes: "02:00"},
+		},
+		Holidays: []models.HolidayHours{
+			{Date: "2026-12-28", Name: "Boxing Day (observed)"},
+		},
+		OutsideHours: outsideHours,
+	})
+	require.NoError(t, err)
+	return stores
+}
+
+func melbourne(t *testing.T, value string) time.Time {
+	t.Helper()
+	location, err := time.LoadLocation("Australia/Melbourne")
+	require.NoError(t, err)
+	at, err := time.ParseInLocation("2006-01-02 15:04", value, location)
+	require.NoError(t, err)
+	return at
+}
+
+func TestStoreService_ScheduleOrder(t *testing.T) {
+	ctx := context.Background()
+	stores := newOpeningHoursStores(t, models.OutsideHoursReject)
+
+	// Monday 2026-10-19 during the day and Friday night past midnight are open
+	for _, open := range []string{"2026-10-19 09:00", "2026-10-19 16:59", "2026-10-23 23:30", "2026-10-24 01:59"} {
+		scheduled, err := stores.ScheduleOrder(ctx, "", melbourne(t, open), nil)
+		require.NoError(t, err, open)
+		assert.Nil(t, scheduled, open)
+	}
+
+	var closed *services.StoreClosedError
+	_, err := stores.ScheduleOrder(ctx, "", melbourne(t, "2026-10-19 17:00"), nil)
+	require.ErrorAs(t, err, &closed)
+	assert.True(t, melbourne(t, "2026-10-23 18:00").Equal(*closed.NextOpenAt))
+	_, err = stores.ScheduleOrder(ctx, "", melbourne(t, "2026-10-24 02:00"), nil)
+	require.ErrorAs(t, err, &closed)
+	assert.True(t, melbourne(t, "2026-10-26 09:00").Equal(*closed.NextOpenAt))
+
+	// The holiday closes the Monday it falls on
+	_, err = stores.ScheduleOrder(ctx, "", melbourne(t, "2026-12-28 10:00"), nil)
+	require.ErrorAs(t, err, &closed)
+	assert.True(t, melbourne(t, "2027-01-01 18:00").Equal(*closed.NextOpenAt))
+}
+
+func TestStoreService_ScheduleOrder_Requested(t *testing.T) {
+	ctx := context.Background()
+	stores := newOpeningHoursStores(t, models.OutsideHoursReject)
+	now := melbourne(t, "2026-10-18 12:00")
+
+	requested := melbourne(t, "2026-10-19 12:30")
+	scheduled, err := stores.ScheduleOrder(ctx, "", now, &requested)
+	require.NoError(t, err)
+	assert.Equal(t, &requested, scheduled)
+
+	requested = melbourne(t, "2026-10-19 18:00")
+	var closed *services.StoreClosedError
+	_, err = stores.ScheduleOrder(ctx, "", now, &requested)
+	require.ErrorAs(t, err, &closed, "a requested time is never moved")
+
+	past := now.Add(-time.Minute)
+	_, err = stores.ScheduleOrder(ctx, "", now, &past)
+	assert.ErrorIs(t, err, services.ErrInvalidSchedule)
+	later := now.AddDate(0, 2, 0)
+	_, err = stores.ScheduleOrder(ctx, "", now, &later)
+	assert.ErrorIs(t, err, services.ErrInvalidSchedule)
+}
+
+func TestStoreService_ScheduleOrder_OutsideHours(t *testing.T) {
+	ctx := context.Background()
+	stores := newOpeningHoursStores(t, models.OutsideHoursSchedule)
+
+	scheduled, err := stores.ScheduleOrder(ctx, "", melbourne(t, "2026-10-19 20:00"), nil)
+	require.NoError(t, err)
+	require.NotNil(t, scheduled)
+	assert.True(t, melbourne(t, "2026-10-23 18:00").Equal(*scheduled))
+
+	// Stores without opening hours are always open
+	require.NoError(t, stores.DeleteOpeningHours(ctx, models.DefaultStoreID))
+	scheduled, err = stores.ScheduleOrder(ctx, "", melbourne(t, "2026-10-19 20:00"), nil)
+	require.NoError(t, err)
+	assert.Nil(t, scheduled)
+}
+
+func TestStoreService_SetOpeningHours_Invalid(t *testing.T) {
+	ctx := context.Background()
+	stores := services.NewStoreService(repository.NewMemoryStoreRepository())
+
+	for name, hours := range map[string]models.OpeningHours{
+		"time zone":     {TimeZone: "Mars/Olympus"},
+		"day":           {TimeZone: "UTC", Weekly: []models.OpeningPeriod{{Day: "someday", Opens: "09:00", Closes: "17:00"}}},
+		"clock":         {TimeZone: "UTC", Weekly: []models.OpeningPeriod{{Day: "monday", Opens: "9am", Closes: "17:00"}}},
+		"outside hours": {TimeZone: "UTC", OutsideHours: "queue"},
+		"holiday date":  {TimeZone: "UTC", Holidays: []models.HolidayHours{{Date: "25/12/2026"}}},
+		"holiday both": {TimeZone: "UTC", Holidays: []models.HolidayHours{
+			{Date: "2026-12-25"}, {Date: "2026-12-25", Opens: "10:00", Closes: "14:00"},
+		}},
+	} {
+		_, err := stores.SetOpeningHours(ctx, models.DefaultStoreID, hours)
+		assert.ErrorIs(t, err, services.ErrInvalidOpeningHours, name)
+	}
+
+	hours, err := stores.SetOpeningHours(ctx, models.DefaultStoreID, models.OpeningHours{TimeZone: "UTC"})
+	require.NoError(t, err)
+	assert.Equal(t, models.OutsideHoursReject, hours.OutsideHours)
+	_, err = stores.SetOpeningHours(ctx, "missing", models.OpeningHours{TimeZone: "UTC"})
+	assert.ErrorContains(t, err, "store not found")
+}
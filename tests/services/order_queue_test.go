@@ -0,0 +1,127 @@
+package services
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"oolio/internal/app/models"
+	"oolio/internal/app/repository"
+	"oolio/internal/app/services"
+)
+
+func TestRetryLinks(t *testing.T) {
+	assert.Nil(t, services.NewRetryLinks("", "https://api.example.com", time.Hour), "links need a secret")
+	assert.Empty(t, (*services.RetryLinks)(nil).URL("q1"))
+
+	links := services.NewRetryLinks("secret", "https://api.example.com/", time.Hour)
+	link, err := url.Parse(links.URL("q1"))
+	require.NoError(t, err)
+	assert.Equal(t, "https://api.example.com/api/v1/queue/q1/retry", link.Scheme+"://"+link.Host+link.Path)
+
+	expires, signature := link.Query().Get("expires"), link.Query().Get("signature")
+	assert.True(t, links.Valid("q1", expires, signature))
+	assert.False(t, links.Valid("q2", expires, signature), "a link only retries its own item")
+	assert.False(t, links.Valid("q1", expires+"0", signature), "the expiry is signed")
+	assert.False(t, services.NewRetryLinks("other", "https://api.example.com", time.Hour).Valid("q1", expires, signature))
+
+	expired := services.NewRetryLinks("secret", "https://api.example.com", -time.Minute)
+	link, err = url.Parse(expired.URL("q1"))
+	require.NoError(t, err)
+	assert.False(t, expired.Valid("q1", link.Query().Get("expires"), link.Query().Get("signature")))
+}
+
+func TestOrderQueue_AlertsFailedOrderWithHistory(t *testing.T) {
+	ctx := context.Background()
+	orders := repository.NewMemoryOrderRepository()
+	queueRepo := repository.NewMemoryOrderQueueRepository()
+	orderService := services.NewOrderService(orders, repository.NewMemoryProductRepository(), queueRepo, nil, nil, nil, nil, nil, nil, nil, nil, zap.NewNop())
+	alerter := &recordingAlerter{}
+	links := services.NewRetryLinks("secret", "https://api.example.com", time.Hour)
+	queue := services.NewOrderQueueService(services.OrderQueueDeps{
+		QueueRepo:    queueRepo,
+		OrderRepo:    orders,
+		OrderService: orderService,
+		Alerter:      alerter,
+		RetryLinks:   links,
+	}, nil, "test-worker")
+
+	item, err := queue.AddOrderToQueue(ctx, &models.OrderReq{Items: []models.OrderItem{{ProductID: "no-such-product", Quantity: 2}}})
+	require.NoError(t, err)
+	for range 3 {
+		stored, err := queueRepo.GetOrderFromQueue(ctx, item.ID)
+		require.NoError(t, err)
+		// Saving a failed item records another failure, so the retry is made due as pending
+		stored.Status, stored.NextAttemptAt = "pending", time.Now()
+		require.NoError(t, queueRepo.UpdateItem(ctx, stored))
+		_, err = queue.ProcessBatch(ctx, 10)
+		require.NoError(t, err)
+	}
+
+	require.Len(t, alerter.Alerts(), 1)
+	alert := alerter.Alerts()[0]
+	assert.Equal(t, "Order failed permanently", alert.Title)
+	assert.Contains(t, alert.Text, "queue retry "+item.ID)
+	assert.Contains(t, alert.Text, "https://api.example.com/api/v1/queue/"+item.ID+"/retry?")
+	assert.Contains(t, alert.Text, `"productId":"no-such-product"`, "the alert quotes the order request")
+	for _, attempt := range []string{"\n1. ", "\n2. ", "\n3. "} {
+		assert.Contains(t, alert.Text, attempt)
+	}
+	assert.Equal(t, 3, strings.Count(alert.Text, " "+models.QueueErrorOrderFailed+": "), "each attempt is listed with its error")
+
+	details, err := queue.Inspect(ctx, item.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "failed", details.Status)
+	assert.Equal(t, "no-such-product", details.OrderReq.Items[0].ProductID)
+	assert.Equal(t, "test-worker", details.ClaimedBy)
+	assert.NotNil(t, details.ClaimedAt)
+	assert.Empty(t, details.OrderID, "the order was never created")
+	require.Len(t, details.Attempts, 3)
+	for i, attempt := range details.Attempts {
+		assert.Equal(t, i+1, attempt.Attempt)
+		assert.Equal(t, models.QueueErrorOrderFailed, attempt.ErrorCode)
+		assert.NotEmpty(t, attempt.Error)
+		assert.False(t, attempt.At.IsZero())
+	}
+	_, err = queue.Inspect(ctx, "00000000-0000-0000-0000-000000000000")
+	assert.ErrorIs(t, err, services.ErrQueueItemNotFound)
+
+	require.NoError(t, queue.Retry(ctx, item.ID))
+	requeued, err := queueRepo.GetOrderFromQueue(ctx, item.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "pending", requeued.Status)
+	assert.ErrorIs(t, queue.Retry(ctx, item.ID), services.ErrQueueItemNotFailed, "only failed items are retried")
+}
+
+func TestOrderQueue_HoldsScheduledOrders(t *testing.T) {
+	ctx := context.Background()
+	queueRepo := repository.NewMemoryOrderQueueRepository()
+	queue := services.NewOrderQueueService(services.OrderQueueDeps{
+		QueueRepo: queueRepo,
+		OrderRepo: repository.NewMemoryOrderRepository(),
+	}, nil, "test-worker")
+
+	scheduledFor := time.Now().Add(time.Hour)
+	item, err := queue.AddOrderToQueue(ctx, &models.OrderReq{
+		Items:        []models.OrderItem{{ProductID: "p1", Quantity: 1}},
+		ScheduledFor: &scheduledFor,
+	})
+	require.NoError(t, err)
+	assert.True(t, scheduledFor.Equal(item.NextAttemptAt))
+
+	pending, err := queueRepo.GetPendingItems(ctx, 10)
+	require.NoError(t, err)
+	assert.Empty(t, pending, "the order waits until its scheduled time")
+
+	// Paying for it doesn't bring it forward
+	require.NoError(t, queueRepo.SetPaymentIntent(ctx, item.ID, "pi_1"))
+	stored, err := queueRepo.GetOrderFromQueue(ctx, item.ID)
+	require.NoError(t, err)
+	assert.True(t, scheduledFor.Equal(stored.NextAttemptAt))
+}
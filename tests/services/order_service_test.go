@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+
+	"oolio/internal/app/models"
+	"oolio/internal/app/repository"
+	"oolio/internal/app/services"
+)
+
+func TestOrderService_DeleteOrder(t *testing.T) {
+	ctx := context.Background()
+	orderRepo := repository.NewMemoryOrderRepository()
+	queueRepo := repository.NewMemoryOrderQueueRepository()
+	core, audit := observer.New(zap.InfoLevel)
+	service := services.NewOrderService(orderRepo, repository.NewMemoryProductRepository(), queueRepo, nil, nil, nil, nil, nil, nil, nil, nil, zap.New(core))
+
+	failed := &models.OrderQueueItem{
+		ID:         uuid.New().String(),
+		OrderReq:   models.OrderReq{CouponCode: "HAPPYHRS", Items: []models.OrderItem{{ProductID: "p1", Quantity: 1}}},
+		Status:     "failed",
+		Error:      "boom",
+		RetryCount: 3,
+		CreatedAt:  time.Now(),
+	}
+	retrying := &models.OrderQueueItem{ID: uuid.New().String(), Status: "failed", RetryCount: 1, CreatedAt: time.Now()}
+	require.NoError(t, queueRepo.AddToQueue(ctx, failed))
+	require.NoError(t, queueRepo.AddToQueue(ctx, retrying))
+
+	order := &models.Order{Total: 1000}
+	require.NoError(t, orderRepo.Create(ctx, order))
+
+	// Completed/pending orders and items that will still be retried are kept
+	assert.ErrorIs(t, service.DeleteOrder(ctx, order.ID, "127.0.0.1"), services.ErrOrderNotDeletable)
+	assert.ErrorIs(t, service.DeleteOrder(ctx, retrying.ID, "127.0.0.1"), services.ErrOrderNotDeletable)
+	assert.ErrorContains(t, service.DeleteOrder(ctx, uuid.New().String(), "127.0.0.1"), "order not found")
+	assert.ErrorContains(t, service.DeleteOrder(ctx, "not-a-uuid", "127.0.0.1"), "order not found")
+
+	require.NoError(t, service.DeleteOrder(ctx, failed.ID, "127.0.0.1"))
+
+	stored, err := queueRepo.GetOrderFromQueue(ctx, failed.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "failed", stored.Status)
+	assert.Empty(t, stored.OrderReq.Items)
+	assert.Empty(t, stored.OrderReq.CouponCode)
+	assert.Empty(t, stored.Error)
+
+	entries := audit.FilterMessage("Order deleted").All()
+	require.Len(t, entries, 1)
+	assert.Equal(t, failed.ID, entries[0].ContextMap()["orderId"])
+	assert.Equal(t, "127.0.0.1", entries[0].ContextMap()["actor"])
+}
+
+func TestOrderService_ListOrders(t *testing.T) {
+	ctx := context.Background()
+	orderRepo := repository.NewMemoryOrderRepository()
+	service := services.NewOrderService(orderRepo, repository.NewMemoryProductRepository(), repository.NewMemoryOrderQueueRepository(), nil, nil, nil, nil, nil, nil, nil, nil, zap.NewNop())
+
+	for range 3 {
+		require.NoError(t, orderRepo.Create(ctx, &models.Order{
+			Total:    1000,
+			Items:    []models.OrderItem{{ProductID: "p1", Quantity: 1, Price: 1000}},
+			Products: []models.Product{{ID: "p1", Name: "Waffle", Price: 1000}},
+		}))
+	}
+
+	orders, total, err := service.ListOrders(ctx, models.PageRequest{Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+	require.Len(t, orders, 2)
+	assert.Len(t, orders[0].Items, 1)
+	assert.Len(t, orders[0].Products, 1)
+}
+
+func TestOrderService_QuoteOrder_LoadsProductsOnce(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := &MockProductRepository{}
+	service := services.NewOrderService(repository.NewMemoryOrderRepository(), mockRepo, repository.NewMemoryOrderQueueRepository(), nil, nil, nil, nil, nil, nil, nil, nil, zap.NewNop())
+
+	waffle := models.Product{ID: uuid.New().String(), Name: "Waffle", Price: 650}
+	fries := models.Product{ID: uuid.New().String(), Name: "Fries", Price: 400}
+	mockRepo.On("FindByIDs", ctx, []string{waffle.ID, fries.ID}).Return([]models.Product{fries, waffle}, nil).Once()
+
+	// Repeated lines of a product are priced each time but looked up once
+	order, err := service.QuoteOrder(ctx, &models.OrderReq{Items: []models.OrderItem{
+		{ProductID: waffle.ID, Quantity: 1},
+		{ProductID: fries.ID, Quantity: 2},
+		{ProductID: waffle.ID, Quantity: 3},
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, models.Money(4*650+2*400), order.Total)
+	mockRepo.AssertExpectations(t)
+
+	missing := uuid.New().String()
+	mockRepo.On("FindByIDs", ctx, []string{waffle.ID, missing}).Return([]models.Product{waffle}, nil).Once()
+	_, err = service.QuoteOrder(ctx, &models.OrderReq{Items: []models.OrderItem{{ProductID: waffle.ID, Quantity: 1}, {ProductID: missing, Quantity: 1}}})
+	assert.ErrorContains(t, err, "product not found")
+}
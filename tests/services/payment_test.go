@@ -0,0 +1,506 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"oolio/internal/app/models"
+	"oolio/internal/app/repository"
+	"oolio/internal/app/services"
+)
+
+func TestMockPaymentService(t *testing.T) {
+	ctx := context.Background()
+	payments := services.NewMockPaymentService("aud")
+
+	intent, err := payments.CreateIntent(ctx, 2598, nil)
+	require.NoError(t, err)
+	assert.True(t, intent.Authorized())
+	assert.Equal(t, "aud", intent.Currency)
+	assert.NotEmpty(t, intent.ClientSecret)
+
+	_, err = payments.Refund(ctx, intent.ID, 0)
+	assert.ErrorIs(t, err, services.ErrPaymentRejected, "nothing is captured yet")
+	_, err = payments.Capture(ctx, intent.ID, 2599)
+	assert.ErrorIs(t, err, services.ErrPaymentRejected, "more than was authorized")
+
+	captured, err := payments.Capture(ctx, intent.ID, 2000)
+	require.NoError(t, err)
+	assert.Equal(t, models.Money(2000), captured.AmountCaptured)
+	assert.Equal(t, models.PaymentStatusSucceeded, captured.Status)
+
+	refund, err := payments.Refund(ctx, intent.ID, 500)
+	require.NoError(t, err)
+	assert.Equal(t, models.Money(500), refund.Amount)
+	refund, err = payments.Refund(ctx, intent.ID, 0)
+	require.NoError(t, err)
+	assert.Equal(t, models.Money(1500), refund.Amount, "zero refunds the rest")
+
+	_, err = payments.GetIntent(ctx, "pi_unknown")
+	assert.EqualError(t, err, "payment intent not found")
+}
+
+func TestStripePaymentService(t *testing.T) {
+	ctx := context.Background()
+	var captureForm string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer sk_test_123", r.Header.Get("Authorization"))
+		require.NoError(t, r.ParseForm())
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v1/payment_intents":
+			assert.Equal(t, "2598", r.PostForm.Get("amount"))
+			assert.Equal(t, "manual", r.PostForm.Get("capture_method"))
+			assert.Equal(t, "42", r.PostForm.Get("metadata[customerId]"))
+			w.Write([]byte(`{"id":"pi_1","client_secret":"pi_1_secret","amount":2598,"currency":"aud","status":"requires_payment_method"}`))
+		case "/v1/payment_intents/pi_1/capture":
+			captureForm = r.PostForm.Encode()
+			w.Write([]byte(`{"id":"pi_1","amount":2598,"amount_received":2598,"currency":"aud","status":"succeeded"}`))
+		case "/v1/payment_intents/pi_2/capture":
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":{"code":"payment_intent_unexpected_state","message":"This PaymentIntent could not be captured"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":{"code":"resource_missing","message":"No such payment_intent"}}`))
+		}
+	}))
+	defer server.Close()
+
+	payments := services.NewStripePaymentService(services.StripeOptions{SecretKey: "sk_test_123", Currency: "aud", BaseURL: server.URL})
+
+	intent, err := payments.CreateIntent(ctx, 2598, map[string]string{"customerId": "42"})
+	require.NoError(t, err)
+	assert.Equal(t, "pi_1_secret", intent.ClientSecret)
+	assert.False(t, intent.Authorized(), "the customer hasn't paid yet")
+
+	captured, err := payments.Capture(ctx, "pi_1", 0)
+	require.NoError(t, err)
+	assert.Equal(t, models.Money(2598), captured.AmountCaptured)
+	assert.Empty(t, captureForm, "zero captures the whole authorization")
+
+	_, err = payments.Capture(ctx, "pi_2", 0)
+	assert.ErrorIs(t, err, services.ErrPaymentRejected)
+	assert.Contains(t, err.Error(), "could not be captured")
+
+	_, err = payments.GetIntent(ctx, "pi_unknown")
+	assert.EqualError(t, err, "payment intent not found")
+}
+
+func TestOrderService_CapturesPayment(t *testing.T) {
+	ctx := context.Background()
+	products := repository.NewMemoryProductRepository()
+	seeded, err := products.Find(ctx)
+	require.NoError(t, err)
+	require.NotEmpty(t, seeded)
+
+	payments := services.NewMockPaymentService("aud")
+	service := services.NewOrderService(repository.NewMemoryOrderRepository(), products, repository.NewMemoryOrderQueueRepository(), nil, nil, nil, nil, payments, nil, nil, nil, zap.NewNop())
+
+	orderReq := func(intentID string) *models.OrderReq {
+		return &models.OrderReq{
+			PaymentIntentID: intentID,
+			Items:           []models.OrderItem{{ProductID: seeded[0].ID, Quantity: 2}},
+		}
+	}
+
+	short, err := payments.CreateIntent(ctx, seeded[0].Price, nil)
+	require.NoError(t, err)
+	_, err = service.CreateOrder(ctx, orderReq(short.ID))
+	assert.ErrorIs(t, err, services.ErrPaymentNotAuthorized, "the intent covers one of two items")
+
+	intent, err := payments.CreateIntent(ctx, seeded[0].Price.Mul(2), nil)
+	require.NoError(t, err)
+	_, err = service.CreateOrder(ctx, orderReq(intent.ID))
+	require.NoError(t, err)
+
+	captured, err := payments.GetIntent(ctx, intent.ID)
+	require.NoError(t, err)
+	assert.Equal(t, seeded[0].Price.Mul(2), captured.AmountCaptured)
+
+	_, err = service.CreateOrder(ctx, orderReq(intent.ID))
+	assert.ErrorIs(t, err, services.ErrPaymentNotAuthorized, "a captured intent can't pay for another order")
+}
+
+func TestOrderService_RefundOrder(t *testing.T) {
+	ctx := context.Background()
+	products := repository.NewMemoryProductRepository()
+	seeded, err := products.Find(ctx)
+	require.NoError(t, err)
+	require.NotEmpty(t, seeded)
+
+	payments := services.NewMockPaymentService("aud")
+	orders := repository.NewMemoryOrderRepository()
+	service := services.NewOrderService(orders, products, repository.NewMemoryOrderQueueRepository(), nil, nil, nil, nil, payments, nil, nil, nil, zap.NewNop())
+
+	unpaid, err := service.CreateOrder(ctx, &models.OrderReq{Items: []models.OrderItem{{ProductID: seeded[0].ID, Quantity: 1}}})
+	require.NoError(t, err)
+	_, err = service.RefundOrder(ctx, unpaid.ID, 0, "admin")
+	assert.ErrorIs(t, err, services.ErrOrderNotRefundable)
+
+	due := seeded[0].Price.Mul(2)
+	intent, err := payments.CreateIntent(ctx, due, nil)
+	require.NoError(t, err)
+	order, err := service.CreateOrder(ctx, &models.OrderReq{
+		PaymentIntentID: intent.ID,
+		Items:           []models.OrderItem{{ProductID: seeded[0].ID, Quantity: 2}},
+	})
+	require.NoError(t, err)
+
+	partial, err := service.RefundOrder(ctx, order.ID, seeded[0].Price, "admin")
+	require.NoError(t, err)
+	assert.Equal(t, models.RefundStatusProcessed, partial.Status)
+	assert.NotEmpty(t, partial.ProviderRefundID)
+	stored, err := orders.FindOne(ctx, order.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.OrderPaymentCaptured, stored.PaymentStatus, "half is left")
+
+	_, err = service.RefundOrder(ctx, order.ID, due, "admin")
+	assert.ErrorIs(t, err, services.ErrRefundExceedsPayment)
+
+	rest, err := service.RefundOrder(ctx, order.ID, 0, "admin")
+	require.NoError(t, err)
+	assert.Equal(t, due-seeded[0].Price, rest.Amount, "zero refunds the rest")
+	stored, err = orders.FindOne(ctx, order.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.OrderPaymentRefunded, stored.PaymentStatus)
+
+	refunds, err := service.GetRefunds(ctx, order.ID)
+	require.NoError(t, err)
+	assert.Len(t, refunds, 2)
+}
+
+func TestOrderService_RefundOrderFails(t *testing.T) {
+	ctx := context.Background()
+	products := repository.NewMemoryProductRepository()
+	seeded, err := products.Find(ctx)
+	require.NoError(t, err)
+	require.NotEmpty(t, seeded)
+
+	payments := unrefundablePayments{services.NewMockPaymentService("aud")}
+	service := services.NewOrderService(repository.NewMemoryOrderRepository(), products, repository.NewMemoryOrderQueueRepository(), nil, nil, nil, nil, payments, nil, nil, nil, zap.NewNop())
+
+	intent, err := payments.CreateIntent(ctx, seeded[0].Price, nil)
+	require.NoError(t, err)
+	order, err := service.CreateOrder(ctx, &models.OrderReq{
+		PaymentIntentID: intent.ID,
+		Items:           []models.OrderItem{{ProductID: seeded[0].ID, Quantity: 1}},
+	})
+	require.NoError(t, err)
+
+	refund, err := service.RefundOrder(ctx, order.ID, 0, "admin")
+	assert.ErrorIs(t, err, services.ErrPaymentRejected)
+	require.NotNil(t, refund)
+	assert.Equal(t, models.RefundStatusFailed, refund.Status)
+	assert.Contains(t, refund.Error, "charge_disputed")
+
+	// A failed refund leaves the payment to refund again
+	refunds, err := service.GetRefunds(ctx, order.ID)
+	require.NoError(t, err)
+	require.Len(t, refunds, 1)
+	_, err = service.RefundOrder(ctx, order.ID, 0, "admin")
+	assert.ErrorIs(t, err, services.ErrPaymentRejected, "not ErrRefundExceedsPayment")
+}
+
+// unrefundablePayments takes payments like the mock provider but rejects every refund
+type unrefundablePayments struct {
+	services.PaymentService
+}
+
+func (p unrefundablePayments) Refund(ctx context.Context, id string, amount models.Money) (*models.PaymentRefund, error) {
+	return nil, fmt.Errorf("%w: charge_disputed", services.ErrPaymentRejected)
+}
+
+// fulfilledOrders records the orders it was asked to fulfill
+type fulfilledOrders struct {
+	ids []string
+}
+
+func (f *fulfilledOrders) Fulfill(ctx context.Context, order *models.Order) error {
+	f.ids = append(f.ids, order.ID)
+	return nil
+}
+
+// uncapturablePayments authorizes intents like the mock provider but fails every capture
+type uncapturablePayments struct {
+	services.PaymentService
+}
+
+func (p uncapturablePayments) Capture(ctx context.Context, id string, amount models.Money) (*models.PaymentIntent, error) {
+	return nil, fmt.Errorf("stripe request failed: 500 Internal Server Error")
+}
+
+// flakyCaptures authorizes intents like the mock provider but fails the first captures
+// with err
+type flakyCaptures struct {
+	services.PaymentService
+	failures int
+	err      error
+}
+
+func (p *flakyCaptures) Capture(ctx context.Context, id string, amount models.Money) (*models.PaymentIntent, error) {
+	if p.failures > 0 {
+		p.failures--
+		return nil, p.err
+	}
+	return p.PaymentService.Capture(ctx, id, amount)
+}
+
+func TestOrderQueue_RetriesPaymentCapture(t *testing.T) {
+	ctx := context.Background()
+	products := repository.NewMemoryProductRepository()
+	seeded, err := products.Find(ctx)
+	require.NoError(t, err)
+	require.NotEmpty(t, seeded)
+
+	// process runs the queue once the failed item's retry delay has passed
+	process := func(t *testing.T, queue services.OrderQueueService, queueRepo repository.OrderQueueRepository, itemID string) *models.BatchProcessResult {
+		item, err := queueRepo.GetOrderFromQueue(ctx, itemID)
+		require.NoError(t, err)
+		item.NextAttemptAt = time.Now()
+		require.NoError(t, queueRepo.UpdateItem(ctx, item))
+		result, err := queue.ProcessBatch(ctx, 10)
+		require.NoError(t, err)
+		return result
+	}
+	run := func(t *testing.T, failures int, captureErr error) (*models.OrderQueueItem, func() *models.BatchProcessResult, repository.OrderRepository, *fulfilledOrders, *recordingAlerter) {
+		payments := &flakyCaptures{PaymentService: services.NewMockPaymentService("aud"), failures: failures, err: captureErr}
+		orders := repository.NewMemoryOrderRepository()
+		queueRepo := repository.NewMemoryOrderQueueRepository()
+		orderService := services.NewOrderService(orders, products, queueRepo, nil, nil, nil, nil, payments, nil, nil, nil, zap.NewNop())
+		fulfilled, alerter := &fulfilledOrders{}, &recordingAlerter{}
+		queue := services.NewOrderQueueService(services.OrderQueueDeps{
+			QueueRepo:    queueRepo,
+			OrderRepo:    orders,
+			OrderService: orderService,
+			Fulfillment:  fulfilled,
+			Alerter:      alerter,
+		}, nil, "test-worker")
+
+		intent, err := payments.CreateIntent(ctx, seeded[0].Price, nil)
+		require.NoError(t, err)
+		item, err := queue.AddOrderToQueue(ctx, &models.OrderReq{
+			PaymentIntentID: intent.ID,
+			Items:           []models.OrderItem{{ProductID: seeded[0].ID, Quantity: 1}},
+		})
+		require.NoError(t, err)
+		return item, func() *models.BatchProcessResult { return process(t, queue, queueRepo, item.ID) }, orders, fulfilled, alerter
+	}
+
+	t.Run("captured on retry", func(t *testing.T) {
+		_, next, orders, fulfilled, alerter := run(t, 1, fmt.Errorf("stripe request failed: 503 Service Unavailable"))
+		assert.Equal(t, 1, next().PaymentFailed)
+		assert.Equal(t, 1, next().Processed)
+
+		_, total, err := orders.FindPage(ctx, models.PageRequest{Limit: 10})
+		require.NoError(t, err)
+		assert.Equal(t, 1, total, "the order is created once")
+		stored, err := orders.FindOne(ctx, fulfilled.ids[0])
+		require.NoError(t, err)
+		assert.Equal(t, models.OrderPaymentCaptured, stored.PaymentStatus)
+		assert.Len(t, fulfilled.ids, 1)
+		assert.Empty(t, alerter.Alerts())
+	})
+
+	t.Run("retries exhausted", func(t *testing.T) {
+		item, next, _, fulfilled, alerter := run(t, 3, fmt.Errorf("stripe request failed: 503 Service Unavailable"))
+		for range 3 {
+			assert.Equal(t, 1, next().PaymentFailed)
+		}
+		assert.Empty(t, next().Items, "no retries are left")
+		assert.Empty(t, fulfilled.ids)
+		require.Len(t, alerter.Alerts(), 1)
+		assert.Contains(t, alerter.Alerts()[0].Text, item.OrderReq.PaymentIntentID)
+		assert.Contains(t, alerter.Alerts()[0].Text, "queue retry "+item.ID)
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		_, next, _, fulfilled, alerter := run(t, 1, fmt.Errorf("%w: intent is canceled", services.ErrPaymentRejected))
+		assert.Equal(t, 1, next().PaymentFailed)
+		assert.Empty(t, next().Items, "a rejected capture isn't retried")
+		assert.Empty(t, fulfilled.ids)
+		assert.Len(t, alerter.Alerts(), 1)
+	})
+}
+
+func TestOrderQueue_WaitsForPayment(t *testing.T) {
+	ctx := context.Background()
+	products := repository.NewMemoryProductRepository()
+	seeded, err := products.Find(ctx)
+	require.NoError(t, err)
+	require.NotEmpty(t, seeded)
+
+	newQueue := func(payments services.PaymentService, window time.Duration) (services.OrderQueueService, *fulfilledOrders, *recordingAlerter) {
+		orders := repository.NewMemoryOrderRepository()
+		queueRepo := repository.NewMemoryOrderQueueRepository()
+		orderService := services.NewOrderService(orders, products, queueRepo, nil, nil, nil, nil, payments, nil, nil, nil, zap.NewNop())
+		fulfilled, alerter := &fulfilledOrders{}, &recordingAlerter{}
+		queue := services.NewOrderQueueService(services.OrderQueueDeps{
+			QueueRepo:    queueRepo,
+			OrderRepo:    orders,
+			OrderService: orderService,
+			Fulfillment:  fulfilled,
+			Alerter:      alerter,
+			Payment:      services.PaymentPolicy{Required: true, Window: window},
+		}, nil, "test-worker")
+		return queue, fulfilled, alerter
+	}
+	orderReq := &models.OrderReq{Items: []models.OrderItem{{ProductID: seeded[0].ID, Quantity: 1}}}
+
+	t.Run("paid", func(t *testing.T) {
+		payments := services.NewMockPaymentService("aud")
+		queue, fulfilled, _ := newQueue(payments, time.Hour)
+		item, err := queue.AddOrderToQueue(ctx, orderReq)
+		require.NoError(t, err)
+
+		result, err := queue.ProcessBatch(ctx, 10)
+		require.NoError(t, err)
+		assert.Equal(t, 1, result.AwaitingPayment)
+		assert.Zero(t, result.Failed)
+		waiting, err := queue.GetOrderFromQueue(ctx, item.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "pending", waiting.Status)
+		assert.Equal(t, services.ErrPaymentRequired.Error(), waiting.Error)
+
+		intent, err := payments.CreateIntent(ctx, seeded[0].Price, nil)
+		require.NoError(t, err)
+		require.NoError(t, queue.AttachPayment(ctx, item.ID, intent.ID))
+
+		result, err = queue.ProcessBatch(ctx, 10)
+		require.NoError(t, err)
+		assert.Equal(t, 1, result.Processed)
+		completed, err := queue.GetOrderFromQueue(ctx, item.ID)
+		require.NoError(t, err)
+		require.NotNil(t, completed.Order)
+		assert.Equal(t, intent.ID, completed.Order.PaymentIntentID)
+		assert.Equal(t, models.OrderPaymentCaptured, completed.Order.PaymentStatus)
+		assert.Equal(t, []string{completed.Order.ID}, fulfilled.ids)
+
+		assert.ErrorIs(t, queue.AttachPayment(ctx, item.ID, intent.ID), services.ErrOrderNotPayable)
+	})
+
+	t.Run("not captured", func(t *testing.T) {
+		payments := uncapturablePayments{services.NewMockPaymentService("aud")}
+		queue, fulfilled, alerter := newQueue(payments, time.Hour)
+		intent, err := payments.CreateIntent(ctx, seeded[0].Price, nil)
+		require.NoError(t, err)
+		paid := *orderReq
+		paid.PaymentIntentID = intent.ID
+		item, err := queue.AddOrderToQueue(ctx, &paid)
+		require.NoError(t, err)
+
+		result, err := queue.ProcessBatch(ctx, 10)
+		require.NoError(t, err)
+		assert.Equal(t, 1, result.PaymentFailed)
+		assert.Zero(t, result.Failed)
+		failed, err := queue.GetOrderFromQueue(ctx, item.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "failed", failed.Status)
+		assert.Equal(t, models.QueueErrorCaptureFailed, failed.ErrorCode)
+		require.NotNil(t, failed.Order, "the order stands")
+		assert.Equal(t, models.OrderPaymentAuthorized, failed.Order.PaymentStatus)
+		assert.Empty(t, fulfilled.ids)
+		assert.Empty(t, alerter.Alerts(), "the capture is retried")
+		assert.ErrorIs(t, queue.AttachPayment(ctx, item.ID, intent.ID), services.ErrOrderNotPayable)
+	})
+
+	t.Run("window passed", func(t *testing.T) {
+		queue, _, _ := newQueue(services.NewMockPaymentService("aud"), time.Nanosecond)
+		item, err := queue.AddOrderToQueue(ctx, orderReq)
+		require.NoError(t, err)
+
+		result, err := queue.ProcessBatch(ctx, 10)
+		require.NoError(t, err)
+		assert.Equal(t, 1, result.Failed)
+		failed, err := queue.GetOrderFromQueue(ctx, item.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "failed", failed.Status)
+		assert.Equal(t, services.ErrPaymentExpired.Error(), failed.Error)
+		assert.Equal(t, models.QueueErrorPaymentExpired, failed.ErrorCode)
+		assert.ErrorIs(t, queue.AttachPayment(ctx, item.ID, "pi_late"), services.ErrOrderNotPayable)
+	})
+}
+
+func TestOrderQueue_ProcessesSandboxOrders(t *testing.T) {
+	ctx := context.Background()
+	products := repository.NewMemoryProductRepository()
+	seeded, err := products.Find(ctx)
+	require.NoError(t, err)
+	require.NotEmpty(t, seeded)
+
+	orders := repository.NewMemoryOrderRepository()
+	queueRepo := repository.NewMemoryOrderQueueRepository()
+	orderService := services.NewOrderService(orders, products, queueRepo, nil, nil, nil, nil, services.NewMockPaymentService("aud"), nil, nil, nil, zap.NewNop())
+	fulfilled := &fulfilledOrders{}
+	queue := services.NewOrderQueueService(services.OrderQueueDeps{
+		QueueRepo:    queueRepo,
+		OrderRepo:    orders,
+		OrderService: orderService,
+		Fulfillment:  fulfilled,
+		Payment:      services.PaymentPolicy{Required: true},
+	}, nil, "test-worker")
+
+	item, err := queue.AddOrderToQueue(ctx, &models.OrderReq{
+		Sandbox: true,
+		Items:   []models.OrderItem{{ProductID: seeded[0].ID, Quantity: 1}},
+	})
+	require.NoError(t, err)
+	backlog, err := queue.GetBacklog(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, backlog.Pending, "sandbox items are left out of the backlog")
+
+	result, err := queue.ProcessBatch(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Processed, "sandbox orders don't wait for payment")
+	completed, err := queue.GetOrderFromQueue(ctx, item.ID)
+	require.NoError(t, err)
+	require.NotNil(t, completed.Order)
+	assert.True(t, completed.Order.Sandbox)
+	assert.Empty(t, completed.Order.PaymentStatus)
+	assert.Empty(t, fulfilled.ids, "sandbox orders aren't fulfilled")
+
+	stats, err := queue.GetQueueStatus(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, stats["completed"], "sandbox items are left out of the stats")
+}
+
+func TestPaymentPolicy_CheckMethod(t *testing.T) {
+	_, err := services.NewPaymentPolicy(false, 0, []string{"card", "cheque"})
+	assert.ErrorContains(t, err, `unknown payment method "cheque"`)
+
+	policy, err := services.NewPaymentPolicy(true, 0, []string{" Cash", "counter", "cash"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cash", "counter"}, policy.Methods)
+
+	delivery := &models.DeliveryAddress{Line1: "1 George St"}
+
+	orderReq := &models.OrderReq{DeliveryAddress: delivery}
+	require.NoError(t, policy.CheckMethod(orderReq))
+	assert.Equal(t, models.PaymentMethodCash, orderReq.PaymentMethod, "the first method is the default")
+	assert.False(t, policy.AwaitsPayment(orderReq), "cash is paid on delivery")
+
+	assert.ErrorIs(t, policy.CheckMethod(&models.OrderReq{PaymentMethod: "card"}), services.ErrPaymentMethodNotAccepted)
+	assert.ErrorIs(t, policy.CheckMethod(&models.OrderReq{PaymentMethod: "cash"}), services.ErrCashNeedsDelivery)
+	assert.ErrorIs(t, policy.CheckMethod(&models.OrderReq{PaymentMethod: "COUNTER", DeliveryAddress: delivery}), services.ErrCounterNoDelivery)
+	assert.ErrorIs(t, policy.CheckMethod(&models.OrderReq{PaymentMethod: "counter", PaymentIntentID: "pi_1"}), services.ErrPaymentIntentNeedsCard)
+
+	// Without methods all are accepted, card first
+	card := &models.OrderReq{}
+	require.NoError(t, services.PaymentPolicy{Required: true}.CheckMethod(card))
+	assert.Equal(t, models.PaymentMethodCard, card.PaymentMethod)
+	assert.True(t, services.PaymentPolicy{Required: true}.AwaitsPayment(card))
+
+	// Sandbox orders take no payment
+	sandbox := &models.OrderReq{Sandbox: true}
+	require.NoError(t, services.PaymentPolicy{Required: true}.CheckMethod(sandbox))
+	assert.False(t, services.PaymentPolicy{Required: true}.AwaitsPayment(sandbox))
+	assert.ErrorIs(t, policy.CheckMethod(&models.OrderReq{Sandbox: true, PaymentIntentID: "pi_1"}), services.ErrSandboxPayment)
+	assert.ErrorIs(t, policy.CheckMethod(&models.OrderReq{Sandbox: true, TableNumber: "4"}), services.ErrSandboxTableOrder)
+}
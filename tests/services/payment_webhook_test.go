@@ -0,0 +1,145 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"oolio/internal/app/models"
+	"oolio/internal/app/repository"
+	"oolio/internal/app/services"
+)
+
+const webhookSecret = "whsec_test"
+
+// signWebhook signs payload the way Stripe does, at the given time
+func signWebhook(payload string, at time.Time) string {
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(webhookSecret))
+	mac.Write([]byte(timestamp + "." + payload))
+	return "t=" + timestamp + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func paymentEventPayload(eventID, eventType, intentID, itemID string) string {
+	return fmt.Sprintf(`{"id":%q,"type":%q,"data":{"object":{"id":%q,"status":"requires_capture","metadata":{"orderId":%q}}}}`,
+		eventID, eventType, intentID, itemID)
+}
+
+func TestPaymentWebhook_Signature(t *testing.T) {
+	ctx := context.Background()
+	webhooks := services.NewPaymentWebhookService(repository.NewMemoryPaymentEventRepository(), repository.NewMemoryOrderRepository(), nil, nil, zap.NewNop(),
+		services.PaymentWebhookOptions{Secret: webhookSecret, Tolerance: time.Minute})
+	payload := paymentEventPayload("evt_1", "charge.updated", "pi_1", "")
+
+	_, err := webhooks.Handle(ctx, []byte(payload), "")
+	assert.ErrorIs(t, err, services.ErrWebhookSignature, "unsigned")
+	_, err = webhooks.Handle(ctx, []byte(payload), signWebhook(payload+" ", time.Now()))
+	assert.ErrorIs(t, err, services.ErrWebhookSignature, "signed over another body")
+	_, err = webhooks.Handle(ctx, []byte(payload), signWebhook(payload, time.Now().Add(-2*time.Minute)))
+	assert.ErrorIs(t, err, services.ErrWebhookSignature, "a replay of an old delivery")
+	_, err = webhooks.Handle(ctx, []byte(`{"object":"list"}`), signWebhook(`{"object":"list"}`, time.Now()))
+	assert.ErrorIs(t, err, services.ErrWebhookPayload)
+
+	// Signatures made with a retired secret are sent alongside while it is rolled
+	rolled := "v1=" + hex.EncodeToString(make([]byte, 32)) + "," + signWebhook(payload, time.Now())
+	duplicate, err := webhooks.Handle(ctx, []byte(payload), rolled)
+	require.NoError(t, err)
+	assert.False(t, duplicate)
+	duplicate, err = webhooks.Handle(ctx, []byte(payload), signWebhook(payload, time.Now()))
+	require.NoError(t, err)
+	assert.True(t, duplicate, "redelivered")
+}
+
+func TestPaymentWebhook_AuthorizedStartsOrder(t *testing.T) {
+	ctx := context.Background()
+	products := repository.NewMemoryProductRepository()
+	seeded, err := products.Find(ctx)
+	require.NoError(t, err)
+	require.NotEmpty(t, seeded)
+
+	payments := services.NewMockPaymentService("aud")
+	orders := repository.NewMemoryOrderRepository()
+	queueRepo := repository.NewMemoryOrderQueueRepository()
+	orderService := services.NewOrderService(orders, products, queueRepo, nil, nil, nil, nil, payments, nil, nil, nil, zap.NewNop())
+	fulfilled := &fulfilledOrders{}
+	queue := services.NewOrderQueueService(services.OrderQueueDeps{
+		QueueRepo:    queueRepo,
+		OrderRepo:    orders,
+		OrderService: orderService,
+		Fulfillment:  fulfilled,
+		Payment:      services.PaymentPolicy{Required: true},
+	}, nil, "test-worker")
+	webhooks := services.NewPaymentWebhookService(repository.NewMemoryPaymentEventRepository(), orders, queue, fulfilled, zap.NewNop(),
+		services.PaymentWebhookOptions{Secret: webhookSecret})
+
+	item, err := queue.AddOrderToQueue(ctx, &models.OrderReq{Items: []models.OrderItem{{ProductID: seeded[0].ID, Quantity: 1}}})
+	require.NoError(t, err)
+	result, err := queue.ProcessBatch(ctx, 10)
+	require.NoError(t, err)
+	require.Equal(t, 1, result.AwaitingPayment)
+
+	intent, err := payments.CreateIntent(ctx, seeded[0].Price, map[string]string{"orderId": item.ID})
+	require.NoError(t, err)
+	payload := paymentEventPayload("evt_auth", "payment_intent.amount_capturable_updated", intent.ID, item.ID)
+	_, err = webhooks.Handle(ctx, []byte(payload), signWebhook(payload, time.Now()))
+	require.NoError(t, err)
+
+	result, err = queue.ProcessBatch(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Processed, "due as soon as the payment is authorized")
+	completed, err := queue.GetOrderFromQueue(ctx, item.ID)
+	require.NoError(t, err)
+	require.NotNil(t, completed.Order)
+	assert.Equal(t, intent.ID, completed.Order.PaymentIntentID)
+	assert.Equal(t, models.OrderPaymentCaptured, completed.Order.PaymentStatus)
+
+	// The processed order can't take the intent again, which isn't a failure
+	payload = paymentEventPayload("evt_auth_again", "payment_intent.amount_capturable_updated", intent.ID, item.ID)
+	duplicate, err := webhooks.Handle(ctx, []byte(payload), signWebhook(payload, time.Now()))
+	require.NoError(t, err)
+	assert.False(t, duplicate)
+}
+
+func TestPaymentWebhook_SucceededFulfillsOrder(t *testing.T) {
+	ctx := context.Background()
+	orders := repository.NewMemoryOrderRepository()
+	order := &models.Order{PaymentIntentID: "pi_uncaptured", PaymentStatus: models.OrderPaymentAuthorized}
+	require.NoError(t, orders.Create(ctx, order))
+	fulfilled := &fulfilledOrders{}
+	webhooks := services.NewPaymentWebhookService(repository.NewMemoryPaymentEventRepository(), orders, nil, fulfilled, zap.NewNop(),
+		services.PaymentWebhookOptions{Secret: webhookSecret})
+
+	for _, eventID := range []string{"evt_paid", "evt_paid", "evt_paid_again"} {
+		payload := paymentEventPayload(eventID, "payment_intent.succeeded", "pi_uncaptured", "")
+		_, err := webhooks.Handle(ctx, []byte(payload), signWebhook(payload, time.Now()))
+		require.NoError(t, err)
+	}
+
+	stored, err := orders.FindOne(ctx, order.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.OrderPaymentCaptured, stored.PaymentStatus)
+	assert.Equal(t, []string{order.ID}, fulfilled.ids, "fulfilled once")
+
+	// An intent without an order yet is redelivered until the order is created
+	payload := paymentEventPayload("evt_early", "payment_intent.succeeded", "pi_early", "")
+	_, err = webhooks.Handle(ctx, []byte(payload), signWebhook(payload, time.Now()))
+	assert.ErrorIs(t, err, services.ErrWebhookOrderPending)
+
+	early := &models.Order{PaymentIntentID: "pi_early", PaymentStatus: models.OrderPaymentAuthorized}
+	require.NoError(t, orders.Create(ctx, early))
+	duplicate, err := webhooks.Handle(ctx, []byte(payload), signWebhook(payload, time.Now()))
+	require.NoError(t, err)
+	assert.False(t, duplicate, "the failed delivery was forgotten")
+	stored, err = orders.FindOne(ctx, early.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.OrderPaymentCaptured, stored.PaymentStatus)
+}
@@ -0,0 +1,116 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"oolio/internal/app/models"
+	"oolio/internal/app/repository"
+	"oolio/internal/app/services"
+)
+
+func TestPricingService_Tiers(t *testing.T) {
+	ctx := context.Background()
+	pricing := services.NewPricingService(repository.NewMemoryPricingRepository())
+
+	tiers, err := pricing.ListTiers(ctx)
+	require.NoError(t, err)
+	names := make([]string, len(tiers))
+	for i, tier := range tiers {
+		names[i] = tier.Name
+	}
+	assert.Equal(t, []string{"retail", "corporate", "staff"}, names)
+
+	tier, err := pricing.SaveTier(ctx, "staff", models.PricingTierReq{DiscountPercentage: 30})
+	require.NoError(t, err)
+	assert.Equal(t, 30.0, tier.DiscountPercentage)
+
+	_, err = pricing.SaveTier(ctx, "retail", models.PricingTierReq{DiscountPercentage: 5})
+	assert.ErrorIs(t, err, services.ErrRetailTier)
+	_, err = pricing.SaveTier(ctx, "Bad Name", models.PricingTierReq{DiscountPercentage: 5})
+	assert.ErrorIs(t, err, services.ErrInvalidPricingTier)
+	_, err = pricing.SaveTier(ctx, "free", models.PricingTierReq{DiscountPercentage: 100})
+	assert.ErrorIs(t, err, services.ErrInvalidPricingTier)
+
+	assert.ErrorIs(t, pricing.DeleteTier(ctx, "retail"), services.ErrRetailTier)
+	assert.ErrorContains(t, pricing.DeleteTier(ctx, "missing"), "not found")
+}
+
+func TestPricingService_ResolveTier(t *testing.T) {
+	ctx := context.Background()
+	pricing := services.NewPricingService(repository.NewMemoryPricingRepository())
+	_, err := pricing.SaveTier(ctx, "staff", models.PricingTierReq{DiscountPercentage: 30})
+	require.NoError(t, err)
+	_, err = pricing.SaveTier(ctx, "corporate", models.PricingTierReq{DiscountPercentage: 10})
+	require.NoError(t, err)
+
+	tier, err := pricing.ResolveTier(ctx, "c1", "key")
+	require.NoError(t, err)
+	assert.Equal(t, models.TierRetail, tier.Name)
+
+	require.NoError(t, pricing.Assign(ctx, models.PricingAssignmentReq{APIKey: "key", Tier: "corporate"}))
+	require.NoError(t, pricing.Assign(ctx, models.PricingAssignmentReq{CustomerID: "c1", Tier: "staff"}))
+	assert.ErrorContains(t, pricing.Assign(ctx, models.PricingAssignmentReq{CustomerID: "c2", Tier: "missing"}), "not found")
+	assert.ErrorIs(t, pricing.Assign(ctx, models.PricingAssignmentReq{CustomerID: "c2", APIKey: "key", Tier: "staff"}), services.ErrInvalidPricingOwner)
+
+	tier, err = pricing.ResolveTier(ctx, "c1", "key")
+	require.NoError(t, err)
+	assert.Equal(t, "staff", tier.Name, "the customer's tier wins over the API key's")
+
+	tier, err = pricing.ResolveTier(ctx, "c2", "key")
+	require.NoError(t, err)
+	assert.Equal(t, "corporate", tier.Name)
+
+	assignments, err := pricing.ListAssignments(ctx)
+	require.NoError(t, err)
+	require.Len(t, assignments, 2)
+	assert.NotContains(t, []string{assignments[0].Subject, assignments[1].Subject}, "key", "API keys are stored hashed")
+
+	require.NoError(t, pricing.Assign(ctx, models.PricingAssignmentReq{CustomerID: "c1", Tier: models.TierRetail}))
+	require.NoError(t, pricing.Assign(ctx, models.PricingAssignmentReq{CustomerID: "c1", Tier: models.TierRetail}), "already retail")
+	tier, err = pricing.ResolveTier(ctx, "c1", "")
+	require.NoError(t, err)
+	assert.Equal(t, models.TierRetail, tier.Name)
+
+	// Deleting a tier sends its holders, and queued orders placed with it, back to retail
+	require.NoError(t, pricing.DeleteTier(ctx, "corporate"))
+	tier, err = pricing.ResolveTier(ctx, "c2", "key")
+	require.NoError(t, err)
+	assert.Equal(t, models.TierRetail, tier.Name)
+	tier, err = pricing.Tier(ctx, "corporate")
+	require.NoError(t, err)
+	assert.Equal(t, models.TierRetail, tier.Name)
+}
+
+func TestOrderService_PricingTier(t *testing.T) {
+	ctx := context.Background()
+	products := repository.NewMemoryProductRepository()
+	seeded, err := products.Find(ctx)
+	require.NoError(t, err)
+	require.NotEmpty(t, seeded)
+
+	pricing := services.NewPricingService(repository.NewMemoryPricingRepository())
+	staff, err := pricing.SaveTier(ctx, "staff", models.PricingTierReq{DiscountPercentage: 50})
+	require.NoError(t, err)
+
+	service := services.NewOrderService(repository.NewMemoryOrderRepository(), products, repository.NewMemoryOrderQueueRepository(), nil, nil, pricing, nil, nil, nil, nil, nil, zap.NewNop())
+
+	order, err := service.CreateOrder(ctx, &models.OrderReq{
+		PricingTier: "staff",
+		Items:       []models.OrderItem{{ProductID: seeded[0].ID, Quantity: 2}},
+	})
+	require.NoError(t, err)
+	unit := staff.Price(seeded[0].Price)
+	assert.Equal(t, unit.Mul(2), order.Total)
+	assert.Equal(t, unit, order.Items[0].Price, "items record the price charged")
+
+	order, err = service.CreateOrder(ctx, &models.OrderReq{
+		Items: []models.OrderItem{{ProductID: seeded[0].ID, Quantity: 2}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, seeded[0].Price.Mul(2), order.Total)
+}
@@ -0,0 +1,154 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"oolio/internal/app/repository"
+	"oolio/internal/app/services"
+	"oolio/internal/storage"
+)
+
+// pngHeader is enough of a PNG for content sniffing, but not to decode
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+// encodePNG is a width by height PNG
+func encodePNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for i := range img.Pix {
+		img.Pix[i] = uint8(i)
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestProductImageService_Upload(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewLocalStorage(t.TempDir())
+	require.NoError(t, err)
+
+	productService := services.NewProductService(repository.NewMemoryProductRepository())
+	imageService := services.NewProductImageService(productService, store, "http://cdn.test/files/")
+
+	products, err := productService.GetAllProducts(ctx)
+	require.NoError(t, err)
+	productID := products[0].ID
+
+	// read loads the object behind an image URL
+	read := func(t *testing.T, url string) ([]byte, error) {
+		t.Helper()
+		key, ok := strings.CutPrefix(url, "http://cdn.test/files/products/"+productID+"/")
+		require.True(t, ok, url)
+		file, err := store.Get(ctx, "products/"+productID+"/"+key)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		return io.ReadAll(file)
+	}
+	size := func(t *testing.T, url string) image.Point {
+		t.Helper()
+		data, err := read(t, url)
+		require.NoError(t, err)
+		config, err := png.DecodeConfig(bytes.NewReader(data))
+		require.NoError(t, err)
+		return image.Pt(config.Width, config.Height)
+	}
+
+	upload := encodePNG(t, 800, 400)
+	first, err := imageService.Upload(ctx, productID, bytes.NewReader(upload))
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(first.Image.Thumbnail, "-thumbnail.png"), first.Image.Thumbnail)
+	assert.Equal(t, image.Pt(200, 100), size(t, first.Image.Thumbnail))
+	assert.Equal(t, image.Pt(640, 320), size(t, first.Image.Mobile))
+	assert.Equal(t, first.Image.Tablet, first.Image.Desktop, "the image isn't wider than either")
+	original, err := read(t, first.Image.Desktop)
+	require.NoError(t, err)
+	assert.Equal(t, upload, original)
+
+	stored, err := productService.GetProductByID(ctx, productID)
+	require.NoError(t, err)
+	assert.Equal(t, first.Image, stored.Image)
+
+	// A new upload replaces the previous objects
+	second, err := imageService.Upload(ctx, productID, bytes.NewReader(encodePNG(t, 100, 100)))
+	require.NoError(t, err)
+	assert.NotEqual(t, first.Image.Thumbnail, second.Image.Thumbnail)
+	assert.Equal(t, second.Image.Thumbnail, second.Image.Desktop, "the image isn't wider than any size")
+	for _, url := range []string{first.Image.Thumbnail, first.Image.Mobile, first.Image.Desktop} {
+		_, err = read(t, url)
+		assert.ErrorIs(t, err, storage.ErrNotFound, url)
+	}
+	assert.Equal(t, image.Pt(100, 100), size(t, second.Image.Thumbnail))
+}
+
+func TestProductImageService_UploadWebP(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewLocalStorage(t.TempDir())
+	require.NoError(t, err)
+
+	productService := services.NewProductService(repository.NewMemoryProductRepository())
+	imageService := services.NewProductImageService(productService, store, "http://cdn.test/files")
+
+	products, err := productService.GetAllProducts(ctx)
+	require.NoError(t, err)
+
+	// WebP images are used as uploaded for every size
+	product, err := imageService.Upload(ctx, products[0].ID, strings.NewReader("RIFF\x00\x00\x00\x00WEBPVP8 "))
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(product.Image.Thumbnail, ".webp"), product.Image.Thumbnail)
+	assert.Equal(t, product.Image.Thumbnail, product.Image.Desktop)
+}
+
+func TestProductImageService_Rejects(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewLocalStorage(t.TempDir())
+	require.NoError(t, err)
+
+	productService := services.NewProductService(repository.NewMemoryProductRepository())
+	imageService := services.NewProductImageService(productService, store, "http://cdn.test/files")
+
+	products, err := productService.GetAllProducts(ctx)
+	require.NoError(t, err)
+
+	_, err = imageService.Upload(ctx, products[0].ID, strings.NewReader("<html>not an image</html>"))
+	assert.ErrorIs(t, err, services.ErrUnsupportedImage)
+	_, err = imageService.Upload(ctx, products[0].ID, bytes.NewReader(pngHeader))
+	assert.ErrorIs(t, err, services.ErrUnsupportedImage, "a PNG that can't be decoded")
+
+	_, err = imageService.Upload(ctx, products[0].ID, io.MultiReader(bytes.NewReader(pngHeader), bytes.NewReader(make([]byte, services.MaxProductImageBytes))))
+	assert.ErrorIs(t, err, services.ErrImageTooLarge)
+
+	// A few bytes declare the dimensions; they are refused before the pixels are decoded
+	_, err = imageService.Upload(ctx, products[0].ID, bytes.NewReader(pngOfSize(20000, 20000)))
+	assert.ErrorIs(t, err, services.ErrImageTooManyPixels)
+
+	_, err = imageService.Upload(ctx, "00000000-0000-0000-0000-000000000000", bytes.NewReader(pngHeader))
+	assert.ErrorContains(t, err, "not found")
+}
+
+// pngOfSize is a PNG signature and header declaring a width by height RGBA image, with no
+// pixel data
+func pngOfSize(width, height uint32) []byte {
+	chunk := []byte("IHDR")
+	chunk = binary.BigEndian.AppendUint32(chunk, width)
+	chunk = binary.BigEndian.AppendUint32(chunk, height)
+	chunk = append(chunk, 8, 6, 0, 0, 0)
+
+	data := []byte("\x89PNG\r\n\x1a\n")
+	data = binary.BigEndian.AppendUint32(data, uint32(len(chunk)-4))
+	data = append(data, chunk...)
+	return binary.BigEndian.AppendUint32(data, crc32.ChecksumIEEE(chunk))
+}
@@ -0,0 +1,51 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"oolio/internal/app/models"
+	"oolio/internal/app/services"
+)
+
+func TestParseProductCSV(t *testing.T) {
+	input := `Category,Name,Price,Thumbnail
+Waffle,Chicken Waffle,12.50,https://example.com/waffle.jpg
+Drinks, "Iced Latte, Large",6,
+`
+	products, err := services.ParseProductCSV(strings.NewReader(input))
+	require.NoError(t, err)
+	require.Len(t, products, 2)
+
+	assert.Equal(t, "Chicken Waffle", products[0].Name)
+	assert.Equal(t, models.Cents(1250), products[0].Price)
+	assert.Equal(t, "Waffle", products[0].Category)
+	assert.Equal(t, "https://example.com/waffle.jpg", products[0].Image.Thumbnail)
+
+	assert.Equal(t, "Iced Latte, Large", products[1].Name)
+	assert.Equal(t, models.Cents(600), products[1].Price)
+	assert.Empty(t, products[1].Image.Thumbnail)
+}
+
+func TestParseProductCSV_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"empty", "", "empty"},
+		{"missing column", "name,price\nWaffle,1\n", `missing "category" column`},
+		{"bad price", "name,price,category\nWaffle,1,Waffle\nPie,abc,Pie\n", "line 3"},
+		{"missing name", "name,price,category\n,1,Waffle\n", "line 2: name and category are required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := services.ParseProductCSV(strings.NewReader(tt.input))
+			assert.ErrorContains(t, err, tt.want)
+		})
+	}
+}
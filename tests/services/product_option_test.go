@@ -0,0 +1,227 @@
+package services
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"oolio/internal/app/models"
+	"oolio/internal/app/repository"
+	"oolio/internal/app/services"
+)
+
+func TestProductService_Options(t *testing.T) {
+	ctx := context.Background()
+	products := services.NewProductService(repository.NewMemoryProductRepository())
+
+	product := &models.Product{
+		Name:      "Iced Coffee",
+		Price:     500,
+		Category:  "Drinks",
+		Variants:  []models.ProductOption{{Name: "Regular"}, {Name: "Large", PriceDelta: 150}},
+		Modifiers: []models.ProductOption{{Name: "Extra shot", PriceDelta: 80}},
+	}
+	require.NoError(t, products.CreateProduct(ctx, product))
+	for _, option := range slices.Concat(product.Variants, product.Modifiers) {
+		assert.NoError(t, uuid.Validate(option.ID))
+	}
+
+	// Options sent with their ID keep it; others, even with another product's, get a new one
+	other := &models.Product{Name: "Tea", Price: 400, Category: "Drinks", Modifiers: []models.ProductOption{{Name: "Honey"}}}
+	require.NoError(t, products.CreateProduct(ctx, other))
+	large, shot := product.Variants[1], product.Modifiers[0]
+	update := &models.Product{
+		ID:        product.ID,
+		Name:      product.Name,
+		Price:     product.Price,
+		Category:  product.Category,
+		Version:   product.Version,
+		Variants:  []models.ProductOption{{ID: large.ID, Name: "Large", PriceDelta: 200}},
+		Modifiers: []models.ProductOption{{ID: other.Modifiers[0].ID, Name: "Honey"}, {ID: shot.ID, Name: "Extra shot", PriceDelta: 80}},
+	}
+	require.NoError(t, products.UpdateProduct(ctx, update))
+
+	found, err := products.GetProductByID(ctx, product.ID)
+	require.NoError(t, err)
+	require.Len(t, found.Variants, 1)
+	assert.Equal(t, models.ProductOption{ID: large.ID, Name: "Large", PriceDelta: 200}, found.Variants[0])
+	require.Len(t, found.Modifiers, 2)
+	assert.NotEqual(t, other.Modifiers[0].ID, found.Modifiers[0].ID)
+	assert.Equal(t, shot.ID, found.Modifiers[1].ID)
+
+	err = products.CreateProduct(ctx, &models.Product{Name: "Juice", Price: 400, Category: "Drinks", Modifiers: []models.ProductOption{{Name: ""}}})
+	assert.Error(t, err)
+}
+
+func TestOrderService_PricesOptions(t *testing.T) {
+	ctx := context.Background()
+	productRepo := repository.NewMemoryProductRepository()
+	product := &models.Product{
+		Name:      "Iced Coffee",
+		Price:     500,
+		Category:  "Drinks",
+		Variants:  []models.ProductOption{{Name: "Regular"}, {Name: "Large", PriceDelta: 150}},
+		Modifiers: []models.ProductOption{{Name: "Extra shot", PriceDelta: 80}, {Name: "Oat milk", PriceDelta: 60}},
+	}
+	require.NoError(t, services.NewProductService(productRepo).CreateProduct(ctx, product))
+	large, shot, oat := product.Variants[1], product.Modifiers[0], product.Modifiers[1]
+
+	orders := repository.NewMemoryOrderRepository()
+	service := services.NewOrderService(orders, productRepo, repository.NewMemoryOrderQueueRepository(), nil, nil, nil, nil, nil, nil, nil, nil, zap.NewNop())
+	orderReq := func(items ...models.OrderItem) *models.OrderReq {
+		return &models.OrderReq{Items: items}
+	}
+
+	// Items naming no variant get the first
+	quote, err := service.QuoteOrder(ctx, orderReq(models.OrderItem{ProductID: product.ID, Quantity: 2}))
+	require.NoError(t, err)
+	assert.Equal(t, models.Money(1000), quote.Total)
+
+	order, err := service.CreateOrder(ctx, orderReq(
+		models.OrderItem{ProductID: product.ID, Quantity: 2, VariantID: large.ID, ModifierIDs: []string{shot.ID, oat.ID}},
+		models.OrderItem{ProductID: product.ID, Quantity: 1},
+	))
+	require.NoError(t, err)
+	assert.Equal(t, models.Money(500+150+80+60), order.Items[0].Price)
+	assert.Equal(t, models.Money(500), order.Items[1].Price)
+	assert.Equal(t, models.Money(2*790+500), order.Total)
+	assert.Equal(t, []models.OrderItemOption{
+		{ID: large.ID, Kind: models.OptionVariant, Name: "Large", PriceDelta: 150},
+		{ID: shot.ID, Kind: models.OptionModifier, Name: "Extra shot", PriceDelta: 80},
+		{ID: oat.ID, Kind: models.OptionModifier, Name: "Oat milk", PriceDelta: 60},
+	}, order.Items[0].Options)
+	assert.Equal(t, "Iced Coffee (Large, Extra shot, Oat milk)", order.Items[0].Describe(product.Name))
+
+	// The order keeps the options as they were charged
+	product.Variants[1].Name, product.Variants[1].PriceDelta = "Huge", 300
+	require.NoError(t, services.NewProductService(productRepo).UpdateProduct(ctx, product))
+	stored, err := orders.FindOne(ctx, order.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Large", stored.Items[0].Options[0].Name)
+
+	for _, item := range []models.OrderItem{
+		{ProductID: product.ID, Quantity: 1, VariantID: uuid.NewString()},
+		{ProductID: product.ID, Quantity: 1, ModifierIDs: []string{uuid.NewString()}},
+		{ProductID: product.ID, Quantity: 1, ModifierIDs: []string{shot.ID, shot.ID}},
+	} {
+		_, err := service.QuoteOrder(ctx, orderReq(item))
+		assert.ErrorIs(t, err, services.ErrInvalidOption)
+	}
+}
+
+func TestOrderService_PricesOptionsAtTier(t *testing.T) {
+	ctx := context.Background()
+	productRepo := repository.NewMemoryProductRepository()
+	product := &models.Product{
+		Name:      "Iced Coffee",
+		Price:     500,
+		Category:  "Drinks",
+		Variants:  []models.ProductOption{{Name: "Regular"}, {Name: "Large", PriceDelta: 150}},
+		Modifiers: []models.ProductOption{{Name: "Extra shot", PriceDelta: 80}},
+	}
+	require.NoError(t, services.NewProductService(productRepo).CreateProduct(ctx, product))
+	large, shot := product.Variants[1], product.Modifiers[0]
+
+	pricing := services.NewPricingService(repository.NewMemoryPricingRepository())
+	staff, err := pricing.SaveTier(ctx, "staff", models.PricingTierReq{DiscountPercentage: 50})
+	require.NoError(t, err)
+	service := services.NewOrderService(repository.NewMemoryOrderRepository(), productRepo, repository.NewMemoryOrderQueueRepository(), nil, nil, pricing, nil, nil, nil, nil, nil, zap.NewNop())
+
+	// The options are discounted like the product
+	order, err := service.CreateOrder(ctx, &models.OrderReq{
+		PricingTier: "staff",
+		Items:       []models.OrderItem{{ProductID: product.ID, Quantity: 2, VariantID: large.ID, ModifierIDs: []string{shot.ID}}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, models.Money(250+75+40), order.Items[0].Price)
+	assert.Equal(t, models.Money(2*365), order.Total)
+	assert.Equal(t, []models.OrderItemOption{
+		{ID: large.ID, Kind: models.OptionVariant, Name: "Large", PriceDelta: 75},
+		{ID: shot.ID, Kind: models.OptionModifier, Name: "Extra shot", PriceDelta: 40},
+	}, order.Items[0].Options)
+
+	// and listed at the tier's prices, leaving the product as it was
+	listed := staff.Apply([]models.Product{*product})
+	assert.Equal(t, models.Money(75), listed[0].Variants[1].PriceDelta)
+	assert.Equal(t, models.Money(40), listed[0].Modifiers[0].PriceDelta)
+	assert.Equal(t, models.Money(150), product.Variants[1].PriceDelta)
+}
+
+func TestOrderQueueService_InvalidOption(t *testing.T) {
+	ctx := context.Background()
+	productRepo := repository.NewMemoryProductRepository()
+	productService := services.NewProductService(productRepo)
+	product := &models.Product{
+		Name:      "Iced Coffee",
+		Price:     500,
+		Category:  "Drinks",
+		Modifiers: []models.ProductOption{{Name: "Extra shot", PriceDelta: 80}},
+	}
+	require.NoError(t, productService.CreateProduct(ctx, product))
+
+	orders := repository.NewMemoryOrderRepository()
+	queueRepo := repository.NewMemoryOrderQueueRepository()
+	queue := services.NewOrderQueueService(services.OrderQueueDeps{
+		QueueRepo:    queueRepo,
+		OrderRepo:    orders,
+		OrderService: services.NewOrderService(orders, productRepo, queueRepo, nil, nil, nil, nil, nil, nil, nil, nil, zap.NewNop()),
+	}, nil, "test-worker")
+
+	queued, err := queue.AddOrderToQueue(ctx, &models.OrderReq{
+		Items: []models.OrderItem{{ProductID: product.ID, Quantity: 1, ModifierIDs: []string{product.Modifiers[0].ID}}},
+	})
+	require.NoError(t, err)
+
+	// The modifier is deleted while the order waits, which retrying won't fix
+	product.Modifiers = nil
+	require.NoError(t, productService.UpdateProduct(ctx, product))
+	result, err := queue.ProcessBatch(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Failed)
+
+	item, err := queue.GetOrderFromQueue(ctx, queued.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "failed", item.Status)
+	assert.Equal(t, models.QueueErrorInvalidOption, item.ErrorCode)
+	result, err = queue.ProcessBatch(ctx, 10)
+	require.NoError(t, err)
+	assert.Zero(t, result.Failed+result.Processed)
+}
+
+func TestCartService_Options(t *testing.T) {
+	ctx := context.Background()
+	productRepo := repository.NewMemoryProductRepository()
+	product := &models.Product{
+		Name:      "Iced Coffee",
+		Price:     500,
+		Category:  "Drinks",
+		Variants:  []models.ProductOption{{Name: "Regular"}, {Name: "Large", PriceDelta: 150}},
+		Modifiers: []models.ProductOption{{Name: "Extra shot", PriceDelta: 80}},
+	}
+	require.NoError(t, services.NewProductService(productRepo).CreateProduct(ctx, product))
+	large, shot := product.Variants[1], product.Modifiers[0]
+
+	carts := services.NewCartService(repository.NewMemoryCartRepository(), productRepo, time.Hour, zap.NewNop())
+	owner := services.CartOwner{CustomerID: "c1"}
+	_, err := carts.AddItem(ctx, owner, models.CartItemReq{ProductID: product.ID, ModifierIDs: []string{shot.ID}})
+	require.NoError(t, err)
+
+	// Adding the product again takes the options sent
+	cart, err := carts.AddItem(ctx, owner, models.CartItemReq{ProductID: product.ID, VariantID: large.ID, ModifierIDs: []string{shot.ID}})
+	require.NoError(t, err)
+	assert.Equal(t, []models.CartItem{{ProductID: product.ID, Quantity: 2, VariantID: large.ID, ModifierIDs: []string{shot.ID}}}, cart.Items)
+
+	_, err = carts.AddItem(ctx, owner, models.CartItemReq{ProductID: product.ID, VariantID: uuid.NewString()})
+	assert.ErrorIs(t, err, services.ErrInvalidOption)
+
+	// and they are ordered with it
+	orderReq, err := carts.CheckoutOrder(ctx, owner, models.CheckoutReq{})
+	require.NoError(t, err)
+	assert.Equal(t, []models.OrderItem{{ProductID: product.ID, Quantity: 2, VariantID: large.ID, ModifierIDs: []string{shot.ID}}}, orderReq.Items)
+}
@@ -3,12 +3,16 @@ package services
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 
 	"oolio/internal/app/models"
+	"oolio/internal/app/repository"
 	"oolio/internal/app/services"
 )
 
@@ -22,6 +26,21 @@ func (m *MockProductRepository) Find(ctx context.Context) ([]models.Product, err
 	return args.Get(0).([]models.Product), args.Error(1)
 }
 
+func (m *MockProductRepository) FindPage(ctx context.Context, page models.PageRequest) ([]models.Product, int, error) {
+	args := m.Called(ctx, page)
+	return args.Get(0).([]models.Product), args.Int(1), args.Error(2)
+}
+
+func (m *MockProductRepository) FindUpdatedSince(ctx context.Context, since time.Time) ([]models.Product, error) {
+	args := m.Called(ctx, since)
+	return args.Get(0).([]models.Product), args.Error(1)
+}
+
+func (m *MockProductRepository) FindByIDs(ctx context.Context, ids []string) ([]models.Product, error) {
+	args := m.Called(ctx, ids)
+	return args.Get(0).([]models.Product), args.Error(1)
+}
+
 func (m *MockProductRepository) FindOne(ctx context.Context, id string) (*models.Product, error) {
 	args := m.Called(ctx, id)
 	if args.Get(0) == nil {
@@ -30,6 +49,14 @@ func (m *MockProductRepository) FindOne(ctx context.Context, id string) (*models
 	return args.Get(0).(*models.Product), args.Error(1)
 }
 
+func (m *MockProductRepository) FindOneForUpdate(ctx context.Context, id string) (*models.Product, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Product), args.Error(1)
+}
+
 func (m *MockProductRepository) Create(ctx context.Context, product *models.Product) error {
 	args := m.Called(ctx, product)
 	return args.Error(0)
@@ -45,6 +72,21 @@ func (m *MockProductRepository) Delete(ctx context.Context, id string) error {
 	return args.Error(0)
 }
 
+func (m *MockProductRepository) FindDeleted(ctx context.Context, since time.Time) ([]models.Product, error) {
+	args := m.Called(ctx, since)
+	return args.Get(0).([]models.Product), args.Error(1)
+}
+
+func (m *MockProductRepository) Restore(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockProductRepository) FindChanges(ctx context.Context, storeID string, after int64, limit int) ([]models.ProductChange, error) {
+	args := m.Called(ctx, storeID, after, limit)
+	return args.Get(0).([]models.ProductChange), args.Error(1)
+}
+
 func TestProductService_GetAllProducts(t *testing.T) {
 	mockRepo := &MockProductRepository{}
 	service := services.NewProductService(mockRepo)
@@ -54,7 +96,7 @@ func TestProductService_GetAllProducts(t *testing.T) {
 		{
 			ID:       "test-1",
 			Name:     "Test Product 1",
-			Price:    10.99,
+			Price:    1099,
 			Category: "Waffle",
 		},
 	}
@@ -76,7 +118,7 @@ func TestProductService_GetProductByID(t *testing.T) {
 	expectedProduct := &models.Product{
 		ID:       "test-1",
 		Name:     "Test Product 1",
-		Price:    10.99,
+		Price:    1099,
 		Category: "Waffle",
 	}
 
@@ -122,7 +164,7 @@ func TestProductService_CreateProduct(t *testing.T) {
 
 	product := &models.Product{
 		Name:     "New Product",
-		Price:    25.99,
+		Price:    2599,
 		Category: "Waffle",
 		Image: models.Image{
 			Thumbnail: "http://example.com/thumb.jpg",
@@ -152,7 +194,7 @@ func TestProductService_CreateProduct_ValidationError(t *testing.T) {
 	// Test with empty name
 	product := &models.Product{
 		Name:     "",
-		Price:    25.99,
+		Price:    2599,
 		Category: "Waffle",
 	}
 	err = service.CreateProduct(ctx, product)
@@ -167,7 +209,7 @@ func TestProductService_CreateProduct_ValidationError(t *testing.T) {
 	assert.Contains(t, err.Error(), "product price must be greater than 0")
 
 	// Test with empty category
-	product.Price = 25.99
+	product.Price = 2599
 	product.Category = ""
 	err = service.CreateProduct(ctx, product)
 	assert.Error(t, err)
@@ -182,7 +224,7 @@ func TestProductService_UpdateProduct(t *testing.T) {
 	product := &models.Product{
 		ID:       "test-1",
 		Name:     "Updated Product",
-		Price:    99.99,
+		Price:    9999,
 		Category: "Waffle",
 		Image: models.Image{
 			Thumbnail: "http://example.com/thumb.jpg",
@@ -206,7 +248,7 @@ func TestProductService_UpdateProduct_EmptyID(t *testing.T) {
 
 	product := &models.Product{
 		Name:     "Updated Product",
-		Price:    99.99,
+		Price:    9999,
 		Category: "Waffle",
 	}
 
@@ -238,3 +280,105 @@ func TestProductService_DeleteProduct_EmptyID(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "product ID cannot be empty")
 }
+
+func TestCachingProductService(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := &MockProductRepository{}
+	service := services.NewCachingProductService(services.NewProductService(mockRepo), repository.NewLRUCache(10), time.Minute)
+
+	since := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	mockRepo.On("Find", ctx).Return([]models.Product{{ID: "p1", Name: "Waffle"}}, nil).Twice()
+	mockRepo.On("FindUpdatedSince", ctx, since).Return([]models.Product{}, nil).Once()
+
+	for range 2 {
+		products, err := service.GetAllProducts(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, []models.Product{{ID: "p1", Name: "Waffle"}}, products)
+		_, err = service.GetProductsUpdatedSince(ctx, since)
+		assert.NoError(t, err)
+	}
+
+	// A write drops every cached listing, whatever its filter
+	product := &models.Product{ID: "p1", Name: "Waffle", Price: 6, Category: "Waffle"}
+	mockRepo.On("Update", ctx, product).Return(nil).Once()
+	mockRepo.On("FindUpdatedSince", ctx, since).Return([]models.Product{{ID: "p1"}}, nil).Once()
+	assert.NoError(t, service.UpdateProduct(ctx, product))
+
+	_, err := service.GetAllProducts(ctx)
+	assert.NoError(t, err)
+	updated, err := service.GetProductsUpdatedSince(ctx, since)
+	assert.NoError(t, err)
+	assert.Len(t, updated, 1)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestHookedProductService_BumpsCatalogVersion(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := &MockProductRepository{}
+	version := services.NewCatalogVersion(repository.NewLRUCache(10))
+	service := services.NewHookedProductService(services.NewProductService(mockRepo), version.Bump)
+
+	before, err := version.Current(ctx)
+	require.NoError(t, err)
+	current, err := version.Current(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, before, current, "reads keep the version")
+
+	// Failed writes bump it too, since they may have been applied
+	mockRepo.On("Delete", ctx, "p1").Return(errors.New("connection reset")).Once()
+	assert.Error(t, service.DeleteProduct(ctx, "p1"))
+	after, err := version.Current(ctx)
+	require.NoError(t, err)
+	assert.NotEqual(t, before, after)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestProductService_GetChanges(t *testing.T) {
+	ctx := context.Background()
+	service := services.NewProductService(repository.NewMemoryProductRepository())
+
+	// The memory repository starts with the seeded menu, each product created
+	start, err := service.GetChanges(ctx, "", "", 100)
+	require.NoError(t, err)
+	require.NotEmpty(t, start.Changes)
+	assert.False(t, start.HasMore)
+
+	product := &models.Product{Name: "Pecan Waffle", Price: 1399, Category: "Waffle"}
+	require.NoError(t, service.CreateProduct(ctx, product))
+	product.Price = 1499
+	require.NoError(t, service.UpdateProduct(ctx, product))
+	require.NoError(t, service.DeleteProduct(ctx, product.ID))
+	require.NoError(t, service.RestoreProduct(ctx, product.ID))
+
+	changes, err := service.GetChanges(ctx, "", start.Cursor, 3)
+	require.NoError(t, err)
+	require.Len(t, changes.Changes, 3)
+	assert.True(t, changes.HasMore)
+	var kinds []string
+	for _, change := range changes.Changes {
+		assert.Equal(t, product.ID, change.ProductID)
+		kinds = append(kinds, change.Kind)
+	}
+	assert.Equal(t, []string{models.ProductCreated, models.ProductUpdated, models.ProductDeleted}, kinds)
+	require.NotNil(t, changes.Changes[1].Product)
+	assert.Equal(t, models.Money(1499), changes.Changes[1].Product.Price, "changes carry the product as it is now")
+	assert.Nil(t, changes.Changes[2].Product, "deletions only name the product")
+
+	rest, err := service.GetChanges(ctx, "", changes.Cursor, 3)
+	require.NoError(t, err)
+	require.Len(t, rest.Changes, 1)
+	assert.Equal(t, models.ProductCreated, rest.Changes[0].Kind, "a restored product is created again")
+	assert.False(t, rest.HasMore)
+
+	caughtUp, err := service.GetChanges(ctx, "", rest.Cursor, 3)
+	require.NoError(t, err)
+	assert.Empty(t, caughtUp.Changes)
+	assert.Equal(t, rest.Cursor, caughtUp.Cursor, "the cursor stays put until something changes")
+
+	other, err := service.GetChanges(ctx, "00000000-0000-0000-0000-0000000000ff", start.Cursor, 3)
+	require.NoError(t, err)
+	assert.Empty(t, other.Changes, "other stores' changes are left out")
+
+	_, err = service.GetChanges(ctx, "", "not-a-cursor", 3)
+	assert.ErrorIs(t, err, services.ErrInvalidCursor)
+}
@@ -0,0 +1,221 @@
+package services
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"oolio/internal/app/models"
+	"oolio/internal/app/repository"
+	"oolio/internal/app/services"
+)
+
+// pushedMessages records push notifications, and rejects tokens in invalid
+type pushedMessages struct {
+	pushes  []string // token|title
+	invalid map[string]bool
+}
+
+func (p *pushedMessages) SendPush(ctx context.Context, token string, push services.Push) error {
+	if p.invalid[token] {
+		return services.ErrPushTokenInvalid
+	}
+	p.pushes = append(p.pushes, token+"|"+push.Title)
+	return nil
+}
+
+// pemKey encodes a private key the way Google and Apple hand them out
+func pemKey(t *testing.T, key any) string {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+}
+
+func TestNotificationService_Push(t *testing.T) {
+	ctx := context.Background()
+	devices := repository.NewMemoryPushDeviceRepository()
+	pushed := &pushedMessages{invalid: map[string]bool{"gone": true}}
+	enabled := true
+	service := services.NewNotificationService(repository.NewMemoryNotificationRepository(), nil, nil, services.NotificationOptions{
+		Push:        map[string]services.PushSender{models.PushPlatformFCM: pushed},
+		Devices:     devices,
+		PushEnabled: func() bool { return enabled },
+	})
+
+	_, err := service.RegisterDevice(ctx, "42", models.PushDeviceReq{Platform: models.PushPlatformAPNs, Token: "apple"})
+	assert.ErrorIs(t, err, services.ErrPushPlatformNotSupported)
+	_, err = service.RegisterDevice(ctx, "42", models.PushDeviceReq{Platform: models.PushPlatformFCM, Token: "two words"})
+	assert.ErrorIs(t, err, services.ErrInvalidPushToken)
+
+	device, err := service.RegisterDevice(ctx, "42", models.PushDeviceReq{Platform: models.PushPlatformFCM, Token: " phone "})
+	require.NoError(t, err)
+	assert.Equal(t, "phone", device.Token)
+	_, err = service.RegisterDevice(ctx, "42", models.PushDeviceReq{Platform: models.PushPlatformFCM, Token: "gone"})
+	require.NoError(t, err)
+
+	order := &models.Order{ID: "3f2a9c1e-0000-0000-0000-000000000000", CustomerID: "42"}
+	_, err = service.OrderReady(ctx, order)
+	assert.ErrorIs(t, err, services.ErrPushTokenInvalid)
+	assert.Equal(t, []string{"phone|Your order is ready"}, pushed.pushes)
+
+	listed, err := service.ListDevices(ctx, "42")
+	require.NoError(t, err)
+	require.Len(t, listed, 1, "a token the provider rejected is forgotten")
+	assert.Equal(t, "phone", listed[0].Token)
+
+	// Turned off, nothing is pushed
+	enabled = false
+	_, err = service.OrderPlaced(ctx, order)
+	require.NoError(t, err)
+	assert.Len(t, pushed.pushes, 1)
+
+	// The token moves to whoever registers it last
+	_, err = service.RegisterDevice(ctx, "7", models.PushDeviceReq{Platform: models.PushPlatformFCM, Token: "phone"})
+	require.NoError(t, err)
+	assert.ErrorIs(t, service.UnregisterDevice(ctx, "42", "phone"), services.ErrPushDeviceNotFound)
+	require.NoError(t, service.UnregisterDevice(ctx, "7", "phone"))
+
+	listed, err = service.ListDevices(ctx, "7")
+	require.NoError(t, err)
+	assert.NotNil(t, listed)
+	assert.Empty(t, listed)
+}
+
+func TestNotificationService_PushThroughOutbox(t *testing.T) {
+	ctx := context.Background()
+	devices := repository.NewMemoryPushDeviceRepository()
+	outbox := repository.NewMemoryNotificationOutboxRepository()
+	pushed := &pushedMessages{invalid: map[string]bool{"gone": true}}
+	service := services.NewNotificationService(repository.NewMemoryNotificationRepository(), nil, nil, services.NotificationOptions{
+		Push:        map[string]services.PushSender{models.PushPlatformFCM: pushed},
+		Devices:     devices,
+		Outbox:      outbox,
+		MaxAttempts: 5,
+	})
+	for _, token := range []string{"phone", "gone"} {
+		_, err := service.RegisterDevice(ctx, "42", models.PushDeviceReq{Platform: models.PushPlatformFCM, Token: token})
+		require.NoError(t, err)
+	}
+
+	_, err := service.OrderReady(ctx, &models.Order{ID: "3f2a9c1e-0000-0000-0000-000000000000", CustomerID: "42"})
+	require.NoError(t, err)
+
+	result, err := service.DeliverBatch(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Sent)
+	assert.Equal(t, 1, result.Dead, "a rejected token isn't retried")
+	assert.Equal(t, []string{"phone|Your order is ready"}, pushed.pushes)
+}
+
+func TestFCMPushSender(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	tokenRequests := 0
+	var messages []map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/token" {
+			tokenRequests++
+			require.NoError(t, r.ParseForm())
+			assert.Equal(t, "urn:ietf:params:oauth:grant-type:jwt-bearer", r.PostForm.Get("grant_type"))
+			assert.Len(t, strings.Split(r.PostForm.Get("assertion"), "."), 3)
+			json.NewEncoder(w).Encode(map[string]any{"access_token": "access", "expires_in": 3600})
+			return
+		}
+
+		assert.Equal(t, "/v1/projects/oolio-app/messages:send", r.URL.Path)
+		assert.Equal(t, "Bearer access", r.Header.Get("Authorization"))
+		var body struct {
+			Message map[string]any `json:"message"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		if body.Message["token"] == "gone" {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]any{"error": map[string]any{"status": "NOT_FOUND", "message": "Requested entity was not found."}})
+			return
+		}
+		messages = append(messages, body.Message)
+		json.NewEncoder(w).Encode(map[string]any{"name": "projects/oolio-app/messages/1"})
+	}))
+	defer server.Close()
+
+	account := filepath.Join(t.TempDir(), "service-account.json")
+	data, err := json.Marshal(map[string]string{
+		"project_id":   "oolio-app",
+		"client_email": "push@oolio-app.iam.gserviceaccount.com",
+		"private_key":  pemKey(t, key),
+		"token_uri":    server.URL + "/token",
+	})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(account, data, 0o600))
+
+	opts, err := services.LoadFCMServiceAccount(account)
+	require.NoError(t, err)
+	opts.BaseURL = server.URL
+	opts.Timeout = time.Second
+	sender, err := services.NewFCMPushSender(opts)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, sender.SendPush(ctx, "phone", services.Push{Title: "Your order is ready", Body: "Order 3F2A9C1E is ready.", OrderID: "3f2a9c1e"}))
+	require.NoError(t, sender.SendPush(ctx, "tablet", services.Push{Title: "Order received"}))
+	assert.ErrorIs(t, sender.SendPush(ctx, "gone", services.Push{Title: "Order received"}), services.ErrPushTokenInvalid)
+
+	assert.Equal(t, 1, tokenRequests, "the access token is reused")
+	require.Len(t, messages, 2)
+	assert.Equal(t, map[string]any{"title": "Your order is ready", "body": "Order 3F2A9C1E is ready."}, messages[0]["notification"])
+	assert.Equal(t, map[string]any{"orderId": "3f2a9c1e"}, messages[0]["data"])
+}
+
+func TestAPNsPushSender(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	var topics []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "bearer "))
+		assert.Equal(t, "alert", r.Header.Get("apns-push-type"))
+		if r.URL.Path == "/3/device/gone" {
+			w.WriteHeader(http.StatusGone)
+			json.NewEncoder(w).Encode(map[string]any{"reason": "Unregistered"})
+			return
+		}
+		assert.Equal(t, "/3/device/phone", r.URL.Path)
+		topics = append(topics, r.Header.Get("apns-topic"))
+	}))
+	defer server.Close()
+
+	sender, err := services.NewAPNsPushSender(services.APNsOptions{
+		KeyID:      "KEY123",
+		TeamID:     "TEAM123",
+		PrivateKey: pemKey(t, key),
+		Topic:      "com.oolio.app",
+		BaseURL:    server.URL,
+		Timeout:    time.Second,
+	})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, sender.SendPush(ctx, "phone", services.Push{Title: "Your order is ready"}))
+	assert.ErrorIs(t, sender.SendPush(ctx, "gone", services.Push{Title: "Your order is ready"}), services.ErrPushTokenInvalid)
+	assert.Equal(t, []string{"com.oolio.app"}, topics)
+
+	_, err = services.NewAPNsPushSender(services.APNsOptions{PrivateKey: "not a key"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"oolio/internal/app/models"
+	"oolio/internal/app/repository"
+	"oolio/internal/app/services"
+)
+
+// changedOrders is an order repository whose orders all changed within the window
+type changedOrders struct {
+	repository.OrderRepository
+	orders []models.Order
+}
+
+func (r *changedOrders) FindUpdatedSince(ctx context.Context, since time.Time) ([]models.Order, error) {
+	return r.orders, nil
+}
+
+func TestReconciliationService_Reconcile(t *testing.T) {
+	ctx := context.Background()
+	waffle := func(quantity int) []models.OrderItem {
+		return []models.OrderItem{{ProductID: "waffle", Quantity: quantity, Price: 900}}
+	}
+	orders := &changedOrders{orders: []models.Order{
+		{ID: "consistent", Total: 1800, Discounts: 180, CouponCode: "HAPPYHRS", Items: waffle(2)},
+		// Recorded at the product's current price rather than the one its item was sold at
+		{ID: "wrong-total", Total: 1000, Discounts: 100, CouponCode: "HAPPYHRS", Items: waffle(1), StoreID: models.DefaultStoreID},
+		{ID: "wrong-discount", Total: 900, Discounts: 50, Items: waffle(1)},
+		// The coupon's terms are unknown, so only the total is checked
+		{ID: "expired-coupon", Total: 900, Discounts: 450, CouponCode: "EXPIRED", Items: waffle(1)},
+		{ID: "cancelled", Total: 1, Items: waffle(1), Status: models.OrderStatusCancelled},
+	}}
+	coupons := services.NewCouponService(services.CouponOptions{
+		Discounts: map[string]float64{"happyhrs": 10},
+	}, zap.NewNop())
+	service := services.NewReconciliationService(orders, repository.NewMemoryOrderDiscrepancyRepository(), coupons, zap.NewNop())
+
+	result, err := service.Reconcile(ctx, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 4, result.Checked, "cancelled orders are skipped")
+	assert.Equal(t, 3, result.Found)
+
+	report, err := service.Discrepancies(ctx, "")
+	require.NoError(t, err)
+	require.Equal(t, 3, report.Count)
+	found := make(map[string]models.OrderDiscrepancy)
+	for _, discrepancy := range report.Discrepancies {
+		found[discrepancy.OrderID+"/"+discrepancy.Kind] = discrepancy
+	}
+	assert.Equal(t, models.Money(900), found["wrong-total/total"].Expected)
+	assert.Equal(t, models.Money(100), found["wrong-total/total"].Difference)
+	assert.Equal(t, models.Money(90), found["wrong-total/discount"].Expected, "discounts are recomputed from the items' total")
+	assert.Equal(t, "HAPPYHRS", found["wrong-total/discount"].CouponCode)
+	assert.Equal(t, models.Money(0), found["wrong-discount/discount"].Expected)
+
+	byStore, err := service.Discrepancies(ctx, models.DefaultStoreID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, byStore.Count)
+
+	// Fixed orders drop out of the report on the next run; the others keep when they were found
+	detectedAt := found["wrong-total/total"].DetectedAt
+	orders.orders[2].Discounts = 0
+
+	_, err = service.Reconcile(ctx, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	report, err = service.Discrepancies(ctx, "")
+	require.NoError(t, err)
+	require.Equal(t, 2, report.Count)
+	for _, discrepancy := range report.Discrepancies {
+		assert.Equal(t, "wrong-total", discrepancy.OrderID)
+		assert.Equal(t, detectedAt, discrepancy.DetectedAt)
+	}
+}
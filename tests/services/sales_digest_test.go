@@ -0,0 +1,138 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"oolio/internal/app/events"
+	"oolio/internal/app/models"
+	"oolio/internal/app/repository"
+	"oolio/internal/app/services"
+)
+
+func seedDigestOrders(t *testing.T) repository.OrderRepository {
+	orders := repository.NewMemoryOrderRepository()
+	waffle := models.Product{ID: "waffle", Name: "Waffle"}
+	for _, order := range []*models.Order{
+		{
+			Total:      1800,
+			Discounts:  180,
+			Items:      []models.OrderItem{{ProductID: "waffle", Quantity: 2, Price: 900}},
+			Products:   []models.Product{waffle},
+			CouponCode: "HAPPYHRS",
+		},
+		{
+			Total:         900,
+			Items:         []models.OrderItem{{ProductID: "waffle", Quantity: 1, Price: 900}},
+			Products:      []models.Product{waffle},
+			PaymentMethod: models.PaymentMethodCash,
+		},
+	} {
+		require.NoError(t, orders.Create(context.Background(), order))
+	}
+	return orders
+}
+
+func TestSalesDigestService_Build(t *testing.T) {
+	ctx := context.Background()
+	service := services.NewSalesDigestService(seedDigestOrders(t), nil, nil, services.SalesDigestOptions{}, zap.NewNop())
+
+	today := time.Now().UTC()
+	digest, err := service.Build(ctx, today)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC), digest.From)
+	assert.Equal(t, digest.From.AddDate(0, 0, 1), digest.To)
+	assert.Equal(t, 2, digest.Orders)
+	assert.Equal(t, models.Money(2520), digest.Revenue)
+	assert.Equal(t, []models.ProductSales{{ProductID: "waffle", Name: "Waffle", Quantity: 3, Revenue: 2700}}, digest.TopProducts)
+	assert.Equal(t, []models.CouponUsage{{Code: "HAPPYHRS", Orders: 1, Discounts: 180}}, digest.Coupons)
+
+	yesterday, err := service.Build(ctx, today.AddDate(0, 0, -1))
+	require.NoError(t, err)
+	assert.Zero(t, yesterday.Orders)
+	assert.Empty(t, yesterday.TopProducts)
+}
+
+func TestSalesDigestService_Send(t *testing.T) {
+	ctx := context.Background()
+	var received events.Event
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	email := &sentMessages{}
+	webhooks, _ := newWebhookService(server.URL, 3)
+	service := services.NewSalesDigestService(seedDigestOrders(t), email, webhooks, services.SalesDigestOptions{
+		Recipients: []string{"owner@example.com", "manager@example.com"},
+		Webhook:    true,
+		Source:     "/oolio",
+	}, zap.NewNop())
+
+	digest, err := service.Build(ctx, time.Now().UTC())
+	require.NoError(t, err)
+	require.NoError(t, service.Send(ctx, digest))
+	result, err := webhooks.DeliverBatch(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Delivered)
+
+	subject := "Sales for " + digest.From.Format("Mon 2 Jan 2006") + ": 2 orders, 25.20"
+	assert.Equal(t, []string{"owner@example.com|" + subject, "manager@example.com|" + subject}, email.emails)
+
+	day := digest.From.Format(time.DateOnly)
+	assert.Equal(t, "sales-digest:"+day, received.ID)
+	assert.Equal(t, "com.oolio.sales.digest", received.Type)
+	assert.Equal(t, day, received.Subject)
+
+	var data models.SalesDigest
+	require.NoError(t, json.Unmarshal(received.Data, &data))
+	assert.Equal(t, 2, data.Orders)
+	assert.Equal(t, digest.Coupons, data.Coupons)
+}
+
+func TestSalesDigestService_SendReportsFailures(t *testing.T) {
+	ctx := context.Background()
+	email := &flakyEmailSender{failures: 1}
+	webhooks, _ := newWebhookService("http://receiver.example.com", 3)
+	service := services.NewSalesDigestService(seedDigestOrders(t), email, webhooks, services.SalesDigestOptions{
+		Recipients: []string{"owner@example.com", "manager@example.com"},
+		Webhook:    true,
+	}, zap.NewNop())
+
+	digest, err := service.Build(ctx, time.Now().UTC())
+	require.NoError(t, err)
+
+	// One recipient not getting it doesn't keep the digest from the others
+	err = service.Send(ctx, digest)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "owner@example.com")
+	assert.Len(t, email.emails, 1)
+	_, queued, err := webhooks.ListDeliveries(ctx, models.WebhookPending, models.PageRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, queued)
+}
+
+func TestSalesDigestService_Disabled(t *testing.T) {
+	ctx := context.Background()
+	// Recipients alone aren't enough without an email provider, nor the webhook without the
+	// webhook service
+	service := services.NewSalesDigestService(seedDigestOrders(t), nil, nil, services.SalesDigestOptions{
+		Recipients: []string{"owner@example.com"},
+		Webhook:    true,
+	}, zap.NewNop())
+
+	digest, err := service.Build(ctx, time.Now().UTC())
+	require.NoError(t, err)
+	assert.ErrorIs(t, service.Send(ctx, digest), services.ErrSalesDigestDisabled)
+
+	assert.False(t, service.Enabled(), "the digest isn't scheduled")
+}
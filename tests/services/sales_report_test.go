@@ -0,0 +1,85 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"oolio/internal/app/models"
+	"oolio/internal/app/repository"
+	"oolio/internal/app/services"
+)
+
+func TestSalesReportService_Build(t *testing.T) {
+	ctx := context.Background()
+	orders := repository.NewMemoryOrderRepository()
+	waffle := models.Product{ID: "waffle", Name: "Waffle", Category: "Waffle"}
+	coffee := models.Product{ID: "coffee", Name: "Coffee", Category: "Drinks"}
+	for _, order := range []*models.Order{
+		{
+			Total:     2000,
+			Discounts: 200,
+			Items:     []models.OrderItem{{ProductID: "waffle", Quantity: 2, Price: 600}, {ProductID: "coffee", Quantity: 2, Price: 400}},
+			Products:  []models.Product{waffle, coffee},
+		},
+		{
+			Total:    600,
+			Items:    []models.OrderItem{{ProductID: "waffle", Quantity: 1, Price: 600}},
+			Products: []models.Product{waffle},
+		},
+		// Placed with a test key, so it never counts as a sale
+		{
+			Total:    1200,
+			Items:    []models.OrderItem{{ProductID: "coffee", Quantity: 3, Price: 400}},
+			Products: []models.Product{coffee},
+			Sandbox:  true,
+		},
+	} {
+		require.NoError(t, orders.Create(ctx, order))
+	}
+	service := services.NewSalesReportService(orders, nil)
+	today := time.Now().UTC()
+	totals := models.SalesFigures{Orders: 2, Revenue: 2400, Discounts: 200, AverageOrderValue: 1200}
+
+	byDay, err := service.Build(ctx, "", today.AddDate(0, 0, -6), today, "")
+	require.NoError(t, err)
+	assert.Equal(t, models.SalesByDay, byDay.GroupBy)
+	assert.Equal(t, "UTC", byDay.TimeZone)
+	assert.Equal(t, time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, -6), byDay.From)
+	assert.Equal(t, totals, byDay.Totals)
+	assert.Equal(t, []models.SalesGroup{{Key: today.Format(time.DateOnly), SalesFigures: totals}}, byDay.Groups)
+
+	// The discounts of the first order are shared in proportion to what each item added
+	byProduct, err := service.Build(ctx, "product", today, today, "")
+	require.NoError(t, err)
+	assert.Equal(t, totals, byProduct.Totals)
+	assert.Equal(t, []models.SalesGroup{
+		{Key: "waffle", Name: "Waffle", Quantity: 3, SalesFigures: models.SalesFigures{Orders: 2, Revenue: 1680, Discounts: 120, AverageOrderValue: 840}},
+		{Key: "coffee", Name: "Coffee", Quantity: 2, SalesFigures: models.SalesFigures{Orders: 1, Revenue: 720, Discounts: 80, AverageOrderValue: 720}},
+	}, byProduct.Groups)
+
+	byCategory, err := service.Build(ctx, "Category", today, today, "")
+	require.NoError(t, err)
+	require.Len(t, byCategory.Groups, 2)
+	assert.Equal(t, "Waffle", byCategory.Groups[0].Key)
+	assert.Equal(t, "Drinks", byCategory.Groups[1].Key)
+
+	// Another store's orders and days without orders are left out
+	other, err := service.Build(ctx, "day", today, today, "7f0a7d5e-3b8a-4d8e-9a53-2a6f4d0e9c11")
+	require.NoError(t, err)
+	assert.Equal(t, models.SalesFigures{}, other.Totals)
+	assert.Empty(t, other.Groups)
+	earlier, err := service.Build(ctx, "day", today.AddDate(0, 0, -3), today.AddDate(0, 0, -1), "")
+	require.NoError(t, err)
+	assert.Empty(t, earlier.Groups)
+
+	_, err = service.Build(ctx, "week", today, today, "")
+	assert.ErrorIs(t, err, services.ErrInvalidSalesGrouping)
+	_, err = service.Build(ctx, "day", today, today.AddDate(0, 0, -1), "")
+	assert.ErrorIs(t, err, services.ErrInvalidSalesRange)
+	_, err = service.Build(ctx, "day", today.AddDate(-1, 0, -1), today, "")
+	assert.ErrorIs(t, err, services.ErrInvalidSalesRange)
+}
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"oolio/internal/app/models"
+	"oolio/internal/app/repository"
+	"oolio/internal/app/services"
+)
+
+func TestScheduleFeedLinks(t *testing.T) {
+	assert.Nil(t, services.NewScheduleFeedLinks("", "https://api.example.com", time.Hour), "links need a secret")
+	assert.False(t, (*services.ScheduleFeedLinks)(nil).Valid("", "1", "x"))
+
+	links := services.NewScheduleFeedLinks("secret", "https://api.example.com/", time.Hour)
+	issued := links.Issue(models.DefaultStoreID)
+	ics, err := url.Parse(issued.ICS)
+	require.NoError(t, err)
+	assert.Equal(t, "https://api.example.com/api/v1/admin/orders/schedule.ics", ics.Scheme+"://"+ics.Host+ics.Path)
+	assert.True(t, strings.HasPrefix(issued.CSV, "https://api.example.com/api/v1/admin/orders/schedule.csv?"))
+
+	query := ics.Query()
+	expires, signature := query.Get("expires"), query.Get("signature")
+	assert.Equal(t, models.DefaultStoreID, query.Get("store_id"))
+	assert.True(t, links.Valid(models.DefaultStoreID, expires, signature))
+	assert.False(t, links.Valid("", expires, signature), "a store's URL doesn't list every store")
+	assert.False(t, links.Valid(models.DefaultStoreID, expires+"0", signature), "the expiry is signed")
+
+	expired := services.NewScheduleFeedLinks("secret", "https://api.example.com", -time.Minute)
+	query = mustQuery(t, expired.Issue("").ICS)
+	assert.False(t, expired.Valid("", query.Get("expires"), query.Get("signature")))
+}
+
+func mustQuery(t *testing.T, link string) url.Values {
+	t.Helper()
+	parsed, err := url.Parse(link)
+	require.NoError(t, err)
+	return parsed.Query()
+}
+
+func TestScheduleService_Upcoming(t *testing.T) {
+	ctx := context.Background()
+	queueRepo := repository.NewMemoryOrderQueueRepository()
+	products := repository.NewMemoryProductRepository()
+	waffle := &models.Product{Name: "Waffle, with syrup", Price: 8, Category: "Waffle"}
+	require.NoError(t, products.Create(ctx, waffle))
+	queue := services.NewOrderQueueService(services.OrderQueueDeps{
+		QueueRepo: queueRepo,
+		OrderRepo: repository.NewMemoryOrderRepository(),
+	}, nil, "test-worker")
+
+	now := time.Now()
+	later, soon := now.Add(3*time.Hour), now.Add(time.Hour)
+	for _, req := range []models.OrderReq{
+		{Items: []models.OrderItem{{ProductID: waffle.ID, Quantity: 2}}, ScheduledFor: &later},
+		{Items: []models.OrderItem{{ProductID: "gone", Quantity: 1}}, ScheduledFor: &soon, DeliveryAddress: &models.DeliveryAddress{Line1: "1 Main St"}},
+		{Items: []models.OrderItem{{ProductID: waffle.ID, Quantity: 1}}},
+	} {
+		_, err := queue.AddOrderToQueue(ctx, &req)
+		require.NoError(t, err)
+	}
+	otherStore := now.Add(2 * time.Hour)
+	_, err := queue.AddOrderToQueue(ctx, &models.OrderReq{Items: []models.OrderItem{{ProductID: waffle.ID, Quantity: 1}}, ScheduledFor: &otherStore, StoreID: "other-store"})
+	require.NoError(t, err)
+
+	orders, err := services.NewScheduleService(queueRepo, products).Upcoming(ctx, models.DefaultStoreID, now)
+	require.NoError(t, err)
+	require.Len(t, orders, 2, "only the store's scheduled orders")
+	assert.True(t, soon.Equal(orders[0].ScheduledFor), "soonest first")
+	assert.Equal(t, "gone", orders[0].Items[0].Name, "products that no longer exist go by their ID")
+	assert.True(t, orders[0].Delivery)
+	assert.Equal(t, "Waffle, with syrup", orders[1].Items[0].Name)
+	assert.Equal(t, 2, orders[1].Items[0].Quantity)
+
+	var ics bytes.Buffer
+	require.NoError(t, services.WriteScheduleICS(&ics, orders, now))
+	feed := ics.String()
+	assert.True(t, strings.HasPrefix(feed, "BEGIN:VCALENDAR\r\n"))
+	assert.True(t, strings.HasSuffix(feed, "END:VCALENDAR\r\n"))
+	assert.Equal(t, 2, strings.Count(feed, "BEGIN:VEVENT\r\n"))
+	assert.Contains(t, feed, "UID:"+orders[1].QueueItemID+"@oolio\r\n")
+	assert.Contains(t, feed, "DTSTART:"+later.UTC().Format("20060102T150405Z")+"\r\n")
+	assert.Contains(t, feed, `2 × Waffle\, with syrup\n`, "text values are escaped")
+	for _, line := range strings.Split(feed, "\r\n") {
+		assert.LessOrEqual(t, len(line), 75, "lines are folded")
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, services.WriteScheduleCSV(&out, orders))
+	rows, err := csv.NewReader(&out).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 3)
+	assert.Equal(t, "scheduled_for", rows[0][0])
+	assert.Equal(t, []string{orders[1].QueueItemID, models.DefaultStoreID, "pending", "2", "2 × Waffle, with syrup"}, rows[2][1:6])
+}
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"oolio/internal/app/models"
+	"oolio/internal/app/repository"
+	"oolio/internal/app/services"
+)
+
+var testSegmentRules = models.SegmentRules{
+	NewWindow:   30 * 24 * time.Hour,
+	LapsedAfter: 90 * 24 * time.Hour,
+	VIPSpend:    50000,
+	VIPWindow:   365 * 24 * time.Hour,
+}
+
+func TestSegmentRules_Segment(t *testing.T) {
+	now := time.Now()
+	day := 24 * time.Hour
+
+	assert.Equal(t, models.SegmentNew, testSegmentRules.Segment(now, now.Add(-day), now.Add(-day), 1000))
+	assert.Equal(t, models.SegmentRegular, testSegmentRules.Segment(now, now.Add(-60*day), now.Add(-day), 1000))
+	assert.Equal(t, models.SegmentVIP, testSegmentRules.Segment(now, now.Add(-day), now.Add(-day), 50000))
+	assert.Equal(t, models.SegmentLapsed, testSegmentRules.Segment(now, now.Add(-200*day), now.Add(-100*day), 90000),
+		"lapsed wins over VIP so win-back promotions reach former big spenders")
+}
+
+func TestSegmentService_Refresh(t *testing.T) {
+	ctx := context.Background()
+	orders := repository.NewMemoryOrderRepository()
+	repo := repository.NewMemorySegmentRepository(orders)
+	service := services.NewSegmentService(repo, testSegmentRules, zap.NewNop())
+
+	require.NoError(t, orders.Create(ctx, &models.Order{CustomerID: "small", Total: 1000}))
+	require.NoError(t, orders.Create(ctx, &models.Order{CustomerID: "big", Total: 40000}))
+	require.NoError(t, orders.Create(ctx, &models.Order{CustomerID: "big", Total: 20000, Discounts: 2000}))
+	require.NoError(t, orders.Create(ctx, &models.Order{Total: 90000}))
+
+	require.NoError(t, service.Refresh(ctx))
+
+	for customerID, want := range map[string]string{
+		"small":   models.SegmentNew,
+		"big":     models.SegmentVIP,
+		"unknown": models.SegmentNew,
+		"":        "",
+	} {
+		segment, err := service.SegmentFor(ctx, customerID)
+		require.NoError(t, err)
+		assert.Equal(t, want, segment, customerID)
+	}
+
+	big, err := repo.FindByCustomer(ctx, "big")
+	require.NoError(t, err)
+	assert.Equal(t, 2, big.OrderCount)
+	assert.Equal(t, models.Money(58000), big.TotalSpent, "spend is after discounts")
+
+	// A hundred days on, nobody has ordered since
+	_, err = repo.Refresh(ctx, testSegmentRules, time.Now().Add(100*24*time.Hour))
+	require.NoError(t, err)
+	segment, err := service.SegmentFor(ctx, "big")
+	require.NoError(t, err)
+	assert.Equal(t, models.SegmentLapsed, segment)
+}
+
+func TestOrderService_SegmentCoupon(t *testing.T) {
+	ctx := context.Background()
+	orders := repository.NewMemoryOrderRepository()
+	products := repository.NewMemoryProductRepository()
+	seeded, err := products.Find(ctx)
+	require.NoError(t, err)
+	require.NotEmpty(t, seeded)
+
+	coupons := services.NewCouponService(services.CouponOptions{
+		Discounts: map[string]float64{"welcome10": 10},
+		Segments:  map[string]string{"welcome10": "NEW"},
+	}, zap.NewNop())
+	assert.Equal(t, models.SegmentNew, coupons.RequiredSegment("WELCOME10"))
+	assert.Empty(t, coupons.RequiredSegment("HAPPYHRS"))
+
+	segments := services.NewSegmentService(repository.NewMemorySegmentRepository(orders), testSegmentRules, zap.NewNop())
+	service := services.NewOrderService(orders, products, repository.NewMemoryOrderQueueRepository(), coupons, segments, nil, nil, nil, nil, nil, nil, zap.NewNop())
+
+	order, err := service.CreateOrder(ctx, &models.OrderReq{
+		CustomerID: "first-timer",
+		CouponCode: "WELCOME10",
+		Items:      []models.OrderItem{{ProductID: seeded[0].ID, Quantity: 1}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, seeded[0].Price.Percent(10), order.Discounts)
+	assert.Equal(t, "first-timer", order.CustomerID)
+
+	require.NoError(t, orders.Create(ctx, &models.Order{CustomerID: "regular", Total: 90000}))
+	require.NoError(t, segments.Refresh(ctx))
+
+	_, err = service.CreateOrder(ctx, &models.OrderReq{
+		CustomerID: "regular",
+		CouponCode: "WELCOME10",
+		Items:      []models.OrderItem{{ProductID: seeded[0].ID, Quantity: 1}},
+	})
+	assert.ErrorContains(t, err, "coupon WELCOME10 is not available to this customer")
+
+	_, err = service.CreateOrder(ctx, &models.OrderReq{
+		CouponCode: "WELCOME10",
+		Items:      []models.OrderItem{{ProductID: seeded[0].ID, Quantity: 1}},
+	})
+	assert.ErrorContains(t, err, "not available to this customer", "guests are in no segment")
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"oolio/internal/app/models"
+	"oolio/internal/app/services"
+)
+
+// countingQueueService reports a fixed pending count and how often it was asked
+type countingQueueService struct {
+	services.OrderQueueService
+	pending int
+	err     error
+	calls   int
+}
+
+func (q *countingQueueService) GetQueueStatus(ctx context.Context) (map[string]int, error) {
+	q.calls++
+	return map[string]int{"pending": q.pending}, q.err
+}
+
+type stubBreaker bool
+
+func (b stubBreaker) Open() bool { return bool(b) }
+
+func newStatusService(queue services.OrderQueueService, breaker services.Breaker) services.StatusService {
+	return services.NewStatusService(services.StatusOptions{
+		Database:         breaker,
+		Queue:            queue,
+		WorkerInterval:   5 * time.Second,
+		WorkerBatchSize:  func() int { return 10 },
+		BacklogThreshold: 50,
+		CacheTTL:         time.Minute,
+	})
+}
+
+func TestStatusService_Status(t *testing.T) {
+	ctx := context.Background()
+
+	queue := &countingQueueService{pending: 25}
+	status := newStatusService(queue, stubBreaker(false)).Status(ctx)
+	assert.Equal(t, models.StatusOperational, status.Status)
+	assert.True(t, status.AcceptingOrders)
+	require.NotNil(t, status.EstimatedWaitSeconds)
+	// Two full batches ahead plus the order's own
+	assert.Equal(t, 15, *status.EstimatedWaitSeconds)
+
+	status = newStatusService(&countingQueueService{pending: 50}, stubBreaker(false)).Status(ctx)
+	assert.Equal(t, models.StatusDegraded, status.Status)
+	assert.True(t, status.AcceptingOrders)
+
+	status = newStatusService(&countingQueueService{err: errors.New("timeout")}, stubBreaker(false)).Status(ctx)
+	assert.Equal(t, models.StatusDegraded, status.Status)
+	assert.Nil(t, status.EstimatedWaitSeconds)
+
+	status = newStatusService(&countingQueueService{}, stubBreaker(true)).Status(ctx)
+	assert.Equal(t, models.StatusUnavailable, status.Status)
+	assert.False(t, status.AcceptingOrders)
+}
+
+func TestStatusService_Caches(t *testing.T) {
+	ctx := context.Background()
+	queue := &countingQueueService{pending: 1}
+	statusService := newStatusService(queue, nil)
+
+	first := statusService.Status(ctx)
+	queue.pending = 100
+	second := statusService.Status(ctx)
+
+	assert.Equal(t, first, second)
+	assert.Equal(t, 1, queue.calls)
+}
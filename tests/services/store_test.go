@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"oolio/internal/app/models"
+	"oolio/internal/app/repository"
+	"oolio/internal/app/services"
+)
+
+func TestStoreService_Stores(t *testing.T) {
+	ctx := context.Background()
+	stores := services.NewStoreService(repository.NewMemoryStoreRepository())
+
+	all, err := stores.ListStores(ctx)
+	require.NoError(t, err)
+	require.Len(t, all, 1)
+	assert.Equal(t, models.DefaultStoreID, all[0].ID)
+
+	store, err := stores.CreateStore(ctx, models.StoreReq{Name: "  Harbour St  "})
+	require.NoError(t, err)
+	assert.Equal(t, "Harbour St", store.Name)
+
+	store, err = stores.UpdateStore(ctx, store.ID, models.StoreReq{Name: "Harbour Street"})
+	require.NoError(t, err)
+	assert.Equal(t, "Harbour Street", store.Name)
+
+	_, err = stores.CreateStore(ctx, models.StoreReq{Name: " "})
+	assert.ErrorIs(t, err, services.ErrInvalidStoreName)
+	_, err = stores.CreateStore(ctx, models.StoreReq{Name: strings.Repeat("x", 101)})
+	assert.ErrorIs(t, err, services.ErrInvalidStoreName)
+	_, err = stores.UpdateStore(ctx, "missing", models.StoreReq{Name: "Anywhere"})
+	assert.ErrorContains(t, err, "store not found")
+}
+
+func TestStoreService_APIKeys(t *testing.T) {
+	ctx := context.Background()
+	stores := services.NewStoreService(repository.NewMemoryStoreRepository())
+	store, err := stores.CreateStore(ctx, models.StoreReq{Name: "Harbour St"})
+	require.NoError(t, err)
+
+	key, err := stores.CreateAPIKey(ctx, store.ID, models.StoreAPIKeyReq{Name: "Till 1"})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(key.Key, "sk_"))
+	assert.True(t, strings.HasPrefix(key.Key, key.Prefix))
+
+	resolved, err := stores.ResolveAPIKey(ctx, key.Key)
+	require.NoError(t, err)
+	assert.Equal(t, store.ID, resolved.StoreID)
+	assert.False(t, resolved.Test)
+
+	// The key itself is only returned when it is created
+	keys, err := stores.ListAPIKeys(ctx, store.ID)
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.Empty(t, keys[0].Key)
+	assert.Equal(t, key.Prefix, keys[0].Prefix)
+
+	_, err = stores.ResolveAPIKey(ctx, "secret-key")
+	assert.ErrorIs(t, err, services.ErrUnknownAPIKey)
+	_, err = stores.ResolveAPIKey(ctx, key.Key+"0")
+	assert.ErrorIs(t, err, services.ErrUnknownAPIKey)
+
+	assert.ErrorContains(t, stores.RevokeAPIKey(ctx, models.DefaultStoreID, key.ID), "store API key not found",
+		"a key is revoked through its own store")
+	require.NoError(t, stores.RevokeAPIKey(ctx, store.ID, key.ID))
+	_, err = stores.ResolveAPIKey(ctx, key.Key)
+	assert.ErrorIs(t, err, services.ErrUnknownAPIKey)
+	assert.ErrorContains(t, stores.RevokeAPIKey(ctx, store.ID, key.ID), "store API key not found")
+
+	_, err = stores.CreateAPIKey(ctx, "missing", models.StoreAPIKeyReq{Name: "Till 2"})
+	assert.ErrorContains(t, err, "store not found")
+}
+
+func TestStoreService_TestAPIKeys(t *testing.T) {
+	ctx := context.Background()
+	stores := services.NewStoreService(repository.NewMemoryStoreRepository())
+
+	key, err := stores.CreateAPIKey(ctx, models.DefaultStoreID, models.StoreAPIKeyReq{Name: "Integration", Test: true})
+	require.NoError(t, err)
+	assert.True(t, key.Test)
+
+	resolved, err := stores.ResolveAPIKey(ctx, key.Key)
+	require.NoError(t, err)
+	assert.Equal(t, models.DefaultStoreID, resolved.StoreID)
+	assert.True(t, resolved.Test, "orders placed with it are sandbox orders")
+
+	keys, err := stores.ListAPIKeys(ctx, models.DefaultStoreID)
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.True(t, keys[0].Test)
+}
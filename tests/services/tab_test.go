@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"oolio/internal/app/models"
+	"oolio/internal/app/repository"
+	"oolio/internal/app/services"
+)
+
+func TestTabService_RoundsAndClose(t *testing.T) {
+	ctx := context.Background()
+	products := repository.NewMemoryProductRepository()
+	waffle := &models.Product{Name: "Waffle", Price: 8, Category: "Waffle"}
+	require.NoError(t, products.Create(ctx, waffle))
+
+	orders := repository.NewMemoryOrderRepository()
+	queueRepo := repository.NewMemoryOrderQueueRepository()
+	orderService := services.NewOrderService(orders, products, queueRepo, nil, nil, nil, nil, nil, nil, nil, nil, zap.NewNop())
+	queue := services.NewOrderQueueService(services.OrderQueueDeps{
+		QueueRepo:    queueRepo,
+		OrderRepo:    orders,
+		OrderService: orderService,
+	}, nil, "test-worker")
+	tabs := services.NewTabService(repository.NewMemoryTabRepository(), queueRepo, services.PaymentPolicy{})
+
+	_, err := tabs.Join(ctx, "", "12 ")
+	assert.ErrorIs(t, err, services.ErrInvalidTable)
+	_, err = tabs.Get(ctx, "", "12")
+	assert.ErrorIs(t, err, services.ErrTabNotFound)
+
+	// Each order placed at the table is a round of the same tab
+	placeRound := func(quantity int) {
+		tab, err := tabs.Join(ctx, "", "12")
+		require.NoError(t, err)
+		_, err = queue.AddOrderToQueue(ctx, &models.OrderReq{
+			Items:       []models.OrderItem{{ProductID: waffle.ID, Quantity: quantity}},
+			TableNumber: "12",
+			TabID:       tab.ID,
+		})
+		require.NoError(t, err)
+	}
+	placeRound(1)
+	placeRound(2)
+
+	tab, err := tabs.Get(ctx, "", "12")
+	require.NoError(t, err)
+	assert.Equal(t, models.DefaultStoreID, tab.StoreID)
+	assert.Equal(t, models.TabStatusOpen, tab.Status)
+	require.Len(t, tab.Rounds, 2)
+	assert.Equal(t, "pending", tab.Rounds[0].Status)
+	assert.Equal(t, 1, tab.Rounds[0].Items[0].Quantity)
+	assert.Equal(t, 2, tab.Rounds[1].Items[0].Quantity)
+	assert.Zero(t, tab.Total, "no order has been created yet")
+
+	// The kitchen sees the table with its rounds; other tables have no tab
+	kitchen, err := tabs.Kitchen(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, kitchen, 1)
+	assert.Equal(t, "12", kitchen[0].TableNumber)
+	assert.Equal(t, tab.ID, kitchen[0].TabID)
+	assert.Len(t, kitchen[0].Rounds, 2)
+
+	_, err = tabs.Close(ctx, "", "12", "card")
+	assert.ErrorIs(t, err, services.ErrTabOrdersQueued)
+
+	_, err = queue.ProcessBatch(ctx, 10)
+	require.NoError(t, err)
+
+	_, err = tabs.Close(ctx, "", "12", "cheque")
+	assert.ErrorIs(t, err, services.ErrPaymentMethodNotAccepted)
+
+	closed, err := tabs.Close(ctx, "", "12", " Card")
+	require.NoError(t, err)
+	assert.Equal(t, models.TabStatusClosed, closed.Status)
+	assert.Equal(t, models.PaymentMethodCard, closed.PaymentMethod)
+	require.NotNil(t, closed.ClosedAt)
+	require.Len(t, closed.Rounds, 2)
+	assert.NotEmpty(t, closed.Rounds[0].OrderID)
+	assert.Equal(t, models.Money(24), closed.Total)
+
+	_, err = tabs.Close(ctx, "", "12", "card")
+	assert.ErrorIs(t, err, services.ErrTabNotFound)
+	kitchen, err = tabs.Kitchen(ctx, "")
+	require.NoError(t, err)
+	assert.Empty(t, kitchen)
+
+	// The table's next order opens a new tab
+	next, err := tabs.Join(ctx, "", "12")
+	require.NoError(t, err)
+	assert.NotEqual(t, tab.ID, next.ID)
+}
+
+func TestPaymentPolicy_CheckMethodForTables(t *testing.T) {
+	policy := services.PaymentPolicy{Methods: []string{models.PaymentMethodCard}}
+
+	orderReq := &models.OrderReq{TableNumber: "12", PaymentMethod: "card"}
+	require.NoError(t, policy.CheckMethod(orderReq))
+	assert.Equal(t, models.PaymentMethodCounter, orderReq.PaymentMethod, "table orders are paid when the tab is closed")
+	assert.False(t, policy.AwaitsPayment(orderReq))
+
+	assert.ErrorIs(t, policy.CheckMethod(&models.OrderReq{TableNumber: "12", PaymentIntentID: "pi_1"}), services.ErrTableOrderPaidOnTab)
+	assert.ErrorIs(t, policy.CheckMethod(&models.OrderReq{TableNumber: "12", DeliveryAddress: &testDeliveryAddress}), services.ErrTableOrderPaidOnTab)
+}
@@ -0,0 +1,155 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"oolio/internal/app/models"
+	"oolio/internal/app/repository"
+	"oolio/internal/app/services"
+)
+
+const (
+	twilioToken       = "twilio-token"
+	twilioCallbackURL = "https://api.oolio.test/api/v1/notifications/sms/status"
+)
+
+// recordedEvents is an outbox that keeps what was appended
+type recordedEvents struct {
+	repository.OutboxRepository
+	statuses map[string][]models.SMSStatus // by order
+}
+
+func (e *recordedEvents) Append(ctx context.Context, tx *sql.Tx, aggregateType, aggregateID, eventType string, payload any) error {
+	if e.statuses == nil {
+		e.statuses = make(map[string][]models.SMSStatus)
+	}
+	e.statuses[aggregateID] = append(e.statuses[aggregateID], payload.(models.SMSStatus))
+	return nil
+}
+
+// signTwilio signs a status callback the way Twilio does
+func signTwilio(callbackURL string, form url.Values) string {
+	names := make([]string, 0, len(form))
+	for name := range form {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	mac := hmac.New(sha1.New, []byte(twilioToken))
+	mac.Write([]byte(callbackURL))
+	for _, name := range names {
+		mac.Write([]byte(name + form.Get(name)))
+	}
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// fakeTwilio accepts messages, and rejects those to numbers in rejected
+func fakeTwilio(t *testing.T, rejected string) (*httptest.Server, *[]url.Values) {
+	var messages []url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, _ := r.BasicAuth()
+		assert.Equal(t, "AC123", user)
+		assert.Equal(t, twilioToken, password)
+		assert.Equal(t, "/2010-04-01/Accounts/AC123/Messages.json", r.URL.Path)
+		require.NoError(t, r.ParseForm())
+		messages = append(messages, r.PostForm)
+
+		w.Header().Set("Content-Type", "application/json")
+		if r.PostForm.Get("To") == rejected {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]any{"code": 21211, "message": "The 'To' number is not valid."})
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]any{"sid": "SM" + r.PostForm.Get("To")[1:], "status": "queued"})
+	}))
+	t.Cleanup(server.Close)
+	return server, &messages
+}
+
+func TestNotificationService_ReadyTextsThroughTwilio(t *testing.T) {
+	ctx := context.Background()
+	server, messages := fakeTwilio(t, "+61400000009")
+	twilio := services.NewTwilioSMSSender(services.TwilioOptions{
+		AccountSID: "AC123", AuthToken: twilioToken, From: "+61400000001", BaseURL: server.URL,
+		StatusCallbackURL: twilioCallbackURL, Timeout: time.Second,
+	})
+	events := &recordedEvents{}
+	enabled := true
+	service := services.NewNotificationService(repository.NewMemoryNotificationRepository(), nil, twilio, services.NotificationOptions{
+		Events:          events,
+		RateLimiter:     services.NewMemoryRateLimiterService(),
+		SMSPerHour:      2,
+		SMSReadyEnabled: func() bool { return enabled },
+	})
+	_, err := service.SavePreferences(ctx, "42", models.NotificationPreferencesReq{Phone: "+61400000000", SMSReadyAlert: true})
+	require.NoError(t, err)
+
+	order := &models.Order{ID: "3f2a9c1e-0000-0000-0000-000000000000", CustomerID: "42"}
+	notified, err := service.OrderReady(ctx, order)
+	require.NoError(t, err)
+	assert.True(t, notified)
+	require.Len(t, *messages, 1)
+	assert.Equal(t, "Your order 3F2A9C1E is ready.", (*messages)[0].Get("Body"))
+	assert.Equal(t, twilioCallbackURL+"?reference="+order.ID, (*messages)[0].Get("StatusCallback"))
+
+	enabled = false
+	notified, err = service.OrderReady(ctx, order)
+	require.NoError(t, err)
+	assert.False(t, notified, "turned off")
+	enabled = true
+
+	// The second text this hour is the last the phone gets
+	_, err = service.OrderReady(ctx, order)
+	require.NoError(t, err)
+	notified, err = service.OrderReady(ctx, order)
+	require.NoError(t, err)
+	assert.False(t, notified)
+	assert.Len(t, *messages, 2)
+
+	assert.Equal(t, []models.SMSStatus{
+		{Kind: models.NotificationSMSReady, Status: models.SMSStatusSent, MessageID: "SM61400000000"},
+		{Kind: models.NotificationSMSReady, Status: models.SMSStatusSent, MessageID: "SM61400000000"},
+		{Kind: models.NotificationSMSReady, Status: models.SMSStatusRateLimited},
+	}, events.statuses[order.ID])
+
+	// Twilio reports the delivery to the callback
+	query := url.Values{"reference": {order.ID}}
+	form := url.Values{"MessageSid": {"SM61400000000"}, "MessageStatus": {"undelivered"}, "ErrorCode": {"30003"}}
+	assert.ErrorIs(t, service.SMSStatus(ctx, query, form, "forged"), services.ErrSMSStatusSignature)
+	require.NoError(t, service.SMSStatus(ctx, query, form, signTwilio(twilioCallbackURL+"?reference="+order.ID, form)))
+	form = url.Values{"MessageSid": {"SM61400000000"}, "MessageStatus": {"sent"}}
+	require.NoError(t, service.SMSStatus(ctx, query, form, signTwilio(twilioCallbackURL+"?reference="+order.ID, form)))
+	statuses := events.statuses[order.ID]
+	assert.Len(t, statuses, 4, "only final statuses are recorded")
+	assert.Equal(t, models.SMSStatus{Status: models.SMSStatusUndelivered, MessageID: "SM61400000000", Error: "Twilio error 30003"}, statuses[3])
+
+	// Texts Twilio won't send are recorded as failed
+	_, err = service.SavePreferences(ctx, "43", models.NotificationPreferencesReq{Phone: "+61400000009", SMSReadyAlert: true})
+	require.NoError(t, err)
+	rejected := &models.Order{ID: "9b1d2c3e-0000-0000-0000-000000000000", CustomerID: "43"}
+	notified, err = service.OrderReady(ctx, rejected)
+	assert.EqualError(t, err, "Twilio rejected the message: 400 Bad Request The 'To' number is not valid.")
+	assert.False(t, notified)
+	require.Len(t, events.statuses[rejected.ID], 1)
+	assert.Equal(t, models.SMSStatusFailed, events.statuses[rejected.ID][0].Status)
+}
+
+func TestNotificationService_SMSStatusNotSupported(t *testing.T) {
+	service := services.NewNotificationService(repository.NewMemoryNotificationRepository(), nil, &sentMessages{}, services.NotificationOptions{})
+	err := service.SMSStatus(context.Background(), url.Values{}, url.Values{}, "")
+	assert.ErrorIs(t, err, services.ErrSMSStatusNotSupported)
+}
@@ -0,0 +1,129 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"oolio/internal/app/models"
+	"oolio/internal/app/repository"
+	"oolio/internal/app/services"
+)
+
+// capturingSMSSender keeps the last message instead of sending it
+type capturingSMSSender struct {
+	phone, message string
+}
+
+func (s *capturingSMSSender) SendSMS(ctx context.Context, phone, message string) error {
+	s.phone, s.message = phone, message
+	return nil
+}
+
+var verificationCodePattern = regexp.MustCompile(`\b[0-9]{6}\b`)
+
+func TestVerificationService_SendAndCheck(t *testing.T) {
+	ctx := context.Background()
+	sender := &capturingSMSSender{}
+	service := services.NewVerificationService(sender, repository.NewMemoryProductRepository(), services.VerificationOptions{
+		Secret:   "secret",
+		CodeTTL:  10 * time.Minute,
+		TokenTTL: time.Hour,
+	})
+
+	_, err := service.SendCode(ctx, "0400 000 000")
+	assert.ErrorIs(t, err, services.ErrInvalidPhone)
+
+	challenge, err := service.SendCode(ctx, "+61400000000")
+	require.NoError(t, err)
+	assert.Equal(t, "+61400000000", sender.phone)
+	code := verificationCodePattern.FindString(sender.message)
+	require.NotEmpty(t, code, sender.message)
+	assert.NotContains(t, challenge.VerificationID, code, "the code can't be read off the verification ID")
+
+	wrong := "000000"
+	if code == wrong {
+		wrong = "111111"
+	}
+	_, err = service.CheckCode(ctx, models.VerificationCheckReq{VerificationID: challenge.VerificationID, Code: wrong})
+	assert.ErrorIs(t, err, services.ErrInvalidVerification)
+
+	verified, err := service.CheckCode(ctx, models.VerificationCheckReq{VerificationID: challenge.VerificationID, Code: code})
+	require.NoError(t, err)
+	assert.Equal(t, "+61400000000", verified.Phone)
+
+	phone, ok := service.VerifiedPhone(verified.Token)
+	assert.True(t, ok)
+	assert.Equal(t, "+61400000000", phone)
+
+	_, ok = service.VerifiedPhone(challenge.VerificationID)
+	assert.False(t, ok, "a verification ID is not a verified phone token")
+
+	other := services.NewVerificationService(sender, nil, services.VerificationOptions{Secret: "other", TokenTTL: time.Hour})
+	_, ok = other.VerifiedPhone(verified.Token)
+	assert.False(t, ok, "tokens are bound to the secret")
+}
+
+func TestVerificationService_Expiry(t *testing.T) {
+	ctx := context.Background()
+	sender := &capturingSMSSender{}
+	service := services.NewVerificationService(sender, nil, services.VerificationOptions{Secret: "secret", CodeTTL: -time.Second})
+
+	challenge, err := service.SendCode(ctx, "+61400000000")
+	require.NoError(t, err)
+	_, err = service.CheckCode(ctx, models.VerificationCheckReq{VerificationID: challenge.VerificationID, Code: verificationCodePattern.FindString(sender.message)})
+	assert.ErrorIs(t, err, services.ErrInvalidVerification)
+}
+
+func TestVerificationService_RequiredFor(t *testing.T) {
+	ctx := context.Background()
+	products := repository.NewMemoryProductRepository()
+	seeded, err := products.Find(ctx)
+	require.NoError(t, err)
+	require.NotEmpty(t, seeded)
+	price := seeded[0].Price
+
+	service := services.NewVerificationService(&capturingSMSSender{}, products, services.VerificationOptions{Secret: "secret", GuestOrderMin: price.Mul(3)})
+
+	required, err := service.RequiredFor(ctx, &models.OrderReq{Items: []models.OrderItem{{ProductID: seeded[0].ID, Quantity: 2}}})
+	require.NoError(t, err)
+	assert.False(t, required)
+
+	required, err = service.RequiredFor(ctx, &models.OrderReq{Items: []models.OrderItem{{ProductID: seeded[0].ID, Quantity: 3}}})
+	require.NoError(t, err)
+	assert.True(t, required)
+
+	disabled := services.NewVerificationService(&capturingSMSSender{}, products, services.VerificationOptions{Secret: "secret"})
+	required, err = disabled.RequiredFor(ctx, &models.OrderReq{Items: []models.OrderItem{{ProductID: seeded[0].ID, Quantity: 99}}})
+	require.NoError(t, err)
+	assert.False(t, required)
+}
+
+func TestWebhookSMSSender(t *testing.T) {
+	var body map[string]string
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	sender := services.NewWebhookSMSSender(server.URL, "token", time.Second)
+	require.NoError(t, sender.SendSMS(context.Background(), "+61400000000", "hello"))
+	assert.Equal(t, "Bearer token", authorization)
+	assert.Equal(t, map[string]string{"to": "+61400000000", "message": "hello"}, body)
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer failing.Close()
+	assert.Error(t, services.NewWebhookSMSSender(failing.URL, "", time.Second).SendSMS(context.Background(), "+61400000000", "hello"))
+}
@@ -0,0 +1,172 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"oolio/internal/app/events"
+	"oolio/internal/app/models"
+	"oolio/internal/app/repository"
+	"oolio/internal/app/services"
+)
+
+// newWebhookService posts every endpoint's deliveries to url, signed with "secret", and
+// retries them straight away
+func newWebhookService(url string, maxAttempts int) (services.WebhookService, repository.WebhookDeliveryRepository) {
+	repo := repository.NewMemoryWebhookDeliveryRepository()
+	endpoint := services.WebhookEndpoint{URL: url, Secret: "secret", Timeout: time.Second}
+	return services.NewWebhookService(repo, services.WebhookOptions{
+		Endpoints: map[string]services.WebhookEndpoint{
+			models.WebhookEndpointFulfillment: endpoint,
+			models.WebhookEndpointEvents:      endpoint,
+			models.WebhookEndpointDigest:      endpoint,
+			models.WebhookEndpointDelivery:    endpoint,
+		},
+		MaxAttempts:  maxAttempts,
+		RetryBackoff: time.Nanosecond,
+	}), repo
+}
+
+func TestSignWebhook(t *testing.T) {
+	body := []byte(`{"id":"e1"}`)
+	signature := services.SignWebhook([]byte("secret"), "d1", 1700000000, body)
+
+	assert.Len(t, signature, 64)
+	assert.Equal(t, signature, services.SignWebhook([]byte("secret"), "d1", 1700000000, body))
+	// Every part is covered, so a signature can't be moved to another delivery or time
+	assert.NotEqual(t, signature, services.SignWebhook([]byte("other"), "d1", 1700000000, body))
+	assert.NotEqual(t, signature, services.SignWebhook([]byte("secret"), "d2", 1700000000, body))
+	assert.NotEqual(t, signature, services.SignWebhook([]byte("secret"), "d1", 1700000001, body))
+	assert.NotEqual(t, signature, services.SignWebhook([]byte("secret"), "d1", 1700000000, []byte(`{"id":"e2"}`)))
+}
+
+func TestWebhookService_DeliverSigned(t *testing.T) {
+	ctx := context.Background()
+	var received events.Event
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		timestamp, err := strconv.ParseInt(r.Header.Get(services.TimestampHeader), 10, 64)
+		require.NoError(t, err)
+		assert.WithinDuration(t, time.Now(), time.Unix(timestamp, 0), time.Minute)
+		deliveryID := r.Header.Get(services.DeliveryHeader)
+		assert.NotEmpty(t, deliveryID)
+		assert.Equal(t, services.SignWebhook([]byte("secret"), deliveryID, timestamp, body), r.Header.Get(services.SignatureHeader))
+		assert.Equal(t, "application/cloudevents+json", r.Header.Get("Content-Type"))
+
+		require.NoError(t, json.Unmarshal(body, &received))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	webhooks, _ := newWebhookService(server.URL, 3)
+	event := events.New("/oolio", "e1", "order", "o1", models.EventOrderRefunded, json.RawMessage(`{}`), time.Now())
+	require.NoError(t, webhooks.Enqueue(ctx, models.WebhookEndpointEvents, event))
+	// The same event is only delivered once
+	require.NoError(t, webhooks.Enqueue(ctx, models.WebhookEndpointEvents, event))
+
+	result, err := webhooks.DeliverBatch(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Delivered)
+	assert.Equal(t, "e1", received.ID)
+
+	deliveries, total, err := webhooks.ListDeliveries(ctx, models.WebhookDelivered, models.PageRequest{})
+	require.NoError(t, err)
+	require.Equal(t, 1, total)
+	delivery, err := webhooks.GetDelivery(ctx, deliveries[0].ID)
+	require.NoError(t, err)
+	require.Len(t, delivery.AttemptLog, 1)
+	assert.Equal(t, http.StatusNoContent, delivery.AttemptLog[0].StatusCode)
+	assert.Equal(t, server.URL, delivery.AttemptLog[0].URL)
+	assert.NotNil(t, delivery.DeliveredAt)
+}
+
+func TestWebhookService_RetryDeadLetterAndRedeliver(t *testing.T) {
+	ctx := context.Background()
+	var attempts atomic.Int32
+	var up atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		if !up.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	webhooks, _ := newWebhookService(server.URL, 2)
+	event := events.New("/oolio", "e1", "order", "o1", models.EventOrderCreated, json.RawMessage(`{}`), time.Now())
+	require.NoError(t, webhooks.Enqueue(ctx, models.WebhookEndpointFulfillment, event))
+
+	result, err := webhooks.DeliverBatch(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Failed)
+	require.Len(t, result.Errors, 1)
+	assert.Contains(t, result.Errors[0], "503")
+
+	time.Sleep(5 * time.Millisecond)
+	result, err = webhooks.DeliverBatch(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Dead, "out of attempts")
+
+	dead, total, err := webhooks.ListDeliveries(ctx, models.WebhookDead, models.PageRequest{})
+	require.NoError(t, err)
+	require.Equal(t, 1, total)
+	assert.Contains(t, dead[0].LastError, "503")
+
+	// Nothing is posted while it is dead
+	result, err = webhooks.DeliverBatch(ctx, 10)
+	require.NoError(t, err)
+	assert.Zero(t, result.Delivered+result.Failed+result.Dead)
+
+	up.Store(true)
+	require.NoError(t, webhooks.Redeliver(ctx, dead[0].ID))
+	assert.ErrorContains(t, webhooks.Redeliver(ctx, dead[0].ID), "webhook delivery not found", "only dead deliveries are redelivered")
+
+	result, err = webhooks.DeliverBatch(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Delivered)
+
+	delivery, err := webhooks.GetDelivery(ctx, dead[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.WebhookDelivered, delivery.Status)
+	require.Len(t, delivery.AttemptLog, 3)
+	assert.Equal(t, http.StatusServiceUnavailable, delivery.AttemptLog[0].StatusCode)
+	assert.Equal(t, http.StatusOK, delivery.AttemptLog[2].StatusCode)
+	assert.Equal(t, int32(3), attempts.Load())
+}
+
+func TestWebhookService_ClientErrorDeadStraightAway(t *testing.T) {
+	ctx := context.Background()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	webhooks, _ := newWebhookService(server.URL, 5)
+	event := events.New("/oolio", "e1", "order", "o1", models.EventOrderCreated, json.RawMessage(`{}`), time.Now())
+	require.NoError(t, webhooks.Enqueue(ctx, models.WebhookEndpointFulfillment, event))
+
+	result, err := webhooks.DeliverBatch(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Dead)
+	assert.Contains(t, result.Errors[0], "400 Bad Request")
+}
+
+func TestWebhookService_EndpointNotConfigured(t *testing.T) {
+	webhooks := services.NewWebhookService(repository.NewMemoryWebhookDeliveryRepository(), services.WebhookOptions{})
+	event := events.New("/oolio", "e1", "order", "o1", models.EventOrderCreated, json.RawMessage(`{}`), time.Now())
+	assert.ErrorIs(t, webhooks.Enqueue(context.Background(), models.WebhookEndpointEvents, event), services.ErrWebhookEndpointNotConfigured)
+}
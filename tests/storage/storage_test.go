@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"oolio/internal/storage"
+)
+
+func TestLocalStorage(t *testing.T) {
+	store, err := storage.NewLocalStorage(t.TempDir())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	_, err = store.Get(ctx, "products/1/image.png")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+
+	require.NoError(t, store.Put(ctx, "products/1/image.png", strings.NewReader("png"), "image/png"))
+	require.NoError(t, store.Put(ctx, "products/1/image.png", strings.NewReader("png v2"), "image/png"))
+
+	file, err := store.Get(ctx, "products/1/image.png")
+	require.NoError(t, err)
+	data, err := io.ReadAll(file)
+	file.Close()
+	require.NoError(t, err)
+	assert.Equal(t, "png v2", string(data))
+
+	require.NoError(t, store.Delete(ctx, "products/1/image.png"))
+	require.NoError(t, store.Delete(ctx, "products/1/image.png"), "deleting a missing key is not an error")
+	_, err = store.Get(ctx, "products/1/image.png")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+
+	for _, key := range []string{"../secret", "a/../../b", "", "a//b"} {
+		assert.Error(t, store.Put(ctx, key, strings.NewReader("x"), ""), key)
+	}
+}
+
+func TestS3Storage(t *testing.T) {
+	objects := map[string][]byte{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		assert.True(t, strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKID/"), auth)
+		assert.Contains(t, auth, "/auto/s3/aws4_request, SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature=")
+		assert.Equal(t, "UNSIGNED-PAYLOAD", r.Header.Get("X-Amz-Content-Sha256"))
+		assert.NotEmpty(t, r.Header.Get("X-Amz-Date"))
+
+		switch r.Method {
+		case http.MethodPut:
+			assert.Equal(t, int64(5), r.ContentLength)
+			assert.Equal(t, "text/plain", r.Header.Get("Content-Type"))
+			body, _ := io.ReadAll(r.Body)
+			objects[r.URL.EscapedPath()] = body
+		case http.MethodGet:
+			body, ok := objects[r.URL.EscapedPath()]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write(body)
+		case http.MethodDelete:
+			delete(objects, r.URL.EscapedPath())
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	store, err := storage.NewS3Storage(storage.S3Options{
+		Endpoint:        server.URL,
+		Region:          storage.GCSRegion,
+		Bucket:          "menu",
+		AccessKeyID:     "AKID",
+		SecretAccessKey: "secret",
+		Timeout:         time.Second,
+	})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// Readers that can't seek are buffered so the size is known up front
+	require.NoError(t, store.Put(ctx, "coupons/a b+c.gz", io.MultiReader(bytes.NewReader([]byte("hello"))), "text/plain"))
+	assert.Contains(t, objects, "/menu/coupons/a%20b%2Bc.gz")
+
+	file, err := store.Get(ctx, "coupons/a b+c.gz")
+	require.NoError(t, err)
+	data, _ := io.ReadAll(file)
+	file.Close()
+	assert.Equal(t, "hello", string(data))
+
+	require.NoError(t, store.Delete(ctx, "coupons/a b+c.gz"))
+	_, err = store.Get(ctx, "coupons/a b+c.gz")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+}
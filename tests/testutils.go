@@ -16,7 +16,7 @@ func CreateTestProduct(t *testing.T) *models.Product {
 	return &models.Product{
 		ID:       uuid.New().String(),
 		Name:     "Test Product",
-		Price:    10.99,
+		Price:    1099,
 		Category: "Waffle",
 		Image: models.Image{
 			Thumbnail: "http://example.com/thumb.jpg",
@@ -28,7 +28,7 @@ func CreateTestProduct(t *testing.T) *models.Product {
 }
 
 // CreateTestProductWithCustomData creates a test product with custom values
-func CreateTestProductWithCustomData(t *testing.T, name string, price float64, category string) *models.Product {
+func CreateTestProductWithCustomData(t *testing.T, name string, price models.Money, category string) *models.Product {
 	product := CreateTestProduct(t)
 	product.Name = name
 	product.Price = price
@@ -40,13 +40,13 @@ func CreateTestProductWithCustomData(t *testing.T, name string, price float64, c
 func CreateTestOrder(t *testing.T) *models.Order {
 	return &models.Order{
 		ID:        uuid.New().String(),
-		Total:     25.99,
-		Discounts: 0.0,
+		Total:     2599,
+		Discounts: 0,
 		Items: []models.OrderItem{
 			{
 				ProductID: uuid.New().String(),
 				Quantity:  2,
-				Price:     10.99,
+				Price:     1099,
 			},
 		},
 		Products: []models.Product{
@@ -81,12 +81,12 @@ func CreateTestOrderItem(t *testing.T) *models.OrderItem {
 	return &models.OrderItem{
 		ProductID: uuid.New().String(),
 		Quantity:  1,
-		Price:     10.99,
+		Price:     1099,
 	}
 }
 
 // CreateTestOrderItemWithCustomData creates a test order item with custom values
-func CreateTestOrderItemWithCustomData(t *testing.T, productID string, quantity int, price float64) *models.OrderItem {
+func CreateTestOrderItemWithCustomData(t *testing.T, productID string, quantity int, price models.Money) *models.OrderItem {
 	return &models.OrderItem{
 		ProductID: productID,
 		Quantity:  quantity,
@@ -101,7 +101,7 @@ func CreateTestProducts(t *testing.T, count int) []models.Product {
 		products[i] = *CreateTestProductWithCustomData(
 			t,
 			"Test Product "+string(rune('A'+i)),
-			float64(10+i),
+			models.Cents(int64(1000+100*i)),
 			"Category "+string(rune('A'+i)),
 		)
 	}
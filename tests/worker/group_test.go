@@ -0,0 +1,68 @@
+package worker
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"oolio/internal/app/models"
+	"oolio/internal/app/services"
+	"oolio/internal/app/worker"
+)
+
+func TestGroup_StopWaitsForJobs(t *testing.T) {
+	jobs := worker.NewGroup()
+
+	var finished atomic.Bool
+	jobs.Go("orders", func(ctx context.Context) {
+		<-ctx.Done()
+		// Finishing the batch under way after shutdown began
+		time.Sleep(20 * time.Millisecond)
+		finished.Store(true)
+	})
+	jobs.Go("panics", func(ctx context.Context) { panic("boom") })
+
+	require.Eventually(t, func() bool { return len(jobs.Running()) == 1 }, time.Second, time.Millisecond)
+	assert.Equal(t, []string{"orders"}, jobs.Running())
+
+	require.NoError(t, jobs.Stop(context.Background()))
+	assert.True(t, finished.Load())
+	assert.Empty(t, jobs.Running())
+}
+
+func TestGroup_StopGivesUpAtDeadline(t *testing.T) {
+	jobs := worker.NewGroup()
+	release := make(chan struct{})
+	defer close(release)
+	jobs.Go("stuck", func(ctx context.Context) { <-release })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := jobs.Stop(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "stuck")
+}
+
+// sendingNotificationService sends full batches until it runs out
+type sendingNotificationService struct {
+	services.NotificationService
+	pending int
+}
+
+func (s *sendingNotificationService) DeliverBatch(ctx context.Context, batchSize int) (*models.NotificationDeliveryResult, error) {
+	sent := min(s.pending, batchSize)
+	s.pending -= sent
+	return &models.NotificationDeliveryResult{Sent: sent}, nil
+}
+
+func TestNotificationWorker_Flush(t *testing.T) {
+	notifications := &sendingNotificationService{pending: 45}
+	w := worker.NewNotificationWorker(notifications, time.Second, 20)
+
+	require.NoError(t, w.Flush(context.Background()))
+	assert.Zero(t, notifications.pending)
+}
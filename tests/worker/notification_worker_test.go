@@ -0,0 +1,41 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"oolio/internal/app/models"
+	"oolio/internal/app/services"
+	"oolio/internal/app/worker"
+)
+
+// stubNotificationService hands out a fixed delivery result; other methods are not used by
+// the tests
+type stubNotificationService struct {
+	services.NotificationService
+	batchSizes []int
+	err        error
+}
+
+func (s *stubNotificationService) DeliverBatch(ctx context.Context, batchSize int) (*models.NotificationDeliveryResult, error) {
+	s.batchSizes = append(s.batchSizes, batchSize)
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &models.NotificationDeliveryResult{Sent: 1}, nil
+}
+
+func TestNotificationWorker_DeliverBatch(t *testing.T) {
+	notifications := &stubNotificationService{}
+	w := worker.NewNotificationWorker(notifications, time.Second, 25)
+
+	assert.NoError(t, w.DeliverBatch(context.Background()))
+	assert.Equal(t, []int{25}, notifications.batchSizes)
+
+	notifications.err = errors.New("database is down")
+	assert.ErrorIs(t, w.DeliverBatch(context.Background()), notifications.err)
+}
@@ -0,0 +1,122 @@
+package worker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"oolio/internal/app/models"
+	"oolio/internal/app/services"
+	"oolio/internal/app/worker"
+)
+
+// stubQueueService reports a fixed backlog and batch result; other methods are not used by
+// the tests
+type stubQueueService struct {
+	services.OrderQueueService
+	backlog models.QueueBacklog
+	result  models.BatchProcessResult
+}
+
+func (s *stubQueueService) GetBacklog(ctx context.Context) (*models.QueueBacklog, error) {
+	backlog := s.backlog
+	return &backlog, nil
+}
+
+func (s *stubQueueService) ProcessBatch(ctx context.Context, batchSize int) (*models.BatchProcessResult, error) {
+	result := s.result
+	return &result, nil
+}
+
+type countingAlerter struct {
+	alerts []services.Alert
+}
+
+func (a *countingAlerter) Alert(ctx context.Context, alert services.Alert) error {
+	a.alerts = append(a.alerts, alert)
+	return nil
+}
+
+func (a *countingAlerter) titles() []string {
+	var titles []string
+	for _, alert := range a.alerts {
+		titles = append(titles, alert.Title)
+	}
+	return titles
+}
+
+func TestOrderWorker_CheckBacklog(t *testing.T) {
+	queue := &stubQueueService{backlog: models.QueueBacklog{Pending: 5}}
+	alerter := &countingAlerter{}
+	w := worker.NewOrderWorker(queue, alerter, time.Second, 10, worker.BacklogThresholds{Pending: 10})
+	ctx := context.Background()
+
+	w.CheckBacklog(ctx)
+	assert.Empty(t, alerter.alerts)
+
+	queue.backlog.Pending = 12
+	w.CheckBacklog(ctx)
+	w.CheckBacklog(ctx)
+	assert.Len(t, alerter.alerts, 1, "alerts once while the backlog persists")
+	assert.Contains(t, alerter.alerts[0].Text, "12 orders are pending")
+
+	queue.backlog.Pending = 3
+	w.CheckBacklog(ctx)
+	w.CheckBacklog(ctx)
+	queue.backlog.Pending = 20
+	w.CheckBacklog(ctx)
+	assert.Equal(t, []string{"Order queue backlog", "Order queue backlog resolved", "Order queue backlog"}, alerter.titles(),
+		"resolves once healthy and alerts again after that")
+}
+
+func TestOrderWorker_CheckBacklogOldest(t *testing.T) {
+	dueAt := time.Now().Add(-20 * time.Minute)
+	queue := &stubQueueService{backlog: models.QueueBacklog{Pending: 1, OldestDueAt: &dueAt}}
+	alerter := &countingAlerter{}
+	w := worker.NewOrderWorker(queue, alerter, time.Second, 10, worker.BacklogThresholds{OldestAge: 15 * time.Minute})
+	ctx := context.Background()
+
+	w.CheckBacklog(ctx)
+	require.Len(t, alerter.alerts, 1)
+	assert.Equal(t, "Order queue stalled", alerter.alerts[0].Title)
+	assert.Contains(t, alerter.alerts[0].Text, "waited 20m0s")
+
+	queue.backlog = models.QueueBacklog{}
+	w.CheckBacklog(ctx)
+	assert.Equal(t, []string{"Order queue stalled", "Order queue stalled resolved"}, alerter.titles())
+}
+
+func TestOrderWorker_CheckBacklogFailureRate(t *testing.T) {
+	queue := &stubQueueService{result: models.BatchProcessResult{Processed: 3, Failed: 1, PaymentFailed: 1}}
+	alerter := &countingAlerter{}
+	w := worker.NewOrderWorker(queue, alerter, time.Second, 10, worker.BacklogThresholds{FailureRate: 30, FailureWindow: time.Minute})
+	ctx := context.Background()
+
+	// 2 of 5 failed, but that is too few orders to tell
+	require.NoError(t, w.ProcessBatch(ctx))
+	w.CheckBacklog(ctx)
+	assert.Empty(t, alerter.alerts)
+
+	require.NoError(t, w.ProcessBatch(ctx))
+	w.CheckBacklog(ctx)
+	require.Len(t, alerter.alerts, 1)
+	assert.Equal(t, "Order queue failure rate", alerter.alerts[0].Title)
+	assert.Contains(t, alerter.alerts[0].Text, "40.0% of the 10 orders")
+
+	queue.result = models.BatchProcessResult{Processed: 20}
+	require.NoError(t, w.ProcessBatch(ctx))
+	w.CheckBacklog(ctx)
+	assert.Equal(t, []string{"Order queue failure rate", "Order queue failure rate resolved"}, alerter.titles())
+}
+
+func TestOrderWorker_CheckBacklogDisabled(t *testing.T) {
+	queue := &stubQueueService{backlog: models.QueueBacklog{Pending: 1000}}
+	alerter := &countingAlerter{}
+
+	worker.NewOrderWorker(queue, alerter, time.Second, 10, worker.BacklogThresholds{}).CheckBacklog(context.Background())
+	worker.NewOrderWorker(queue, nil, time.Second, 10, worker.BacklogThresholds{Pending: 10}).CheckBacklog(context.Background())
+	assert.Empty(t, alerter.alerts)
+}
@@ -0,0 +1,169 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"oolio/internal/app/events"
+	"oolio/internal/app/models"
+	"oolio/internal/app/worker"
+)
+
+// memoryOutbox keeps events the way the outbox table does
+type memoryOutbox struct {
+	entries []*outboxEntry
+	claims  int
+}
+
+type outboxEntry struct {
+	event     models.OutboxEvent
+	due       time.Time
+	published bool
+	dead      bool
+	lastError string
+}
+
+func (o *memoryOutbox) add(eventType string) *outboxEntry {
+	entry := &outboxEntry{
+		event: models.OutboxEvent{
+			ID:            uuid.NewString(),
+			AggregateType: "order",
+			AggregateID:   uuid.NewString(),
+			EventType:     eventType,
+			Payload:       []byte(`{}`),
+			CreatedAt:     time.Now().Add(time.Duration(len(o.entries)) * time.Millisecond),
+		},
+		due: time.Now(),
+	}
+	o.entries = append(o.entries, entry)
+	return entry
+}
+
+// makeDue lets failed events be retried without waiting for their backoff
+func (o *memoryOutbox) makeDue() {
+	for _, entry := range o.entries {
+		entry.due = time.Now()
+	}
+}
+
+func (o *memoryOutbox) find(id string) *outboxEntry {
+	for _, entry := range o.entries {
+		if entry.event.ID == id {
+			return entry
+		}
+	}
+	return nil
+}
+
+func (o *memoryOutbox) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]models.OutboxEvent, error) {
+	o.claims++
+	var claimed []models.OutboxEvent
+	for _, entry := range o.entries {
+		if len(claimed) == limit {
+			break
+		}
+		if entry.published || entry.dead || entry.due.After(time.Now()) {
+			continue
+		}
+		entry.due = time.Now().Add(lease)
+		claimed = append(claimed, entry.event)
+	}
+	return claimed, nil
+}
+
+func (o *memoryOutbox) MarkPublished(ctx context.Context, id string) error {
+	o.find(id).published = true
+	return nil
+}
+
+func (o *memoryOutbox) MarkFailed(ctx context.Context, id, errorMsg string, retryAt time.Time) error {
+	entry := o.find(id)
+	entry.event.Attempts++
+	entry.lastError = errorMsg
+	entry.due = retryAt
+	return nil
+}
+
+func (o *memoryOutbox) MarkDead(ctx context.Context, id, errorMsg string) error {
+	entry := o.find(id)
+	entry.event.Attempts++
+	entry.lastError = errorMsg
+	entry.dead = true
+	return nil
+}
+
+func (o *memoryOutbox) Append(ctx context.Context, tx *sql.Tx, aggregateType, aggregateID, eventType string, payload any) error {
+	return nil
+}
+
+// refusingPublisher refuses events of the given type and accepts the rest
+type refusingPublisher struct {
+	refused   string
+	published []string
+}
+
+func (p *refusingPublisher) Publish(ctx context.Context, event events.Event) error {
+	if event.DomainType() == p.refused {
+		return errors.New("broker refused the event")
+	}
+	p.published = append(p.published, event.DomainType())
+	return nil
+}
+
+func TestOutboxRelay_RetriesThenDeadLetters(t *testing.T) {
+	ctx := context.Background()
+	outbox := &memoryOutbox{}
+	refused := outbox.add("order.refused")
+	outbox.add(models.EventOrderCreated)
+	publisher := &refusingPublisher{refused: "order.refused"}
+	relay := worker.NewOutboxRelay(outbox, publisher, "oolio", time.Second, 10, worker.OutboxRetries{MaxAttempts: 3, Backoff: time.Minute})
+
+	require.NoError(t, relay.RelayBatch(ctx))
+	assert.Equal(t, []string{models.EventOrderCreated}, publisher.published, "a refused event doesn't hold back the next one")
+	assert.Equal(t, 1, refused.event.Attempts)
+	assert.Equal(t, "broker refused the event", refused.lastError)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), refused.due, time.Second)
+
+	// Not retried before its backoff is over
+	require.NoError(t, relay.RelayBatch(ctx))
+	assert.Equal(t, 1, refused.event.Attempts)
+
+	outbox.makeDue()
+	require.NoError(t, relay.RelayBatch(ctx))
+	assert.Equal(t, 2, refused.event.Attempts)
+	assert.WithinDuration(t, time.Now().Add(2*time.Minute), refused.due, time.Second, "the backoff doubles")
+
+	outbox.makeDue()
+	require.NoError(t, relay.RelayBatch(ctx))
+	assert.Equal(t, 3, refused.event.Attempts)
+	assert.True(t, refused.dead, "dead-lettered after MaxAttempts")
+
+	outbox.makeDue()
+	require.NoError(t, relay.RelayBatch(ctx))
+	assert.Equal(t, 3, refused.event.Attempts, "dead events aren't published again")
+	assert.Len(t, publisher.published, 1)
+}
+
+func TestOutboxRelay_Flush(t *testing.T) {
+	outbox := &memoryOutbox{}
+	for i := range 34 {
+		outbox.add(fmt.Sprintf("order.event%d", i))
+	}
+	publisher := &refusingPublisher{refused: "order.event3"}
+	relay := worker.NewOutboxRelay(outbox, publisher, "oolio", time.Second, 10, worker.OutboxRetries{MaxAttempts: 5, Backoff: time.Minute})
+
+	require.NoError(t, relay.Flush(context.Background()))
+	assert.Equal(t, 4, outbox.claims, "flushing stops at the first short batch")
+	assert.Len(t, publisher.published, 33, "the refused event waits for its retry")
+
+	// Without outbox storage there is nothing to flush
+	assert.NoError(t, worker.NewOutboxRelay(nil, nil, "oolio", time.Second, 10, worker.OutboxRetries{}).Flush(context.Background()))
+}
@@ -0,0 +1,41 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"oolio/internal/app/models"
+	"oolio/internal/app/services"
+	"oolio/internal/app/worker"
+)
+
+// stubWebhookService hands out a fixed delivery result; other methods are not used by the
+// tests
+type stubWebhookService struct {
+	services.WebhookService
+	batchSizes []int
+	err        error
+}
+
+func (s *stubWebhookService) DeliverBatch(ctx context.Context, batchSize int) (*models.WebhookDeliveryResult, error) {
+	s.batchSizes = append(s.batchSizes, batchSize)
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &models.WebhookDeliveryResult{Delivered: 1}, nil
+}
+
+func TestWebhookWorker_DeliverBatch(t *testing.T) {
+	webhooks := &stubWebhookService{}
+	w := worker.NewWebhookWorker(webhooks, time.Second, 20)
+
+	assert.NoError(t, w.DeliverBatch(context.Background()))
+	assert.Equal(t, []int{20}, webhooks.batchSizes)
+
+	webhooks.err = errors.New("database is down")
+	assert.ErrorIs(t, w.DeliverBatch(context.Background()), webhooks.err)
+}
@@ -0,0 +1,132 @@
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"oolio/internal/workerpool"
+)
+
+func TestPool_BoundsConcurrency(t *testing.T) {
+	pool := workerpool.New("test", 3)
+
+	var running, peak atomic.Int64
+	errs := pool.Each(context.Background(), 12, func(ctx context.Context, i int) error {
+		now := running.Add(1)
+		defer running.Add(-1)
+		for {
+			seen := peak.Load()
+			if now <= seen || peak.CompareAndSwap(seen, now) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		if i%4 == 0 {
+			return errors.New("boom")
+		}
+		return nil
+	})
+
+	require.Len(t, errs, 12)
+	for i, err := range errs {
+		if i%4 == 0 {
+			assert.EqualError(t, err, "boom")
+		} else {
+			assert.NoError(t, err)
+		}
+	}
+	assert.LessOrEqual(t, peak.Load(), int64(3))
+	assert.Greater(t, peak.Load(), int64(1))
+
+	stats := pool.Stats()
+	assert.Equal(t, "test", stats.Name)
+	assert.Equal(t, 3, stats.Size)
+	assert.Equal(t, int64(9), stats.Completed)
+	assert.Equal(t, int64(3), stats.Failed)
+	assert.Zero(t, stats.Running)
+	assert.Zero(t, stats.Waiting)
+}
+
+func TestPool_IsolatesPanics(t *testing.T) {
+	pool := workerpool.New("test", 2)
+
+	errs := pool.Each(context.Background(), 3, func(ctx context.Context, i int) error {
+		if i == 1 {
+			panic("bad item")
+		}
+		return nil
+	})
+
+	assert.NoError(t, errs[0])
+	assert.NoError(t, errs[2])
+	var panicErr *workerpool.PanicError
+	require.ErrorAs(t, errs[1], &panicErr)
+	assert.Equal(t, "bad item", panicErr.Value)
+	assert.NotEmpty(t, panicErr.Stack)
+
+	stats := pool.Stats()
+	assert.Equal(t, int64(2), stats.Completed)
+	assert.Equal(t, int64(1), stats.Failed)
+	assert.Equal(t, int64(1), stats.Panicked)
+}
+
+func TestPool_SkipsTasksOnceCanceled(t *testing.T) {
+	pool := workerpool.New("test", 1)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var started atomic.Int64
+	errs := pool.Each(ctx, 5, func(ctx context.Context, i int) error {
+		started.Add(1)
+		if i == 0 {
+			cancel()
+			<-ctx.Done()
+		}
+		return nil
+	})
+
+	assert.Equal(t, int64(1), started.Load())
+	assert.NoError(t, errs[0])
+	for _, err := range errs[1:] {
+		assert.ErrorIs(t, err, context.Canceled)
+	}
+	assert.Equal(t, int64(4), pool.Stats().Canceled)
+}
+
+func TestPool_NilRunsSequentially(t *testing.T) {
+	var pool *workerpool.Pool
+
+	var order []int
+	errs := pool.Each(context.Background(), 3, func(ctx context.Context, i int) error {
+		order = append(order, i)
+		if i == 2 {
+			panic("bad item")
+		}
+		return nil
+	})
+
+	assert.Equal(t, []int{0, 1, 2}, order)
+	assert.NoError(t, errs[0])
+	var panicErr *workerpool.PanicError
+	assert.ErrorAs(t, errs[2], &panicErr)
+}
+
+func TestRegistry_Stats(t *testing.T) {
+	registry := workerpool.NewRegistry()
+	webhooks := registry.New("webhooks", 4)
+	registry.New("coupons", 0)
+
+	webhooks.Each(context.Background(), 2, func(ctx context.Context, i int) error { return nil })
+
+	stats := registry.Stats()
+	require.Len(t, stats, 2)
+	assert.Equal(t, "coupons", stats[0].Name)
+	assert.Equal(t, 1, stats[0].Size)
+	assert.Equal(t, "webhooks", stats[1].Name)
+	assert.Equal(t, int64(2), stats[1].Completed)
+}